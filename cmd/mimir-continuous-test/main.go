@@ -26,6 +26,7 @@ type Config struct {
 	Client              continuoustest.ClientConfig
 	Manager             continuoustest.ManagerConfig
 	WriteReadSeriesTest continuoustest.WriteReadSeriesTestConfig
+	ChurnSeriesTest     continuoustest.ChurnSeriesTestConfig
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -34,6 +35,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Client.RegisterFlags(f)
 	cfg.Manager.RegisterFlags(f)
 	cfg.WriteReadSeriesTest.RegisterFlags(f)
+	cfg.ChurnSeriesTest.RegisterFlags(f)
 }
 
 func main() {
@@ -76,6 +78,9 @@ func main() {
 	// Run continuous testing.
 	m := continuoustest.NewManager(cfg.Manager, logger)
 	m.AddTest(continuoustest.NewWriteReadSeriesTest(cfg.WriteReadSeriesTest, client, logger, registry))
+	if cfg.ChurnSeriesTest.Enabled {
+		m.AddTest(continuoustest.NewChurnSeriesTest(cfg.ChurnSeriesTest, client, logger, registry))
+	}
 	if err := m.Run(context.Background()); err != nil {
 		level.Error(logger).Log("msg", "Failed to run continuous test", "err", err.Error())
 		os.Exit(1)
@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/mimir/pkg/mimir"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/usage"
+	"github.com/grafana/mimir/pkg/util/validation"
 	"github.com/grafana/mimir/pkg/util/version"
 )
 
@@ -134,6 +135,13 @@ func main() {
 		return
 	}
 
+	for _, name := range validation.LimitNamesSetByFlags(flag.CommandLine) {
+		if cfg.ConfiguredLimits == nil {
+			cfg.ConfiguredLimits = map[string]struct{}{}
+		}
+		cfg.ConfiguredLimits[name] = struct{}{}
+	}
+
 	if err := mimir.InheritCommonFlagValues(util_log.Logger, flag.CommandLine, cfg.Common, &cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "error inheriting common flag values: %v\n", err)
 		if !testMode {
@@ -267,6 +275,18 @@ func LoadConfig(filename string, expandEnv bool, cfg *mimir.Config) error {
 		return errors.Wrap(err, "Error parsing config file")
 	}
 
+	// The limits set in the config file are tracked to report the source of the limits applied to the tenants.
+	var limits struct {
+		Limits map[string]yaml.Node `yaml:"limits"`
+	}
+	if err := yaml.Unmarshal(buf, &limits); err != nil {
+		return errors.Wrap(err, "Error parsing config file")
+	}
+	cfg.ConfiguredLimits = make(map[string]struct{}, len(limits.Limits))
+	for name := range limits.Limits {
+		cfg.ConfiguredLimits[name] = struct{}{}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,425 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// alertVolumeResolution is the time range covered by each bucket of the alert volume statistics.
+	alertVolumeResolution = 5 * time.Minute
+
+	// alertVolumeSnapshot is the name of the file the alert volume statistics are stored to, within the tenant directory.
+	alertVolumeSnapshot = "alert_volume"
+
+	// alertVolumeSnapshotVersion is the version of the alert volume snapshot format.
+	alertVolumeSnapshotVersion = 1
+
+	// Default time range and step of the alert volume statistics returned by the API.
+	defaultAlertVolumeRange = 24 * time.Hour
+	defaultAlertVolumeStep  = time.Hour
+
+	// maxAlertVolumeWindows is the maximum number of windows returned by the API.
+	maxAlertVolumeWindows = 11000
+)
+
+// alertVolumeCounter is a statistic of the alert volume.
+type alertVolumeCounter int
+
+const (
+	// alertVolumeReceived counts the alerts received through the API.
+	alertVolumeReceived alertVolumeCounter = iota
+	// alertVolumeGrouped counts the alerts flushed by the aggregation groups to the notification pipeline.
+	alertVolumeGrouped
+	// alertVolumeNotified counts the alerts successfully notified by an integration.
+	alertVolumeNotified
+	// alertVolumeSilenced counts the alerts flushed by the aggregation groups which are silenced.
+	alertVolumeSilenced
+	// alertVolumeInhibited counts the alerts flushed by the aggregation groups which are inhibited.
+	alertVolumeInhibited
+
+	alertVolumeCountersCount
+)
+
+// alertVolumeBucket holds the alert volume statistics of a time range of alertVolumeResolution.
+type alertVolumeBucket struct {
+	// Index of the bucket since the Unix epoch, in multiples of alertVolumeResolution.
+	index    int64
+	counters [alertVolumeCountersCount]uint64
+}
+
+// AlertVolumeWindow holds the alert volume statistics of a time window.
+type AlertVolumeWindow struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Received  uint64    `json:"received"`
+	Grouped   uint64    `json:"grouped"`
+	Notified  uint64    `json:"notified"`
+	Silenced  uint64    `json:"silenced"`
+	Inhibited uint64    `json:"inhibited"`
+}
+
+// alertVolume tracks the alert volume statistics of a tenant in buckets of alertVolumeResolution, for the
+// retention period. The statistics are stored to the snapshot file periodically, and loaded at startup.
+type alertVolume struct {
+	snapshotFile string
+	retention    time.Duration
+	logger       log.Logger
+	now          func() time.Time
+
+	mtx sync.Mutex
+	// Sorted by index.
+	buckets []alertVolumeBucket
+}
+
+// newAlertVolume makes a new alertVolume, loading the statistics from the snapshot file, if it exists.
+func newAlertVolume(snapshotFile string, retention time.Duration, logger log.Logger) *alertVolume {
+	v := &alertVolume{
+		snapshotFile: snapshotFile,
+		retention:    retention,
+		logger:       logger,
+		now:          time.Now,
+	}
+
+	if err := v.loadSnapshot(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		level.Warn(logger).Log("msg", "failed to load alert volume statistics, starting from scratch", "file", snapshotFile, "err", err)
+	}
+	return v
+}
+
+func alertVolumeBucketIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(alertVolumeResolution)
+}
+
+func alertVolumeBucketStart(index int64) time.Time {
+	return time.Unix(0, index*int64(alertVolumeResolution))
+}
+
+// add adds n to the counter of the current bucket.
+func (v *alertVolume) add(counter alertVolumeCounter, n int) {
+	if n <= 0 {
+		return
+	}
+
+	now := v.now()
+	index := alertVolumeBucketIndex(now)
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	if len(v.buckets) == 0 || v.buckets[len(v.buckets)-1].index < index {
+		v.buckets = append(v.buckets, alertVolumeBucket{index: index})
+		v.removeExpiredLocked(now)
+	}
+
+	// The clock may go backwards, in which case the samples are added to the latest bucket.
+	v.buckets[len(v.buckets)-1].counters[counter] += uint64(n)
+}
+
+// removeExpiredLocked removes the buckets older than the retention period. Must be called with mtx held.
+func (v *alertVolume) removeExpiredLocked(now time.Time) {
+	minIndex := alertVolumeBucketIndex(now.Add(-v.retention))
+
+	expired := 0
+	for expired < len(v.buckets) && v.buckets[expired].index < minIndex {
+		expired++
+	}
+	if expired > 0 {
+		v.buckets = append(v.buckets[:0], v.buckets[expired:]...)
+	}
+}
+
+// alertVolumeWindows returns the index of the first and last windows of step between start and end.
+func alertVolumeWindows(start, end time.Time, step time.Duration) (firstWindow, lastWindow int64) {
+	stepBuckets := int64(step / alertVolumeResolution)
+	return alertVolumeBucketIndex(start) / stepBuckets, alertVolumeBucketIndex(end) / stepBuckets
+}
+
+// query returns the statistics between start and end, in windows of step aligned to multiples of step.
+func (v *alertVolume) query(start, end time.Time, step time.Duration) []AlertVolumeWindow {
+	stepBuckets := int64(step / alertVolumeResolution)
+	firstWindow, lastWindow := alertVolumeWindows(start, end, step)
+
+	windows := make([]AlertVolumeWindow, 0, lastWindow-firstWindow+1)
+	for w := firstWindow; w <= lastWindow; w++ {
+		windows = append(windows, AlertVolumeWindow{
+			Start: alertVolumeBucketStart(w * stepBuckets),
+			End:   alertVolumeBucketStart((w + 1) * stepBuckets),
+		})
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	for _, b := range v.buckets {
+		w := b.index / stepBuckets
+		if w < firstWindow || w > lastWindow {
+			continue
+		}
+
+		window := &windows[w-firstWindow]
+		window.Received += b.counters[alertVolumeReceived]
+		window.Grouped += b.counters[alertVolumeGrouped]
+		window.Notified += b.counters[alertVolumeNotified]
+		window.Silenced += b.counters[alertVolumeSilenced]
+		window.Inhibited += b.counters[alertVolumeInhibited]
+	}
+	return windows
+}
+
+// maintenance stores the statistics to the snapshot file every interval, and a last time once stopc is closed.
+func (v *alertVolume) maintenance(interval time.Duration, stopc <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			v.storeSnapshotAndLog()
+			return
+		case <-ticker.C:
+			v.storeSnapshotAndLog()
+		}
+	}
+}
+
+func (v *alertVolume) storeSnapshotAndLog() {
+	if err := v.storeSnapshot(); err != nil {
+		level.Warn(v.logger).Log("msg", "failed to store alert volume statistics", "file", v.snapshotFile, "err", err)
+	}
+}
+
+// storeSnapshot writes the statistics to the snapshot file. The snapshot is made of the format version, the
+// number of buckets, and each bucket as its index delta from the previous one followed by its counters, all
+// encoded as uvarints.
+func (v *alertVolume) storeSnapshot() error {
+	v.mtx.Lock()
+	v.removeExpiredLocked(v.now())
+
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(v.buckets)*(1+int(alertVolumeCountersCount))*binary.MaxVarintLen64)
+	buf = binary.AppendUvarint(buf, alertVolumeSnapshotVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(v.buckets)))
+
+	prev := int64(0)
+	for _, b := range v.buckets {
+		buf = binary.AppendUvarint(buf, uint64(b.index-prev))
+		for _, c := range b.counters {
+			buf = binary.AppendUvarint(buf, c)
+		}
+		prev = b.index
+	}
+	v.mtx.Unlock()
+
+	// The snapshot is written to a temporary file first, so that a partially written snapshot is never loaded.
+	tmp := v.snapshotFile + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	merr := multierror.New()
+	_, err = file.Write(buf)
+	merr.Add(err)
+	merr.Add(file.Sync())
+	merr.Add(file.Close())
+	if err := merr.Err(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, v.snapshotFile)
+}
+
+func (v *alertVolume) loadSnapshot() error {
+	file, err := os.Open(v.snapshotFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if version != alertVolumeSnapshotVersion {
+		return fmt.Errorf("unsupported alert volume snapshot version %d", version)
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+
+	buckets := make([]alertVolumeBucket, 0, count)
+	prev := int64(0)
+	for i := uint64(0); i < count; i++ {
+		delta, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+
+		b := alertVolumeBucket{index: prev + int64(delta)}
+		for c := range b.counters {
+			if b.counters[c], err = binary.ReadUvarint(r); err != nil {
+				return err
+			}
+		}
+		buckets = append(buckets, b)
+		prev = b.index
+	}
+	if _, err := r.ReadByte(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unexpected data at the end of the alert volume snapshot")
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	v.buckets = buckets
+	v.removeExpiredLocked(v.now())
+	return nil
+}
+
+// serveAlertVolume returns the alert volume statistics between the start and end parameters, by default the
+// last 24 hours, in windows of the step parameter, by default 1 hour. The time range is limited to the retention
+// period, and the number of windows to maxAlertVolumeWindows.
+func (v *alertVolume) serveAlertVolume(w http.ResponseWriter, r *http.Request) {
+	now := v.now()
+	end, start, step := now, now.Add(-defaultAlertVolumeRange), defaultAlertVolumeStep
+
+	if value := r.FormValue("end"); value != "" {
+		ms, err := util.ParseTime(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		end = util.TimeFromMillis(ms)
+		start = end.Add(-defaultAlertVolumeRange)
+	}
+	if value := r.FormValue("start"); value != "" {
+		ms, err := util.ParseTime(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		start = util.TimeFromMillis(ms)
+	}
+	if value := r.FormValue("step"); value != "" {
+		parsed, err := model.ParseDuration(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid step: %v", err), http.StatusBadRequest)
+			return
+		}
+		step = time.Duration(parsed)
+	}
+
+	if step <= 0 || step%alertVolumeResolution != 0 {
+		http.Error(w, fmt.Sprintf("the step must be a multiple of %s", model.Duration(alertVolumeResolution)), http.StatusBadRequest)
+		return
+	}
+	if end.Before(start) {
+		http.Error(w, "the end must not be before the start", http.StatusBadRequest)
+		return
+	}
+	if start.Before(now.Add(-v.retention)) {
+		start = now.Add(-v.retention)
+	}
+	if start.After(now) {
+		start = now
+	}
+	if end.After(now) {
+		end = now
+	}
+	if end.Before(start) {
+		end = start
+	}
+
+	if firstWindow, lastWindow := alertVolumeWindows(start, end, step); lastWindow-firstWindow+1 > maxAlertVolumeWindows {
+		http.Error(w, fmt.Sprintf("exceeded maximum resolution of %d windows per query. Try decreasing the query resolution (?step=XX)", maxAlertVolumeWindows), http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, struct {
+		Status string              `json:"status"`
+		Data   []AlertVolumeWindow `json:"data"`
+	}{
+		Status: "success",
+		Data:   v.query(start, end, step),
+	})
+}
+
+// alertVolumeAlerts counts the alerts received through the API.
+type alertVolumeAlerts struct {
+	provider.Alerts
+	volume *alertVolume
+}
+
+func (a *alertVolumeAlerts) Put(alerts ...*types.Alert) error {
+	err := a.Alerts.Put(alerts...)
+	if err == nil {
+		a.volume.add(alertVolumeReceived, len(alerts))
+	}
+	return err
+}
+
+// alertVolumeStage counts the alerts flushed by the aggregation groups to the notification pipeline, and the
+// ones silenced or inhibited by the pipeline, according to their status once the pipeline has run.
+type alertVolumeStage struct {
+	next   notify.Stage
+	marker types.Marker
+	volume *alertVolume
+}
+
+func (s *alertVolumeStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, out, err := s.next.Exec(ctx, l, alerts...)
+
+	// The inhibition is checked before the silences, so the inhibited alerts aren't checked against the silences.
+	var silenced, inhibited int
+	for _, a := range alerts {
+		status := s.marker.Status(a.Fingerprint())
+		switch {
+		case len(status.InhibitedBy) > 0:
+			inhibited++
+		case len(status.SilencedBy) > 0:
+			silenced++
+		}
+	}
+
+	s.volume.add(alertVolumeGrouped, len(alerts))
+	s.volume.add(alertVolumeSilenced, silenced)
+	s.volume.add(alertVolumeInhibited, inhibited)
+	return ctx, out, err
+}
+
+// alertVolumeNotifier counts the alerts successfully notified by an integration.
+type alertVolumeNotifier struct {
+	upstream notify.Notifier
+	volume   *alertVolume
+}
+
+func (n *alertVolumeNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	if err == nil {
+		n.volume.add(alertVolumeNotified, len(alerts))
+	}
+	return retry, err
+}
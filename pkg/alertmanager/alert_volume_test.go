@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAlertVolume(t *testing.T, now *time.Time) *alertVolume {
+	v := newAlertVolume(filepath.Join(t.TempDir(), alertVolumeSnapshot), 6*time.Hour, log.NewNopLogger())
+	v.now = func() time.Time { return *now }
+	return v
+}
+
+func TestAlertVolume_Query(t *testing.T) {
+	now := time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)
+	v := newTestAlertVolume(t, &now)
+
+	v.add(alertVolumeReceived, 3)
+	v.add(alertVolumeGrouped, 2)
+	now = now.Add(10 * time.Minute)
+	v.add(alertVolumeReceived, 1)
+	v.add(alertVolumeNotified, 2)
+	now = now.Add(time.Hour)
+	v.add(alertVolumeSilenced, 1)
+	v.add(alertVolumeInhibited, 4)
+	v.add(alertVolumeReceived, 0)
+
+	start := time.Date(2023, 6, 20, 9, 30, 0, 0, time.UTC)
+	assert.Equal(t, []AlertVolumeWindow{
+		{Start: start.Add(-30 * time.Minute).Local(), End: start.Add(30 * time.Minute).Local()},
+		{Start: start.Add(30 * time.Minute).Local(), End: start.Add(90 * time.Minute).Local(), Received: 4, Grouped: 2, Notified: 2},
+		{Start: start.Add(90 * time.Minute).Local(), End: start.Add(150 * time.Minute).Local(), Silenced: 1, Inhibited: 4},
+	}, v.query(start, now, time.Hour))
+
+	assert.Equal(t, []AlertVolumeWindow{
+		{Start: start.Add(30 * time.Minute).Local(), End: start.Add(35 * time.Minute).Local(), Received: 3, Grouped: 2},
+	}, v.query(start.Add(30*time.Minute), start.Add(34*time.Minute), alertVolumeResolution))
+}
+
+func TestAlertVolume_Retention(t *testing.T) {
+	now := time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)
+	v := newTestAlertVolume(t, &now)
+
+	v.add(alertVolumeReceived, 1)
+	now = now.Add(5 * time.Hour)
+	v.add(alertVolumeReceived, 2)
+	require.Len(t, v.buckets, 2)
+
+	// The first bucket expires once a new bucket is created after the retention period.
+	now = now.Add(2 * time.Hour)
+	v.add(alertVolumeReceived, 3)
+	require.Len(t, v.buckets, 2)
+	assert.Equal(t, uint64(2), v.buckets[0].counters[alertVolumeReceived])
+	assert.Equal(t, uint64(3), v.buckets[1].counters[alertVolumeReceived])
+}
+
+func TestAlertVolume_Snapshot(t *testing.T) {
+	now := time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)
+	v := newTestAlertVolume(t, &now)
+
+	v.add(alertVolumeReceived, 1000)
+	v.add(alertVolumeNotified, 1)
+	now = now.Add(time.Hour)
+	v.add(alertVolumeGrouped, 7)
+	require.NoError(t, v.storeSnapshot())
+
+	_, err := os.Stat(v.snapshotFile + ".tmp")
+	require.True(t, os.IsNotExist(err))
+
+	loaded := &alertVolume{snapshotFile: v.snapshotFile, retention: v.retention, now: v.now}
+	require.NoError(t, loaded.loadSnapshot())
+	assert.Equal(t, v.buckets, loaded.buckets)
+
+	// The expired buckets are not loaded.
+	now = now.Add(5*time.Hour + 30*time.Minute)
+	loaded = &alertVolume{snapshotFile: v.snapshotFile, retention: v.retention, now: v.now}
+	require.NoError(t, loaded.loadSnapshot())
+	assert.Equal(t, v.buckets[1:], loaded.buckets)
+
+	// A snapshot with an unknown version is ignored.
+	require.NoError(t, os.WriteFile(v.snapshotFile, []byte{2, 0}, 0o600))
+	require.Error(t, loaded.loadSnapshot())
+	assert.Empty(t, newAlertVolume(v.snapshotFile, v.retention, log.NewNopLogger()).buckets)
+
+	// A truncated snapshot is ignored.
+	require.NoError(t, os.WriteFile(v.snapshotFile, []byte{alertVolumeSnapshotVersion, 1, 1}, 0o600))
+	require.Error(t, loaded.loadSnapshot())
+	assert.Empty(t, newAlertVolume(v.snapshotFile, v.retention, log.NewNopLogger()).buckets)
+}
+
+func TestAlertVolume_Hooks(t *testing.T) {
+	now := time.Now()
+	v := newTestAlertVolume(t, &now)
+	marker := types.NewMarker(prometheus.NewRegistry())
+
+	alerts, err := mem.NewAlerts(context.Background(), marker, 30*time.Minute, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	defer alerts.Close()
+
+	newAlert := func(name string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}, EndsAt: now.Add(time.Hour)}}
+	}
+	silenced, inhibited, active := newAlert("silenced"), newAlert("inhibited"), newAlert("active")
+
+	received := &alertVolumeAlerts{Alerts: alerts, volume: v}
+	require.NoError(t, received.Put(silenced, inhibited, active))
+
+	stage := &alertVolumeStage{
+		next: notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			marker.SetActiveOrSilenced(silenced.Fingerprint(), 0, []string{"silence"}, nil)
+			marker.SetInhibited(inhibited.Fingerprint(), "inhibitor")
+			return ctx, alerts[2:], nil
+		}),
+		marker: marker,
+		volume: v,
+	}
+	_, out, err := stage.Exec(context.Background(), log.NewNopLogger(), silenced, inhibited, active)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Alert{active}, out)
+
+	notifier := &alertVolumeNotifier{upstream: &failingNotifier{}, volume: v}
+	_, err = notifier.Notify(context.Background(), active)
+	require.NoError(t, err)
+
+	notifier = &alertVolumeNotifier{upstream: &failingNotifier{err: errors.New("failed")}, volume: v}
+	_, err = notifier.Notify(context.Background(), active)
+	require.Error(t, err)
+
+	windows := v.query(now, now, alertVolumeResolution)
+	require.Len(t, windows, 1)
+	assert.Equal(t, uint64(3), windows[0].Received)
+	assert.Equal(t, uint64(3), windows[0].Grouped)
+	assert.Equal(t, uint64(1), windows[0].Notified)
+	assert.Equal(t, uint64(1), windows[0].Silenced)
+	assert.Equal(t, uint64(1), windows[0].Inhibited)
+}
+
+func TestAlertVolume_ServeAlertVolume(t *testing.T) {
+	now := time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)
+	v := newTestAlertVolume(t, &now)
+	v.add(alertVolumeReceived, 5)
+
+	for name, tc := range map[string]struct {
+		query           string
+		expectedStatus  int
+		expectedWindows int
+	}{
+		"defaults to the last 24h in 1h windows, limited to the retention": {
+			expectedStatus:  http.StatusOK,
+			expectedWindows: 7,
+		},
+		"custom range and step": {
+			query:           "?start=2023-06-20T09:00:00Z&end=2023-06-20T10:00:00Z&step=15m",
+			expectedStatus:  http.StatusOK,
+			expectedWindows: 5,
+		},
+		"invalid start": {
+			query:          "?start=foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid end": {
+			query:          "?end=foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid step": {
+			query:          "?step=foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"step not multiple of the resolution": {
+			query:          "?step=7m",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"range limited to now": {
+			query:           "?start=2023-06-20T09:00:00Z&end=2023-06-30T09:00:00Z&step=15m",
+			expectedStatus:  http.StatusOK,
+			expectedWindows: 5,
+		},
+		"end before start": {
+			query:          "?start=2023-06-20T10:00:00Z&end=2023-06-20T09:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			v.serveAlertVolume(rec, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/volume"+tc.query, nil))
+			require.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Status string              `json:"status"`
+				Data   []AlertVolumeWindow `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, "success", resp.Status)
+			assert.Len(t, resp.Data, tc.expectedWindows)
+
+			var received uint64
+			for _, w := range resp.Data {
+				received += w.Received
+			}
+			assert.Equal(t, uint64(5), received)
+		})
+	}
+}
+
+func TestAlertVolume_ServeAlertVolume_MaxWindows(t *testing.T) {
+	now := time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)
+	v := newAlertVolume(filepath.Join(t.TempDir(), alertVolumeSnapshot), 365*24*time.Hour, log.NewNopLogger())
+	v.now = func() time.Time { return now }
+
+	rec := httptest.NewRecorder()
+	v.serveAlertVolume(rec, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/volume?start=2023-01-01T00:00:00Z&step=5m", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "exceeded maximum resolution")
+
+	rec = httptest.NewRecorder()
+	v.serveAlertVolume(rec, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/volume?start=2023-01-01T00:00:00Z&step=1h", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
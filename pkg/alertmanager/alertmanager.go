@@ -56,6 +56,7 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	"github.com/grafana/mimir/pkg/util"
 	util_net "github.com/grafana/mimir/pkg/util/net"
 )
 
@@ -120,7 +121,14 @@ type Alertmanager struct {
 	// hence we need to generate the metric ourselves.
 	configHashMetric prometheus.Gauge
 
-	rateLimitedNotifications *prometheus.CounterVec
+	rateLimitedNotifications     *prometheus.CounterVec
+	notificationDeliveryDuration *prometheus.HistogramVec
+
+	// Most recent notifications that failed to be delivered, kept across configuration reloads.
+	notificationFailures *notificationFailures
+
+	// Alert volume statistics of the tenant.
+	alertVolume *alertVolume
 }
 
 var (
@@ -183,6 +191,14 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 			Help: "Number of rate-limited notifications per integration.",
 		}, []string{"integration"}), // "integration" is consistent with other alertmanager metrics.
 
+		notificationDeliveryDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "alertmanager_notification_delivery_duration_seconds",
+			Help:    "Time taken to deliver a notification per integration, including failed attempts.",
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 15, 20},
+		}, []string{"integration"}),
+
+		notificationFailures: newNotificationFailures(maxRecentNotificationFailures),
+		alertVolume:          newAlertVolume(filepath.Join(cfg.TenantDataDir, alertVolumeSnapshot), cfg.Retention, log.With(cfg.Logger, "user", cfg.UserID, "component", "alert-volume")),
 	}
 
 	am.registry = reg
@@ -255,8 +271,14 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		return nil, fmt.Errorf("failed to create alerts: %v", err)
 	}
 
+	am.wg.Add(1)
+	go func() {
+		am.alertVolume.maintenance(maintenancePeriod, am.maintenanceStop)
+		am.wg.Done()
+	}()
+
 	am.api, err = api.New(api.Options{
-		Alerts:      am.alerts,
+		Alerts:      &alertVolumeAlerts{Alerts: am.alerts, volume: am.alertVolume},
 		Silences:    am.silences,
 		StatusFunc:  am.marker.Status,
 		Concurrency: cfg.MaxConcurrentGetRequestsPerTenant,
@@ -288,6 +310,9 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.mux.Handle(a, http.NotFoundHandler())
 	}
 
+	am.mux.HandleFunc(path.Join(am.cfg.ExternalURL.Path, "/api/v1/notifications/failures"), am.serveNotificationFailures)
+	am.mux.HandleFunc(path.Join(am.cfg.ExternalURL.Path, "/api/v1/alerts/volume"), am.alertVolume.serveAlertVolume)
+
 	am.dispatcherMetrics = dispatch.NewDispatcherMetrics(true, am.registry)
 
 	//TODO: From this point onward, the alertmanager _might_ receive requests - we need to make sure we've settled and are ready.
@@ -354,6 +379,11 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
 
 	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
+		notifier = &alertVolumeNotifier{upstream: notifier, volume: am.alertVolume}
+
+		// Rate-limited notifications are not delivery attempts, so they're not instrumented.
+		notifier = newInstrumentedNotifier(notifier, integrationName, am.notificationDeliveryDuration.WithLabelValues(integrationName), am.notificationFailures)
+
 		if am.cfg.Limits != nil {
 			rl := &tenantRateLimits{
 				tenant:      userID,
@@ -391,7 +421,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	am.dispatcher = dispatch.NewDispatcher(
 		am.alerts,
 		dispatch.NewRoute(conf.Route, nil),
-		pipeline,
+		&alertVolumeStage{next: pipeline, marker: am.marker, volume: am.alertVolume},
 		am.marker,
 		timeoutFunc,
 		&dispatcherLimits{tenant: am.cfg.UserID, limits: am.cfg.Limits},
@@ -437,6 +467,17 @@ func (am *Alertmanager) StopAndWait() {
 	am.wg.Wait()
 }
 
+// serveNotificationFailures returns the most recent notifications that failed to be delivered.
+func (am *Alertmanager) serveNotificationFailures(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, struct {
+		Status string                `json:"status"`
+		Data   []NotificationFailure `json:"data"`
+	}{
+		Status: "success",
+		Data:   am.notificationFailures.list(),
+	})
+}
+
 func (am *Alertmanager) mergePartialExternalState(part *clusterpb.Part) error {
 	return am.state.MergePartialState(part)
 }
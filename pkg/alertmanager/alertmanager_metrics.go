@@ -27,6 +27,7 @@ type alertmanagerMetrics struct {
 	numNotificationRequestsTotal       *prometheus.Desc
 	numNotificationRequestsFailedTotal *prometheus.Desc
 	notificationLatencySeconds         *prometheus.Desc
+	notificationDeliveryDuration       *prometheus.Desc
 
 	// exported metrics, gathered from Alertmanager nflog
 	nflogGCDuration              *prometheus.Desc
@@ -111,6 +112,10 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_notification_latency_seconds",
 			"The latency of notifications in seconds.",
 			nil, nil),
+		notificationDeliveryDuration: prometheus.NewDesc(
+			"cortex_alertmanager_notification_delivery_duration_seconds",
+			"Time taken to deliver a notification per integration, including failed attempts.",
+			[]string{"integration"}, nil),
 		nflogGCDuration: prometheus.NewDesc(
 			"cortex_alertmanager_nflog_gc_duration_seconds",
 			"Duration of the last notification log garbage collection cycle.",
@@ -288,6 +293,7 @@ func (m *alertmanagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.numNotificationRequestsTotal
 	out <- m.numNotificationRequestsFailedTotal
 	out <- m.notificationLatencySeconds
+	out <- m.notificationDeliveryDuration
 	out <- m.markerAlerts
 	out <- m.nflogGCDuration
 	out <- m.nflogSnapshotDuration
@@ -340,6 +346,7 @@ func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfCountersPerTenant(out, m.numNotificationRequestsTotal, "alertmanager_notification_requests_total", dskit_metrics.WithLabels("integration"), dskit_metrics.WithSkipZeroValueMetrics)
 	data.SendSumOfCountersPerTenant(out, m.numNotificationRequestsFailedTotal, "alertmanager_notification_requests_failed_total", dskit_metrics.WithLabels("integration"), dskit_metrics.WithSkipZeroValueMetrics)
 	data.SendSumOfHistograms(out, m.notificationLatencySeconds, "alertmanager_notification_latency_seconds")
+	data.SendSumOfHistogramsWithLabels(out, m.notificationDeliveryDuration, "alertmanager_notification_delivery_duration_seconds", "integration")
 	data.SendSumOfGaugesPerTenantWithLabels(out, m.markerAlerts, "alertmanager_alerts", "state")
 
 	data.SendSumOfSummaries(out, m.nflogGCDuration, "alertmanager_nflog_gc_duration_seconds")
@@ -949,6 +949,40 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAlertmanagerMetricsNotificationDeliveryDuration(t *testing.T) {
+	mainReg := prometheus.NewPedanticRegistry()
+
+	alertmanangerMetrics := newAlertmanagerMetrics()
+	mainReg.MustRegister(alertmanangerMetrics)
+
+	for _, user := range []string{"user1", "user2"} {
+		reg := prometheus.NewRegistry()
+		duration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "alertmanager_notification_delivery_duration_seconds",
+			Buckets: []float64{1, 5},
+		}, []string{"integration"})
+		duration.WithLabelValues("email").Observe(0.5)
+		duration.WithLabelValues("webhook").Observe(2)
+		alertmanangerMetrics.addUserRegistry(user, reg)
+	}
+
+	err := testutil.GatherAndCompare(mainReg, bytes.NewBufferString(`
+		# HELP cortex_alertmanager_notification_delivery_duration_seconds Time taken to deliver a notification per integration, including failed attempts.
+		# TYPE cortex_alertmanager_notification_delivery_duration_seconds histogram
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="email",le="1"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="email",le="5"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="email",le="+Inf"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_sum{integration="email"} 1
+		cortex_alertmanager_notification_delivery_duration_seconds_count{integration="email"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="webhook",le="1"} 0
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="webhook",le="5"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_bucket{integration="webhook",le="+Inf"} 2
+		cortex_alertmanager_notification_delivery_duration_seconds_sum{integration="webhook"} 4
+		cortex_alertmanager_notification_delivery_duration_seconds_count{integration="webhook"} 2
+	`), "cortex_alertmanager_notification_delivery_duration_seconds")
+	require.NoError(t, err)
+}
+
 func populateAlertmanager(base float64) *prometheus.Registry {
 	reg := prometheus.NewRegistry()
 	s := newSilenceMetrics(reg)
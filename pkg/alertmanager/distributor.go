@@ -105,6 +105,12 @@ func (d *Distributor) isQuorumReadPath(p string) (bool, merger.Merger) {
 	if strings.HasSuffix(path.Dir(p), "/v2/silence") {
 		return true, merger.V2SilenceID{}
 	}
+	if strings.HasSuffix(p, "/v1/notifications/failures") {
+		return true, merger.V1NotificationFailures{}
+	}
+	if strings.HasSuffix(p, "/v1/alerts/volume") {
+		return true, merger.V1AlertVolume{}
+	}
 	return false, nil
 }
 
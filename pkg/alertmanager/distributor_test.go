@@ -172,6 +172,28 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			route:              "/v2/silence/id",
 			responseBody:       []byte(`{"id":"aaa","updatedAt":"2020-01-01T00:00:00Z"}`),
 		},
+		{
+			name:               "Read /v1/notifications/failures is sent to 3 AMs",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 3,
+			route:              "/v1/notifications/failures",
+			responseBody:       []byte(`{"status":"success","data":[]}`),
+		},
+		{
+			name:               "Read /v1/alerts/volume is sent to 3 AMs",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 3,
+			route:              "/v1/alerts/volume",
+			responseBody:       []byte(`{"status":"success","data":[]}`),
+		},
 		{
 			name:                "Write /silence/id not supported",
 			numAM:               5,
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Maximum number of recent notification failures kept for each tenant.
+	maxRecentNotificationFailures = 100
+
+	// Maximum length of the error message kept for each notification failure.
+	maxNotificationFailureErrorLength = 1024
+)
+
+// NotificationFailure describes a notification that could not be delivered by an integration.
+type NotificationFailure struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Receiver    string    `json:"receiver"`
+	Integration string    `json:"integration"`
+	GroupKey    string    `json:"groupKey"`
+	NumAlerts   int       `json:"numAlerts"`
+	Error       string    `json:"error"`
+}
+
+// notificationFailures keeps the most recent notification failures of a tenant in a ring buffer.
+type notificationFailures struct {
+	mtx      sync.Mutex
+	failures []NotificationFailure
+	next     int
+}
+
+func newNotificationFailures(size int) *notificationFailures {
+	return &notificationFailures{
+		failures: make([]NotificationFailure, 0, size),
+	}
+}
+
+func (f *notificationFailures) add(failure NotificationFailure) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if len(f.failures) < cap(f.failures) {
+		f.failures = append(f.failures, failure)
+		return
+	}
+	f.failures[f.next] = failure
+	f.next = (f.next + 1) % len(f.failures)
+}
+
+// list returns the recorded failures, most recent first.
+func (f *notificationFailures) list() []NotificationFailure {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	result := make([]NotificationFailure, 0, len(f.failures))
+	for i := len(f.failures) - 1; i >= 0; i-- {
+		result = append(result, f.failures[(f.next+i)%len(f.failures)])
+	}
+	return result
+}
+
+// instrumentedNotifier tracks the latency of the notifications sent by the upstream notifier,
+// and records the failed ones.
+type instrumentedNotifier struct {
+	upstream    notify.Notifier
+	integration string
+	failures    *notificationFailures
+	duration    prometheus.Observer
+}
+
+func newInstrumentedNotifier(upstream notify.Notifier, integration string, duration prometheus.Observer, failures *notificationFailures) *instrumentedNotifier {
+	return &instrumentedNotifier{
+		upstream:    upstream,
+		integration: integration,
+		failures:    failures,
+		duration:    duration,
+	}
+}
+
+func (n *instrumentedNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	n.duration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		receiver, _ := notify.ReceiverName(ctx)
+		groupKey, _ := notify.GroupKey(ctx)
+
+		msg := err.Error()
+		if len(msg) > maxNotificationFailureErrorLength {
+			msg = msg[:maxNotificationFailureErrorLength]
+		}
+
+		n.failures.add(NotificationFailure{
+			Timestamp:   start,
+			Receiver:    receiver,
+			Integration: n.integration,
+			GroupKey:    groupKey,
+			NumAlerts:   len(alerts),
+			Error:       msg,
+		})
+	}
+
+	return retry, err
+}
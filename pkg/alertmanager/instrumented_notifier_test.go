@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingNotifier struct {
+	err error
+}
+
+func (n *failingNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	return n.err != nil, n.err
+}
+
+func TestInstrumentedNotifier(t *testing.T) {
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test"})
+	failures := newNotificationFailures(maxRecentNotificationFailures)
+
+	upstream := &failingNotifier{}
+	n := newInstrumentedNotifier(upstream, "webhook", duration, failures)
+
+	ctx := notify.WithReceiverName(context.Background(), "team-a")
+	ctx = notify.WithGroupKey(ctx, "{}:{}")
+
+	retry, err := n.Notify(ctx, &types.Alert{})
+	require.NoError(t, err)
+	assert.False(t, retry)
+
+	upstream.err = errors.New("unexpected status code 500")
+	retry, err = n.Notify(ctx, &types.Alert{}, &types.Alert{})
+	require.Equal(t, upstream.err, err)
+	assert.True(t, retry)
+
+	// Both successful and failed attempts should be observed.
+	m := &dto.Metric{}
+	require.NoError(t, duration.Write(m))
+	assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+
+	listed := failures.list()
+	require.Len(t, listed, 1)
+	assert.Equal(t, "team-a", listed[0].Receiver)
+	assert.Equal(t, "webhook", listed[0].Integration)
+	assert.Equal(t, "{}:{}", listed[0].GroupKey)
+	assert.Equal(t, 2, listed[0].NumAlerts)
+	assert.Equal(t, "unexpected status code 500", listed[0].Error)
+}
+
+func TestNotificationFailures(t *testing.T) {
+	failures := newNotificationFailures(3)
+	assert.Empty(t, failures.list())
+
+	for i := 0; i < 5; i++ {
+		failures.add(NotificationFailure{Error: fmt.Sprintf("error-%d", i)})
+	}
+
+	// Only the most recent failures should be kept, most recent first.
+	listed := failures.list()
+	require.Len(t, listed, 3)
+	assert.Equal(t, "error-4", listed[0].Error)
+	assert.Equal(t, "error-3", listed[1].Error)
+	assert.Equal(t, "error-2", listed[2].Error)
+}
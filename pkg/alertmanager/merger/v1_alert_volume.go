@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// V1AlertVolume implements the Merger interface for GET /v1/alerts/volume. Every replica receives, groups,
+// silences and inhibits the same alerts, so the maximum over all the responses is taken for those counters.
+// The notifications are deduplicated across replicas, so the notified counters are summed.
+type V1AlertVolume struct{}
+
+type alertVolumeWindow struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Received  uint64    `json:"received"`
+	Grouped   uint64    `json:"grouped"`
+	Notified  uint64    `json:"notified"`
+	Silenced  uint64    `json:"silenced"`
+	Inhibited uint64    `json:"inhibited"`
+}
+
+func (V1AlertVolume) MergeResponses(in [][]byte) ([]byte, error) {
+	type bodyType struct {
+		Status string              `json:"status"`
+		Data   []alertVolumeWindow `json:"data"`
+	}
+
+	windows := make(map[time.Time]*alertVolumeWindow)
+	for _, body := range in {
+		parsed := bodyType{}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, err
+		}
+		if parsed.Status != statusSuccess {
+			return nil, fmt.Errorf("unable to merge response of status: %s", parsed.Status)
+		}
+
+		for _, w := range parsed.Data {
+			merged, ok := windows[w.Start]
+			if !ok {
+				w := w
+				windows[w.Start] = &w
+				continue
+			}
+			merged.Received = maxUint64(merged.Received, w.Received)
+			merged.Grouped = maxUint64(merged.Grouped, w.Grouped)
+			merged.Notified += w.Notified
+			merged.Silenced = maxUint64(merged.Silenced, w.Silenced)
+			merged.Inhibited = maxUint64(merged.Inhibited, w.Inhibited)
+		}
+	}
+
+	result := make([]alertVolumeWindow, 0, len(windows))
+	for _, w := range windows {
+		result = append(result, *w)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return json.Marshal(bodyType{
+		Status: statusSuccess,
+		Data:   result,
+	})
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
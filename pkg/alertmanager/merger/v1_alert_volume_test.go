@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1AlertVolume(t *testing.T) {
+	in := [][]byte{
+		[]byte(`{"status":"success","data":[` +
+			`{"start":"2023-06-20T10:00:00Z","end":"2023-06-20T11:00:00Z","received":10,"grouped":4,"notified":2,"silenced":1,"inhibited":0},` +
+			`{"start":"2023-06-20T11:00:00Z","end":"2023-06-20T12:00:00Z","received":5,"grouped":2,"notified":0,"silenced":0,"inhibited":1}]}`),
+		[]byte(`{"status":"success","data":[` +
+			`{"start":"2023-06-20T11:00:00Z","end":"2023-06-20T12:00:00Z","received":6,"grouped":2,"notified":1,"silenced":0,"inhibited":1},` +
+			`{"start":"2023-06-20T10:00:00Z","end":"2023-06-20T11:00:00Z","received":9,"grouped":4,"notified":1,"silenced":1,"inhibited":0}]}`),
+		[]byte(`{"status":"success","data":[]}`),
+	}
+
+	expected := []byte(`{"status":"success","data":[` +
+		`{"start":"2023-06-20T10:00:00Z","end":"2023-06-20T11:00:00Z","received":10,"grouped":4,"notified":3,"silenced":1,"inhibited":0},` +
+		`{"start":"2023-06-20T11:00:00Z","end":"2023-06-20T12:00:00Z","received":6,"grouped":2,"notified":1,"silenced":0,"inhibited":1}]}`)
+
+	out, err := V1AlertVolume{}.MergeResponses(in)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(out))
+
+	_, err = V1AlertVolume{}.MergeResponses([][]byte{[]byte(`{"status":"error","data":[]}`)})
+	require.Error(t, err)
+}
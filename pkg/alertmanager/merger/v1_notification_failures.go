@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// V1NotificationFailures implements the Merger interface for GET /v1/notifications/failures. It returns
+// the union of the notification failures over all the responses, most recent first. Each replica
+// reports the failures of the notifications it tried to deliver, so no deduplication is done.
+type V1NotificationFailures struct{}
+
+type notificationFailure struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Receiver    string    `json:"receiver"`
+	Integration string    `json:"integration"`
+	GroupKey    string    `json:"groupKey"`
+	NumAlerts   int       `json:"numAlerts"`
+	Error       string    `json:"error"`
+}
+
+func (V1NotificationFailures) MergeResponses(in [][]byte) ([]byte, error) {
+	type bodyType struct {
+		Status string                `json:"status"`
+		Data   []notificationFailure `json:"data"`
+	}
+
+	failures := make([]notificationFailure, 0)
+	for _, body := range in {
+		parsed := bodyType{}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, err
+		}
+		if parsed.Status != statusSuccess {
+			return nil, fmt.Errorf("unable to merge response of status: %s", parsed.Status)
+		}
+		failures = append(failures, parsed.Data...)
+	}
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Timestamp.After(failures[j].Timestamp)
+	})
+
+	return json.Marshal(bodyType{
+		Status: statusSuccess,
+		Data:   failures,
+	})
+}
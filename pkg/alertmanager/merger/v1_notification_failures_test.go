@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1NotificationFailures(t *testing.T) {
+	in := [][]byte{
+		[]byte(`{"status":"success","data":[` +
+			`{"timestamp":"2023-06-20T10:00:02Z","receiver":"team-a","integration":"webhook","groupKey":"{}:{}","numAlerts":2,"error":"unexpected status code 500"},` +
+			`{"timestamp":"2023-06-20T10:00:00Z","receiver":"team-b","integration":"slack","groupKey":"{}:{}","numAlerts":1,"error":"context deadline exceeded"}]}`),
+		[]byte(`{"status":"success","data":[` +
+			`{"timestamp":"2023-06-20T10:00:01Z","receiver":"team-a","integration":"webhook","groupKey":"{}:{}","numAlerts":2,"error":"unexpected status code 503"}]}`),
+		[]byte(`{"status":"success","data":[]}`),
+	}
+
+	expected := []byte(`{"status":"success","data":[` +
+		`{"timestamp":"2023-06-20T10:00:02Z","receiver":"team-a","integration":"webhook","groupKey":"{}:{}","numAlerts":2,"error":"unexpected status code 500"},` +
+		`{"timestamp":"2023-06-20T10:00:01Z","receiver":"team-a","integration":"webhook","groupKey":"{}:{}","numAlerts":2,"error":"unexpected status code 503"},` +
+		`{"timestamp":"2023-06-20T10:00:00Z","receiver":"team-b","integration":"slack","groupKey":"{}:{}","numAlerts":1,"error":"context deadline exceeded"}]}`)
+
+	out, err := V1NotificationFailures{}.MergeResponses(in)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(out))
+
+	_, err = V1NotificationFailures{}.MergeResponses([][]byte{[]byte(`{"status":"error","data":[]}`)})
+	require.Error(t, err)
+}
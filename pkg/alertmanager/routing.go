@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	errReadingRoutingTest = "unable to read the routing test request"
+	errInvalidRoutingTest = "invalid routing test request"
+	errNoConfigToTest     = "the Alertmanager is not configured"
+)
+
+// RoutingTestRequest is a request to test the routing of a synthetic alert.
+type RoutingTestRequest struct {
+	// Labels of the synthetic alert.
+	Labels map[string]string `json:"labels"`
+
+	// Time at which the mute and active time intervals are evaluated. Defaults to the current time.
+	Time time.Time `json:"time"`
+
+	// AlertmanagerConfig is the YAML Alertmanager configuration to test the routing against.
+	// Defaults to the tenant's current configuration.
+	AlertmanagerConfig string `json:"alertmanager_config"`
+}
+
+// RoutingTestResult describes a route matching the synthetic alert, and how the alert
+// would be grouped and notified by such route.
+type RoutingTestResult struct {
+	// RoutePath holds the matchers of each route traversed from the root route
+	// to the matching route, the latter included.
+	RoutePath      []string       `json:"route_path"`
+	Receiver       string         `json:"receiver"`
+	GroupBy        []string       `json:"group_by"`
+	GroupByAll     bool           `json:"group_by_all"`
+	GroupKey       string         `json:"group_key"`
+	GroupWait      model.Duration `json:"group_wait"`
+	GroupInterval  model.Duration `json:"group_interval"`
+	RepeatInterval model.Duration `json:"repeat_interval"`
+
+	MuteTimeIntervals   []string `json:"mute_time_intervals"`
+	ActiveTimeIntervals []string `json:"active_time_intervals"`
+
+	// MutedByTimeIntervals holds the mute time intervals containing the evaluation time.
+	MutedByTimeIntervals []string `json:"muted_by_time_intervals"`
+
+	// Active is false if the route has active time intervals but none of them contains the evaluation time.
+	Active bool `json:"active"`
+
+	// Muted is true if notifications for the alert wouldn't be sent at the evaluation time.
+	Muted bool `json:"muted"`
+}
+
+// TestRouting returns the routes matching a synthetic alert, without sending any notification.
+// The routing is tested against the Alertmanager configuration in the request, if any, or against
+// the tenant's current configuration otherwise, so that routing changes can be tested before uploading them.
+func (am *MultitenantAlertmanager) TestRouting(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		level.Error(logger).Log("msg", errReadingRoutingTest, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errReadingRoutingTest, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	req := RoutingTestRequest{}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errInvalidRoutingTest, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	lset, err := validateRoutingTestLabels(req.Labels)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errInvalidRoutingTest, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	rawCfg := req.AlertmanagerConfig
+	if rawCfg != "" {
+		if maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID); maxConfigSize > 0 && len(rawCfg) > maxConfigSize {
+			http.Error(w, fmt.Sprintf(errConfigurationTooBig, maxConfigSize), http.StatusBadRequest)
+			return
+		}
+	} else {
+		cfgDesc, err := am.store.GetAlertConfig(r.Context(), userID)
+		if err != nil && !errors.Is(err, alertspb.ErrNotFound) {
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		// Like when the configuration is synced, an empty configuration is replaced by the fallback one.
+		rawCfg = cfgDesc.RawConfig
+		if rawCfg == "" {
+			rawCfg = am.fallbackConfig
+		}
+		if rawCfg == "" {
+			http.Error(w, errNoConfigToTest, http.StatusNotFound)
+			return
+		}
+	}
+
+	cfg, err := config.Load(rawCfg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	util.WriteJSONResponse(w, struct {
+		Status string              `json:"status"`
+		Data   []RoutingTestResult `json:"data"`
+	}{
+		Status: "success",
+		Data:   testRouting(cfg, lset, now),
+	})
+}
+
+func validateRoutingTestLabels(labels map[string]string) (model.LabelSet, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("the alert must have at least one label")
+	}
+
+	lset := make(model.LabelSet, len(labels))
+	for name, value := range labels {
+		lset[model.LabelName(name)] = model.LabelValue(value)
+	}
+	if err := lset.Validate(); err != nil {
+		return nil, err
+	}
+	return lset, nil
+}
+
+// testRouting returns the routes of cfg matching the input alert labels, in the same order
+// as they would be notified by the dispatcher.
+func testRouting(cfg *config.Config, lset model.LabelSet, now time.Time) []RoutingTestResult {
+	timeIntervals := make(map[string][]timeinterval.TimeInterval, len(cfg.MuteTimeIntervals)+len(cfg.TimeIntervals))
+	for _, ti := range cfg.MuteTimeIntervals {
+		timeIntervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, ti := range cfg.TimeIntervals {
+		timeIntervals[ti.Name] = ti.TimeIntervals
+	}
+
+	results := []RoutingTestResult{}
+	for _, path := range matchRoutePaths(dispatch.NewRoute(cfg.Route, nil), lset, nil) {
+		results = append(results, routingTestResult(path, lset, timeIntervals, now))
+	}
+	return results
+}
+
+// matchRoutePaths mirrors dispatch.Route.Match, but returns the path from the root
+// route to each matching route, instead of the matching route only.
+func matchRoutePaths(r *dispatch.Route, lset model.LabelSet, parents []*dispatch.Route) [][]*dispatch.Route {
+	if !r.Matchers.Matches(lset) {
+		return nil
+	}
+
+	path := make([]*dispatch.Route, 0, len(parents)+1)
+	path = append(path, parents...)
+	path = append(path, r)
+
+	var all [][]*dispatch.Route
+	for _, cr := range r.Routes {
+		matches := matchRoutePaths(cr, lset, path)
+		all = append(all, matches...)
+
+		if matches != nil && !cr.Continue {
+			break
+		}
+	}
+
+	// If no child nodes were matches, the current node itself is a match.
+	if len(all) == 0 {
+		all = append(all, path)
+	}
+	return all
+}
+
+func routingTestResult(path []*dispatch.Route, lset model.LabelSet, timeIntervals map[string][]timeinterval.TimeInterval, now time.Time) RoutingTestResult {
+	route := path[len(path)-1]
+	opts := route.RouteOpts
+
+	res := RoutingTestResult{
+		RoutePath:            make([]string, 0, len(path)),
+		Receiver:             opts.Receiver,
+		GroupBy:              make([]string, 0, len(opts.GroupBy)),
+		GroupByAll:           opts.GroupByAll,
+		GroupWait:            model.Duration(opts.GroupWait),
+		GroupInterval:        model.Duration(opts.GroupInterval),
+		RepeatInterval:       model.Duration(opts.RepeatInterval),
+		MuteTimeIntervals:    append([]string{}, opts.MuteTimeIntervals...),
+		ActiveTimeIntervals:  append([]string{}, opts.ActiveTimeIntervals...),
+		MutedByTimeIntervals: []string{},
+		Active:               len(opts.ActiveTimeIntervals) == 0,
+	}
+
+	for _, r := range path {
+		res.RoutePath = append(res.RoutePath, r.Matchers.String())
+	}
+	if !opts.GroupByAll {
+		for name := range opts.GroupBy {
+			res.GroupBy = append(res.GroupBy, string(name))
+		}
+		sort.Strings(res.GroupBy)
+	}
+
+	// The group key is built the same way the dispatcher does for aggregation groups.
+	groupLabels := model.LabelSet{}
+	for name, value := range lset {
+		if _, ok := opts.GroupBy[name]; ok || opts.GroupByAll {
+			groupLabels[name] = value
+		}
+	}
+	res.GroupKey = fmt.Sprintf("%s:%s", route.Key(), groupLabels)
+
+	for _, name := range opts.MuteTimeIntervals {
+		if timeIntervalsContain(timeIntervals[name], now) {
+			res.MutedByTimeIntervals = append(res.MutedByTimeIntervals, name)
+		}
+	}
+	for _, name := range opts.ActiveTimeIntervals {
+		if timeIntervalsContain(timeIntervals[name], now) {
+			res.Active = true
+			break
+		}
+	}
+	res.Muted = len(res.MutedByTimeIntervals) > 0 || !res.Active
+
+	return res
+}
+
+func timeIntervalsContain(intervals []timeinterval.TimeInterval, t time.Time) bool {
+	for _, ti := range intervals {
+		if ti.ContainsTime(t.UTC()) {
+			return true
+		}
+	}
+	return false
+}
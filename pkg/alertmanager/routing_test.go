@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+)
+
+const routingTestConfig = `
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - receiver: team-a
+    matchers: ['team="a"']
+    group_by: [alertname, cluster]
+    continue: true
+    routes:
+    - receiver: team-a-critical
+      matchers: ['severity="critical"']
+      active_time_intervals: [working-hours]
+  - receiver: team-a-audit
+    matchers: ['team="a"']
+    mute_time_intervals: [weekends]
+  - receiver: team-b
+    matchers: ['team="b"']
+    group_by: ['...']
+time_intervals:
+- name: working-hours
+  time_intervals:
+  - times:
+    - start_time: '09:00'
+      end_time: '17:00'
+- name: weekends
+  time_intervals:
+  - weekdays: ['saturday', 'sunday']
+receivers:
+- name: default
+- name: team-a
+- name: team-a-critical
+- name: team-a-audit
+- name: team-b
+`
+
+func TestTestRouting(t *testing.T) {
+	cfg, err := config.Load(routingTestConfig)
+	require.NoError(t, err)
+
+	// Saturday, outside of working hours.
+	saturdayNight := time.Date(2023, 6, 17, 22, 0, 0, 0, time.UTC)
+	// Monday, during working hours.
+	mondayMorning := time.Date(2023, 6, 19, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		labels   model.LabelSet
+		now      time.Time
+		expected []RoutingTestResult
+	}{
+		"should match the root route if no child route matches": {
+			labels: model.LabelSet{"alertname": "test", "team": "c"},
+			now:    mondayMorning,
+			expected: []RoutingTestResult{{
+				RoutePath:            []string{"{}"},
+				Receiver:             "default",
+				GroupBy:              []string{"alertname"},
+				GroupKey:             `{}:{alertname="test"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{},
+				ActiveTimeIntervals:  []string{},
+				MutedByTimeIntervals: []string{},
+				Active:               true,
+			}},
+		},
+		"should group by all labels": {
+			labels: model.LabelSet{"alertname": "test", "team": "b"},
+			now:    mondayMorning,
+			expected: []RoutingTestResult{{
+				RoutePath:            []string{"{}", `{team="b"}`},
+				Receiver:             "team-b",
+				GroupBy:              []string{},
+				GroupByAll:           true,
+				GroupKey:             `{}/{team="b"}:{alertname="test", team="b"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{},
+				ActiveTimeIntervals:  []string{},
+				MutedByTimeIntervals: []string{},
+				Active:               true,
+			}},
+		},
+		"should match multiple routes when continue is set, and evaluate time intervals": {
+			labels: model.LabelSet{"alertname": "test", "team": "a", "severity": "critical", "cluster": "eu"},
+			now:    saturdayNight,
+			expected: []RoutingTestResult{{
+				RoutePath:            []string{"{}", `{team="a"}`, `{severity="critical"}`},
+				Receiver:             "team-a-critical",
+				GroupBy:              []string{"alertname", "cluster"},
+				GroupKey:             `{}/{team="a"}/{severity="critical"}:{alertname="test", cluster="eu"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{},
+				ActiveTimeIntervals:  []string{"working-hours"},
+				MutedByTimeIntervals: []string{},
+				Active:               false,
+				Muted:                true,
+			}, {
+				RoutePath:            []string{"{}", `{team="a"}`},
+				Receiver:             "team-a-audit",
+				GroupBy:              []string{"alertname"},
+				GroupKey:             `{}/{team="a"}:{alertname="test"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{"weekends"},
+				ActiveTimeIntervals:  []string{},
+				MutedByTimeIntervals: []string{"weekends"},
+				Active:               true,
+				Muted:                true,
+			}},
+		},
+		"should not mute routes outside of mute time intervals and within active time intervals": {
+			labels: model.LabelSet{"alertname": "test", "team": "a", "severity": "critical"},
+			now:    mondayMorning,
+			expected: []RoutingTestResult{{
+				RoutePath:            []string{"{}", `{team="a"}`, `{severity="critical"}`},
+				Receiver:             "team-a-critical",
+				GroupBy:              []string{"alertname", "cluster"},
+				GroupKey:             `{}/{team="a"}/{severity="critical"}:{alertname="test"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{},
+				ActiveTimeIntervals:  []string{"working-hours"},
+				MutedByTimeIntervals: []string{},
+				Active:               true,
+			}, {
+				RoutePath:            []string{"{}", `{team="a"}`},
+				Receiver:             "team-a-audit",
+				GroupBy:              []string{"alertname"},
+				GroupKey:             `{}/{team="a"}:{alertname="test"}`,
+				GroupWait:            model.Duration(30 * time.Second),
+				GroupInterval:        model.Duration(5 * time.Minute),
+				RepeatInterval:       model.Duration(4 * time.Hour),
+				MuteTimeIntervals:    []string{"weekends"},
+				ActiveTimeIntervals:  []string{},
+				MutedByTimeIntervals: []string{},
+				Active:               true,
+			}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testRouting(cfg, testData.labels, testData.now))
+		})
+	}
+}
+
+func TestMultitenantAlertmanager_TestRouting(t *testing.T) {
+	storage := objstore.NewInMemBucket()
+	alertStore := bucketclient.NewBucketAlertStore(storage, nil, log.NewNopLogger())
+
+	am := &MultitenantAlertmanager{
+		store:  alertStore,
+		logger: log.NewNopLogger(),
+		limits: &mockAlertManagerLimits{maxConfigSize: 4096},
+	}
+
+	require.NoError(t, alertStore.SetAlertConfig(context.Background(), alertspb.AlertConfigDesc{
+		User:      "user-with-config",
+		RawConfig: routingTestConfig,
+	}))
+
+	tests := map[string]struct {
+		userID            string
+		body              string
+		expectedCode      int
+		expectedReceivers []string
+		expectedError     string
+	}{
+		"should test the routing against the tenant's current configuration": {
+			userID:            "user-with-config",
+			body:              `{"labels": {"alertname": "test", "team": "b"}}`,
+			expectedCode:      http.StatusOK,
+			expectedReceivers: []string{"team-b"},
+		},
+		"should test the routing against the configuration in the request": {
+			userID:            "user-without-config",
+			body:              `{"labels": {"alertname": "test"}, "alertmanager_config": "route:\n  receiver: other\nreceivers:\n- name: other\n"}`,
+			expectedCode:      http.StatusOK,
+			expectedReceivers: []string{"other"},
+		},
+		"should return error if the tenant has no configuration and none is in the request": {
+			userID:        "user-without-config",
+			body:          `{"labels": {"alertname": "test"}}`,
+			expectedCode:  http.StatusNotFound,
+			expectedError: errNoConfigToTest,
+		},
+		"should return error if the alert has no labels": {
+			userID:        "user-with-config",
+			body:          `{"labels": {}}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: errInvalidRoutingTest,
+		},
+		"should return error if the alert has invalid labels": {
+			userID:        "user-with-config",
+			body:          `{"labels": {"0invalid": "test"}}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: errInvalidRoutingTest,
+		},
+		"should return error if the configuration in the request is invalid": {
+			userID:        "user-with-config",
+			body:          `{"labels": {"alertname": "test"}, "alertmanager_config": "route:\n  receiver: missing\n"}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: errValidatingConfig,
+		},
+		"should return error if the configuration in the request is too big": {
+			userID:        "user-with-config",
+			body:          `{"labels": {"alertname": "test"}, "alertmanager_config": "` + strings.Repeat("#", 4097) + `"}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "Alertmanager configuration is too big",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/test_routing", strings.NewReader(testData.body))
+			req = req.WithContext(user.InjectOrgID(req.Context(), testData.userID))
+
+			rec := httptest.NewRecorder()
+			am.TestRouting(rec, req)
+			require.Equal(t, testData.expectedCode, rec.Code)
+
+			if testData.expectedError != "" {
+				assert.Contains(t, rec.Body.String(), testData.expectedError)
+				return
+			}
+
+			res := struct {
+				Status string              `json:"status"`
+				Data   []RoutingTestResult `json:"data"`
+			}{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, "success", res.Status)
+
+			var receivers []string
+			for _, r := range res.Data {
+				receivers = append(receivers, r.Receiver)
+			}
+			assert.Equal(t, testData.expectedReceivers, receivers)
+		})
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
 	"github.com/grafana/mimir/pkg/distributor/distributorpb"
+	"github.com/grafana/mimir/pkg/frontend/heavyqueries"
+	"github.com/grafana/mimir/pkg/frontend/queryrecorder"
 	frontendv1 "github.com/grafana/mimir/pkg/frontend/v1"
 	"github.com/grafana/mimir/pkg/frontend/v1/frontendv1pb"
 	frontendv2 "github.com/grafana/mimir/pkg/frontend/v2"
@@ -39,9 +41,11 @@ import (
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/util/grpcreflection"
 	"github.com/grafana/mimir/pkg/util/gziphandler"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
 	"github.com/grafana/mimir/pkg/util/validation/exporter"
 )
 
@@ -53,6 +57,8 @@ type Config struct {
 	AlertmanagerHTTPPrefix string `yaml:"alertmanager_http_prefix" category:"advanced"`
 	PrometheusHTTPPrefix   string `yaml:"prometheus_http_prefix" category:"advanced"`
 
+	GRPCReflectionEnabled bool `yaml:"grpc_reflection_enabled" category:"experimental"`
+
 	// The following configs are injected by the upstream caller.
 	ServerPrefix       string               `yaml:"-"`
 	HTTPAuthMiddleware middleware.Interface `yaml:"-"`
@@ -67,6 +73,7 @@ type Config struct {
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.SkipLabelNameValidationHeader, "api.skip-label-name-validation-header-enabled", false, "Allows to skip label name validation via X-Mimir-SkipLabelNameValidation header on the http write path. Use with caution as it breaks PromQL. Allowing this for external clients allows any client to send invalid label names. After enabling it, requests with a specific HTTP header set to true will not have label names validated.")
+	f.BoolVar(&cfg.GRPCReflectionEnabled, "api.grpc-reflection-enabled", false, "True to register the gRPC server reflection service, which allows tools like grpcurl to list and call the gRPC services without their .proto files.")
 	cfg.RegisterFlagsWithPrefix("", f)
 }
 
@@ -114,6 +121,10 @@ func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logge
 		api.AuthMiddleware = middleware.AuthenticateUser
 	}
 
+	if cfg.GRPCReflectionEnabled {
+		grpcreflection.Register(s.GRPC)
+	}
+
 	return api, nil
 }
 
@@ -194,6 +205,7 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, api
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.GetUserConfig), true, true, "GET")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.SetUserConfig), true, true, "POST")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.DeleteUserConfig), true, true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/test_routing", http.HandlerFunc(am.TestRouting), true, true, "POST")
 	}
 }
 
@@ -224,22 +236,50 @@ func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc, userL
 	a.RegisterRoute("/api/v1/user_limits", userLimitsHandler, true, true, "GET")
 }
 
+// RegisterEffectiveLimits registers the endpoint returning the limits applied to the tenant.
+func (a *API) RegisterEffectiveLimits(effectiveLimitsHandler http.HandlerFunc) {
+	a.RegisterRoute("/api/v1/effective_limits", effectiveLimitsHandler, true, true, "GET")
+}
+
 // RegisterDistributor registers the endpoints associated with the distributor.
-func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config, reg prometheus.Registerer) {
+func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config, limits *validation.Overrides, reg prometheus.Registerer) {
 	distributorpb.RegisterDistributorServer(a.server.GRPC, d)
 
-	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, d.PushWithMiddlewares), true, false, "POST")
-	a.RegisterRoute("/otlp/v1/metrics", push.OTLPHandler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, reg, d.PushWithMiddlewares), true, false, "POST")
+	pushHandler := push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, d.PushWithMiddlewares)
+	if pushConfig.MaxOversizedRecvMsgSize > 0 {
+		pushHandler = push.SplittingHandler(pushConfig.MaxRecvMsgSize, pushConfig.MaxOversizedRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, d.PushWithMiddlewares)
+	}
+
+	registerPushRoute := func(path string, handler http.Handler) {
+		if d.ClientCertificateTenants == nil {
+			a.RegisterRoute(path, handler, true, false, "POST")
+			return
+		}
+		// The tenant is set from the client certificate before the authentication middleware reads it.
+		a.RegisterRoute(path, d.ClientCertificateTenants.Wrap(a.AuthMiddleware.Wrap(handler)), false, false, "POST")
+	}
+
+	registerPushRoute("/api/v1/push", pushHandler)
+	registerPushRoute("/api/v1/push/influx-style-dry-run", d.PushDryRunHandler(pushConfig.MaxRecvMsgSize, a.cfg.SkipLabelNameValidationHeader))
+	registerPushRoute("/api/v1/push/influx", push.InfluxHandler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, limits, reg, d.PushWithMiddlewares))
+	registerPushRoute("/otlp/v1/metrics", push.OTLPHandler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, reg, d.PushWithMiddlewares))
 
 	a.indexPage.AddLinks(defaultWeight, "Distributor", []IndexPageLink{
 		{Desc: "Ring status", Path: "/distributor/ring"},
+		{Desc: "Ring rebalancing recommendations", Path: "/distributor/ring/rebalancing"},
 		{Desc: "Usage statistics", Path: "/distributor/all_user_stats"},
 		{Desc: "HA tracker status", Path: "/distributor/ha_tracker"},
 	})
 
 	a.RegisterRoute("/distributor/ring", d, false, true, "GET", "POST")
+	a.RegisterRoute("/distributor/ring/rebalancing", http.HandlerFunc(d.RingRebalancingHandler), false, true, "GET")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, true, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, true, "GET")
+	a.RegisterRoute("/distributor/ha_tracker/elect", http.HandlerFunc(d.HATracker.ElectReplicaHandler), false, true, "POST")
+	a.RegisterRoute("/distributor/circuit_breaker/reset", http.HandlerFunc(d.CircuitBreakerResetHandler), false, true, "POST")
+	a.RegisterRoute("/distributor/top_metrics", http.HandlerFunc(d.TopMetricsHandler), false, true, "GET")
+	a.RegisterRoute("/distributor/discarded_samples", http.HandlerFunc(d.DiscardedSamplesHandler), true, true, "GET")
+	a.RegisterRoute("/distributor/series_sharding", http.HandlerFunc(d.SeriesShardingPreviewHandler), false, true, "GET")
 }
 
 // Ingester is defined as an interface to allow for alternative implementations
@@ -249,8 +289,10 @@ type Ingester interface {
 	FlushHandler(http.ResponseWriter, *http.Request)
 	ShutdownHandler(http.ResponseWriter, *http.Request)
 	PrepareShutdownHandler(http.ResponseWriter, *http.Request)
+	InstanceLimitsHandler(http.ResponseWriter, *http.Request)
 	PushWithCleanup(context.Context, *push.Request) (*mimirpb.WriteResponse, error)
 	UserRegistryHandler(http.ResponseWriter, *http.Request)
+	DiscardedSamplesHandler(http.ResponseWriter, *http.Request)
 }
 
 // RegisterIngester registers the ingester HTTP and gRPC services.
@@ -265,8 +307,10 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config) {
 	a.RegisterRoute("/ingester/flush", http.HandlerFunc(i.FlushHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/ingester/prepare-shutdown", http.HandlerFunc(i.PrepareShutdownHandler), false, true, "GET", "POST", "DELETE")
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/ingester/instance-limits", http.HandlerFunc(i.InstanceLimitsHandler), false, true, "GET", "POST", "DELETE")
 	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, i.PushWithCleanup), true, false, "POST") // For testing and debugging.
 	a.RegisterRoute("/ingester/tsdb_metrics", http.HandlerFunc(i.UserRegistryHandler), true, true, "GET")
+	a.RegisterRoute("/ingester/discarded_samples", http.HandlerFunc(i.DiscardedSamplesHandler), true, true, "GET")
 }
 
 // RegisterRuler registers routes associated with the Ruler service.
@@ -285,6 +329,11 @@ func (a *API) RegisterRuler(r *ruler.Ruler) {
 	ruler.RegisterRulerServer(a.server.GRPC, r)
 }
 
+// RegisterRulerNotificationsDeadLetter registers the endpoints associated with the ruler notifications dead-letter.
+func (a *API) RegisterRulerNotificationsDeadLetter(m *ruler.DefaultMultiTenantManager) {
+	a.RegisterRoute("/ruler/notifications_dead_letter", http.HandlerFunc(m.NotificationsDeadLetterHandler), true, true, "GET", "POST")
+}
+
 // RegisterRulerAPI registers routes associated with the Ruler API
 func (a *API) RegisterRulerAPI(r *ruler.API, configAPIEnabled bool, buildInfoHandler http.Handler) {
 	// Prometheus Rule API Routes
@@ -292,6 +341,7 @@ func (a *API) RegisterRulerAPI(r *ruler.API, configAPIEnabled bool, buildInfoHan
 	// you would like the API to be disabled and still be able to understand in what state rule evaluations are.
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/rules"), http.HandlerFunc(r.PrometheusRules), true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/alerts"), http.HandlerFunc(r.PrometheusAlerts), true, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/alerts/history"), http.HandlerFunc(r.PrometheusAlertsHistory), true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/buildinfo"), buildInfoHandler, false, true, "GET")
 
 	if configAPIEnabled {
@@ -302,15 +352,23 @@ func (a *API) RegisterRulerAPI(r *ruler.API, configAPIEnabled bool, buildInfoHan
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.CreateRuleGroup), true, true, "POST")
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}"), http.HandlerFunc(r.DeleteRuleGroup), true, true, "DELETE")
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.DeleteNamespace), true, true, "DELETE")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}/versions"), http.HandlerFunc(r.ListRuleGroupVersions), true, true, "GET")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}/versions/{version}"), http.HandlerFunc(r.GetRuleGroupVersion), true, true, "GET")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}/versions/{version}/rollback"), http.HandlerFunc(r.RollbackRuleGroup), true, true, "POST")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/namespace-defaults/{namespace}"), http.HandlerFunc(r.GetNamespaceDefaults), true, true, "GET")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/namespace-defaults/{namespace}"), http.HandlerFunc(r.SetNamespaceDefaults), true, true, "POST")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/namespace-defaults/{namespace}"), http.HandlerFunc(r.DeleteNamespaceDefaults), true, true, "DELETE")
 	}
 }
 
-// RegisterRing registers the ring UI page associated with the distributor for writes.
-func (a *API) RegisterRing(r http.Handler) {
+// RegisterRing registers the ring UI page and the ring rebalancing recommendations associated with the distributor for writes.
+func (a *API) RegisterRing(r http.Handler, rebalancing http.Handler) {
 	a.indexPage.AddLinks(defaultWeight, "Ingester", []IndexPageLink{
 		{Desc: "Ring status", Path: "/ingester/ring"},
+		{Desc: "Ring rebalancing recommendations", Path: "/ingester/ring/rebalancing"},
 	})
 	a.RegisterRoute("/ingester/ring", r, false, true, "GET", "POST")
+	a.RegisterRoute("/ingester/ring/rebalancing", rebalancing, false, true, "GET")
 }
 
 // RegisterStoreGateway registers the ring UI page associated with the store-gateway.
@@ -319,9 +377,11 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 
 	a.indexPage.AddLinks(defaultWeight, "Store-gateway", []IndexPageLink{
 		{Desc: "Ring status", Path: "/store-gateway/ring"},
+		{Desc: "Ring rebalancing recommendations", Path: "/store-gateway/ring/rebalancing"},
 		{Desc: "Tenants & Blocks", Path: "/store-gateway/tenants"},
 	})
 	a.RegisterRoute("/store-gateway/ring", http.HandlerFunc(s.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/store-gateway/ring/rebalancing", http.HandlerFunc(s.RingRebalancingHandler), false, true, "GET")
 	a.RegisterRoute("/store-gateway/tenants", http.HandlerFunc(s.TenantsHandler), false, true, "GET")
 	a.RegisterRoute("/store-gateway/tenant/{tenant}/blocks", http.HandlerFunc(s.BlocksHandler), false, true, "GET")
 	a.RegisterRoute("/store-gateway/prepare-shutdown", http.HandlerFunc(s.PrepareShutdownHandler), false, true, "GET", "POST", "DELETE")
@@ -331,8 +391,10 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 	a.indexPage.AddLinks(defaultWeight, "Compactor", []IndexPageLink{
 		{Desc: "Ring status", Path: "/compactor/ring"},
+		{Desc: "Lagging tenants", Path: "/compactor/lagging_tenants"},
 	})
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/compactor/lagging_tenants", http.HandlerFunc(c.LaggingTenantsHandler), false, true, "GET")
 	a.RegisterRoute("/api/v1/upload/block/{block}/start", http.HandlerFunc(c.StartBlockUpload), true, false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/files", a.DisableServerHTTPTimeouts(http.HandlerFunc(c.UploadBlockFile)), true, false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/finish", http.HandlerFunc(c.FinishBlockUpload), true, false, http.MethodPost)
@@ -362,6 +424,7 @@ func (a *API) DisableServerHTTPTimeouts(next http.Handler) http.Handler {
 type Distributor interface {
 	querier.Distributor
 	UserStatsHandler(w http.ResponseWriter, r *http.Request)
+	MetadataUsageHandler(w http.ResponseWriter, r *http.Request)
 }
 
 // RegisterQueryable registers the default routes associated with the querier
@@ -369,6 +432,7 @@ type Distributor interface {
 func (a *API) RegisterQueryable(distributor Distributor) {
 	// these routes are always registered to the default server
 	a.RegisterRoute("/api/v1/user_stats", http.HandlerFunc(distributor.UserStatsHandler), true, true, "GET")
+	a.RegisterRoute("/api/v1/metadata_usage", http.HandlerFunc(distributor.MetadataUsageHandler), true, true, "GET")
 }
 
 // RegisterQueryAPI registers the Prometheus API routes with the provided handler.
@@ -379,11 +443,14 @@ func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handl
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_exemplars"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/labels"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), handler, true, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/paginated/labels"), handler, true, true, "GET", "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/paginated/label/{name}/values"), handler, true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), handler, true, true, "GET", "POST", "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/buildinfo"), buildInfoHandler, false, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), handler, true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_names"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_values"), handler, true, true, "GET", "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/active_series"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/format_query"), handler, true, true, "GET", "POST")
 }
 
@@ -394,6 +461,50 @@ func (a *API) RegisterQueryFrontendHandler(h http.Handler, buildInfoHandler http
 	a.RegisterQueryAPI(h, buildInfoHandler)
 }
 
+// RegisterQueryFrontendFederate registers the Prometheus /federate endpoint served by the query-frontend.
+func (a *API) RegisterQueryFrontendFederate(h http.Handler) {
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/federate"), h, true, true, "GET")
+}
+
+// RegisterQueryFrontendPassthrough registers the handler of the GET and POST requests to the Prometheus HTTP API
+// paths not matched by any other route, which the query-frontend proxies to the tenant's passthrough URL. The
+// requests to the other paths keep being served by the previous not found handler.
+func (a *API) RegisterQueryFrontendPassthrough(h http.Handler) {
+	prefix := path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1") + "/"
+	h = gziphandler.GzipHandler(a.AuthMiddleware.Wrap(h))
+
+	notFound := a.server.HTTP.NotFoundHandler
+	if notFound == nil {
+		notFound = http.NotFoundHandler()
+	}
+
+	a.server.HTTP.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isPassthroughRequest(r, prefix) {
+			notFound.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isPassthroughRequest returns whether the request not matched by any route is proxied to the passthrough URL.
+func isPassthroughRequest(r *http.Request, prefix string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, prefix)
+}
+
+// RegisterQueryRecorder registers the endpoints associated with the query-frontend query recordings.
+func (a *API) RegisterQueryRecorder(r *queryrecorder.Recorder) {
+	a.RegisterRoute("/api/v1/query_recordings", r, true, true, "GET", "POST")
+}
+
+// RegisterHeavyQueriesTracker registers the endpoints associated with the query-frontend heavy queries tracking.
+func (a *API) RegisterHeavyQueriesTracker(t *heavyqueries.Tracker) {
+	a.RegisterRoute("/api/v1/heavy_queries", t, true, true, "GET")
+}
+
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
 	frontendv1pb.RegisterFrontendServer(a.server.GRPC, f)
 }
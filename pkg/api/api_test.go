@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 
@@ -186,6 +187,45 @@ func TestApiGzip(t *testing.T) {
 	})
 }
 
+func TestApiQueryFrontendPassthrough(t *testing.T) {
+	cfg := Config{PrometheusHTTPPrefix: "/prometheus"}
+	s := server.Server{HTTP: mux.NewRouter()}
+	s.HTTP.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	api, err := New(cfg, server.Config{}, &s, log.NewNopLogger())
+	require.NoError(t, err)
+
+	api.RegisterRoute("/prometheus/api/v1/query", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), true, true, http.MethodGet)
+	api.RegisterQueryFrontendPassthrough(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	for name, tc := range map[string]struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		"matched route":                         {method: http.MethodGet, path: "/prometheus/api/v1/query", expectedStatus: http.StatusOK},
+		"unsupported API path":                  {method: http.MethodGet, path: "/prometheus/api/v1/targets", expectedStatus: http.StatusAccepted},
+		"unsupported API path with POST":        {method: http.MethodPost, path: "/prometheus/api/v1/targets", expectedStatus: http.StatusAccepted},
+		"unsupported API path with DELETE":      {method: http.MethodDelete, path: "/prometheus/api/v1/targets", expectedStatus: http.StatusTeapot},
+		"path outside of the API":               {method: http.MethodGet, path: "/unknown", expectedStatus: http.StatusTeapot},
+		"path outside of the Prometheus prefix": {method: http.MethodGet, path: "/api/v1/targets", expectedStatus: http.StatusTeapot},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("X-Scope-OrgID", "user-1")
+			w := httptest.NewRecorder()
+			s.HTTP.ServeHTTP(w, req)
+			require.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
 // Generates server config, with gRPC listening on random port.
 func getServerConfig(t *testing.T) server.Config {
 	grpcHost, grpcPortNum := getHostnameAndRandomPort(t)
@@ -29,6 +29,7 @@ const (
 	TypeTooManyRequests Type = "too_many_requests"
 	TypeTooLargeEntry   Type = "too_large_entry"
 	TypeNotAcceptable   Type = "not_acceptable"
+	TypeForbidden       Type = "forbidden"
 )
 
 type apiError struct {
@@ -61,6 +62,8 @@ func (e *apiError) statusCode() int {
 		return http.StatusRequestEntityTooLarge
 	case TypeNotAcceptable:
 		return http.StatusNotAcceptable
+	case TypeForbidden:
+		return http.StatusForbidden
 	}
 	return http.StatusInternalServerError
 }
@@ -116,15 +119,17 @@ func IsAPIError(err error) bool {
 	return errors.As(err, &apiErr)
 }
 
-// IsNonRetryableAPIError returns true if err is an apiError which should be failed and not retried.
-func IsNonRetryableAPIError(err error) bool {
+// TypeOf returns the type of the apiError wrapped by err, or TypeNone if err is not an apiError.
+func TypeOf(err error) Type {
 	apiErr := &apiError{}
-	// Reasoning:
+	// Reasoning for the types retried by the query-frontend by default:
 	// TypeNone, TypeUnavailable and TypeNotFound are not used anywhere in Mimir or Prometheus;
 	// TypeTimeout, TypeTooManyRequests, TypeNotAcceptable we presume a retry of the same request will fail in the same way.
 	// TypeCanceled means something wants us to stop.
 	// TypeExec, TypeBadData and TypeTooLargeEntry are caused by the input data.
 	// TypeInternal can be a 500 error e.g. from querier failing to contact storegateway.
-
-	return errors.As(err, &apiErr) && apiErr.Type != TypeInternal
+	if !errors.As(err, &apiErr) {
+		return TypeNone
+	}
+	return apiErr.Type
 }
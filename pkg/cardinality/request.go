@@ -97,6 +97,10 @@ type LabelValuesRequest struct {
 	Matchers    []*labels.Matcher
 	CountMethod CountMethod
 	Limit       int
+
+	// Approximate is true if the series count of each label value can be estimated
+	// from a sample of the series, trading exactness for a lower query cost.
+	Approximate bool
 }
 
 // Strings returns a full representation of the request. The returned string can be
@@ -129,6 +133,12 @@ func (r *LabelValuesRequest) String() string {
 	b.WriteRune(stringParamSeparator)
 	b.WriteString(strconv.Itoa(r.Limit))
 
+	// Add approximate. It's only added when enabled, to keep the representation of exact requests unchanged.
+	if r.Approximate {
+		b.WriteRune(stringParamSeparator)
+		b.WriteString("approximate")
+	}
+
 	return b.String()
 }
 
@@ -168,6 +178,38 @@ func DecodeLabelValuesRequest(r *http.Request) (*LabelValuesRequest, error) {
 		return nil, err
 	}
 
+	parsed.Approximate, err = extractApproximate(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+type ActiveSeriesRequest struct {
+	Matchers []*labels.Matcher
+}
+
+// DecodeActiveSeriesRequest decodes the input http.Request into an ActiveSeriesRequest.
+// The input http.Request can either be a GET or POST with URL-encoded parameters.
+func DecodeActiveSeriesRequest(r *http.Request) (*ActiveSeriesRequest, error) {
+	var (
+		parsed = &ActiveSeriesRequest{}
+		err    error
+	)
+
+	if err = r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	parsed.Matchers, err = extractSelector(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Matchers) == 0 {
+		return nil, fmt.Errorf("missing 'selector' param")
+	}
+
 	return parsed, nil
 }
 
@@ -258,3 +300,19 @@ func extractCountMethod(r *http.Request) (countMethod CountMethod, err error) {
 		return "", fmt.Errorf("invalid 'count_method' param '%v'. valid options are: [%s]", countMethodParams[0], strings.Join([]string{string(ActiveMethod), string(InMemoryMethod)}, ","))
 	}
 }
+
+// extractApproximate parses and validates request param `approximate` if it's defined, otherwise returns false.
+func extractApproximate(r *http.Request) (bool, error) {
+	approximateParams := r.Form["approximate"]
+	if len(approximateParams) == 0 {
+		return false, nil
+	}
+	if len(approximateParams) > 1 {
+		return false, fmt.Errorf("multiple 'approximate' params are not allowed")
+	}
+	approximate, err := strconv.ParseBool(approximateParams[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid 'approximate' param '%v'", approximateParams[0])
+	}
+	return approximate, nil
+}
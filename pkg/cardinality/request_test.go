@@ -71,6 +71,7 @@ func TestDecodeLabelValuesRequest(t *testing.T) {
 			"label_names[]": []string{"metric_2", "metric_1"},
 			"count_method":  []string{"active"},
 			"limit":         []string{"100"},
+			"approximate":   []string{"true"},
 		}.Encode()
 
 		expected = &LabelValuesRequest{
@@ -81,6 +82,7 @@ func TestDecodeLabelValuesRequest(t *testing.T) {
 			},
 			CountMethod: ActiveMethod,
 			Limit:       100,
+			Approximate: true,
 		}
 	)
 
@@ -105,6 +107,14 @@ func TestDecodeLabelValuesRequest(t *testing.T) {
 
 		assert.Equal(t, expected, actual)
 	})
+
+	t.Run("invalid approximate param", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://localhost?label_names[]=metric_1&approximate=maybe", nil)
+		require.NoError(t, err)
+
+		_, err = DecodeLabelValuesRequest(req)
+		require.EqualError(t, err, "invalid 'approximate' param 'maybe'")
+	})
 }
 
 func TestLabelValuesRequest_String(t *testing.T) {
@@ -116,7 +126,35 @@ func TestLabelValuesRequest_String(t *testing.T) {
 		},
 		CountMethod: ActiveMethod,
 		Limit:       100,
+		Approximate: true,
 	}
 
+	assert.Equal(t, "foo\x01bar\x00first=\"1\"\x01second!=\"2\"\x00active\x00100\x00approximate", req.String())
+
+	req.Approximate = false
 	assert.Equal(t, "foo\x01bar\x00first=\"1\"\x01second!=\"2\"\x00active\x00100", req.String())
 }
+
+func TestDecodeActiveSeriesRequest(t *testing.T) {
+	t.Run("valid selector", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://localhost?"+url.Values{"selector": []string{`{second!="2",first="1"}`}}.Encode(), nil)
+		require.NoError(t, err)
+
+		actual, err := DecodeActiveSeriesRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, &ActiveSeriesRequest{
+			Matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "first", "1"),
+				labels.MustNewMatcher(labels.MatchNotEqual, "second", "2"),
+			},
+		}, actual)
+	})
+
+	t.Run("missing selector", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://localhost", nil)
+		require.NoError(t, err)
+
+		_, err = DecodeActiveSeriesRequest(req)
+		require.EqualError(t, err, "missing 'selector' param")
+	})
+}
@@ -546,6 +546,10 @@ func (c *MultitenantCompactor) validateBlock(ctx context.Context, logger log.Log
 		}
 	}
 
+	if err := block.VerifyFileDigests(blockDir, blockMetadata.Thanos.Files); err != nil {
+		return err
+	}
+
 	// validate block
 	checkChunks := c.cfgProvider.CompactorBlockUploadVerifyChunks(userID)
 	err = block.VerifyBlock(c.logger, blockDir, blockMetadata.MinTime, blockMetadata.MaxTime, checkChunks)
@@ -1605,6 +1605,12 @@ func TestMultitenantCompactor_ValidateBlock(t *testing.T) {
 		{
 			name: "segment file invalid checksum",
 			lbls: validLabels,
+			metaInject: func(meta *block.Meta) {
+				// Remove the file digests, so that the corruption is detected by the chunks verification.
+				for i := range meta.Thanos.Files {
+					meta.Thanos.Files[i].CRC32C = ""
+				}
+			},
 			chunkInject: func(fname string) {
 				flipByteAt(t, fname, 12) // guaranteed to be a data byte
 			},
@@ -1613,6 +1619,16 @@ func TestMultitenantCompactor_ValidateBlock(t *testing.T) {
 			expectError:      true,
 			expectedMsg:      "checksum mismatch",
 		},
+		{
+			name: "segment file digest mismatch",
+			lbls: validLabels,
+			chunkInject: func(fname string) {
+				flipByteAt(t, fname, 12) // guaranteed to be a data byte
+			},
+			populateFileList: true,
+			expectError:      true,
+			expectedMsg:      "chunks/000001: expected crc32c",
+		},
 		{
 			name: "empty segment file",
 			lbls: validLabels,
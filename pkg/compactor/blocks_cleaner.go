@@ -8,6 +8,7 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
@@ -34,6 +36,10 @@ import (
 
 const (
 	defaultDeleteBlocksConcurrency = 16
+
+	// Global markers of blocks which don't exist in the storage are deleted only once they're older than
+	// this period, to avoid racing with blocks uploaded after the bucket index has been updated.
+	staleGlobalMarkerMinAge = time.Hour
 )
 
 type BlocksCleanerConfig struct {
@@ -67,9 +73,12 @@ type BlocksCleaner struct {
 	blocksFailedTotal              prometheus.Counter
 	blocksMarkedForDeletion        prometheus.Counter
 	partialBlocksMarkedForDeletion prometheus.Counter
+	staleGlobalMarkersDeleted      prometheus.Counter
 	tenantBlocks                   *prometheus.GaugeVec
 	tenantMarkedBlocks             *prometheus.GaugeVec
 	tenantPartialBlocks            *prometheus.GaugeVec
+	tenantStaleGlobalMarkers       *prometheus.GaugeVec
+	tenantQuarantinedBlocks        *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate    *prometheus.GaugeVec
 }
 
@@ -116,6 +125,10 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "partial"},
 		}),
+		staleGlobalMarkersDeleted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_stale_global_markers_deleted_total",
+			Help: "Total number of global markers deleted because the block they refer to doesn't exist anymore.",
+		}),
 
 		// The following metrics don't have the "cortex_compactor" prefix because not strictly related to
 		// the compactor. They're just tracked by the compactor because it's the most logical place where these
@@ -132,6 +145,14 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Name: "cortex_bucket_blocks_partials_count",
 			Help: "Total number of partial blocks.",
 		}, []string{"user"}),
+		tenantStaleGlobalMarkers: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_stale_global_markers_count",
+			Help: "Total number of global markers referring to blocks which don't exist in the bucket, and haven't been deleted yet.",
+		}, []string{"user"}),
+		tenantQuarantinedBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_blocks_quarantined_count",
+			Help: "Total number of blocks in the quarantine location of the bucket, including the partially quarantined ones.",
+		}, []string{"user"}),
 		tenantBucketIndexLastUpdate: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_bucket_index_last_successful_update_timestamp_seconds",
 			Help: "Timestamp of the last successful update of a tenant's bucket index.",
@@ -229,6 +250,8 @@ func (c *BlocksCleaner) refreshOwnedUsers(ctx context.Context) ([]string, map[st
 			c.tenantBlocks.DeleteLabelValues(userID)
 			c.tenantMarkedBlocks.DeleteLabelValues(userID)
 			c.tenantPartialBlocks.DeleteLabelValues(userID)
+			c.tenantStaleGlobalMarkers.DeleteLabelValues(userID)
+			c.tenantQuarantinedBlocks.DeleteLabelValues(userID)
 			c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 		}
 	}
@@ -302,6 +325,7 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 		c.tenantBlocks.WithLabelValues(userID).Set(float64(failed))
 		c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(failed))
 		c.tenantPartialBlocks.WithLabelValues(userID).Set(0)
+		c.tenantStaleGlobalMarkers.WithLabelValues(userID).Set(0)
 
 		return errors.Errorf("failed to delete %d blocks", failed)
 	}
@@ -310,6 +334,8 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	c.tenantBlocks.DeleteLabelValues(userID)
 	c.tenantMarkedBlocks.DeleteLabelValues(userID)
 	c.tenantPartialBlocks.DeleteLabelValues(userID)
+	c.tenantStaleGlobalMarkers.DeleteLabelValues(userID)
+	c.tenantQuarantinedBlocks.DeleteLabelValues(userID)
 
 	if deletedBlocks > 0 {
 		level.Info(userLogger).Log("msg", "deleted blocks for tenant marked for deletion", "deletedBlocks", deletedBlocks)
@@ -345,6 +371,12 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 		level.Info(userLogger).Log("msg", "deleted files under "+block.DebugMetas+" for tenant marked for deletion", "count", deleted)
 	}
 
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, block.QuarantinePathname, userLogger); err != nil {
+		return errors.Wrap(err, "failed to delete quarantined blocks")
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted quarantined blocks files for tenant marked for deletion", "count", deleted)
+	}
+
 	// Tenant deletion mark file is inside Markers as well.
 	if deleted, err := bucket.DeletePrefix(ctx, userBucket, block.MarkersPathname, userLogger); err != nil {
 		return errors.Wrap(err, "failed to delete marker files")
@@ -413,6 +445,23 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 		level.Info(userLogger).Log("msg", "cleaned up partial blocks", "partials", len(partials))
 	}
 
+	// Global markers are expected to be deleted together with their block, but they're leaked if the
+	// deletion fails halfway or the block is deleted by other means. This is a best effort, so we don't
+	// return error if the cleanup of stale global markers fails.
+	staleMarkers, err := c.cleanUserStaleGlobalMarkers(ctx, idx, partials, userBucket, userLogger)
+	if err != nil {
+		level.Warn(userLogger).Log("msg", "failed to clean up stale global markers", "err", err)
+	}
+
+	// Quarantined blocks are kept for investigation, so they're only counted, and never deleted unless the
+	// tenant is deleted.
+	quarantined, err := block.ListQuarantinedBlocks(ctx, userBucket)
+	if err != nil {
+		level.Warn(userLogger).Log("msg", "failed to list quarantined blocks", "err", err)
+	} else {
+		c.tenantQuarantinedBlocks.WithLabelValues(userID).Set(float64(len(quarantined)))
+	}
+
 	// Upload the updated index to the storage.
 	if err := bucketindex.WriteIndex(ctx, c.bucketClient, userID, c.cfgProvider, idx); err != nil {
 		return err
@@ -421,6 +470,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 	c.tenantBlocks.WithLabelValues(userID).Set(float64(len(idx.Blocks)))
 	c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(len(idx.BlockDeletionMarks)))
 	c.tenantPartialBlocks.WithLabelValues(userID).Set(float64(len(partials)))
+	c.tenantStaleGlobalMarkers.WithLabelValues(userID).Set(float64(staleMarkers))
 	c.tenantBucketIndexLastUpdate.WithLabelValues(userID).SetToCurrentTime()
 
 	return nil
@@ -482,8 +532,19 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 	_ = concurrency.ForEachJob(ctx, len(blocks), c.cfg.DeleteBlocksConcurrency, func(ctx context.Context, jobIdx int) error {
 		blockID := blocks[jobIdx]
 
-		// We can safely delete only partial blocks with a deletion mark.
+		// We can safely delete only partial blocks with a deletion mark, or which have been quarantined,
+		// given the quarantined copy is kept.
 		err := block.ReadMarker(ctx, userLogger, userBucket, blockID.String(), &block.DeletionMark{})
+		if errors.Is(err, block.ErrorMarkerNotFound) {
+			quarantined, qErr := block.IsQuarantined(ctx, userBucket, blockID)
+			if qErr != nil {
+				level.Warn(userLogger).Log("msg", "error checking whether partial block has been quarantined", "block", blockID, "err", qErr)
+				return nil
+			}
+			if quarantined {
+				err = nil
+			}
+		}
 		if errors.Is(err, block.ErrorMarkerNotFound) {
 			mu.Lock()
 			partialBlocksWithoutDeletionMarker = append(partialBlocksWithoutDeletionMarker, blockID)
@@ -495,7 +556,7 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 			return nil
 		}
 
-		// Hard-delete partial blocks having a deletion mark, even if the deletion threshold has not
+		// Hard-delete partial blocks having a deletion mark or quarantined, even if the deletion threshold has not
 		// been reached yet.
 		if err := block.Delete(ctx, userLogger, userBucket, blockID); err != nil {
 			c.blocksFailedTotal.Inc()
@@ -532,6 +593,84 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 	}
 }
 
+// cleanUserStaleGlobalMarkers deletes the global markers (deletion and no-compact marks) of blocks which
+// don't exist in the storage anymore, and removes their deletion marks from the bucket index too.
+// A block exists if it's either in the bucket index or partial. Returns the number of stale global
+// markers left in the storage, either because they're too recent or couldn't be deleted.
+func (c *BlocksCleaner) cleanUserStaleGlobalMarkers(ctx context.Context, idx *bucketindex.Index, partials map[ulid.ULID]error, userBucket objstore.InstrumentedBucket, userLogger log.Logger) (int, error) {
+	existing := make(map[ulid.ULID]struct{}, len(idx.Blocks)+len(partials))
+	for _, b := range idx.Blocks {
+		existing[b.ID] = struct{}{}
+	}
+	for blockID := range partials {
+		existing[blockID] = struct{}{}
+	}
+
+	var staleMarkers []string
+	var staleBlocks []ulid.ULID
+
+	err := userBucket.Iter(ctx, block.MarkersPathname+"/", func(name string) error {
+		blockID, ok := block.IsDeletionMarkFilename(path.Base(name))
+		if !ok {
+			blockID, ok = block.IsNoCompactMarkFilename(path.Base(name))
+		}
+		if !ok {
+			// Not a block marker (eg. the tenant deletion mark).
+			return nil
+		}
+
+		if _, ok := existing[blockID]; !ok {
+			staleMarkers = append(staleMarkers, name)
+			staleBlocks = append(staleBlocks, blockID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "list global markers")
+	}
+
+	if len(staleMarkers) == 0 {
+		return 0, nil
+	}
+
+	level.Warn(userLogger).Log("msg", "found global markers referring to blocks which don't exist in the storage", "count", len(staleMarkers))
+
+	var mu sync.Mutex
+	var remaining atomic.Int64
+	minLastModified := time.Now().Add(-staleGlobalMarkerMinAge)
+
+	// We don't want to return errors from our function, as that would stop ForEach loop early.
+	_ = concurrency.ForEachJob(ctx, len(staleMarkers), c.cfg.DeleteBlocksConcurrency, func(ctx context.Context, jobIdx int) error {
+		name := staleMarkers[jobIdx]
+		blockID := staleBlocks[jobIdx]
+
+		attrs, err := userBucket.ReaderWithExpectedErrs(userBucket.IsObjNotFoundErr).Attributes(ctx, name)
+		if err == nil && attrs.LastModified.After(minLastModified) {
+			remaining.Inc()
+			return nil
+		}
+		if err == nil {
+			err = userBucket.Delete(ctx, name)
+		}
+		if err != nil && !userBucket.IsObjNotFoundErr(err) {
+			remaining.Inc()
+			level.Warn(userLogger).Log("msg", "failed to delete stale global marker", "block", blockID, "marker", name, "err", err)
+			return nil
+		}
+
+		// Remove the block deletion mark from the bucket index too.
+		mu.Lock()
+		idx.RemoveBlock(blockID)
+		mu.Unlock()
+
+		c.staleGlobalMarkersDeleted.Inc()
+		level.Info(userLogger).Log("msg", "deleted stale global marker", "block", blockID, "marker", name)
+		return nil
+	})
+
+	return int(remaining.Load()), nil
+}
+
 // applyUserRetentionPeriod marks blocks for deletion which have aged past the retention period.
 func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
 	// The retention period of zero is a special value indicating to never delete.
@@ -334,6 +334,74 @@ func TestBlocksCleaner_ShouldRebuildBucketIndexOnCorruptedOne(t *testing.T) {
 	assert.ElementsMatch(t, []ulid.ULID{block3}, idx.BlockDeletionMarks.GetULIDs())
 }
 
+func TestBlocksCleaner_ShouldDeleteStaleGlobalMarkers(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, storageDir := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	now := time.Now()
+	deletionDelay := 12 * time.Hour
+
+	// Create a block with a no-compact mark, which should be preserved.
+	block1 := createTSDBBlock(t, bucketClient, userID, 10, 20, 2, nil)
+	require.NoError(t, block.MarkForNoCompact(ctx, log.NewNopLogger(), bucket.NewUserBucketClient(userID, bucketClient, nil), block1, block.ManualNoCompactReason, "", prometheus.NewCounter(prometheus.CounterOpts{})))
+
+	// Create global markers of blocks which don't exist in the storage.
+	staleDeletionMark := ulid.MustNew(1, nil)
+	staleNoCompactMark := ulid.MustNew(2, nil)
+	recentDeletionMark := ulid.MustNew(3, nil)
+	for _, markPath := range []string{
+		block.DeletionMarkFilepath(staleDeletionMark),
+		block.NoCompactMarkFilepath(staleNoCompactMark),
+		block.DeletionMarkFilepath(recentDeletionMark),
+	} {
+		require.NoError(t, bucketClient.Upload(ctx, path.Join(userID, markPath), strings.NewReader(mockDeletionMarkJSON(staleDeletionMark.String(), now))))
+	}
+
+	oldTime := now.Add(-2 * staleGlobalMarkerMinAge)
+	require.NoError(t, os.Chtimes(filepath.Join(storageDir, userID, block.DeletionMarkFilepath(staleDeletionMark)), oldTime, oldTime))
+	require.NoError(t, os.Chtimes(filepath.Join(storageDir, userID, block.NoCompactMarkFilepath(staleNoCompactMark)), oldTime, oldTime))
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           deletionDelay,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, newMockConfigProvider(), log.NewNopLogger(), reg)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, cleaner))
+	defer services.StopAndAwaitTerminated(ctx, cleaner) //nolint:errcheck
+
+	for _, tc := range []struct {
+		path           string
+		expectedExists bool
+	}{
+		{path: path.Join(userID, block1.String(), block.MetaFilename), expectedExists: true},
+		{path: path.Join(userID, block.NoCompactMarkFilepath(block1)), expectedExists: true},
+		{path: path.Join(userID, block.DeletionMarkFilepath(staleDeletionMark)), expectedExists: false},
+		{path: path.Join(userID, block.NoCompactMarkFilepath(staleNoCompactMark)), expectedExists: false},
+		{path: path.Join(userID, block.DeletionMarkFilepath(recentDeletionMark)), expectedExists: true},
+	} {
+		exists, err := bucketClient.Exists(ctx, tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expectedExists, exists, tc.path)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_stale_global_markers_deleted_total Total number of global markers deleted because the block they refer to doesn't exist anymore.
+		# TYPE cortex_compactor_stale_global_markers_deleted_total counter
+		cortex_compactor_stale_global_markers_deleted_total 2
+
+		# HELP cortex_bucket_stale_global_markers_count Total number of global markers referring to blocks which don't exist in the bucket, and haven't been deleted yet.
+		# TYPE cortex_bucket_stale_global_markers_count gauge
+		cortex_bucket_stale_global_markers_count{user="user-1"} 1
+	`), "cortex_compactor_stale_global_markers_deleted_total", "cortex_bucket_stale_global_markers_count"))
+}
+
 func TestBlocksCleaner_ShouldRemoveMetricsForTenantsNotBelongingAnymoreToTheShard(t *testing.T) {
 	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
@@ -908,6 +976,62 @@ func TestBlocksCleaner_ShouldNotRemovePartialBlocksIfConfiguredDelayIsInvalid(t
 	))
 }
 
+func TestBlocksCleaner_ShouldRemovePartialBlocksQuarantined(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ts := func(hours int) int64 {
+		return time.Now().Add(time.Duration(hours)*time.Hour).Unix() * 1000
+	}
+
+	block1 := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), 2, nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+	}
+
+	ctx := context.Background()
+	logger := test.NewTestingLogger(t)
+	reg := prometheus.NewPedanticRegistry()
+	cfgProvider := newMockConfigProvider()
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, reg)
+
+	// Simulate a block whose deletion from its original location failed halfway after being quarantined.
+	markFile := path.Join("user-1", block.QuarantineDir(block1), block.QuarantineMarkFilename)
+	require.NoError(t, bucketClient.Upload(ctx, markFile, strings.NewReader(fmt.Sprintf(`{"id":"%s","version":1,"reason":"upload-verification-failed"}`, block1))))
+	require.NoError(t, bucketClient.Delete(ctx, path.Join("user-1", block1.String(), block.MetaFilename)))
+
+	// Set partial block delay such that block would not be marked for deletion if it wasn't quarantined.
+	cfgProvider.userPartialBlockDelay["user-1"] = 1 * time.Hour
+
+	require.NoError(t, cleaner.cleanUser(ctx, "user-1", logger))
+
+	// The partial block has been deleted, while the quarantined one has been kept.
+	exists, err := bucketClient.Exists(ctx, path.Join("user-1", block1.String(), block.IndexFilename))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = bucketClient.Exists(ctx, markFile)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_blocks_quarantined_count Total number of blocks in the quarantine location of the bucket, including the partially quarantined ones.
+			# TYPE cortex_bucket_blocks_quarantined_count gauge
+			cortex_bucket_blocks_quarantined_count{user="user-1"} 1
+			# HELP cortex_compactor_blocks_cleaned_total Total number of blocks deleted.
+			# TYPE cortex_compactor_blocks_cleaned_total counter
+			cortex_compactor_blocks_cleaned_total 1
+			`),
+		"cortex_bucket_blocks_quarantined_count",
+		"cortex_compactor_blocks_cleaned_total",
+	))
+}
+
 func TestStalePartialBlockLastModifiedTime(t *testing.T) {
 	b, dir := mimir_testutil.PrepareFilesystemBucket(t)
 
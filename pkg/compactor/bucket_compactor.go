@@ -241,7 +241,8 @@ type Compactor interface {
 
 // runCompactionJob plans and runs a single compaction against the provided job. The compacted result
 // is uploaded into the bucket the blocks were retrieved from.
-func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shouldRerun bool, compIDs []ulid.ULID, rerr error) {
+// If shared is not nil, the source blocks are downloaded through it.
+func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job, shared *sharedBlocks) (shouldRerun bool, compIDs []ulid.ULID, rerr error) {
 	jobBeginTime := time.Now()
 
 	jobLogger := log.With(c.logger, "groupKey", job.Key())
@@ -289,7 +290,27 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		// Must be the same as in blocksToCompactDirs.
 		bdir := filepath.Join(subDir, meta.ULID.String())
 
-		if err := block.Download(ctx, jobLogger, c.bkt, meta.ULID, bdir); err != nil {
+		download := func() error { return block.Download(ctx, jobLogger, c.bkt, meta.ULID, bdir) }
+		if shared != nil {
+			download = func() error { return shared.linkBlock(ctx, jobLogger, meta.ULID, bdir) }
+		}
+
+		err := download()
+		if errors.Is(err, block.ErrDigestMismatch) {
+			// The block is downloaded again, so that transient corruptions are recovered.
+			c.metrics.blocksWithDigestMismatch.Inc()
+			level.Warn(jobLogger).Log("msg", "downloaded block doesn't match the digests stored in its meta.json, downloading it again", "block", meta.ULID, "err", err)
+
+			if err = os.RemoveAll(bdir); err == nil {
+				err = download()
+			}
+			if errors.Is(err, block.ErrDigestMismatch) {
+				c.metrics.blocksWithDigestMismatch.Inc()
+				level.Error(jobLogger).Log("msg", "downloaded block doesn't match the digests stored in its meta.json, the block may be corrupted in the object storage", "block", meta.ULID, "err", err)
+				return digestMismatchError(errors.Wrapf(err, "download block %s", meta.ULID), meta.ULID)
+			}
+		}
+		if err != nil {
 			return errors.Wrapf(err, "download block %s", meta.ULID)
 		}
 
@@ -361,6 +382,11 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	uploadBegin := time.Now()
 	uploadedBlocks := atomic.NewInt64(0)
 
+	var (
+		histogramStatsMtx sync.Mutex
+		histogramStats    block.NativeHistogramStats
+	)
+
 	blocksToUpload := convertCompactionResultToForEachJobs(compIDs, job.UseSplitting(), jobLogger)
 	err = concurrency.ForEachJob(ctx, len(blocksToUpload), c.blockSyncConcurrency, func(ctx context.Context, idx int) error {
 		blockToUpload := blocksToUpload[idx]
@@ -394,6 +420,20 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			return errors.Wrapf(err, "invalid result block %s", bdir)
 		}
 
+		// Ensure the native histograms of the output block are valid, since their corruption would otherwise
+		// only be detected at query time.
+		if c.verifyNativeHistograms {
+			stats, err := block.GatherNativeHistogramStats(bdir)
+			if err != nil {
+				c.metrics.blocksWithInvalidNativeHistograms.Inc()
+				return invalidNativeHistogramsError(errors.Wrapf(err, "invalid native histograms in result block %s", bdir), nil)
+			}
+
+			histogramStatsMtx.Lock()
+			histogramStats.Add(stats)
+			histogramStatsMtx.Unlock()
+		}
+
 		begin := time.Now()
 		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, nil); err != nil {
 			return errors.Wrapf(err, "upload of %s failed", blockToUpload.ulid)
@@ -401,8 +441,15 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 
 		elapsed := time.Since(begin)
 		level.Info(jobLogger).Log("msg", "uploaded block", "result_block", blockToUpload.ulid, "duration", elapsed, "duration_ms", elapsed.Milliseconds(), "external_labels", labels.FromMap(newLabels))
+
+		if c.verifyUploads {
+			return c.verifyUploadedBlock(ctx, jobLogger, bdir, blockToUpload.ulid, toCompact)
+		}
 		return nil
 	})
+	if IsInvalidNativeHistogramsError(err) {
+		return false, nil, findSourceBlocksWithInvalidNativeHistograms(jobLogger, err, toCompact, subDir)
+	}
 	if err != nil {
 		return false, nil, err
 	}
@@ -410,6 +457,13 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	elapsed = time.Since(uploadBegin)
 	level.Info(jobLogger).Log("msg", "uploaded all blocks", "blocks", uploadedBlocks, "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 
+	if histogramStats.Chunks > 0 {
+		c.metrics.nativeHistogramChunks.Add(float64(histogramStats.Chunks))
+		c.metrics.nativeHistogramChunksBytes.Add(float64(histogramStats.Bytes))
+		c.metrics.nativeHistogramChunksUnknownCounterReset.Add(float64(histogramStats.UnknownCounterResetChunks))
+		level.Info(jobLogger).Log("msg", "compacted native histogram chunks", "chunks", histogramStats.Chunks, "bytes", histogramStats.Bytes, "samples", histogramStats.Samples, "unknown_counter_reset_chunks", histogramStats.UnknownCounterResetChunks)
+	}
+
 	// Mark for deletion the blocks we just compacted from the job and bucket so they do not get included
 	// into the next planning cycle.
 	// Eventually the block we just uploaded should get synced into the job again (including sync-delay).
@@ -422,6 +476,41 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	return true, compIDs, nil
 }
 
+// verifyUploadedBlock verifies the upload of the compacted block, and quarantines it if it's corrupted. An error is
+// returned if the block couldn't be verified or is corrupted, so that the source blocks are not marked for deletion
+// and get compacted again. If the local compacted block is invalid, the uploaded block is deleted, and the returned
+// error references the source blocks, so that they're marked for no-compaction instead of being compacted again to
+// an invalid block.
+func (c *BucketCompactor) verifyUploadedBlock(ctx context.Context, jobLogger log.Logger, bdir string, id ulid.ULID, sources []*block.Meta) error {
+	err := block.VerifyUploadedBlock(ctx, jobLogger, c.bkt, bdir)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, block.ErrLocalBlockInvalid) {
+		level.Error(jobLogger).Log("msg", "compacted block is invalid, deleting the uploaded block", "result_block", id, "err", err)
+		if delErr := block.Delete(ctx, jobLogger, c.bkt, id); delErr != nil {
+			return errors.Wrapf(delErr, "deletion of the invalid block %s failed (%v)", id, err)
+		}
+
+		sourceIDs := make([]ulid.ULID, 0, len(sources))
+		for _, meta := range sources {
+			sourceIDs = append(sourceIDs, meta.ULID)
+		}
+		return invalidCompactedIndexError(errors.Wrapf(err, "compacted block %s has been deleted", id), sourceIDs)
+	}
+	if !errors.Is(err, block.ErrUploadedBlockCorrupted) {
+		return errors.Wrapf(err, "verification of the upload of %s failed", id)
+	}
+
+	c.metrics.blocksFailedUploadVerification.Inc()
+	level.Error(jobLogger).Log("msg", "uploaded block is corrupted, moving it to the quarantine", "result_block", id, "err", err)
+
+	if qErr := block.Quarantine(ctx, jobLogger, c.bkt, bdir, block.UploadVerificationQuarantineReason, err.Error(), c.metrics.blocksQuarantined); qErr != nil {
+		return errors.Wrapf(qErr, "quarantine of the corrupted block %s failed (%v)", id, err)
+	}
+	return errors.Wrapf(err, "uploaded block %s has been quarantined", id)
+}
+
 // convertCompactionResultToForEachJobs filters out empty ULIDs.
 // When handling result of split compactions, shard index is index in the slice returned by compaction.
 func convertCompactionResultToForEachJobs(compactedBlocks []ulid.ULID, splitJob bool, jobLogger log.Logger) []ulidWithShardIndex {
@@ -489,6 +578,82 @@ func IsOutOfOrderChunkError(err error) bool {
 	return ok
 }
 
+// DigestMismatchError is a type wrapper for the error of a block whose files don't match the digests stored in its meta.json.
+type DigestMismatchError struct {
+	err error
+	id  ulid.ULID
+}
+
+func (e DigestMismatchError) Error() string {
+	return e.err.Error()
+}
+
+func digestMismatchError(err error, corruptedBlock ulid.ULID) DigestMismatchError {
+	return DigestMismatchError{err: err, id: corruptedBlock}
+}
+
+// IsDigestMismatchError returns true if the base error is a DigestMismatchError.
+func IsDigestMismatchError(err error) bool {
+	_, ok := errors.Cause(err).(DigestMismatchError)
+	return ok
+}
+
+// InvalidNativeHistogramsError is a type wrapper for the error of a result block with invalid native histograms. It
+// references the source blocks with invalid native histograms, if any.
+type InvalidNativeHistogramsError struct {
+	err error
+	ids []ulid.ULID
+}
+
+func (e InvalidNativeHistogramsError) Error() string {
+	return e.err.Error()
+}
+
+func invalidNativeHistogramsError(err error, invalidBlocks []ulid.ULID) InvalidNativeHistogramsError {
+	return InvalidNativeHistogramsError{err: err, ids: invalidBlocks}
+}
+
+// IsInvalidNativeHistogramsError returns true if the base error is a InvalidNativeHistogramsError.
+func IsInvalidNativeHistogramsError(err error) bool {
+	_, ok := errors.Cause(err).(InvalidNativeHistogramsError)
+	return ok
+}
+
+// InvalidCompactedIndexError is a type wrapper for the error of a compacted block whose index is invalid. It
+// references the source blocks of the compacted block.
+type InvalidCompactedIndexError struct {
+	err error
+	ids []ulid.ULID
+}
+
+func (e InvalidCompactedIndexError) Error() string {
+	return e.err.Error()
+}
+
+func invalidCompactedIndexError(err error, sourceBlocks []ulid.ULID) InvalidCompactedIndexError {
+	return InvalidCompactedIndexError{err: err, ids: sourceBlocks}
+}
+
+// IsInvalidCompactedIndexError returns true if the base error is a InvalidCompactedIndexError.
+func IsInvalidCompactedIndexError(err error) bool {
+	_, ok := errors.Cause(err).(InvalidCompactedIndexError)
+	return ok
+}
+
+// findSourceBlocksWithInvalidNativeHistograms verifies the native histograms of the source blocks of a result block
+// with invalid native histograms, and returns the error referencing the invalid ones. If none of them is invalid, the
+// native histograms have been corrupted by the compaction, and no source block is referenced.
+func findSourceBlocksWithInvalidNativeHistograms(logger log.Logger, err error, toCompact []*block.Meta, subDir string) error {
+	var invalidBlocks []ulid.ULID
+	for _, meta := range toCompact {
+		if _, verifyErr := block.GatherNativeHistogramStats(filepath.Join(subDir, meta.ULID.String())); verifyErr != nil {
+			level.Warn(logger).Log("msg", "source block has invalid native histograms", "block", meta.ULID, "err", verifyErr)
+			invalidBlocks = append(invalidBlocks, meta.ULID)
+		}
+	}
+	return invalidNativeHistogramsError(errors.Cause(err).(InvalidNativeHistogramsError).err, invalidBlocks)
+}
+
 // RepairIssue347 repairs the https://github.com/prometheus/tsdb/issues/347 issue when having issue347Error.
 func RepairIssue347(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blocksMarkedForDeletion prometheus.Counter, issue347Err error) error {
 	ie, ok := errors.Cause(issue347Err).(Issue347Error)
@@ -564,13 +729,26 @@ func deleteBlock(bkt objstore.Bucket, id ulid.ULID, bdir string, logger log.Logg
 
 // BucketCompactorMetrics holds the metrics tracked by BucketCompactor.
 type BucketCompactorMetrics struct {
-	groupCompactionRunsStarted   prometheus.Counter
-	groupCompactionRunsCompleted prometheus.Counter
-	groupCompactionRunsFailed    prometheus.Counter
-	groupCompactions             prometheus.Counter
-	blocksMarkedForDeletion      prometheus.Counter
-	blocksMarkedForNoCompact     prometheus.Counter
-	blocksMaxTimeDelta           prometheus.Histogram
+	groupCompactionRunsStarted                          prometheus.Counter
+	groupCompactionRunsCompleted                        prometheus.Counter
+	groupCompactionRunsFailed                           prometheus.Counter
+	groupCompactions                                    prometheus.Counter
+	blocksMarkedForDeletion                             prometheus.Counter
+	blocksMarkedForNoCompact                            prometheus.Counter
+	blocksWithDigestMismatchMarkedForNoCompact          prometheus.Counter
+	blocksWithInvalidNativeHistogramsMarkedForNoCompact prometheus.Counter
+	blocksWithInvalidCompactedIndexMarkedForNoCompact   prometheus.Counter
+	blocksMaxTimeDelta                                  prometheus.Histogram
+	blocksWithDigestMismatch                            prometheus.Counter
+	blocksDownloadsDeduplicated                         prometheus.Counter
+
+	blocksFailedUploadVerification prometheus.Counter
+	blocksQuarantined              prometheus.Counter
+
+	blocksWithInvalidNativeHistograms        prometheus.Counter
+	nativeHistogramChunks                    prometheus.Counter
+	nativeHistogramChunksBytes               prometheus.Counter
+	nativeHistogramChunksUnknownCounterReset prometheus.Counter
 }
 
 // NewBucketCompactorMetrics makes a new BucketCompactorMetrics.
@@ -598,11 +776,59 @@ func NewBucketCompactorMetrics(blocksMarkedForDeletion prometheus.Counter, reg p
 			Help:        "Total number of blocks that were marked for no-compaction.",
 			ConstLabels: prometheus.Labels{"reason": block.OutOfOrderChunksNoCompactReason},
 		}),
+		blocksWithDigestMismatchMarkedForNoCompact: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_compactor_blocks_marked_for_no_compaction_total",
+			Help:        "Total number of blocks that were marked for no-compaction.",
+			ConstLabels: prometheus.Labels{"reason": block.DigestMismatchNoCompactReason},
+		}),
+		blocksWithInvalidNativeHistogramsMarkedForNoCompact: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_compactor_blocks_marked_for_no_compaction_total",
+			Help:        "Total number of blocks that were marked for no-compaction.",
+			ConstLabels: prometheus.Labels{"reason": block.InvalidNativeHistogramsNoCompactReason},
+		}),
+		blocksWithInvalidCompactedIndexMarkedForNoCompact: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_compactor_blocks_marked_for_no_compaction_total",
+			Help:        "Total number of blocks that were marked for no-compaction.",
+			ConstLabels: prometheus.Labels{"reason": block.InvalidCompactedIndexNoCompactReason},
+		}),
 		blocksMaxTimeDelta: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_compactor_block_max_time_delta_seconds",
 			Help:    "Difference between now and the max time of a block being compacted in seconds.",
 			Buckets: prometheus.LinearBuckets(86400, 43200, 8), // 1 to 5 days, in 12 hour intervals
 		}),
+		blocksWithDigestMismatch: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_digest_mismatches_total",
+			Help: "Total number of downloaded blocks whose files don't match the digests stored in the block meta.json, a sign of corruption in the object storage.",
+		}),
+		blocksDownloadsDeduplicated: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_downloads_deduplicated_total",
+			Help: "Total number of source blocks not downloaded because already downloaded for another compaction job.",
+		}),
+		blocksFailedUploadVerification: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_upload_verification_failures_total",
+			Help: "Total number of compacted blocks whose upload to the object storage failed the verification, because the uploaded block is corrupted.",
+		}),
+		blocksQuarantined: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_compactor_blocks_quarantined_total",
+			Help:        "Total number of blocks moved to the quarantine location of the object storage.",
+			ConstLabels: prometheus.Labels{"reason": string(block.UploadVerificationQuarantineReason)},
+		}),
+		blocksWithInvalidNativeHistograms: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_with_invalid_native_histograms_total",
+			Help: "Total number of compacted blocks which failed the verification of their native histogram chunks.",
+		}),
+		nativeHistogramChunks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_native_histogram_chunks_total",
+			Help: "Total number of native histogram chunks in the compacted blocks.",
+		}),
+		nativeHistogramChunksBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_native_histogram_chunks_bytes_total",
+			Help: "Total size in bytes of the native histogram chunks in the compacted blocks.",
+		}),
+		nativeHistogramChunksUnknownCounterReset: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_native_histogram_chunks_unknown_counter_reset_total",
+			Help: "Total number of native histogram chunks in the compacted blocks whose counter reset header is unknown, meaning that it's not known whether the first sample of the chunk is a counter reset.",
+		}),
 	}
 }
 
@@ -623,11 +849,15 @@ type BucketCompactor struct {
 	compactDir                     string
 	bkt                            objstore.Bucket
 	concurrency                    int
+	splitConcurrency               int
 	skipBlocksWithOutOfOrderChunks bool
 	ownJob                         ownCompactionJobFunc
 	sortJobs                       JobsOrderFunc
 	waitPeriod                     time.Duration
 	blockSyncConcurrency           int
+	sharedBlocksDownload           bool
+	verifyNativeHistograms         bool
+	verifyUploads                  bool
 	metrics                        *BucketCompactorMetrics
 }
 
@@ -641,16 +871,23 @@ func NewBucketCompactor(
 	compactDir string,
 	bkt objstore.Bucket,
 	concurrency int,
+	splitConcurrency int,
 	skipBlocksWithOutOfOrderChunks bool,
 	ownJob ownCompactionJobFunc,
 	sortJobs JobsOrderFunc,
 	waitPeriod time.Duration,
 	blockSyncConcurrency int,
+	sharedBlocksDownload bool,
+	verifyNativeHistograms bool,
+	verifyUploads bool,
 	metrics *BucketCompactorMetrics,
 ) (*BucketCompactor, error) {
 	if concurrency <= 0 {
 		return nil, errors.Errorf("invalid concurrency level (%d), concurrency level must be > 0", concurrency)
 	}
+	if splitConcurrency < 0 {
+		return nil, errors.Errorf("invalid split concurrency level (%d), split concurrency level must be >= 0", splitConcurrency)
+	}
 	return &BucketCompactor{
 		logger:                         logger,
 		sy:                             sy,
@@ -660,17 +897,37 @@ func NewBucketCompactor(
 		compactDir:                     compactDir,
 		bkt:                            bkt,
 		concurrency:                    concurrency,
+		splitConcurrency:               splitConcurrency,
 		skipBlocksWithOutOfOrderChunks: skipBlocksWithOutOfOrderChunks,
 		ownJob:                         ownJob,
 		sortJobs:                       sortJobs,
 		waitPeriod:                     waitPeriod,
 		blockSyncConcurrency:           blockSyncConcurrency,
+		sharedBlocksDownload:           sharedBlocksDownload,
+		verifyNativeHistograms:         verifyNativeHistograms,
+		verifyUploads:                  verifyUploads,
 		metrics:                        metrics,
 	}, nil
 }
 
+// markBlocksForNoCompact marks the input blocks for no compaction, and returns true if there's at least one block
+// and all of them have been marked.
+func (c *BucketCompactor) markBlocksForNoCompact(ctx context.Context, ids []ulid.ULID, reason block.NoCompactReason, details string, markedForNoCompact prometheus.Counter) bool {
+	if len(ids) == 0 {
+		return false
+	}
+	for _, id := range ids {
+		if err := block.MarkForNoCompact(ctx, c.logger, c.bkt, id, reason, details, markedForNoCompact); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // Compact runs compaction over bucket.
 // If maxCompactionTime is positive then after this time no more new compactions are started.
+// If the split concurrency is positive, split jobs are run by a dedicated pool of workers, so that
+// blocks get split as soon as possible regardless of the number of merge jobs waiting to run.
 func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Duration) (rerr error) {
 	defer func() {
 		// Do not remove the compactDir if an error has occurred
@@ -695,79 +952,144 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 			wg                     sync.WaitGroup
 			workCtx, workCtxCancel = context.WithCancel(ctx)
 			jobChan                = make(chan *Job)
-			errChan                = make(chan error, c.concurrency)
+			splitJobChan           = make(chan *Job)
+			errChan                = make(chan error, c.concurrency+c.splitConcurrency)
 			finishedAllJobs        = true
 			mtx                    sync.Mutex
+			shared                 *sharedBlocks
 		)
 		defer workCtxCancel()
 
+		if c.sharedBlocksDownload {
+			shared = newSharedBlocks(filepath.Join(c.compactDir, sharedBlocksDirName), c.bkt, c.metrics.blocksDownloadsDeduplicated)
+		}
+		releaseSharedBlocks := func(job *Job) {
+			if shared != nil {
+				shared.release(c.logger, job)
+			}
+		}
+
 		// Set up workers who will compact the jobs when the jobs are ready.
 		// They will compact available jobs until they encounter an error, after which they will stop.
-		for i := 0; i < c.concurrency; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for g := range jobChan {
-					// Ensure the job is still owned by the current compactor instance.
-					// If not, we shouldn't run it because another compactor instance may already
-					// process it (or will do it soon).
-					if ok, err := c.ownJob(g); err != nil {
-						level.Info(c.logger).Log("msg", "skipped compaction because unable to check whether the job is owned by the compactor instance", "groupKey", g.Key(), "err", err)
-						continue
-					} else if !ok {
-						level.Info(c.logger).Log("msg", "skipped compaction because job is not owned by the compactor instance anymore", "groupKey", g.Key())
-						continue
-					}
+		runWorker := func(jobChan <-chan *Job) {
+			defer wg.Done()
+			for g := range jobChan {
+				// Ensure the job is still owned by the current compactor instance.
+				// If not, we shouldn't run it because another compactor instance may already
+				// process it (or will do it soon).
+				if ok, err := c.ownJob(g); err != nil {
+					level.Info(c.logger).Log("msg", "skipped compaction because unable to check whether the job is owned by the compactor instance", "groupKey", g.Key(), "err", err)
+					releaseSharedBlocks(g)
+					continue
+				} else if !ok {
+					level.Info(c.logger).Log("msg", "skipped compaction because job is not owned by the compactor instance anymore", "groupKey", g.Key())
+					releaseSharedBlocks(g)
+					continue
+				}
 
-					c.metrics.groupCompactionRunsStarted.Inc()
+				c.metrics.groupCompactionRunsStarted.Inc()
 
-					shouldRerunJob, compactedBlockIDs, err := c.runCompactionJob(workCtx, g)
-					if err == nil {
-						c.metrics.groupCompactionRunsCompleted.Inc()
-						if hasNonZeroULIDs(compactedBlockIDs) {
-							c.metrics.groupCompactions.Inc()
-						}
+				shouldRerunJob, compactedBlockIDs, err := c.runCompactionJob(workCtx, g, shared)
+				releaseSharedBlocks(g)
+				if err == nil {
+					c.metrics.groupCompactionRunsCompleted.Inc()
+					if hasNonZeroULIDs(compactedBlockIDs) {
+						c.metrics.groupCompactions.Inc()
+					}
 
-						if shouldRerunJob {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-						}
-						continue
+					if shouldRerunJob {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
 					}
+					continue
+				}
 
-					// At this point the compaction has failed.
-					c.metrics.groupCompactionRunsFailed.Inc()
+				// At this point the compaction has failed.
+				c.metrics.groupCompactionRunsFailed.Inc()
 
-					if IsIssue347Error(err) {
-						if err := RepairIssue347(workCtx, c.logger, c.bkt, c.sy.metrics.blocksMarkedForDeletion, err); err == nil {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-							continue
-						}
+				if IsIssue347Error(err) {
+					if err := RepairIssue347(workCtx, c.logger, c.bkt, c.sy.metrics.blocksMarkedForDeletion, err); err == nil {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
 					}
-					// If block has out of order chunk and it has been configured to skip it,
-					// then we can mark the block for no compaction so that the next compaction run
-					// will skip it.
-					if IsOutOfOrderChunkError(err) && c.skipBlocksWithOutOfOrderChunks {
-						if err := block.MarkForNoCompact(
-							ctx,
-							c.logger,
-							c.bkt,
-							err.(OutOfOrderChunksError).id,
-							block.OutOfOrderChunksNoCompactReason,
-							"OutofOrderChunk: marking block with out-of-order series/chunks to as no compact to unblock compaction", c.metrics.blocksMarkedForNoCompact); err == nil {
-							mtx.Lock()
-							finishedAllJobs = false
-							mtx.Unlock()
-							continue
-						}
+				}
+				// If block has out of order chunk and it has been configured to skip it,
+				// then we can mark the block for no compaction so that the next compaction run
+				// will skip it.
+				if IsOutOfOrderChunkError(err) && c.skipBlocksWithOutOfOrderChunks {
+					if err := block.MarkForNoCompact(
+						ctx,
+						c.logger,
+						c.bkt,
+						err.(OutOfOrderChunksError).id,
+						block.OutOfOrderChunksNoCompactReason,
+						"OutofOrderChunk: marking block with out-of-order series/chunks to as no compact to unblock compaction", c.metrics.blocksMarkedForNoCompact); err == nil {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
 					}
-					errChan <- errors.Wrapf(err, "group %s", g.Key())
-					return
 				}
-			}()
+				// If a block doesn't match the digests stored in its meta.json even once downloaded again,
+				// then it's corrupted in the object storage and we mark it for no compaction so that the
+				// next compaction run will skip it, instead of failing forever.
+				if IsDigestMismatchError(err) {
+					if err := block.MarkForNoCompact(
+						ctx,
+						c.logger,
+						c.bkt,
+						errors.Cause(err).(DigestMismatchError).id,
+						block.DigestMismatchNoCompactReason,
+						"DigestMismatch: marking block whose files don't match the digests stored in its meta.json as no compact to unblock compaction", c.metrics.blocksWithDigestMismatchMarkedForNoCompact); err == nil {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
+					}
+				}
+				// If the native histograms of the result block are invalid because some source blocks have invalid
+				// native histograms, then we mark these source blocks for no compaction so that the next compaction
+				// run will skip them, instead of failing forever.
+				if IsInvalidNativeHistogramsError(err) {
+					if c.markBlocksForNoCompact(
+						ctx,
+						errors.Cause(err).(InvalidNativeHistogramsError).ids,
+						block.InvalidNativeHistogramsNoCompactReason,
+						"InvalidNativeHistograms: marking block with invalid native histogram samples as no compact to unblock compaction", c.metrics.blocksWithInvalidNativeHistogramsMarkedForNoCompact) {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
+					}
+				}
+				// If the compacted block is invalid, then we mark its source blocks for no compaction so that the
+				// next compaction run will skip them, instead of compacting them again to an invalid block.
+				if IsInvalidCompactedIndexError(err) {
+					if c.markBlocksForNoCompact(
+						ctx,
+						errors.Cause(err).(InvalidCompactedIndexError).ids,
+						block.InvalidCompactedIndexNoCompactReason,
+						"InvalidCompactedIndex: marking block compacted to a block with an invalid index as no compact to unblock compaction", c.metrics.blocksWithInvalidCompactedIndexMarkedForNoCompact) {
+						mtx.Lock()
+						finishedAllJobs = false
+						mtx.Unlock()
+						continue
+					}
+				}
+				errChan <- errors.Wrapf(err, "group %s", g.Key())
+				return
+			}
+		}
+		for i := 0; i < c.concurrency; i++ {
+			wg.Add(1)
+			go runWorker(jobChan)
+		}
+		for i := 0; i < c.splitConcurrency; i++ {
+			wg.Add(1)
+			go runWorker(splitJobChan)
 		}
 
 		level.Info(c.logger).Log("msg", "start sync of metas")
@@ -819,18 +1141,48 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 			level.Warn(c.logger).Log("msg", "failed deleting non-compaction job directories/files, some disk space usage might have leaked. Continuing", "err", err, "dir", c.compactDir)
 		}
 
+		// Reference the source blocks of all the jobs to run, so that the blocks shared by
+		// multiple jobs are downloaded once and kept until the last of these jobs is done.
+		if shared != nil {
+			for _, job := range jobs {
+				shared.retain(job)
+			}
+		}
+
 		level.Info(c.logger).Log("msg", "start of compactions")
 
+		// Split jobs are sent to the dedicated workers, if any.
+		var splitJobs []*Job
+		if c.splitConcurrency > 0 {
+			splitJobs, jobs = partitionJobsByStage(jobs)
+		}
+
 		maxCompactionTimeReached := false
 		// Send all jobs found during this pass to the compaction workers.
 		var jobErrs multierror.MultiError
 	jobLoop:
-		for _, g := range jobs {
+		for len(jobs) > 0 || len(splitJobs) > 0 {
+			// Sending to a nil channel blocks forever, so a worker pool without pending jobs is never selected.
+			var (
+				nextJob, nextSplitJob *Job
+				nextJobChan           chan *Job
+				nextSplitJobChan      chan *Job
+			)
+			if len(jobs) > 0 {
+				nextJob, nextJobChan = jobs[0], jobChan
+			}
+			if len(splitJobs) > 0 {
+				nextSplitJob, nextSplitJobChan = splitJobs[0], splitJobChan
+			}
+
 			select {
 			case jobErr := <-errChan:
 				jobErrs.Add(jobErr)
 				break jobLoop
-			case jobChan <- g:
+			case nextJobChan <- nextJob:
+				jobs = jobs[1:]
+			case nextSplitJobChan <- nextSplitJob:
+				splitJobs = splitJobs[1:]
 			case <-maxCompactionTimeChan:
 				maxCompactionTimeReached = true
 				level.Info(c.logger).Log("msg", "max compaction time reached, no more compactions will be started")
@@ -838,8 +1190,16 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 			}
 		}
 		close(jobChan)
+		close(splitJobChan)
 		wg.Wait()
 
+		// Remove the shared blocks still referenced by the jobs which have not been run.
+		if shared != nil {
+			if err := os.RemoveAll(shared.dir); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to remove shared blocks directory", "path", shared.dir, "err", err)
+			}
+		}
+
 		// Collect any other error reported by the workers, or any error reported
 		// while we were waiting for the last batch of jobs to run the compaction.
 		close(errChan)
@@ -13,17 +13,21 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/util/extprom"
+	"github.com/grafana/mimir/pkg/util/test"
 )
 
 func TestGroupKey(t *testing.T) {
@@ -119,7 +123,7 @@ func TestBucketCompactor_FilterOwnJobs(t *testing.T) {
 	m := NewBucketCompactorMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), nil)
 	for testName, testCase := range tests {
 		t.Run(testName, func(t *testing.T) {
-			bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, testCase.ownJob, nil, 0, 4, m)
+			bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, 0, false, testCase.ownJob, nil, 0, 4, false, false, false, m)
 			require.NoError(t, err)
 
 			res, err := bc.filterOwnJobs(jobsFn())
@@ -155,7 +159,7 @@ func TestBlockMaxTimeDeltas(t *testing.T) {
 
 	metrics := NewBucketCompactorMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), nil)
 	now := time.UnixMilli(1500002900159)
-	bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, nil, nil, 0, 4, metrics)
+	bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, 0, false, nil, nil, 0, 4, false, false, false, metrics)
 	require.NoError(t, err)
 
 	deltas := bc.blockMaxTimeDeltas(now, []*Job{j1, j2})
@@ -280,3 +284,35 @@ func TestConvertCompactionResultToForEachJobs(t *testing.T) {
 	require.Equal(t, ulidWithShardIndex{ulid: ulid1, shardIndex: 1}, res[0])
 	require.Equal(t, ulidWithShardIndex{ulid: ulid2, shardIndex: 3}, res[1])
 }
+
+func TestFindSourceBlocksWithInvalidNativeHistograms(t *testing.T) {
+	histogramSeries := func(metricName string, count uint64) *block.SeriesSpec {
+		h := test.GenerateTestHistogram(1)
+		h.Count = count
+
+		chk := chunkenc.NewHistogramChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		app.AppendHistogram(0, h)
+		return &block.SeriesSpec{Labels: labels.FromStrings(labels.MetricName, metricName), Chunks: []chunks.Meta{{MinTime: 0, MaxTime: 0, Chunk: chk}}}
+	}
+
+	subDir := t.TempDir()
+	valid, err := block.GenerateBlockFromSpec("", subDir, block.SeriesSpecs{histogramSeries("valid", test.GenerateTestHistogram(1).Count)})
+	require.NoError(t, err)
+	// The count of the histogram is lower than the sum of its buckets.
+	invalid, err := block.GenerateBlockFromSpec("", subDir, block.SeriesSpecs{histogramSeries("invalid", 1)})
+	require.NoError(t, err)
+
+	resultErr := invalidNativeHistogramsError(errors.New("invalid native histograms in result block"), nil)
+
+	err = findSourceBlocksWithInvalidNativeHistograms(log.NewNopLogger(), resultErr, []*block.Meta{valid, invalid}, subDir)
+	require.True(t, IsInvalidNativeHistogramsError(err))
+	assert.Equal(t, []ulid.ULID{invalid.ULID}, err.(InvalidNativeHistogramsError).ids)
+	assert.EqualError(t, err, "invalid native histograms in result block")
+
+	// If none of the source blocks is invalid, no block is referenced.
+	err = findSourceBlocksWithInvalidNativeHistograms(log.NewNopLogger(), resultErr, []*block.Meta{valid}, subDir)
+	require.True(t, IsInvalidNativeHistogramsError(err))
+	assert.Empty(t, err.(InvalidNativeHistogramsError).ids)
+}
@@ -58,6 +58,8 @@ var (
 	errInvalidMaxClosingBlocksConcurrency         = fmt.Errorf("invalid max-closing-blocks-concurrency value, must be positive")
 	errInvalidSymbolFlushersConcurrency           = fmt.Errorf("invalid symbols-flushers-concurrency value, must be positive")
 	errInvalidMaxBlockUploadValidationConcurrency = fmt.Errorf("invalid max-block-upload-validation-concurrency value, can't be negative")
+	errInvalidSplitConcurrency                    = fmt.Errorf("invalid split-compaction-concurrency value, can't be negative")
+	errInvalidTenantCompactionLagThreshold        = fmt.Errorf("invalid tenant-compaction-lag-threshold value, must be positive")
 	RingOp                                        = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
 )
 
@@ -88,6 +90,7 @@ type Config struct {
 	CompactionInterval    time.Duration           `yaml:"compaction_interval" category:"advanced"`
 	CompactionRetries     int                     `yaml:"compaction_retries" category:"advanced"`
 	CompactionConcurrency int                     `yaml:"compaction_concurrency" category:"advanced"`
+	SplitConcurrency      int                     `yaml:"split_compaction_concurrency" category:"experimental"`
 	CompactionWaitPeriod  time.Duration           `yaml:"first_level_compaction_wait_period"`
 	CleanupInterval       time.Duration           `yaml:"cleanup_interval" category:"advanced"`
 	CleanupConcurrency    int                     `yaml:"cleanup_concurrency" category:"advanced"`
@@ -95,6 +98,14 @@ type Config struct {
 	TenantCleanupDelay    time.Duration           `yaml:"tenant_cleanup_delay" category:"advanced"`
 	MaxCompactionTime     time.Duration           `yaml:"max_compaction_time" category:"advanced"`
 
+	SharedBlocksDownloadEnabled bool `yaml:"shared_blocks_download_enabled" category:"experimental"`
+
+	NativeHistogramsVerificationEnabled bool `yaml:"native_histograms_verification_enabled" category:"experimental"`
+
+	UploadVerificationEnabled bool `yaml:"upload_verification_enabled" category:"experimental"`
+
+	TenantCompactionLagThreshold time.Duration `yaml:"tenant_compaction_lag_threshold" category:"experimental"`
+
 	// Compactor concurrency options
 	MaxOpeningBlocksConcurrency         int `yaml:"max_opening_blocks_concurrency" category:"advanced"`          // Number of goroutines opening blocks before compaction.
 	MaxClosingBlocksConcurrency         int `yaml:"max_closing_blocks_concurrency" category:"advanced"`          // Max number of blocks that can be closed concurrently during split compaction. Note that closing of newly compacted block uses a lot of memory for writing index.
@@ -136,6 +147,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.DurationVar(&cfg.MaxCompactionTime, "compactor.max-compaction-time", time.Hour, "Max time for starting compactions for a single tenant. After this time no new compactions for the tenant are started before next compaction cycle. This can help in multi-tenant environments to avoid single tenant using all compaction time, but also in single-tenant environments to force new discovery of blocks more often. 0 = disabled.")
 	f.IntVar(&cfg.CompactionRetries, "compactor.compaction-retries", 3, "How many times to retry a failed compaction within a single compaction run.")
 	f.IntVar(&cfg.CompactionConcurrency, "compactor.compaction-concurrency", 1, "Max number of concurrent compactions running.")
+	f.BoolVar(&cfg.SharedBlocksDownloadEnabled, "compactor.shared-blocks-download-enabled", false, "If enabled, the source blocks shared by multiple compaction jobs of a tenant are downloaded once per compaction run, and referenced by all these jobs until they complete, instead of being downloaded by each job.")
+	f.BoolVar(&cfg.NativeHistogramsVerificationEnabled, "compactor.native-histograms-verification-enabled", false, "If enabled, the native histogram chunks of the compacted blocks are validated before the blocks are uploaded, and statistics about them are tracked. The compaction job fails if any native histogram sample is invalid, and the source blocks with invalid native histogram samples are marked for no-compaction.")
+	f.BoolVar(&cfg.UploadVerificationEnabled, "compactor.upload-verification-enabled", false, "If enabled, the upload of the compacted blocks is verified by downloading again their meta.json and index footer. Corrupted blocks are moved to the quarantine location of the tenant's bucket, and the compaction job fails without marking the source blocks for deletion. If the local compacted block is invalid, the uploaded block is deleted and the source blocks are marked for no-compaction.")
+	f.DurationVar(&cfg.TenantCompactionLagThreshold, "compactor.tenant-compaction-lag-threshold", 12*time.Hour, "Tenants whose oldest level-1 block not compacted yet, or last successful compaction, are older than this threshold are listed by the /compactor/lagging_tenants endpoint.")
+	f.IntVar(&cfg.SplitConcurrency, "compactor.split-compaction-concurrency", 0, "Max number of concurrent split compactions running in addition to -compactor.compaction-concurrency. When greater than 0, split jobs are run by a dedicated pool of workers, so that blocks are split for query sharding as soon as possible even when there's a large backlog of merge jobs. 0 to run split and merge jobs in the same pool.")
 	f.DurationVar(&cfg.CompactionWaitPeriod, "compactor.first-level-compaction-wait-period", 25*time.Minute, "How long the compactor waits before compacting first-level blocks that are uploaded by the ingesters. This configuration option allows for the reduction of cases where the compactor begins to compact blocks before all ingesters have uploaded their blocks to the storage.")
 	f.DurationVar(&cfg.CleanupInterval, "compactor.cleanup-interval", 15*time.Minute, "How frequently compactor should run blocks cleanup and maintenance, as well as update the bucket index.")
 	f.IntVar(&cfg.CleanupConcurrency, "compactor.cleanup-concurrency", 20, "Max number of tenants for which blocks cleanup and maintenance should run concurrently.")
@@ -174,6 +190,12 @@ func (cfg *Config) Validate() error {
 	if cfg.MaxBlockUploadValidationConcurrency < 0 {
 		return errInvalidMaxBlockUploadValidationConcurrency
 	}
+	if cfg.SplitConcurrency < 0 {
+		return errInvalidSplitConcurrency
+	}
+	if cfg.TenantCompactionLagThreshold <= 0 {
+		return errInvalidTenantCompactionLagThreshold
+	}
 	if !util.StringsContain(CompactionOrders, cfg.CompactionJobsOrder) {
 		return errInvalidCompactionOrder
 	}
@@ -271,6 +293,9 @@ type MultitenantCompactor struct {
 	// TSDB syncer metrics
 	syncerMetrics *aggregatedSyncerMetrics
 
+	// Compaction status of the owned tenants.
+	tenantsCompactionStatus *tenantsCompactionStatus
+
 	blockUploadValidations atomic.Int64
 }
 
@@ -307,16 +332,17 @@ func newMultitenantCompactor(
 	blocksCompactorFactory BlocksCompactorFactory,
 ) (*MultitenantCompactor, error) {
 	c := &MultitenantCompactor{
-		compactorCfg:           compactorCfg,
-		storageCfg:             storageCfg,
-		cfgProvider:            cfgProvider,
-		parentLogger:           logger,
-		logger:                 log.With(logger, "component", "compactor"),
-		registerer:             registerer,
-		syncerMetrics:          newAggregatedSyncerMetrics(registerer),
-		bucketClientFactory:    bucketClientFactory,
-		blocksGrouperFactory:   blocksGrouperFactory,
-		blocksCompactorFactory: blocksCompactorFactory,
+		compactorCfg:            compactorCfg,
+		storageCfg:              storageCfg,
+		cfgProvider:             cfgProvider,
+		parentLogger:            logger,
+		logger:                  log.With(logger, "component", "compactor"),
+		registerer:              registerer,
+		syncerMetrics:           newAggregatedSyncerMetrics(registerer),
+		tenantsCompactionStatus: newTenantsCompactionStatus(),
+		bucketClientFactory:     bucketClientFactory,
+		blocksGrouperFactory:    blocksGrouperFactory,
+		blocksCompactorFactory:  blocksCompactorFactory,
 
 		compactionRunsStarted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_runs_started_total",
@@ -374,6 +400,10 @@ func newMultitenantCompactor(
 		return float64(c.blockUploadValidations.Load())
 	})
 
+	if registerer != nil {
+		registerer.MustRegister(c.tenantsCompactionStatus)
+	}
+
 	c.bucketCompactorMetrics = NewBucketCompactorMetrics(c.blocksMarkedForDeletion, registerer)
 
 	if len(compactorCfg.EnabledTenants) > 0 {
@@ -608,6 +638,7 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		}
 
 		ownedUsers[userID] = struct{}{}
+		c.tenantsCompactionStatus.tenantOwned(userID)
 
 		if markedForDeletion, err := mimir_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, userID); err != nil {
 			c.compactionRunSkippedTenants.Inc()
@@ -636,9 +667,12 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		}
 
 		c.compactionRunSucceededTenants.Inc()
+		c.tenantsCompactionStatus.compactionSucceeded(userID)
 		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
 	}
 
+	c.tenantsCompactionStatus.retainTenants(ownedUsers)
+
 	// Delete local files for unowned tenants, if there are any. This cleans up
 	// leftover local files for tenants that belong to different compactors now,
 	// or have been deleted completely.
@@ -749,18 +783,29 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		path.Join(c.compactorCfg.DataDir, "compact"),
 		userBucket,
 		c.compactorCfg.CompactionConcurrency,
+		c.compactorCfg.SplitConcurrency,
 		true, // Skip blocks without of order chunks, and mark them for no-compaction.
 		c.shardingStrategy.ownJob,
 		c.jobsOrder,
 		c.compactorCfg.CompactionWaitPeriod,
 		c.compactorCfg.BlockSyncConcurrency,
+		c.compactorCfg.SharedBlocksDownloadEnabled,
+		c.compactorCfg.NativeHistogramsVerificationEnabled,
+		c.compactorCfg.UploadVerificationEnabled,
 		c.bucketCompactorMetrics,
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create bucket compactor")
 	}
 
-	if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
+	err = compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime)
+
+	// The blocks synced last are the ones left once the compaction is done, or has been interrupted.
+	if metas := syncer.Metas(); metas != nil {
+		c.tenantsCompactionStatus.updateOldestUncompactedBlock(userID, metas)
+	}
+
+	if err != nil {
 		return errors.Wrap(err, "compaction")
 	}
 
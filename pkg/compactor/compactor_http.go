@@ -13,6 +13,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
 
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -45,3 +46,17 @@ func (c *MultitenantCompactor) RingHandler(w http.ResponseWriter, req *http.Requ
 
 	c.ring.ServeHTTP(w, req)
 }
+
+// LaggingTenantsHandler lists the tenants owned by the compactor whose oldest level-1 block not compacted
+// yet, or last successful compaction, are older than the configured threshold.
+func (c *MultitenantCompactor) LaggingTenantsHandler(w http.ResponseWriter, _ *http.Request) {
+	threshold := c.compactorCfg.TenantCompactionLagThreshold
+
+	util.WriteJSONResponse(w, struct {
+		ThresholdSeconds float64         `json:"threshold_seconds"`
+		Tenants          []laggingTenant `json:"tenants"`
+	}{
+		ThresholdSeconds: threshold.Seconds(),
+		Tenants:          c.tenantsCompactionStatus.laggingTenants(threshold),
+	})
+}
@@ -70,6 +70,18 @@ func sortJobsBySmallestRangeOldestBlocksFirst(jobs []*Job) []*Job {
 	return jobs
 }
 
+// partitionJobsByStage splits the input jobs into the split jobs and the merge jobs, preserving their order.
+func partitionJobsByStage(jobs []*Job) (splitJobs, mergeJobs []*Job) {
+	for _, job := range jobs {
+		if job.UseSplitting() {
+			splitJobs = append(splitJobs, job)
+		} else {
+			mergeJobs = append(mergeJobs, job)
+		}
+	}
+	return splitJobs, mergeJobs
+}
+
 // sortJobsByNewestBlocksFirst returns input jobs sorted by most recent time ranges first
 // (regardless of their compaction level). The rationale of this sorting is that in case the
 // compactor is lagging behind, we compact up to the largest range (eg. 24h) the most recent
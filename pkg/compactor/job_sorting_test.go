@@ -132,6 +132,40 @@ func TestSortJobsByNewestBlocksFirst(t *testing.T) {
 	}
 }
 
+func TestPartitionJobsByStage(t *testing.T) {
+	split1 := &Job{key: "split-1", useSplitting: true}
+	split2 := &Job{key: "split-2", useSplitting: true}
+	merge1 := &Job{key: "merge-1"}
+	merge2 := &Job{key: "merge-2"}
+
+	tests := map[string]struct {
+		input             []*Job
+		expectedSplitJobs []*Job
+		expectedMergeJobs []*Job
+	}{
+		"should do nothing on empty input": {
+			input: nil,
+		},
+		"should partition jobs preserving their order": {
+			input:             []*Job{merge2, split1, merge1, split2},
+			expectedSplitJobs: []*Job{split1, split2},
+			expectedMergeJobs: []*Job{merge2, merge1},
+		},
+		"should return no split jobs if all jobs are merge jobs": {
+			input:             []*Job{merge1, merge2},
+			expectedMergeJobs: []*Job{merge1, merge2},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			splitJobs, mergeJobs := partitionJobsByStage(testData.input)
+			assert.Equal(t, testData.expectedSplitJobs, splitJobs)
+			assert.Equal(t, testData.expectedMergeJobs, mergeJobs)
+		})
+	}
+}
+
 func mockMetaWithMinMax(id ulid.ULID, minTime, maxTime int64) *block.Meta {
 	return &block.Meta{
 		BlockMeta: tsdb.BlockMeta{
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+// sharedBlocksDirName is the name of the directory, within the compaction work directory,
+// where the source blocks shared by multiple jobs are downloaded.
+const sharedBlocksDirName = "shared-blocks"
+
+// sharedBlocks downloads the source blocks shared by multiple compaction jobs only once. Each block is
+// downloaded into a directory named after the block ID, and referenced by the jobs compacting it. The
+// directory is removed once all the jobs referencing the block have been completed.
+type sharedBlocks struct {
+	dir          string
+	bkt          objstore.Bucket
+	deduplicated prometheus.Counter
+
+	mtx    sync.Mutex
+	blocks map[ulid.ULID]*sharedBlock
+}
+
+type sharedBlock struct {
+	// refs is the number of jobs referencing the block.
+	refs int
+	// downloading is closed once the download in progress, if any, is done.
+	downloading chan struct{}
+	downloaded  bool
+}
+
+func newSharedBlocks(dir string, bkt objstore.Bucket, deduplicated prometheus.Counter) *sharedBlocks {
+	return &sharedBlocks{
+		dir:          dir,
+		bkt:          bkt,
+		deduplicated: deduplicated,
+		blocks:       map[ulid.ULID]*sharedBlock{},
+	}
+}
+
+// retain adds a reference to the source blocks of the job.
+func (s *sharedBlocks) retain(job *Job) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, id := range job.IDs() {
+		b, ok := s.blocks[id]
+		if !ok {
+			b = &sharedBlock{}
+			s.blocks[id] = b
+		}
+		b.refs++
+	}
+}
+
+// release removes a reference to the source blocks of the job, and removes from disk
+// the blocks which are not referenced anymore.
+func (s *sharedBlocks) release(logger log.Logger, job *Job) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, id := range job.IDs() {
+		b, ok := s.blocks[id]
+		if !ok {
+			continue
+		}
+		if b.refs--; b.refs > 0 {
+			continue
+		}
+
+		delete(s.blocks, id)
+		if b.downloaded {
+			if err := os.RemoveAll(s.blockDir(id)); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove shared block directory", "block", id, "err", err)
+			}
+		}
+	}
+}
+
+// linkBlock makes the block available in dst, downloading it unless already downloaded
+// for another job. The files of the block are hard linked, so that jobs can modify their
+// copy of the block directory without affecting the other jobs.
+func (s *sharedBlocks) linkBlock(ctx context.Context, logger log.Logger, id ulid.ULID, dst string) error {
+	if err := s.download(ctx, logger, id); err != nil {
+		return err
+	}
+	return linkDir(s.blockDir(id), dst)
+}
+
+func (s *sharedBlocks) download(ctx context.Context, logger log.Logger, id ulid.ULID) error {
+	for {
+		s.mtx.Lock()
+		b, ok := s.blocks[id]
+		if !ok {
+			s.mtx.Unlock()
+			return errors.Errorf("block %s is not referenced by any job", id)
+		}
+		if b.downloaded {
+			s.mtx.Unlock()
+			s.deduplicated.Inc()
+			return nil
+		}
+		if b.downloading != nil {
+			// Another job is downloading the block: wait for it, and try again if it failed.
+			downloading := b.downloading
+			s.mtx.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-downloading:
+				continue
+			}
+		}
+
+		b.downloading = make(chan struct{})
+		s.mtx.Unlock()
+
+		err := block.Download(ctx, logger, s.bkt, id, s.blockDir(id))
+		if err != nil {
+			// Do not leave a partial download behind, so that it's retried from scratch.
+			_ = os.RemoveAll(s.blockDir(id))
+		}
+
+		s.mtx.Lock()
+		close(b.downloading)
+		b.downloading = nil
+		b.downloaded = err == nil
+		s.mtx.Unlock()
+
+		return err
+	}
+}
+
+func (s *sharedBlocks) blockDir(id ulid.ULID) string {
+	return filepath.Join(s.dir, id.String())
+}
+
+// linkDir recreates the src directory tree in dst, hard linking all the files.
+func linkDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0750)
+		}
+		return errors.Wrapf(os.Link(path, target), "link %s", path)
+	})
+}
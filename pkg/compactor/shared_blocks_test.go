@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestSharedBlocks(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	metas := createAndUpload(t, bkt, []blockgenSpec{
+		{numSamples: 100, mint: 0, maxt: 1000, extLset: labels.EmptyLabels(), series: []labels.Labels{labels.FromStrings("a", "1")}},
+		{numSamples: 100, mint: 1000, maxt: 2000, extLset: labels.EmptyLabels(), series: []labels.Labels{labels.FromStrings("a", "1")}},
+	}, nil)
+
+	// Both jobs compact the first block.
+	job1 := NewJob("user-1", "job-1", labels.EmptyLabels(), 0, false, 0, "")
+	require.NoError(t, job1.AppendMeta(metas[0]))
+	job2 := NewJob("user-1", "job-2", labels.EmptyLabels(), 0, false, 0, "")
+	require.NoError(t, job2.AppendMeta(metas[0]))
+	require.NoError(t, job2.AppendMeta(metas[1]))
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	dir := t.TempDir()
+	deduplicated := prometheus.NewCounter(prometheus.CounterOpts{})
+	shared := newSharedBlocks(filepath.Join(dir, sharedBlocksDirName), bkt, deduplicated)
+	shared.retain(job1)
+	shared.retain(job2)
+
+	// Concurrently link the blocks of both jobs.
+	wg := sync.WaitGroup{}
+	for _, job := range []*Job{job1, job2} {
+		for _, meta := range job.metasByMinTime {
+			job, meta := job, meta
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, shared.linkBlock(ctx, logger, meta.ULID, filepath.Join(dir, job.Key(), meta.ULID.String())))
+			}()
+		}
+	}
+	wg.Wait()
+
+	// The block shared by the two jobs has been downloaded once.
+	assert.Equal(t, 1.0, testutil.ToFloat64(deduplicated))
+	for _, job := range []*Job{job1, job2} {
+		for _, meta := range job.metasByMinTime {
+			require.NoError(t, block.VerifyBlock(logger, filepath.Join(dir, job.Key(), meta.ULID.String()), meta.MinTime, meta.MaxTime, false))
+		}
+	}
+
+	// Modifying the block of a job doesn't affect the other job.
+	require.NoError(t, os.Remove(filepath.Join(dir, job1.Key(), metas[0].ULID.String(), block.MetaFilename)))
+	require.FileExists(t, filepath.Join(dir, job2.Key(), metas[0].ULID.String(), block.MetaFilename))
+
+	// The shared block is kept until all the jobs referencing it are done.
+	shared.release(logger, job1)
+	require.DirExists(t, shared.blockDir(metas[0].ULID))
+	require.DirExists(t, shared.blockDir(metas[1].ULID))
+
+	shared.release(logger, job2)
+	require.NoDirExists(t, shared.blockDir(metas[0].ULID))
+	require.NoDirExists(t, shared.blockDir(metas[1].ULID))
+
+	// Blocks not referenced by any job can't be downloaded.
+	require.Error(t, shared.linkBlock(ctx, logger, metas[0].ULID, filepath.Join(dir, "job-3", metas[0].ULID.String())))
+}
+
+func TestSharedBlocks_DownloadFailure(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	metas := createAndUpload(t, bkt, []blockgenSpec{
+		{numSamples: 100, mint: 0, maxt: 1000, extLset: labels.EmptyLabels(), series: []labels.Labels{labels.FromStrings("a", "1")}},
+	}, nil)
+
+	job := NewJob("user-1", "job-1", labels.EmptyLabels(), 0, false, 0, "")
+	require.NoError(t, job.AppendMeta(metas[0]))
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	dir := t.TempDir()
+	shared := newSharedBlocks(filepath.Join(dir, sharedBlocksDirName), bkt, prometheus.NewCounter(prometheus.CounterOpts{}))
+	shared.retain(job)
+
+	// Make the download fail by removing the block index from the bucket.
+	indexPath := path.Join(metas[0].ULID.String(), block.IndexFilename)
+	index, err := bkt.Get(ctx, indexPath)
+	require.NoError(t, err)
+	indexContent, err := io.ReadAll(index)
+	require.NoError(t, err)
+	require.NoError(t, bkt.Delete(ctx, indexPath))
+
+	dst := filepath.Join(dir, job.Key(), metas[0].ULID.String())
+	require.Error(t, shared.linkBlock(ctx, logger, metas[0].ULID, dst))
+	require.NoDirExists(t, shared.blockDir(metas[0].ULID))
+
+	// The download is retried once the block is fixed.
+	require.NoError(t, bkt.Upload(ctx, indexPath, bytes.NewReader(indexContent)))
+	require.NoError(t, os.RemoveAll(dst))
+	require.NoError(t, shared.linkBlock(ctx, logger, metas[0].ULID, dst))
+	require.NoError(t, block.VerifyBlock(logger, dst, metas[0].MinTime, metas[0].MaxTime, false))
+}
@@ -4,6 +4,7 @@ package compactor
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -61,6 +62,8 @@ func TestMultitenantCompactor_ShouldSupportSplitAndMergeCompactor(t *testing.T)
 	tests := map[string]struct {
 		numShards int
 		setup     func(t *testing.T, bkt objstore.Bucket) []block.Meta
+
+		expectedNativeHistogramChunks bool
 	}{
 		"overlapping blocks matching the 1st compaction range should be merged and split": {
 			numShards: 2,
@@ -605,7 +608,8 @@ func TestMultitenantCompactor_ShouldSupportSplitAndMergeCompactor(t *testing.T)
 			},
 		},
 		"compaction on blocks containing native histograms": {
-			numShards: 2,
+			numShards:                     2,
+			expectedNativeHistogramChunks: true,
 			setup: func(t *testing.T, bkt objstore.Bucket) []block.Meta {
 				minT := blockRangeMillis
 				maxT := 2 * blockRangeMillis
@@ -672,74 +676,83 @@ func TestMultitenantCompactor_ShouldSupportSplitAndMergeCompactor(t *testing.T)
 	}
 
 	for testName, testData := range tests {
-		t.Run(testName, func(t *testing.T) {
-			workDir := t.TempDir()
-			storageDir := t.TempDir()
-			fetcherDir := t.TempDir()
-
-			storageCfg := mimir_tsdb.BlocksStorageConfig{}
-			flagext.DefaultValues(&storageCfg)
-			storageCfg.Bucket.Backend = bucket.Filesystem
-			storageCfg.Bucket.Filesystem.Directory = storageDir
-
-			compactorCfg := prepareConfig(t)
-			compactorCfg.DataDir = workDir
-			compactorCfg.BlockRanges = compactionRanges
-
-			cfgProvider := newMockConfigProvider()
-			cfgProvider.splitAndMergeShards[userID] = testData.numShards
-
-			logger := log.NewLogfmtLogger(os.Stdout)
-			reg := prometheus.NewPedanticRegistry()
-			ctx := context.Background()
-
-			// Create TSDB blocks in the storage and get the expected blocks.
-			bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
-			require.NoError(t, err)
-			expected := testData.setup(t, bucketClient)
-
-			c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
-			require.NoError(t, err)
-			require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
-			t.Cleanup(func() {
-				require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
-			})
+		for _, splitConcurrency := range []int{0, 2} {
+			t.Run(fmt.Sprintf("%s, split concurrency: %d", testName, splitConcurrency), func(t *testing.T) {
+				workDir := t.TempDir()
+				storageDir := t.TempDir()
+				fetcherDir := t.TempDir()
+
+				storageCfg := mimir_tsdb.BlocksStorageConfig{}
+				flagext.DefaultValues(&storageCfg)
+				storageCfg.Bucket.Backend = bucket.Filesystem
+				storageCfg.Bucket.Filesystem.Directory = storageDir
+
+				compactorCfg := prepareConfig(t)
+				compactorCfg.DataDir = workDir
+				compactorCfg.BlockRanges = compactionRanges
+				compactorCfg.SplitConcurrency = splitConcurrency
+				compactorCfg.NativeHistogramsVerificationEnabled = true
+
+				cfgProvider := newMockConfigProvider()
+				cfgProvider.splitAndMergeShards[userID] = testData.numShards
+
+				logger := log.NewLogfmtLogger(os.Stdout)
+				reg := prometheus.NewPedanticRegistry()
+				ctx := context.Background()
+
+				// Create TSDB blocks in the storage and get the expected blocks.
+				bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+				require.NoError(t, err)
+				expected := testData.setup(t, bucketClient)
+
+				c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
+				require.NoError(t, err)
+				require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+				t.Cleanup(func() {
+					require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+				})
+
+				// Wait until the first compaction run completed.
+				test.Poll(t, 15*time.Second, nil, func() interface{} {
+					return testutil.GatherAndCompare(reg, strings.NewReader(`
+						# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+						# TYPE cortex_compactor_runs_completed_total counter
+						cortex_compactor_runs_completed_total 1
+					`), "cortex_compactor_runs_completed_total")
+				})
+
+				// List back any (non deleted) block from the storage.
+				userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+				fetcher, err := block.NewMetaFetcher(logger,
+					1,
+					userBucket,
+					fetcherDir,
+					reg,
+					nil,
+				)
+				require.NoError(t, err)
+				metas, partials, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+				require.NoError(t, err)
+				require.Empty(t, partials)
+
+				// Sort blocks by MinTime and labels so that we get a stable comparison.
+				actual := sortMetasByMinTime(convertMetasMapToSlice(metas))
+
+				// Compare actual blocks with the expected ones.
+				require.Len(t, actual, len(expected))
+				for i, e := range expected {
+					assert.Equal(t, e.MinTime, actual[i].MinTime)
+					assert.Equal(t, e.MaxTime, actual[i].MaxTime)
+					assert.Equal(t, e.Compaction.Sources, actual[i].Compaction.Sources)
+					assert.Equal(t, e.Thanos.Labels, actual[i].Thanos.Labels)
+				}
 
-			// Wait until the first compaction run completed.
-			test.Poll(t, 15*time.Second, nil, func() interface{} {
-				return testutil.GatherAndCompare(reg, strings.NewReader(`
-					# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
-					# TYPE cortex_compactor_runs_completed_total counter
-					cortex_compactor_runs_completed_total 1
-				`), "cortex_compactor_runs_completed_total")
+				// The native histogram chunks of the compacted blocks have been verified.
+				assert.Zero(t, testutil.ToFloat64(c.bucketCompactorMetrics.blocksWithInvalidNativeHistograms))
+				assert.Equal(t, testData.expectedNativeHistogramChunks, testutil.ToFloat64(c.bucketCompactorMetrics.nativeHistogramChunks) > 0)
+				assert.Equal(t, testData.expectedNativeHistogramChunks, testutil.ToFloat64(c.bucketCompactorMetrics.nativeHistogramChunksBytes) > 0)
 			})
-
-			// List back any (non deleted) block from the storage.
-			userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
-			fetcher, err := block.NewMetaFetcher(logger,
-				1,
-				userBucket,
-				fetcherDir,
-				reg,
-				nil,
-			)
-			require.NoError(t, err)
-			metas, partials, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
-			require.NoError(t, err)
-			require.Empty(t, partials)
-
-			// Sort blocks by MinTime and labels so that we get a stable comparison.
-			actual := sortMetasByMinTime(convertMetasMapToSlice(metas))
-
-			// Compare actual blocks with the expected ones.
-			require.Len(t, actual, len(expected))
-			for i, e := range expected {
-				assert.Equal(t, e.MinTime, actual[i].MinTime)
-				assert.Equal(t, e.MaxTime, actual[i].MaxTime)
-				assert.Equal(t, e.Compaction.Sources, actual[i].Compaction.Sources)
-				assert.Equal(t, e.Thanos.Labels, actual[i].Thanos.Labels)
-			}
-		})
+		}
 	}
 }
 
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+// tenantCompactionStatus is the compaction status of a tenant owned by the compactor.
+type tenantCompactionStatus struct {
+	// When the tenant has been owned by the compactor for the first time.
+	firstOwned time.Time
+
+	// When the compaction of the tenant last succeeded, zero if it never succeeded.
+	lastSuccess time.Time
+
+	// Creation time of the oldest level-1 block not compacted yet, zero if there's none.
+	oldestUncompactedBlock time.Time
+}
+
+// tenantsCompactionStatus tracks the compaction status of the tenants owned by the compactor, and exports
+// metrics of how far behind the compaction of each tenant is. The ages are computed at collection time.
+type tenantsCompactionStatus struct {
+	now func() time.Time
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantCompactionStatus
+
+	oldestUncompactedBlockAgeDesc *prometheus.Desc
+	lastSuccessAgeDesc            *prometheus.Desc
+}
+
+func newTenantsCompactionStatus() *tenantsCompactionStatus {
+	return &tenantsCompactionStatus{
+		now:     time.Now,
+		tenants: map[string]*tenantCompactionStatus{},
+
+		oldestUncompactedBlockAgeDesc: prometheus.NewDesc(
+			"cortex_compactor_tenant_oldest_uncompacted_block_age_seconds",
+			"Age of the oldest level-1 block of the tenant which hasn't been compacted yet, as of the last compaction of the tenant. 0 if all the level-1 blocks have been compacted.",
+			[]string{"user"}, nil),
+		lastSuccessAgeDesc: prometheus.NewDesc(
+			"cortex_compactor_tenant_last_successful_compaction_age_seconds",
+			"Time since the compaction of the tenant last succeeded.",
+			[]string{"user"}, nil),
+	}
+}
+
+// getOrCreate returns the status of the tenant, creating it if the tenant wasn't owned yet. Must be called with mtx held.
+func (s *tenantsCompactionStatus) getOrCreate(userID string) *tenantCompactionStatus {
+	status, ok := s.tenants[userID]
+	if !ok {
+		status = &tenantCompactionStatus{firstOwned: s.now()}
+		s.tenants[userID] = status
+	}
+	return status
+}
+
+// updateOldestUncompactedBlock updates the oldest level-1 block of the tenant not compacted yet, from the
+// metas of the blocks synced during the compaction of the tenant.
+func (s *tenantsCompactionStatus) updateOldestUncompactedBlock(userID string, metas map[ulid.ULID]*block.Meta) {
+	var oldest time.Time
+	for id, m := range metas {
+		if m.Compaction.Level != 1 {
+			continue
+		}
+		if created := ulid.Time(id.Time()); oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.getOrCreate(userID).oldestUncompactedBlock = oldest
+}
+
+// compactionSucceeded records a successful compaction of the tenant.
+func (s *tenantsCompactionStatus) compactionSucceeded(userID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.getOrCreate(userID).lastSuccess = s.now()
+}
+
+// tenantOwned records that the tenant is owned by the compactor.
+func (s *tenantsCompactionStatus) tenantOwned(userID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.getOrCreate(userID)
+}
+
+// retainTenants forgets the tenants which aren't owned by the compactor anymore.
+func (s *tenantsCompactionStatus) retainTenants(owned map[string]struct{}) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for userID := range s.tenants {
+		if _, ok := owned[userID]; !ok {
+			delete(s.tenants, userID)
+		}
+	}
+}
+
+// laggingTenant is a tenant whose compaction is behind.
+type laggingTenant struct {
+	UserID                           string     `json:"user"`
+	OldestUncompactedBlockAgeSeconds float64    `json:"oldest_uncompacted_block_age_seconds"`
+	LastSuccessfulCompaction         *time.Time `json:"last_successful_compaction,omitempty"`
+	// Time since the last successful compaction, or since the tenant is owned by the compactor if it never succeeded.
+	SecondsSinceLastSuccessfulCompaction float64 `json:"seconds_since_last_successful_compaction"`
+}
+
+// laggingTenants returns the tenants whose oldest level-1 block not compacted yet, or last successful
+// compaction, are older than threshold, sorted by user ID.
+func (s *tenantsCompactionStatus) laggingTenants(threshold time.Duration) []laggingTenant {
+	now := s.now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	lagging := []laggingTenant{}
+	for userID, status := range s.tenants {
+		t := laggingTenant{UserID: userID}
+		if !status.oldestUncompactedBlock.IsZero() {
+			t.OldestUncompactedBlockAgeSeconds = now.Sub(status.oldestUncompactedBlock).Seconds()
+		}
+		if status.lastSuccess.IsZero() {
+			t.SecondsSinceLastSuccessfulCompaction = now.Sub(status.firstOwned).Seconds()
+		} else {
+			lastSuccess := status.lastSuccess
+			t.LastSuccessfulCompaction = &lastSuccess
+			t.SecondsSinceLastSuccessfulCompaction = now.Sub(lastSuccess).Seconds()
+		}
+
+		if t.OldestUncompactedBlockAgeSeconds > threshold.Seconds() || t.SecondsSinceLastSuccessfulCompaction > threshold.Seconds() {
+			lagging = append(lagging, t)
+		}
+	}
+
+	sort.Slice(lagging, func(i, j int) bool {
+		return lagging[i].UserID < lagging[j].UserID
+	})
+	return lagging
+}
+
+func (s *tenantsCompactionStatus) Describe(out chan<- *prometheus.Desc) {
+	out <- s.oldestUncompactedBlockAgeDesc
+	out <- s.lastSuccessAgeDesc
+}
+
+func (s *tenantsCompactionStatus) Collect(out chan<- prometheus.Metric) {
+	now := s.now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for userID, status := range s.tenants {
+		age := 0.0
+		if !status.oldestUncompactedBlock.IsZero() {
+			age = now.Sub(status.oldestUncompactedBlock).Seconds()
+		}
+		out <- prometheus.MustNewConstMetric(s.oldestUncompactedBlockAgeDesc, prometheus.GaugeValue, age, userID)
+
+		if !status.lastSuccess.IsZero() {
+			out <- prometheus.MustNewConstMetric(s.lastSuccessAgeDesc, prometheus.GaugeValue, now.Sub(status.lastSuccess).Seconds(), userID)
+		}
+	}
+}
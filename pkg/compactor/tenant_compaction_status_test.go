@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestTenantsCompactionStatus(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTenantsCompactionStatus()
+	s.now = func() time.Time { return now }
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(s)
+
+	newMeta := func(created time.Time, level int) (ulid.ULID, *block.Meta) {
+		id := ulid.MustNew(ulid.Timestamp(created), rand.Reader)
+		return id, &block.Meta{BlockMeta: tsdb.BlockMeta{ULID: id, Compaction: tsdb.BlockMetaCompaction{Level: level}}}
+	}
+	metas := func(ms ...*block.Meta) map[ulid.ULID]*block.Meta {
+		out := map[ulid.ULID]*block.Meta{}
+		for _, m := range ms {
+			out[m.ULID] = m
+		}
+		return out
+	}
+
+	_, oldLevel1 := newMeta(now.Add(-20*time.Hour), 1)
+	_, newLevel1 := newMeta(now.Add(-time.Hour), 1)
+	_, oldLevel2 := newMeta(now.Add(-48*time.Hour), 2)
+
+	// user-1 is up to date, user-2 has an old level-1 block, user-3 never succeeded.
+	s.tenantOwned("user-1")
+	s.updateOldestUncompactedBlock("user-1", metas(newLevel1, oldLevel2))
+	s.compactionSucceeded("user-1")
+	s.tenantOwned("user-2")
+	s.updateOldestUncompactedBlock("user-2", metas(oldLevel1, newLevel1, oldLevel2))
+	s.compactionSucceeded("user-2")
+	s.tenantOwned("user-3")
+	s.updateOldestUncompactedBlock("user-3", metas(oldLevel2))
+
+	now = now.Add(13 * time.Hour)
+	s.compactionSucceeded("user-1")
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_tenant_last_successful_compaction_age_seconds Time since the compaction of the tenant last succeeded.
+		# TYPE cortex_compactor_tenant_last_successful_compaction_age_seconds gauge
+		cortex_compactor_tenant_last_successful_compaction_age_seconds{user="user-1"} 0
+		cortex_compactor_tenant_last_successful_compaction_age_seconds{user="user-2"} 46800
+		# HELP cortex_compactor_tenant_oldest_uncompacted_block_age_seconds Age of the oldest level-1 block of the tenant which hasn't been compacted yet, as of the last compaction of the tenant. 0 if all the level-1 blocks have been compacted.
+		# TYPE cortex_compactor_tenant_oldest_uncompacted_block_age_seconds gauge
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-1"} 50400
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-2"} 118800
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-3"} 0
+	`)))
+
+	lagging := s.laggingTenants(12 * time.Hour)
+	require.Len(t, lagging, 3)
+
+	lagging = s.laggingTenants(13 * time.Hour)
+	require.Len(t, lagging, 2)
+	assert.Equal(t, "user-1", lagging[0].UserID)
+	assert.Equal(t, 50400.0, lagging[0].OldestUncompactedBlockAgeSeconds)
+	assert.Equal(t, 0.0, lagging[0].SecondsSinceLastSuccessfulCompaction)
+	assert.Equal(t, "user-2", lagging[1].UserID)
+	assert.Equal(t, 118800.0, lagging[1].OldestUncompactedBlockAgeSeconds)
+	require.NotNil(t, lagging[1].LastSuccessfulCompaction)
+	assert.Equal(t, 46800.0, lagging[1].SecondsSinceLastSuccessfulCompaction)
+
+	// The tenants which never succeeded are lagging since they're owned by the compactor.
+	lagging = s.laggingTenants(12*time.Hour + 30*time.Minute)
+	require.Len(t, lagging, 3)
+	assert.Equal(t, "user-3", lagging[2].UserID)
+	assert.Nil(t, lagging[2].LastSuccessfulCompaction)
+	assert.Equal(t, 46800.0, lagging[2].SecondsSinceLastSuccessfulCompaction)
+
+	// The tenants not owned anymore are forgotten.
+	s.retainTenants(map[string]struct{}{"user-2": {}})
+	lagging = s.laggingTenants(time.Hour)
+	require.Len(t, lagging, 1)
+	assert.Equal(t, "user-2", lagging[0].UserID)
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package continuoustest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+const (
+	churnMetricName = "mimir_continuous_test_churn"
+
+	// churnQueryLookback is the PromQL lookback period: the series retired while the test wasn't running,
+	// and so without a staleness marker, are returned by the queries for up to this period.
+	churnQueryLookback = 5 * time.Minute
+)
+
+type ChurnSeriesTestConfig struct {
+	Enabled       bool
+	NumSeries     int
+	ChurnFraction float64
+	ChurnInterval time.Duration
+}
+
+func (cfg *ChurnSeriesTestConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "tests.churn-series-test.enabled", false, "Set to true to run the test continuously replacing a fraction of its series, simulating the churn of the series of restarting pods.")
+	f.IntVar(&cfg.NumSeries, "tests.churn-series-test.num-series", 1000, "Number of series written at any time by the churn test.")
+	f.Float64Var(&cfg.ChurnFraction, "tests.churn-series-test.churn-fraction", 0.1, "Fraction of the series of the churn test replaced by new series every churn interval.")
+	f.DurationVar(&cfg.ChurnInterval, "tests.churn-series-test.churn-interval", 10*time.Minute, "How frequently the churn test replaces a fraction of its series. Must be a multiple of 20s, the interval between the written samples.")
+}
+
+// ChurnSeriesTest continuously writes a fixed number of series, and replaces a fraction of them every churn interval
+// with new series. The replaced series are retired writing a staleness marker, like Prometheus does. The test checks
+// that the sum of the series returned by the queries matches the number of series written at any time, so it fails
+// if the retired series are still returned.
+type ChurnSeriesTest struct {
+	name    string
+	cfg     ChurnSeriesTestConfig
+	client  MimirClient
+	logger  log.Logger
+	metrics *TestMetrics
+
+	records MetricHistory
+}
+
+func NewChurnSeriesTest(cfg ChurnSeriesTestConfig, client MimirClient, logger log.Logger, reg prometheus.Registerer) *ChurnSeriesTest {
+	const name = "churn-series"
+
+	return &ChurnSeriesTest{
+		name:    name,
+		cfg:     cfg,
+		client:  client,
+		logger:  log.With(logger, "test", name),
+		metrics: NewTestMetrics(name, reg),
+	}
+}
+
+// Name implements Test.
+func (t *ChurnSeriesTest) Name() string {
+	return t.name
+}
+
+// Init implements Test.
+func (t *ChurnSeriesTest) Init(context.Context, time.Time) error {
+	if t.cfg.NumSeries <= 0 {
+		return errors.New("the number of series of the churn test must be greater than 0")
+	}
+	if t.cfg.ChurnFraction <= 0 || t.cfg.ChurnFraction > 1 {
+		return errors.New("the churn fraction must be greater than 0 and lower than or equal to 1")
+	}
+	if t.cfg.ChurnInterval <= 0 || t.cfg.ChurnInterval%writeInterval != 0 {
+		return fmt.Errorf("the churn interval must be a multiple of %s", writeInterval)
+	}
+	return nil
+}
+
+// Run implements Test.
+func (t *ChurnSeriesTest) Run(ctx context.Context, now time.Time) error {
+	// Send a sample for each series per second at most. The burst also allows the staleness markers of the
+	// retired series written at the beginning of each churn interval.
+	writeLimiter := rate.NewLimiter(rate.Limit(t.cfg.NumSeries), 2*t.cfg.NumSeries)
+
+	errs := new(multierror.MultiError)
+
+	// Write series for each expected timestamp until now.
+	for timestamp := t.nextWriteTimestamp(now); !timestamp.After(now); timestamp = t.nextWriteTimestamp(now) {
+		series := t.generateSeries(timestamp)
+		if err := writeLimiter.WaitN(ctx, len(series)); err != nil {
+			// Context has been canceled, so we should interrupt.
+			errs.Add(err)
+			return errs.Err()
+		}
+
+		if err := t.writeSamples(ctx, timestamp, series); err != nil {
+			errs.Add(err)
+			break
+		}
+	}
+
+	// Skip the queries until the series retired without a staleness marker can't be returned anymore.
+	if t.records.queryMinTime.IsZero() || t.records.queryMaxTime.Before(t.records.queryMinTime) {
+		level.Info(t.logger).Log("msg", "Skipped queries because there's no valid time range to query yet")
+		return errs.Err()
+	}
+
+	query := fmt.Sprintf("sum(%s)", churnMetricName)
+	start := maxTime(t.records.queryMinTime, alignTimestampToInterval(now.Add(-time.Hour), writeInterval))
+	for _, resultsCacheEnabled := range []bool{true, false} {
+		errs.Add(t.runRangeQueryAndVerifyResult(ctx, query, start, t.records.queryMaxTime, resultsCacheEnabled))
+		errs.Add(t.runInstantQueryAndVerifyResult(ctx, query, t.records.queryMaxTime, resultsCacheEnabled))
+	}
+
+	return errs.Err()
+}
+
+func (t *ChurnSeriesTest) nextWriteTimestamp(now time.Time) time.Time {
+	if t.records.lastWrittenTimestamp.IsZero() {
+		return alignTimestampToInterval(now, writeInterval)
+	}
+
+	return t.records.lastWrittenTimestamp.Add(writeInterval)
+}
+
+// churnedSeriesPerInterval returns the number of series replaced every churn interval.
+func (t *ChurnSeriesTest) churnedSeriesPerInterval() int {
+	n := int(math.Ceil(t.cfg.ChurnFraction * float64(t.cfg.NumSeries)))
+	if n > t.cfg.NumSeries {
+		return t.cfg.NumSeries
+	}
+	return n
+}
+
+// seriesGeneration returns the generation of the series with the ID during the churn interval. The series are replaced
+// in a round-robin fashion, and the generation of a series is the churn interval it was last replaced at.
+// The generation only depends on the timestamp, so the test writes the same series after a restart.
+func (t *ChurnSeriesTest) seriesGeneration(seriesID int, interval int64) int64 {
+	churned := int64(t.churnedSeriesPerInterval())
+	numSeries := int64(t.cfg.NumSeries)
+
+	// All the series are replaced within this number of intervals.
+	maxIntervals := (numSeries + churned - 1) / churned
+	for i := interval; i > interval-maxIntervals; i-- {
+		if offset := ((int64(seriesID)-i*churned)%numSeries + numSeries) % numSeries; offset < churned {
+			return i
+		}
+	}
+	return interval - maxIntervals
+}
+
+// generateSeries returns the series to write at the timestamp. At the beginning of each churn interval, the series
+// replaced by new ones are retired writing a staleness marker.
+func (t *ChurnSeriesTest) generateSeries(timestamp time.Time) []prompb.TimeSeries {
+	ts := timestamp.UnixMilli()
+	intervalMs := t.cfg.ChurnInterval.Milliseconds()
+	interval := ts / intervalMs
+	churnStart := ts%intervalMs == 0
+
+	out := make([]prompb.TimeSeries, 0, t.cfg.NumSeries+t.churnedSeriesPerInterval())
+	for i := 0; i < t.cfg.NumSeries; i++ {
+		generation := t.seriesGeneration(i, interval)
+		out = append(out, churnSeries(i, generation, 1, ts))
+
+		if churnStart && generation == interval {
+			out = append(out, churnSeries(i, t.seriesGeneration(i, interval-1), math.Float64frombits(value.StaleNaN), ts))
+		}
+	}
+
+	return out
+}
+
+func churnSeries(seriesID int, generation int64, v float64, ts int64) prompb.TimeSeries {
+	return prompb.TimeSeries{
+		Labels: []prompb.Label{
+			{Name: "__name__", Value: churnMetricName},
+			{Name: "generation", Value: strconv.FormatInt(generation, 10)},
+			{Name: "series_id", Value: strconv.Itoa(seriesID)},
+		},
+		Samples: []prompb.Sample{{Value: v, Timestamp: ts}},
+	}
+}
+
+func (t *ChurnSeriesTest) writeSamples(ctx context.Context, timestamp time.Time, series []prompb.TimeSeries) error {
+	sp, ctx := spanlogger.NewWithLogger(ctx, t.logger, "ChurnSeriesTest.writeSamples")
+	defer sp.Finish()
+	logger := log.With(sp, "timestamp", timestamp.String(), "num_series", len(series))
+
+	statusCode, err := t.client.WriteSeries(ctx, series)
+
+	t.metrics.writesTotal.WithLabelValues(floatTypeLabel).Inc()
+	if statusCode/100 != 2 {
+		t.metrics.writesFailedTotal.WithLabelValues(strconv.Itoa(statusCode), floatTypeLabel).Inc()
+		level.Warn(logger).Log("msg", "Failed to remote write series", "status_code", statusCode, "err", err)
+	} else {
+		level.Debug(logger).Log("msg", "Remote write series succeeded")
+	}
+
+	// If the write request failed because of a 4xx error, retrying the request isn't expected to succeed.
+	// We keep writing the next interval, but we reset the query timestamp because the staleness markers
+	// may have been not written.
+	if statusCode/100 == 4 {
+		t.records.lastWrittenTimestamp = timestamp
+		t.records.queryMinTime = time.Time{}
+		t.records.queryMaxTime = time.Time{}
+		return nil
+	}
+
+	// If the write request failed because of a network or 5xx error, we'll retry to write series
+	// in the next test run.
+	if err != nil {
+		return errors.Wrap(err, "failed to remote write series")
+	}
+	if statusCode/100 != 2 {
+		return errors.Wrapf(err, "remote write series failed with status code %d", statusCode)
+	}
+
+	// The write request succeeded.
+	t.records.lastWrittenTimestamp = timestamp
+	t.records.queryMaxTime = timestamp
+	if t.records.queryMinTime.IsZero() {
+		t.records.queryMinTime = timestamp.Add(churnQueryLookback)
+	}
+
+	return nil
+}
+
+func (t *ChurnSeriesTest) runRangeQueryAndVerifyResult(ctx context.Context, query string, start, end time.Time, resultsCacheEnabled bool) error {
+	step := getQueryStep(start, end, writeInterval)
+
+	sp, ctx := spanlogger.NewWithLogger(ctx, t.logger, "ChurnSeriesTest.runRangeQueryAndVerifyResult")
+	defer sp.Finish()
+
+	logger := log.With(sp, "query", query, "start", start.UnixMilli(), "end", end.UnixMilli(), "step", step, "results_cache", strconv.FormatBool(resultsCacheEnabled))
+	level.Debug(logger).Log("msg", "Running range query")
+
+	t.metrics.queriesTotal.WithLabelValues(floatTypeLabel).Inc()
+	matrix, err := t.client.QueryRange(ctx, query, start, end, step, WithResultsCacheEnabled(resultsCacheEnabled))
+	if err != nil {
+		t.metrics.queriesFailedTotal.WithLabelValues(floatTypeLabel).Inc()
+		level.Warn(logger).Log("msg", "Failed to execute range query", "err", err)
+		return errors.Wrap(err, "failed to execute range query")
+	}
+
+	t.metrics.queryResultChecksTotal.WithLabelValues(floatTypeLabel).Inc()
+	if _, err := verifySamplesSum(matrix, t.cfg.NumSeries, step, generateChurnValue, nil); err != nil {
+		t.metrics.queryResultChecksFailedTotal.WithLabelValues(floatTypeLabel).Inc()
+		level.Warn(logger).Log("msg", "Range query result check failed", "err", err)
+		return errors.Wrap(err, "range query result check failed")
+	}
+	return nil
+}
+
+func (t *ChurnSeriesTest) runInstantQueryAndVerifyResult(ctx context.Context, query string, ts time.Time, resultsCacheEnabled bool) error {
+	sp, ctx := spanlogger.NewWithLogger(ctx, t.logger, "ChurnSeriesTest.runInstantQueryAndVerifyResult")
+	defer sp.Finish()
+
+	logger := log.With(sp, "query", query, "ts", ts.UnixMilli(), "results_cache", strconv.FormatBool(resultsCacheEnabled))
+	level.Debug(logger).Log("msg", "Running instant query")
+
+	t.metrics.queriesTotal.WithLabelValues(floatTypeLabel).Inc()
+	vector, err := t.client.Query(ctx, query, ts, WithResultsCacheEnabled(resultsCacheEnabled))
+	if err != nil {
+		t.metrics.queriesFailedTotal.WithLabelValues(floatTypeLabel).Inc()
+		level.Warn(logger).Log("msg", "Failed to execute instant query", "err", err)
+		return errors.Wrap(err, "failed to execute instant query")
+	}
+
+	t.metrics.queryResultChecksTotal.WithLabelValues(floatTypeLabel).Inc()
+	if len(vector) != 1 || !compareFloatValues(float64(vector[0].Value), float64(t.cfg.NumSeries), maxComparisonDeltaFloat) {
+		t.metrics.queryResultChecksFailedTotal.WithLabelValues(floatTypeLabel).Inc()
+		err := fmt.Errorf("expected a single series with value %d but got %s", t.cfg.NumSeries, vector.String())
+		level.Warn(logger).Log("msg", "Instant query result check failed", "err", err)
+		return errors.Wrap(err, "instant query result check failed")
+	}
+	return nil
+}
+
+// generateChurnValue returns the value of the samples of the series written by the churn test.
+func generateChurnValue(time.Time) float64 {
+	return 1
+}
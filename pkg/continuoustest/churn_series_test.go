@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package continuoustest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	util_test "github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestChurnSeriesTest_Init(t *testing.T) {
+	for name, testData := range map[string]struct {
+		modify      func(cfg *ChurnSeriesTestConfig)
+		expectedErr string
+	}{
+		"valid config": {
+			modify: func(*ChurnSeriesTestConfig) {},
+		},
+		"no series": {
+			modify:      func(cfg *ChurnSeriesTestConfig) { cfg.NumSeries = 0 },
+			expectedErr: "the number of series of the churn test must be greater than 0",
+		},
+		"no churn": {
+			modify:      func(cfg *ChurnSeriesTestConfig) { cfg.ChurnFraction = 0 },
+			expectedErr: "the churn fraction must be greater than 0 and lower than or equal to 1",
+		},
+		"churn interval not aligned to the write interval": {
+			modify:      func(cfg *ChurnSeriesTestConfig) { cfg.ChurnInterval = 30 * time.Second },
+			expectedErr: "the churn interval must be a multiple of 20s",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := ChurnSeriesTestConfig{}
+			flagext.DefaultValues(&cfg)
+			testData.modify(&cfg)
+
+			err := NewChurnSeriesTest(cfg, &ClientMock{}, log.NewNopLogger(), prometheus.NewPedanticRegistry()).Init(context.Background(), time.Now())
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChurnSeriesTest_generateSeries(t *testing.T) {
+	cfg := ChurnSeriesTestConfig{NumSeries: 10, ChurnFraction: 0.3, ChurnInterval: time.Minute}
+	test := NewChurnSeriesTest(cfg, &ClientMock{}, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+	type seriesKey struct {
+		seriesID, generation string
+	}
+	parse := func(series []prompb.TimeSeries) (active, stale map[seriesKey]bool) {
+		active, stale = map[seriesKey]bool{}, map[seriesKey]bool{}
+		for _, s := range series {
+			require.Len(t, s.Samples, 1)
+			key := seriesKey{seriesID: s.Labels[2].Value, generation: s.Labels[1].Value}
+			if value.IsStaleNaN(s.Samples[0].Value) {
+				stale[key] = true
+			} else {
+				assert.Equal(t, 1.0, s.Samples[0].Value)
+				active[key] = true
+			}
+		}
+		return active, stale
+	}
+
+	var prevActive map[seriesKey]bool
+	for interval := int64(100); interval < 110; interval++ {
+		// No series is retired within the churn interval.
+		active, stale := parse(test.generateSeries(time.UnixMilli(interval*time.Minute.Milliseconds() + writeInterval.Milliseconds())))
+		assert.Len(t, active, 10)
+		assert.Empty(t, stale)
+
+		// The retired series are written a staleness marker at the beginning of the churn interval.
+		startActive, startStale := parse(test.generateSeries(time.UnixMilli(interval * time.Minute.Milliseconds())))
+		assert.Equal(t, active, startActive)
+		assert.Len(t, startStale, 3)
+
+		if prevActive != nil {
+			for key := range startStale {
+				assert.True(t, prevActive[key], "the retired series %v must have been active in the previous churn interval", key)
+				assert.False(t, active[key], "the retired series %v must not be active anymore", key)
+			}
+
+			// The replaced series are the retired ones, with a new generation.
+			replaced := 0
+			for key := range prevActive {
+				if !active[key] {
+					replaced++
+					assert.True(t, startStale[key])
+				}
+			}
+			assert.Equal(t, 3, replaced)
+		}
+		prevActive = active
+	}
+}
+
+func TestChurnSeriesTest_Run(t *testing.T) {
+	const numSeries = 10
+
+	cfg := ChurnSeriesTestConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+	cfg.NumSeries = numSeries
+	cfg.ChurnInterval = time.Minute
+
+	churnValues := func(start, end time.Time, v float64) model.Matrix {
+		ss := &model.SampleStream{}
+		for ts := start; !ts.After(end); ts = ts.Add(writeInterval) {
+			ss.Values = append(ss.Values, model.SamplePair{Timestamp: model.TimeFromUnixNano(ts.UnixNano()), Value: model.SampleValue(v)})
+		}
+		return model.Matrix{ss}
+	}
+
+	t.Run("should skip the queries until the series retired without staleness marker can't be returned anymore", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewChurnSeriesTest(cfg, client, log.NewNopLogger(), reg)
+
+		require.NoError(t, test.Run(context.Background(), time.Unix(1000, 0)))
+		client.AssertNumberOfCalls(t, "WriteSeries", 1)
+		client.AssertNumberOfCalls(t, "QueryRange", 0)
+		client.AssertNumberOfCalls(t, "Query", 0)
+		assert.Equal(t, time.Unix(1000, 0).Add(churnQueryLookback), test.records.queryMinTime)
+	})
+
+	for name, testData := range map[string]struct {
+		queriedValue float64
+		expectedErr  bool
+	}{
+		"should succeed if the queries return the written series": {
+			queriedValue: numSeries,
+		},
+		"should fail if the queries return the retired series": {
+			queriedValue: numSeries + 3,
+			expectedErr:  true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Unix(1300, 0)
+			end := time.Unix(1320, 0)
+
+			client := &ClientMock{}
+			client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+			client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(churnValues(start, end, testData.queriedValue), nil)
+			client.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Vector{{Value: model.SampleValue(testData.queriedValue), Timestamp: model.TimeFromUnixNano(end.UnixNano())}}, nil)
+
+			reg := prometheus.NewPedanticRegistry()
+			test := NewChurnSeriesTest(cfg, client, log.NewNopLogger(), reg)
+			test.records.lastWrittenTimestamp = time.Unix(1240, 0)
+			test.records.queryMinTime = start
+
+			err := test.Run(context.Background(), end)
+			if testData.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// A write request for each write interval since the last written one.
+			client.AssertNumberOfCalls(t, "WriteSeries", 4)
+			client.AssertCalled(t, "WriteSeries", mock.Anything, test.generateSeries(time.Unix(1280, 0)))
+			client.AssertCalled(t, "QueryRange", mock.Anything, "sum(mimir_continuous_test_churn)", start, end, writeInterval, mock.Anything)
+			client.AssertCalled(t, "Query", mock.Anything, "sum(mimir_continuous_test_churn)", end, mock.Anything)
+
+			em := util_test.ExpectedMetrics{Context: emCtx}
+			em.Add("mimir_continuous_test_writes_total", `test="churn-series",type="float"`, 4)
+			em.Add("mimir_continuous_test_queries_total", `test="churn-series",type="float"`, 4)
+			em.Add("mimir_continuous_test_query_result_checks_total", `test="churn-series",type="float"`, 4)
+			if testData.expectedErr {
+				em.Add("mimir_continuous_test_query_result_checks_failed_total", `test="churn-series",type="float"`, 4)
+			} else {
+				em.AddEmpty("mimir_continuous_test_query_result_checks_failed_total")
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, em.GetOutput(), em.GetNames()...))
+		})
+	}
+}
+
+func TestChurnSeries(t *testing.T) {
+	s := churnSeries(3, 7, math.Float64frombits(value.StaleNaN), 1000)
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: churnMetricName},
+		{Name: "generation", Value: "7"},
+		{Name: "series_id", Value: "3"},
+	}, s.Labels)
+	assert.True(t, value.IsStaleNaN(s.Samples[0].Value))
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/httpgrpc"
+	"golang.org/x/exp/slices"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// ActiveSeries returns the labels of the active series matching the matchers, sorted and deduplicated across the
+// ingesters and zones.
+func (d *Distributor) ActiveSeries(ctx context.Context, matchers []*labels.Matcher) ([]labels.Labels, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationSet, err := d.GetIngesters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we have a single zone, we require all ingesters to respond, like for the label values cardinality.
+	if replicationSet.ZoneCount() == 1 {
+		replicationSet.MaxErrors = 0
+	}
+
+	matchersProto, err := ingester_client.ToLabelMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+	req := &ingester_client.ActiveSeriesRequest{Matchers: matchersProto}
+
+	merger := &activeSeriesMerger{
+		series:         map[uint64][]labels.Labels{},
+		sizeLimitBytes: d.limits.ActiveSeriesResultsMaxSizeBytes(userID),
+	}
+	_, err = ring.DoUntilQuorum[struct{}](ctx, replicationSet, d.cfg.MinimizeIngesterRequests, func(ctx context.Context, desc *ring.InstanceDesc) (struct{}, error) {
+		poolClient, err := d.ingesterPool.GetClientFor(desc.Addr)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		stream, err := poolClient.(ingester_client.IngesterClient).ActiveSeries(ctx, req)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+
+		return struct{}{}, merger.collect(stream)
+	}, func(struct{}) {})
+	if err != nil {
+		return nil, err
+	}
+
+	return merger.result()
+}
+
+// activeSeriesMerger merges the active series streamed by the ingesters, deduplicating the series replicated to
+// multiple ingesters and zones. The series are indexed by hash, and the labels are compared on hash collisions.
+type activeSeriesMerger struct {
+	lock             sync.Mutex
+	series           map[uint64][]labels.Labels
+	sizeLimitBytes   int
+	currentSizeBytes int
+
+	// err is set once the size limit is exceeded, so that the request fails even if the quorum of the ingesters
+	// has been reached without the failing ingester.
+	err error
+}
+
+func (m *activeSeriesMerger) collect(stream ingester_client.Ingester_ActiveSeriesClient) error {
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := m.add(resp.Metric); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *activeSeriesMerger) add(metrics []*mimirpb.Metric) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, metric := range metrics {
+		lbls := mimirpb.FromLabelAdaptersToLabels(metric.Labels)
+		hash := labels.StableHash(lbls)
+		if m.contains(hash, lbls) {
+			continue
+		}
+
+		m.currentSizeBytes += metric.Size()
+		if m.sizeLimitBytes > 0 && m.currentSizeBytes > m.sizeLimitBytes {
+			m.err = httpgrpc.Errorf(http.StatusUnprocessableEntity, "size of distinct active series is greater than %v bytes", m.sizeLimitBytes)
+			return m.err
+		}
+		// The labels are copied, since they reference the buffer of the received message.
+		m.series[hash] = append(m.series[hash], lbls.Copy())
+	}
+	return nil
+}
+
+func (m *activeSeriesMerger) contains(hash uint64, lbls labels.Labels) bool {
+	for _, existing := range m.series[hash] {
+		if labels.Equal(existing, lbls) {
+			return true
+		}
+	}
+	return false
+}
+
+// result returns the sorted active series. The lock is acquired, since some ingesters responses might still be
+// processed once the quorum of instances has been reached.
+func (m *activeSeriesMerger) result() ([]labels.Labels, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	result := make([]labels.Labels, 0, len(m.series))
+	for _, series := range m.series {
+		result = append(result, series...)
+	}
+	slices.SortFunc(result, func(a, b labels.Labels) bool {
+		return labels.Compare(a, b) < 0
+	})
+	return result, nil
+}
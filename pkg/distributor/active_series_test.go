@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_ActiveSeries(t *testing.T) {
+	fixtures := []labels.Labels{
+		labels.FromStrings(labels.MetricName, "test_1", "status", "200"),
+		labels.FromStrings(labels.MetricName, "test_1", "status", "500"),
+		labels.FromStrings(labels.MetricName, "test_2"),
+	}
+
+	tests := map[string]struct {
+		zones            []string
+		matchers         []*labels.Matcher
+		sizeLimitBytes   int
+		expectedSeries   []labels.Labels
+		expectedErrorMsg string
+	}{
+		"should return the deduplicated series matching the matchers": {
+			matchers:       []*labels.Matcher{mustNewMatcher(labels.MatchEqual, labels.MetricName, "test_1")},
+			expectedSeries: fixtures[:2],
+		},
+		"should return the deduplicated series if zone awareness is enabled": {
+			zones:          []string{"A", "B", "C"},
+			matchers:       []*labels.Matcher{mustNewMatcher(labels.MatchRegexp, labels.MetricName, "test_.*")},
+			expectedSeries: fixtures,
+		},
+		"should return an empty response if no series match": {
+			matchers:       []*labels.Matcher{mustNewMatcher(labels.MatchEqual, labels.MetricName, "unknown")},
+			expectedSeries: []labels.Labels{},
+		},
+		"should fail if the series exceed the size limit": {
+			matchers:         []*labels.Matcher{mustNewMatcher(labels.MatchRegexp, labels.MetricName, "test_.*")},
+			sizeLimitBytes:   30,
+			expectedErrorMsg: "size of distinct active series is greater than 30 bytes",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			if tc.sizeLimitBytes > 0 {
+				limits.ActiveSeriesResultsMaxSizeBytes = tc.sizeLimitBytes
+			}
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:      6,
+				happyIngesters:    6,
+				numDistributors:   1,
+				replicationFactor: 3,
+				ingesterZones:     tc.zones,
+				limits:            limits,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			for _, series := range fixtures {
+				_, err := ds[0].Push(ctx, mockWriteRequest(series, 1, 100000))
+				require.NoError(t, err)
+			}
+
+			series, err := ds[0].ActiveSeries(ctx, tc.matchers)
+			if tc.expectedErrorMsg != "" {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusUnprocessableEntity), resp.Code)
+				assert.Equal(t, tc.expectedErrorMsg, string(resp.Body))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSeries, series)
+
+			if len(tc.zones) == 0 {
+				// With a single zone, all the ingesters are queried.
+				assert.Equal(t, 6, countMockIngestersCalls(ingesters, "ActiveSeries"))
+			}
+		})
+	}
+}
+
+func TestActiveSeriesMerger_ShouldCompareTheLabelsOnHashCollisions(t *testing.T) {
+	// The labels of two series are compared when their hashes collide, so the series are stored under the same hash
+	// to simulate the collision.
+	first := labels.FromStrings(labels.MetricName, "test_1")
+	second := labels.FromStrings(labels.MetricName, "test_2")
+	merger := &activeSeriesMerger{series: map[uint64][]labels.Labels{labels.StableHash(second): {first}}}
+
+	require.NoError(t, merger.add([]*mimirpb.Metric{{Labels: mimirpb.FromLabelsToLabelAdapters(second)}}))
+	require.NoError(t, merger.add([]*mimirpb.Metric{{Labels: mimirpb.FromLabelsToLabelAdapters(second)}}))
+
+	series, err := merger.result()
+	require.NoError(t, err)
+	assert.Equal(t, []labels.Labels{first, second}, series)
+}
@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// AggregationConfig configures the aggregation of the received series according to the tenants aggregation rules.
+type AggregationConfig struct {
+	Enabled       bool          `yaml:"enabled" category:"experimental"`
+	FlushInterval time.Duration `yaml:"flush_interval" category:"experimental"`
+	InstanceLabel string        `yaml:"instance_label" category:"experimental"`
+}
+
+func (cfg *AggregationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.aggregation.enabled", false, "True to aggregate the received series matching the tenants aggregation rules, configured with the aggregation_rules limit, before writing them to the ingesters. The aggregation state is kept in the distributors memory, and lost when a distributor shuts down without being able to write it.")
+	f.DurationVar(&cfg.FlushInterval, "distributor.aggregation.flush-interval", time.Minute, "How frequently the aggregated series are written to the ingesters. Each aggregated series gets one sample per interval, computed from the latest sample received during the interval for each of the series it aggregates.")
+	f.StringVar(&cfg.InstanceLabel, "distributor.aggregation.instance-label", "aggregated_by", "Label added to the aggregated series, set to the ID of the distributor which aggregated them. Each distributor only aggregates the series it receives, so the aggregated series written by different distributors are kept apart by this label, and need to be aggregated again at query time.")
+}
+
+func (cfg *AggregationConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FlushInterval <= 0 {
+		return fmt.Errorf("the aggregation flush interval must be greater than 0")
+	}
+	if !model.LabelName(cfg.InstanceLabel).IsValid() || cfg.InstanceLabel == model.MetricNameLabel {
+		return fmt.Errorf("invalid aggregation instance label %q", cfg.InstanceLabel)
+	}
+	return nil
+}
+
+// aggregatedCounterStaleIntervals is the number of flush intervals after which a counter which hasn't received
+// any sample stops being aggregated. If it receives samples again, it's aggregated as a new counter.
+const aggregatedCounterStaleIntervals = 5
+
+// aggregatedCounter is the state of a counter aggregated by a sum_counters aggregation rule.
+type aggregatedCounter struct {
+	last        mimirpb.Sample
+	lastFlushID uint64
+}
+
+// aggregatedSeries is the state of a series aggregating the series matching an aggregation rule.
+type aggregatedSeries struct {
+	labels      []mimirpb.LabelAdapter
+	aggregation string
+
+	// Latest value received during the current interval for each aggregated series, by labels hash.
+	// Only used by the sum and avg aggregations, and reset at each flush.
+	latest map[uint64]mimirpb.Sample
+
+	// State of each aggregated counter, by labels hash, and sum of their increases since the aggregation
+	// started. Only used by the sum_counters aggregation, and kept across flushes.
+	counters map[uint64]*aggregatedCounter
+	total    float64
+}
+
+func (s *aggregatedSeries) value() float64 {
+	if s.aggregation == validation.AggregationSumCounters {
+		return s.total
+	}
+
+	sum := 0.0
+	for _, sample := range s.latest {
+		sum += sample.Value
+	}
+	if s.aggregation == validation.AggregationAvg {
+		return sum / float64(len(s.latest))
+	}
+	return sum
+}
+
+// addCounterSample adds the increase of the counter since its previous sample to the total. A value lower than
+// the previous one is a counter reset, so the whole value is the increase. The first sample of a counter only
+// sets its starting value, because its increase since it has been created is unknown.
+func (s *aggregatedSeries) addCounterSample(inputHash uint64, sample mimirpb.Sample, flushID uint64) {
+	counter, ok := s.counters[inputHash]
+	if !ok {
+		s.counters[inputHash] = &aggregatedCounter{last: sample, lastFlushID: flushID}
+		return
+	}
+	counter.lastFlushID = flushID
+	if sample.TimestampMs <= counter.last.TimestampMs {
+		return
+	}
+
+	if sample.Value >= counter.last.Value {
+		s.total += sample.Value - counter.last.Value
+	} else {
+		s.total += sample.Value
+	}
+	counter.last = sample
+}
+
+// seriesAggregator keeps the state of the series aggregated by the tenants aggregation rules, and periodically
+// flushes the aggregated series.
+type seriesAggregator struct {
+	services.Service
+
+	logger        log.Logger
+	instanceLabel mimirpb.LabelAdapter
+	flush         func(ctx context.Context, userID string, series []mimirpb.PreallocTimeseries) error
+
+	mtx sync.Mutex
+	// Aggregated series, by user and labels.
+	series map[string]map[string]*aggregatedSeries
+	// Users whose aggregated series have been flushed at the previous interval.
+	flushedUsers map[string]struct{}
+	// Incremented at each flush.
+	flushID uint64
+
+	aggregatedSamples *prometheus.CounterVec
+	inputSeries       *prometheus.GaugeVec
+	outputSeries      *prometheus.GaugeVec
+	failedFlushes     *prometheus.CounterVec
+}
+
+func newSeriesAggregator(cfg AggregationConfig, instanceID string, flush func(context.Context, string, []mimirpb.PreallocTimeseries) error, reg prometheus.Registerer, logger log.Logger) *seriesAggregator {
+	a := &seriesAggregator{
+		logger:        logger,
+		instanceLabel: mimirpb.LabelAdapter{Name: cfg.InstanceLabel, Value: instanceID},
+		flush:         flush,
+		series:        map[string]map[string]*aggregatedSeries{},
+		flushedUsers:  map[string]struct{}{},
+		aggregatedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_aggregation_aggregated_samples_total",
+			Help: "The total number of received samples aggregated by the aggregation rules, instead of being written to the ingesters.",
+		}, []string{"user"}),
+		inputSeries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_aggregation_input_series",
+			Help: "Number of received series aggregated by the aggregation rules during the last flush interval.",
+		}, []string{"user"}),
+		outputSeries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_aggregation_output_series",
+			Help: "Number of aggregated series written to the ingesters at the last flush.",
+		}, []string{"user"}),
+		failedFlushes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_aggregation_failed_flushes_total",
+			Help: "The total number of failed writes of the aggregated series to the ingesters. The aggregated samples of failed writes are lost.",
+		}, []string{"user"}),
+	}
+
+	a.Service = services.NewTimerService(cfg.FlushInterval, nil, a.iteration, a.stopping).WithName("distributor aggregation")
+	return a
+}
+
+func (a *seriesAggregator) iteration(ctx context.Context) error {
+	a.flushAll(ctx, time.Now())
+	return nil
+}
+
+// stopping flushes the aggregated series of the current interval, on a best-effort basis.
+func (a *seriesAggregator) stopping(_ error) error {
+	a.flushAll(context.Background(), time.Now())
+	return nil
+}
+
+// add aggregates the input series into the series without the labels dropped by the rule, and with the
+// instance label of the distributor. The labels of the input series aren't retained.
+func (a *seriesAggregator) add(userID string, rule validation.AggregationRule, ts *mimirpb.PreallocTimeseries) {
+	outputLabels := make([]mimirpb.LabelAdapter, 0, len(ts.Labels)+1)
+	for _, l := range ts.Labels {
+		if !util.StringsContain(rule.DropLabels, l.Name) && l.Name != a.instanceLabel.Name {
+			outputLabels = append(outputLabels, l)
+		}
+	}
+	outputLabels = append(outputLabels, a.instanceLabel)
+	sort.Slice(outputLabels, func(i, j int) bool { return outputLabels[i].Name < outputLabels[j].Name })
+
+	key := mimirpb.FromLabelAdaptersToLabels(outputLabels).String()
+	inputHash := mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash()
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	userSeries, ok := a.series[userID]
+	if !ok {
+		userSeries = map[string]*aggregatedSeries{}
+		a.series[userID] = userSeries
+	}
+
+	series, ok := userSeries[key]
+	if !ok {
+		series = &aggregatedSeries{
+			labels:      mimirpb.FromLabelsToLabelAdapters(mimirpb.FromLabelAdaptersToLabelsWithCopy(outputLabels)),
+			aggregation: rule.Aggregation,
+			latest:      map[uint64]mimirpb.Sample{},
+			counters:    map[uint64]*aggregatedCounter{},
+		}
+		userSeries[key] = series
+	}
+
+	if rule.Aggregation == validation.AggregationSumCounters {
+		// All the samples are used, so that the counter resets between them are detected.
+		for _, sample := range ts.Samples {
+			series.addCounterSample(inputHash, sample, a.flushID)
+		}
+	} else {
+		latest := ts.Samples[0]
+		for _, s := range ts.Samples[1:] {
+			if s.TimestampMs >= latest.TimestampMs {
+				latest = s
+			}
+		}
+		if prev, ok := series.latest[inputHash]; !ok || latest.TimestampMs >= prev.TimestampMs {
+			series.latest[inputHash] = latest
+		}
+	}
+
+	a.aggregatedSamples.WithLabelValues(userID).Add(float64(len(ts.Samples)))
+}
+
+// flushAll writes the aggregated series of the current interval of all users, with a sample at the input time.
+// The state of the sum and avg aggregations is reset, while the aggregated counters are kept until they go stale.
+func (a *seriesAggregator) flushAll(ctx context.Context, now time.Time) {
+	timestamp := now.UnixMilli()
+	timeseriesByUser := map[string][]mimirpb.PreallocTimeseries{}
+	inputSeriesByUser := map[string]int{}
+
+	a.mtx.Lock()
+	flushID := a.flushID
+	a.flushID++
+	for userID, userSeries := range a.series {
+		for key, s := range userSeries {
+			numInputSeries := len(s.latest)
+			for hash, c := range s.counters {
+				if c.lastFlushID == flushID {
+					numInputSeries++
+				} else if flushID-c.lastFlushID >= aggregatedCounterStaleIntervals {
+					delete(s.counters, hash)
+				}
+			}
+
+			// The aggregated counters are written until they all go stale, even if they haven't received any
+			// sample during the interval, so that the sum doesn't have gaps when the counters are scraped less
+			// frequently than the flush interval.
+			if numInputSeries > 0 || len(s.counters) > 0 {
+				inputSeriesByUser[userID] += numInputSeries
+				timeseriesByUser[userID] = append(timeseriesByUser[userID], mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+					Labels:  s.labels,
+					Samples: []mimirpb.Sample{{Value: s.value(), TimestampMs: timestamp}},
+				}})
+			}
+
+			s.latest = map[uint64]mimirpb.Sample{}
+			if len(s.counters) == 0 {
+				delete(userSeries, key)
+			}
+		}
+		if len(userSeries) == 0 {
+			delete(a.series, userID)
+		}
+	}
+	prevFlushedUsers := a.flushedUsers
+	a.flushedUsers = make(map[string]struct{}, len(timeseriesByUser))
+	for userID := range timeseriesByUser {
+		a.flushedUsers[userID] = struct{}{}
+	}
+	a.mtx.Unlock()
+
+	// Reset the metrics of the users which haven't received any series to aggregate during the interval.
+	for userID := range prevFlushedUsers {
+		if _, ok := timeseriesByUser[userID]; !ok {
+			a.inputSeries.DeleteLabelValues(userID)
+			a.outputSeries.DeleteLabelValues(userID)
+		}
+	}
+
+	for userID, timeseries := range timeseriesByUser {
+		a.inputSeries.WithLabelValues(userID).Set(float64(inputSeriesByUser[userID]))
+		a.outputSeries.WithLabelValues(userID).Set(float64(len(timeseries)))
+
+		if err := a.flush(ctx, userID, timeseries); err != nil {
+			a.failedFlushes.WithLabelValues(userID).Inc()
+			level.Warn(a.logger).Log("msg", "failed to write aggregated series", "user", userID, "err", err)
+		}
+	}
+}
+
+func (a *seriesAggregator) cleanupUser(userID string) {
+	a.aggregatedSamples.DeleteLabelValues(userID)
+	a.inputSeries.DeleteLabelValues(userID)
+	a.outputSeries.DeleteLabelValues(userID)
+	a.failedFlushes.DeleteLabelValues(userID)
+}
+
+// pushAggregatedSeries writes the aggregated series of the user to the ingesters. The aggregated series
+// skip the push middlewares, because the series they aggregate have already gone through them.
+func (d *Distributor) pushAggregatedSeries(ctx context.Context, userID string, series []mimirpb.PreallocTimeseries) error {
+	req := &mimirpb.WriteRequest{Timeseries: series, Source: mimirpb.API}
+	_, err := d.push(user.InjectOrgID(ctx, userID), push.NewParsedRequest(req))
+	return err
+}
+
+// prePushAggregationMiddleware removes from the write request the series matching the tenant's aggregation
+// rules, and adds them to the aggregation state instead. Only the series with float samples are aggregated.
+func (d *Distributor) prePushAggregationMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		cleanupInDefer := true
+		defer func() {
+			if cleanupInDefer {
+				pushReq.CleanUp()
+			}
+		}()
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := d.limits.AggregationRules(userID)
+		if len(rules) == 0 {
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		matchers := make([][]*labels.Matcher, len(rules))
+		for idx, rule := range rules {
+			// The rules have already been validated when loading the limits.
+			matchers[idx], _ = rule.Matchers()
+		}
+
+		var removeIndexes []int
+		for tsIdx := range req.Timeseries {
+			ts := &req.Timeseries[tsIdx]
+			if len(ts.Samples) == 0 || len(ts.Histograms) > 0 {
+				continue
+			}
+
+			for ruleIdx, rule := range rules {
+				if matchers[ruleIdx] == nil || !matchesLabelAdapters(matchers[ruleIdx], ts.Labels) {
+					continue
+				}
+
+				d.aggregator.add(userID, rule, ts)
+				removeIndexes = append(removeIndexes, tsIdx)
+				break
+			}
+		}
+
+		if len(removeIndexes) > 0 {
+			for _, removeIndex := range removeIndexes {
+				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeIndex])
+			}
+			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeIndexes)
+		}
+
+		cleanupInDefer = false
+		return next(ctx, pushReq)
+	}
+}
+
+func matchesLabelAdapters(matchers []*labels.Matcher, lbls []mimirpb.LabelAdapter) bool {
+	for _, m := range matchers {
+		value := ""
+		for _, l := range lbls {
+			if l.Name == m.Name {
+				value = l.Value
+				break
+			}
+		}
+
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
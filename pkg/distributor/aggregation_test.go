@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_Push_Aggregation(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AggregationRules = []validation.AggregationRule{
+		{Match: `{__name__="http_requests_total"}`, DropLabels: []string{"pod"}, Aggregation: validation.AggregationSum},
+		{Match: `{__name__="cpu_usage"}`, DropLabels: []string{"pod", "instance"}, Aggregation: validation.AggregationAvg},
+	}
+
+	distributors, ingesters, regs := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		replicationFactor: 3,
+		limits:            limits,
+		aggregation:       AggregationConfig{Enabled: true, FlushInterval: time.Hour, InstanceLabel: "aggregated_by"},
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	push := func(series []labels.Labels, values []float64, timestampMs int64) {
+		lbls := make([][]mimirpb.LabelAdapter, 0, len(series))
+		samples := make([]mimirpb.Sample, 0, len(series))
+		for idx, s := range series {
+			lbls = append(lbls, mimirpb.FromLabelsToLabelAdapters(s))
+			samples = append(samples, mimirpb.Sample{Value: values[idx], TimestampMs: timestampMs})
+		}
+		_, err := distributors[0].Push(ctx, mimirpb.ToWriteRequest(lbls, samples, nil, nil, mimirpb.API))
+		require.NoError(t, err)
+	}
+
+	push([]labels.Labels{
+		labels.FromStrings(labels.MetricName, "http_requests_total", "job", "a", "pod", "1"),
+		labels.FromStrings(labels.MetricName, "http_requests_total", "job", "a", "pod", "2"),
+		labels.FromStrings(labels.MetricName, "http_requests_total", "job", "b", "pod", "1"),
+		labels.FromStrings(labels.MetricName, "cpu_usage", "instance", "x", "pod", "1"),
+		labels.FromStrings(labels.MetricName, "cpu_usage", "instance", "y", "pod", "2"),
+		labels.FromStrings(labels.MetricName, "other", "pod", "1"),
+	}, []float64{1, 2, 4, 1, 3, 5}, 1000)
+
+	// Only the latest sample of each aggregated series is used.
+	push([]labels.Labels{
+		labels.FromStrings(labels.MetricName, "http_requests_total", "job", "a", "pod", "1"),
+	}, []float64{10}, 2000)
+
+	// The series which don't match any rule are written right away.
+	test.Poll(t, time.Second, map[string]mimirpb.Sample{
+		`{__name__="other", pod="1"}`: {Value: 5, TimestampMs: 1000},
+	}, func() interface{} {
+		return ingesterSamples(&ingesters[0])
+	})
+
+	distributors[0].aggregator.flushAll(context.Background(), time.UnixMilli(5000))
+
+	test.Poll(t, time.Second, map[string]mimirpb.Sample{
+		`{__name__="other", pod="1"}`:                                  {Value: 5, TimestampMs: 1000},
+		`{__name__="http_requests_total", aggregated_by="0", job="a"}`: {Value: 12, TimestampMs: 5000},
+		`{__name__="http_requests_total", aggregated_by="0", job="b"}`: {Value: 4, TimestampMs: 5000},
+		`{__name__="cpu_usage", aggregated_by="0"}`:                    {Value: 2, TimestampMs: 5000},
+	}, func() interface{} {
+		return ingesterSamples(&ingesters[0])
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_aggregation_aggregated_samples_total The total number of received samples aggregated by the aggregation rules, instead of being written to the ingesters.
+		# TYPE cortex_distributor_aggregation_aggregated_samples_total counter
+		cortex_distributor_aggregation_aggregated_samples_total{user="user"} 6
+
+		# HELP cortex_distributor_aggregation_input_series Number of received series aggregated by the aggregation rules during the last flush interval.
+		# TYPE cortex_distributor_aggregation_input_series gauge
+		cortex_distributor_aggregation_input_series{user="user"} 5
+
+		# HELP cortex_distributor_aggregation_output_series Number of aggregated series written to the ingesters at the last flush.
+		# TYPE cortex_distributor_aggregation_output_series gauge
+		cortex_distributor_aggregation_output_series{user="user"} 3
+	`), "cortex_distributor_aggregation_aggregated_samples_total", "cortex_distributor_aggregation_input_series", "cortex_distributor_aggregation_output_series", "cortex_distributor_aggregation_failed_flushes_total"))
+
+	// The aggregation state is reset at each flush.
+	distributors[0].aggregator.flushAll(context.Background(), time.UnixMilli(10000))
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(""), "cortex_distributor_aggregation_input_series", "cortex_distributor_aggregation_output_series"))
+	// Nothing is written when there's nothing aggregated.
+	assert.Equal(t, mimirpb.Sample{Value: 12, TimestampMs: 5000}, ingesterSamples(&ingesters[0])[`{__name__="http_requests_total", aggregated_by="0", job="a"}`])
+}
+
+func TestDistributor_Push_Aggregation_MultipleDistributors(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AggregationRules = []validation.AggregationRule{
+		{Match: `{__name__="http_requests_total"}`, DropLabels: []string{"pod"}, Aggregation: validation.AggregationSum},
+	}
+
+	distributors, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   2,
+		replicationFactor: 3,
+		limits:            limits,
+		aggregation:       AggregationConfig{Enabled: true, FlushInterval: time.Hour, InstanceLabel: "aggregated_by"},
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	for idx, d := range distributors {
+		// The series aggregated by each distributor have the same labels.
+		_, err := d.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{
+			mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "http_requests_total", "pod", strconv.Itoa(idx))),
+		}, []mimirpb.Sample{{Value: float64(idx + 1), TimestampMs: 1000}}, nil, nil, mimirpb.API))
+		require.NoError(t, err)
+	}
+
+	for _, d := range distributors {
+		d.aggregator.flushAll(context.Background(), time.UnixMilli(5000))
+	}
+
+	// Each distributor writes its own aggregated series, instead of overwriting the other one.
+	test.Poll(t, time.Second, map[string]mimirpb.Sample{
+		`{__name__="http_requests_total", aggregated_by="0"}`: {Value: 1, TimestampMs: 5000},
+		`{__name__="http_requests_total", aggregated_by="1"}`: {Value: 2, TimestampMs: 5000},
+	}, func() interface{} {
+		return ingesterSamples(&ingesters[0])
+	})
+}
+
+func TestSeriesAggregator_SumCounters(t *testing.T) {
+	var flushed []mimirpb.PreallocTimeseries
+	a := newSeriesAggregator(AggregationConfig{FlushInterval: time.Hour, InstanceLabel: "aggregated_by"}, "distributor-1", func(_ context.Context, _ string, series []mimirpb.PreallocTimeseries) error {
+		flushed = series
+		return nil
+	}, nil, log.NewNopLogger())
+	rule := validation.AggregationRule{Match: `{__name__="requests_total"}`, DropLabels: []string{"pod"}, Aggregation: validation.AggregationSumCounters}
+
+	add := func(pod string, samples ...mimirpb.Sample) {
+		a.add("user", rule, &mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+			Labels:  mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "requests_total", "pod", pod)),
+			Samples: samples,
+		}})
+	}
+	flush := func(timestampMs int64) float64 {
+		flushed = nil
+		a.flushAll(context.Background(), time.UnixMilli(timestampMs))
+		require.Len(t, flushed, 1)
+		require.Equal(t, `{__name__="requests_total", aggregated_by="distributor-1"}`, mimirpb.FromLabelAdaptersToLabels(flushed[0].Labels).String())
+		return flushed[0].Samples[0].Value
+	}
+
+	// The first sample of each counter is its starting value.
+	add("1", mimirpb.Sample{Value: 10, TimestampMs: 1000})
+	add("2", mimirpb.Sample{Value: 100, TimestampMs: 1000})
+	assert.Equal(t, 0.0, flush(5000))
+
+	add("1", mimirpb.Sample{Value: 15, TimestampMs: 6000})
+	add("2", mimirpb.Sample{Value: 120, TimestampMs: 6000})
+	assert.Equal(t, 25.0, flush(10000))
+
+	// The counter resets are detected, both within a series and across flushes.
+	add("1", mimirpb.Sample{Value: 20, TimestampMs: 11000}, mimirpb.Sample{Value: 3, TimestampMs: 12000}, mimirpb.Sample{Value: 5, TimestampMs: 13000})
+	assert.Equal(t, 35.0, flush(15000))
+	add("2", mimirpb.Sample{Value: 7, TimestampMs: 16000})
+	assert.Equal(t, 42.0, flush(20000))
+
+	// The sum is written as long as the counters aren't stale, even without new samples.
+	assert.Equal(t, 42.0, flush(25000))
+
+	// The stale counters aren't aggregated anymore.
+	for i := 0; i < aggregatedCounterStaleIntervals; i++ {
+		a.flushAll(context.Background(), time.Now())
+	}
+	flushed = nil
+	a.flushAll(context.Background(), time.Now())
+	assert.Empty(t, flushed)
+}
+
+func TestDistributor_Push_Aggregation_HistogramsAreNotAggregated(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AggregationRules = []validation.AggregationRule{
+		{Match: `{__name__=~".+"}`, DropLabels: []string{"pod"}, Aggregation: validation.AggregationSum},
+	}
+
+	distributors, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		replicationFactor: 3,
+		limits:            limits,
+		aggregation:       AggregationConfig{Enabled: true, FlushInterval: time.Hour, InstanceLabel: "aggregated_by"},
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := distributors[0].Push(ctx, makeWriteRequest(1000, 2, 0, false, true))
+	require.NoError(t, err)
+
+	// The series with histograms are written right away.
+	test.Poll(t, time.Second, 2, func() interface{} {
+		return len(ingesters[0].series())
+	})
+}
+
+func TestAggregationConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&AggregationConfig{}).Validate())
+	assert.NoError(t, (&AggregationConfig{Enabled: true, FlushInterval: time.Minute, InstanceLabel: "aggregated_by"}).Validate())
+	assert.Error(t, (&AggregationConfig{Enabled: true, InstanceLabel: "aggregated_by"}).Validate())
+	assert.Error(t, (&AggregationConfig{Enabled: true, FlushInterval: time.Minute, InstanceLabel: "__name__"}).Validate())
+	assert.Error(t, (&AggregationConfig{Enabled: true, FlushInterval: time.Minute, InstanceLabel: "invalid-label"}).Validate())
+}
+
+// ingesterSamples returns the latest sample of each series written to the ingester, by series labels.
+func ingesterSamples(ingester *mockIngester) map[string]mimirpb.Sample {
+	samples := map[string]mimirpb.Sample{}
+	for _, series := range ingester.series() {
+		if len(series.Samples) > 0 {
+			samples[mimirpb.FromLabelAdaptersToLabels(series.Labels).String()] = series.Samples[len(series.Samples)-1]
+		}
+	}
+	return samples
+}
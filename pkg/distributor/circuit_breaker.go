@@ -0,0 +1,311 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+const (
+	circuitBreakerClosed   = "closed"
+	circuitBreakerOpen     = "open"
+	circuitBreakerHalfOpen = "half-open"
+)
+
+var circuitBreakerStates = []string{circuitBreakerClosed, circuitBreakerOpen, circuitBreakerHalfOpen}
+
+// CircuitBreakerConfig configures the per-tenant circuit breaker of the writes to the ingesters.
+type CircuitBreakerConfig struct {
+	Enabled          bool          `yaml:"enabled" category:"experimental"`
+	FailureThreshold float64       `yaml:"failure_threshold" category:"experimental"`
+	MinRequests      int           `yaml:"min_requests" category:"experimental"`
+	Window           time.Duration `yaml:"window" category:"experimental"`
+	CooldownPeriod   time.Duration `yaml:"cooldown_period" category:"experimental"`
+}
+
+func (cfg *CircuitBreakerConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.circuit-breaker.enabled", false, "True to stop writing to the ingesters the write requests of a tenant whose writes are consistently failing, for example because the ingesters are unavailable or time out. While the circuit breaker of a tenant is open, its write requests are rejected with the 429 status code without being sent to the ingesters.")
+	f.Float64Var(&cfg.FailureThreshold, "distributor.circuit-breaker.failure-threshold", 0.9, "Ratio, between 0 and 1, of the tenant's failed write requests within the window which opens the circuit breaker. Only the server errors returned by the ingesters, the ingesters being unavailable and the timeouts are counted as failures, while the client errors, like the tenant exceeding its limits, are not.")
+	f.IntVar(&cfg.MinRequests, "distributor.circuit-breaker.min-requests", 100, "Minimum number of write requests of the tenant within the window before the circuit breaker can open.")
+	f.DurationVar(&cfg.Window, "distributor.circuit-breaker.window", time.Minute, "Period over which the failure ratio of the tenant's write requests is computed.")
+	f.DurationVar(&cfg.CooldownPeriod, "distributor.circuit-breaker.cooldown-period", time.Minute, "How long the circuit breaker stays open before letting the tenant's write requests through again. The circuit breaker closes if the first write request after the cooldown period succeeds, and opens again otherwise.")
+}
+
+func (cfg *CircuitBreakerConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FailureThreshold <= 0 || cfg.FailureThreshold > 1 {
+		return fmt.Errorf("the circuit breaker failure threshold must be greater than 0 and not greater than 1")
+	}
+	if cfg.MinRequests <= 0 {
+		return fmt.Errorf("the circuit breaker min requests must be greater than 0")
+	}
+	if cfg.Window <= 0 {
+		return fmt.Errorf("the circuit breaker window must be greater than 0")
+	}
+	if cfg.CooldownPeriod <= 0 {
+		return fmt.Errorf("the circuit breaker cooldown period must be greater than 0")
+	}
+	return nil
+}
+
+// tenantCircuitBreaker is the circuit breaker state of a tenant.
+type tenantCircuitBreaker struct {
+	state string
+
+	// windowStart, requests and failures track the outcome of the write requests within the current window.
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	// openUntil is when the cooldown period of the open circuit breaker ends.
+	openUntil time.Time
+}
+
+// circuitBreaker tracks, for each tenant, the outcome of the write requests sent to the ingesters, and short-circuits
+// the write requests of the tenants whose writes are consistently failing, to not waste the ingesters resources.
+type circuitBreaker struct {
+	cfg    CircuitBreakerConfig
+	logger log.Logger
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantCircuitBreaker
+
+	state            *prometheus.GaugeVec
+	transitions      *prometheus.CounterVec
+	rejectedRequests *prometheus.CounterVec
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig, reg prometheus.Registerer, logger log.Logger) *circuitBreaker {
+	return &circuitBreaker{
+		cfg:     cfg,
+		logger:  logger,
+		tenants: map[string]*tenantCircuitBreaker{},
+		state: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_circuit_breaker_state",
+			Help: "State of the tenant's circuit breaker of the writes to the ingesters. 1 for the current state, 0 for the others.",
+		}, []string{"user", "state"}),
+		transitions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_circuit_breaker_transitions_total",
+			Help: "The total number of times the tenant's circuit breaker of the writes to the ingesters moved to the state.",
+		}, []string{"user", "state"}),
+		rejectedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_circuit_breaker_rejected_requests_total",
+			Help: "The total number of write requests rejected because the tenant's circuit breaker was open.",
+		}, []string{"user"}),
+	}
+}
+
+// allow returns whether the write request of the tenant can be sent to the ingesters and, if not, how long until
+// the circuit breaker lets the tenant's write requests through again.
+func (cb *circuitBreaker) allow(userID string, now time.Time) (bool, time.Duration) {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	t, ok := cb.tenants[userID]
+	if !ok || t.state != circuitBreakerOpen {
+		return true, 0
+	}
+
+	if now.Before(t.openUntil) {
+		cb.rejectedRequests.WithLabelValues(userID).Inc()
+		return false, t.openUntil.Sub(now)
+	}
+
+	cb.setState(userID, t, circuitBreakerHalfOpen)
+	return true, 0
+}
+
+// record records the outcome of a write request of the tenant sent to the ingesters.
+func (cb *circuitBreaker) record(userID string, failed bool, now time.Time) {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	t, ok := cb.tenants[userID]
+	if !ok {
+		t = &tenantCircuitBreaker{state: circuitBreakerClosed, windowStart: now}
+		cb.tenants[userID] = t
+	}
+
+	switch t.state {
+	case circuitBreakerOpen:
+		// The write request was sent before the circuit breaker opened.
+		return
+
+	case circuitBreakerHalfOpen:
+		// The first outcome after the cooldown period decides whether the ingesters accept the tenant's writes again.
+		if failed {
+			cb.open(userID, t, now)
+		} else {
+			cb.setState(userID, t, circuitBreakerClosed)
+			t.windowStart, t.requests, t.failures = now, 0, 0
+		}
+		return
+	}
+
+	if now.Sub(t.windowStart) >= cb.cfg.Window {
+		t.windowStart, t.requests, t.failures = now, 0, 0
+	}
+
+	t.requests++
+	if failed {
+		t.failures++
+	}
+
+	if t.requests >= cb.cfg.MinRequests && float64(t.failures) >= cb.cfg.FailureThreshold*float64(t.requests) {
+		cb.open(userID, t, now)
+	}
+}
+
+func (cb *circuitBreaker) open(userID string, t *tenantCircuitBreaker, now time.Time) {
+	level.Warn(cb.logger).Log("msg", "circuit breaker opened, the tenant's write requests are rejected until the cooldown period ends", "user", userID, "requests", t.requests, "failures", t.failures, "cooldown_period", cb.cfg.CooldownPeriod)
+
+	cb.setState(userID, t, circuitBreakerOpen)
+	t.openUntil = now.Add(cb.cfg.CooldownPeriod)
+}
+
+// setState moves the tenant's circuit breaker to the state. Must be called with the lock held.
+func (cb *circuitBreaker) setState(userID string, t *tenantCircuitBreaker, state string) {
+	t.state = state
+	for _, s := range circuitBreakerStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		cb.state.WithLabelValues(userID, s).Set(value)
+	}
+	cb.transitions.WithLabelValues(userID, state).Inc()
+}
+
+// reset closes the tenant's circuit breaker, and clears the outcome of its write requests. It returns the state
+// the circuit breaker was in.
+func (cb *circuitBreaker) reset(userID string) string {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	t, ok := cb.tenants[userID]
+	if !ok {
+		return circuitBreakerClosed
+	}
+
+	previous := t.state
+	if previous != circuitBreakerClosed {
+		cb.setState(userID, t, circuitBreakerClosed)
+	}
+	t.windowStart, t.requests, t.failures = time.Now(), 0, 0
+	return previous
+}
+
+func (cb *circuitBreaker) cleanupUser(userID string) {
+	cb.mtx.Lock()
+	delete(cb.tenants, userID)
+	cb.mtx.Unlock()
+
+	filter := prometheus.Labels{"user": userID}
+	cb.state.DeletePartialMatch(filter)
+	cb.transitions.DeletePartialMatch(filter)
+	cb.rejectedRequests.DeleteLabelValues(userID)
+}
+
+// isCircuitBreakerFailure returns whether the write request failed because of the ingesters, like a server error,
+// the ingesters being unavailable or a timeout. The client errors, like a write request partially rejected because
+// of the tenant's limits, are not failures.
+func isCircuitBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(errors.Cause(err)); ok {
+		return resp.Code/100 == 5
+	}
+	if s, ok := status.FromError(errors.Cause(err)); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal:
+			return true
+		}
+	}
+	return false
+}
+
+func newCircuitBreakerOpenError(retryAfter time.Duration) error {
+	return httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code: http.StatusTooManyRequests,
+		Body: []byte(globalerror.TenantCircuitBreakerOpen.Message("the request has been rejected because most of the tenant's recent write requests have failed in the ingesters, and the tenant's writes are paused for a cooldown period. Retry the request after the cooldown period")),
+		Headers: []*httpgrpc.Header{
+			{Key: "Retry-After", Values: []string{strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))}},
+		},
+	})
+}
+
+// prePushCircuitBreakerMiddleware rejects the write requests of the tenants whose circuit breaker is open, and
+// records the outcome of the write requests sent to the ingesters.
+func (d *Distributor) prePushCircuitBreakerMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+
+		if ok, retryAfter := d.circuitBreaker.allow(userID, time.Now()); !ok {
+			pushReq.CleanUp()
+			return nil, newCircuitBreakerOpenError(retryAfter)
+		}
+
+		resp, err := next(ctx, pushReq)
+		d.circuitBreaker.record(userID, isCircuitBreakerFailure(err), time.Now())
+		return resp, err
+	}
+}
+
+type circuitBreakerResetResponse struct {
+	UserID        string `json:"user"`
+	PreviousState string `json:"previous_state"`
+}
+
+// CircuitBreakerResetHandler closes the circuit breaker of the tenant passed with the user parameter. The
+// circuit breaker state is kept by each distributor, so it's only reset in the distributor receiving the request.
+func (d *Distributor) CircuitBreakerResetHandler(w http.ResponseWriter, r *http.Request) {
+	if d.circuitBreaker == nil {
+		http.Error(w, "the circuit breaker is disabled", http.StatusNotFound)
+		return
+	}
+
+	userID := r.FormValue("user")
+	if userID == "" {
+		http.Error(w, "the user parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	previous := d.circuitBreaker.reset(userID)
+	level.Info(d.log).Log("msg", "circuit breaker reset manually", "user", userID, "previous_state", previous, "remote_addr", r.RemoteAddr)
+
+	util.WriteJSONResponse(w, circuitBreakerResetResponse{
+		UserID:        userID,
+		PreviousState: previous,
+	})
+}
@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+func TestCircuitBreakerConfig_Validate(t *testing.T) {
+	valid := CircuitBreakerConfig{Enabled: true, FailureThreshold: 0.5, MinRequests: 1, Window: time.Minute, CooldownPeriod: time.Minute}
+	require.NoError(t, valid.Validate())
+	require.NoError(t, (&CircuitBreakerConfig{}).Validate())
+
+	for name, modify := range map[string]func(*CircuitBreakerConfig){
+		"zero failure threshold":     func(cfg *CircuitBreakerConfig) { cfg.FailureThreshold = 0 },
+		"too high failure threshold": func(cfg *CircuitBreakerConfig) { cfg.FailureThreshold = 1.1 },
+		"zero min requests":          func(cfg *CircuitBreakerConfig) { cfg.MinRequests = 0 },
+		"zero window":                func(cfg *CircuitBreakerConfig) { cfg.Window = 0 },
+		"zero cooldown period":       func(cfg *CircuitBreakerConfig) { cfg.CooldownPeriod = 0 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := valid
+			modify(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	cb := newCircuitBreaker(CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		CooldownPeriod:   30 * time.Second,
+	}, reg, log.NewNopLogger())
+
+	now := time.Unix(1690000000, 0)
+	assertAllowed := func(userID string, expected bool, expectedRetryAfter time.Duration) {
+		t.Helper()
+		ok, retryAfter := cb.allow(userID, now)
+		assert.Equal(t, expected, ok)
+		assert.Equal(t, expectedRetryAfter, retryAfter)
+	}
+
+	// The circuit breaker doesn't open before the minimum number of requests.
+	for i := 0; i < 3; i++ {
+		assertAllowed("user-1", true, 0)
+		cb.record("user-1", true, now)
+	}
+	assertAllowed("user-1", true, 0)
+
+	// The failures of a previous window are not counted.
+	now = now.Add(time.Minute)
+	cb.record("user-1", true, now)
+	cb.record("user-1", false, now)
+	cb.record("user-1", false, now)
+	cb.record("user-1", false, now)
+	assertAllowed("user-1", true, 0)
+
+	// The circuit breaker opens once the failure ratio is reached.
+	now = now.Add(time.Minute)
+	cb.record("user-1", true, now)
+	cb.record("user-1", false, now)
+	cb.record("user-1", true, now)
+	cb.record("user-1", false, now)
+	assertAllowed("user-1", false, 30*time.Second)
+	assertAllowed("user-2", true, 0)
+
+	// The outcome of the requests sent before the circuit breaker opened is ignored.
+	cb.record("user-1", false, now)
+	now = now.Add(10 * time.Second)
+	assertAllowed("user-1", false, 20*time.Second)
+
+	// Once the cooldown period ends, a failed request opens the circuit breaker again.
+	now = now.Add(20 * time.Second)
+	assertAllowed("user-1", true, 0)
+	cb.record("user-1", true, now)
+	assertAllowed("user-1", false, 30*time.Second)
+
+	// Once the cooldown period ends, a successful request closes the circuit breaker.
+	now = now.Add(30 * time.Second)
+	assertAllowed("user-1", true, 0)
+	cb.record("user-1", false, now)
+	assertAllowed("user-1", true, 0)
+	cb.record("user-1", true, now)
+	assertAllowed("user-1", true, 0)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_circuit_breaker_rejected_requests_total The total number of write requests rejected because the tenant's circuit breaker was open.
+		# TYPE cortex_distributor_circuit_breaker_rejected_requests_total counter
+		cortex_distributor_circuit_breaker_rejected_requests_total{user="user-1"} 3
+		# HELP cortex_distributor_circuit_breaker_state State of the tenant's circuit breaker of the writes to the ingesters. 1 for the current state, 0 for the others.
+		# TYPE cortex_distributor_circuit_breaker_state gauge
+		cortex_distributor_circuit_breaker_state{state="closed",user="user-1"} 1
+		cortex_distributor_circuit_breaker_state{state="half-open",user="user-1"} 0
+		cortex_distributor_circuit_breaker_state{state="open",user="user-1"} 0
+		# HELP cortex_distributor_circuit_breaker_transitions_total The total number of times the tenant's circuit breaker of the writes to the ingesters moved to the state.
+		# TYPE cortex_distributor_circuit_breaker_transitions_total counter
+		cortex_distributor_circuit_breaker_transitions_total{state="closed",user="user-1"} 1
+		cortex_distributor_circuit_breaker_transitions_total{state="half-open",user="user-1"} 2
+		cortex_distributor_circuit_breaker_transitions_total{state="open",user="user-1"} 2
+	`)))
+
+	cb.cleanupUser("user-1")
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader("")))
+}
+
+func TestDistributor_CircuitBreaker(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var pushErr error
+	d := &Distributor{log: log.NewNopLogger()}
+	d.circuitBreaker = newCircuitBreaker(CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 1,
+		MinRequests:      2,
+		Window:           time.Minute,
+		CooldownPeriod:   time.Minute,
+	}, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+	pushes := 0
+	pushFn := d.prePushCircuitBreakerMiddleware(func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+		pushes++
+		return &mimirpb.WriteResponse{}, pushErr
+	})
+	doPush := func() error {
+		_, err := pushFn(ctx, push.NewParsedRequest(&mimirpb.WriteRequest{}))
+		return err
+	}
+
+	// The ingesters partially rejecting the tenant's writes doesn't open the circuit breaker.
+	pushErr = httpgrpc.Errorf(http.StatusBadRequest, "per-user series limit exceeded, 1 of 10 series rejected")
+	require.Error(t, doPush())
+	require.Error(t, doPush())
+
+	// The ingesters failing opens the circuit breaker.
+	d.circuitBreaker.reset("user")
+	pushErr = httpgrpc.Errorf(http.StatusInternalServerError, "ingesters unavailable")
+	require.Error(t, doPush())
+	require.Error(t, doPush())
+	require.Equal(t, 4, pushes)
+
+	err := doPush()
+	require.Equal(t, 4, pushes)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	assert.Contains(t, string(resp.Body), "err-mimir-tenant-circuit-breaker-open")
+	require.Len(t, resp.Headers, 1)
+	assert.Equal(t, "Retry-After", resp.Headers[0].Key)
+	assert.Equal(t, []string{"60"}, resp.Headers[0].Values)
+
+	// The circuit breaker can be reset manually.
+	req := httptest.NewRequest(http.MethodPost, "/distributor/circuit_breaker/reset?user=user", nil)
+	w := httptest.NewRecorder()
+	d.CircuitBreakerResetHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user":"user","previous_state":"open"}`, w.Body.String())
+
+	pushErr = nil
+	require.NoError(t, doPush())
+	require.Equal(t, 5, pushes)
+}
+
+func TestIsCircuitBreakerFailure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err      error
+		expected bool
+	}{
+		"no error":          {err: nil, expected: false},
+		"canceled":          {err: context.Canceled, expected: false},
+		"deadline exceeded": {err: errors.Wrap(context.DeadlineExceeded, "push"), expected: true},
+		"client error":      {err: httpgrpc.Errorf(http.StatusBadRequest, "out of order sample"), expected: false},
+		"too many requests": {err: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit exceeded"), expected: false},
+		"server error":      {err: httpgrpc.Errorf(http.StatusInternalServerError, "exceeded configured distributor remote timeout"), expected: true},
+		"unavailable":       {err: status.Error(codes.Unavailable, "connection refused"), expected: true},
+		"other grpc error":  {err: status.Error(codes.InvalidArgument, "invalid"), expected: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isCircuitBreakerFailure(tc.err))
+		})
+	}
+}
+
+func TestDistributor_CircuitBreakerResetHandler(t *testing.T) {
+	for name, tc := range map[string]struct {
+		enabled        bool
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should fail if the circuit breaker is disabled": {
+			path:           "/distributor/circuit_breaker/reset?user=user",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the circuit breaker is disabled",
+		},
+		"should fail without the user": {
+			enabled:        true,
+			path:           "/distributor/circuit_breaker/reset",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "the user parameter is required",
+		},
+		"should reset a closed circuit breaker": {
+			enabled:        true,
+			path:           "/distributor/circuit_breaker/reset?user=user",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"user":"user","previous_state":"closed"}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := &Distributor{log: log.NewNopLogger()}
+			if tc.enabled {
+				d.circuitBreaker = newCircuitBreaker(CircuitBreakerConfig{}, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+			}
+
+			w := httptest.NewRecorder()
+			d.CircuitBreakerResetHandler(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
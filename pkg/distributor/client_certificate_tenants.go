@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"bytes"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	clientCertificateUnmappedReasonNoCertificate = "no_certificate"
+	clientCertificateUnmappedReasonUnknown       = "unknown_identity"
+)
+
+// ClientCertificateTenantsConfig configures the mapping of the TLS client certificates to the tenants
+// of the write requests.
+type ClientCertificateTenantsConfig struct {
+	MappingFile           string `yaml:"mapping_file" category:"experimental"`
+	HeaderFallbackEnabled bool   `yaml:"header_fallback_enabled" category:"experimental"`
+}
+
+func (cfg *ClientCertificateTenantsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.MappingFile, "distributor.client-certificate-tenants.mapping-file", "", "Path to a YAML file mapping the identities of the verified TLS client certificates to the tenants of the write requests received by the push endpoints, instead of reading the tenant from the X-Scope-OrgID header. The file maps the certificate subject alternative names under the `san` key, and the certificate subject organizational units under the `organizational_unit` key, to tenant IDs. The subject alternative names are looked up first. Requires the HTTP server to verify the client certificates. Empty to disable.")
+	f.BoolVar(&cfg.HeaderFallbackEnabled, "distributor.client-certificate-tenants.header-fallback-enabled", false, "True to read the tenant from the X-Scope-OrgID header of the write requests without a client certificate mapped to a tenant, instead of rejecting them.")
+}
+
+func (cfg *ClientCertificateTenantsConfig) Enabled() bool {
+	return cfg.MappingFile != ""
+}
+
+// clientCertificateTenantsMapping is the content of the client certificate tenants mapping file.
+type clientCertificateTenantsMapping struct {
+	SAN                map[string]string `yaml:"san"`
+	OrganizationalUnit map[string]string `yaml:"organizational_unit"`
+}
+
+func loadClientCertificateTenantsMapping(path string) (clientCertificateTenantsMapping, error) {
+	var mapping clientCertificateTenantsMapping
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, fmt.Errorf("failed to read the client certificate tenants mapping file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&mapping); err != nil {
+		return mapping, fmt.Errorf("failed to parse the client certificate tenants mapping file %s: %w", path, err)
+	}
+
+	for identity, tenantID := range mapping.SAN {
+		if tenantID == "" {
+			return mapping, fmt.Errorf("the subject alternative name %q is mapped to an empty tenant in the client certificate tenants mapping file %s", identity, path)
+		}
+	}
+	for identity, tenantID := range mapping.OrganizationalUnit {
+		if tenantID == "" {
+			return mapping, fmt.Errorf("the organizational unit %q is mapped to an empty tenant in the client certificate tenants mapping file %s", identity, path)
+		}
+	}
+
+	return mapping, nil
+}
+
+// tenantForCertificate returns the tenant mapped to the first subject alternative name of the certificate
+// found in the mapping or, if none, to the first organizational unit of the certificate subject found in the
+// mapping. Returns an empty string if the certificate isn't mapped to any tenant.
+func (m clientCertificateTenantsMapping) tenantForCertificate(cert *x509.Certificate) string {
+	for _, name := range cert.DNSNames {
+		if tenantID, ok := m.SAN[name]; ok {
+			return tenantID
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if tenantID, ok := m.SAN[email]; ok {
+			return tenantID
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if tenantID, ok := m.SAN[ip.String()]; ok {
+			return tenantID
+		}
+	}
+	for _, uri := range cert.URIs {
+		if tenantID, ok := m.SAN[uri.String()]; ok {
+			return tenantID
+		}
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if tenantID, ok := m.OrganizationalUnit[ou]; ok {
+			return tenantID
+		}
+	}
+	return ""
+}
+
+// clientCertificateTenants is an HTTP middleware setting the tenant of the requests from the verified TLS
+// client certificate, before the authentication middleware reads it from the X-Scope-OrgID header.
+type clientCertificateTenants struct {
+	cfg     ClientCertificateTenantsConfig
+	mapping clientCertificateTenantsMapping
+	logger  log.Logger
+
+	mappedRequests   prometheus.Counter
+	unmappedRequests *prometheus.CounterVec
+}
+
+func newClientCertificateTenants(cfg ClientCertificateTenantsConfig, reg prometheus.Registerer, logger log.Logger) (*clientCertificateTenants, error) {
+	mapping, err := loadClientCertificateTenantsMapping(cfg.MappingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientCertificateTenants{
+		cfg:     cfg,
+		mapping: mapping,
+		logger:  logger,
+
+		mappedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_client_certificate_mapped_requests_total",
+			Help: "The total number of write requests whose tenant has been mapped from the TLS client certificate.",
+		}),
+		unmappedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_client_certificate_unmapped_requests_total",
+			Help: "The total number of write requests whose TLS client certificate couldn't be mapped to a tenant.",
+		}, []string{"reason"}),
+	}, nil
+}
+
+// Wrap implements middleware.Interface.
+func (c *clientCertificateTenants) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the certificates verified by the server can be trusted.
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			c.unmapped(w, r, next, clientCertificateUnmappedReasonNoCertificate, nil)
+			return
+		}
+
+		cert := r.TLS.VerifiedChains[0][0]
+		tenantID := c.mapping.tenantForCertificate(cert)
+		if tenantID == "" {
+			c.unmapped(w, r, next, clientCertificateUnmappedReasonUnknown, cert)
+			return
+		}
+
+		c.mappedRequests.Inc()
+
+		// The tenant set by the client, if any, is overridden.
+		r.Header.Set(user.OrgIDHeaderName, tenantID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *clientCertificateTenants) unmapped(w http.ResponseWriter, r *http.Request, next http.Handler, reason string, cert *x509.Certificate) {
+	c.unmappedRequests.WithLabelValues(reason).Inc()
+
+	if cert != nil {
+		level.Debug(c.logger).Log("msg", "TLS client certificate not mapped to any tenant", "subject", cert.Subject.String(), "dns_names", fmt.Sprint(cert.DNSNames), "remote_addr", r.RemoteAddr)
+	}
+
+	if c.cfg.HeaderFallbackEnabled {
+		next.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, "the TLS client certificate of the request is not mapped to any tenant", http.StatusUnauthorized)
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+)
+
+func TestLoadClientCertificateTenantsMapping(t *testing.T) {
+	tests := map[string]struct {
+		content     string
+		expected    clientCertificateTenantsMapping
+		expectedErr string
+	}{
+		"valid mapping": {
+			content: `
+san:
+  prometheus.example.com: tenant-a
+organizational_unit:
+  team-b: tenant-b
+`,
+			expected: clientCertificateTenantsMapping{
+				SAN:                map[string]string{"prometheus.example.com": "tenant-a"},
+				OrganizationalUnit: map[string]string{"team-b": "tenant-b"},
+			},
+		},
+		"unknown field": {
+			content:     "common_name:\n  foo: tenant-a\n",
+			expectedErr: "field common_name not found",
+		},
+		"empty tenant": {
+			content:     "san:\n  prometheus.example.com: \"\"\n",
+			expectedErr: `the subject alternative name "prometheus.example.com" is mapped to an empty tenant`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(testData.content), 0o600))
+
+			mapping, err := loadClientCertificateTenantsMapping(path)
+			if testData.expectedErr != "" {
+				require.ErrorContains(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, mapping)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadClientCertificateTenantsMapping(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.Error(t, err)
+	})
+}
+
+func TestClientCertificateTenants(t *testing.T) {
+	mapping := `
+san:
+  prometheus.example.com: tenant-dns
+  spiffe://example.com/agent: tenant-uri
+organizational_unit:
+  team-b: tenant-ou
+`
+	spiffeURI, err := url.Parse("spiffe://example.com/agent")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		headerFallbackEnabled bool
+		cert                  *x509.Certificate
+		unverified            bool
+		header                string
+		expectedStatus        int
+		expectedTenant        string
+		expectedMetrics       string
+	}{
+		"mapped by DNS name": {
+			cert:           &x509.Certificate{DNSNames: []string{"other.example.com", "prometheus.example.com"}, Subject: pkix.Name{OrganizationalUnit: []string{"team-b"}}},
+			expectedStatus: http.StatusOK,
+			expectedTenant: "tenant-dns",
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 1
+			`,
+		},
+		"mapped by URI": {
+			cert:           &x509.Certificate{URIs: []*url.URL{spiffeURI}},
+			expectedStatus: http.StatusOK,
+			expectedTenant: "tenant-uri",
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 1
+			`,
+		},
+		"mapped by organizational unit, overriding the header": {
+			cert:           &x509.Certificate{DNSNames: []string{"other.example.com"}, Subject: pkix.Name{OrganizationalUnit: []string{"team-a", "team-b"}}},
+			header:         "spoofed",
+			expectedStatus: http.StatusOK,
+			expectedTenant: "tenant-ou",
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 1
+			`,
+		},
+		"unverified certificate": {
+			cert:           &x509.Certificate{DNSNames: []string{"prometheus.example.com"}},
+			unverified:     true,
+			header:         "tenant-header",
+			expectedStatus: http.StatusUnauthorized,
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 0
+				# HELP cortex_distributor_client_certificate_unmapped_requests_total The total number of write requests whose TLS client certificate couldn't be mapped to a tenant.
+				# TYPE cortex_distributor_client_certificate_unmapped_requests_total counter
+				cortex_distributor_client_certificate_unmapped_requests_total{reason="no_certificate"} 1
+			`,
+		},
+		"unknown identity": {
+			cert:           &x509.Certificate{DNSNames: []string{"other.example.com"}},
+			header:         "tenant-header",
+			expectedStatus: http.StatusUnauthorized,
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 0
+				# HELP cortex_distributor_client_certificate_unmapped_requests_total The total number of write requests whose TLS client certificate couldn't be mapped to a tenant.
+				# TYPE cortex_distributor_client_certificate_unmapped_requests_total counter
+				cortex_distributor_client_certificate_unmapped_requests_total{reason="unknown_identity"} 1
+			`,
+		},
+		"unknown identity, header fallback enabled": {
+			headerFallbackEnabled: true,
+			cert:                  &x509.Certificate{DNSNames: []string{"other.example.com"}},
+			header:                "tenant-header",
+			expectedStatus:        http.StatusOK,
+			expectedTenant:        "tenant-header",
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 0
+				# HELP cortex_distributor_client_certificate_unmapped_requests_total The total number of write requests whose TLS client certificate couldn't be mapped to a tenant.
+				# TYPE cortex_distributor_client_certificate_unmapped_requests_total counter
+				cortex_distributor_client_certificate_unmapped_requests_total{reason="unknown_identity"} 1
+			`,
+		},
+		"no certificate, header fallback enabled": {
+			headerFallbackEnabled: true,
+			header:                "tenant-header",
+			expectedStatus:        http.StatusOK,
+			expectedTenant:        "tenant-header",
+			expectedMetrics: `
+				# HELP cortex_distributor_client_certificate_mapped_requests_total The total number of write requests whose tenant has been mapped from the TLS client certificate.
+				# TYPE cortex_distributor_client_certificate_mapped_requests_total counter
+				cortex_distributor_client_certificate_mapped_requests_total 0
+				# HELP cortex_distributor_client_certificate_unmapped_requests_total The total number of write requests whose TLS client certificate couldn't be mapped to a tenant.
+				# TYPE cortex_distributor_client_certificate_unmapped_requests_total counter
+				cortex_distributor_client_certificate_unmapped_requests_total{reason="no_certificate"} 1
+			`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(mapping), 0o600))
+
+			reg := prometheus.NewPedanticRegistry()
+			c, err := newClientCertificateTenants(ClientCertificateTenantsConfig{MappingFile: path, HeaderFallbackEnabled: testData.headerFallbackEnabled}, reg, log.NewNopLogger())
+			require.NoError(t, err)
+
+			var receivedTenant string
+			handler := c.Wrap(middleware.AuthenticateUser.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedTenant, err = user.ExtractOrgID(r.Context())
+				require.NoError(t, err)
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/push", nil)
+			if testData.header != "" {
+				req.Header.Set(user.OrgIDHeaderName, testData.header)
+			}
+			if testData.cert != nil {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{testData.cert}}
+				if !testData.unverified {
+					req.TLS.VerifiedChains = [][]*x509.Certificate{{testData.cert}}
+				}
+			}
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, testData.expectedStatus, resp.Code)
+			assert.Equal(t, testData.expectedTenant, receivedTenant)
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics),
+				"cortex_distributor_client_certificate_mapped_requests_total", "cortex_distributor_client_certificate_unmapped_requests_total"))
+		})
+	}
+}
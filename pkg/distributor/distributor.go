@@ -12,6 +12,7 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -41,6 +42,7 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/mimir/pkg/cardinality"
+	"github.com/grafana/mimir/pkg/distributor/limitspolicy"
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/stats"
@@ -48,12 +50,14 @@ import (
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/ringadvisor"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 var (
 	// Validation errors.
-	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater than or equal to zero")
+	errInvalidTenantShardSize         = errors.New("invalid tenant shard size, the value must be greater than or equal to zero")
+	errInvalidMaxOversizedRecvMsgSize = errors.New("invalid max oversized recv msg size, the value must be 0 or greater than the max recv msg size")
 )
 
 const (
@@ -68,6 +72,11 @@ const (
 	metaLabelTenantID = model.MetaLabelPrefix + "tenant_id"
 
 	instanceIngestionRateTickInterval = time.Second
+	instanceIngestionRateAlpha        = 0.2
+
+	// metadataCacheRingCheckInterval is how often the ingesters ring is checked for the ingesters becoming ACTIVE,
+	// to forward again the metadata cached by the metadata cache.
+	metadataCacheRingCheckInterval = 5 * time.Second
 
 	// Size of "slab" when using pooled buffers for marshaling write requests. When handling single Push request
 	// buffers for multiple write requests sent to ingesters will be allocated from single "slab", if there is enough space.
@@ -86,16 +95,23 @@ type Distributor struct {
 
 	// The global rate limiter requires a distributors ring to count
 	// the number of healthy instances
-	distributorsLifecycler *ring.BasicLifecycler
-	distributorsRing       *ring.Ring
-	healthyInstancesCount  *atomic.Uint32
+	distributorsLifecycler  *ring.BasicLifecycler
+	distributorsRing        *ring.Ring
+	distributorsRingAdvisor http.Handler
+	healthyInstancesCount   *healthyInstancesCounter
 
 	// For handling HA replicas.
 	HATracker *haTracker
 
+	// Sets the tenant of the write requests from the TLS client certificate. Nil if disabled.
+	ClientCertificateTenants *clientCertificateTenants
+
 	// Per-user rate limiters.
-	requestRateLimiter   *limiter.RateLimiter
-	ingestionRateLimiter *limiter.RateLimiter
+	requestRateLimiter        *rateLimiter
+	ingestionRateLimiter      *rateLimiter
+	ingestionBytesRateLimiter *rateLimiter
+	ingestionQuotaRateLimiter *rateLimiter
+	ingestionQuotaMatchers    *ingestionQuotaMatchers
 
 	// Manager for subservices (HA Tracker, distributor ring and client pool)
 	subservices        *services.Manager
@@ -124,18 +140,56 @@ type Distributor struct {
 	sampleDelayHistogram             prometheus.Histogram
 	replicationFactor                prometheus.Gauge
 	latestSeenSampleTimestampPerUser *prometheus.GaugeVec
+	nonMonotonicSeriesSorted         *prometheus.CounterVec
+	labelNamesPolicyDroppedLabels    *prometheus.CounterVec
+	normalizedSeries                 *prometheus.CounterVec
+	partiallyAcceptedRequests        prometheus.Counter
 	QueryChunkMetrics                *stats.QueryChunkMetrics
+	instanceLimitsRetryAfter         *prometheus.GaugeVec
 
 	discardedSamplesTooManyHaClusters *prometheus.CounterVec
 	discardedSamplesRateLimited       *prometheus.CounterVec
 	discardedRequestsRateLimited      *prometheus.CounterVec
+	discardedRequestsBytesRateLimited *prometheus.CounterVec
 	discardedExemplarsRateLimited     *prometheus.CounterVec
 	discardedMetadataRateLimited      *prometheus.CounterVec
+	discardedSamplesIngestionQuota    *prometheus.CounterVec
+	ingestionQuotaDiscardedSamples    *prometheus.CounterVec
 
 	sampleValidationMetrics   *validation.SampleValidationMetrics
 	exemplarValidationMetrics *validation.ExemplarValidationMetrics
 	metadataValidationMetrics *validation.MetadataValidationMetrics
 
+	// Examples of the discarded series, exposed to tenants.
+	discardedSamplesExamples *validation.DiscardedSamplesExamples
+
+	// Number of exemplars accepted per series in the current minute.
+	exemplarsPerSeries *exemplarsPerSeriesTracker
+
+	// Coalesces the write requests of multiple tenants sent to the same ingester. Nil if disabled.
+	multiTenantPushBatcher *multiTenantPushBatcher
+
+	// Replicates the write requests of selected tenants to a second cluster. Nil if disabled.
+	dualWriter *dualWriter
+
+	// Writes the exemplars with their own replication factor. Nil if disabled.
+	exemplarsReplicator *exemplarsReplicator
+
+	// Aggregates the series matching the tenants aggregation rules. Nil if disabled.
+	aggregator *seriesAggregator
+
+	// Queues on disk the write requests which couldn't be written to the ingesters. Nil if disabled.
+	spillQueue *spillQueue
+
+	circuitBreaker *circuitBreaker
+
+	topMetrics *topMetricsTracker
+
+	// Cache of the metadata recently forwarded to the ingesters, nil if disabled.
+	metadataCache *metadataCache
+
+	hedgingMetrics *ingesterHedgingMetrics
+
 	PushWithMiddlewares push.Func
 
 	// Pool of []byte used when marshalling write requests.
@@ -149,8 +203,11 @@ type Config struct {
 
 	HATrackerConfig HATrackerConfig `yaml:"ha_tracker"`
 
-	MaxRecvMsgSize int           `yaml:"max_recv_msg_size" category:"advanced"`
-	RemoteTimeout  time.Duration `yaml:"remote_timeout" category:"advanced"`
+	LimitsPolicy limitspolicy.Config `yaml:"limits_policy"`
+
+	MaxRecvMsgSize          int           `yaml:"max_recv_msg_size" category:"advanced"`
+	MaxOversizedRecvMsgSize int           `yaml:"max_oversized_recv_msg_size" category:"experimental"`
+	RemoteTimeout           time.Duration `yaml:"remote_timeout" category:"advanced"`
 
 	// Distributors ring
 	DistributorRing RingConfig `yaml:"ring"`
@@ -178,6 +235,28 @@ type Config struct {
 	PushWrappers []PushWrapper `yaml:"-"`
 
 	WriteRequestsBufferPoolingEnabled bool `yaml:"write_requests_buffer_pooling_enabled" category:"experimental"`
+
+	MultiTenantBatching MultiTenantBatchingConfig `yaml:"multi_tenant_batching"`
+
+	DualWrite DualWriteConfig `yaml:"dual_write"`
+
+	ExemplarsReplication ExemplarsReplicationConfig `yaml:"exemplars_replication"`
+
+	Aggregation AggregationConfig `yaml:"aggregation"`
+
+	SpillQueue SpillQueueConfig `yaml:"spill_queue"`
+
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	TopMetrics TopMetricsConfig `yaml:"top_metrics"`
+
+	MetadataCache MetadataCacheConfig `yaml:"metadata_cache"`
+
+	IngesterHedging IngesterHedgingConfig `yaml:"ingester_hedging"`
+
+	PartialAcceptance PartialAcceptanceConfig `yaml:"partial_acceptance"`
+
+	ClientCertificateTenants ClientCertificateTenantsConfig `yaml:"client_certificate_tenants"`
 }
 
 // PushWrapper wraps around a push. It is similar to middleware.Interface.
@@ -187,9 +266,22 @@ type PushWrapper func(next push.Func) push.Func
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.PoolConfig.RegisterFlags(f)
 	cfg.HATrackerConfig.RegisterFlags(f)
+	cfg.LimitsPolicy.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f, logger)
+	cfg.MultiTenantBatching.RegisterFlags(f)
+	cfg.DualWrite.RegisterFlags(f)
+	cfg.ExemplarsReplication.RegisterFlags(f)
+	cfg.Aggregation.RegisterFlags(f)
+	cfg.SpillQueue.RegisterFlags(f)
+	cfg.CircuitBreaker.RegisterFlags(f)
+	cfg.TopMetrics.RegisterFlags(f)
+	cfg.MetadataCache.RegisterFlags(f)
+	cfg.IngesterHedging.RegisterFlags(f)
+	cfg.PartialAcceptance.RegisterFlags(f)
+	cfg.ClientCertificateTenants.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "Max message size in bytes that the distributors will accept for incoming push requests to the remote write API. If exceeded, the request will be rejected.")
+	f.IntVar(&cfg.MaxOversizedRecvMsgSize, "distributor.max-oversized-recv-msg-size", 0, "If greater than -distributor.max-recv-msg-size, push requests to the remote write API larger than -distributor.max-recv-msg-size are accepted up to this size, and split into multiple push requests not larger than -distributor.max-recv-msg-size. 0 to disable.")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
 	f.BoolVar(&cfg.WriteRequestsBufferPoolingEnabled, "distributor.write-requests-buffer-pooling-enabled", false, "Enable pooling of buffers used for marshaling write requests.")
 
@@ -202,6 +294,54 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidTenantShardSize
 	}
 
+	if cfg.MaxOversizedRecvMsgSize != 0 && cfg.MaxOversizedRecvMsgSize <= cfg.MaxRecvMsgSize {
+		return errInvalidMaxOversizedRecvMsgSize
+	}
+
+	if err := cfg.LimitsPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.MultiTenantBatching.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.DualWrite.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.ExemplarsReplication.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Aggregation.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.SpillQueue.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.CircuitBreaker.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.TopMetrics.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.MetadataCache.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.IngesterHedging.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.PartialAcceptance.Validate(cfg.RemoteTimeout); err != nil {
+		return err
+	}
+
 	return cfg.HATrackerConfig.Validate()
 }
 
@@ -234,10 +374,10 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		log:                   log,
 		ingestersRing:         ingestersRing,
 		ingesterPool:          NewPool(cfg.PoolConfig, ingestersRing, cfg.IngesterClientFactory, log),
-		healthyInstancesCount: atomic.NewUint32(0),
+		healthyInstancesCount: newHealthyInstancesCounter(cfg.DistributorRing.InstancesCountHysteresisPeriod),
 		limits:                limits,
 		HATracker:             haTracker,
-		ingestionRate:         util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
+		ingestionRate:         util_math.NewEWMARate(instanceIngestionRateAlpha, instanceIngestionRateTickInterval),
 		QueryChunkMetrics:     stats.NewQueryChunkMetrics(reg),
 
 		queryDuration: instrument.NewHistogramCollector(promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
@@ -330,16 +470,89 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name: "cortex_distributor_latest_seen_sample_timestamp_seconds",
 			Help: "Unix timestamp of latest received sample per user.",
 		}, []string{"user"}),
+		nonMonotonicSeriesSorted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_non_monotonic_series_sorted_total",
+			Help: "The total number of received series whose samples have been sorted by timestamp because their timestamps were not monotonically increasing within the write request.",
+		}, []string{"user"}),
+		labelNamesPolicyDroppedLabels: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_label_names_policy_dropped_labels_total",
+			Help: "The total number of labels dropped from the received series because their label names are not allowed by the tenant.",
+		}, []string{"user"}),
+		normalizedSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_normalized_series_total",
+			Help: "The total number of received series whose label values have been changed by the tenant's label value normalization rules.",
+		}, []string{"user"}),
+		partiallyAcceptedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_partially_accepted_requests_total",
+			Help: "The total number of write requests partially accepted, because not all their series have been written to a quorum of ingesters before the partial acceptance timeout margin.",
+		}),
 
 		discardedSamplesTooManyHaClusters: validation.DiscardedSamplesCounter(reg, validation.ReasonTooManyHAClusters),
 		discardedSamplesRateLimited:       validation.DiscardedSamplesCounter(reg, validation.ReasonRateLimited),
 		discardedRequestsRateLimited:      validation.DiscardedRequestsCounter(reg, validation.ReasonRateLimited),
+		discardedRequestsBytesRateLimited: validation.DiscardedRequestsCounter(reg, validation.ReasonBytesRateLimited),
 		discardedExemplarsRateLimited:     validation.DiscardedExemplarsCounter(reg, validation.ReasonRateLimited),
 		discardedMetadataRateLimited:      validation.DiscardedMetadataCounter(reg, validation.ReasonRateLimited),
+		discardedSamplesIngestionQuota:    validation.DiscardedSamplesCounter(reg, validation.ReasonIngestionQuotaExceeded),
+		ingestionQuotaDiscardedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingestion_quota_discarded_samples_total",
+			Help: "The total number of samples discarded because the tenant exceeded one of its ingestion quotas, by quota.",
+		}, []string{"user", "quota"}),
 
-		sampleValidationMetrics:   validation.NewSampleValidationMetrics(reg),
 		exemplarValidationMetrics: validation.NewExemplarValidationMetrics(reg),
 		metadataValidationMetrics: validation.NewMetadataValidationMetrics(reg),
+
+		discardedSamplesExamples: validation.NewDiscardedSamplesExamples(limits),
+		exemplarsPerSeries:       newExemplarsPerSeriesTracker(),
+	}
+	d.sampleValidationMetrics = validation.NewSampleValidationMetrics(reg, d.discardedSamplesExamples)
+
+	if cfg.MultiTenantBatching.Enabled {
+		d.multiTenantPushBatcher = newMultiTenantPushBatcher(cfg.MultiTenantBatching, cfg.RemoteTimeout, d.ingesterPool, reg)
+	}
+
+	if cfg.DualWrite.Enabled() {
+		d.dualWriter, err = newDualWriter(cfg.DualWrite, reg, log)
+		if err != nil {
+			return nil, err
+		}
+		subservices = append(subservices, d.dualWriter)
+	}
+
+	if cfg.ExemplarsReplication.Enabled {
+		d.exemplarsReplicator = newExemplarsReplicator(cfg.ExemplarsReplication, reg, log)
+	}
+
+	if cfg.Aggregation.Enabled {
+		d.aggregator = newSeriesAggregator(cfg.Aggregation, cfg.DistributorRing.Common.InstanceID, d.pushAggregatedSeries, reg, log)
+		subservices = append(subservices, d.aggregator)
+	}
+
+	if cfg.SpillQueue.Enabled() {
+		d.spillQueue = newSpillQueue(cfg.SpillQueue, limits, d.replaySpilledRequest, reg, log)
+		subservices = append(subservices, d.spillQueue)
+	}
+
+	if cfg.CircuitBreaker.Enabled {
+		d.circuitBreaker = newCircuitBreaker(cfg.CircuitBreaker, reg, log)
+	}
+
+	if cfg.TopMetrics.Enabled {
+		d.topMetrics = newTopMetricsTracker(cfg.TopMetrics, log)
+		subservices = append(subservices, d.topMetrics)
+	}
+
+	if cfg.MetadataCache.Enabled {
+		d.metadataCache = newMetadataCache(cfg.MetadataCache, reg)
+	}
+
+	d.hedgingMetrics = newIngesterHedgingMetrics(reg)
+
+	if cfg.ClientCertificateTenants.Enabled() {
+		d.ClientCertificateTenants, err = newClientCertificateTenants(cfg.ClientCertificateTenants, reg, log)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
@@ -367,6 +580,11 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		return il.MaxIngestionRate
 	})
 
+	d.instanceLimitsRetryAfter = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_distributor_instance_limits_retry_after_seconds",
+		Help: "Retry-After suggested to the clients by the last write request rejected because this distributor reached the instance limit.",
+	}, []string{limitLabel})
+
 	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "cortex_distributor_inflight_push_requests",
 		Help: "Current number of inflight push requests in distributor.",
@@ -389,26 +607,45 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	// Create the configured ingestion rate limit strategy (local or global). In case
 	// it's an internal dependency and we can't join the distributors ring, we skip rate
 	// limiting.
-	var ingestionRateStrategy, requestRateStrategy limiter.RateLimiterStrategy
+	var ingestionRateStrategy, ingestionBytesRateStrategy, ingestionQuotaRateStrategy, requestRateStrategy limiter.RateLimiterStrategy
 	var distributorsLifecycler *ring.BasicLifecycler
 	var distributorsRing *ring.Ring
 
 	if !canJoinDistributorsRing {
 		requestRateStrategy = newInfiniteRateStrategy()
 		ingestionRateStrategy = newInfiniteRateStrategy()
+		ingestionBytesRateStrategy = newInfiniteRateStrategy()
+		ingestionQuotaRateStrategy = newInfiniteRateStrategy()
 	} else {
-		distributorsRing, distributorsLifecycler, err = newRingAndLifecycler(cfg.DistributorRing, d.healthyInstancesCount, log, reg)
+		var healthyInstancesWatcher services.Service
+		distributorsRing, distributorsLifecycler, healthyInstancesWatcher, err = newRingAndLifecycler(cfg.DistributorRing, d.healthyInstancesCount, log, reg)
 		if err != nil {
 			return nil, err
 		}
 
-		subservices = append(subservices, distributorsLifecycler, distributorsRing)
+		subservices = append(subservices, distributorsLifecycler, distributorsRing, healthyInstancesWatcher)
+		// The distributors ring is only used to count the healthy distributors, so their tokens don't matter.
+		d.distributorsRingAdvisor = ringadvisor.NewHandler(ringadvisor.ConfigFromRingConfig(cfg.DistributorRing.toRingConfig(), false), distributorsRing.KVClient, distributorRingKey)
 		requestRateStrategy = newGlobalRateStrategy(newRequestRateStrategy(limits), d)
 		ingestionRateStrategy = newGlobalRateStrategy(newIngestionRateStrategy(limits), d)
-	}
-
-	d.requestRateLimiter = limiter.NewRateLimiter(requestRateStrategy, 10*time.Second)
-	d.ingestionRateLimiter = limiter.NewRateLimiter(ingestionRateStrategy, 10*time.Second)
+		ingestionBytesRateStrategy = newGlobalRateStrategy(newIngestionBytesRateStrategy(limits), d)
+		ingestionQuotaRateStrategy = newGlobalRateStrategy(newIngestionQuotaRateStrategy(limits), d)
+	}
+
+	d.requestRateLimiter = newRateLimiter(requestRateStrategy, 10*time.Second)
+	d.ingestionRateLimiter = newRateLimiter(ingestionRateStrategy, 10*time.Second)
+	d.ingestionBytesRateLimiter = newRateLimiter(ingestionBytesRateStrategy, 10*time.Second)
+	d.ingestionQuotaRateLimiter = newRateLimiter(ingestionQuotaRateStrategy, 10*time.Second)
+	d.ingestionQuotaMatchers = newIngestionQuotaMatchers(log)
+
+	// Apply the new global rate limits as soon as the number of healthy distributors changes,
+	// instead of waiting for the next periodic recheck of the limiters.
+	d.healthyInstancesCount.onChange(func() {
+		d.requestRateLimiter.recheckAll()
+		d.ingestionRateLimiter.recheckAll()
+		d.ingestionBytesRateLimiter.recheckAll()
+		d.ingestionQuotaRateLimiter.recheckAll()
+	})
 	d.distributorsLifecycler = distributorsLifecycler
 	d.distributorsRing = distributorsRing
 
@@ -419,6 +656,9 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	d.PushWithMiddlewares = d.wrapPushWithMiddlewares(d.push)
 
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
+	if cfg.PoolConfig.ConnectionsCheckPeriod > 0 {
+		subservices = append(subservices, newPoolConnectionsChecker(cfg.PoolConfig, d.ingesterPool, ingestersRing, reg, log))
+	}
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -431,17 +671,18 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	return d, nil
 }
 
-// newRingAndLifecycler creates a new distributor ring and lifecycler with all required lifecycler delegates
-func newRingAndLifecycler(cfg RingConfig, instanceCount *atomic.Uint32, logger log.Logger, reg prometheus.Registerer) (*ring.Ring, *ring.BasicLifecycler, error) {
+// newRingAndLifecycler creates a new distributor ring and lifecycler with all required lifecycler delegates,
+// and a service keeping the number of healthy distributors up to date as soon as the ring changes.
+func newRingAndLifecycler(cfg RingConfig, instanceCount *healthyInstancesCounter, logger log.Logger, reg prometheus.Registerer) (*ring.Ring, *ring.BasicLifecycler, services.Service, error) {
 	reg = prometheus.WrapRegistererWithPrefix("cortex_", reg)
 	kvStore, err := kv.NewClient(cfg.Common.KVStore, ring.GetCodec(), kv.RegistererWithKVName(reg, "distributor-lifecycler"), logger)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to initialize distributors' KV store")
+		return nil, nil, nil, errors.Wrap(err, "failed to initialize distributors' KV store")
 	}
 
 	lifecyclerCfg, err := cfg.ToBasicLifecyclerConfig(logger)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to build distributors' lifecycler config")
+		return nil, nil, nil, errors.Wrap(err, "failed to build distributors' lifecycler config")
 	}
 
 	var delegate ring.BasicLifecyclerDelegate
@@ -452,15 +693,17 @@ func newRingAndLifecycler(cfg RingConfig, instanceCount *atomic.Uint32, logger l
 
 	distributorsLifecycler, err := ring.NewBasicLifecycler(lifecyclerCfg, "distributor", distributorRingKey, kvStore, delegate, logger, reg)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to initialize distributors' lifecycler")
+		return nil, nil, nil, errors.Wrap(err, "failed to initialize distributors' lifecycler")
 	}
 
 	distributorsRing, err := ring.New(cfg.toRingConfig(), "distributor", distributorRingKey, logger, reg)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to initialize distributors' ring client")
+		return nil, nil, nil, errors.Wrap(err, "failed to initialize distributors' ring client")
 	}
 
-	return distributorsRing, distributorsLifecycler, nil
+	healthyInstancesWatcher := newHealthyInstancesWatcher(kvStore, distributorRingKey, instanceCount, cfg.Common.HeartbeatTimeout)
+
+	return distributorsRing, distributorsLifecycler, healthyInstancesWatcher, nil
 }
 
 func (d *Distributor) starting(ctx context.Context) error {
@@ -484,6 +727,13 @@ func (d *Distributor) running(ctx context.Context) error {
 	ingestionRateTicker := time.NewTicker(instanceIngestionRateTickInterval)
 	defer ingestionRateTicker.Stop()
 
+	var metadataCacheRingCheck <-chan time.Time
+	if d.metadataCache != nil {
+		ticker := time.NewTicker(metadataCacheRingCheckInterval)
+		defer ticker.Stop()
+		metadataCacheRingCheck = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -492,6 +742,9 @@ func (d *Distributor) running(ctx context.Context) error {
 		case <-ingestionRateTicker.C:
 			d.ingestionRate.Tick()
 
+		case <-metadataCacheRingCheck:
+			d.checkMetadataCacheIngesters()
+
 		case err := <-d.subservicesWatcher.Chan():
 			return errors.Wrap(err, "distributor subservice failed")
 		}
@@ -513,24 +766,79 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.incomingMetadata.DeleteLabelValues(userID)
 	d.nonHASamples.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
+	d.nonMonotonicSeriesSorted.DeleteLabelValues(userID)
+	d.labelNamesPolicyDroppedLabels.DeleteLabelValues(userID)
+	d.normalizedSeries.DeleteLabelValues(userID)
 
 	filter := prometheus.Labels{"user": userID}
 	d.dedupedSamples.DeletePartialMatch(filter)
 	d.discardedSamplesTooManyHaClusters.DeletePartialMatch(filter)
 	d.discardedSamplesRateLimited.DeletePartialMatch(filter)
 	d.discardedRequestsRateLimited.DeleteLabelValues(userID)
+	d.discardedRequestsBytesRateLimited.DeleteLabelValues(userID)
 	d.discardedExemplarsRateLimited.DeleteLabelValues(userID)
 	d.discardedMetadataRateLimited.DeleteLabelValues(userID)
+	d.discardedSamplesIngestionQuota.DeletePartialMatch(filter)
+	d.ingestionQuotaDiscardedSamples.DeletePartialMatch(filter)
+	d.ingestionQuotaMatchers.cleanupUser(userID)
 
 	d.sampleValidationMetrics.DeleteUserMetrics(userID)
 	d.exemplarValidationMetrics.DeleteUserMetrics(userID)
 	d.metadataValidationMetrics.DeleteUserMetrics(userID)
+
+	d.discardedSamplesExamples.DeleteUser(userID)
+
+	if d.dualWriter != nil {
+		d.dualWriter.cleanupUser(userID)
+	}
+
+	if d.aggregator != nil {
+		d.aggregator.cleanupUser(userID)
+	}
+
+	if d.spillQueue != nil {
+		d.spillQueue.cleanupUser(userID)
+	}
+
+	if d.circuitBreaker != nil {
+		d.circuitBreaker.cleanupUser(userID)
+	}
+
+	if d.topMetrics != nil {
+		d.topMetrics.cleanupUser(userID)
+	}
+
+	if d.metadataCache != nil {
+		d.metadataCache.cleanupUser(userID)
+	}
+}
+
+// recordDiscardedRequestExample records the first series with samples of a request whose samples have all been
+// discarded as an example of series discarded for the input reason.
+func (d *Distributor) recordDiscardedRequestExample(userID, reason string, req *mimirpb.WriteRequest) {
+	for _, ts := range req.Timeseries {
+		switch {
+		case len(ts.Samples) > 0:
+			d.discardedSamplesExamples.Record(userID, reason, ts.Labels, ts.Samples[0].TimestampMs)
+		case len(ts.Histograms) > 0:
+			d.discardedSamplesExamples.Record(userID, reason, ts.Labels, ts.Histograms[0].Timestamp)
+		default:
+			continue
+		}
+		return
+	}
+}
+
+// DiscardedSamplesHandler returns the examples of series discarded by this distributor for the authenticated tenant.
+func (d *Distributor) DiscardedSamplesHandler(w http.ResponseWriter, r *http.Request) {
+	d.discardedSamplesExamples.Handler(w, r)
 }
 
 func (d *Distributor) RemoveGroupMetricsForUser(userID, group string) {
 	d.dedupedSamples.DeleteLabelValues(userID, group)
 	d.discardedSamplesTooManyHaClusters.DeleteLabelValues(userID, group)
 	d.discardedSamplesRateLimited.DeleteLabelValues(userID, group)
+	d.discardedSamplesIngestionQuota.DeleteLabelValues(userID, group)
 	d.sampleValidationMetrics.DeleteUserMetricsForGroup(userID, group)
 }
 
@@ -540,11 +848,29 @@ func (d *Distributor) stopping(_ error) error {
 }
 
 func (d *Distributor) tokenForLabels(userID string, labels []mimirpb.LabelAdapter) uint32 {
-	return shardByAllLabels(userID, labels)
+	return d.shardingScheme(userID).tokenForLabels(userID, labels)
 }
 
 func (d *Distributor) tokenForMetadata(userID string, metricName string) uint32 {
-	return shardByMetricName(userID, metricName)
+	return d.shardingScheme(userID).tokenForMetadata(userID, metricName)
+}
+
+// shardingScheme returns the scheme used to compute the ring tokens of the tenant's series and metadata.
+func (d *Distributor) shardingScheme(userID string) shardingScheme {
+	if scheme := shardingSchemeByName(d.limits.SeriesShardingScheme(userID)); scheme != nil {
+		return scheme
+	}
+	return shardingSchemeV1{}
+}
+
+// migrationShardingScheme returns the scheme used to compute the second ring token of the tenant's series while
+// migrating between sharding schemes, or nil if the tenant isn't migrating.
+func (d *Distributor) migrationShardingScheme(userID string) shardingScheme {
+	migration := d.limits.SeriesShardingMigrationScheme(userID)
+	if migration == "" || migration == d.limits.SeriesShardingScheme(userID) {
+		return nil
+	}
+	return shardingSchemeByName(migration)
 }
 
 // shardByMetricName returns the token for the given metric. The provided metricName
@@ -602,31 +928,74 @@ func (d *Distributor) checkSample(ctx context.Context, userID, cluster, replica
 // The returned error may retain the series labels.
 // It uses the passed nowt time to observe the delay of sample timestamps.
 func (d *Distributor) validateSeries(nowt time.Time, ts *mimirpb.PreallocTimeseries, userID, group string, skipLabelNameValidation bool, minExemplarTS int64) error {
-	if err := validation.ValidateLabels(d.sampleValidationMetrics, d.limits, userID, group, ts.Labels, skipLabelNameValidation); err != nil {
+	if err := d.validateSeriesWithMetrics(d.sampleValidationMetrics, d.exemplarValidationMetrics, d.sampleDelayHistogram, nowt, ts, userID, group, skipLabelNameValidation, minExemplarTS); err != nil {
 		return err
 	}
 
+	// The limit is enforced after the validation, so that only valid exemplars are accounted for.
+	// It's not enforced by the dry-run validation, which must not consume the budget of the series.
+	d.enforceMaxExemplarsPerSeries(nowt, ts, userID)
+	return nil
+}
+
+// enforceMaxExemplarsPerSeries discards the exemplars of the series exceeding the maximum number
+// of exemplars per series per minute.
+func (d *Distributor) enforceMaxExemplarsPerSeries(now time.Time, ts *mimirpb.PreallocTimeseries, userID string) {
+	limit := d.limits.MaxExemplarsPerSeriesPerMinute(userID)
+	if limit <= 0 || len(ts.Exemplars) == 0 {
+		return
+	}
+
+	seriesHash := mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash()
+	accepted := d.exemplarsPerSeries.accept(userID, seriesHash, now, limit, len(ts.Exemplars))
+	if discarded := len(ts.Exemplars) - accepted; discarded > 0 {
+		for len(ts.Exemplars) > accepted {
+			ts.DeleteExemplarByMovingLast(len(ts.Exemplars) - 1)
+		}
+		d.exemplarValidationMetrics.DiscardedPerSeriesLimit(userID, discarded)
+	}
+}
+
+// validateSeriesWithMetrics is like validateSeries, but tracks the discarded data in the input metrics.
+// The delay of sample timestamps is not observed if sampleDelay is nil.
+func (d *Distributor) validateSeriesWithMetrics(sampleMetrics *validation.SampleValidationMetrics, exemplarMetrics *validation.ExemplarValidationMetrics, sampleDelay prometheus.Observer, nowt time.Time, ts *mimirpb.PreallocTimeseries, userID, group string, skipLabelNameValidation bool, minExemplarTS int64) error {
+	if err := validation.ValidateLabels(sampleMetrics, d.limits, userID, group, ts.Labels, skipLabelNameValidation); err != nil {
+		return err
+	}
+
+	switch d.limits.NonMonotonicSamplesPolicy(userID) {
+	case validation.NonMonotonicSamplesPolicySort:
+		if validation.SortSamplesByTimestamp(ts.Samples, ts.Histograms) {
+			ts.SamplesReordered()
+			d.nonMonotonicSeriesSorted.WithLabelValues(userID).Inc()
+		}
+	case validation.NonMonotonicSamplesPolicyReject:
+		if err := validation.ValidateMonotonicTimestamps(sampleMetrics, userID, group, ts.Labels, ts.Samples, ts.Histograms); err != nil {
+			return err
+		}
+	}
+
 	now := model.TimeFromUnixNano(nowt.UnixNano())
 
 	for _, s := range ts.Samples {
 
 		delta := now - model.Time(s.TimestampMs)
-		if delta > 0 {
-			d.sampleDelayHistogram.Observe(float64(delta) / 1000)
+		if delta > 0 && sampleDelay != nil {
+			sampleDelay.Observe(float64(delta) / 1000)
 		}
 
-		if err := validation.ValidateSample(d.sampleValidationMetrics, now, d.limits, userID, group, ts.Labels, s); err != nil {
+		if err := validation.ValidateSample(sampleMetrics, now, d.limits, userID, group, ts.Labels, s); err != nil {
 			return err
 		}
 	}
 
 	for _, h := range ts.Histograms {
 		delta := now - model.Time(h.Timestamp)
-		if delta > 0 {
-			d.sampleDelayHistogram.Observe(float64(delta) / 1000)
+		if delta > 0 && sampleDelay != nil {
+			sampleDelay.Observe(float64(delta) / 1000)
 		}
 
-		if err := validation.ValidateSampleHistogram(d.sampleValidationMetrics, now, d.limits, userID, group, ts.Labels, h); err != nil {
+		if err := validation.ValidateSampleHistogram(sampleMetrics, now, d.limits, userID, group, ts.Labels, h); err != nil {
 			return err
 		}
 	}
@@ -638,14 +1007,14 @@ func (d *Distributor) validateSeries(nowt time.Time, ts *mimirpb.PreallocTimeser
 
 	for i := 0; i < len(ts.Exemplars); {
 		e := ts.Exemplars[i]
-		if err := validation.ValidateExemplar(d.exemplarValidationMetrics, userID, ts.Labels, e); err != nil {
+		if err := validation.ValidateExemplar(exemplarMetrics, userID, ts.Labels, e); err != nil {
 			// An exemplar validation error prevents ingesting samples
 			// in the same series object. However because the current Prometheus
 			// remote write implementation only populates one or the other,
 			// there never will be any.
 			return err
 		}
-		if !validation.ExemplarTimestampOK(d.exemplarValidationMetrics, userID, minExemplarTS, e) {
+		if !validation.ExemplarTimestampOK(exemplarMetrics, userID, minExemplarTS, e) {
 			ts.DeleteExemplarByMovingLast(i)
 			// Don't increase index i. After moving last exemplar to this index, we want to check it again.
 			continue
@@ -660,14 +1029,44 @@ func (d *Distributor) validateSeries(nowt time.Time, ts *mimirpb.PreallocTimeser
 func (d *Distributor) wrapPushWithMiddlewares(next push.Func) push.Func {
 	var middlewares []PushWrapper
 
+	// Each step is traced with its own span, and records its decision in the debug report.
+	pushStep := func(name string, middleware PushWrapper) PushWrapper {
+		return tracePushStep(name, debugPushStep(name, middleware))
+	}
+
 	// The middlewares will be applied to the request (!) in the specified order, from first to last.
 	// To guarantee that, middleware functions will be called in reversed order, wrapping the
 	// result from previous call.
-	middlewares = append(middlewares, d.limitsMiddleware) // should run first because it checks limits before other middlewares need to read the request body
-	middlewares = append(middlewares, d.metricsMiddleware)
-	middlewares = append(middlewares, d.prePushHaDedupeMiddleware)
-	middlewares = append(middlewares, d.prePushRelabelMiddleware)
-	middlewares = append(middlewares, d.prePushValidationMiddleware)
+	// The debug middleware should run first, because it enables the debug report the other middlewares record their decision to.
+	middlewares = append(middlewares, d.pushDebugMiddleware)
+	middlewares = append(middlewares, d.pushTracingMiddleware)
+	// The limits middleware should run before the other middlewares, because it checks limits before they need to read the request body.
+	middlewares = append(middlewares, pushStep("limits", d.limitsMiddleware))
+	middlewares = append(middlewares, pushStep("metrics", d.metricsMiddleware))
+	middlewares = append(middlewares, pushStep("ha_dedupe", d.prePushHaDedupeMiddleware))
+	middlewares = append(middlewares, pushStep("relabel", d.prePushRelabelMiddleware))
+	middlewares = append(middlewares, pushStep("validation", d.prePushValidationMiddleware))
+	// The top metrics only account for the samples which passed the validation, before they're aggregated.
+	if d.topMetrics != nil {
+		middlewares = append(middlewares, pushStep("top_metrics", d.prePushTopMetricsMiddleware))
+	}
+	// The unchanged metadata is dropped once validated, before being sharded.
+	if d.metadataCache != nil {
+		middlewares = append(middlewares, pushStep("metadata_cache", d.prePushMetadataCacheMiddleware))
+	}
+	if d.aggregator != nil {
+		middlewares = append(middlewares, pushStep("aggregation", d.prePushAggregationMiddleware))
+	}
+	if d.dualWriter != nil {
+		middlewares = append(middlewares, pushStep("dual_write", d.prePushDualWriteMiddleware))
+	}
+	if d.spillQueue != nil {
+		middlewares = append(middlewares, pushStep("spill_queue", d.prePushSpillQueueMiddleware))
+	}
+	// The circuit breaker should run last, to only record the outcome of the writes to the ingesters.
+	if d.circuitBreaker != nil {
+		middlewares = append(middlewares, pushStep("circuit_breaker", d.prePushCircuitBreakerMiddleware))
+	}
 	middlewares = append(middlewares, d.cfg.PushWrappers...)
 
 	for ix := len(middlewares) - 1; ix >= 0; ix-- {
@@ -701,16 +1100,9 @@ func (d *Distributor) prePushHaDedupeMiddleware(next push.Func) push.Func {
 			return next(ctx, pushReq)
 		}
 
-		haReplicaLabel := d.limits.HAReplicaLabel(userID)
-		cluster, replica := findHALabels(haReplicaLabel, d.limits.HAClusterLabel(userID), req.Timeseries[0].Labels)
-		// Make a copy of these, since they may be retained as labels on our metrics, e.g. dedupedSamples.
-		cluster, replica = copyString(cluster), copyString(replica)
-
-		span := opentracing.SpanFromContext(ctx)
-		if span != nil {
-			span.SetTag("cluster", cluster)
-			span.SetTag("replica", replica)
-		}
+		// The levels of the HA topology are evaluated from the outermost to the innermost: the samples
+		// are deduped as soon as the replica of a level isn't the elected one.
+		levels := findHALevels(d.limits, userID, req.Timeseries[0].Labels)
 
 		numSamples := 0
 		group := d.activeGroups.UpdateActiveGroupTimestamp(userID, validation.GroupLabel(d.limits, userID, req.Timeseries), time.Now())
@@ -718,28 +1110,48 @@ func (d *Distributor) prePushHaDedupeMiddleware(next push.Func) push.Func {
 			numSamples += len(ts.Samples) + len(ts.Histograms)
 		}
 
-		removeReplica, err := d.checkSample(ctx, userID, cluster, replica)
-		if err != nil {
-			if errors.Is(err, replicasNotMatchError{}) {
-				// These samples have been deduped.
-				d.dedupedSamples.WithLabelValues(userID, cluster).Add(float64(numSamples))
-				return nil, httpgrpc.Errorf(http.StatusAccepted, err.Error())
+		span := opentracing.SpanFromContext(ctx)
+		var removeReplicaLabels []string
+		for _, level := range levels {
+			if span != nil {
+				span.SetTag("cluster", level.cluster)
+				span.SetTag("replica", level.replica)
+				if level.cluster != "" {
+					span.SetBaggageItem(haClusterBaggageKey, level.cluster)
+				}
 			}
 
-			if errors.Is(err, tooManyClustersError{}) {
-				d.discardedSamplesTooManyHaClusters.WithLabelValues(userID, group).Add(float64(numSamples))
-				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+			removeReplica, err := d.checkSample(ctx, userID, level.cluster, level.replica)
+			if err != nil {
+				if errors.Is(err, replicasNotMatchError{}) {
+					// These samples have been deduped.
+					d.dedupedSamples.WithLabelValues(userID, level.cluster).Add(float64(numSamples))
+					push.IngestionReportFromContext(ctx).AddDeduped(numSamples)
+					return nil, httpgrpc.Errorf(http.StatusAccepted, err.Error())
+				}
+
+				if errors.Is(err, tooManyClustersError{}) {
+					d.discardedSamplesTooManyHaClusters.WithLabelValues(userID, group).Add(float64(numSamples))
+					d.recordDiscardedRequestExample(userID, validation.ReasonTooManyHAClusters, req)
+					return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+				}
+
+				return nil, err
 			}
 
-			return nil, err
+			if removeReplica {
+				removeReplicaLabels = append(removeReplicaLabels, level.replicaLabel)
+			}
 		}
 
-		if removeReplica {
+		if len(removeReplicaLabels) > 0 {
 			// If we found both the cluster and replica labels, we only want to include the cluster label when
 			// storing series in Mimir. If we kept the replica label we would end up with another series for the same
 			// series we're trying to dedupe when HA tracking moves over to a different replica.
 			for ix := range req.Timeseries {
-				req.Timeseries[ix].RemoveLabel(haReplicaLabel)
+				for _, replicaLabel := range removeReplicaLabels {
+					req.Timeseries[ix].RemoveLabel(replicaLabel)
+				}
 			}
 		} else {
 			// If there wasn't an error but removeReplica is false that means we didn't find both HA labels.
@@ -771,47 +1183,29 @@ func (d *Distributor) prePushRelabelMiddleware(next push.Func) push.Func {
 		}
 
 		var removeTsIndexes []int
+		numNormalized := 0
 		lb := labels.NewBuilder(labels.EmptyLabels())
 		for tsIdx := 0; tsIdx < len(req.Timeseries); tsIdx++ {
-			ts := req.Timeseries[tsIdx]
-
-			if mrc := d.limits.MetricRelabelConfigs(userID); len(mrc) > 0 {
-				mimirpb.FromLabelAdaptersToBuilder(ts.Labels, lb)
-				lb.Set(metaLabelTenantID, userID)
-				keep := relabel.ProcessBuilder(lb, mrc...)
-				if !keep {
-					removeTsIndexes = append(removeTsIndexes, tsIdx)
-					continue
-				}
-				lb.Del(metaLabelTenantID)
-				req.Timeseries[tsIdx].SetLabels(mimirpb.FromBuilderToLabelAdapters(lb, ts.Labels))
+			outcome, normalized := d.relabelSeries(userID, &req.Timeseries[tsIdx], lb)
+			if normalized {
+				numNormalized++
 			}
-
-			for _, labelName := range d.limits.DropLabels(userID) {
-				req.Timeseries[tsIdx].RemoveLabel(labelName)
-			}
-
-			// Prometheus strips empty values before storing; drop them now, before sharding to ingesters.
-			req.Timeseries[tsIdx].RemoveEmptyLabelValues()
-
-			if len(ts.Labels) == 0 {
+			if outcome != relabelKept {
 				removeTsIndexes = append(removeTsIndexes, tsIdx)
-				continue
 			}
+		}
 
-			// We rely on sorted labels in different places:
-			// 1) When computing token for labels, and sharding by all labels. Here different order of labels returns
-			// different tokens, which is bad.
-			// 2) In validation code, when checking for duplicate label names. As duplicate label names are rejected
-			// later in the validation phase, we ignore them here.
-			// 3) Ingesters expect labels to be sorted in the Push request.
-			req.Timeseries[tsIdx].SortLabelsIfNeeded()
+		if numNormalized > 0 {
+			d.normalizedSeries.WithLabelValues(userID).Add(float64(numNormalized))
 		}
 
 		if len(removeTsIndexes) > 0 {
+			droppedSamples := 0
 			for _, removeTsIndex := range removeTsIndexes {
+				droppedSamples += len(req.Timeseries[removeTsIndex].Samples) + len(req.Timeseries[removeTsIndex].Histograms)
 				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
 			}
+			push.IngestionReportFromContext(ctx).AddRelabelDropped(droppedSamples)
 			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
 		}
 
@@ -820,6 +1214,74 @@ func (d *Distributor) prePushRelabelMiddleware(next push.Func) push.Func {
 	}
 }
 
+type relabelOutcome int
+
+const (
+	relabelKept relabelOutcome = iota
+	relabelDroppedByRules
+	relabelDroppedNoLabels
+)
+
+// relabelSeries applies the tenant's label value normalization rules, metric relabel configs and dropped labels
+// to the series, in place. The returned outcome tells whether the series should be kept or dropped, and why, and
+// the returned bool whether any label value has been changed by the normalization rules.
+func (d *Distributor) relabelSeries(userID string, ts *mimirpb.PreallocTimeseries, lb *labels.Builder) (_ relabelOutcome, normalized bool) {
+	if rules := d.limits.LabelValueNormalizationRules(userID); len(rules) > 0 {
+		normalized = normalizeLabelValues(ts, rules)
+	}
+
+	if mrc := d.limits.MetricRelabelConfigs(userID); len(mrc) > 0 {
+		mimirpb.FromLabelAdaptersToBuilder(ts.Labels, lb)
+		lb.Set(metaLabelTenantID, userID)
+		keep := relabel.ProcessBuilder(lb, mrc...)
+		if !keep {
+			return relabelDroppedByRules, normalized
+		}
+		lb.Del(metaLabelTenantID)
+		ts.SetLabels(mimirpb.FromBuilderToLabelAdapters(lb, ts.Labels))
+	}
+
+	for _, labelName := range d.limits.DropLabels(userID) {
+		ts.RemoveLabel(labelName)
+	}
+
+	// Prometheus strips empty values before storing; drop them now, before sharding to ingesters.
+	ts.RemoveEmptyLabelValues()
+
+	if len(ts.Labels) == 0 {
+		return relabelDroppedNoLabels, normalized
+	}
+
+	// We rely on sorted labels in different places:
+	// 1) When computing token for labels, and sharding by all labels. Here different order of labels returns
+	// different tokens, which is bad.
+	// 2) In validation code, when checking for duplicate label names. As duplicate label names are rejected
+	// later in the validation phase, we ignore them here.
+	// 3) Ingesters expect labels to be sorted in the Push request.
+	ts.SortLabelsIfNeeded()
+	return relabelKept, normalized
+}
+
+// normalizeLabelValues applies the label value normalization rules to the series, in place, and returns
+// whether any label value has been changed.
+func normalizeLabelValues(ts *mimirpb.PreallocTimeseries, rules []validation.LabelValueNormalizationRule) bool {
+	changed := false
+	for idx, l := range ts.Labels {
+		value := l.Value
+		for _, rule := range rules {
+			if rule.Label == l.Name {
+				value = rule.Normalize(value)
+			}
+		}
+
+		if value != l.Value {
+			ts.SetLabelValue(idx, value)
+			changed = true
+		}
+	}
+	return changed
+}
+
 func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
 		cleanupInDefer := true
@@ -840,6 +1302,7 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 		}
 
 		now := mtime.Now()
+		ingestionReport := push.IngestionReportFromContext(ctx)
 		d.receivedRequests.WithLabelValues(userID).Add(1)
 		d.activeUsers.UpdateUserTimestamp(userID, now)
 
@@ -885,6 +1348,25 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 
 			d.labelsHistogram.Observe(float64(len(ts.Labels)))
 
+			// Enforce the tenant's policy on the label names before the validation, because it may drop some labels.
+			droppedLabels, policyErr := validation.ValidateLabelNamesPolicy(d.sampleValidationMetrics, d.limits, userID, group, &req.Timeseries[tsIdx])
+			if droppedLabels > 0 {
+				d.labelNamesPolicyDroppedLabels.WithLabelValues(userID).Add(float64(droppedLabels))
+			}
+			if policyErr != nil {
+				if ingestionReport != nil {
+					ingestionReport.AddInvalid(ingestionReportReason(policyErr), len(ts.Samples)+len(ts.Histograms))
+				}
+				if d.limits.LabelNamesPolicyAction(userID) == validation.LabelNamesPolicyActionRejectRequest {
+					return nil, httpgrpc.Errorf(http.StatusBadRequest, policyErr.Error())
+				}
+				if firstPartialErr == nil {
+					firstPartialErr = httpgrpc.Errorf(http.StatusBadRequest, policyErr.Error())
+				}
+				removeIndexes = append(removeIndexes, tsIdx)
+				continue
+			}
+
 			skipLabelNameValidation := d.cfg.SkipLabelNameValidation || req.GetSkipLabelNameValidation()
 			// Note that validateSeries may drop some data in ts.
 			validationErr := d.validateSeries(now, &req.Timeseries[tsIdx], userID, group, skipLabelNameValidation, minExemplarTS)
@@ -892,6 +1374,9 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 			// Errors in validation are considered non-fatal, as one series in a request may contain
 			// invalid data but all the remaining series could be perfectly valid.
 			if validationErr != nil {
+				if ingestionReport != nil {
+					ingestionReport.AddInvalid(ingestionReportReason(validationErr), len(ts.Samples)+len(ts.Histograms))
+				}
 				if firstPartialErr == nil {
 					// The series labels may be retained by validationErr but that's not a problem for this
 					// use case because we format it calling Error() and then we discard it.
@@ -912,6 +1397,15 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 			removeIndexes = removeIndexes[:0]
 		}
 
+		// Discard the series exceeding the tenant's ingestion quotas.
+		discardedSamples, discardedExemplars, quotaErr := d.applyIngestionQuotas(now, userID, group, req)
+		validatedSamples -= discardedSamples
+		validatedExemplars -= discardedExemplars
+		ingestionReport.AddInvalid(validation.ReasonIngestionQuotaExceeded, discardedSamples)
+		if quotaErr != nil && firstPartialErr == nil {
+			firstPartialErr = quotaErr
+		}
+
 		for mIdx, m := range req.Metadata {
 			if validationErr := validation.CleanAndValidateMetadata(d.metadataValidationMetrics, d.limits, userID, m); validationErr != nil {
 				if firstPartialErr == nil {
@@ -937,6 +1431,7 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 		totalN := validatedSamples + validatedExemplars + validatedMetadata
 		if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
 			d.discardedSamplesRateLimited.WithLabelValues(userID, group).Add(float64(validatedSamples))
+			d.recordDiscardedRequestExample(userID, validation.ReasonRateLimited, req)
 			d.discardedExemplarsRateLimited.WithLabelValues(userID).Add(float64(validatedExemplars))
 			d.discardedMetadataRateLimited.WithLabelValues(userID).Add(float64(validatedMetadata))
 			// Return a 429 here to tell the client it is going too fast.
@@ -954,11 +1449,21 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 			// Errors resulting from the pushing to the ingesters have priority over validation errors.
 			return nil, err
 		}
+		ingestionReport.AddAccepted(validatedSamples)
 
 		return res, firstPartialErr
 	}
 }
 
+// ingestionReportReason returns the reason the samples which failed the validation with the input error
+// are reported with in the ingestion report, which is the discard reason of the validation error.
+func ingestionReportReason(err error) string {
+	if reason, ok := validation.DiscardReason(err); ok {
+		return reason
+	}
+	return push.IngestionReportReasonUnknown
+}
+
 // metricsMiddleware updates metrics which are expected to account for all received data,
 // including data that later gets modified or dropped.
 func (d *Distributor) metricsMiddleware(next push.Func) push.Func {
@@ -991,6 +1496,7 @@ func (d *Distributor) metricsMiddleware(next push.Func) push.Func {
 		d.incomingSamples.WithLabelValues(userID).Add(float64(numSamples))
 		d.incomingExemplars.WithLabelValues(userID).Add(float64(numExemplars))
 		d.incomingMetadata.WithLabelValues(userID).Add(float64(len(req.Metadata)))
+		push.IngestionReportFromContext(ctx).AddReceived(numSamples)
 
 		cleanupInDefer = false
 		return next(ctx, pushReq)
@@ -1016,12 +1522,14 @@ func (d *Distributor) limitsMiddleware(next push.Func) push.Func {
 
 		il := d.getInstanceLimits()
 		if il.MaxInflightPushRequests > 0 && inflight > int64(il.MaxInflightPushRequests) {
-			return nil, errMaxInflightRequestsReached
+			retryAfter := inflightRetryAfter(inflight, int64(il.MaxInflightPushRequests))
+			return nil, d.instanceLimitError(errMaxInflightRequestsReached, "max_inflight_push_requests", retryAfter)
 		}
 
 		if il.MaxIngestionRate > 0 {
 			if rate := d.ingestionRate.Rate(); rate >= il.MaxIngestionRate {
-				return nil, errMaxIngestionRateReached
+				retryAfter := ingestionRateRetryAfter(rate, il.MaxIngestionRate, instanceIngestionRateAlpha, instanceIngestionRateTickInterval)
+				return nil, d.instanceLimitError(errMaxIngestionRateReached, "max_ingestion_rate", retryAfter)
 			}
 		}
 
@@ -1051,7 +1559,18 @@ func (d *Distributor) limitsMiddleware(next push.Func) push.Func {
 		})
 
 		if il.MaxInflightPushRequestsBytes > 0 && inflightBytes > int64(il.MaxInflightPushRequestsBytes) {
-			return nil, errMaxInflightRequestsBytesReached
+			retryAfter := inflightRetryAfter(inflightBytes, int64(il.MaxInflightPushRequestsBytes))
+			return nil, d.instanceLimitError(errMaxInflightRequestsBytesReached, "max_inflight_push_requests_bytes", retryAfter)
+		}
+
+		// The number of samples is a poor proxy for the cost of write requests with very wide series,
+		// so the uncompressed size of the request is rate limited too.
+		if !d.ingestionBytesRateLimiter.AllowN(now, userID, int(reqSize)) {
+			d.discardedRequestsBytesRateLimited.WithLabelValues(userID).Add(1)
+
+			// Return a 429 here to tell the client it is going too fast.
+			// Client may discard the data or slow down and re-send.
+			return nil, httpgrpc.Errorf(http.StatusTooManyRequests, validation.NewIngestionBytesRateLimitedError(d.limits.IngestionBytesRate(userID), d.limits.IngestionBytesBurstSize(userID)).Error())
 		}
 
 		cleanupInDefer = false
@@ -1059,6 +1578,13 @@ func (d *Distributor) limitsMiddleware(next push.Func) push.Func {
 	}
 }
 
+// instanceLimitError records the Retry-After suggested to the client whose write request has been rejected because
+// the given instance limit has been reached, and returns the error carrying it.
+func (d *Distributor) instanceLimitError(err error, limit string, retryAfter time.Duration) error {
+	d.instanceLimitsRetryAfter.WithLabelValues(limit).Set(retryAfter.Seconds())
+	return newInstanceLimitError(err, retryAfter)
+}
+
 // Push is gRPC method registered as client.IngesterServer and distributor.DistributorServer.
 func (d *Distributor) Push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
 	pushReq := push.NewParsedRequest(req)
@@ -1102,12 +1628,32 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 		span.SetTag("organization", userID)
 	}
 
-	seriesKeys := d.getTokensForSeries(userID, req.Timeseries)
-	metadataKeys := make([]uint32, 0, len(req.Metadata))
+	// The exemplars are written with their own replication factor, so they're split from the series
+	// written to the ingesters with the replication factor of the ring.
+	timeseries, exemplarSeries := req.Timeseries, []mimirpb.PreallocTimeseries(nil)
+	if d.exemplarsReplicator != nil {
+		timeseries, exemplarSeries = splitExemplars(req.Timeseries)
+	}
+
+	// While migrating between sharding schemes, the series are also written with the token of the migration scheme.
+	scheme, migrationScheme := d.shardingScheme(userID), d.migrationShardingScheme(userID)
+	migrationSeries := 0
+	if migrationScheme != nil {
+		migrationSeries = len(timeseries)
+	}
 
+	// All tokens, stored in order: series, metadata, series with the migration scheme.
+	keysBuf := getTokensSlice(len(timeseries) + len(req.Metadata) + migrationSeries)
+	keys := appendTokensForSeries(*keysBuf, userID, scheme, timeseries)
+	initialMetadataIndex := len(keys)
 	for _, m := range req.Metadata {
-		metadataKeys = append(metadataKeys, d.tokenForMetadata(userID, m.MetricFamilyName))
+		keys = append(keys, scheme.tokenForMetadata(userID, m.MetricFamilyName))
 	}
+	initialMigrationIndex := len(keys)
+	if migrationScheme != nil {
+		keys = appendTokensForSeries(keys, userID, migrationScheme, timeseries)
+	}
+	*keysBuf = keys
 
 	// Get a subring if tenant has shuffle shard size configured.
 	subRing := d.ingestersRing.ShuffleShard(userID, d.limits.IngestionTenantShardSize(userID))
@@ -1122,53 +1668,131 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 		localCtx = opentracing.ContextWithSpan(localCtx, sp)
 	}
 
-	// All tokens, stored in order: series, metadata.
-	keys := make([]uint32, len(seriesKeys)+len(metadataKeys))
-	initialMetadataIndex := len(seriesKeys)
-	copy(keys, seriesKeys)
-	copy(keys[initialMetadataIndex:], metadataKeys)
-
 	// we must not re-use buffers now until all DoBatch goroutines have finished,
 	// so set this flag false and pass cleanup() to DoBatch.
 	cleanupInDefer = false
+	cleanup := func() { pushReq.CleanUp(); putTokensSlice(keysBuf); cancel() }
+
+	// The series written to a quorum of ingesters are tracked if the request may be partially accepted.
+	var partialAcceptance *partialAcceptanceTracker
+	if req.PartialAcceptance && d.cfg.PartialAcceptance.TimeoutMargin > 0 && len(timeseries) > 0 {
+		partialAcceptance = newPartialAcceptanceTracker(len(keys), subRing.ReplicationFactor())
+	}
+
+	// The exemplars-only series, and the failed series of the partially accepted requests, reference the
+	// buffers of the request too, so the cleanup runs once both DoBatch and their handling have finished.
+	if len(exemplarSeries) > 0 || partialAcceptance != nil {
+		pending := atomic.NewInt32(2)
+		cleanupOnce := cleanup
+		cleanup = func() {
+			if pending.Dec() == 0 {
+				cleanupOnce()
+			}
+		}
+		defer cleanup()
+	}
+
+	debugReport := push.DebugReportFromContext(ctx)
 
 	if d.cfg.WriteRequestsBufferPoolingEnabled {
 		slabPool := pool.NewFastReleasingSlabPool[byte](&d.writeRequestBytePool, writeRequestSlabPoolSize)
 		localCtx = ingester_client.WithSlabPool(localCtx, slabPool)
 	}
 
-	err = ring.DoBatch(ctx, ring.WriteNoExtend, subRing, keys, func(ingester ring.InstanceDesc, indexes []int) error {
-		var timeseriesCount, metadataCount int
-		for _, i := range indexes {
-			if i >= initialMetadataIndex {
-				metadataCount++
-			} else {
-				timeseriesCount++
+	sendToIngester := func(ingester ring.InstanceDesc, indexes []int) error {
+		timeseriesIndexes, metadataIndexes := splitIngesterBatchIndexes(indexes, initialMetadataIndex)
+		metadataIndexes, migrationIndexes := splitIngesterBatchIndexes(metadataIndexes, initialMigrationIndex)
+		migrationIndexes = dedupeMigrationIndexes(migrationIndexes, timeseriesIndexes, initialMigrationIndex)
+
+		var ingesterTimeseries []mimirpb.PreallocTimeseries
+		if len(timeseriesIndexes)+len(migrationIndexes) > 0 {
+			timeseriesBuf := getIngesterTimeseriesSlice(len(timeseriesIndexes) + len(migrationIndexes))
+			defer putIngesterTimeseriesSlice(timeseriesBuf)
+
+			for _, i := range timeseriesIndexes {
+				*timeseriesBuf = append(*timeseriesBuf, timeseries[i])
+			}
+			for _, i := range migrationIndexes {
+				*timeseriesBuf = append(*timeseriesBuf, timeseries[i-initialMigrationIndex])
 			}
+			ingesterTimeseries = *timeseriesBuf
 		}
 
-		timeseries := preallocSliceIfNeeded[mimirpb.PreallocTimeseries](timeseriesCount)
-		metadata := preallocSliceIfNeeded[*mimirpb.MetricMetadata](metadataCount)
+		metadata := preallocSliceIfNeeded[*mimirpb.MetricMetadata](len(metadataIndexes))
+		for _, i := range metadataIndexes {
+			metadata = append(metadata, req.Metadata[i-initialMetadataIndex])
+		}
 
-		for _, i := range indexes {
-			if i >= initialMetadataIndex {
-				metadata = append(metadata, req.Metadata[i-initialMetadataIndex])
-			} else {
-				timeseries = append(timeseries, req.Timeseries[i])
+		var start time.Time
+		if debugReport != nil {
+			start = time.Now()
+		}
+
+		err := d.send(localCtx, ingester, ingesterTimeseries, metadata, req.Source)
+		if err == nil && partialAcceptance != nil {
+			partialAcceptance.recordSuccess(indexes)
+		}
+
+		if debugReport != nil {
+			result := push.DebugReportIngester{
+				Addr:     ingester.Addr,
+				Zone:     ingester.Zone,
+				Series:   len(ingesterTimeseries),
+				Metadata: len(metadata),
+				Duration: model.Duration(time.Since(start)),
 			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			debugReport.AddIngester(result)
 		}
 
-		err := d.send(localCtx, ingester, timeseries, metadata, req.Source)
 		if errors.Is(err, context.DeadlineExceeded) {
 			return httpgrpc.Errorf(500, "exceeded configured distributor remote timeout: %s", err.Error())
 		}
 		return err
-	}, func() { pushReq.CleanUp(); cancel() })
+	}
+
+	// The partially accepted requests are answered before the remote timeout expires, while the series
+	// keep being written to the ingesters in the background.
+	batchCtx := ctx
+	if partialAcceptance != nil {
+		var cancelBatch context.CancelFunc
+		batchCtx, cancelBatch = context.WithTimeout(ctx, d.cfg.RemoteTimeout-d.cfg.PartialAcceptance.TimeoutMargin)
+		defer cancelBatch()
+	}
+
+	// The write request may only carry exemplars, which are written separately.
+	if len(keys) > 0 {
+		err = ring.DoBatch(batchCtx, ring.WriteNoExtend, subRing, keys, sendToIngester, cleanup)
+	} else {
+		cleanup()
+	}
 
+	var failedSeries []mimirpb.FailedSeries
+	if err != nil && partialAcceptance != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		// If no series has been written, the request fails as a whole.
+		var anyWritten bool
+		if failedSeries, anyWritten = partialAcceptance.failedSeries(timeseries, initialMigrationIndex, migrationScheme != nil); anyWritten {
+			err = nil
+			if len(failedSeries) > 0 {
+				d.partiallyAcceptedRequests.Inc()
+			}
+		} else {
+			err = httpgrpc.Errorf(http.StatusInternalServerError, "no series has been written to a quorum of ingesters before the partial acceptance deadline: %s", err.Error())
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &mimirpb.WriteResponse{}, nil
+
+	// The exemplars are written once the samples have been written, so that their series likely exist in the ingesters.
+	if len(exemplarSeries) > 0 {
+		exemplarKeys := appendTokensForSeries(make([]uint32, 0, len(exemplarSeries)), userID, scheme, exemplarSeries)
+		d.sendExemplars(localCtx, subRing, exemplarSeries, exemplarKeys, req.Source)
+	}
+
+	return &mimirpb.WriteResponse{FailedSeries: failedSeries}, nil
 }
 
 func preallocSliceIfNeeded[T any](size int) []T {
@@ -1178,16 +1802,89 @@ func preallocSliceIfNeeded[T any](size int) []T {
 	return nil
 }
 
-func (d *Distributor) getTokensForSeries(userID string, series []mimirpb.PreallocTimeseries) []uint32 {
-	if len(series) == 0 {
-		return nil
+// appendTokensForSeries appends the token of each series computed with the scheme to dst, in the same order.
+func appendTokensForSeries(dst []uint32, userID string, scheme shardingScheme, series []mimirpb.PreallocTimeseries) []uint32 {
+	for _, ts := range series {
+		dst = append(dst, scheme.tokenForLabels(userID, ts.Labels))
 	}
+	return dst
+}
 
-	result := make([]uint32, 0, len(series))
-	for _, ts := range series {
-		result = append(result, d.tokenForLabels(userID, ts.Labels))
+// dedupeMigrationIndexes removes from the sorted indexes of the series keys computed with the migration sharding
+// scheme the ones of the series already sent to the same ingester because of their key computed with the primary
+// scheme, so that an ingester doesn't receive the same series twice in a write request. The migration indexes
+// are filtered in place.
+func dedupeMigrationIndexes(migrationIndexes, timeseriesIndexes []int, initialMigrationIndex int) []int {
+	if len(migrationIndexes) == 0 || len(timeseriesIndexes) == 0 {
+		return migrationIndexes
+	}
+
+	// Both the slices are sorted, so they're merged.
+	deduped := migrationIndexes[:0]
+	j := 0
+	for _, i := range migrationIndexes {
+		seriesIdx := i - initialMigrationIndex
+		for j < len(timeseriesIndexes) && timeseriesIndexes[j] < seriesIdx {
+			j++
+		}
+		if j < len(timeseriesIndexes) && timeseriesIndexes[j] == seriesIdx {
+			continue
+		}
+		deduped = append(deduped, i)
 	}
-	return result
+	return deduped
+}
+
+// splitIngesterBatchIndexes splits the indexes of the keys sent to an ingester into the indexes of the series
+// and the ones of the metadata, given the keys are stored in order series, metadata. The metadata indexes may
+// be split again from the following ones the same way. The indexes passed by
+// ring.DoBatch are sorted, so they're split with a binary search instead of iterating all of them.
+func splitIngesterBatchIndexes(indexes []int, initialMetadataIndex int) (timeseries, metadata []int) {
+	if !sort.IntsAreSorted(indexes) {
+		sort.Ints(indexes)
+	}
+
+	split := sort.SearchInts(indexes, initialMetadataIndex)
+	return indexes[:split], indexes[split:]
+}
+
+var (
+	// Pool of the tokens slices built for each push request.
+	tokensSlicePool = sync.Pool{New: func() any { return &[]uint32{} }}
+
+	// Pool of the series slices built for each ingester a push request is sent to. The slices only
+	// reference the series of the push request, which are released once sent to all ingesters.
+	ingesterTimeseriesSlicePool = sync.Pool{New: func() any { return &[]mimirpb.PreallocTimeseries{} }}
+)
+
+func getTokensSlice(size int) *[]uint32 {
+	s := tokensSlicePool.Get().(*[]uint32)
+	if cap(*s) < size {
+		*s = make([]uint32, 0, size)
+	}
+	return s
+}
+
+func putTokensSlice(s *[]uint32) {
+	*s = (*s)[:0]
+	tokensSlicePool.Put(s)
+}
+
+func getIngesterTimeseriesSlice(size int) *[]mimirpb.PreallocTimeseries {
+	s := ingesterTimeseriesSlicePool.Get().(*[]mimirpb.PreallocTimeseries)
+	if cap(*s) < size {
+		*s = make([]mimirpb.PreallocTimeseries, 0, size)
+	}
+	return s
+}
+
+func putIngesterTimeseriesSlice(s *[]mimirpb.PreallocTimeseries) {
+	// Clear the references to the series, so that they can be garbage collected.
+	for i := range *s {
+		(*s)[i] = mimirpb.PreallocTimeseries{}
+	}
+	*s = (*s)[:0]
+	ingesterTimeseriesSlicePool.Put(s)
 }
 
 func (d *Distributor) updateReceivedMetrics(req *mimirpb.WriteRequest, userID string) {
@@ -1208,19 +1905,21 @@ func copyString(s string) string {
 }
 
 func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
-	h, err := d.ingesterPool.GetClientFor(ingester.Addr)
-	if err != nil {
-		return err
-	}
-	c := h.(ingester_client.IngesterClient)
-
 	req := mimirpb.WriteRequest{
 		Timeseries: timeseries,
 		Metadata:   metadata,
 		Source:     source,
 	}
+	return d.sendRequest(ctx, ingester, &req)
+}
 
-	_, err = c.Push(ctx, &req)
+func (d *Distributor) sendRequest(ctx context.Context, ingester ring.InstanceDesc, req *mimirpb.WriteRequest) error {
+	var err error
+	if d.multiTenantPushBatcher != nil {
+		err = d.sendBatched(ctx, ingester, req)
+	} else {
+		err = d.sendSingle(ctx, ingester, req)
+	}
 	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
 		// Wrap HTTP gRPC error with more explanatory message.
 		return httpgrpc.Errorf(int(resp.Code), "failed pushing to ingester: %s", resp.Body)
@@ -1228,6 +1927,25 @@ func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, time
 	return errors.Wrap(err, "failed pushing to ingester")
 }
 
+func (d *Distributor) sendSingle(ctx context.Context, ingester ring.InstanceDesc, req *mimirpb.WriteRequest) error {
+	h, err := d.ingesterPool.GetClientFor(ingester.Addr)
+	if err != nil {
+		return err
+	}
+	c := h.(ingester_client.IngesterClient)
+
+	_, err = c.Push(ctx, req)
+	return err
+}
+
+func (d *Distributor) sendBatched(ctx context.Context, ingester ring.InstanceDesc, req *mimirpb.WriteRequest) error {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	return d.multiTenantPushBatcher.push(ingester.Addr, userID, req)
+}
+
 // forReplicationSet runs f, in parallel, for all ingesters in the input replication set.
 func forReplicationSet[T any](ctx context.Context, d *Distributor, replicationSet ring.ReplicationSet, f func(context.Context, ingester_client.IngesterClient) (T, error)) ([]T, error) {
 	wrappedF := func(ctx context.Context, ing *ring.InstanceDesc) (T, error) {
@@ -1259,7 +1977,7 @@ func (d *Distributor) LabelValuesForLabelName(ctx context.Context, from, to mode
 		return nil, err
 	}
 
-	resps, err := forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+	resps, err := forReplicationSetWithHedging(ctx, d, hedgingOpLabelValues, d.cfg.IngesterHedging.LabelValuesDelay, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.LabelValues(ctx, req)
 	})
 	if err != nil {
@@ -1393,7 +2111,9 @@ func (m *labelNamesAndValuesResponseMerger) putItemsToMap(message *ingester_clie
 // LabelValuesCardinality performs the following two operations in parallel:
 //   - queries ingesters for label values cardinality of a set of labelNames
 //   - queries ingesters for user stats to get the ingester's series head count
-func (d *Distributor) LabelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod) (uint64, *ingester_client.LabelValuesCardinalityResponse, error) {
+//
+// If approximate is true, ingesters estimate the series count of each label value from a sample of the series.
+func (d *Distributor) LabelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod, approximate bool) (uint64, *ingester_client.LabelValuesCardinalityResponse, error) {
 	var totalSeries uint64
 	var labelValuesCardinalityResponse *ingester_client.LabelValuesCardinalityResponse
 
@@ -1410,7 +2130,7 @@ func (d *Distributor) LabelValuesCardinality(ctx context.Context, labelNames []m
 	// Run labelValuesCardinality and UserStats methods in parallel
 	group, ctx := errgroup.WithContext(ctx)
 	group.Go(func() error {
-		response, err := d.labelValuesCardinality(ctx, labelNames, matchers, countMethod)
+		response, err := d.labelValuesCardinality(ctx, labelNames, matchers, countMethod, approximate)
 		if err == nil {
 			labelValuesCardinalityResponse = response
 		}
@@ -1431,7 +2151,7 @@ func (d *Distributor) LabelValuesCardinality(ctx context.Context, labelNames []m
 
 // labelValuesCardinality queries ingesters for label values cardinality of a set of labelNames
 // Returns a LabelValuesCardinalityResponse where each item contains an exclusive label name and associated label values
-func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod) (*ingester_client.LabelValuesCardinalityResponse, error) {
+func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod, approximate bool) (*ingester_client.LabelValuesCardinalityResponse, error) {
 	replicationSet, err := d.GetIngesters(ctx)
 	if err != nil {
 		return nil, err
@@ -1446,7 +2166,7 @@ func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []m
 		cardinalityMapByZone: make(map[string]map[string]map[string]uint64, len(labelNames)),
 	}
 
-	labelValuesReq, err := toLabelValuesCardinalityRequest(labelNames, matchers, countMethod)
+	labelValuesReq, err := toLabelValuesCardinalityRequest(labelNames, matchers, countMethod, approximate)
 	if err != nil {
 		return nil, err
 	}
@@ -1473,7 +2193,7 @@ func (d *Distributor) labelValuesCardinality(ctx context.Context, labelNames []m
 	return cardinalityConcurrentMap.toLabelValuesCardinalityResponse(replicationSet.ZoneCount(), d.ingestersRing.ReplicationFactor()), nil
 }
 
-func toLabelValuesCardinalityRequest(labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod) (*ingester_client.LabelValuesCardinalityRequest, error) {
+func toLabelValuesCardinalityRequest(labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod, approximate bool) (*ingester_client.LabelValuesCardinalityRequest, error) {
 	matchersProto, err := ingester_client.ToLabelMatchers(matchers)
 	if err != nil {
 		return nil, err
@@ -1486,7 +2206,7 @@ func toLabelValuesCardinalityRequest(labelNames []model.LabelName, matchers []*l
 	if err != nil {
 		return nil, err
 	}
-	return &ingester_client.LabelValuesCardinalityRequest{LabelNames: labelNamesStr, Matchers: matchersProto, CountMethod: ingesterCountMethod}, nil
+	return &ingester_client.LabelValuesCardinalityRequest{LabelNames: labelNamesStr, Matchers: matchersProto, CountMethod: ingesterCountMethod, Approximate: approximate}, nil
 }
 
 func toIngesterCountMethod(countMethod cardinality.CountMethod) (ingester_client.CountMethod, error) {
@@ -1621,7 +2341,7 @@ func (d *Distributor) LabelNames(ctx context.Context, from, to model.Time, match
 		return nil, err
 	}
 
-	resps, err := forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+	resps, err := forReplicationSetWithHedging(ctx, d, hedgingOpLabelNames, d.cfg.IngesterHedging.LabelNamesDelay, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.LabelNames(ctx, req)
 	})
 	if err != nil {
@@ -1657,7 +2377,7 @@ func (d *Distributor) MetricsForLabelMatchers(ctx context.Context, from, through
 		return nil, err
 	}
 
-	resps, err := forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+	resps, err := forReplicationSetWithHedging(ctx, d, hedgingOpMetricsForLabelMatchers, d.cfg.IngesterHedging.MetricsForLabelMatchersDelay, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.MetricsForLabelMatchers(ctx, req)
 	})
 	if err != nil {
@@ -1872,6 +2592,15 @@ func (d *Distributor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// RingRebalancingHandler serves the rebalancing recommendations of the distributors ring.
+func (d *Distributor) RingRebalancingHandler(w http.ResponseWriter, req *http.Request) {
+	if d.distributorsRingAdvisor == nil {
+		http.Error(w, "Distributor is not running with global limits enabled", http.StatusNotFound)
+		return
+	}
+	d.distributorsRingAdvisor.ServeHTTP(w, req)
+}
+
 // HealthyInstancesCount implements the ReadLifecycler interface
 //
 // We use a ring lifecycler delegate to count the number of members of the
@@ -7,7 +7,9 @@ package distributor
 
 import (
 	"flag"
-	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
@@ -28,10 +30,14 @@ const (
 // to the user.
 type RingConfig struct {
 	Common util.CommonRingConfig `yaml:",inline"`
+
+	InstancesCountHysteresisPeriod time.Duration `yaml:"instances_count_hysteresis_period" category:"experimental"`
 }
 
 func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.Common.RegisterFlags("distributor.ring.", "collectors/", "distributors", f, logger)
+
+	f.DurationVar(&cfg.InstancesCountHysteresisPeriod, "distributor.ring.instances-count-hysteresis-period", 0, "Minimum period after a change of the number of healthy distributors before a change in the opposite direction is applied to the global rate limits. Used to avoid the per-distributor rate limits oscillating while distributors are flapping, for example during rollouts. 0 to disable.")
 }
 
 func (cfg *RingConfig) ToBasicLifecyclerConfig(logger log.Logger) (ring.BasicLifecyclerConfig, error) {
@@ -44,7 +50,7 @@ func (cfg *RingConfig) ToBasicLifecyclerConfig(logger log.Logger) (ring.BasicLif
 
 	return ring.BasicLifecyclerConfig{
 		ID:                              cfg.Common.InstanceID,
-		Addr:                            fmt.Sprintf("%s:%d", instanceAddr, instancePort),
+		Addr:                            net.JoinHostPort(instanceAddr, strconv.Itoa(instancePort)),
 		HeartbeatPeriod:                 cfg.Common.HeartbeatPeriod,
 		HeartbeatTimeout:                cfg.Common.HeartbeatTimeout,
 		TokensObservePeriod:             0,
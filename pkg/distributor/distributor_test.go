@@ -67,6 +67,7 @@ var (
 
 func TestConfig_Validate(t *testing.T) {
 	tests := map[string]struct {
+		initConfig func(*Config)
 		initLimits func(*validation.Limits)
 		expected   error
 	}{
@@ -86,6 +87,20 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: nil,
 		},
+		"should fail if the max oversized recv msg size is not greater than the max recv msg size": {
+			initConfig: func(cfg *Config) {
+				cfg.MaxOversizedRecvMsgSize = cfg.MaxRecvMsgSize
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidMaxOversizedRecvMsgSize,
+		},
+		"should pass if the max oversized recv msg size is greater than the max recv msg size": {
+			initConfig: func(cfg *Config) {
+				cfg.MaxOversizedRecvMsgSize = 2 * cfg.MaxRecvMsgSize
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   nil,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -94,6 +109,9 @@ func TestConfig_Validate(t *testing.T) {
 			limits := validation.Limits{}
 			flagext.DefaultValues(&cfg, &limits)
 
+			if testData.initConfig != nil {
+				testData.initConfig(&cfg)
+			}
 			testData.initLimits(&limits)
 
 			assert.Equal(t, testData.expected, cfg.Validate(limits))
@@ -543,6 +561,77 @@ func TestDistributor_PushRequestRateLimiter(t *testing.T) {
 	}
 }
 
+func TestDistributor_PushIngestionBytesRateLimiter(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	reqSize := makeWriteRequest(0, 1, 1, false, true).Size()
+
+	tests := map[string]struct {
+		distributors     int
+		bytesRate        float64
+		bytesBurstSize   int
+		expectedAccepted int
+	}{
+		"bytes limit should be evenly shared across distributors": {
+			distributors:     2,
+			bytesRate:        float64(4 * reqSize),
+			bytesBurstSize:   2 * reqSize,
+			expectedAccepted: 2,
+		},
+		"bytes limit is disabled when set to 0": {
+			distributors:     2,
+			bytesRate:        0,
+			bytesBurstSize:   0,
+			expectedAccepted: 3,
+		},
+		"requests bigger than the burst size are always rejected": {
+			distributors:     1,
+			bytesRate:        float64(10 * reqSize),
+			bytesBurstSize:   reqSize - 1,
+			expectedAccepted: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.IngestionBytesRate = testData.bytesRate
+			limits.IngestionBytesBurstSize = testData.bytesBurstSize
+
+			// Start all expected distributors
+			distributors, _, regs := prepare(t, prepConfig{
+				numIngesters:    3,
+				happyIngesters:  3,
+				numDistributors: testData.distributors,
+				limits:          limits,
+			})
+
+			// Send multiple requests to the first distributor
+			for i := 0; i < 3; i++ {
+				response, err := distributors[0].Push(ctx, makeWriteRequest(0, 1, 1, false, true))
+
+				if i < testData.expectedAccepted {
+					assert.Equal(t, emptyResponse, response)
+					assert.Nil(t, err)
+				} else {
+					assert.Nil(t, response)
+					assert.EqualError(t, err, httpgrpc.Errorf(http.StatusTooManyRequests, validation.NewIngestionBytesRateLimitedError(testData.bytesRate, testData.bytesBurstSize).Error()).Error())
+				}
+			}
+
+			if testData.expectedAccepted < 3 {
+				assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(fmt.Sprintf(`
+					# HELP cortex_discarded_requests_total The total number of requests that were discarded due to rate limiting.
+					# TYPE cortex_discarded_requests_total counter
+					cortex_discarded_requests_total{reason="bytes_rate_limited",user="user"} %d
+				`, 3-testData.expectedAccepted)), "cortex_discarded_requests_total"))
+			}
+		})
+	}
+}
+
 func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 	type testPush struct {
 		samples       int
@@ -683,6 +772,13 @@ func TestDistributor_PushInstanceLimits(t *testing.T) {
 			pushes: []testPush{
 				{samples: 100, expectedError: errMaxInflightRequestsReached},
 			},
+
+			metricNames: []string{"cortex_distributor_instance_limits_retry_after_seconds"},
+			expectedMetrics: `
+				# HELP cortex_distributor_instance_limits_retry_after_seconds Retry-After suggested to the clients by the last write request rejected because this distributor reached the instance limit.
+				# TYPE cortex_distributor_instance_limits_retry_after_seconds gauge
+				cortex_distributor_instance_limits_retry_after_seconds{limit="max_inflight_push_requests"} 2
+			`,
 		},
 		"below ingestion rate limit": {
 			preRateSamples:     500,
@@ -788,7 +884,12 @@ func TestDistributor_PushInstanceLimits(t *testing.T) {
 				if push.expectedError == nil {
 					assert.Nil(t, err)
 				} else {
-					assert.Equal(t, push.expectedError, err)
+					resp, ok := httpgrpc.HTTPResponseFromError(err)
+					require.True(t, ok)
+					assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+					assert.Equal(t, push.expectedError.Error(), string(resp.Body))
+					require.Len(t, resp.Headers, 1)
+					assert.Equal(t, "Retry-After", resp.Headers[0].Key)
 				}
 
 				d.ingestionRate.Tick()
@@ -1249,6 +1350,304 @@ func TestDistributor_Push_LabelNameValidation(t *testing.T) {
 	}
 }
 
+func TestDistributor_Push_ShouldRecordDiscardedSamplesExamples(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.DiscardedSamplesExamplesPerReason = 10
+	limits.IngestionRate = 1
+	limits.IngestionBurstSize = 1
+
+	ds, _, _ := prepare(t, prepConfig{
+		limits:          limits,
+		numIngesters:    2,
+		happyIngesters:  2,
+		numDistributors: 1,
+	})
+
+	// A series with an invalid label is discarded by the validation.
+	_, err := ds[0].Push(ctx, mockWriteRequest(labels.FromStrings(model.MetricNameLabel, "foo", "999.illegal", "baz"), 1, 100000))
+	require.Error(t, err)
+
+	// The ingestion rate limit is exceeded by a request with 2 samples.
+	_, err = ds[0].Push(ctx, &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		makeWriteRequestTimeseries([]mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "bar"}}, 200000, 1),
+		makeWriteRequestTimeseries([]mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "baz"}}, 200000, 1),
+	}})
+	require.Error(t, err)
+
+	examples := ds[0].discardedSamplesExamples.Examples("user")
+	require.Len(t, examples, 2)
+
+	assert.Equal(t, "label_invalid", examples[0].Reason)
+	assert.Equal(t, `{999.illegal="baz", __name__="foo"}`, examples[0].Labels)
+	assert.Equal(t, validation.ReasonRateLimited, examples[1].Reason)
+	assert.Equal(t, `{__name__="bar"}`, examples[1].Labels)
+	assert.Equal(t, int64(200000), examples[1].TimestampMs)
+
+	// The examples are removed once the tenant is inactive.
+	ds[0].cleanupInactiveUser("user")
+	assert.Empty(t, ds[0].discardedSamplesExamples.Examples("user"))
+}
+
+func TestDistributor_Push_NonMonotonicSamples(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	// The request is built for each push, because the distributor reuses it once done.
+	makeRequest := func() *mimirpb.WriteRequest {
+		return &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{{TimeSeries: &mimirpb.TimeSeries{
+			Labels:  []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}},
+			Samples: []mimirpb.Sample{{TimestampMs: now - 1000, Value: 1}, {TimestampMs: now - 3000, Value: 3}, {TimestampMs: now - 2000, Value: 2}},
+		}}}}
+	}
+
+	tests := map[string]struct {
+		policy          string
+		expectedErr     string
+		expectedSamples []mimirpb.Sample
+		expectedSorted  float64
+	}{
+		"allow": {
+			policy:          validation.NonMonotonicSamplesPolicyAllow,
+			expectedSamples: []mimirpb.Sample{{TimestampMs: now - 1000, Value: 1}, {TimestampMs: now - 3000, Value: 3}, {TimestampMs: now - 2000, Value: 2}},
+		},
+		"sort": {
+			policy:          validation.NonMonotonicSamplesPolicySort,
+			expectedSamples: []mimirpb.Sample{{TimestampMs: now - 3000, Value: 3}, {TimestampMs: now - 2000, Value: 2}, {TimestampMs: now - 1000, Value: 1}},
+			expectedSorted:  1,
+		},
+		"reject": {
+			policy:      validation.NonMonotonicSamplesPolicyReject,
+			expectedErr: fmt.Sprintf("the sample at index 1 has timestamp %d which is older than the previous timestamp %d, series: '{__name__=\"foo\"}' (err-mimir-sample-timestamps-not-monotonic)", now-3000, now-1000),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.NonMonotonicSamplesPolicy = testData.policy
+			// Exemplars are enabled, so that they're not cleared from the validated series.
+			limits.MaxGlobalExemplarsPerUser = 10
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				limits:            limits,
+				numIngesters:      1,
+				happyIngesters:    1,
+				numDistributors:   1,
+				replicationFactor: 1,
+			})
+
+			_, err := ds[0].Push(ctx, makeRequest())
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				res, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), res.Code)
+				assert.Contains(t, string(res.Body), testData.expectedErr)
+				assert.Empty(t, ingesters[0].series())
+				return
+			}
+
+			require.NoError(t, err)
+			received := ingesters[0].series()
+			require.Len(t, received, 1)
+			for _, ts := range received {
+				assert.Equal(t, testData.expectedSamples, ts.Samples)
+			}
+			assert.Equal(t, testData.expectedSorted, testutil.ToFloat64(ds[0].nonMonotonicSeriesSorted.WithLabelValues("user")))
+
+			// The series unmarshalled from the request should be marshalled with the validated samples.
+			data, err := makeRequest().Timeseries[0].Marshal()
+			require.NoError(t, err)
+			ts := mimirpb.PreallocTimeseries{}
+			require.NoError(t, ts.Unmarshal(data))
+			require.NoError(t, ds[0].validateSeries(time.Now(), &ts, "user", "", false, 0))
+
+			marshalled, err := ts.Marshal()
+			require.NoError(t, err)
+			decoded := mimirpb.TimeSeries{}
+			require.NoError(t, decoded.Unmarshal(marshalled))
+			assert.Equal(t, testData.expectedSamples, decoded.Samples)
+		})
+	}
+}
+
+func TestDistributor_Push_LabelNamesPolicy(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	// The request is built for each push, because the distributor reuses it once done.
+	makeRequest := func() *mimirpb.WriteRequest {
+		return &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+			{TimeSeries: &mimirpb.TimeSeries{
+				Labels:  []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "allowed"}, {Name: "team", Value: "a"}},
+				Samples: []mimirpb.Sample{{TimestampMs: now, Value: 1}},
+			}},
+			{TimeSeries: &mimirpb.TimeSeries{
+				Labels:  []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "not_allowed"}, {Name: "pod", Value: "b"}, {Name: "team", Value: "a"}},
+				Samples: []mimirpb.Sample{{TimestampMs: now, Value: 2}},
+			}},
+		}}
+	}
+
+	tests := map[string]struct {
+		action                string
+		expectedErr           string
+		expectedSeries        []string
+		expectedDroppedLabels float64
+	}{
+		"drop-label": {
+			action:                validation.LabelNamesPolicyActionDropLabel,
+			expectedSeries:        []string{`{__name__="allowed", team="a"}`, `{__name__="not_allowed", team="a"}`},
+			expectedDroppedLabels: 1,
+		},
+		"drop-series": {
+			action:         validation.LabelNamesPolicyActionDropSeries,
+			expectedErr:    "received a series with a label name not allowed, label: 'pod'",
+			expectedSeries: []string{`{__name__="allowed", team="a"}`},
+		},
+		"reject-request": {
+			action:      validation.LabelNamesPolicyActionRejectRequest,
+			expectedErr: "received a series with a label name not allowed, label: 'pod'",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.AllowedLabelNames = []string{"team"}
+			limits.RequiredLabelNames = []string{"team"}
+			limits.LabelNamesPolicyAction = testData.action
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				limits:            limits,
+				numIngesters:      1,
+				happyIngesters:    1,
+				numDistributors:   1,
+				replicationFactor: 1,
+			})
+
+			_, err := ds[0].Push(ctx, makeRequest())
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				res, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), res.Code)
+				assert.Contains(t, string(res.Body), testData.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var received []string
+			for _, ts := range ingesters[0].series() {
+				received = append(received, mimirpb.FromLabelAdaptersToLabels(ts.Labels).String())
+			}
+			assert.ElementsMatch(t, testData.expectedSeries, received)
+			assert.Equal(t, testData.expectedDroppedLabels, testutil.ToFloat64(ds[0].labelNamesPolicyDroppedLabels.WithLabelValues("user")))
+		})
+	}
+}
+
+func TestDistributor_Push_RequiredLabelNamesExemptions(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RequiredLabelNames = []string{"cluster", "namespace"}
+	limits.RequiredLabelNamesExempt = []string{`{job="legacy"}`, "up"}
+
+	ds, ingesters, regs := prepare(t, prepConfig{
+		limits:            limits,
+		numIngesters:      1,
+		happyIngesters:    1,
+		numDistributors:   1,
+		replicationFactor: 1,
+	})
+
+	series := func(lbls ...string) mimirpb.PreallocTimeseries {
+		return mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+			Labels:  mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(lbls...)),
+			Samples: []mimirpb.Sample{{TimestampMs: now, Value: 1}},
+		}}
+	}
+
+	_, err := ds[0].Push(ctx, &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		series(model.MetricNameLabel, "complete", "cluster", "c", "namespace", "n"),
+		series(model.MetricNameLabel, "incomplete", "cluster", "c"),
+		series(model.MetricNameLabel, "incomplete", "job", "legacy"),
+		series(model.MetricNameLabel, "up"),
+	}})
+	require.Error(t, err)
+	res, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), res.Code)
+	assert.Contains(t, string(res.Body), "received a series missing a required label name, label: 'namespace'")
+
+	var received []string
+	for _, ts := range ingesters[0].series() {
+		received = append(received, mimirpb.FromLabelAdaptersToLabels(ts.Labels).String())
+	}
+	assert.ElementsMatch(t, []string{
+		`{__name__="complete", cluster="c", namespace="n"}`,
+		`{__name__="incomplete", job="legacy"}`,
+		`{__name__="up"}`,
+	}, received)
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{group="",reason="missing_required_label_name",user="user"} 1
+	`), "cortex_discarded_samples_total"))
+}
+
+func TestDistributor_Push_RequiredLabelNamesExemptionsMatchedAfterDroppingLabels(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AllowedLabelNames = []string{"cluster", "namespace", "team"}
+	limits.RequiredLabelNames = []string{"cluster", "namespace"}
+	limits.RequiredLabelNamesExempt = []string{`{job="legacy"}`, `{team="infra"}`}
+	limits.LabelNamesPolicyAction = validation.LabelNamesPolicyActionDropLabel
+
+	ds, ingesters, _ := prepare(t, prepConfig{
+		limits:            limits,
+		numIngesters:      1,
+		happyIngesters:    1,
+		numDistributors:   1,
+		replicationFactor: 1,
+	})
+
+	series := func(lbls ...string) mimirpb.PreallocTimeseries {
+		return mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+			Labels:  mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(lbls...)),
+			Samples: []mimirpb.Sample{{TimestampMs: now, Value: 1}},
+		}}
+	}
+
+	// The job label is dropped because it's not allowed, so the series doesn't match the exemption anymore.
+	_, err := ds[0].Push(ctx, &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		series(model.MetricNameLabel, "dropped", "job", "legacy"),
+		series(model.MetricNameLabel, "exempted", "job", "legacy", "team", "infra"),
+	}})
+	require.Error(t, err)
+	res, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), res.Code)
+	assert.Contains(t, string(res.Body), "received a series missing a required label name, label: 'cluster' series: 'dropped'")
+
+	var received []string
+	for _, ts := range ingesters[0].series() {
+		received = append(received, mimirpb.FromLabelAdaptersToLabels(ts.Labels).String())
+	}
+	assert.Equal(t, []string{`{__name__="exempted", team="infra"}`}, received)
+}
+
 func TestDistributor_Push_ExemplarValidation(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 	manyLabels := []string{model.MetricNameLabel, "test"}
@@ -1772,6 +2171,130 @@ func BenchmarkDistributor_Push(b *testing.B) {
 	}
 }
 
+func BenchmarkDistributor_PushBatchAssembly(b *testing.B) {
+	const numSeriesPerRequest = 10000
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	for _, numIngesters := range []int{3, 30, 100} {
+		for _, replicationFactor := range []int{1, 3} {
+			b.Run(fmt.Sprintf("ingesters: %d, replication factor: %d", numIngesters, replicationFactor), func(b *testing.B) {
+				kvStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+				b.Cleanup(func() { assert.NoError(b, closer.Close()) })
+
+				err := kvStore.CAS(context.Background(), ingester.IngesterRingKey,
+					func(_ interface{}) (interface{}, bool, error) {
+						d := &ring.Desc{}
+						for i := 0; i < numIngesters; i++ {
+							d.AddIngester(fmt.Sprintf("ingester-%d", i), fmt.Sprintf("127.0.0.%d", i), "", ring.NewRandomTokenGenerator().GenerateTokens(128, nil), ring.ACTIVE, time.Now())
+						}
+						return d, true, nil
+					},
+				)
+				require.NoError(b, err)
+
+				ingestersRing, err := ring.New(ring.Config{
+					KVStore:           kv.Config{Mock: kvStore},
+					HeartbeatTimeout:  60 * time.Minute,
+					ReplicationFactor: replicationFactor,
+				}, ingester.IngesterRingKey, ingester.IngesterRingKey, log.NewNopLogger(), nil)
+				require.NoError(b, err)
+				require.NoError(b, services.StartAndAwaitRunning(context.Background(), ingestersRing))
+				b.Cleanup(func() {
+					require.NoError(b, services.StopAndAwaitTerminated(context.Background(), ingestersRing))
+				})
+
+				test.Poll(b, time.Second, numIngesters, func() interface{} {
+					return ingestersRing.InstancesCount()
+				})
+
+				var distributorCfg Config
+				var clientConfig client.Config
+				limits := validation.Limits{}
+				flagext.DefaultValues(&distributorCfg, &clientConfig, &limits)
+				distributorCfg.DistributorRing.Common.KVStore.Store = "inmemory"
+				distributorCfg.IngesterClientFactory = func(addr string) (ring_client.PoolClient, error) {
+					return &noopIngester{}, nil
+				}
+				limits.IngestionRate = float64(rate.Inf) // Unlimited.
+
+				overrides, err := validation.NewOverrides(limits, nil)
+				require.NoError(b, err)
+
+				distributor, err := New(distributorCfg, clientConfig, overrides, nil, ingestersRing, true, nil, log.NewNopLogger())
+				require.NoError(b, err)
+				require.NoError(b, services.StartAndAwaitRunning(context.Background(), distributor))
+				b.Cleanup(func() {
+					require.NoError(b, services.StopAndAwaitTerminated(context.Background(), distributor))
+				})
+
+				metrics := make([][]mimirpb.LabelAdapter, numSeriesPerRequest)
+				samples := make([]mimirpb.Sample, numSeriesPerRequest)
+				for i := 0; i < numSeriesPerRequest; i++ {
+					metrics[i] = mkLabels(10)
+					samples[i] = mimirpb.Sample{Value: float64(i), TimestampMs: time.Now().UnixMilli()}
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for n := 0; n < b.N; n++ {
+					if _, err := distributor.Push(ctx, mimirpb.ToWriteRequest(metrics, samples, nil, nil, mimirpb.API)); err != nil {
+						b.Fatalf("no error expected but got %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSplitIngesterBatchIndexes(t *testing.T) {
+	tests := map[string]struct {
+		indexes              []int
+		initialMetadataIndex int
+		expectedTimeseries   []int
+		expectedMetadata     []int
+	}{
+		"no indexes": {
+			indexes:              []int{},
+			initialMetadataIndex: 3,
+			expectedTimeseries:   []int{},
+			expectedMetadata:     []int{},
+		},
+		"only series": {
+			indexes:              []int{0, 2, 5},
+			initialMetadataIndex: 10,
+			expectedTimeseries:   []int{0, 2, 5},
+			expectedMetadata:     []int{},
+		},
+		"only metadata": {
+			indexes:              []int{3, 4},
+			initialMetadataIndex: 3,
+			expectedTimeseries:   []int{},
+			expectedMetadata:     []int{3, 4},
+		},
+		"series and metadata": {
+			indexes:              []int{1, 2, 6, 7, 9},
+			initialMetadataIndex: 6,
+			expectedTimeseries:   []int{1, 2},
+			expectedMetadata:     []int{6, 7, 9},
+		},
+		"unsorted indexes": {
+			indexes:              []int{9, 1, 6, 2},
+			initialMetadataIndex: 5,
+			expectedTimeseries:   []int{1, 2},
+			expectedMetadata:     []int{6, 9},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			timeseries, metadata := splitIngesterBatchIndexes(testData.indexes, testData.initialMetadataIndex)
+			assert.Equal(t, testData.expectedTimeseries, timeseries)
+			assert.Equal(t, testData.expectedMetadata, metadata)
+		})
+	}
+}
+
 func TestSlowQueries(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 	nameMatcher := mustEqualMatcher(model.MetricNameLabel, "foo")
@@ -2250,7 +2773,7 @@ func TestDistributor_LabelValuesCardinality_ExpectedAllIngestersResponsesToBeCom
 	ctx, ds := prepareWithZoneAwarenessAndZoneDelay(t, createSeries(10000))
 
 	names := []model.LabelName{labels.MetricName}
-	response, err := ds[0].labelValuesCardinality(ctx, names, []*labels.Matcher{}, cardinality.InMemoryMethod)
+	response, err := ds[0].labelValuesCardinality(ctx, names, []*labels.Matcher{}, cardinality.InMemoryMethod, false)
 	require.NoError(t, err)
 	require.Len(t, response.Items, 1)
 	// labelValuesCardinality must wait for all responses from all ingesters
@@ -2423,7 +2946,7 @@ func TestDistributor_LabelValuesCardinality(t *testing.T) {
 			// the final ingester may not have received series yet.
 			// To avoid flaky test we retry the assertions until we hit the desired state within a reasonable timeout.
 			test.Poll(t, time.Second, testData.expectedResult, func() interface{} {
-				seriesCountTotal, cardinalityMap, err := ds[0].LabelValuesCardinality(ctx, testData.labelNames, testData.matchers, cardinality.InMemoryMethod)
+				seriesCountTotal, cardinalityMap, err := ds[0].LabelValuesCardinality(ctx, testData.labelNames, testData.matchers, cardinality.InMemoryMethod, false)
 				require.NoError(t, err)
 				assert.Equal(t, testData.expectedSeriesCountTotal, seriesCountTotal)
 				// Make sure the resultant label names are sorted
@@ -2491,7 +3014,7 @@ func TestDistributor_LabelValuesCardinalityLimit(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			_, _, err := ds[0].LabelValuesCardinality(ctx, testData.labelNames, []*labels.Matcher{}, cardinality.InMemoryMethod)
+			_, _, err := ds[0].LabelValuesCardinality(ctx, testData.labelNames, []*labels.Matcher{}, cardinality.InMemoryMethod, false)
 			if testData.expectedHTTPGrpcError == nil {
 				require.NoError(t, err)
 			} else {
@@ -2518,7 +3041,7 @@ func TestDistributor_LabelValuesCardinality_Concurrency(t *testing.T) {
 		// Set the first ingester as unhappy
 		ingesters[0].happy = false
 
-		_, _, err := ds[0].LabelValuesCardinality(ctx, []model.LabelName{labels.MetricName}, []*labels.Matcher{}, cardinality.InMemoryMethod)
+		_, _, err := ds[0].LabelValuesCardinality(ctx, []model.LabelName{labels.MetricName}, []*labels.Matcher{}, cardinality.InMemoryMethod, false)
 		require.Error(t, err)
 	})
 }
@@ -2669,6 +3192,92 @@ func TestHaDedupeMiddleware(t *testing.T) {
 	}
 }
 
+func TestHaDedupeMiddleware_HALabelPairs(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	labelSetGen := func(regionReplica, replica, cluster string, withReplicas bool) func(int) []mimirpb.LabelAdapter {
+		return func(id int) []mimirpb.LabelAdapter {
+			lbls := []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}}
+			if withReplicas {
+				lbls = append(lbls, mimirpb.LabelAdapter{Name: "__region_replica__", Value: regionReplica}, mimirpb.LabelAdapter{Name: "__replica__", Value: replica})
+			}
+			return append(lbls,
+				mimirpb.LabelAdapter{Name: "cluster", Value: cluster},
+				mimirpb.LabelAdapter{Name: "region", Value: "eu"},
+				mimirpb.LabelAdapter{Name: "sample", Value: fmt.Sprintf("%d", id)},
+			)
+		}
+	}
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.AcceptHASamples = true
+	limits.HALabelPairs = []validation.HALabelPair{
+		{Name: "region", ClusterLabels: []string{"region"}, ReplicaLabel: "__region_replica__"},
+		{Name: "cluster", ClusterLabels: []string{"region", "cluster"}, ReplicaLabel: "__replica__"},
+	}
+
+	ds, _, _ := prepare(t, prepConfig{
+		numDistributors: 1,
+		limits:          &limits,
+		enableTracker:   true,
+	})
+
+	var gotReqs []*mimirpb.WriteRequest
+	middleware := ds[0].prePushHaDedupeMiddleware(func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		req, err := pushReq.WriteRequest()
+		require.NoError(t, err)
+		gotReqs = append(gotReqs, req)
+		pushReq.CleanUp()
+		return nil, nil
+	})
+
+	for _, tc := range []struct {
+		regionReplica, replica, cluster string
+		expectedCode                    int
+	}{
+		// The first replicas are elected at both levels.
+		{regionReplica: "region-a", replica: "replica-1", cluster: "cluster-1"},
+		// Deduped by the region level.
+		{regionReplica: "region-b", replica: "replica-1", cluster: "cluster-1", expectedCode: http.StatusAccepted},
+		// Deduped by the cluster level.
+		{regionReplica: "region-a", replica: "replica-2", cluster: "cluster-1", expectedCode: http.StatusAccepted},
+		// Each cluster of the region has its own elected replica.
+		{regionReplica: "region-a", replica: "replica-2", cluster: "cluster-2"},
+	} {
+		_, err := middleware(ctx, push.NewParsedRequest(makeWriteRequestForGenerators(5, labelSetGen(tc.regionReplica, tc.replica, tc.cluster, true), nil, nil)))
+		if tc.expectedCode == 0 {
+			require.NoError(t, err)
+			continue
+		}
+		resp, ok := httpgrpc.HTTPResponseFromError(err)
+		require.True(t, ok)
+		assert.Equal(t, tc.expectedCode, int(resp.Code))
+	}
+
+	// The replica labels of all the levels are removed from the accepted series.
+	assert.Equal(t, []*mimirpb.WriteRequest{
+		makeWriteRequestForGenerators(5, labelSetGen("", "", "cluster-1", false), nil, nil),
+		makeWriteRequestForGenerators(5, labelSetGen("", "", "cluster-2", false), nil, nil),
+	}, gotReqs)
+
+	// Each series has both a float and a histogram sample.
+	assert.Equal(t, 10.0, testutil.ToFloat64(ds[0].dedupedSamples.WithLabelValues("user", "region/eu")))
+	assert.Equal(t, 10.0, testutil.ToFloat64(ds[0].dedupedSamples.WithLabelValues("user", "cluster/eu/cluster-1")))
+
+	ds[0].HATracker.electedLock.RLock()
+	defer ds[0].HATracker.electedLock.RUnlock()
+	elected := map[string]string{}
+	for cluster, info := range ds[0].HATracker.clusters["user"] {
+		elected[cluster] = info.elected.Replica
+	}
+	assert.Equal(t, map[string]string{
+		"region/eu":            "region-a",
+		"cluster/eu/cluster-1": "replica-1",
+		"cluster/eu/cluster-2": "replica-2",
+	}, elected)
+}
+
 func TestInstanceLimitsBeforeHaDedupe(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 
@@ -2711,7 +3320,9 @@ func TestInstanceLimitsBeforeHaDedupe(t *testing.T) {
 	// If we HA deduplication runs before instance limits check,
 	// then this would set replica for the cluster.
 	_, err := wrappedMockPush(ctx, push.NewParsedRequest(writeReqReplica1))
-	require.Equal(t, errMaxInflightRequestsReached, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, errMaxInflightRequestsReached.Error(), string(resp.Body))
 
 	// Simulate no other inflight request.
 	ds[0].inflightPushRequests.Dec()
@@ -2876,6 +3487,66 @@ func TestRelabelMiddleware(t *testing.T) {
 	}
 }
 
+func TestRelabelMiddleware_LabelValueNormalization(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.LabelValueNormalizationRules = []validation.LabelValueNormalizationRule{
+		{Label: "env", Lowercase: true, StripPrefixes: []string{"env-", "environment-"}, ValueMappings: map[string]string{"prod": "production", "none": ""}},
+		{Label: "env", ValueMappings: map[string]string{"production": "prd"}},
+		{Label: "team", ValueMappings: map[string]string{"a": "team-a"}},
+	}
+	// The normalized label values are visible to the metric relabel configs.
+	limits.MetricRelabelConfigs = []*relabel.Config{{
+		SourceLabels: []model.LabelName{"env"},
+		Action:       relabel.Drop,
+		Regex:        relabel.MustNewRegexp("staging"),
+	}}
+	ds, _, _ := prepare(t, prepConfig{
+		numDistributors: 1,
+		limits:          &limits,
+	})
+
+	series := func(env, team string) mimirpb.PreallocTimeseries {
+		lbls := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "metric"}}
+		if env != "" {
+			lbls = append(lbls, mimirpb.LabelAdapter{Name: "env", Value: env})
+		}
+		if team != "" {
+			lbls = append(lbls, mimirpb.LabelAdapter{Name: "team", Value: team})
+		}
+		return makeWriteRequestTimeseries(lbls, 123, 1.23)
+	}
+
+	var gotReq *mimirpb.WriteRequest
+	next := func(_ context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		var err error
+		gotReq, err = pushReq.WriteRequest()
+		require.NoError(t, err)
+		pushReq.CleanUp()
+		return nil, nil
+	}
+
+	req := &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+		series("ENV-Prod", "a"),
+		series("environment-dev", "b"),
+		series("Staging", ""),
+		series("none", ""),
+		series("dev", "b"),
+	}}
+	_, err := ds[0].prePushRelabelMiddleware(next)(ctx, push.NewParsedRequest(req))
+	require.NoError(t, err)
+
+	assert.Equal(t, []mimirpb.PreallocTimeseries{
+		series("prd", "team-a"),
+		series("dev", "b"),
+		series("", ""),
+		series("dev", "b"),
+	}, gotReq.Timeseries)
+	assert.Equal(t, 4.0, testutil.ToFloat64(ds[0].normalizedSeries.WithLabelValues("user")))
+}
+
 func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
 	m, err := labels.NewMatcher(t, n, v)
 	if err != nil {
@@ -2912,6 +3583,13 @@ type prepConfig struct {
 	ingestersSeriesCountTotal          uint64
 	ingesterZones                      []string
 	labelNamesStreamZonesResponseDelay map[string]time.Duration
+	multiTenantBatching                MultiTenantBatchingConfig
+	dualWriteURL                       string
+	exemplarsReplication               ExemplarsReplicationConfig
+	aggregation                        AggregationConfig
+	spillQueue                         SpillQueueConfig
+	metadataCache                      MetadataCacheConfig
+	partialAcceptance                  PartialAcceptanceConfig
 
 	timeOut bool
 }
@@ -3022,6 +3700,15 @@ func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*p
 		distributorCfg.DefaultLimits.MaxInflightPushRequestsBytes = cfg.maxInflightRequestsBytes
 		distributorCfg.DefaultLimits.MaxIngestionRate = cfg.maxIngestionRate
 		distributorCfg.ShuffleShardingLookbackPeriod = time.Hour
+		distributorCfg.MultiTenantBatching = cfg.multiTenantBatching
+		distributorCfg.ExemplarsReplication = cfg.exemplarsReplication
+		distributorCfg.Aggregation = cfg.aggregation
+		distributorCfg.SpillQueue = cfg.spillQueue
+		distributorCfg.MetadataCache = cfg.metadataCache
+		distributorCfg.PartialAcceptance = cfg.partialAcceptance
+		if cfg.dualWriteURL != "" {
+			require.NoError(t, distributorCfg.DualWrite.URL.Set(cfg.dualWriteURL))
+		}
 
 		cfg.limits.IngestionTenantShardSize = cfg.shuffleShardSize
 
@@ -3374,6 +4061,7 @@ type mockIngester struct {
 	labelNamesStreamResponseDelay time.Duration
 	timeOut                       bool
 	tokens                        []uint32
+	exemplars                     int
 }
 
 func (i *mockIngester) series() map[uint32]*mimirpb.PreallocTimeseries {
@@ -3430,6 +4118,8 @@ func (i *mockIngester) Push(ctx context.Context, req *mimirpb.WriteRequest, _ ..
 	}
 
 	for _, series := range req.Timeseries {
+		i.exemplars += len(series.Exemplars)
+
 		hash := shardByAllLabels(orgid, series.Labels)
 		existing, ok := i.timeseries[hash]
 		if !ok {
@@ -3476,6 +4166,24 @@ func makeWireChunk(c chunk.EncodedChunk) client.Chunk {
 	return chunk
 }
 
+func (i *mockIngester) PushMultiTenant(ctx context.Context, req *client.MultiTenantWriteRequest, _ ...grpc.CallOption) (*client.MultiTenantWriteResponse, error) {
+	i.Lock()
+	i.trackCall("PushMultiTenant")
+	happy := i.happy
+	i.Unlock()
+
+	if !happy {
+		return nil, errFail
+	}
+
+	resp := &client.MultiTenantWriteResponse{}
+	for _, tenantReq := range req.Requests {
+		_, err := i.Push(user.InjectOrgID(ctx, tenantReq.TenantId), tenantReq.Request)
+		resp.Responses = append(resp.Responses, client.NewTenantWriteResponse(err))
+	}
+	return resp, nil
+}
+
 func (i *mockIngester) QueryStream(_ context.Context, req *client.QueryRequest, _ ...grpc.CallOption) (client.Ingester_QueryStreamClient, error) {
 	time.Sleep(i.queryDelay)
 
@@ -3689,6 +4397,133 @@ func (i *mockIngester) LabelNames(_ context.Context, req *client.LabelNamesReque
 	return &response, nil
 }
 
+func (i *mockIngester) LabelValuesStream(ctx context.Context, req *client.LabelValuesRequest, _ ...grpc.CallOption) (client.Ingester_LabelValuesStreamClient, error) {
+	resp, err := i.LabelValues(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &labelValuesMockStream{}
+	for _, batch := range paginatedLabelsMockBatches(resp.LabelValues, req.After, req.Limit) {
+		stream.responses = append(stream.responses, &client.LabelValuesResponse{LabelValues: batch})
+	}
+	return stream, nil
+}
+
+func (i *mockIngester) LabelNamesStream(ctx context.Context, req *client.LabelNamesRequest, _ ...grpc.CallOption) (client.Ingester_LabelNamesStreamClient, error) {
+	resp, err := i.LabelNames(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &labelNamesMockStream{}
+	for _, batch := range paginatedLabelsMockBatches(resp.LabelNames, req.After, req.Limit) {
+		stream.responses = append(stream.responses, &client.LabelNamesResponse{LabelNames: batch})
+	}
+	return stream, nil
+}
+
+// paginatedLabelsMockBatches returns the distinct sorted values after the given one, at most limit values if limit
+// is greater than 0, split in batches of 2 values like the streaming ingesters would split them in batches.
+func paginatedLabelsMockBatches(sorted []string, after string, limit int64) [][]string {
+	var values []string
+	for _, v := range slices.Compact(sorted) {
+		if v > after {
+			values = append(values, v)
+		}
+	}
+	if limit > 0 && int64(len(values)) > limit {
+		values = values[:limit]
+	}
+
+	var batches [][]string
+	for len(values) > 0 {
+		size := util_math.Min(2, len(values))
+		batches = append(batches, values[:size])
+		values = values[size:]
+	}
+	return batches
+}
+
+type labelValuesMockStream struct {
+	grpc.ClientStream
+	responses []*client.LabelValuesResponse
+}
+
+func (*labelValuesMockStream) CloseSend() error {
+	return nil
+}
+
+func (s *labelValuesMockStream) Recv() (*client.LabelValuesResponse, error) {
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	result := s.responses[0]
+	s.responses = s.responses[1:]
+	return result, nil
+}
+
+type labelNamesMockStream struct {
+	grpc.ClientStream
+	responses []*client.LabelNamesResponse
+}
+
+func (*labelNamesMockStream) CloseSend() error {
+	return nil
+}
+
+func (s *labelNamesMockStream) Recv() (*client.LabelNamesResponse, error) {
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	result := s.responses[0]
+	s.responses = s.responses[1:]
+	return result, nil
+}
+
+func (i *mockIngester) ActiveSeries(_ context.Context, req *client.ActiveSeriesRequest, _ ...grpc.CallOption) (client.Ingester_ActiveSeriesClient, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("ActiveSeries")
+
+	if !i.happy {
+		return nil, errFail
+	}
+
+	matchers, err := client.FromLabelMatchers(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	// All the series of the mocked ingester are active. Each series is sent in its own message.
+	stream := &activeSeriesMockStream{}
+	for _, ts := range i.timeseries {
+		if match(ts.Labels, matchers) {
+			stream.responses = append(stream.responses, &client.ActiveSeriesResponse{Metric: []*mimirpb.Metric{{Labels: ts.Labels}}})
+		}
+	}
+	return stream, nil
+}
+
+type activeSeriesMockStream struct {
+	grpc.ClientStream
+	responses []*client.ActiveSeriesResponse
+}
+
+func (*activeSeriesMockStream) CloseSend() error {
+	return nil
+}
+
+func (s *activeSeriesMockStream) Recv() (*client.ActiveSeriesResponse, error) {
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	result := s.responses[0]
+	s.responses = s.responses[1:]
+	return result, nil
+}
+
 func (i *mockIngester) MetricsMetadata(context.Context, *client.MetricsMetadataRequest, ...grpc.CallOption) (*client.MetricsMetadataResponse, error) {
 	i.Lock()
 	defer i.Unlock()
@@ -4530,7 +5365,10 @@ func TestDistributor_CleanupIsDoneAfterLastIngesterReturns(t *testing.T) {
 	// First push request returned, but there's still an ingester call inflight.
 	// This means that the push request is counted as inflight, so another incoming request should be rejected.
 	_, err = distributors[0].Push(ctx, mockWriteRequest(labels.EmptyLabels(), 1, 1))
-	assert.ErrorIs(t, err, errMaxInflightRequestsReached)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+	assert.Equal(t, errMaxInflightRequestsReached.Error(), string(resp.Body))
 }
 
 func TestSeriesAreShardedToCorrectIngesters(t *testing.T) {
@@ -4631,3 +5469,86 @@ func searchToken(tokens []uint32, key uint32) int {
 	}
 	return i
 }
+
+func TestDistributor_PushIngestionReport(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now()
+
+	t.Run("should report the accepted, dropped and invalid samples", func(t *testing.T) {
+		var limits validation.Limits
+		flagext.DefaultValues(&limits)
+		limits.MaxLabelNameLength = 10
+		limits.CreationGracePeriod = model.Duration(time.Minute)
+		limits.MetricRelabelConfigs = []*relabel.Config{{
+			SourceLabels: []model.LabelName{model.MetricNameLabel},
+			Action:       relabel.Drop,
+			Regex:        relabel.MustNewRegexp("dropped"),
+		}}
+
+		ds, _, _ := prepare(t, prepConfig{
+			numIngesters:    3,
+			happyIngesters:  3,
+			numDistributors: 1,
+			limits:          &limits,
+		})
+
+		series := func(name string, extraLabel string, ts int64) mimirpb.PreallocTimeseries {
+			lbls := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: name}}
+			if extraLabel != "" {
+				lbls = append(lbls, mimirpb.LabelAdapter{Name: extraLabel, Value: "value"})
+			}
+			return makeWriteRequestTimeseries(lbls, ts, 1)
+		}
+
+		req := &mimirpb.WriteRequest{Timeseries: []mimirpb.PreallocTimeseries{
+			series("accepted", "", now.UnixMilli()),
+			series("accepted", "job", now.UnixMilli()),
+			series("dropped", "", now.UnixMilli()),
+			series("invalid", "too_long_label_name", now.UnixMilli()),
+			series("invalid", "job", now.Add(time.Hour).UnixMilli()),
+			series("invalid", "instance", now.Add(time.Hour).UnixMilli()),
+		}}
+
+		report := push.NewIngestionReport()
+		_, err := ds[0].PushWithMiddlewares(push.ContextWithIngestionReport(ctx, report), push.NewParsedRequest(req))
+		require.Error(t, err)
+
+		assert.Equal(t, 6, report.ReceivedSamples)
+		assert.Equal(t, 2, report.AcceptedSamples)
+		assert.Equal(t, 0, report.DedupedSamples)
+		assert.Equal(t, 1, report.RelabelDroppedSamples)
+		assert.Equal(t, 3, report.InvalidSamples)
+		assert.Equal(t, map[string]int{
+			"label_name_too_long": 1,
+			"too_far_in_future":   2,
+		}, report.InvalidSamplesByReason)
+	})
+
+	t.Run("should report the deduped samples", func(t *testing.T) {
+		var limits validation.Limits
+		flagext.DefaultValues(&limits)
+		limits.AcceptHASamples = true
+
+		ds, _, _ := prepare(t, prepConfig{
+			numIngesters:    3,
+			happyIngesters:  3,
+			numDistributors: 1,
+			limits:          &limits,
+			enableTracker:   true,
+		})
+		require.NoError(t, ds[0].HATracker.checkReplica(ctx, "user", "cluster0", "instance0", now))
+
+		report := push.NewIngestionReport()
+		req := makeWriteRequestForGenerators(5, labelSetGenWithReplicaAndCluster("instance1", "cluster0"), nil, nil)
+		_, err := ds[0].PushWithMiddlewares(push.ContextWithIngestionReport(ctx, report), push.NewParsedRequest(req))
+		resp, ok := httpgrpc.HTTPResponseFromError(err)
+		require.True(t, ok)
+		assert.Equal(t, int32(http.StatusAccepted), resp.Code)
+
+		// Each series has both a float and a histogram sample.
+		assert.Equal(t, 10, report.ReceivedSamples)
+		assert.Equal(t, 0, report.AcceptedSamples)
+		assert.Equal(t, 10, report.DedupedSamples)
+		assert.Equal(t, 0, report.InvalidSamples)
+	})
+}
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/crypto/tls"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+const (
+	dualWriteReasonQueueFull        = "queue_full"
+	dualWriteReasonRejected         = "rejected"
+	dualWriteReasonRetriesExhausted = "retries_exhausted"
+)
+
+// DualWriteConfig configures the replication of the write requests of selected tenants to a second Mimir cluster.
+type DualWriteConfig struct {
+	URL           flagext.URLValue `yaml:"url" category:"experimental"`
+	TLSEnabled    bool             `yaml:"tls_enabled" category:"experimental"`
+	TLS           tls.ClientConfig `yaml:",inline"`
+	BasicAuth     util.BasicAuth   `yaml:",inline"`
+	Timeout       time.Duration    `yaml:"timeout" category:"experimental"`
+	QueueCapacity int              `yaml:"queue_capacity" category:"experimental"`
+	QueueMaxBytes int              `yaml:"queue_max_bytes" category:"experimental"`
+	Concurrency   int              `yaml:"concurrency" category:"experimental"`
+	MaxRetries    int              `yaml:"max_retries" category:"experimental"`
+	MinBackoff    time.Duration    `yaml:"min_backoff" category:"experimental"`
+	MaxBackoff    time.Duration    `yaml:"max_backoff" category:"experimental"`
+}
+
+func (cfg *DualWriteConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.URL, "distributor.dual-write.url", "URL of the remote write endpoint of a second Mimir cluster, for example http://mimir/api/v1/push, the write requests of the tenants with dual-write enabled are replicated to. The write requests are replicated asynchronously, once they have been validated, and failing to replicate them doesn't fail the write requests. Empty to disable.")
+	f.BoolVar(&cfg.TLSEnabled, "distributor.dual-write.tls-enabled", false, "Enable TLS for the client connecting to the second cluster, configured by the TLS options of the dual-write client.")
+	cfg.TLS.RegisterFlagsWithPrefix("distributor.dual-write", f)
+	cfg.BasicAuth.RegisterFlagsWithPrefix("distributor.dual-write.", f)
+	f.DurationVar(&cfg.Timeout, "distributor.dual-write.timeout", 5*time.Second, "Timeout of each write request sent to the second cluster.")
+	f.IntVar(&cfg.QueueCapacity, "distributor.dual-write.queue-capacity", 10000, "Maximum number of write requests waiting to be replicated to the second cluster. Write requests exceeding the capacity aren't replicated.")
+	f.IntVar(&cfg.QueueMaxBytes, "distributor.dual-write.queue-max-bytes", 256<<20, "Maximum total size, in bytes, of the write requests waiting to be replicated to the second cluster. Write requests exceeding the size aren't replicated.")
+	f.IntVar(&cfg.Concurrency, "distributor.dual-write.concurrency", 10, "Number of write requests sent concurrently to the second cluster.")
+	f.IntVar(&cfg.MaxRetries, "distributor.dual-write.max-retries", 3, "Maximum number of times a write request is retried, when the second cluster fails with a network error, a 5xx or a 429 status code.")
+	f.DurationVar(&cfg.MinBackoff, "distributor.dual-write.min-backoff", 100*time.Millisecond, "Minimum backoff between retries of a write request sent to the second cluster.")
+	f.DurationVar(&cfg.MaxBackoff, "distributor.dual-write.max-backoff", 5*time.Second, "Maximum backoff between retries of a write request sent to the second cluster.")
+}
+
+func (cfg *DualWriteConfig) Enabled() bool {
+	return cfg.URL.URL != nil
+}
+
+func (cfg *DualWriteConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("the dual-write timeout must be greater than 0")
+	}
+	if cfg.QueueCapacity <= 0 {
+		return fmt.Errorf("the dual-write queue capacity must be greater than 0")
+	}
+	if cfg.QueueMaxBytes <= 0 {
+		return fmt.Errorf("the dual-write queue max bytes must be greater than 0")
+	}
+	if cfg.Concurrency <= 0 {
+		return fmt.Errorf("the dual-write concurrency must be greater than 0")
+	}
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("the dual-write max retries must be greater than or equal to 0")
+	}
+	if cfg.MinBackoff <= 0 || cfg.MaxBackoff < cfg.MinBackoff {
+		return fmt.Errorf("the dual-write min backoff must be greater than 0 and not greater than the max backoff")
+	}
+	return nil
+}
+
+// dualWriteRequest is a write request waiting to be replicated to the second cluster.
+type dualWriteRequest struct {
+	userID     string
+	data       []byte
+	enqueuedAt time.Time
+}
+
+// dualWriter asynchronously replicates write requests to a second Mimir cluster via remote write.
+// Replication is independent of the write path: requests are queued and sent by a pool of workers,
+// and are dropped when the queue is full or the second cluster keeps failing.
+type dualWriter struct {
+	services.Service
+
+	cfg    DualWriteConfig
+	client *http.Client
+	logger log.Logger
+	queue  chan dualWriteRequest
+
+	// Total size of the write requests queued or being sent.
+	queueBytes atomic.Int64
+
+	sentRequests   *prometheus.CounterVec
+	failedRequests *prometheus.CounterVec
+	lag            prometheus.Histogram
+}
+
+func newDualWriter(cfg DualWriteConfig, reg prometheus.Registerer, logger log.Logger) (*dualWriter, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	if cfg.TLSEnabled {
+		tlsConfig, err := cfg.TLS.GetTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the dual-write client TLS config: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+
+	w := &dualWriter{
+		cfg:    cfg,
+		client: client,
+		logger: logger,
+		queue:  make(chan dualWriteRequest, cfg.QueueCapacity),
+		sentRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_dual_write_sent_requests_total",
+			Help: "Total number of write requests successfully replicated to the second cluster.",
+		}, []string{"user"}),
+		failedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_dual_write_failed_requests_total",
+			Help: "Total number of write requests which failed to be replicated to the second cluster.",
+		}, []string{"user", "reason"}),
+		lag: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_dual_write_lag_seconds",
+			Help:    "Time between when a write request is received and when it has been replicated to the second cluster.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_distributor_dual_write_queue_length",
+		Help: "Number of write requests waiting to be replicated to the second cluster.",
+	}, func() float64 {
+		return float64(len(w.queue))
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_distributor_dual_write_queue_bytes",
+		Help: "Total size of the write requests waiting or being replicated to the second cluster.",
+	}, func() float64 {
+		return float64(w.queueBytes.Load())
+	})
+
+	w.Service = services.NewBasicService(nil, w.running, nil)
+	return w, nil
+}
+
+func (w *dualWriter) running(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	wg.Add(w.cfg.Concurrency)
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			w.runWorker(ctx)
+		}()
+	}
+	wg.Wait()
+
+	// The write requests still queued are lost.
+	return nil
+}
+
+func (w *dualWriter) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-w.queue:
+			w.send(ctx, req)
+			w.queueBytes.Sub(int64(len(req.data)))
+		}
+	}
+}
+
+// enqueue queues the marshalled write request of the tenant to be replicated to the second cluster.
+// It never blocks: the write request is dropped if the queue is full, either by number of requests or by size.
+func (w *dualWriter) enqueue(userID string, data []byte) {
+	size := int64(len(data))
+	if w.queueBytes.Add(size) > int64(w.cfg.QueueMaxBytes) {
+		w.queueBytes.Sub(size)
+		w.failedRequests.WithLabelValues(userID, dualWriteReasonQueueFull).Inc()
+		return
+	}
+
+	select {
+	case w.queue <- dualWriteRequest{userID: userID, data: data, enqueuedAt: time.Now()}:
+	default:
+		w.queueBytes.Sub(size)
+		w.failedRequests.WithLabelValues(userID, dualWriteReasonQueueFull).Inc()
+	}
+}
+
+func (w *dualWriter) send(ctx context.Context, req dualWriteRequest) {
+	compressed := snappy.Encode(nil, req.data)
+
+	boff := backoff.New(ctx, backoff.Config{
+		MinBackoff: w.cfg.MinBackoff,
+		MaxBackoff: w.cfg.MaxBackoff,
+		MaxRetries: w.cfg.MaxRetries + 1,
+	})
+
+	var err error
+	for boff.Ongoing() {
+		var retryable bool
+		retryable, err = w.sendOnce(ctx, req.userID, compressed)
+		if err == nil {
+			w.sentRequests.WithLabelValues(req.userID).Inc()
+			w.lag.Observe(time.Since(req.enqueuedAt).Seconds())
+			return
+		}
+		if !retryable {
+			level.Warn(w.logger).Log("msg", "write request rejected by the dual-write cluster", "user", req.userID, "err", err)
+			w.failedRequests.WithLabelValues(req.userID, dualWriteReasonRejected).Inc()
+			return
+		}
+		boff.Wait()
+	}
+
+	// The write request is dropped when the distributor is shutting down, too.
+	level.Warn(w.logger).Log("msg", "failed to replicate write request to the dual-write cluster", "user", req.userID, "err", err)
+	w.failedRequests.WithLabelValues(req.userID, dualWriteReasonRetriesExhausted).Inc()
+}
+
+// sendOnce sends the compressed write request to the second cluster, and returns whether the request
+// can be retried in case of failure.
+func (w *dualWriter) sendOnce(ctx context.Context, userID string, compressed []byte) (bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL.String(), bytes.NewReader(compressed))
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	httpReq.Header.Set(user.OrgIDHeaderName, userID)
+	if w.cfg.BasicAuth.IsEnabled() {
+		httpReq.SetBasicAuth(w.cfg.BasicAuth.Username, w.cfg.BasicAuth.Password.String())
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("server returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(body))
+	return resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+func (w *dualWriter) cleanupUser(userID string) {
+	w.sentRequests.DeleteLabelValues(userID)
+	w.failedRequests.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// prePushDualWriteMiddleware queues the validated write requests of the tenants with dual-write enabled
+// to be replicated to the second cluster. The write request is pushed to the ingesters regardless of
+// the outcome of the replication.
+func (d *Distributor) prePushDualWriteMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+		if !d.limits.DualWriteEnabled(userID) {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+
+		// The write request is marshalled right away, because its memory is pooled and
+		// reused as soon as it has been pushed to the ingesters.
+		data, err := req.Marshal()
+		if err != nil {
+			level.Warn(d.log).Log("msg", "failed to marshal write request for the dual-write cluster", "user", userID, "err", err)
+		} else {
+			d.dualWriter.enqueue(userID, data)
+		}
+
+		return next(ctx, pushReq)
+	}
+}
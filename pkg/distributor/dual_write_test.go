@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_Push_DualWrite(t *testing.T) {
+	tests := map[string]struct {
+		statusCodes     []int
+		expectedCalls   int
+		expectedMetrics string
+	}{
+		"should replicate the write requests of the enabled tenants": {
+			statusCodes:   []int{http.StatusOK},
+			expectedCalls: 1,
+			expectedMetrics: `
+				# HELP cortex_distributor_dual_write_sent_requests_total Total number of write requests successfully replicated to the second cluster.
+				# TYPE cortex_distributor_dual_write_sent_requests_total counter
+				cortex_distributor_dual_write_sent_requests_total{user="enabled"} 1
+			`,
+		},
+		"should retry the write requests failed with a 5xx status code": {
+			statusCodes:   []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK},
+			expectedCalls: 3,
+			expectedMetrics: `
+				# HELP cortex_distributor_dual_write_sent_requests_total Total number of write requests successfully replicated to the second cluster.
+				# TYPE cortex_distributor_dual_write_sent_requests_total counter
+				cortex_distributor_dual_write_sent_requests_total{user="enabled"} 1
+			`,
+		},
+		"should not retry the write requests failed with a 4xx status code": {
+			statusCodes:   []int{http.StatusBadRequest},
+			expectedCalls: 1,
+			expectedMetrics: `
+				# HELP cortex_distributor_dual_write_failed_requests_total Total number of write requests which failed to be replicated to the second cluster.
+				# TYPE cortex_distributor_dual_write_failed_requests_total counter
+				cortex_distributor_dual_write_failed_requests_total{reason="rejected",user="enabled"} 1
+			`,
+		},
+		"should give up once the retries are exhausted": {
+			statusCodes:   []int{http.StatusServiceUnavailable},
+			expectedCalls: 4,
+			expectedMetrics: `
+				# HELP cortex_distributor_dual_write_failed_requests_total Total number of write requests which failed to be replicated to the second cluster.
+				# TYPE cortex_distributor_dual_write_failed_requests_total counter
+				cortex_distributor_dual_write_failed_requests_total{reason="retries_exhausted",user="enabled"} 1
+			`,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := atomic.NewInt32(0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := int(calls.Inc()) - 1
+
+				assert.Equal(t, "enabled", r.Header.Get(user.OrgIDHeaderName))
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "dual-write", username)
+				assert.Equal(t, "secret", password)
+
+				compressed, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				data, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				req := mimirpb.WriteRequest{}
+				require.NoError(t, req.Unmarshal(data))
+				assert.Len(t, req.Timeseries, 1)
+
+				if call >= len(testData.statusCodes) {
+					call = len(testData.statusCodes) - 1
+				}
+				w.WriteHeader(testData.statusCodes[call])
+			}))
+			t.Cleanup(server.Close)
+
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			tenantLimits := map[string]*validation.Limits{"enabled": {}}
+			*tenantLimits["enabled"] = *limits
+			tenantLimits["enabled"].DualWriteEnabled = true
+
+			distributors, ingesters, regs := prepare(t, prepConfig{
+				numIngesters:    3,
+				happyIngesters:  3,
+				numDistributors: 1,
+				limits:          limits,
+				dualWriteURL:    server.URL,
+			})
+			overrides, err := validation.NewOverrides(*limits, validation.NewMockTenantLimits(tenantLimits))
+			require.NoError(t, err)
+			distributors[0].limits = overrides
+			distributors[0].dualWriter.cfg.MinBackoff = time.Millisecond
+			distributors[0].dualWriter.cfg.MaxBackoff = time.Millisecond
+			distributors[0].dualWriter.cfg.BasicAuth.Username = "dual-write"
+			require.NoError(t, distributors[0].dualWriter.cfg.BasicAuth.Password.Set("secret"))
+
+			for _, userID := range []string{"enabled", "disabled"} {
+				_, err := distributors[0].Push(user.InjectOrgID(context.Background(), userID), makeWriteRequest(0, 1, 0, false, false))
+				require.NoError(t, err)
+			}
+
+			// The write requests are pushed to the ingesters regardless of the replication.
+			test.Poll(t, time.Second, 2*len(ingesters), func() interface{} {
+				calls := 0
+				for idx := range ingesters {
+					calls += ingesters[idx].countCalls("Push")
+				}
+				return calls
+			})
+
+			test.Poll(t, 5*time.Second, nil, func() interface{} {
+				return testutil.GatherAndCompare(regs[0], strings.NewReader(testData.expectedMetrics), "cortex_distributor_dual_write_sent_requests_total", "cortex_distributor_dual_write_failed_requests_total")
+			})
+			assert.Equal(t, testData.expectedCalls, int(calls.Load()))
+		})
+	}
+}
+
+func TestDualWriter_QueueFull(t *testing.T) {
+	cfg := DualWriteConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.QueueCapacity = 1
+
+	// The writer isn't started, so the queue is never consumed.
+	reg := prometheus.NewPedanticRegistry()
+	w, err := newDualWriter(cfg, reg, log.NewNopLogger())
+	require.NoError(t, err)
+	w.enqueue("user", []byte("1"))
+	w.enqueue("user", []byte("2"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_dual_write_failed_requests_total Total number of write requests which failed to be replicated to the second cluster.
+		# TYPE cortex_distributor_dual_write_failed_requests_total counter
+		cortex_distributor_dual_write_failed_requests_total{reason="queue_full",user="user"} 1
+		# HELP cortex_distributor_dual_write_queue_length Number of write requests waiting to be replicated to the second cluster.
+		# TYPE cortex_distributor_dual_write_queue_length gauge
+		cortex_distributor_dual_write_queue_length 1
+	`), "cortex_distributor_dual_write_failed_requests_total", "cortex_distributor_dual_write_queue_length"))
+}
+
+func TestDualWriter_QueueMaxBytes(t *testing.T) {
+	cfg := DualWriteConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.QueueMaxBytes = 10
+
+	// The writer isn't started, so the queue is never consumed.
+	reg := prometheus.NewPedanticRegistry()
+	w, err := newDualWriter(cfg, reg, log.NewNopLogger())
+	require.NoError(t, err)
+	w.enqueue("user", []byte("123456"))
+	w.enqueue("user", []byte("123456"))
+	w.enqueue("user", []byte("1234"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_dual_write_failed_requests_total Total number of write requests which failed to be replicated to the second cluster.
+		# TYPE cortex_distributor_dual_write_failed_requests_total counter
+		cortex_distributor_dual_write_failed_requests_total{reason="queue_full",user="user"} 1
+		# HELP cortex_distributor_dual_write_queue_bytes Total size of the write requests waiting or being replicated to the second cluster.
+		# TYPE cortex_distributor_dual_write_queue_bytes gauge
+		cortex_distributor_dual_write_queue_bytes 10
+		# HELP cortex_distributor_dual_write_queue_length Number of write requests waiting to be replicated to the second cluster.
+		# TYPE cortex_distributor_dual_write_queue_length gauge
+		cortex_distributor_dual_write_queue_length 2
+	`), "cortex_distributor_dual_write_failed_requests_total", "cortex_distributor_dual_write_queue_bytes", "cortex_distributor_dual_write_queue_length"))
+}
+
+func TestDualWriteConfig_Validate(t *testing.T) {
+	cfg := DualWriteConfig{}
+	flagext.DefaultValues(&cfg)
+	assert.NoError(t, cfg.Validate())
+
+	require.NoError(t, cfg.URL.Set("http://mimir/api/v1/push"))
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Concurrency = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg.Concurrency = 1
+	cfg.QueueMaxBytes = 0
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	exemplarsPerSeriesWindow  = time.Minute
+	exemplarsPerSeriesStripes = 64
+)
+
+// exemplarsPerSeriesTracker tracks the number of exemplars accepted per series in the current minute,
+// to enforce the maximum number of exemplars per series per minute. Series are identified by the hash
+// of their labels, so series with colliding hashes share the same budget. The tracking is local to
+// each distributor.
+type exemplarsPerSeriesTracker struct {
+	stripes [exemplarsPerSeriesStripes]exemplarsPerSeriesStripe
+}
+
+type exemplarsPerSeriesStripe struct {
+	mtx       sync.Mutex
+	series    map[exemplarsPerSeriesKey]exemplarsPerSeriesWindowCount
+	lastPurge time.Time
+}
+
+type exemplarsPerSeriesKey struct {
+	userID     string
+	seriesHash uint64
+}
+
+type exemplarsPerSeriesWindowCount struct {
+	windowStart time.Time
+	count       int
+}
+
+func newExemplarsPerSeriesTracker() *exemplarsPerSeriesTracker {
+	t := &exemplarsPerSeriesTracker{}
+	for i := range t.stripes {
+		t.stripes[i].series = map[exemplarsPerSeriesKey]exemplarsPerSeriesWindowCount{}
+	}
+	return t
+}
+
+// accept returns how many of the input number of exemplars of the series can be accepted without
+// exceeding the limit in the current minute, and accounts for them.
+func (t *exemplarsPerSeriesTracker) accept(userID string, seriesHash uint64, now time.Time, limit, count int) int {
+	stripe := &t.stripes[seriesHash%exemplarsPerSeriesStripes]
+	key := exemplarsPerSeriesKey{userID: userID, seriesHash: seriesHash}
+	windowStart := now.Truncate(exemplarsPerSeriesWindow)
+
+	stripe.mtx.Lock()
+	defer stripe.mtx.Unlock()
+
+	// The series of the past windows are purged while accessing the stripe,
+	// so there's no need for a background cleanup.
+	if now.Sub(stripe.lastPurge) >= exemplarsPerSeriesWindow {
+		for k, c := range stripe.series {
+			if c.windowStart.Before(windowStart) {
+				delete(stripe.series, k)
+			}
+		}
+		stripe.lastPurge = now
+	}
+
+	current := stripe.series[key]
+	if !current.windowStart.Equal(windowStart) {
+		current = exemplarsPerSeriesWindowCount{windowStart: windowStart}
+	}
+
+	accepted := count
+	if remaining := limit - current.count; accepted > remaining {
+		accepted = remaining
+	}
+	if accepted < 0 {
+		accepted = 0
+	}
+
+	current.count += accepted
+	stripe.series[key] = current
+	return accepted
+}
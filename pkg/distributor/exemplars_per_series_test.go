@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestExemplarsPerSeriesTracker(t *testing.T) {
+	tracker := newExemplarsPerSeriesTracker()
+	now := time.Unix(1000*60, 0)
+
+	assert.Equal(t, 2, tracker.accept("user-1", 1, now, 3, 2))
+	assert.Equal(t, 1, tracker.accept("user-1", 1, now.Add(10*time.Second), 3, 2))
+	assert.Equal(t, 0, tracker.accept("user-1", 1, now.Add(20*time.Second), 3, 1))
+
+	// Other series and tenants have their own budget.
+	assert.Equal(t, 3, tracker.accept("user-1", 2, now, 3, 5))
+	assert.Equal(t, 3, tracker.accept("user-2", 1, now, 3, 5))
+
+	// The budget is reset in the next minute.
+	assert.Equal(t, 3, tracker.accept("user-1", 1, now.Add(time.Minute), 3, 5))
+
+	// The series of the past minutes are purged.
+	stripe := &tracker.stripes[2%exemplarsPerSeriesStripes]
+	assert.Len(t, stripe.series, 1)
+	tracker.accept("user-1", 2+exemplarsPerSeriesStripes, now.Add(2*time.Minute), 3, 1)
+	assert.Len(t, stripe.series, 1)
+}
+
+func TestDistributor_EnforceMaxExemplarsPerSeries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits["user"] = validation.MockDefaultLimits()
+		tenantLimits["user"].MaxExemplarsPerSeriesPerMinute = 2
+	})
+	d := &Distributor{
+		limits:                    limits,
+		exemplarValidationMetrics: validation.NewExemplarValidationMetrics(reg),
+		exemplarsPerSeries:        newExemplarsPerSeriesTracker(),
+	}
+
+	makeSeries := func(name string) *mimirpb.PreallocTimeseries {
+		return &mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+			Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: name}},
+			Exemplars: []mimirpb.Exemplar{
+				{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, TimestampMs: 1000},
+				{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "2"}}, TimestampMs: 2000},
+				{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "3"}}, TimestampMs: 3000},
+			},
+		}}
+	}
+
+	now := time.Now()
+
+	series := makeSeries("foo")
+	d.enforceMaxExemplarsPerSeries(now, series, "user")
+	require.Len(t, series.Exemplars, 2)
+	assert.Equal(t, int64(1000), series.Exemplars[0].TimestampMs)
+	assert.Equal(t, int64(2000), series.Exemplars[1].TimestampMs)
+
+	series = makeSeries("foo")
+	d.enforceMaxExemplarsPerSeries(now, series, "user")
+	assert.Empty(t, series.Exemplars)
+
+	// The limit is not enforced for the tenants without it.
+	series = makeSeries("foo")
+	d.enforceMaxExemplarsPerSeries(now, series, "other")
+	assert.Len(t, series.Exemplars, 3)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_discarded_exemplars_total The total number of exemplars that were discarded.
+		# TYPE cortex_discarded_exemplars_total counter
+		cortex_discarded_exemplars_total{reason="exemplars_per_series_limit",user="user"} 4
+	`), "cortex_discarded_exemplars_total"))
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// ExemplarsReplicationConfig configures the sharding and replication of the exemplars independently from the samples.
+type ExemplarsReplicationConfig struct {
+	Enabled           bool `yaml:"enabled" category:"experimental"`
+	ReplicationFactor int  `yaml:"replication_factor" category:"experimental"`
+}
+
+func (cfg *ExemplarsReplicationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.exemplars-replication.enabled", false, "True to write the exemplars to a lower number of ingesters than the samples, configured with -distributor.exemplars-replication.replication-factor, to reduce the ingesters memory. The exemplars are sent to the ingesters once the samples have been written, and failing to write them doesn't fail the write request.")
+	f.IntVar(&cfg.ReplicationFactor, "distributor.exemplars-replication.replication-factor", 1, "Number of ingesters each exemplar is written to. The exemplars of a series are written to the first ingesters owning the series, so the value can't exceed the ingesters replication factor.")
+}
+
+func (cfg *ExemplarsReplicationConfig) Validate() error {
+	if cfg.Enabled && cfg.ReplicationFactor <= 0 {
+		return fmt.Errorf("the exemplars replication factor must be greater than 0")
+	}
+	return nil
+}
+
+// exemplarsReplicator writes the exemplars of the write requests to the ingesters with their own replication factor.
+type exemplarsReplicator struct {
+	cfg    ExemplarsReplicationConfig
+	logger log.Logger
+
+	failedPushes prometheus.Counter
+}
+
+func newExemplarsReplicator(cfg ExemplarsReplicationConfig, reg prometheus.Registerer, logger log.Logger) *exemplarsReplicator {
+	return &exemplarsReplicator{
+		cfg:    cfg,
+		logger: logger,
+		failedPushes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_exemplars_replication_failed_pushes_total",
+			Help: "The total number of exemplars-only pushes to ingesters which failed. The exemplars of failed pushes are dropped.",
+		}),
+	}
+}
+
+// splitExemplars moves the exemplars of the series out of them, into exemplars-only series. It returns the series
+// with samples or histograms to write with the ingesters replication factor, and the exemplars-only series.
+func splitExemplars(series []mimirpb.PreallocTimeseries) (samples, exemplars []mimirpb.PreallocTimeseries) {
+	numExemplarSeries := 0
+	for _, ts := range series {
+		if len(ts.Exemplars) > 0 {
+			numExemplarSeries++
+		}
+	}
+
+	// Quick case: nothing to split.
+	if numExemplarSeries == 0 {
+		return series, nil
+	}
+
+	samples = make([]mimirpb.PreallocTimeseries, 0, len(series))
+	exemplars = make([]mimirpb.PreallocTimeseries, 0, numExemplarSeries)
+
+	for i := range series {
+		ts := &series[i]
+		if len(ts.Exemplars) > 0 {
+			exemplars = append(exemplars, mimirpb.PreallocTimeseries{
+				TimeSeries: &mimirpb.TimeSeries{Labels: ts.Labels, Exemplars: ts.TakeExemplars()},
+			})
+		}
+		if len(ts.Samples) > 0 || len(ts.Histograms) > 0 {
+			samples = append(samples, *ts)
+		}
+	}
+
+	return samples, exemplars
+}
+
+// sendExemplars writes the exemplars-only series to the first ingesters owning each series, up to the exemplars
+// replication factor. The exemplars are written on a best-effort basis, so failures are tracked but not returned.
+func (d *Distributor) sendExemplars(ctx context.Context, subRing ring.ReadRing, series []mimirpb.PreallocTimeseries, keys []uint32, source mimirpb.WriteRequest_SourceEnum) {
+	type ingesterExemplars struct {
+		ingester ring.InstanceDesc
+		series   []mimirpb.PreallocTimeseries
+	}
+
+	var (
+		replicator = d.exemplarsReplicator
+		byIngester = map[string]*ingesterExemplars{}
+
+		bufDescs, bufHosts, bufZones = ring.MakeBuffersForGet()
+	)
+
+	for i, key := range keys {
+		replicationSet, err := subRing.Get(key, ring.WriteNoExtend, bufDescs, bufHosts, bufZones)
+		if err != nil {
+			replicator.failedPushes.Inc()
+			level.Warn(replicator.logger).Log("msg", "failed to find the ingesters to write the exemplars to", "err", err)
+			return
+		}
+
+		instances := replicationSet.Instances
+		if len(instances) > replicator.cfg.ReplicationFactor {
+			instances = instances[:replicator.cfg.ReplicationFactor]
+		}
+
+		for _, instance := range instances {
+			batch, ok := byIngester[instance.Addr]
+			if !ok {
+				batch = &ingesterExemplars{ingester: instance}
+				byIngester[instance.Addr] = batch
+			}
+			batch.series = append(batch.series, series[i])
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(byIngester))
+
+	for _, batch := range byIngester {
+		go func(batch *ingesterExemplars) {
+			defer wg.Done()
+
+			req := mimirpb.WriteRequest{
+				Timeseries:    batch.series,
+				Source:        source,
+				ExemplarsOnly: true,
+			}
+			if err := d.sendRequest(ctx, batch.ingester, &req); err != nil {
+				replicator.failedPushes.Inc()
+				level.Warn(replicator.logger).Log("msg", "failed to write exemplars to ingester", "ingester", batch.ingester.Addr, "err", err)
+			}
+		}(batch)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_Push_ExemplarsReplication(t *testing.T) {
+	tests := map[string]struct {
+		exemplarsReplication ExemplarsReplicationConfig
+		expectedExemplars    int
+	}{
+		"exemplars replicated with the samples when disabled": {
+			exemplarsReplication: ExemplarsReplicationConfig{Enabled: false},
+			expectedExemplars:    3 * 3,
+		},
+		"exemplars written to one ingester": {
+			exemplarsReplication: ExemplarsReplicationConfig{Enabled: true, ReplicationFactor: 1},
+			expectedExemplars:    3,
+		},
+		"exemplars written to two ingesters": {
+			exemplarsReplication: ExemplarsReplicationConfig{Enabled: true, ReplicationFactor: 2},
+			expectedExemplars:    3 * 2,
+		},
+		"exemplars replication factor higher than the ingesters one": {
+			exemplarsReplication: ExemplarsReplicationConfig{Enabled: true, ReplicationFactor: 5},
+			expectedExemplars:    3 * 3,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.MaxGlobalExemplarsPerUser = 10
+
+			distributors, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:         3,
+				happyIngesters:       3,
+				numDistributors:      1,
+				replicationFactor:    3,
+				limits:               limits,
+				exemplarsReplication: testData.exemplarsReplication,
+			})
+
+			// 3 series with samples and exemplars.
+			ctx := user.InjectOrgID(context.Background(), "user")
+			_, err := distributors[0].Push(ctx, makeWriteRequest(1000, 3, 0, true, false))
+			require.NoError(t, err)
+
+			// The samples are always written to all the ingesters.
+			test.Poll(t, time.Second, 3*3, func() interface{} {
+				samples := 0
+				for idx := range ingesters {
+					for _, series := range ingesters[idx].series() {
+						samples += len(series.Samples)
+					}
+				}
+				return samples
+			})
+
+			test.Poll(t, time.Second, testData.expectedExemplars, func() interface{} {
+				exemplars := 0
+				for idx := range ingesters {
+					ingesters[idx].Lock()
+					exemplars += ingesters[idx].exemplars
+					ingesters[idx].Unlock()
+				}
+				return exemplars
+			})
+		})
+	}
+}
+
+func TestSplitExemplars(t *testing.T) {
+	withSamples := makeWriteRequest(1000, 2, 0, true, false).Timeseries
+	exemplarsOnly := makeExemplarTimeseries([]string{"__name__", "foo"}, 1000, []string{"traceID", "123"})
+	withoutExemplars := makeWriteRequest(0, 1, 0, false, false).Timeseries[0]
+
+	series := []mimirpb.PreallocTimeseries{withSamples[0], exemplarsOnly, withSamples[1], withoutExemplars}
+	samples, exemplars := splitExemplars(series)
+
+	require.Len(t, samples, 3)
+	for _, ts := range samples {
+		assert.NotEmpty(t, ts.Samples)
+		assert.Empty(t, ts.Exemplars)
+	}
+
+	require.Len(t, exemplars, 3)
+	for _, ts := range exemplars {
+		assert.Empty(t, ts.Samples)
+		assert.Len(t, ts.Exemplars, 1)
+	}
+	assert.Equal(t, exemplarsOnly.Labels, exemplars[1].Labels)
+
+	// Nothing is split when the series have no exemplars.
+	samples, exemplars = splitExemplars([]mimirpb.PreallocTimeseries{withoutExemplars})
+	assert.Len(t, samples, 1)
+	assert.Nil(t, exemplars)
+}
+
+func TestExemplarsReplicationConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&ExemplarsReplicationConfig{}).Validate())
+	assert.NoError(t, (&ExemplarsReplicationConfig{Enabled: true, ReplicationFactor: 1}).Validate())
+	assert.Error(t, (&ExemplarsReplicationConfig{Enabled: true, ReplicationFactor: 0}).Validate())
+}
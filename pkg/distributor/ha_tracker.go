@@ -24,6 +24,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/timestamp"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
@@ -35,8 +36,13 @@ var (
 	errNegativeUpdateTimeoutJitterMax = errors.New("HA tracker max update timeout jitter shouldn't be negative")
 	errInvalidFailoverTimeout         = "HA Tracker failover timeout (%v) must be at least 1s greater than update timeout - max jitter (%v)"
 	errMemberlistUnsupported          = errors.New("memberlist is not supported by the HA tracker since gossip propagation is too slow for HA purposes")
+	errHATrackerDisabled              = errors.New("the HA tracker is disabled")
+	errHAClusterNotFound              = errors.New("the HA cluster isn't tracked")
 )
 
+// haTrackerMaxElections is the max number of elections of each cluster stored in the KV store.
+const haTrackerMaxElections = 10
+
 type haTrackerLimits interface {
 	// MaxHAClusters returns max number of clusters that HA tracker should track for a user.
 	// Samples from additional clusters are rejected.
@@ -448,6 +454,36 @@ func (h *haTracker) checkReplica(ctx context.Context, userID, cluster, replica s
 	return h.checkReplica(ctx, userID, cluster, replica, now)
 }
 
+// dryRunCheckReplica is like checkReplica, but it doesn't update the local cache nor the KV store.
+// A replica of a cluster not known yet is accepted, unless the max number of clusters has been reached,
+// because it would be elected by checkReplica.
+func (h *haTracker) dryRunCheckReplica(userID, cluster, replica string) error {
+	if !h.cfg.EnableHATracker {
+		return nil
+	}
+
+	h.electedLock.RLock()
+	entry := h.clusters[userID][cluster]
+	var elected string
+	if entry != nil {
+		elected = entry.elected.Replica
+	}
+	nClusters := len(h.clusters[userID])
+	h.electedLock.RUnlock()
+
+	if entry != nil {
+		if elected != replica {
+			return replicasNotMatchError{replica: replica, elected: elected}
+		}
+		return nil
+	}
+
+	if limit := h.limits.MaxHAClusters(userID); limit > 0 && nClusters+1 > limit {
+		return tooManyClustersError{limit: limit}
+	}
+	return nil
+}
+
 func (h *haTracker) withinUpdateTimeout(now time.Time, receivedAt int64) bool {
 	return now.Sub(timestamp.Time(receivedAt)) < h.cfg.UpdateTimeout+h.updateTimeoutJitter
 }
@@ -474,26 +510,33 @@ func (h *haTracker) updateCache(userID, cluster string, desc *ReplicaDesc) {
 func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica string, now time.Time) error {
 	key := fmt.Sprintf("%s/%s", userID, cluster)
 	var desc *ReplicaDesc
+	var failedOverFrom string
 	err := h.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
-		var ok bool
-		if desc, ok = in.(*ReplicaDesc); ok && desc.DeletedAt == 0 {
+		failedOverFrom = ""
+		prev, ok := in.(*ReplicaDesc)
+		if ok && prev.DeletedAt == 0 {
+			desc = prev
 			// If the entry in KVStore is up-to-date, just stop the loop.
 			if h.withinUpdateTimeout(now, desc.ReceivedAt) ||
-				// If our replica is different, wait until the failover time.
-				desc.Replica != replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < h.cfg.FailoverTimeout {
+				// If our replica is different, wait until the failover time, and until the end of the
+				// hold-down period of a replica elected manually.
+				desc.Replica != replica && (now.Sub(timestamp.Time(desc.ReceivedAt)) < h.cfg.FailoverTimeout || now.Before(timestamp.Time(desc.HeldUntil))) {
 				return nil, false, nil
 			}
+
+			if prev.Replica != replica {
+				failedOverFrom = prev.Replica
+			}
 		}
 
 		// Attempt to update KVStore to our timestamp and replica.
-		desc = &ReplicaDesc{
-			Replica:    replica,
-			ReceivedAt: timestamp.FromTime(now),
-			DeletedAt:  0,
-		}
+		desc = nextReplicaDesc(prev, replica, now, false)
 		return desc, true, nil
 	})
 	h.kvCASCalls.WithLabelValues(userID, cluster).Inc()
+	if err == nil && failedOverFrom != "" {
+		level.Info(h.logger).Log("msg", "HA tracker failed over to another replica", "user", userID, "cluster", cluster, "replica", replica, "previous_replica", failedOverFrom)
+	}
 	// If cache is currently empty, add the data we either stored or received from KVStore
 	if err == nil && desc != nil {
 		h.electedLock.Lock()
@@ -505,6 +548,69 @@ func (h *haTracker) updateKVStore(ctx context.Context, userID, cluster, replica
 	return err
 }
 
+// forceElectReplica elects the replica of the cluster, regardless of the currently elected replica, and holds it
+// elected until the end of the hold-down period: the HA tracker doesn't fail over to another replica before,
+// even if the elected replica doesn't send any sample. It returns the replica elected before.
+func (h *haTracker) forceElectReplica(ctx context.Context, userID, cluster, replica string, holdDown time.Duration, now time.Time) (string, error) {
+	if !h.cfg.EnableHATracker {
+		return "", errHATrackerDisabled
+	}
+
+	key := fmt.Sprintf("%s/%s", userID, cluster)
+	var desc *ReplicaDesc
+	var previous string
+	err := h.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		prev, ok := in.(*ReplicaDesc)
+		if !ok || prev == nil || prev.DeletedAt > 0 {
+			return nil, false, errHAClusterNotFound
+		}
+
+		previous = prev.Replica
+		desc = nextReplicaDesc(prev, replica, now, true)
+		desc.HeldUntil = timestamp.FromTime(now.Add(holdDown))
+		return desc, true, nil
+	})
+	h.kvCASCalls.WithLabelValues(userID, cluster).Inc()
+	if err != nil {
+		return "", err
+	}
+
+	// Other distributors get the elected replica from the KV store watch.
+	h.electedLock.Lock()
+	h.updateCache(userID, cluster, desc)
+	h.electedLock.Unlock()
+	return previous, nil
+}
+
+// nextReplicaDesc returns the descriptor of the replica elected at now for a cluster whose previous descriptor
+// is prev, which may be nil. A new election is recorded if the elected replica changes or is elected manually,
+// and the previous elections are kept up to haTrackerMaxElections.
+func nextReplicaDesc(prev *ReplicaDesc, replica string, now time.Time, forced bool) *ReplicaDesc {
+	desc := &ReplicaDesc{
+		Replica:    replica,
+		ReceivedAt: timestamp.FromTime(now),
+	}
+	if prev == nil {
+		prev = &ReplicaDesc{}
+	}
+
+	if !forced && prev.DeletedAt == 0 && prev.Replica == replica {
+		desc.HeldUntil = prev.HeldUntil
+		desc.Elections = prev.Elections
+		return desc
+	}
+
+	desc.Elections = make([]ReplicaElection, 0, haTrackerMaxElections)
+	desc.Elections = append(desc.Elections, ReplicaElection{Replica: replica, ElectedAt: desc.ReceivedAt, Forced: forced})
+	for _, election := range prev.Elections {
+		if len(desc.Elections) >= haTrackerMaxElections {
+			break
+		}
+		desc.Elections = append(desc.Elections, election)
+	}
+	return desc
+}
+
 type replicasNotMatchError struct {
 	replica, elected string
 }
@@ -558,6 +664,59 @@ func findHALabels(replicaLabel, clusterLabel string, labels []mimirpb.LabelAdapt
 	return cluster, replica
 }
 
+type haLabelsLimits interface {
+	HAClusterLabel(userID string) string
+	HAReplicaLabel(userID string) string
+	HALabelPairs(userID string) []validation.HALabelPair
+}
+
+// haLevel is the HA cluster and replica of a series at a level of the tenant's HA topology.
+type haLevel struct {
+	replicaLabel     string
+	cluster, replica string
+}
+
+// findHALevels returns the HA cluster and replica of the series at each level of the tenant's HA topology, from
+// the outermost to the innermost. The tenant's HA label pairs take precedence over its HA cluster and replica
+// labels, which identify a single level. The cluster or the replica of a level are empty if the series misses
+// any of their labels. They're copies of the label values, since they may be retained as labels on our metrics,
+// e.g. dedupedSamples.
+func findHALevels(limits haLabelsLimits, userID string, labels []mimirpb.LabelAdapter) []haLevel {
+	pairs := limits.HALabelPairs(userID)
+	if len(pairs) == 0 {
+		replicaLabel := limits.HAReplicaLabel(userID)
+		cluster, replica := findHALabels(replicaLabel, limits.HAClusterLabel(userID), labels)
+		return []haLevel{{replicaLabel: replicaLabel, cluster: copyString(cluster), replica: copyString(replica)}}
+	}
+
+	levels := make([]haLevel, 0, len(pairs))
+	for _, pair := range pairs {
+		level := haLevel{replicaLabel: pair.ReplicaLabel, replica: copyString(findHALabelValue(pair.ReplicaLabel, labels))}
+
+		// The cluster is named after the pair, so that each pair tracks its own elected replicas.
+		values := make([]string, 0, len(pair.ClusterLabels)+1)
+		values = append(values, pair.Name)
+		for _, clusterLabel := range pair.ClusterLabels {
+			values = append(values, findHALabelValue(clusterLabel, labels))
+		}
+		if !slices.Contains(values, "") {
+			level.cluster = strings.Join(values, "/")
+		}
+
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+func findHALabelValue(name string, labels []mimirpb.LabelAdapter) string {
+	for _, pair := range labels {
+		if pair.Name == name {
+			return pair.Value
+		}
+	}
+	return ""
+}
+
 func (h *haTracker) cleanupHATrackerMetricsForUser(userID string) {
 	filter := prometheus.Labels{"user": userID}
 
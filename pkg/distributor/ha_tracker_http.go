@@ -7,11 +7,15 @@ package distributor
 
 import (
 	_ "embed" // Used to embed html template
+	"errors"
+	"fmt"
 	"html/template"
 	"net/http"
 	"sort"
 	"time"
 
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/timestamp"
 
 	"github.com/grafana/mimir/pkg/util"
@@ -27,12 +31,28 @@ type haTrackerStatusPageContents struct {
 }
 
 type haTrackerReplica struct {
-	UserID       string        `json:"userID"`
-	Cluster      string        `json:"cluster"`
-	Replica      string        `json:"replica"`
-	ElectedAt    time.Time     `json:"electedAt"`
-	UpdateTime   time.Duration `json:"updateDuration"`
-	FailoverTime time.Duration `json:"failoverDuration"`
+	UserID       string              `json:"userID"`
+	Cluster      string              `json:"cluster"`
+	Replica      string              `json:"replica"`
+	ElectedAt    time.Time           `json:"electedAt"`
+	UpdateTime   time.Duration       `json:"updateDuration"`
+	FailoverTime time.Duration       `json:"failoverDuration"`
+	HeldUntil    *time.Time          `json:"heldUntil,omitempty"`
+	Elections    []haTrackerElection `json:"elections"`
+}
+
+type haTrackerElection struct {
+	Replica   string    `json:"replica"`
+	ElectedAt time.Time `json:"electedAt"`
+	Forced    bool      `json:"forced"`
+}
+
+type haTrackerElectResponse struct {
+	UserID          string    `json:"userID"`
+	Cluster         string    `json:"cluster"`
+	Replica         string    `json:"replica"`
+	PreviousReplica string    `json:"previousReplica"`
+	HeldUntil       time.Time `json:"heldUntil"`
 }
 
 func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -42,14 +62,33 @@ func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	for userID, clusters := range h.clusters {
 		for cluster, entry := range clusters {
 			desc := &entry.elected
-			electedReplicas = append(electedReplicas, haTrackerReplica{
+			replica := haTrackerReplica{
 				UserID:       userID,
 				Cluster:      cluster,
 				Replica:      desc.Replica,
 				ElectedAt:    timestamp.Time(desc.ReceivedAt),
 				UpdateTime:   time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.UpdateTimeout)),
 				FailoverTime: time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.FailoverTimeout)),
-			})
+				Elections:    make([]haTrackerElection, 0, len(desc.Elections)),
+			}
+
+			// The replicas elected before the elections were tracked only have the time they were last updated at.
+			if len(desc.Elections) > 0 && desc.Elections[0].Replica == desc.Replica {
+				replica.ElectedAt = timestamp.Time(desc.Elections[0].ElectedAt)
+			}
+			if desc.HeldUntil > 0 && time.Now().Before(timestamp.Time(desc.HeldUntil)) {
+				heldUntil := timestamp.Time(desc.HeldUntil)
+				replica.HeldUntil = &heldUntil
+			}
+			for _, election := range desc.Elections {
+				replica.Elections = append(replica.Elections, haTrackerElection{
+					Replica:   election.Replica,
+					ElectedAt: timestamp.Time(election.ElectedAt),
+					Forced:    election.Forced,
+				})
+			}
+
+			electedReplicas = append(electedReplicas, replica)
 		}
 	}
 	h.electedLock.RUnlock()
@@ -69,3 +108,46 @@ func (h *haTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		Now:     time.Now(),
 	}, haTrackerStatusPageTemplate, req)
 }
+
+// ElectReplicaHandler elects manually the replica of a HA cluster, regardless of the replica currently elected,
+// and holds it elected for the hold_down period. The HA tracker doesn't fail over to another replica before the
+// end of the hold-down period, even if the elected replica doesn't send any sample.
+func (h *haTracker) ElectReplicaHandler(w http.ResponseWriter, req *http.Request) {
+	userID, cluster, replica := req.FormValue("user"), req.FormValue("cluster"), req.FormValue("replica")
+	if userID == "" || cluster == "" || replica == "" {
+		http.Error(w, "the user, cluster and replica parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var holdDown time.Duration
+	if value := req.FormValue("hold_down"); value != "" {
+		parsed, err := model.ParseDuration(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid hold_down parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		holdDown = time.Duration(parsed)
+	}
+
+	now := time.Now()
+	previous, err := h.forceElectReplica(req.Context(), userID, cluster, replica, holdDown, now)
+	switch {
+	case errors.Is(err, errHATrackerDisabled) || errors.Is(err, errHAClusterNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		level.Error(h.logger).Log("msg", "failed to elect HA tracker replica manually", "user", userID, "cluster", cluster, "replica", replica, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(h.logger).Log("msg", "HA tracker replica elected manually", "user", userID, "cluster", cluster, "replica", replica, "previous_replica", previous, "hold_down", holdDown, "remote_addr", req.RemoteAddr)
+
+	util.WriteJSONResponse(w, haTrackerElectResponse{
+		UserID:          userID,
+		Cluster:         cluster,
+		Replica:         replica,
+		PreviousReplica: previous,
+		HeldUntil:       now.Add(holdDown),
+	})
+}
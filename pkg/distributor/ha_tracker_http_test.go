@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHATracker_ElectReplicaHandler(t *testing.T) {
+	kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	c, err := newHATracker(HATrackerConfig{
+		EnableHATracker: true,
+		KVStore:         kv.Config{Mock: kv.PrefixClient(kvStore, "prefix")},
+		UpdateTimeout:   time.Second,
+		FailoverTimeout: 2 * time.Second,
+	}, trackerLimits{maxClusters: 100}, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+	require.NoError(t, c.checkReplica(context.Background(), "user", "c1", "r1", time.Now()))
+
+	elect := func(params url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/distributor/ha_tracker/elect", strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		c.ElectReplicaHandler(rec, req)
+		return rec
+	}
+
+	rec := elect(url.Values{"user": {"user"}, "cluster": {"c1"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = elect(url.Values{"user": {"user"}, "cluster": {"c1"}, "replica": {"r2"}, "hold_down": {"invalid"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = elect(url.Values{"user": {"user"}, "cluster": {"unknown"}, "replica": {"r2"}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = elect(url.Values{"user": {"user"}, "cluster": {"c1"}, "replica": {"r2"}, "hold_down": {"1h"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp haTrackerElectResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "r2", resp.Replica)
+	assert.Equal(t, "r1", resp.PreviousReplica)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.HeldUntil, time.Minute)
+
+	// The status page lists the elections of each cluster.
+	req := httptest.NewRequest(http.MethodGet, "/distributor/ha_tracker", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status haTrackerStatusPageContents
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Len(t, status.Elected, 1)
+	assert.Equal(t, "r2", status.Elected[0].Replica)
+	require.NotNil(t, status.Elected[0].HeldUntil)
+	require.Len(t, status.Elected[0].Elections, 2)
+	assert.Equal(t, "r2", status.Elected[0].Elections[0].Replica)
+	assert.True(t, status.Elected[0].Elections[0].Forced)
+	assert.Equal(t, "r1", status.Elected[0].Elections[1].Replica)
+	assert.False(t, status.Elected[0].Elections[1].Forced)
+}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func checkReplicaTimestamp(t *testing.T, duration time.Duration, c *haTracker, user, cluster, replica string, expected time.Time) {
@@ -562,6 +563,44 @@ func TestFindHALabels(t *testing.T) {
 	}
 }
 
+type haLabelsLimitsMock struct {
+	clusterLabel, replicaLabel string
+	pairs                      []validation.HALabelPair
+}
+
+func (m haLabelsLimitsMock) HAClusterLabel(string) string                 { return m.clusterLabel }
+func (m haLabelsLimitsMock) HAReplicaLabel(string) string                 { return m.replicaLabel }
+func (m haLabelsLimitsMock) HALabelPairs(string) []validation.HALabelPair { return m.pairs }
+
+func TestFindHALevels(t *testing.T) {
+	series := []mimirpb.LabelAdapter{
+		{Name: "__name__", Value: "foo"},
+		{Name: "__region_replica__", Value: "region-replica-1"},
+		{Name: "__replica__", Value: "replica-1"},
+		{Name: "cluster", Value: "cluster-1"},
+		{Name: "region", Value: "eu"},
+	}
+
+	t.Run("should use the HA cluster and replica labels if there are no HA label pairs", func(t *testing.T) {
+		limits := haLabelsLimitsMock{clusterLabel: "cluster", replicaLabel: "__replica__"}
+		assert.Equal(t, []haLevel{{replicaLabel: "__replica__", cluster: "cluster-1", replica: "replica-1"}}, findHALevels(limits, "user", series))
+	})
+
+	t.Run("should name the cluster of each level after the HA label pair", func(t *testing.T) {
+		limits := haLabelsLimitsMock{clusterLabel: "cluster", replicaLabel: "__replica__", pairs: []validation.HALabelPair{
+			{Name: "region", ClusterLabels: []string{"region"}, ReplicaLabel: "__region_replica__"},
+			{Name: "cluster", ClusterLabels: []string{"region", "cluster"}, ReplicaLabel: "__replica__"},
+			{Name: "zone", ClusterLabels: []string{"region", "zone"}, ReplicaLabel: "__zone_replica__"},
+		}}
+		assert.Equal(t, []haLevel{
+			{replicaLabel: "__region_replica__", cluster: "region/eu", replica: "region-replica-1"},
+			{replicaLabel: "__replica__", cluster: "cluster/eu/cluster-1", replica: "replica-1"},
+			// The series has neither the zone nor the zone replica label.
+			{replicaLabel: "__zone_replica__", cluster: "", replica: ""},
+		}, findHALevels(limits, "user", series))
+	})
+}
+
 func TestHATrackerConfig_ShouldCustomizePrefixDefaultValue(t *testing.T) {
 	haConfig := HATrackerConfig{}
 	ringConfig := ring.Config{}
@@ -897,3 +936,86 @@ func getSumOfHistogramSampleCount(families []*dto.MetricFamily, metricName strin
 
 	return sum
 }
+
+func TestHATracker_ForceElectReplica(t *testing.T) {
+	const (
+		user    = "user"
+		cluster = "c1"
+	)
+
+	kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	c, err := newHATracker(HATrackerConfig{
+		EnableHATracker:        true,
+		KVStore:                kv.Config{Mock: kv.PrefixClient(kvStore, "prefix")},
+		UpdateTimeout:          time.Second,
+		UpdateTimeoutJitterMax: 0,
+		FailoverTimeout:        2 * time.Second,
+	}, trackerLimits{maxClusters: 100}, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+	start := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, c.checkReplica(context.Background(), user, cluster, "r1", start))
+
+	// Only the clusters already tracked can be elected manually.
+	_, err = c.forceElectReplica(context.Background(), user, "unknown", "r2", time.Hour, start)
+	require.ErrorIs(t, err, errHAClusterNotFound)
+
+	forcedAt := start.Add(100 * time.Millisecond)
+	previous, err := c.forceElectReplica(context.Background(), user, cluster, "r2", 10*time.Second, forcedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", previous)
+
+	checkReplicaTimestamp(t, time.Second, c, user, cluster, "r2", forcedAt)
+	require.NoError(t, c.checkReplica(context.Background(), user, cluster, "r2", forcedAt))
+	require.ErrorIs(t, c.checkReplica(context.Background(), user, cluster, "r1", forcedAt), replicasNotMatchError{})
+
+	// The replica elected manually isn't failed over during the hold-down period, even if it doesn't send samples.
+	now := start.Add(5 * time.Second)
+	require.Error(t, c.checkReplica(context.Background(), user, cluster, "r1", now))
+	c.updateKVStoreAll(context.Background(), now)
+	checkReplicaTimestamp(t, time.Second, c, user, cluster, "r2", forcedAt)
+
+	// It's failed over once the hold-down period has passed.
+	now = start.Add(20 * time.Second)
+	require.Error(t, c.checkReplica(context.Background(), user, cluster, "r1", now))
+	c.updateKVStoreAll(context.Background(), now)
+	checkReplicaTimestamp(t, time.Second, c, user, cluster, "r1", now)
+
+	c.electedLock.RLock()
+	elections := c.clusters[user][cluster].elected.Elections
+	c.electedLock.RUnlock()
+	assert.Equal(t, []ReplicaElection{
+		{Replica: "r1", ElectedAt: timestamp.FromTime(now)},
+		{Replica: "r2", ElectedAt: timestamp.FromTime(forcedAt), Forced: true},
+		{Replica: "r1", ElectedAt: timestamp.FromTime(start)},
+	}, elections)
+}
+
+func TestNextReplicaDesc(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+
+	desc := nextReplicaDesc(nil, "r1", now, false)
+	assert.Equal(t, []ReplicaElection{{Replica: "r1", ElectedAt: timestamp.FromTime(now)}}, desc.Elections)
+
+	// Refreshing the elected replica keeps its elections and hold-down period.
+	desc.HeldUntil = timestamp.FromTime(now.Add(time.Hour))
+	refreshed := nextReplicaDesc(desc, "r1", now.Add(time.Minute), false)
+	assert.Equal(t, timestamp.FromTime(now.Add(time.Minute)), refreshed.ReceivedAt)
+	assert.Equal(t, desc.HeldUntil, refreshed.HeldUntil)
+	assert.Equal(t, desc.Elections, refreshed.Elections)
+
+	// The replica elected after the descriptor has been marked for deletion is a new election.
+	deleted := &ReplicaDesc{Replica: "r1", ReceivedAt: desc.ReceivedAt, DeletedAt: desc.ReceivedAt, Elections: desc.Elections}
+	assert.Len(t, nextReplicaDesc(deleted, "r1", now, false).Elections, 2)
+
+	// Only the latest elections are kept.
+	for i := 0; i < 2*haTrackerMaxElections; i++ {
+		desc = nextReplicaDesc(desc, fmt.Sprintf("r%d", i%2), now.Add(time.Duration(i)*time.Second), false)
+	}
+	require.Len(t, desc.Elections, haTrackerMaxElections)
+	assert.Equal(t, timestamp.FromTime(now.Add(time.Duration(2*haTrackerMaxElections-1)*time.Second)), desc.Elections[0].ElectedAt)
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	hedgingOpLabelNames              = "label_names"
+	hedgingOpLabelValues             = "label_values"
+	hedgingOpMetricsForLabelMatchers = "metrics_for_label_matchers"
+)
+
+// IngesterHedgingConfig configures the hedging of the requests sent to the ingesters on the query path.
+type IngesterHedgingConfig struct {
+	LabelNamesDelay              time.Duration `yaml:"label_names_delay" category:"experimental"`
+	LabelValuesDelay             time.Duration `yaml:"label_values_delay" category:"experimental"`
+	MetricsForLabelMatchersDelay time.Duration `yaml:"metrics_for_label_matchers_delay" category:"experimental"`
+}
+
+func (cfg *IngesterHedgingConfig) RegisterFlags(f *flag.FlagSet) {
+	const help = "How long to wait for the minimum set of ingesters required to reach the quorum before sending the %s requests to the remaining ingesters too, and using the responses of whichever ingesters reach the quorum first. Requires -querier.minimize-ingester-requests. 0 to disable."
+
+	f.DurationVar(&cfg.LabelNamesDelay, "distributor.ingester-hedging.label-names-delay", 0, fmt.Sprintf(help, "label names"))
+	f.DurationVar(&cfg.LabelValuesDelay, "distributor.ingester-hedging.label-values-delay", 0, fmt.Sprintf(help, "label values"))
+	f.DurationVar(&cfg.MetricsForLabelMatchersDelay, "distributor.ingester-hedging.metrics-for-label-matchers-delay", 0, fmt.Sprintf(help, "series"))
+}
+
+func (cfg *IngesterHedgingConfig) Validate() error {
+	if cfg.LabelNamesDelay < 0 || cfg.LabelValuesDelay < 0 || cfg.MetricsForLabelMatchersDelay < 0 {
+		return fmt.Errorf("the ingester hedging delays must not be negative")
+	}
+	return nil
+}
+
+type ingesterHedgingMetrics struct {
+	hedgedRequests    *prometheus.CounterVec
+	cancelledRequests *prometheus.CounterVec
+}
+
+func newIngesterHedgingMetrics(reg prometheus.Registerer) *ingesterHedgingMetrics {
+	return &ingesterHedgingMetrics{
+		hedgedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingester_hedged_requests_total",
+			Help: "The total number of requests sent to the ingesters outside of the minimum set required to reach the quorum, because the minimum set didn't respond within the hedging delay or failed.",
+		}, []string{"op"}),
+		cancelledRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingester_hedged_requests_cancelled_total",
+			Help: "The total number of requests sent to the ingesters, with hedging enabled, cancelled before completion because the quorum has been reached without them or the query has been cancelled.",
+		}, []string{"op"}),
+	}
+}
+
+// forReplicationSetWithHedging behaves like forReplicationSet, except that, if the delay is greater than 0 and the
+// ingester requests are minimized, the requests are sent to the remaining ingesters of the replication set too once
+// the delay has elapsed or one of the initial requests has failed.
+func forReplicationSetWithHedging[T any](ctx context.Context, d *Distributor, op string, delay time.Duration, replicationSet ring.ReplicationSet, f func(context.Context, ingester_client.IngesterClient) (T, error)) ([]T, error) {
+	if delay <= 0 || !d.cfg.MinimizeIngesterRequests {
+		return forReplicationSet(ctx, d, replicationSet, f)
+	}
+
+	return doUntilQuorumWithHedging(ctx, replicationSet, delay, d.hedgingMetrics.hedgedRequests.WithLabelValues(op), d.hedgingMetrics.cancelledRequests.WithLabelValues(op), func(ctx context.Context, ing *ring.InstanceDesc) (T, error) {
+		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		if err != nil {
+			var empty T
+			return empty, err
+		}
+
+		return f(ctx, client.(ingester_client.IngesterClient))
+	})
+}
+
+// doUntilQuorumWithHedging calls f for the minimum set of instances of the replication set required to reach the
+// quorum, and for the remaining instances too once the delay has elapsed or one of the calls to the minimum set
+// has failed. It returns the results of the instances reaching the quorum first, like ring.DoUntilQuorum.
+func doUntilQuorumWithHedging[T any](ctx context.Context, replicationSet ring.ReplicationSet, delay time.Duration, hedged, cancelled prometheus.Counter, f func(context.Context, *ring.InstanceDesc) (T, error)) ([]T, error) {
+	initial := minimumQuorumInstances(replicationSet)
+
+	hedge := make(chan struct{})
+	hedgeOnce := sync.Once{}
+	startHedging := func() { hedgeOnce.Do(func() { close(hedge) }) }
+	timer := time.AfterFunc(delay, startHedging)
+	defer timer.Stop()
+
+	wrappedF := func(ctx context.Context, ing *ring.InstanceDesc) (T, error) {
+		var empty T
+
+		_, isInitial := initial[ing.Addr]
+		if !isInitial {
+			select {
+			case <-hedge:
+				hedged.Inc()
+			case <-ctx.Done():
+				// The quorum has been reached without this instance.
+				return empty, ctx.Err()
+			}
+		}
+
+		res, err := f(ctx, ing)
+		if err != nil {
+			if ctx.Err() != nil {
+				cancelled.Inc()
+			} else if isInitial {
+				// Don't wait for the delay to replace the failed instance.
+				startHedging()
+			}
+		}
+		return res, err
+	}
+
+	// All the instances are passed to ring.DoUntilQuorum, which cancels the calls still waiting for the
+	// hedging delay once the quorum has been reached.
+	return ring.DoUntilQuorum(ctx, replicationSet, false, wrappedF, func(T) {})
+}
+
+// minimumQuorumInstances returns the addresses of a random minimum set of instances required to reach the quorum:
+// the instances of all the zones but the max unavailable zones, if the replication set is zone-aware, or all the
+// instances but the max errors otherwise.
+func minimumQuorumInstances(replicationSet ring.ReplicationSet) map[string]struct{} {
+	result := map[string]struct{}{}
+
+	if replicationSet.MaxUnavailableZones > 0 {
+		var zones []string
+		seen := map[string]struct{}{}
+		for _, ing := range replicationSet.Instances {
+			if _, ok := seen[ing.Zone]; !ok {
+				seen[ing.Zone] = struct{}{}
+				zones = append(zones, ing.Zone)
+			}
+		}
+		rand.Shuffle(len(zones), func(i, j int) { zones[i], zones[j] = zones[j], zones[i] })
+
+		selected := map[string]struct{}{}
+		for _, zone := range zones[:util_math.Max(0, len(zones)-replicationSet.MaxUnavailableZones)] {
+			selected[zone] = struct{}{}
+		}
+		for _, ing := range replicationSet.Instances {
+			if _, ok := selected[ing.Zone]; ok {
+				result[ing.Addr] = struct{}{}
+			}
+		}
+		return result
+	}
+
+	for _, i := range rand.Perm(len(replicationSet.Instances))[:util_math.Max(0, len(replicationSet.Instances)-replicationSet.MaxErrors)] {
+		result[replicationSet.Instances[i].Addr] = struct{}{}
+	}
+	return result
+}
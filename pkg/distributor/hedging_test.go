@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimumQuorumInstances(t *testing.T) {
+	t.Run("not zone-aware", func(t *testing.T) {
+		set := ring.ReplicationSet{
+			Instances: []ring.InstanceDesc{{Addr: "a"}, {Addr: "b"}, {Addr: "c"}},
+			MaxErrors: 1,
+		}
+		for i := 0; i < 10; i++ {
+			assert.Len(t, minimumQuorumInstances(set), 2)
+		}
+	})
+
+	t.Run("zone-aware", func(t *testing.T) {
+		set := ring.ReplicationSet{
+			Instances: []ring.InstanceDesc{
+				{Addr: "a-1", Zone: "a"}, {Addr: "a-2", Zone: "a"},
+				{Addr: "b-1", Zone: "b"}, {Addr: "b-2", Zone: "b"},
+				{Addr: "c-1", Zone: "c"}, {Addr: "c-2", Zone: "c"},
+			},
+			MaxUnavailableZones: 1,
+		}
+		for i := 0; i < 10; i++ {
+			instances := minimumQuorumInstances(set)
+			require.Len(t, instances, 4)
+
+			// All the instances of the selected zones are selected.
+			zones := map[string]int{}
+			for _, ing := range set.Instances {
+				if _, ok := instances[ing.Addr]; ok {
+					zones[ing.Zone]++
+				}
+			}
+			assert.Len(t, zones, 2)
+			for _, count := range zones {
+				assert.Equal(t, 2, count)
+			}
+		}
+	})
+}
+
+func TestDoUntilQuorumWithHedging(t *testing.T) {
+	set := ring.ReplicationSet{
+		Instances: []ring.InstanceDesc{{Addr: "a"}, {Addr: "b"}, {Addr: "c"}},
+		MaxErrors: 1,
+	}
+
+	t.Run("the initial instances respond within the delay", func(t *testing.T) {
+		hedged, cancelled := prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{})
+
+		results, err := doUntilQuorumWithHedging(context.Background(), set, time.Minute, hedged, cancelled, func(_ context.Context, ing *ring.InstanceDesc) (string, error) {
+			return ing.Addr, nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, 0.0, testutil.ToFloat64(hedged))
+		assert.Equal(t, 0.0, testutil.ToFloat64(cancelled))
+	})
+
+	t.Run("an initial instance is slower than the delay", func(t *testing.T) {
+		hedged, cancelled := prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{})
+		first := firstInstance{}
+
+		results, err := doUntilQuorumWithHedging(context.Background(), set, 10*time.Millisecond, hedged, cancelled, func(ctx context.Context, ing *ring.InstanceDesc) (string, error) {
+			// The first instance called is slow.
+			if first.is(ing.Addr) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return ing.Addr, nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.NotContains(t, results, first.addr)
+		assert.Equal(t, 1.0, testutil.ToFloat64(hedged))
+
+		// The slow call is cancelled once the quorum has been reached.
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(cancelled) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("an initial instance fails", func(t *testing.T) {
+		hedged, cancelled := prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{})
+		first := firstInstance{}
+
+		results, err := doUntilQuorumWithHedging(context.Background(), set, time.Minute, hedged, cancelled, func(_ context.Context, ing *ring.InstanceDesc) (string, error) {
+			// The first instance called fails.
+			if first.is(ing.Addr) {
+				return "", errors.New("failed")
+			}
+			return ing.Addr, nil
+		})
+		// The remaining instance is called without waiting for the delay.
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.NotContains(t, results, first.addr)
+		assert.Equal(t, 1.0, testutil.ToFloat64(hedged))
+	})
+}
+
+// firstInstance tracks the first instance called.
+type firstInstance struct {
+	mtx  sync.Mutex
+	addr string
+}
+
+// is returns whether addr is the first instance called.
+func (f *firstInstance) is(addr string) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.addr == "" {
+		f.addr = addr
+	}
+	return f.addr == addr
+}
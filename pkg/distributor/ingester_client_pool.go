@@ -6,14 +6,18 @@
 package distributor
 
 import (
+	"context"
 	"flag"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/connectivity"
 )
 
 //lint:ignore faillint It's non-trivial to remove this global variable.
@@ -25,15 +29,19 @@ var clients = promauto.NewGauge(prometheus.GaugeOpts{
 
 // PoolConfig is config for creating a Pool.
 type PoolConfig struct {
-	ClientCleanupPeriod  time.Duration `yaml:"client_cleanup_period" category:"advanced"`
-	HealthCheckIngesters bool          `yaml:"health_check_ingesters" category:"advanced"`
-	RemoteTimeout        time.Duration `yaml:"-"`
+	ClientCleanupPeriod      time.Duration `yaml:"client_cleanup_period" category:"advanced"`
+	HealthCheckIngesters     bool          `yaml:"health_check_ingesters" category:"advanced"`
+	ConnectionsCheckPeriod   time.Duration `yaml:"connections_check_period" category:"experimental"`
+	ConnectionsWarmupEnabled bool          `yaml:"connections_warmup_enabled" category:"experimental"`
+	RemoteTimeout            time.Duration `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *PoolConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.ClientCleanupPeriod, "distributor.client-cleanup-period", 15*time.Second, "How frequently to clean up clients for ingesters that have gone away.")
 	f.BoolVar(&cfg.HealthCheckIngesters, "distributor.health-check-ingesters", true, "Run a health check on each ingester client during periodic cleanup.")
+	f.DurationVar(&cfg.ConnectionsCheckPeriod, "distributor.client-connections-check-period", 0, "How frequently to check the state of the connections of the ingester clients. Clients whose connection is broken are evicted and redialed before they're used for pushes. 0 to disable.")
+	f.BoolVar(&cfg.ConnectionsWarmupEnabled, "distributor.client-connections-warmup-enabled", false, "Establish the connections to the ingesters which are in the ring but don't have a client yet when checking the connections, so that the first push to a new ingester doesn't have to wait for the connection to be established. Requires -distributor.client-connections-check-period.")
 }
 
 func NewPool(cfg PoolConfig, ring ring.ReadRing, factory ring_client.PoolFactory, logger log.Logger) *ring_client.Pool {
@@ -45,3 +53,121 @@ func NewPool(cfg PoolConfig, ring ring.ReadRing, factory ring_client.PoolFactory
 
 	return ring_client.NewPool("ingester", poolCfg, ring_client.NewRingServiceDiscovery(ring), factory, clients, logger)
 }
+
+// connectionStateClient is an ingester client exposing the state of its gRPC connection.
+type connectionStateClient interface {
+	ConnectionState() connectivity.State
+	Connect()
+}
+
+// poolConnectionsChecker periodically checks the connections of the clients in the pool, without sending
+// any request to the ingesters. Clients whose connection is broken are evicted and redialed, which resets
+// the gRPC reconnection backoff, and connections to the ingesters which have joined the ring are established
+// before they're used for pushes. This reduces the errors on the first pushes after the ingesters roll out.
+type poolConnectionsChecker struct {
+	services.Service
+
+	cfg       PoolConfig
+	pool      *ring_client.Pool
+	discovery ring_client.PoolServiceDiscovery
+	logger    log.Logger
+
+	evictedClients  prometheus.Counter
+	warmedUpClients prometheus.Counter
+}
+
+func newPoolConnectionsChecker(cfg PoolConfig, pool *ring_client.Pool, ring ring.ReadRing, reg prometheus.Registerer, logger log.Logger) *poolConnectionsChecker {
+	c := &poolConnectionsChecker{
+		cfg:       cfg,
+		pool:      pool,
+		discovery: ring_client.NewRingServiceDiscovery(ring),
+		logger:    logger,
+		evictedClients: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingester_clients_evicted_total",
+			Help: "The total number of ingester clients evicted and redialed because their connection was broken.",
+		}),
+		warmedUpClients: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingester_clients_warmed_up_total",
+			Help: "The total number of ingester clients created before being used for a request.",
+		}),
+	}
+
+	c.Service = services.NewTimerService(cfg.ConnectionsCheckPeriod, nil, c.iteration, nil).WithName("ingester client pool connections checker")
+	return c
+}
+
+func (c *poolConnectionsChecker) iteration(_ context.Context) error {
+	addrs, err := c.discovery()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "unable to discover the ingesters to check the connections to", "err", err)
+		return nil
+	}
+
+	inRing := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		inRing[addr] = struct{}{}
+	}
+
+	// Stale clients, whose ingester isn't in the ring anymore, are removed by the pool itself.
+	registered := map[string]struct{}{}
+	for _, addr := range c.pool.RegisteredAddresses() {
+		registered[addr] = struct{}{}
+		if _, ok := inRing[addr]; ok {
+			c.checkConnection(addr)
+		}
+	}
+
+	if !c.cfg.ConnectionsWarmupEnabled {
+		return nil
+	}
+	for _, addr := range addrs {
+		if _, ok := registered[addr]; ok {
+			continue
+		}
+		if _, err := c.connect(addr); err != nil {
+			level.Warn(c.logger).Log("msg", "unable to warm up the ingester client", "addr", addr, "err", err)
+			continue
+		}
+		c.warmedUpClients.Inc()
+	}
+
+	// Never return an error, otherwise the checker would stop.
+	return nil
+}
+
+// checkConnection evicts and redials the client to the input address if its connection is broken.
+func (c *poolConnectionsChecker) checkConnection(addr string) {
+	client, err := c.pool.GetClientFor(addr)
+	if err != nil {
+		return
+	}
+	stateClient, ok := client.(connectionStateClient)
+	if !ok {
+		return
+	}
+
+	switch state := stateClient.ConnectionState(); state {
+	case connectivity.Idle:
+		stateClient.Connect()
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		level.Info(c.logger).Log("msg", "evicting ingester client with broken connection", "addr", addr, "state", state.String())
+		c.pool.RemoveClientFor(addr)
+		c.evictedClients.Inc()
+
+		if _, err := c.connect(addr); err != nil {
+			level.Warn(c.logger).Log("msg", "unable to redial the ingester client", "addr", addr, "err", err)
+		}
+	}
+}
+
+// connect creates the client to the input address, and starts establishing its connection in the background.
+func (c *poolConnectionsChecker) connect(addr string) (ring_client.PoolClient, error) {
+	client, err := c.pool.GetClientFor(addr)
+	if err != nil {
+		return nil, err
+	}
+	if stateClient, ok := client.(connectionStateClient); ok {
+		stateClient.Connect()
+	}
+	return client, nil
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/ring"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestPoolConnectionsChecker(t *testing.T) {
+	tests := map[string]struct {
+		warmupEnabled     bool
+		state             connectivity.State
+		expectedAddrs     []string
+		expectedDials     map[string]int
+		expectedConnects  map[string]int
+		expectedEvictions int
+		expectedWarmups   int
+	}{
+		"should keep the client with a ready connection": {
+			state:            connectivity.Ready,
+			expectedAddrs:    []string{"ingester-1"},
+			expectedDials:    map[string]int{"ingester-1": 1},
+			expectedConnects: map[string]int{},
+		},
+		"should connect the client with an idle connection": {
+			state:            connectivity.Idle,
+			expectedAddrs:    []string{"ingester-1"},
+			expectedDials:    map[string]int{"ingester-1": 1},
+			expectedConnects: map[string]int{"ingester-1": 1},
+		},
+		"should evict and redial the client with a broken connection": {
+			state:             connectivity.TransientFailure,
+			expectedAddrs:     []string{"ingester-1"},
+			expectedDials:     map[string]int{"ingester-1": 2},
+			expectedConnects:  map[string]int{"ingester-1": 1},
+			expectedEvictions: 1,
+		},
+		"should warm up the clients of the ingesters in the ring if enabled": {
+			warmupEnabled:    true,
+			state:            connectivity.Ready,
+			expectedAddrs:    []string{"ingester-1", "ingester-2"},
+			expectedDials:    map[string]int{"ingester-1": 1, "ingester-2": 1},
+			expectedConnects: map[string]int{"ingester-2": 1},
+			expectedWarmups:  1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			dials := map[string]int{}
+			connects := map[string]*atomic.Int32{}
+			factory := func(addr string) (ring_client.PoolClient, error) {
+				dials[addr]++
+				state := testData.state
+				if dials[addr] > 1 {
+					// The redialed client has a healthy connection.
+					state = connectivity.Ready
+				}
+				if connects[addr] == nil {
+					connects[addr] = atomic.NewInt32(0)
+				}
+				return &connectionStateMockClient{state: state, connects: connects[addr]}, nil
+			}
+
+			ingestersRing := &poolConnectionsMockRing{addrs: []string{"ingester-1", "ingester-2"}}
+			cfg := PoolConfig{ConnectionsWarmupEnabled: testData.warmupEnabled}
+			pool := ring_client.NewPool("ingester", ring_client.PoolConfig{}, nil, factory, nil, log.NewNopLogger())
+
+			_, err := pool.GetClientFor("ingester-1")
+			require.NoError(t, err)
+
+			reg := prometheus.NewPedanticRegistry()
+			checker := newPoolConnectionsChecker(cfg, pool, ingestersRing, reg, log.NewNopLogger())
+			require.NoError(t, checker.iteration(context.Background()))
+
+			assert.ElementsMatch(t, testData.expectedAddrs, pool.RegisteredAddresses())
+			assert.Equal(t, testData.expectedDials, dials)
+
+			actualConnects := map[string]int{}
+			for addr, count := range connects {
+				if count.Load() > 0 {
+					actualConnects[addr] = int(count.Load())
+				}
+			}
+			assert.Equal(t, testData.expectedConnects, actualConnects)
+			assert.Equal(t, float64(testData.expectedEvictions), testutil.ToFloat64(checker.evictedClients))
+			assert.Equal(t, float64(testData.expectedWarmups), testutil.ToFloat64(checker.warmedUpClients))
+		})
+	}
+}
+
+type poolConnectionsMockRing struct {
+	ring.ReadRing
+	addrs []string
+}
+
+func (r *poolConnectionsMockRing) GetAllHealthy(ring.Operation) (ring.ReplicationSet, error) {
+	set := ring.ReplicationSet{}
+	for _, addr := range r.addrs {
+		set.Instances = append(set.Instances, ring.InstanceDesc{Addr: addr})
+	}
+	return set, nil
+}
+
+type connectionStateMockClient struct {
+	state    connectivity.State
+	connects *atomic.Int32
+}
+
+func (c *connectionStateMockClient) Check(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (c *connectionStateMockClient) Watch(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (grpc_health_v1.Health_WatchClient, error) {
+	return nil, nil
+}
+
+func (c *connectionStateMockClient) Close() error {
+	return nil
+}
+
+func (c *connectionStateMockClient) ConnectionState() connectivity.State {
+	return c.state
+}
+
+func (c *connectionStateMockClient) Connect() {
+	c.connects.Inc()
+}
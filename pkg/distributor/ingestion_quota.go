@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/httpgrpc"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// ingestionQuotaKeySeparator separates the tenant ID from the quota name in the keys of the ingestion quotas
+// rate limiter. It can't be part of a tenant ID.
+const ingestionQuotaKeySeparator = "\x00"
+
+func ingestionQuotaKey(userID, name string) string {
+	return userID + ingestionQuotaKeySeparator + name
+}
+
+// ingestionQuotaMatchers caches the label matchers of the ingestion quotas of each tenant, so that the selectors
+// are only parsed again when the quotas of the tenant change.
+type ingestionQuotaMatchers struct {
+	logger log.Logger
+
+	mtx    sync.RWMutex
+	byUser map[string]cachedIngestionQuotaMatchers
+}
+
+type cachedIngestionQuotaMatchers struct {
+	quotas []validation.IngestionQuota
+	// Matchers of each quota, nil if the selector of the quota is invalid.
+	matchers [][]*labels.Matcher
+}
+
+func newIngestionQuotaMatchers(logger log.Logger) *ingestionQuotaMatchers {
+	return &ingestionQuotaMatchers{
+		logger: logger,
+		byUser: map[string]cachedIngestionQuotaMatchers{},
+	}
+}
+
+// get returns the label matchers of each quota of the user.
+func (m *ingestionQuotaMatchers) get(userID string, quotas []validation.IngestionQuota) [][]*labels.Matcher {
+	m.mtx.RLock()
+	cached, ok := m.byUser[userID]
+	m.mtx.RUnlock()
+	if ok && slices.Equal(cached.quotas, quotas) {
+		return cached.matchers
+	}
+
+	cached = cachedIngestionQuotaMatchers{
+		quotas:   slices.Clone(quotas),
+		matchers: make([][]*labels.Matcher, len(quotas)),
+	}
+	for idx, q := range quotas {
+		matchers, err := q.Matchers()
+		if err != nil {
+			// The quotas are validated when loading the limits, so this isn't expected to happen.
+			level.Warn(m.logger).Log("msg", "ignoring ingestion quota with invalid selector", "user", userID, "quota", q.Name, "err", err)
+			continue
+		}
+		cached.matchers[idx] = matchers
+	}
+
+	m.mtx.Lock()
+	m.byUser[userID] = cached
+	m.mtx.Unlock()
+	return cached.matchers
+}
+
+func (m *ingestionQuotaMatchers) cleanupUser(userID string) {
+	m.mtx.Lock()
+	delete(m.byUser, userID)
+	m.mtx.Unlock()
+}
+
+// applyIngestionQuotas removes from the write request the series matching an ingestion quota of the tenant which
+// has been exceeded, and returns the number of samples and exemplars removed. Each series is accounted to the
+// first matching quota. The returned error, if any, reports the first exceeded quota.
+func (d *Distributor) applyIngestionQuotas(now time.Time, userID, group string, req *mimirpb.WriteRequest) (discardedSamples, discardedExemplars int, err error) {
+	quotas := d.limits.IngestionQuotas(userID)
+	if len(quotas) == 0 {
+		return 0, 0, nil
+	}
+
+	matchers := d.ingestionQuotaMatchers.get(userID, quotas)
+
+	// Find the quota of each series, and the number of samples matching each quota.
+	seriesQuotas := make([]int, len(req.Timeseries))
+	quotaSamples := make([]int, len(quotas))
+	for tsIdx, ts := range req.Timeseries {
+		seriesQuotas[tsIdx] = -1
+		for quotaIdx := range quotas {
+			if matchers[quotaIdx] != nil && matchesLabelAdapters(matchers[quotaIdx], ts.Labels) {
+				seriesQuotas[tsIdx] = quotaIdx
+				quotaSamples[quotaIdx] += len(ts.Samples) + len(ts.Histograms)
+				break
+			}
+		}
+	}
+
+	exceeded := make([]bool, len(quotas))
+	anyExceeded := false
+	for quotaIdx, q := range quotas {
+		if quotaSamples[quotaIdx] == 0 {
+			continue
+		}
+		if !d.ingestionQuotaRateLimiter.AllowN(now, ingestionQuotaKey(userID, q.Name), quotaSamples[quotaIdx]) {
+			exceeded[quotaIdx] = true
+			anyExceeded = true
+		}
+	}
+	if !anyExceeded {
+		return 0, 0, nil
+	}
+
+	var removeIndexes []int
+	for tsIdx, quotaIdx := range seriesQuotas {
+		if quotaIdx < 0 || !exceeded[quotaIdx] {
+			continue
+		}
+
+		ts := req.Timeseries[tsIdx]
+		if err == nil {
+			err = httpgrpc.Errorf(http.StatusTooManyRequests, validation.NewIngestionQuotaExceededError(quotas[quotaIdx]).Error())
+			switch {
+			case len(ts.Samples) > 0:
+				d.discardedSamplesExamples.Record(userID, validation.ReasonIngestionQuotaExceeded, ts.Labels, ts.Samples[0].TimestampMs)
+			case len(ts.Histograms) > 0:
+				d.discardedSamplesExamples.Record(userID, validation.ReasonIngestionQuotaExceeded, ts.Labels, ts.Histograms[0].Timestamp)
+			}
+		}
+
+		samples := len(ts.Samples) + len(ts.Histograms)
+		d.ingestionQuotaDiscardedSamples.WithLabelValues(userID, quotas[quotaIdx].Name).Add(float64(samples))
+		discardedSamples += samples
+		discardedExemplars += len(ts.Exemplars)
+		removeIndexes = append(removeIndexes, tsIdx)
+	}
+
+	d.discardedSamplesIngestionQuota.WithLabelValues(userID, group).Add(float64(discardedSamples))
+	for _, removeIndex := range removeIndexes {
+		mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeIndex])
+	}
+	req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeIndexes)
+
+	return discardedSamples, discardedExemplars, err
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_Push_IngestionQuotas(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.IngestionQuotas = []validation.IngestionQuota{
+		{Name: "noisy-team", Match: `{namespace="noisy-team"}`, SamplesPerSecond: 0.001, Burst: 3},
+		{Name: "catch-all", Match: `{namespace=~".+"}`, SamplesPerSecond: 1000},
+	}
+
+	distributors, ingesters, regs := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		replicationFactor: 3,
+		limits:            limits,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	push := func(series ...labels.Labels) error {
+		lbls := make([][]mimirpb.LabelAdapter, 0, len(series))
+		samples := make([]mimirpb.Sample, 0, len(series))
+		for _, s := range series {
+			lbls = append(lbls, mimirpb.FromLabelsToLabelAdapters(s))
+			samples = append(samples, mimirpb.Sample{Value: 1, TimestampMs: 1000})
+		}
+		_, err := distributors[0].Push(ctx, mimirpb.ToWriteRequest(lbls, samples, nil, nil, mimirpb.API))
+		return err
+	}
+
+	// Within the quota.
+	require.NoError(t, push(
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "1"),
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "2"),
+	))
+
+	// The series exceeding the quota are discarded, the others are ingested.
+	err := push(
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "3"),
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "4"),
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "quiet-team", "pod", "1"),
+		labels.FromStrings(labels.MetricName, "requests", "pod", "1"),
+	)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	assert.Contains(t, string(resp.Body), `ingestion quota "noisy-team"`)
+
+	test.Poll(t, time.Second, map[string]mimirpb.Sample{
+		`{__name__="requests", namespace="noisy-team", pod="1"}`: {Value: 1, TimestampMs: 1000},
+		`{__name__="requests", namespace="noisy-team", pod="2"}`: {Value: 1, TimestampMs: 1000},
+		`{__name__="requests", namespace="quiet-team", pod="1"}`: {Value: 1, TimestampMs: 1000},
+		`{__name__="requests", pod="1"}`:                         {Value: 1, TimestampMs: 1000},
+	}, func() interface{} {
+		return ingesterSamples(&ingesters[0])
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{group="",reason="ingestion_quota_exceeded",user="user"} 2
+
+		# HELP cortex_distributor_ingestion_quota_discarded_samples_total The total number of samples discarded because the tenant exceeded one of its ingestion quotas, by quota.
+		# TYPE cortex_distributor_ingestion_quota_discarded_samples_total counter
+		cortex_distributor_ingestion_quota_discarded_samples_total{quota="noisy-team",user="user"} 2
+	`), "cortex_discarded_samples_total", "cortex_distributor_ingestion_quota_discarded_samples_total"))
+
+	// A request whose series all exceed the quota is rejected.
+	err = push(
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "5"),
+		labels.FromStrings(labels.MetricName, "requests", "namespace", "noisy-team", "pod", "6"),
+	)
+	resp, ok = httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+}
+
+func TestIngestionQuotaMatchers(t *testing.T) {
+	m := newIngestionQuotaMatchers(log.NewNopLogger())
+	quotas := []validation.IngestionQuota{
+		{Name: "team-a", Match: `{namespace="a"}`, SamplesPerSecond: 10},
+		{Name: "invalid", Match: `{namespace=`, SamplesPerSecond: 10},
+	}
+
+	matchers := m.get("user", quotas)
+	require.Len(t, matchers, 2)
+	require.Len(t, matchers[0], 1)
+	assert.Equal(t, "namespace", matchers[0][0].Name)
+	assert.Nil(t, matchers[1])
+
+	// The matchers are reused while the quotas don't change.
+	assert.Same(t, &matchers[0][0], &m.get("user", slices.Clone(quotas))[0][0])
+
+	// The matchers are parsed again once the quotas change.
+	quotas[0].Match = `{namespace="b"}`
+	updated := m.get("user", quotas)
+	assert.Equal(t, "b", updated[0][0].Value)
+	assert.Equal(t, "a", matchers[0][0].Value)
+
+	m.cleanupUser("user")
+	assert.Empty(t, m.byUser)
+}
@@ -3,23 +3,131 @@
 package distributor
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
 	"go.uber.org/atomic"
 )
 
+// healthyInstancesCounter keeps track of the number of healthy instances that are part of the ring.
+// Used here to count the number of distributors in the ring to determine how to enforce rate limiting.
+//
+// A new count is applied as soon as it's observed, unless it reverts the direction of the last change
+// applied less than hysteresisPeriod ago: in this case it's applied only once the hysteresis period has
+// elapsed and if it's still observed, in order to avoid the effective rate limits oscillating while
+// instances are flapping.
+type healthyInstancesCounter struct {
+	hysteresisPeriod time.Duration
+
+	// count is the number of healthy instances currently applied.
+	count atomic.Uint32
+
+	mtx            sync.Mutex
+	lastChangeAt   time.Time
+	lastIncreased  bool
+	changeHandlers []func()
+}
+
+func newHealthyInstancesCounter(hysteresisPeriod time.Duration) *healthyInstancesCounter {
+	return &healthyInstancesCounter{hysteresisPeriod: hysteresisPeriod}
+}
+
+// Load returns the number of healthy instances currently applied.
+func (c *healthyInstancesCounter) Load() uint32 {
+	return c.count.Load()
+}
+
+// onChange registers a function called each time the number of healthy instances applied changes.
+func (c *healthyInstancesCounter) onChange(f func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.changeHandlers = append(c.changeHandlers, f)
+}
+
+// observe records the number of healthy instances observed in the ring at the given time.
+func (c *healthyInstancesCounter) observe(now time.Time, observed uint32) {
+	c.mtx.Lock()
+
+	current := c.count.Load()
+	if observed == current {
+		c.mtx.Unlock()
+		return
+	}
+
+	// The first count observed is applied immediately and doesn't count as a change.
+	if current == 0 {
+		c.count.Store(observed)
+		c.mtx.Unlock()
+		c.notifyChange()
+		return
+	}
+
+	increased := observed > current
+	if !c.lastChangeAt.IsZero() && increased != c.lastIncreased && now.Sub(c.lastChangeAt) < c.hysteresisPeriod {
+		c.mtx.Unlock()
+		return
+	}
+
+	c.count.Store(observed)
+	c.lastChangeAt = now
+	c.lastIncreased = increased
+	c.mtx.Unlock()
+	c.notifyChange()
+}
+
+func (c *healthyInstancesCounter) notifyChange() {
+	c.mtx.Lock()
+	handlers := c.changeHandlers
+	c.mtx.Unlock()
+
+	for _, f := range handlers {
+		f()
+	}
+}
+
+// countHealthyInstances returns the number of ACTIVE instances with a healthy heartbeat in the ring.
+func countHealthyInstances(ringDesc *ring.Desc, heartbeatTimeout time.Duration, now time.Time) uint32 {
+	activeMembers := uint32(0)
+
+	for _, instance := range ringDesc.Ingesters {
+		if ring.ACTIVE == instance.State && instance.IsHeartbeatHealthy(heartbeatTimeout, now) {
+			activeMembers++
+		}
+	}
+
+	return activeMembers
+}
+
+// newHealthyInstancesWatcher returns a service updating the number of healthy instances as soon as the ring
+// changes in the KV store, instead of waiting for the next heartbeat of the local instance.
+func newHealthyInstancesWatcher(kvClient kv.Client, key string, counter *healthyInstancesCounter, heartbeatTimeout time.Duration) services.Service {
+	return services.NewBasicService(nil, func(ctx context.Context) error {
+		kvClient.WatchKey(ctx, key, func(value interface{}) bool {
+			if ringDesc, ok := value.(*ring.Desc); ok && ringDesc != nil {
+				now := time.Now()
+				counter.observe(now, countHealthyInstances(ringDesc, heartbeatTimeout, now))
+			}
+			return true
+		})
+		return nil
+	}, nil)
+}
+
 // healthyInstanceDelegate counts the number of healthy instances that are part of the ring
-// and stores the count to the provided atomic integer. Used here to count the number of
-// distributors in the ring to determine how to enforce rate limiting.
+// on each heartbeat and records the count to the provided counter.
 type healthyInstanceDelegate struct {
-	count            *atomic.Uint32
+	counter          *healthyInstancesCounter
 	heartbeatTimeout time.Duration
 	next             ring.BasicLifecyclerDelegate
 }
 
-func newHealthyInstanceDelegate(count *atomic.Uint32, heartbeatTimeout time.Duration, next ring.BasicLifecyclerDelegate) *healthyInstanceDelegate {
-	return &healthyInstanceDelegate{count: count, heartbeatTimeout: heartbeatTimeout, next: next}
+func newHealthyInstanceDelegate(counter *healthyInstancesCounter, heartbeatTimeout time.Duration, next ring.BasicLifecyclerDelegate) *healthyInstanceDelegate {
+	return &healthyInstanceDelegate{counter: counter, heartbeatTimeout: heartbeatTimeout, next: next}
 }
 
 // OnRingInstanceRegister implements the ring.BasicLifecyclerDelegate interface
@@ -39,15 +147,7 @@ func (d *healthyInstanceDelegate) OnRingInstanceStopping(lifecycler *ring.BasicL
 
 // OnRingInstanceHeartbeat implements the ring.BasicLifecyclerDelegate interface
 func (d *healthyInstanceDelegate) OnRingInstanceHeartbeat(lifecycler *ring.BasicLifecycler, ringDesc *ring.Desc, instanceDesc *ring.InstanceDesc) {
-	activeMembers := uint32(0)
 	now := time.Now()
-
-	for _, instance := range ringDesc.Ingesters {
-		if ring.ACTIVE == instance.State && instance.IsHeartbeatHealthy(d.heartbeatTimeout, now) {
-			activeMembers++
-		}
-	}
-
-	d.count.Store(activeMembers)
+	d.counter.observe(now, countHealthyInstances(ringDesc, d.heartbeatTimeout, now))
 	d.next.OnRingInstanceHeartbeat(lifecycler, ringDesc, instanceDesc)
 }
@@ -8,7 +8,6 @@ import (
 
 	"github.com/grafana/dskit/ring"
 	"github.com/stretchr/testify/assert"
-	"go.uber.org/atomic"
 )
 
 type nopDelegate struct{}
@@ -86,7 +85,7 @@ func TestHealthyInstanceDelegate_OnRingInstanceHeartbeat(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			count := atomic.NewUint32(0)
+			count := newHealthyInstancesCounter(0)
 			ringDesc := ring.NewDesc()
 
 			testData.ringSetup(ringDesc)
@@ -99,3 +98,76 @@ func TestHealthyInstanceDelegate_OnRingInstanceHeartbeat(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthyInstancesCounter_Observe(t *testing.T) {
+	const hysteresisPeriod = time.Minute
+	now := time.Now()
+
+	type observation struct {
+		at       time.Duration
+		count    uint32
+		expected uint32
+	}
+
+	tests := map[string]struct {
+		hysteresisPeriod time.Duration
+		observations     []observation
+		expectedChanges  int
+	}{
+		"changes in the same direction are applied immediately": {
+			hysteresisPeriod: hysteresisPeriod,
+			observations: []observation{
+				{at: 0, count: 3, expected: 3},
+				{at: time.Second, count: 2, expected: 2},
+				{at: 2 * time.Second, count: 1, expected: 1},
+			},
+			expectedChanges: 3,
+		},
+		"a change reverting the last one is delayed until the hysteresis period has elapsed": {
+			hysteresisPeriod: hysteresisPeriod,
+			observations: []observation{
+				{at: 0, count: 3, expected: 3},
+				{at: time.Second, count: 2, expected: 2},
+				{at: 2 * time.Second, count: 3, expected: 2},
+				{at: 30 * time.Second, count: 3, expected: 2},
+				{at: time.Second + hysteresisPeriod, count: 3, expected: 3},
+			},
+			expectedChanges: 3,
+		},
+		"a change reverting the last one is dropped if not observed anymore after the hysteresis period": {
+			hysteresisPeriod: hysteresisPeriod,
+			observations: []observation{
+				{at: 0, count: 3, expected: 3},
+				{at: time.Second, count: 2, expected: 2},
+				{at: 2 * time.Second, count: 3, expected: 2},
+				{at: time.Second + hysteresisPeriod, count: 2, expected: 2},
+			},
+			expectedChanges: 2,
+		},
+		"every change is applied immediately if hysteresis is disabled": {
+			hysteresisPeriod: 0,
+			observations: []observation{
+				{at: 0, count: 3, expected: 3},
+				{at: time.Second, count: 2, expected: 2},
+				{at: 2 * time.Second, count: 3, expected: 3},
+				{at: 3 * time.Second, count: 2, expected: 2},
+			},
+			expectedChanges: 4,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			changes := 0
+			counter := newHealthyInstancesCounter(testData.hysteresisPeriod)
+			counter.onChange(func() { changes++ })
+
+			for _, o := range testData.observations {
+				counter.observe(now.Add(o.at), o.count)
+				assert.Equal(t, o.expected, counter.Load(), "observed %d at %s", o.count, o.at)
+			}
+
+			assert.Equal(t, testData.expectedChanges, changes)
+		})
+	}
+}
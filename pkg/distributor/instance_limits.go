@@ -4,8 +4,13 @@ package distributor
 
 import (
 	"flag"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/util/globalerror"
@@ -15,6 +20,11 @@ const (
 	maxIngestionRateFlag             = "distributor.instance-limits.max-ingestion-rate"
 	maxInflightPushRequestsFlag      = "distributor.instance-limits.max-inflight-push-requests"
 	maxInflightPushRequestsBytesFlag = "distributor.instance-limits.max-inflight-push-requests-bytes"
+
+	// The Retry-After suggested to the clients whose write requests are rejected because of the instance limits
+	// is kept within these bounds.
+	minInstanceLimitRetryAfter = time.Second
+	maxInstanceLimitRetryAfter = time.Minute
 )
 
 var (
@@ -51,3 +61,47 @@ func (l *InstanceLimits) UnmarshalYAML(value *yaml.Node) error {
 	type plain InstanceLimits // type indirection to make sure we don't go into recursive loop
 	return value.DecodeWithOptions((*plain)(l), yaml.DecodeOptions{KnownFields: true})
 }
+
+// newInstanceLimitError returns a 503 error carrying a Retry-After header, so that remote-write clients back off
+// for the given duration before retrying the rejected write request.
+func newInstanceLimitError(err error, retryAfter time.Duration) error {
+	return httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code: http.StatusServiceUnavailable,
+		Body: []byte(err.Error()),
+		Headers: []*httpgrpc.Header{
+			{Key: "Retry-After", Values: []string{strconv.Itoa(int(retryAfter.Seconds()))}},
+		},
+	})
+}
+
+// ingestionRateRetryAfter returns how long the ingestion rate, an exponentially weighted moving average with the
+// given alpha updated every interval, takes to decay below the limit if no more samples are received.
+func ingestionRateRetryAfter(rate, limit, alpha float64, interval time.Duration) time.Duration {
+	if limit <= 0 || rate <= limit {
+		return minInstanceLimitRetryAfter
+	}
+	ticks := math.Ceil(math.Log(limit/rate) / math.Log(1-alpha))
+	return clampInstanceLimitRetryAfter(time.Duration(ticks) * interval)
+}
+
+// inflightRetryAfter scales the minimum Retry-After by how much the inflight requests, or bytes, exceed the limit:
+// the further the distributor is beyond its limit, the longer it takes to drain the inflight requests.
+func inflightRetryAfter(inflight, limit int64) time.Duration {
+	if limit <= 0 || inflight <= limit {
+		return minInstanceLimitRetryAfter
+	}
+	return clampInstanceLimitRetryAfter(time.Duration(float64(minInstanceLimitRetryAfter) * float64(inflight) / float64(limit)))
+}
+
+// clampInstanceLimitRetryAfter rounds the Retry-After up to seconds, the granularity of the header, and keeps it
+// within the bounds.
+func clampInstanceLimitRetryAfter(d time.Duration) time.Duration {
+	d = time.Duration(math.Ceil(d.Seconds())) * time.Second
+	if d < minInstanceLimitRetryAfter {
+		return minInstanceLimitRetryAfter
+	}
+	if d > maxInstanceLimitRetryAfter {
+		return maxInstanceLimitRetryAfter
+	}
+	return d
+}
@@ -5,7 +5,9 @@ package distributor
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
@@ -29,3 +31,35 @@ max_inflight_push_requests: 50
 	require.Equal(t, 50, l.MaxInflightPushRequests)
 	require.Equal(t, 1024*1024, l.MaxInflightPushRequestsBytes) // default value
 }
+
+func TestIngestionRateRetryAfter(t *testing.T) {
+	for name, tc := range map[string]struct {
+		rate, limit float64
+		expected    time.Duration
+	}{
+		"rate at the limit":        {rate: 1000, limit: 1000, expected: time.Second},
+		"rate above the limit":     {rate: 1400, limit: 1000, expected: 2 * time.Second}, // 1400 -> 1120 -> 896
+		"rate far above the limit": {rate: 10000, limit: 1000, expected: 11 * time.Second},
+		"capped":                   {rate: 1e12, limit: 1, expected: maxInstanceLimitRetryAfter},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ingestionRateRetryAfter(tc.rate, tc.limit, instanceIngestionRateAlpha, instanceIngestionRateTickInterval))
+		})
+	}
+}
+
+func TestInflightRetryAfter(t *testing.T) {
+	for name, tc := range map[string]struct {
+		inflight, limit int64
+		expected        time.Duration
+	}{
+		"at the limit":         {inflight: 100, limit: 100, expected: time.Second},
+		"just above the limit": {inflight: 101, limit: 100, expected: 2 * time.Second},
+		"3 times the limit":    {inflight: 300, limit: 100, expected: 3 * time.Second},
+		"capped":               {inflight: 1000, limit: 1, expected: maxInstanceLimitRetryAfter},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, inflightRetryAfter(tc.inflight, tc.limit))
+		})
+	}
+}
@@ -0,0 +1,810 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: limitspolicy.proto
+
+package limitspolicy
+
+import (
+	context "context"
+	encoding_binary "encoding/binary"
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetTenantLimitsRequest struct {
+	TenantId string `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+}
+
+func (m *GetTenantLimitsRequest) Reset()      { *m = GetTenantLimitsRequest{} }
+func (*GetTenantLimitsRequest) ProtoMessage() {}
+func (*GetTenantLimitsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_244d54636d427bee, []int{0}
+}
+func (m *GetTenantLimitsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetTenantLimitsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetTenantLimitsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetTenantLimitsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTenantLimitsRequest.Merge(m, src)
+}
+func (m *GetTenantLimitsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetTenantLimitsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTenantLimitsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTenantLimitsRequest proto.InternalMessageInfo
+
+func (m *GetTenantLimitsRequest) GetTenantId() string {
+	if m != nil {
+		return m.TenantId
+	}
+	return ""
+}
+
+// GetTenantLimitsResponse holds the limits to enforce for a tenant. Limits set to 0 are not
+// overridden, and the limits configured in Mimir are enforced instead.
+type GetTenantLimitsResponse struct {
+	IngestionRate      float64 `protobuf:"fixed64,1,opt,name=ingestion_rate,json=ingestionRate,proto3" json:"ingestion_rate,omitempty"`
+	IngestionBurstSize int32   `protobuf:"varint,2,opt,name=ingestion_burst_size,json=ingestionBurstSize,proto3" json:"ingestion_burst_size,omitempty"`
+	RequestRate        float64 `protobuf:"fixed64,3,opt,name=request_rate,json=requestRate,proto3" json:"request_rate,omitempty"`
+	RequestBurstSize   int32   `protobuf:"varint,4,opt,name=request_burst_size,json=requestBurstSize,proto3" json:"request_burst_size,omitempty"`
+}
+
+func (m *GetTenantLimitsResponse) Reset()      { *m = GetTenantLimitsResponse{} }
+func (*GetTenantLimitsResponse) ProtoMessage() {}
+func (*GetTenantLimitsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_244d54636d427bee, []int{1}
+}
+func (m *GetTenantLimitsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetTenantLimitsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetTenantLimitsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetTenantLimitsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTenantLimitsResponse.Merge(m, src)
+}
+func (m *GetTenantLimitsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetTenantLimitsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTenantLimitsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTenantLimitsResponse proto.InternalMessageInfo
+
+func (m *GetTenantLimitsResponse) GetIngestionRate() float64 {
+	if m != nil {
+		return m.IngestionRate
+	}
+	return 0
+}
+
+func (m *GetTenantLimitsResponse) GetIngestionBurstSize() int32 {
+	if m != nil {
+		return m.IngestionBurstSize
+	}
+	return 0
+}
+
+func (m *GetTenantLimitsResponse) GetRequestRate() float64 {
+	if m != nil {
+		return m.RequestRate
+	}
+	return 0
+}
+
+func (m *GetTenantLimitsResponse) GetRequestBurstSize() int32 {
+	if m != nil {
+		return m.RequestBurstSize
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*GetTenantLimitsRequest)(nil), "limitspolicy.GetTenantLimitsRequest")
+	proto.RegisterType((*GetTenantLimitsResponse)(nil), "limitspolicy.GetTenantLimitsResponse")
+}
+
+func init() { proto.RegisterFile("limitspolicy.proto", fileDescriptor_244d54636d427bee) }
+
+var fileDescriptor_244d54636d427bee = []byte{
+	// 329 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xbf, 0x4e, 0x02, 0x41,
+	0x10, 0xc6, 0x77, 0xfc, 0x17, 0x59, 0xf1, 0x4f, 0x36, 0x46, 0x09, 0x26, 0x13, 0x24, 0x92, 0x50,
+	0x28, 0x18, 0x8d, 0x2f, 0x40, 0x63, 0x4c, 0x2c, 0xcc, 0x69, 0x65, 0x83, 0x1c, 0xac, 0xe7, 0x26,
+	0x70, 0x7b, 0xde, 0xee, 0x15, 0x52, 0xf9, 0x08, 0x3e, 0x86, 0xef, 0x61, 0x63, 0x49, 0x49, 0x29,
+	0x4b, 0x63, 0xc9, 0x23, 0x18, 0xe7, 0x10, 0x41, 0x4d, 0xec, 0x76, 0xbe, 0xf9, 0xe6, 0x97, 0xd9,
+	0x6f, 0xb8, 0x68, 0xab, 0x8e, 0xb2, 0x26, 0xd2, 0x6d, 0xd5, 0x7c, 0xa8, 0x44, 0xb1, 0xb6, 0x5a,
+	0x64, 0xa7, 0xb5, 0xfc, 0x41, 0xa0, 0xec, 0x5d, 0xe2, 0x57, 0x9a, 0xba, 0x53, 0x0d, 0x74, 0xa0,
+	0xab, 0x64, 0xf2, 0x93, 0x5b, 0xaa, 0xa8, 0xa0, 0x57, 0x3a, 0x5c, 0x3c, 0xe1, 0x5b, 0xa7, 0xd2,
+	0x5e, 0xc9, 0xb0, 0x11, 0xda, 0x73, 0xe2, 0x78, 0xf2, 0x3e, 0x91, 0xc6, 0x8a, 0x1d, 0x9e, 0xb1,
+	0x24, 0xd7, 0x55, 0x2b, 0x07, 0x05, 0x28, 0x67, 0xbc, 0xe5, 0x54, 0x38, 0x6b, 0x15, 0x5f, 0x80,
+	0x6f, 0xff, 0x9a, 0x33, 0x91, 0x0e, 0x8d, 0x14, 0x25, 0xbe, 0xa6, 0xc2, 0x40, 0x1a, 0xab, 0x74,
+	0x58, 0x8f, 0x1b, 0x56, 0xd2, 0x34, 0x78, 0xab, 0x13, 0xd5, 0x6b, 0x58, 0x29, 0x0e, 0xf9, 0xe6,
+	0xb7, 0xcd, 0x4f, 0x62, 0x63, 0xeb, 0x46, 0x75, 0x65, 0x6e, 0xae, 0x00, 0xe5, 0x45, 0x4f, 0x4c,
+	0x7a, 0xb5, 0xcf, 0xd6, 0xa5, 0xea, 0x4a, 0xb1, 0xcb, 0xb3, 0x71, 0xba, 0x5c, 0x8a, 0x9d, 0x27,
+	0xec, 0xca, 0x58, 0x23, 0xe8, 0x3e, 0x17, 0x5f, 0x96, 0x29, 0xe4, 0x02, 0x21, 0x37, 0xc6, 0x9d,
+	0x09, 0xf0, 0x28, 0xe2, 0xd9, 0x74, 0xf7, 0x0b, 0xca, 0x4e, 0xdc, 0xf0, 0xf5, 0x1f, 0x9f, 0x12,
+	0x7b, 0x95, 0x99, 0xc4, 0xff, 0xce, 0x2a, 0x5f, 0xfa, 0xc7, 0x95, 0x26, 0x53, 0x64, 0xb5, 0x5a,
+	0x6f, 0x80, 0xac, 0x3f, 0x40, 0x36, 0x1a, 0x20, 0x3c, 0x3a, 0x84, 0x67, 0x87, 0xf0, 0xea, 0x10,
+	0x7a, 0x0e, 0xe1, 0xcd, 0x21, 0xbc, 0x3b, 0x64, 0x23, 0x87, 0xf0, 0x34, 0x44, 0xd6, 0x1b, 0x22,
+	0xeb, 0x0f, 0x91, 0x5d, 0xcf, 0x5c, 0xd8, 0x5f, 0xa2, 0xcb, 0x1d, 0x7f, 0x04, 0x00, 0x00, 0xff,
+	0xff, 0xd6, 0x77, 0x8e, 0x69, 0x0c, 0x02, 0x00, 0x00,
+}
+
+func (this *GetTenantLimitsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*GetTenantLimitsRequest)
+	if !ok {
+		that2, ok := that.(GetTenantLimitsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TenantId != that1.TenantId {
+		return false
+	}
+	return true
+}
+func (this *GetTenantLimitsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*GetTenantLimitsResponse)
+	if !ok {
+		that2, ok := that.(GetTenantLimitsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.IngestionRate != that1.IngestionRate {
+		return false
+	}
+	if this.IngestionBurstSize != that1.IngestionBurstSize {
+		return false
+	}
+	if this.RequestRate != that1.RequestRate {
+		return false
+	}
+	if this.RequestBurstSize != that1.RequestBurstSize {
+		return false
+	}
+	return true
+}
+func (this *GetTenantLimitsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&limitspolicy.GetTenantLimitsRequest{")
+	s = append(s, "TenantId: "+fmt.Sprintf("%#v", this.TenantId)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *GetTenantLimitsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&limitspolicy.GetTenantLimitsResponse{")
+	s = append(s, "IngestionRate: "+fmt.Sprintf("%#v", this.IngestionRate)+",\n")
+	s = append(s, "IngestionBurstSize: "+fmt.Sprintf("%#v", this.IngestionBurstSize)+",\n")
+	s = append(s, "RequestRate: "+fmt.Sprintf("%#v", this.RequestRate)+",\n")
+	s = append(s, "RequestBurstSize: "+fmt.Sprintf("%#v", this.RequestBurstSize)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringLimitspolicy(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// LimitsPolicyClient is the client API for LimitsPolicy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type LimitsPolicyClient interface {
+	GetTenantLimits(ctx context.Context, in *GetTenantLimitsRequest, opts ...grpc.CallOption) (*GetTenantLimitsResponse, error)
+}
+
+type limitsPolicyClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewLimitsPolicyClient(cc *grpc.ClientConn) LimitsPolicyClient {
+	return &limitsPolicyClient{cc}
+}
+
+func (c *limitsPolicyClient) GetTenantLimits(ctx context.Context, in *GetTenantLimitsRequest, opts ...grpc.CallOption) (*GetTenantLimitsResponse, error) {
+	out := new(GetTenantLimitsResponse)
+	err := c.cc.Invoke(ctx, "/limitspolicy.LimitsPolicy/GetTenantLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LimitsPolicyServer is the server API for LimitsPolicy service.
+type LimitsPolicyServer interface {
+	GetTenantLimits(context.Context, *GetTenantLimitsRequest) (*GetTenantLimitsResponse, error)
+}
+
+// UnimplementedLimitsPolicyServer can be embedded to have forward compatible implementations.
+type UnimplementedLimitsPolicyServer struct {
+}
+
+func (*UnimplementedLimitsPolicyServer) GetTenantLimits(ctx context.Context, req *GetTenantLimitsRequest) (*GetTenantLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTenantLimits not implemented")
+}
+
+func RegisterLimitsPolicyServer(s *grpc.Server, srv LimitsPolicyServer) {
+	s.RegisterService(&_LimitsPolicy_serviceDesc, srv)
+}
+
+func _LimitsPolicy_GetTenantLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTenantLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LimitsPolicyServer).GetTenantLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/limitspolicy.LimitsPolicy/GetTenantLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LimitsPolicyServer).GetTenantLimits(ctx, req.(*GetTenantLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _LimitsPolicy_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "limitspolicy.LimitsPolicy",
+	HandlerType: (*LimitsPolicyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTenantLimits",
+			Handler:    _LimitsPolicy_GetTenantLimits_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "limitspolicy.proto",
+}
+
+func (m *GetTenantLimitsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetTenantLimitsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetTenantLimitsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.TenantId) > 0 {
+		i -= len(m.TenantId)
+		copy(dAtA[i:], m.TenantId)
+		i = encodeVarintLimitspolicy(dAtA, i, uint64(len(m.TenantId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetTenantLimitsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetTenantLimitsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetTenantLimitsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.RequestBurstSize != 0 {
+		i = encodeVarintLimitspolicy(dAtA, i, uint64(m.RequestBurstSize))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.RequestRate != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.RequestRate))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.IngestionBurstSize != 0 {
+		i = encodeVarintLimitspolicy(dAtA, i, uint64(m.IngestionBurstSize))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.IngestionRate != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.IngestionRate))))
+		i--
+		dAtA[i] = 0x9
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintLimitspolicy(dAtA []byte, offset int, v uint64) int {
+	offset -= sovLimitspolicy(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *GetTenantLimitsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.TenantId)
+	if l > 0 {
+		n += 1 + l + sovLimitspolicy(uint64(l))
+	}
+	return n
+}
+
+func (m *GetTenantLimitsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.IngestionRate != 0 {
+		n += 9
+	}
+	if m.IngestionBurstSize != 0 {
+		n += 1 + sovLimitspolicy(uint64(m.IngestionBurstSize))
+	}
+	if m.RequestRate != 0 {
+		n += 9
+	}
+	if m.RequestBurstSize != 0 {
+		n += 1 + sovLimitspolicy(uint64(m.RequestBurstSize))
+	}
+	return n
+}
+
+func sovLimitspolicy(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozLimitspolicy(x uint64) (n int) {
+	return sovLimitspolicy(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *GetTenantLimitsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&GetTenantLimitsRequest{`,
+		`TenantId:` + fmt.Sprintf("%v", this.TenantId) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *GetTenantLimitsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&GetTenantLimitsResponse{`,
+		`IngestionRate:` + fmt.Sprintf("%v", this.IngestionRate) + `,`,
+		`IngestionBurstSize:` + fmt.Sprintf("%v", this.IngestionBurstSize) + `,`,
+		`RequestRate:` + fmt.Sprintf("%v", this.RequestRate) + `,`,
+		`RequestBurstSize:` + fmt.Sprintf("%v", this.RequestBurstSize) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringLimitspolicy(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *GetTenantLimitsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLimitspolicy
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetTenantLimitsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetTenantLimitsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TenantId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLimitspolicy
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TenantId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLimitspolicy(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetTenantLimitsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLimitspolicy
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetTenantLimitsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetTenantLimitsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IngestionRate", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.IngestionRate = float64(math.Float64frombits(v))
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IngestionBurstSize", wireType)
+			}
+			m.IngestionBurstSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLimitspolicy
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.IngestionBurstSize |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestRate", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.RequestRate = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestBurstSize", wireType)
+			}
+			m.RequestBurstSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLimitspolicy
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RequestBurstSize |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLimitspolicy(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLimitspolicy
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipLimitspolicy(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowLimitspolicy
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowLimitspolicy
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowLimitspolicy
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthLimitspolicy
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthLimitspolicy
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowLimitspolicy
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipLimitspolicy(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthLimitspolicy
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthLimitspolicy = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowLimitspolicy   = fmt.Errorf("proto: integer overflow")
+)
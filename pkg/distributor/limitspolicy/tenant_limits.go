@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limitspolicy
+
+import (
+	"context"
+	"flag"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/services"
+	otgrpc "github.com/opentracing-contrib/go-grpc"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// Maximum number of tenants whose limits are waiting to be fetched for the first time.
+	maxPendingFetches = 1024
+
+	fetchConcurrency = 8
+)
+
+// Config holds the configuration of the client for the external limits policy service.
+type Config struct {
+	Address          string            `yaml:"address" category:"experimental"`
+	Timeout          time.Duration     `yaml:"timeout" category:"experimental"`
+	RefreshInterval  time.Duration     `yaml:"refresh_interval" category:"experimental"`
+	CacheTTL         time.Duration     `yaml:"cache_ttl" category:"experimental"`
+	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate with the limits policy service."`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Address, "distributor.limits-policy.address", "", "gRPC address of an external limits policy service, consulted to dynamically override the per-tenant ingestion and request rate limits. When empty, the limits policy service is disabled.")
+	f.DurationVar(&cfg.Timeout, "distributor.limits-policy.timeout", time.Second, "Timeout of requests to the limits policy service.")
+	f.DurationVar(&cfg.RefreshInterval, "distributor.limits-policy.refresh-interval", time.Minute, "How frequently the limits of each tenant are refreshed from the limits policy service.")
+	f.DurationVar(&cfg.CacheTTL, "distributor.limits-policy.cache-ttl", 10*time.Minute, "How long the limits received from the limits policy service are used after they could not be refreshed. Once expired, the limits configured in Mimir are enforced again. Tenants with no push requests for longer than this period are removed from the cache.")
+
+	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("distributor.limits-policy.grpc-client-config", f)
+}
+
+func (cfg *Config) Enabled() bool {
+	return cfg.Address != ""
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("the limits policy refresh interval must be greater than 0")
+	}
+	if cfg.CacheTTL < cfg.RefreshInterval {
+		return errors.New("the limits policy cache TTL must be greater than or equal to the refresh interval")
+	}
+	return cfg.GRPCClientConfig.Validate()
+}
+
+type tenantEntry struct {
+	// Last time the tenant's limits have been looked up, in unix nanoseconds.
+	lastAccess atomic.Int64
+
+	// The following fields are protected by TenantLimits.mtx.
+	policy    *GetTenantLimitsResponse
+	fetchedAt time.Time
+	base      *validation.Limits
+	merged    *validation.Limits
+}
+
+// TenantLimits implements validation.TenantLimits, overriding the limits returned by the wrapped
+// TenantLimits with the ones received from the external limits policy service.
+//
+// Limits are fetched asynchronously and cached, so that looking them up never blocks on the limits
+// policy service. The policy fails open: until a tenant's limits have been received, or once they
+// couldn't be refreshed for longer than the cache TTL, the wrapped limits are returned.
+type TenantLimits struct {
+	services.Service
+
+	cfg      Config
+	client   LimitsPolicyClient
+	defaults *validation.Limits
+	next     validation.TenantLimits
+	logger   log.Logger
+
+	conn    io.Closer
+	pending chan string
+
+	mtx     sync.RWMutex
+	tenants map[string]*tenantEntry
+
+	requests *prometheus.CounterVec
+}
+
+// NewTenantLimits dials the limits policy service and returns TenantLimits wrapping next. The input
+// defaults are the limits used for tenants with no overrides in next.
+func NewTenantLimits(cfg Config, defaults *validation.Limits, next validation.TenantLimits, logger log.Logger, reg prometheus.Registerer) (*TenantLimits, error) {
+	opts, err := cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
+		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(cfg.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial the limits policy service")
+	}
+
+	l := newTenantLimits(cfg, NewLimitsPolicyClient(conn), defaults, next, logger, reg)
+	l.conn = conn
+	return l, nil
+}
+
+func newTenantLimits(cfg Config, client LimitsPolicyClient, defaults *validation.Limits, next validation.TenantLimits, logger log.Logger, reg prometheus.Registerer) *TenantLimits {
+	l := &TenantLimits{
+		cfg:      cfg,
+		client:   client,
+		defaults: defaults,
+		next:     next,
+		logger:   logger,
+		pending:  make(chan string, maxPendingFetches),
+		tenants:  map[string]*tenantEntry{},
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_limits_policy_requests_total",
+			Help: "Total number of requests to the limits policy service.",
+		}, []string{"outcome"}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_distributor_limits_policy_cached_tenants",
+		Help: "Number of tenants whose limits received from the limits policy service are currently enforced.",
+	}, func() float64 {
+		l.mtx.RLock()
+		defer l.mtx.RUnlock()
+
+		count := 0
+		for _, entry := range l.tenants {
+			if entry.policy != nil {
+				count++
+			}
+		}
+		return float64(count)
+	})
+
+	// Initialise the metrics.
+	l.requests.WithLabelValues("success")
+	l.requests.WithLabelValues("failure")
+
+	l.Service = services.NewTimerService(cfg.RefreshInterval, l.starting, l.refresh, l.stopping)
+	return l
+}
+
+func (l *TenantLimits) starting(ctx context.Context) error {
+	// Fetch the limits of tenants seen for the first time as soon as possible,
+	// instead of waiting for the next refresh.
+	for i := 0; i < fetchConcurrency; i++ {
+		go l.fetchPending(ctx)
+	}
+	return nil
+}
+
+func (l *TenantLimits) stopping(_ error) error {
+	if l.conn != nil {
+		return l.conn.Close()
+	}
+	return nil
+}
+
+func (l *TenantLimits) fetchPending(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tenantID := <-l.pending:
+			l.fetch(ctx, tenantID, time.Now())
+		}
+	}
+}
+
+// refresh fetches the limits of all tenants looked up recently, and removes the idle ones.
+func (l *TenantLimits) refresh(ctx context.Context) error {
+	now := time.Now()
+	idleDeadline := now.Add(-l.cfg.CacheTTL).UnixNano()
+
+	l.mtx.Lock()
+	tenantIDs := make([]string, 0, len(l.tenants))
+	for tenantID, entry := range l.tenants {
+		if entry.lastAccess.Load() < idleDeadline {
+			delete(l.tenants, tenantID)
+			continue
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	l.mtx.Unlock()
+
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		l.fetch(ctx, tenantID, now)
+	}
+	return nil
+}
+
+func (l *TenantLimits) fetch(ctx context.Context, tenantID string, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, l.cfg.Timeout)
+	defer cancel()
+
+	resp, err := l.client.GetTenantLimits(ctx, &GetTenantLimitsRequest{TenantId: tenantID})
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	entry, ok := l.tenants[tenantID]
+	if !ok {
+		return
+	}
+
+	if err != nil {
+		l.requests.WithLabelValues("failure").Inc()
+		level.Warn(l.logger).Log("msg", "failed to fetch tenant limits from the limits policy service", "user", tenantID, "err", err)
+
+		// Fail open: stop enforcing the limits received from the limits policy service
+		// once they couldn't be refreshed for too long.
+		if entry.policy != nil && now.Sub(entry.fetchedAt) > l.cfg.CacheTTL {
+			entry.policy = nil
+			entry.merged = nil
+		}
+		return
+	}
+
+	l.requests.WithLabelValues("success").Inc()
+	entry.policy = resp
+	entry.fetchedAt = now
+	entry.merged = nil
+}
+
+// ByUserID implements validation.TenantLimits.
+func (l *TenantLimits) ByUserID(userID string) *validation.Limits {
+	var base *validation.Limits
+	if l.next != nil {
+		base = l.next.ByUserID(userID)
+	}
+
+	l.mtx.RLock()
+	entry, ok := l.tenants[userID]
+	if ok {
+		entry.lastAccess.Store(time.Now().UnixNano())
+		if entry.policy == nil {
+			l.mtx.RUnlock()
+			return base
+		}
+		if entry.merged != nil && entry.base == base {
+			merged := entry.merged
+			l.mtx.RUnlock()
+			return merged
+		}
+	}
+	l.mtx.RUnlock()
+
+	if !ok {
+		l.addTenant(userID)
+		return base
+	}
+
+	return l.merge(userID, base)
+}
+
+// AllByUserID implements validation.TenantLimits.
+func (l *TenantLimits) AllByUserID() map[string]*validation.Limits {
+	if l.next == nil {
+		return nil
+	}
+	return l.next.AllByUserID()
+}
+
+func (l *TenantLimits) addTenant(userID string) {
+	l.mtx.Lock()
+	if _, ok := l.tenants[userID]; ok {
+		l.mtx.Unlock()
+		return
+	}
+
+	entry := &tenantEntry{}
+	entry.lastAccess.Store(time.Now().UnixNano())
+	l.tenants[userID] = entry
+	l.mtx.Unlock()
+
+	select {
+	case l.pending <- userID:
+	default:
+		// The limits will be fetched at the next refresh.
+	}
+}
+
+// merge returns the base limits overridden by the limits received from the limits policy service,
+// and caches them until either the base limits or the limits policy change.
+func (l *TenantLimits) merge(userID string, base *validation.Limits) *validation.Limits {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	entry, ok := l.tenants[userID]
+	if !ok || entry.policy == nil {
+		return base
+	}
+
+	merged := l.defaults
+	if base != nil {
+		merged = base
+	}
+	copied := *merged
+
+	if entry.policy.IngestionRate > 0 {
+		copied.IngestionRate = entry.policy.IngestionRate
+	}
+	if entry.policy.IngestionBurstSize > 0 {
+		copied.IngestionBurstSize = int(entry.policy.IngestionBurstSize)
+	}
+	if entry.policy.RequestRate > 0 {
+		copied.RequestRate = entry.policy.RequestRate
+	}
+	if entry.policy.RequestBurstSize > 0 {
+		copied.RequestBurstSize = int(entry.policy.RequestBurstSize)
+	}
+
+	entry.base = base
+	entry.merged = &copied
+	return entry.merged
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limitspolicy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type mockLimitsPolicyClient struct {
+	mtx    sync.Mutex
+	limits map[string]*GetTenantLimitsResponse
+	err    error
+}
+
+func (c *mockLimitsPolicyClient) GetTenantLimits(_ context.Context, req *GetTenantLimitsRequest, _ ...grpc.CallOption) (*GetTenantLimitsResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if resp, ok := c.limits[req.TenantId]; ok {
+		return resp, nil
+	}
+	return &GetTenantLimitsResponse{}, nil
+}
+
+func (c *mockLimitsPolicyClient) setError(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.err = err
+}
+
+func TestTenantLimits(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.RefreshInterval = time.Hour
+
+	defaults := validation.MockDefaultLimits()
+	defaults.IngestionRate = 100
+	defaults.IngestionBurstSize = 1000
+
+	overridden := validation.MockDefaultLimits()
+	overridden.IngestionRate = 200
+	overridden.IngestionBurstSize = 2000
+	next := validation.NewMockTenantLimits(map[string]*validation.Limits{"user-2": overridden})
+
+	client := &mockLimitsPolicyClient{limits: map[string]*GetTenantLimitsResponse{
+		"user-1": {IngestionRate: 10, RequestRate: 5},
+		"user-2": {IngestionBurstSize: 20},
+	}}
+
+	l := newTenantLimits(cfg, client, defaults, next, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), l))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), l))
+	})
+
+	overrides, err := validation.NewOverrides(*defaults, l)
+	require.NoError(t, err)
+
+	// The limits configured in Mimir are enforced until the limits policy has been received.
+	assert.Equal(t, float64(100), overrides.IngestionRate("user-1"))
+	assert.Equal(t, float64(200), overrides.IngestionRate("user-2"))
+	assert.Equal(t, float64(100), overrides.IngestionRate("user-3"))
+
+	test.Poll(t, time.Second, float64(10), func() interface{} {
+		return overrides.IngestionRate("user-1")
+	})
+	assert.Equal(t, 1000, overrides.IngestionBurstSize("user-1"))
+	assert.Equal(t, float64(5), overrides.RequestRate("user-1"))
+
+	test.Poll(t, time.Second, 20, func() interface{} {
+		return overrides.IngestionBurstSize("user-2")
+	})
+	assert.Equal(t, float64(200), overrides.IngestionRate("user-2"))
+
+	// Limits not overridden by the limits policy are left untouched.
+	assert.Equal(t, float64(100), overrides.IngestionRate("user-3"))
+
+	// The merged limits should be cached.
+	assert.Same(t, l.ByUserID("user-1"), l.ByUserID("user-1"))
+
+	// Limits received from the limits policy are enforced as long as they don't expire...
+	client.setError(errors.New("unavailable"))
+	require.NoError(t, l.refresh(context.Background()))
+	assert.Equal(t, float64(10), overrides.IngestionRate("user-1"))
+
+	// ...and then the limits configured in Mimir are enforced again.
+	l.fetch(context.Background(), "user-1", time.Now().Add(cfg.CacheTTL+time.Minute))
+	assert.Equal(t, float64(100), overrides.IngestionRate("user-1"))
+	assert.Equal(t, 20, overrides.IngestionBurstSize("user-2"))
+}
+
+func TestTenantLimits_RemovesIdleTenants(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+
+	client := &mockLimitsPolicyClient{limits: map[string]*GetTenantLimitsResponse{
+		"user-1": {IngestionRate: 10},
+	}}
+	l := newTenantLimits(cfg, client, validation.MockDefaultLimits(), nil, log.NewNopLogger(), nil)
+
+	assert.Nil(t, l.ByUserID("user-1"))
+	require.NoError(t, l.refresh(context.Background()))
+	require.NotNil(t, l.ByUserID("user-1"))
+	assert.Equal(t, float64(10), l.ByUserID("user-1").IngestionRate)
+
+	l.mtx.RLock()
+	l.tenants["user-1"].lastAccess.Store(time.Now().Add(-cfg.CacheTTL - time.Minute).UnixNano())
+	l.mtx.RUnlock()
+
+	require.NoError(t, l.refresh(context.Background()))
+
+	l.mtx.RLock()
+	assert.Empty(t, l.tenants)
+	l.mtx.RUnlock()
+}
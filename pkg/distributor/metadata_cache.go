@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/tenant"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+// MetadataCacheConfig configures the cache of the metadata recently forwarded to the ingesters, used to drop
+// the unchanged metadata received again before the TTL expires.
+type MetadataCacheConfig struct {
+	Enabled             bool          `yaml:"enabled" category:"experimental"`
+	TTL                 time.Duration `yaml:"ttl" category:"experimental"`
+	MaxEntriesPerTenant int           `yaml:"max_entries_per_tenant" category:"experimental"`
+}
+
+func (cfg *MetadataCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.metadata-cache.enabled", false, "True to drop the metadata received again unchanged, with the same metric family name, help, type and unit, within the TTL since it has been forwarded to the ingesters.")
+	f.DurationVar(&cfg.TTL, "distributor.metadata-cache.ttl", 2*time.Minute, "How long the unchanged metadata is dropped after it has been forwarded to the ingesters. Must be lower than -ingester.metadata-retain-period, so that the metadata is forwarded again before the ingesters delete it.")
+	f.IntVar(&cfg.MaxEntriesPerTenant, "distributor.metadata-cache.max-entries-per-tenant", 10000, "Maximum number of metadata cached for each tenant. When the limit is reached, the least recently forwarded metadata is evicted.")
+}
+
+func (cfg *MetadataCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		return fmt.Errorf("the metadata cache TTL must be greater than 0")
+	}
+	if cfg.MaxEntriesPerTenant <= 0 {
+		return fmt.Errorf("the metadata cache max entries per tenant must be greater than 0")
+	}
+	return nil
+}
+
+// metadataCache tracks, for each tenant, when the metadata has been last forwarded to the ingesters.
+// The metadata are identified by the hash of their metric family name, help, type and unit.
+type metadataCache struct {
+	cfg MetadataCacheConfig
+
+	mtx     sync.RWMutex
+	tenants map[string]*metadataCacheTenant
+
+	// activeIngesters are the addresses of the ingesters ACTIVE in the ring when last checked. It's only accessed by
+	// the distributor's running loop.
+	activeIngesters map[string]struct{}
+
+	droppedMetadata *prometheus.CounterVec
+	droppedBytes    *prometheus.CounterVec
+	purges          prometheus.Counter
+}
+
+// metadataCacheTenant is the cache of the metadata forwarded for a tenant, locked once for each write request.
+type metadataCacheTenant struct {
+	mtx     sync.Mutex
+	entries *lru.LRU
+}
+
+func newMetadataCache(cfg MetadataCacheConfig, reg prometheus.Registerer) *metadataCache {
+	return &metadataCache{
+		cfg:     cfg,
+		tenants: map[string]*metadataCacheTenant{},
+		droppedMetadata: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_metadata_cache_dropped_metadata_total",
+			Help: "The total number of metadata dropped because forwarded unchanged to the ingesters within the metadata cache TTL.",
+		}, []string{"user"}),
+		droppedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_metadata_cache_dropped_bytes_total",
+			Help: "The total size, in bytes, of the metric family name, help and unit of the metadata dropped because forwarded unchanged to the ingesters within the metadata cache TTL.",
+		}, []string{"user"}),
+		purges: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_metadata_cache_purges_total",
+			Help: "The total number of times the metadata cache has been purged because an ingester became ACTIVE, for example after a restart.",
+		}),
+	}
+}
+
+// tenant returns the cache of the tenant, creating it if missing.
+func (c *metadataCache) tenant(userID string) *metadataCacheTenant {
+	c.mtx.RLock()
+	t, ok := c.tenants[userID]
+	c.mtx.RUnlock()
+	if ok {
+		return t
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if t, ok = c.tenants[userID]; !ok {
+		// The error is only returned for a non-positive size, which is prevented by the config validation.
+		entries, _ := lru.NewLRU(c.cfg.MaxEntriesPerTenant, nil)
+		t = &metadataCacheTenant{entries: entries}
+		c.tenants[userID] = t
+	}
+	return t
+}
+
+// freshIndexes returns the indexes of the hashes of the metadata forwarded to the ingesters within the TTL.
+func (t *metadataCacheTenant) freshIndexes(hashes []uint64, ttl time.Duration, now time.Time) []int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var indexes []int
+	for i, hash := range hashes {
+		// The entry isn't refreshed, so that the metadata is forwarded again once the TTL expires.
+		if forwardedAt, ok := t.entries.Peek(hash); ok && now.Sub(forwardedAt.(time.Time)) < ttl {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// forwarded records the metadata with the hashes as forwarded to the ingesters at the given time.
+func (t *metadataCacheTenant) forwarded(hashes []uint64, now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, hash := range hashes {
+		t.entries.Add(hash, now)
+	}
+}
+
+// checkIngesters purges the cache when an ingester which wasn't ACTIVE at the previous check is ACTIVE, because
+// the ingesters don't keep the metadata across restarts, so that the metadata is forwarded again to it.
+func (c *metadataCache) checkIngesters(instances []ring.InstanceDesc) {
+	active := make(map[string]struct{}, len(instances))
+	for _, instance := range instances {
+		if instance.State == ring.ACTIVE {
+			active[instance.Addr] = struct{}{}
+		}
+	}
+
+	previous := c.activeIngesters
+	c.activeIngesters = active
+	if previous == nil {
+		return
+	}
+
+	for id := range active {
+		if _, ok := previous[id]; !ok {
+			c.purge()
+			return
+		}
+	}
+}
+
+// purge removes the metadata of all the tenants. The write requests in progress record their metadata in the
+// caches removed, so that it's forwarded again.
+func (c *metadataCache) purge() {
+	c.mtx.Lock()
+	c.tenants = map[string]*metadataCacheTenant{}
+	c.mtx.Unlock()
+
+	c.purges.Inc()
+}
+
+var metadataCacheHashSeparator = []byte{0xff}
+
+// metadataHash returns the hash identifying the metadata.
+func metadataHash(m *mimirpb.MetricMetadata) uint64 {
+	h := xxhash.New()
+	_, _ = h.WriteString(m.MetricFamilyName)
+	_, _ = h.Write(metadataCacheHashSeparator)
+	_, _ = h.WriteString(m.Help)
+	_, _ = h.Write(metadataCacheHashSeparator)
+	_, _ = h.WriteString(m.Type.String())
+	_, _ = h.Write(metadataCacheHashSeparator)
+	_, _ = h.WriteString(m.Unit)
+	return h.Sum64()
+}
+
+func (c *metadataCache) cleanupUser(userID string) {
+	c.mtx.Lock()
+	delete(c.tenants, userID)
+	c.mtx.Unlock()
+
+	c.droppedMetadata.DeleteLabelValues(userID)
+	c.droppedBytes.DeleteLabelValues(userID)
+}
+
+// checkMetadataCacheIngesters purges the metadata cache when an ingester becomes ACTIVE in the ingesters ring.
+func (d *Distributor) checkMetadataCacheIngesters() {
+	instances, err := d.ingestersRing.GetAllHealthy(ring.Reporting)
+	if err != nil {
+		level.Debug(d.log).Log("msg", "failed to get the ingesters to check for the metadata cache", "err", err)
+		return
+	}
+	d.metadataCache.checkIngesters(instances.Instances)
+}
+
+// prePushMetadataCacheMiddleware drops the metadata forwarded unchanged to the ingesters within the metadata
+// cache TTL, and records the metadata forwarded once the write request has been successfully pushed.
+func (d *Distributor) prePushMetadataCacheMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			pushReq.CleanUp()
+			return nil, err
+		}
+
+		if len(req.Metadata) == 0 {
+			return next(ctx, pushReq)
+		}
+
+		now := time.Now()
+		// The hashes are computed before pushing, because the metadata may reference buffers reused once pushed.
+		hashes := make([]uint64, 0, len(req.Metadata))
+		for _, m := range req.Metadata {
+			hashes = append(hashes, metadataHash(m))
+		}
+
+		cache := d.metadataCache.tenant(userID)
+		if removeIndexes := cache.freshIndexes(hashes, d.metadataCache.cfg.TTL, now); len(removeIndexes) > 0 {
+			droppedBytes := 0
+			for _, mIdx := range removeIndexes {
+				m := req.Metadata[mIdx]
+				droppedBytes += len(m.MetricFamilyName) + len(m.Help) + len(m.Unit)
+			}
+
+			req.Metadata = util.RemoveSliceIndexes(req.Metadata, removeIndexes)
+			hashes = util.RemoveSliceIndexes(hashes, removeIndexes)
+			d.metadataCache.droppedMetadata.WithLabelValues(userID).Add(float64(len(removeIndexes)))
+			d.metadataCache.droppedBytes.WithLabelValues(userID).Add(float64(droppedBytes))
+		}
+
+		resp, err := next(ctx, pushReq)
+
+		// Only the metadata successfully pushed is cached, so that it's forwarded again if the push failed.
+		if err == nil && len(hashes) > 0 {
+			cache.forwarded(hashes, now)
+		}
+		return resp, err
+	}
+}
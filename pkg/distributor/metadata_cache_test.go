@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestMetadataCacheConfig_Validate(t *testing.T) {
+	valid := MetadataCacheConfig{Enabled: true, TTL: time.Minute, MaxEntriesPerTenant: 10}
+	require.NoError(t, valid.Validate())
+	require.NoError(t, (&MetadataCacheConfig{}).Validate())
+
+	for name, modify := range map[string]func(*MetadataCacheConfig){
+		"zero TTL":                    func(cfg *MetadataCacheConfig) { cfg.TTL = 0 },
+		"zero max entries per tenant": func(cfg *MetadataCacheConfig) { cfg.MaxEntriesPerTenant = 0 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := valid
+			modify(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestMetadataHash(t *testing.T) {
+	m := mimirpb.MetricMetadata{MetricFamilyName: "metric", Help: "help", Type: mimirpb.COUNTER, Unit: "seconds"}
+	hash := metadataHash(&m)
+
+	for name, modify := range map[string]func(*mimirpb.MetricMetadata){
+		"metric family name": func(m *mimirpb.MetricMetadata) { m.MetricFamilyName = "other" },
+		"help":               func(m *mimirpb.MetricMetadata) { m.Help = "other" },
+		"type":               func(m *mimirpb.MetricMetadata) { m.Type = mimirpb.GAUGE },
+		"unit":               func(m *mimirpb.MetricMetadata) { m.Unit = "bytes" },
+		"boundaries":         func(m *mimirpb.MetricMetadata) { m.MetricFamilyName, m.Help = "metrichelp", "" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			changed := m
+			modify(&changed)
+			assert.NotEqual(t, hash, metadataHash(&changed))
+		})
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	c := newMetadataCache(MetadataCacheConfig{Enabled: true, TTL: time.Minute, MaxEntriesPerTenant: 2}, nil)
+	now := time.Now()
+
+	assert.Empty(t, c.tenant("user").freshIndexes([]uint64{1}, time.Minute, now))
+
+	c.tenant("user").forwarded([]uint64{1, 2}, now)
+	assert.Equal(t, []int{0, 2}, c.tenant("user").freshIndexes([]uint64{1, 3, 2}, time.Minute, now.Add(30*time.Second)))
+	assert.Empty(t, c.tenant("user-2").freshIndexes([]uint64{1}, time.Minute, now))
+
+	// The entries expire after the TTL.
+	assert.Empty(t, c.tenant("user").freshIndexes([]uint64{1}, time.Minute, now.Add(time.Minute)))
+
+	// The least recently forwarded entry is evicted once the tenant's entries are full.
+	c.tenant("user").forwarded([]uint64{3}, now)
+	assert.Equal(t, []int{1, 2}, c.tenant("user").freshIndexes([]uint64{1, 2, 3}, time.Minute, now))
+
+	c.cleanupUser("user")
+	assert.Empty(t, c.tenant("user").freshIndexes([]uint64{2}, time.Minute, now))
+}
+
+func TestMetadataCache_ShouldPurgeOnceAnIngesterBecomesActive(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	c := newMetadataCache(MetadataCacheConfig{Enabled: true, TTL: time.Minute, MaxEntriesPerTenant: 10}, reg)
+	now := time.Now()
+	isCached := func() bool {
+		return len(c.tenant("user").freshIndexes([]uint64{1}, time.Minute, now)) > 0
+	}
+
+	c.checkIngesters([]ring.InstanceDesc{{Addr: "ingester-1", State: ring.ACTIVE}, {Addr: "ingester-2", State: ring.ACTIVE}})
+	c.tenant("user").forwarded([]uint64{1}, now)
+
+	// An ingester leaving the ring, like when restarting, doesn't purge the cache.
+	c.checkIngesters([]ring.InstanceDesc{{Addr: "ingester-1", State: ring.ACTIVE}, {Addr: "ingester-2", State: ring.LEAVING}})
+	assert.True(t, isCached())
+	c.checkIngesters([]ring.InstanceDesc{{Addr: "ingester-1", State: ring.ACTIVE}})
+	assert.True(t, isCached())
+	c.checkIngesters([]ring.InstanceDesc{{Addr: "ingester-1", State: ring.ACTIVE}, {Addr: "ingester-2", State: ring.JOINING}})
+	assert.True(t, isCached())
+
+	// The ingester becoming ACTIVE again purges the cache, so that the metadata is forwarded again to it.
+	c.checkIngesters([]ring.InstanceDesc{{Addr: "ingester-1", State: ring.ACTIVE}, {Addr: "ingester-2", State: ring.ACTIVE}})
+	assert.False(t, isCached())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_metadata_cache_purges_total The total number of times the metadata cache has been purged because an ingester became ACTIVE, for example after a restart.
+		# TYPE cortex_distributor_metadata_cache_purges_total counter
+		cortex_distributor_metadata_cache_purges_total 1
+	`), "cortex_distributor_metadata_cache_purges_total"))
+}
+
+func TestDistributor_MetadataCache(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, _, regs := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+		metadataCache:     MetadataCacheConfig{Enabled: true, TTL: time.Hour, MaxEntriesPerTenant: 100},
+	})
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	_, err := ds[0].Push(ctx, makeWriteRequest(0, 0, 2, false, false))
+	require.NoError(t, err)
+
+	// The unchanged metadata is dropped, while the changed one is forwarded.
+	req := makeWriteRequest(0, 0, 2, false, false)
+	req.Metadata[1].Help = "a changed help"
+	_, err = ds[0].Push(ctx, req)
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_metadata_cache_dropped_metadata_total The total number of metadata dropped because forwarded unchanged to the ingesters within the metadata cache TTL.
+		# TYPE cortex_distributor_metadata_cache_dropped_metadata_total counter
+		cortex_distributor_metadata_cache_dropped_metadata_total{user="user"} 1
+		# HELP cortex_distributor_metadata_cache_dropped_bytes_total The total size, in bytes, of the metric family name, help and unit of the metadata dropped because forwarded unchanged to the ingesters within the metadata cache TTL.
+		# TYPE cortex_distributor_metadata_cache_dropped_bytes_total counter
+		cortex_distributor_metadata_cache_dropped_bytes_total{user="user"} 27
+		# HELP cortex_distributor_received_metadata_total The total number of received metadata, excluding rejected.
+		# TYPE cortex_distributor_received_metadata_total counter
+		cortex_distributor_received_metadata_total{user="user"} 3
+	`), "cortex_distributor_metadata_cache_dropped_metadata_total", "cortex_distributor_metadata_cache_dropped_bytes_total", "cortex_distributor_received_metadata_total"))
+}
+
+func TestDistributor_MetadataCache_ShouldNotCacheMetadataFailedToPush(t *testing.T) {
+	d := &Distributor{metadataCache: newMetadataCache(MetadataCacheConfig{Enabled: true, TTL: time.Hour, MaxEntriesPerTenant: 100}, prometheus.NewPedanticRegistry())}
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var pushedMetadata int
+	pushErr := errors.New("failed")
+	next := func(_ context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		req, err := pushReq.WriteRequest()
+		require.NoError(t, err)
+		pushedMetadata += len(req.Metadata)
+		return nil, pushErr
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := d.prePushMetadataCacheMiddleware(next)(ctx, push.NewParsedRequest(makeWriteRequest(0, 0, 2, false, false)))
+		require.ErrorIs(t, err, pushErr)
+	}
+	assert.Equal(t, 4, pushedMetadata)
+}
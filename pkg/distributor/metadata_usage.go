@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const defaultMetadataUsageTopMetricFamilies = 10
+
+// MetadataUsage models the size of the metric metadata currently stored in the ingesters for one tenant.
+type MetadataUsage struct {
+	// TotalBytes is the sum of the length of metric name, HELP and unit of all the stored metadata.
+	TotalBytes        uint64                      `json:"totalBytes"`
+	NumMetadata       int                         `json:"numMetadata"`
+	NumMetricFamilies int                         `json:"numMetricFamilies"`
+	TopMetricFamilies []MetricFamilyMetadataUsage `json:"topMetricFamilies"`
+}
+
+// MetricFamilyMetadataUsage models the size of the metric metadata stored for a single metric family.
+type MetricFamilyMetadataUsage struct {
+	MetricFamilyName string `json:"metricFamilyName"`
+	NumMetadata      int    `json:"numMetadata"`
+	Bytes            uint64 `json:"bytes"`
+}
+
+// MetadataUsage returns the size of the metric metadata currently stored in the ingesters for the tenant,
+// and the topN metric families with the biggest metadata. Metadata replicated across ingesters is counted once.
+func (d *Distributor) MetadataUsage(ctx context.Context, topN int) (*MetadataUsage, error) {
+	replicationSet, err := d.GetIngesters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ingester_client.MetricsMetadataRequest{}
+	resps, err := forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return client.MetricsMetadata(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dedupTracker := map[mimirpb.MetricMetadata]struct{}{}
+	families := map[string]*MetricFamilyMetadataUsage{}
+	usage := &MetadataUsage{}
+
+	for _, resp := range resps {
+		r := resp.(*ingester_client.MetricsMetadataResponse)
+		for _, m := range r.Metadata {
+			// Given we look across all ingesters - dedup the metadata.
+			if _, ok := dedupTracker[*m]; ok {
+				continue
+			}
+			dedupTracker[*m] = struct{}{}
+
+			size := uint64(len(m.MetricFamilyName) + len(m.Help) + len(m.Unit))
+			usage.TotalBytes += size
+			usage.NumMetadata++
+
+			family, ok := families[m.MetricFamilyName]
+			if !ok {
+				family = &MetricFamilyMetadataUsage{MetricFamilyName: m.MetricFamilyName}
+				families[m.MetricFamilyName] = family
+			}
+			family.NumMetadata++
+			family.Bytes += size
+		}
+	}
+
+	usage.NumMetricFamilies = len(families)
+	usage.TopMetricFamilies = make([]MetricFamilyMetadataUsage, 0, len(families))
+	for _, family := range families {
+		usage.TopMetricFamilies = append(usage.TopMetricFamilies, *family)
+	}
+
+	sort.Slice(usage.TopMetricFamilies, func(i, j int) bool {
+		a, b := usage.TopMetricFamilies[i], usage.TopMetricFamilies[j]
+		return a.Bytes > b.Bytes || (a.Bytes == b.Bytes && a.MetricFamilyName < b.MetricFamilyName)
+	})
+	if len(usage.TopMetricFamilies) > topN {
+		usage.TopMetricFamilies = usage.TopMetricFamilies[:topN]
+	}
+
+	return usage, nil
+}
+
+// MetadataUsageHandler returns the size of the metric metadata currently stored in the ingesters
+// for the authenticated tenant.
+func (d *Distributor) MetadataUsageHandler(w http.ResponseWriter, r *http.Request) {
+	topN := defaultMetadataUsageTopMetricFamilies
+	if v := r.FormValue("limit"); v != "" {
+		var err error
+		if topN, err = strconv.Atoi(v); err != nil || topN < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	usage, err := d.MetadataUsage(r.Context(), topN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, usage)
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestDistributor_MetadataUsage(t *testing.T) {
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		replicationFactor: 3,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	req := &mimirpb.WriteRequest{
+		Metadata: []*mimirpb.MetricMetadata{
+			{MetricFamilyName: "small", Type: mimirpb.COUNTER, Help: "h"},
+			{MetricFamilyName: "big", Type: mimirpb.COUNTER, Help: "a much longer help", Unit: "seconds"},
+			{MetricFamilyName: "medium", Type: mimirpb.GAUGE, Help: "some help"},
+		},
+		Source: mimirpb.API,
+	}
+	_, err := ds[0].Push(ctx, req)
+	require.NoError(t, err)
+
+	usage, err := ds[0].MetadataUsage(ctx, 2)
+	require.NoError(t, err)
+
+	// The metadata is replicated to all the ingesters, but it should be counted once.
+	assert.Equal(t, &MetadataUsage{
+		TotalBytes:        (5 + 1) + (3 + 18 + 7) + (6 + 9),
+		NumMetadata:       3,
+		NumMetricFamilies: 3,
+		TopMetricFamilies: []MetricFamilyMetadataUsage{
+			{MetricFamilyName: "big", NumMetadata: 1, Bytes: 3 + 18 + 7},
+			{MetricFamilyName: "medium", NumMetadata: 1, Bytes: 6 + 9},
+		},
+	}, usage)
+
+	t.Run("http handler", func(t *testing.T) {
+		for _, tc := range []struct {
+			query            string
+			expectedStatus   int
+			expectedFamilies int
+		}{
+			{query: "", expectedStatus: http.StatusOK, expectedFamilies: 3},
+			{query: "?limit=1", expectedStatus: http.StatusOK, expectedFamilies: 1},
+			{query: "?limit=0", expectedStatus: http.StatusOK, expectedFamilies: 0},
+			{query: "?limit=-1", expectedStatus: http.StatusBadRequest},
+			{query: "?limit=foo", expectedStatus: http.StatusBadRequest},
+		} {
+			t.Run(tc.query, func(t *testing.T) {
+				httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/metadata_usage"+tc.query, nil).WithContext(ctx)
+				rec := httptest.NewRecorder()
+				ds[0].MetadataUsageHandler(rec, httpReq)
+
+				require.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+				if tc.expectedStatus != http.StatusOK {
+					return
+				}
+
+				var got MetadataUsage
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, usage.TotalBytes, got.TotalBytes)
+				assert.Len(t, got.TopMetricFamilies, tc.expectedFamilies)
+			})
+		}
+	})
+}
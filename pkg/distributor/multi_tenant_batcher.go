@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/exp/slices"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// MultiTenantBatchingConfig configures the coalescing of the write requests of multiple tenants,
+// sent to the same ingester, into a single gRPC call.
+type MultiTenantBatchingConfig struct {
+	Enabled      bool          `yaml:"enabled" category:"experimental"`
+	MaxWait      time.Duration `yaml:"max_wait" category:"experimental"`
+	MaxBatchSize int           `yaml:"max_batch_size" category:"experimental"`
+}
+
+func (cfg *MultiTenantBatchingConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.multi-tenant-batching.enabled", false, "True to coalesce the write requests sent to the same ingester, possibly of different tenants, into a single gRPC call. This reduces the per-request overhead when many tenants push a low volume of samples, at the cost of an increased write latency.")
+	f.DurationVar(&cfg.MaxWait, "distributor.multi-tenant-batching.max-wait", 5*time.Millisecond, "Maximum time a write request waits for other write requests to the same ingester before the batch is sent.")
+	f.IntVar(&cfg.MaxBatchSize, "distributor.multi-tenant-batching.max-batch-size", 100, "Maximum number of write requests in a batch. A batch is sent as soon as it reaches this size.")
+}
+
+func (cfg *MultiTenantBatchingConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MaxWait <= 0 {
+		return fmt.Errorf("the multi-tenant batching max wait must be greater than 0")
+	}
+	if cfg.MaxBatchSize <= 0 {
+		return fmt.Errorf("the multi-tenant batching max batch size must be greater than 0")
+	}
+	return nil
+}
+
+// multiTenantPushBatcher coalesces the write requests sent to the same ingester within a short window
+// into a single PushMultiTenant call.
+type multiTenantPushBatcher struct {
+	cfg           MultiTenantBatchingConfig
+	remoteTimeout time.Duration
+	pool          *ring_client.Pool
+
+	mtx sync.Mutex
+	// Batches waiting to be sent, by ingester address.
+	pending map[string]*multiTenantPushBatch
+
+	batchSize     prometheus.Histogram
+	failedBatches prometheus.Counter
+}
+
+type multiTenantPushBatch struct {
+	addr     string
+	requests []*ingester_client.TenantWriteRequest
+	done     []chan error
+	timer    *time.Timer
+}
+
+func newMultiTenantPushBatcher(cfg MultiTenantBatchingConfig, remoteTimeout time.Duration, pool *ring_client.Pool, reg prometheus.Registerer) *multiTenantPushBatcher {
+	return &multiTenantPushBatcher{
+		cfg:           cfg,
+		remoteTimeout: remoteTimeout,
+		pool:          pool,
+		pending:       map[string]*multiTenantPushBatch{},
+		batchSize: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_multi_tenant_push_batch_size",
+			Help:    "Number of write requests coalesced in each multi-tenant push to ingesters.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		failedBatches: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_multi_tenant_push_failed_batches_total",
+			Help: "Total number of multi-tenant pushes to ingesters which failed as a whole.",
+		}),
+	}
+}
+
+// push adds the write request of the tenant to the batch of the ingester, and waits until the batch has been sent.
+// The write request must not be modified until push returns.
+func (b *multiTenantPushBatcher) push(addr, userID string, req *mimirpb.WriteRequest) error {
+	done := make(chan error, 1)
+
+	b.mtx.Lock()
+	batch, ok := b.pending[addr]
+	if !ok {
+		batch = &multiTenantPushBatch{addr: addr}
+		batch.timer = time.AfterFunc(b.cfg.MaxWait, func() { b.flush(batch) })
+		b.pending[addr] = batch
+	}
+	batch.requests = append(batch.requests, &ingester_client.TenantWriteRequest{TenantId: userID, Request: req})
+	batch.done = append(batch.done, done)
+
+	full := len(batch.requests) >= b.cfg.MaxBatchSize
+	if full {
+		batch.timer.Stop()
+		delete(b.pending, addr)
+	}
+	b.mtx.Unlock()
+
+	if full {
+		b.send(batch)
+	}
+
+	// The series of the write request reference the buffers of the distributor push request,
+	// so we have to wait until the batch has been sent, even if the caller gave up meanwhile.
+	return <-done
+}
+
+// flush sends the batch, unless it has already been sent because it reached the max size.
+func (b *multiTenantPushBatcher) flush(batch *multiTenantPushBatch) {
+	b.mtx.Lock()
+	if b.pending[batch.addr] != batch {
+		b.mtx.Unlock()
+		return
+	}
+	delete(b.pending, batch.addr)
+	b.mtx.Unlock()
+
+	b.send(batch)
+}
+
+func (b *multiTenantPushBatcher) send(batch *multiTenantPushBatch) {
+	b.batchSize.Observe(float64(len(batch.requests)))
+
+	resp, err := b.pushMultiTenant(batch)
+	if err == nil && len(resp.Responses) != len(batch.requests) {
+		err = fmt.Errorf("the ingester returned %d responses for %d write requests", len(resp.Responses), len(batch.requests))
+	}
+	if err != nil {
+		b.failedBatches.Inc()
+	}
+
+	for idx, done := range batch.done {
+		if err != nil {
+			done <- err
+			continue
+		}
+		done <- resp.Responses[idx].Err()
+	}
+}
+
+func (b *multiTenantPushBatcher) pushMultiTenant(batch *multiTenantPushBatch) (*ingester_client.MultiTenantWriteResponse, error) {
+	h, err := b.pool.GetClientFor(batch.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := h.(ingester_client.IngesterClient)
+
+	// The call is authenticated as all the tenants of the batch, so that the ingester
+	// can check that each write request belongs to one of them.
+	tenants := make([]string, 0, len(batch.requests))
+	for _, req := range batch.requests {
+		tenants = append(tenants, req.TenantId)
+	}
+	slices.Sort(tenants)
+	tenants = slices.Compact(tenants)
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.remoteTimeout)
+	defer cancel()
+	ctx = user.InjectOrgID(ctx, tenant.JoinTenantIDs(tenants))
+
+	return c.PushMultiTenant(ctx, &ingester_client.MultiTenantWriteRequest{Requests: batch.requests})
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+)
+
+func TestDistributor_Push_MultiTenantBatching(t *testing.T) {
+	const numTenants = 10
+
+	t.Run("should coalesce the write requests of multiple tenants", func(t *testing.T) {
+		distributors, ingesters, regs := prepare(t, prepConfig{
+			numIngesters:        3,
+			happyIngesters:      3,
+			numDistributors:     1,
+			multiTenantBatching: MultiTenantBatchingConfig{Enabled: true, MaxWait: 100 * time.Millisecond, MaxBatchSize: numTenants},
+		})
+
+		wg := sync.WaitGroup{}
+		for n := 0; n < numTenants; n++ {
+			wg.Add(1)
+			go func(userID string) {
+				defer wg.Done()
+				_, err := distributors[0].Push(user.InjectOrgID(context.Background(), userID), makeWriteRequest(0, 1, 0, false, false))
+				assert.NoError(t, err)
+			}(fmt.Sprintf("user-%d", n))
+		}
+		wg.Wait()
+
+		// With a replication factor of 3, each ingester receives the write request of each tenant.
+		for idx := range ingesters {
+			assert.Equal(t, numTenants, ingesters[idx].countCalls("Push"))
+			assert.Less(t, ingesters[idx].countCalls("PushMultiTenant"), numTenants)
+			assert.Len(t, ingesters[idx].series(), numTenants)
+		}
+
+		metrics, err := regs[0].Gather()
+		require.NoError(t, err)
+		var batchedRequests float64
+		for _, mf := range metrics {
+			if mf.GetName() == "cortex_distributor_multi_tenant_push_batch_size" {
+				batchedRequests = mf.GetMetric()[0].GetHistogram().GetSampleSum()
+			}
+		}
+		assert.Equal(t, float64(len(ingesters)*numTenants), batchedRequests)
+	})
+
+	t.Run("should fail the write requests of the batch if the ingesters fail", func(t *testing.T) {
+		distributors, ingesters, _ := prepare(t, prepConfig{
+			numIngesters:        3,
+			happyIngesters:      0,
+			numDistributors:     1,
+			multiTenantBatching: MultiTenantBatchingConfig{Enabled: true, MaxWait: time.Millisecond, MaxBatchSize: numTenants},
+		})
+
+		_, err := distributors[0].Push(user.InjectOrgID(context.Background(), "user"), makeWriteRequest(0, 1, 0, false, false))
+		assert.Equal(t, httpgrpc.Errorf(http.StatusInternalServerError, "failed pushing to ingester: Fail"), err)
+
+		for idx := range ingesters {
+			assert.Equal(t, 0, ingesters[idx].countCalls("Push"))
+		}
+	})
+}
+
+func TestMultiTenantBatchingConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&MultiTenantBatchingConfig{}).Validate())
+	assert.NoError(t, (&MultiTenantBatchingConfig{Enabled: true, MaxWait: time.Millisecond, MaxBatchSize: 1}).Validate())
+	assert.Error(t, (&MultiTenantBatchingConfig{Enabled: true, MaxBatchSize: 1}).Validate())
+	assert.Error(t, (&MultiTenantBatchingConfig{Enabled: true, MaxWait: time.Millisecond}).Validate())
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+)
+
+// PaginatedLabelValuesForLabelName returns the sorted values of the label name sorting after the given value, and
+// at most limit values if limit is greater than 0. The values are streamed by the ingesters, and only the values of
+// the page are kept in memory.
+func (d *Distributor) PaginatedLabelValuesForLabelName(ctx context.Context, from, to model.Time, labelName model.LabelName, after string, limit int, matchers ...*labels.Matcher) ([]string, error) {
+	replicationSet, err := d.GetIngesters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := ingester_client.ToLabelValuesRequest(labelName, from, to, matchers)
+	if err != nil {
+		return nil, err
+	}
+	req.After = after
+	req.Limit = int64(limit)
+
+	merger := &sortedLabelsMerger{limit: limit}
+	_, err = forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.LabelValuesStream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+
+		return nil, merger.collect(func() ([]string, error) {
+			resp, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return resp.LabelValues, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merger.values(), nil
+}
+
+// PaginatedLabelNames returns the sorted label names sorting after the given name, and at most limit names if limit
+// is greater than 0. The names are streamed by the ingesters, and only the names of the page are kept in memory.
+func (d *Distributor) PaginatedLabelNames(ctx context.Context, from, to model.Time, after string, limit int, matchers ...*labels.Matcher) ([]string, error) {
+	replicationSet, err := d.GetIngesters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := ingester_client.ToLabelNamesRequest(from, to, matchers)
+	if err != nil {
+		return nil, err
+	}
+	req.After = after
+	req.Limit = int64(limit)
+
+	merger := &sortedLabelsMerger{limit: limit}
+	_, err = forReplicationSet(ctx, d, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.LabelNamesStream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+
+		return nil, merger.collect(func() ([]string, error) {
+			resp, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return resp.LabelNames, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merger.values(), nil
+}
+
+// sortedLabelsMerger merges the sorted label values or names streamed by the ingesters, keeping only the first limit
+// distinct ones if limit is greater than 0.
+type sortedLabelsMerger struct {
+	limit int
+
+	mtx    sync.Mutex
+	result []string
+}
+
+// collect merges the batches returned by recv until io.EOF, or until the following batches of the sorted stream
+// can't be part of the result anymore.
+func (m *sortedLabelsMerger) collect(recv func() ([]string, error)) error {
+	for {
+		batch, err := recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if full := m.add(batch); full {
+			return nil
+		}
+	}
+}
+
+// add merges the sorted batch into the result, and returns whether the values sorting after the batch can't be
+// part of the result, because it's full and they would sort after all its values.
+func (m *sortedLabelsMerger) add(batch []string) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	merged := make([]string, 0, len(m.result)+len(batch))
+	i, j := 0, 0
+	for i < len(m.result) && j < len(batch) {
+		switch {
+		case m.result[i] < batch[j]:
+			merged = append(merged, m.result[i])
+			i++
+		case m.result[i] > batch[j]:
+			merged = append(merged, batch[j])
+			j++
+		default:
+			merged = append(merged, m.result[i])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, m.result[i:]...)
+	merged = append(merged, batch[j:]...)
+
+	if m.limit > 0 && len(merged) > m.limit {
+		merged = merged[:m.limit]
+	}
+	m.result = merged
+
+	return m.limit > 0 && len(m.result) == m.limit && len(batch) > 0 && batch[len(batch)-1] >= m.result[len(m.result)-1]
+}
+
+func (m *sortedLabelsMerger) values() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.result
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestSortedLabelsMerger(t *testing.T) {
+	tests := map[string]struct {
+		limit    int
+		batches  [][]string
+		expected []string
+	}{
+		"no limit": {
+			batches:  [][]string{{"a", "c"}, {"b", "c", "d"}, {"e"}},
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		"limit": {
+			limit:    3,
+			batches:  [][]string{{"b", "d"}, {"a", "c", "e"}},
+			expected: []string{"a", "b", "c"},
+		},
+		"no batches": {
+			limit: 3,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			merger := &sortedLabelsMerger{limit: tc.limit}
+			for _, batch := range tc.batches {
+				merger.add(batch)
+			}
+			assert.Equal(t, tc.expected, merger.values())
+		})
+	}
+}
+
+func TestSortedLabelsMerger_Collect(t *testing.T) {
+	recv := func(batches ...[]string) (func() ([]string, error), *int) {
+		calls := 0
+		return func() ([]string, error) {
+			if calls >= len(batches) {
+				return nil, io.EOF
+			}
+			calls++
+			return batches[calls-1], nil
+		}, &calls
+	}
+
+	merger := &sortedLabelsMerger{limit: 2}
+	first, firstCalls := recv([]string{"b"}, []string{"d", "e"}, []string{"f"})
+	require.NoError(t, merger.collect(first))
+	// The stream is closed once the merged values are full and sort before its following batches.
+	assert.Equal(t, 2, *firstCalls)
+
+	second, secondCalls := recv([]string{"a", "c"}, []string{"g"})
+	require.NoError(t, merger.collect(second))
+	assert.Equal(t, 1, *secondCalls)
+	assert.Equal(t, []string{"a", "b"}, merger.values())
+
+	failing := func() ([]string, error) { return nil, fmt.Errorf("stream failed") }
+	require.Error(t, merger.collect(failing))
+}
+
+func TestDistributor_PaginatedLabelValuesForLabelName(t *testing.T) {
+	const numIngesters = 5
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      numIngesters,
+		happyIngesters:    numIngesters,
+		numDistributors:   1,
+		replicationFactor: 3,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	for i := 0; i < 10; i++ {
+		req := mockWriteRequest(labels.FromStrings(labels.MetricName, "test", "instance", fmt.Sprintf("instance_%d", i)), 1, 100000)
+		_, err := ds[0].Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	from, to := model.Time(0), model.Time(200000)
+
+	var pages [][]string
+	after := ""
+	for {
+		values, err := ds[0].PaginatedLabelValuesForLabelName(ctx, from, to, "instance", after, 4)
+		require.NoError(t, err)
+		pages = append(pages, values)
+		if len(values) < 4 {
+			break
+		}
+		after = values[len(values)-1]
+	}
+
+	assert.Equal(t, [][]string{
+		{"instance_0", "instance_1", "instance_2", "instance_3"},
+		{"instance_4", "instance_5", "instance_6", "instance_7"},
+		{"instance_8", "instance_9"},
+	}, pages)
+
+	values, err := ds[0].PaginatedLabelValuesForLabelName(ctx, from, to, "instance", "", 0, mustNewMatcher(labels.MatchRegexp, "instance", "instance_[12]"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"instance_1", "instance_2"}, values)
+}
+
+func TestDistributor_PaginatedLabelNames(t *testing.T) {
+	const numIngesters = 5
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      numIngesters,
+		happyIngesters:    numIngesters,
+		numDistributors:   1,
+		replicationFactor: 3,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings(labels.MetricName, "test_1", "status", "200"),
+		labels.FromStrings(labels.MetricName, "test_1", "status", "500", "reason", "broken"),
+		labels.FromStrings(labels.MetricName, "test_2", "zone", "a"),
+	} {
+		_, err := ds[0].Push(ctx, mockWriteRequest(lbls, 1, 100000))
+		require.NoError(t, err)
+	}
+
+	from, to := model.Time(0), model.Time(200000)
+
+	names, err := ds[0].PaginatedLabelNames(ctx, from, to, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{labels.MetricName, "reason"}, names)
+
+	names, err = ds[0].PaginatedLabelNames(ctx, from, to, "reason", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "zone"}, names)
+
+	names, err = ds[0].PaginatedLabelNames(ctx, from, to, "zone", 2)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	names, err = ds[0].PaginatedLabelNames(ctx, from, to, "", 0, mustNewMatcher(labels.MatchEqual, labels.MetricName, "test_2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{labels.MetricName, "zone"}, names)
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// PartialAcceptanceConfig configures the partial acceptance of the write requests allowing it.
+type PartialAcceptanceConfig struct {
+	TimeoutMargin time.Duration `yaml:"timeout_margin" category:"experimental"`
+}
+
+func (cfg *PartialAcceptanceConfig) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.TimeoutMargin, "distributor.partial-acceptance.timeout-margin", 0, "If greater than 0, the write requests allowing partial acceptance are answered this long before -distributor.remote-timeout expires, if not all their series have been written to a quorum of ingesters yet, with the series which haven't been written instead of an error. The ingesters keep receiving the series until the remote timeout expires. 0 to disable.")
+}
+
+func (cfg *PartialAcceptanceConfig) Validate(remoteTimeout time.Duration) error {
+	if cfg.TimeoutMargin < 0 || (cfg.TimeoutMargin > 0 && cfg.TimeoutMargin >= remoteTimeout) {
+		return fmt.Errorf("the partial acceptance timeout margin must be 0 or less than the remote timeout")
+	}
+	return nil
+}
+
+// partialAcceptanceTracker tracks the keys written to a quorum of ingesters, for the write requests allowing
+// partial acceptance.
+type partialAcceptanceTracker struct {
+	quorum    int32
+	succeeded []atomic.Int32
+}
+
+func newPartialAcceptanceTracker(keys, replicationFactor int) *partialAcceptanceTracker {
+	return &partialAcceptanceTracker{
+		// Like the replication strategy of the ring, regardless of the instances actually available.
+		quorum:    int32(replicationFactor/2 + 1),
+		succeeded: make([]atomic.Int32, keys),
+	}
+}
+
+// recordSuccess records the keys successfully written to an ingester.
+func (t *partialAcceptanceTracker) recordSuccess(indexes []int) {
+	for _, i := range indexes {
+		t.succeeded[i].Inc()
+	}
+}
+
+func (t *partialAcceptanceTracker) reachedQuorum(index int) bool {
+	return t.succeeded[index].Load() >= t.quorum
+}
+
+// failedSeries returns a copy of the labels of the series which haven't been written to a quorum of ingesters yet,
+// and whether any series has been. While migrating between sharding schemes, a series has been written once the key
+// of the migration scheme, stored at initialMigrationIndex onwards, has been written too.
+func (t *partialAcceptanceTracker) failedSeries(timeseries []mimirpb.PreallocTimeseries, initialMigrationIndex int, migration bool) ([]mimirpb.FailedSeries, bool) {
+	var failed []mimirpb.FailedSeries
+	for i, ts := range timeseries {
+		if t.reachedQuorum(i) && (!migration || t.reachedQuorum(initialMigrationIndex+i)) {
+			continue
+		}
+
+		// The labels reference the buffers of the request, which are reused once it has been written.
+		lbls := make([]mimirpb.LabelAdapter, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			lbls = append(lbls, mimirpb.LabelAdapter{Name: strings.Clone(l.Name), Value: strings.Clone(l.Value)})
+		}
+		failed = append(failed, mimirpb.FailedSeries{Labels: lbls})
+	}
+	return failed, len(failed) < len(timeseries)
+}
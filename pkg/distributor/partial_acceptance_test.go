@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestPartialAcceptanceConfig_Validate(t *testing.T) {
+	require.NoError(t, (&PartialAcceptanceConfig{}).Validate(2*time.Second))
+	require.NoError(t, (&PartialAcceptanceConfig{TimeoutMargin: time.Second}).Validate(2*time.Second))
+	require.Error(t, (&PartialAcceptanceConfig{TimeoutMargin: -time.Second}).Validate(2*time.Second))
+	require.Error(t, (&PartialAcceptanceConfig{TimeoutMargin: 2 * time.Second}).Validate(2*time.Second))
+}
+
+func TestPartialAcceptanceTracker_FailedSeries(t *testing.T) {
+	timeseries := []mimirpb.PreallocTimeseries{
+		{TimeSeries: &mimirpb.TimeSeries{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "a"}}}},
+		{TimeSeries: &mimirpb.TimeSeries{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "b"}}}},
+	}
+
+	t.Run("without migration", func(t *testing.T) {
+		tracker := newPartialAcceptanceTracker(2, 3)
+		tracker.recordSuccess([]int{0, 1})
+		tracker.recordSuccess([]int{0})
+
+		failed, anyWritten := tracker.failedSeries(timeseries, 2, false)
+		assert.True(t, anyWritten)
+		assert.Equal(t, []mimirpb.FailedSeries{{Labels: timeseries[1].Labels}}, failed)
+
+		tracker.recordSuccess([]int{1})
+		failed, anyWritten = tracker.failedSeries(timeseries, 2, false)
+		assert.True(t, anyWritten)
+		assert.Empty(t, failed)
+	})
+
+	t.Run("with migration", func(t *testing.T) {
+		tracker := newPartialAcceptanceTracker(4, 1)
+		tracker.recordSuccess([]int{0, 1, 2})
+
+		// The migration key of the second series hasn't been written.
+		failed, anyWritten := tracker.failedSeries(timeseries, 2, true)
+		assert.True(t, anyWritten)
+		assert.Equal(t, []mimirpb.FailedSeries{{Labels: timeseries[1].Labels}}, failed)
+	})
+
+	t.Run("no series written", func(t *testing.T) {
+		tracker := newPartialAcceptanceTracker(2, 3)
+		tracker.recordSuccess([]int{0, 1})
+
+		failed, anyWritten := tracker.failedSeries(timeseries, 2, false)
+		assert.False(t, anyWritten)
+		assert.Len(t, failed, 2)
+	})
+}
+
+func TestDistributor_Push_PartialAcceptance(t *testing.T) {
+	const numSeries = 50
+
+	writeRequest := func(partialAcceptance bool) *mimirpb.WriteRequest {
+		req := &mimirpb.WriteRequest{PartialAcceptance: partialAcceptance}
+		for i := 0; i < numSeries; i++ {
+			req.Timeseries = append(req.Timeseries, mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+				Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: fmt.Sprintf("series_%d", i)}},
+				Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}},
+			}})
+		}
+		return req
+	}
+
+	setup := func(t *testing.T) *Distributor {
+		distributors, ingesters, _ := prepare(t, prepConfig{
+			numIngesters:      5,
+			happyIngesters:    5,
+			numDistributors:   1,
+			replicationFactor: 3,
+			partialAcceptance: PartialAcceptanceConfig{TimeoutMargin: 1800 * time.Millisecond},
+		})
+
+		// The series replicated to both the slow ingesters don't reach the quorum before the deadline.
+		ingesters[0].pushDelay = time.Second
+		ingesters[1].pushDelay = time.Second
+		return distributors[0]
+	}
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	t.Run("the request allows partial acceptance", func(t *testing.T) {
+		d := setup(t)
+
+		resp, err := d.Push(ctx, writeRequest(true))
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.FailedSeries)
+		require.Less(t, len(resp.FailedSeries), numSeries)
+		for _, series := range resp.FailedSeries {
+			assert.Regexp(t, "^series_[0-9]+$", series.Labels[0].Value)
+		}
+		assert.Equal(t, 1.0, testutil.ToFloat64(d.partiallyAcceptedRequests))
+	})
+
+	t.Run("the request doesn't allow partial acceptance", func(t *testing.T) {
+		d := setup(t)
+
+		start := time.Now()
+		_, err := d.Push(ctx, writeRequest(false))
+		require.NoError(t, err)
+
+		// The request waits for the slow ingesters.
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.Equal(t, 0.0, testutil.ToFloat64(d.partiallyAcceptedRequests))
+	})
+}
+
+func TestDistributor_Push_PartialAcceptance_NoSeriesWritten(t *testing.T) {
+	distributors, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		replicationFactor: 3,
+		partialAcceptance: PartialAcceptanceConfig{TimeoutMargin: 1800 * time.Millisecond},
+	})
+	for i := range ingesters {
+		ingesters[i].pushDelay = time.Second
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	req := mockWriteRequest(labels.FromStrings("__name__", "series_1"), 1, 1000)
+	req.PartialAcceptance = true
+
+	_, err := distributors[0].Push(ctx, req)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusInternalServerError), resp.Code)
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+// pushDebugMiddleware enables the debug report requested for the write request, if any,
+// when the tenant is allowed to request it.
+func (d *Distributor) pushDebugMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		if report := push.DebugReportFromContext(ctx); report != nil {
+			if userID, err := tenant.TenantID(ctx); err == nil && d.limits.PushDebugReportEnabled(userID) {
+				report.Enable(userID)
+			}
+		}
+
+		return next(ctx, pushReq)
+	}
+}
+
+// debugPushStep wraps the input middleware to record its decision in the debug report of the write request,
+// when enabled. The middleware is considered to have passed the request if it called the next push function.
+func debugPushStep(name string, middleware PushWrapper) PushWrapper {
+	return func(next push.Func) push.Func {
+		wrapped := middleware(next)
+
+		return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+			report := push.DebugReportFromContext(ctx)
+			if !report.Enabled() {
+				return wrapped(ctx, pushReq)
+			}
+
+			// The middleware is wrapped on each request, to track whether it passed this request.
+			start := time.Now()
+			passed := false
+			res, err := middleware(func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+				passed = true
+
+				step := push.DebugReportStep{Name: name, Decision: push.DebugStepPassed, Duration: model.Duration(time.Since(start))}
+				if req, err := pushReq.WriteRequest(); err == nil {
+					step.Series, step.Metadata = len(req.Timeseries), len(req.Metadata)
+				}
+				report.AddStep(step)
+
+				return next(ctx, pushReq)
+			})(ctx, pushReq)
+
+			if !passed {
+				step := push.DebugReportStep{Name: name, Decision: push.DebugStepRejected, Duration: model.Duration(time.Since(start))}
+				if err != nil {
+					step.Reason = err.Error()
+				} else {
+					step.Decision = push.DebugStepDropped
+					step.Reason = "nothing left to push"
+				}
+				report.AddStep(step)
+			}
+
+			return res, err
+		}
+	}
+}
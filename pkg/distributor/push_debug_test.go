@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_PushDebugReport(t *testing.T) {
+	tests := map[string]struct {
+		debugEnabled      bool
+		maxLabelNameLen   int
+		expectedErr       bool
+		expectedSteps     map[string]string
+		expectedIngesters int
+	}{
+		"should not collect the report if the tenant is not allowed to request it": {
+			debugEnabled: false,
+		},
+		"should report the decision of each step and the ingesters the request has been sent to": {
+			debugEnabled: true,
+			expectedSteps: map[string]string{
+				"limits":     push.DebugStepPassed,
+				"metrics":    push.DebugStepPassed,
+				"ha_dedupe":  push.DebugStepPassed,
+				"relabel":    push.DebugStepPassed,
+				"validation": push.DebugStepPassed,
+			},
+			expectedIngesters: 3,
+		},
+		"should report the step rejecting the request": {
+			debugEnabled:    true,
+			maxLabelNameLen: 1,
+			expectedErr:     true,
+			expectedSteps: map[string]string{
+				"limits":     push.DebugStepPassed,
+				"metrics":    push.DebugStepPassed,
+				"ha_dedupe":  push.DebugStepPassed,
+				"relabel":    push.DebugStepPassed,
+				"validation": push.DebugStepRejected,
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.PushDebugReportEnabled = testData.debugEnabled
+			if testData.maxLabelNameLen > 0 {
+				limits.MaxLabelNameLength = testData.maxLabelNameLen
+			}
+
+			ds, _, _ := prepare(t, prepConfig{
+				numIngesters:      3,
+				happyIngesters:    3,
+				numDistributors:   1,
+				limits:            &limits,
+				replicationFactor: 3,
+			})
+
+			report := push.NewDebugReport()
+			ctx := push.ContextWithDebugReport(user.InjectOrgID(context.Background(), "user"), report)
+
+			_, err := ds[0].PushWithMiddlewares(ctx, push.NewParsedRequest(makeWriteRequest(time.Now().UnixMilli(), 1, 0, false, false, "foo")))
+			if testData.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, testData.debugEnabled, report.Enabled())
+			if !testData.debugEnabled {
+				assert.Empty(t, report.Steps)
+				assert.Empty(t, report.Ingesters)
+				return
+			}
+
+			assert.Equal(t, "user", report.Tenant)
+			actualSteps := map[string]string{}
+			for _, step := range report.Steps {
+				actualSteps[step.Name] = step.Decision
+				if step.Decision == push.DebugStepRejected {
+					assert.Contains(t, step.Reason, "err-mimir-label-name-too-long")
+				}
+			}
+			assert.Equal(t, testData.expectedSteps, actualSteps)
+
+			// The request may be acknowledged before all the ingesters have replied, so the report
+			// is read through its JSON encoding, which is safe for concurrent use.
+			require.Eventually(t, func() bool {
+				data, err := json.Marshal(report)
+				require.NoError(t, err)
+
+				decoded := struct {
+					Ingesters []push.DebugReportIngester `json:"ingesters"`
+				}{}
+				require.NoError(t, json.Unmarshal(data, &decoded))
+				return len(decoded.Ingesters) == testData.expectedIngesters
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestDebugPushStep_ShouldReportDroppedRequests(t *testing.T) {
+	report := push.NewDebugReport()
+	report.Enable("user")
+	ctx := push.ContextWithDebugReport(context.Background(), report)
+
+	dropAll := func(push.Func) push.Func {
+		return func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+			return &mimirpb.WriteResponse{}, nil
+		}
+	}
+
+	_, err := debugPushStep("drop_all", dropAll)(nil)(ctx, push.NewParsedRequest(&mimirpb.WriteRequest{}))
+	require.NoError(t, err)
+
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, "drop_all", report.Steps[0].Name)
+	assert.Equal(t, push.DebugStepDropped, report.Steps[0].Decision)
+}
@@ -0,0 +1,344 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/mtime"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	pushDryRunStatusAccepted = "accepted"
+	pushDryRunStatusModified = "modified"
+	pushDryRunStatusDropped  = "dropped"
+
+	pushDryRunStageHADedupe = "ha_dedupe"
+	pushDryRunStageRelabel  = "relabel"
+	pushDryRunStageValidate = "validation"
+
+	pushDryRunHANotHA        = "not_ha"
+	pushDryRunHAAccepted     = "accepted"
+	pushDryRunHADeduplicated = "deduplicated"
+	pushDryRunHARejected     = "rejected"
+)
+
+// PushDryRunReport describes what the distributor would do with a write request, without storing anything.
+type PushDryRunReport struct {
+	HATracker PushDryRunHATrackerResult  `json:"ha_tracker"`
+	Series    []PushDryRunSeriesResult   `json:"series"`
+	Metadata  []PushDryRunMetadataResult `json:"metadata"`
+	Summary   PushDryRunSummary          `json:"summary"`
+}
+
+// PushDryRunHATrackerResult describes the outcome of the HA deduplication of a write request.
+type PushDryRunHATrackerResult struct {
+	Cluster  string `json:"cluster,omitempty"`
+	Replica  string `json:"replica,omitempty"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// PushDryRunSeriesResult describes the outcome for a single series of a write request.
+type PushDryRunSeriesResult struct {
+	Labels           string   `json:"labels"`
+	FinalLabels      string   `json:"final_labels,omitempty"`
+	Status           string   `json:"status"`
+	Stage            string   `json:"stage,omitempty"`
+	Reason           string   `json:"reason,omitempty"`
+	Modifications    []string `json:"modifications,omitempty"`
+	Samples          int      `json:"samples"`
+	Histograms       int      `json:"histograms"`
+	Exemplars        int      `json:"exemplars"`
+	DroppedExemplars int      `json:"dropped_exemplars"`
+}
+
+// PushDryRunMetadataResult describes the outcome for a single metric metadata of a write request.
+type PushDryRunMetadataResult struct {
+	MetricFamilyName string `json:"metric_family_name"`
+	Status           string `json:"status"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// PushDryRunSummary summarizes the outcome of a write request.
+type PushDryRunSummary struct {
+	ReceivedSeries   int `json:"received_series"`
+	AcceptedSeries   int `json:"accepted_series"`
+	ModifiedSeries   int `json:"modified_series"`
+	DroppedSeries    int `json:"dropped_series"`
+	AcceptedSamples  int `json:"accepted_samples"`
+	DroppedSamples   int `json:"dropped_samples"`
+	AcceptedMetadata int `json:"accepted_metadata"`
+	DroppedMetadata  int `json:"dropped_metadata"`
+}
+
+// PushDryRunHandler returns a http.Handler which accepts write requests in the same format of the push API,
+// and replies with a PushDryRunReport describing how the write request would be deduplicated, relabeled and
+// validated. Nothing is stored, and neither the HA tracker state nor the tenant's ingestion metrics are updated.
+func (d *Distributor) PushDryRunHandler(maxRecvMsgSize int, allowSkipLabelNameValidation bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req mimirpb.PreallocWriteRequest
+		if _, err := util.ParseProtoReader(r.Context(), r.Body, int(r.ContentLength), maxRecvMsgSize, nil, &req, util.RawSnappy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer mimirpb.ReuseSlice(req.Timeseries)
+
+		if allowSkipLabelNameValidation {
+			req.SkipLabelNameValidation = req.SkipLabelNameValidation && r.Header.Get(push.SkipLabelNameValidationHeader) == "true"
+		} else {
+			req.SkipLabelNameValidation = false
+		}
+
+		report, err := d.PushDryRun(r.Context(), &req.WriteRequest)
+		if err != nil {
+			if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+				http.Error(w, string(resp.Body), int(resp.Code))
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		util.WriteJSONResponse(w, report)
+	})
+}
+
+// PushDryRun runs the input write request through the HA deduplication, relabeling and validation,
+// and returns a report of what would be dropped or modified, and why. The input request is modified
+// in place, like it would be by Push, but it's not sent to ingesters.
+func (d *Distributor) PushDryRun(ctx context.Context, req *mimirpb.WriteRequest) (*PushDryRunReport, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	report := &PushDryRunReport{
+		HATracker: PushDryRunHATrackerResult{Decision: pushDryRunHANotHA},
+		Series:    make([]PushDryRunSeriesResult, len(req.Timeseries)),
+		Metadata:  make([]PushDryRunMetadataResult, len(req.Metadata)),
+	}
+
+	for i, ts := range req.Timeseries {
+		report.Series[i] = PushDryRunSeriesResult{
+			Labels:     mimirpb.FromLabelAdaptersToMetric(ts.Labels).String(),
+			Status:     pushDryRunStatusAccepted,
+			Samples:    len(ts.Samples),
+			Histograms: len(ts.Histograms),
+			Exemplars:  len(ts.Exemplars),
+		}
+	}
+
+	d.pushDryRunHADedupe(userID, req, report)
+	d.pushDryRunRelabel(userID, req, report)
+	d.pushDryRunValidate(userID, req, report)
+
+	for i, ts := range req.Timeseries {
+		result := &report.Series[i]
+		if result.Status == pushDryRunStatusDropped {
+			continue
+		}
+		if finalLabels := mimirpb.FromLabelAdaptersToMetric(ts.Labels).String(); finalLabels != result.Labels {
+			result.FinalLabels = finalLabels
+		}
+		if len(result.Modifications) > 0 {
+			result.Status = pushDryRunStatusModified
+		}
+	}
+
+	report.Summary.ReceivedSeries = len(report.Series)
+	for _, result := range report.Series {
+		samples := result.Samples + result.Histograms
+		switch result.Status {
+		case pushDryRunStatusDropped:
+			report.Summary.DroppedSeries++
+			report.Summary.DroppedSamples += samples
+		case pushDryRunStatusModified:
+			report.Summary.ModifiedSeries++
+			report.Summary.AcceptedSeries++
+			report.Summary.AcceptedSamples += samples
+		default:
+			report.Summary.AcceptedSeries++
+			report.Summary.AcceptedSamples += samples
+		}
+	}
+	for _, result := range report.Metadata {
+		if result.Status == pushDryRunStatusDropped {
+			report.Summary.DroppedMetadata++
+		} else {
+			report.Summary.AcceptedMetadata++
+		}
+	}
+
+	return report, nil
+}
+
+// pushDryRunHADedupe mirrors prePushHaDedupeMiddleware, without updating the HA tracker state.
+func (d *Distributor) pushDryRunHADedupe(userID string, req *mimirpb.WriteRequest, report *PushDryRunReport) {
+	if len(req.Timeseries) == 0 || !d.limits.AcceptHASamples(userID) {
+		return
+	}
+
+	var removeReplicaLabels []string
+	for _, level := range findHALevels(d.limits, userID, req.Timeseries[0].Labels) {
+		// The report includes the cluster and replica of the innermost level evaluated.
+		report.HATracker.Cluster, report.HATracker.Replica = level.cluster, level.replica
+
+		removeReplica, err := d.dryRunCheckSample(userID, level.cluster, level.replica)
+		if err != nil {
+			decision := pushDryRunHARejected
+			if errors.Is(err, replicasNotMatchError{}) {
+				decision = pushDryRunHADeduplicated
+			}
+			report.HATracker.Decision = decision
+			report.HATracker.Reason = err.Error()
+
+			for i := range report.Series {
+				pushDryRunDropSeries(&report.Series[i], pushDryRunStageHADedupe, err.Error())
+			}
+			return
+		}
+
+		if removeReplica {
+			removeReplicaLabels = append(removeReplicaLabels, level.replicaLabel)
+		}
+	}
+
+	if len(removeReplicaLabels) == 0 {
+		report.HATracker.Reason = "the series are accepted without deduplication because the HA cluster or replica label is missing, or the replica label is too long"
+		return
+	}
+
+	report.HATracker.Decision = pushDryRunHAAccepted
+	for ix := range req.Timeseries {
+		for _, replicaLabel := range removeReplicaLabels {
+			numLabels := len(req.Timeseries[ix].Labels)
+			req.Timeseries[ix].RemoveLabel(replicaLabel)
+			if len(req.Timeseries[ix].Labels) != numLabels {
+				report.Series[ix].Modifications = append(report.Series[ix].Modifications, fmt.Sprintf("removed the HA replica label %q", replicaLabel))
+			}
+		}
+	}
+}
+
+// dryRunCheckSample is like checkSample, but it doesn't update the HA tracker state.
+func (d *Distributor) dryRunCheckSample(userID, cluster, replica string) (removeReplicaLabel bool, _ error) {
+	if cluster == "" || replica == "" {
+		return false, nil
+	}
+
+	if len(replica) > d.limits.MaxLabelValueLength(userID) {
+		return false, nil
+	}
+
+	if err := d.HATracker.dryRunCheckReplica(userID, cluster, replica); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pushDryRunRelabel mirrors prePushRelabelMiddleware.
+func (d *Distributor) pushDryRunRelabel(userID string, req *mimirpb.WriteRequest, report *PushDryRunReport) {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	for tsIdx := range req.Timeseries {
+		result := &report.Series[tsIdx]
+		if result.Status == pushDryRunStatusDropped {
+			continue
+		}
+
+		before := mimirpb.FromLabelAdaptersToMetric(req.Timeseries[tsIdx].Labels).String()
+
+		switch outcome, _ := d.relabelSeries(userID, &req.Timeseries[tsIdx], lb); outcome {
+		case relabelDroppedByRules:
+			pushDryRunDropSeries(result, pushDryRunStageRelabel, "dropped by the tenant's metric relabel configs")
+		case relabelDroppedNoLabels:
+			pushDryRunDropSeries(result, pushDryRunStageRelabel, "no labels left after applying the tenant's metric relabel configs and dropped labels")
+		default:
+			if after := mimirpb.FromLabelAdaptersToMetric(req.Timeseries[tsIdx].Labels).String(); after != before {
+				result.Modifications = append(result.Modifications, "labels changed by the tenant's label value normalization rules, metric relabel configs, dropped labels or empty label values")
+			}
+		}
+	}
+}
+
+// pushDryRunValidate mirrors prePushValidationMiddleware, without updating the tenant's metrics and
+// the ingestion rate limiter.
+func (d *Distributor) pushDryRunValidate(userID string, req *mimirpb.WriteRequest, report *PushDryRunReport) {
+	now := mtime.Now()
+	group := validation.GroupLabel(d.limits, userID, req.Timeseries)
+
+	// Discarded data is tracked by metrics which are not registered, so that the tenant's metrics are not affected.
+	sampleMetrics := validation.NewSampleValidationMetrics(nil, nil)
+	exemplarMetrics := validation.NewExemplarValidationMetrics(nil)
+	metadataMetrics := validation.NewMetadataValidationMetrics(nil)
+
+	earliestSampleTimestampMs := int64(math.MaxInt64)
+	for tsIdx, ts := range req.Timeseries {
+		if report.Series[tsIdx].Status == pushDryRunStatusDropped {
+			continue
+		}
+		for _, s := range ts.Samples {
+			earliestSampleTimestampMs = util_math.Min(earliestSampleTimestampMs, s.TimestampMs)
+		}
+		for _, h := range ts.Histograms {
+			earliestSampleTimestampMs = util_math.Min(earliestSampleTimestampMs, h.Timestamp)
+		}
+	}
+
+	var minExemplarTS int64
+	if earliestSampleTimestampMs != math.MaxInt64 {
+		minExemplarTS = earliestSampleTimestampMs - 5*time.Minute.Milliseconds()
+	}
+
+	skipLabelNameValidation := d.cfg.SkipLabelNameValidation || req.GetSkipLabelNameValidation()
+	for tsIdx := range req.Timeseries {
+		result := &report.Series[tsIdx]
+		if result.Status == pushDryRunStatusDropped {
+			continue
+		}
+
+		exemplarsBefore := len(req.Timeseries[tsIdx].Exemplars)
+		if err := d.validateSeriesWithMetrics(sampleMetrics, exemplarMetrics, nil, now, &req.Timeseries[tsIdx], userID, group, skipLabelNameValidation, minExemplarTS); err != nil {
+			pushDryRunDropSeries(result, pushDryRunStageValidate, err.Error())
+			continue
+		}
+
+		if dropped := exemplarsBefore - len(req.Timeseries[tsIdx].Exemplars); dropped > 0 {
+			result.DroppedExemplars = dropped
+			result.Modifications = append(result.Modifications, fmt.Sprintf("dropped %d exemplars because exemplars are disabled or too old", dropped))
+		}
+	}
+
+	for mIdx, m := range req.Metadata {
+		result := &report.Metadata[mIdx]
+		result.MetricFamilyName = m.GetMetricFamilyName()
+		result.Status = pushDryRunStatusAccepted
+
+		if err := validation.CleanAndValidateMetadata(metadataMetrics, d.limits, userID, m); err != nil {
+			result.Status = pushDryRunStatusDropped
+			result.Reason = err.Error()
+		}
+	}
+}
+
+func pushDryRunDropSeries(result *PushDryRunSeriesResult, stage, reason string) {
+	result.Status = pushDryRunStatusDropped
+	result.Stage = stage
+	result.Reason = reason
+	result.Modifications = nil
+	result.DroppedExemplars = result.Exemplars
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_PushDryRun(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MaxLabelNameLength = 10
+	limits.DropLabels = []string{"pod"}
+	limits.MetricRelabelConfigs = []*relabel.Config{{
+		SourceLabels: []model.LabelName{model.MetricNameLabel},
+		Action:       relabel.Drop,
+		Regex:        relabel.MustNewRegexp("dropped_metric"),
+		Separator:    relabel.DefaultRelabelConfig.Separator,
+	}}
+
+	ds, ingesters, regs := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+	})
+
+	req := mimirpb.ToWriteRequest(
+		[][]mimirpb.LabelAdapter{
+			{{Name: model.MetricNameLabel, Value: "accepted_metric"}},
+			{{Name: model.MetricNameLabel, Value: "modified_metric"}, {Name: "pod", Value: "pod-1"}},
+			{{Name: model.MetricNameLabel, Value: "dropped_metric"}},
+			{{Name: model.MetricNameLabel, Value: "invalid_metric"}, {Name: "too_long_label_name", Value: "value"}},
+		},
+		[]mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}, {TimestampMs: 3, Value: 3}, {TimestampMs: 4, Value: 4}},
+		nil,
+		[]*mimirpb.MetricMetadata{{MetricFamilyName: "accepted_metric", Type: mimirpb.COUNTER, Help: "help"}},
+		mimirpb.API,
+	)
+
+	report, err := ds[0].PushDryRun(ctx, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, PushDryRunHATrackerResult{Decision: pushDryRunHANotHA}, report.HATracker)
+	require.Len(t, report.Series, 4)
+
+	assert.Equal(t, PushDryRunSeriesResult{Labels: "accepted_metric", Status: pushDryRunStatusAccepted, Samples: 1}, report.Series[0])
+
+	assert.Equal(t, `modified_metric{pod="pod-1"}`, report.Series[1].Labels)
+	assert.Equal(t, "modified_metric", report.Series[1].FinalLabels)
+	assert.Equal(t, pushDryRunStatusModified, report.Series[1].Status)
+	assert.Len(t, report.Series[1].Modifications, 1)
+
+	assert.Equal(t, pushDryRunStatusDropped, report.Series[2].Status)
+	assert.Equal(t, pushDryRunStageRelabel, report.Series[2].Stage)
+
+	assert.Equal(t, pushDryRunStatusDropped, report.Series[3].Status)
+	assert.Equal(t, pushDryRunStageValidate, report.Series[3].Stage)
+	assert.Contains(t, report.Series[3].Reason, "too_long_label_name")
+
+	assert.Equal(t, []PushDryRunMetadataResult{{MetricFamilyName: "accepted_metric", Status: pushDryRunStatusAccepted}}, report.Metadata)
+	assert.Equal(t, PushDryRunSummary{
+		ReceivedSeries:   4,
+		AcceptedSeries:   2,
+		ModifiedSeries:   1,
+		DroppedSeries:    2,
+		AcceptedSamples:  2,
+		DroppedSamples:   2,
+		AcceptedMetadata: 1,
+	}, report.Summary)
+
+	// Nothing should have been stored, nor tracked as received or discarded.
+	for i := range ingesters {
+		assert.Empty(t, ingesters[i].series())
+	}
+	count, err := testutil.GatherAndCount(regs[0], "cortex_discarded_samples_total", "cortex_distributor_received_samples_total")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestDistributor_PushDryRun_HADedupe(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.AcceptHASamples = true
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		enableTracker:     true,
+		replicationFactor: 3,
+	})
+
+	// The dry-run of an unknown cluster accepts the replica, but doesn't elect it.
+	report, err := ds[0].PushDryRun(ctx, makeWriteRequestForGenerators(2, labelSetGenWithReplicaAndCluster("replicaA", "cluster"), nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, PushDryRunHATrackerResult{Cluster: "cluster", Replica: "replicaA", Decision: pushDryRunHAAccepted}, report.HATracker)
+	assert.Equal(t, 2, report.Summary.ModifiedSeries)
+	assert.Equal(t, `foo{bar="baz", cluster="cluster", sample="0"}`, report.Series[0].FinalLabels)
+
+	// A push from another replica is accepted, because the dry-run didn't elect replicaA.
+	_, err = ds[0].Push(ctx, makeWriteRequestForGenerators(2, labelSetGenWithReplicaAndCluster("replicaB", "cluster"), nil, nil))
+	require.NoError(t, err)
+
+	// The dry-run now reports that replicaA would be deduplicated.
+	report, err = ds[0].PushDryRun(ctx, makeWriteRequestForGenerators(2, labelSetGenWithReplicaAndCluster("replicaA", "cluster"), nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, pushDryRunHADeduplicated, report.HATracker.Decision)
+	assert.Equal(t, 2, report.Summary.DroppedSeries)
+	for _, series := range report.Series {
+		assert.Equal(t, pushDryRunStageHADedupe, series.Stage)
+	}
+}
+
+func TestDistributor_PushDryRunHandler(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+	})
+	handler := ds[0].PushDryRunHandler(100000, false)
+
+	body, err := makeWriteRequestForGenerators(3, labelSetGenForStringPairs(t, "__name__", "metric"), nil, nil).Marshal()
+	require.NoError(t, err)
+
+	t.Run("should return the report", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/push/influx-style-dry-run", bytes.NewReader(snappy.Encode(nil, body)))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var report PushDryRunReport
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		assert.Equal(t, PushDryRunSummary{ReceivedSeries: 3, AcceptedSeries: 3, AcceptedSamples: 6}, report.Summary)
+	})
+
+	t.Run("should fail on missing tenant", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/push/influx-style-dry-run", bytes.NewReader(snappy.Encode(nil, body)))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should fail on invalid request", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/push/influx-style-dry-run", bytes.NewReader([]byte("invalid")))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/uber/jaeger-client-go"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+const (
+	// tenantBaggageKey and haClusterBaggageKey are the baggage items propagated by the write path tracing
+	// to the spans of the downstream calls, including the ones to the ingesters.
+	tenantBaggageKey    = "tenant"
+	haClusterBaggageKey = "ha_cluster"
+)
+
+// pushTracingMiddleware sets the tenant of the write request as baggage of the tracing span, so that
+// it's propagated to the spans of the downstream calls, including the ones to the ingesters.
+func (d *Distributor) pushTracingMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			if userID, err := tenant.TenantID(ctx); err == nil {
+				span.SetBaggageItem(tenantBaggageKey, userID)
+			}
+		}
+
+		return next(ctx, pushReq)
+	}
+}
+
+type pushStepContextKey int
+
+const pushStepKey pushStepContextKey = 0
+
+// pushStepSpan is the tracing span of a write path step, passed through the context to the middleware.
+type pushStepSpan struct {
+	span   opentracing.Span
+	parent opentracing.Span
+
+	passed   bool
+	decision string
+}
+
+// tracePushStep wraps the input middleware to trace it with a child span of the write request span, if any and
+// sampled. The span is annotated with the series, samples and metadata passed to the next push function, and with
+// the time they were passed, so that the number of series and samples dropped by each step can be told apart. The
+// next push function is called with the write request span, so that the spans of the following steps aren't nested
+// in this one, after copying to it the baggage set by the middleware.
+func tracePushStep(name string, middleware PushWrapper) PushWrapper {
+	operationName := "distributor.push." + name
+
+	return func(next push.Func) push.Func {
+		wrapped := middleware(next)
+		traced := middleware(func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+			step, _ := ctx.Value(pushStepKey).(*pushStepSpan)
+			if step == nil {
+				return next(ctx, pushReq)
+			}
+
+			step.passed = true
+			if req, err := pushReq.WriteRequest(); err == nil {
+				series, samples, histograms, exemplars := countWriteRequestEntries(req)
+				step.span.SetTag("series", series)
+				step.span.SetTag("samples", samples)
+				step.span.SetTag("histograms", histograms)
+				step.span.SetTag("exemplars", exemplars)
+				step.span.SetTag("metadata", len(req.Metadata))
+			}
+			step.span.LogFields(otlog.String("event", "passed"))
+
+			// The baggage set by the middleware is propagated to the following steps and downstream calls.
+			parent := step.parent
+			step.span.Context().ForeachBaggageItem(func(k, v string) bool {
+				if parent.BaggageItem(k) != v {
+					parent.SetBaggageItem(k, v)
+				}
+				return true
+			})
+
+			return next(opentracing.ContextWithSpan(ctx, parent), pushReq)
+		})
+
+		return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+			parent := opentracing.SpanFromContext(ctx)
+			if parent == nil || !isSampled(parent) {
+				return wrapped(ctx, pushReq)
+			}
+
+			span, stepCtx := opentracing.StartSpanFromContext(ctx, operationName)
+			defer span.Finish()
+
+			step := &pushStepSpan{span: span, parent: parent}
+			res, err := traced(context.WithValue(stepCtx, pushStepKey, step), pushReq)
+
+			switch {
+			case step.decision != "":
+				span.SetTag("decision", step.decision)
+			case step.passed:
+				span.SetTag("decision", push.DebugStepPassed)
+			case err != nil:
+				span.SetTag("decision", push.DebugStepRejected)
+			default:
+				span.SetTag("decision", push.DebugStepDropped)
+			}
+			// The errors of the following steps are reported by their own spans.
+			if err != nil && !step.passed {
+				ext.Error.Set(span, true)
+				span.LogFields(otlog.Error(err))
+			}
+
+			return res, err
+		}
+	}
+}
+
+// setPushStepDecision overrides the decision the span of the write path step is annotated with, for the
+// middlewares taking a different decision after the next push function returns.
+func setPushStepDecision(ctx context.Context, decision string) {
+	if step, _ := ctx.Value(pushStepKey).(*pushStepSpan); step != nil {
+		step.decision = decision
+	}
+}
+
+// isSampled returns whether the span is sampled. The spans of tracers other than Jaeger are considered sampled.
+func isSampled(span opentracing.Span) bool {
+	sctx, ok := span.Context().(jaeger.SpanContext)
+	return !ok || sctx.IsSampled()
+}
+
+// countWriteRequestEntries returns the number of series, float samples, histogram samples and exemplars
+// in the write request.
+func countWriteRequestEntries(req *mimirpb.WriteRequest) (series, samples, histograms, exemplars int) {
+	for _, ts := range req.Timeseries {
+		samples += len(ts.Samples)
+		histograms += len(ts.Histograms)
+		exemplars += len(ts.Exemplars)
+	}
+	return len(req.Timeseries), samples, histograms, exemplars
+}
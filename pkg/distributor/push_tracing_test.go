@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func withMockTracer(t *testing.T) *mocktracer.MockTracer {
+	tracer := mocktracer.New()
+	previous := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() { opentracing.SetGlobalTracer(previous) })
+	return tracer
+}
+
+func TestDistributor_PushTracing(t *testing.T) {
+	tracer := withMockTracer(t)
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.AcceptHASamples = true
+	limits.MaxLabelValueLength = 15
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+		enableTracker:     true,
+	})
+
+	req := makeWriteRequestForGenerators(2, labelSetGenWithReplicaAndCluster("replica", "cluster"), nil, nil)
+	// The last series is dropped by the validation, because of its label value too long.
+	req.Timeseries[1].Labels = append(req.Timeseries[1].Labels, mimirpb.LabelAdapter{Name: "long", Value: "a-too-long-label-value"})
+
+	root := tracer.StartSpan("push")
+	ctx := opentracing.ContextWithSpan(user.InjectOrgID(context.Background(), "user"), root)
+	_, err := ds[0].PushWithMiddlewares(ctx, push.NewParsedRequest(req))
+	require.Error(t, err)
+	root.Finish()
+
+	assert.Equal(t, "user", root.BaggageItem(tenantBaggageKey))
+	assert.Equal(t, "cluster", root.BaggageItem(haClusterBaggageKey))
+
+	steps := map[string]*mocktracer.MockSpan{}
+	for _, span := range tracer.FinishedSpans() {
+		steps[span.OperationName] = span
+
+		// The steps aren't nested in each other.
+		if strings.HasPrefix(span.OperationName, "distributor.push.") {
+			assert.Equal(t, root.(*mocktracer.MockSpan).SpanContext.SpanID, span.ParentID, span.OperationName)
+		}
+	}
+
+	for _, name := range []string{"limits", "metrics", "ha_dedupe", "relabel"} {
+		span := steps["distributor.push."+name]
+		require.NotNil(t, span, name)
+		assert.Equal(t, push.DebugStepPassed, span.Tag("decision"), name)
+		assert.Equal(t, 2, span.Tag("series"), name)
+		assert.Equal(t, 2, span.Tag("samples"), name)
+	}
+
+	validation := steps["distributor.push.validation"]
+	require.NotNil(t, validation)
+	assert.Equal(t, push.DebugStepPassed, validation.Tag("decision"))
+	assert.Equal(t, 1, validation.Tag("series"))
+	assert.Equal(t, 1, validation.Tag("samples"))
+}
+
+func TestTracePushStep(t *testing.T) {
+	tracer := withMockTracer(t)
+
+	tests := map[string]struct {
+		middleware       PushWrapper
+		expectedDecision string
+		expectedErr      bool
+	}{
+		"passed": {
+			middleware:       func(next push.Func) push.Func { return next },
+			expectedDecision: push.DebugStepPassed,
+		},
+		"rejected": {
+			middleware: func(push.Func) push.Func {
+				return func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+					return nil, errors.New("rejected")
+				}
+			},
+			expectedDecision: push.DebugStepRejected,
+			expectedErr:      true,
+		},
+		"dropped": {
+			middleware: func(push.Func) push.Func {
+				return func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+					return &mimirpb.WriteResponse{}, nil
+				}
+			},
+			expectedDecision: push.DebugStepDropped,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tracer.Reset()
+
+			root := tracer.StartSpan("push")
+			ctx := opentracing.ContextWithSpan(context.Background(), root)
+
+			var nextSpan opentracing.Span
+			next := func(ctx context.Context, _ *push.Request) (*mimirpb.WriteResponse, error) {
+				nextSpan = opentracing.SpanFromContext(ctx)
+				return &mimirpb.WriteResponse{}, nil
+			}
+
+			_, err := tracePushStep("step", testData.middleware)(next)(ctx, push.NewParsedRequest(makeWriteRequest(time.Now().UnixMilli(), 3, 0, false, false, "foo")))
+			if testData.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			spans := tracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "distributor.push.step", spans[0].OperationName)
+			assert.Equal(t, testData.expectedDecision, spans[0].Tag("decision"))
+			assert.Equal(t, testData.expectedErr, spans[0].Tag("error") == true)
+
+			// The next push function is called with the parent span.
+			if testData.expectedDecision == push.DebugStepPassed {
+				assert.Equal(t, root, nextSpan)
+				assert.Equal(t, 3, spans[0].Tag("series"))
+			}
+		})
+	}
+
+	t.Run("decision overridden by the middleware", func(t *testing.T) {
+		tracer.Reset()
+
+		root := tracer.StartSpan("push")
+		ctx := opentracing.ContextWithSpan(context.Background(), root)
+
+		// The middleware takes a decision after the next push function failed, like the spill queue does.
+		middleware := func(next push.Func) push.Func {
+			return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+				if _, err := next(ctx, pushReq); err != nil {
+					setPushStepDecision(ctx, spillQueueDecisionSpilled)
+				}
+				return &mimirpb.WriteResponse{}, nil
+			}
+		}
+		_, err := tracePushStep("step", middleware)(func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+			return nil, errors.New("ingesters unavailable")
+		})(ctx, push.NewParsedRequest(&mimirpb.WriteRequest{}))
+		require.NoError(t, err)
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, spillQueueDecisionSpilled, spans[0].Tag("decision"))
+	})
+
+	t.Run("error of the following steps", func(t *testing.T) {
+		tracer.Reset()
+
+		root := tracer.StartSpan("push")
+		ctx := opentracing.ContextWithSpan(context.Background(), root)
+
+		_, err := tracePushStep("step", func(next push.Func) push.Func { return next })(func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+			return nil, errors.New("ingesters unavailable")
+		})(ctx, push.NewParsedRequest(&mimirpb.WriteRequest{}))
+		require.Error(t, err)
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, push.DebugStepPassed, spans[0].Tag("decision"))
+		assert.Nil(t, spans[0].Tag("error"))
+	})
+
+	t.Run("parent span not sampled", func(t *testing.T) {
+		tracer.Reset()
+
+		jaegerTracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+		t.Cleanup(func() { _ = closer.Close() })
+		root := jaegerTracer.StartSpan("push")
+		ctx := opentracing.ContextWithSpan(context.Background(), root)
+
+		var nextSpan opentracing.Span
+		_, err := tracePushStep("step", func(next push.Func) push.Func { return next })(func(ctx context.Context, _ *push.Request) (*mimirpb.WriteResponse, error) {
+			nextSpan = opentracing.SpanFromContext(ctx)
+			return &mimirpb.WriteResponse{}, nil
+		})(ctx, push.NewParsedRequest(&mimirpb.WriteRequest{}))
+		require.NoError(t, err)
+		assert.Empty(t, tracer.FinishedSpans())
+		assert.Equal(t, root, nextSpan)
+	})
+
+	t.Run("no parent span", func(t *testing.T) {
+		tracer.Reset()
+
+		_, err := tracePushStep("step", func(next push.Func) push.Func { return next })(func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+			return &mimirpb.WriteResponse{}, nil
+		})(context.Background(), push.NewParsedRequest(&mimirpb.WriteRequest{}))
+		require.NoError(t, err)
+		assert.Empty(t, tracer.FinishedSpans())
+	})
+}
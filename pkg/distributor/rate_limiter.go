@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/limiter"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter is a multi-tenant local rate limiter based on golang.org/x/time/rate.
+// It requires a strategy in input, which is used to get the limit and burst settings
+// for each tenant. The limit and burst are rechecked every recheckPeriod and, unlike
+// dskit's limiter.RateLimiter, can also be rechecked on demand calling recheckAll(),
+// which is used to apply a new global limit as soon as the number of healthy distributors
+// changes.
+type rateLimiter struct {
+	strategy      limiter.RateLimiterStrategy
+	recheckPeriod time.Duration
+
+	tenantsLock sync.RWMutex
+	tenants     map[string]*tenantRateLimiter
+}
+
+type tenantRateLimiter struct {
+	limiter   *rate.Limiter
+	recheckAt time.Time
+}
+
+func newRateLimiter(strategy limiter.RateLimiterStrategy, recheckPeriod time.Duration) *rateLimiter {
+	return &rateLimiter{
+		strategy:      strategy,
+		recheckPeriod: recheckPeriod,
+		tenants:       map[string]*tenantRateLimiter{},
+	}
+}
+
+// AllowN reports whether n tokens may be consumed at time now.
+func (l *rateLimiter) AllowN(now time.Time, tenantID string, n int) bool {
+	return l.getTenantLimiter(now, tenantID).AllowN(now, n)
+}
+
+// Limit returns the currently configured maximum overall tokens rate.
+func (l *rateLimiter) Limit(now time.Time, tenantID string) float64 {
+	return float64(l.getTenantLimiter(now, tenantID).Limit())
+}
+
+// recheckAll forces the limit and burst of all tenants to be rechecked on their next use.
+// The tokens currently available to each tenant are preserved.
+func (l *rateLimiter) recheckAll() {
+	l.tenantsLock.Lock()
+	defer l.tenantsLock.Unlock()
+
+	for _, entry := range l.tenants {
+		entry.recheckAt = time.Time{}
+	}
+}
+
+func (l *rateLimiter) getTenantLimiter(now time.Time, tenantID string) *rate.Limiter {
+	// Check if the per-tenant limiter already exists and if should
+	// be rechecked because the recheck period has elapsed.
+	l.tenantsLock.RLock()
+	entry, ok := l.tenants[tenantID]
+	recheck := ok && !now.Before(entry.recheckAt)
+	l.tenantsLock.RUnlock()
+
+	if ok && recheck {
+		return l.recheckTenantLimiter(now, tenantID)
+	} else if ok {
+		return entry.limiter
+	}
+
+	// Create a new limiter.
+	lim := rate.NewLimiter(rate.Limit(l.strategy.Limit(tenantID)), l.strategy.Burst(tenantID))
+
+	l.tenantsLock.Lock()
+	defer l.tenantsLock.Unlock()
+
+	if entry, ok = l.tenants[tenantID]; !ok {
+		entry = &tenantRateLimiter{limiter: lim, recheckAt: now.Add(l.recheckPeriod)}
+		l.tenants[tenantID] = entry
+	}
+
+	return entry.limiter
+}
+
+func (l *rateLimiter) recheckTenantLimiter(now time.Time, tenantID string) *rate.Limiter {
+	limit := rate.Limit(l.strategy.Limit(tenantID))
+	burst := l.strategy.Burst(tenantID)
+
+	l.tenantsLock.Lock()
+	defer l.tenantsLock.Unlock()
+
+	entry := l.tenants[tenantID]
+
+	// Check again if the recheck is due, because it may have
+	// already been rechecked in the meanwhile.
+	if now.Before(entry.recheckAt) {
+		return entry.limiter
+	}
+
+	// Ensure the limiter's limit and burst match the expected value.
+	if entry.limiter.Limit() != limit {
+		entry.limiter.SetLimitAt(now, limit)
+	}
+	if entry.limiter.Burst() != burst {
+		entry.limiter.SetBurstAt(now, burst)
+	}
+
+	entry.recheckAt = now.Add(l.recheckPeriod)
+
+	return entry.limiter
+}
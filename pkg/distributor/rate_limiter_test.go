@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRateStrategy struct {
+	limit float64
+	burst int
+}
+
+func (s *staticRateStrategy) Limit(string) float64 { return s.limit }
+func (s *staticRateStrategy) Burst(string) int     { return s.burst }
+
+func TestRateLimiter_RecheckPeriod(t *testing.T) {
+	strategy := &staticRateStrategy{limit: 10, burst: 10}
+	l := newRateLimiter(strategy, 10*time.Second)
+	now := time.Now()
+
+	assert.Equal(t, float64(10), l.Limit(now, "tenant"))
+
+	// The new limit is not applied until the recheck period has elapsed.
+	strategy.limit = 5
+	assert.Equal(t, float64(10), l.Limit(now.Add(time.Second), "tenant"))
+	assert.Equal(t, float64(5), l.Limit(now.Add(10*time.Second), "tenant"))
+}
+
+func TestRateLimiter_RecheckAll(t *testing.T) {
+	strategy := &staticRateStrategy{limit: 10, burst: 10}
+	l := newRateLimiter(strategy, time.Hour)
+	now := time.Now()
+
+	assert.Equal(t, float64(10), l.Limit(now, "tenant-1"))
+	assert.Equal(t, float64(10), l.Limit(now, "tenant-2"))
+
+	// Consume some tokens of tenant-1.
+	assert.True(t, l.AllowN(now, "tenant-1", 8))
+
+	// The new limit is applied to all tenants as soon as they're rechecked.
+	strategy.limit = 5
+	l.recheckAll()
+	assert.Equal(t, float64(5), l.Limit(now, "tenant-1"))
+	assert.Equal(t, float64(5), l.Limit(now, "tenant-2"))
+
+	// The tokens consumed before the recheck are preserved.
+	assert.False(t, l.AllowN(now, "tenant-1", 3))
+	assert.True(t, l.AllowN(now, "tenant-1", 2))
+}
@@ -7,6 +7,7 @@ package distributor
 
 import (
 	"math"
+	"strings"
 
 	"github.com/grafana/dskit/limiter"
 	"golang.org/x/time/rate"
@@ -94,6 +95,39 @@ func (s *ingestionRateStrategy) Burst(tenantID string) int {
 	return s.limits.IngestionBurstSize(tenantID)
 }
 
+type ingestionBytesRateStrategy struct {
+	limits *validation.Overrides
+}
+
+func newIngestionBytesRateStrategy(limits *validation.Overrides) limiter.RateLimiterStrategy {
+	return &ingestionBytesRateStrategy{
+		limits: limits,
+	}
+}
+
+func (s *ingestionBytesRateStrategy) Limit(tenantID string) float64 {
+	if lm := s.limits.IngestionBytesRate(tenantID); lm > 0 {
+		return lm
+	}
+	return float64(rate.Inf)
+}
+
+func (s *ingestionBytesRateStrategy) Burst(tenantID string) int {
+	limit := s.limits.IngestionBytesRate(tenantID)
+	if limit <= 0 {
+		// Burst is ignored when limit = rate.Inf
+		return 0
+	}
+	if lm := s.limits.IngestionBytesBurstSize(tenantID); lm > 0 {
+		return lm
+	}
+	// Fall back to the rate limit, so that the rate limit is enforced even without a burst size.
+	if limit >= math.MaxInt {
+		return math.MaxInt
+	}
+	return int(math.Ceil(limit))
+}
+
 type infiniteStrategy struct{}
 
 func newInfiniteRateStrategy() limiter.RateLimiterStrategy {
@@ -108,3 +142,40 @@ func (s *infiniteStrategy) Burst(_ string) int {
 	// Burst is ignored when limit = rate.Inf
 	return 0
 }
+
+// ingestionQuotaRateStrategy is the strategy of the rate limiters of the ingestion quotas, identified by the key
+// returned by ingestionQuotaKey. The quotas not configured anymore aren't rate limited.
+type ingestionQuotaRateStrategy struct {
+	limits *validation.Overrides
+}
+
+func newIngestionQuotaRateStrategy(limits *validation.Overrides) limiter.RateLimiterStrategy {
+	return &ingestionQuotaRateStrategy{
+		limits: limits,
+	}
+}
+
+func (s *ingestionQuotaRateStrategy) Limit(key string) float64 {
+	if q, ok := s.quota(key); ok {
+		return q.SamplesPerSecond
+	}
+	return float64(rate.Inf)
+}
+
+func (s *ingestionQuotaRateStrategy) Burst(key string) int {
+	if q, ok := s.quota(key); ok {
+		return q.BurstSize()
+	}
+	// Burst is ignored when limit = rate.Inf
+	return 0
+}
+
+func (s *ingestionQuotaRateStrategy) quota(key string) (validation.IngestionQuota, bool) {
+	userID, name, _ := strings.Cut(key, ingestionQuotaKeySeparator)
+	for _, q := range s.limits.IngestionQuotas(userID) {
+		if q.Name == name {
+			return q, true
+		}
+	}
+	return validation.IngestionQuota{}, false
+}
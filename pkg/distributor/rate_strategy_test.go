@@ -33,6 +33,48 @@ func TestIngestionRateStrategy(t *testing.T) {
 		assert.Equal(t, strategy.Burst("test"), 10000)
 	})
 
+	t.Run("bytes rate limiter should share the limit across the number of distributors", func(t *testing.T) {
+		overrides, err := validation.NewOverrides(validation.Limits{
+			IngestionBytesRate:      float64(1000),
+			IngestionBytesBurstSize: 10000,
+		}, nil)
+		require.NoError(t, err)
+
+		mockRing := newReadLifecyclerMock()
+		mockRing.On("HealthyInstancesCount").Return(2)
+
+		strategy := newGlobalRateStrategy(newIngestionBytesRateStrategy(overrides), mockRing)
+		assert.Equal(t, strategy.Limit("test"), float64(500))
+		assert.Equal(t, strategy.Burst("test"), 10000)
+	})
+
+	t.Run("bytes rate limiter should be unlimited if the limit is disabled", func(t *testing.T) {
+		overrides, err := validation.NewOverrides(validation.Limits{
+			IngestionBytesRate:      0,
+			IngestionBytesBurstSize: 10000,
+		}, nil)
+		require.NoError(t, err)
+
+		strategy := newIngestionBytesRateStrategy(overrides)
+		assert.Equal(t, strategy.Limit("test"), float64(rate.Inf))
+		assert.Equal(t, strategy.Burst("test"), 0)
+	})
+
+	t.Run("bytes rate limiter should use the limit as burst size if the burst size is disabled", func(t *testing.T) {
+		overrides, err := validation.NewOverrides(validation.Limits{
+			IngestionBytesRate:      float64(1000.5),
+			IngestionBytesBurstSize: 0,
+		}, nil)
+		require.NoError(t, err)
+
+		mockRing := newReadLifecyclerMock()
+		mockRing.On("HealthyInstancesCount").Return(2)
+
+		strategy := newGlobalRateStrategy(newIngestionBytesRateStrategy(overrides), mockRing)
+		assert.Equal(t, strategy.Limit("test"), float64(500.25))
+		assert.Equal(t, strategy.Burst("test"), 1001)
+	})
+
 	t.Run("infinite rate limiter should return unlimited settings", func(t *testing.T) {
 		strategy := newInfiniteRateStrategy()
 
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// SeriesShardingPreview describes how the distributor shards the series of a tenant among the ingesters.
+type SeriesShardingPreview struct {
+	Tenant            string                        `json:"tenant"`
+	ShardSize         int                           `json:"shard_size"`
+	ReplicationFactor int                           `json:"replication_factor"`
+	ShardingScheme    string                        `json:"sharding_scheme"`
+	MigrationScheme   string                        `json:"migration_sharding_scheme,omitempty"`
+	Subring           SeriesShardingPreviewSubring  `json:"subring"`
+	Series            []SeriesShardingPreviewSeries `json:"series"`
+}
+
+// SeriesShardingPreviewSubring is the shuffle-shard subring of the ingesters the tenant's series are written to.
+type SeriesShardingPreviewSubring struct {
+	// InstancesCount is the number of ingesters in the subring, including the unhealthy ones.
+	InstancesCount int `json:"instances_count"`
+
+	// Instances are the healthy ingesters of the subring, sorted by zone and address.
+	Instances []SeriesShardingPreviewIngester `json:"instances"`
+}
+
+// SeriesShardingPreviewSeries describes where a series of the tenant is written to.
+type SeriesShardingPreviewSeries struct {
+	Labels    string                          `json:"labels"`
+	Token     uint32                          `json:"token"`
+	Ingesters []SeriesShardingPreviewIngester `json:"ingesters,omitempty"`
+	Error     string                          `json:"error,omitempty"`
+
+	// Migration describes where the series is also written to while the tenant migrates between sharding schemes.
+	Migration *SeriesShardingPreviewMigration `json:"migration,omitempty"`
+}
+
+// SeriesShardingPreviewMigration describes where a series is written to with the migration sharding scheme.
+type SeriesShardingPreviewMigration struct {
+	Token     uint32                          `json:"token"`
+	Ingesters []SeriesShardingPreviewIngester `json:"ingesters,omitempty"`
+	Error     string                          `json:"error,omitempty"`
+}
+
+// SeriesShardingPreviewIngester is an ingester of the ring.
+type SeriesShardingPreviewIngester struct {
+	Addr  string `json:"addr"`
+	Zone  string `json:"zone,omitempty"`
+	State string `json:"state"`
+}
+
+// SeriesShardingPreviewHandler replies with a SeriesShardingPreview of the series of the tenant passed with the
+// user parameter, from the series[] parameters, in the Prometheus text notation. The shard_size parameter
+// overrides the tenant's shard size, to preview how a change of -distributor.ingestion-tenant-shard-size would
+// affect the tenant. It's an admin endpoint, so the tenant isn't the authenticated one.
+func (d *Distributor) SeriesShardingPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("error parsing form values: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Form.Get("user")
+	if userID == "" {
+		http.Error(w, "the user parameter is required", http.StatusBadRequest)
+		return
+	}
+	if err := tenant.ValidTenantID(userID); err != nil {
+		http.Error(w, fmt.Sprintf("invalid user %q: %v", userID, err), http.StatusBadRequest)
+		return
+	}
+
+	series := make([][]mimirpb.LabelAdapter, 0, len(r.Form["series[]"]))
+	for _, s := range r.Form["series[]"] {
+		lbls, err := parser.ParseMetric(s)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid series[] %q: %v", s, err), http.StatusBadRequest)
+			return
+		}
+		series = append(series, mimirpb.FromLabelsToLabelAdapters(lbls))
+	}
+	if len(series) == 0 {
+		http.Error(w, "at least one series[] is required", http.StatusBadRequest)
+		return
+	}
+
+	shardSize := -1
+	if v := r.Form.Get("shard_size"); v != "" {
+		var err error
+		if shardSize, err = strconv.Atoi(v); err != nil || shardSize < 0 {
+			http.Error(w, fmt.Sprintf("invalid shard_size %q: must be a non-negative integer", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	util.WriteJSONResponse(w, d.SeriesShardingPreview(userID, series, shardSize))
+}
+
+// SeriesShardingPreview computes the token of each input series, and the ingesters it's written to by the
+// push path, out of the tenant's shuffle-shard subring. The tenant's shard size is used if shardSize is negative.
+// The labels of the series are hashed like the push path does, which depends on their order with the v1 sharding scheme.
+func (d *Distributor) SeriesShardingPreview(userID string, series [][]mimirpb.LabelAdapter, shardSize int) *SeriesShardingPreview {
+	if shardSize < 0 {
+		shardSize = d.limits.IngestionTenantShardSize(userID)
+	}
+
+	scheme, migrationScheme := d.shardingScheme(userID), d.migrationShardingScheme(userID)
+	subRing := d.ingestersRing.ShuffleShard(userID, shardSize)
+	preview := &SeriesShardingPreview{
+		Tenant:            userID,
+		ShardSize:         shardSize,
+		ReplicationFactor: subRing.ReplicationFactor(),
+		ShardingScheme:    shardingSchemeName(scheme),
+		MigrationScheme:   shardingSchemeName(migrationScheme),
+		Subring: SeriesShardingPreviewSubring{
+			InstancesCount: subRing.InstancesCount(),
+			Instances:      []SeriesShardingPreviewIngester{},
+		},
+		Series: make([]SeriesShardingPreviewSeries, 0, len(series)),
+	}
+
+	// The unhealthy ingesters are still part of the subring, but aren't returned by the ring.
+	if healthy, err := subRing.GetAllHealthy(ring.Reporting); err == nil {
+		preview.Subring.Instances = previewIngesters(healthy.Instances)
+	}
+
+	bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+	for _, lbls := range series {
+		s := SeriesShardingPreviewSeries{
+			Labels: mimirpb.FromLabelAdaptersToMetric(lbls).String(),
+			Token:  scheme.tokenForLabels(userID, lbls),
+		}
+
+		if set, err := subRing.Get(s.Token, ring.WriteNoExtend, bufDescs, bufHosts, bufZones); err != nil {
+			s.Error = err.Error()
+		} else {
+			s.Ingesters = previewIngesters(set.Instances)
+		}
+
+		if migrationScheme != nil {
+			s.Migration = &SeriesShardingPreviewMigration{Token: migrationScheme.tokenForLabels(userID, lbls)}
+			if set, err := subRing.Get(s.Migration.Token, ring.WriteNoExtend, bufDescs, bufHosts, bufZones); err != nil {
+				s.Migration.Error = err.Error()
+			} else {
+				s.Migration.Ingesters = previewIngesters(set.Instances)
+			}
+		}
+		preview.Series = append(preview.Series, s)
+	}
+
+	return preview
+}
+
+func previewIngesters(instances []ring.InstanceDesc) []SeriesShardingPreviewIngester {
+	ingesters := make([]SeriesShardingPreviewIngester, 0, len(instances))
+	for _, inst := range instances {
+		ingesters = append(ingesters, SeriesShardingPreviewIngester{
+			Addr:  inst.Addr,
+			Zone:  inst.Zone,
+			State: inst.State.String(),
+		})
+	}
+
+	sort.Slice(ingesters, func(i, j int) bool {
+		if ingesters[i].Zone != ingesters[j].Zone {
+			return ingesters[i].Zone < ingesters[j].Zone
+		}
+		return ingesters[i].Addr < ingesters[j].Addr
+	})
+	return ingesters
+}
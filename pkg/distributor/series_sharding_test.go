@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_SeriesShardingPreview(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      6,
+		happyIngesters:    6,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+		shuffleShardSize:  3,
+		ingesterZones:     []string{"zone-a", "zone-b", "zone-c"},
+	})
+
+	series := [][]mimirpb.LabelAdapter{
+		mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "up", "job", "a")),
+		mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "up", "job", "b")),
+	}
+
+	for name, tc := range map[string]struct {
+		shardSize         int
+		expectedShardSize int
+		expectedInstances int
+	}{
+		"should use the tenant's shard size": {
+			shardSize:         -1,
+			expectedShardSize: 3,
+			expectedInstances: 3,
+		},
+		"should use the input shard size": {
+			shardSize:         0,
+			expectedShardSize: 0,
+			expectedInstances: 6,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			preview := ds[0].SeriesShardingPreview("user", series, tc.shardSize)
+
+			assert.Equal(t, "user", preview.Tenant)
+			assert.Equal(t, tc.expectedShardSize, preview.ShardSize)
+			assert.Equal(t, 3, preview.ReplicationFactor)
+			assert.Equal(t, validation.SeriesShardingSchemeV1, preview.ShardingScheme)
+			assert.Empty(t, preview.MigrationScheme)
+			assert.Equal(t, tc.expectedInstances, preview.Subring.InstancesCount)
+			require.Len(t, preview.Subring.Instances, tc.expectedInstances)
+
+			subring := map[string]bool{}
+			for _, inst := range preview.Subring.Instances {
+				assert.Equal(t, "ACTIVE", inst.State)
+				subring[inst.Addr] = true
+			}
+
+			require.Len(t, preview.Series, len(series))
+			for i, s := range preview.Series {
+				assert.Equal(t, mimirpb.FromLabelAdaptersToMetric(series[i]).String(), s.Labels)
+				assert.Equal(t, shardByAllLabels("user", series[i]), s.Token)
+				assert.Empty(t, s.Error)
+				assert.Nil(t, s.Migration)
+
+				// Each series is written to one ingester of each zone, out of the subring.
+				require.Len(t, s.Ingesters, 3)
+				for j, ing := range s.Ingesters {
+					assert.Equal(t, []string{"zone-a", "zone-b", "zone-c"}[j], ing.Zone)
+					assert.True(t, subring[ing.Addr], ing.Addr)
+				}
+			}
+		})
+	}
+}
+
+func TestDistributor_SeriesShardingPreview_Migration(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.SeriesShardingScheme = validation.SeriesShardingSchemeV2
+	limits.SeriesShardingMigration = validation.SeriesShardingSchemeV1
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 1,
+	})
+
+	series := [][]mimirpb.LabelAdapter{mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "up", "job", "a"))}
+	preview := ds[0].SeriesShardingPreview("user", series, -1)
+
+	assert.Equal(t, validation.SeriesShardingSchemeV2, preview.ShardingScheme)
+	assert.Equal(t, validation.SeriesShardingSchemeV1, preview.MigrationScheme)
+
+	require.Len(t, preview.Series, 1)
+	assert.Equal(t, shardingSchemeV2{}.tokenForLabels("user", series[0]), preview.Series[0].Token)
+	assert.Len(t, preview.Series[0].Ingesters, 1)
+	require.NotNil(t, preview.Series[0].Migration)
+	assert.Equal(t, shardByAllLabels("user", series[0]), preview.Series[0].Migration.Token)
+	assert.Len(t, preview.Series[0].Migration.Ingesters, 1)
+	assert.Empty(t, preview.Series[0].Migration.Error)
+}
+
+func TestDistributor_SeriesShardingPreviewHandler(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            &limits,
+		replicationFactor: 3,
+	})
+
+	for name, tc := range map[string]struct {
+		params         url.Values
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should fail without user": {
+			params:         url.Values{"series[]": []string{`up`}},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "the user parameter is required\n",
+		},
+		"should fail with an invalid user": {
+			params:         url.Values{"user": []string{"../user"}, "series[]": []string{`up`}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"should fail without series": {
+			params:         url.Values{"user": []string{"user"}},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "at least one series[] is required\n",
+		},
+		"should fail with an invalid series": {
+			params:         url.Values{"user": []string{"user"}, "series[]": []string{`up{`}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"should fail with an invalid shard size": {
+			params:         url.Values{"user": []string{"user"}, "series[]": []string{`up`}, "shard_size": []string{"-1"}},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid shard_size \"-1\": must be a non-negative integer\n",
+		},
+		"should return the preview of the series": {
+			params:         url.Values{"user": []string{"user"}, "series[]": []string{`up{job="a"}`}, "shard_size": []string{"2"}},
+			expectedStatus: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The tenant isn't taken from the authenticated one.
+			req := httptest.NewRequest(http.MethodGet, "/distributor/series_sharding?"+tc.params.Encode(), nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "another-user"))
+
+			resp := httptest.NewRecorder()
+			ds[0].SeriesShardingPreviewHandler(resp, req)
+
+			require.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, resp.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var preview SeriesShardingPreview
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &preview))
+			assert.Equal(t, 2, preview.ShardSize)
+			assert.Equal(t, 2, preview.Subring.InstancesCount)
+			require.Len(t, preview.Series, 1)
+			assert.Equal(t, `up{job="a"}`, preview.Series[0].Labels)
+			assert.Empty(t, preview.Series[0].Error)
+			// The subring is smaller than the replication factor, so the series is written to all its ingesters.
+			assert.Len(t, preview.Series[0].Ingesters, 2)
+		})
+	}
+}
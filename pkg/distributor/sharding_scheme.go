@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// shardingScheme computes the ring tokens of the series and metadata of a tenant. The ring tokens determine
+// the ingesters the series and metadata are written to, so changing the scheme of a tenant moves its series.
+type shardingScheme interface {
+	tokenForLabels(userID string, labels []mimirpb.LabelAdapter) uint32
+	tokenForMetadata(userID string, metricName string) uint32
+}
+
+// shardingSchemeByName returns the sharding scheme with the given name, or nil if the name is empty.
+// The names are validated by the limits, so unknown names fall back to the v1 scheme.
+func shardingSchemeByName(name string) shardingScheme {
+	switch name {
+	case "":
+		return nil
+	case validation.SeriesShardingSchemeV2:
+		return shardingSchemeV2{}
+	default:
+		return shardingSchemeV1{}
+	}
+}
+
+// shardingSchemeName returns the name of the sharding scheme, or an empty string if nil.
+func shardingSchemeName(scheme shardingScheme) string {
+	switch scheme.(type) {
+	case nil:
+		return ""
+	case shardingSchemeV2:
+		return validation.SeriesShardingSchemeV2
+	default:
+		return validation.SeriesShardingSchemeV1
+	}
+}
+
+// shardingSchemeV1 hashes the tenant ID and the labels with FNV-1a. The token depends on the order of the labels.
+type shardingSchemeV1 struct{}
+
+func (shardingSchemeV1) tokenForLabels(userID string, labels []mimirpb.LabelAdapter) uint32 {
+	return shardByAllLabels(userID, labels)
+}
+
+func (shardingSchemeV1) tokenForMetadata(userID string, metricName string) uint32 {
+	return shardByMetricName(userID, metricName)
+}
+
+// shardingSchemeV2 hashes the tenant ID and the labels sorted by name with xxhash, so that the token doesn't
+// depend on the order of the labels.
+type shardingSchemeV2 struct{}
+
+// shardingSchemeV2Separator separates the hashed strings, so that different sequences of strings having the same
+// concatenation don't get the same token. It's not a valid UTF-8 byte, so it can't be part of valid label names
+// and values.
+var shardingSchemeV2Separator = []byte{0xff}
+
+func (shardingSchemeV2) tokenForLabels(userID string, labels []mimirpb.LabelAdapter) uint32 {
+	h := xxhash.New()
+	_, _ = h.WriteString(userID)
+	_, _ = h.Write(shardingSchemeV2Separator)
+
+	// The labels are sorted by name once validated, so it's rare to hash them out of order.
+	if !labelAdaptersSorted(labels) {
+		labels = sortedLabelAdapters(labels)
+	}
+	for _, l := range labels {
+		_, _ = h.WriteString(l.Name)
+		_, _ = h.Write(shardingSchemeV2Separator)
+		_, _ = h.WriteString(l.Value)
+		_, _ = h.Write(shardingSchemeV2Separator)
+	}
+	return foldToken(h.Sum64())
+}
+
+func (shardingSchemeV2) tokenForMetadata(userID string, metricName string) uint32 {
+	h := xxhash.New()
+	_, _ = h.WriteString(userID)
+	_, _ = h.Write(shardingSchemeV2Separator)
+	_, _ = h.WriteString(metricName)
+	return foldToken(h.Sum64())
+}
+
+// foldToken folds a 64-bit hash into a 32-bit ring token.
+func foldToken(h uint64) uint32 {
+	return uint32(h) ^ uint32(h>>32)
+}
+
+func labelAdaptersSorted(labels []mimirpb.LabelAdapter) bool {
+	for i := 1; i < len(labels); i++ {
+		if labels[i-1].Name > labels[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedLabelAdapters returns a copy of the labels sorted by name. The input labels aren't modified.
+func sortedLabelAdapters(labels []mimirpb.LabelAdapter) []mimirpb.LabelAdapter {
+	sorted := make([]mimirpb.LabelAdapter, len(labels))
+	copy(sorted, labels)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestShardingSchemeV1(t *testing.T) {
+	lbls := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "up", "job", "a"))
+
+	// The v1 scheme must keep the tokens computed before the sharding schemes were introduced.
+	assert.Equal(t, shardByAllLabels("user", lbls), shardingSchemeV1{}.tokenForLabels("user", lbls))
+	assert.Equal(t, shardByMetricName("user", "up"), shardingSchemeV1{}.tokenForMetadata("user", "up"))
+}
+
+func TestShardingSchemeV2(t *testing.T) {
+	scheme := shardingSchemeV2{}
+	sorted := []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "a"}}
+	unsorted := []mimirpb.LabelAdapter{{Name: "job", Value: "a"}, {Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}}
+	unsortedCopy := append([]mimirpb.LabelAdapter(nil), unsorted...)
+
+	// The token doesn't depend on the order of the labels, and the labels aren't modified.
+	assert.Equal(t, scheme.tokenForLabels("user", sorted), scheme.tokenForLabels("user", unsorted))
+	assert.Equal(t, unsortedCopy, unsorted)
+
+	// The token depends on the tenant and on the boundaries between the strings.
+	assert.NotEqual(t, scheme.tokenForLabels("user", sorted), scheme.tokenForLabels("user-2", sorted))
+	assert.NotEqual(t,
+		scheme.tokenForLabels("user", []mimirpb.LabelAdapter{{Name: "a", Value: "bc"}}),
+		scheme.tokenForLabels("user", []mimirpb.LabelAdapter{{Name: "ab", Value: "c"}}))
+
+	assert.NotEqual(t, scheme.tokenForMetadata("user", "up"), scheme.tokenForMetadata("user-2", "up"))
+	assert.NotEqual(t, scheme.tokenForMetadata("user", "up"), scheme.tokenForMetadata("user", "down"))
+}
+
+func TestShardingSchemeByName(t *testing.T) {
+	assert.Nil(t, shardingSchemeByName(""))
+	assert.Equal(t, shardingSchemeV1{}, shardingSchemeByName(validation.SeriesShardingSchemeV1))
+	assert.Equal(t, shardingSchemeV2{}, shardingSchemeByName(validation.SeriesShardingSchemeV2))
+
+	for _, name := range []string{"", validation.SeriesShardingSchemeV1, validation.SeriesShardingSchemeV2} {
+		assert.Equal(t, name, shardingSchemeName(shardingSchemeByName(name)))
+	}
+}
+
+func TestDedupeMigrationIndexes(t *testing.T) {
+	tests := map[string]struct {
+		migrationIndexes  []int
+		timeseriesIndexes []int
+		expected          []int
+	}{
+		"no migration indexes": {
+			timeseriesIndexes: []int{0, 1},
+			expected:          nil,
+		},
+		"no series indexes": {
+			migrationIndexes: []int{10, 11},
+			expected:         []int{10, 11},
+		},
+		"no series sent twice": {
+			migrationIndexes:  []int{10, 12},
+			timeseriesIndexes: []int{1, 3},
+			expected:          []int{10, 12},
+		},
+		"some series sent twice": {
+			migrationIndexes:  []int{10, 11, 13, 14},
+			timeseriesIndexes: []int{1, 2, 3},
+			expected:          []int{10, 14},
+		},
+		"all series sent twice": {
+			migrationIndexes:  []int{10, 11},
+			timeseriesIndexes: []int{0, 1},
+			expected:          []int{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := dedupeMigrationIndexes(tc.migrationIndexes, tc.timeseriesIndexes, 10)
+			if tc.expected == nil {
+				assert.Nil(t, actual)
+			} else {
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDistributor_Push_ShardingSchemeMigration(t *testing.T) {
+	const numSeries = 50
+	now := time.Now().UnixMilli()
+
+	for name, tc := range map[string]struct {
+		scheme          string
+		migrationScheme string
+	}{
+		"v1": {
+			scheme: validation.SeriesShardingSchemeV1,
+		},
+		"v2": {
+			scheme: validation.SeriesShardingSchemeV2,
+		},
+		"v1 migrating to v2": {
+			scheme:          validation.SeriesShardingSchemeV1,
+			migrationScheme: validation.SeriesShardingSchemeV2,
+		},
+		"v2 migrating from v1": {
+			scheme:          validation.SeriesShardingSchemeV2,
+			migrationScheme: validation.SeriesShardingSchemeV1,
+		},
+		"migrating to the same scheme": {
+			scheme:          validation.SeriesShardingSchemeV2,
+			migrationScheme: validation.SeriesShardingSchemeV2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.SeriesShardingScheme = tc.scheme
+			limits.SeriesShardingMigration = tc.migrationScheme
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:      6,
+				happyIngesters:    6,
+				numDistributors:   1,
+				limits:            &limits,
+				replicationFactor: 1,
+			})
+
+			req := &mimirpb.WriteRequest{}
+			series := make([][]mimirpb.LabelAdapter, 0, numSeries)
+			for i := 0; i < numSeries; i++ {
+				lbls := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "metric", "series", strconv.Itoa(i)))
+				series = append(series, lbls)
+				req.Timeseries = append(req.Timeseries, mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+					Labels:  append([]mimirpb.LabelAdapter(nil), lbls...),
+					Samples: []mimirpb.Sample{{TimestampMs: now, Value: float64(i)}},
+				}})
+			}
+
+			_, err := ds[0].Push(user.InjectOrgID(context.Background(), "user"), req)
+			require.NoError(t, err)
+
+			// Find out the ingesters each series is expected to be written to.
+			expected := map[string]map[uint32]bool{}
+			schemes := []shardingScheme{shardingSchemeByName(tc.scheme)}
+			if tc.migrationScheme != "" {
+				schemes = append(schemes, shardingSchemeByName(tc.migrationScheme))
+			}
+			bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+			for _, lbls := range series {
+				for _, scheme := range schemes {
+					set, err := ds[0].ingestersRing.Get(scheme.tokenForLabels("user", lbls), ring.WriteNoExtend, bufDescs, bufHosts, bufZones)
+					require.NoError(t, err)
+					require.Len(t, set.Instances, 1)
+
+					addr := set.Instances[0].Addr
+					if expected[addr] == nil {
+						expected[addr] = map[uint32]bool{}
+					}
+					// The mock ingester indexes the series by their v1 token.
+					expected[addr][shardByAllLabels("user", lbls)] = true
+				}
+			}
+
+			for i := range ingesters {
+				addr := fmt.Sprintf("%d", i)
+				received := ingesters[i].series()
+				assert.Len(t, received, len(expected[addr]), addr)
+
+				for token, ts := range received {
+					assert.True(t, expected[addr][token], "unexpected series %s written to ingester %s", mimirpb.FromLabelAdaptersToLabels(ts.Labels), addr)
+					// Each ingester receives each series once.
+					assert.Len(t, ts.Samples, 1)
+				}
+			}
+		})
+	}
+}
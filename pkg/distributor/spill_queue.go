@@ -0,0 +1,534 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/multierror"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	spillQueueReasonQuotaExceeded = "quota_exceeded"
+	spillQueueReasonQueueFull     = "queue_full"
+	spillQueueReasonWriteFailed   = "write_failed"
+	spillQueueReasonTooOld        = "too_old"
+	spillQueueReasonRejected      = "rejected"
+	spillQueueReasonCorrupted     = "corrupted"
+
+	spillQueueFileExtension = ".snappy"
+
+	// spillQueueDecisionSpilled is the decision of the spill queue write path step when the request is queued.
+	spillQueueDecisionSpilled = "spilled"
+)
+
+// SpillQueueConfig configures the on-disk queue of the write requests which couldn't be written to the ingesters.
+type SpillQueueConfig struct {
+	Dir            string        `yaml:"dir" category:"experimental"`
+	MaxAge         time.Duration `yaml:"max_age" category:"experimental"`
+	ReplayInterval time.Duration `yaml:"replay_interval" category:"experimental"`
+	MaxBytes       int64         `yaml:"max_bytes" category:"experimental"`
+}
+
+func (cfg *SpillQueueConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Dir, "distributor.spill-queue.dir", "", "Directory where the distributor queues the validated write requests which couldn't be written to the ingesters because they're unavailable, to replay them once the ingesters recover. Only the write requests of the tenants with a -distributor.spill-queue-max-bytes quota are queued, and are reported as successful to the client. Empty to disable.")
+	f.DurationVar(&cfg.MaxAge, "distributor.spill-queue.max-age", time.Hour, "Maximum time a write request is kept in the spill queue. Older write requests are dropped without being replayed.")
+	f.DurationVar(&cfg.ReplayInterval, "distributor.spill-queue.replay-interval", 10*time.Second, "How frequently the distributor tries to replay the write requests in the spill queue.")
+	f.Int64Var(&cfg.MaxBytes, "distributor.spill-queue.max-bytes", 10<<30, "Maximum total size, in bytes, of the write requests queued on disk by the distributor, across all tenants. The write requests exceeding the size aren't queued.")
+}
+
+func (cfg *SpillQueueConfig) Enabled() bool {
+	return cfg.Dir != ""
+}
+
+func (cfg *SpillQueueConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.MaxAge <= 0 {
+		return fmt.Errorf("the spill queue max age must be greater than 0")
+	}
+	if cfg.ReplayInterval <= 0 {
+		return fmt.Errorf("the spill queue replay interval must be greater than 0")
+	}
+	if cfg.MaxBytes <= 0 {
+		return fmt.Errorf("the spill queue max bytes must be greater than 0")
+	}
+	return nil
+}
+
+var (
+	errSpillQueueQuotaExceeded = errors.New("the tenant's spill queue quota has been exceeded")
+	errSpillQueueFull          = errors.New("the spill queue is full")
+)
+
+// spillQueueEntry is a write request stored in the spill queue.
+type spillQueueEntry struct {
+	path      string
+	size      int64
+	samples   int
+	createdAt time.Time
+}
+
+// spillQueueTenant is the spill queue of a tenant, from the oldest to the newest write request.
+type spillQueueTenant struct {
+	entries []spillQueueEntry
+	// Size of the queued write requests, and of the ones being written to disk.
+	bytes int64
+}
+
+// spillQueue stores on disk the write requests which couldn't be written to the ingesters, and periodically
+// replays them, oldest first, until the ingesters accept them.
+type spillQueue struct {
+	services.Service
+
+	cfg    SpillQueueConfig
+	limits *validation.Overrides
+	replay func(ctx context.Context, userID string, req *mimirpb.WriteRequest) error
+	logger log.Logger
+
+	mtx     sync.Mutex
+	tenants map[string]*spillQueueTenant
+	seq     uint64
+	// Size of the write requests of all tenants, including the ones being written to disk.
+	bytes int64
+
+	queuedSamples   *prometheus.CounterVec
+	replayedSamples *prometheus.CounterVec
+	droppedSamples  *prometheus.CounterVec
+	queueBytes      *prometheus.GaugeVec
+}
+
+func newSpillQueue(cfg SpillQueueConfig, limits *validation.Overrides, replay func(context.Context, string, *mimirpb.WriteRequest) error, reg prometheus.Registerer, logger log.Logger) *spillQueue {
+	q := &spillQueue{
+		cfg:     cfg,
+		limits:  limits,
+		replay:  replay,
+		logger:  logger,
+		tenants: map[string]*spillQueueTenant{},
+		queuedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_queue_queued_samples_total",
+			Help: "The total number of samples queued on disk because they couldn't be written to the ingesters.",
+		}, []string{"user"}),
+		replayedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_queue_replayed_samples_total",
+			Help: "The total number of queued samples successfully replayed to the ingesters.",
+		}, []string{"user"}),
+		droppedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_queue_dropped_samples_total",
+			Help: "The total number of samples which couldn't be queued, or have been dropped from the queue without being replayed.",
+		}, []string{"user", "reason"}),
+		queueBytes: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_spill_queue_size_bytes",
+			Help: "Size, in bytes, of the write requests queued on disk.",
+		}, []string{"user"}),
+	}
+
+	q.Service = services.NewTimerService(cfg.ReplayInterval, q.starting, q.iteration, nil).WithName("distributor spill queue")
+	return q
+}
+
+// starting loads the write requests queued on disk before the distributor restarted.
+func (q *spillQueue) starting(_ context.Context) error {
+	if err := os.MkdirAll(q.cfg.Dir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create the spill queue directory")
+	}
+
+	userDirs, err := os.ReadDir(q.cfg.Dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the spill queue directory")
+	}
+
+	for _, userDir := range userDirs {
+		if !userDir.IsDir() {
+			continue
+		}
+		if err := q.loadUser(userDir.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *spillQueue) loadUser(userID string) error {
+	dir := filepath.Join(q.cfg.Dir, userID)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the spill queue directory of the tenant %s", userID)
+	}
+
+	// The files are named after their creation time, so they're sorted from the oldest.
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		createdAt, samples, ok := parseSpillQueueFileName(file.Name())
+		if !ok {
+			// Partially written files are left behind if the distributor crashes while writing them.
+			level.Warn(q.logger).Log("msg", "removing unexpected file from the spill queue", "path", path)
+			_ = os.Remove(path)
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read spill queue file %s", path)
+		}
+
+		q.addEntry(userID, spillQueueEntry{path: path, size: info.Size(), samples: samples, createdAt: createdAt})
+	}
+	return nil
+}
+
+func (q *spillQueue) iteration(ctx context.Context) error {
+	q.mtx.Lock()
+	userIDs := make([]string, 0, len(q.tenants))
+	for userID := range q.tenants {
+		userIDs = append(userIDs, userID)
+	}
+	q.mtx.Unlock()
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		q.replayUser(ctx, userID)
+	}
+	return nil
+}
+
+// replayUser replays the queued write requests of the user, oldest first, until the ingesters fail to accept them.
+func (q *spillQueue) replayUser(ctx context.Context, userID string) {
+	for ctx.Err() == nil {
+		entry, ok := q.oldestEntry(userID)
+		if !ok {
+			return
+		}
+
+		if time.Since(entry.createdAt) > q.cfg.MaxAge {
+			q.removeOldestEntry(userID, spillQueueReasonTooOld)
+			continue
+		}
+
+		req, err := readSpillQueueFile(entry.path)
+		if err != nil {
+			level.Warn(q.logger).Log("msg", "dropping corrupted write request from the spill queue", "user", userID, "path", entry.path, "err", err)
+			q.removeOldestEntry(userID, spillQueueReasonCorrupted)
+			continue
+		}
+
+		err = q.replay(ctx, userID, req)
+		if err != nil && isSpillableError(err) {
+			// The ingesters are still unavailable: retry at the next iteration.
+			return
+		}
+		if err != nil {
+			level.Warn(q.logger).Log("msg", "queued write request rejected by the ingesters", "user", userID, "err", err)
+			q.removeOldestEntry(userID, spillQueueReasonRejected)
+			continue
+		}
+
+		q.replayedSamples.WithLabelValues(userID).Add(float64(entry.samples))
+		q.removeOldestEntry(userID, "")
+	}
+}
+
+// enqueue stores on disk the marshalled write request of the user, unless the user's quota or the queue's max
+// size is exceeded. The space of the write request is reserved before writing it, so that the lock isn't held
+// while writing and syncing the file.
+func (q *spillQueue) enqueue(userID string, data []byte, samples int) error {
+	compressed := snappy.Encode(nil, data)
+	size := int64(len(compressed))
+
+	q.mtx.Lock()
+	quota := int64(q.limits.SpillQueueMaxBytes(userID))
+	t := q.tenants[userID]
+	if quota <= 0 || (t != nil && t.bytes+size > quota) {
+		q.mtx.Unlock()
+		q.droppedSamples.WithLabelValues(userID, spillQueueReasonQuotaExceeded).Add(float64(samples))
+		return errSpillQueueQuotaExceeded
+	}
+	if q.bytes+size > q.cfg.MaxBytes {
+		q.mtx.Unlock()
+		q.droppedSamples.WithLabelValues(userID, spillQueueReasonQueueFull).Add(float64(samples))
+		return errSpillQueueFull
+	}
+
+	now := time.Now()
+	q.seq++
+	path := filepath.Join(q.cfg.Dir, userID, spillQueueFileName(now, q.seq, samples))
+	q.reserveLocked(userID, size)
+	q.mtx.Unlock()
+
+	if err := writeSpillQueueFile(path, compressed); err != nil {
+		q.mtx.Lock()
+		q.reserveLocked(userID, -size)
+		q.mtx.Unlock()
+		q.droppedSamples.WithLabelValues(userID, spillQueueReasonWriteFailed).Add(float64(samples))
+		return err
+	}
+
+	q.mtx.Lock()
+	q.reserveLocked(userID, -size)
+	q.addEntryLocked(userID, spillQueueEntry{path: path, size: size, samples: samples, createdAt: now})
+	q.mtx.Unlock()
+
+	q.queuedSamples.WithLabelValues(userID).Add(float64(samples))
+	return nil
+}
+
+// reserveLocked adds the size, which can be negative, to the size of the queue of the user.
+func (q *spillQueue) reserveLocked(userID string, size int64) {
+	t, ok := q.tenants[userID]
+	if !ok {
+		t = &spillQueueTenant{}
+		q.tenants[userID] = t
+	}
+
+	t.bytes += size
+	q.bytes += size
+	q.deleteTenantIfEmptyLocked(userID, t)
+}
+
+// deleteTenantIfEmptyLocked deletes the queue of the user once it has no write request, either queued or being
+// written to disk.
+func (q *spillQueue) deleteTenantIfEmptyLocked(userID string, t *spillQueueTenant) {
+	if len(t.entries) == 0 && t.bytes == 0 {
+		delete(q.tenants, userID)
+		q.queueBytes.DeleteLabelValues(userID)
+	} else {
+		q.queueBytes.WithLabelValues(userID).Set(float64(t.bytes))
+	}
+}
+
+func (q *spillQueue) addEntry(userID string, entry spillQueueEntry) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.addEntryLocked(userID, entry)
+}
+
+func (q *spillQueue) addEntryLocked(userID string, entry spillQueueEntry) {
+	t, ok := q.tenants[userID]
+	if !ok {
+		t = &spillQueueTenant{}
+		q.tenants[userID] = t
+	}
+
+	t.entries = append(t.entries, entry)
+	t.bytes += entry.size
+	q.bytes += entry.size
+	q.queueBytes.WithLabelValues(userID).Set(float64(t.bytes))
+}
+
+func (q *spillQueue) oldestEntry(userID string) (spillQueueEntry, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	t, ok := q.tenants[userID]
+	if !ok || len(t.entries) == 0 {
+		return spillQueueEntry{}, false
+	}
+	return t.entries[0], true
+}
+
+// removeOldestEntry removes the oldest write request of the user from the queue. The samples of the write
+// request are tracked as dropped if the reason isn't empty.
+func (q *spillQueue) removeOldestEntry(userID, reason string) {
+	q.mtx.Lock()
+	t := q.tenants[userID]
+	entry := t.entries[0]
+	t.entries = t.entries[1:]
+	t.bytes -= entry.size
+	q.bytes -= entry.size
+	q.deleteTenantIfEmptyLocked(userID, t)
+	q.mtx.Unlock()
+
+	if reason != "" {
+		q.droppedSamples.WithLabelValues(userID, reason).Add(float64(entry.samples))
+	}
+	if err := os.Remove(entry.path); err != nil {
+		level.Warn(q.logger).Log("msg", "failed to remove file from the spill queue", "path", entry.path, "err", err)
+	}
+}
+
+func (q *spillQueue) cleanupUser(userID string) {
+	q.queuedSamples.DeleteLabelValues(userID)
+	q.replayedSamples.DeleteLabelValues(userID)
+	q.droppedSamples.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// spillQueueFileName returns the name of the file of a queued write request. The creation time is zero-padded
+// so that the files are sorted by creation time.
+func spillQueueFileName(createdAt time.Time, seq uint64, samples int) string {
+	return fmt.Sprintf("%020d-%d-%d%s", createdAt.UnixNano(), seq, samples, spillQueueFileExtension)
+}
+
+func parseSpillQueueFileName(name string) (createdAt time.Time, samples int, ok bool) {
+	name, ok = strings.CutSuffix(name, spillQueueFileExtension)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	parts := strings.Split(name, "-")
+	if len(parts) != 3 {
+		return time.Time{}, 0, false
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	samples, err = strconv.Atoi(parts[2])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return time.Unix(0, nanos), samples, true
+}
+
+func writeSpillQueueFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// The file is written to a temporary file first, so that partially written files are never replayed.
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	merr := multierror.New()
+	_, err = file.Write(data)
+	merr.Add(err)
+	merr.Add(file.Sync())
+	merr.Add(file.Close())
+	if err := merr.Err(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func readSpillQueueFile(path string) (*mimirpb.WriteRequest, error) {
+	compressed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &mimirpb.WriteRequest{}
+	if err := req.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// isSpillableError returns whether the write request failed because the ingesters are unavailable, as opposed
+// to being rejected by the ingesters.
+func isSpillableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(errors.Cause(err)); ok {
+		return resp.Code/100 == 5
+	}
+	return true
+}
+
+// countSpillableSamples returns the number of samples and histograms of the write request.
+func countSpillableSamples(req *mimirpb.WriteRequest) int {
+	samples := 0
+	for _, ts := range req.Timeseries {
+		samples += len(ts.Samples) + len(ts.Histograms)
+	}
+	return samples
+}
+
+// replaySpilledRequest writes a write request of the spill queue to the ingesters. The write request skips
+// the push middlewares, because it went through them before being queued.
+func (d *Distributor) replaySpilledRequest(ctx context.Context, userID string, req *mimirpb.WriteRequest) error {
+	pushReq := push.NewParsedRequest(req)
+	pushReq.AddCleanup(func() {
+		mimirpb.ReuseSlice(req.Timeseries)
+	})
+
+	_, err := d.push(user.InjectOrgID(ctx, userID), pushReq)
+	return err
+}
+
+// prePushSpillQueueMiddleware queues on disk the write requests of the tenants with a spill queue quota which
+// can't be written to the ingesters because they're unavailable. The queued write requests are reported as
+// successful to the client.
+func (d *Distributor) prePushSpillQueueMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil || d.limits.SpillQueueMaxBytes(userID) <= 0 {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+
+		// The memory of the write request is pooled and reused once it has been pushed to the ingesters, so it's
+		// only released once both the push and the spill queue are done with it. This way, the write request is
+		// only marshalled when it has to be queued.
+		pending := atomic.NewInt32(2)
+		release := func() {
+			if pending.Dec() == 0 {
+				pushReq.CleanUp()
+			}
+		}
+		defer release()
+
+		nextReq := push.NewParsedRequest(req)
+		nextReq.AddCleanup(release)
+
+		resp, err := next(ctx, nextReq)
+		if err == nil || !isSpillableError(err) {
+			return resp, err
+		}
+
+		data, marshalErr := req.Marshal()
+		if marshalErr != nil {
+			level.Warn(d.log).Log("msg", "failed to marshal write request for the spill queue", "user", userID, "err", marshalErr)
+			return resp, err
+		}
+
+		if queueErr := d.spillQueue.enqueue(userID, data, countSpillableSamples(req)); queueErr != nil {
+			level.Warn(d.log).Log("msg", "failed to queue write request to the spill queue", "user", userID, "err", queueErr)
+			return resp, err
+		}
+		setPushStepDecision(ctx, spillQueueDecisionSpilled)
+		return &mimirpb.WriteResponse{}, nil
+	}
+}
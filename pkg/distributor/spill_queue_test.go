@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_Push_SpillQueue(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.SpillQueueMaxBytes = 1024 * 1024
+
+	dir := t.TempDir()
+	distributors, ingesters, regs := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    0,
+		numDistributors:   1,
+		replicationFactor: 3,
+		limits:            limits,
+		spillQueue:        SpillQueueConfig{Dir: dir, MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024},
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	req := mimirpb.ToWriteRequest(
+		[][]mimirpb.LabelAdapter{
+			mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "foo", "a", "1")),
+			mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "foo", "a", "2")),
+		},
+		[]mimirpb.Sample{{Value: 1, TimestampMs: 1000}, {Value: 2, TimestampMs: 1000}},
+		nil, nil, mimirpb.API,
+	)
+
+	// The write request is queued while the ingesters are unavailable.
+	_, err := distributors[0].Push(ctx, req)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(filepath.Join(dir, "user"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_spill_queue_queued_samples_total The total number of samples queued on disk because they couldn't be written to the ingesters.
+		# TYPE cortex_distributor_spill_queue_queued_samples_total counter
+		cortex_distributor_spill_queue_queued_samples_total{user="user"} 2
+	`), "cortex_distributor_spill_queue_queued_samples_total", "cortex_distributor_spill_queue_replayed_samples_total"))
+
+	// The write request stays queued while the ingesters are still unavailable.
+	require.NoError(t, distributors[0].spillQueue.iteration(context.Background()))
+	files, err = os.ReadDir(filepath.Join(dir, "user"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	for i := range ingesters {
+		ingesters[i].Lock()
+		ingesters[i].happy = true
+		ingesters[i].Unlock()
+	}
+
+	require.NoError(t, distributors[0].spillQueue.iteration(context.Background()))
+
+	test.Poll(t, time.Second, map[string]mimirpb.Sample{
+		`{__name__="foo", a="1"}`: {Value: 1, TimestampMs: 1000},
+		`{__name__="foo", a="2"}`: {Value: 2, TimestampMs: 1000},
+	}, func() interface{} {
+		return ingesterSamples(&ingesters[0])
+	})
+
+	files, err = os.ReadDir(filepath.Join(dir, "user"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_spill_queue_queued_samples_total The total number of samples queued on disk because they couldn't be written to the ingesters.
+		# TYPE cortex_distributor_spill_queue_queued_samples_total counter
+		cortex_distributor_spill_queue_queued_samples_total{user="user"} 2
+
+		# HELP cortex_distributor_spill_queue_replayed_samples_total The total number of queued samples successfully replayed to the ingesters.
+		# TYPE cortex_distributor_spill_queue_replayed_samples_total counter
+		cortex_distributor_spill_queue_replayed_samples_total{user="user"} 2
+	`), "cortex_distributor_spill_queue_queued_samples_total", "cortex_distributor_spill_queue_replayed_samples_total", "cortex_distributor_spill_queue_size_bytes"))
+}
+
+func TestDistributor_Push_SpillQueue_DisabledForTenant(t *testing.T) {
+	distributors, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    0,
+		numDistributors:   1,
+		replicationFactor: 3,
+		spillQueue:        SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024},
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := distributors[0].Push(ctx, makeWriteRequest(0, 1, 0, false, false))
+	require.Error(t, err)
+}
+
+func TestSpillQueue(t *testing.T) {
+	newRequest := func(metric string) []byte {
+		req := mimirpb.ToWriteRequest(
+			[][]mimirpb.LabelAdapter{mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, metric))},
+			[]mimirpb.Sample{{Value: 1, TimestampMs: 1000}},
+			nil, nil, mimirpb.API,
+		)
+		data, err := req.Marshal()
+		require.NoError(t, err)
+		return data
+	}
+
+	newQueue := func(t *testing.T, cfg SpillQueueConfig, maxBytes int, replay func(context.Context, string, *mimirpb.WriteRequest) error) (*spillQueue, *prometheus.Registry) {
+		limits := validation.Limits{}
+		flagext.DefaultValues(&limits)
+		limits.SpillQueueMaxBytes = maxBytes
+		overrides, err := validation.NewOverrides(limits, nil)
+		require.NoError(t, err)
+
+		reg := prometheus.NewPedanticRegistry()
+		q := newSpillQueue(cfg, overrides, replay, reg, log.NewNopLogger())
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), q))
+		t.Cleanup(func() {
+			require.NoError(t, services.StopAndAwaitTerminated(context.Background(), q))
+		})
+		return q, reg
+	}
+
+	t.Run("the write requests exceeding the quota are dropped", func(t *testing.T) {
+		data := newRequest("foo")
+		q, reg := newQueue(t, SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024}, len(data)*3/2, nil)
+
+		require.NoError(t, q.enqueue("user", data, 1))
+		require.ErrorIs(t, q.enqueue("user", data, 1), errSpillQueueQuotaExceeded)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_spill_queue_dropped_samples_total The total number of samples which couldn't be queued, or have been dropped from the queue without being replayed.
+			# TYPE cortex_distributor_spill_queue_dropped_samples_total counter
+			cortex_distributor_spill_queue_dropped_samples_total{reason="quota_exceeded",user="user"} 1
+		`), "cortex_distributor_spill_queue_dropped_samples_total"))
+	})
+
+	t.Run("the write requests exceeding the queue max bytes are dropped", func(t *testing.T) {
+		data := newRequest("foo")
+		cfg := SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: int64(len(data)) * 3 / 2}
+		q, reg := newQueue(t, cfg, 1024*1024, nil)
+
+		// The max bytes applies to the write requests of all the tenants.
+		require.NoError(t, q.enqueue("user-1", data, 1))
+		require.ErrorIs(t, q.enqueue("user-2", data, 1), errSpillQueueFull)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_spill_queue_dropped_samples_total The total number of samples which couldn't be queued, or have been dropped from the queue without being replayed.
+			# TYPE cortex_distributor_spill_queue_dropped_samples_total counter
+			cortex_distributor_spill_queue_dropped_samples_total{reason="queue_full",user="user-2"} 1
+		`), "cortex_distributor_spill_queue_dropped_samples_total"))
+	})
+
+	t.Run("the write requests are replayed in order, and the rejected ones are dropped", func(t *testing.T) {
+		var replayed []string
+		replay := func(_ context.Context, userID string, req *mimirpb.WriteRequest) error {
+			metric := req.Timeseries[0].Labels[0].Value
+			replayed = append(replayed, metric)
+			if metric == "rejected" {
+				return httpgrpc.Errorf(http.StatusBadRequest, "rejected")
+			}
+			return nil
+		}
+
+		q, reg := newQueue(t, SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024}, 1024*1024, replay)
+		for _, metric := range []string{"first", "rejected", "last"} {
+			require.NoError(t, q.enqueue("user", newRequest(metric), 1))
+		}
+
+		require.NoError(t, q.iteration(context.Background()))
+		assert.Equal(t, []string{"first", "rejected", "last"}, replayed)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_spill_queue_dropped_samples_total The total number of samples which couldn't be queued, or have been dropped from the queue without being replayed.
+			# TYPE cortex_distributor_spill_queue_dropped_samples_total counter
+			cortex_distributor_spill_queue_dropped_samples_total{reason="rejected",user="user"} 1
+
+			# HELP cortex_distributor_spill_queue_replayed_samples_total The total number of queued samples successfully replayed to the ingesters.
+			# TYPE cortex_distributor_spill_queue_replayed_samples_total counter
+			cortex_distributor_spill_queue_replayed_samples_total{user="user"} 2
+		`), "cortex_distributor_spill_queue_dropped_samples_total", "cortex_distributor_spill_queue_replayed_samples_total", "cortex_distributor_spill_queue_size_bytes"))
+	})
+
+	t.Run("the write requests older than the max age are dropped", func(t *testing.T) {
+		replay := func(context.Context, string, *mimirpb.WriteRequest) error {
+			require.Fail(t, "no write request should be replayed")
+			return nil
+		}
+
+		q, reg := newQueue(t, SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Millisecond, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024}, 1024*1024, replay)
+		require.NoError(t, q.enqueue("user", newRequest("foo"), 1))
+		time.Sleep(10 * time.Millisecond)
+
+		require.NoError(t, q.iteration(context.Background()))
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_spill_queue_dropped_samples_total The total number of samples which couldn't be queued, or have been dropped from the queue without being replayed.
+			# TYPE cortex_distributor_spill_queue_dropped_samples_total counter
+			cortex_distributor_spill_queue_dropped_samples_total{reason="too_old",user="user"} 1
+		`), "cortex_distributor_spill_queue_dropped_samples_total"))
+	})
+
+	t.Run("the write requests queued before a restart are loaded at startup", func(t *testing.T) {
+		cfg := SpillQueueConfig{Dir: t.TempDir(), MaxAge: time.Hour, ReplayInterval: time.Hour, MaxBytes: 1024 * 1024}
+		unavailable := func(context.Context, string, *mimirpb.WriteRequest) error { return errFail }
+
+		q, _ := newQueue(t, cfg, 1024*1024, unavailable)
+		require.NoError(t, q.enqueue("user", newRequest("first"), 1))
+		require.NoError(t, q.enqueue("user", newRequest("second"), 1))
+		require.NoError(t, q.iteration(context.Background()))
+
+		// Partially written files are removed at startup.
+		require.NoError(t, os.WriteFile(filepath.Join(cfg.Dir, "user", "partial.snappy.tmp"), []byte("x"), 0o600))
+
+		var replayed []string
+		replay := func(_ context.Context, userID string, req *mimirpb.WriteRequest) error {
+			replayed = append(replayed, userID+"/"+req.Timeseries[0].Labels[0].Value)
+			return nil
+		}
+
+		restarted, reg := newQueue(t, cfg, 1024*1024, replay)
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_spill_queue_size_bytes Size, in bytes, of the write requests queued on disk.
+			# TYPE cortex_distributor_spill_queue_size_bytes gauge
+			cortex_distributor_spill_queue_size_bytes{user="user"} `+strconv.FormatInt(q.tenants["user"].bytes, 10)+`
+		`), "cortex_distributor_spill_queue_size_bytes"))
+
+		require.NoError(t, restarted.iteration(context.Background()))
+		assert.Equal(t, []string{"user/first", "user/second"}, replayed)
+
+		files, err := os.ReadDir(filepath.Join(cfg.Dir, "user"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+}
+
+func TestSpillQueueConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&SpillQueueConfig{}).Validate())
+	assert.NoError(t, (&SpillQueueConfig{Dir: "queue", MaxAge: time.Hour, ReplayInterval: time.Second, MaxBytes: 1024}).Validate())
+	assert.Error(t, (&SpillQueueConfig{Dir: "queue", ReplayInterval: time.Second, MaxBytes: 1024}).Validate())
+	assert.Error(t, (&SpillQueueConfig{Dir: "queue", MaxAge: time.Hour, MaxBytes: 1024}).Validate())
+	assert.Error(t, (&SpillQueueConfig{Dir: "queue", MaxAge: time.Hour, ReplayInterval: time.Second}).Validate())
+}
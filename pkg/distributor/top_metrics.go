@@ -0,0 +1,421 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"container/heap"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/extract"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+const (
+	// topMetricsSketchDepth and topMetricsSketchWidth are the size of the count-min sketch of each tenant. The
+	// estimated number of samples of a metric name exceeds the actual one by at most e/width of the tenant's samples
+	// in the window, with a probability of 1-e^-depth.
+	topMetricsSketchDepth = 4
+	topMetricsSketchWidth = 1024
+)
+
+// TopMetricsConfig configures the tracking of the metric names with the most samples of each tenant.
+type TopMetricsConfig struct {
+	Enabled     bool          `yaml:"enabled" category:"experimental"`
+	Size        int           `yaml:"size" category:"experimental"`
+	Window      time.Duration `yaml:"window" category:"experimental"`
+	LogInterval time.Duration `yaml:"log_interval" category:"experimental"`
+}
+
+func (cfg *TopMetricsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.top-metrics.enabled", false, "True to track the metric names with the most samples received by the distributor for each tenant, and expose them at the /distributor/top_metrics endpoint. The number of samples is estimated with a probabilistic sketch, so it can be slightly higher than the actual one.")
+	f.IntVar(&cfg.Size, "distributor.top-metrics.size", 10, "Number of metric names with the most samples tracked for each tenant.")
+	f.DurationVar(&cfg.Window, "distributor.top-metrics.window", 10*time.Minute, "The metric names are ranked by the samples received in the last one to two windows: the samples received before the previous window are discarded at the end of each window.")
+	f.DurationVar(&cfg.LogInterval, "distributor.top-metrics.log-interval", 0, "How frequently to log the metric names with the most samples of each tenant. 0 to disable.")
+}
+
+func (cfg *TopMetricsConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Size <= 0 {
+		return fmt.Errorf("the top metrics size must be greater than 0")
+	}
+	if cfg.Window <= 0 {
+		return fmt.Errorf("the top metrics window must be greater than 0")
+	}
+	if cfg.LogInterval < 0 {
+		return fmt.Errorf("the top metrics log interval must not be negative")
+	}
+	return nil
+}
+
+// TopMetric is a metric name of a tenant, with the estimated number of samples received.
+type TopMetric struct {
+	MetricName string `json:"metric_name"`
+	Samples    uint64 `json:"samples"`
+}
+
+// countMinSketch estimates the number of samples of each metric name. The estimate is never lower than the actual count.
+type countMinSketch struct {
+	counters [topMetricsSketchDepth][topMetricsSketchWidth]uint64
+}
+
+// add adds n samples to the metric name, and returns the new estimated number of samples of the metric name.
+func (s *countMinSketch) add(name string, n uint64) uint64 {
+	h := xxhash.Sum64String(name)
+	h1, h2 := uint32(h), uint32(h>>32)
+
+	estimate := uint64(0)
+	for i := 0; i < topMetricsSketchDepth; i++ {
+		c := &s.counters[i][(h1+uint32(i)*h2)%topMetricsSketchWidth]
+		*c += n
+		if i == 0 || *c < estimate {
+			estimate = *c
+		}
+	}
+	return estimate
+}
+
+func (s *countMinSketch) estimate(name string) uint64 {
+	h := xxhash.Sum64String(name)
+	h1, h2 := uint32(h), uint32(h>>32)
+
+	estimate := uint64(0)
+	for i := 0; i < topMetricsSketchDepth; i++ {
+		c := s.counters[i][(h1+uint32(i)*h2)%topMetricsSketchWidth]
+		if i == 0 || c < estimate {
+			estimate = c
+		}
+	}
+	return estimate
+}
+
+// topMetricsHeap is a min-heap of the metric names with the most samples, so that the metric name
+// with the least samples is the first one to be replaced.
+type topMetricsHeap struct {
+	metrics []TopMetric
+	// indexes are the indexes of the metric names in metrics.
+	indexes map[string]int
+}
+
+func (h *topMetricsHeap) Len() int           { return len(h.metrics) }
+func (h *topMetricsHeap) Less(i, j int) bool { return h.metrics[i].Samples < h.metrics[j].Samples }
+
+func (h *topMetricsHeap) Swap(i, j int) {
+	h.metrics[i], h.metrics[j] = h.metrics[j], h.metrics[i]
+	h.indexes[h.metrics[i].MetricName] = i
+	h.indexes[h.metrics[j].MetricName] = j
+}
+
+func (h *topMetricsHeap) Push(x interface{}) {
+	m := x.(TopMetric)
+	h.indexes[m.MetricName] = len(h.metrics)
+	h.metrics = append(h.metrics, m)
+}
+
+func (h *topMetricsHeap) Pop() interface{} {
+	m := h.metrics[len(h.metrics)-1]
+	h.metrics = h.metrics[:len(h.metrics)-1]
+	delete(h.indexes, m.MetricName)
+	return m
+}
+
+// topMetricsWindow tracks the metric names with the most samples received within a window.
+type topMetricsWindow struct {
+	sketch countMinSketch
+	top    topMetricsHeap
+}
+
+func newTopMetricsWindow() *topMetricsWindow {
+	return &topMetricsWindow{top: topMetricsHeap{indexes: map[string]int{}}}
+}
+
+func (w *topMetricsWindow) add(name string, samples uint64, size int) {
+	estimate := w.sketch.add(name, samples)
+
+	if i, ok := w.top.indexes[name]; ok {
+		w.top.metrics[i].Samples = estimate
+		heap.Fix(&w.top, i)
+		return
+	}
+
+	if w.top.Len() < size {
+		// The metric name may be unsafe, because it references the write request buffer.
+		heap.Push(&w.top, TopMetric{MetricName: strings.Clone(name), Samples: estimate})
+		return
+	}
+
+	if estimate > w.top.metrics[0].Samples {
+		heap.Pop(&w.top)
+		heap.Push(&w.top, TopMetric{MetricName: strings.Clone(name), Samples: estimate})
+	}
+}
+
+// tenantTopMetrics tracks the metric names with the most samples of a tenant in the current and the previous window.
+type tenantTopMetrics struct {
+	mtx      sync.Mutex
+	current  *topMetricsWindow
+	previous *topMetricsWindow
+}
+
+// topMetricsTracker tracks the metric names with the most samples received by the distributor for each tenant,
+// using a count-min sketch to estimate the number of samples of each metric name in a bounded memory.
+type topMetricsTracker struct {
+	services.Service
+
+	cfg    TopMetricsConfig
+	logger log.Logger
+
+	mtx     sync.RWMutex
+	tenants map[string]*tenantTopMetrics
+}
+
+func newTopMetricsTracker(cfg TopMetricsConfig, logger log.Logger) *topMetricsTracker {
+	t := &topMetricsTracker{
+		cfg:     cfg,
+		logger:  logger,
+		tenants: map[string]*tenantTopMetrics{},
+	}
+
+	t.Service = services.NewBasicService(nil, t.running, nil).WithName("distributor top metrics")
+	return t
+}
+
+func (t *topMetricsTracker) running(ctx context.Context) error {
+	windowTicker := time.NewTicker(t.cfg.Window)
+	defer windowTicker.Stop()
+
+	var logTick <-chan time.Time
+	if t.cfg.LogInterval > 0 {
+		logTicker := time.NewTicker(t.cfg.LogInterval)
+		defer logTicker.Stop()
+		logTick = logTicker.C
+	}
+
+	for {
+		select {
+		case <-windowTicker.C:
+			t.rotate()
+		case <-logTick:
+			t.log()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// record adds the samples of the write request to the tenant's metric names.
+func (t *topMetricsTracker) record(userID string, timeseries []mimirpb.PreallocTimeseries) {
+	samples := map[string]uint64{}
+	for _, ts := range timeseries {
+		name, err := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+		if err != nil {
+			continue
+		}
+		samples[name] += uint64(len(ts.Samples) + len(ts.Histograms))
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	tenant := t.tenant(userID)
+	tenant.mtx.Lock()
+	defer tenant.mtx.Unlock()
+
+	for name, n := range samples {
+		if n > 0 {
+			tenant.current.add(name, n, t.cfg.Size)
+		}
+	}
+}
+
+func (t *topMetricsTracker) tenant(userID string) *tenantTopMetrics {
+	t.mtx.RLock()
+	tenant, ok := t.tenants[userID]
+	t.mtx.RUnlock()
+	if ok {
+		return tenant
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if tenant, ok = t.tenants[userID]; !ok {
+		tenant = &tenantTopMetrics{current: newTopMetricsWindow()}
+		t.tenants[userID] = tenant
+	}
+	return tenant
+}
+
+// rotate starts a new window for each tenant, discarding the samples received before the previous window.
+// The tenants which haven't received any sample in the current window are removed.
+func (t *topMetricsTracker) rotate() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for userID, tenant := range t.tenants {
+		tenant.mtx.Lock()
+		if tenant.current.top.Len() == 0 {
+			delete(t.tenants, userID)
+		} else {
+			tenant.previous, tenant.current = tenant.current, newTopMetricsWindow()
+		}
+		tenant.mtx.Unlock()
+	}
+}
+
+// topMetrics returns the tenant's metric names with the most samples in the current and previous windows,
+// sorted by number of samples.
+func (t *topMetricsTracker) topMetrics(userID string) []TopMetric {
+	t.mtx.RLock()
+	tenant, ok := t.tenants[userID]
+	t.mtx.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	tenant.mtx.Lock()
+	defer tenant.mtx.Unlock()
+
+	// The metric names with the most samples over both windows are among the top ones of either window.
+	windows := []*topMetricsWindow{tenant.current, tenant.previous}
+	estimates := map[string]uint64{}
+	for _, w := range windows {
+		if w == nil {
+			continue
+		}
+		for _, m := range w.top.metrics {
+			if _, ok := estimates[m.MetricName]; ok {
+				continue
+			}
+			for _, ww := range windows {
+				if ww != nil {
+					estimates[m.MetricName] += ww.sketch.estimate(m.MetricName)
+				}
+			}
+		}
+	}
+
+	metrics := make([]TopMetric, 0, len(estimates))
+	for name, samples := range estimates {
+		metrics = append(metrics, TopMetric{MetricName: name, Samples: samples})
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Samples != metrics[j].Samples {
+			return metrics[i].Samples > metrics[j].Samples
+		}
+		return metrics[i].MetricName < metrics[j].MetricName
+	})
+	if len(metrics) > t.cfg.Size {
+		metrics = metrics[:t.cfg.Size]
+	}
+	return metrics
+}
+
+func (t *topMetricsTracker) userIDs() []string {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	userIDs := make([]string, 0, len(t.tenants))
+	for userID := range t.tenants {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+func (t *topMetricsTracker) log() {
+	for _, userID := range t.userIDs() {
+		metrics := t.topMetrics(userID)
+		if len(metrics) == 0 {
+			continue
+		}
+
+		formatted := make([]string, 0, len(metrics))
+		for _, m := range metrics {
+			formatted = append(formatted, m.MetricName+"="+strconv.FormatUint(m.Samples, 10))
+		}
+		level.Info(t.logger).Log("msg", "metric names with the most samples", "user", userID, "metrics", strings.Join(formatted, ","))
+	}
+}
+
+func (t *topMetricsTracker) cleanupUser(userID string) {
+	t.mtx.Lock()
+	delete(t.tenants, userID)
+	t.mtx.Unlock()
+}
+
+// prePushTopMetricsMiddleware records the samples of the write requests to the tenant's top metric names.
+func (d *Distributor) prePushTopMetricsMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			pushReq.CleanUp()
+			return nil, err
+		}
+
+		d.topMetrics.record(userID, req.Timeseries)
+		return next(ctx, pushReq)
+	}
+}
+
+// TopMetricsTenant is the metric names with the most samples of a tenant.
+type TopMetricsTenant struct {
+	UserID  string      `json:"user"`
+	Metrics []TopMetric `json:"metrics"`
+}
+
+// TopMetricsResponse is the response of the TopMetricsHandler.
+type TopMetricsResponse struct {
+	Window  string             `json:"window"`
+	Tenants []TopMetricsTenant `json:"tenants"`
+}
+
+// TopMetricsHandler replies with the metric names with the most samples received by this distributor for each
+// tenant, or only for the tenant passed with the user parameter. The top metrics are tracked by each distributor,
+// so they only account for the samples received by the distributor serving the request.
+func (d *Distributor) TopMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if d.topMetrics == nil {
+		http.Error(w, "the top metrics tracking is disabled", http.StatusNotFound)
+		return
+	}
+
+	userIDs := d.topMetrics.userIDs()
+	if userID := r.FormValue("user"); userID != "" {
+		userIDs = []string{userID}
+	}
+
+	resp := TopMetricsResponse{
+		Window:  d.cfg.TopMetrics.Window.String(),
+		Tenants: make([]TopMetricsTenant, 0, len(userIDs)),
+	}
+	for _, userID := range userIDs {
+		metrics := d.topMetrics.topMetrics(userID)
+		if metrics == nil {
+			metrics = []TopMetric{}
+		}
+		resp.Tenants = append(resp.Tenants, TopMetricsTenant{UserID: userID, Metrics: metrics})
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/push"
+)
+
+func TestTopMetricsConfig_Validate(t *testing.T) {
+	valid := TopMetricsConfig{Enabled: true, Size: 10, Window: time.Minute}
+	require.NoError(t, valid.Validate())
+	require.NoError(t, (&TopMetricsConfig{}).Validate())
+
+	for name, modify := range map[string]func(*TopMetricsConfig){
+		"zero size":             func(cfg *TopMetricsConfig) { cfg.Size = 0 },
+		"zero window":           func(cfg *TopMetricsConfig) { cfg.Window = 0 },
+		"negative log interval": func(cfg *TopMetricsConfig) { cfg.LogInterval = -time.Second },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := valid
+			modify(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestTopMetricsTracker(t *testing.T) {
+	tracker := newTopMetricsTracker(TopMetricsConfig{Enabled: true, Size: 3, Window: time.Minute}, log.NewNopLogger())
+
+	// Many metric names with few samples, and a few metric names with many samples.
+	for i := 0; i < 1000; i++ {
+		tracker.record("user-1", topMetricsTimeseries(fmt.Sprintf("metric_%d", i), 1))
+	}
+	tracker.record("user-1", topMetricsTimeseries("noisy_1", 3000))
+	tracker.record("user-1", topMetricsTimeseries("noisy_2", 2000))
+	tracker.record("user-1", topMetricsTimeseries("noisy_3", 1000))
+	tracker.record("user-2", topMetricsTimeseries("other", 10))
+
+	assertTopMetrics := func(userID string, expected []string) {
+		t.Helper()
+		var names []string
+		for _, m := range tracker.topMetrics(userID) {
+			names = append(names, m.MetricName)
+		}
+		assert.Equal(t, expected, names)
+	}
+
+	assertTopMetrics("user-1", []string{"noisy_1", "noisy_2", "noisy_3"})
+	assertTopMetrics("user-2", []string{"other"})
+	assertTopMetrics("user-3", nil)
+
+	// The estimated number of samples is never lower than the actual one.
+	top := tracker.topMetrics("user-1")
+	assert.GreaterOrEqual(t, top[0].Samples, uint64(3000))
+	assert.Less(t, top[0].Samples, uint64(3100))
+
+	// The samples of the previous window are still accounted for.
+	tracker.rotate()
+	tracker.record("user-1", topMetricsTimeseries("noisy_3", 3000))
+	assertTopMetrics("user-1", []string{"noisy_3", "noisy_1", "noisy_2"})
+	assertTopMetrics("user-2", []string{"other"})
+
+	// The samples received before the previous window are discarded, and the tenants without samples are removed.
+	tracker.rotate()
+	assertTopMetrics("user-1", []string{"noisy_3"})
+	assertTopMetrics("user-2", nil)
+	assert.Equal(t, []string{"user-1"}, tracker.userIDs())
+
+	tracker.cleanupUser("user-1")
+	assert.Empty(t, tracker.userIDs())
+}
+
+func TestTopMetricsTracker_Log(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tracker := newTopMetricsTracker(TopMetricsConfig{Enabled: true, Size: 2, Window: time.Minute}, log.NewLogfmtLogger(buf))
+
+	tracker.record("user-1", topMetricsTimeseries("noisy_1", 30))
+	tracker.record("user-1", topMetricsTimeseries("noisy_2", 20))
+	tracker.record("user-1", topMetricsTimeseries("noisy_3", 10))
+	tracker.log()
+
+	assert.Equal(t, `level=info msg="metric names with the most samples" user=user-1 metrics="noisy_1=30,noisy_2=20"`+"\n", buf.String())
+}
+
+func TestDistributor_TopMetricsHandler(t *testing.T) {
+	for name, tc := range map[string]struct {
+		enabled        bool
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should fail if the top metrics tracking is disabled": {
+			path:           "/distributor/top_metrics",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "the top metrics tracking is disabled",
+		},
+		"should return the top metrics of all tenants": {
+			enabled:        true,
+			path:           "/distributor/top_metrics",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"window":"1m0s","tenants":[{"user":"user-1","metrics":[{"metric_name":"noisy","samples":20},{"metric_name":"quiet","samples":1}]},{"user":"user-2","metrics":[{"metric_name":"other","samples":5}]}]}`,
+		},
+		"should return the top metrics of the tenant": {
+			enabled:        true,
+			path:           "/distributor/top_metrics?user=user-2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"window":"1m0s","tenants":[{"user":"user-2","metrics":[{"metric_name":"other","samples":5}]}]}`,
+		},
+		"should return no top metrics for an unknown tenant": {
+			enabled:        true,
+			path:           "/distributor/top_metrics?user=unknown",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"window":"1m0s","tenants":[{"user":"unknown","metrics":[]}]}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := TopMetricsConfig{Enabled: true, Size: 10, Window: time.Minute}
+			d := &Distributor{cfg: Config{TopMetrics: cfg}, log: log.NewNopLogger()}
+			if tc.enabled {
+				d.topMetrics = newTopMetricsTracker(cfg, log.NewNopLogger())
+
+				pushFn := d.prePushTopMetricsMiddleware(func(context.Context, *push.Request) (*mimirpb.WriteResponse, error) {
+					return &mimirpb.WriteResponse{}, nil
+				})
+				for userID, timeseries := range map[string][]mimirpb.PreallocTimeseries{
+					"user-1": append(topMetricsTimeseries("noisy", 20), topMetricsTimeseries("quiet", 1)...),
+					"user-2": topMetricsTimeseries("other", 5),
+				} {
+					_, err := pushFn(user.InjectOrgID(context.Background(), userID), push.NewParsedRequest(&mimirpb.WriteRequest{Timeseries: timeseries}))
+					require.NoError(t, err)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			d.TopMetricsHandler(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+// topMetricsTimeseries returns a series of the metric name with the number of samples.
+func topMetricsTimeseries(metricName string, samples int) []mimirpb.PreallocTimeseries {
+	ts := mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+		Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: metricName}, {Name: "job", Value: "test"}},
+	}}
+	for i := 0; i < samples; i++ {
+		ts.Samples = append(ts.Samples, mimirpb.Sample{TimestampMs: int64(i), Value: float64(i)})
+	}
+	return []mimirpb.PreallocTimeseries{ts}
+}
@@ -10,15 +10,17 @@ import (
 	"net/http"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/netutil"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/grafana/mimir/pkg/frontend/heavyqueries"
 	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
+	"github.com/grafana/mimir/pkg/frontend/queryrecorder"
 	"github.com/grafana/mimir/pkg/frontend/transport"
 	v1 "github.com/grafana/mimir/pkg/frontend/v1"
 	v2 "github.com/grafana/mimir/pkg/frontend/v2"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
-	"github.com/grafana/mimir/pkg/util"
 )
 
 // CombinedFrontendConfig combines several configuration options together to preserve backwards compatibility.
@@ -28,8 +30,12 @@ type CombinedFrontendConfig struct {
 	FrontendV2 v2.Config               `yaml:",inline"`
 
 	QueryMiddleware querymiddleware.Config `yaml:",inline"`
+	QueryRecording  queryrecorder.Config   `yaml:"query_recording"`
+	HeavyQueries    heavyqueries.Config    `yaml:"heavy_queries"`
 
 	DownstreamURL string `yaml:"downstream_url" category:"advanced"`
+
+	QueryResultChecksumsEnabled bool `yaml:"query_result_checksums_enabled" category:"experimental"`
 }
 
 func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
@@ -37,8 +43,11 @@ func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet, logger log.Log
 	cfg.FrontendV1.RegisterFlags(f)
 	cfg.FrontendV2.RegisterFlags(f, logger)
 	cfg.QueryMiddleware.RegisterFlags(f)
+	cfg.QueryRecording.RegisterFlags(f)
+	cfg.HeavyQueries.RegisterFlags(f)
 
 	f.StringVar(&cfg.DownstreamURL, "query-frontend.downstream-url", "", "URL of downstream Prometheus.")
+	f.BoolVar(&cfg.QueryResultChecksumsEnabled, "query-frontend.query-result-checksums-enabled", false, "If true, the query-frontend requests the queriers to compute the checksum of the query results, and verifies it to detect corrupted query results. Query results whose checksum doesn't match are discarded.")
 }
 
 func (cfg *CombinedFrontendConfig) Validate() error {
@@ -48,6 +57,15 @@ func (cfg *CombinedFrontendConfig) Validate() error {
 	if err := cfg.QueryMiddleware.Validate(); err != nil {
 		return err
 	}
+	if err := cfg.QueryRecording.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.HeavyQueries.Validate(); err != nil {
+		return err
+	}
+	if cfg.HeavyQueries.Enabled && !cfg.Handler.QueryStatsEnabled {
+		return errors.New("the heavy queries tracking requires the query statistics to be enabled")
+	}
 	return nil
 }
 
@@ -67,7 +85,7 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 	case cfg.FrontendV2.SchedulerAddress != "" || cfg.FrontendV2.QuerySchedulerDiscovery.Mode == schedulerdiscovery.ModeRing:
 		// Query-scheduler is enabled when its addressed is configured or is configured to use ring-based service discovery.
 		if cfg.FrontendV2.Addr == "" {
-			addr, err := util.GetFirstAddressOf(cfg.FrontendV2.InfNames)
+			addr, err := netutil.GetFirstAddressOf(cfg.FrontendV2.InfNames, log, cfg.FrontendV2.EnableIPv6)
 			if err != nil {
 				return nil, nil, nil, errors.Wrap(err, "failed to get frontend address")
 			}
@@ -80,7 +98,10 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 		}
 
 		fr, err := v2.NewFrontend(cfg.FrontendV2, log, reg)
-		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), nil, fr, err
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return cfg.wrapChecksumRoundTripper(transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), reg), nil, fr, nil
 
 	default:
 		// No scheduler = use original frontend.
@@ -88,6 +109,13 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), fr, nil, nil
+		return cfg.wrapChecksumRoundTripper(transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), reg), fr, nil, nil
+	}
+}
+
+func (cfg *CombinedFrontendConfig) wrapChecksumRoundTripper(rt http.RoundTripper, reg prometheus.Registerer) http.RoundTripper {
+	if !cfg.QueryResultChecksumsEnabled {
+		return rt
 	}
+	return transport.NewChecksumRoundTripper(rt, reg)
 }
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package federate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/grafana/dskit/tenant"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc/server"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	federatePathSuffix     = "/federate"
+	instantQueryPathSuffix = "/api/v1/query"
+
+	// defaultLookbackDelta is the lookback delta used by the PromQL engine when not configured.
+	defaultLookbackDelta = 5 * time.Minute
+)
+
+// Limits are the per-tenant limits of the /federate endpoint.
+type Limits interface {
+	// FederateEndpointEnabled returns whether the tenant can use the /federate endpoint.
+	FederateEndpointEnabled(userID string) bool
+
+	// FederateMaxSeries returns the maximum number of series a request to the /federate endpoint can return.
+	FederateMaxSeries(userID string) int
+}
+
+// Handler implements the Prometheus /federate endpoint on top of the query-frontend: the match[] selectors are
+// evaluated by instant queries at the current time, through the same round-tripper serving the queries API, and
+// the latest sample of each series is returned, with its timestamp, in the exposition format negotiated with the
+// scraper.
+type Handler struct {
+	next          http.RoundTripper
+	limits        Limits
+	lookbackDelta time.Duration
+	maxBodySize   int64
+	logger        log.Logger
+
+	// now is the time the selectors are evaluated at. Overridden in tests.
+	now func() time.Time
+}
+
+// NewHandler makes a new Handler running the instant queries with next.
+func NewHandler(next http.RoundTripper, limits Limits, lookbackDelta time.Duration, maxBodySize int64, logger log.Logger) *Handler {
+	if lookbackDelta == 0 {
+		lookbackDelta = defaultLookbackDelta
+	}
+	return &Handler{
+		next:          next,
+		limits:        limits,
+		lookbackDelta: lookbackDelta,
+		maxBodySize:   maxBodySize,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !validation.AllTrueBooleansPerTenant(tenantIDs, h.limits.FederateEndpointEnabled) {
+		http.Error(w, fmt.Sprintf("the /federate endpoint is disabled for the tenant: %s", tenant.JoinTenantIDs(tenantIDs)), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("error parsing form values: %v", err), http.StatusBadRequest)
+		return
+	}
+	selectors := r.Form["match[]"]
+	if len(selectors) == 0 {
+		http.Error(w, "at least one match[] selector is required", http.StatusBadRequest)
+		return
+	}
+	for _, s := range selectors {
+		if _, err := parser.ParseMetricSelector(s); err != nil {
+			http.Error(w, fmt.Sprintf("invalid match[] selector %q: %v", s, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	maxSeries := validation.SmallestPositiveIntPerTenant(tenantIDs, h.limits.FederateMaxSeries)
+	now := h.now()
+
+	// The same series can match multiple selectors, but is returned once.
+	series := map[model.Fingerprint]*model.Sample{}
+	for _, s := range selectors {
+		// The instant vector selector skips the series whose latest sample is a stale marker, which can't be
+		// represented in the exposition formats, but its samples have the evaluation timestamp. The actual
+		// timestamps are taken from the samples selected by the range vector selector over the lookback delta.
+		var vector model.Vector
+		if err := h.instantQuery(r, s, now, model.ValVector, &vector); err != nil {
+			writeError(w, err)
+			return
+		}
+		if len(vector) == 0 {
+			continue
+		}
+
+		var matrix model.Matrix
+		if err := h.instantQuery(r, fmt.Sprintf("%s[%s]", s, model.Duration(h.lookbackDelta)), now, model.ValMatrix, &matrix); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		live := make(map[model.Fingerprint]struct{}, len(vector))
+		for _, sample := range vector {
+			live[sample.Metric.Fingerprint()] = struct{}{}
+		}
+		for _, stream := range matrix {
+			fp := stream.Metric.Fingerprint()
+			if _, ok := live[fp]; !ok {
+				continue
+			}
+			// Native histograms can't be represented in the exposition formats supported by federation.
+			if len(stream.Values) == 0 {
+				continue
+			}
+			latest := stream.Values[len(stream.Values)-1]
+			series[fp] = &model.Sample{Metric: stream.Metric, Value: latest.Value, Timestamp: latest.Timestamp}
+		}
+
+		if maxSeries > 0 && len(series) > maxSeries {
+			http.Error(w, fmt.Sprintf("the federation request matched more than %d series, which is the maximum allowed (limit: -query-frontend.federate-max-series)", maxSeries), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	format := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(format))
+	enc := expfmt.NewEncoder(w, format)
+	for _, mf := range metricFamilies(series) {
+		if err := enc.Encode(mf); err != nil {
+			level.Warn(h.logger).Log("msg", "failed to encode the federation response", "err", err)
+			return
+		}
+	}
+}
+
+// instantQuery evaluates the query at the given time, through the same path as the queries received by the
+// queries API, and decodes the result, of the given type, into out.
+func (h *Handler) instantQuery(r *http.Request, query string, now time.Time, resultType model.ValueType, out interface{}) error {
+	req, err := newInstantQueryRequest(r.Context(), r, query, now)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.next.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+		ErrorType apierror.Type `json:"errorType"`
+		Error     string        `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode/100 != 2 {
+			return apierror.New(statusCodeErrorType(resp.StatusCode), strings.TrimSpace(string(body)))
+		}
+		return apierror.Newf(apierror.TypeInternal, "failed to decode the query response: %v", err)
+	}
+	if result.Status != "success" {
+		errType := result.ErrorType
+		if errType == apierror.TypeNone {
+			errType = statusCodeErrorType(resp.StatusCode)
+		}
+		return apierror.New(errType, result.Error)
+	}
+	if result.Data.ResultType != resultType.String() {
+		return apierror.Newf(apierror.TypeInternal, "unexpected result type %q of the query response", result.Data.ResultType)
+	}
+
+	if err := json.Unmarshal(result.Data.Result, out); err != nil {
+		return apierror.Newf(apierror.TypeInternal, "failed to decode the query response: %v", err)
+	}
+	return nil
+}
+
+// newInstantQueryRequest makes the instant query request evaluating the query at the given time. The request
+// keeps the headers of the federation request, like the tenant ID, except the ones negotiating the response format.
+func newInstantQueryRequest(ctx context.Context, r *http.Request, query string, now time.Time) (*http.Request, error) {
+	u := *r.URL
+	u.Path = strings.TrimSuffix(u.Path, federatePathSuffix) + instantQueryPathSuffix
+	u.RawPath = ""
+	u.RawQuery = url.Values{
+		"query": []string{query},
+		"time":  []string{strconv.FormatFloat(float64(now.UnixMilli())/1000, 'f', -1, 64)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = u.RequestURI()
+	req.Header = r.Header.Clone()
+	req.Header.Set("Accept", "application/json")
+	req.Header.Del("Accept-Encoding")
+	req.Header.Del("Content-Type")
+	req.Header.Del("Content-Length")
+	return req, nil
+}
+
+// metricFamilies groups the series by metric name, in the order of the names, as untyped metrics: the type of the
+// federated metrics isn't known.
+func metricFamilies(series map[model.Fingerprint]*model.Sample) []*dto.MetricFamily {
+	byName := map[string]*dto.MetricFamily{}
+	for _, s := range series {
+		name := string(s.Metric[model.MetricNameLabel])
+		mf, ok := byName[name]
+		if !ok {
+			mf = &dto.MetricFamily{
+				Name: proto.String(name),
+				Type: dto.MetricType_UNTYPED.Enum(),
+			}
+			byName[name] = mf
+		}
+
+		m := &dto.Metric{
+			Untyped:     &dto.Untyped{Value: proto.Float64(float64(s.Value))},
+			TimestampMs: proto.Int64(int64(s.Timestamp)),
+		}
+		for ln, lv := range s.Metric {
+			if ln == model.MetricNameLabel {
+				continue
+			}
+			m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(string(ln)), Value: proto.String(string(lv))})
+		}
+		sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+		mf.Metric = append(mf.Metric, m)
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(byName))
+	for _, mf := range byName {
+		sort.Slice(mf.Metric, func(i, j int) bool { return labelPairsLess(mf.Metric[i].Label, mf.Metric[j].Label) })
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+	return families
+}
+
+func labelPairsLess(a, b []*dto.LabelPair) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].GetName() != b[i].GetName() {
+			return a[i].GetName() < b[i].GetName()
+		}
+		if a[i].GetValue() != b[i].GetValue() {
+			return a[i].GetValue() < b[i].GetValue()
+		}
+	}
+	return len(a) < len(b)
+}
+
+// statusCodeErrorType returns the type of the API errors with the given status code.
+func statusCodeErrorType(code int) apierror.Type {
+	switch code {
+	case http.StatusBadRequest:
+		return apierror.TypeBadData
+	case http.StatusUnprocessableEntity:
+		return apierror.TypeExec
+	case http.StatusNotFound:
+		return apierror.TypeNotFound
+	case http.StatusTooManyRequests:
+		return apierror.TypeTooManyRequests
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return apierror.TypeTimeout
+	default:
+		return apierror.TypeInternal
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if resp, ok := apierror.HTTPResponseFromError(err); ok {
+		_ = server.WriteResponse(w, resp)
+		return
+	}
+	server.WriteError(w, err)
+}
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package federate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+)
+
+type mockLimits struct {
+	enabled   bool
+	maxSeries int
+}
+
+func (m mockLimits) FederateEndpointEnabled(string) bool { return m.enabled }
+func (m mockLimits) FederateMaxSeries(string) int        { return m.maxSeries }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// queryResponses returns a round-tripper replying to the instant queries with the result of the query from results,
+// which is a matrix for the range vector selectors, and a vector otherwise.
+func queryResponses(t *testing.T, results map[string]string, requests *[]*http.Request) http.RoundTripper {
+	var mtx sync.Mutex
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mtx.Lock()
+		*requests = append(*requests, r)
+		mtx.Unlock()
+
+		query := r.URL.Query().Get("query")
+		result, ok := results[query]
+		require.True(t, ok, query)
+		resultType := "vector"
+		if strings.HasSuffix(query, "]") {
+			resultType = "matrix"
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"status":"success","data":{"resultType":%q,"result":%s}}`, resultType, result))),
+		}, nil
+	})
+}
+
+func TestHandler(t *testing.T) {
+	now := time.UnixMilli(1690000000000)
+	// The series of the job c ended with a stale marker, so they're only selected by the range vector selectors.
+	results := map[string]string{
+		`up`: `[
+			{"metric":{"__name__":"up","job":"b"},"value":[1690000000,"0"]},
+			{"metric":{"__name__":"up","job":"a"},"value":[1690000000,"1"]}
+		]`,
+		`up[5m]`: `[
+			{"metric":{"__name__":"up","job":"b"},"values":[[1689999970,"1"],[1689999985,"0"]]},
+			{"metric":{"__name__":"up","job":"a"},"values":[[1689999990,"1"]]},
+			{"metric":{"__name__":"up","job":"c"},"values":[[1689999900,"1"]]}
+		]`,
+		`{job="a"}`: `[
+			{"metric":{"__name__":"up","job":"a"},"value":[1690000000,"1"]},
+			{"metric":{"__name__":"requests_total","job":"a","code":"200"},"value":[1690000000,"1234.5"]},
+			{"metric":{"__name__":"latency","job":"a"},"histogram":[1690000000,{"count":"1","sum":"1","buckets":[[0,"0","1","1"]]}]}
+		]`,
+		`{job="a"}[5m]`: `[
+			{"metric":{"__name__":"up","job":"a"},"values":[[1689999990,"1"]]},
+			{"metric":{"__name__":"requests_total","job":"a","code":"200"},"values":[[1689999991,"1234.5"]]},
+			{"metric":{"__name__":"latency","job":"a"},"histograms":[[1689999992,{"count":"1","sum":"1","buckets":[[0,"0","1","1"]]}]]}
+		]`,
+	}
+
+	for name, tc := range map[string]struct {
+		limits         mockLimits
+		selectors      []string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should fail if the endpoint is disabled for the tenant": {
+			limits:         mockLimits{enabled: false},
+			selectors:      []string{`up`},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "the /federate endpoint is disabled for the tenant: user-1",
+		},
+		"should fail without selectors": {
+			limits:         mockLimits{enabled: true},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "at least one match[] selector is required",
+		},
+		"should fail with an invalid selector": {
+			limits:         mockLimits{enabled: true},
+			selectors:      []string{`up{`},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `invalid match[] selector "up{"`,
+		},
+		"should return the latest sample of the series matching the selectors once, skipping the stale series and the native histograms": {
+			limits:         mockLimits{enabled: true, maxSeries: 3},
+			selectors:      []string{`up`, `{job="a"}`},
+			expectedStatus: http.StatusOK,
+			expectedBody: `# TYPE requests_total untyped
+requests_total{code="200",job="a"} 1234.5 1689999991000
+# TYPE up untyped
+up{job="a"} 1 1689999990000
+up{job="b"} 0 1689999985000
+`,
+		},
+		"should fail if the series exceed the limit": {
+			limits:         mockLimits{enabled: true, maxSeries: 2},
+			selectors:      []string{`up`, `{job="a"}`},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "the federation request matched more than 2 series",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var requests []*http.Request
+			h := NewHandler(queryResponses(t, results, &requests), tc.limits, 5*time.Minute, 1024, log.NewNopLogger())
+			h.now = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/prometheus/federate?"+url.Values{"match[]": tc.selectors}.Encode(), nil)
+			req.Header.Set("X-Scope-OrgID", "user-1")
+			req.Header.Set("Accept", "text/plain")
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedBody, resp.Body.String())
+				assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header().Get("Content-Type"))
+			} else {
+				assert.Contains(t, resp.Body.String(), tc.expectedBody)
+			}
+
+			for _, r := range requests {
+				assert.Equal(t, "/prometheus/api/v1/query", r.URL.Path)
+				assert.Equal(t, "1690000000", r.URL.Query().Get("time"))
+				assert.Equal(t, "user-1", r.Header.Get("X-Scope-OrgID"))
+				assert.Equal(t, "application/json", r.Header.Get("Accept"))
+				assert.Equal(t, r.URL.RequestURI(), r.RequestURI)
+			}
+		})
+	}
+}
+
+func TestHandler_QueryErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		roundTrip      roundTripperFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should return the API error of the query": {
+			roundTrip: func(*http.Request) (*http.Response, error) {
+				return nil, apierror.New(apierror.TypeExec, "the query exceeded the limit")
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"error","errorType":"execution","error":"the query exceeded the limit"}`,
+		},
+		"should return the error of the query response": {
+			roundTrip: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(strings.NewReader(`{"status":"error","errorType":"bad_data","error":"invalid query"}`)),
+				}, nil
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"error","errorType":"bad_data","error":"invalid query"}`,
+		},
+		"should return the error of a non JSON query response": {
+			roundTrip: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Body:       io.NopCloser(strings.NewReader("too many outstanding requests\n")),
+				}, nil
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"status":"error","errorType":"too_many_requests","error":"too many outstanding requests"}`,
+		},
+		"should fail on a result which isn't a vector": {
+			roundTrip: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"status":"success","data":{"resultType":"scalar","result":[1690000000,"1"]}}`)),
+				}, nil
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"error","errorType":"internal","error":"unexpected result type \"scalar\" of the query response"}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHandler(tc.roundTrip, mockLimits{enabled: true}, 5*time.Minute, 1024, log.NewNopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/prometheus/federate?match[]=up", nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code)
+			assert.Equal(t, tc.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func TestHandler_MaxBodySize(t *testing.T) {
+	h := NewHandler(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		require.Fail(t, "no query should be run")
+		return nil, nil
+	}), mockLimits{enabled: true}, 5*time.Minute, 16, log.NewNopLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/prometheus/federate", strings.NewReader(url.Values{"match[]": []string{`{job="a-very-long-job-name"}`}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "error parsing form values")
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package heavyqueries
+
+import (
+	"net/http"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// ServeHTTP returns the heaviest queries of the tenant, by cumulative wall time and fetched bytes.
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	tenantID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	util.WriteJSONResponse(w, t.HeavyQueries(tenantID))
+}
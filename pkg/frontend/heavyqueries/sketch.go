@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package heavyqueries
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// sketchEntry is a query tracked by a sketch.
+type sketchEntry struct {
+	fingerprint string
+	path        string
+	query       string
+
+	// value is the estimated cumulative value of the query. It overestimates the actual
+	// value by at most maxError.
+	value    float64
+	maxError float64
+
+	// executions is the number of executions of the query observed since it has been tracked.
+	executions int64
+
+	// index of the entry in the heap.
+	index int
+}
+
+// sketch finds the queries with the highest cumulative value in bounded space, using the
+// Space-Saving algorithm: when the sketch is full, the query with the lowest value is evicted
+// and the new query inherits its value as error, so that the heavy queries are never evicted
+// by a long tail of light ones.
+type sketch struct {
+	capacity int
+	entries  map[string]*sketchEntry
+	heap     sketchHeap
+}
+
+func newSketch(capacity int) *sketch {
+	return &sketch{
+		capacity: capacity,
+		entries:  make(map[string]*sketchEntry, capacity),
+		heap:     make(sketchHeap, 0, capacity),
+	}
+}
+
+func (s *sketch) add(fingerprint, path, query string, value float64) {
+	if e, ok := s.entries[fingerprint]; ok {
+		e.value += value
+		e.executions++
+		heap.Fix(&s.heap, e.index)
+		return
+	}
+
+	if len(s.heap) < s.capacity {
+		e := &sketchEntry{fingerprint: fingerprint, path: path, query: query, value: value, executions: 1}
+		s.entries[fingerprint] = e
+		heap.Push(&s.heap, e)
+		return
+	}
+
+	// Replace the query with the lowest value.
+	e := s.heap[0]
+	delete(s.entries, e.fingerprint)
+	*e = sketchEntry{fingerprint: fingerprint, path: path, query: query, value: e.value + value, maxError: e.value, executions: 1, index: e.index}
+	s.entries[fingerprint] = e
+	heap.Fix(&s.heap, e.index)
+}
+
+// topK returns a copy of the k entries with the highest value, sorted by value in descending order.
+func (s *sketch) topK(k int) []sketchEntry {
+	res := make([]sketchEntry, 0, len(s.heap))
+	for _, e := range s.heap {
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].value != res[j].value {
+			return res[i].value > res[j].value
+		}
+		return res[i].fingerprint < res[j].fingerprint
+	})
+	if len(res) > k {
+		res = res[:k]
+	}
+	return res
+}
+
+// sketchHeap is a min-heap of sketch entries by value.
+type sketchHeap []*sketchEntry
+
+func (h sketchHeap) Len() int           { return len(h) }
+func (h sketchHeap) Less(i, j int) bool { return h[i].value < h[j].value }
+
+func (h sketchHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *sketchHeap) Push(x interface{}) {
+	e := x.(*sketchEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *sketchHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package heavyqueries
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSketch(t *testing.T) {
+	s := newSketch(3)
+	s.add("a", "/query", "a", 1)
+	s.add("b", "/query", "b", 5)
+	s.add("a", "/query", "a", 2)
+	s.add("c", "/query", "c", 4)
+
+	assert.Equal(t, []sketchEntry{
+		{fingerprint: "b", path: "/query", query: "b", value: 5, executions: 1},
+		{fingerprint: "c", path: "/query", query: "c", value: 4, executions: 1},
+	}, withoutIndex(s.topK(2)))
+
+	// The sketch is full, so the query with the lowest value is replaced.
+	s.add("d", "/query", "d", 0.5)
+	assert.Equal(t, []sketchEntry{
+		{fingerprint: "b", path: "/query", query: "b", value: 5, executions: 1},
+		{fingerprint: "c", path: "/query", query: "c", value: 4, executions: 1},
+		{fingerprint: "d", path: "/query", query: "d", value: 3.5, maxError: 3, executions: 1},
+	}, withoutIndex(s.topK(10)))
+}
+
+func TestSketch_HeavyHittersAreNeverEvicted(t *testing.T) {
+	s := newSketch(10)
+	rnd := rand.New(rand.NewSource(0))
+
+	// A long tail of light queries, mixed with few heavy ones.
+	for i := 0; i < 10000; i++ {
+		if i%100 == 0 {
+			s.add(fmt.Sprintf("heavy-%d", i%300), "/query", "", 100)
+		}
+		s.add(fmt.Sprintf("light-%d", rnd.Intn(1000)), "/query", "", 1)
+	}
+
+	top := s.topK(3)
+	require.Len(t, top, 3)
+	for _, e := range top {
+		assert.Contains(t, e.fingerprint, "heavy-")
+		assert.GreaterOrEqual(t, e.value, float64(100*33))
+	}
+}
+
+func withoutIndex(entries []sketchEntry) []sketchEntry {
+	for i := range entries {
+		entries[i].index = 0
+	}
+	return entries
+}
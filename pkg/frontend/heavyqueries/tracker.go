@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package heavyqueries
+
+import (
+	"context"
+	"flag"
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Config holds the heavy queries tracking configuration.
+type Config struct {
+	Enabled     bool          `yaml:"enabled" category:"experimental"`
+	TopK        int           `yaml:"top_k" category:"experimental"`
+	SketchSize  int           `yaml:"sketch_size" category:"experimental"`
+	ResetPeriod time.Duration `yaml:"reset_period" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "query-frontend.heavy-queries.enabled", false, "True to track, for each tenant, the queries with the highest cumulative wall time and fetched bytes, and expose them through the /api/v1/heavy_queries API and metrics. Requires -query-frontend.query-stats-enabled.")
+	f.IntVar(&cfg.TopK, "query-frontend.heavy-queries.top-k", 10, "Number of heaviest queries exposed for each tenant.")
+	f.IntVar(&cfg.SketchSize, "query-frontend.heavy-queries.sketch-size", 100, "Maximum number of distinct queries tracked for each tenant. A higher number increases the accuracy of the heaviest queries at the cost of a higher memory usage.")
+	f.DurationVar(&cfg.ResetPeriod, "query-frontend.heavy-queries.reset-period", time.Hour, "How often the tracked queries are reset.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TopK <= 0 {
+		return errors.New("the heavy queries top-k must be greater than 0")
+	}
+	if cfg.SketchSize < cfg.TopK {
+		return errors.New("the heavy queries sketch size must be greater than or equal to the top-k")
+	}
+	if cfg.ResetPeriod <= 0 {
+		return errors.New("the heavy queries reset period must be greater than 0")
+	}
+	return nil
+}
+
+// tenantSketches holds the sketches of the queries of a tenant.
+type tenantSketches struct {
+	wallTime     *sketch
+	fetchedBytes *sketch
+}
+
+// Tracker tracks, for each tenant, the queries with the highest cumulative wall time and fetched bytes
+// in bounded space. Queries are identified by a fingerprint of the API path and the normalized expression,
+// so that the executions of the same query over different time ranges are accounted together.
+type Tracker struct {
+	services.Service
+
+	cfg Config
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantSketches
+	since   time.Time
+
+	wallTimeDesc     *prometheus.Desc
+	fetchedBytesDesc *prometheus.Desc
+}
+
+// NewTracker makes a new Tracker.
+func NewTracker(cfg Config, reg prometheus.Registerer) *Tracker {
+	t := &Tracker{
+		cfg:     cfg,
+		tenants: map[string]*tenantSketches{},
+		since:   time.Now(),
+		wallTimeDesc: prometheus.NewDesc(
+			"cortex_query_frontend_heavy_query_wall_time_seconds",
+			"Estimated cumulative wall time of the heaviest queries of each tenant by wall time, since the last reset.",
+			[]string{"user", "fingerprint"}, nil),
+		fetchedBytesDesc: prometheus.NewDesc(
+			"cortex_query_frontend_heavy_query_fetched_bytes",
+			"Estimated cumulative chunk and index bytes fetched by the heaviest queries of each tenant by fetched bytes, since the last reset.",
+			[]string{"user", "fingerprint"}, nil),
+	}
+
+	if reg != nil {
+		reg.MustRegister(t)
+	}
+
+	t.Service = services.NewTimerService(cfg.ResetPeriod, nil, t.iteration, nil)
+	return t
+}
+
+func (t *Tracker) iteration(_ context.Context) error {
+	t.reset(time.Now())
+	return nil
+}
+
+// reset forgets the queries tracked for all tenants.
+func (t *Tracker) reset(now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.tenants = map[string]*tenantSketches{}
+	t.since = now
+}
+
+// ObserveQuery accounts the wall time and the fetched bytes of an executed query to the tenant's heavy queries.
+func (t *Tracker) ObserveQuery(tenantID, path string, params url.Values, wallTime time.Duration, fetchedBytes uint64) {
+	fingerprint, query := queryFingerprint(path, params)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, ok := t.tenants[tenantID]
+	if !ok {
+		s = &tenantSketches{wallTime: newSketch(t.cfg.SketchSize), fetchedBytes: newSketch(t.cfg.SketchSize)}
+		t.tenants[tenantID] = s
+	}
+	s.wallTime.add(fingerprint, path, query, wallTime.Seconds())
+	s.fetchedBytes.add(fingerprint, path, query, float64(fetchedBytes))
+}
+
+// HeavyQuery is one of the heaviest queries of a tenant.
+type HeavyQuery struct {
+	Fingerprint string `json:"fingerprint"`
+	Path        string `json:"path"`
+	Query       string `json:"query"`
+	// Executions is the number of executions of the query since it's been tracked.
+	Executions int64 `json:"executions"`
+	// Value is the estimated cumulative wall time in seconds or fetched bytes of the query.
+	Value float64 `json:"value"`
+	// MaxOverestimation is the maximum amount by which Value may overestimate the actual value.
+	MaxOverestimation float64 `json:"max_overestimation"`
+}
+
+// HeavyQueries are the heaviest queries of a tenant.
+type HeavyQueries struct {
+	Since          time.Time    `json:"since"`
+	ByWallTime     []HeavyQuery `json:"by_wall_time_seconds"`
+	ByFetchedBytes []HeavyQuery `json:"by_fetched_bytes"`
+}
+
+// HeavyQueries returns the heaviest queries of the tenant.
+func (t *Tracker) HeavyQueries(tenantID string) HeavyQueries {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	res := HeavyQueries{Since: t.since, ByWallTime: []HeavyQuery{}, ByFetchedBytes: []HeavyQuery{}}
+	if s, ok := t.tenants[tenantID]; ok {
+		res.ByWallTime = toHeavyQueries(s.wallTime.topK(t.cfg.TopK))
+		res.ByFetchedBytes = toHeavyQueries(s.fetchedBytes.topK(t.cfg.TopK))
+	}
+	return res
+}
+
+func toHeavyQueries(entries []sketchEntry) []HeavyQuery {
+	res := make([]HeavyQuery, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, HeavyQuery{
+			Fingerprint:       e.fingerprint,
+			Path:              e.path,
+			Query:             e.query,
+			Executions:        e.executions,
+			Value:             e.value,
+			MaxOverestimation: e.maxError,
+		})
+	}
+	return res
+}
+
+// Describe implements prometheus.Collector.
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.wallTimeDesc
+	ch <- t.fetchedBytesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for tenantID, s := range t.tenants {
+		for _, e := range s.wallTime.topK(t.cfg.TopK) {
+			ch <- prometheus.MustNewConstMetric(t.wallTimeDesc, prometheus.GaugeValue, e.value, tenantID, e.fingerprint)
+		}
+		for _, e := range s.fetchedBytes.topK(t.cfg.TopK) {
+			ch <- prometheus.MustNewConstMetric(t.fetchedBytesDesc, prometheus.GaugeValue, e.value, tenantID, e.fingerprint)
+		}
+	}
+}
+
+// queryFingerprint returns the fingerprint of the query and its normalized description. The time range
+// and the step of the query are ignored.
+func queryFingerprint(path string, params url.Values) (string, string) {
+	var query string
+	if expr := params.Get("query"); expr != "" {
+		query = expr
+		if parsed, err := parser.ParseExpr(expr); err == nil {
+			query = parsed.String()
+		}
+	} else if matchers := params["match[]"]; len(matchers) > 0 {
+		sorted := append([]string(nil), matchers...)
+		sort.Strings(sorted)
+		query = strings.Join(sorted, ", ")
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(path))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(query))
+	return strconv.FormatUint(h.Sum64(), 16), query
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package heavyqueries
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestTracker(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	tracker := NewTracker(Config{Enabled: true, TopK: 1, SketchSize: 10, ResetPeriod: time.Hour}, reg)
+
+	rangeQuery := func(expr, start string) url.Values {
+		return url.Values{"query": []string{expr}, "start": []string{start}, "end": []string{"100"}, "step": []string{"10"}}
+	}
+
+	// The same query over different time ranges, and formatted differently, is accounted together.
+	tracker.ObserveQuery("user-1", "/api/v1/query_range", rangeQuery("sum(rate(foo[1m]))", "0"), 2*time.Second, 100)
+	tracker.ObserveQuery("user-1", "/api/v1/query_range", rangeQuery("sum( rate(foo[1m]) )", "50"), 3*time.Second, 100)
+	tracker.ObserveQuery("user-1", "/api/v1/query_range", rangeQuery("up", "0"), time.Second, 1000)
+	tracker.ObserveQuery("user-2", "/api/v1/series", url.Values{"match[]": []string{"up"}}, time.Second, 10)
+
+	sumFingerprint, _ := queryFingerprint("/api/v1/query_range", rangeQuery("sum(rate(foo[1m]))", "0"))
+	upFingerprint, _ := queryFingerprint("/api/v1/query_range", rangeQuery("up", "0"))
+
+	res := tracker.HeavyQueries("user-1")
+	assert.Equal(t, []HeavyQuery{{Fingerprint: sumFingerprint, Path: "/api/v1/query_range", Query: "sum(rate(foo[1m]))", Executions: 2, Value: 5}}, res.ByWallTime)
+	assert.Equal(t, []HeavyQuery{{Fingerprint: upFingerprint, Path: "/api/v1/query_range", Query: "up", Executions: 1, Value: 1000}}, res.ByFetchedBytes)
+
+	res = tracker.HeavyQueries("user-3")
+	assert.Empty(t, res.ByWallTime)
+	assert.Empty(t, res.ByFetchedBytes)
+
+	seriesFingerprint, _ := queryFingerprint("/api/v1/series", url.Values{"match[]": []string{"up"}})
+	expectedMetrics := `
+		# HELP cortex_query_frontend_heavy_query_wall_time_seconds Estimated cumulative wall time of the heaviest queries of each tenant by wall time, since the last reset.
+		# TYPE cortex_query_frontend_heavy_query_wall_time_seconds gauge
+		cortex_query_frontend_heavy_query_wall_time_seconds{fingerprint="` + sumFingerprint + `",user="user-1"} 5
+		cortex_query_frontend_heavy_query_wall_time_seconds{fingerprint="` + seriesFingerprint + `",user="user-2"} 1
+		# HELP cortex_query_frontend_heavy_query_fetched_bytes Estimated cumulative chunk and index bytes fetched by the heaviest queries of each tenant by fetched bytes, since the last reset.
+		# TYPE cortex_query_frontend_heavy_query_fetched_bytes gauge
+		cortex_query_frontend_heavy_query_fetched_bytes{fingerprint="` + upFingerprint + `",user="user-1"} 1000
+		cortex_query_frontend_heavy_query_fetched_bytes{fingerprint="` + seriesFingerprint + `",user="user-2"} 10
+	`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics)))
+
+	// The tracked queries are forgotten on reset.
+	now := time.Now()
+	tracker.reset(now)
+	res = tracker.HeavyQueries("user-1")
+	assert.Equal(t, now, res.Since)
+	assert.Empty(t, res.ByWallTime)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader("")))
+}
+
+func TestTracker_ServeHTTP(t *testing.T) {
+	tracker := NewTracker(Config{Enabled: true, TopK: 10, SketchSize: 10, ResetPeriod: time.Hour}, nil)
+	tracker.ObserveQuery("user-1", "/api/v1/query", url.Values{"query": []string{"up"}}, time.Second, 100)
+
+	resp := httptest.NewRecorder()
+	tracker.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/heavy_queries", nil))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/heavy_queries", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+	resp = httptest.NewRecorder()
+	tracker.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	res := HeavyQueries{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	require.Len(t, res.ByWallTime, 1)
+	assert.Equal(t, "up", res.ByWallTime[0].Query)
+	assert.Equal(t, float64(1), res.ByWallTime[0].Value)
+	require.Len(t, res.ByFetchedBytes, 1)
+	assert.Equal(t, float64(100), res.ByFetchedBytes[0].Value)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate())
+	assert.NoError(t, (&Config{Enabled: true, TopK: 10, SketchSize: 10, ResetPeriod: time.Hour}).Validate())
+	assert.Error(t, (&Config{Enabled: true, TopK: 0, SketchSize: 10, ResetPeriod: time.Hour}).Validate())
+	assert.Error(t, (&Config{Enabled: true, TopK: 10, SketchSize: 5, ResetPeriod: time.Hour}).Validate())
+	assert.Error(t, (&Config{Enabled: true, TopK: 10, SketchSize: 10}).Validate())
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+type accessPolicyContextKey int
+
+const accessPolicyKey accessPolicyContextKey = 0
+
+// defaultLookbackDelta is the lookback delta used by the PromQL engine when not configured.
+const defaultLookbackDelta = 5 * time.Minute
+
+// accessPolicy is the query access policy enforced on the queries of a sub-user.
+type accessPolicy struct {
+	subUser          string
+	matchers         []*labels.Matcher
+	maxQueryLookback time.Duration
+}
+
+// newAccessPolicyTripperware creates a new Tripperware which looks up the query access policy of the
+// sub-user identified by the given header. Sub-users with a policy can only run range and instant
+// queries, which are restricted by the access policy middleware. The header is trusted like the
+// tenant ID header, so it must be set by the authenticating proxy in front of Mimir.
+func newAccessPolicyTripperware(header string, limits Limits) Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if header == "" {
+			return next
+		}
+
+		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			tenantIDs, err := tenant.TenantIDs(r.Context())
+			if err != nil {
+				return nil, apierror.New(apierror.TypeBadData, err.Error())
+			}
+
+			policy, err := subUserAccessPolicy(tenantIDs, r.Header.Get(header), header, limits)
+			if err != nil {
+				return nil, err
+			}
+			if policy == nil {
+				return next.RoundTrip(r)
+			}
+
+			if !isRangeQuery(r.URL.Path) && !isInstantQuery(r.URL.Path) {
+				return nil, apierror.Newf(apierror.TypeForbidden, "the query access policy of the sub-user %q only allows range and instant queries", policy.subUser)
+			}
+			return next.RoundTrip(r.WithContext(context.WithValue(r.Context(), accessPolicyKey, policy)))
+		})
+	}
+}
+
+// subUserAccessPolicy returns the query access policy of the sub-user, or nil if the tenants have no policies.
+// The requests of a tenant with policies which don't identify the sub-user are rejected, so that omitting
+// the header doesn't bypass the policies.
+func subUserAccessPolicy(tenantIDs []string, subUser, header string, limits Limits) (*accessPolicy, error) {
+	var restricted []string
+	for _, tenantID := range tenantIDs {
+		if len(limits.QueryAccessPolicies(tenantID)) > 0 {
+			restricted = append(restricted, tenantID)
+		}
+	}
+	if len(restricted) == 0 {
+		return nil, nil
+	}
+	if len(tenantIDs) > 1 {
+		return nil, apierror.Newf(apierror.TypeForbidden, "query access policies are not supported for cross-tenant queries, but the tenant %q has query access policies", restricted[0])
+	}
+
+	if subUser == "" {
+		return nil, apierror.Newf(apierror.TypeForbidden, "the tenant %q has query access policies, but the request doesn't identify the sub-user with the %s header", tenantIDs[0], header)
+	}
+
+	policy, ok := limits.QueryAccessPolicies(tenantIDs[0])[subUser]
+	if !ok {
+		return nil, apierror.Newf(apierror.TypeForbidden, "the sub-user %q has no query access policy", subUser)
+	}
+
+	matchers, err := policy.Matchers()
+	if err != nil {
+		// Should never happen, because the policies are validated when loaded.
+		return nil, apierror.New(apierror.TypeInternal, err.Error())
+	}
+
+	return &accessPolicy{
+		subUser:          subUser,
+		matchers:         matchers,
+		maxQueryLookback: time.Duration(policy.MaxQueryLookback),
+	}, nil
+}
+
+type accessPolicyMiddleware struct {
+	next          Handler
+	lookbackDelta time.Duration
+	logger        log.Logger
+}
+
+// newAccessPolicyMiddleware creates a new Middleware which enforces the query access policy of the sub-user,
+// if any: the policy label matchers are added to every selector of the query, and the start of the query
+// is moved forward so that no data older than the policy max query lookback is read.
+func newAccessPolicyMiddleware(lookbackDelta time.Duration, logger log.Logger) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return accessPolicyMiddleware{
+			next:          next,
+			lookbackDelta: lookbackDelta,
+			logger:        logger,
+		}
+	})
+}
+
+func (m accessPolicyMiddleware) Do(ctx context.Context, r Request) (Response, error) {
+	policy, ok := ctx.Value(accessPolicyKey).(*accessPolicy)
+	if !ok {
+		return m.next.Do(ctx, r)
+	}
+
+	log, ctx := spanlogger.NewWithLogger(ctx, m.logger, "accessPolicy")
+	defer log.Finish()
+
+	expr, err := parser.ParseExpr(r.GetQuery())
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	// The earliest data read by the query is this far before the start of the query.
+	reach, hasAtModifier := m.enforceMatchers(expr, policy.matchers)
+
+	if policy.maxQueryLookback > 0 {
+		if hasAtModifier {
+			return nil, apierror.Newf(apierror.TypeForbidden, "the query access policy of the sub-user %q doesn't allow the @ modifier", policy.subUser)
+		}
+
+		minStartTime := util.TimeToMillis(time.Now().Add(-policy.maxQueryLookback).Add(reach))
+		if r.GetEnd() < minStartTime {
+			level.Debug(log).Log(
+				"msg", "skipping the execution of the query because its time range is before the max query lookback of the sub-user access policy",
+				"subUser", policy.subUser,
+				"reqStart", util.FormatTimeMillis(r.GetStart()),
+				"reqEnd", util.FormatTimeMillis(r.GetEnd()),
+				"maxQueryLookback", policy.maxQueryLookback)
+
+			return newEmptyPrometheusResponse(), nil
+		}
+
+		if r.GetStart() < minStartTime {
+			level.Debug(log).Log(
+				"msg", "the start time of the query has been manipulated because of the max query lookback of the sub-user access policy",
+				"subUser", policy.subUser,
+				"original", util.FormatTimeMillis(r.GetStart()),
+				"updated", util.FormatTimeMillis(minStartTime),
+				"maxQueryLookback", policy.maxQueryLookback)
+
+			r = r.WithStartEnd(minStartTime, r.GetEnd())
+		}
+	}
+
+	if len(policy.matchers) > 0 {
+		r = r.WithQuery(expr.String())
+	}
+
+	return m.next.Do(ctx, r)
+}
+
+// enforceMatchers adds the matchers to every selector of expr, and returns how far before the
+// evaluation time the query reads data, and whether the query uses the @ modifier.
+func (m accessPolicyMiddleware) enforceMatchers(expr parser.Expr, matchers []*labels.Matcher) (reach time.Duration, hasAtModifier bool) {
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			n.LabelMatchers = append(n.LabelMatchers, matchers...)
+			hasAtModifier = hasAtModifier || n.Timestamp != nil || n.StartOrEnd != 0
+
+			selectorReach := n.OriginalOffset + m.lookbackDelta
+			if len(path) > 0 {
+				if matrix, ok := path[len(path)-1].(*parser.MatrixSelector); ok {
+					selectorReach = n.OriginalOffset + matrix.Range
+				}
+			}
+			for _, p := range path {
+				if subquery, ok := p.(*parser.SubqueryExpr); ok {
+					selectorReach += subquery.Range + subquery.OriginalOffset
+				}
+			}
+			if selectorReach > reach {
+				reach = selectorReach
+			}
+
+		case *parser.SubqueryExpr:
+			hasAtModifier = hasAtModifier || n.Timestamp != nil || n.StartOrEnd != 0
+		}
+		return nil
+	})
+
+	return reach, hasAtModifier
+}
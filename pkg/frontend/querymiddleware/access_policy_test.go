@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestAccessPolicyTripperware(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	const header = "X-Mimir-Sub-User"
+
+	restricted := mockLimits{queryAccessPolicies: map[string]validation.QueryAccessPolicy{
+		"dev": {Selector: `{namespace="dev"}`, MaxQueryLookback: model.Duration(time.Hour)},
+	}}
+
+	tests := map[string]struct {
+		limits         Limits
+		tenantID       string
+		path           string
+		subUser        string
+		expectedPolicy *accessPolicy
+		expectedErr    apierror.Type
+	}{
+		"should not restrict the queries of a tenant without policies": {
+			limits:   mockLimits{},
+			tenantID: "user-1",
+			path:     "/api/v1/query_range",
+			subUser:  "dev",
+		},
+		"should not restrict the requests without sub-user of a tenant without policies": {
+			limits:   mockLimits{},
+			tenantID: "user-1",
+			path:     "/api/v1/series",
+		},
+		"should reject the requests without sub-user of a tenant with policies": {
+			limits:      restricted,
+			tenantID:    "user-1",
+			path:        "/api/v1/query_range",
+			expectedErr: apierror.TypeForbidden,
+		},
+		"should enforce the policy of a sub-user with an empty selector": {
+			limits: mockLimits{queryAccessPolicies: map[string]validation.QueryAccessPolicy{
+				"admin": {},
+			}},
+			tenantID:       "user-1",
+			path:           "/api/v1/query_range",
+			subUser:        "admin",
+			expectedPolicy: &accessPolicy{subUser: "admin"},
+		},
+		"should enforce the policy of the sub-user on range queries": {
+			limits:         restricted,
+			tenantID:       "user-1",
+			path:           "/api/v1/query_range",
+			subUser:        "dev",
+			expectedPolicy: &accessPolicy{subUser: "dev", matchers: mustParseMatchers(t, `{namespace="dev"}`), maxQueryLookback: time.Hour},
+		},
+		"should enforce the policy of the sub-user on instant queries": {
+			limits:         restricted,
+			tenantID:       "user-1",
+			path:           "/api/v1/query",
+			subUser:        "dev",
+			expectedPolicy: &accessPolicy{subUser: "dev", matchers: mustParseMatchers(t, `{namespace="dev"}`), maxQueryLookback: time.Hour},
+		},
+		"should reject the other requests of the sub-user": {
+			limits:      restricted,
+			tenantID:    "user-1",
+			path:        "/api/v1/series",
+			subUser:     "dev",
+			expectedErr: apierror.TypeForbidden,
+		},
+		"should reject the queries of a sub-user without policy": {
+			limits:      restricted,
+			tenantID:    "user-1",
+			path:        "/api/v1/query_range",
+			subUser:     "prod",
+			expectedErr: apierror.TypeForbidden,
+		},
+		"should reject the cross-tenant queries of a sub-user": {
+			limits:      restricted,
+			tenantID:    "user-1|user-2",
+			path:        "/api/v1/query_range",
+			subUser:     "dev",
+			expectedErr: apierror.TypeForbidden,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotPolicy *accessPolicy
+			next := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+				gotPolicy, _ = r.Context().Value(accessPolicyKey).(*accessPolicy)
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "http://localhost"+tc.path, nil)
+			require.NoError(t, err)
+			if tc.subUser != "" {
+				req.Header.Set(header, tc.subUser)
+			}
+			req = req.WithContext(user.InjectOrgID(context.Background(), tc.tenantID))
+
+			_, err = newAccessPolicyTripperware(header, tc.limits)(next).RoundTrip(req)
+			if tc.expectedErr != apierror.TypeNone {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedErr, apierror.TypeOf(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPolicy, gotPolicy)
+		})
+	}
+
+	t.Run("should not restrict any query if the sub-user header is not configured", func(t *testing.T) {
+		next := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			assert.Nil(t, r.Context().Value(accessPolicyKey))
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/series", nil)
+		require.NoError(t, err)
+		req.Header.Set(header, "dev")
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+		_, err = newAccessPolicyTripperware("", restricted)(next).RoundTrip(req)
+		require.NoError(t, err)
+	})
+}
+
+func TestAccessPolicyMiddleware(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		policy        *accessPolicy
+		query         string
+		start, end    time.Time
+		expectedQuery string
+		expectedStart time.Time
+		expectedEmpty bool
+		expectedErr   apierror.Type
+	}{
+		"should not modify the query without policy": {
+			query:         `sum(rate(foo[5m]))`,
+			start:         now.Add(-2 * time.Hour),
+			end:           now,
+			expectedQuery: `sum(rate(foo[5m]))`,
+			expectedStart: now.Add(-2 * time.Hour),
+		},
+		"should add the policy matchers to every selector": {
+			policy:        &accessPolicy{matchers: mustParseMatchers(t, `{namespace="dev"}`)},
+			query:         `sum(rate(foo[5m])) / on() bar{job="a"} + sum_over_time(baz[10m:1m])`,
+			start:         now.Add(-2 * time.Hour),
+			end:           now,
+			expectedQuery: `sum(rate(foo{namespace="dev"}[5m])) / on () bar{job="a",namespace="dev"} + sum_over_time(baz{namespace="dev"}[10m:1m])`,
+			expectedStart: now.Add(-2 * time.Hour),
+		},
+		"should move the start of the query forward, including the range and offset of the selectors": {
+			policy:        &accessPolicy{maxQueryLookback: time.Hour},
+			query:         `rate(foo[10m] offset 5m)`,
+			start:         now.Add(-2 * time.Hour),
+			end:           now,
+			expectedQuery: `rate(foo[10m] offset 5m)`,
+			expectedStart: now.Add(-time.Hour + 15*time.Minute),
+		},
+		"should include the lookback delta and the subqueries range": {
+			policy:        &accessPolicy{maxQueryLookback: time.Hour},
+			query:         `max_over_time(foo[20m:1m])`,
+			start:         now.Add(-2 * time.Hour),
+			end:           now,
+			expectedQuery: `max_over_time(foo[20m:1m])`,
+			expectedStart: now.Add(-time.Hour + 25*time.Minute),
+		},
+		"should not modify the start of the query within the max query lookback": {
+			policy:        &accessPolicy{maxQueryLookback: 3 * time.Hour},
+			query:         `rate(foo[10m])`,
+			start:         now.Add(-2 * time.Hour),
+			end:           now,
+			expectedQuery: `rate(foo[10m])`,
+			expectedStart: now.Add(-2 * time.Hour),
+		},
+		"should return an empty response if the query is fully before the max query lookback": {
+			policy:        &accessPolicy{maxQueryLookback: time.Hour},
+			query:         `foo`,
+			start:         now.Add(-3 * time.Hour),
+			end:           now.Add(-2 * time.Hour),
+			expectedEmpty: true,
+		},
+		"should reject the @ modifier if the policy has a max query lookback": {
+			policy:      &accessPolicy{maxQueryLookback: time.Hour},
+			query:       `foo @ 0`,
+			start:       now.Add(-time.Minute),
+			end:         now,
+			expectedErr: apierror.TypeForbidden,
+		},
+		"should reject invalid queries": {
+			policy:      &accessPolicy{matchers: mustParseMatchers(t, `{namespace="dev"}`)},
+			query:       `sum(`,
+			start:       now.Add(-time.Minute),
+			end:         now,
+			expectedErr: apierror.TypeBadData,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.policy != nil {
+				ctx = context.WithValue(ctx, accessPolicyKey, tc.policy)
+			}
+
+			req := &PrometheusRangeQueryRequest{
+				Path:  "/api/v1/query_range",
+				Start: util.TimeToMillis(tc.start),
+				End:   util.TimeToMillis(tc.end),
+				Step:  60000,
+				Query: tc.query,
+			}
+
+			var gotReq Request
+			next := HandlerFunc(func(_ context.Context, r Request) (Response, error) {
+				gotReq = r
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			})
+
+			resp, err := newAccessPolicyMiddleware(5*time.Minute, log.NewNopLogger()).Wrap(next).Do(ctx, req)
+			if tc.expectedErr != apierror.TypeNone {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedErr, apierror.TypeOf(err))
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.expectedEmpty {
+				assert.Nil(t, gotReq)
+				assert.Equal(t, newEmptyPrometheusResponse(), resp)
+				return
+			}
+
+			require.NotNil(t, gotReq)
+			assert.Equal(t, tc.expectedQuery, gotReq.GetQuery())
+			// The time passing while the test runs moves the start forward by a few milliseconds.
+			assert.InDelta(t, util.TimeToMillis(tc.expectedStart), gotReq.GetStart(), float64(time.Second.Milliseconds()))
+			assert.Equal(t, util.TimeToMillis(tc.end), gotReq.GetEnd())
+		})
+	}
+}
+
+func mustParseMatchers(t *testing.T, selector string) []*labels.Matcher {
+	matchers, err := parser.ParseMetricSelector(selector)
+	require.NoError(t, err)
+	return matchers
+}
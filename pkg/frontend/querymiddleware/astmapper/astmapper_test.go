@@ -133,7 +133,7 @@ func TestSharding_BinaryExpressionsDontTakeExponentialTime(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	mapper, err := NewSharding(ctx, 2, log.NewNopLogger(), NewMapperStats())
+	mapper, err := NewSharding(ctx, 2, 0, log.NewNopLogger(), NewMapperStats())
 	require.NoError(t, err)
 
 	_, err = mapper.Map(expr)
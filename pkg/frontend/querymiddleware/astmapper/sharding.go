@@ -8,6 +8,7 @@ package astmapper
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
@@ -17,9 +18,11 @@ import (
 	"github.com/grafana/mimir/pkg/storage/sharding"
 )
 
-// NewSharding creates a new query sharding mapper.
-func NewSharding(ctx context.Context, shards int, logger log.Logger, stats *MapperStats) (ASTMapper, error) {
-	shardSummer, err := newShardSummer(ctx, shards, vectorSquasher, logger, stats)
+// NewSharding creates a new query sharding mapper. If topkOversamplingFactor is positive, the topk and bottomk
+// aggregations are sharded too, and the ones over sharded aggregations are approximated by selecting
+// topkOversamplingFactor times the requested number of elements in each shard.
+func NewSharding(ctx context.Context, shards int, topkOversamplingFactor float64, logger log.Logger, stats *MapperStats) (ASTMapper, error) {
+	shardSummer, err := newShardSummer(ctx, shards, topkOversamplingFactor, vectorSquasher, logger, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -35,17 +38,18 @@ type squasher = func(...parser.Expr) (parser.Expr, error)
 type shardSummer struct {
 	ctx context.Context
 
-	shards       int
-	currentShard *int
-	squash       squasher
-	logger       log.Logger
-	stats        *MapperStats
+	shards                 int
+	topkOversamplingFactor float64
+	currentShard           *int
+	squash                 squasher
+	logger                 log.Logger
+	stats                  *MapperStats
 
 	canShardAllVectorSelectorsCache map[string]bool
 }
 
 // newShardSummer instantiates an ASTMapper which will fan out sum queries by shard
-func newShardSummer(ctx context.Context, shards int, squasher squasher, logger log.Logger, stats *MapperStats) (ASTMapper, error) {
+func newShardSummer(ctx context.Context, shards int, topkOversamplingFactor float64, squasher squasher, logger log.Logger, stats *MapperStats) (ASTMapper, error) {
 	if squasher == nil {
 		return nil, errors.Errorf("squasher required and not passed")
 	}
@@ -53,11 +57,12 @@ func newShardSummer(ctx context.Context, shards int, squasher squasher, logger l
 	return NewASTExprMapper(&shardSummer{
 		ctx: ctx,
 
-		shards:       shards,
-		squash:       squasher,
-		currentShard: nil,
-		logger:       logger,
-		stats:        stats,
+		shards:                 shards,
+		topkOversamplingFactor: topkOversamplingFactor,
+		squash:                 squasher,
+		currentShard:           nil,
+		logger:                 logger,
+		stats:                  stats,
 
 		canShardAllVectorSelectorsCache: make(map[string]bool),
 	}), nil
@@ -94,6 +99,9 @@ func (summer *shardSummer) MapExpr(expr parser.Expr) (mapped parser.Expr, finish
 		if CanParallelize(e, summer.logger) {
 			return summer.shardAggregate(e)
 		}
+		if (e.Op == parser.TOPK || e.Op == parser.BOTTOMK) && summer.topkOversamplingFactor > 0 {
+			return summer.shardTopK(e)
+		}
 		return e, false, nil
 
 	case *parser.VectorSelector:
@@ -395,6 +403,125 @@ func (summer *shardSummer) shardAvg(expr *parser.AggregateExpr) (result parser.E
 	}, nil
 }
 
+// shardTopK attempts to shard the given TOPK/BOTTOMK aggregation expression. The expression is left as is,
+// to be mapped further, if it can't be sharded.
+func (summer *shardSummer) shardTopK(expr *parser.AggregateExpr) (mapped parser.Expr, finished bool, err error) {
+	/*
+		parallelizing a topk over non-aggregated series is representable as
+		topk(5,
+		  topk(5, rate(bar1{__query_shard__="0_of_2",baz="blip"}[1m])) or
+		  topk(5, rate(bar1{__query_shard__="1_of_2",baz="blip"}[1m]))
+		)
+		which is exact, because each series belongs to a single shard.
+
+		parallelizing a topk over a shardable aggregation, with an oversampling factor of 2, is representable as
+		topk(5, sum by(foo) (
+		  topk(10, sum by(foo) (rate(bar1{__query_shard__="0_of_2",baz="blip"}[1m]))) or
+		  topk(10, sum by(foo) (rate(bar1{__query_shard__="1_of_2",baz="blip"}[1m])))
+		))
+		which is approximate, because the partial aggregations of a group which isn't selected in a shard
+		are missing from the final aggregation.
+	*/
+
+	// Only a constant number of elements is supported, to compute the number of elements to select in each shard.
+	param, ok := expr.Param.(*parser.NumberLiteral)
+	if !ok || param.Val < 1 || math.IsInf(param.Val, 0) {
+		return expr, false, nil
+	}
+
+	inner := expr.Expr
+	for {
+		paren, ok := inner.(*parser.ParenExpr)
+		if !ok {
+			break
+		}
+		inner = paren.Expr
+	}
+
+	// The aggregation can't be sharded if the inner expression can't.
+	if !CanParallelize(inner, summer.logger) {
+		return expr, false, nil
+	}
+
+	innerAggr, isAggr := inner.(*parser.AggregateExpr)
+	if !isAggr {
+		if containsAggregateExpr(inner) {
+			return expr, false, nil
+		}
+
+		sharded, err := summer.shardAndSquashTopKExpr(expr, param.Val, inner)
+		if err != nil {
+			return nil, false, err
+		}
+		return &parser.AggregateExpr{
+			Op:       expr.Op,
+			Expr:     sharded,
+			Param:    &parser.NumberLiteral{Val: param.Val},
+			Grouping: expr.Grouping,
+			Without:  expr.Without,
+		}, true, nil
+	}
+
+	// The partial aggregations selected in each shard are merged with mergeOp.
+	var mergeOp parser.ItemType
+	switch innerAggr.Op {
+	case parser.SUM, parser.COUNT:
+		mergeOp = parser.SUM
+	case parser.MIN, parser.MAX:
+		mergeOp = innerAggr.Op
+	default:
+		return expr, false, nil
+	}
+
+	shardParam := math.Ceil(param.Val * summer.topkOversamplingFactor)
+	sharded, err := summer.shardAndSquashTopKExpr(expr, shardParam, innerAggr)
+	if err != nil {
+		return nil, false, err
+	}
+	summer.stats.SetApproximate()
+
+	return &parser.AggregateExpr{
+		Op: expr.Op,
+		Expr: &parser.AggregateExpr{
+			Op:       mergeOp,
+			Expr:     sharded,
+			Grouping: innerAggr.Grouping,
+			Without:  innerAggr.Without,
+		},
+		Param:    &parser.NumberLiteral{Val: param.Val},
+		Grouping: expr.Grouping,
+		Without:  expr.Without,
+	}, true, nil
+}
+
+// shardAndSquashTopKExpr returns a squashed CONCAT expression including N embedded queries, where N is the
+// number of shards and each sub-query runs the given TOPK/BOTTOMK aggregation, selecting param elements, over
+// the inner expression of a different shard.
+func (summer *shardSummer) shardAndSquashTopKExpr(expr *parser.AggregateExpr, param float64, inner parser.Expr) (parser.Expr, error) {
+	children := make([]parser.Expr, 0, summer.shards)
+
+	// Create sub-query for each shard.
+	for i := 0; i < summer.shards; i++ {
+		sharded, err := cloneAndMap(NewASTExprMapper(summer.CopyWithCurShard(i)), inner)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, &parser.AggregateExpr{
+			Op:       expr.Op,
+			Expr:     sharded,
+			Param:    &parser.NumberLiteral{Val: param},
+			Grouping: expr.Grouping,
+			Without:  expr.Without,
+		})
+	}
+
+	// Update stats.
+	summer.stats.AddShardedQueries(summer.shards)
+
+	return summer.squash(children...)
+}
+
 // shardAndSquashAggregateExpr returns a squashed CONCAT expression including N embedded
 // queries, where N is the number of shards and each sub-query queries a different shard
 // with the given "op" aggregation operation.
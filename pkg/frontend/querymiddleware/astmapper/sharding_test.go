@@ -508,7 +508,7 @@ func TestShardSummer(t *testing.T) {
 
 		t.Run(tt.in, func(t *testing.T) {
 			stats := NewMapperStats()
-			mapper, err := NewSharding(context.Background(), 3, log.NewNopLogger(), stats)
+			mapper, err := NewSharding(context.Background(), 3, 0, log.NewNopLogger(), stats)
 			require.NoError(t, err)
 			expr, err := parser.ParseExpr(tt.in)
 			require.NoError(t, err)
@@ -548,6 +548,95 @@ func concat(queries ...string) string {
 	return mapped.String()
 }
 
+func TestShardSummerWithTopKOversampling(t *testing.T) {
+	for _, tt := range []struct {
+		in                     string
+		out                    string
+		oversamplingFactor     float64
+		expectedShardedQueries int
+		expectedApproximate    bool
+	}{
+		{
+			in:                     `topk(5, rate(metric[1m]))`,
+			out:                    concat(`topk(5, rate(metric[1m]))`),
+			oversamplingFactor:     0,
+			expectedShardedQueries: 0,
+		},
+		{
+			in:                     `topk(5, rate(metric[1m]))`,
+			out:                    `topk(5, ` + concatShards(3, `topk(5, rate(metric{__query_shard__="x_of_y"}[1m]))`) + `)`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 3,
+		},
+		{
+			in:                     `bottomk by(foo) (5, metric)`,
+			out:                    `bottomk by(foo) (5, ` + concatShards(3, `bottomk by(foo) (5, metric{__query_shard__="x_of_y"})`) + `)`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 3,
+		},
+		{
+			in:                     `topk(5, sum by(foo) (rate(metric[1m])))`,
+			out:                    `topk(5, sum by(foo) (` + concatShards(3, `topk(10, sum by(foo) (rate(metric{__query_shard__="x_of_y"}[1m])))`) + `))`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 3,
+			expectedApproximate:    true,
+		},
+		{
+			in:                     `bottomk(3, (count without(instance) (metric)))`,
+			out:                    `bottomk(3, sum without(instance) (` + concatShards(3, `bottomk(5, count without(instance) (metric{__query_shard__="x_of_y"}))`) + `))`,
+			oversamplingFactor:     1.5,
+			expectedShardedQueries: 3,
+			expectedApproximate:    true,
+		},
+		{
+			in:                     `topk(5, max by(foo) (metric))`,
+			out:                    `topk(5, max by(foo) (` + concatShards(3, `topk(5, max by(foo) (metric{__query_shard__="x_of_y"}))`) + `))`,
+			oversamplingFactor:     1,
+			expectedShardedQueries: 3,
+			expectedApproximate:    true,
+		},
+		{
+			// The averages can't be merged from the partial averages of the shards.
+			in:                     `topk(5, avg by(foo) (metric))`,
+			out:                    `topk(5, (sum by(foo) (` + concatShards(3, `sum by(foo) (metric{__query_shard__="x_of_y"})`) + `) / sum by(foo) (` + concatShards(3, `count by(foo) (metric{__query_shard__="x_of_y"})`) + `)))`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 6,
+		},
+		{
+			// The number of elements to select must be a constant.
+			in:                     `topk(scalar(count(metric)), metric)`,
+			out:                    concat(`topk(scalar(count(metric)), metric)`),
+			oversamplingFactor:     2,
+			expectedShardedQueries: 0,
+		},
+		{
+			// Nested aggregations can't be sharded.
+			in:                     `topk(5, sum by(foo) (max by(foo, bar) (metric)))`,
+			out:                    `topk(5, sum by(foo) (max by(foo, bar) (` + concatShards(3, `max by(foo, bar) (metric{__query_shard__="x_of_y"})`) + `)))`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 3,
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.in, func(t *testing.T) {
+			stats := NewMapperStats()
+			mapper, err := NewSharding(context.Background(), 3, tt.oversamplingFactor, log.NewNopLogger(), stats)
+			require.NoError(t, err)
+			expr, err := parser.ParseExpr(tt.in)
+			require.NoError(t, err)
+			out, err := parser.ParseExpr(tt.out)
+			require.NoError(t, err)
+
+			mapped, err := mapper.Map(expr)
+			require.NoError(t, err)
+			require.Equal(t, out.String(), mapped.String())
+			assert.Equal(t, tt.expectedShardedQueries, stats.GetShardedQueries())
+			assert.Equal(t, tt.expectedApproximate, stats.IsApproximate())
+		})
+	}
+}
+
 func TestShardSummerWithEncoding(t *testing.T) {
 	for i, c := range []struct {
 		shards   int
@@ -562,7 +651,7 @@ func TestShardSummerWithEncoding(t *testing.T) {
 	} {
 		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
 			stats := NewMapperStats()
-			summer, err := newShardSummer(context.Background(), c.shards, vectorSquasher, log.NewNopLogger(), stats)
+			summer, err := newShardSummer(context.Background(), c.shards, 0, vectorSquasher, log.NewNopLogger(), stats)
 			require.Nil(t, err)
 			expr, err := parser.ParseExpr(c.input)
 			require.Nil(t, err)
@@ -4,6 +4,7 @@ package astmapper
 
 type MapperStats struct {
 	shardedQueries int
+	approximate    bool
 }
 
 func NewMapperStats() *MapperStats {
@@ -19,3 +20,13 @@ func (s *MapperStats) AddShardedQueries(num int) {
 func (s *MapperStats) GetShardedQueries() int {
 	return s.shardedQueries
 }
+
+// SetApproximate records that the mapped query computes approximate results.
+func (s *MapperStats) SetApproximate() {
+	s.approximate = true
+}
+
+// IsApproximate returns whether the mapped query computes approximate results.
+func (s *MapperStats) IsApproximate() bool {
+	return s.approximate
+}
@@ -74,12 +74,17 @@ func (c *cardinalityEstimation) Do(ctx context.Context, request Request) (Respon
 	k := generateCardinalityEstimationCacheKey(tenant.JoinTenantIDs(tenants), request, cardinalityEstimateBucketSize)
 	spanLog.LogFields(otlog.String("cache key", k))
 
-	estimatedCardinality, estimateAvailable := c.lookupCardinalityForKey(ctx, k)
+	estimate, estimateAvailable := c.lookupCardinalityForKey(ctx, k)
+	estimatedCardinality := estimate.GetEstimatedSeriesCount()
 	if estimateAvailable {
 		request = request.WithEstimatedSeriesCountHint(estimatedCardinality)
+		if estimate.GetShardedQueries() > 0 {
+			request = request.WithObservedShardedQueriesHint(estimate.GetShardedQueries())
+		}
 		spanLog.LogFields(
 			otlog.Bool("estimate available", true),
 			otlog.Uint64("estimated cardinality", estimatedCardinality),
+			otlog.Uint32("observed sharded queries", estimate.GetShardedQueries()),
 		)
 	} else {
 		spanLog.LogFields(otlog.Bool("estimate available", false))
@@ -92,10 +97,17 @@ func (c *cardinalityEstimation) Do(ctx context.Context, request Request) (Respon
 
 	statistics := stats.FromContext(ctx)
 	actualCardinality := statistics.GetFetchedSeriesCount()
-	spanLog.LogFields(otlog.Uint64("actual cardinality", actualCardinality))
-
-	if !estimateAvailable || !isCardinalitySimilar(actualCardinality, estimatedCardinality) {
-		c.storeCardinalityForKey(k, actualCardinality)
+	actualShardedQueries := statistics.LoadShardedQueries()
+	spanLog.LogFields(
+		otlog.Uint64("actual cardinality", actualCardinality),
+		otlog.Uint32("actual sharded queries", actualShardedQueries),
+	)
+
+	// The number of sharded queries is stored along with the cardinality, so that the
+	// query-sharding middleware can compute the series actually fetched by each shard.
+	// If it changed, the cache must be updated to keep the two values consistent.
+	if !estimateAvailable || !isCardinalitySimilar(actualCardinality, estimatedCardinality) || actualShardedQueries != estimate.GetShardedQueries() {
+		c.storeCardinalityForKey(k, &QueryStatistics{EstimatedSeriesCount: actualCardinality, ShardedQueries: actualShardedQueries})
 		spanLog.LogFields(otlog.Bool("cache updated", true))
 	}
 
@@ -111,9 +123,9 @@ func (c *cardinalityEstimation) Do(ctx context.Context, request Request) (Respon
 
 // lookupCardinalityForKey fetches a cardinality estimate for the given key from
 // the results cache.
-func (c *cardinalityEstimation) lookupCardinalityForKey(ctx context.Context, key string) (uint64, bool) {
+func (c *cardinalityEstimation) lookupCardinalityForKey(ctx context.Context, key string) (*QueryStatistics, bool) {
 	if c.cache == nil {
-		return 0, false
+		return nil, false
 	}
 	res := c.cache.Fetch(ctx, []string{key})
 	if val, ok := res[key]; ok {
@@ -121,20 +133,19 @@ func (c *cardinalityEstimation) lookupCardinalityForKey(ctx context.Context, key
 		err := proto.Unmarshal(val, qs)
 		if err != nil {
 			level.Warn(c.logger).Log("msg", "failed to unmarshal cardinality estimate")
-			return 0, false
+			return nil, false
 		}
-		return qs.EstimatedSeriesCount, true
+		return qs, true
 	}
-	return 0, false
+	return nil, false
 }
 
 // storeCardinalityForKey stores a cardinality estimate for the given key in the
 // results cache.
-func (c *cardinalityEstimation) storeCardinalityForKey(key string, count uint64) {
+func (c *cardinalityEstimation) storeCardinalityForKey(key string, m *QueryStatistics) {
 	if c.cache == nil {
 		return
 	}
-	m := &QueryStatistics{EstimatedSeriesCount: count}
 	marshaled, err := proto.Marshal(m)
 	if err != nil {
 		level.Warn(c.logger).Log("msg", "failed to marshal cardinality estimate")
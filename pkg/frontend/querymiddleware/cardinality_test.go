@@ -113,13 +113,13 @@ func Test_cardinalityEstimation_lookupCardinalityForKey(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ce := cardinalityEstimation{cache: tt.cache}
-			ce.storeCardinalityForKey(actualKey, actualValue)
+			ce.storeCardinalityForKey(actualKey, &QueryStatistics{EstimatedSeriesCount: actualValue})
 			estimate, ok := ce.lookupCardinalityForKey(ctx, tt.key)
 			if tt.cache != nil {
 				expectedFetchCount++
 			}
 			assert.Equal(t, expectedFetchCount, c.CountFetchCalls())
-			assert.Equal(t, tt.expectedCardinality, estimate)
+			assert.Equal(t, tt.expectedCardinality, estimate.GetEstimatedSeriesCount())
 			assert.Equal(t, tt.expectedPresent, ok)
 		})
 	}
@@ -143,8 +143,22 @@ func Test_cardinalityEstimation_Do(t *testing.T) {
 			return &PrometheusResponse{}, nil
 		}
 	}
+	addShardedSeriesHandler := func(estimate uint64, observedShardedQueries uint32, actual uint64, actualShardedQueries uint32) HandlerFunc {
+		return func(ctx context.Context, request Request) (Response, error) {
+			require.NotNil(t, request.GetHints())
+			require.Equal(t, estimate, request.GetHints().GetEstimatedSeriesCount())
+			require.Equal(t, observedShardedQueries, request.GetHints().GetObservedShardedQueries())
+
+			queryStats := stats.FromContext(ctx)
+			queryStats.AddFetchedSeries(actual)
+			queryStats.AddShardedQueries(actualShardedQueries)
+			return &PrometheusResponse{}, nil
+		}
+	}
 	marshaledEstimate, err := proto.Marshal(&QueryStatistics{EstimatedSeriesCount: numSeries})
 	require.NoError(t, err)
+	marshaledShardedEstimate, err := proto.Marshal(&QueryStatistics{EstimatedSeriesCount: numSeries, ShardedQueries: 4})
+	require.NoError(t, err)
 
 	tests := []struct {
 		name              string
@@ -202,6 +216,24 @@ func Test_cardinalityEstimation_Do(t *testing.T) {
 			expectedStores:    1,
 			expectedErr:       assert.NoError,
 		},
+		{
+			name:              "with populated cache, unchanged cardinality and unchanged sharded queries",
+			tenantID:          "1",
+			downstreamHandler: addShardedSeriesHandler(numSeries, 4, numSeries, 4),
+			cacheContent:      map[string][]byte{generateCardinalityEstimationCacheKey("1", request, cardinalityEstimateBucketSize): marshaledShardedEstimate},
+			expectedLoads:     1,
+			expectedStores:    0,
+			expectedErr:       assert.NoError,
+		},
+		{
+			name:              "with populated cache, unchanged cardinality and changed sharded queries",
+			tenantID:          "1",
+			downstreamHandler: addShardedSeriesHandler(numSeries, 4, numSeries, 2),
+			cacheContent:      map[string][]byte{generateCardinalityEstimationCacheKey("1", request, cardinalityEstimateBucketSize): marshaledShardedEstimate},
+			expectedLoads:     1,
+			expectedStores:    1,
+			expectedErr:       assert.NoError,
+		},
 		{
 			name:     "with empty cache",
 			tenantID: "1",
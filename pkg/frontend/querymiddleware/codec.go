@@ -42,6 +42,10 @@ var (
 	errNegativeStep   = apierror.New(apierror.TypeBadData, `invalid parameter "step": zero or negative query resolution step widths are not accepted. Try a positive integer`)
 	errStepTooSmall   = apierror.New(apierror.TypeBadData, "exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)")
 	allFormats        = []string{formatJSON, formatProtobuf}
+
+	// allResponseFormats are the formats of the query results the clients can negotiate with the query-frontend.
+	allResponseFormats     = []string{formatJSON, formatProtobuf, formatArrow}
+	defaultResponseFormats = []string{formatJSON, formatProtobuf}
 )
 
 const (
@@ -60,6 +64,7 @@ const (
 
 	formatJSON     = "json"
 	formatProtobuf = "protobuf"
+	formatArrow    = "arrow"
 )
 
 // Codec is used to encode/decode query range requests and responses so they can be passed down to middlewares.
@@ -110,6 +115,9 @@ type Request interface {
 	WithTotalQueriesHint(int32) Request
 	// WithEstimatedSeriesCountHint WithEstimatedCardinalityHint adds a cardinality estimate to this request's Hints.
 	WithEstimatedSeriesCountHint(uint64) Request
+	// WithObservedShardedQueriesHint adds the number of sharded queries executed when
+	// the cardinality estimate was observed to this request's Hints.
+	WithObservedShardedQueriesHint(uint32) Request
 	proto.Message
 	// LogToSpan writes information about this request to an OpenTracing span
 	LogToSpan(opentracing.Span)
@@ -151,6 +159,9 @@ func newPrometheusCodecMetrics(registerer prometheus.Registerer) *prometheusCode
 type prometheusCodec struct {
 	metrics                            *prometheusCodecMetrics
 	preferredQueryResultResponseFormat string
+
+	// Formatters of the responses the clients can negotiate, in order of preference.
+	responseFormatters []formatter
 }
 
 type formatter interface {
@@ -165,12 +176,25 @@ var jsonFormatterInstance = jsonFormatter{}
 var knownFormats = []formatter{
 	jsonFormatterInstance,
 	protobufFormatter{},
+	arrowFormatter{},
 }
 
-func NewPrometheusCodec(registerer prometheus.Registerer, queryResultResponseFormat string) Codec {
+// NewPrometheusCodec returns a Codec retrieving the query results from the queriers in the queryResultResponseFormat,
+// and encoding the responses to the clients in the responseFormats negotiated with the Accept header.
+func NewPrometheusCodec(registerer prometheus.Registerer, queryResultResponseFormat string, responseFormats []string) Codec {
+	responseFormatters := make([]formatter, 0, len(responseFormats))
+	for _, name := range responseFormats {
+		for _, f := range knownFormats {
+			if f.Name() == name {
+				responseFormatters = append(responseFormatters, f)
+			}
+		}
+	}
+
 	return prometheusCodec{
 		metrics:                            newPrometheusCodecMetrics(registerer),
 		preferredQueryResultResponseFormat: queryResultResponseFormat,
+		responseFormatters:                 responseFormatters,
 	}
 }
 
@@ -180,6 +204,7 @@ func (prometheusCodec) MergeResponse(responses ...Response) (Response, error) {
 	}
 
 	promResponses := make([]*PrometheusResponse, 0, len(responses))
+	var warnings []string
 
 	for _, res := range responses {
 		pr := res.(*PrometheusResponse)
@@ -192,6 +217,11 @@ func (prometheusCodec) MergeResponse(responses ...Response) (Response, error) {
 		}
 
 		promResponses = append(promResponses, pr)
+		for _, w := range pr.Warnings {
+			if !slices.Contains(warnings, w) {
+				warnings = append(warnings, w)
+			}
+		}
 	}
 
 	// Merge the responses.
@@ -203,6 +233,7 @@ func (prometheusCodec) MergeResponse(responses ...Response) (Response, error) {
 			ResultType: model.ValMatrix.String(),
 			Result:     matrixMerge(promResponses),
 		},
+		Warnings: warnings,
 	}, nil
 }
 
@@ -253,6 +284,11 @@ func (prometheusCodec) decodeRangeQueryRequest(r *http.Request) (Request, error)
 	result.Query = r.FormValue("query")
 	result.Path = r.URL.Path
 	decodeOptions(r, &result.Options)
+
+	// The response filter is applied when encoding the response, but we validate it upfront.
+	if _, err := decodeResponseFilter(r); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
@@ -267,11 +303,17 @@ func (c prometheusCodec) decodeInstantQueryRequest(r *http.Request) (Request, er
 	result.Query = r.FormValue("query")
 	result.Path = r.URL.Path
 	decodeOptions(r, &result.Options)
+
+	// The response filter is applied when encoding the response, but we validate it upfront.
+	if _, err := decodeResponseFilter(r); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 func decodeOptions(r *http.Request, opts *Options) {
 	opts.CacheDisabled = decodeCacheDisabledOption(r)
+	opts.CacheLookupDisabled = decodeCacheLookupDisabledOption(r)
 
 	for _, value := range r.Header.Values(totalShardsControlHeader) {
 		shards, err := strconv.ParseInt(value, 10, 32)
@@ -307,6 +349,18 @@ func decodeCacheDisabledOption(r *http.Request) bool {
 	return false
 }
 
+// decodeCacheLookupDisabledOption returns whether the client asked to not be served cached results,
+// while still allowing the fresh results to be cached.
+func decodeCacheLookupDisabledOption(r *http.Request) bool {
+	for _, value := range r.Header.Values(cacheControlHeader) {
+		if strings.Contains(value, noCacheValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Request, error) {
 	var u *url.URL
 	switch r := r.(type) {
@@ -418,6 +472,13 @@ func (c prometheusCodec) EncodeResponse(ctx context.Context, req *http.Request,
 	if !ok {
 		return nil, apierror.Newf(apierror.TypeInternal, "invalid response format")
 	}
+
+	filter, err := decodeResponseFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	a = filter.apply(a)
+
 	if a.Data != nil {
 		sp.LogFields(otlog.Int("series", len(a.Data.Result)))
 	}
@@ -445,16 +506,24 @@ func (c prometheusCodec) EncodeResponse(ctx context.Context, req *http.Request,
 		StatusCode:    http.StatusOK,
 		ContentLength: int64(len(b)),
 	}
+
+	// Forward the headers describing the results cache usage to the client.
+	for _, h := range a.Headers {
+		if isResultsCacheResponseHeader(h.Name) {
+			resp.Header[h.Name] = h.Values
+		}
+	}
+
 	return &resp, nil
 }
 
-func (prometheusCodec) negotiateContentType(acceptHeader string) (string, formatter) {
+func (c prometheusCodec) negotiateContentType(acceptHeader string) (string, formatter) {
 	if acceptHeader == "" {
 		return jsonMimeType, jsonFormatterInstance
 	}
 
 	for _, clause := range goautoneg.ParseAccept(acceptHeader) {
-		for _, formatter := range knownFormats {
+		for _, formatter := range c.responseFormatters {
 			if formatter.ContentType().Satisfies(clause) {
 				return formatter.ContentType().String(), formatter
 			}
@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/prometheus/common/model"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	arrowMimeTypeType    = "application"
+	arrowMimeTypeSubType = "vnd.apache.arrow.stream"
+
+	// The names of the timestamp and value columns use the prefix reserved to the internal labels,
+	// so that they can't clash with the label columns.
+	arrowTimestampColumn = "__timestamp__"
+	arrowValueColumn     = "__value__"
+
+	arrowMetadataStatus     = "mimir.status"
+	arrowMetadataResultType = "mimir.result_type"
+	arrowMetadataWarnings   = "mimir.warnings"
+	arrowMetadataErrorType  = "mimir.error_type"
+	arrowMetadataError      = "mimir.error"
+)
+
+var errArrowNativeHistograms = errors.New("native histograms are not supported by the Arrow format")
+
+// arrowFormatter encodes the query results as an Arrow IPC stream, in the "long" format consumed by the
+// data-science tools: each row is a sample, with the timestamp and value columns, and a column for each
+// label name, dictionary encoded and null for the series without the label. The result type, status and
+// warnings are stored in the schema metadata. Native histograms and string results aren't supported.
+type arrowFormatter struct{}
+
+func (f arrowFormatter) Name() string {
+	return formatArrow
+}
+
+func (f arrowFormatter) ContentType() v1.MIMEType {
+	return v1.MIMEType{Type: arrowMimeTypeType, SubType: arrowMimeTypeSubType}
+}
+
+func (f arrowFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, error) {
+	var streams []SampleStream
+	metadata := map[string]string{
+		arrowMetadataStatus: resp.Status,
+	}
+	if resp.ErrorType != "" {
+		metadata[arrowMetadataErrorType] = resp.ErrorType
+	}
+	if resp.Error != "" {
+		metadata[arrowMetadataError] = resp.Error
+	}
+	if len(resp.Warnings) > 0 {
+		warnings, err := json.Marshal(resp.Warnings)
+		if err != nil {
+			return nil, err
+		}
+		metadata[arrowMetadataWarnings] = string(warnings)
+	}
+
+	if resp.Data != nil {
+		switch resp.Data.ResultType {
+		case model.ValScalar.String(), model.ValVector.String(), model.ValMatrix.String():
+			streams = resp.Data.Result
+		default:
+			return nil, fmt.Errorf("result type '%s' is not supported by the Arrow format", resp.Data.ResultType)
+		}
+		metadata[arrowMetadataResultType] = resp.Data.ResultType
+	}
+
+	// The label columns are sorted by name.
+	labelNames := map[string]int{}
+	for _, stream := range streams {
+		if len(stream.Histograms) > 0 {
+			return nil, errArrowNativeHistograms
+		}
+		for _, l := range stream.Labels {
+			labelNames[l.Name] = 0
+		}
+	}
+	sortedNames := make([]string, 0, len(labelNames))
+	for name := range labelNames {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	fields := make([]arrow.Field, 0, len(sortedNames)+2)
+	fields = append(fields,
+		arrow.Field{Name: arrowTimestampColumn, Type: arrow.FixedWidthTypes.Timestamp_ms},
+		arrow.Field{Name: arrowValueColumn, Type: arrow.PrimitiveTypes.Float64},
+	)
+	for i, name := range sortedNames {
+		labelNames[name] = i + 2
+		fields = append(fields, arrow.Field{
+			Name:     name,
+			Type:     &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String},
+			Nullable: true,
+		})
+	}
+
+	metadataKeys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		metadataKeys = append(metadataKeys, k)
+	}
+	sort.Strings(metadataKeys)
+	metadataValues := make([]string, 0, len(metadata))
+	for _, k := range metadataKeys {
+		metadataValues = append(metadataValues, metadata[k])
+	}
+	schemaMetadata := arrow.NewMetadata(metadataKeys, metadataValues)
+	schema := arrow.NewSchema(fields, &schemaMetadata)
+
+	mem := memory.NewGoAllocator()
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+
+	timestamps := builder.Field(0).(*array.TimestampBuilder)
+	values := builder.Field(1).(*array.Float64Builder)
+	present := make([]bool, len(fields))
+
+	for _, stream := range streams {
+		for i := range present {
+			present[i] = false
+		}
+		for _, l := range stream.Labels {
+			present[labelNames[l.Name]] = true
+		}
+
+		for _, sample := range stream.Samples {
+			timestamps.Append(arrow.Timestamp(sample.TimestampMs))
+			values.Append(sample.Value)
+
+			for _, l := range stream.Labels {
+				if err := builder.Field(labelNames[l.Name]).(*array.BinaryDictionaryBuilder).AppendString(l.Value); err != nil {
+					return nil, err
+				}
+			}
+			for i := 2; i < len(fields); i++ {
+				if !present[i] {
+					builder.Field(i).AppendNull()
+				}
+			}
+		}
+	}
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var buf bytes.Buffer
+	writer := ipc.NewWriter(&buf, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	if err := writer.Write(record); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f arrowFormatter) DecodeResponse(buf []byte) (*PrometheusResponse, error) {
+	reader, err := ipc.NewReader(bytes.NewReader(buf), ipc.WithAllocator(memory.NewGoAllocator()))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Release()
+
+	schema := reader.Schema()
+	metadata := schema.Metadata()
+	resp := &PrometheusResponse{
+		Status:    metadataValue(metadata, arrowMetadataStatus),
+		ErrorType: metadataValue(metadata, arrowMetadataErrorType),
+		Error:     metadataValue(metadata, arrowMetadataError),
+	}
+	if warnings := metadataValue(metadata, arrowMetadataWarnings); warnings != "" {
+		if err := json.Unmarshal([]byte(warnings), &resp.Warnings); err != nil {
+			return nil, err
+		}
+	}
+
+	resultType := metadataValue(metadata, arrowMetadataResultType)
+	if resultType == "" {
+		return resp, nil
+	}
+	resp.Data = &PrometheusData{ResultType: resultType, Result: []SampleStream{}}
+
+	if len(schema.Fields()) < 2 || schema.Field(0).Name != arrowTimestampColumn || schema.Field(1).Name != arrowValueColumn {
+		return nil, fmt.Errorf("expected the first columns to be %s and %s", arrowTimestampColumn, arrowValueColumn)
+	}
+
+	// The samples of each series are contiguous, so a new series begins whenever the labels change.
+	var current *SampleStream
+	for reader.Next() {
+		record := reader.Record()
+		timestamps, ok := record.Column(0).(*array.Timestamp)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %s of the %s column", record.Column(0).DataType(), arrowTimestampColumn)
+		}
+		values, ok := record.Column(1).(*array.Float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %s of the %s column", record.Column(1).DataType(), arrowValueColumn)
+		}
+
+		for row := 0; row < int(record.NumRows()); row++ {
+			var lbls []mimirpb.LabelAdapter
+			for col := 2; col < int(record.NumCols()); col++ {
+				value, ok, err := arrowLabelValue(record.Column(col), row)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					lbls = append(lbls, mimirpb.LabelAdapter{Name: record.ColumnName(col), Value: value})
+				}
+			}
+
+			if current == nil || !slices.Equal(current.Labels, lbls) {
+				resp.Data.Result = append(resp.Data.Result, SampleStream{Labels: lbls})
+				current = &resp.Data.Result[len(resp.Data.Result)-1]
+			}
+			current.Samples = append(current.Samples, mimirpb.Sample{
+				TimestampMs: int64(timestamps.Value(row)),
+				Value:       values.Value(row),
+			})
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// arrowLabelValue returns the value of the label column at the given row, and false if it's null.
+func arrowLabelValue(column arrow.Array, row int) (string, bool, error) {
+	if column.IsNull(row) {
+		return "", false, nil
+	}
+
+	dict, ok := column.(*array.Dictionary)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected type %s of a label column", column.DataType())
+	}
+	values, ok := dict.Dictionary().(*array.String)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected type %s of a label column dictionary", dict.Dictionary().DataType())
+	}
+	return values.Value(dict.GetValueIndex(row)), true, nil
+}
+
+func metadataValue(metadata arrow.Metadata, key string) string {
+	if idx := metadata.FindKey(key); idx >= 0 {
+		return metadata.Values()[idx]
+	}
+	return ""
+}
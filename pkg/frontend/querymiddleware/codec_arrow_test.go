@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestArrowFormatter_EncodeDecodeResponse(t *testing.T) {
+	tests := map[string]*PrometheusResponse{
+		"matrix": {
+			Status:   statusSuccess,
+			Warnings: []string{"some warning"},
+			Data: &PrometheusData{
+				ResultType: model.ValMatrix.String(),
+				Result: []SampleStream{
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: 2}},
+					},
+					{
+						// The series don't all have the same labels.
+						Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 3}},
+					},
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "job", Value: "c"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 2000, Value: 4}},
+					},
+				},
+			},
+		},
+		"vector": {
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValVector.String(),
+				Result: []SampleStream{
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "job", Value: "a"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}},
+					},
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "job", Value: "b"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 2}},
+					},
+				},
+			},
+		},
+		"scalar": {
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValScalar.String(),
+				Result: []SampleStream{
+					{Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}},
+				},
+			},
+		},
+		"empty matrix": {
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValMatrix.String(),
+				Result:     []SampleStream{},
+			},
+		},
+		"error": {
+			Status:    statusError,
+			ErrorType: "bad_data",
+			Error:     "something went wrong",
+		},
+	}
+
+	for name, resp := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := arrowFormatter{}.EncodeResponse(resp)
+			require.NoError(t, err)
+
+			decoded, err := arrowFormatter{}.DecodeResponse(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, resp, decoded)
+		})
+	}
+}
+
+func TestArrowFormatter_EncodeResponse_Columns(t *testing.T) {
+	resp := &PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "job", Value: "a"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: 2}},
+				},
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "instance", Value: "b"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 3}},
+				},
+			},
+		},
+	}
+
+	encoded, err := arrowFormatter{}.EncodeResponse(resp)
+	require.NoError(t, err)
+
+	reader, err := ipc.NewReader(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	defer reader.Release()
+
+	// The label columns are sorted by name, after the timestamp and value columns.
+	fields := reader.Schema().Fields()
+	require.Len(t, fields, 4)
+	assert.Equal(t, arrowTimestampColumn, fields[0].Name)
+	assert.Equal(t, arrow.TIMESTAMP, fields[0].Type.ID())
+	assert.Equal(t, arrowValueColumn, fields[1].Name)
+	assert.Equal(t, arrow.FLOAT64, fields[1].Type.ID())
+	assert.Equal(t, "instance", fields[2].Name)
+	assert.Equal(t, arrow.DICTIONARY, fields[2].Type.ID())
+	assert.Equal(t, "job", fields[3].Name)
+
+	require.True(t, reader.Next())
+	record := reader.Record()
+	require.Equal(t, int64(3), record.NumRows())
+
+	// The label columns are null for the series without the label.
+	assert.Equal(t, []bool{true, true, false}, []bool{record.Column(2).IsNull(0), record.Column(2).IsNull(1), record.Column(2).IsNull(2)})
+	assert.Equal(t, []bool{false, false, true}, []bool{record.Column(3).IsNull(0), record.Column(3).IsNull(1), record.Column(3).IsNull(2)})
+	assert.False(t, reader.Next())
+}
+
+func TestArrowFormatter_EncodeResponse_Unsupported(t *testing.T) {
+	_, err := arrowFormatter{}.EncodeResponse(&PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result: []SampleStream{{
+				Labels:     []mimirpb.LabelAdapter{{Name: "job", Value: "a"}},
+				Histograms: []mimirpb.FloatHistogramPair{{TimestampMs: 1000, Histogram: &mimirpb.FloatHistogram{}}},
+			}},
+		},
+	})
+	require.ErrorIs(t, err, errArrowNativeHistograms)
+
+	_, err = arrowFormatter{}.EncodeResponse(&PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValString.String(),
+			Result: []SampleStream{{
+				Labels:  []mimirpb.LabelAdapter{{Name: "value", Value: "foo"}},
+				Samples: []mimirpb.Sample{{TimestampMs: 1000}},
+			}},
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestPrometheusCodec_EncodeResponse_ArrowNegotiation(t *testing.T) {
+	resp := &PrometheusResponse{
+		Status: statusSuccess,
+		Data:   &PrometheusData{ResultType: model.ValMatrix.String(), Result: []SampleStream{}},
+	}
+	arrowMimeType := arrowFormatter{}.ContentType().String()
+
+	tests := map[string]struct {
+		responseFormats     []string
+		acceptHeader        string
+		expectedContentType string
+		expectedNotAccepted bool
+	}{
+		"arrow requested and enabled": {
+			responseFormats:     allResponseFormats,
+			acceptHeader:        arrowMimeType,
+			expectedContentType: arrowMimeType,
+		},
+		"arrow requested but disabled": {
+			responseFormats:     defaultResponseFormats,
+			acceptHeader:        arrowMimeType,
+			expectedNotAccepted: true,
+		},
+		"arrow requested but disabled, with JSON fallback": {
+			responseFormats:     defaultResponseFormats,
+			acceptHeader:        arrowMimeType + ",application/json;q=0.5",
+			expectedContentType: jsonMimeType,
+		},
+		"any format, arrow preferred by the configuration": {
+			responseFormats:     []string{formatArrow, formatJSON},
+			acceptHeader:        "*/*",
+			expectedContentType: arrowMimeType,
+		},
+		"no Accept header": {
+			responseFormats:     []string{formatArrow},
+			expectedContentType: jsonMimeType,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			codec := NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatProtobuf, tc.responseFormats)
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/query_range", nil)
+			require.NoError(t, err)
+			if tc.acceptHeader != "" {
+				req.Header.Set("Accept", tc.acceptHeader)
+			}
+
+			encoded, err := codec.EncodeResponse(context.Background(), req, resp)
+			if tc.expectedNotAccepted {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContentType, encoded.Header.Get("Content-Type"))
+
+			// The responses are decoded according to their content type.
+			encoded.StatusCode = http.StatusOK
+			decoded, err := codec.DecodeResponse(context.Background(), encoded, nil, log.NewNopLogger())
+			require.NoError(t, err)
+			assert.Equal(t, resp.Data, decoded.(*PrometheusResponse).Data)
+		})
+	}
+}
@@ -37,6 +37,7 @@ func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, err
 		Status:    status,
 		ErrorType: errorType,
 		Error:     resp.Error,
+		Warnings:  resp.Warnings,
 	}
 
 	if resp.Data != nil {
@@ -211,6 +212,7 @@ func (f protobufFormatter) DecodeResponse(buf []byte) (*PrometheusResponse, erro
 		ErrorType: errorType,
 		Error:     resp.Error,
 		Data:      data,
+		Warnings:  resp.Warnings,
 	}, nil
 }
 
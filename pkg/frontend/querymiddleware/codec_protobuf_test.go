@@ -132,6 +132,25 @@ var protobufCodecScenarios = []struct {
 			Headers: expectedProtobufResponseHeaders,
 		},
 	},
+	{
+		name: "successful empty vector response with warnings",
+		payload: mimirpb.QueryResponse{
+			Status: mimirpb.QueryResponse_SUCCESS,
+			Data: &mimirpb.QueryResponse_Vector{
+				Vector: &mimirpb.VectorData{},
+			},
+			Warnings: []string{"some warning"},
+		},
+		response: &PrometheusResponse{
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValVector.String(),
+				Result:     []SampleStream{},
+			},
+			Headers:  expectedProtobufResponseHeaders,
+			Warnings: []string{"some warning"},
+		},
+	},
 	{
 		name: "successful vector response with single series with no labels",
 		payload: mimirpb.QueryResponse{
@@ -632,7 +651,7 @@ func TestProtobufFormat_DecodeResponse(t *testing.T) {
 	for _, tc := range protobufCodecScenarios {
 		t.Run(tc.name, func(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
-			codec := NewPrometheusCodec(reg, formatProtobuf)
+			codec := NewPrometheusCodec(reg, formatProtobuf, defaultResponseFormats)
 
 			body, err := tc.payload.Marshal()
 			require.NoError(t, err)
@@ -673,7 +692,7 @@ func TestProtobufFormat_EncodeResponse(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
-			codec := NewPrometheusCodec(reg, formatProtobuf)
+			codec := NewPrometheusCodec(reg, formatProtobuf, defaultResponseFormats)
 
 			expectedBodyBytes, err := tc.payload.Marshal()
 			require.NoError(t, err)
@@ -713,7 +732,7 @@ func TestProtobufFormat_EncodeResponse(t *testing.T) {
 func BenchmarkProtobufFormat_DecodeResponse(b *testing.B) {
 	headers := http.Header{"Content-Type": []string{mimirpb.QueryResponseMimeType}}
 	reg := prometheus.NewPedanticRegistry()
-	codec := NewPrometheusCodec(reg, formatProtobuf)
+	codec := NewPrometheusCodec(reg, formatProtobuf, defaultResponseFormats)
 
 	for _, tc := range protobufCodecScenarios {
 		body, err := tc.payload.Marshal()
@@ -738,7 +757,7 @@ func BenchmarkProtobufFormat_DecodeResponse(b *testing.B) {
 
 func BenchmarkProtobufFormat_EncodeResponse(b *testing.B) {
 	reg := prometheus.NewPedanticRegistry()
-	codec := NewPrometheusCodec(reg, formatProtobuf)
+	codec := NewPrometheusCodec(reg, formatProtobuf, defaultResponseFormats)
 
 	req := &http.Request{
 		Header: http.Header{"Accept": []string{mimirpb.QueryResponseMimeType}},
@@ -86,6 +86,18 @@ func TestRequest(t *testing.T) {
 			url:         "api/v1/query_range?start=0&end=11001&step=1",
 			expectedErr: errStepTooSmall,
 		},
+		{
+			url:         "api/v1/query?query=up&time=123&series_limit=foo",
+			expectedErr: apierror.New(apierror.TypeBadData, "invalid parameter \"series_limit\": must be a non-negative integer"),
+		},
+		{
+			url:         "api/v1/query?query=up&time=123&drop_labels=job&keep_labels=instance",
+			expectedErr: apierror.New(apierror.TypeBadData, "parameters \"drop_labels\" and \"keep_labels\" can't be used together"),
+		},
+		{
+			url:         "api/v1/query_range?query=up&start=123&end=456&step=1&keep_labels=1nvalid",
+			expectedErr: apierror.New(apierror.TypeBadData, "invalid parameter \"keep_labels\": invalid label name \"1nvalid\""),
+		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			r, err := http.NewRequest("GET", tc.url, nil)
@@ -111,7 +123,7 @@ func TestRequest(t *testing.T) {
 func TestPrometheusCodec_EncodeRequest_AcceptHeader(t *testing.T) {
 	for _, queryResultPayloadFormat := range allFormats {
 		t.Run(queryResultPayloadFormat, func(t *testing.T) {
-			codec := NewPrometheusCodec(prometheus.NewPedanticRegistry(), queryResultPayloadFormat)
+			codec := NewPrometheusCodec(prometheus.NewPedanticRegistry(), queryResultPayloadFormat, defaultResponseFormats)
 			req := PrometheusInstantQueryRequest{}
 			encodedRequest, err := codec.EncodeRequest(context.Background(), &req)
 			require.NoError(t, err)
@@ -205,6 +217,30 @@ func TestPrometheusCodec_EncodeResponse_ContentNegotiation(t *testing.T) {
 	}
 }
 
+func TestPrometheusCodec_EncodeResponse_ResultsCacheHeaders(t *testing.T) {
+	testResponse := &PrometheusResponse{
+		Status: statusSuccess,
+		Data:   &PrometheusData{ResultType: model.ValMatrix.String()},
+		Headers: []*PrometheusResponseHeader{
+			{Name: "Content-Type", Values: []string{"application/x-downstream"}},
+			{Name: resultsCacheHitRatioHeader, Values: []string{"0.500"}},
+			{Name: resultsCacheOldestExtentAgeHeader, Values: []string{"60"}},
+			{Name: resultsCacheNewestExtentAgeHeader, Values: []string{"10"}},
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/something", nil)
+	require.NoError(t, err)
+
+	encodedResponse, err := newTestPrometheusCodec().EncodeResponse(context.Background(), req, testResponse)
+	require.NoError(t, err)
+
+	require.Equal(t, jsonMimeType, encodedResponse.Header.Get("Content-Type"))
+	require.Equal(t, "0.500", encodedResponse.Header.Get(resultsCacheHitRatioHeader))
+	require.Equal(t, "60", encodedResponse.Header.Get(resultsCacheOldestExtentAgeHeader))
+	require.Equal(t, "10", encodedResponse.Header.Get(resultsCacheNewestExtentAgeHeader))
+}
+
 type prometheusAPIResponse struct {
 	Status    string       `json:"status"`
 	Data      interface{}  `json:"data,omitempty"`
@@ -279,7 +315,7 @@ func TestPrometheusCodec_DecodeResponse_ContentTypeHandling(t *testing.T) {
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
-			codec := NewPrometheusCodec(reg, formatJSON)
+			codec := NewPrometheusCodec(reg, formatJSON, defaultResponseFormats)
 
 			resp := prometheusAPIResponse{}
 			body, err := json.Marshal(resp)
@@ -835,6 +871,17 @@ func Test_DecodeOptions(t *testing.T) {
 				CacheDisabled: true,
 			},
 		},
+		{
+			name: "disable cache lookup",
+			input: &http.Request{
+				Header: http.Header{
+					cacheControlHeader: []string{noCacheValue},
+				},
+			},
+			expected: &Options{
+				CacheLookupDisabled: true,
+			},
+		},
 		{
 			name: "custom sharding",
 			input: &http.Request{
@@ -891,5 +938,5 @@ func Test_DecodeOptions(t *testing.T) {
 }
 
 func newTestPrometheusCodec() Codec {
-	return NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatJSON)
+	return NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatJSON, defaultResponseFormats)
 }
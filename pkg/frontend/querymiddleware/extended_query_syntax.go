@@ -0,0 +1,377 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// extendedQuerySyntaxMiddleware rewrites the queries using the extended query syntax to standard PromQL,
+// for the tenants which enabled it, before any other middleware parses them. The extended query syntax
+// supports:
+//   - The $__interval, $__interval_ms, $__range, $__range_ms and $__range_s variables, resolved from the
+//     step and time range of range queries, like Grafana does.
+//   - The arithmetic between durations and numbers in the ranges and subqueries, like [5m + $__interval].
+type extendedQuerySyntaxMiddleware struct {
+	next   Handler
+	limits Limits
+	logger log.Logger
+}
+
+// newExtendedQuerySyntaxMiddleware makes a new extendedQuerySyntaxMiddleware.
+func newExtendedQuerySyntaxMiddleware(limits Limits, logger log.Logger) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &extendedQuerySyntaxMiddleware{
+			next:   next,
+			limits: limits,
+			logger: logger,
+		}
+	})
+}
+
+func (e *extendedQuerySyntaxMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	if !validation.AllTrueBooleansPerTenant(tenantIDs, e.limits.ExtendedQuerySyntaxEnabled) {
+		return e.next.Do(ctx, req)
+	}
+
+	query, err := expandExtendedQuerySyntax(req.GetQuery(), extendedQuerySyntaxVariables(req))
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	if query != req.GetQuery() {
+		spanLog := spanlogger.FromContext(ctx, e.logger)
+		level.Debug(spanLog).Log("msg", "rewritten query using the extended query syntax", "original", req.GetQuery(), "rewritten", query)
+		req = req.WithQuery(query)
+	}
+
+	return e.next.Do(ctx, req)
+}
+
+// extendedQuerySyntaxVariables returns the values of the variables of the request. The variables are only
+// defined for range queries.
+func extendedQuerySyntaxVariables(req Request) map[string]string {
+	if req.GetStep() <= 0 {
+		return nil
+	}
+
+	step := req.GetStep()
+	queryRange := req.GetEnd() - req.GetStart()
+
+	return map[string]string{
+		"__interval":    model.Duration(time.Duration(step) * time.Millisecond).String(),
+		"__interval_ms": strconv.FormatInt(step, 10),
+		"__range":       model.Duration(time.Duration(queryRange) * time.Millisecond).String(),
+		"__range_ms":    strconv.FormatInt(queryRange, 10),
+		"__range_s":     strconv.FormatInt(queryRange/1000, 10),
+	}
+}
+
+// expandExtendedQuerySyntax substitutes the variables referenced as $name or ${name} in the query, outside
+// of the string literals, and evaluates the arithmetic in the ranges and subqueries. The references to
+// variables whose name doesn't start with __ are left untouched.
+func expandExtendedQuerySyntax(query string, vars map[string]string) (string, error) {
+	if !strings.ContainsAny(query, "$[") {
+		return query, nil
+	}
+
+	sb := strings.Builder{}
+	sb.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		switch c := query[i]; c {
+		case '"', '\'', '`':
+			end := stringLiteralEnd(query, i)
+			sb.WriteString(query[i:end])
+			i = end
+
+		case '$':
+			value, end, err := expandVariable(query, i, vars)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+			i = end
+
+		case '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				// Let the PromQL parser report the syntax error.
+				sb.WriteString(query[i:])
+				i = len(query)
+				break
+			}
+			end += i
+
+			expanded, err := expandRange(query[i+1:end], vars)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte('[')
+			sb.WriteString(expanded)
+			sb.WriteByte(']')
+			i = end + 1
+
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// stringLiteralEnd returns the index following the end of the string literal starting at start.
+func stringLiteralEnd(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			// Raw strings have no escape sequences.
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// expandVariable returns the value of the variable referenced at start, and the index following the reference.
+func expandVariable(query string, start int, vars map[string]string) (string, int, error) {
+	nameStart, braces := start+1, false
+	if nameStart < len(query) && query[nameStart] == '{' {
+		nameStart, braces = nameStart+1, true
+	}
+
+	nameEnd := nameStart
+	for nameEnd < len(query) && isVariableNameChar(query[nameEnd], nameEnd == nameStart) {
+		nameEnd++
+	}
+
+	end := nameEnd
+	if braces {
+		if end >= len(query) || query[end] != '}' {
+			return query[start:nameStart], nameStart, nil
+		}
+		end++
+	}
+
+	name := query[nameStart:nameEnd]
+	if !strings.HasPrefix(name, "__") {
+		return query[start:end], end, nil
+	}
+
+	value, ok := vars[name]
+	if !ok {
+		if vars == nil {
+			return "", 0, fmt.Errorf("the variable $%s can only be used in range queries", name)
+		}
+		return "", 0, fmt.Errorf("unknown variable $%s", name)
+	}
+	return value, end, nil
+}
+
+func isVariableNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// expandRange substitutes the variables in the range, or subquery range and resolution, and evaluates them.
+func expandRange(rng string, vars map[string]string) (string, error) {
+	rng, err := expandExtendedQuerySyntax(rng, vars)
+	if err != nil {
+		return "", err
+	}
+
+	changed := false
+	parts := strings.Split(rng, ":")
+	for idx, part := range parts {
+		parts[idx] = strings.TrimSpace(part)
+
+		// The subquery resolution is optional. The references to other variables are left for the PromQL
+		// parser to report.
+		if parts[idx] == "" || strings.Contains(parts[idx], "$") {
+			continue
+		}
+		if _, err := model.ParseDuration(parts[idx]); err == nil {
+			continue
+		}
+
+		value, err := evalDurationExpr(parts[idx])
+		if err != nil {
+			return "", fmt.Errorf("invalid duration expression %q: %w", parts[idx], err)
+		}
+		parts[idx] = value.String()
+		changed = true
+	}
+
+	if !changed {
+		return rng, nil
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// evalDurationExpr evaluates the arithmetic expression between durations and numbers, which must result in a
+// positive duration.
+func evalDurationExpr(expr string) (model.Duration, error) {
+	p := durationExprParser{input: expr}
+	res, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpaces(); p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+	if !res.isDuration {
+		return 0, fmt.Errorf("the expression must result in a duration")
+	}
+
+	d := time.Duration(res.value).Round(time.Millisecond)
+	if d <= 0 {
+		return 0, fmt.Errorf("the expression must result in a positive duration")
+	}
+	return model.Duration(d), nil
+}
+
+// durationExprValue is either a duration, in nanoseconds, or a number.
+type durationExprValue struct {
+	value      float64
+	isDuration bool
+}
+
+// durationExprParser is a recursive descent parser of the arithmetic expressions between durations and numbers.
+type durationExprParser struct {
+	input string
+	pos   int
+}
+
+func (p *durationExprParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *durationExprParser) parseSum() (durationExprValue, error) {
+	lhs, err := p.parseProduct()
+	if err != nil {
+		return lhs, err
+	}
+
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) || (p.input[p.pos] != '+' && p.input[p.pos] != '-') {
+			return lhs, nil
+		}
+		op := p.input[p.pos]
+		p.pos++
+
+		rhs, err := p.parseProduct()
+		if err != nil {
+			return lhs, err
+		}
+		if lhs.isDuration != rhs.isDuration {
+			return lhs, fmt.Errorf("can't add or subtract a duration and a number")
+		}
+		if op == '+' {
+			lhs.value += rhs.value
+		} else {
+			lhs.value -= rhs.value
+		}
+	}
+}
+
+func (p *durationExprParser) parseProduct() (durationExprValue, error) {
+	lhs, err := p.parseOperand()
+	if err != nil {
+		return lhs, err
+	}
+
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) || (p.input[p.pos] != '*' && p.input[p.pos] != '/') {
+			return lhs, nil
+		}
+		op := p.input[p.pos]
+		p.pos++
+
+		rhs, err := p.parseOperand()
+		if err != nil {
+			return lhs, err
+		}
+
+		switch {
+		case op == '*' && lhs.isDuration && rhs.isDuration:
+			return lhs, fmt.Errorf("can't multiply two durations")
+		case op == '*':
+			lhs = durationExprValue{value: lhs.value * rhs.value, isDuration: lhs.isDuration || rhs.isDuration}
+		case rhs.value == 0:
+			return lhs, fmt.Errorf("division by zero")
+		case !lhs.isDuration && rhs.isDuration:
+			return lhs, fmt.Errorf("can't divide a number by a duration")
+		default:
+			// The division of two durations is a number.
+			lhs = durationExprValue{value: lhs.value / rhs.value, isDuration: lhs.isDuration && !rhs.isDuration}
+		}
+	}
+}
+
+func (p *durationExprParser) parseOperand() (durationExprValue, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return durationExprValue{}, fmt.Errorf("unexpected end of expression")
+	}
+
+	if p.input[p.pos] == '(' {
+		p.pos++
+		res, err := p.parseSum()
+		if err != nil {
+			return res, err
+		}
+		if p.skipSpaces(); p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return res, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return res, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && isDurationExprOperandChar(p.input[p.pos]) {
+		p.pos++
+	}
+	operand := p.input[start:p.pos]
+	if operand == "" {
+		return durationExprValue{}, fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	if number, err := strconv.ParseFloat(operand, 64); err == nil {
+		return durationExprValue{value: number}, nil
+	}
+	d, err := model.ParseDuration(operand)
+	if err != nil {
+		return durationExprValue{}, err
+	}
+	return durationExprValue{value: float64(d), isDuration: true}, nil
+}
+
+func isDurationExprOperandChar(c byte) bool {
+	return c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z')
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+)
+
+func TestExpandExtendedQuerySyntax(t *testing.T) {
+	vars := extendedQuerySyntaxVariables(&PrometheusRangeQueryRequest{
+		Start: 0,
+		End:   (3 * time.Hour).Milliseconds(),
+		Step:  (30 * time.Second).Milliseconds(),
+	})
+
+	tests := map[string]struct {
+		query         string
+		expectedQuery string
+		expectedErr   string
+	}{
+		"standard PromQL is left untouched": {
+			query:         `sum(rate(foo{job="a"}[5m])) / sum(rate(foo[1h:1m])) offset 5m`,
+			expectedQuery: `sum(rate(foo{job="a"}[5m])) / sum(rate(foo[1h:1m])) offset 5m`,
+		},
+		"variables": {
+			query:         `rate(foo[$__interval]) * $__interval_ms / ${__range_s} offset $__range`,
+			expectedQuery: `rate(foo[30s]) * 30000 / 10800 offset 3h`,
+		},
+		"variables in string literals are left untouched": {
+			query:         `foo{a="$__interval", b='$__range', c=~` + "`x$`" + `}`,
+			expectedQuery: `foo{a="$__interval", b='$__range', c=~` + "`x$`" + `}`,
+		},
+		"escaped quotes in string literals": {
+			query:         `foo{a="\"$__interval"} * $__interval_ms`,
+			expectedQuery: `foo{a="\"$__interval"} * 30000`,
+		},
+		"variables not starting with __ are left untouched": {
+			query:         `rate(foo[$interval])`,
+			expectedQuery: `rate(foo[$interval])`,
+		},
+		"duration arithmetic in ranges": {
+			query:         `rate(foo[5m + $__interval]) + rate(foo[1h * 2]) + rate(foo[(1h - 30m) / 2])`,
+			expectedQuery: `rate(foo[5m30s]) + rate(foo[2h]) + rate(foo[15m])`,
+		},
+		"duration arithmetic in subqueries": {
+			query:         `max_over_time(rate(foo[5m])[$__range : $__interval * 2])`,
+			expectedQuery: `max_over_time(rate(foo[5m])[3h:1m])`,
+		},
+		"subquery with the default resolution": {
+			query:         `max_over_time(rate(foo[5m])[1h + 1h:])`,
+			expectedQuery: `max_over_time(rate(foo[5m])[2h:])`,
+		},
+		"numbers in milliseconds multiplied by a duration": {
+			query:         `rate(foo[$__interval_ms * 1ms])`,
+			expectedQuery: `rate(foo[30s])`,
+		},
+		"unknown variable": {
+			query:       `rate(foo[$__unknown])`,
+			expectedErr: "unknown variable $__unknown",
+		},
+		"range resulting in a number": {
+			query:       `rate(foo[5m / 1m])`,
+			expectedErr: `invalid duration expression "5m / 1m": the expression must result in a duration`,
+		},
+		"negative range": {
+			query:       `rate(foo[5m - 10m])`,
+			expectedErr: `invalid duration expression "5m - 10m": the expression must result in a positive duration`,
+		},
+		"multiplication of durations": {
+			query:       `rate(foo[5m * 5m])`,
+			expectedErr: `invalid duration expression "5m * 5m": can't multiply two durations`,
+		},
+		"sum of a duration and a number": {
+			query:       `rate(foo[5m + 5])`,
+			expectedErr: `invalid duration expression "5m + 5": can't add or subtract a duration and a number`,
+		},
+		"division by zero": {
+			query:       `rate(foo[5m / 0])`,
+			expectedErr: `invalid duration expression "5m / 0": division by zero`,
+		},
+		"missing closing parenthesis": {
+			query:       `rate(foo[(5m + 1m])`,
+			expectedErr: `invalid duration expression "(5m + 1m": missing closing parenthesis`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual, err := expandExtendedQuerySyntax(testData.query, vars)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedQuery, actual)
+		})
+	}
+}
+
+func TestExtendedQuerySyntaxMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		limits        Limits
+		req           Request
+		expectedQuery string
+		expectedErr   string
+	}{
+		"disabled for the tenant": {
+			limits:        mockLimits{},
+			req:           &PrometheusRangeQueryRequest{Start: 0, End: 3_600_000, Step: 60_000, Query: "rate(foo[$__interval])"},
+			expectedQuery: "rate(foo[$__interval])",
+		},
+		"range query": {
+			limits:        mockLimits{extendedQuerySyntaxEnabled: true},
+			req:           &PrometheusRangeQueryRequest{Start: 0, End: 3_600_000, Step: 60_000, Query: "rate(foo[$__interval * 4])"},
+			expectedQuery: "rate(foo[4m])",
+		},
+		"instant query with duration arithmetic": {
+			limits:        mockLimits{extendedQuerySyntaxEnabled: true},
+			req:           &PrometheusInstantQueryRequest{Time: 3_600_000, Query: "rate(foo[1m * 5])"},
+			expectedQuery: "rate(foo[5m])",
+		},
+		"instant query with variables": {
+			limits:      mockLimits{extendedQuerySyntaxEnabled: true},
+			req:         &PrometheusInstantQueryRequest{Time: 3_600_000, Query: "rate(foo[$__interval])"},
+			expectedErr: "the variable $__interval can only be used in range queries",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actual Request
+			next := HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				actual = req
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			})
+
+			mw := newExtendedQuerySyntaxMiddleware(testData.limits, log.NewNopLogger()).Wrap(next)
+			_, err := mw.Do(user.InjectOrgID(context.Background(), "user-1"), testData.req)
+
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.True(t, apierror.IsAPIError(err))
+				assert.Contains(t, err.Error(), testData.expectedErr)
+				assert.Nil(t, actual)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedQuery, actual.GetQuery())
+		})
+	}
+}
@@ -7,6 +7,7 @@ package querymiddleware
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -43,6 +44,10 @@ type Limits interface {
 	// query may be. 0 means "unlimited".
 	MaxQueryExpressionSizeBytes(userID string) int
 
+	// MaxQueryExecutionTime returns the maximum time a query can take to execute, from when it's
+	// received by the query-frontend. 0 means "unlimited".
+	MaxQueryExecutionTime(userID string) time.Duration
+
 	// MaxCacheFreshness returns the period after which results are cacheable,
 	// to prevent caching of very recent results.
 	MaxCacheFreshness(userID string) time.Duration
@@ -59,6 +64,10 @@ type Limits interface {
 	// than this limit, the query will not be sharded. 0 to disable limit.
 	QueryShardingMaxRegexpSizeBytes(userID string) int
 
+	// QueryShardingTopKOversamplingFactor returns the factor applied to the number of elements selected by the
+	// sharded topk and bottomk aggregations. 0 if topk and bottomk aggregations shouldn't be sharded.
+	QueryShardingTopKOversamplingFactor(userID string) float64
+
 	// SplitInstantQueriesByInterval returns the time interval to split instant queries for a given tenant.
 	SplitInstantQueriesByInterval(userID string) time.Duration
 
@@ -88,6 +97,30 @@ type Limits interface {
 
 	// ResultsCacheTTLForCardinalityQuery returns TTL for cached results for cardinality queries.
 	ResultsCacheTTLForCardinalityQuery(userID string) time.Duration
+
+	// ResultsCacheSlidingTTLMax returns the maximum TTL of cached results whose TTL slides with the age of their
+	// data. The sliding TTL is disabled if not greater than ResultsCacheTTL.
+	ResultsCacheSlidingTTLMax(userID string) time.Duration
+
+	// ResultsCacheTTLForPassthrough returns TTL for cached responses of the requests proxied to the PassthroughURL.
+	ResultsCacheTTLForPassthrough(userID string) time.Duration
+
+	// PassthroughURL returns the URL the requests to the Prometheus HTTP API paths not implemented by Mimir are
+	// proxied to. Empty if disabled.
+	PassthroughURL(userID string) string
+
+	// FuseStepMisalignedQueries returns whether range queries should be aligned to their step,
+	// and concurrent identical queries fused.
+	FuseStepMisalignedQueries(userID string) bool
+
+	// ExtendedQuerySyntaxEnabled returns whether the extended query syntax is enabled for the tenant.
+	ExtendedQuerySyntaxEnabled(userID string) bool
+
+	// QueryRetryErrorClasses returns the classes of the downstream errors queries are retried on.
+	QueryRetryErrorClasses(userID string) []string
+
+	// QueryAccessPolicies returns the query access policies of the sub-users of the tenant.
+	QueryAccessPolicies(userID string) map[string]validation.QueryAccessPolicy
 }
 
 type limitsMiddleware struct {
@@ -184,6 +217,48 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 	return l.next.Do(ctx, r)
 }
 
+// newMaxQueryExecutionTimeTripperware creates a new Tripperware that abandons the request once the
+// max query execution time of the tenant has elapsed. The deadline is propagated downstream, so that
+// queriers, ingesters and store-gateways stop working on the request too.
+func newMaxQueryExecutionTimeTripperware(limits Limits) Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			tenantIDs, err := tenant.TenantIDs(r.Context())
+			if err != nil {
+				return nil, apierror.New(apierror.TypeBadData, err.Error())
+			}
+
+			timeout := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, limits.MaxQueryExecutionTime)
+			if timeout <= 0 {
+				return next.RoundTrip(r)
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			resp, err := next.RoundTrip(r.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			// The response body may be streamed from downstream, so the context can be
+			// canceled only once it has been read.
+			resp.Body = cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		})
+	}
+}
+
+// cancelOnCloseBody is a response body which cancels the request context once closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
 type limitedParallelismRoundTripper struct {
 	downstream Handler
 	limits     Limits
@@ -23,6 +23,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func TestLimitsMiddleware_MaxQueryLookback(t *testing.T) {
@@ -371,6 +372,10 @@ func (m multiTenantMockLimits) MaxQueryExpressionSizeBytes(userID string) int {
 	return m.byTenant[userID].maxQueryExpressionSizeBytes
 }
 
+func (m multiTenantMockLimits) MaxQueryExecutionTime(userID string) time.Duration {
+	return m.byTenant[userID].maxQueryExecutionTime
+}
+
 func (m multiTenantMockLimits) MaxQueryParallelism(userID string) int {
 	return m.byTenant[userID].maxQueryParallelism
 }
@@ -391,6 +396,10 @@ func (m multiTenantMockLimits) QueryShardingMaxRegexpSizeBytes(userID string) in
 	return m.byTenant[userID].maxRegexpSizeBytes
 }
 
+func (m multiTenantMockLimits) QueryShardingTopKOversamplingFactor(userID string) float64 {
+	return m.byTenant[userID].topkOversamplingFactor
+}
+
 func (m multiTenantMockLimits) SplitInstantQueriesByInterval(userID string) time.Duration {
 	return m.byTenant[userID].splitInstantQueriesInterval
 }
@@ -419,6 +428,18 @@ func (m multiTenantMockLimits) ResultsCacheTTLForCardinalityQuery(userID string)
 	return m.byTenant[userID].resultsCacheTTLForCardinalityQuery
 }
 
+func (m multiTenantMockLimits) ResultsCacheSlidingTTLMax(userID string) time.Duration {
+	return m.byTenant[userID].resultsCacheSlidingTTLMax
+}
+
+func (m multiTenantMockLimits) ResultsCacheTTLForPassthrough(userID string) time.Duration {
+	return m.byTenant[userID].resultsCacheTTLForPassthrough
+}
+
+func (m multiTenantMockLimits) PassthroughURL(userID string) string {
+	return m.byTenant[userID].passthroughURL
+}
+
 func (m multiTenantMockLimits) CreationGracePeriod(userID string) time.Duration {
 	return m.byTenant[userID].creationGracePeriod
 }
@@ -427,15 +448,33 @@ func (m multiTenantMockLimits) NativeHistogramsIngestionEnabled(userID string) b
 	return m.byTenant[userID].nativeHistogramsIngestionEnabled
 }
 
+func (m multiTenantMockLimits) QueryRetryErrorClasses(userID string) []string {
+	return m.byTenant[userID].QueryRetryErrorClasses(userID)
+}
+
+func (m multiTenantMockLimits) QueryAccessPolicies(userID string) map[string]validation.QueryAccessPolicy {
+	return m.byTenant[userID].queryAccessPolicies
+}
+
+func (m multiTenantMockLimits) FuseStepMisalignedQueries(userID string) bool {
+	return m.byTenant[userID].fuseStepMisalignedQueries
+}
+
+func (m multiTenantMockLimits) ExtendedQuerySyntaxEnabled(userID string) bool {
+	return m.byTenant[userID].extendedQuerySyntaxEnabled
+}
+
 type mockLimits struct {
 	maxQueryLookback                   time.Duration
 	maxQueryLength                     time.Duration
 	maxTotalQueryLength                time.Duration
 	maxQueryExpressionSizeBytes        int
+	maxQueryExecutionTime              time.Duration
 	maxCacheFreshness                  time.Duration
 	maxQueryParallelism                int
 	maxShardedQueries                  int
 	maxRegexpSizeBytes                 int
+	topkOversamplingFactor             float64
 	splitInstantQueriesInterval        time.Duration
 	totalShards                        int
 	compactorShards                    int
@@ -446,6 +485,13 @@ type mockLimits struct {
 	resultsCacheTTL                    time.Duration
 	resultsCacheOutOfOrderWindowTTL    time.Duration
 	resultsCacheTTLForCardinalityQuery time.Duration
+	resultsCacheSlidingTTLMax          time.Duration
+	resultsCacheTTLForPassthrough      time.Duration
+	passthroughURL                     string
+	fuseStepMisalignedQueries          bool
+	extendedQuerySyntaxEnabled         bool
+	queryRetryErrorClasses             []string
+	queryAccessPolicies                map[string]validation.QueryAccessPolicy
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -463,6 +509,10 @@ func (m mockLimits) MaxQueryExpressionSizeBytes(string) int {
 	return m.maxQueryExpressionSizeBytes
 }
 
+func (m mockLimits) MaxQueryExecutionTime(string) time.Duration {
+	return m.maxQueryExecutionTime
+}
+
 func (m mockLimits) MaxQueryParallelism(string) int {
 	if m.maxQueryParallelism == 0 {
 		return 14 // Flag default.
@@ -486,6 +536,10 @@ func (m mockLimits) QueryShardingMaxRegexpSizeBytes(string) int {
 	return m.maxRegexpSizeBytes
 }
 
+func (m mockLimits) QueryShardingTopKOversamplingFactor(string) float64 {
+	return m.topkOversamplingFactor
+}
+
 func (m mockLimits) SplitInstantQueriesByInterval(string) time.Duration {
 	return m.splitInstantQueriesInterval
 }
@@ -514,6 +568,18 @@ func (m mockLimits) ResultsCacheTTLForCardinalityQuery(string) time.Duration {
 	return m.resultsCacheTTLForCardinalityQuery
 }
 
+func (m mockLimits) ResultsCacheSlidingTTLMax(string) time.Duration {
+	return m.resultsCacheSlidingTTLMax
+}
+
+func (m mockLimits) ResultsCacheTTLForPassthrough(string) time.Duration {
+	return m.resultsCacheTTLForPassthrough
+}
+
+func (m mockLimits) PassthroughURL(string) string {
+	return m.passthroughURL
+}
+
 func (m mockLimits) CreationGracePeriod(string) time.Duration {
 	return m.creationGracePeriod
 }
@@ -522,6 +588,26 @@ func (m mockLimits) NativeHistogramsIngestionEnabled(string) bool {
 	return m.nativeHistogramsIngestionEnabled
 }
 
+func (m mockLimits) QueryAccessPolicies(string) map[string]validation.QueryAccessPolicy {
+	return m.queryAccessPolicies
+}
+
+func (m mockLimits) QueryRetryErrorClasses(string) []string {
+	if m.queryRetryErrorClasses == nil {
+		// Flag default.
+		return []string{validation.QueryErrorClassNetwork, validation.QueryErrorClassInternal}
+	}
+	return m.queryRetryErrorClasses
+}
+
+func (m mockLimits) FuseStepMisalignedQueries(string) bool {
+	return m.fuseStepMisalignedQueries
+}
+
+func (m mockLimits) ExtendedQuerySyntaxEnabled(string) bool {
+	return m.extendedQuerySyntaxEnabled
+}
+
 type mockHandler struct {
 	mock.Mock
 }
@@ -679,3 +765,67 @@ func TestLimitedRoundTripper_OriginalRequestContextCancellation(t *testing.T) {
 	).RoundTrip(r)
 	require.NoError(t, err)
 }
+
+func TestMaxQueryExecutionTimeTripperware(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	limits := multiTenantMockLimits{
+		byTenant: map[string]mockLimits{
+			"test1": {maxQueryExecutionTime: time.Minute},
+			"test2": {maxQueryExecutionTime: time.Hour},
+			"test3": {},
+		},
+	}
+
+	tests := map[string]struct {
+		orgID           string
+		expectedTimeout time.Duration
+	}{
+		"should not set a deadline if the limit is disabled": {
+			orgID:           "test3",
+			expectedTimeout: 0,
+		},
+		"should set a deadline based on the tenant limit": {
+			orgID:           "test2",
+			expectedTimeout: time.Hour,
+		},
+		"should set a deadline based on the smallest limit of the tenants": {
+			orgID:           "test1|test2|test3",
+			expectedTimeout: time.Minute,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var downstreamCtx context.Context
+			downstream := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				downstreamCtx = req.Context()
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})
+
+			req, err := http.NewRequestWithContext(user.InjectOrgID(context.Background(), testData.orgID), http.MethodGet, "/api/v1/labels", nil)
+			require.NoError(t, err)
+
+			resp, err := newMaxQueryExecutionTimeTripperware(limits)(downstream).RoundTrip(req)
+			require.NoError(t, err)
+			require.NotNil(t, downstreamCtx)
+
+			deadline, ok := downstreamCtx.Deadline()
+			if testData.expectedTimeout == 0 {
+				assert.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(testData.expectedTimeout), deadline, 5*time.Second)
+
+			// The context should be canceled only once the response body has been closed.
+			assert.NoError(t, downstreamCtx.Err())
+			require.NoError(t, resp.Body.Close())
+			assert.ErrorIs(t, downstreamCtx.Err(), context.Canceled)
+		})
+	}
+}
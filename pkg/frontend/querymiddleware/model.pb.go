@@ -288,6 +288,7 @@ type PrometheusResponse struct {
 	ErrorType string                      `protobuf:"bytes,3,opt,name=ErrorType,proto3" json:"errorType,omitempty"`
 	Error     string                      `protobuf:"bytes,4,opt,name=Error,proto3" json:"error,omitempty"`
 	Headers   []*PrometheusResponseHeader `protobuf:"bytes,5,rep,name=Headers,proto3" json:"-"`
+	Warnings  []string                    `protobuf:"bytes,6,rep,name=Warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (m *PrometheusResponse) Reset()      { *m = PrometheusResponse{} }
@@ -357,6 +358,13 @@ func (m *PrometheusResponse) GetHeaders() []*PrometheusResponseHeader {
 	return nil
 }
 
+func (m *PrometheusResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type PrometheusData struct {
 	ResultType string         `protobuf:"bytes,1,opt,name=ResultType,proto3" json:"resultType"`
 	Result     []SampleStream `protobuf:"bytes,2,rep,name=Result,proto3" json:"result"`
@@ -597,6 +605,8 @@ type Options struct {
 	InstantSplitDisabled bool  `protobuf:"varint,4,opt,name=InstantSplitDisabled,proto3" json:"InstantSplitDisabled,omitempty"`
 	// Instant split by time interval unit stored in nanoseconds (time.Duration unit in int64)
 	InstantSplitInterval int64 `protobuf:"varint,5,opt,name=InstantSplitInterval,proto3" json:"InstantSplitInterval,omitempty"`
+	// Skip the results cache lookup, but still store the results in the cache.
+	CacheLookupDisabled bool `protobuf:"varint,6,opt,name=CacheLookupDisabled,proto3" json:"CacheLookupDisabled,omitempty"`
 }
 
 func (m *Options) Reset()      { *m = Options{} }
@@ -666,6 +676,13 @@ func (m *Options) GetInstantSplitInterval() int64 {
 	return 0
 }
 
+func (m *Options) GetCacheLookupDisabled() bool {
+	if m != nil {
+		return m.CacheLookupDisabled
+	}
+	return false
+}
+
 type Hints struct {
 	// Total number of queries that are expected to to be executed to serve the original request.
 	TotalQueries int32 `protobuf:"varint,1,opt,name=TotalQueries,proto3" json:"TotalQueries,omitempty"`
@@ -676,6 +693,9 @@ type Hints struct {
 	// Types that are valid to be assigned to CardinalityEstimate:
 	//	*Hints_EstimatedSeriesCount
 	CardinalityEstimate isHints_CardinalityEstimate `protobuf_oneof:"CardinalityEstimate"`
+	// Number of sharded queries executed the last time the estimated series count
+	// has been observed. Zero if the query was not sharded at that time.
+	ObservedShardedQueries uint32 `protobuf:"varint,3,opt,name=ObservedShardedQueries,proto3" json:"ObservedShardedQueries,omitempty"`
 }
 
 func (m *Hints) Reset()      { *m = Hints{} }
@@ -744,6 +764,13 @@ func (m *Hints) GetEstimatedSeriesCount() uint64 {
 	return 0
 }
 
+func (m *Hints) GetObservedShardedQueries() uint32 {
+	if m != nil {
+		return m.ObservedShardedQueries
+	}
+	return 0
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Hints) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -753,6 +780,8 @@ func (*Hints) XXX_OneofWrappers() []interface{} {
 
 type QueryStatistics struct {
 	EstimatedSeriesCount uint64 `protobuf:"varint,1,opt,name=EstimatedSeriesCount,proto3" json:"EstimatedSeriesCount,omitempty"`
+	// Number of sharded queries executed when the EstimatedSeriesCount was observed.
+	ShardedQueries uint32 `protobuf:"varint,2,opt,name=ShardedQueries,proto3" json:"ShardedQueries,omitempty"`
 }
 
 func (m *QueryStatistics) Reset()      { *m = QueryStatistics{} }
@@ -794,6 +823,13 @@ func (m *QueryStatistics) GetEstimatedSeriesCount() uint64 {
 	return 0
 }
 
+func (m *QueryStatistics) GetShardedQueries() uint32 {
+	if m != nil {
+		return m.ShardedQueries
+	}
+	return 0
+}
+
 // CachedHTTPResponse holds a generic HTTP response in the query results cache.
 type CachedHTTPResponse struct {
 	// cacheKey contains the non-hashed cache key, used to guarantee there haven't
@@ -938,83 +974,87 @@ func init() {
 func init() { proto.RegisterFile("model.proto", fileDescriptor_4c16552f9fdb66d8) }
 
 var fileDescriptor_4c16552f9fdb66d8 = []byte{
-	// 1202 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0x4d, 0x8f, 0x1b, 0x45,
-	0x13, 0xf6, 0xd8, 0xe3, 0xaf, 0xf2, 0xbe, 0xbb, 0xfb, 0xf6, 0x2e, 0x62, 0x36, 0x21, 0x33, 0xd6,
-	0x28, 0x87, 0x05, 0x25, 0x5e, 0xd8, 0x00, 0x07, 0x04, 0x88, 0xcc, 0x66, 0xd1, 0x86, 0xcf, 0xa5,
-	0x77, 0xc5, 0x81, 0xcb, 0xaa, 0xed, 0xe9, 0xd8, 0x43, 0xe6, 0x2b, 0xd3, 0xed, 0x10, 0xdf, 0x10,
-	0x3f, 0x00, 0x71, 0xe4, 0xc4, 0x0d, 0x89, 0x5f, 0xc0, 0x6f, 0xc8, 0x31, 0xdc, 0xa2, 0x1c, 0x0c,
-	0x71, 0x84, 0x84, 0x7c, 0xca, 0x4f, 0x40, 0x5d, 0x3d, 0x63, 0xcf, 0x7e, 0x20, 0xc2, 0xc5, 0xae,
-	0xae, 0x7a, 0xaa, 0xfa, 0xa9, 0x9a, 0xee, 0xa7, 0xa1, 0x13, 0x25, 0x3e, 0x0f, 0x7b, 0x69, 0x96,
-	0xc8, 0x84, 0xc0, 0xbd, 0x31, 0xcf, 0x26, 0x19, 0x8b, 0x87, 0xfc, 0xd2, 0xf5, 0x61, 0x20, 0x47,
-	0xe3, 0x7e, 0x6f, 0x90, 0x44, 0x3b, 0xc3, 0x64, 0x98, 0xec, 0x20, 0xa4, 0x3f, 0xbe, 0x83, 0x2b,
-	0x5c, 0xa0, 0xa5, 0x53, 0x2f, 0xd9, 0xc3, 0x24, 0x19, 0x86, 0x7c, 0x89, 0xf2, 0xc7, 0x19, 0x93,
-	0x41, 0x12, 0xe7, 0xf1, 0xd7, 0xcb, 0xe5, 0x32, 0x76, 0x87, 0xc5, 0x6c, 0x27, 0x0a, 0xa2, 0x20,
-	0xdb, 0x49, 0xef, 0x0e, 0xb5, 0x95, 0xf6, 0xf5, 0x7f, 0x9e, 0xb1, 0x75, 0xb6, 0x22, 0x8b, 0x27,
-	0x3a, 0xe4, 0xfe, 0x5a, 0x85, 0xcb, 0x87, 0x59, 0x12, 0x71, 0x39, 0xe2, 0x63, 0x41, 0x15, 0xdf,
-	0x2f, 0x14, 0x73, 0xca, 0xef, 0x8d, 0xb9, 0x90, 0x84, 0x80, 0x99, 0x32, 0x39, 0xb2, 0x8c, 0xae,
-	0xb1, 0xdd, 0xa6, 0x68, 0x93, 0x4d, 0xa8, 0x0b, 0xc9, 0x32, 0x69, 0x55, 0xbb, 0xc6, 0x76, 0x8d,
-	0xea, 0x05, 0x59, 0x87, 0x1a, 0x8f, 0x7d, 0xab, 0x86, 0x3e, 0x65, 0xaa, 0x5c, 0x21, 0x79, 0x6a,
-	0x99, 0xe8, 0x42, 0x9b, 0xbc, 0x07, 0x4d, 0x19, 0x44, 0x3c, 0x19, 0x4b, 0xab, 0xde, 0x35, 0xb6,
-	0x3b, 0xbb, 0x5b, 0x3d, 0x4d, 0xae, 0x57, 0x90, 0xeb, 0xdd, 0xca, 0xdb, 0xf5, 0x5a, 0x0f, 0xa7,
-	0x4e, 0xe5, 0xc7, 0xdf, 0x1d, 0x83, 0x16, 0x39, 0x6a, 0x6b, 0x1c, 0xac, 0xd5, 0x40, 0x3e, 0x7a,
-	0x41, 0x6e, 0x40, 0x33, 0x49, 0x55, 0x8a, 0xb0, 0x9a, 0x58, 0x74, 0xa3, 0xb7, 0x1c, 0x7f, 0xef,
-	0x73, 0x1d, 0xf2, 0x4c, 0x55, 0x8e, 0x16, 0x48, 0xb2, 0x0a, 0xd5, 0xc0, 0xb7, 0x5a, 0xc8, 0xad,
-	0x1a, 0xf8, 0xe4, 0x3a, 0xd4, 0x47, 0x41, 0x2c, 0x85, 0xd5, 0xc6, 0x12, 0xff, 0x2f, 0x97, 0x38,
-	0x50, 0x01, 0x2c, 0x60, 0x50, 0x8d, 0x72, 0x7f, 0x33, 0xe0, 0xca, 0x72, 0x70, 0xb7, 0x63, 0x21,
-	0x59, 0x2c, 0xff, 0x75, 0x74, 0x04, 0x4c, 0xd5, 0x4a, 0x3e, 0x39, 0xb4, 0x97, 0x3d, 0xd5, 0xfe,
-	0xa1, 0x27, 0xf3, 0x3f, 0xf6, 0x54, 0x3f, 0xdf, 0x53, 0xe3, 0x85, 0x7a, 0x3a, 0x06, 0xab, 0x74,
-	0x16, 0xb8, 0x48, 0x93, 0x58, 0xf0, 0x03, 0xce, 0x7c, 0x9e, 0x91, 0x2d, 0x30, 0x3f, 0x63, 0x11,
-	0xd7, 0xdd, 0x78, 0xf5, 0xf9, 0xd4, 0x31, 0xae, 0x53, 0x74, 0x91, 0x2b, 0xd0, 0xf8, 0x92, 0x85,
-	0x63, 0x2e, 0xac, 0x6a, 0xb7, 0xb6, 0x0c, 0xe6, 0x4e, 0xf7, 0xe7, 0x2a, 0x90, 0xf3, 0x65, 0x89,
-	0x0b, 0x8d, 0x23, 0xc9, 0xe4, 0x58, 0xe4, 0x25, 0x61, 0x3e, 0x75, 0x1a, 0x02, 0x3d, 0x34, 0x8f,
-	0x10, 0x0f, 0xcc, 0x5b, 0x4c, 0x32, 0x1c, 0x57, 0x67, 0xf7, 0x52, 0x99, 0xfe, 0xb2, 0xa2, 0x42,
-	0x78, 0x64, 0x3e, 0x75, 0x56, 0x7d, 0x26, 0xd9, 0xb5, 0x24, 0x0a, 0x24, 0x8f, 0x52, 0x39, 0xa1,
-	0x98, 0x4b, 0xde, 0x82, 0xf6, 0x7e, 0x96, 0x25, 0xd9, 0xf1, 0x24, 0xe5, 0x7a, 0xc4, 0xde, 0xcb,
-	0xf3, 0xa9, 0xb3, 0xc1, 0x0b, 0x67, 0x29, 0x63, 0x89, 0x24, 0xaf, 0x42, 0x1d, 0x17, 0x38, 0xfd,
-	0xb6, 0xb7, 0x31, 0x9f, 0x3a, 0x6b, 0x98, 0x52, 0x82, 0x6b, 0x04, 0xd9, 0x87, 0xa6, 0x1e, 0x92,
-	0xb0, 0xea, 0xdd, 0xda, 0x76, 0x67, 0xf7, 0xea, 0xc5, 0x44, 0x4f, 0x4f, 0xb4, 0x18, 0x53, 0x91,
-	0xeb, 0x7e, 0x67, 0xc0, 0xea, 0xe9, 0xae, 0x48, 0x0f, 0x80, 0x72, 0x31, 0x0e, 0x25, 0x92, 0xd7,
-	0x73, 0x5a, 0x9d, 0x4f, 0x1d, 0xc8, 0x16, 0x5e, 0x5a, 0x42, 0x90, 0x0f, 0xa0, 0xa1, 0x57, 0xf8,
-	0x25, 0x3a, 0xbb, 0x56, 0x99, 0xc8, 0x11, 0x8b, 0xd2, 0x90, 0x1f, 0xc9, 0x8c, 0xb3, 0xc8, 0x5b,
-	0x55, 0x07, 0x47, 0x4d, 0x5c, 0x57, 0xa2, 0x79, 0x9e, 0xfb, 0x7d, 0x15, 0x56, 0xca, 0x40, 0x92,
-	0x42, 0x23, 0x64, 0x7d, 0x1e, 0xaa, 0xcf, 0x54, 0xc3, 0x63, 0x38, 0x48, 0x32, 0xc9, 0x1f, 0xa4,
-	0xfd, 0xde, 0x27, 0xca, 0x7f, 0xc8, 0x82, 0xcc, 0xdb, 0x53, 0xd5, 0x9e, 0x4c, 0x9d, 0x37, 0x5e,
-	0x44, 0x9a, 0x74, 0xde, 0x4d, 0x9f, 0xa5, 0x92, 0x67, 0x8a, 0x42, 0xc4, 0x65, 0x16, 0x0c, 0x68,
-	0xbe, 0x0f, 0x79, 0x07, 0x9a, 0x02, 0x19, 0x88, 0xbc, 0x8b, 0xf5, 0xe5, 0x96, 0x9a, 0xda, 0x92,
-	0xfd, 0x7d, 0x3c, 0x62, 0xb4, 0x48, 0x20, 0x87, 0x00, 0xa3, 0x40, 0xc8, 0x64, 0x98, 0xb1, 0x48,
-	0x58, 0x35, 0x4c, 0x7f, 0x65, 0x99, 0xfe, 0x61, 0x98, 0x30, 0x79, 0x50, 0x00, 0x90, 0x3a, 0xc9,
-	0x4b, 0x95, 0xf2, 0x68, 0xc9, 0x76, 0xbf, 0x86, 0xd5, 0x3d, 0x36, 0x18, 0x71, 0x7f, 0x71, 0x70,
-	0xb7, 0xa0, 0x76, 0x97, 0x4f, 0xf2, 0xaf, 0xd1, 0x9c, 0x4f, 0x1d, 0xb5, 0xa4, 0xea, 0x47, 0xa9,
-	0x1b, 0x7f, 0x20, 0xb9, 0xba, 0x71, 0x9a, 0x3a, 0x29, 0x7f, 0x80, 0x7d, 0x0c, 0x79, 0x6b, 0xf9,
-	0x8e, 0x05, 0x94, 0x16, 0x86, 0xfb, 0xc4, 0x80, 0x86, 0x06, 0x11, 0xa7, 0xd0, 0x58, 0xb5, 0x4d,
-	0xcd, 0x6b, 0xcf, 0xa7, 0x8e, 0x76, 0x14, 0x72, 0xbb, 0xa5, 0xe5, 0x16, 0x85, 0x44, 0xb3, 0xe0,
-	0xb1, 0xaf, 0x75, 0xb7, 0x0b, 0x2d, 0x99, 0xb1, 0x01, 0x3f, 0x09, 0xfc, 0xfc, 0xf4, 0x16, 0x47,
-	0x0d, 0xdd, 0xb7, 0x7d, 0xf2, 0x3e, 0xb4, 0xb2, 0xbc, 0x9d, 0x5c, 0x86, 0x37, 0xcf, 0xc9, 0xf0,
-	0xcd, 0x78, 0xe2, 0xad, 0xcc, 0xa7, 0xce, 0x02, 0x49, 0x17, 0x16, 0xb9, 0x06, 0x04, 0xfb, 0x3a,
-	0x51, 0x02, 0x26, 0x24, 0x8b, 0xd2, 0x93, 0x48, 0x8b, 0x4c, 0x8d, 0xae, 0x63, 0xe4, 0xb8, 0x08,
-	0x7c, 0x2a, 0x3e, 0x32, 0x5b, 0xb5, 0x75, 0xd3, 0xfd, 0xd3, 0x80, 0x66, 0x2e, 0x5b, 0xe4, 0x2a,
-	0xfc, 0x0f, 0x87, 0x7a, 0x2b, 0x10, 0xac, 0x1f, 0x72, 0x1f, 0xbb, 0x6c, 0xd1, 0xd3, 0x4e, 0xf2,
-	0x1a, 0xac, 0x1f, 0x8d, 0x58, 0xe6, 0x07, 0xf1, 0x70, 0x01, 0xac, 0x22, 0xf0, 0x9c, 0x9f, 0x74,
-	0xa1, 0x73, 0x9c, 0x48, 0x16, 0x62, 0x40, 0xe0, 0x3d, 0xaf, 0xd3, 0xb2, 0x8b, 0xec, 0xc2, 0x66,
-	0xae, 0xd2, 0x47, 0x69, 0x18, 0xc8, 0x45, 0x45, 0x13, 0x2b, 0x5e, 0x18, 0x3b, 0x9b, 0x73, 0x3b,
-	0x96, 0x3c, 0xbb, 0xcf, 0xc2, 0x5c, 0x61, 0x2f, 0x8c, 0xb9, 0x0f, 0xa0, 0x8e, 0xd2, 0x4a, 0x5c,
-	0x58, 0xc1, 0xfd, 0xd5, 0xa3, 0x10, 0x70, 0x2d, 0x73, 0x75, 0x7a, 0xca, 0x47, 0xde, 0x84, 0xcd,
-	0x7d, 0x21, 0x83, 0x88, 0x49, 0xee, 0x1f, 0xa1, 0x6b, 0x2f, 0x19, 0xc7, 0xfa, 0x65, 0x35, 0x0f,
-	0x2a, 0xf4, 0xc2, 0xa8, 0xf7, 0x12, 0x6c, 0xec, 0x61, 0xff, 0x2c, 0x0c, 0xe4, 0xa4, 0x80, 0xb8,
-	0xfb, 0xb0, 0x86, 0x0f, 0x90, 0x12, 0xcf, 0x40, 0xc8, 0x60, 0x80, 0x4d, 0x5f, 0x58, 0x5f, 0x71,
-	0x31, 0x2f, 0xae, 0xee, 0xfe, 0x64, 0x00, 0xd1, 0x47, 0xfe, 0xe0, 0xf8, 0xf8, 0x70, 0x71, 0xec,
-	0x2f, 0x43, 0x7b, 0xa0, 0xbc, 0x27, 0x8b, 0xc3, 0x4f, 0x5b, 0xe8, 0xf8, 0x98, 0x4f, 0x88, 0x03,
-	0x1d, 0x2d, 0xdd, 0x27, 0x83, 0xc4, 0xd7, 0xcf, 0x5b, 0x9d, 0x82, 0x76, 0xed, 0x25, 0x3e, 0x27,
-	0x6f, 0x43, 0x73, 0x94, 0x6b, 0x64, 0x71, 0x2b, 0x4b, 0x37, 0x63, 0xb9, 0x9d, 0x16, 0x43, 0x5a,
-	0x80, 0xd5, 0x83, 0xd9, 0x4f, 0xfc, 0x09, 0x7e, 0xa5, 0x15, 0x8a, 0xb6, 0xfb, 0x2e, 0xac, 0x9f,
-	0x4d, 0x50, 0xb8, 0x78, 0xf1, 0x3c, 0x51, 0xb4, 0xd5, 0xc3, 0x8a, 0xfa, 0x80, 0x74, 0xda, 0x54,
-	0x2f, 0xbc, 0xfd, 0x47, 0x4f, 0xed, 0xca, 0xe3, 0xa7, 0x76, 0xe5, 0xf9, 0x53, 0xdb, 0xf8, 0x76,
-	0x66, 0x1b, 0xbf, 0xcc, 0x6c, 0xe3, 0xe1, 0xcc, 0x36, 0x1e, 0xcd, 0x6c, 0xe3, 0x8f, 0x99, 0x6d,
-	0xfc, 0x35, 0xb3, 0x2b, 0xcf, 0x67, 0xb6, 0xf1, 0xc3, 0x33, 0xbb, 0xf2, 0xe8, 0x99, 0x5d, 0x79,
-	0xfc, 0xcc, 0xae, 0x7c, 0xb5, 0x86, 0x6c, 0xa3, 0xc0, 0xf7, 0x43, 0xfe, 0x0d, 0xcb, 0x78, 0xbf,
-	0x81, 0x17, 0xe5, 0xc6, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xf2, 0xd9, 0xcc, 0xb5, 0xf6, 0x09,
-	0x00, 0x00,
+	// 1276 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xcb, 0x72, 0x1b, 0x45,
+	0x17, 0xd6, 0xe8, 0xee, 0x63, 0x47, 0xf6, 0xdf, 0xf6, 0x1f, 0xc6, 0x09, 0x99, 0x51, 0x4d, 0xa5,
+	0x28, 0x43, 0x25, 0x72, 0x70, 0x20, 0x0b, 0x0a, 0x28, 0x32, 0x8e, 0x29, 0x07, 0x02, 0x31, 0x6d,
+	0x17, 0x54, 0xb1, 0x71, 0xb5, 0x34, 0x1d, 0x69, 0xc8, 0xdc, 0xd2, 0xd3, 0x4a, 0xa2, 0x1d, 0xc5,
+	0x03, 0x50, 0xec, 0x60, 0xc5, 0x9a, 0x25, 0x2b, 0x9e, 0x21, 0xcb, 0xb0, 0x0b, 0x59, 0x08, 0xa2,
+	0x6c, 0x28, 0xad, 0xf2, 0x08, 0x54, 0x9f, 0x9e, 0x91, 0xc6, 0x97, 0x14, 0x61, 0x63, 0x9f, 0xfe,
+	0xce, 0xa5, 0xbf, 0x73, 0xa6, 0xfb, 0x6b, 0xc1, 0x62, 0x18, 0x7b, 0x3c, 0xe8, 0x24, 0x22, 0x96,
+	0x31, 0x81, 0x7b, 0x43, 0x2e, 0x46, 0x82, 0x45, 0x7d, 0x7e, 0xee, 0x72, 0xdf, 0x97, 0x83, 0x61,
+	0xb7, 0xd3, 0x8b, 0xc3, 0xcd, 0x7e, 0xdc, 0x8f, 0x37, 0x31, 0xa4, 0x3b, 0xbc, 0x83, 0x2b, 0x5c,
+	0xa0, 0xa5, 0x53, 0xcf, 0x59, 0xfd, 0x38, 0xee, 0x07, 0x7c, 0x1e, 0xe5, 0x0d, 0x05, 0x93, 0x7e,
+	0x1c, 0x65, 0xfe, 0x2b, 0xc5, 0x72, 0x82, 0xdd, 0x61, 0x11, 0xdb, 0x0c, 0xfd, 0xd0, 0x17, 0x9b,
+	0xc9, 0xdd, 0xbe, 0xb6, 0x92, 0xae, 0xfe, 0x9f, 0x65, 0xac, 0x1f, 0xaf, 0xc8, 0xa2, 0x91, 0x76,
+	0x39, 0xbf, 0x95, 0xe1, 0xfc, 0x9e, 0x88, 0x43, 0x2e, 0x07, 0x7c, 0x98, 0x52, 0xc5, 0xf7, 0x0b,
+	0xc5, 0x9c, 0xf2, 0x7b, 0x43, 0x9e, 0x4a, 0x42, 0xa0, 0x9a, 0x30, 0x39, 0x30, 0x8d, 0xb6, 0xb1,
+	0xb1, 0x40, 0xd1, 0x26, 0x6b, 0x50, 0x4b, 0x25, 0x13, 0xd2, 0x2c, 0xb7, 0x8d, 0x8d, 0x0a, 0xd5,
+	0x0b, 0xb2, 0x02, 0x15, 0x1e, 0x79, 0x66, 0x05, 0x31, 0x65, 0xaa, 0xdc, 0x54, 0xf2, 0xc4, 0xac,
+	0x22, 0x84, 0x36, 0xf9, 0x00, 0x1a, 0xd2, 0x0f, 0x79, 0x3c, 0x94, 0x66, 0xad, 0x6d, 0x6c, 0x2c,
+	0x6e, 0xad, 0x77, 0x34, 0xb9, 0x4e, 0x4e, 0xae, 0x73, 0x23, 0x6b, 0xd7, 0x6d, 0x3e, 0x1a, 0xdb,
+	0xa5, 0x9f, 0xfe, 0xb4, 0x0d, 0x9a, 0xe7, 0xa8, 0xad, 0x71, 0xb0, 0x66, 0x1d, 0xf9, 0xe8, 0x05,
+	0xb9, 0x0a, 0x8d, 0x38, 0x51, 0x29, 0xa9, 0xd9, 0xc0, 0xa2, 0xab, 0x9d, 0xf9, 0xf8, 0x3b, 0xb7,
+	0xb5, 0xcb, 0xad, 0xaa, 0x72, 0x34, 0x8f, 0x24, 0x2d, 0x28, 0xfb, 0x9e, 0xd9, 0x44, 0x6e, 0x65,
+	0xdf, 0x23, 0x97, 0xa1, 0x36, 0xf0, 0x23, 0x99, 0x9a, 0x0b, 0x58, 0xe2, 0x7f, 0xc5, 0x12, 0xbb,
+	0xca, 0x81, 0x05, 0x0c, 0xaa, 0xa3, 0x9c, 0xdf, 0x0d, 0xb8, 0x30, 0x1f, 0xdc, 0xcd, 0x28, 0x95,
+	0x2c, 0x92, 0xff, 0x3a, 0x3a, 0x02, 0x55, 0xd5, 0x4a, 0x36, 0x39, 0xb4, 0xe7, 0x3d, 0x55, 0x5e,
+	0xd2, 0x53, 0xf5, 0x3f, 0xf6, 0x54, 0x3b, 0xd9, 0x53, 0xfd, 0x95, 0x7a, 0x3a, 0x00, 0xb3, 0x70,
+	0x16, 0x78, 0x9a, 0xc4, 0x51, 0xca, 0x77, 0x39, 0xf3, 0xb8, 0x20, 0xeb, 0x50, 0xfd, 0x9c, 0x85,
+	0x5c, 0x77, 0xe3, 0xd6, 0xa6, 0x63, 0xdb, 0xb8, 0x4c, 0x11, 0x22, 0x17, 0xa0, 0xfe, 0x25, 0x0b,
+	0x86, 0x3c, 0x35, 0xcb, 0xed, 0xca, 0xdc, 0x99, 0x81, 0xce, 0x1f, 0x65, 0x20, 0x27, 0xcb, 0x12,
+	0x07, 0xea, 0xfb, 0x92, 0xc9, 0x61, 0x9a, 0x95, 0x84, 0xe9, 0xd8, 0xae, 0xa7, 0x88, 0xd0, 0xcc,
+	0x43, 0x5c, 0xa8, 0xde, 0x60, 0x92, 0xe1, 0xb8, 0x16, 0xb7, 0xce, 0x15, 0xe9, 0xcf, 0x2b, 0xaa,
+	0x08, 0x97, 0x4c, 0xc7, 0x76, 0xcb, 0x63, 0x92, 0x5d, 0x8a, 0x43, 0x5f, 0xf2, 0x30, 0x91, 0x23,
+	0x8a, 0xb9, 0xe4, 0x5d, 0x58, 0xd8, 0x11, 0x22, 0x16, 0x07, 0xa3, 0x84, 0xeb, 0x11, 0xbb, 0xaf,
+	0x4d, 0xc7, 0xf6, 0x2a, 0xcf, 0xc1, 0x42, 0xc6, 0x3c, 0x92, 0xbc, 0x09, 0x35, 0x5c, 0xe0, 0xf4,
+	0x17, 0xdc, 0xd5, 0xe9, 0xd8, 0x5e, 0xc6, 0x94, 0x42, 0xb8, 0x8e, 0x20, 0x3b, 0xd0, 0xd0, 0x43,
+	0x4a, 0xcd, 0x5a, 0xbb, 0xb2, 0xb1, 0xb8, 0x75, 0xf1, 0x74, 0xa2, 0x47, 0x27, 0x9a, 0x8f, 0x29,
+	0xcf, 0x25, 0x5b, 0xd0, 0xfc, 0x8a, 0x89, 0xc8, 0x8f, 0xfa, 0xea, 0x7b, 0xa9, 0x41, 0x9e, 0x9d,
+	0x8e, 0x6d, 0xf2, 0x20, 0xc3, 0x0a, 0xfb, 0xce, 0xe2, 0x9c, 0xef, 0x0c, 0x68, 0x1d, 0x9d, 0x04,
+	0xe9, 0x00, 0x50, 0x9e, 0x0e, 0x03, 0x89, 0x0d, 0xeb, 0xd9, 0xb6, 0xa6, 0x63, 0x1b, 0xc4, 0x0c,
+	0xa5, 0x85, 0x08, 0xf2, 0x11, 0xd4, 0xf5, 0x0a, 0xbf, 0xde, 0xe2, 0x96, 0x59, 0x24, 0xbf, 0xcf,
+	0xc2, 0x24, 0xe0, 0xfb, 0x52, 0x70, 0x16, 0xba, 0x2d, 0x75, 0xd8, 0xd4, 0x57, 0xd2, 0x95, 0x68,
+	0x96, 0xe7, 0x7c, 0x5f, 0x86, 0xa5, 0x62, 0x20, 0x49, 0xa0, 0x1e, 0xb0, 0x2e, 0x0f, 0xd4, 0xa7,
+	0xad, 0xe0, 0xd1, 0xed, 0xc5, 0x42, 0xf2, 0x87, 0x49, 0xb7, 0x73, 0x4b, 0xe1, 0x7b, 0xcc, 0x17,
+	0xee, 0xb6, 0xaa, 0xf6, 0x74, 0x6c, 0xbf, 0xfd, 0x2a, 0x72, 0xa6, 0xf3, 0xae, 0x7b, 0x2c, 0x91,
+	0x5c, 0x28, 0x0a, 0x21, 0x97, 0xc2, 0xef, 0xd1, 0x6c, 0x1f, 0xf2, 0x1e, 0x34, 0x52, 0x64, 0x90,
+	0x66, 0x5d, 0xac, 0xcc, 0xb7, 0xd4, 0xd4, 0xe6, 0xec, 0xef, 0xe3, 0xb1, 0xa4, 0x79, 0x02, 0xd9,
+	0x03, 0x18, 0xf8, 0xa9, 0x8c, 0xfb, 0x82, 0x85, 0xa9, 0x59, 0xc1, 0xf4, 0xd7, 0xe7, 0xe9, 0x1f,
+	0x07, 0x31, 0x93, 0xbb, 0x79, 0x00, 0x52, 0x27, 0x59, 0xa9, 0x42, 0x1e, 0x2d, 0xd8, 0xce, 0x37,
+	0xd0, 0xda, 0x66, 0xbd, 0x01, 0xf7, 0x66, 0x87, 0x7d, 0x1d, 0x2a, 0x77, 0xf9, 0x28, 0xfb, 0x1a,
+	0x8d, 0xe9, 0xd8, 0x56, 0x4b, 0xaa, 0xfe, 0x28, 0x45, 0xe4, 0x0f, 0x25, 0x57, 0xb7, 0x54, 0x53,
+	0x27, 0xc5, 0x0f, 0xb0, 0x83, 0x2e, 0x77, 0x39, 0xdb, 0x31, 0x0f, 0xa5, 0xb9, 0xe1, 0x3c, 0x35,
+	0xa0, 0xae, 0x83, 0x88, 0x9d, 0xeb, 0xb2, 0xda, 0xa6, 0xe2, 0x2e, 0x4c, 0xc7, 0xb6, 0x06, 0x72,
+	0x89, 0x5e, 0xd7, 0x12, 0x8d, 0xe2, 0xa3, 0x59, 0xf0, 0xc8, 0xd3, 0x5a, 0xdd, 0x86, 0xa6, 0x14,
+	0xac, 0xc7, 0x0f, 0x7d, 0x2f, 0x3b, 0xf1, 0xf9, 0xf1, 0x44, 0xf8, 0xa6, 0x47, 0x3e, 0x84, 0xa6,
+	0xc8, 0xda, 0xc9, 0xa4, 0x7b, 0xed, 0x84, 0x74, 0x5f, 0x8f, 0x46, 0xee, 0xd2, 0x74, 0x6c, 0xcf,
+	0x22, 0xe9, 0xcc, 0x22, 0x97, 0x80, 0x60, 0x5f, 0x87, 0x4a, 0xf4, 0x52, 0xc9, 0xc2, 0xe4, 0x30,
+	0xd4, 0xc2, 0x54, 0xa1, 0x2b, 0xe8, 0x39, 0xc8, 0x1d, 0x9f, 0xa5, 0x9f, 0x54, 0x9b, 0x95, 0x95,
+	0xaa, 0xf3, 0x63, 0x19, 0x1a, 0x99, 0xd4, 0x91, 0x8b, 0x70, 0x06, 0x87, 0x7a, 0xc3, 0x4f, 0x59,
+	0x37, 0xe0, 0x1e, 0x76, 0xd9, 0xa4, 0x47, 0x41, 0xf2, 0x16, 0xac, 0xec, 0x0f, 0x98, 0xf0, 0xfc,
+	0xa8, 0x3f, 0x0b, 0x2c, 0x63, 0xe0, 0x09, 0x9c, 0xb4, 0x61, 0xf1, 0x20, 0x96, 0x2c, 0x40, 0x47,
+	0x8a, 0xda, 0x50, 0xa3, 0x45, 0x88, 0x6c, 0xc1, 0x5a, 0xa6, 0xec, 0xfb, 0x49, 0xe0, 0xcb, 0x59,
+	0xc5, 0x2a, 0x56, 0x3c, 0xd5, 0x77, 0x3c, 0xe7, 0x66, 0x24, 0xb9, 0xb8, 0xcf, 0x82, 0x4c, 0x95,
+	0x4f, 0xf5, 0x91, 0x2b, 0xb0, 0x8a, 0x6d, 0xdc, 0x8a, 0xe3, 0xbb, 0xc3, 0x64, 0xb6, 0x4d, 0x1d,
+	0xb7, 0x39, 0xcd, 0xe5, 0xfc, 0x6a, 0x40, 0x0d, 0x15, 0x9c, 0x38, 0xb0, 0x84, 0x94, 0xd5, 0xdb,
+	0xe3, 0x73, 0xad, 0xa6, 0x35, 0x7a, 0x04, 0x23, 0xef, 0xc0, 0xda, 0x4e, 0x2a, 0xfd, 0x90, 0x49,
+	0xee, 0xed, 0x23, 0xb4, 0x1d, 0x0f, 0x23, 0xfd, 0x80, 0x57, 0x77, 0x4b, 0xf4, 0x54, 0x2f, 0xb9,
+	0x06, 0x67, 0x6f, 0x77, 0x53, 0x2e, 0xee, 0x73, 0x0f, 0xe7, 0xc1, 0xbd, 0x7c, 0x0f, 0x35, 0xaa,
+	0x33, 0xf4, 0x25, 0x5e, 0xf7, 0xff, 0xaa, 0x1b, 0x35, 0x6a, 0x16, 0xf8, 0x72, 0x94, 0x97, 0x76,
+	0x42, 0x58, 0xc6, 0xf7, 0x51, 0x69, 0xbb, 0x9f, 0x4a, 0xbf, 0x87, 0xf3, 0x3d, 0x95, 0x97, 0xea,
+	0xa1, 0xfa, 0x12, 0x56, 0x6f, 0x40, 0xeb, 0x18, 0x9b, 0x32, 0xb2, 0x39, 0x86, 0x3a, 0x3f, 0x1b,
+	0x40, 0xf4, 0x2d, 0xdc, 0x3d, 0x38, 0xd8, 0x9b, 0xdd, 0xc4, 0xf3, 0xb0, 0xd0, 0x53, 0xe8, 0xe1,
+	0xec, 0x3e, 0xd2, 0x26, 0x02, 0x9f, 0xf2, 0x11, 0xb1, 0x61, 0x51, 0xbf, 0x40, 0x87, 0xbd, 0xd8,
+	0xd3, 0xaf, 0x74, 0x8d, 0x82, 0x86, 0xb6, 0x63, 0x8f, 0x93, 0x6b, 0xd0, 0x18, 0x64, 0x52, 0x9f,
+	0x0b, 0x45, 0xe1, 0xb2, 0xce, 0xb7, 0xd3, 0x9a, 0x4e, 0xf3, 0x60, 0xf5, 0xee, 0x77, 0x63, 0x6f,
+	0x84, 0x07, 0x67, 0x89, 0xa2, 0xed, 0xbc, 0x0f, 0x2b, 0xc7, 0x13, 0x54, 0x5c, 0x34, 0x7b, 0x65,
+	0x29, 0xda, 0xea, 0xf7, 0x01, 0x4a, 0x16, 0xd2, 0x59, 0xa0, 0x7a, 0xe1, 0xee, 0x3c, 0x7e, 0x66,
+	0x95, 0x9e, 0x3c, 0xb3, 0x4a, 0x2f, 0x9e, 0x59, 0xc6, 0xb7, 0x13, 0xcb, 0xf8, 0x65, 0x62, 0x19,
+	0x8f, 0x26, 0x96, 0xf1, 0x78, 0x62, 0x19, 0x7f, 0x4d, 0x2c, 0xe3, 0xef, 0x89, 0x55, 0x7a, 0x31,
+	0xb1, 0x8c, 0x1f, 0x9e, 0x5b, 0xa5, 0xc7, 0xcf, 0xad, 0xd2, 0x93, 0xe7, 0x56, 0xe9, 0xeb, 0x65,
+	0x64, 0x1b, 0xfa, 0x9e, 0x17, 0xf0, 0x07, 0x4c, 0xf0, 0x6e, 0x1d, 0xef, 0xee, 0xd5, 0x7f, 0x02,
+	0x00, 0x00, 0xff, 0xff, 0x00, 0x57, 0xe0, 0xcb, 0xbd, 0x0a, 0x00, 0x00,
 }
 
 func (this *PrometheusRangeQueryRequest) Equal(that interface{}) bool {
@@ -1175,6 +1215,14 @@ func (this *PrometheusResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *PrometheusData) Equal(that interface{}) bool {
@@ -1356,6 +1404,9 @@ func (this *Options) Equal(that interface{}) bool {
 	if this.InstantSplitInterval != that1.InstantSplitInterval {
 		return false
 	}
+	if this.CacheLookupDisabled != that1.CacheLookupDisabled {
+		return false
+	}
 	return true
 }
 func (this *Hints) Equal(that interface{}) bool {
@@ -1389,6 +1440,9 @@ func (this *Hints) Equal(that interface{}) bool {
 	} else if !this.CardinalityEstimate.Equal(that1.CardinalityEstimate) {
 		return false
 	}
+	if this.ObservedShardedQueries != that1.ObservedShardedQueries {
+		return false
+	}
 	return true
 }
 func (this *Hints_EstimatedSeriesCount) Equal(that interface{}) bool {
@@ -1437,6 +1491,9 @@ func (this *QueryStatistics) Equal(that interface{}) bool {
 	if this.EstimatedSeriesCount != that1.EstimatedSeriesCount {
 		return false
 	}
+	if this.ShardedQueries != that1.ShardedQueries {
+		return false
+	}
 	return true
 }
 func (this *CachedHTTPResponse) Equal(that interface{}) bool {
@@ -1556,7 +1613,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&querymiddleware.PrometheusResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	if this.Data != nil {
@@ -1567,6 +1624,7 @@ func (this *PrometheusResponse) GoString() string {
 	if this.Headers != nil {
 		s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
 	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1648,13 +1706,14 @@ func (this *Options) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&querymiddleware.Options{")
 	s = append(s, "CacheDisabled: "+fmt.Sprintf("%#v", this.CacheDisabled)+",\n")
 	s = append(s, "ShardingDisabled: "+fmt.Sprintf("%#v", this.ShardingDisabled)+",\n")
 	s = append(s, "TotalShards: "+fmt.Sprintf("%#v", this.TotalShards)+",\n")
 	s = append(s, "InstantSplitDisabled: "+fmt.Sprintf("%#v", this.InstantSplitDisabled)+",\n")
 	s = append(s, "InstantSplitInterval: "+fmt.Sprintf("%#v", this.InstantSplitInterval)+",\n")
+	s = append(s, "CacheLookupDisabled: "+fmt.Sprintf("%#v", this.CacheLookupDisabled)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1662,12 +1721,13 @@ func (this *Hints) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&querymiddleware.Hints{")
 	s = append(s, "TotalQueries: "+fmt.Sprintf("%#v", this.TotalQueries)+",\n")
 	if this.CardinalityEstimate != nil {
 		s = append(s, "CardinalityEstimate: "+fmt.Sprintf("%#v", this.CardinalityEstimate)+",\n")
 	}
+	s = append(s, "ObservedShardedQueries: "+fmt.Sprintf("%#v", this.ObservedShardedQueries)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1683,9 +1743,10 @@ func (this *QueryStatistics) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&querymiddleware.QueryStatistics{")
 	s = append(s, "EstimatedSeriesCount: "+fmt.Sprintf("%#v", this.EstimatedSeriesCount)+",\n")
+	s = append(s, "ShardedQueries: "+fmt.Sprintf("%#v", this.ShardedQueries)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1938,6 +1999,15 @@ func (m *PrometheusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintModel(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
 	if len(m.Headers) > 0 {
 		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -2218,6 +2288,16 @@ func (m *Options) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.CacheLookupDisabled {
+		i--
+		if m.CacheLookupDisabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.InstantSplitInterval != 0 {
 		i = encodeVarintModel(dAtA, i, uint64(m.InstantSplitInterval))
 		i--
@@ -2281,6 +2361,11 @@ func (m *Hints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ObservedShardedQueries != 0 {
+		i = encodeVarintModel(dAtA, i, uint64(m.ObservedShardedQueries))
+		i--
+		dAtA[i] = 0x18
+	}
 	if m.CardinalityEstimate != nil {
 		{
 			size := m.CardinalityEstimate.Size()
@@ -2329,6 +2414,11 @@ func (m *QueryStatistics) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ShardedQueries != 0 {
+		i = encodeVarintModel(dAtA, i, uint64(m.ShardedQueries))
+		i--
+		dAtA[i] = 0x10
+	}
 	if m.EstimatedSeriesCount != 0 {
 		i = encodeVarintModel(dAtA, i, uint64(m.EstimatedSeriesCount))
 		i--
@@ -2554,6 +2644,12 @@ func (m *PrometheusResponse) Size() (n int) {
 			n += 1 + l + sovModel(uint64(l))
 		}
 	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovModel(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -2669,6 +2765,9 @@ func (m *Options) Size() (n int) {
 	if m.InstantSplitInterval != 0 {
 		n += 1 + sovModel(uint64(m.InstantSplitInterval))
 	}
+	if m.CacheLookupDisabled {
+		n += 2
+	}
 	return n
 }
 
@@ -2684,6 +2783,9 @@ func (m *Hints) Size() (n int) {
 	if m.CardinalityEstimate != nil {
 		n += m.CardinalityEstimate.Size()
 	}
+	if m.ObservedShardedQueries != 0 {
+		n += 1 + sovModel(uint64(m.ObservedShardedQueries))
+	}
 	return n
 }
 
@@ -2705,6 +2807,9 @@ func (m *QueryStatistics) Size() (n int) {
 	if m.EstimatedSeriesCount != 0 {
 		n += 1 + sovModel(uint64(m.EstimatedSeriesCount))
 	}
+	if m.ShardedQueries != 0 {
+		n += 1 + sovModel(uint64(m.ShardedQueries))
+	}
 	return n
 }
 
@@ -2816,6 +2921,7 @@ func (this *PrometheusResponse) String() string {
 		`ErrorType:` + fmt.Sprintf("%v", this.ErrorType) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
 		`Headers:` + repeatedStringForHeaders + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2898,6 +3004,7 @@ func (this *Options) String() string {
 		`TotalShards:` + fmt.Sprintf("%v", this.TotalShards) + `,`,
 		`InstantSplitDisabled:` + fmt.Sprintf("%v", this.InstantSplitDisabled) + `,`,
 		`InstantSplitInterval:` + fmt.Sprintf("%v", this.InstantSplitInterval) + `,`,
+		`CacheLookupDisabled:` + fmt.Sprintf("%v", this.CacheLookupDisabled) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2909,6 +3016,7 @@ func (this *Hints) String() string {
 	s := strings.Join([]string{`&Hints{`,
 		`TotalQueries:` + fmt.Sprintf("%v", this.TotalQueries) + `,`,
 		`CardinalityEstimate:` + fmt.Sprintf("%v", this.CardinalityEstimate) + `,`,
+		`ObservedShardedQueries:` + fmt.Sprintf("%v", this.ObservedShardedQueries) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2929,6 +3037,7 @@ func (this *QueryStatistics) String() string {
 	}
 	s := strings.Join([]string{`&QueryStatistics{`,
 		`EstimatedSeriesCount:` + fmt.Sprintf("%v", this.EstimatedSeriesCount) + `,`,
+		`ShardedQueries:` + fmt.Sprintf("%v", this.ShardedQueries) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3801,6 +3910,38 @@ func (m *PrometheusResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -4523,6 +4664,26 @@ func (m *Options) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CacheLookupDisabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CacheLookupDisabled = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -4615,6 +4776,25 @@ func (m *Hints) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.CardinalityEstimate = &Hints_EstimatedSeriesCount{v}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ObservedShardedQueries", wireType)
+			}
+			m.ObservedShardedQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ObservedShardedQueries |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -4687,6 +4867,25 @@ func (m *QueryStatistics) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardedQueries", wireType)
+			}
+			m.ShardedQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardedQueries |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -92,6 +92,19 @@ func (q *PrometheusRangeQueryRequest) WithEstimatedSeriesCountHint(count uint64)
 	return &newRequest
 }
 
+// WithObservedShardedQueriesHint clones the current `PrometheusRangeQueryRequest`
+// with an added Hint value for ObservedShardedQueries.
+func (q *PrometheusRangeQueryRequest) WithObservedShardedQueriesHint(count uint32) Request {
+	newRequest := *q
+	if newRequest.Hints == nil {
+		newRequest.Hints = &Hints{ObservedShardedQueries: count}
+	} else {
+		*newRequest.Hints = *(q.Hints)
+		newRequest.Hints.ObservedShardedQueries = count
+	}
+	return &newRequest
+}
+
 // LogToSpan logs the current `PrometheusRangeQueryRequest` parameters to the specified span.
 func (q *PrometheusRangeQueryRequest) LogToSpan(sp opentracing.Span) {
 	sp.LogFields(
@@ -156,6 +169,17 @@ func (r *PrometheusInstantQueryRequest) WithEstimatedSeriesCountHint(count uint6
 	return &newRequest
 }
 
+func (r *PrometheusInstantQueryRequest) WithObservedShardedQueriesHint(count uint32) Request {
+	newRequest := *r
+	if newRequest.Hints == nil {
+		newRequest.Hints = &Hints{ObservedShardedQueries: count}
+	} else {
+		*newRequest.Hints = *(r.Hints)
+		newRequest.Hints.ObservedShardedQueries = count
+	}
+	return &newRequest
+}
+
 func (r *PrometheusInstantQueryRequest) LogToSpan(sp opentracing.Span) {
 	sp.LogFields(
 		otlog.String("query", r.GetQuery()),
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+const (
+	passthroughCachePrefix = "pt:"
+
+	// PassthroughURLHeader is the header annotating the responses served by the tenant's passthrough URL.
+	PassthroughURLHeader = "X-Mimir-Passthrough-Url"
+
+	// prometheusAPIPath is the part of the path of the Prometheus HTTP API requests appended to the passthrough URL.
+	prometheusAPIPath = "/api/v1/"
+)
+
+type passthroughContextKey int
+
+const unsupportedPathKey passthroughContextKey = 0
+
+// PassthroughHandler marks the requests served by next as requests to Prometheus HTTP API paths not implemented by
+// Mimir, which the query-frontend proxies to the tenant's passthrough URL, if any.
+func PassthroughHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), unsupportedPathKey, true)))
+	})
+}
+
+func isUnsupportedPathRequest(r *http.Request) bool {
+	unsupported, _ := r.Context().Value(unsupportedPathKey).(bool)
+	return unsupported
+}
+
+// passthroughRoundTripper proxies the requests to Prometheus HTTP API paths not implemented by Mimir to the tenant's
+// passthrough URL, to ease the migrations from a system still holding some of the data. The responses to the GET
+// requests are cached, if the results cache is enabled.
+type passthroughRoundTripper struct {
+	client  *http.Client
+	cache   cache.Cache
+	limits  Limits
+	metrics *resultsCacheMetrics
+	logger  log.Logger
+}
+
+func newPassthroughRoundTripper(client *http.Client, cache cache.Cache, limits Limits, logger log.Logger, reg prometheus.Registerer) http.RoundTripper {
+	return &passthroughRoundTripper{
+		client:  client,
+		cache:   cache,
+		limits:  limits,
+		metrics: newResultsCacheMetrics("passthrough", reg),
+		logger:  logger,
+	}
+}
+
+func (p *passthroughRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(r.Context(), p.logger, "passthroughRoundTripper.RoundTrip")
+	defer spanLog.Finish()
+
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	baseURL := p.limits.PassthroughURL(tenantID)
+	idx := strings.Index(r.URL.Path, prometheusAPIPath)
+	if baseURL == "" || idx < 0 {
+		return nil, apierror.Newf(apierror.TypeNotFound, "the %s path is not supported", r.URL.Path)
+	}
+
+	target, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, apierror.Newf(apierror.TypeInternal, "invalid passthrough URL: %v", err)
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path[idx:]
+	target.RawQuery = r.URL.RawQuery
+
+	// Only the idempotent requests are cached.
+	cacheTTL := time.Duration(0)
+	if p.cache != nil && r.Method == http.MethodGet && !decodeCacheDisabledOption(r) {
+		cacheTTL = p.limits.ResultsCacheTTLForPassthrough(tenantID)
+	}
+
+	var cacheKey, hashedCacheKey string
+	if cacheTTL > 0 {
+		p.metrics.cacheRequests.Inc()
+		cacheKey = fmt.Sprintf("%s:%s", tenantID, target.String())
+		hashedCacheKey = passthroughCachePrefix + cacheHashKey(cacheKey)
+
+		if res := p.fetchCachedResponse(ctx, cacheKey, hashedCacheKey); res != nil {
+			p.metrics.cacheHits.Inc()
+			level.Debug(spanLog).Log("msg", "passthrough response fetched from the cache")
+			return res, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, target.String(), r.Body)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeInternal, err.Error())
+	}
+	// The tenant's credentials aren't forwarded: the credentials of the downstream endpoint, if any, are in its URL.
+	for _, name := range []string{"Accept", "Content-Type", "User-Agent"} {
+		if value := r.Header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		return nil, apierror.Newf(apierror.TypeUnavailable, "the request to the passthrough URL failed: %v", err)
+	}
+	res.Header.Set(PassthroughURLHeader, target.Redacted())
+
+	if cacheTTL > 0 && res.StatusCode >= 200 && res.StatusCode < 300 {
+		cachedRes, err := EncodeCachedHTTPResponse(cacheKey, res)
+		if err != nil {
+			level.Warn(spanLog).Log("msg", "failed to read passthrough response before storing it to cache", "err", err)
+			return nil, err
+		}
+		p.storeCachedResponse(cachedRes, hashedCacheKey, cacheTTL)
+	}
+
+	return res, nil
+}
+
+func (p *passthroughRoundTripper) fetchCachedResponse(ctx context.Context, cacheKey, hashedCacheKey string) *http.Response {
+	cacheHits := p.cache.Fetch(ctx, []string{hashedCacheKey})
+	if cacheHits[hashedCacheKey] == nil {
+		return nil
+	}
+
+	cachedRes := &CachedHTTPResponse{}
+	if err := cachedRes.Unmarshal(cacheHits[hashedCacheKey]); err != nil {
+		level.Warn(p.logger).Log("msg", "failed to decode cached passthrough response", "cache_key", hashedCacheKey, "err", err)
+		return nil
+	}
+
+	// Ensure no cache key collision.
+	if cachedRes.GetCacheKey() != cacheKey {
+		level.Warn(p.logger).Log("msg", "skipped cached passthrough response because a cache key collision has been found", "cache_key", hashedCacheKey)
+		return nil
+	}
+
+	return DecodeCachedHTTPResponse(cachedRes)
+}
+
+func (p *passthroughRoundTripper) storeCachedResponse(cachedRes *CachedHTTPResponse, hashedCacheKey string, cacheTTL time.Duration) {
+	encoded, err := cachedRes.Marshal()
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to encode cached passthrough response", "err", err)
+		return
+	}
+
+	p.cache.StoreAsync(map[string][]byte{hashedCacheKey: encoded}, cacheTTL)
+}
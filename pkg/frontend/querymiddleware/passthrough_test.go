@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/util/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+)
+
+func TestPassthroughHandler(t *testing.T) {
+	var marked bool
+	h := PassthroughHandler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		marked = isUnsupportedPathRequest(r)
+	}))
+
+	assert.False(t, isUnsupportedPathRequest(httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/targets", nil)))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/targets", nil))
+	assert.True(t, marked)
+}
+
+func TestPassthroughRoundTripper(t *testing.T) {
+	const userID = "user-1"
+
+	type downstreamRequest struct {
+		method, path, query, body, username string
+	}
+
+	tests := map[string]struct {
+		method                string
+		path                  string
+		body                  string
+		reqHeader             http.Header
+		passthroughPath       string
+		withoutPassthroughURL bool
+		withoutCache          bool
+		cacheTTL              time.Duration
+		downstreamStatusCode  int
+		expectedErr           error
+		expectedDownstream    []downstreamRequest
+	}{
+		"should fail if the tenant has no passthrough URL": {
+			method:                http.MethodGet,
+			path:                  "/prometheus/api/v1/targets",
+			withoutPassthroughURL: true,
+			expectedErr:           apierror.New(apierror.TypeNotFound, "the /prometheus/api/v1/targets path is not supported"),
+		},
+		"should proxy the request to the passthrough URL": {
+			method:          http.MethodGet,
+			path:            "/prometheus/api/v1/targets",
+			passthroughPath: "/remote/",
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodGet, path: "/remote/api/v1/targets", query: "state=active", username: "admin"},
+				{method: http.MethodGet, path: "/remote/api/v1/targets", query: "state=active", username: "admin"},
+			},
+		},
+		"should proxy the request body": {
+			method: http.MethodPost,
+			path:   "/api/v1/targets",
+			body:   "state=active",
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodPost, path: "/api/v1/targets", query: "state=active", body: "state=active", username: "admin"},
+				{method: http.MethodPost, path: "/api/v1/targets", query: "state=active", body: "state=active", username: "admin"},
+			},
+		},
+		"should cache the response of the GET requests": {
+			method:             http.MethodGet,
+			path:               "/prometheus/api/v1/targets",
+			cacheTTL:           time.Minute,
+			expectedDownstream: []downstreamRequest{{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"}},
+		},
+		"should not cache the response of the POST requests": {
+			method:   http.MethodPost,
+			path:     "/prometheus/api/v1/targets",
+			cacheTTL: time.Minute,
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodPost, path: "/api/v1/targets", query: "state=active", username: "admin"},
+				{method: http.MethodPost, path: "/api/v1/targets", query: "state=active", username: "admin"},
+			},
+		},
+		"should not cache the error responses": {
+			method:               http.MethodGet,
+			path:                 "/prometheus/api/v1/targets",
+			cacheTTL:             time.Minute,
+			downstreamStatusCode: http.StatusServiceUnavailable,
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+			},
+		},
+		"should not cache the response if the request disables the cache": {
+			method:    http.MethodGet,
+			path:      "/prometheus/api/v1/targets",
+			reqHeader: http.Header{cacheControlHeader: []string{noStoreValue}},
+			cacheTTL:  time.Minute,
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+			},
+		},
+		"should not cache the response if the results cache is disabled": {
+			method:       http.MethodGet,
+			path:         "/prometheus/api/v1/targets",
+			withoutCache: true,
+			cacheTTL:     time.Minute,
+			expectedDownstream: []downstreamRequest{
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+				{method: http.MethodGet, path: "/api/v1/targets", query: "state=active", username: "admin"},
+			},
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			var downstreamRequests []downstreamRequest
+			downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				username, _, _ := r.BasicAuth()
+				downstreamRequests = append(downstreamRequests, downstreamRequest{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, body: string(body), username: username})
+
+				w.Header().Set("Content-Type", "application/json")
+				if testData.downstreamStatusCode != 0 {
+					w.WriteHeader(testData.downstreamStatusCode)
+				}
+				_, _ = w.Write([]byte(`{"status":"success"}`))
+			}))
+			t.Cleanup(downstream.Close)
+
+			limits := mockLimits{resultsCacheTTLForPassthrough: testData.cacheTTL}
+			if !testData.withoutPassthroughURL {
+				limits.passthroughURL = strings.Replace(downstream.URL, "http://", "http://admin:secret@", 1) + testData.passthroughPath
+			}
+
+			var c cache.Cache
+			if !testData.withoutCache {
+				c = cache.NewMockCache()
+			}
+			rt := newPassthroughRoundTripper(downstream.Client(), c, limits, testutil.NewLogger(t), prometheus.NewPedanticRegistry())
+
+			// Send the same request twice, to check whether the response is cached.
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(testData.method, testData.path+"?state=active", strings.NewReader(testData.body))
+				for name, values := range testData.reqHeader {
+					req.Header[name] = values
+				}
+				req = req.WithContext(user.InjectOrgID(context.Background(), userID))
+
+				res, err := rt.RoundTrip(req)
+				if testData.expectedErr != nil {
+					require.Equal(t, testData.expectedErr, err)
+					continue
+				}
+				require.NoError(t, err)
+
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, `{"status":"success"}`, string(body))
+				assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+				assert.Contains(t, res.Header.Get(PassthroughURLHeader), "admin:xxxxx@")
+				assert.NotContains(t, res.Header.Get(PassthroughURLHeader), "secret")
+			}
+
+			assert.Equal(t, testData.expectedDownstream, downstreamRequests)
+		})
+	}
+}
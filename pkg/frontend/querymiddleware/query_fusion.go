@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// queryFusionMiddleware is a Middleware that, for the tenants which opted in, aligns the start and end
+// of range queries to their step, and fuses concurrent queries which are identical once aligned, so that
+// they're executed once and share the results. Queries issued by many clients auto-refreshing the same
+// dashboard usually only differ by a jitter of the start and end smaller than the step.
+type queryFusionMiddleware struct {
+	next   Handler
+	limits Limits
+	logger log.Logger
+
+	inflightMx sync.Mutex
+	inflight   map[string]*fusedQuery
+
+	metrics queryFusionMetrics
+}
+
+// fusedQuery is a query being executed on behalf of all the identical queries received while it's running.
+type fusedQuery struct {
+	done chan struct{}
+	res  Response
+	err  error
+}
+
+type queryFusionMetrics struct {
+	alignedQueries prometheus.Counter
+	fusedQueries   prometheus.Counter
+}
+
+func newQueryFusionMetrics(registerer prometheus.Registerer) queryFusionMetrics {
+	return queryFusionMetrics{
+		alignedQueries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_fusion_aligned_queries_total",
+			Help: "Total number of step-misaligned range queries whose start and end have been aligned to the step to be fused.",
+		}),
+		fusedQueries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_fusion_fused_queries_total",
+			Help: "Total number of range queries which shared the execution and results of a concurrent identical query.",
+		}),
+	}
+}
+
+// newQueryFusionMiddleware makes a new queryFusionMiddleware.
+func newQueryFusionMiddleware(limits Limits, logger log.Logger, registerer prometheus.Registerer) Middleware {
+	metrics := newQueryFusionMetrics(registerer)
+
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &queryFusionMiddleware{
+			next:     next,
+			limits:   limits,
+			logger:   logger,
+			inflight: map[string]*fusedQuery{},
+			metrics:  metrics,
+		}
+	})
+}
+
+func (q *queryFusionMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	if req.GetStep() <= 0 || !validation.AllTrueBooleansPerTenant(tenantIDs, q.limits.FuseStepMisalignedQueries) {
+		return q.next.Do(ctx, req)
+	}
+
+	if !isRequestStepAligned(req) {
+		start := (req.GetStart() / req.GetStep()) * req.GetStep()
+		end := (req.GetEnd() / req.GetStep()) * req.GetStep()
+		req = req.WithStartEnd(start, end)
+		q.metrics.alignedQueries.Inc()
+	}
+
+	key := fusedQueryKey(tenant.JoinTenantIDs(tenantIDs), req)
+
+	q.inflightMx.Lock()
+	if query, ok := q.inflight[key]; ok {
+		q.inflightMx.Unlock()
+		return q.wait(ctx, req, query)
+	}
+	query := &fusedQuery{done: make(chan struct{})}
+	q.inflight[key] = query
+	q.inflightMx.Unlock()
+
+	query.res, query.err = q.next.Do(ctx, req)
+
+	q.inflightMx.Lock()
+	delete(q.inflight, key)
+	q.inflightMx.Unlock()
+	close(query.done)
+
+	return query.res, query.err
+}
+
+// wait waits until the input query, started by another request, completes and returns its results.
+func (q *queryFusionMiddleware) wait(ctx context.Context, req Request, query *fusedQuery) (Response, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-query.done:
+	}
+
+	// The execution of the query may have been canceled because the client which started it went away.
+	// In this case, the query is executed again on behalf of this request.
+	if errors.Is(query.err, context.Canceled) && ctx.Err() == nil {
+		spanLog := spanlogger.FromContext(ctx, q.logger)
+		level.Debug(spanLog).Log("msg", "the fused query has been canceled, executing it again", "query", req.GetQuery())
+		return q.next.Do(ctx, req)
+	}
+
+	q.metrics.fusedQueries.Inc()
+	return query.res, query.err
+}
+
+// fusedQueryKey returns the key identifying the queries which can be fused with the input one.
+func fusedQueryKey(tenantID string, req Request) string {
+	options := req.GetOptions()
+	return fmt.Sprintf("%s:%d:%d:%d:%s:%s", tenantID, req.GetStart(), req.GetEnd(), req.GetStep(), options.String(), req.GetQuery())
+}
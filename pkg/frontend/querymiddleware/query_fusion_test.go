@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+func TestQueryFusionMiddleware_ShouldAlignQueriesOnlyIfEnabledForAllTenants(t *testing.T) {
+	const step = int64(30_000)
+
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	tests := map[string]struct {
+		tenants       string
+		limits        Limits
+		expectedStart int64
+		expectedEnd   int64
+	}{
+		"disabled for the tenant": {
+			tenants:       "user-1",
+			limits:        mockLimits{},
+			expectedStart: 10*step + 100,
+			expectedEnd:   20*step + 200,
+		},
+		"enabled for the tenant": {
+			tenants:       "user-1",
+			limits:        mockLimits{fuseStepMisalignedQueries: true},
+			expectedStart: 10 * step,
+			expectedEnd:   20 * step,
+		},
+		"enabled only for some of the tenants": {
+			tenants: "user-1|user-2",
+			limits: multiTenantMockLimits{byTenant: map[string]mockLimits{
+				"user-1": {fuseStepMisalignedQueries: true},
+				"user-2": {},
+			}},
+			expectedStart: 10*step + 100,
+			expectedEnd:   20*step + 200,
+		},
+		"enabled for all the tenants": {
+			tenants: "user-1|user-2",
+			limits: multiTenantMockLimits{byTenant: map[string]mockLimits{
+				"user-1": {fuseStepMisalignedQueries: true},
+				"user-2": {fuseStepMisalignedQueries: true},
+			}},
+			expectedStart: 10 * step,
+			expectedEnd:   20 * step,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actual Request
+			next := HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				actual = req
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			})
+
+			mw := newQueryFusionMiddleware(testData.limits, log.NewNopLogger(), prometheus.NewPedanticRegistry()).Wrap(next)
+			req := &PrometheusRangeQueryRequest{Start: 10*step + 100, End: 20*step + 200, Step: step, Query: "up"}
+
+			_, err := mw.Do(user.InjectOrgID(context.Background(), testData.tenants), req)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedStart, actual.GetStart())
+			assert.Equal(t, testData.expectedEnd, actual.GetEnd())
+		})
+	}
+}
+
+func TestQueryFusionMiddleware_ShouldFuseConcurrentQueriesDifferingByJitterWithinStep(t *testing.T) {
+	const (
+		step       = int64(30_000)
+		numQueries = 5
+	)
+
+	var (
+		calls    = atomic.NewInt32(0)
+		started  = make(chan struct{})
+		release  = make(chan struct{})
+		expected = &PrometheusResponse{Status: statusSuccess}
+		reg      = prometheus.NewPedanticRegistry()
+	)
+
+	next := HandlerFunc(func(context.Context, Request) (Response, error) {
+		if calls.Inc() == 1 {
+			close(started)
+		}
+		<-release
+		return expected, nil
+	})
+
+	mw := newQueryFusionMiddleware(mockLimits{fuseStepMisalignedQueries: true}, log.NewNopLogger(), reg).Wrap(next).(*queryFusionMiddleware)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	// Start the first query, and wait until it's running.
+	wg := sync.WaitGroup{}
+	responses := make([]Response, numQueries)
+	run := func(idx int) {
+		defer wg.Done()
+
+		jitter := int64(idx) * 1000
+		res, err := mw.Do(ctx, &PrometheusRangeQueryRequest{Start: 10*step + jitter, End: 20*step + jitter, Step: step, Query: "up"})
+		require.NoError(t, err)
+		responses[idx] = res
+	}
+
+	wg.Add(1)
+	go run(0)
+	<-started
+
+	// Start the other queries, while the first one is running.
+	for i := 1; i < numQueries; i++ {
+		wg.Add(1)
+		go run(i)
+	}
+
+	// Wait until all the other queries have been aligned, and give them time to wait for the first one.
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(mw.metrics.alignedQueries) == numQueries-1
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, res := range responses {
+		assert.Same(t, expected, res)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_fusion_aligned_queries_total Total number of step-misaligned range queries whose start and end have been aligned to the step to be fused.
+		# TYPE cortex_frontend_query_fusion_aligned_queries_total counter
+		cortex_frontend_query_fusion_aligned_queries_total 4
+
+		# HELP cortex_frontend_query_fusion_fused_queries_total Total number of range queries which shared the execution and results of a concurrent identical query.
+		# TYPE cortex_frontend_query_fusion_fused_queries_total counter
+		cortex_frontend_query_fusion_fused_queries_total 4
+	`)))
+}
+
+func TestQueryFusionMiddleware_ShouldNotFuseDifferentQueries(t *testing.T) {
+	const step = int64(30_000)
+
+	var (
+		calls   = atomic.NewInt32(0)
+		release = make(chan struct{})
+	)
+
+	next := HandlerFunc(func(context.Context, Request) (Response, error) {
+		calls.Inc()
+		<-release
+		return &PrometheusResponse{Status: statusSuccess}, nil
+	})
+
+	mw := newQueryFusionMiddleware(mockLimits{fuseStepMisalignedQueries: true}, log.NewNopLogger(), prometheus.NewPedanticRegistry()).Wrap(next)
+
+	reqs := []struct {
+		tenant string
+		req    Request
+	}{
+		{tenant: "user-1", req: &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: step, Query: "up"}},
+		{tenant: "user-2", req: &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: step, Query: "up"}},
+		{tenant: "user-1", req: &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: step, Query: "down"}},
+		{tenant: "user-1", req: &PrometheusRangeQueryRequest{Start: 11 * step, End: 21 * step, Step: step, Query: "up"}},
+		{tenant: "user-1", req: &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: 2 * step, Query: "up"}},
+		{tenant: "user-1", req: &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: step, Query: "up", Options: Options{CacheDisabled: true}}},
+	}
+
+	wg := sync.WaitGroup{}
+	for _, r := range reqs {
+		wg.Add(1)
+		go func(tenant string, req Request) {
+			defer wg.Done()
+			_, err := mw.Do(user.InjectOrgID(context.Background(), tenant), req)
+			require.NoError(t, err)
+		}(r.tenant, r.req)
+	}
+
+	require.Eventually(t, func() bool {
+		return calls.Load() == int32(len(reqs))
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	wg.Wait()
+}
+
+func TestQueryFusionMiddleware_ShouldExecuteQueryAgainIfTheFusedQueryHasBeenCanceled(t *testing.T) {
+	const step = int64(30_000)
+
+	var (
+		calls   = atomic.NewInt32(0)
+		started = make(chan struct{})
+	)
+
+	next := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		if calls.Inc() == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &PrometheusResponse{Status: statusSuccess}, nil
+	})
+
+	mw := newQueryFusionMiddleware(mockLimits{fuseStepMisalignedQueries: true}, log.NewNopLogger(), prometheus.NewPedanticRegistry()).Wrap(next)
+	req := &PrometheusRangeQueryRequest{Start: 10 * step, End: 20 * step, Step: step, Query: "up"}
+
+	firstCtx, cancelFirst := context.WithCancel(user.InjectOrgID(context.Background(), "user-1"))
+	firstErr := make(chan error)
+	go func() {
+		_, err := mw.Do(firstCtx, req)
+		firstErr <- err
+	}()
+	<-started
+
+	secondRes := make(chan Response)
+	go func() {
+		res, err := mw.Do(user.InjectOrgID(context.Background(), "user-1"), req)
+		require.NoError(t, err)
+		secondRes <- res
+	}()
+
+	// Give the second query time to wait for the first one, then cancel the first one.
+	time.Sleep(100 * time.Millisecond)
+	cancelFirst()
+
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.Equal(t, &PrometheusResponse{Status: statusSuccess}, <-secondRes)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -33,7 +33,11 @@ import (
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
-const shardingTimeout = 10 * time.Second
+const (
+	shardingTimeout = 10 * time.Second
+
+	approximateTopKWarning = "the results of topk or bottomk aggregations are approximate, because the query has been sharded"
+)
 
 type querySharding struct {
 	limit Limits
@@ -51,6 +55,7 @@ type queryShardingMetrics struct {
 	shardingSuccesses      prometheus.Counter
 	shardedQueries         prometheus.Counter
 	shardedQueriesPerQuery prometheus.Histogram
+	approximatedQueries    prometheus.Counter
 }
 
 // newQueryShardingMiddleware creates a middleware that will split queries by shard.
@@ -84,6 +89,10 @@ func newQueryShardingMiddleware(
 			Help:    "Number of sharded queries a single query has been rewritten to.",
 			Buckets: prometheus.ExponentialBuckets(2, 2, 10),
 		}),
+		approximatedQueries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_sharding_approximated_queries_total",
+			Help: "Total number of queries the query-frontend rewritten in a shardable way computing approximate results.",
+		}),
 	}
 	return MiddlewareFunc(func(next Handler) Handler {
 		return &querySharding{
@@ -118,7 +127,8 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	}
 
 	s.shardingAttempts.Inc()
-	shardedQuery, shardingStats, err := s.shardQuery(ctx, r.GetQuery(), totalShards)
+	topkOversamplingFactor := topkOversamplingFactorPerTenant(tenantIDs, s.limit)
+	shardedQuery, shardingStats, err := s.shardQuery(ctx, r.GetQuery(), totalShards, topkOversamplingFactor)
 
 	// If an error occurred while trying to rewrite the query or the query has not been sharded,
 	// then we should fallback to execute it via queriers.
@@ -141,6 +151,12 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	s.shardedQueries.Add(float64(shardingStats.GetShardedQueries()))
 	s.shardedQueriesPerQuery.Observe(float64(shardingStats.GetShardedQueries()))
 
+	var warnings []string
+	if shardingStats.IsApproximate() {
+		s.approximatedQueries.Inc()
+		warnings = append(warnings, approximateTopKWarning)
+	}
+
 	// Update query stats.
 	queryStats := stats.FromContext(ctx)
 	queryStats.AddShardedQueries(uint32(shardingStats.GetShardedQueries()))
@@ -164,7 +180,8 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 			ResultType: string(res.Value.Type()),
 			Result:     extracted,
 		},
-		Headers: shardedQueryable.getResponseHeaders(),
+		Headers:  shardedQueryable.getResponseHeaders(),
+		Warnings: warnings,
 	}, nil
 }
 
@@ -236,13 +253,14 @@ func mapEngineError(err error) error {
 
 // shardQuery attempts to rewrite the input query in a shardable way. Returns the rewritten query
 // to be executed by PromQL engine with shardedQueryable or an empty string if the input query
-// can't be sharded.
-func (s *querySharding) shardQuery(ctx context.Context, query string, totalShards int) (string, *astmapper.MapperStats, error) {
+// can't be sharded. The topk and bottomk aggregations are sharded only if topkOversamplingFactor
+// is positive.
+func (s *querySharding) shardQuery(ctx context.Context, query string, totalShards int, topkOversamplingFactor float64) (string, *astmapper.MapperStats, error) {
 	stats := astmapper.NewMapperStats()
 	ctx, cancel := context.WithTimeout(ctx, shardingTimeout)
 	defer cancel()
 
-	mapper, err := astmapper.NewSharding(ctx, totalShards, s.logger, stats)
+	mapper, err := astmapper.NewSharding(ctx, totalShards, topkOversamplingFactor, s.logger, stats)
 	if err != nil {
 		return "", nil, err
 	}
@@ -299,16 +317,37 @@ func (s *querySharding) getShardsForQuery(ctx context.Context, tenantIDs []strin
 
 	if v, ok := hints.GetCardinalityEstimate().(*Hints_EstimatedSeriesCount); ok && s.maxSeriesPerShard > 0 {
 		prevTotalShards := totalShards
+		estimatedSeriesCount := v.EstimatedSeriesCount
+
+		// If the estimate has been observed while running the query sharded, use the feedback
+		// from that execution: the series actually fetched by each sharded query, multiplied by
+		// the number of shards used at that time, is the number of series fetched by each
+		// shardable leg, which is what a single set of shards has to split.
+		if observedShardedQueries := hints.GetObservedShardedQueries(); observedShardedQueries > 0 {
+			numShardableLegs := s.getShardableLegs(ctx, r.GetQuery(), topkOversamplingFactorPerTenant(tenantIDs, s.limit))
+			observedShards := util_math.Max(1, int(observedShardedQueries)/numShardableLegs)
+			observedSeriesPerShard := estimatedSeriesCount / uint64(observedShardedQueries)
+			estimatedSeriesCount = observedSeriesPerShard * uint64(observedShards)
+
+			level.Debug(spanLog).Log(
+				"msg", "using the series per shard observed in a previous execution of the query to estimate the number of shards",
+				"observed sharded queries", observedShardedQueries,
+				"observed shards", observedShards,
+				"observed series per shard", observedSeriesPerShard,
+				"shardable legs", numShardableLegs,
+			)
+		}
+
 		// If an estimate for query cardinality is available, use it to limit the number
 		// of shards based on linear interpolation.
-		totalShards = util_math.Min(totalShards, int(v.EstimatedSeriesCount/s.maxSeriesPerShard)+1)
+		totalShards = util_math.Min(totalShards, int(estimatedSeriesCount/s.maxSeriesPerShard)+1)
 
 		if prevTotalShards != totalShards {
 			level.Debug(spanLog).Log(
 				"msg", "number of shards has been adjusted to match the estimated series count",
 				"updated total shards", totalShards,
 				"previous total shards", prevTotalShards,
-				"estimated series count", v.EstimatedSeriesCount,
+				"estimated series count", estimatedSeriesCount,
 			)
 		}
 	}
@@ -316,24 +355,7 @@ func (s *querySharding) getShardsForQuery(ctx context.Context, tenantIDs []strin
 	// If total queries is provided through hints, then we adjust the number of shards for the query
 	// based on the configured max sharded queries limit.
 	if hints != nil && hints.TotalQueries > 0 && maxShardedQueries > 0 {
-		// Calculate how many legs are shardable. To do it we use a trick: rewrite the query passing 1
-		// total shards and then we check how many sharded queries are generated. In case of any error,
-		// we just consider as if there's only 1 shardable leg (the error will be detected anyway later on).
-		//
-		// "Leg" is the terminology we use in query sharding to mention a part of the query that can be sharded.
-		// For example, look at this query:
-		// sum(metric) / count(metric)
-		//
-		// This query has 2 shardable "legs":
-		// - sum(metric)
-		// - count(metric)
-		//
-		// Calling s.shardQuery() with 1 total shards we can see how many shardable legs the query has.
-		_, shardingStats, err := s.shardQuery(ctx, r.GetQuery(), 1)
-		numShardableLegs := 1
-		if err == nil && shardingStats.GetShardedQueries() > 0 {
-			numShardableLegs = shardingStats.GetShardedQueries()
-		}
+		numShardableLegs := s.getShardableLegs(ctx, r.GetQuery(), topkOversamplingFactorPerTenant(tenantIDs, s.limit))
 
 		prevTotalShards := totalShards
 		totalShards = util_math.Max(1, util_math.Min(totalShards, (maxShardedQueries/int(hints.TotalQueries))/numShardableLegs))
@@ -387,6 +409,46 @@ func (s *querySharding) getShardsForQuery(ctx context.Context, tenantIDs []strin
 	return totalShards
 }
 
+// topkOversamplingFactorPerTenant returns the oversampling factor of the sharded topk and bottomk aggregations
+// for the given tenants. The topk and bottomk aggregations are sharded only if it's enabled for all the tenants,
+// in which case the largest factor is used, so that the results are approximated at least as well as the tenants
+// configured. Returns 0 if the topk and bottomk aggregations shouldn't be sharded.
+func topkOversamplingFactorPerTenant(tenantIDs []string, limits Limits) float64 {
+	largest := 0.0
+	for _, tenantID := range tenantIDs {
+		factor := limits.QueryShardingTopKOversamplingFactor(tenantID)
+		if factor <= 0 {
+			return 0
+		}
+		if factor > largest {
+			largest = factor
+		}
+	}
+	return largest
+}
+
+// getShardableLegs returns the number of parts of the query that can be sharded.
+func (s *querySharding) getShardableLegs(ctx context.Context, query string, topkOversamplingFactor float64) int {
+	// Calculate how many legs are shardable. To do it we use a trick: rewrite the query passing 1
+	// total shards and then we check how many sharded queries are generated. In case of any error,
+	// we just consider as if there's only 1 shardable leg (the error will be detected anyway later on).
+	//
+	// "Leg" is the terminology we use in query sharding to mention a part of the query that can be sharded.
+	// For example, look at this query:
+	// sum(metric) / count(metric)
+	//
+	// This query has 2 shardable "legs":
+	// - sum(metric)
+	// - count(metric)
+	//
+	// Calling s.shardQuery() with 1 total shards we can see how many shardable legs the query has.
+	_, shardingStats, err := s.shardQuery(ctx, query, 1, topkOversamplingFactor)
+	if err == nil && shardingStats.GetShardedQueries() > 0 {
+		return shardingStats.GetShardedQueries()
+	}
+	return 1
+}
+
 // promqlResultToSamples transforms a promql query result into a samplestream
 func promqlResultToSamples(res *promql.Result) ([]SampleStream, error) {
 	if res.Err != nil {
@@ -1050,6 +1050,123 @@ func testQueryShardingFunctionCorrectness(t *testing.T, queryable storage.Querya
 	}
 }
 
+func TestQuerySharding_TopKOversampling(t *testing.T) {
+	const numSeries = 100
+
+	series := make([]*promql.StorageSeries, 0, numSeries)
+	for i := 0; i < numSeries; i++ {
+		series = append(series, newSeries(newTestCounterLabels(i), start.Add(-lookbackDelta), end, step, factor(float64(i))))
+	}
+	queryable := storageSeriesQueryable(series)
+
+	tests := map[string]struct {
+		query                  string
+		oversamplingFactor     float64
+		expectedShardedQueries int
+		expectedApproximate    bool
+	}{
+		"topk() disabled": {
+			query:                  `topk(3, metric_counter)`,
+			oversamplingFactor:     0,
+			expectedShardedQueries: 0,
+		},
+		"topk()": {
+			query:                  `topk(3, metric_counter)`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 1,
+		},
+		"bottomk() grouping 'by'": {
+			query:                  `bottomk by(group_2) (2, rate(metric_counter[1m]))`,
+			oversamplingFactor:     2,
+			expectedShardedQueries: 1,
+		},
+		"topk(sum())": {
+			// The oversampling factor is high enough for all the groups to be selected in each shard.
+			query:                  `topk(3, sum by(group_1) (metric_counter))`,
+			oversamplingFactor:     4,
+			expectedShardedQueries: 1,
+			expectedApproximate:    true,
+		},
+		"bottomk(count())": {
+			query:                  `bottomk(1, count by(group_2) (metric_counter))`,
+			oversamplingFactor:     3,
+			expectedShardedQueries: 1,
+			expectedApproximate:    true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &PrometheusInstantQueryRequest{
+				Path:  "/query",
+				Time:  util.TimeToMillis(end),
+				Query: testData.query,
+			}
+
+			engine := newEngine()
+			downstream := &downstreamHandler{
+				engine:    engine,
+				queryable: queryable,
+			}
+
+			// Run the query without sharding.
+			expectedRes, err := downstream.Do(context.Background(), req)
+			require.NoError(t, err)
+			expectedPrometheusRes := expectedRes.(*PrometheusResponse)
+			sort.Sort(byLabels(expectedPrometheusRes.Data.Result))
+			require.NotEmpty(t, expectedPrometheusRes.Data.Result)
+
+			const numShards = 4
+			reg := prometheus.NewPedanticRegistry()
+			shardingware := newQueryShardingMiddleware(
+				log.NewNopLogger(),
+				engine,
+				mockLimits{totalShards: numShards, topkOversamplingFactor: testData.oversamplingFactor},
+				0,
+				reg,
+			)
+
+			// Run the query with sharding.
+			shardedRes, err := shardingware.Wrap(downstream).Do(user.InjectOrgID(context.Background(), "test"), req)
+			require.NoError(t, err)
+			shardedPrometheusRes := shardedRes.(*PrometheusResponse)
+			sort.Sort(byLabels(shardedPrometheusRes.Data.Result))
+			approximatelyEquals(t, expectedPrometheusRes, shardedPrometheusRes)
+
+			expectedApproximated := 0
+			if testData.expectedApproximate {
+				expectedApproximated = 1
+				assert.Equal(t, []string{approximateTopKWarning}, shardedPrometheusRes.Warnings)
+			} else {
+				assert.Empty(t, shardedPrometheusRes.Warnings)
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_frontend_sharded_queries_total Total number of sharded queries.
+				# TYPE cortex_frontend_sharded_queries_total counter
+				cortex_frontend_sharded_queries_total %d
+				# HELP cortex_frontend_query_sharding_approximated_queries_total Total number of queries the query-frontend rewritten in a shardable way computing approximate results.
+				# TYPE cortex_frontend_query_sharding_approximated_queries_total counter
+				cortex_frontend_query_sharding_approximated_queries_total %d
+			`, testData.expectedShardedQueries*numShards, expectedApproximated)),
+				"cortex_frontend_sharded_queries_total",
+				"cortex_frontend_query_sharding_approximated_queries_total"))
+		})
+	}
+}
+
+func TestTopKOversamplingFactorPerTenant(t *testing.T) {
+	limits := multiTenantMockLimits{byTenant: map[string]mockLimits{
+		"tenant-a": {topkOversamplingFactor: 2},
+		"tenant-b": {topkOversamplingFactor: 3},
+		"tenant-c": {topkOversamplingFactor: 0},
+	}}
+
+	assert.Equal(t, 2.0, topkOversamplingFactorPerTenant([]string{"tenant-a"}, limits))
+	assert.Equal(t, 3.0, topkOversamplingFactorPerTenant([]string{"tenant-a", "tenant-b"}, limits))
+	assert.Equal(t, 0.0, topkOversamplingFactorPerTenant([]string{"tenant-a", "tenant-c"}, limits))
+}
+
 func TestQuerySharding_ShouldSkipShardingViaOption(t *testing.T) {
 	req := &PrometheusRangeQueryRequest{
 		Path:  "/query_range",
@@ -1566,6 +1683,10 @@ func TestQuerySharding_ShouldUseCardinalityEstimate(t *testing.T) {
 		Time:  util.TimeToMillis(start),
 		Query: "sum by (foo) (rate(bar{}[1m]))", // shardable query.
 	}
+	twoLegsReq := &PrometheusInstantQueryRequest{
+		Time:  util.TimeToMillis(start),
+		Query: "sum(rate(bar{}[1m])) / count(rate(bar{}[1m]))", // shardable query with 2 legs.
+	}
 
 	tests := []struct {
 		name          string
@@ -1582,6 +1703,27 @@ func TestQuerySharding_ShouldUseCardinalityEstimate(t *testing.T) {
 			req.WithEstimatedSeriesCountHint(29_000),
 			3,
 		},
+		{
+			"instant query with observed sharded queries",
+			req.WithEstimatedSeriesCountHint(29_000).WithObservedShardedQueriesHint(4),
+			3,
+		},
+		{
+			"two legs query",
+			twoLegsReq.WithEstimatedSeriesCountHint(58_000),
+			12,
+		},
+		{
+			// 58k series fetched by 6 sharded queries (3 shards per leg) means each leg fetched 29k series.
+			"two legs query with observed sharded queries",
+			twoLegsReq.WithEstimatedSeriesCountHint(58_000).WithObservedShardedQueriesHint(6),
+			6,
+		},
+		{
+			"two legs query with observed sharded queries when the query was run with more shards",
+			twoLegsReq.WithEstimatedSeriesCountHint(58_000).WithObservedShardedQueriesHint(32),
+			6,
+		},
 		{
 			"no hints",
 			req,
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/common/model"
+	"golang.org/x/exp/slices"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// seriesLimitParam is the request parameter used to cap the number of series returned in the response.
+	seriesLimitParam = "series_limit"
+
+	// dropLabelsParam is the request parameter used to drop labels from the series returned in the response.
+	dropLabelsParam = "drop_labels"
+
+	// keepLabelsParam is the request parameter used to only keep some labels in the series returned in the response.
+	keepLabelsParam = "keep_labels"
+)
+
+// responseFilter filters the series returned in a query response, so that clients can request compact results.
+// The filter is applied to the final response only, so that the results cache always stores the full results.
+type responseFilter struct {
+	seriesLimit int
+	dropLabels  []string
+	keepLabels  []string
+}
+
+// decodeResponseFilter decodes the response filter from the request parameters.
+func decodeResponseFilter(r *http.Request) (responseFilter, error) {
+	var f responseFilter
+
+	if value := r.FormValue(seriesLimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return responseFilter{}, apierror.Newf(apierror.TypeBadData, "invalid parameter %q: must be a non-negative integer", seriesLimitParam)
+		}
+		f.seriesLimit = limit
+	}
+
+	// r.Form has been populated by FormValue().
+	f.dropLabels = r.Form[dropLabelsParam]
+	f.keepLabels = r.Form[keepLabelsParam]
+
+	if len(f.dropLabels) > 0 && len(f.keepLabels) > 0 {
+		return responseFilter{}, apierror.Newf(apierror.TypeBadData, "parameters %q and %q can't be used together", dropLabelsParam, keepLabelsParam)
+	}
+
+	for _, param := range []string{dropLabelsParam, keepLabelsParam} {
+		for _, name := range r.Form[param] {
+			if !model.LabelName(name).IsValid() {
+				return responseFilter{}, apierror.Newf(apierror.TypeBadData, "invalid parameter %q: invalid label name %q", param, name)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+func (f responseFilter) isEmpty() bool {
+	return f.seriesLimit == 0 && len(f.dropLabels) == 0 && len(f.keepLabels) == 0
+}
+
+// apply returns a copy of the response with the filter applied. The input response is left untouched.
+func (f responseFilter) apply(resp *PrometheusResponse) *PrometheusResponse {
+	if f.isEmpty() || resp.Data == nil {
+		return resp
+	}
+
+	// Only series can be filtered.
+	if resp.Data.ResultType != model.ValMatrix.String() && resp.Data.ResultType != model.ValVector.String() {
+		return resp
+	}
+
+	filtered := *resp
+	filtered.Data = &PrometheusData{
+		ResultType: resp.Data.ResultType,
+		Result:     resp.Data.Result,
+	}
+
+	if f.seriesLimit > 0 && len(filtered.Data.Result) > f.seriesLimit {
+		filtered.Data.Result = filtered.Data.Result[:f.seriesLimit]
+		filtered.Warnings = append(slices.Clone(resp.Warnings), fmt.Sprintf("results truncated to %d series out of %d because of the %q parameter", f.seriesLimit, len(resp.Data.Result), seriesLimitParam))
+	}
+
+	if len(f.dropLabels) > 0 || len(f.keepLabels) > 0 {
+		result := make([]SampleStream, 0, len(filtered.Data.Result))
+		for _, stream := range filtered.Data.Result {
+			stream.Labels = f.filterLabels(stream.Labels)
+			result = append(result, stream)
+		}
+		filtered.Data.Result = result
+	}
+
+	return &filtered
+}
+
+func (f responseFilter) filterLabels(lbls []mimirpb.LabelAdapter) []mimirpb.LabelAdapter {
+	filtered := make([]mimirpb.LabelAdapter, 0, len(lbls))
+	for _, l := range lbls {
+		if len(f.keepLabels) > 0 && !slices.Contains(f.keepLabels, l.Name) {
+			continue
+		}
+		if slices.Contains(f.dropLabels, l.Name) {
+			continue
+		}
+		filtered = append(filtered, l)
+	}
+	return filtered
+}
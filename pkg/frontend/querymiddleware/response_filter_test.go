@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestResponseFilter_Apply(t *testing.T) {
+	newResponse := func(resultType model.ValueType) *PrometheusResponse {
+		return &PrometheusResponse{
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: resultType.String(),
+				Result: []SampleStream{
+					{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "a"}, {Name: "job", Value: "x"}}, Samples: []mimirpb.Sample{{TimestampMs: 1, Value: 1}}},
+					{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "x"}}, Samples: []mimirpb.Sample{{TimestampMs: 1, Value: 2}}},
+					{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "c"}, {Name: "job", Value: "y"}}, Samples: []mimirpb.Sample{{TimestampMs: 1, Value: 3}}},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		filter             responseFilter
+		resultType         model.ValueType
+		expectedLabels     [][]mimirpb.LabelAdapter
+		expectedWarnings   []string
+		expectedUnmodified bool
+	}{
+		"empty filter": {
+			filter:             responseFilter{},
+			resultType:         model.ValMatrix,
+			expectedUnmodified: true,
+		},
+		"series limit higher than the number of series": {
+			filter:     responseFilter{seriesLimit: 3},
+			resultType: model.ValMatrix,
+			expectedLabels: [][]mimirpb.LabelAdapter{
+				{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "a"}, {Name: "job", Value: "x"}},
+				{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "x"}},
+				{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "c"}, {Name: "job", Value: "y"}},
+			},
+		},
+		"series limit lower than the number of series": {
+			filter:     responseFilter{seriesLimit: 2},
+			resultType: model.ValVector,
+			expectedLabels: [][]mimirpb.LabelAdapter{
+				{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "a"}, {Name: "job", Value: "x"}},
+				{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "x"}},
+			},
+			expectedWarnings: []string{`results truncated to 2 series out of 3 because of the "series_limit" parameter`},
+		},
+		"drop labels": {
+			filter:     responseFilter{dropLabels: []string{"__name__", "job"}},
+			resultType: model.ValMatrix,
+			expectedLabels: [][]mimirpb.LabelAdapter{
+				{{Name: "instance", Value: "a"}},
+				{{Name: "instance", Value: "b"}},
+				{{Name: "instance", Value: "c"}},
+			},
+		},
+		"keep labels": {
+			filter:     responseFilter{keepLabels: []string{"job", "unknown"}},
+			resultType: model.ValMatrix,
+			expectedLabels: [][]mimirpb.LabelAdapter{
+				{{Name: "job", Value: "x"}},
+				{{Name: "job", Value: "x"}},
+				{{Name: "job", Value: "y"}},
+			},
+		},
+		"series limit and keep labels": {
+			filter:     responseFilter{seriesLimit: 1, keepLabels: []string{"instance"}},
+			resultType: model.ValMatrix,
+			expectedLabels: [][]mimirpb.LabelAdapter{
+				{{Name: "instance", Value: "a"}},
+			},
+			expectedWarnings: []string{`results truncated to 1 series out of 3 because of the "series_limit" parameter`},
+		},
+		"scalar results are not filtered": {
+			filter:             responseFilter{seriesLimit: 1, dropLabels: []string{"job"}},
+			resultType:         model.ValScalar,
+			expectedUnmodified: true,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := newResponse(testData.resultType)
+			actual := testData.filter.apply(input)
+
+			// The input response should never be modified.
+			require.Equal(t, newResponse(testData.resultType), input)
+
+			if testData.expectedUnmodified {
+				require.Same(t, input, actual)
+				return
+			}
+
+			var actualLabels [][]mimirpb.LabelAdapter
+			for _, stream := range actual.Data.Result {
+				actualLabels = append(actualLabels, stream.Labels)
+			}
+			assert.Equal(t, testData.expectedLabels, actualLabels)
+			assert.Equal(t, testData.expectedWarnings, actual.Warnings)
+			assert.Equal(t, testData.resultType.String(), actual.Data.ResultType)
+		})
+	}
+}
+
+func TestPrometheusCodec_EncodeResponse_ResponseFilter(t *testing.T) {
+	testResponse := &PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValVector.String(),
+			Result: []SampleStream{
+				{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "a"}}, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}},
+				{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "b"}}, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 2}}},
+			},
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/query?query=up&series_limit=1&drop_labels=__name__", nil)
+	require.NoError(t, err)
+
+	encodedResponse, err := newTestPrometheusCodec().EncodeResponse(context.Background(), req, testResponse)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(encodedResponse.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [{"metric": {"instance": "a"}, "value": [1, "1"]}]
+		},
+		"warnings": ["results truncated to 1 series out of 2 because of the \"series_limit\" parameter"]
+	}`, string(body))
+}
@@ -43,7 +43,21 @@ const (
 	cacheControlHeader = "Cache-Control"
 
 	// noStoreValue is the value that cacheControlHeader has if the response indicates that the results should not be cached.
+	// When set in the request, the results cache is neither looked up nor updated.
 	noStoreValue = "no-store"
+
+	// noCacheValue is the value that cacheControlHeader has if the request indicates that the results should not be
+	// served from the cache. The fresh results are still stored in the cache.
+	noCacheValue = "no-cache"
+
+	// resultsCacheHitRatioHeader is the name of the response header reporting the ratio, between 0 and 1, of the
+	// query time range served from the results cache.
+	resultsCacheHitRatioHeader = "Results-Cache-Hit-Ratio"
+
+	// resultsCacheOldestExtentAgeHeader and resultsCacheNewestExtentAgeHeader are the names of the response headers
+	// reporting the age, in seconds, of the oldest and newest results cache extents used to serve the response.
+	resultsCacheOldestExtentAgeHeader = "Results-Cache-Oldest-Extent-Age"
+	resultsCacheNewestExtentAgeHeader = "Results-Cache-Newest-Extent-Age"
 )
 
 var (
@@ -226,6 +240,11 @@ func isRequestCachable(req Request, maxCacheTime int64, cacheUnalignedRequests b
 	return true, ""
 }
 
+// isResultsCacheResponseHeader returns whether the input header name is a response header describing the results cache usage.
+func isResultsCacheResponseHeader(name string) bool {
+	return name == resultsCacheHitRatioHeader || name == resultsCacheOldestExtentAgeHeader || name == resultsCacheNewestExtentAgeHeader
+}
+
 // isResponseCachable says whether the response should be cached or not.
 func isResponseCachable(r Response, logger log.Logger) bool {
 	headerValues := getHeaderValuesWithName(r, cacheControlHeader)
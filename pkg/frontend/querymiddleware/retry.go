@@ -8,23 +8,31 @@ package querymiddleware
 import (
 	"context"
 	"errors"
+	"net/http"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/tenant"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/weaveworks/common/httpgrpc"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 type retryMiddlewareMetrics struct {
-	retriesCount prometheus.Histogram
+	retriesCount   prometheus.Histogram
+	retriesByClass *prometheus.CounterVec
 }
 
-func newRetryMiddlewareMetrics(registerer prometheus.Registerer) prometheus.Observer {
+func newRetryMiddlewareMetrics(registerer prometheus.Registerer) *retryMiddlewareMetrics {
 	return &retryMiddlewareMetrics{
 		retriesCount: promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
 			Namespace: "cortex",
@@ -32,24 +40,27 @@ func newRetryMiddlewareMetrics(registerer prometheus.Registerer) prometheus.Obse
 			Help:      "Number of times a request is retried.",
 			Buckets:   []float64{0, 1, 2, 3, 4, 5},
 		}),
+		retriesByClass: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "query_frontend_retries_total",
+			Help:      "Total number of times a request has been retried, by the class of the error causing the retry.",
+		}, []string{"error_class"}),
 	}
 }
 
-func (m *retryMiddlewareMetrics) Observe(v float64) {
-	m.retriesCount.Observe(v)
-}
-
 type retry struct {
 	log        log.Logger
 	next       Handler
+	limits     Limits
 	maxRetries int
+	backoff    backoff.Config
 
-	metrics prometheus.Observer
+	metrics *retryMiddlewareMetrics
 }
 
-// newRetryMiddleware returns a middleware that retries requests if they
-// fail with 500 or a non-HTTP error.
-func newRetryMiddleware(log log.Logger, maxRetries int, metrics prometheus.Observer) Middleware {
+// newRetryMiddleware returns a middleware that retries requests failing with an error whose class
+// is retried for the tenant, waiting for a jittered exponential backoff between the retries.
+func newRetryMiddleware(log log.Logger, limits Limits, maxRetries int, backoffCfg backoff.Config, metrics *retryMiddlewareMetrics) Middleware {
 	if metrics == nil {
 		metrics = newRetryMiddlewareMetrics(nil)
 	}
@@ -58,7 +69,9 @@ func newRetryMiddleware(log log.Logger, maxRetries int, metrics prometheus.Obser
 		return retry{
 			log:        log,
 			next:       next,
+			limits:     limits,
 			maxRetries: maxRetries,
+			backoff:    backoffCfg,
 			metrics:    metrics,
 		}
 	})
@@ -66,7 +79,14 @@ func newRetryMiddleware(log log.Logger, maxRetries int, metrics prometheus.Obser
 
 func (r retry) Do(ctx context.Context, req Request) (Response, error) {
 	tries := 0
-	defer func() { r.metrics.Observe(float64(tries)) }()
+	defer func() { r.metrics.retriesCount.Observe(float64(tries)) }()
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	retryBackoff := backoff.New(ctx, r.backoff)
 
 	var lastErr error
 	for ; tries < r.maxRetries; tries++ {
@@ -78,19 +98,99 @@ func (r retry) Do(ctx context.Context, req Request) (Response, error) {
 			return resp, nil
 		}
 
-		if apierror.IsNonRetryableAPIError(err) || errors.Is(err, context.Canceled) {
+		if errors.Is(err, context.Canceled) || apierror.TypeOf(err) == apierror.TypeCanceled {
 			return nil, err
 		}
-		// Retry if we get a HTTP 500 or a non-HTTP error.
-		httpResp, ok := httpgrpc.HTTPResponseFromError(err)
-		if !ok || httpResp.Code/100 == 5 {
-			lastErr = err
-			log := util_log.WithContext(ctx, spanlogger.FromContext(ctx, r.log))
-			level.Error(log).Log("msg", "error processing request", "try", tries, "err", err)
-			continue
+
+		class := classifyError(err)
+		if !r.isRetried(tenantIDs, class) {
+			return nil, err
 		}
 
-		return nil, err
+		lastErr = err
+		log := util_log.WithContext(ctx, spanlogger.FromContext(ctx, r.log))
+		level.Error(log).Log("msg", "error processing request", "try", tries, "error_class", class, "err", err)
+
+		if tries+1 < r.maxRetries {
+			r.metrics.retriesByClass.WithLabelValues(class).Inc()
+			retryBackoff.Wait()
+		}
 	}
 	return nil, lastErr
 }
+
+// isRetried returns whether the errors of the input class are retried for all the tenants.
+func (r retry) isRetried(tenantIDs []string, class string) bool {
+	for _, tenantID := range tenantIDs {
+		if !slices.Contains(r.limits.QueryRetryErrorClasses(tenantID), class) {
+			return false
+		}
+	}
+	return true
+}
+
+// classifyError returns the class of the error returned by the downstream, which is one of validation.QueryErrorClasses.
+func classifyError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return validation.QueryErrorClassTimeout
+	}
+
+	if apierror.IsAPIError(err) {
+		switch apierror.TypeOf(err) {
+		case apierror.TypeTimeout:
+			return validation.QueryErrorClassTimeout
+		case apierror.TypeTooManyRequests:
+			return validation.QueryErrorClassResourceExhausted
+		case apierror.TypeInternal:
+			return validation.QueryErrorClassInternal
+		case apierror.TypeUnavailable:
+			return validation.QueryErrorClassNetwork
+		default:
+			return validation.QueryErrorClassBadData
+		}
+	}
+
+	if httpResp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return classifyHTTPResponse(httpResp)
+	}
+
+	// Errors which are not HTTP responses are returned by the transport.
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.DeadlineExceeded:
+			return validation.QueryErrorClassTimeout
+		case codes.ResourceExhausted:
+			return validation.QueryErrorClassResourceExhausted
+		}
+	}
+	return validation.QueryErrorClassNetwork
+}
+
+func classifyHTTPResponse(resp *httpgrpc.HTTPResponse) string {
+	// The API errors are encoded in the body, and their type is more accurate than the status code.
+	body := struct {
+		ErrorType apierror.Type `json:"errorType"`
+	}{}
+	if json.Unmarshal(resp.Body, &body) == nil {
+		switch body.ErrorType {
+		case apierror.TypeTimeout:
+			return validation.QueryErrorClassTimeout
+		case apierror.TypeTooManyRequests:
+			return validation.QueryErrorClassResourceExhausted
+		}
+	}
+
+	switch resp.Code {
+	case http.StatusInternalServerError:
+		return validation.QueryErrorClassInternal
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return validation.QueryErrorClassNetwork
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return validation.QueryErrorClassTimeout
+	case http.StatusTooManyRequests:
+		return validation.QueryErrorClassResourceExhausted
+	default:
+		// The other status codes, like 4xx and 501 Not Implemented, are caused by the request itself.
+		return validation.QueryErrorClassBadData
+	}
+}
@@ -13,11 +13,21 @@ import (
 	"testing"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func TestRetry(t *testing.T) {
@@ -32,13 +42,24 @@ func TestRetry(t *testing.T) {
 		Code: http.StatusInternalServerError,
 		Body: []byte("Internal Server Error"),
 	})
+	errGatewayTimeout := httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code: http.StatusGatewayTimeout,
+		Body: []byte("Gateway Timeout"),
+	})
+	errNotImplemented := httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code: http.StatusNotImplemented,
+		Body: []byte("Not Implemented"),
+	})
+	errTooManyRequests := apierror.New(apierror.TypeTooManyRequests, "too many outstanding requests")
 
 	for _, tc := range []struct {
-		name            string
-		handler         Handler
-		resp            Response
-		err             error
-		expectedRetries int
+		name                  string
+		handler               Handler
+		retryErrorClasses     []string
+		resp                  Response
+		err                   error
+		expectedRetries       int
+		expectedRetriesByType map[string]float64
 	}{
 		{
 			name:            "retry failures",
@@ -49,7 +70,8 @@ func TestRetry(t *testing.T) {
 				}
 				return nil, fmt.Errorf("fail")
 			}),
-			resp: &PrometheusResponse{Status: "Hello World"},
+			resp:                  &PrometheusResponse{Status: "Hello World"},
+			expectedRetriesByType: map[string]float64{validation.QueryErrorClassNetwork: 4},
 		},
 		{
 			name:            "don't retry 400s",
@@ -73,8 +95,55 @@ func TestRetry(t *testing.T) {
 			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
 				return nil, errInternal
 			}),
+			err:                   errInternal,
+			expectedRetriesByType: map[string]float64{validation.QueryErrorClassInternal: 4},
+		},
+		{
+			name:              "don't retry 500s if the internal errors are not retried for the tenant",
+			retryErrorClasses: []string{validation.QueryErrorClassNetwork},
+			expectedRetries:   0,
+			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				return nil, errInternal
+			}),
 			err: errInternal,
 		},
+		{
+			name:            "don't retry timeouts",
+			expectedRetries: 0,
+			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				return nil, errGatewayTimeout
+			}),
+			err: errGatewayTimeout,
+		},
+		{
+			name:            "don't retry 5xx which can never succeed",
+			expectedRetries: 0,
+			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				return nil, errNotImplemented
+			}),
+			err: errNotImplemented,
+		},
+		{
+			name:            "don't retry resource exhausted by default",
+			expectedRetries: 0,
+			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				return nil, errTooManyRequests
+			}),
+			err: errTooManyRequests,
+		},
+		{
+			name:              "retry resource exhausted if enabled for the tenant",
+			retryErrorClasses: []string{validation.QueryErrorClassResourceExhausted},
+			expectedRetries:   1,
+			handler: HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				if try.Inc() == 2 {
+					return &PrometheusResponse{Status: "Hello World"}, nil
+				}
+				return nil, errTooManyRequests
+			}),
+			resp:                  &PrometheusResponse{Status: "Hello World"},
+			expectedRetriesByType: map[string]float64{validation.QueryErrorClassResourceExhausted: 1},
+		},
 		{
 			name:            "last error",
 			expectedRetries: 4,
@@ -84,34 +153,121 @@ func TestRetry(t *testing.T) {
 				}
 				return nil, errInternal
 			}),
-			err: errBadRequest,
+			err:                   errBadRequest,
+			expectedRetriesByType: map[string]float64{validation.QueryErrorClassInternal: 4},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			try.Store(0)
-			mockMetrics := mockRetryMetrics{}
-			h := newRetryMiddleware(log.NewNopLogger(), 5, &mockMetrics).Wrap(tc.handler)
-			resp, err := h.Do(context.Background(), nil)
+			metrics := newRetryMiddlewareMetrics(prometheus.NewPedanticRegistry())
+			limits := mockLimits{queryRetryErrorClasses: tc.retryErrorClasses}
+			h := newRetryMiddleware(log.NewNopLogger(), limits, 5, backoff.Config{}, metrics).Wrap(tc.handler)
+			resp, err := h.Do(user.InjectOrgID(context.Background(), "user"), nil)
 			require.Equal(t, tc.err, err)
 			require.Equal(t, tc.resp, resp)
-			require.Equal(t, float64(tc.expectedRetries), mockMetrics.retries)
+
+			observed := &dto.Metric{}
+			require.NoError(t, metrics.retriesCount.(prometheus.Metric).Write(observed))
+			require.Equal(t, float64(tc.expectedRetries), observed.GetHistogram().GetSampleSum())
+
+			for _, class := range validation.QueryErrorClasses {
+				assert.Equal(t, tc.expectedRetriesByType[class], testutil.ToFloat64(metrics.retriesByClass.WithLabelValues(class)), class)
+			}
 		})
 	}
 }
 
-type mockRetryMetrics struct {
-	retries float64
+func TestRetry_MultipleTenants(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	limits := multiTenantMockLimits{byTenant: map[string]mockLimits{
+		"tenant-a": {},
+		"tenant-b": {queryRetryErrorClasses: []string{validation.QueryErrorClassNetwork}},
+	}}
+
+	var tries atomic.Int32
+	h := newRetryMiddleware(log.NewNopLogger(), limits, 5, backoff.Config{}, nil).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		tries.Inc()
+		return nil, apierror.New(apierror.TypeInternal, "internal error")
+	}))
+
+	// The internal errors are not retried, because they're not retried for one of the tenants.
+	_, err := h.Do(user.InjectOrgID(context.Background(), "tenant-a|tenant-b"), nil)
+	require.Error(t, err)
+	require.Equal(t, int32(1), tries.Load())
 }
 
-func (c *mockRetryMetrics) Observe(v float64) {
-	c.retries = v
+func TestClassifyError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err      error
+		expected string
+	}{
+		"non-HTTP error": {
+			err:      errors.New("connection refused"),
+			expected: validation.QueryErrorClassNetwork,
+		},
+		"context deadline exceeded": {
+			err:      fmt.Errorf("wrapped: %w", context.DeadlineExceeded),
+			expected: validation.QueryErrorClassTimeout,
+		},
+		"gRPC resource exhausted": {
+			err:      status.Error(codes.ResourceExhausted, "too many requests"),
+			expected: validation.QueryErrorClassResourceExhausted,
+		},
+		"gRPC deadline exceeded": {
+			err:      status.Error(codes.DeadlineExceeded, "deadline exceeded"),
+			expected: validation.QueryErrorClassTimeout,
+		},
+		"API error with timeout type": {
+			err:      apierror.New(apierror.TypeTimeout, "query timed out"),
+			expected: validation.QueryErrorClassTimeout,
+		},
+		"API error with execution type": {
+			err:      apierror.New(apierror.TypeExec, "expanding series: the query exceeded the limit"),
+			expected: validation.QueryErrorClassBadData,
+		},
+		"API error with internal type": {
+			err:      apierror.New(apierror.TypeInternal, "unable to contact the store-gateways"),
+			expected: validation.QueryErrorClassInternal,
+		},
+		"HTTP 503 encoding an API error with timeout type": {
+			err: httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+				Code: http.StatusServiceUnavailable,
+				Body: []byte(`{"status":"error","errorType":"timeout","error":"query timed out"}`),
+			}),
+			expected: validation.QueryErrorClassTimeout,
+		},
+		"HTTP 503": {
+			err:      httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{Code: http.StatusServiceUnavailable}),
+			expected: validation.QueryErrorClassNetwork,
+		},
+		"HTTP 502": {
+			err:      httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{Code: http.StatusBadGateway}),
+			expected: validation.QueryErrorClassNetwork,
+		},
+		"HTTP 429": {
+			err:      httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{Code: http.StatusTooManyRequests}),
+			expected: validation.QueryErrorClassResourceExhausted,
+		},
+		"HTTP 422": {
+			err:      httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{Code: http.StatusUnprocessableEntity}),
+			expected: validation.QueryErrorClassBadData,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, classifyError(tc.err))
+		})
+	}
 }
 
 func Test_RetryMiddlewareCancel(t *testing.T) {
 	var try atomic.Int32
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(user.InjectOrgID(context.Background(), "user"))
 	cancel()
-	_, err := newRetryMiddleware(log.NewNopLogger(), 5, nil).Wrap(
+	_, err := newRetryMiddleware(log.NewNopLogger(), mockLimits{}, 5, backoff.Config{}, nil).Wrap(
 		HandlerFunc(func(c context.Context, r Request) (Response, error) {
 			try.Inc()
 			return nil, ctx.Err()
@@ -120,8 +276,8 @@ func Test_RetryMiddlewareCancel(t *testing.T) {
 	require.Equal(t, int32(0), try.Load())
 	require.Equal(t, ctx.Err(), err)
 
-	ctx, cancel = context.WithCancel(context.Background())
-	_, err = newRetryMiddleware(log.NewNopLogger(), 5, nil).Wrap(
+	ctx, cancel = context.WithCancel(user.InjectOrgID(context.Background(), "user"))
+	_, err = newRetryMiddleware(log.NewNopLogger(), mockLimits{}, 5, backoff.Config{}, nil).Wrap(
 		HandlerFunc(func(c context.Context, r Request) (Response, error) {
 			try.Inc()
 			cancel()
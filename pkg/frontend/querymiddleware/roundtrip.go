@@ -15,7 +15,9 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/tenant"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,11 +52,20 @@ type Config struct {
 	CacheSplitter CacheSplitter `yaml:"-"`
 
 	QueryResultResponseFormat string `yaml:"query_result_response_format"`
+
+	ResponseFormats flagext.StringSliceCSV `yaml:"response_formats" category:"experimental"`
+
+	RetryBackoffMinPeriod time.Duration `yaml:"retry_backoff_min_period" category:"experimental"`
+	RetryBackoffMaxPeriod time.Duration `yaml:"retry_backoff_max_period" category:"experimental"`
+
+	SubUserHeader string `yaml:"sub_user_header" category:"experimental"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxRetries, "query-frontend.max-retries-per-request", 5, "Maximum number of retries for a single request; beyond this, the downstream error is returned.")
+	f.DurationVar(&cfg.RetryBackoffMinPeriod, "query-frontend.retry-backoff-min-period", 50*time.Millisecond, "Minimum delay before retrying a request. The delay is jittered and increased exponentially on each retry.")
+	f.DurationVar(&cfg.RetryBackoffMaxPeriod, "query-frontend.retry-backoff-max-period", time.Second, "Maximum delay before retrying a request.")
 	f.DurationVar(&cfg.SplitQueriesByInterval, "query-frontend.split-queries-by-interval", 24*time.Hour, "Split range queries by an interval and execute in parallel. You should use a multiple of 24 hours to optimize querying blocks. 0 to disable it.")
 	f.BoolVar(&cfg.AlignQueriesWithStep, "query-frontend.align-queries-with-step", false, "Mutate incoming queries to align their start and end with their step.")
 	f.BoolVar(&cfg.CacheResults, "query-frontend.cache-results", false, "Cache query results.")
@@ -62,6 +73,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CacheUnalignedRequests, "query-frontend.cache-unaligned-requests", false, "Cache requests that are not step-aligned.")
 	f.Uint64Var(&cfg.TargetSeriesPerShard, "query-frontend.query-sharding-target-series-per-shard", 0, "How many series a single sharded partial query should load at most. This is not a strict requirement guaranteed to be honoured by query sharding, but a hint given to the query sharding when the query execution is initially planned. 0 to disable cardinality-based hints.")
 	f.StringVar(&cfg.QueryResultResponseFormat, "query-frontend.query-result-response-format", formatProtobuf, fmt.Sprintf("Format to use when retrieving query results from queriers. Supported values: %s", strings.Join(allFormats, ", ")))
+	cfg.ResponseFormats = append(flagext.StringSliceCSV(nil), defaultResponseFormats...)
+	f.Var(&cfg.ResponseFormats, "query-frontend.response-formats", fmt.Sprintf("Comma-separated list of the formats of the query results the clients can negotiate with the Accept header, in order of preference when the Accept header matches multiple formats with the same weight. Requests without the Accept header get JSON. Supported values: %s", strings.Join(allResponseFormats, ", ")))
+	f.StringVar(&cfg.SubUserHeader, "query-frontend.sub-user-header", "X-Mimir-Sub-User", "HTTP header identifying the sub-user of the tenant running the query, whose query access policy, if any, is enforced. Like the tenant ID header, it must be set by a trusted authenticating proxy, which overrides any value sent by the client. Empty to disable the query access policies.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
 }
 
@@ -83,6 +97,12 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("unknown query result response format '%s'. Supported values: %s", cfg.QueryResultResponseFormat, strings.Join(allFormats, ", "))
 	}
 
+	for _, format := range cfg.ResponseFormats {
+		if !slices.Contains(allResponseFormats, format) {
+			return fmt.Errorf("unknown response format '%s'. Supported values: %s", format, strings.Join(allResponseFormats, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -165,6 +185,8 @@ func NewTripperware(
 	}
 	return MergeTripperwares(
 		newActiveUsersTripperware(registerer),
+		newMaxQueryExecutionTimeTripperware(limits),
+		newAccessPolicyTripperware(cfg.SubUserHeader, limits),
 		queryRangeTripperware,
 	), err
 }
@@ -182,17 +204,28 @@ func newQueryTripperware(
 	engineOpts.ActiveQueryTracker = nil
 	engine := promql.NewEngine(engineOpts)
 
+	lookbackDelta := engineOpts.LookbackDelta
+	if lookbackDelta == 0 {
+		lookbackDelta = defaultLookbackDelta
+	}
+
 	// Metric used to keep track of each middleware execution duration.
 	metrics := newInstrumentMiddlewareMetrics(registerer)
 
+	// The extended query syntax is rewritten before any other middleware parses the query.
+	extendedQuerySyntaxMiddleware := newExtendedQuerySyntaxMiddleware(limits, log)
+
 	queryRangeMiddleware := []Middleware{
+		extendedQuerySyntaxMiddleware,
 		// Track query range statistics. Added first before any subsequent middleware modifies the request.
 		newQueryStatsMiddleware(registerer),
+		newAccessPolicyMiddleware(lookbackDelta, log),
 		newLimitsMiddleware(limits, log),
 	}
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("step_align", metrics), newStepAlignMiddleware())
 	}
+	queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("query_fusion", metrics), newQueryFusionMiddleware(limits, log, registerer))
 
 	var c cache.Cache
 	if cfg.CacheResults || cfg.cardinalityBasedShardingEnabled() {
@@ -232,7 +265,7 @@ func newQueryTripperware(
 		))
 	}
 
-	queryInstantMiddleware := []Middleware{newLimitsMiddleware(limits, log)}
+	queryInstantMiddleware := []Middleware{extendedQuerySyntaxMiddleware, newAccessPolicyMiddleware(lookbackDelta, log), newLimitsMiddleware(limits, log)}
 
 	queryInstantMiddleware = append(
 		queryInstantMiddleware,
@@ -278,8 +311,9 @@ func newQueryTripperware(
 
 	if cfg.MaxRetries > 0 {
 		retryMiddlewareMetrics := newRetryMiddlewareMetrics(registerer)
-		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("retry", metrics), newRetryMiddleware(log, cfg.MaxRetries, retryMiddlewareMetrics))
-		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("retry", metrics), newRetryMiddleware(log, cfg.MaxRetries, retryMiddlewareMetrics))
+		retryBackoff := backoff.Config{MinBackoff: cfg.RetryBackoffMinPeriod, MaxBackoff: cfg.RetryBackoffMaxPeriod}
+		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("retry", metrics), newRetryMiddleware(log, limits, cfg.MaxRetries, retryBackoff, retryMiddlewareMetrics))
+		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("retry", metrics), newRetryMiddleware(log, limits, cfg.MaxRetries, retryBackoff, retryMiddlewareMetrics))
 	}
 
 	return func(next http.RoundTripper) http.RoundTripper {
@@ -294,6 +328,13 @@ func newQueryTripperware(
 			cardinality = newCardinalityQueryCacheRoundTripper(c, limits, next, log, registerer)
 		}
 
+		// The responses of the passthrough URL are cached only if the query results cache is enabled.
+		var passthroughCache cache.Cache
+		if cfg.CacheResults {
+			passthroughCache = c
+		}
+		passthrough := newPassthroughRoundTripper(&http.Client{}, passthroughCache, limits, log, registerer)
+
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 			switch {
 			case isRangeQuery(r.URL.Path):
@@ -302,6 +343,8 @@ func newQueryTripperware(
 				return instant.RoundTrip(r)
 			case isCardinalityQuery(r.URL.Path):
 				return cardinality.RoundTrip(r)
+			case isUnsupportedPathRequest(r):
+				return passthrough.RoundTrip(r)
 			default:
 				return next.RoundTrip(r)
 			}
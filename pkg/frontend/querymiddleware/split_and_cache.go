@@ -8,6 +8,7 @@ package querymiddleware
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
 
@@ -156,7 +157,20 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
 
 	// Lookup the results cache.
-	if isCacheEnabled {
+	if isCacheEnabled && req.GetOptions().CacheLookupDisabled {
+		// The client asked to not be served cached results, so we execute the original requests
+		// and store the fresh results in the cache, replacing the cached ones.
+		for _, splitReq := range splitReqs {
+			splitReq.downstreamRequests = []Request{splitReq.orig}
+
+			if cachable, reason := isRequestCachable(splitReq.orig, maxCacheTime, s.cacheUnalignedRequests, s.logger); !cachable {
+				s.metrics.queryResultCacheSkippedCount.WithLabelValues(reason).Inc()
+				continue
+			}
+
+			splitReq.cacheKey = s.splitter.GenerateCacheKey(ctx, tenant.JoinTenantIDs(tenantIDs), splitReq.orig)
+		}
+	} else if isCacheEnabled {
 		s.metrics.queryResultCacheAttemptedCount.Add(float64(len(splitReqs)))
 
 		// Build the cache keys for all requests to try to fetch from cache.
@@ -201,6 +215,7 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 				}
 
 				lookupReqs[lookupIdx].cachedResponses = []Response{response}
+				lookupReqs[lookupIdx].cachedExtents = extents
 				continue
 			}
 
@@ -298,7 +313,20 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		responses = append(responses, splitReq.downstreamResponses...)
 	}
 
-	return s.merger.MergeResponse(responses...)
+	response, err := s.merger.MergeResponse(responses...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report the results cache usage to the client. The response is copied, because it may be
+	// the same instance returned by the downstream handler.
+	if promRes, ok := response.(*PrometheusResponse); ok && isCacheEnabled {
+		withHeaders := *promRes
+		withHeaders.Headers = append(append([]*PrometheusResponseHeader(nil), promRes.Headers...), splitReqs.resultsCacheHeaders(queryTime)...)
+		response = &withHeaders
+	}
+
+	return response, nil
 }
 
 // splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled.
@@ -357,7 +385,7 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, now tim
 	returnedBytes := 0
 	extentsOutOfTTL := 0
 
-	ttl, ttlForExtentsInOOOWindow, oooWindow := s.getCacheOptions(tenantIDs)
+	ttls := s.getCacheOptions(tenantIDs)
 
 	for foundKey, foundData := range founds {
 		// Find the index of this cache key.
@@ -387,7 +415,7 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, now tim
 		for ix := range resp.Extents {
 			// If we don't know the query timestamp, we use the cached result.
 			// This is temporary ... after max 7 days (previous hardcoded TTL) all cached results will have query timestamp recorded.
-			usedTTL := getTTLForExtent(now, ttl, ttlForExtentsInOOOWindow, oooWindow, &resp.Extents[ix])
+			usedTTL := ttls.forExtent(now, &resp.Extents[ix])
 			if resp.Extents[ix].QueryTimestampMs > 0 && resp.Extents[ix].QueryTimestampMs < now.UnixMilli()-usedTTL.Milliseconds() {
 				extentsOutOfTTL++
 				continue
@@ -411,11 +439,22 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, now tim
 	return extents
 }
 
-func (s *splitAndCacheMiddleware) getCacheOptions(tenantIDs []string) (ttl, ttlInOOO, oooWindow time.Duration) {
-	ttl = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTL)
-	ttlInOOO = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTLForOutOfOrderTimeWindow)
-	oooWindow = validation.MaxDurationPerTenant(tenantIDs, s.limits.OutOfOrderTimeWindow)
-	return
+// resultsCacheTTLs holds the options used to compute the TTL of the cached extents.
+type resultsCacheTTLs struct {
+	ttl            time.Duration
+	ttlInOOOWindow time.Duration
+	oooWindow      time.Duration
+	slidingTTLMax  time.Duration
+}
+
+func (s *splitAndCacheMiddleware) getCacheOptions(tenantIDs []string) resultsCacheTTLs {
+	return resultsCacheTTLs{
+		ttl:            validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTL),
+		ttlInOOOWindow: validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTLForOutOfOrderTimeWindow),
+		oooWindow:      validation.MaxDurationPerTenant(tenantIDs, s.limits.OutOfOrderTimeWindow),
+		// The sliding TTL is only used if enabled for all the tenants.
+		slidingTTLMax: validation.MinDurationPerTenant(tenantIDs, s.limits.ResultsCacheSlidingTTLMax),
+	}
 }
 
 // storeCacheExtents stores the extents for given key in the cache.
@@ -424,8 +463,8 @@ func (s *splitAndCacheMiddleware) storeCacheExtents(key string, tenantIDs []stri
 		return
 	}
 
-	ttl, ttlInOOO, oooWindow := s.getCacheOptions(tenantIDs)
-	usedTTL := getTTLForExtent(time.Now(), ttl, ttlInOOO, oooWindow, &extents[len(extents)-1])
+	// The most recent extent has the shortest TTL.
+	usedTTL := s.getCacheOptions(tenantIDs).forExtent(time.Now(), &extents[len(extents)-1])
 
 	buf, err := proto.Marshal(&CachedResponse{
 		Key:     key,
@@ -439,11 +478,31 @@ func (s *splitAndCacheMiddleware) storeCacheExtents(key string, tenantIDs []stri
 	s.cache.StoreAsync(map[string][]byte{cacheHashKey(key): buf}, usedTTL)
 }
 
-func getTTLForExtent(now time.Time, ttl, ttlInOOOWindow, oooWindow time.Duration, e *Extent) time.Duration {
-	if oooWindow > 0 && e.End >= now.Add(-oooWindow).UnixMilli() {
-		return ttlInOOOWindow
+// forExtent returns the TTL of the extent. If the sliding TTL is enabled, the TTL is the age of the most recent
+// data of the extent when it was cached, bounded between the regular TTL and the maximum sliding TTL.
+func (t resultsCacheTTLs) forExtent(now time.Time, e *Extent) time.Duration {
+	if t.oooWindow > 0 && e.End >= now.Add(-t.oooWindow).UnixMilli() {
+		return t.ttlInOOOWindow
+	}
+	if t.slidingTTLMax <= t.ttl {
+		return t.ttl
+	}
+
+	// If we don't know the query timestamp, the extent is being cached now.
+	cachedAt := now.UnixMilli()
+	if e.QueryTimestampMs > 0 {
+		cachedAt = e.QueryTimestampMs
+	}
+
+	age := time.Duration(cachedAt-e.End) * time.Millisecond
+	switch {
+	case age > t.slidingTTLMax:
+		return t.slidingTTLMax
+	case age > t.ttl:
+		return age
+	default:
+		return t.ttl
 	}
-	return ttl
 }
 
 // splitRequest holds information about a split request.
@@ -487,6 +546,63 @@ func (s *splitRequests) countDownstreamRequests() int {
 	return count
 }
 
+// resultsCacheHeaders returns the response headers describing how much of the query time range has been
+// served from the results cache, and how old are the cached extents used to serve it.
+func (s *splitRequests) resultsCacheHeaders(now time.Time) []*PrometheusResponseHeader {
+	var (
+		totalRange, downstreamRange    int64
+		oldestExtentMs, newestExtentMs int64
+	)
+
+	for _, splitReq := range *s {
+		totalRange += splitReq.orig.GetEnd() - splitReq.orig.GetStart() + 1
+
+		for _, downstreamReq := range splitReq.downstreamRequests {
+			downstreamRange += downstreamReq.GetEnd() - downstreamReq.GetStart() + 1
+		}
+
+		for _, extent := range splitReq.cachedExtents {
+			// The query timestamp is unknown for extents cached by older versions.
+			if extent.QueryTimestampMs <= 0 {
+				continue
+			}
+			if oldestExtentMs == 0 || extent.QueryTimestampMs < oldestExtentMs {
+				oldestExtentMs = extent.QueryTimestampMs
+			}
+			if extent.QueryTimestampMs > newestExtentMs {
+				newestExtentMs = extent.QueryTimestampMs
+			}
+		}
+	}
+
+	hitRatio := 0.0
+	if totalRange > 0 && downstreamRange < totalRange {
+		hitRatio = 1 - float64(downstreamRange)/float64(totalRange)
+	}
+
+	headers := []*PrometheusResponseHeader{
+		{Name: resultsCacheHitRatioHeader, Values: []string{strconv.FormatFloat(hitRatio, 'f', 3, 64)}},
+	}
+
+	if oldestExtentMs > 0 {
+		headers = append(headers,
+			&PrometheusResponseHeader{Name: resultsCacheOldestExtentAgeHeader, Values: []string{formatExtentAge(now, oldestExtentMs)}},
+			&PrometheusResponseHeader{Name: resultsCacheNewestExtentAgeHeader, Values: []string{formatExtentAge(now, newestExtentMs)}},
+		)
+	}
+
+	return headers
+}
+
+// formatExtentAge returns the age, in seconds, of an extent cached at the input query timestamp.
+func formatExtentAge(now time.Time, queryTimestampMs int64) string {
+	age := now.UnixMilli() - queryTimestampMs
+	if age < 0 {
+		age = 0
+	}
+	return strconv.FormatInt(age/1000, 10)
+}
+
 // prepareDownstreamRequests injects a unique ID and hints to all downstream requests and
 // initialize downstream responses slice to have the same length of requests.
 func (s *splitRequests) prepareDownstreamRequests() []Request {
@@ -315,7 +315,8 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Equal(t, 1, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
+	assert.Equal(t, map[string][]string{resultsCacheHitRatioHeader: {"0.000"}}, getResultsCacheHeaders(resp))
 	assert.Equal(t, 1, cacheBackend.CountStoreCalls())
 	// Assert query stats from context
 	queryStats := stats.FromContext(ctx)
@@ -325,7 +326,10 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	resp, err = rc.Do(ctx, req)
 	require.NoError(t, err)
 	require.Equal(t, 1, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
+	assert.Equal(t, []string{"1.000"}, getResultsCacheHeaders(resp)[resultsCacheHitRatioHeader])
+	assert.Contains(t, getResultsCacheHeaders(resp), resultsCacheOldestExtentAgeHeader)
+	assert.Contains(t, getResultsCacheHeaders(resp), resultsCacheNewestExtentAgeHeader)
 	assert.Equal(t, 1, cacheBackend.CountStoreCalls())
 	// Assert query stats from context
 	queryStats = stats.FromContext(ctx)
@@ -367,6 +371,71 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	`)))
 }
 
+func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheOnCacheLookupDisabled(t *testing.T) {
+	cacheBackend := cache.NewInstrumentedMockCache()
+
+	mw := newSplitAndCacheMiddleware(
+		true,
+		true,
+		24*time.Hour,
+		false,
+		mockLimits{maxCacheFreshness: 10 * time.Minute, resultsCacheTTL: resultsCacheTTL, resultsCacheOutOfOrderWindowTTL: resultsCacheLowerTTL},
+		newTestPrometheusCodec(),
+		cacheBackend,
+		ConstSplitter(day),
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	)
+
+	start := parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000
+	end := parseTimeRFC3339(t, "2021-10-15T12:00:00Z").Unix() * 1000
+	step := int64(120 * 1000)
+
+	downstreamReqs := 0
+	downstreamRes := mkAPIResponse(start, end, step)
+	rc := mw.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		downstreamReqs++
+		return downstreamRes, nil
+	}))
+
+	req := Request(&PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: start,
+		End:   end,
+		Step:  step,
+		Query: `{__name__=~".+"}`,
+	})
+
+	_, ctx := stats.ContextWithEmptyStats(context.Background())
+	ctx = user.InjectOrgID(ctx, "1")
+
+	// The first request populates the cache.
+	_, err := rc.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, downstreamReqs)
+	require.Equal(t, 1, cacheBackend.CountFetchCalls())
+	require.Equal(t, 1, cacheBackend.CountStoreCalls())
+
+	// A request with the cache lookup disabled should be executed again, and its results should be stored in the cache.
+	lookupDisabledReq := *req.(*PrometheusRangeQueryRequest)
+	lookupDisabledReq.Options = Options{CacheLookupDisabled: true}
+	resp, err := rc.Do(ctx, &lookupDisabledReq)
+	require.NoError(t, err)
+	require.Equal(t, 2, downstreamReqs)
+	require.Equal(t, 1, cacheBackend.CountFetchCalls())
+	require.Equal(t, 2, cacheBackend.CountStoreCalls())
+	require.Equal(t, downstreamRes, withoutResultsCacheHeaders(resp))
+	assert.Equal(t, map[string][]string{resultsCacheHitRatioHeader: {"0.000"}}, getResultsCacheHeaders(resp))
+
+	// A subsequent request should be served from the cache.
+	_, err = rc.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 2, downstreamReqs)
+	require.Equal(t, 2, cacheBackend.CountFetchCalls())
+}
+
 func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAligned(t *testing.T) {
 	cacheBackend := cache.NewInstrumentedMockCache()
 	reg := prometheus.NewPedanticRegistry()
@@ -447,7 +516,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAli
 	require.NoError(t, err)
 
 	require.Equal(t, 1, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
 
 	// Should not touch the cache at all.
 	assert.Equal(t, 0, cacheBackend.CountFetchCalls())
@@ -534,7 +603,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_EnabledCachingOfStepUnalignedReque
 	require.NoError(t, err)
 
 	require.Equal(t, 1, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
 
 	// Since we're caching unaligned requests, we should see that.
 	assert.Equal(t, 1, cacheBackend.CountFetchCalls())
@@ -547,7 +616,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_EnabledCachingOfStepUnalignedReque
 	resp, err = rc.Do(ctx, req)
 	require.NoError(t, err)
 	require.Equal(t, 1, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
 	assert.Equal(t, 2, cacheBackend.CountFetchCalls())
 	assert.Equal(t, 1, cacheBackend.CountStoreCalls())
 	// Assert query stats from context
@@ -559,7 +628,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_EnabledCachingOfStepUnalignedReque
 	resp, err = rc.Do(ctx, req)
 	require.NoError(t, err)
 	require.Equal(t, 2, downstreamReqs)
-	require.Equal(t, expectedResponse, resp)
+	require.Equal(t, expectedResponse, withoutResultsCacheHeaders(resp))
 
 	assert.Equal(t, 3, cacheBackend.CountFetchCalls())
 	assert.Equal(t, 2, cacheBackend.CountStoreCalls())
@@ -685,13 +754,13 @@ func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotCacheRequestEarlierThanMa
 			resp, err := rc.Do(ctx, req)
 			require.NoError(t, err)
 			require.Equal(t, 1, calls)
-			require.Equal(t, testData.downstreamResponse, resp)
+			require.Equal(t, testData.downstreamResponse, withoutResultsCacheHeaders(resp))
 
 			// Doing same request again should result in another query to fetch most recent data.
 			resp, err = rc.Do(ctx, req)
 			require.NoError(t, err)
 			require.Equal(t, 2, calls)
-			require.Equal(t, testData.downstreamResponse, resp)
+			require.Equal(t, testData.downstreamResponse, withoutResultsCacheHeaders(resp))
 
 			// Check if the response was cached.
 			cacheKey := cacheHashKey(cacheSplitter.GenerateCacheKey(ctx, userID, req))
@@ -874,7 +943,7 @@ func TestSplitAndCacheMiddleware_ResultsCacheFuzzy(t *testing.T) {
 				require.NoError(t, concurrency.ForEachJob(ctx, len(reqs), maxConcurrency, func(ctx context.Context, idx int) error {
 					actual, err := mw.Do(ctx, reqs[idx])
 					require.NoError(t, err)
-					require.Equal(t, expectedRes[reqs[idx].GetId()], actual)
+					require.Equal(t, expectedRes[reqs[idx].GetId()], withoutResultsCacheHeaders(actual))
 
 					return nil
 				}))
@@ -1162,7 +1231,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_ExtentsEdgeCases(t *testing.T) {
 			require.NoError(t, err)
 
 			expectedResponse := mkAPIResponse(testData.req.GetStart(), testData.req.GetEnd(), testData.req.GetStep())
-			assert.Equal(t, expectedResponse, actualRes)
+			assert.Equal(t, expectedResponse, withoutResultsCacheHeaders(actualRes))
 
 			// Check the updated cached extents.
 			actualExtents := mw.fetchCacheExtents(ctx, time.UnixMilli(now), []string{userID}, []string{cacheKey})
@@ -1266,6 +1335,73 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 	})
 }
 
+func TestResultsCacheTTLs_ForExtent(t *testing.T) {
+	now := time.Now()
+	ttls := resultsCacheTTLs{
+		ttl:            time.Hour,
+		ttlInOOOWindow: 10 * time.Minute,
+		oooWindow:      30 * time.Minute,
+		slidingTTLMax:  24 * time.Hour,
+	}
+	extentEndingAgo := func(d time.Duration, queryTimeMs int64) Extent {
+		end := now.Add(-d).UnixMilli()
+		return mkExtentWithStepAndQueryTime(end-100, end, 10, queryTimeMs)
+	}
+
+	tests := map[string]struct {
+		ttls     resultsCacheTTLs
+		extent   Extent
+		expected time.Duration
+	}{
+		"extent in the out-of-order time window": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(20*time.Minute, now.UnixMilli()),
+			expected: 10 * time.Minute,
+		},
+		"sliding TTL disabled": {
+			ttls:     resultsCacheTTLs{ttl: time.Hour},
+			extent:   extentEndingAgo(6*time.Hour, now.UnixMilli()),
+			expected: time.Hour,
+		},
+		"sliding TTL not greater than the regular TTL": {
+			ttls:     resultsCacheTTLs{ttl: time.Hour, slidingTTLMax: time.Hour},
+			extent:   extentEndingAgo(6*time.Hour, now.UnixMilli()),
+			expected: time.Hour,
+		},
+		"data younger than the regular TTL": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(45*time.Minute, now.UnixMilli()),
+			expected: time.Hour,
+		},
+		"data older than the regular TTL": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(6*time.Hour, now.UnixMilli()),
+			expected: 6 * time.Hour,
+		},
+		"data older than the maximum sliding TTL": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(72*time.Hour, now.UnixMilli()),
+			expected: 24 * time.Hour,
+		},
+		"age of the data when cached": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(8*time.Hour, now.Add(-2*time.Hour).UnixMilli()),
+			expected: 6 * time.Hour,
+		},
+		"unknown query time": {
+			ttls:     ttls,
+			extent:   extentEndingAgo(8*time.Hour, 0),
+			expected: 8 * time.Hour,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.ttls.forExtent(now, &tc.extent))
+		})
+	}
+}
+
 func TestSplitAndCacheMiddleware_WrapMultipleTimes(t *testing.T) {
 	m := newSplitAndCacheMiddleware(
 		false,
@@ -1328,6 +1464,63 @@ func TestSplitRequests_prepareDownstreamRequests(t *testing.T) {
 	}
 }
 
+func TestSplitRequests_resultsCacheHeaders(t *testing.T) {
+	now := time.UnixMilli(100_000)
+
+	tests := map[string]struct {
+		input    splitRequests
+		expected map[string][]string
+	}{
+		"should report no cache hit on no cached extents": {
+			input: splitRequests{
+				{
+					orig:               &PrometheusRangeQueryRequest{Start: 0, End: 999},
+					downstreamRequests: []Request{&PrometheusRangeQueryRequest{Start: 0, End: 999}},
+				},
+			},
+			expected: map[string][]string{resultsCacheHitRatioHeader: {"0.000"}},
+		},
+		"should report the ratio of the time range served from the cache, and the age of the cached extents": {
+			input: splitRequests{
+				{
+					orig:               &PrometheusRangeQueryRequest{Start: 0, End: 999},
+					cachedExtents:      []Extent{{Start: 0, End: 499, QueryTimestampMs: 40_000}},
+					downstreamRequests: []Request{&PrometheusRangeQueryRequest{Start: 500, End: 999}},
+				},
+				{
+					orig:          &PrometheusRangeQueryRequest{Start: 1000, End: 1999},
+					cachedExtents: []Extent{{Start: 1000, End: 1999, QueryTimestampMs: 90_500}},
+				},
+			},
+			expected: map[string][]string{
+				resultsCacheHitRatioHeader:        {"0.750"},
+				resultsCacheOldestExtentAgeHeader: {"60"},
+				resultsCacheNewestExtentAgeHeader: {"9"},
+			},
+		},
+		"should ignore the age of extents with unknown query timestamp": {
+			input: splitRequests{
+				{
+					orig:          &PrometheusRangeQueryRequest{Start: 0, End: 999},
+					cachedExtents: []Extent{{Start: 0, End: 999}},
+				},
+			},
+			expected: map[string][]string{resultsCacheHitRatioHeader: {"1.000"}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := map[string][]string{}
+			for _, h := range testData.input.resultsCacheHeaders(now) {
+				actual[h.Name] = h.Values
+			}
+
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
 func TestSplitRequests_storeDownstreamResponses(t *testing.T) {
 	tests := map[string]struct {
 		requests    splitRequests
@@ -1456,6 +1649,33 @@ func TestSplitRequests_storeDownstreamResponses(t *testing.T) {
 	}
 }
 
+// withoutResultsCacheHeaders returns a copy of the input response without the headers describing the results cache usage.
+func withoutResultsCacheHeaders(res Response) Response {
+	promRes, ok := res.(*PrometheusResponse)
+	if !ok {
+		return res
+	}
+
+	filtered := *promRes
+	filtered.Headers = nil
+	for _, h := range promRes.Headers {
+		if !isResultsCacheResponseHeader(h.Name) {
+			filtered.Headers = append(filtered.Headers, h)
+		}
+	}
+	return &filtered
+}
+
+func getResultsCacheHeaders(res Response) map[string][]string {
+	headers := map[string][]string{}
+	for _, h := range res.(*PrometheusResponse).Headers {
+		if isResultsCacheResponseHeader(h.Name) {
+			headers[h.Name] = h.Values
+		}
+	}
+	return headers
+}
+
 func parseTimeRFC3339(t *testing.T, input string) time.Time {
 	parsed, err := time.Parse(time.RFC3339, input)
 	require.NoError(t, err)
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queryrecorder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+type listRecordingsResponse struct {
+	Active     *Recording `json:"active,omitempty"`
+	Recordings []string   `json:"recordings"`
+}
+
+// ServeHTTP serves the query recordings API:
+//   - POST starts a new recording for the tenant, lasting for the duration specified by the "duration" parameter
+//     and recording the ratio of the queries specified by the "sampling_ratio" parameter.
+//   - GET with the "id" parameter returns the tenant's recording with the given ID.
+//   - GET without parameters lists the tenant's recordings.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	tenantID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPost:
+		r.startRecordingHandler(w, req, tenantID)
+	default:
+		if id := req.FormValue("id"); id != "" {
+			r.getRecordingHandler(w, req, tenantID, id)
+			return
+		}
+		r.listRecordingsHandler(w, req, tenantID)
+	}
+}
+
+func (r *Recorder) startRecordingHandler(w http.ResponseWriter, req *http.Request, tenantID string) {
+	duration := r.cfg.MaxDuration
+	if value := req.FormValue("duration"); value != "" {
+		var err error
+		if duration, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	samplingRatio := r.cfg.SamplingRatio
+	if value := req.FormValue("sampling_ratio"); value != "" {
+		var err error
+		if samplingRatio, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "invalid sampling ratio: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rec, err := r.Start(tenantID, duration, samplingRatio, time.Now())
+	if errors.Is(err, errRecordingAlreadyActive) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, rec)
+}
+
+func (r *Recorder) getRecordingHandler(w http.ResponseWriter, req *http.Request, tenantID, id string) {
+	rec, err := r.Get(req.Context(), tenantID, id)
+	if errors.Is(err, errRecordingNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(r.logger).Log("msg", "failed to read query recording", "user", tenantID, "recording", id, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, rec)
+}
+
+func (r *Recorder) listRecordingsHandler(w http.ResponseWriter, req *http.Request, tenantID string) {
+	ids, err := r.List(req.Context(), tenantID)
+	if err != nil {
+		level.Error(r.logger).Log("msg", "failed to list query recordings", "user", tenantID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, listRecordingsResponse{
+		Active:     r.Active(tenantID),
+		Recordings: ids,
+	})
+}
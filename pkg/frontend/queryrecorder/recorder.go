@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queryrecorder
+
+import (
+	"bytes"
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	// RecordingsPrefix is the prefix, within the tenant's bucket location, where recordings are stored.
+	RecordingsPrefix = "query-recordings"
+
+	flushInterval = 10 * time.Second
+)
+
+var (
+	errRecordingAlreadyActive = errors.New("a query recording is already in progress for the tenant")
+	errRecordingNotFound      = errors.New("query recording not found")
+)
+
+// Config holds the query recording configuration.
+type Config struct {
+	Enabled     bool          `yaml:"enabled" category:"experimental"`
+	MaxDuration time.Duration `yaml:"max_duration" category:"experimental"`
+	MaxQueries  int           `yaml:"max_queries" category:"experimental"`
+
+	SamplingRatio float64 `yaml:"sampling_ratio" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "query-frontend.query-recording.enabled", false, "True to enable the API to record the queries received by a tenant for a time window. Recordings are stored in the blocks storage bucket and can be replayed against another cluster.")
+	f.DurationVar(&cfg.MaxDuration, "query-frontend.query-recording.max-duration", time.Hour, "Maximum duration of a single query recording.")
+	f.IntVar(&cfg.MaxQueries, "query-frontend.query-recording.max-queries", 100000, "Maximum number of queries stored in a single query recording. Once reached, the recording is completed.")
+	f.Float64Var(&cfg.SamplingRatio, "query-frontend.query-recording.sampling-ratio", 1, "Default ratio, greater than 0 and less than or equal to 1, of the queries received by a tenant to store in a query recording. The queries are sampled deterministically by hashing their method, path and parameters, so that the same queries are selected by every recording.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MaxDuration <= 0 {
+		return errors.New("the query recording max duration must be greater than 0")
+	}
+	if cfg.MaxQueries <= 0 {
+		return errors.New("the query recording max queries must be greater than 0")
+	}
+	if !validSamplingRatio(cfg.SamplingRatio) {
+		return errors.New("the query recording sampling ratio must be greater than 0 and less than or equal to 1")
+	}
+	return nil
+}
+
+func validSamplingRatio(ratio float64) bool {
+	return ratio > 0 && ratio <= 1
+}
+
+// Recording is a set of queries received by the query-frontend for a tenant during a time window.
+type Recording struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	SamplingRatio float64         `json:"sampling_ratio"`
+	Queries       []RecordedQuery `json:"queries"`
+}
+
+// RecordedQuery is a single query received by the query-frontend.
+type RecordedQuery struct {
+	// OffsetMillis is the time elapsed between the recording start and the query being received.
+	OffsetMillis int64      `json:"offset_ms"`
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	Params       url.Values `json:"params"`
+	StatusCode   int        `json:"status_code"`
+	// ResponseTimeMillis is the time taken by the query-frontend to respond to the query.
+	ResponseTimeMillis float64 `json:"response_time_ms"`
+}
+
+// Recorder records the queries received by the query-frontend for the tenants with an active recording.
+// Completed recordings are uploaded to the bucket.
+type Recorder struct {
+	services.Service
+
+	cfg    Config
+	bucket objstore.Bucket
+	logger log.Logger
+
+	mtx    sync.Mutex
+	active map[string]*Recording
+
+	recordedQueries prometheus.Counter
+	uploadedTotal   prometheus.Counter
+	uploadFailures  prometheus.Counter
+}
+
+// NewRecorder makes a new Recorder.
+func NewRecorder(cfg Config, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) *Recorder {
+	r := &Recorder{
+		cfg:    cfg,
+		bucket: bkt,
+		logger: logger,
+		active: map[string]*Recording{},
+		recordedQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_recorded_queries_total",
+			Help: "Total number of queries added to a query recording.",
+		}),
+		uploadedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_recordings_uploaded_total",
+			Help: "Total number of query recordings uploaded to the bucket.",
+		}),
+		uploadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_recordings_upload_failures_total",
+			Help: "Total number of query recordings which failed to be uploaded to the bucket.",
+		}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_query_frontend_query_recordings_active",
+		Help: "Number of query recordings currently in progress.",
+	}, func() float64 {
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		return float64(len(r.active))
+	})
+
+	r.Service = services.NewTimerService(flushInterval, nil, r.iteration, r.stopping)
+	return r
+}
+
+func (r *Recorder) iteration(ctx context.Context) error {
+	r.uploadCompleted(ctx, time.Now(), false)
+	return nil
+}
+
+func (r *Recorder) stopping(_ error) error {
+	// Upload all recordings, even the ones still in progress, to not lose them.
+	r.uploadCompleted(context.Background(), time.Now(), true)
+	return nil
+}
+
+// Start starts a new recording for the tenant, lasting for the input duration and recording the input
+// ratio of the queries.
+func (r *Recorder) Start(tenantID string, duration time.Duration, samplingRatio float64, now time.Time) (*Recording, error) {
+	if duration <= 0 || duration > r.cfg.MaxDuration {
+		return nil, fmt.Errorf("the recording duration must be greater than 0 and less than or equal to %s", r.cfg.MaxDuration)
+	}
+	if !validSamplingRatio(samplingRatio) {
+		return nil, errors.New("the recording sampling ratio must be greater than 0 and less than or equal to 1")
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.active[tenantID]; ok {
+		return nil, errRecordingAlreadyActive
+	}
+
+	rec := &Recording{
+		ID:            ulid.MustNew(ulid.Timestamp(now), crypto_rand.Reader).String(),
+		TenantID:      tenantID,
+		StartTime:     now,
+		EndTime:       now.Add(duration),
+		SamplingRatio: samplingRatio,
+	}
+	r.active[tenantID] = rec
+
+	level.Info(r.logger).Log("msg", "started query recording", "user", tenantID, "recording", rec.ID, "end_time", rec.EndTime, "sampling_ratio", samplingRatio)
+
+	// Return a copy, so that the caller can safely read it.
+	return rec.copyWithoutQueries(), nil
+}
+
+// RecordQuery adds the query to the tenant's recording, if any recording is in progress for the tenant
+// and the query is sampled.
+func (r *Recorder) RecordQuery(tenantID, method, urlPath string, params url.Values, receivedAt time.Time, responseTime time.Duration, statusCode int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	rec, ok := r.active[tenantID]
+	if !ok || receivedAt.Before(rec.StartTime) || !receivedAt.Before(rec.EndTime) || len(rec.Queries) >= r.cfg.MaxQueries {
+		return
+	}
+	if !isSampled(method, urlPath, params, rec.SamplingRatio) {
+		return
+	}
+
+	rec.Queries = append(rec.Queries, RecordedQuery{
+		OffsetMillis:       receivedAt.Sub(rec.StartTime).Milliseconds(),
+		Method:             method,
+		Path:               urlPath,
+		Params:             params,
+		StatusCode:         statusCode,
+		ResponseTimeMillis: float64(responseTime) / float64(time.Millisecond),
+	})
+	r.recordedQueries.Inc()
+}
+
+// isSampled returns whether the query is selected by the sampling ratio. The selection only depends on the
+// method, path and params of the query, so that the same queries are selected by every recording.
+func isSampled(method, urlPath string, params url.Values, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(method))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(urlPath))
+	_, _ = h.Write([]byte{0})
+	// Encode sorts the params by name.
+	_, _ = h.Write([]byte(params.Encode()))
+	return float64(h.Sum64()) < ratio*math.MaxUint64
+}
+
+// uploadCompleted uploads all completed recordings to the bucket. If force is true, all recordings
+// are uploaded, including the ones still in progress.
+func (r *Recorder) uploadCompleted(ctx context.Context, now time.Time, force bool) {
+	var completed []*Recording
+
+	r.mtx.Lock()
+	for tenantID, rec := range r.active {
+		if force || !now.Before(rec.EndTime) || len(rec.Queries) >= r.cfg.MaxQueries {
+			completed = append(completed, rec)
+			delete(r.active, tenantID)
+		}
+	}
+	r.mtx.Unlock()
+
+	for _, rec := range completed {
+		if err := r.upload(ctx, rec); err != nil {
+			r.uploadFailures.Inc()
+			level.Error(r.logger).Log("msg", "failed to upload query recording", "user", rec.TenantID, "recording", rec.ID, "err", err)
+			continue
+		}
+
+		r.uploadedTotal.Inc()
+		level.Info(r.logger).Log("msg", "uploaded query recording", "user", rec.TenantID, "recording", rec.ID, "queries", len(rec.Queries))
+	}
+}
+
+func (r *Recorder) upload(ctx context.Context, rec *Recording) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "marshal recording")
+	}
+
+	userBkt := bucket.NewUserBucketClient(rec.TenantID, r.bucket, nil)
+	return userBkt.Upload(ctx, recordingPath(rec.ID), bytes.NewReader(data))
+}
+
+// List returns the IDs of the tenant's recordings stored in the bucket.
+func (r *Recorder) List(ctx context.Context, tenantID string) ([]string, error) {
+	ids := []string{}
+
+	userBkt := bucket.NewUserBucketClient(tenantID, r.bucket, nil)
+	err := userBkt.Iter(ctx, RecordingsPrefix+"/", func(name string) error {
+		if id := strings.TrimSuffix(path.Base(name), ".json"); id != path.Base(name) {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+
+	return ids, err
+}
+
+// Get returns the tenant's recording stored in the bucket.
+func (r *Recorder) Get(ctx context.Context, tenantID, id string) (*Recording, error) {
+	if _, err := ulid.Parse(id); err != nil {
+		return nil, errRecordingNotFound
+	}
+
+	userBkt := bucket.NewUserBucketClient(tenantID, r.bucket, nil)
+	reader, err := userBkt.Get(ctx, recordingPath(id))
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil, errRecordingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	rec := &Recording{}
+	if err := json.NewDecoder(reader).Decode(rec); err != nil {
+		return nil, errors.Wrap(err, "decode recording")
+	}
+	return rec, nil
+}
+
+// Active returns a copy of the tenant's recording in progress, if any.
+func (r *Recorder) Active(tenantID string) *Recording {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	rec, ok := r.active[tenantID]
+	if !ok {
+		return nil
+	}
+	return rec.copyWithoutQueries()
+}
+
+func (rec *Recording) copyWithoutQueries() *Recording {
+	return &Recording{ID: rec.ID, TenantID: rec.TenantID, StartTime: rec.StartTime, EndTime: rec.EndTime, SamplingRatio: rec.SamplingRatio}
+}
+
+func recordingPath(id string) string {
+	return path.Join(RecordingsPrefix, id+".json")
+}
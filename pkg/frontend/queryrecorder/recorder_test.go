@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queryrecorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+)
+
+func TestRecorder(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+	cfg.MaxQueries = 2
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	r := NewRecorder(cfg, bkt, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	now := time.Now()
+
+	// Invalid durations should be rejected.
+	_, err := r.Start("user-1", 0, 1, now)
+	require.Error(t, err)
+	_, err = r.Start("user-1", cfg.MaxDuration+time.Second, 1, now)
+	require.Error(t, err)
+
+	// Invalid sampling ratios should be rejected.
+	_, err = r.Start("user-1", time.Minute, 0, now)
+	require.Error(t, err)
+	_, err = r.Start("user-1", time.Minute, 1.5, now)
+	require.Error(t, err)
+
+	rec, err := r.Start("user-1", time.Minute, 1, now)
+	require.NoError(t, err)
+
+	// Only one recording per tenant can be in progress.
+	_, err = r.Start("user-1", time.Minute, 1, now)
+	require.ErrorIs(t, err, errRecordingAlreadyActive)
+
+	params := url.Values{"query": []string{"up"}}
+	r.RecordQuery("user-1", http.MethodGet, "/prometheus/api/v1/query", params, now.Add(time.Second), 100*time.Millisecond, http.StatusOK)
+	r.RecordQuery("user-2", http.MethodGet, "/prometheus/api/v1/query", params, now.Add(time.Second), 100*time.Millisecond, http.StatusOK)
+	r.RecordQuery("user-1", http.MethodPost, "/prometheus/api/v1/query", params, now.Add(2*time.Second), 200*time.Millisecond, http.StatusBadRequest)
+	// Queries above the limit should not be recorded.
+	r.RecordQuery("user-1", http.MethodGet, "/prometheus/api/v1/query", params, now.Add(3*time.Second), time.Millisecond, http.StatusOK)
+
+	require.NotNil(t, r.Active("user-1"))
+	assert.Nil(t, r.Active("user-2"))
+
+	// The recording reached the max number of queries, so it should be uploaded even if the end time is not reached yet.
+	r.uploadCompleted(ctx, now.Add(3*time.Second), false)
+	assert.Nil(t, r.Active("user-1"))
+
+	ids, err := r.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{rec.ID}, ids)
+
+	ids, err = r.List(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	stored, err := r.Get(ctx, "user-1", rec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rec.ID, stored.ID)
+	assert.Equal(t, []RecordedQuery{
+		{OffsetMillis: 1000, Method: http.MethodGet, Path: "/prometheus/api/v1/query", Params: params, StatusCode: http.StatusOK, ResponseTimeMillis: 100},
+		{OffsetMillis: 2000, Method: http.MethodPost, Path: "/prometheus/api/v1/query", Params: params, StatusCode: http.StatusBadRequest, ResponseTimeMillis: 200},
+	}, stored.Queries)
+
+	// Recordings are isolated by tenant.
+	_, err = r.Get(ctx, "user-2", rec.ID)
+	require.ErrorIs(t, err, errRecordingNotFound)
+}
+
+func TestRecorder_Sampling(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+
+	ctx := context.Background()
+	now := time.Now()
+	r := NewRecorder(cfg, objstore.NewInMemBucket(), log.NewNopLogger(), nil)
+
+	record := func() []RecordedQuery {
+		rec, err := r.Start("user-1", time.Minute, 0.25, now)
+		require.NoError(t, err)
+		assert.Equal(t, 0.25, rec.SamplingRatio)
+
+		for i := 0; i < 1000; i++ {
+			params := url.Values{"query": []string{fmt.Sprintf("up{instance=\"%d\"}", i)}}
+			r.RecordQuery("user-1", http.MethodGet, "/prometheus/api/v1/query", params, now.Add(time.Second), time.Millisecond, http.StatusOK)
+		}
+		r.uploadCompleted(ctx, now, true)
+
+		stored, err := r.Get(ctx, "user-1", rec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.25, stored.SamplingRatio)
+		return stored.Queries
+	}
+
+	first := record()
+	assert.InDelta(t, 250, len(first), 50)
+
+	// The same queries are selected by every recording.
+	assert.Equal(t, first, record())
+}
+
+func TestIsSampled(t *testing.T) {
+	params := url.Values{"query": []string{"up"}, "time": []string{"1"}}
+	reordered := url.Values{"time": []string{"1"}, "query": []string{"up"}}
+
+	assert.True(t, isSampled(http.MethodGet, "/api/v1/query", params, 1))
+	assert.Equal(t, isSampled(http.MethodGet, "/api/v1/query", params, 0.5), isSampled(http.MethodGet, "/api/v1/query", reordered, 0.5))
+
+	// A query selected by a ratio is selected by any greater ratio.
+	for i := 0; i < 100; i++ {
+		params := url.Values{"query": []string{fmt.Sprintf("up{instance=\"%d\"}", i)}}
+		if isSampled(http.MethodGet, "/api/v1/query", params, 0.1) {
+			assert.True(t, isSampled(http.MethodGet, "/api/v1/query", params, 0.5))
+		}
+	}
+}
+
+func TestRecorder_ServeHTTP(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+
+	r := NewRecorder(cfg, objstore.NewInMemBucket(), log.NewNopLogger(), nil)
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/api/v1/query_recordings?duration=invalid")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = do(http.MethodPost, "/api/v1/query_recordings?sampling_ratio=invalid")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = do(http.MethodPost, "/api/v1/query_recordings?sampling_ratio=2")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = do(http.MethodPost, "/api/v1/query_recordings?duration=1m")
+	require.Equal(t, http.StatusOK, resp.Code)
+	started := Recording{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &started))
+	assert.Equal(t, 1.0, started.SamplingRatio)
+
+	resp = do(http.MethodPost, "/api/v1/query_recordings")
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	resp = do(http.MethodGet, "/api/v1/query_recordings")
+	require.Equal(t, http.StatusOK, resp.Code)
+	listed := listRecordingsResponse{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	require.NotNil(t, listed.Active)
+	assert.Equal(t, started.ID, listed.Active.ID)
+	assert.Empty(t, listed.Recordings)
+
+	resp = do(http.MethodGet, "/api/v1/query_recordings?id="+started.ID)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	// Once uploaded, the recording should be listed and readable.
+	r.uploadCompleted(context.Background(), time.Now(), true)
+
+	resp = do(http.MethodGet, "/api/v1/query_recordings")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, strings.Contains(resp.Body.String(), started.ID))
+
+	resp = do(http.MethodGet, "/api/v1/query_recordings?id="+started.ID)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
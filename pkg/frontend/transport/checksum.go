@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util/checksum"
+)
+
+var errResultChecksumMismatch = errors.New("the checksum of the query result received from the querier doesn't match, the query result may have been corrupted")
+
+type checksumRoundTripper struct {
+	next http.RoundTripper
+
+	verified   prometheus.Counter
+	mismatched prometheus.Counter
+}
+
+// NewChecksumRoundTripper wraps the next http.RoundTripper, requesting the checksum of the query results
+// to the queriers and verifying it. A query result whose checksum doesn't match is discarded, and an error
+// is returned instead.
+func NewChecksumRoundTripper(next http.RoundTripper, reg prometheus.Registerer) http.RoundTripper {
+	return &checksumRoundTripper{
+		next: next,
+		verified: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_result_checksums_verified_total",
+			Help: "Total number of query results whose checksum has been verified.",
+		}),
+		mismatched: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_result_checksum_mismatches_total",
+			Help: "Total number of query results whose checksum didn't match, because they have been corrupted.",
+		}),
+	}
+}
+
+func (c *checksumRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(checksum.RequestHTTPHeader, "true")
+
+	resp, err := c.next.RoundTrip(r)
+	if err != nil {
+		return resp, err
+	}
+
+	// The query result has no checksum if it has been generated by the query-frontend itself,
+	// or if the querier doesn't support checksums.
+	expected := resp.Header.Get(checksum.ResponseHTTPHeader)
+	if expected == "" {
+		return resp, nil
+	}
+	resp.Header.Del(checksum.ResponseHTTPHeader)
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	c.verified.Inc()
+	if !checksum.Verify(body, expected) {
+		c.mismatched.Inc()
+		return nil, errResultChecksumMismatch
+	}
+
+	resp.Body = &buffer{buff: body, ReadCloser: io.NopCloser(bytes.NewReader(body))}
+	return resp, nil
+}
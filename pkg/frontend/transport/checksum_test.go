@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/checksum"
+)
+
+func TestChecksumRoundTripper(t *testing.T) {
+	const body = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+
+	tests := map[string]struct {
+		checksum        func(req *httpgrpc.HTTPRequest) string
+		expectedErr     error
+		expectedMetrics string
+	}{
+		"should return the query result if the checksum matches": {
+			checksum: func(req *httpgrpc.HTTPRequest) string {
+				for _, h := range req.Headers {
+					if h.Key == checksum.RequestHTTPHeader {
+						return checksum.Format([]byte(body))
+					}
+				}
+				return ""
+			},
+			expectedMetrics: `
+				# HELP cortex_query_frontend_query_result_checksums_verified_total Total number of query results whose checksum has been verified.
+				# TYPE cortex_query_frontend_query_result_checksums_verified_total counter
+				cortex_query_frontend_query_result_checksums_verified_total 1
+				# HELP cortex_query_frontend_query_result_checksum_mismatches_total Total number of query results whose checksum didn't match, because they have been corrupted.
+				# TYPE cortex_query_frontend_query_result_checksum_mismatches_total counter
+				cortex_query_frontend_query_result_checksum_mismatches_total 0
+			`,
+		},
+		"should return an error if the checksum doesn't match": {
+			checksum: func(*httpgrpc.HTTPRequest) string {
+				return checksum.Format([]byte(strings.ToUpper(body)))
+			},
+			expectedErr: errResultChecksumMismatch,
+			expectedMetrics: `
+				# HELP cortex_query_frontend_query_result_checksums_verified_total Total number of query results whose checksum has been verified.
+				# TYPE cortex_query_frontend_query_result_checksums_verified_total counter
+				cortex_query_frontend_query_result_checksums_verified_total 1
+				# HELP cortex_query_frontend_query_result_checksum_mismatches_total Total number of query results whose checksum didn't match, because they have been corrupted.
+				# TYPE cortex_query_frontend_query_result_checksum_mismatches_total counter
+				cortex_query_frontend_query_result_checksum_mismatches_total 1
+			`,
+		},
+		"should return the query result without checksum": {
+			checksum: func(*httpgrpc.HTTPRequest) string {
+				return ""
+			},
+			expectedMetrics: `
+				# HELP cortex_query_frontend_query_result_checksums_verified_total Total number of query results whose checksum has been verified.
+				# TYPE cortex_query_frontend_query_result_checksums_verified_total counter
+				cortex_query_frontend_query_result_checksums_verified_total 0
+				# HELP cortex_query_frontend_query_result_checksum_mismatches_total Total number of query results whose checksum didn't match, because they have been corrupted.
+				# TYPE cortex_query_frontend_query_result_checksum_mismatches_total counter
+				cortex_query_frontend_query_result_checksum_mismatches_total 0
+			`,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			rt := NewChecksumRoundTripper(AdaptGrpcRoundTripperToHTTPRoundTripper(grpcRoundTripperFunc(func(_ context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+				resp := &httpgrpc.HTTPResponse{Code: http.StatusOK, Body: []byte(body)}
+				if c := testData.checksum(req); c != "" {
+					resp.Headers = append(resp.Headers, &httpgrpc.Header{Key: checksum.ResponseHTTPHeader, Values: []string{c}})
+				}
+				return resp, nil
+			})), reg)
+
+			resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil))
+			if testData.expectedErr != nil {
+				require.ErrorIs(t, err, testData.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Empty(t, resp.Header.Get(checksum.ResponseHTTPHeader))
+
+				actual, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, string(actual))
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+		})
+	}
+}
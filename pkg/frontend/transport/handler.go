@@ -34,6 +34,7 @@ import (
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
 const (
@@ -63,6 +64,16 @@ func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.QueryStatsEnabled, "query-frontend.query-stats-enabled", true, "False to disable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
 }
 
+// QueryRecorder records the queries received by the query-frontend.
+type QueryRecorder interface {
+	RecordQuery(tenantID, method, path string, params url.Values, receivedAt time.Time, responseTime time.Duration, statusCode int)
+}
+
+// HeavyQueriesTracker tracks the heaviest queries received by the query-frontend.
+type HeavyQueriesTracker interface {
+	ObserveQuery(tenantID, path string, params url.Values, wallTime time.Duration, fetchedBytes uint64)
+}
+
 // Handler accepts queries and forwards them to RoundTripper. It can wait on in-flight requests and log slow queries,
 // all other logic is inside the RoundTripper.
 type Handler struct {
@@ -70,6 +81,8 @@ type Handler struct {
 	log          log.Logger
 	roundTripper http.RoundTripper
 	at           *activitytracker.ActivityTracker
+	recorder     QueryRecorder
+	heavyQueries HeavyQueriesTracker
 
 	// Metrics.
 	querySeconds    *prometheus.CounterVec
@@ -85,13 +98,15 @@ type Handler struct {
 	cond             *sync.Cond
 }
 
-// NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) *Handler {
+// NewHandler creates a new frontend handler. The recorder and the heavy queries tracker are optional and can be nil.
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker, recorder QueryRecorder, heavyQueries HeavyQueriesTracker) *Handler {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
 		roundTripper: roundTripper,
 		at:           at,
+		recorder:     recorder,
+		heavyQueries: heavyQueries,
 	}
 	h.cond = sync.NewCond(&h.mtx)
 
@@ -99,35 +114,35 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 		h.querySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_seconds_total",
 			Help: "Total amount of wall clock time spend processing queries.",
-		}, []string{"user", "sharded"})
+		}, []string{"user", "sharded", "source"})
 
 		h.querySeries = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_fetched_series_total",
 			Help: "Number of series fetched to execute a query.",
-		}, []string{"user"})
+		}, []string{"user", "source"})
 
 		h.queryChunkBytes = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_fetched_chunk_bytes_total",
 			Help: "Number of chunk bytes fetched to execute a query.",
-		}, []string{"user"})
+		}, []string{"user", "source"})
 
 		h.queryChunks = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_fetched_chunks_total",
 			Help: "Number of chunks fetched to execute a query.",
-		}, []string{"user"})
+		}, []string{"user", "source"})
 
 		h.queryIndexBytes = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_fetched_index_bytes_total",
 			Help: "Number of TSDB index bytes fetched from store-gateway to execute a query.",
-		}, []string{"user"})
+		}, []string{"user", "source"})
 
 		h.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
-			h.querySeconds.DeleteLabelValues(user, "true")
-			h.querySeconds.DeleteLabelValues(user, "false")
-			h.querySeries.DeleteLabelValues(user)
-			h.queryChunkBytes.DeleteLabelValues(user)
-			h.queryChunks.DeleteLabelValues(user)
-			h.queryIndexBytes.DeleteLabelValues(user)
+			filter := prometheus.Labels{"user": user}
+			h.querySeconds.DeletePartialMatch(filter)
+			h.querySeries.DeletePartialMatch(filter)
+			h.queryChunkBytes.DeletePartialMatch(filter)
+			h.queryChunks.DeletePartialMatch(filter)
+			h.queryIndexBytes.DeletePartialMatch(filter)
 		})
 		// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
 		_ = h.activeUsers.StartAsync(context.Background())
@@ -166,6 +181,10 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		f.mtx.Unlock()
 	}()
 
+	// Attach the source of the query (e.g. a rule evaluation run by the ruler) to the context,
+	// so that it's propagated down the request chain.
+	r = r.WithContext(querysource.ContextWithSource(r.Context(), querysource.FromHTTPRequest(r)))
+
 	var stats *querier_stats.Stats
 
 	// Initialise the stats in the context and make sure it's propagated
@@ -209,8 +228,10 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		writeError(w, err)
 		f.reportQueryStats(r, params, queryResponseTime, 0, stats, err)
+		f.recordQuery(r, params, startTime, queryResponseTime, statusCodeFromError(err))
 		return
 	}
+	defer func() { _ = resp.Body.Close() }()
 
 	hs := w.Header()
 	for h, vs := range resp.Header {
@@ -231,6 +252,21 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if f.cfg.QueryStatsEnabled {
 		f.reportQueryStats(r, params, queryResponseTime, queryResponseSize, stats, nil)
 	}
+	f.recordQuery(r, params, startTime, queryResponseTime, resp.StatusCode)
+}
+
+// recordQuery adds the query to the tenant's query recording, if any.
+func (f *Handler) recordQuery(r *http.Request, params url.Values, receivedAt time.Time, responseTime time.Duration, statusCode int) {
+	if f.recorder == nil {
+		return
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return
+	}
+
+	f.recorder.RecordQuery(tenant.JoinTenantIDs(tenantIDs), r.Method, r.URL.Path, params, receivedAt, responseTime, statusCode)
 }
 
 // reportSlowQuery reports slow queries.
@@ -262,21 +298,27 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 	numChunks := stats.LoadFetchedChunks()
 	numIndexBytes := stats.LoadFetchedIndexBytes()
 	sharded := strconv.FormatBool(stats.GetShardedQueries() > 0)
+	source := querysource.FromContext(r.Context())
 
 	if stats != nil {
 		// Track stats.
-		f.querySeconds.WithLabelValues(userID, sharded).Add(wallTime.Seconds())
-		f.querySeries.WithLabelValues(userID).Add(float64(numSeries))
-		f.queryChunkBytes.WithLabelValues(userID).Add(float64(numBytes))
-		f.queryChunks.WithLabelValues(userID).Add(float64(numChunks))
-		f.queryIndexBytes.WithLabelValues(userID).Add(float64(numIndexBytes))
+		f.querySeconds.WithLabelValues(userID, sharded, source).Add(wallTime.Seconds())
+		f.querySeries.WithLabelValues(userID, source).Add(float64(numSeries))
+		f.queryChunkBytes.WithLabelValues(userID, source).Add(float64(numBytes))
+		f.queryChunks.WithLabelValues(userID, source).Add(float64(numChunks))
+		f.queryIndexBytes.WithLabelValues(userID, source).Add(float64(numIndexBytes))
 		f.activeUsers.UpdateUserTimestamp(userID, time.Now())
+
+		if f.heavyQueries != nil {
+			f.heavyQueries.ObserveQuery(userID, r.URL.Path, queryString, wallTime, numBytes+numIndexBytes)
+		}
 	}
 
 	// Log stats.
 	logMessage := append([]interface{}{
 		"msg", "query stats",
 		"component", "query-frontend",
+		"source", source,
 		"method", r.Method,
 		"path", r.URL.Path,
 		"user_agent", r.UserAgent(),
@@ -290,6 +332,8 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 		"sharded_queries", stats.LoadShardedQueries(),
 		"split_queries", stats.LoadSplitQueries(),
 		"estimated_series_count", stats.GetEstimatedSeriesCount(),
+		"peak_samples", stats.LoadPeakSamples(),
+		"max_samples", stats.LoadMaxSamples(),
 	}, formatQueryString(queryString)...)
 
 	if len(f.cfg.LogQueryRequestHeaders) != 0 {
@@ -352,6 +396,26 @@ func writeError(w http.ResponseWriter, err error) {
 	server.WriteError(w, err)
 }
 
+// statusCodeFromError returns the HTTP status code written by writeError() for the input error.
+func statusCodeFromError(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case util.IsRequestBodyTooLarge(err):
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if resp, ok := apierror.HTTPResponseFromError(err); ok {
+		return int(resp.Code)
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return int(resp.Code)
+	}
+	return http.StatusInternalServerError
+}
+
 func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Header, stats *querier_stats.Stats) {
 	if stats != nil {
 		parts := make([]string, 0)
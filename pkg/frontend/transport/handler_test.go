@@ -31,7 +31,9 @@ import (
 	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
 
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -149,7 +151,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			t.Cleanup(func() { require.NoError(t, at.Close()) })
 
 			logger := &testLogger{}
-			handler := NewHandler(tt.cfg, roundTripper, logger, reg, at)
+			handler := NewHandler(tt.cfg, roundTripper, logger, reg, at, nil, nil)
 
 			req := tt.request().WithContext(user.InjectOrgID(context.Background(), "12345"))
 			resp := httptest.NewRecorder()
@@ -178,10 +180,11 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.Len(t, logger.logMessages, 1)
 
 				msg := logger.logMessages[0]
-				require.Len(t, msg, 18+len(tt.expectedParams))
+				require.Len(t, msg, 21+len(tt.expectedParams))
 				require.Equal(t, level.InfoValue(), msg["level"])
 				require.Equal(t, "query stats", msg["msg"])
 				require.Equal(t, "query-frontend", msg["component"])
+				require.Equal(t, "api", msg["source"])
 				require.Equal(t, "success", msg["status"])
 				require.Equal(t, "12345", msg["user"])
 				require.Equal(t, req.Method, msg["method"])
@@ -197,6 +200,8 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.EqualValues(t, 0, msg["sharded_queries"])
 				require.EqualValues(t, 0, msg["split_queries"])
 				require.EqualValues(t, 0, msg["estimated_series_count"])
+				require.EqualValues(t, 0, msg["peak_samples"])
+				require.EqualValues(t, 0, msg["max_samples"])
 
 				for name, values := range tt.expectedParams {
 					logMessageKey := fmt.Sprintf("param_%v", name)
@@ -210,6 +215,83 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestHandler_ShouldTrackQueryStatsBySource(t *testing.T) {
+	var actualSource string
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		actualSource = querysource.FromContext(req.Context())
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	logger := &testLogger{}
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, roundTripper, logger, reg, nil, nil, nil)
+
+	for _, source := range []string{querysource.Ruler, querysource.Alerting, ""} {
+		req := httptest.NewRequest("GET", "/api/v1/query?query=some_metric&time=42", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+		if source != "" {
+			req.Header.Set(querysource.HTTPHeader, source)
+		}
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		expectedSource := source
+		if expectedSource == "" {
+			expectedSource = querysource.API
+		}
+		assert.Equal(t, expectedSource, actualSource)
+		assert.Equal(t, expectedSource, logger.logMessages[len(logger.logMessages)-1]["source"])
+	}
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_fetched_series_total Number of series fetched to execute a query.
+		# TYPE cortex_query_fetched_series_total counter
+		cortex_query_fetched_series_total{source="alerting",user="12345"} 0
+		cortex_query_fetched_series_total{source="api",user="12345"} 0
+		cortex_query_fetched_series_total{source="ruler",user="12345"} 0
+	`), "cortex_query_fetched_series_total"))
+}
+
+type heavyQueriesTrackerFunc func(tenantID, path string, params url.Values, wallTime time.Duration, fetchedBytes uint64)
+
+func (f heavyQueriesTrackerFunc) ObserveQuery(tenantID, path string, params url.Values, wallTime time.Duration, fetchedBytes uint64) {
+	f(tenantID, path, params, wallTime, fetchedBytes)
+}
+
+func TestHandler_ShouldObserveHeavyQueries(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		stats := querier_stats.FromContext(req.Context())
+		stats.AddWallTime(time.Second)
+		stats.AddFetchedChunkBytes(100)
+		stats.AddFetchedIndexBytes(10)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	var observed []string
+	tracker := heavyQueriesTrackerFunc(func(tenantID, path string, params url.Values, wallTime time.Duration, fetchedBytes uint64) {
+		observed = append(observed, fmt.Sprintf("%s %s %s %s %d", tenantID, path, params.Get("query"), wallTime, fetchedBytes))
+	})
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, roundTripper, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil, nil, tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/query?query=some_metric&time=42", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	assert.Equal(t, []string{"12345 /api/v1/query some_metric 1s 110"}, observed)
+}
+
 func TestHandler_FailedRoundTrip(t *testing.T) {
 	for _, test := range []struct {
 		name                string
@@ -244,7 +326,7 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
 			logs := &concurrency.SyncBuffer{}
 			logger := log.NewLogfmtLogger(logs)
-			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil)
+			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil, nil, nil)
 
 			ctx := user.InjectOrgID(context.Background(), "12345")
 			req := httptest.NewRequest("GET", test.path, nil)
@@ -296,7 +378,7 @@ func TestHandler_Stop(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 	cfg := HandlerConfig{MaxBodySize: 1024}
 	logger := &testLogger{}
-	handler := NewHandler(cfg, roundTripper, logger, reg, nil)
+	handler := NewHandler(cfg, roundTripper, logger, reg, nil, nil, nil)
 
 	var wg sync.WaitGroup
 	for i := 0; i < requests; i++ {
@@ -13,6 +13,8 @@ import (
 
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/httpgrpc/server"
+
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
 // GrpcRoundTripper is similar to http.RoundTripper, but works with HTTP requests converted to protobuf messages.
@@ -44,6 +46,9 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 		return nil, err
 	}
 
+	// Propagate the source of the query to the querier.
+	req.Headers = setHeader(req.Headers, querysource.HTTPHeader, querysource.FromContext(r.Context()))
+
 	resp, err := a.roundTripper.RoundTripGRPC(r.Context(), req)
 	if err != nil {
 		var ok bool
@@ -63,3 +68,14 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 	}
 	return httpResp, nil
 }
+
+// setHeader sets the header to the given value, replacing any existing value.
+func setHeader(headers []*httpgrpc.Header, key, value string) []*httpgrpc.Header {
+	for _, h := range headers {
+		if h.Key == key {
+			h.Values = []string{value}
+			return headers
+		}
+	}
+	return append(headers, &httpgrpc.Header{Key: key, Values: []string{value}})
+}
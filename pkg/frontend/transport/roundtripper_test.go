@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/querysource"
+)
+
+type grpcRoundTripperFunc func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+
+func (f grpcRoundTripperFunc) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	return f(ctx, req)
+}
+
+func TestGrpcRoundTripperAdapter_ShouldPropagateQuerySource(t *testing.T) {
+	tests := map[string]struct {
+		ctx            context.Context
+		header         string
+		expectedSource string
+	}{
+		"no source in the context": {
+			ctx:            context.Background(),
+			expectedSource: querysource.API,
+		},
+		"source in the context": {
+			ctx:            querysource.ContextWithSource(context.Background(), querysource.Ruler),
+			expectedSource: querysource.Ruler,
+		},
+		"source in the context overrides the request header": {
+			ctx:            querysource.ContextWithSource(context.Background(), querysource.Alerting),
+			header:         querysource.Ruler,
+			expectedSource: querysource.Alerting,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual []string
+			adapter := AdaptGrpcRoundTripperToHTTPRoundTripper(grpcRoundTripperFunc(func(_ context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+				for _, h := range req.Headers {
+					if h.Key == querysource.HTTPHeader {
+						actual = append(actual, h.Values...)
+					}
+				}
+				return &httpgrpc.HTTPResponse{Code: http.StatusOK}, nil
+			}))
+
+			req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil).WithContext(testData.ctx)
+			if testData.header != "" {
+				req.Header.Set(querysource.HTTPHeader, testData.header)
+			}
+
+			_, err := adapter.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, []string{testData.expectedSource}, actual)
+		})
+	}
+}
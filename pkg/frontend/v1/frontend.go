@@ -244,6 +244,8 @@ func (f *Frontend) Process(server frontendv1pb.Frontend_ProcessServer) error {
 				Type:         frontendv1pb.HTTP_REQUEST,
 				HttpRequest:  req.request,
 				StatsEnabled: stats.IsEnabled(req.originalCtx),
+				// Propagate the time left to execute the query, excluding the time spent in the queue.
+				Timeout: util.RemainingTimeout(req.originalCtx),
 			})
 			if err != nil {
 				errs <- err
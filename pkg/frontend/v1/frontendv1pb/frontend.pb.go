@@ -11,6 +11,8 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+	_ "github.com/golang/protobuf/ptypes/duration"
 	stats "github.com/grafana/mimir/pkg/querier/stats"
 	httpgrpc "github.com/weaveworks/common/httpgrpc"
 	grpc "google.golang.org/grpc"
@@ -22,12 +24,14 @@ import (
 	reflect "reflect"
 	strconv "strconv"
 	strings "strings"
+	time "time"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -62,6 +66,9 @@ type FrontendToClient struct {
 	// Whether query statistics tracking should be enabled. The response will include
 	// statistics only when this option is enabled.
 	StatsEnabled bool `protobuf:"varint,3,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// The time left to execute the query, after which the query has been abandoned by the frontend.
+	// The client should stop working on the query once it has elapsed. 0 means no timeout.
+	Timeout time.Duration `protobuf:"bytes,4,opt,name=timeout,proto3,stdduration" json:"timeout"`
 }
 
 func (m *FrontendToClient) Reset()      { *m = FrontendToClient{} }
@@ -117,6 +124,13 @@ func (m *FrontendToClient) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *FrontendToClient) GetTimeout() time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
 type ClientToFrontend struct {
 	HttpResponse *httpgrpc.HTTPResponse `protobuf:"bytes,1,opt,name=httpResponse,proto3" json:"httpResponse,omitempty"`
 	ClientID     string                 `protobuf:"bytes,2,opt,name=clientID,proto3" json:"clientID,omitempty"`
@@ -265,38 +279,41 @@ func init() {
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 487 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0x41, 0x6e, 0xd3, 0x40,
-	0x14, 0x86, 0xfd, 0xa0, 0x94, 0xf0, 0x6a, 0x45, 0xd6, 0xa8, 0xa0, 0xc8, 0xa0, 0x51, 0x64, 0x01,
-	0x8a, 0x58, 0xd8, 0x10, 0x10, 0x08, 0x96, 0xa5, 0xa1, 0x74, 0x83, 0x8a, 0x63, 0x36, 0x6c, 0x2a,
-	0xc7, 0x99, 0x38, 0x56, 0x6b, 0x8f, 0x3b, 0x9e, 0x34, 0xca, 0x8e, 0x13, 0x20, 0x24, 0x2e, 0xc1,
-	0x19, 0x38, 0x01, 0xcb, 0x2c, 0xbb, 0x24, 0xce, 0x86, 0x65, 0x8f, 0x80, 0xec, 0x49, 0xdc, 0x24,
-	0xaa, 0x60, 0x33, 0x9a, 0x99, 0x7f, 0xde, 0x7b, 0xdf, 0xff, 0xdb, 0x58, 0x1f, 0x08, 0x9e, 0x48,
-	0x96, 0xf4, 0xed, 0x54, 0x70, 0xc9, 0x49, 0x6d, 0x79, 0x36, 0x77, 0x43, 0x1e, 0xf2, 0xf2, 0xd2,
-	0x29, 0x76, 0x4a, 0x37, 0x5f, 0x84, 0x91, 0x1c, 0x8e, 0x7a, 0x76, 0xc0, 0x63, 0x67, 0xcc, 0xfc,
-	0x73, 0x36, 0xe6, 0xe2, 0x24, 0x73, 0x02, 0x1e, 0xc7, 0x3c, 0x71, 0x86, 0x52, 0xa6, 0xa1, 0x48,
-	0x83, 0x6a, 0xb3, 0xa8, 0x7a, 0xb9, 0x52, 0x15, 0x0a, 0x7f, 0xe0, 0x27, 0xbe, 0x13, 0x47, 0x71,
-	0x24, 0x9c, 0xf4, 0x24, 0x74, 0xce, 0x46, 0x4c, 0x44, 0x4c, 0x38, 0x99, 0xf4, 0x65, 0xa6, 0x56,
-	0x55, 0x67, 0x7d, 0x07, 0x34, 0xde, 0x2d, 0x80, 0x3c, 0xfe, 0xf6, 0x34, 0x62, 0x89, 0x24, 0xaf,
-	0x70, 0xa7, 0x68, 0xef, 0xb2, 0xb3, 0x11, 0xcb, 0x64, 0x03, 0x9a, 0xd0, 0xda, 0x69, 0xdf, 0xb5,
-	0xab, 0x91, 0xef, 0x3d, 0xef, 0x68, 0x21, 0xba, 0xab, 0x2f, 0x89, 0x85, 0x5b, 0x72, 0x92, 0xb2,
-	0xc6, 0x8d, 0x26, 0xb4, 0xea, 0xed, 0xba, 0x5d, 0x59, 0xf7, 0x26, 0x29, 0x73, 0x4b, 0x8d, 0x58,
-	0xa8, 0x97, 0x00, 0x9d, 0xc4, 0xef, 0x9d, 0xb2, 0x7e, 0xe3, 0x66, 0x13, 0x5a, 0x35, 0x77, 0xed,
-	0xce, 0xfa, 0x0a, 0x68, 0x28, 0x16, 0x8f, 0x2f, 0xe9, 0xc8, 0x1b, 0xd4, 0xd5, 0xac, 0x2c, 0xe5,
-	0x49, 0xc6, 0x16, 0x58, 0xf7, 0x36, 0xb1, 0x94, 0xea, 0xae, 0xbd, 0x25, 0x26, 0xd6, 0x82, 0xb2,
-	0xdf, 0xe1, 0x7e, 0x09, 0x77, 0xc7, 0xad, 0xce, 0xc4, 0xc2, 0x5b, 0xe5, 0xf0, 0x92, 0x64, 0xa7,
-	0xad, 0xdb, 0x2a, 0x9f, 0x6e, 0xb1, 0xba, 0x4a, 0xb2, 0x5e, 0xe3, 0xfd, 0x0f, 0x5c, 0x46, 0x83,
-	0x89, 0xa2, 0xea, 0x0e, 0x47, 0xb2, 0xcf, 0xc7, 0xc9, 0xd2, 0xf7, 0x6a, 0x7b, 0x58, 0x6f, 0x6f,
-	0x51, 0x7c, 0x70, 0x7d, 0xa9, 0x42, 0x7b, 0xf2, 0x10, 0xb7, 0x8a, 0x74, 0x88, 0x81, 0x7a, 0x61,
-	0xe0, 0xd8, 0xed, 0x7c, 0xfc, 0xd4, 0xe9, 0x7a, 0x86, 0x46, 0x10, 0xb7, 0x0f, 0x3a, 0xde, 0xf1,
-	0xe1, 0xbe, 0x01, 0xed, 0x9f, 0x80, 0xb5, 0x2a, 0x89, 0x03, 0xbc, 0x7d, 0x24, 0x78, 0xc0, 0xb2,
-	0x8c, 0x98, 0x57, 0x19, 0x6f, 0x06, 0x66, 0xae, 0x68, 0x9b, 0x9f, 0xd8, 0xd2, 0x5a, 0xf0, 0x14,
-	0x08, 0xc3, 0xdd, 0xeb, 0xd8, 0xc8, 0xa3, 0xab, 0xca, 0x7f, 0xd8, 0x36, 0x1f, 0xff, 0xef, 0x99,
-	0xb2, 0xb8, 0xb7, 0x37, 0x9d, 0x51, 0xed, 0x62, 0x46, 0xb5, 0xcb, 0x19, 0x85, 0x2f, 0x39, 0x85,
-	0x1f, 0x39, 0x85, 0x5f, 0x39, 0x85, 0x69, 0x4e, 0xe1, 0x77, 0x4e, 0xe1, 0x4f, 0x4e, 0xb5, 0xcb,
-	0x9c, 0xc2, 0xb7, 0x39, 0xd5, 0xa6, 0x73, 0xaa, 0x5d, 0xcc, 0xa9, 0xf6, 0x59, 0x5f, 0x36, 0x3f,
-	0x7f, 0x96, 0xf6, 0x7a, 0xdb, 0xe5, 0xff, 0xfa, 0xfc, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xa3,
-	0x66, 0xe8, 0x9e, 0x4f, 0x03, 0x00, 0x00,
+	// 541 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0xc1, 0x6e, 0xd3, 0x30,
+	0x18, 0x8e, 0xa1, 0x6c, 0xc5, 0xad, 0xaa, 0xca, 0x1a, 0xa8, 0x04, 0xe4, 0x55, 0x11, 0xa0, 0x8a,
+	0x43, 0x0c, 0x05, 0x81, 0x40, 0xe2, 0x52, 0x5a, 0xc6, 0x2e, 0x68, 0xa4, 0xe1, 0xc2, 0x65, 0x4a,
+	0x5b, 0x37, 0x8d, 0xd6, 0xc4, 0x99, 0xe3, 0xac, 0xea, 0x8d, 0x27, 0x40, 0x1c, 0x79, 0x04, 0x9e,
+	0x81, 0x27, 0xd8, 0xb1, 0xe2, 0xb4, 0x13, 0xd0, 0xf4, 0xc2, 0x71, 0x8f, 0x80, 0x6a, 0x27, 0x59,
+	0x5b, 0x4d, 0xec, 0x62, 0xf9, 0xf7, 0xf7, 0x7f, 0xbf, 0xbf, 0xef, 0xb3, 0x61, 0x65, 0xc8, 0x59,
+	0x20, 0x68, 0x30, 0x30, 0x43, 0xce, 0x04, 0x43, 0xc5, 0xac, 0xd6, 0x77, 0x5c, 0xe6, 0x32, 0x79,
+	0x48, 0x96, 0x3b, 0x85, 0xeb, 0xd8, 0x65, 0xcc, 0x1d, 0x53, 0x22, 0xab, 0x5e, 0x3c, 0x24, 0x83,
+	0x98, 0x3b, 0xc2, 0x63, 0x41, 0x8a, 0x3f, 0x73, 0x3d, 0x31, 0x8a, 0x7b, 0x66, 0x9f, 0xf9, 0x64,
+	0x42, 0x9d, 0x13, 0x3a, 0x61, 0xfc, 0x28, 0x22, 0x7d, 0xe6, 0xfb, 0x2c, 0x20, 0x23, 0x21, 0x42,
+	0x97, 0x87, 0xfd, 0x7c, 0x93, 0xb2, 0x9e, 0xaf, 0xb0, 0x5c, 0xee, 0x0c, 0x9d, 0xc0, 0x21, 0xbe,
+	0xe7, 0x7b, 0x9c, 0x84, 0x47, 0x2e, 0x39, 0x8e, 0x29, 0xf7, 0x28, 0x27, 0x91, 0x70, 0x44, 0xa4,
+	0x56, 0xc5, 0x33, 0x7e, 0x02, 0x58, 0x7d, 0x9b, 0x0a, 0xb6, 0xd9, 0x9b, 0xb1, 0x47, 0x03, 0x81,
+	0x5e, 0xc0, 0xd2, 0x72, 0xbc, 0x45, 0x8f, 0x63, 0x1a, 0x89, 0x1a, 0xa8, 0x83, 0x46, 0xa9, 0x79,
+	0xcb, 0xcc, 0xaf, 0x7c, 0x67, 0xdb, 0x07, 0x29, 0x68, 0xad, 0x76, 0x22, 0x03, 0x16, 0xc4, 0x34,
+	0xa4, 0xb5, 0x6b, 0x75, 0xd0, 0xa8, 0x34, 0x2b, 0x66, 0x1e, 0x8d, 0x3d, 0x0d, 0xa9, 0x25, 0x31,
+	0x64, 0xc0, 0xb2, 0x14, 0xd0, 0x09, 0x9c, 0xde, 0x98, 0x0e, 0x6a, 0xd7, 0xeb, 0xa0, 0x51, 0xb4,
+	0xd6, 0xce, 0xd0, 0x6b, 0xb8, 0x2d, 0x3c, 0x9f, 0xb2, 0x58, 0xd4, 0x0a, 0xf2, 0xf2, 0x3b, 0xa6,
+	0x4a, 0xcd, 0xcc, 0x52, 0x33, 0xdb, 0x69, 0x6a, 0xad, 0xe2, 0xe9, 0xaf, 0x5d, 0xed, 0xdb, 0xef,
+	0x5d, 0x60, 0x65, 0x1c, 0xe3, 0x0b, 0x80, 0x55, 0x65, 0xc5, 0x66, 0x99, 0x39, 0xf4, 0x0a, 0x96,
+	0x95, 0xd4, 0x28, 0x64, 0x41, 0x44, 0x53, 0x57, 0xb7, 0x37, 0x5d, 0x29, 0xd4, 0x5a, 0xeb, 0x45,
+	0x3a, 0x2c, 0xf6, 0xe5, 0xbc, 0xfd, 0xb6, 0xf4, 0x76, 0xd3, 0xca, 0x6b, 0x64, 0xc0, 0x1b, 0x52,
+	0xbb, 0x34, 0x52, 0x6a, 0x96, 0x4d, 0x15, 0x6f, 0x77, 0xb9, 0x5a, 0x0a, 0x32, 0x5e, 0xc2, 0xbb,
+	0xef, 0x99, 0xf0, 0x86, 0x53, 0xa5, 0xaa, 0x3b, 0x8a, 0xc5, 0x80, 0x4d, 0x82, 0x2c, 0xb6, 0xd5,
+	0xf1, 0x60, 0x7d, 0xbc, 0x81, 0xe1, 0xbd, 0xcb, 0xa9, 0x4a, 0xda, 0xa3, 0xfb, 0xb0, 0xb0, 0x0c,
+	0x17, 0x55, 0x61, 0x79, 0x69, 0xe0, 0xd0, 0xea, 0x7c, 0xf8, 0xd8, 0xe9, 0xda, 0x55, 0x0d, 0x41,
+	0xb8, 0xb5, 0xd7, 0xb1, 0x0f, 0xf7, 0xdb, 0x55, 0xd0, 0xfc, 0x01, 0x60, 0x31, 0x4f, 0x62, 0x0f,
+	0x6e, 0x1f, 0x70, 0xd6, 0xa7, 0x51, 0x84, 0xf4, 0x8b, 0x27, 0xda, 0x0c, 0x4c, 0x5f, 0xc1, 0x36,
+	0x7f, 0x88, 0xa1, 0x35, 0xc0, 0x63, 0x80, 0x28, 0xdc, 0xb9, 0x4c, 0x1b, 0x7a, 0x70, 0xc1, 0xfc,
+	0x8f, 0x6d, 0xfd, 0xe1, 0x55, 0x6d, 0xca, 0x62, 0xab, 0x35, 0x9b, 0x63, 0xed, 0x6c, 0x8e, 0xb5,
+	0xf3, 0x39, 0x06, 0x9f, 0x13, 0x0c, 0xbe, 0x27, 0x18, 0x9c, 0x26, 0x18, 0xcc, 0x12, 0x0c, 0xfe,
+	0x24, 0x18, 0xfc, 0x4d, 0xb0, 0x76, 0x9e, 0x60, 0xf0, 0x75, 0x81, 0xb5, 0xd9, 0x02, 0x6b, 0x67,
+	0x0b, 0xac, 0x7d, 0x2a, 0x67, 0xc3, 0x4f, 0x9e, 0x84, 0xbd, 0xde, 0x96, 0xfc, 0x38, 0x4f, 0xff,
+	0x05, 0x00, 0x00, 0xff, 0xff, 0x20, 0x3d, 0x72, 0xc1, 0xae, 0x03, 0x00, 0x00,
 }
 
 func (x Type) String() string {
@@ -334,6 +351,9 @@ func (this *FrontendToClient) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.Timeout != that1.Timeout {
+		return false
+	}
 	return true
 }
 func (this *ClientToFrontend) Equal(that interface{}) bool {
@@ -415,13 +435,14 @@ func (this *FrontendToClient) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&frontendv1pb.FrontendToClient{")
 	if this.HttpRequest != nil {
 		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
 	}
 	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "Timeout: "+fmt.Sprintf("%#v", this.Timeout)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -644,6 +665,14 @@ func (m *FrontendToClient) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintFrontend(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x22
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -808,6 +837,8 @@ func (m *FrontendToClient) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovFrontend(uint64(l))
 	return n
 }
 
@@ -868,6 +899,7 @@ func (this *FrontendToClient) String() string {
 		`HttpRequest:` + strings.Replace(fmt.Sprintf("%v", this.HttpRequest), "HTTPRequest", "httpgrpc.HTTPRequest", 1) + `,`,
 		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`Timeout:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timeout), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1015,6 +1047,39 @@ func (m *FrontendToClient) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFrontend(dAtA[iNdEx:])
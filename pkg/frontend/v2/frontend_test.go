@@ -134,6 +134,34 @@ func TestFrontendBasicWorkflow(t *testing.T) {
 	require.Equal(t, []byte(body), resp.Body)
 }
 
+func TestFrontendPropagatesTimeoutToScheduler(t *testing.T) {
+	const userID = "test"
+
+	var enqueuedTimeout atomic.Int64
+
+	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		enqueuedTimeout.Store(int64(msg.Timeout))
+
+		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{Code: 200})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	// No timeout is propagated if the request has no deadline.
+	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), enqueuedTimeout.Load())
+
+	// The time left to execute the request is propagated otherwise.
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), userID), time.Minute)
+	defer cancel()
+
+	_, err = f.RoundTripGRPC(ctx, &httpgrpc.HTTPRequest{})
+	require.NoError(t, err)
+	require.Greater(t, enqueuedTimeout.Load(), int64(0))
+	require.LessOrEqual(t, enqueuedTimeout.Load(), int64(time.Minute))
+}
+
 func TestFrontendRequestsPerWorkerMetric(t *testing.T) {
 	const (
 		body   = "all fine here"
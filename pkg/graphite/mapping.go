@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// MappingRule maps the Graphite metric paths matching a pattern to a metric name and labels.
+type MappingRule struct {
+	// Match is the pattern of the metric paths the rule applies to. Each * matches any string within a
+	// single path component, that is without dots.
+	Match string `yaml:"match"`
+	// Name is the metric name of the matching paths. $n is replaced with the string matched by the nth *.
+	Name string `yaml:"name"`
+	// Labels are the labels added to the matching paths. $n in the values is replaced with the string
+	// matched by the nth *.
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+func (r MappingRule) validate() error {
+	if r.Match == "" {
+		return fmt.Errorf("the match pattern of the mapping rules must not be empty")
+	}
+	if r.Name == "" {
+		return fmt.Errorf("the name of the mapping rule matching %q must not be empty", r.Match)
+	}
+	for name := range r.Labels {
+		if !model.LabelName(name).IsValid() || name == model.MetricNameLabel {
+			return fmt.Errorf("invalid label name %q in the mapping rule matching %q", name, r.Match)
+		}
+	}
+	return nil
+}
+
+type compiledMappingRule struct {
+	MappingRule
+	regexp *regexp.Regexp
+}
+
+// mapper converts Graphite metric paths to labels.
+type mapper struct {
+	rules []compiledMappingRule
+}
+
+func newMapper(rules []MappingRule) (*mapper, error) {
+	m := &mapper{rules: make([]compiledMappingRule, 0, len(rules))}
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+
+		parts := strings.Split(r.Match, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		re, err := regexp.Compile("^" + strings.Join(parts, "([^.]*)") + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %w", r.Match, err)
+		}
+
+		m.rules = append(m.rules, compiledMappingRule{MappingRule: r, regexp: re})
+	}
+	return m, nil
+}
+
+// labels returns the labels of the metric path, sorted by name. The path may be followed by Graphite tags,
+// like path;tag1=value1;tag2=value2, which are added as labels. The path is mapped by the first matching rule,
+// or, if none matches, to a metric name made of the path with its dots replaced by underscores.
+func (m *mapper) labels(path string) ([]mimirpb.LabelAdapter, error) {
+	path, tags, _ := strings.Cut(path, ";")
+	if path == "" {
+		return nil, fmt.Errorf("empty metric path")
+	}
+
+	var labels []mimirpb.LabelAdapter
+	for _, tag := range strings.Split(tags, ";") {
+		if tag == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(tag, "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		labels = append(labels, mimirpb.LabelAdapter{Name: sanitizeName(name), Value: value})
+	}
+
+	name := ""
+	for _, r := range m.rules {
+		match := r.regexp.FindStringSubmatchIndex(path)
+		if match == nil {
+			continue
+		}
+
+		name = string(r.regexp.ExpandString(nil, r.Name, path, match))
+		for labelName, template := range r.Labels {
+			labels = append(labels, mimirpb.LabelAdapter{Name: labelName, Value: string(r.regexp.ExpandString(nil, template, path, match))})
+		}
+		break
+	}
+	if name == "" {
+		name = path
+	}
+	labels = append(labels, mimirpb.LabelAdapter{Name: model.MetricNameLabel, Value: sanitizeName(name)})
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	// Remove the labels with an empty value, and reject the duplicate ones.
+	out := labels[:0]
+	for _, l := range labels {
+		if l.Value == "" {
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1].Name == l.Name {
+			return nil, fmt.Errorf("duplicate label %q", l.Name)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// sanitizeName replaces the characters not allowed in Prometheus metric and label names, including dots,
+// with underscores.
+func sanitizeName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || (c >= '0' && c <= '9' && i > 0)) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestMapper_Labels(t *testing.T) {
+	m, err := newMapper([]MappingRule{
+		{
+			Match:  "servers.*.cpu.*",
+			Name:   "cpu_$2",
+			Labels: map[string]string{"host": "$1"},
+		},
+		{
+			Match:  "servers.*.*",
+			Name:   "server_$2",
+			Labels: map[string]string{"host": "$1", "source": "graphite"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		path     string
+		expected []mimirpb.LabelAdapter
+		err      string
+	}{
+		"first matching rule": {
+			path:     "servers.web-1.cpu.idle",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "cpu_idle"}, {Name: "host", Value: "web-1"}},
+		},
+		"second matching rule": {
+			path:     "servers.web-1.uptime",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "server_uptime"}, {Name: "host", Value: "web-1"}, {Name: "source", Value: "graphite"}},
+		},
+		"no matching rule": {
+			path:     "app.requests.count",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "app_requests_count"}},
+		},
+		"* doesn't match dots": {
+			path:     "servers.web-1.disk.sda.used",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "servers_web_1_disk_sda_used"}},
+		},
+		"empty matched component": {
+			path:     "servers..uptime",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "server_uptime"}, {Name: "source", Value: "graphite"}},
+		},
+		"tags": {
+			path:     "app.requests;env=prod;dc.name=eu",
+			expected: []mimirpb.LabelAdapter{{Name: "__name__", Value: "app_requests"}, {Name: "dc_name", Value: "eu"}, {Name: "env", Value: "prod"}},
+		},
+		"tag conflicting with a mapped label": {
+			path: "servers.web-1.uptime;host=web-2",
+			err:  `duplicate label "host"`,
+		},
+		"invalid tag": {
+			path: "app.requests;env",
+			err:  `invalid tag "env"`,
+		},
+		"empty path": {
+			path: ";env=prod",
+			err:  "empty metric path",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			labels, err := m.labels(tc.path)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, labels)
+		})
+	}
+}
+
+func TestNewMapper_InvalidRules(t *testing.T) {
+	_, err := newMapper([]MappingRule{{Match: "foo.*", Name: "foo", Labels: map[string]string{"__name__": "bar"}}})
+	require.ErrorContains(t, err, `invalid label name "__name__"`)
+
+	_, err = newMapper([]MappingRule{{Match: "foo.*"}})
+	require.ErrorContains(t, err, "must not be empty")
+}
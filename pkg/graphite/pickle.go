@@ -0,0 +1,554 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The pickle opcodes used to serialize the carbon pickle messages, which are lists of (path, (timestamp, value))
+// tuples. See https://github.com/python/cpython/blob/main/Lib/pickletools.py for their specification.
+const (
+	pickleMark           = '('
+	pickleStop           = '.'
+	picklePop            = '0'
+	picklePopMark        = '1'
+	pickleDup            = '2'
+	pickleFloat          = 'F'
+	pickleInt            = 'I'
+	pickleBinInt         = 'J'
+	pickleBinInt1        = 'K'
+	pickleLong           = 'L'
+	pickleBinInt2        = 'M'
+	pickleNone           = 'N'
+	pickleBinFloat       = 'G'
+	pickleString         = 'S'
+	pickleBinString      = 'T'
+	pickleShortBinString = 'U'
+	pickleUnicode        = 'V'
+	pickleBinUnicode     = 'X'
+	pickleBinBytes       = 'B'
+	pickleShortBinBytes  = 'C'
+	pickleAppend         = 'a'
+	pickleAppends        = 'e'
+	pickleGet            = 'g'
+	pickleBinGet         = 'h'
+	pickleLongBinGet     = 'j'
+	pickleList           = 'l'
+	pickleEmptyList      = ']'
+	picklePut            = 'p'
+	pickleBinPut         = 'q'
+	pickleLongBinPut     = 'r'
+	pickleTuple          = 't'
+	pickleEmptyTuple     = ')'
+	pickleProto          = 0x80
+	pickleTuple1         = 0x85
+	pickleTuple2         = 0x86
+	pickleTuple3         = 0x87
+	pickleNewTrue        = 0x88
+	pickleNewFalse       = 0x89
+	pickleLong1          = 0x8a
+	pickleLong4          = 0x8b
+	pickleShortBinUnicod = 0x8c
+	pickleBinUnicode8    = 0x8d
+	pickleBinBytes8      = 0x8e
+	pickleMemoize        = 0x94
+	pickleFrame          = 0x95
+)
+
+// Carbon only sends int64 and float values, so larger integers are rejected rather than parsed, since parsing
+// arbitrarily large integers takes quadratic time.
+const (
+	// Maximum length of the INT and LONG decimal lines, which is enough for any 64 bits integer.
+	maxPickleTextIntLength = 32
+
+	// Maximum size, in bytes, of the LONG1 and LONG4 two's complement integers.
+	maxPickleLongSize = 16
+)
+
+// pickleListValue is a mutable list, which may be referenced from the memo while being appended to.
+type pickleListValue struct {
+	items []interface{}
+}
+
+// pickleMarkValue is the marker pushed to the stack by the MARK opcode.
+type pickleMarkValue struct{}
+
+// unpickler decodes the subset of the pickle format needed to decode carbon pickle messages: lists, tuples,
+// strings, numbers, booleans and None, in any protocol version.
+type unpickler struct {
+	data  []byte
+	pos   int
+	stack []interface{}
+	memo  map[int]interface{}
+}
+
+// decodePickle decodes the carbon pickle message, returning its samples. A message is a list of
+// (path, (timestamp, value)) tuples.
+func decodePickle(data []byte, now time.Time) ([]sample, error) {
+	u := unpickler{data: data, memo: map[int]interface{}{}}
+	v, err := u.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := v.(*pickleListValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected pickle message, expected a list")
+	}
+
+	samples := make([]sample, 0, len(list.items))
+	for _, item := range list.items {
+		s, err := pickleSample(item, now)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func pickleSample(item interface{}, now time.Time) (sample, error) {
+	metric, ok := item.([]interface{})
+	if !ok || len(metric) != 2 {
+		return sample{}, fmt.Errorf("unexpected pickle metric, expected a (path, (timestamp, value)) tuple")
+	}
+	path, ok := metric[0].(string)
+	if !ok {
+		return sample{}, fmt.Errorf("unexpected pickle metric path, expected a string")
+	}
+	datapoint, ok := metric[1].([]interface{})
+	if !ok || len(datapoint) != 2 {
+		return sample{}, fmt.Errorf("unexpected pickle datapoint of %q, expected a (timestamp, value) tuple", path)
+	}
+
+	timestamp, err := pickleNumber(datapoint[0])
+	if err != nil {
+		return sample{}, fmt.Errorf("invalid timestamp of %q: %w", path, err)
+	}
+	value, err := pickleNumber(datapoint[1])
+	if err != nil {
+		return sample{}, fmt.Errorf("invalid value of %q: %w", path, err)
+	}
+
+	return sample{path: path, value: value, timestampMs: timestampToMillis(timestamp, now)}, nil
+}
+
+// pickleNumber returns the number, or the number in the string.
+func pickleNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("unexpected value %v", v)
+	}
+}
+
+func (u *unpickler) decode() (interface{}, error) {
+	for {
+		op, err := u.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case pickleProto:
+			_, err = u.read(1)
+		case pickleFrame:
+			_, err = u.read(8)
+		case pickleStop:
+			return u.pop()
+		case pickleMark:
+			u.push(pickleMarkValue{})
+		case picklePop:
+			_, err = u.pop()
+		case picklePopMark:
+			_, err = u.popMark()
+		case pickleDup:
+			var v interface{}
+			if v, err = u.top(); err == nil {
+				u.push(v)
+			}
+		case pickleNone:
+			u.push(nil)
+		case pickleNewTrue:
+			u.push(true)
+		case pickleNewFalse:
+			u.push(false)
+
+		case pickleInt:
+			err = u.decodeTextInt()
+		case pickleLong:
+			err = u.decodeTextLong()
+		case pickleBinInt:
+			err = u.decodeFixed(4, func(b []byte) interface{} { return int64(int32(binary.LittleEndian.Uint32(b))) })
+		case pickleBinInt1:
+			err = u.decodeFixed(1, func(b []byte) interface{} { return int64(b[0]) })
+		case pickleBinInt2:
+			err = u.decodeFixed(2, func(b []byte) interface{} { return int64(binary.LittleEndian.Uint16(b)) })
+		case pickleLong1:
+			err = u.decodeLong(1)
+		case pickleLong4:
+			err = u.decodeLong(4)
+		case pickleFloat:
+			var line string
+			if line, err = u.readLine(); err == nil {
+				var f float64
+				if f, err = strconv.ParseFloat(line, 64); err == nil {
+					u.push(f)
+				}
+			}
+		case pickleBinFloat:
+			err = u.decodeFixed(8, func(b []byte) interface{} { return math.Float64frombits(binary.BigEndian.Uint64(b)) })
+
+		case pickleString:
+			var line string
+			if line, err = u.readLine(); err == nil {
+				var s string
+				if s, err = unquotePickleString(line); err == nil {
+					u.push(s)
+				}
+			}
+		case pickleUnicode:
+			var line string
+			if line, err = u.readLine(); err == nil {
+				u.push(line)
+			}
+		case pickleShortBinString, pickleShortBinBytes, pickleShortBinUnicod:
+			err = u.decodeString(1)
+		case pickleBinString, pickleBinBytes, pickleBinUnicode:
+			err = u.decodeString(4)
+		case pickleBinUnicode8, pickleBinBytes8:
+			err = u.decodeString(8)
+
+		case pickleEmptyList:
+			u.push(&pickleListValue{})
+		case pickleList:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				u.push(&pickleListValue{items: items})
+			}
+		case pickleAppend:
+			var v interface{}
+			if v, err = u.pop(); err == nil {
+				err = u.appendToList(v)
+			}
+		case pickleAppends:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				err = u.appendToList(items...)
+			}
+
+		case pickleEmptyTuple:
+			u.push([]interface{}{})
+		case pickleTuple:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				u.push(items)
+			}
+		case pickleTuple1, pickleTuple2, pickleTuple3:
+			err = u.decodeTuple(int(op-pickleTuple1) + 1)
+
+		case pickleGet:
+			err = u.decodeTextMemoIndex(u.get)
+		case pickleBinGet:
+			err = u.decodeMemoIndex(1, u.get)
+		case pickleLongBinGet:
+			err = u.decodeMemoIndex(4, u.get)
+		case picklePut:
+			err = u.decodeTextMemoIndex(u.put)
+		case pickleBinPut:
+			err = u.decodeMemoIndex(1, u.put)
+		case pickleLongBinPut:
+			err = u.decodeMemoIndex(4, u.put)
+		case pickleMemoize:
+			err = u.put(len(u.memo))
+
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode 0x%x", op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (u *unpickler) push(v interface{}) {
+	u.stack = append(u.stack, v)
+}
+
+func (u *unpickler) top() (interface{}, error) {
+	if len(u.stack) == 0 {
+		return nil, errors.New("pickle stack underflow")
+	}
+	return u.stack[len(u.stack)-1], nil
+}
+
+func (u *unpickler) pop() (interface{}, error) {
+	v, err := u.top()
+	if err != nil {
+		return nil, err
+	}
+	u.stack = u.stack[:len(u.stack)-1]
+	return v, nil
+}
+
+// popMark pops the items up to the topmost mark, and the mark itself.
+func (u *unpickler) popMark() ([]interface{}, error) {
+	for i := len(u.stack) - 1; i >= 0; i-- {
+		if _, ok := u.stack[i].(pickleMarkValue); ok {
+			items := append([]interface{}(nil), u.stack[i+1:]...)
+			u.stack = u.stack[:i]
+			return items, nil
+		}
+	}
+	return nil, errors.New("pickle mark not found")
+}
+
+func (u *unpickler) appendToList(items ...interface{}) error {
+	v, err := u.top()
+	if err != nil {
+		return err
+	}
+	list, ok := v.(*pickleListValue)
+	if !ok {
+		return errors.New("unexpected pickle append to a non-list")
+	}
+	list.items = append(list.items, items...)
+	return nil
+}
+
+func (u *unpickler) decodeTuple(size int) error {
+	if len(u.stack) < size {
+		return errors.New("pickle stack underflow")
+	}
+	items := append([]interface{}(nil), u.stack[len(u.stack)-size:]...)
+	u.stack = u.stack[:len(u.stack)-size]
+	u.push(items)
+	return nil
+}
+
+func (u *unpickler) get(index int) error {
+	v, ok := u.memo[index]
+	if !ok {
+		return fmt.Errorf("pickle memo index %d not found", index)
+	}
+	u.push(v)
+	return nil
+}
+
+func (u *unpickler) put(index int) error {
+	v, err := u.top()
+	if err != nil {
+		return err
+	}
+	u.memo[index] = v
+	return nil
+}
+
+func (u *unpickler) decodeMemoIndex(size int, fn func(int) error) error {
+	b, err := u.read(size)
+	if err != nil {
+		return err
+	}
+	if size == 1 {
+		return fn(int(b[0]))
+	}
+	return fn(int(binary.LittleEndian.Uint32(b)))
+}
+
+func (u *unpickler) decodeTextMemoIndex(fn func(int) error) error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+	index, err := strconv.Atoi(line)
+	if err != nil {
+		return fmt.Errorf("invalid pickle memo index %q", line)
+	}
+	return fn(index)
+}
+
+func (u *unpickler) decodeFixed(size int, fn func([]byte) interface{}) error {
+	b, err := u.read(size)
+	if err != nil {
+		return err
+	}
+	u.push(fn(b))
+	return nil
+}
+
+// decodeTextInt decodes an INT, which is also used by protocol 0 for booleans.
+func (u *unpickler) decodeTextInt() error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+	if len(line) > maxPickleTextIntLength {
+		return fmt.Errorf("pickle int of %d characters exceeds the limit of %d", len(line), maxPickleTextIntLength)
+	}
+	switch line {
+	case "00":
+		u.push(false)
+		return nil
+	case "01":
+		u.push(true)
+		return nil
+	}
+
+	n, err := strconv.ParseInt(line, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid pickle int %q", line)
+	}
+	u.push(n)
+	return nil
+}
+
+func (u *unpickler) decodeTextLong() error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+	if len(line) > maxPickleTextIntLength {
+		return fmt.Errorf("pickle long of %d characters exceeds the limit of %d", len(line), maxPickleTextIntLength)
+	}
+	n, ok := new(big.Int).SetString(strings.TrimSuffix(line, "L"), 10)
+	if !ok {
+		return fmt.Errorf("invalid pickle long %q", line)
+	}
+	u.pushBigInt(n)
+	return nil
+}
+
+// decodeLong decodes a LONG1 or LONG4, which is a little-endian two's complement integer prefixed by its size.
+func (u *unpickler) decodeLong(sizeLen int) error {
+	size, err := u.readSize(sizeLen)
+	if err != nil {
+		return err
+	}
+	if size > maxPickleLongSize {
+		return fmt.Errorf("pickle long of %d bytes exceeds the limit of %d", size, maxPickleLongSize)
+	}
+	b, err := u.read(size)
+	if err != nil {
+		return err
+	}
+
+	// Convert to big-endian.
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	n := new(big.Int).SetBytes(be)
+	if len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	u.pushBigInt(n)
+	return nil
+}
+
+// pushBigInt pushes the integer, as a float if it doesn't fit an int64.
+func (u *unpickler) pushBigInt(n *big.Int) {
+	if n.IsInt64() {
+		u.push(n.Int64())
+		return
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	u.push(f)
+}
+
+func (u *unpickler) decodeString(sizeLen int) error {
+	size, err := u.readSize(sizeLen)
+	if err != nil {
+		return err
+	}
+	b, err := u.read(size)
+	if err != nil {
+		return err
+	}
+	u.push(string(b))
+	return nil
+}
+
+func (u *unpickler) readSize(sizeLen int) (int, error) {
+	b, err := u.read(sizeLen)
+	if err != nil {
+		return 0, err
+	}
+
+	var size uint64
+	switch sizeLen {
+	case 1:
+		size = uint64(b[0])
+	case 4:
+		size = uint64(binary.LittleEndian.Uint32(b))
+	default:
+		size = binary.LittleEndian.Uint64(b)
+	}
+	if size > uint64(len(u.data)-u.pos) {
+		return 0, errors.New("unexpected end of pickle data")
+	}
+	return int(size), nil
+}
+
+func (u *unpickler) readByte() (byte, error) {
+	b, err := u.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (u *unpickler) read(n int) ([]byte, error) {
+	if n > len(u.data)-u.pos {
+		return nil, errors.New("unexpected end of pickle data")
+	}
+	b := u.data[u.pos : u.pos+n]
+	u.pos += n
+	return b, nil
+}
+
+func (u *unpickler) readLine() (string, error) {
+	i := bytes.IndexByte(u.data[u.pos:], '\n')
+	if i < 0 {
+		return "", errors.New("unexpected end of pickle data")
+	}
+	line := string(u.data[u.pos : u.pos+i])
+	u.pos += i + 1
+	return line, nil
+}
+
+// unquotePickleString unquotes the argument of a protocol 0 STRING, which is a Python string literal.
+func unquotePickleString(s string) (string, error) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	} else {
+		return "", fmt.Errorf("invalid pickle string %q", s)
+	}
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(strings.ReplaceAll(s, `\'`, `'`), `"`, `\"`) + `"`)
+	if err != nil {
+		return "", fmt.Errorf("invalid pickle string %q", s)
+	}
+	return unquoted, nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePickle(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+
+	// Generated with pickle.dumps([("foo.bar", (1700000000, 1.5)), ("baz;env=prod", (1700000000.5, "2")), ("big", (-1, 2**70))], protocol=n).
+	expected := []sample{
+		{path: "foo.bar", value: 1.5, timestampMs: 1700000000000},
+		{path: "baz;env=prod", value: 2, timestampMs: 1700000000500},
+		{path: "big", value: 1180591620717411303424, timestampMs: now.UnixMilli()},
+	}
+
+	tests := map[string]struct {
+		data     string
+		expected []sample
+		err      string
+	}{
+		"protocol 0": {
+			data:     "(lp0\n(Vfoo.bar\np1\n(I1700000000\nF1.5\ntp2\ntp3\na(Vbaz;env=prod\np4\n(F1700000000.5\nV2\np5\ntp6\ntp7\na(Vbig\np8\n(I-1\nL1180591620717411303424L\ntp9\ntp10\na.",
+			expected: expected,
+		},
+		"protocol 2": {
+			data:     "\x80\x02]q\x00(X\x07\x00\x00\x00foo.barq\x01J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\x0c\x00\x00\x00baz;env=prodq\x04GA\xd9T\xfc@ \x00\x00X\x01\x00\x00\x002q\x05\x86q\x06\x86q\x07X\x03\x00\x00\x00bigq\x08J\xff\xff\xff\xff\x8a\x09\x00\x00\x00\x00\x00\x00\x00\x00@\x86q\x09\x86q\ne.",
+			expected: expected,
+		},
+		"protocol 4": {
+			data:     "\x80\x04\x95[\x00\x00\x00\x00\x00\x00\x00]\x94(\x8c\x07foo.bar\x94J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00\x86\x94\x86\x94\x8c\x0cbaz;env=prod\x94GA\xd9T\xfc@ \x00\x00\x8c\x012\x94\x86\x94\x86\x94\x8c\x03big\x94J\xff\xff\xff\xff\x8a\x09\x00\x00\x00\x00\x00\x00\x00\x00@\x86\x94\x86\x94e.",
+			expected: expected,
+		},
+		"python 2 strings": {
+			data:     "(lp0\n(S'foo.bar'\np1\n(I1700000000\nI01\ntp2\ntp3\na.",
+			expected: []sample{{path: "foo.bar", value: 1, timestampMs: 1700000000000}},
+		},
+		"empty list": {
+			data:     "\x80\x02].",
+			expected: []sample{},
+		},
+		"not a list": {
+			data: "\x80\x02K\x01.",
+			err:  "expected a list",
+		},
+		"invalid metric": {
+			data: "\x80\x02]q\x00X\x03\x00\x00\x00fooq\x01a.",
+			err:  "expected a (path, (timestamp, value)) tuple",
+		},
+		"truncated": {
+			data: "\x80\x02]q\x00(X\x07\x00\x00\x00foo",
+			err:  "unexpected end of pickle data",
+		},
+		"too long text int": {
+			data: "(lp0\n(Vfoo\n(I1700000000\nI" + strings.Repeat("9", 33) + "\ntp1\ntp2\na.",
+			err:  "pickle int of 33 characters exceeds the limit of 32",
+		},
+		"too long text long": {
+			data: "(lp0\n(Vfoo\n(I1700000000\nL" + strings.Repeat("9", 100000) + "L\ntp1\ntp2\na.",
+			err:  "pickle long of 100001 characters exceeds the limit of 32",
+		},
+		"too long binary long": {
+			data: "\x80\x02]q\x00X\x03\x00\x00\x00foo\x8b\x11\x00\x00\x00" + strings.Repeat("\x01", 17) + ".",
+			err:  "pickle long of 17 bytes exceeds the limit of 16",
+		},
+		"unsupported opcode": {
+			data: "\x80\x02cos\nsystem\n.",
+			err:  "unsupported pickle opcode 0x63",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			samples, err := decodePickle([]byte(tc.data), now)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, samples)
+		})
+	}
+}
+
+func FuzzDecodePickle(f *testing.F) {
+	f.Add([]byte("(lp0\n(Vfoo.bar\np1\n(I1700000000\nF1.5\ntp2\ntp3\na."))
+	f.Add([]byte("\x80\x02]q\x00(X\x07\x00\x00\x00foo.barq\x01J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03e."))
+	f.Add([]byte("\x80\x04\x95\x1d\x00\x00\x00\x00\x00\x00\x00]\x94\x8c\x03big\x94J\xff\xff\xff\xff\x8a\x09\x00\x00\x00\x00\x00\x00\x00\x00@\x86\x94\x86\x94a."))
+	f.Add([]byte("(lp0\n(S'foo'\np1\n(I1700000000\nL123L\ntp2\ntp3\na."))
+
+	now := time.Unix(1800000000, 0)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		samples, err := decodePickle(data, now)
+		if err != nil {
+			require.Nil(t, samples)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sample is a sample received by the proxy, before its metric path is mapped to labels.
+type sample struct {
+	path        string
+	value       float64
+	timestampMs int64
+}
+
+// parsePlaintextLine parses a line of the carbon plaintext protocol: <path> <value> <timestamp>. The timestamp
+// is in seconds, and may be a float. A timestamp of -1 means now.
+func parsePlaintextLine(line string, now time.Time) (sample, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return sample{}, fmt.Errorf("invalid line %q, expected <path> <value> <timestamp>", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("invalid value %q", fields[1])
+	}
+
+	timestamp, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || math.IsNaN(timestamp) || math.IsInf(timestamp, 0) {
+		return sample{}, fmt.Errorf("invalid timestamp %q", fields[2])
+	}
+
+	return sample{path: fields[0], value: value, timestampMs: timestampToMillis(timestamp, now)}, nil
+}
+
+// timestampToMillis converts a carbon timestamp in seconds to milliseconds. A timestamp of -1 means now.
+func timestampToMillis(timestamp float64, now time.Time) int64 {
+	if timestamp == -1 {
+		return now.UnixMilli()
+	}
+	return int64(math.Round(timestamp * 1000))
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlaintextLine(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+
+	tests := map[string]struct {
+		line     string
+		expected sample
+		err      string
+	}{
+		"valid": {
+			line:     "foo.bar 1.5 1700000000",
+			expected: sample{path: "foo.bar", value: 1.5, timestampMs: 1700000000000},
+		},
+		"float timestamp": {
+			line:     "foo.bar 2 1700000000.25",
+			expected: sample{path: "foo.bar", value: 2, timestampMs: 1700000000250},
+		},
+		"timestamp now": {
+			line:     "foo.bar 2 -1",
+			expected: sample{path: "foo.bar", value: 2, timestampMs: now.UnixMilli()},
+		},
+		"tags and extra whitespace": {
+			line:     "  foo.bar;env=prod\t3   1700000000\r",
+			expected: sample{path: "foo.bar;env=prod", value: 3, timestampMs: 1700000000000},
+		},
+		"missing timestamp": {
+			line: "foo.bar 1.5",
+			err:  "expected <path> <value> <timestamp>",
+		},
+		"invalid value": {
+			line: "foo.bar abc 1700000000",
+			err:  `invalid value "abc"`,
+		},
+		"invalid timestamp": {
+			line: "foo.bar 1 NaN",
+			err:  `invalid timestamp "NaN"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := parsePlaintextLine(tc.line, now)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, s)
+		})
+	}
+}
@@ -0,0 +1,421 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	protocolPlaintextTCP = "plaintext-tcp"
+	protocolPlaintextUDP = "plaintext-udp"
+	protocolPickle       = "pickle"
+
+	// maxPlaintextLineLength is the maximum length of a line of the plaintext protocol.
+	maxPlaintextLineLength = 64 * 1024
+	// maxPickleMessageSize is the maximum size of a message of the pickle protocol.
+	maxPickleMessageSize = 1024 * 1024
+	// maxUDPPacketSize is the maximum size of a UDP packet.
+	maxUDPPacketSize = 64 * 1024
+)
+
+// PushFunc pushes a write request.
+type PushFunc func(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
+
+// Config configures the Graphite write proxy.
+type Config struct {
+	PlaintextTCPListenAddress string        `yaml:"plaintext_tcp_listen_address" category:"experimental"`
+	PlaintextUDPListenAddress string        `yaml:"plaintext_udp_listen_address" category:"experimental"`
+	PickleTCPListenAddress    string        `yaml:"pickle_tcp_listen_address" category:"experimental"`
+	TenantID                  string        `yaml:"tenant_id" category:"experimental"`
+	BatchSize                 int           `yaml:"batch_size" category:"experimental"`
+	FlushPeriod               time.Duration `yaml:"flush_period" category:"experimental"`
+	MappingRules              []MappingRule `yaml:"mapping_rules" category:"experimental" doc:"nocli|description=Rules mapping the Graphite metric paths to a metric name and labels. The first matching rule applies. Each * in the match pattern matches a path component, and $n in the name and label values is replaced with the component matched by the nth *. Paths not matching any rule are mapped to a metric name made of the path with its dots replaced by underscores."`
+}
+
+// RegisterFlags registers the flags of the Graphite write proxy.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.PlaintextTCPListenAddress, "graphite-write-proxy.plaintext-tcp-listen-address", ":2003", "The TCP address to listen on for the carbon plaintext protocol. Empty to disable.")
+	f.StringVar(&cfg.PlaintextUDPListenAddress, "graphite-write-proxy.plaintext-udp-listen-address", ":2003", "The UDP address to listen on for the carbon plaintext protocol. Empty to disable.")
+	f.StringVar(&cfg.PickleTCPListenAddress, "graphite-write-proxy.pickle-tcp-listen-address", ":2004", "The TCP address to listen on for the carbon pickle protocol. Empty to disable.")
+	f.StringVar(&cfg.TenantID, "graphite-write-proxy.tenant-id", "", "The tenant the received samples are pushed for. If empty, the tenant used when multitenancy is disabled is used.")
+	f.IntVar(&cfg.BatchSize, "graphite-write-proxy.batch-size", 1000, "The maximum number of samples pushed in a single write request.")
+	f.DurationVar(&cfg.FlushPeriod, "graphite-write-proxy.flush-period", time.Second, "How often the received samples are pushed, if the batch isn't full yet.")
+}
+
+// Validate validates the configuration.
+func (cfg *Config) Validate() error {
+	if cfg.BatchSize <= 0 {
+		return errors.New("the Graphite write proxy batch size must be greater than 0")
+	}
+	if cfg.FlushPeriod <= 0 {
+		return errors.New("the Graphite write proxy flush period must be greater than 0")
+	}
+	for _, r := range cfg.MappingRules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProxy receives samples over the carbon plaintext and pickle protocols, maps their Graphite metric paths
+// to labels, and pushes them.
+type WriteProxy struct {
+	services.Service
+
+	cfg      Config
+	tenantID string
+	mapper   *mapper
+	push     PushFunc
+	logger   log.Logger
+
+	plaintextTCPListener net.Listener
+	plaintextUDPConn     net.PacketConn
+	pickleListener       net.Listener
+
+	// Connections currently open, closed on stopping.
+	connsMtx sync.Mutex
+	conns    map[net.Conn]struct{}
+	connsWG  sync.WaitGroup
+
+	batchMtx sync.Mutex
+	batch    []mimirpb.PreallocTimeseries
+
+	receivedSamples   *prometheus.CounterVec
+	parseErrors       *prometheus.CounterVec
+	pushFailedSamples prometheus.Counter
+	pushedSamples     prometheus.Counter
+	openConnections   *prometheus.GaugeVec
+	now               func() time.Time
+}
+
+// NewWriteProxy makes a new WriteProxy, pushing the samples for the tenant.
+func NewWriteProxy(cfg Config, tenantID string, push PushFunc, logger log.Logger, reg prometheus.Registerer) (*WriteProxy, error) {
+	m, err := newMapper(cfg.MappingRules)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &WriteProxy{
+		cfg:      cfg,
+		tenantID: tenantID,
+		mapper:   m,
+		push:     push,
+		logger:   logger,
+		conns:    map[net.Conn]struct{}{},
+		now:      time.Now,
+
+		receivedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_graphite_write_proxy_received_samples_total",
+			Help: "Total number of samples received by the Graphite write proxy.",
+		}, []string{"protocol"}),
+		parseErrors: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_graphite_write_proxy_parse_errors_total",
+			Help: "Total number of lines and messages the Graphite write proxy failed to parse.",
+		}, []string{"protocol"}),
+		pushedSamples: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_graphite_write_proxy_pushed_samples_total",
+			Help: "Total number of samples successfully pushed by the Graphite write proxy.",
+		}),
+		pushFailedSamples: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_graphite_write_proxy_push_failed_samples_total",
+			Help: "Total number of samples the Graphite write proxy failed to push.",
+		}),
+		openConnections: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_graphite_write_proxy_open_connections",
+			Help: "Number of TCP connections currently open to the Graphite write proxy.",
+		}, []string{"protocol"}),
+	}
+	p.Service = services.NewBasicService(p.starting, p.running, p.stopping)
+	return p, nil
+}
+
+func (p *WriteProxy) starting(_ context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			p.closeListeners()
+		}
+	}()
+
+	if p.cfg.PlaintextTCPListenAddress != "" {
+		if p.plaintextTCPListener, err = net.Listen("tcp", p.cfg.PlaintextTCPListenAddress); err != nil {
+			return fmt.Errorf("failed to listen for the carbon plaintext protocol over TCP: %w", err)
+		}
+	}
+	if p.cfg.PlaintextUDPListenAddress != "" {
+		if p.plaintextUDPConn, err = net.ListenPacket("udp", p.cfg.PlaintextUDPListenAddress); err != nil {
+			return fmt.Errorf("failed to listen for the carbon plaintext protocol over UDP: %w", err)
+		}
+	}
+	if p.cfg.PickleTCPListenAddress != "" {
+		if p.pickleListener, err = net.Listen("tcp", p.cfg.PickleTCPListenAddress); err != nil {
+			return fmt.Errorf("failed to listen for the carbon pickle protocol: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *WriteProxy) running(ctx context.Context) error {
+	var wg sync.WaitGroup
+	if p.plaintextTCPListener != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.acceptConnections(p.plaintextTCPListener, protocolPlaintextTCP, p.readPlaintext)
+		}()
+	}
+	if p.pickleListener != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.acceptConnections(p.pickleListener, protocolPickle, p.readPickle)
+		}()
+	}
+	if p.plaintextUDPConn != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.readPlaintextUDP(p.plaintextUDPConn)
+		}()
+	}
+
+	ticker := time.NewTicker(p.cfg.FlushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-ctx.Done():
+			// Stop receiving, and wait until the samples being received have been added to the batch.
+			p.closeListeners()
+			wg.Wait()
+			p.closeConnections()
+			p.connsWG.Wait()
+			return nil
+		}
+	}
+}
+
+func (p *WriteProxy) stopping(_ error) error {
+	// Push the samples received until the listeners were closed.
+	p.flush()
+	return nil
+}
+
+func (p *WriteProxy) closeListeners() {
+	if p.plaintextTCPListener != nil {
+		_ = p.plaintextTCPListener.Close()
+	}
+	if p.plaintextUDPConn != nil {
+		_ = p.plaintextUDPConn.Close()
+	}
+	if p.pickleListener != nil {
+		_ = p.pickleListener.Close()
+	}
+}
+
+func (p *WriteProxy) closeConnections() {
+	p.connsMtx.Lock()
+	defer p.connsMtx.Unlock()
+
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+}
+
+func (p *WriteProxy) acceptConnections(l net.Listener, protocol string, read func(io.Reader)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				level.Warn(p.logger).Log("msg", "failed to accept connection", "protocol", protocol, "err", err)
+			}
+			return
+		}
+
+		p.connsMtx.Lock()
+		p.conns[conn] = struct{}{}
+		p.connsWG.Add(1)
+		p.connsMtx.Unlock()
+
+		go func() {
+			defer p.connsWG.Done()
+
+			p.openConnections.WithLabelValues(protocol).Inc()
+			defer p.openConnections.WithLabelValues(protocol).Dec()
+
+			read(conn)
+
+			p.connsMtx.Lock()
+			delete(p.conns, conn)
+			p.connsMtx.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (p *WriteProxy) readPlaintext(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxPlaintextLineLength)
+
+	for scanner.Scan() {
+		p.receivePlaintextLine(scanner.Text(), protocolPlaintextTCP)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		p.parseErrors.WithLabelValues(protocolPlaintextTCP).Inc()
+		level.Debug(p.logger).Log("msg", "failed to read carbon plaintext protocol", "err", err)
+	}
+}
+
+func (p *WriteProxy) readPlaintextUDP(conn net.PacketConn) {
+	buf := make([]byte, maxUDPPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				level.Warn(p.logger).Log("msg", "failed to read UDP packet", "err", err)
+			}
+			return
+		}
+
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			p.receivePlaintextLine(line, protocolPlaintextUDP)
+		}
+	}
+}
+
+func (p *WriteProxy) receivePlaintextLine(line, protocol string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	s, err := parsePlaintextLine(line, p.now())
+	if err != nil {
+		p.parseErrors.WithLabelValues(protocol).Inc()
+		level.Debug(p.logger).Log("msg", "failed to parse carbon plaintext line", "protocol", protocol, "err", err)
+		return
+	}
+	p.receive(protocol, s)
+}
+
+func (p *WriteProxy) readPickle(r io.Reader) {
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				p.parseErrors.WithLabelValues(protocolPickle).Inc()
+				level.Debug(p.logger).Log("msg", "failed to read carbon pickle message", "err", err)
+			}
+			return
+		}
+
+		size := binary.BigEndian.Uint32(header)
+		if size > maxPickleMessageSize {
+			// The connection can't be read anymore, since the message can't be skipped.
+			p.parseErrors.WithLabelValues(protocolPickle).Inc()
+			level.Debug(p.logger).Log("msg", "carbon pickle message too large", "size", size, "limit", maxPickleMessageSize)
+			return
+		}
+
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			p.parseErrors.WithLabelValues(protocolPickle).Inc()
+			level.Debug(p.logger).Log("msg", "failed to read carbon pickle message", "err", err)
+			return
+		}
+
+		samples, err := decodePickle(msg, p.now())
+		if err != nil {
+			p.parseErrors.WithLabelValues(protocolPickle).Inc()
+			level.Debug(p.logger).Log("msg", "failed to decode carbon pickle message", "err", err)
+			continue
+		}
+		p.receive(protocolPickle, samples...)
+	}
+}
+
+// receive maps the samples to series, and adds them to the batch. If the batch is full, it's pushed.
+func (p *WriteProxy) receive(protocol string, samples ...sample) {
+	series := make([]mimirpb.PreallocTimeseries, 0, len(samples))
+	for _, s := range samples {
+		labels, err := p.mapper.labels(s.path)
+		if err != nil {
+			p.parseErrors.WithLabelValues(protocol).Inc()
+			level.Debug(p.logger).Log("msg", "failed to map Graphite metric path to labels", "protocol", protocol, "path", s.path, "err", err)
+			continue
+		}
+
+		ts := mimirpb.TimeseriesFromPool()
+		ts.Labels = labels
+		ts.Samples = append(ts.Samples, mimirpb.Sample{Value: s.value, TimestampMs: s.timestampMs})
+		series = append(series, mimirpb.PreallocTimeseries{TimeSeries: ts})
+	}
+	p.receivedSamples.WithLabelValues(protocol).Add(float64(len(series)))
+
+	for len(series) > 0 {
+		var full []mimirpb.PreallocTimeseries
+
+		p.batchMtx.Lock()
+		n := p.cfg.BatchSize - len(p.batch)
+		if n > len(series) {
+			n = len(series)
+		}
+		p.batch = append(p.batch, series[:n]...)
+		series = series[n:]
+		if len(p.batch) >= p.cfg.BatchSize {
+			full = p.batch
+			p.batch = nil
+		}
+		p.batchMtx.Unlock()
+
+		if full != nil {
+			p.pushBatch(full)
+		}
+	}
+}
+
+// flush pushes the samples in the batch.
+func (p *WriteProxy) flush() {
+	p.batchMtx.Lock()
+	batch := p.batch
+	p.batch = nil
+	p.batchMtx.Unlock()
+
+	if len(batch) > 0 {
+		p.pushBatch(batch)
+	}
+}
+
+func (p *WriteProxy) pushBatch(batch []mimirpb.PreallocTimeseries) {
+	req := &mimirpb.WriteRequest{Timeseries: batch, Source: mimirpb.API}
+	ctx := user.InjectOrgID(context.Background(), p.tenantID)
+
+	// The distributor returns the request to the pool once it's done with it.
+	if _, err := p.push(ctx, req); err != nil {
+		p.pushFailedSamples.Add(float64(len(batch)))
+		level.Warn(p.logger).Log("msg", "failed to push samples received by the Graphite write proxy", "samples", len(batch), "err", err)
+		return
+	}
+	p.pushedSamples.Add(float64(len(batch)))
+}
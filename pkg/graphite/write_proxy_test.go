@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package graphite
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+type pushedSeries struct {
+	tenantID string
+	series   string
+	value    float64
+}
+
+type mockPusher struct {
+	mtx    sync.Mutex
+	series []pushedSeries
+	reqs   int
+	err    error
+}
+
+func (m *mockPusher) push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
+	tenantID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.reqs++
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, ts := range req.Timeseries {
+		for _, s := range ts.Samples {
+			m.series = append(m.series, pushedSeries{tenantID: tenantID, series: mimirpb.FromLabelAdaptersToLabels(ts.Labels).String(), value: s.Value})
+		}
+	}
+	return &mimirpb.WriteResponse{}, nil
+}
+
+func (m *mockPusher) pushed() []pushedSeries {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]pushedSeries(nil), m.series...)
+}
+
+func newTestWriteProxy(t *testing.T, cfg Config, pusher *mockPusher) (*WriteProxy, *prometheus.Registry) {
+	reg := prometheus.NewPedanticRegistry()
+	p, err := NewWriteProxy(cfg, "tenant-1", pusher.push, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), p))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), p)
+	})
+	return p, reg
+}
+
+func testConfig() Config {
+	return Config{
+		PlaintextTCPListenAddress: "localhost:0",
+		PlaintextUDPListenAddress: "localhost:0",
+		PickleTCPListenAddress:    "localhost:0",
+		BatchSize:                 100,
+		FlushPeriod:               50 * time.Millisecond,
+		MappingRules: []MappingRule{
+			{Match: "servers.*.*", Name: "server_$2", Labels: map[string]string{"host": "$1"}},
+		},
+	}
+}
+
+func TestWriteProxy_Plaintext(t *testing.T) {
+	pusher := &mockPusher{}
+	p, reg := newTestWriteProxy(t, testConfig(), pusher)
+
+	conn, err := net.Dial("tcp", p.plaintextTCPListener.Addr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(conn, "servers.web-1.uptime 10 1700000000\ninvalid line\napp.requests;env=prod 5 1700000000\n")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	udp, err := net.Dial("udp", p.plaintextUDPConn.LocalAddr().String())
+	require.NoError(t, err)
+	_, err = udp.Write([]byte("app.errors 1 1700000000\n"))
+	require.NoError(t, err)
+	require.NoError(t, udp.Close())
+
+	require.Eventually(t, func() bool {
+		return len(pusher.pushed()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.ElementsMatch(t, []pushedSeries{
+		{tenantID: "tenant-1", series: `{__name__="server_uptime", host="web-1"}`, value: 10},
+		{tenantID: "tenant-1", series: `{__name__="app_requests", env="prod"}`, value: 5},
+		{tenantID: "tenant-1", series: `{__name__="app_errors"}`, value: 1},
+	}, pusher.pushed())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_graphite_write_proxy_parse_errors_total Total number of lines and messages the Graphite write proxy failed to parse.
+		# TYPE cortex_graphite_write_proxy_parse_errors_total counter
+		cortex_graphite_write_proxy_parse_errors_total{protocol="plaintext-tcp"} 1
+		# HELP cortex_graphite_write_proxy_pushed_samples_total Total number of samples successfully pushed by the Graphite write proxy.
+		# TYPE cortex_graphite_write_proxy_pushed_samples_total counter
+		cortex_graphite_write_proxy_pushed_samples_total 3
+		# HELP cortex_graphite_write_proxy_received_samples_total Total number of samples received by the Graphite write proxy.
+		# TYPE cortex_graphite_write_proxy_received_samples_total counter
+		cortex_graphite_write_proxy_received_samples_total{protocol="plaintext-tcp"} 2
+		cortex_graphite_write_proxy_received_samples_total{protocol="plaintext-udp"} 1
+	`), "cortex_graphite_write_proxy_parse_errors_total", "cortex_graphite_write_proxy_pushed_samples_total", "cortex_graphite_write_proxy_received_samples_total"))
+}
+
+func TestWriteProxy_Pickle(t *testing.T) {
+	pusher := &mockPusher{}
+	p, _ := newTestWriteProxy(t, testConfig(), pusher)
+
+	conn, err := net.Dial("tcp", p.pickleListener.Addr().String())
+	require.NoError(t, err)
+
+	// pickle.dumps([("servers.web-1.uptime", (1700000000, 10))], protocol=2)
+	msg := []byte("\x80\x02]q\x00X\x14\x00\x00\x00servers.web-1.uptimeq\x01J\x00\xf1SeK\n\x86q\x02\x86q\x03a.")
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(msg)))
+	for i := 0; i < 2; i++ {
+		_, err = conn.Write(append(header, msg...))
+		require.NoError(t, err)
+	}
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		return len(pusher.pushed()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, s := range pusher.pushed() {
+		assert.Equal(t, pushedSeries{tenantID: "tenant-1", series: `{__name__="server_uptime", host="web-1"}`, value: 10}, s)
+	}
+}
+
+func TestWriteProxy_PushesFullBatches(t *testing.T) {
+	cfg := testConfig()
+	cfg.BatchSize = 2
+	cfg.FlushPeriod = time.Hour
+
+	pusher := &mockPusher{}
+	p, _ := newTestWriteProxy(t, cfg, pusher)
+
+	for i := 0; i < 5; i++ {
+		p.receive(protocolPlaintextTCP, sample{path: fmt.Sprintf("app.metric%d", i), value: float64(i)})
+	}
+
+	// The full batches are pushed right away, the remaining sample on flush.
+	pusher.mtx.Lock()
+	assert.Equal(t, 2, pusher.reqs)
+	assert.Len(t, pusher.series, 4)
+	pusher.mtx.Unlock()
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), p))
+	assert.Len(t, pusher.pushed(), 5)
+}
+
+func TestWriteProxy_PushFailure(t *testing.T) {
+	cfg := testConfig()
+	cfg.BatchSize = 1
+
+	pusher := &mockPusher{err: errors.New("push failed")}
+	p, reg := newTestWriteProxy(t, cfg, pusher)
+
+	p.receive(protocolPlaintextTCP, sample{path: "app.metric", value: 1})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_graphite_write_proxy_push_failed_samples_total Total number of samples the Graphite write proxy failed to push.
+		# TYPE cortex_graphite_write_proxy_push_failed_samples_total counter
+		cortex_graphite_write_proxy_push_failed_samples_total 1
+	`), "cortex_graphite_write_proxy_push_failed_samples_total"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := testConfig()
+	require.NoError(t, cfg.Validate())
+
+	cfg.BatchSize = 0
+	require.Error(t, cfg.Validate())
+
+	cfg = testConfig()
+	cfg.MappingRules = append(cfg.MappingRules, MappingRule{Match: "foo"})
+	require.Error(t, cfg.Validate())
+}
@@ -9,14 +9,24 @@ import (
 	"flag"
 
 	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/grpcencoding/snappy"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/grpcencoding/zstd"
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
+const grpcCompressionFlag = "ingester.client.grpc-compression"
+
+var errUnsupportedCompressionFallback = errors.New("unsupported gRPC compression fallback: supported values are 'gzip', 'snappy', 'zstd' and '' (disabled)")
+
 //lint:ignore faillint It's non-trivial to remove this global variable.
 var ingesterClientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 	Name:    "cortex_ingester_client_request_duration_seconds",
@@ -39,10 +49,21 @@ type closableHealthAndIngesterClient struct {
 
 // MakeIngesterClient makes a new IngesterClient
 func MakeIngesterClient(addr string, cfg Config) (HealthAndIngesterClient, error) {
-	dialOpts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(ingesterClientRequestDuration))
+	unaryInterceptors, streamInterceptors := grpcclient.Instrument(ingesterClientRequestDuration)
+	unaryInterceptors = append(unaryInterceptors, querysource.ClientUnaryInterceptor)
+	streamInterceptors = append(streamInterceptors, querysource.ClientStreamInterceptor)
+
+	if cfg.GRPCCompressionFallback != "" {
+		negotiator := newCompressionNegotiator(addr, cfg.GRPCClientConfig.GRPCCompression, cfg.GRPCCompressionFallback)
+		unaryInterceptors = append(unaryInterceptors, negotiator.unaryClientInterceptor)
+		streamInterceptors = append(streamInterceptors, negotiator.streamClientInterceptor)
+	}
+
+	dialOpts, err := cfg.GRPCClientConfig.DialOption(unaryInterceptors, streamInterceptors)
 	if err != nil {
 		return nil, err
 	}
+	dialOpts = append(dialOpts, grpc.WithStatsHandler(payloadBytesStatsHandler{}))
 	conn, err := grpc.Dial(addr, dialOpts...)
 	if err != nil {
 		return nil, err
@@ -62,18 +83,50 @@ func (c *closableHealthAndIngesterClient) Close() error {
 	return c.conn.Close()
 }
 
+// ConnectionState returns the state of the underlying gRPC connection.
+func (c *closableHealthAndIngesterClient) ConnectionState() connectivity.State {
+	return c.conn.GetState()
+}
+
+// Connect makes the underlying gRPC connection leave the idle state, if it's idle,
+// so that it's established in the background.
+func (c *closableHealthAndIngesterClient) Connect() {
+	c.conn.Connect()
+}
+
 // Config is the configuration struct for the ingester client
 type Config struct {
-	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between distributors and ingesters."`
+	GRPCClientConfig        grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between distributors and ingesters."`
+	GRPCCompressionFallback string            `yaml:"grpc_compression_fallback" category:"experimental"`
 }
 
 // RegisterFlags registers configuration settings used by the ingester client config.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("ingester.client", f)
+
+	// The ingester client supports the zstd compression too.
+	if fl := f.Lookup(grpcCompressionFlag); fl != nil {
+		fl.Usage = "Use compression when sending messages. Supported values are: 'gzip', 'snappy' (streaming snappy, using the framed format), 'zstd' and '' (disable compression)"
+	}
+	f.StringVar(&cfg.GRPCCompressionFallback, "ingester.client.grpc-compression-fallback", "", "Compression used with the ingesters which can't decompress the messages compressed with -"+grpcCompressionFlag+", for example while rolling out a new compression. The compression is negotiated per connection: a connection falls back the first time the ingester fails to decompress a message. Supported values are: 'gzip', 'snappy', 'zstd' and '' (disable the fallback)")
 }
 
 func (cfg *Config) Validate() error {
-	return cfg.GRPCClientConfig.Validate()
+	// The zstd compression isn't supported by the dskit gRPC client config, but it is by the ingester client.
+	grpcCfg := cfg.GRPCClientConfig
+	if grpcCfg.GRPCCompression == zstd.Name {
+		grpcCfg.GRPCCompression = ""
+	}
+	if err := grpcCfg.Validate(); err != nil {
+		return err
+	}
+
+	switch cfg.GRPCCompressionFallback {
+	case gzip.Name, snappy.Name, zstd.Name, "":
+		return nil
+	default:
+		return errUnsupportedCompressionFallback
+	}
 }
 
 type CombinedQueryStreamResponse struct {
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const noCompression = "none"
+
+//lint:ignore faillint It's non-trivial to remove this global variable.
+var ingesterClientPayloadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cortex_ingester_client_payload_bytes_total",
+	Help: "Total size of the messages sent to and received from the ingesters, before (stage=uncompressed) and after (stage=compressed) compression.",
+}, []string{"direction", "stage", "compression"})
+
+//lint:ignore faillint It's non-trivial to remove this global variable.
+var ingesterClientCompressionFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "cortex_ingester_client_compression_fallbacks_total",
+	Help: "Total number of connections to the ingesters which fell back to the fallback compression, because the ingester couldn't decompress the requests.",
+})
+
+// compressionNegotiator picks the compression of the requests sent over a connection to an ingester. The connection
+// switches to the fallback compression the first time the ingester replies that it can't decompress the requests,
+// for example because it runs a version not supporting the configured compression, and keeps using it until closed.
+type compressionNegotiator struct {
+	addr        string
+	compression string
+	fallback    string
+
+	fellBack atomic.Bool
+}
+
+func newCompressionNegotiator(addr, compression, fallback string) *compressionNegotiator {
+	return &compressionNegotiator{
+		addr:        addr,
+		compression: compression,
+		fallback:    fallback,
+	}
+}
+
+// current returns the compression the requests are sent with.
+func (n *compressionNegotiator) current() string {
+	if n.fellBack.Load() {
+		return n.fallback
+	}
+	return n.compression
+}
+
+// fallBack switches the connection to the fallback compression, if the failed request was sent with the
+// configured compression. It returns whether the request should be sent again with the fallback compression.
+func (n *compressionNegotiator) fallBack(sentWith string, err error) bool {
+	if sentWith == n.fallback || !isDecompressorNotInstalled(err) {
+		return false
+	}
+
+	if n.fellBack.CompareAndSwap(false, true) {
+		ingesterClientCompressionFallbacks.Inc()
+		level.Warn(util_log.Logger).Log("msg", "ingester can't decompress the requests, falling back to another compression", "ingester", n.addr, "compression", n.compression, "fallback", n.fallback, "err", err)
+	}
+	return true
+}
+
+// unaryClientInterceptor sends the request with the current compression. A request rejected because the
+// ingester can't decompress it is sent again with the fallback compression: the ingester rejects the
+// request before handling it, so it's safe to retry.
+func (n *compressionNegotiator) unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	compression := n.current()
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.UseCompressor(compression))...)
+	if err != nil && n.fallBack(compression, err) {
+		err = invoker(ctx, method, req, reply, cc, append(opts, grpc.UseCompressor(n.fallback))...)
+	}
+	return err
+}
+
+// streamClientInterceptor opens the stream with the current compression. The error of a stream rejected because
+// the ingester can't decompress its messages is only received once the stream is already in use, so the stream
+// isn't retried but the next streams are opened with the fallback compression.
+func (n *compressionNegotiator) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	compression := n.current()
+	stream, err := streamer(ctx, desc, cc, method, append(opts, grpc.UseCompressor(compression))...)
+	if err != nil {
+		return nil, err
+	}
+	return &compressionNegotiatingStream{ClientStream: stream, negotiator: n, compression: compression}, nil
+}
+
+type compressionNegotiatingStream struct {
+	grpc.ClientStream
+	negotiator  *compressionNegotiator
+	compression string
+}
+
+func (s *compressionNegotiatingStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.negotiator.fallBack(s.compression, err)
+	}
+	return err
+}
+
+func isDecompressorNotInstalled(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.Unimplemented && strings.Contains(st.Message(), "Decompressor is not installed")
+}
+
+// payloadBytesStatsHandler tracks the size of the messages sent to and received from the ingesters,
+// before and after compression.
+type payloadBytesStatsHandler struct{}
+
+type rpcCompressionKey struct{}
+
+// rpcCompression holds the compression of the messages of an RPC. The sent and the received messages are
+// handled by different goroutines, but each direction is only accessed by one goroutine at a time.
+type rpcCompression struct {
+	sent     string
+	received string
+}
+
+func (payloadBytesStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcCompressionKey{}, &rpcCompression{sent: noCompression, received: noCompression})
+}
+
+func (payloadBytesStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	c, ok := ctx.Value(rpcCompressionKey{}).(*rpcCompression)
+	if !ok {
+		return
+	}
+
+	switch s := s.(type) {
+	case *stats.OutHeader:
+		if s.Compression != "" {
+			c.sent = s.Compression
+		}
+	case *stats.InHeader:
+		if s.Compression != "" {
+			c.received = s.Compression
+		}
+	case *stats.OutPayload:
+		ingesterClientPayloadBytes.WithLabelValues("sent", "uncompressed", c.sent).Add(float64(s.Length))
+		ingesterClientPayloadBytes.WithLabelValues("sent", "compressed", c.sent).Add(float64(s.CompressedLength))
+	case *stats.InPayload:
+		ingesterClientPayloadBytes.WithLabelValues("received", "uncompressed", c.received).Add(float64(s.Length))
+		ingesterClientPayloadBytes.WithLabelValues("received", "compressed", c.received).Add(float64(s.CompressedLength))
+	}
+}
+
+func (payloadBytesStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (payloadBytesStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/grpcencoding/snappy"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/util/grpcencoding/zstd"
+)
+
+func TestConfig_Validate_Compression(t *testing.T) {
+	tests := map[string]struct {
+		compression string
+		fallback    string
+		expectedErr string
+	}{
+		"no compression":                   {},
+		"snappy compression":               {compression: snappy.Name},
+		"zstd compression":                 {compression: zstd.Name},
+		"zstd compression, gzip fallback":  {compression: zstd.Name, fallback: gzip.Name},
+		"zstd compression, zstd fallback":  {compression: zstd.Name, fallback: zstd.Name},
+		"unsupported compression":          {compression: "lz4", expectedErr: "unsupported compression type: lz4"},
+		"unsupported compression fallback": {compression: zstd.Name, fallback: "lz4", expectedErr: errUnsupportedCompressionFallback.Error()},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.GRPCClientConfig.GRPCCompression = testData.compression
+			cfg.GRPCCompressionFallback = testData.fallback
+
+			err := cfg.Validate()
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCompressionNegotiator_UnaryClientInterceptor(t *testing.T) {
+	notInstalledErr := status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", zstd.Name)
+
+	tests := map[string]struct {
+		errs                 []error
+		expectedCompressions []string
+		expectedErr          error
+		expectedCurrent      string
+	}{
+		"the ingester decompresses the request": {
+			errs:                 []error{nil},
+			expectedCompressions: []string{zstd.Name},
+			expectedCurrent:      zstd.Name,
+		},
+		"the ingester can't decompress the request": {
+			errs:                 []error{notInstalledErr, nil},
+			expectedCompressions: []string{zstd.Name, snappy.Name},
+			expectedCurrent:      snappy.Name,
+		},
+		"the ingester can't decompress the request compressed with the fallback either": {
+			errs:                 []error{notInstalledErr, notInstalledErr},
+			expectedCompressions: []string{zstd.Name, snappy.Name},
+			expectedErr:          notInstalledErr,
+			expectedCurrent:      snappy.Name,
+		},
+		"the request fails for another reason": {
+			errs:                 []error{status.Error(codes.Unimplemented, "unknown method")},
+			expectedCompressions: []string{zstd.Name},
+			expectedErr:          status.Error(codes.Unimplemented, "unknown method"),
+			expectedCurrent:      zstd.Name,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := newCompressionNegotiator("ingester-1", zstd.Name, snappy.Name)
+
+			var compressions []string
+			invoker := func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+				compressions = append(compressions, callCompression(opts))
+				return testData.errs[len(compressions)-1]
+			}
+
+			err := n.unaryClientInterceptor(context.Background(), "/cortex.Ingester/Push", nil, nil, nil, invoker, grpc.UseCompressor(gzip.Name))
+			assert.Equal(t, testData.expectedErr, err)
+			assert.Equal(t, testData.expectedCompressions, compressions)
+			assert.Equal(t, testData.expectedCurrent, n.current())
+		})
+	}
+}
+
+func TestCompressionNegotiator_StreamClientInterceptor(t *testing.T) {
+	n := newCompressionNegotiator("ingester-1", zstd.Name, snappy.Name)
+
+	var compressions []string
+	streamer := func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		compressions = append(compressions, callCompression(opts))
+		return &failingClientStream{err: status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", zstd.Name)}, nil
+	}
+
+	// The stream failing because the ingester can't decompress its messages isn't retried.
+	stream, err := n.streamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, "/cortex.Ingester/QueryStream", streamer)
+	require.NoError(t, err)
+	require.Error(t, stream.RecvMsg(nil))
+	assert.Equal(t, []string{zstd.Name}, compressions)
+
+	// The next streams use the fallback compression.
+	_, err = n.streamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, "/cortex.Ingester/QueryStream", streamer)
+	require.NoError(t, err)
+	assert.Equal(t, []string{zstd.Name, snappy.Name}, compressions)
+}
+
+func TestMakeIngesterClient_Compression(t *testing.T) {
+	for _, compression := range []string{gzip.Name, snappy.Name, zstd.Name} {
+		t.Run(compression, func(t *testing.T) {
+			serv, addr := setupIngesterServer(t)
+
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.GRPCClientConfig.GRPCCompression = compression
+			cfg.GRPCCompressionFallback = gzip.Name
+			require.NoError(t, cfg.Validate())
+
+			c, err := MakeIngesterClient(addr, cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+
+			uncompressedBefore := testutil.ToFloat64(ingesterClientPayloadBytes.WithLabelValues("sent", "uncompressed", compression))
+			compressedBefore := testutil.ToFloat64(ingesterClientPayloadBytes.WithLabelValues("sent", "compressed", compression))
+
+			req := createRequest("test", 100)
+			_, err = c.Push(user.InjectOrgID(context.Background(), "test"), req)
+			require.NoError(t, err)
+			require.Equal(t, req, serv.requests()[0])
+
+			uncompressed := testutil.ToFloat64(ingesterClientPayloadBytes.WithLabelValues("sent", "uncompressed", compression)) - uncompressedBefore
+			compressed := testutil.ToFloat64(ingesterClientPayloadBytes.WithLabelValues("sent", "compressed", compression)) - compressedBefore
+			assert.Equal(t, float64(req.Size()), uncompressed)
+			assert.Greater(t, compressed, float64(0))
+			assert.Less(t, compressed, uncompressed)
+		})
+	}
+}
+
+func TestSnappyCompressor_UsesStreamingFormat(t *testing.T) {
+	data, err := createRequest("test", 100).Marshal()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w, err := encoding.GetCompressor(snappy.Name).Compress(buf)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// The framed snappy format starts with the stream identifier chunk.
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("\xff\x06\x00\x00sNaPpY")))
+}
+
+func BenchmarkCompressors(b *testing.B) {
+	for _, seriesPerRequest := range []int{100, 1000} {
+		data, err := createRequest("test", seriesPerRequest).Marshal()
+		require.NoError(b, err)
+
+		for _, name := range []string{gzip.Name, snappy.Name, zstd.Name} {
+			c := encoding.GetCompressor(name)
+
+			b.Run(fmt.Sprintf("series=%d,compression=%s", seriesPerRequest, name), func(b *testing.B) {
+				buf := bytes.NewBuffer(make([]byte, 0, len(data)))
+				b.ReportAllocs()
+				b.SetBytes(int64(len(data)))
+
+				for i := 0; i < b.N; i++ {
+					buf.Reset()
+					w, err := c.Compress(buf)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := w.Write(data); err != nil {
+						b.Fatal(err)
+					}
+					if err := w.Close(); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.ReportMetric(float64(len(data))/float64(buf.Len()), "ratio")
+			})
+		}
+	}
+}
+
+func setupIngesterServer(t testing.TB) (*mockServer, string) {
+	server := grpc.NewServer()
+	t.Cleanup(server.Stop)
+
+	ingServ := &mockServer{}
+	RegisterIngesterServer(server, ingServ)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = server.Serve(l)
+	}()
+
+	return ingServ, l.Addr().String()
+}
+
+// callCompression returns the compression set by the last grpc.UseCompressor() option.
+func callCompression(opts []grpc.CallOption) string {
+	compression := ""
+	for _, opt := range opts {
+		if o, ok := opt.(grpc.CompressorCallOption); ok {
+			compression = o.CompressorType
+		}
+	}
+	return compression
+}
+
+type failingClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *failingClientStream) RecvMsg(interface{}) error {
+	return s.err
+}
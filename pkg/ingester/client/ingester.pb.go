@@ -103,7 +103,7 @@ var ReadRequest_ResponseType_value = map[string]int32{
 }
 
 func (ReadRequest_ResponseType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{6, 0}
+	return fileDescriptor_60f6df4f3586b478, []int{8, 0}
 }
 
 type StreamChunk_Encoding int32
@@ -130,7 +130,7 @@ var StreamChunk_Encoding_value = map[string]int32{
 }
 
 func (StreamChunk_Encoding) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{10, 0}
+	return fileDescriptor_60f6df4f3586b478, []int{12, 0}
 }
 
 type LabelNamesAndValuesRequest struct {
@@ -274,6 +274,8 @@ type LabelValuesCardinalityRequest struct {
 	LabelNames  []string        `protobuf:"bytes,1,rep,name=label_names,json=labelNames,proto3" json:"label_names,omitempty"`
 	Matchers    []*LabelMatcher `protobuf:"bytes,2,rep,name=matchers,proto3" json:"matchers,omitempty"`
 	CountMethod CountMethod     `protobuf:"varint,3,opt,name=count_method,json=countMethod,proto3,enum=cortex.CountMethod" json:"count_method,omitempty"`
+	// If true, the series count of each label value is estimated from a sample of the matching series.
+	Approximate bool `protobuf:"varint,4,opt,name=approximate,proto3" json:"approximate,omitempty"`
 }
 
 func (m *LabelValuesCardinalityRequest) Reset()      { *m = LabelValuesCardinalityRequest{} }
@@ -329,6 +331,13 @@ func (m *LabelValuesCardinalityRequest) GetCountMethod() CountMethod {
 	return IN_MEMORY
 }
 
+func (m *LabelValuesCardinalityRequest) GetApproximate() bool {
+	if m != nil {
+		return m.Approximate
+	}
+	return false
+}
+
 type LabelValuesCardinalityResponse struct {
 	Items []*LabelValueSeriesCount `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 }
@@ -423,6 +432,92 @@ func (m *LabelValueSeriesCount) GetLabelValueSeries() map[string]uint64 {
 	return nil
 }
 
+type ActiveSeriesRequest struct {
+	Matchers []*LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers,omitempty"`
+}
+
+func (m *ActiveSeriesRequest) Reset()      { *m = ActiveSeriesRequest{} }
+func (*ActiveSeriesRequest) ProtoMessage() {}
+func (*ActiveSeriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{6}
+}
+func (m *ActiveSeriesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ActiveSeriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ActiveSeriesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ActiveSeriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActiveSeriesRequest.Merge(m, src)
+}
+func (m *ActiveSeriesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ActiveSeriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActiveSeriesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActiveSeriesRequest proto.InternalMessageInfo
+
+func (m *ActiveSeriesRequest) GetMatchers() []*LabelMatcher {
+	if m != nil {
+		return m.Matchers
+	}
+	return nil
+}
+
+type ActiveSeriesResponse struct {
+	Metric []*mimirpb.Metric `protobuf:"bytes,1,rep,name=metric,proto3" json:"metric,omitempty"`
+}
+
+func (m *ActiveSeriesResponse) Reset()      { *m = ActiveSeriesResponse{} }
+func (*ActiveSeriesResponse) ProtoMessage() {}
+func (*ActiveSeriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{7}
+}
+func (m *ActiveSeriesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ActiveSeriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ActiveSeriesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ActiveSeriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActiveSeriesResponse.Merge(m, src)
+}
+func (m *ActiveSeriesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ActiveSeriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActiveSeriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActiveSeriesResponse proto.InternalMessageInfo
+
+func (m *ActiveSeriesResponse) GetMetric() []*mimirpb.Metric {
+	if m != nil {
+		return m.Metric
+	}
+	return nil
+}
+
 type ReadRequest struct {
 	Queries               []*QueryRequest            `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
 	AcceptedResponseTypes []ReadRequest_ResponseType `protobuf:"varint,2,rep,packed,name=accepted_response_types,json=acceptedResponseTypes,proto3,enum=cortex.ReadRequest_ResponseType" json:"accepted_response_types,omitempty"`
@@ -431,7 +526,7 @@ type ReadRequest struct {
 func (m *ReadRequest) Reset()      { *m = ReadRequest{} }
 func (*ReadRequest) ProtoMessage() {}
 func (*ReadRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{6}
+	return fileDescriptor_60f6df4f3586b478, []int{8}
 }
 func (m *ReadRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -481,7 +576,7 @@ type ReadResponse struct {
 func (m *ReadResponse) Reset()      { *m = ReadResponse{} }
 func (*ReadResponse) ProtoMessage() {}
 func (*ReadResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{7}
+	return fileDescriptor_60f6df4f3586b478, []int{9}
 }
 func (m *ReadResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -525,7 +620,7 @@ type StreamReadResponse struct {
 func (m *StreamReadResponse) Reset()      { *m = StreamReadResponse{} }
 func (*StreamReadResponse) ProtoMessage() {}
 func (*StreamReadResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{8}
+	return fileDescriptor_60f6df4f3586b478, []int{10}
 }
 func (m *StreamReadResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -576,7 +671,7 @@ type StreamChunkedSeries struct {
 func (m *StreamChunkedSeries) Reset()      { *m = StreamChunkedSeries{} }
 func (*StreamChunkedSeries) ProtoMessage() {}
 func (*StreamChunkedSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{9}
+	return fileDescriptor_60f6df4f3586b478, []int{11}
 }
 func (m *StreamChunkedSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -622,7 +717,7 @@ type StreamChunk struct {
 func (m *StreamChunk) Reset()      { *m = StreamChunk{} }
 func (*StreamChunk) ProtoMessage() {}
 func (*StreamChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{10}
+	return fileDescriptor_60f6df4f3586b478, []int{12}
 }
 func (m *StreamChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -683,7 +778,7 @@ type QueryRequest struct {
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
 func (*QueryRequest) ProtoMessage() {}
 func (*QueryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{11}
+	return fileDescriptor_60f6df4f3586b478, []int{13}
 }
 func (m *QueryRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -749,7 +844,7 @@ type ExemplarQueryRequest struct {
 func (m *ExemplarQueryRequest) Reset()      { *m = ExemplarQueryRequest{} }
 func (*ExemplarQueryRequest) ProtoMessage() {}
 func (*ExemplarQueryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{12}
+	return fileDescriptor_60f6df4f3586b478, []int{14}
 }
 func (m *ExemplarQueryRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -806,7 +901,7 @@ type QueryResponse struct {
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{13}
+	return fileDescriptor_60f6df4f3586b478, []int{15}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -861,7 +956,7 @@ type QueryStreamResponse struct {
 func (m *QueryStreamResponse) Reset()      { *m = QueryStreamResponse{} }
 func (*QueryStreamResponse) ProtoMessage() {}
 func (*QueryStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{14}
+	return fileDescriptor_60f6df4f3586b478, []int{16}
 }
 func (m *QueryStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -932,7 +1027,7 @@ type QueryStreamSeries struct {
 func (m *QueryStreamSeries) Reset()      { *m = QueryStreamSeries{} }
 func (*QueryStreamSeries) ProtoMessage() {}
 func (*QueryStreamSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{15}
+	return fileDescriptor_60f6df4f3586b478, []int{17}
 }
 func (m *QueryStreamSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -969,7 +1064,7 @@ type QueryStreamSeriesChunks struct {
 func (m *QueryStreamSeriesChunks) Reset()      { *m = QueryStreamSeriesChunks{} }
 func (*QueryStreamSeriesChunks) ProtoMessage() {}
 func (*QueryStreamSeriesChunks) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{16}
+	return fileDescriptor_60f6df4f3586b478, []int{18}
 }
 func (m *QueryStreamSeriesChunks) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1019,7 +1114,7 @@ type ExemplarQueryResponse struct {
 func (m *ExemplarQueryResponse) Reset()      { *m = ExemplarQueryResponse{} }
 func (*ExemplarQueryResponse) ProtoMessage() {}
 func (*ExemplarQueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{17}
+	return fileDescriptor_60f6df4f3586b478, []int{19}
 }
 func (m *ExemplarQueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1060,12 +1155,16 @@ type LabelValuesRequest struct {
 	StartTimestampMs int64          `protobuf:"varint,2,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64          `protobuf:"varint,3,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         *LabelMatchers `protobuf:"bytes,4,opt,name=matchers,proto3" json:"matchers,omitempty"`
+	// If greater than 0, at most limit values are returned.
+	Limit int64 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// If set, only the values sorting after it are returned, to continue from the last value of a previous page.
+	After string `protobuf:"bytes,6,opt,name=after,proto3" json:"after,omitempty"`
 }
 
 func (m *LabelValuesRequest) Reset()      { *m = LabelValuesRequest{} }
 func (*LabelValuesRequest) ProtoMessage() {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{18}
+	return fileDescriptor_60f6df4f3586b478, []int{20}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1122,6 +1221,20 @@ func (m *LabelValuesRequest) GetMatchers() *LabelMatchers {
 	return nil
 }
 
+func (m *LabelValuesRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *LabelValuesRequest) GetAfter() string {
+	if m != nil {
+		return m.After
+	}
+	return ""
+}
+
 type LabelValuesResponse struct {
 	LabelValues []string `protobuf:"bytes,1,rep,name=label_values,json=labelValues,proto3" json:"label_values,omitempty"`
 }
@@ -1129,7 +1242,7 @@ type LabelValuesResponse struct {
 func (m *LabelValuesResponse) Reset()      { *m = LabelValuesResponse{} }
 func (*LabelValuesResponse) ProtoMessage() {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{19}
+	return fileDescriptor_60f6df4f3586b478, []int{21}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1169,12 +1282,16 @@ type LabelNamesRequest struct {
 	StartTimestampMs int64          `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64          `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         *LabelMatchers `protobuf:"bytes,3,opt,name=matchers,proto3" json:"matchers,omitempty"`
+	// If greater than 0, at most limit names are returned.
+	Limit int64 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// If set, only the names sorting after it are returned, to continue from the last name of a previous page.
+	After string `protobuf:"bytes,5,opt,name=after,proto3" json:"after,omitempty"`
 }
 
 func (m *LabelNamesRequest) Reset()      { *m = LabelNamesRequest{} }
 func (*LabelNamesRequest) ProtoMessage() {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{20}
+	return fileDescriptor_60f6df4f3586b478, []int{22}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1224,6 +1341,20 @@ func (m *LabelNamesRequest) GetMatchers() *LabelMatchers {
 	return nil
 }
 
+func (m *LabelNamesRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *LabelNamesRequest) GetAfter() string {
+	if m != nil {
+		return m.After
+	}
+	return ""
+}
+
 type LabelNamesResponse struct {
 	LabelNames []string `protobuf:"bytes,1,rep,name=label_names,json=labelNames,proto3" json:"label_names,omitempty"`
 }
@@ -1231,7 +1362,7 @@ type LabelNamesResponse struct {
 func (m *LabelNamesResponse) Reset()      { *m = LabelNamesResponse{} }
 func (*LabelNamesResponse) ProtoMessage() {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{21}
+	return fileDescriptor_60f6df4f3586b478, []int{23}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1274,7 +1405,7 @@ type UserStatsRequest struct {
 func (m *UserStatsRequest) Reset()      { *m = UserStatsRequest{} }
 func (*UserStatsRequest) ProtoMessage() {}
 func (*UserStatsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{22}
+	return fileDescriptor_60f6df4f3586b478, []int{24}
 }
 func (m *UserStatsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1320,7 +1451,7 @@ type UserStatsResponse struct {
 func (m *UserStatsResponse) Reset()      { *m = UserStatsResponse{} }
 func (*UserStatsResponse) ProtoMessage() {}
 func (*UserStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{23}
+	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
 func (m *UserStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1385,7 +1516,7 @@ type UserIDStatsResponse struct {
 func (m *UserIDStatsResponse) Reset()      { *m = UserIDStatsResponse{} }
 func (*UserIDStatsResponse) ProtoMessage() {}
 func (*UserIDStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *UserIDStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1435,7 +1566,7 @@ type UsersStatsResponse struct {
 func (m *UsersStatsResponse) Reset()      { *m = UsersStatsResponse{} }
 func (*UsersStatsResponse) ProtoMessage() {}
 func (*UsersStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *UsersStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1480,7 +1611,7 @@ type MetricsForLabelMatchersRequest struct {
 func (m *MetricsForLabelMatchersRequest) Reset()      { *m = MetricsForLabelMatchersRequest{} }
 func (*MetricsForLabelMatchersRequest) ProtoMessage() {}
 func (*MetricsForLabelMatchersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *MetricsForLabelMatchersRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1537,7 +1668,7 @@ type MetricsForLabelMatchersResponse struct {
 func (m *MetricsForLabelMatchersResponse) Reset()      { *m = MetricsForLabelMatchersResponse{} }
 func (*MetricsForLabelMatchersResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{27}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *MetricsForLabelMatchersResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1579,7 +1710,7 @@ type MetricsMetadataRequest struct {
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{28}
+	return fileDescriptor_60f6df4f3586b478, []int{30}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1615,7 +1746,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{29}
+	return fileDescriptor_60f6df4f3586b478, []int{31}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1661,7 +1792,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{30}
+	return fileDescriptor_60f6df4f3586b478, []int{32}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1721,7 +1852,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{31}
+	return fileDescriptor_60f6df4f3586b478, []int{33}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1778,7 +1909,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{32}
+	return fileDescriptor_60f6df4f3586b478, []int{34}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1823,7 +1954,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{33}
+	return fileDescriptor_60f6df4f3586b478, []int{35}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1883,7 +2014,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{34}
+	return fileDescriptor_60f6df4f3586b478, []int{36}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1940,6 +2071,196 @@ func (m *TimeSeriesFile) GetData() []byte {
 	return nil
 }
 
+type MultiTenantWriteRequest struct {
+	Requests []*TenantWriteRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (m *MultiTenantWriteRequest) Reset()      { *m = MultiTenantWriteRequest{} }
+func (*MultiTenantWriteRequest) ProtoMessage() {}
+func (*MultiTenantWriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{37}
+}
+func (m *MultiTenantWriteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MultiTenantWriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MultiTenantWriteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MultiTenantWriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiTenantWriteRequest.Merge(m, src)
+}
+func (m *MultiTenantWriteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *MultiTenantWriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiTenantWriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiTenantWriteRequest proto.InternalMessageInfo
+
+func (m *MultiTenantWriteRequest) GetRequests() []*TenantWriteRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type TenantWriteRequest struct {
+	TenantId string                `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Request  *mimirpb.WriteRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+}
+
+func (m *TenantWriteRequest) Reset()      { *m = TenantWriteRequest{} }
+func (*TenantWriteRequest) ProtoMessage() {}
+func (*TenantWriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{38}
+}
+func (m *TenantWriteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TenantWriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TenantWriteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TenantWriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantWriteRequest.Merge(m, src)
+}
+func (m *TenantWriteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *TenantWriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantWriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantWriteRequest proto.InternalMessageInfo
+
+func (m *TenantWriteRequest) GetTenantId() string {
+	if m != nil {
+		return m.TenantId
+	}
+	return ""
+}
+
+func (m *TenantWriteRequest) GetRequest() *mimirpb.WriteRequest {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+type MultiTenantWriteResponse struct {
+	// The responses are in the same order of the requests.
+	Responses []*TenantWriteResponse `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+}
+
+func (m *MultiTenantWriteResponse) Reset()      { *m = MultiTenantWriteResponse{} }
+func (*MultiTenantWriteResponse) ProtoMessage() {}
+func (*MultiTenantWriteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{39}
+}
+func (m *MultiTenantWriteResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MultiTenantWriteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MultiTenantWriteResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MultiTenantWriteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiTenantWriteResponse.Merge(m, src)
+}
+func (m *MultiTenantWriteResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *MultiTenantWriteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiTenantWriteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiTenantWriteResponse proto.InternalMessageInfo
+
+func (m *MultiTenantWriteResponse) GetResponses() []*TenantWriteResponse {
+	if m != nil {
+		return m.Responses
+	}
+	return nil
+}
+
+type TenantWriteResponse struct {
+	// HTTP status code of the error, or 0 if the write request succeeded.
+	ErrorCode int32  `protobuf:"varint,1,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	Error     string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *TenantWriteResponse) Reset()      { *m = TenantWriteResponse{} }
+func (*TenantWriteResponse) ProtoMessage() {}
+func (*TenantWriteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{40}
+}
+func (m *TenantWriteResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TenantWriteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TenantWriteResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TenantWriteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantWriteResponse.Merge(m, src)
+}
+func (m *TenantWriteResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TenantWriteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantWriteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantWriteResponse proto.InternalMessageInfo
+
+func (m *TenantWriteResponse) GetErrorCode() int32 {
+	if m != nil {
+		return m.ErrorCode
+	}
+	return 0
+}
+
+func (m *TenantWriteResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("cortex.CountMethod", CountMethod_name, CountMethod_value)
 	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
@@ -1952,6 +2273,8 @@ func init() {
 	proto.RegisterType((*LabelValuesCardinalityResponse)(nil), "cortex.LabelValuesCardinalityResponse")
 	proto.RegisterType((*LabelValueSeriesCount)(nil), "cortex.LabelValueSeriesCount")
 	proto.RegisterMapType((map[string]uint64)(nil), "cortex.LabelValueSeriesCount.LabelValueSeriesEntry")
+	proto.RegisterType((*ActiveSeriesRequest)(nil), "cortex.ActiveSeriesRequest")
+	proto.RegisterType((*ActiveSeriesResponse)(nil), "cortex.ActiveSeriesResponse")
 	proto.RegisterType((*ReadRequest)(nil), "cortex.ReadRequest")
 	proto.RegisterType((*ReadResponse)(nil), "cortex.ReadResponse")
 	proto.RegisterType((*StreamReadResponse)(nil), "cortex.StreamReadResponse")
@@ -1981,132 +2304,150 @@ func init() {
 	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
 	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
 	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+	proto.RegisterType((*MultiTenantWriteRequest)(nil), "cortex.MultiTenantWriteRequest")
+	proto.RegisterType((*TenantWriteRequest)(nil), "cortex.TenantWriteRequest")
+	proto.RegisterType((*MultiTenantWriteResponse)(nil), "cortex.MultiTenantWriteResponse")
+	proto.RegisterType((*TenantWriteResponse)(nil), "cortex.TenantWriteResponse")
 }
 
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1906 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x6f, 0x1b, 0xc7,
-	0x15, 0xe7, 0xf0, 0x4b, 0xe2, 0x23, 0x45, 0xad, 0x86, 0x96, 0xc9, 0xd0, 0x35, 0xa5, 0x6c, 0xe1,
-	0x94, 0x4d, 0x13, 0xca, 0x5f, 0x2d, 0x9c, 0x20, 0x45, 0x40, 0x49, 0xb4, 0x45, 0xdb, 0x24, 0x9d,
-	0x25, 0x95, 0xb8, 0x05, 0x82, 0xc5, 0x92, 0x1c, 0x49, 0x0b, 0x73, 0x97, 0xcc, 0xce, 0xb2, 0x90,
-	0xd2, 0x4b, 0x81, 0xde, 0x8b, 0xde, 0x7a, 0x2b, 0xd0, 0x5b, 0xd1, 0x53, 0xd1, 0x4b, 0x6f, 0x3d,
-	0xe7, 0x12, 0xc0, 0xc7, 0xa0, 0x07, 0xa3, 0x96, 0x7b, 0x68, 0x6f, 0x01, 0xfa, 0x0f, 0x14, 0x3b,
-	0x33, 0xfb, 0xc9, 0x95, 0xa5, 0x04, 0xb6, 0x4f, 0xe4, 0xbc, 0x8f, 0xdf, 0xbc, 0xf7, 0xe6, 0xbd,
-	0x37, 0x6f, 0x07, 0x8a, 0xba, 0x79, 0x48, 0xa8, 0x4d, 0xac, 0xc6, 0xcc, 0x9a, 0xda, 0x53, 0x9c,
-	0x1d, 0x4d, 0x2d, 0x9b, 0x1c, 0x57, 0xdf, 0x3f, 0xd4, 0xed, 0xa3, 0xf9, 0xb0, 0x31, 0x9a, 0x1a,
-	0x5b, 0x87, 0xd3, 0xc3, 0xe9, 0x16, 0x63, 0x0f, 0xe7, 0x07, 0x6c, 0xc5, 0x16, 0xec, 0x1f, 0x57,
-	0xab, 0x5e, 0x0f, 0x8a, 0x5b, 0xda, 0x81, 0x66, 0x6a, 0x5b, 0x86, 0x6e, 0xe8, 0xd6, 0xd6, 0xec,
-	0xc9, 0x21, 0xff, 0x37, 0x1b, 0xf2, 0x5f, 0xae, 0x21, 0x77, 0xa1, 0xfa, 0x50, 0x1b, 0x92, 0x49,
-	0x57, 0x33, 0x08, 0x6d, 0x9a, 0xe3, 0x4f, 0xb5, 0xc9, 0x9c, 0x50, 0x85, 0x7c, 0x31, 0x27, 0xd4,
-	0xc6, 0xd7, 0x61, 0xd9, 0xd0, 0xec, 0xd1, 0x11, 0xb1, 0x68, 0x05, 0x6d, 0xa6, 0xea, 0xf9, 0x9b,
-	0x97, 0x1a, 0xdc, 0xb2, 0x06, 0xd3, 0xea, 0x70, 0xa6, 0xe2, 0x49, 0xc9, 0x7b, 0x70, 0x25, 0x16,
-	0x8f, 0xce, 0xa6, 0x26, 0x25, 0xf8, 0xc7, 0x90, 0xd1, 0x6d, 0x62, 0xb8, 0x68, 0xa5, 0x10, 0x9a,
-	0x90, 0xe5, 0x12, 0xf2, 0x2e, 0xe4, 0x03, 0x54, 0x7c, 0x15, 0x60, 0xe2, 0x2c, 0x55, 0x53, 0x33,
-	0x48, 0x05, 0x6d, 0xa2, 0x7a, 0x4e, 0xc9, 0x4d, 0xdc, 0xad, 0xf0, 0x65, 0xc8, 0xfe, 0x8a, 0x09,
-	0x56, 0x92, 0x9b, 0xa9, 0x7a, 0x4e, 0x11, 0x2b, 0xf9, 0x2f, 0x08, 0xae, 0x06, 0x60, 0x76, 0x34,
-	0x6b, 0xac, 0x9b, 0xda, 0x44, 0xb7, 0x4f, 0x5c, 0x1f, 0x37, 0x20, 0xef, 0x03, 0x73, 0xc3, 0x72,
-	0x0a, 0x78, 0xc8, 0x34, 0x14, 0x84, 0xe4, 0x45, 0x82, 0x80, 0x7f, 0x06, 0x85, 0xd1, 0x74, 0x6e,
-	0xda, 0xaa, 0x41, 0xec, 0xa3, 0xe9, 0xb8, 0x92, 0xda, 0x44, 0xf5, 0xa2, 0xef, 0xec, 0x8e, 0xc3,
-	0xeb, 0x30, 0x96, 0x92, 0x1f, 0xf9, 0x0b, 0x79, 0x1f, 0x6a, 0x67, 0xd9, 0x2a, 0xe2, 0x77, 0x2b,
-	0x1c, 0xbf, 0xab, 0x8b, 0xf1, 0xeb, 0x13, 0x4b, 0x27, 0x94, 0x6d, 0xe1, 0x46, 0xf2, 0x19, 0x82,
-	0xf5, 0x58, 0x81, 0xf3, 0x82, 0xaa, 0x01, 0xe6, 0x6c, 0x16, 0x4c, 0x95, 0x32, 0x4d, 0x11, 0x83,
-	0x5b, 0x2f, 0xdd, 0x7a, 0x81, 0xda, 0x32, 0x6d, 0xeb, 0x44, 0x91, 0x26, 0x11, 0x72, 0x75, 0x67,
-	0xd1, 0x34, 0x26, 0x8a, 0x25, 0x48, 0x3d, 0x21, 0x27, 0xc2, 0x26, 0xe7, 0x2f, 0xbe, 0x04, 0x19,
-	0x66, 0x47, 0x25, 0xb9, 0x89, 0xea, 0x69, 0x85, 0x2f, 0x3e, 0x4c, 0xde, 0x41, 0xf2, 0xd7, 0x08,
-	0xf2, 0x0a, 0xd1, 0xc6, 0xee, 0x91, 0x36, 0x60, 0xe9, 0x8b, 0x39, 0x37, 0x36, 0x92, 0xb5, 0x9f,
-	0xcc, 0x89, 0xe5, 0x9e, 0xbc, 0xe2, 0x0a, 0xe1, 0xc7, 0x50, 0xd6, 0x46, 0x23, 0x32, 0xb3, 0xc9,
-	0x58, 0xb5, 0x44, 0xa8, 0x55, 0xfb, 0x64, 0x26, 0x9c, 0x2d, 0xde, 0xdc, 0x74, 0xf5, 0x03, 0xbb,
-	0x34, 0xdc, 0x43, 0x19, 0x9c, 0xcc, 0x88, 0xb2, 0xee, 0x02, 0x04, 0xa9, 0x54, 0xbe, 0x0d, 0x85,
-	0x20, 0x01, 0xe7, 0x61, 0xa9, 0xdf, 0xec, 0x3c, 0x7a, 0xd8, 0xea, 0x4b, 0x09, 0x5c, 0x86, 0x52,
-	0x7f, 0xa0, 0xb4, 0x9a, 0x9d, 0xd6, 0xae, 0xfa, 0xb8, 0xa7, 0xa8, 0x3b, 0x7b, 0xfb, 0xdd, 0x07,
-	0x7d, 0x09, 0xc9, 0x1f, 0x3b, 0x5a, 0x9a, 0x07, 0x85, 0xb7, 0x60, 0xc9, 0x22, 0x74, 0x3e, 0xb1,
-	0x5d, 0x7f, 0xd6, 0x23, 0xfe, 0x70, 0x39, 0xc5, 0x95, 0x92, 0x4f, 0x00, 0xf7, 0x6d, 0x8b, 0x68,
-	0x46, 0x08, 0x66, 0x1b, 0x8a, 0xa3, 0xa3, 0xb9, 0xf9, 0x84, 0x8c, 0xdd, 0xa3, 0xe4, 0x68, 0x57,
-	0x5c, 0x34, 0xae, 0xb3, 0xc3, 0x65, 0xf8, 0x61, 0x28, 0x2b, 0xa3, 0xe0, 0xd2, 0xa9, 0x16, 0x27,
-	0x6a, 0x27, 0xaa, 0x6e, 0x8e, 0xc9, 0x31, 0x3b, 0x8a, 0x94, 0x02, 0x8c, 0xd4, 0x76, 0x28, 0xf2,
-	0x5f, 0x11, 0x94, 0x62, 0x70, 0xf0, 0x01, 0x64, 0xd9, 0xe1, 0x47, 0x4b, 0x7f, 0x36, 0xe4, 0xb9,
-	0xf2, 0x48, 0xd3, 0xad, 0xed, 0x0f, 0xbe, 0x7a, 0xb6, 0x91, 0xf8, 0xe7, 0xb3, 0x8d, 0x1b, 0x17,
-	0xe9, 0x63, 0x5c, 0xaf, 0x39, 0xd6, 0x66, 0x36, 0xb1, 0x14, 0x81, 0x8e, 0x6f, 0x40, 0x96, 0x59,
-	0xec, 0xe6, 0x69, 0x29, 0xc6, 0xb9, 0xed, 0xb4, 0xb3, 0x8f, 0x22, 0x04, 0xe5, 0x3f, 0x24, 0x21,
-	0x1f, 0xe0, 0xe2, 0x1a, 0xe4, 0x0d, 0xdd, 0x54, 0x6d, 0xdd, 0x20, 0x2a, 0x2b, 0x35, 0xc7, 0xc7,
-	0x9c, 0xa1, 0x9b, 0x03, 0xdd, 0x20, 0x1d, 0xca, 0xf8, 0xda, 0xb1, 0xc7, 0x4f, 0x0a, 0xbe, 0x76,
-	0x2c, 0xf8, 0xd7, 0x21, 0xed, 0x24, 0x8f, 0x28, 0xfb, 0x1f, 0xc4, 0x18, 0xd0, 0x68, 0x99, 0xa3,
-	0xe9, 0x58, 0x37, 0x0f, 0x15, 0x26, 0x89, 0x1f, 0x41, 0x7a, 0xac, 0xd9, 0x5a, 0x25, 0xbd, 0x89,
-	0xea, 0x85, 0xed, 0x8f, 0x44, 0x14, 0x6e, 0x5f, 0x28, 0x0a, 0xfb, 0x26, 0xd5, 0x0e, 0xc8, 0xf6,
-	0x89, 0x4d, 0xfa, 0x13, 0x7d, 0x44, 0x14, 0x86, 0x24, 0xef, 0xc2, 0xb2, 0xbb, 0x87, 0x93, 0x74,
-	0xfb, 0xdd, 0x07, 0xdd, 0xde, 0x67, 0x5d, 0x29, 0x81, 0x97, 0x20, 0xf5, 0xb8, 0xa7, 0x48, 0x08,
-	0xaf, 0x40, 0x6e, 0xaf, 0xdd, 0x1f, 0xf4, 0xee, 0x29, 0xcd, 0x8e, 0x94, 0xc4, 0x25, 0x58, 0xbd,
-	0xfb, 0xb0, 0xd7, 0x1c, 0xa8, 0x3e, 0x31, 0x25, 0xff, 0x1b, 0x41, 0x21, 0x58, 0x32, 0xf8, 0x3d,
-	0xc0, 0xd4, 0xd6, 0x2c, 0x9b, 0x39, 0x4f, 0x6d, 0xcd, 0x98, 0xf9, 0x11, 0x92, 0x18, 0x67, 0xe0,
-	0x32, 0x3a, 0x14, 0xd7, 0x41, 0x22, 0xe6, 0x38, 0x2c, 0xcb, 0xa3, 0x55, 0x24, 0xe6, 0x38, 0x28,
-	0x19, 0xec, 0xb1, 0xa9, 0x0b, 0xf5, 0xd8, 0x9f, 0xc3, 0x15, 0xca, 0x02, 0xaa, 0x9b, 0x87, 0x2a,
-	0x3f, 0x48, 0x75, 0xe8, 0x30, 0x55, 0xaa, 0x7f, 0x49, 0x2a, 0x63, 0xd6, 0x23, 0x2a, 0x9e, 0x08,
-	0x0b, 0x3b, 0xdd, 0x76, 0x04, 0xfa, 0xfa, 0x97, 0xe4, 0x7e, 0x7a, 0x39, 0x2d, 0x65, 0x94, 0xcc,
-	0x91, 0x6e, 0xda, 0x54, 0xfe, 0x13, 0x82, 0x4b, 0xad, 0x63, 0x62, 0xcc, 0x26, 0x9a, 0xf5, 0x46,
-	0xdc, 0xbd, 0xb1, 0xe0, 0xee, 0x7a, 0x9c, 0xbb, 0x34, 0x70, 0xb1, 0x3e, 0x80, 0x95, 0x50, 0xb1,
-	0xe3, 0x0f, 0x01, 0xd8, 0x4e, 0x71, 0x7d, 0x6e, 0x36, 0x6c, 0x38, 0xdb, 0xf1, 0xd2, 0x13, 0xd9,
-	0x1e, 0x90, 0x96, 0xff, 0x97, 0x84, 0x12, 0x43, 0x73, 0xbb, 0x84, 0xc0, 0xfc, 0x18, 0xf2, 0x3c,
-	0x94, 0x41, 0xd0, 0xb2, 0x6b, 0x9a, 0x0f, 0x19, 0xac, 0xa2, 0xa0, 0x46, 0xc4, 0xa8, 0xe4, 0x77,
-	0x31, 0x0a, 0xdf, 0x07, 0xc9, 0x3f, 0x51, 0x81, 0xc0, 0x83, 0xf3, 0x56, 0xa8, 0xdd, 0x71, 0x9b,
-	0x43, 0x30, 0xab, 0x9e, 0xa2, 0xe8, 0x36, 0xb7, 0xa1, 0xac, 0x53, 0xd5, 0x39, 0x8d, 0xe9, 0x81,
-	0xc0, 0x52, 0xb9, 0x0c, 0xab, 0xb1, 0x65, 0xa5, 0xa4, 0xd3, 0x96, 0x39, 0xee, 0x1d, 0x70, 0x79,
-	0x0e, 0x89, 0x3f, 0x87, 0x72, 0xd4, 0x02, 0x91, 0x5a, 0x95, 0x0c, 0x33, 0x64, 0xe3, 0x4c, 0x43,
-	0x44, 0x7e, 0x71, 0x73, 0xd6, 0x23, 0xe6, 0x70, 0xa6, 0xfc, 0x6b, 0x58, 0x5b, 0xd0, 0x7b, 0x53,
-	0x7d, 0x51, 0xd6, 0xa1, 0x7c, 0x86, 0xd1, 0xf8, 0x6d, 0x28, 0x08, 0x67, 0x79, 0x53, 0x47, 0xac,
-	0x76, 0xf2, 0x9c, 0xc6, 0xba, 0x3a, 0xfe, 0x49, 0xa4, 0xab, 0xae, 0x78, 0xb3, 0x4c, 0x4c, 0x3f,
-	0xed, 0xc3, 0x7a, 0xa4, 0x9a, 0x5e, 0x41, 0xca, 0xfe, 0x03, 0x01, 0x0e, 0x4e, 0x89, 0xa2, 0x42,
-	0xcf, 0x99, 0x60, 0xe2, 0x0b, 0x38, 0xf9, 0x1d, 0x0a, 0x38, 0x75, 0x6e, 0x01, 0x3b, 0x09, 0x75,
-	0x81, 0x02, 0xbe, 0x03, 0xa5, 0x90, 0xfd, 0x22, 0x26, 0x6f, 0x43, 0x21, 0x30, 0x63, 0xb9, 0xf3,
-	0x67, 0xde, 0x1f, 0x94, 0xa8, 0xfc, 0x47, 0x04, 0x6b, 0xfe, 0x50, 0xfd, 0x66, 0x7b, 0xd3, 0x85,
-	0x5c, 0xfb, 0xa9, 0x38, 0x1a, 0x61, 0x9f, 0xf0, 0xec, 0xbc, 0xc1, 0x5a, 0xbe, 0x0f, 0xd2, 0x3e,
-	0x25, 0x56, 0xdf, 0xd6, 0x6c, 0xcf, 0xab, 0xe8, 0xe8, 0x8c, 0x2e, 0x38, 0x3a, 0xff, 0x1d, 0xc1,
-	0x5a, 0x00, 0x4c, 0x98, 0x70, 0xcd, 0xfd, 0xb0, 0xd2, 0xa7, 0xa6, 0x6a, 0x69, 0x36, 0xcf, 0x10,
-	0xa4, 0xac, 0x78, 0x54, 0x45, 0xb3, 0x89, 0x93, 0x44, 0xe6, 0xdc, 0xf0, 0xe7, 0x5b, 0x27, 0xfd,
-	0x73, 0xe6, 0xdc, 0x2d, 0xd1, 0xf7, 0x00, 0x6b, 0x33, 0x5d, 0x8d, 0x20, 0xa5, 0x18, 0x92, 0xa4,
-	0xcd, 0xf4, 0x76, 0x08, 0xac, 0x01, 0x25, 0x6b, 0x3e, 0x21, 0x51, 0xf1, 0x34, 0x13, 0x5f, 0x73,
-	0x58, 0x21, 0x79, 0xf9, 0x73, 0x28, 0x39, 0x86, 0xb7, 0x77, 0xc3, 0xa6, 0x97, 0x61, 0x69, 0x4e,
-	0x89, 0xa5, 0xea, 0x63, 0x91, 0xd5, 0x59, 0x67, 0xd9, 0x1e, 0xe3, 0xf7, 0xc5, 0xac, 0x90, 0x64,
-	0x67, 0xe3, 0xb5, 0xc6, 0x05, 0xe7, 0xc5, 0x20, 0x70, 0x0f, 0xb0, 0xc3, 0xa2, 0x61, 0xf4, 0x1b,
-	0x90, 0xa1, 0x0e, 0x21, 0x3a, 0x01, 0xc6, 0x58, 0xa2, 0x70, 0x49, 0xf9, 0x6f, 0x08, 0x6a, 0x1d,
-	0x62, 0x5b, 0xfa, 0x88, 0xde, 0x9d, 0x5a, 0xe1, 0x54, 0x78, 0xcd, 0x29, 0x79, 0x07, 0x0a, 0x6e,
-	0xae, 0xa9, 0x94, 0xd8, 0x2f, 0xbf, 0x32, 0xf3, 0xae, 0x68, 0x9f, 0xd8, 0xf2, 0x03, 0xd8, 0x38,
-	0xd3, 0x66, 0x11, 0x8a, 0x3a, 0x64, 0x0d, 0x26, 0x22, 0x62, 0x21, 0xf9, 0x0d, 0x89, 0xab, 0x2a,
-	0x82, 0x2f, 0x57, 0xe0, 0xb2, 0x00, 0xeb, 0x10, 0x5b, 0x73, 0xa2, 0x2b, 0x1c, 0x97, 0x7b, 0x50,
-	0x5e, 0xe0, 0x08, 0xf8, 0xdb, 0xb0, 0x6c, 0x08, 0x9a, 0xd8, 0xa0, 0x12, 0xdd, 0xc0, 0xd3, 0xf1,
-	0x24, 0xe5, 0xff, 0x22, 0x58, 0x8d, 0x5c, 0xb7, 0x4e, 0xbc, 0x0e, 0xac, 0xa9, 0xa1, 0xba, 0x4f,
-	0x05, 0x7e, 0x6a, 0x14, 0x1d, 0x7a, 0x5b, 0x90, 0xdb, 0xe3, 0x60, 0xee, 0x24, 0x43, 0xb9, 0xe3,
-	0x5f, 0x36, 0xa9, 0xd7, 0x3a, 0x84, 0xfb, 0xd7, 0x45, 0xfa, 0xfc, 0xeb, 0xe2, 0x6b, 0x04, 0x19,
-	0xee, 0xe1, 0xeb, 0xca, 0x9f, 0x2a, 0x2c, 0x13, 0x31, 0x0c, 0xb3, 0xb2, 0xcd, 0x28, 0xde, 0xfa,
-	0x35, 0x8c, 0xde, 0x4d, 0x58, 0x09, 0x65, 0xda, 0xf7, 0x78, 0x45, 0x51, 0xa1, 0x10, 0xe4, 0xe0,
-	0x6b, 0xe2, 0x8b, 0x82, 0x77, 0xc3, 0x35, 0x57, 0x9b, 0xb1, 0xd9, 0xe7, 0x27, 0xff, 0x8c, 0xc0,
-	0x90, 0x66, 0xd7, 0x20, 0x3f, 0x74, 0xf6, 0xdf, 0xff, 0x6a, 0x4e, 0x31, 0x22, 0x5f, 0xc8, 0xbf,
-	0x45, 0x50, 0xf4, 0xf3, 0xeb, 0xae, 0x3e, 0x21, 0xaf, 0x22, 0xbd, 0xaa, 0xb0, 0x7c, 0xa0, 0x4f,
-	0x08, 0xb3, 0x81, 0x6f, 0xe7, 0xad, 0x1d, 0xdb, 0xfc, 0x38, 0xf3, 0x48, 0xbd, 0x5b, 0x87, 0x7c,
-	0xa0, 0xa1, 0x3b, 0x5f, 0x24, 0xed, 0xae, 0xda, 0x69, 0x75, 0x7a, 0xca, 0x2f, 0xa4, 0x04, 0x06,
-	0xc8, 0x36, 0x77, 0x06, 0xed, 0x4f, 0x5b, 0x12, 0x7a, 0xf7, 0x3e, 0xe4, 0x3c, 0x67, 0x71, 0x0e,
-	0x32, 0xad, 0x4f, 0xf6, 0x9b, 0x0f, 0xa5, 0x84, 0xa3, 0xd2, 0xed, 0x0d, 0x54, 0xbe, 0x44, 0x78,
-	0x15, 0xf2, 0x4a, 0xeb, 0x5e, 0xeb, 0xb1, 0xda, 0x69, 0x0e, 0x76, 0xf6, 0xa4, 0x24, 0xc6, 0x50,
-	0xe4, 0x84, 0x6e, 0x4f, 0xd0, 0x52, 0x37, 0x7f, 0xb7, 0x04, 0xcb, 0xae, 0x37, 0xf8, 0x03, 0x48,
-	0x3f, 0x9a, 0xd3, 0x23, 0x7c, 0xd9, 0xaf, 0x84, 0xcf, 0x2c, 0xdd, 0x26, 0xa2, 0xb2, 0xab, 0xe5,
-	0x05, 0x3a, 0xaf, 0x6b, 0x39, 0x81, 0x77, 0x21, 0x1f, 0x98, 0xa8, 0x70, 0xec, 0x1b, 0x43, 0xf5,
-	0x4a, 0xcc, 0xc4, 0xe8, 0x63, 0x5c, 0x47, 0xb8, 0x07, 0x45, 0xc6, 0x72, 0x27, 0x26, 0x8a, 0xbd,
-	0x0f, 0xc6, 0xb8, 0x4f, 0x92, 0xea, 0xd5, 0x33, 0xb8, 0x9e, 0x59, 0x7b, 0xe1, 0x77, 0xb3, 0x6a,
-	0xdc, 0x13, 0x5b, 0xd4, 0xb8, 0x98, 0xc1, 0x44, 0x4e, 0xe0, 0x16, 0x80, 0x7f, 0xad, 0xe3, 0xb7,
-	0x42, 0xc2, 0xc1, 0x51, 0xa4, 0x5a, 0x8d, 0x63, 0x79, 0x30, 0xdb, 0x90, 0xf3, 0x2e, 0x27, 0x5c,
-	0x89, 0xb9, 0xaf, 0x38, 0xc8, 0xd9, 0x37, 0x99, 0x9c, 0xc0, 0x77, 0xa1, 0xd0, 0x9c, 0x4c, 0x2e,
-	0x02, 0x53, 0x0d, 0x72, 0x68, 0x14, 0x67, 0xe2, 0x35, 0xea, 0xe8, 0x7d, 0x80, 0xdf, 0xf1, 0xaa,
-	0xea, 0xa5, 0x97, 0x5c, 0xf5, 0x47, 0xe7, 0xca, 0x79, 0xbb, 0x0d, 0x60, 0x35, 0x72, 0x2d, 0xe0,
-	0x5a, 0x44, 0x3b, 0x72, 0x93, 0x54, 0x37, 0xce, 0xe4, 0x7b, 0xa8, 0x43, 0x31, 0x48, 0x86, 0x9f,
-	0x58, 0xb1, 0xbc, 0x78, 0x08, 0xd1, 0xf7, 0xdc, 0xea, 0x0f, 0x5f, 0x2a, 0x13, 0xc8, 0xca, 0x27,
-	0x70, 0x39, 0xfe, 0x25, 0x12, 0x5f, 0x8b, 0xc9, 0x99, 0xc5, 0x57, 0xd5, 0xea, 0x3b, 0xe7, 0x89,
-	0xf9, 0x9b, 0x6d, 0x7f, 0xf4, 0xf4, 0x79, 0x2d, 0xf1, 0xcd, 0xf3, 0x5a, 0xe2, 0xdb, 0xe7, 0x35,
-	0xf4, 0x9b, 0xd3, 0x1a, 0xfa, 0xf3, 0x69, 0x0d, 0x7d, 0x75, 0x5a, 0x43, 0x4f, 0x4f, 0x6b, 0xe8,
-	0x5f, 0xa7, 0x35, 0xf4, 0x9f, 0xd3, 0x5a, 0xe2, 0xdb, 0xd3, 0x1a, 0xfa, 0xfd, 0x8b, 0x5a, 0xe2,
-	0xe9, 0x8b, 0x5a, 0xe2, 0x9b, 0x17, 0xb5, 0xc4, 0x2f, 0xb3, 0xa3, 0x89, 0x4e, 0x4c, 0x7b, 0x98,
-	0x65, 0x0f, 0xd9, 0xb7, 0xfe, 0x1f, 0x00, 0x00, 0xff, 0xff, 0xd1, 0x8b, 0x35, 0x44, 0x43, 0x17,
-	0x00, 0x00,
+	// 2141 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x59, 0x4f, 0x6f, 0x1b, 0xc7,
+	0x15, 0xe7, 0xf2, 0x9f, 0xc8, 0x47, 0x4a, 0xa2, 0x86, 0x96, 0xc9, 0xac, 0x22, 0x4a, 0xd9, 0xc2,
+	0xa9, 0x9a, 0x26, 0x94, 0xfc, 0xa7, 0x85, 0x1d, 0xa4, 0x48, 0x29, 0x89, 0xb6, 0x28, 0x9b, 0xa2,
+	0xbc, 0xa4, 0x1c, 0xb7, 0x40, 0xb0, 0x58, 0x72, 0x47, 0xd2, 0xc2, 0xdc, 0x25, 0xb3, 0x3b, 0x0c,
+	0xa4, 0xf4, 0x52, 0xa0, 0x5f, 0xa0, 0xb7, 0x9e, 0x7b, 0xeb, 0xb1, 0xe8, 0xa5, 0x5f, 0x21, 0x28,
+	0x1a, 0xc0, 0xa7, 0x22, 0xe8, 0xc1, 0xa8, 0xe5, 0x1e, 0xda, 0x9e, 0x02, 0xf4, 0x0b, 0x04, 0x3b,
+	0x33, 0xfb, 0x97, 0x2b, 0x4b, 0x36, 0x62, 0x9f, 0xc4, 0x79, 0xef, 0x37, 0xbf, 0x79, 0xf3, 0xf6,
+	0xbd, 0x37, 0x6f, 0x46, 0x30, 0xa7, 0x9b, 0x47, 0xd8, 0x26, 0xd8, 0xaa, 0x8f, 0xad, 0x11, 0x19,
+	0xa1, 0xec, 0x60, 0x64, 0x11, 0x7c, 0x22, 0x7e, 0x74, 0xa4, 0x93, 0xe3, 0x49, 0xbf, 0x3e, 0x18,
+	0x19, 0xeb, 0x47, 0xa3, 0xa3, 0xd1, 0x3a, 0x55, 0xf7, 0x27, 0x87, 0x74, 0x44, 0x07, 0xf4, 0x17,
+	0x9b, 0x26, 0x6e, 0x04, 0xe1, 0x96, 0x7a, 0xa8, 0x9a, 0xea, 0xba, 0xa1, 0x1b, 0xba, 0xb5, 0x3e,
+	0x7e, 0x72, 0xc4, 0x7e, 0x8d, 0xfb, 0xec, 0x2f, 0x9b, 0x21, 0xed, 0x81, 0xf8, 0x40, 0xed, 0xe3,
+	0xe1, 0x9e, 0x6a, 0x60, 0xbb, 0x61, 0x6a, 0x8f, 0xd4, 0xe1, 0x04, 0xdb, 0x32, 0xfe, 0x62, 0x82,
+	0x6d, 0x82, 0x36, 0x20, 0x67, 0xa8, 0x64, 0x70, 0x8c, 0x2d, 0xbb, 0x2a, 0xac, 0xa6, 0xd6, 0x0a,
+	0x37, 0xae, 0xd4, 0x99, 0x65, 0x75, 0x3a, 0xab, 0xcd, 0x94, 0xb2, 0x87, 0x92, 0x76, 0x60, 0x29,
+	0x96, 0xcf, 0x1e, 0x8f, 0x4c, 0x1b, 0xa3, 0x9f, 0x40, 0x46, 0x27, 0xd8, 0x70, 0xd9, 0xca, 0x21,
+	0x36, 0x8e, 0x65, 0x08, 0x69, 0x1b, 0x0a, 0x01, 0x29, 0x5a, 0x06, 0x18, 0x3a, 0x43, 0xc5, 0x54,
+	0x0d, 0x5c, 0x15, 0x56, 0x85, 0xb5, 0xbc, 0x9c, 0x1f, 0xba, 0x4b, 0xa1, 0xab, 0x90, 0xfd, 0x92,
+	0x02, 0xab, 0xc9, 0xd5, 0xd4, 0x5a, 0x5e, 0xe6, 0x23, 0xe9, 0xef, 0x02, 0x2c, 0x07, 0x68, 0xb6,
+	0x54, 0x4b, 0xd3, 0x4d, 0x75, 0xa8, 0x93, 0x53, 0x77, 0x8f, 0x2b, 0x50, 0xf0, 0x89, 0x99, 0x61,
+	0x79, 0x19, 0x3c, 0x66, 0x3b, 0xe4, 0x84, 0xe4, 0x65, 0x9c, 0x80, 0x7e, 0x0e, 0xc5, 0xc1, 0x68,
+	0x62, 0x12, 0xc5, 0xc0, 0xe4, 0x78, 0xa4, 0x55, 0x53, 0xab, 0xc2, 0xda, 0x9c, 0xbf, 0xd9, 0x2d,
+	0x47, 0xd7, 0xa6, 0x2a, 0xb9, 0x30, 0xf0, 0x07, 0x68, 0x15, 0x0a, 0xea, 0x78, 0x6c, 0x8d, 0x4e,
+	0x74, 0x43, 0x25, 0xb8, 0x9a, 0x5e, 0x15, 0xd6, 0x72, 0x72, 0x50, 0x24, 0x1d, 0x40, 0xed, 0xbc,
+	0xdd, 0x70, 0x0f, 0xdf, 0x0c, 0x7b, 0x78, 0x79, 0xda, 0xc3, 0x5d, 0x6c, 0xe9, 0xd8, 0xa6, 0x46,
+	0xb8, 0xbe, 0x7e, 0x26, 0xc0, 0x62, 0x2c, 0xe0, 0x22, 0xb7, 0xab, 0x80, 0x98, 0x9a, 0xba, 0x5b,
+	0xb1, 0xe9, 0x4c, 0xee, 0xa5, 0x9b, 0x2f, 0x5d, 0x7a, 0x4a, 0xda, 0x34, 0x89, 0x75, 0x2a, 0x97,
+	0x86, 0x11, 0xb1, 0xb8, 0x35, 0x6d, 0x1a, 0x85, 0xa2, 0x12, 0xa4, 0x9e, 0xe0, 0x53, 0x6e, 0x93,
+	0xf3, 0x13, 0x5d, 0x81, 0x0c, 0xb5, 0xa3, 0x9a, 0x5c, 0x15, 0xd6, 0xd2, 0x32, 0x1b, 0x7c, 0x9c,
+	0xbc, 0x2d, 0x48, 0xf7, 0xa0, 0xdc, 0x18, 0x10, 0xfd, 0x4b, 0x4e, 0xf0, 0xfa, 0xf1, 0xfd, 0x4b,
+	0xb8, 0x12, 0x26, 0xe2, 0x6e, 0x5f, 0x83, 0xac, 0x81, 0x89, 0xa5, 0x0f, 0x38, 0x4f, 0x89, 0xf3,
+	0x8c, 0xfb, 0xf5, 0x36, 0x95, 0xcb, 0x5c, 0x2f, 0x7d, 0x23, 0x40, 0x41, 0xc6, 0xaa, 0xe6, 0xda,
+	0x50, 0x87, 0x99, 0x2f, 0x26, 0xcc, 0x6f, 0x11, 0x13, 0x1e, 0x4e, 0xb0, 0xe5, 0x86, 0xa9, 0xec,
+	0x82, 0xd0, 0x63, 0xa8, 0xa8, 0x83, 0x01, 0x1e, 0x13, 0xac, 0x29, 0x16, 0x5f, 0x5e, 0x21, 0xa7,
+	0x63, 0xee, 0xf7, 0xb9, 0x1b, 0xab, 0xee, 0xfc, 0xc0, 0x2a, 0x75, 0xd7, 0xd0, 0xde, 0xe9, 0x18,
+	0xcb, 0x8b, 0x2e, 0x41, 0x50, 0x6a, 0x4b, 0xb7, 0xa0, 0x18, 0x14, 0xa0, 0x02, 0xcc, 0x74, 0x1b,
+	0xed, 0xfd, 0x07, 0xcd, 0x6e, 0x29, 0x81, 0x2a, 0x50, 0xee, 0xf6, 0xe4, 0x66, 0xa3, 0xdd, 0xdc,
+	0x56, 0x1e, 0x77, 0x64, 0x65, 0x6b, 0xe7, 0x60, 0xef, 0x7e, 0xb7, 0x24, 0x48, 0x9f, 0x3a, 0xb3,
+	0x54, 0x8f, 0x0a, 0xad, 0xc3, 0x8c, 0x85, 0xed, 0xc9, 0x90, 0xb8, 0xfb, 0x59, 0x8c, 0xec, 0x87,
+	0xe1, 0x64, 0x17, 0x25, 0x9d, 0x02, 0xea, 0x12, 0x0b, 0xab, 0x46, 0x88, 0x66, 0x13, 0xe6, 0x06,
+	0xc7, 0x13, 0xf3, 0x09, 0xd6, 0xdc, 0xa8, 0x62, 0x6c, 0x4b, 0x2e, 0x1b, 0x9b, 0xb3, 0xc5, 0x30,
+	0xfc, 0x6b, 0xcc, 0x0e, 0x82, 0x43, 0x27, 0xb5, 0x1d, 0xaf, 0x9d, 0x2a, 0xba, 0xa9, 0xe1, 0x13,
+	0x1a, 0x15, 0x29, 0x19, 0xa8, 0xa8, 0xe5, 0x48, 0xa4, 0x3f, 0x0b, 0x50, 0x8e, 0xe1, 0x41, 0x87,
+	0x90, 0xa5, 0x71, 0x18, 0xad, 0x53, 0xe3, 0x3e, 0x8b, 0x8b, 0x7d, 0x55, 0xb7, 0x36, 0xef, 0x7c,
+	0xfd, 0x6c, 0x25, 0xf1, 0xcf, 0x67, 0x2b, 0xd7, 0x2f, 0x53, 0x74, 0xd9, 0xbc, 0x86, 0xa6, 0x8e,
+	0x09, 0xb6, 0x64, 0xce, 0x8e, 0xae, 0x43, 0x96, 0x5a, 0xec, 0xa6, 0x4c, 0x39, 0x66, 0x73, 0x9b,
+	0x69, 0x67, 0x1d, 0x99, 0x03, 0xa5, 0x3f, 0x24, 0xa1, 0x10, 0xd0, 0xa2, 0x1a, 0x14, 0x0c, 0xdd,
+	0x54, 0x88, 0x6e, 0x60, 0x85, 0x66, 0xbd, 0xb3, 0xc7, 0xbc, 0xa1, 0x9b, 0x3d, 0xdd, 0xc0, 0x6d,
+	0x9b, 0xea, 0xd5, 0x13, 0x4f, 0x9f, 0xe4, 0x7a, 0xf5, 0x84, 0xeb, 0x37, 0x20, 0xed, 0x04, 0x0f,
+	0xaf, 0x51, 0xef, 0xc6, 0x18, 0x50, 0x6f, 0x9a, 0x83, 0x91, 0xa6, 0x9b, 0x47, 0x32, 0x45, 0xa2,
+	0x7d, 0x48, 0x6b, 0x2a, 0x51, 0x69, 0x79, 0x2a, 0x6e, 0x7e, 0xc2, 0xbd, 0x70, 0xeb, 0x52, 0x5e,
+	0x38, 0x30, 0x6d, 0xf5, 0x10, 0x6f, 0x9e, 0x12, 0xdc, 0x1d, 0xea, 0x03, 0x2c, 0x53, 0x26, 0x69,
+	0x1b, 0x72, 0xee, 0x1a, 0x4e, 0xd0, 0x1d, 0xec, 0xdd, 0xdf, 0xeb, 0x7c, 0xb6, 0x57, 0x4a, 0xa0,
+	0x19, 0x48, 0x3d, 0xee, 0xc8, 0x25, 0x01, 0xcd, 0x42, 0x7e, 0xa7, 0xd5, 0xed, 0x75, 0xee, 0xc9,
+	0x8d, 0x76, 0x29, 0x89, 0xca, 0x30, 0x7f, 0xf7, 0x41, 0xa7, 0xd1, 0x53, 0x7c, 0x61, 0x4a, 0xfa,
+	0xb7, 0x00, 0xc5, 0x60, 0xca, 0xa0, 0x0f, 0x01, 0xd9, 0x44, 0xb5, 0x08, 0xdd, 0xbc, 0x4d, 0x54,
+	0x63, 0xec, 0x7b, 0xa8, 0x44, 0x35, 0x3d, 0x57, 0xd1, 0xb6, 0xd1, 0x1a, 0x94, 0xb0, 0xa9, 0x85,
+	0xb1, 0xcc, 0x5b, 0x73, 0xd8, 0xd4, 0x82, 0xc8, 0x60, 0xd5, 0x48, 0x5d, 0xea, 0x40, 0xf8, 0x05,
+	0x2c, 0xd9, 0xd4, 0xa1, 0xba, 0x79, 0xa4, 0xb0, 0x0f, 0xa9, 0xf4, 0x1d, 0xa5, 0x62, 0xeb, 0x5f,
+	0xe1, 0xaa, 0x46, 0xcb, 0x55, 0xd5, 0x83, 0x50, 0xb7, 0xdb, 0x9b, 0x0e, 0xa0, 0xab, 0x7f, 0x85,
+	0x77, 0xd3, 0xb9, 0x74, 0x29, 0x23, 0x67, 0x8e, 0x75, 0x93, 0xd8, 0xd2, 0x1f, 0x05, 0xb8, 0xd2,
+	0x3c, 0xc1, 0xc6, 0x78, 0xa8, 0x5a, 0x6f, 0x65, 0xbb, 0xd7, 0xa7, 0xb6, 0xbb, 0x18, 0xb7, 0x5d,
+	0x3b, 0x50, 0x25, 0xef, 0xc3, 0x6c, 0x28, 0xd9, 0xd1, 0xc7, 0x00, 0x74, 0xa5, 0xb8, 0x3a, 0x37,
+	0xee, 0xd7, 0x9d, 0xe5, 0x58, 0xea, 0xf1, 0x68, 0x0f, 0xa0, 0xa5, 0xff, 0x27, 0xa1, 0x4c, 0xd9,
+	0xdc, 0x2a, 0xc1, 0x39, 0x3f, 0x85, 0x02, 0x73, 0x65, 0x90, 0xb4, 0xe2, 0x9a, 0xe6, 0x53, 0x06,
+	0xb3, 0x28, 0x38, 0x23, 0x62, 0x54, 0xf2, 0x55, 0x8c, 0x42, 0xbb, 0x50, 0xf2, 0xbf, 0x28, 0x67,
+	0x60, 0xce, 0x79, 0x27, 0x54, 0xee, 0x98, 0xcd, 0x21, 0x9a, 0x79, 0x6f, 0x22, 0xaf, 0x36, 0xb7,
+	0xa0, 0xa2, 0xdb, 0x8a, 0xf3, 0x35, 0x46, 0x87, 0x9c, 0x4b, 0x61, 0x18, 0xde, 0x02, 0x94, 0x75,
+	0xbb, 0x69, 0x6a, 0x9d, 0x43, 0x86, 0x67, 0x94, 0xe8, 0x73, 0xa8, 0x44, 0x2d, 0xe0, 0xa1, 0x55,
+	0xcd, 0x50, 0x43, 0x56, 0xce, 0x35, 0x84, 0xc7, 0x17, 0x33, 0x67, 0x31, 0x62, 0x0e, 0x53, 0x4a,
+	0xbf, 0x81, 0x85, 0xa9, 0x79, 0x6f, 0xab, 0x2e, 0x4a, 0x3a, 0x54, 0xce, 0x31, 0x1a, 0xbd, 0x07,
+	0x45, 0xbe, 0x59, 0x56, 0xd4, 0x05, 0x9a, 0x3b, 0x05, 0x26, 0xa3, 0x55, 0x1d, 0xfd, 0x34, 0x52,
+	0x55, 0x67, 0xbd, 0xc6, 0x2b, 0xa6, 0x9e, 0x76, 0x61, 0x31, 0x92, 0x4d, 0x3f, 0x40, 0xc8, 0xfe,
+	0x4f, 0x00, 0x14, 0x6c, 0x69, 0x79, 0x86, 0x5e, 0xd0, 0x4c, 0xc5, 0x27, 0x70, 0xf2, 0x15, 0x12,
+	0x38, 0x75, 0x61, 0x02, 0x3b, 0x01, 0x75, 0x71, 0x02, 0x3b, 0x9d, 0xd4, 0x50, 0x37, 0x74, 0x52,
+	0xcd, 0x50, 0x46, 0x36, 0x70, 0xa4, 0xea, 0x21, 0xc1, 0x56, 0x35, 0x4b, 0x4d, 0x67, 0x03, 0xe9,
+	0x36, 0x94, 0x43, 0x7b, 0xe5, 0xfe, 0x7b, 0x0f, 0x8a, 0x81, 0xd6, 0xd0, 0x6d, 0xac, 0x0b, 0x7e,
+	0x7f, 0x67, 0x4b, 0x7f, 0x13, 0x60, 0xc1, 0xbf, 0x2d, 0xbc, 0xdd, 0x3a, 0xf6, 0x6a, 0x6e, 0x48,
+	0xc7, 0xba, 0x21, 0x13, 0x74, 0xc3, 0xcf, 0xf8, 0x27, 0xe7, 0x7b, 0xe1, 0x5e, 0xb8, 0xe8, 0x76,
+	0x21, 0xed, 0x42, 0xe9, 0xc0, 0xc6, 0x56, 0x97, 0xa8, 0xc4, 0xf3, 0x40, 0xf4, 0xfe, 0x20, 0x5c,
+	0xee, 0xfe, 0x20, 0xfd, 0x55, 0x80, 0x85, 0x00, 0x19, 0x37, 0xe1, 0x9a, 0x7b, 0xbb, 0xd4, 0x47,
+	0xa6, 0x62, 0x39, 0x17, 0x0b, 0x87, 0x4f, 0x90, 0x67, 0x3d, 0xa9, 0xac, 0x12, 0xec, 0x04, 0xa7,
+	0x39, 0x31, 0xfc, 0x16, 0xde, 0x49, 0xab, 0xbc, 0x39, 0x71, 0x53, 0xff, 0x43, 0x40, 0xea, 0x58,
+	0x57, 0x22, 0x4c, 0x29, 0xca, 0x54, 0x52, 0xc7, 0x7a, 0x2b, 0x44, 0x56, 0x87, 0xb2, 0x35, 0x19,
+	0xe2, 0x28, 0x3c, 0x4d, 0xe1, 0x0b, 0x8e, 0x2a, 0x84, 0x97, 0x3e, 0x87, 0xb2, 0x63, 0x78, 0x6b,
+	0x3b, 0x6c, 0x7a, 0x05, 0x66, 0x26, 0x36, 0xb6, 0x14, 0x5d, 0xe3, 0xd9, 0x92, 0x75, 0x86, 0x2d,
+	0x0d, 0x7d, 0xc4, 0x7b, 0x90, 0x24, 0xfd, 0x8e, 0x5e, 0xc9, 0x9d, 0xda, 0x3c, 0x6f, 0x30, 0xee,
+	0x01, 0x72, 0x54, 0x76, 0x98, 0xfd, 0x3a, 0x64, 0x6c, 0x47, 0x10, 0xed, 0x2c, 0x63, 0x2c, 0x91,
+	0x19, 0x52, 0xfa, 0x8b, 0x00, 0x35, 0xd6, 0xcf, 0xdb, 0x77, 0x47, 0x56, 0x38, 0x6c, 0xde, 0x70,
+	0xf8, 0xde, 0x86, 0xa2, 0x1b, 0x97, 0x8a, 0x8d, 0xc9, 0xcb, 0x8f, 0xe2, 0x82, 0x0b, 0xed, 0x62,
+	0x22, 0xdd, 0x87, 0x95, 0x73, 0x6d, 0x7e, 0xe5, 0xeb, 0x4b, 0x15, 0xae, 0x72, 0xb2, 0x36, 0x26,
+	0xaa, 0xe3, 0x5d, 0xbe, 0x71, 0xa9, 0x03, 0x95, 0x29, 0x0d, 0xa7, 0xbf, 0x05, 0x39, 0x83, 0xcb,
+	0xf8, 0x02, 0xd5, 0xe8, 0x02, 0xde, 0x1c, 0x0f, 0x29, 0xfd, 0x57, 0x80, 0xf9, 0xc8, 0x31, 0xee,
+	0xf8, 0xeb, 0xd0, 0x1a, 0x19, 0x8a, 0xfb, 0x5e, 0xe2, 0x87, 0xc6, 0x9c, 0x23, 0x6f, 0x71, 0x71,
+	0x4b, 0x0b, 0xc6, 0x4e, 0x32, 0x14, 0x3b, 0xfe, 0x21, 0x96, 0x7a, 0xa3, 0xcd, 0xbd, 0x7f, 0x0c,
+	0xa5, 0x2f, 0x3e, 0x86, 0xbe, 0x11, 0x20, 0xc3, 0x76, 0xf8, 0xa6, 0xe2, 0x47, 0x84, 0x1c, 0xe6,
+	0x4d, 0x36, 0x4d, 0xdb, 0x8c, 0xec, 0x8d, 0xdf, 0x40, 0x4b, 0xdf, 0x80, 0xd9, 0x50, 0xa4, 0xbd,
+	0xc6, 0x55, 0x5b, 0x81, 0x62, 0x50, 0x83, 0xae, 0xf1, 0x9b, 0x0a, 0xab, 0x86, 0x0b, 0xee, 0x6c,
+	0xaa, 0xa6, 0xd7, 0x5a, 0x76, 0x3d, 0x41, 0x90, 0xa6, 0xc7, 0x2b, 0xfb, 0xe8, 0xf4, 0xb7, 0xff,
+	0x30, 0x90, 0x62, 0x15, 0x9b, 0x0e, 0xa4, 0xdf, 0x09, 0x30, 0xe7, 0xc7, 0xd7, 0x5d, 0x7d, 0x88,
+	0x7f, 0x88, 0xf0, 0x12, 0x21, 0x77, 0xa8, 0x0f, 0x31, 0xb5, 0x81, 0x2d, 0xe7, 0x8d, 0x1d, 0xdb,
+	0x7c, 0x3f, 0x73, 0x4f, 0x3d, 0x84, 0x4a, 0x7b, 0x32, 0x24, 0x7a, 0x0f, 0x9b, 0xaa, 0x49, 0x3e,
+	0xb3, 0x74, 0x82, 0xfd, 0x73, 0x20, 0x67, 0xb1, 0x9f, 0xae, 0xcf, 0x44, 0xaf, 0xbd, 0x9d, 0x42,
+	0xcb, 0x1e, 0x56, 0x1a, 0x00, 0x8a, 0x61, 0x5b, 0x82, 0x3c, 0xa1, 0x52, 0x7f, 0x53, 0x39, 0x26,
+	0x68, 0x69, 0x68, 0xc3, 0xb9, 0xb5, 0x53, 0x1c, 0xaf, 0xa9, 0x57, 0xfd, 0xac, 0x08, 0xad, 0xe2,
+	0xc2, 0xa4, 0x03, 0xa8, 0x4e, 0xdb, 0xcd, 0xf3, 0xfd, 0x0e, 0xe4, 0xdd, 0xa7, 0x89, 0xa9, 0xea,
+	0x1a, 0x83, 0x97, 0x7d, 0xb4, 0xb4, 0x0b, 0xe5, 0x38, 0xc6, 0x65, 0x00, 0x6c, 0x59, 0x23, 0x4b,
+	0x19, 0x8c, 0x34, 0x16, 0x02, 0x19, 0x39, 0x4f, 0x25, 0x5b, 0x23, 0x8d, 0x7e, 0x60, 0x3a, 0xe0,
+	0xdf, 0x82, 0x0d, 0x3e, 0x58, 0x83, 0x42, 0xe0, 0xac, 0x74, 0x2e, 0x91, 0xad, 0x3d, 0xa5, 0xdd,
+	0x6c, 0x77, 0xe4, 0x5f, 0x95, 0x12, 0x08, 0x20, 0xdb, 0xd8, 0xea, 0xb5, 0x1e, 0x35, 0x4b, 0xc2,
+	0x07, 0xbb, 0x90, 0xf7, 0xe2, 0x08, 0xe5, 0x21, 0xd3, 0x7c, 0x78, 0xd0, 0x78, 0x50, 0x4a, 0x38,
+	0x53, 0xf6, 0x3a, 0x3d, 0x85, 0x0d, 0x05, 0x34, 0x0f, 0x05, 0xb9, 0x79, 0xaf, 0xf9, 0x58, 0x69,
+	0x37, 0x7a, 0x5b, 0x3b, 0xa5, 0x24, 0x42, 0x30, 0xc7, 0x04, 0x7b, 0x1d, 0x2e, 0x4b, 0xdd, 0xf8,
+	0x47, 0x1e, 0x72, 0x6e, 0xa0, 0xa0, 0x3b, 0x90, 0xde, 0x9f, 0xd8, 0xc7, 0xe8, 0x1c, 0x77, 0x8a,
+	0x95, 0x29, 0x39, 0xdb, 0xb0, 0x94, 0x40, 0xdb, 0x50, 0x08, 0x34, 0xc1, 0x28, 0xf6, 0x59, 0x48,
+	0x5c, 0x8a, 0x69, 0xf2, 0x7d, 0x8e, 0x0d, 0x01, 0x75, 0x60, 0x8e, 0xaa, 0xdc, 0x26, 0xd7, 0x46,
+	0xde, 0x1d, 0x3f, 0xee, 0x16, 0x29, 0x2e, 0x9f, 0xa3, 0xf5, 0xcc, 0xda, 0x09, 0xbf, 0xcb, 0x8a,
+	0x71, 0x4f, 0xb8, 0x51, 0xe3, 0x62, 0xfa, 0x43, 0x29, 0x81, 0x9a, 0x00, 0x7e, 0xc7, 0x84, 0xde,
+	0x09, 0x81, 0x83, 0x1d, 0xa1, 0x28, 0xc6, 0xa9, 0x3c, 0x9a, 0x4d, 0xc8, 0x7b, 0xe7, 0x3e, 0xaa,
+	0xc6, 0xb4, 0x02, 0x8c, 0xe4, 0xfc, 0x26, 0x41, 0x4a, 0xa0, 0xbb, 0x50, 0x6c, 0x0c, 0x87, 0x97,
+	0xa1, 0x11, 0x83, 0x1a, 0x3b, 0xca, 0x33, 0xf4, 0xce, 0xc0, 0xe8, 0x51, 0x8b, 0xde, 0xf7, 0x0a,
+	0xd6, 0x4b, 0xfb, 0x07, 0xf1, 0xc7, 0x17, 0xe2, 0xbc, 0xd5, 0x7a, 0x30, 0x1f, 0x39, 0x71, 0x51,
+	0x2d, 0x32, 0x3b, 0x72, 0x48, 0x8b, 0x2b, 0xe7, 0xea, 0x3d, 0xd6, 0x3e, 0xef, 0xe7, 0xc3, 0x4f,
+	0xf8, 0x48, 0x9a, 0xfe, 0x08, 0xd1, 0xff, 0x17, 0x88, 0x3f, 0x7a, 0x29, 0x26, 0x10, 0x95, 0x4f,
+	0xe0, 0x6a, 0xfc, 0x3b, 0x36, 0xba, 0x16, 0x13, 0x33, 0xd3, 0xaf, 0xf6, 0xe2, 0xfb, 0x17, 0xc1,
+	0x02, 0x8b, 0x3d, 0x82, 0x79, 0x27, 0x07, 0x03, 0xd5, 0x0a, 0xf9, 0x6e, 0x88, 0x2f, 0xbd, 0xe2,
+	0xea, 0xf9, 0x00, 0xcf, 0x51, 0xfb, 0xfc, 0xf6, 0xc2, 0x56, 0xe7, 0x69, 0xfa, 0xfa, 0xf9, 0xb0,
+	0x21, 0xa0, 0x36, 0x94, 0x7c, 0xcf, 0x71, 0xc2, 0xd7, 0xcd, 0x0b, 0x4a, 0x57, 0x0c, 0x3e, 0x56,
+	0x23, 0x6f, 0xfd, 0x98, 0xb7, 0x70, 0xf1, 0xdd, 0x78, 0xa5, 0x4f, 0xb7, 0xf9, 0xc9, 0xd3, 0xe7,
+	0xb5, 0xc4, 0xb7, 0xcf, 0x6b, 0x89, 0xef, 0x9e, 0xd7, 0x84, 0xdf, 0x9e, 0xd5, 0x84, 0x3f, 0x9d,
+	0xd5, 0x84, 0xaf, 0xcf, 0x6a, 0xc2, 0xd3, 0xb3, 0x9a, 0xf0, 0xaf, 0xb3, 0x9a, 0xf0, 0x9f, 0xb3,
+	0x5a, 0xe2, 0xbb, 0xb3, 0x9a, 0xf0, 0xfb, 0x17, 0xb5, 0xc4, 0xd3, 0x17, 0xb5, 0xc4, 0xb7, 0x2f,
+	0x6a, 0x89, 0x5f, 0x67, 0x07, 0x43, 0x1d, 0x9b, 0xa4, 0x9f, 0xa5, 0xff, 0x70, 0xba, 0xf9, 0x7d,
+	0x00, 0x00, 0x00, 0xff, 0xff, 0xf0, 0x95, 0x63, 0x4f, 0xeb, 0x1a, 0x00, 0x00,
 }
 
 func (x CountMethod) String() string {
@@ -2265,6 +2606,9 @@ func (this *LabelValuesCardinalityRequest) Equal(that interface{}) bool {
 	if this.CountMethod != that1.CountMethod {
 		return false
 	}
+	if this.Approximate != that1.Approximate {
+		return false
+	}
 	return true
 }
 func (this *LabelValuesCardinalityResponse) Equal(that interface{}) bool {
@@ -2328,7 +2672,65 @@ func (this *LabelValueSeriesCount) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *ReadRequest) Equal(that interface{}) bool {
+func (this *ActiveSeriesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ActiveSeriesRequest)
+	if !ok {
+		that2, ok := that.(ActiveSeriesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ActiveSeriesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ActiveSeriesResponse)
+	if !ok {
+		that2, ok := that.(ActiveSeriesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Metric) != len(that1.Metric) {
+		return false
+	}
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ReadRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
@@ -2775,6 +3177,12 @@ func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	if !this.Matchers.Equal(that1.Matchers) {
 		return false
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.After != that1.After {
+		return false
+	}
 	return true
 }
 func (this *LabelValuesResponse) Equal(that interface{}) bool {
@@ -2834,6 +3242,12 @@ func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	if !this.Matchers.Equal(that1.Matchers) {
 		return false
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.After != that1.After {
+		return false
+	}
 	return true
 }
 func (this *LabelNamesResponse) Equal(that interface{}) bool {
@@ -3260,6 +3674,118 @@ func (this *TimeSeriesFile) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *MultiTenantWriteRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MultiTenantWriteRequest)
+	if !ok {
+		that2, ok := that.(MultiTenantWriteRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Requests) != len(that1.Requests) {
+		return false
+	}
+	for i := range this.Requests {
+		if !this.Requests[i].Equal(that1.Requests[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TenantWriteRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TenantWriteRequest)
+	if !ok {
+		that2, ok := that.(TenantWriteRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TenantId != that1.TenantId {
+		return false
+	}
+	if !this.Request.Equal(that1.Request) {
+		return false
+	}
+	return true
+}
+func (this *MultiTenantWriteResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MultiTenantWriteResponse)
+	if !ok {
+		that2, ok := that.(MultiTenantWriteResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Responses) != len(that1.Responses) {
+		return false
+	}
+	for i := range this.Responses {
+		if !this.Responses[i].Equal(that1.Responses[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TenantWriteResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TenantWriteResponse)
+	if !ok {
+		that2, ok := that.(TenantWriteResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.ErrorCode != that1.ErrorCode {
+		return false
+	}
+	if this.Error != that1.Error {
+		return false
+	}
+	return true
+}
 func (this *LabelNamesAndValuesRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3299,13 +3825,14 @@ func (this *LabelValuesCardinalityRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&client.LabelValuesCardinalityRequest{")
 	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
 	s = append(s, "CountMethod: "+fmt.Sprintf("%#v", this.CountMethod)+",\n")
+	s = append(s, "Approximate: "+fmt.Sprintf("%#v", this.Approximate)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3344,6 +3871,30 @@ func (this *LabelValueSeriesCount) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *ActiveSeriesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ActiveSeriesRequest{")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ActiveSeriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ActiveSeriesResponse{")
+	if this.Metric != nil {
+		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *ReadRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3542,7 +4093,7 @@ func (this *LabelValuesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 10)
 	s = append(s, "&client.LabelValuesRequest{")
 	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
@@ -3550,6 +4101,8 @@ func (this *LabelValuesRequest) GoString() string {
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3567,13 +4120,15 @@ func (this *LabelNamesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 9)
 	s = append(s, "&client.LabelNamesRequest{")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
 	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3751,6 +4306,54 @@ func (this *TimeSeriesFile) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *MultiTenantWriteRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.MultiTenantWriteRequest{")
+	if this.Requests != nil {
+		s = append(s, "Requests: "+fmt.Sprintf("%#v", this.Requests)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TenantWriteRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.TenantWriteRequest{")
+	s = append(s, "TenantId: "+fmt.Sprintf("%#v", this.TenantId)+",\n")
+	if this.Request != nil {
+		s = append(s, "Request: "+fmt.Sprintf("%#v", this.Request)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *MultiTenantWriteResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.MultiTenantWriteResponse{")
+	if this.Responses != nil {
+		s = append(s, "Responses: "+fmt.Sprintf("%#v", this.Responses)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TenantWriteResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.TenantWriteResponse{")
+	s = append(s, "ErrorCode: "+fmt.Sprintf("%#v", this.ErrorCode)+",\n")
+	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringIngester(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -3788,6 +4391,16 @@ type IngesterClient interface {
 	// that match the matchers.
 	// The listing order of the labels is not guaranteed.
 	LabelValuesCardinality(ctx context.Context, in *LabelValuesCardinalityRequest, opts ...grpc.CallOption) (Ingester_LabelValuesCardinalityClient, error)
+	// PushMultiTenant pushes the write requests of multiple tenants in a single call. The tenant of
+	// each write request is carried in the request itself, and must be one of the tenants of the call.
+	PushMultiTenant(ctx context.Context, in *MultiTenantWriteRequest, opts ...grpc.CallOption) (*MultiTenantWriteResponse, error)
+	// LabelValuesStream streams the sorted values of the label, in batches, like LabelValues.
+	LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error)
+	// LabelNamesStream streams the sorted label names, in batches, like LabelNames.
+	LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error)
+	// ActiveSeries streams the labels of the active series matching the matchers, in batches.
+	// The order of the series is not guaranteed.
+	ActiveSeries(ctx context.Context, in *ActiveSeriesRequest, opts ...grpc.CallOption) (Ingester_ActiveSeriesClient, error)
 }
 
 type ingesterClient struct {
@@ -3966,40 +4579,155 @@ func (x *ingesterLabelValuesCardinalityClient) Recv() (*LabelValuesCardinalityRe
 	return m, nil
 }
 
-// IngesterServer is the server API for Ingester service.
-type IngesterServer interface {
-	Push(context.Context, *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
-	QueryStream(*QueryRequest, Ingester_QueryStreamServer) error
-	QueryExemplars(context.Context, *ExemplarQueryRequest) (*ExemplarQueryResponse, error)
-	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
-	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
-	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
-	AllUserStats(context.Context, *UserStatsRequest) (*UsersStatsResponse, error)
-	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
-	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
-	// LabelNamesAndValues provides all values for each label that matches the matchers.
-	// The order of the labels and values is not guaranteed.
-	LabelNamesAndValues(*LabelNamesAndValuesRequest, Ingester_LabelNamesAndValuesServer) error
-	// LabelValuesCardinality returns all values and series total count for label_names labels
-	// that match the matchers.
-	// The listing order of the labels is not guaranteed.
-	LabelValuesCardinality(*LabelValuesCardinalityRequest, Ingester_LabelValuesCardinalityServer) error
+func (c *ingesterClient) PushMultiTenant(ctx context.Context, in *MultiTenantWriteRequest, opts ...grpc.CallOption) (*MultiTenantWriteResponse, error) {
+	out := new(MultiTenantWriteResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/PushMultiTenant", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// UnimplementedIngesterServer can be embedded to have forward compatible implementations.
-type UnimplementedIngesterServer struct {
+func (c *ingesterClient) LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/LabelValuesStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterLabelValuesStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (*UnimplementedIngesterServer) Push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
+type Ingester_LabelValuesStreamClient interface {
+	Recv() (*LabelValuesResponse, error)
+	grpc.ClientStream
 }
-func (*UnimplementedIngesterServer) QueryStream(req *QueryRequest, srv Ingester_QueryStreamServer) error {
-	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
+
+type ingesterLabelValuesStreamClient struct {
+	grpc.ClientStream
 }
-func (*UnimplementedIngesterServer) QueryExemplars(ctx context.Context, req *ExemplarQueryRequest) (*ExemplarQueryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method QueryExemplars not implemented")
+
+func (x *ingesterLabelValuesStreamClient) Recv() (*LabelValuesResponse, error) {
+	m := new(LabelValuesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
-func (*UnimplementedIngesterServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
+
+func (c *ingesterClient) LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[4], "/cortex.Ingester/LabelNamesStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterLabelNamesStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_LabelNamesStreamClient interface {
+	Recv() (*LabelNamesResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterLabelNamesStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterLabelNamesStreamClient) Recv() (*LabelNamesResponse, error) {
+	m := new(LabelNamesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ingesterClient) ActiveSeries(ctx context.Context, in *ActiveSeriesRequest, opts ...grpc.CallOption) (Ingester_ActiveSeriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[5], "/cortex.Ingester/ActiveSeries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterActiveSeriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_ActiveSeriesClient interface {
+	Recv() (*ActiveSeriesResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterActiveSeriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterActiveSeriesClient) Recv() (*ActiveSeriesResponse, error) {
+	m := new(ActiveSeriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IngesterServer is the server API for Ingester service.
+type IngesterServer interface {
+	Push(context.Context, *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
+	QueryStream(*QueryRequest, Ingester_QueryStreamServer) error
+	QueryExemplars(context.Context, *ExemplarQueryRequest) (*ExemplarQueryResponse, error)
+	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
+	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
+	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
+	AllUserStats(context.Context, *UserStatsRequest) (*UsersStatsResponse, error)
+	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
+	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
+	// LabelNamesAndValues provides all values for each label that matches the matchers.
+	// The order of the labels and values is not guaranteed.
+	LabelNamesAndValues(*LabelNamesAndValuesRequest, Ingester_LabelNamesAndValuesServer) error
+	// LabelValuesCardinality returns all values and series total count for label_names labels
+	// that match the matchers.
+	// The listing order of the labels is not guaranteed.
+	LabelValuesCardinality(*LabelValuesCardinalityRequest, Ingester_LabelValuesCardinalityServer) error
+	// PushMultiTenant pushes the write requests of multiple tenants in a single call. The tenant of
+	// each write request is carried in the request itself, and must be one of the tenants of the call.
+	PushMultiTenant(context.Context, *MultiTenantWriteRequest) (*MultiTenantWriteResponse, error)
+	// LabelValuesStream streams the sorted values of the label, in batches, like LabelValues.
+	LabelValuesStream(*LabelValuesRequest, Ingester_LabelValuesStreamServer) error
+	// LabelNamesStream streams the sorted label names, in batches, like LabelNames.
+	LabelNamesStream(*LabelNamesRequest, Ingester_LabelNamesStreamServer) error
+	// ActiveSeries streams the labels of the active series matching the matchers, in batches.
+	// The order of the series is not guaranteed.
+	ActiveSeries(*ActiveSeriesRequest, Ingester_ActiveSeriesServer) error
+}
+
+// UnimplementedIngesterServer can be embedded to have forward compatible implementations.
+type UnimplementedIngesterServer struct {
+}
+
+func (*UnimplementedIngesterServer) Push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
+}
+func (*UnimplementedIngesterServer) QueryStream(req *QueryRequest, srv Ingester_QueryStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
+}
+func (*UnimplementedIngesterServer) QueryExemplars(ctx context.Context, req *ExemplarQueryRequest) (*ExemplarQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryExemplars not implemented")
+}
+func (*UnimplementedIngesterServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
 }
 func (*UnimplementedIngesterServer) LabelNames(ctx context.Context, req *LabelNamesRequest) (*LabelNamesResponse, error) {
@@ -4023,6 +4751,18 @@ func (*UnimplementedIngesterServer) LabelNamesAndValues(req *LabelNamesAndValues
 func (*UnimplementedIngesterServer) LabelValuesCardinality(req *LabelValuesCardinalityRequest, srv Ingester_LabelValuesCardinalityServer) error {
 	return status.Errorf(codes.Unimplemented, "method LabelValuesCardinality not implemented")
 }
+func (*UnimplementedIngesterServer) PushMultiTenant(ctx context.Context, req *MultiTenantWriteRequest) (*MultiTenantWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushMultiTenant not implemented")
+}
+func (*UnimplementedIngesterServer) LabelValuesStream(req *LabelValuesRequest, srv Ingester_LabelValuesStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method LabelValuesStream not implemented")
+}
+func (*UnimplementedIngesterServer) LabelNamesStream(req *LabelNamesRequest, srv Ingester_LabelNamesStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method LabelNamesStream not implemented")
+}
+func (*UnimplementedIngesterServer) ActiveSeries(req *ActiveSeriesRequest, srv Ingester_ActiveSeriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ActiveSeries not implemented")
+}
 
 func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
 	s.RegisterService(&_Ingester_serviceDesc, srv)
@@ -4235,6 +4975,87 @@ func (x *ingesterLabelValuesCardinalityServer) Send(m *LabelValuesCardinalityRes
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Ingester_PushMultiTenant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiTenantWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngesterServer).PushMultiTenant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/PushMultiTenant",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).PushMultiTenant(ctx, req.(*MultiTenantWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ingester_LabelValuesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LabelValuesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).LabelValuesStream(m, &ingesterLabelValuesStreamServer{stream})
+}
+
+type Ingester_LabelValuesStreamServer interface {
+	Send(*LabelValuesResponse) error
+	grpc.ServerStream
+}
+
+type ingesterLabelValuesStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterLabelValuesStreamServer) Send(m *LabelValuesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_LabelNamesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LabelNamesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).LabelNamesStream(m, &ingesterLabelNamesStreamServer{stream})
+}
+
+type Ingester_LabelNamesStreamServer interface {
+	Send(*LabelNamesResponse) error
+	grpc.ServerStream
+}
+
+type ingesterLabelNamesStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterLabelNamesStreamServer) Send(m *LabelNamesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_ActiveSeries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ActiveSeriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).ActiveSeries(m, &ingesterActiveSeriesServer{stream})
+}
+
+type Ingester_ActiveSeriesServer interface {
+	Send(*ActiveSeriesResponse) error
+	grpc.ServerStream
+}
+
+type ingesterActiveSeriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterActiveSeriesServer) Send(m *ActiveSeriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Ingester_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "cortex.Ingester",
 	HandlerType: (*IngesterServer)(nil),
@@ -4271,6 +5092,10 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			MethodName: "MetricsMetadata",
 			Handler:    _Ingester_MetricsMetadata_Handler,
 		},
+		{
+			MethodName: "PushMultiTenant",
+			Handler:    _Ingester_PushMultiTenant_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -4288,6 +5113,21 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Ingester_LabelValuesCardinality_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "LabelValuesStream",
+			Handler:       _Ingester_LabelValuesStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "LabelNamesStream",
+			Handler:       _Ingester_LabelNamesStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ActiveSeries",
+			Handler:       _Ingester_ActiveSeries_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "ingester.proto",
 }
@@ -4425,6 +5265,16 @@ func (m *LabelValuesCardinalityRequest) MarshalToSizedBuffer(dAtA []byte) (int,
 	_ = i
 	var l int
 	_ = l
+	if m.Approximate {
+		i--
+		if m.Approximate {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.CountMethod != 0 {
 		i = encodeVarintIngester(dAtA, i, uint64(m.CountMethod))
 		i--
@@ -4540,6 +5390,80 @@ func (m *LabelValueSeriesCount) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *ActiveSeriesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ActiveSeriesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ActiveSeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ActiveSeriesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ActiveSeriesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ActiveSeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *ReadRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -5136,6 +6060,18 @@ func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x28
+	}
 	if m.Matchers != nil {
 		{
 			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
@@ -5220,6 +6156,18 @@ func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Matchers != nil {
 		{
 			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
@@ -5817,20 +6765,171 @@ func (m *TimeSeriesFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
-	offset -= sovIngester(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *MultiTenantWriteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *LabelNamesAndValuesRequest) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *MultiTenantWriteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiTenantWriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for iNdEx := len(m.Requests) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Requests[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TenantWriteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TenantWriteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TenantWriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Request != nil {
+		{
+			size, err := m.Request.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.TenantId) > 0 {
+		i -= len(m.TenantId)
+		copy(dAtA[i:], m.TenantId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.TenantId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MultiTenantWriteResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MultiTenantWriteResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiTenantWriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Responses) > 0 {
+		for iNdEx := len(m.Responses) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Responses[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TenantWriteResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TenantWriteResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TenantWriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ErrorCode != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ErrorCode))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
+	offset -= sovIngester(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *LabelNamesAndValuesRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
 	var l int
 	_ = l
@@ -5898,6 +6997,9 @@ func (m *LabelValuesCardinalityRequest) Size() (n int) {
 	if m.CountMethod != 0 {
 		n += 1 + sovIngester(uint64(m.CountMethod))
 	}
+	if m.Approximate {
+		n += 2
+	}
 	return n
 }
 
@@ -5937,6 +7039,36 @@ func (m *LabelValueSeriesCount) Size() (n int) {
 	return n
 }
 
+func (m *ActiveSeriesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ActiveSeriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *ReadRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -6197,6 +7329,13 @@ func (m *LabelValuesRequest) Size() (n int) {
 		l = m.Matchers.Size()
 		n += 1 + l + sovIngester(uint64(l))
 	}
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
 	return n
 }
 
@@ -6231,6 +7370,13 @@ func (m *LabelNamesRequest) Size() (n int) {
 		l = m.Matchers.Size()
 		n += 1 + l + sovIngester(uint64(l))
 	}
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
 	return n
 }
 
@@ -6483,6 +7629,69 @@ func (m *TimeSeriesFile) Size() (n int) {
 	return n
 }
 
+func (m *MultiTenantWriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for _, e := range m.Requests {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TenantWriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.TenantId)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.Request != nil {
+		l = m.Request.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *MultiTenantWriteResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Responses) > 0 {
+		for _, e := range m.Responses {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TenantWriteResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ErrorCode != 0 {
+		n += 1 + sovIngester(uint64(m.ErrorCode))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
 func sovIngester(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -6543,6 +7752,7 @@ func (this *LabelValuesCardinalityRequest) String() string {
 		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
 		`Matchers:` + repeatedStringForMatchers + `,`,
 		`CountMethod:` + fmt.Sprintf("%v", this.CountMethod) + `,`,
+		`Approximate:` + fmt.Sprintf("%v", this.Approximate) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6583,6 +7793,36 @@ func (this *LabelValueSeriesCount) String() string {
 	}, "")
 	return s
 }
+func (this *ActiveSeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ActiveSeriesRequest{`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ActiveSeriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetric := "[]*Metric{"
+	for _, f := range this.Metric {
+		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "mimirpb.Metric", 1) + ","
+	}
+	repeatedStringForMetric += "}"
+	s := strings.Join([]string{`&ActiveSeriesResponse{`,
+		`Metric:` + repeatedStringForMetric + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *ReadRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -6793,6 +8033,8 @@ func (this *LabelValuesRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6815,6 +8057,8 @@ func (this *LabelNamesRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -7005,20 +8249,72 @@ func (this *TimeSeriesFile) String() string {
 	}, "")
 	return s
 }
-func valueToStringIngester(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *MultiTenantWriteRequest) String() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	repeatedStringForRequests := "[]*TenantWriteRequest{"
+	for _, f := range this.Requests {
+		repeatedStringForRequests += strings.Replace(f.String(), "TenantWriteRequest", "TenantWriteRequest", 1) + ","
+	}
+	repeatedStringForRequests += "}"
+	s := strings.Join([]string{`&MultiTenantWriteRequest{`,
+		`Requests:` + repeatedStringForRequests + `,`,
+		`}`,
+	}, "")
+	return s
 }
-func (m *LabelNamesAndValuesRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
+func (this *TenantWriteRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TenantWriteRequest{`,
+		`TenantId:` + fmt.Sprintf("%v", this.TenantId) + `,`,
+		`Request:` + strings.Replace(fmt.Sprintf("%v", this.Request), "WriteRequest", "mimirpb.WriteRequest", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MultiTenantWriteResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForResponses := "[]*TenantWriteResponse{"
+	for _, f := range this.Responses {
+		repeatedStringForResponses += strings.Replace(f.String(), "TenantWriteResponse", "TenantWriteResponse", 1) + ","
+	}
+	repeatedStringForResponses += "}"
+	s := strings.Join([]string{`&MultiTenantWriteResponse{`,
+		`Responses:` + repeatedStringForResponses + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TenantWriteResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TenantWriteResponse{`,
+		`ErrorCode:` + fmt.Sprintf("%v", this.ErrorCode) + `,`,
+		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringIngester(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *LabelNamesAndValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
 		for shift := uint(0); ; shift += 7 {
 			if shift >= 64 {
 				return ErrIntOverflowIngester
@@ -7418,6 +8714,26 @@ func (m *LabelValuesCardinalityRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Approximate", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Approximate = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -7727,6 +9043,180 @@ func (m *LabelValueSeriesCount) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ActiveSeriesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActiveSeriesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActiveSeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ActiveSeriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActiveSeriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActiveSeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = append(m.Metric, &mimirpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *ReadRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -9320,28 +10810,79 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
 			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthIngester
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.After = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
 	return nil
 }
 func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
@@ -9532,6 +11073,57 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.After = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -11112,6 +12704,405 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *MultiTenantWriteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MultiTenantWriteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MultiTenantWriteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requests", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Requests = append(m.Requests, &TenantWriteRequest{})
+			if err := m.Requests[len(m.Requests)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TenantWriteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TenantWriteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TenantWriteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TenantId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TenantId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Request", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Request == nil {
+				m.Request = &mimirpb.WriteRequest{}
+			}
+			if err := m.Request.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MultiTenantWriteResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MultiTenantWriteResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MultiTenantWriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Responses", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Responses = append(m.Responses, &TenantWriteResponse{})
+			if err := m.Responses[len(m.Responses)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TenantWriteResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TenantWriteResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TenantWriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrorCode", wireType)
+			}
+			m.ErrorCode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ErrorCode |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipIngester(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
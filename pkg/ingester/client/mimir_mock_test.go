@@ -22,6 +22,11 @@ func (m *IngesterServerMock) Push(ctx context.Context, r *mimirpb.WriteRequest)
 	return args.Get(0).(*mimirpb.WriteResponse), args.Error(1)
 }
 
+func (m *IngesterServerMock) PushMultiTenant(ctx context.Context, r *MultiTenantWriteRequest) (*MultiTenantWriteResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*MultiTenantWriteResponse), args.Error(1)
+}
+
 func (m *IngesterServerMock) Query(ctx context.Context, r *QueryRequest) (*QueryResponse, error) {
 	args := m.Called(ctx, r)
 	return args.Get(0).(*QueryResponse), args.Error(1)
@@ -76,3 +81,18 @@ func (m *IngesterServerMock) LabelValuesCardinality(req *LabelValuesCardinalityR
 	args := m.Called(req, srv)
 	return args.Error(0)
 }
+
+func (m *IngesterServerMock) LabelValuesStream(req *LabelValuesRequest, srv Ingester_LabelValuesStreamServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
+
+func (m *IngesterServerMock) LabelNamesStream(req *LabelNamesRequest, srv Ingester_LabelNamesStreamServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
+
+func (m *IngesterServerMock) ActiveSeries(req *ActiveSeriesRequest, srv Ingester_ActiveSeriesServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+// NewTenantWriteResponse returns the TenantWriteResponse carrying the outcome of a tenant write request.
+func NewTenantWriteResponse(err error) *TenantWriteResponse {
+	if err == nil {
+		return &TenantWriteResponse{}
+	}
+
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return &TenantWriteResponse{ErrorCode: resp.Code, Error: string(resp.Body)}
+	}
+	return &TenantWriteResponse{ErrorCode: http.StatusInternalServerError, Error: err.Error()}
+}
+
+// Err returns the error of the tenant write request, or nil if it succeeded. The error
+// is an HTTP gRPC error, like the ones returned by the ingester Push.
+func (r *TenantWriteResponse) Err() error {
+	if r.GetErrorCode() == 0 {
+		return nil
+	}
+	return httpgrpc.Errorf(int(r.GetErrorCode()), "%s", r.GetError())
+}
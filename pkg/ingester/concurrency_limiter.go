@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+var (
+	errTooManyConcurrentWriteRequests = httpgrpc.Errorf(http.StatusServiceUnavailable,
+		"the write request has been rejected because the ingester exceeded the allowed number of concurrent write requests, try again later")
+)
+
+// requestClass is the class of the gRPC methods exposed by the ingester, whose concurrency is limited separately.
+type requestClass int
+
+const (
+	writeRequestClass requestClass = iota
+	readRequestClass
+
+	numRequestClasses
+)
+
+func (c requestClass) String() string {
+	switch c {
+	case writeRequestClass:
+		return "write"
+	case readRequestClass:
+		return "read"
+	default:
+		return "unknown"
+	}
+}
+
+// ConcurrencyLimitsConfig configures the limits on the number of gRPC requests concurrently processed by the ingester.
+type ConcurrencyLimitsConfig struct {
+	MaxConcurrentWriteRequests int           `yaml:"max_concurrent_write_requests" category:"experimental"`
+	MaxConcurrentReadRequests  int           `yaml:"max_concurrent_read_requests" category:"experimental"`
+	MaxQueueWait               time.Duration `yaml:"max_queue_wait" category:"experimental"`
+}
+
+func (cfg *ConcurrencyLimitsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.MaxConcurrentWriteRequests, "ingester.concurrency-limits.max-concurrent-write-requests", 0, "Maximum number of write requests (push) concurrently processed by this ingester. Read requests are not started while write requests are waiting for their turn. 0 = unlimited.")
+	f.IntVar(&cfg.MaxConcurrentReadRequests, "ingester.concurrency-limits.max-concurrent-read-requests", 0, "Maximum number of read requests (queries, labels, series and cardinality) concurrently processed by this ingester. 0 = unlimited.")
+	f.DurationVar(&cfg.MaxQueueWait, "ingester.concurrency-limits.max-queue-wait", time.Second, "Maximum time a request waits for its turn when the concurrency limit of its class has been reached. Requests waiting longer are rejected. 0 to reject the requests immediately.")
+}
+
+func (cfg *ConcurrencyLimitsConfig) Validate() error {
+	if cfg.MaxConcurrentWriteRequests < 0 {
+		return fmt.Errorf("the max concurrent write requests must not be negative")
+	}
+	if cfg.MaxConcurrentReadRequests < 0 {
+		return fmt.Errorf("the max concurrent read requests must not be negative")
+	}
+	if cfg.MaxQueueWait < 0 {
+		return fmt.Errorf("the max queue wait must not be negative")
+	}
+	return nil
+}
+
+func (cfg *ConcurrencyLimitsConfig) enabled() bool {
+	return cfg.MaxConcurrentWriteRequests > 0 || cfg.MaxConcurrentReadRequests > 0
+}
+
+// concurrencyLimiter limits the number of requests concurrently processed for each request class.
+// Write requests have priority over read requests: a read request is not started while any write
+// request is waiting for its turn, so that a storm of read requests can't delay write requests
+// by more than the max queue wait.
+type concurrencyLimiter struct {
+	maxQueueWait time.Duration
+
+	mtx     sync.Mutex
+	classes [numRequestClasses]requestClassState
+
+	inflightRequests *prometheus.GaugeVec
+	queuedRequests   *prometheus.GaugeVec
+	queueWait        *prometheus.HistogramVec
+	rejectedRequests *prometheus.CounterVec
+}
+
+type requestClassState struct {
+	limit    int
+	inflight int
+	// waiters are served in FIFO order.
+	waiters []*concurrencyWaiter
+}
+
+type concurrencyWaiter struct {
+	// admitted is set, while holding the limiter lock, once the waiter has been given a slot.
+	admitted bool
+	ready    chan struct{}
+}
+
+// newConcurrencyLimiter returns a concurrencyLimiter, or nil if the concurrency limits are disabled.
+// The methods of a nil concurrencyLimiter never limit requests.
+func newConcurrencyLimiter(cfg ConcurrencyLimitsConfig, reg prometheus.Registerer) *concurrencyLimiter {
+	if !cfg.enabled() {
+		return nil
+	}
+
+	l := &concurrencyLimiter{
+		maxQueueWait: cfg.MaxQueueWait,
+		inflightRequests: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_concurrency_limited_inflight_requests",
+			Help: "Number of requests currently processed by the ingester, per request class.",
+		}, []string{"class"}),
+		queuedRequests: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_concurrency_limited_queued_requests",
+			Help: "Number of requests waiting for their turn because the concurrency limit of their class has been reached.",
+		}, []string{"class"}),
+		queueWait: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_ingester_concurrency_limited_queue_wait_seconds",
+			Help:    "Time requests waited for their turn, because the concurrency limit of their class had been reached, before being processed or rejected.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"class"}),
+		rejectedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_concurrency_limited_rejected_requests_total",
+			Help: "Total number of requests rejected because the concurrency limit of their class had been reached for longer than the max queue wait.",
+		}, []string{"class"}),
+	}
+	l.classes[writeRequestClass].limit = cfg.MaxConcurrentWriteRequests
+	l.classes[readRequestClass].limit = cfg.MaxConcurrentReadRequests
+
+	limits := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_ingester_concurrency_limit",
+		Help: "Maximum number of requests concurrently processed by the ingester, per request class. 0 = unlimited.",
+	}, []string{"class"})
+	for c := requestClass(0); c < numRequestClasses; c++ {
+		limits.WithLabelValues(c.String()).Set(float64(l.classes[c].limit))
+
+		// Initialise the metrics, so that they're exported even before the first request.
+		l.inflightRequests.WithLabelValues(c.String())
+		l.queuedRequests.WithLabelValues(c.String())
+		l.rejectedRequests.WithLabelValues(c.String())
+	}
+
+	return l
+}
+
+// acquire waits until a request of the input class can be processed. On success, the returned
+// function must be called once the request has been processed.
+func (l *concurrencyLimiter) acquire(ctx context.Context, class requestClass) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release := func() { l.release(class) }
+
+	l.mtx.Lock()
+	s := &l.classes[class]
+	if len(s.waiters) == 0 && l.hasSlotLocked(class) {
+		s.inflight++
+		l.updateMetricsLocked(class)
+		l.mtx.Unlock()
+		return release, nil
+	}
+
+	if l.maxQueueWait <= 0 {
+		l.mtx.Unlock()
+		l.rejectedRequests.WithLabelValues(class.String()).Inc()
+		return nil, l.rejectionError(class)
+	}
+
+	w := &concurrencyWaiter{ready: make(chan struct{})}
+	s.waiters = append(s.waiters, w)
+	l.updateMetricsLocked(class)
+	l.mtx.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(l.maxQueueWait)
+	defer timer.Stop()
+
+	var err error
+	timedOut := false
+	select {
+	case <-w.ready:
+	case <-timer.C:
+		err = l.rejectionError(class)
+		timedOut = true
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	l.queueWait.WithLabelValues(class.String()).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		return release, nil
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	// The waiter may have been admitted meanwhile.
+	if w.admitted {
+		return release, nil
+	}
+
+	for idx, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:idx], s.waiters[idx+1:]...)
+			break
+		}
+	}
+
+	// Read requests may have been waiting for this write request to leave the queue.
+	l.dispatchLocked()
+	l.updateMetricsLocked(class)
+
+	if timedOut {
+		l.rejectedRequests.WithLabelValues(class.String()).Inc()
+	}
+	return nil, err
+}
+
+func (l *concurrencyLimiter) release(class requestClass) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.classes[class].inflight--
+	l.dispatchLocked()
+	l.updateMetricsLocked(class)
+}
+
+// hasSlotLocked returns whether a request of the input class can be started. Must be called with the lock held.
+func (l *concurrencyLimiter) hasSlotLocked(class requestClass) bool {
+	s := &l.classes[class]
+	if s.limit > 0 && s.inflight >= s.limit {
+		return false
+	}
+
+	// Read requests yield to the write requests waiting for their turn.
+	return class != readRequestClass || len(l.classes[writeRequestClass].waiters) == 0
+}
+
+// dispatchLocked admits the waiting requests, as long as there are available slots, starting with
+// the write requests. Must be called with the lock held.
+func (l *concurrencyLimiter) dispatchLocked() {
+	for _, class := range []requestClass{writeRequestClass, readRequestClass} {
+		s := &l.classes[class]
+		admitted := false
+
+		for len(s.waiters) > 0 && l.hasSlotLocked(class) {
+			w := s.waiters[0]
+			s.waiters = s.waiters[1:]
+			s.inflight++
+			w.admitted = true
+			close(w.ready)
+			admitted = true
+		}
+
+		if admitted {
+			l.updateMetricsLocked(class)
+		}
+	}
+}
+
+func (l *concurrencyLimiter) updateMetricsLocked(class requestClass) {
+	s := &l.classes[class]
+	l.inflightRequests.WithLabelValues(class.String()).Set(float64(s.inflight))
+	l.queuedRequests.WithLabelValues(class.String()).Set(float64(len(s.waiters)))
+}
+
+func (l *concurrencyLimiter) rejectionError(class requestClass) error {
+	if class == writeRequestClass {
+		return errTooManyConcurrentWriteRequests
+	}
+	return tooBusyError
+}
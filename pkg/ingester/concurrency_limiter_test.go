@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter_Disabled(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitsConfig{MaxQueueWait: time.Second}, prometheus.NewPedanticRegistry())
+	require.Nil(t, l)
+
+	release, err := l.acquire(context.Background(), writeRequestClass)
+	require.NoError(t, err)
+	release()
+}
+
+func TestConcurrencyLimiter_ShouldRejectRequestsWaitingLongerThanMaxQueueWait(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	l := newConcurrencyLimiter(ConcurrencyLimitsConfig{MaxConcurrentWriteRequests: 1, MaxConcurrentReadRequests: 1, MaxQueueWait: 50 * time.Millisecond}, reg)
+
+	releaseWrite, err := l.acquire(context.Background(), writeRequestClass)
+	require.NoError(t, err)
+	releaseRead, err := l.acquire(context.Background(), readRequestClass)
+	require.NoError(t, err)
+
+	_, err = l.acquire(context.Background(), writeRequestClass)
+	assert.Equal(t, errTooManyConcurrentWriteRequests, err)
+	_, err = l.acquire(context.Background(), readRequestClass)
+	assert.Equal(t, tooBusyError, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_concurrency_limited_inflight_requests Number of requests currently processed by the ingester, per request class.
+		# TYPE cortex_ingester_concurrency_limited_inflight_requests gauge
+		cortex_ingester_concurrency_limited_inflight_requests{class="read"} 1
+		cortex_ingester_concurrency_limited_inflight_requests{class="write"} 1
+
+		# HELP cortex_ingester_concurrency_limited_queued_requests Number of requests waiting for their turn because the concurrency limit of their class has been reached.
+		# TYPE cortex_ingester_concurrency_limited_queued_requests gauge
+		cortex_ingester_concurrency_limited_queued_requests{class="read"} 0
+		cortex_ingester_concurrency_limited_queued_requests{class="write"} 0
+
+		# HELP cortex_ingester_concurrency_limited_rejected_requests_total Total number of requests rejected because the concurrency limit of their class had been reached for longer than the max queue wait.
+		# TYPE cortex_ingester_concurrency_limited_rejected_requests_total counter
+		cortex_ingester_concurrency_limited_rejected_requests_total{class="read"} 1
+		cortex_ingester_concurrency_limited_rejected_requests_total{class="write"} 1
+	`), "cortex_ingester_concurrency_limited_inflight_requests", "cortex_ingester_concurrency_limited_queued_requests", "cortex_ingester_concurrency_limited_rejected_requests_total"))
+
+	// Once the slots are released, new requests are admitted.
+	releaseWrite()
+	releaseRead()
+
+	release, err := l.acquire(context.Background(), writeRequestClass)
+	require.NoError(t, err)
+	release()
+	release, err = l.acquire(context.Background(), readRequestClass)
+	require.NoError(t, err)
+	release()
+}
+
+func TestConcurrencyLimiter_ShouldRejectImmediatelyOnZeroMaxQueueWait(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitsConfig{MaxConcurrentReadRequests: 1}, prometheus.NewPedanticRegistry())
+
+	release, err := l.acquire(context.Background(), readRequestClass)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = l.acquire(context.Background(), readRequestClass)
+	assert.Equal(t, tooBusyError, err)
+
+	// Write requests are unlimited.
+	for n := 0; n < 10; n++ {
+		releaseWrite, err := l.acquire(context.Background(), writeRequestClass)
+		require.NoError(t, err)
+		defer releaseWrite()
+	}
+}
+
+func TestConcurrencyLimiter_ShouldAdmitWaitingRequestOnRelease(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitsConfig{MaxConcurrentWriteRequests: 1, MaxQueueWait: time.Minute}, prometheus.NewPedanticRegistry())
+
+	release, err := l.acquire(context.Background(), writeRequestClass)
+	require.NoError(t, err)
+
+	admitted := make(chan error)
+	go func() {
+		releaseWaiting, err := l.acquire(context.Background(), writeRequestClass)
+		if err == nil {
+			defer releaseWaiting()
+		}
+		admitted <- err
+	}()
+
+	waitQueuedRequests(t, l, writeRequestClass, 1)
+	release()
+
+	select {
+	case err := <-admitted:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the waiting request has not been admitted")
+	}
+}
+
+func TestConcurrencyLimiter_ShouldGivePriorityToWriteRequests(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitsConfig{MaxConcurrentWriteRequests: 1, MaxQueueWait: time.Minute}, prometheus.NewPedanticRegistry())
+
+	release, err := l.acquire(context.Background(), writeRequestClass)
+	require.NoError(t, err)
+
+	// A write request waits for its turn.
+	writeCtx, cancelWrite := context.WithCancel(context.Background())
+	writeErr := make(chan error)
+	go func() {
+		_, err := l.acquire(writeCtx, writeRequestClass)
+		writeErr <- err
+	}()
+	waitQueuedRequests(t, l, writeRequestClass, 1)
+
+	// Read requests are unlimited, but they're not started while a write request is waiting.
+	readErr := make(chan error)
+	go func() {
+		releaseRead, err := l.acquire(context.Background(), readRequestClass)
+		if err == nil {
+			defer releaseRead()
+		}
+		readErr <- err
+	}()
+	waitQueuedRequests(t, l, readRequestClass, 1)
+
+	// Once the waiting write request gives up, the read request is started.
+	cancelWrite()
+	assert.Equal(t, context.Canceled, <-writeErr)
+
+	select {
+	case err := <-readErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the waiting read request has not been admitted")
+	}
+
+	release()
+}
+
+func TestConcurrencyLimitsConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&ConcurrencyLimitsConfig{}).Validate())
+	assert.NoError(t, (&ConcurrencyLimitsConfig{MaxConcurrentWriteRequests: 1, MaxConcurrentReadRequests: 1, MaxQueueWait: time.Second}).Validate())
+	assert.Error(t, (&ConcurrencyLimitsConfig{MaxConcurrentWriteRequests: -1}).Validate())
+	assert.Error(t, (&ConcurrencyLimitsConfig{MaxConcurrentReadRequests: -1}).Validate())
+	assert.Error(t, (&ConcurrencyLimitsConfig{MaxQueueWait: -time.Second}).Validate())
+}
+
+func waitQueuedRequests(t *testing.T, l *concurrencyLimiter, class requestClass, expected int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		return len(l.classes[class].waiters) == expected
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	faultInjectionPush  = "push"
+	faultInjectionQuery = "query"
+
+	faultInjectionLatency = "latency"
+	faultInjectionError   = "error"
+)
+
+// faultInjector delays or fails on purpose the write requests and queries of the tenants configured
+// with fault injection, to run resilience tests without tampering with the network.
+type faultInjector struct {
+	limits *validation.Overrides
+	logger log.Logger
+
+	injectedFaults *prometheus.CounterVec
+}
+
+func newFaultInjector(limits *validation.Overrides, reg prometheus.Registerer, logger log.Logger) *faultInjector {
+	level.Warn(logger).Log("msg", "fault injection is enabled: the write requests and queries of the tenants configured with fault injection are delayed or failed on purpose")
+
+	return &faultInjector{
+		limits: limits,
+		logger: logger,
+		injectedFaults: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_injected_faults_total",
+			Help: "The total number of faults injected on purpose in the write requests and queries of the tenants, per operation and type of fault.",
+		}, []string{"user", "operation", "fault"}),
+	}
+}
+
+// inject injects the faults configured for the user in the operation, and returns the injected error, if any.
+func (f *faultInjector) inject(ctx context.Context, userID, operation string) error {
+	var (
+		latency   time.Duration
+		errorRate float64
+	)
+
+	switch operation {
+	case faultInjectionPush:
+		latency, errorRate = f.limits.IngesterFaultInjectionPushLatency(userID), f.limits.IngesterFaultInjectionPushErrorRate(userID)
+	case faultInjectionQuery:
+		latency, errorRate = f.limits.IngesterFaultInjectionQueryLatency(userID), f.limits.IngesterFaultInjectionQueryErrorRate(userID)
+	}
+
+	if latency > 0 {
+		f.injectedFaults.WithLabelValues(userID, operation, faultInjectionLatency).Inc()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	if errorRate > 0 && rand.Float64() < errorRate {
+		f.injectedFaults.WithLabelValues(userID, operation, faultInjectionError).Inc()
+		level.Warn(f.logger).Log("msg", "injected fault failed the request on purpose", "user", userID, "operation", operation)
+
+		return httpgrpc.Errorf(http.StatusServiceUnavailable, "fault injected on purpose in the %s of the tenant %s", operation, userID)
+	}
+
+	return nil
+}
+
+func (f *faultInjector) deletePerUserMetrics(userID string) {
+	f.injectedFaults.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
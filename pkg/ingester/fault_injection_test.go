@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngester_FaultInjection(t *testing.T) {
+	const latency = 200 * time.Millisecond
+
+	tenantLimits := map[string]*validation.Limits{
+		"faulty": func() *validation.Limits {
+			l := defaultLimitsTestConfig()
+			l.IngesterFaultInjectionPushErrorRate = 1
+			l.IngesterFaultInjectionQueryErrorRate = 1
+			return &l
+		}(),
+		"slow": func() *validation.Limits {
+			l := defaultLimitsTestConfig()
+			l.IngesterFaultInjectionPushLatency = model.Duration(latency)
+			l.IngesterFaultInjectionQueryLatency = model.Duration(latency)
+			return &l
+		}(),
+	}
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(tenantLimits))
+			require.NoError(t, err)
+
+			cfg := defaultIngesterTestConfig(t)
+			cfg.FaultInjectionEnabled = enabled
+
+			reg := prometheus.NewPedanticRegistry()
+			i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, "", "", reg)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			test.Poll(t, 1*time.Second, 1, func() interface{} {
+				return i.lifecycler.HealthyInstancesCount()
+			})
+
+			push := func(userID string) error {
+				ctx := user.InjectOrgID(context.Background(), userID)
+				_, err := i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "foo"), []mimirpb.Sample{{Value: 1, TimestampMs: 1000}}))
+				return err
+			}
+			query := func(userID string) error {
+				ctx := user.InjectOrgID(context.Background(), userID)
+				req := &client.QueryRequest{
+					StartTimestampMs: 0,
+					EndTimestampMs:   2000,
+					Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "foo"}},
+				}
+				return i.QueryStream(req, &stream{ctx: ctx})
+			}
+
+			// The tenants without fault injection are never affected.
+			require.NoError(t, push("healthy"))
+			require.NoError(t, query("healthy"))
+
+			for _, op := range []func(string) error{push, query} {
+				err := op("faulty")
+				if !enabled {
+					require.NoError(t, err)
+					continue
+				}
+
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+			}
+
+			for _, op := range []func(string) error{push, query} {
+				start := time.Now()
+				require.NoError(t, op("slow"))
+				if enabled {
+					assert.GreaterOrEqual(t, time.Since(start), latency)
+				}
+			}
+
+			expectedMetrics := ""
+			if enabled {
+				expectedMetrics = `
+					# HELP cortex_ingester_injected_faults_total The total number of faults injected on purpose in the write requests and queries of the tenants, per operation and type of fault.
+					# TYPE cortex_ingester_injected_faults_total counter
+					cortex_ingester_injected_faults_total{fault="error",operation="push",user="faulty"} 1
+					cortex_ingester_injected_faults_total{fault="error",operation="query",user="faulty"} 1
+					cortex_ingester_injected_faults_total{fault="latency",operation="push",user="slow"} 1
+					cortex_ingester_injected_faults_total{fault="latency",operation="query",user="slow"} 1
+				`
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_ingester_injected_faults_total"))
+		})
+	}
+}
@@ -45,7 +45,9 @@ import (
 	"github.com/prometheus/prometheus/util/zeropool"
 	"github.com/thanos-io/objstore"
 	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 
@@ -65,6 +67,8 @@ import (
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/querysource"
+	"github.com/grafana/mimir/pkg/util/ringadvisor"
 	"github.com/grafana/mimir/pkg/util/shutdownmarker"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -115,6 +119,9 @@ const (
 	// Value used to track the limit between sequential and concurrent TSDB opernings.
 	// Below this value, TSDBs of different tenants are opened sequentially, otherwise concurrently.
 	maxTSDBOpenWithoutConcurrency = 10
+
+	// Name of the directory, within the tenant's TSDB directory, storing the head chunks of the ephemeral series.
+	ephemeralSeriesDirName = "ephemeral"
 )
 
 // BlocksUploader interface is used to have an easy way to mock it in tests.
@@ -163,6 +170,10 @@ type Config struct {
 
 	ReadPathCPUUtilizationLimit    float64 `yaml:"read_path_cpu_utilization_limit" category:"experimental"`
 	ReadPathMemoryUtilizationLimit uint64  `yaml:"read_path_memory_utilization_limit" category:"experimental"`
+
+	ConcurrencyLimits ConcurrencyLimitsConfig `yaml:"concurrency_limits"`
+
+	FaultInjectionEnabled bool `yaml:"fault_injection_enabled" category:"experimental"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -185,9 +196,26 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 
 	f.Float64Var(&cfg.ReadPathCPUUtilizationLimit, "ingester.read-path-cpu-utilization-limit", 0, "CPU utilization limit, as CPU cores, for CPU/memory utilization based read request limiting")
 	f.Uint64Var(&cfg.ReadPathMemoryUtilizationLimit, "ingester.read-path-memory-utilization-limit", 0, "Memory limit, in bytes, for CPU/memory utilization based read request limiting")
+
+	cfg.ConcurrencyLimits.RegisterFlags(f)
+
+	f.BoolVar(&cfg.FaultInjectionEnabled, "ingester.fault-injection-enabled", false, "Enable the injection of the latency and errors configured with the per-tenant -ingester.fault-injection-* limits in the write requests and queries received by the ingester. Use only for resilience testing.")
+}
+
+func (cfg *Config) ValidateLimits(limits validation.Limits) error {
+	// 0 falls back to the TSDB default.
+	if limits.SamplesPerChunk != 0 && (limits.SamplesPerChunk < validation.MinSamplesPerChunk || limits.SamplesPerChunk > validation.MaxSamplesPerChunk) {
+		return fmt.Errorf("the samples per chunk must be between %d and %d", validation.MinSamplesPerChunk, validation.MaxSamplesPerChunk)
+	}
+
+	return nil
 }
 
 func (cfg *Config) Validate() error {
+	if err := cfg.ConcurrencyLimits.Validate(); err != nil {
+		return errors.Wrap(err, "invalid ingester concurrency limits config")
+	}
+
 	utilizationLimitsEnabled := cfg.ReadPathCPUUtilizationLimit > 0 || cfg.ReadPathMemoryUtilizationLimit > 0
 	if !utilizationLimitsEnabled {
 		return nil
@@ -273,6 +301,11 @@ type Ingester struct {
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
 
+	// Instance limits overrides set at run-time via the API. The mutex serializes their updates.
+	instanceLimitsOverridesMtx sync.Mutex
+	instanceLimitsOverrides    atomic.Pointer[instanceLimitsOverrides]
+	overriddenInstanceLimits   atomic.Pointer[overriddenInstanceLimits]
+
 	// Anonymous usage statistics tracked by ingester.
 	memorySeriesStats                  *expvar.Int
 	memoryTenantsStats                 *expvar.Int
@@ -283,6 +316,15 @@ type Ingester struct {
 	maxOutOfOrderTimeWindowSecondsStat *expvar.Int
 
 	utilizationBasedLimiter utilizationBasedLimiter
+
+	// Limits the number of concurrent requests per request class. Nil if disabled.
+	concurrencyLimiter *concurrencyLimiter
+
+	// Injects faults in the write requests and queries of the tenants configured with fault injection. Nil if disabled.
+	faultInjector *faultInjector
+
+	// Examples of the discarded series, exposed to tenants.
+	discardedSamplesExamples *validation.DiscardedSamplesExamples
 }
 
 func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus.Registerer, logger log.Logger) (*Ingester, error) {
@@ -330,6 +372,11 @@ func New(cfg Config, limits *validation.Overrides, activeGroupsCleanupService *u
 	}
 	i.ingestionRate = util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval)
 	i.metrics = newIngesterMetrics(registerer, cfg.ActiveSeriesMetricsEnabled, i.getInstanceLimits, i.ingestionRate, &i.inflightPushRequests)
+	i.concurrencyLimiter = newConcurrencyLimiter(cfg.ConcurrencyLimits, registerer)
+	if cfg.FaultInjectionEnabled {
+		i.faultInjector = newFaultInjector(limits, registerer, logger)
+	}
+	i.discardedSamplesExamples = validation.NewDiscardedSamplesExamples(limits)
 	i.activeGroups = activeGroupsCleanupService
 
 	if registerer != nil {
@@ -446,6 +493,17 @@ func (i *Ingester) starting(ctx context.Context) error {
 		i.setPrepareShutdown()
 	}
 
+	instanceLimitsOverridesPath := i.instanceLimitsOverridesPath()
+	instanceLimitsOverrides, err := readInstanceLimitsOverrides(instanceLimitsOverridesPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read ingester instance limits overrides")
+	}
+
+	if instanceLimitsOverrides != nil {
+		level.Info(i.logger).Log("msg", "detected existing instance limits overrides, applying them", "path", instanceLimitsOverridesPath)
+		i.instanceLimitsOverrides.Store(instanceLimitsOverrides)
+	}
+
 	i.subservices, err = services.NewManager(servs...)
 	if err == nil {
 		err = services.StartManagerAndAwaitHealthy(ctx, i.subservices)
@@ -598,7 +656,7 @@ func (i *Ingester) updateUsageStats() {
 		}
 
 		// Track only tenants with at least 1 series.
-		numSeries := userDB.Head().NumSeries()
+		numSeries := userDB.Head().NumSeries() + userDB.ephemeralNumSeries()
 		if numSeries == 0 {
 			continue
 		}
@@ -715,6 +773,18 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		return nil, err
 	}
 
+	if i.faultInjector != nil {
+		if err := i.faultInjector.inject(ctx, userID, faultInjectionPush); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := i.concurrencyLimiter.acquire(ctx, writeRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	if il != nil && il.MaxIngestionRate > 0 {
 		if rate := i.ingestionRate.Rate(); rate >= il.MaxIngestionRate {
 			return nil, errMaxIngestionRateReached
@@ -767,9 +837,15 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		}
 	)
 
+	// Series matching the tenant's ephemeral series selectors are stored in the ephemeral series head.
+	timeseries, ephemeralTimeseries := req.Timeseries, []mimirpb.PreallocTimeseries(nil)
+	if selectors := i.limits.EphemeralSeriesSelectors(userID); len(selectors) > 0 {
+		timeseries, ephemeralTimeseries = db.splitEphemeralSeries(selectors, req.Timeseries)
+	}
+
 	// Walk the samples, appending them to the users database
 	app := db.Appender(ctx).(extendedAppender)
-	level.Debug(spanlog).Log("event", "got appender for timeseries", "series", len(req.Timeseries))
+	level.Debug(spanlog).Log("event", "got appender for timeseries", "series", len(timeseries), "ephemeral_series", len(ephemeralTimeseries))
 
 	var activeSeries *activeseries.ActiveSeries
 	if i.cfg.ActiveSeriesMetricsEnabled {
@@ -778,7 +854,7 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 
 	minAppendTime, minAppendTimeAvailable := db.Head().AppendableMinValidTime()
 
-	err = i.pushSamplesToAppender(userID, req.Timeseries, app, startAppend, &stats, updateFirstPartial, activeSeries, i.limits.OutOfOrderTimeWindow(userID), minAppendTimeAvailable, minAppendTime)
+	err = i.pushSamplesToAppender(userID, timeseries, app, startAppend, &stats, updateFirstPartial, activeSeries, i.limits.OutOfOrderTimeWindow(userID), minAppendTimeAvailable, minAppendTime, req.ExemplarsOnly)
 	if err != nil {
 		if err := app.Rollback(); err != nil {
 			level.Warn(i.logger).Log("msg", "failed to rollback appender on error", "user", userID, "err", err)
@@ -787,6 +863,18 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		return nil, err
 	}
 
+	var ephemeralApp extendedAppender
+	if len(ephemeralTimeseries) > 0 {
+		ephemeralApp, err = i.pushEphemeralSamples(ctx, userID, db, ephemeralTimeseries, startAppend, &stats, updateFirstPartial)
+		if err != nil {
+			if err := app.Rollback(); err != nil {
+				level.Warn(i.logger).Log("msg", "failed to rollback appender on error", "user", userID, "err", err)
+			}
+
+			return nil, err
+		}
+	}
+
 	// At this point all samples have been added to the appender, so we can track the time it took.
 	i.metrics.appenderAddDuration.Observe(time.Since(startAppend).Seconds())
 
@@ -800,8 +888,18 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 
 	startCommit := time.Now()
 	if err := app.Commit(); err != nil {
+		if ephemeralApp != nil {
+			if err := ephemeralApp.Rollback(); err != nil {
+				level.Warn(i.logger).Log("msg", "failed to rollback ephemeral series appender on error", "user", userID, "err", err)
+			}
+		}
 		return nil, wrapWithUser(err, userID)
 	}
+	if ephemeralApp != nil {
+		if err := ephemeralApp.Commit(); err != nil {
+			return nil, wrapWithUser(errors.Wrap(err, "failed to commit ephemeral series"), userID)
+		}
+	}
 
 	commitDuration := time.Since(startCommit)
 	i.metrics.appenderCommitDuration.Observe(commitDuration.Seconds())
@@ -868,11 +966,43 @@ func (i *Ingester) updateMetricsFromPushStats(userID string, group string, stats
 	}
 }
 
+// pushEphemeralSamples appends the samples of the input ephemeral series to an appender of the
+// tenant's ephemeral series head, creating the head if it doesn't exist yet. The returned appender
+// must be committed or rolled back by the caller. Ephemeral series are not tracked as active series.
+func (i *Ingester) pushEphemeralSamples(ctx context.Context, userID string, db *userTSDB, timeseries []mimirpb.PreallocTimeseries, startAppend time.Time,
+	stats *pushStats, updateFirstPartial func(errFn func() error)) (extendedAppender, error) {
+
+	h, err := db.getOrCreateEphemeralHead()
+	if err != nil {
+		return nil, wrapWithUser(err, userID)
+	}
+
+	app := h.Appender(ctx).(extendedAppender)
+	minAppendTime, minAppendTimeAvailable := h.AppendableMinValidTime()
+
+	if err := i.pushSamplesToAppender(userID, timeseries, app, startAppend, stats, updateFirstPartial, nil, 0, minAppendTimeAvailable, minAppendTime, false); err != nil {
+		if err := app.Rollback(); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to rollback ephemeral series appender on error", "user", userID, "err", err)
+		}
+		return nil, err
+	}
+
+	return app, nil
+}
+
 // pushSamplesToAppender appends samples and exemplars to the appender. Most errors are handled via updateFirstPartial function,
 // but in case of unhandled errors, appender is rolled back and such error is returned.
 func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.PreallocTimeseries, app extendedAppender, startAppend time.Time,
 	stats *pushStats, updateFirstPartial func(errFn func() error), activeSeries *activeseries.ActiveSeries,
-	outOfOrderWindow time.Duration, minAppendTimeAvailable bool, minAppendTime int64) error {
+	outOfOrderWindow time.Duration, minAppendTimeAvailable bool, minAppendTime int64, exemplarsOnly bool) error {
+
+	// Record an example of discarded series only for the first sample discarded for each reason,
+	// to not slow down requests with many discarded samples.
+	recordFirstExample := func(discardedCount int, reason string, timestamp int64, labels []mimirpb.LabelAdapter) {
+		if discardedCount == 1 {
+			i.discardedSamplesExamples.Record(userID, reason, labels, timestamp)
+		}
+	}
 
 	// Return true if handled as soft error, and we can ingest more series.
 	handleAppendError := func(err error, timestamp int64, labels []mimirpb.LabelAdapter) bool {
@@ -884,6 +1014,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 		switch cause := errors.Cause(err); cause {
 		case storage.ErrOutOfBounds:
 			stats.sampleOutOfBoundsCount++
+			recordFirstExample(stats.sampleOutOfBoundsCount, sampleOutOfBounds, timestamp, labels)
 			updateFirstPartial(func() error {
 				return newIngestErrSampleTimestampTooOld(model.Time(timestamp), labels)
 			})
@@ -891,6 +1022,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		case storage.ErrOutOfOrderSample:
 			stats.sampleOutOfOrderCount++
+			recordFirstExample(stats.sampleOutOfOrderCount, sampleOutOfOrder, timestamp, labels)
 			updateFirstPartial(func() error {
 				return newIngestErrSampleOutOfOrder(model.Time(timestamp), labels)
 			})
@@ -898,6 +1030,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		case storage.ErrTooOldSample:
 			stats.sampleTooOldCount++
+			recordFirstExample(stats.sampleTooOldCount, sampleTooOld, timestamp, labels)
 			updateFirstPartial(func() error {
 				return newIngestErrSampleTimestampTooOldOOOEnabled(model.Time(timestamp), labels, outOfOrderWindow)
 			})
@@ -905,6 +1038,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		case storage.ErrDuplicateSampleForTimestamp:
 			stats.newValueForTimestampCount++
+			recordFirstExample(stats.newValueForTimestampCount, newValueForTimestamp, timestamp, labels)
 			updateFirstPartial(func() error {
 				return newIngestErrSampleDuplicateTimestamp(model.Time(timestamp), labels)
 			})
@@ -912,6 +1046,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		case errMaxSeriesPerUserLimitExceeded:
 			stats.perUserSeriesLimitCount++
+			recordFirstExample(stats.perUserSeriesLimitCount, perUserSeriesLimit, timestamp, labels)
 			updateFirstPartial(func() error {
 				return makeLimitError(i.limiter.FormatError(userID, cause))
 			})
@@ -919,6 +1054,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 
 		case errMaxSeriesPerMetricLimitExceeded:
 			stats.perMetricSeriesLimitCount++
+			recordFirstExample(stats.perMetricSeriesLimitCount, perMetricSeriesLimit, timestamp, labels)
 			updateFirstPartial(func() error {
 				return makeMetricLimitError(mimirpb.FromLabelAdaptersToLabelsWithCopy(labels), i.limiter.FormatError(userID, cause))
 			})
@@ -946,9 +1082,6 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 				allOutOfBoundsFloats(ts.Samples, minAppendTime) &&
 				allOutOfBoundsHistograms(ts.Histograms, minAppendTime) {
 
-				stats.failedSamplesCount += len(ts.Samples) + len(ts.Histograms)
-				stats.sampleOutOfBoundsCount += len(ts.Samples) + len(ts.Histograms)
-
 				var firstTimestamp int64
 				if len(ts.Samples) > 0 {
 					firstTimestamp = ts.Samples[0].TimestampMs
@@ -957,6 +1090,10 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 					firstTimestamp = ts.Histograms[0].Timestamp
 				}
 
+				recordFirstExample(stats.sampleOutOfBoundsCount+1, sampleOutOfBounds, firstTimestamp, ts.Labels)
+				stats.failedSamplesCount += len(ts.Samples) + len(ts.Histograms)
+				stats.sampleOutOfBoundsCount += len(ts.Samples) + len(ts.Histograms)
+
 				updateFirstPartial(func() error {
 					return newIngestErrSampleTimestampTooOld(model.Time(firstTimestamp), ts.Labels)
 				})
@@ -967,11 +1104,12 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 			if outOfOrderWindow <= 0 && minAppendTimeAvailable && len(ts.Exemplars) == 0 &&
 				len(ts.Samples) > 0 && allOutOfBoundsFloats(ts.Samples, minAppendTime) {
 
+				firstTimestamp := ts.Samples[0].TimestampMs
+
+				recordFirstExample(stats.sampleOutOfBoundsCount+1, sampleOutOfBounds, firstTimestamp, ts.Labels)
 				stats.failedSamplesCount += len(ts.Samples)
 				stats.sampleOutOfBoundsCount += len(ts.Samples)
 
-				firstTimestamp := ts.Samples[0].TimestampMs
-
 				updateFirstPartial(func() error {
 					return newIngestErrSampleTimestampTooOld(model.Time(firstTimestamp), ts.Labels)
 				})
@@ -1069,9 +1207,13 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 			// app.AppendExemplar currently doesn't create the series, it must
 			// already exist.  If it does not then drop.
 			if ref == 0 {
-				updateFirstPartial(func() error {
-					return newIngestErrExemplarMissingSeries(model.Time(ts.Exemplars[0].TimestampMs), ts.Labels, ts.Exemplars[0].Labels)
-				})
+				// The samples of the series in an exemplars-only request are sent separately, so the series may
+				// not have been created yet. Its exemplars are dropped without failing the request.
+				if !exemplarsOnly {
+					updateFirstPartial(func() error {
+						return newIngestErrExemplarMissingSeries(model.Time(ts.Exemplars[0].TimestampMs), ts.Labels, ts.Exemplars[0].Labels)
+					})
+				}
 				stats.failedExemplarsCount += len(ts.Exemplars)
 			} else { // Note that else is explicit, rather than a continue in the above if, in case of additional logic post exemplar processing.
 				for _, ex := range ts.Exemplars {
@@ -1108,6 +1250,12 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 		return nil, err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(ctx, readRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	spanlog, ctx := spanlogger.NewWithLogger(ctx, i.logger, "Ingester.QueryExemplars")
 	defer spanlog.Finish()
 
@@ -1121,7 +1269,7 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 		return nil, err
 	}
 
-	i.metrics.queries.Inc()
+	i.metrics.queries.WithLabelValues(querysource.FromContext(ctx)).Inc()
 
 	db := i.getTSDB(userID)
 	if db == nil {
@@ -1152,12 +1300,41 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 		result.Timeseries = append(result.Timeseries, ts)
 	}
 
-	i.metrics.queriedExemplars.Observe(float64(numExemplars))
+	i.metrics.queriedExemplars.WithLabelValues(querysource.FromContext(ctx)).Observe(float64(numExemplars))
 
 	return result, nil
 }
 
 func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesRequest) (*client.LabelValuesResponse, error) {
+	vals, err := i.labelValues(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.LabelValuesResponse{
+		LabelValues: vals,
+	}, nil
+}
+
+// labelStreamTargetSizeBytes is the maximum size in bytes of the label values and label names in each message of
+// the LabelValuesStream and LabelNamesStream responses. We arbitrarily set it to 1mb to avoid reaching the actual
+// gRPC default limit (4mb).
+const labelStreamTargetSizeBytes = 1 * 1024 * 1024
+
+// LabelValuesStream implements IngesterServer.
+func (i *Ingester) LabelValuesStream(req *client.LabelValuesRequest, server client.Ingester_LabelValuesStreamServer) error {
+	vals, err := i.labelValues(server.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return sendLabelsInBatches(vals, labelStreamTargetSizeBytes, func(batch []string) error {
+		return server.Send(&client.LabelValuesResponse{LabelValues: batch})
+	})
+}
+
+// labelValues returns the sorted values of the label matching the request, paginated according to its limit and after.
+func (i *Ingester) labelValues(ctx context.Context, req *client.LabelValuesRequest) ([]string, error) {
 	if err := i.checkRunning(); err != nil {
 		return nil, err
 	}
@@ -1165,6 +1342,12 @@ func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesReque
 		return nil, err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(ctx, readRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	labelName, startTimestampMs, endTimestampMs, matchers, err := client.FromLabelValuesRequest(req)
 	if err != nil {
 		return nil, err
@@ -1177,7 +1360,7 @@ func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesReque
 
 	db := i.getTSDB(userID)
 	if db == nil {
-		return &client.LabelValuesResponse{}, nil
+		return nil, nil
 	}
 
 	q, err := db.Querier(ctx, startTimestampMs, endTimestampMs)
@@ -1191,12 +1374,34 @@ func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesReque
 		return nil, err
 	}
 
-	return &client.LabelValuesResponse{
-		LabelValues: vals,
-	}, nil
+	return paginateLabels(vals, req.After, req.Limit), nil
 }
 
 func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest) (*client.LabelNamesResponse, error) {
+	names, err := i.labelNames(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.LabelNamesResponse{
+		LabelNames: names,
+	}, nil
+}
+
+// LabelNamesStream implements IngesterServer.
+func (i *Ingester) LabelNamesStream(req *client.LabelNamesRequest, server client.Ingester_LabelNamesStreamServer) error {
+	names, err := i.labelNames(server.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return sendLabelsInBatches(names, labelStreamTargetSizeBytes, func(batch []string) error {
+		return server.Send(&client.LabelNamesResponse{LabelNames: batch})
+	})
+}
+
+// labelNames returns the sorted label names matching the request, paginated according to its limit and after.
+func (i *Ingester) labelNames(ctx context.Context, req *client.LabelNamesRequest) ([]string, error) {
 	if err := i.checkRunning(); err != nil {
 		return nil, err
 	}
@@ -1204,6 +1409,12 @@ func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest
 		return nil, err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(ctx, readRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -1211,7 +1422,7 @@ func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest
 
 	db := i.getTSDB(userID)
 	if db == nil {
-		return &client.LabelNamesResponse{}, nil
+		return nil, nil
 	}
 
 	mint, maxt, matchers, err := client.FromLabelNamesRequest(req)
@@ -1230,9 +1441,41 @@ func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest
 		return nil, err
 	}
 
-	return &client.LabelNamesResponse{
-		LabelNames: names,
-	}, nil
+	return paginateLabels(names, req.After, req.Limit), nil
+}
+
+// paginateLabels returns the sorted values after the given one, up to limit values if limit is greater than 0.
+func paginateLabels(values []string, after string, limit int64) []string {
+	if after != "" {
+		idx, found := slices.BinarySearch(values, after)
+		if found {
+			idx++
+		}
+		values = values[idx:]
+	}
+	if limit > 0 && int64(len(values)) > limit {
+		values = values[:limit]
+	}
+	return values
+}
+
+// sendLabelsInBatches calls send with consecutive batches of values, each one not larger than targetSizeBytes
+// unless it's a single value.
+func sendLabelsInBatches(values []string, targetSizeBytes int, send func([]string) error) error {
+	start, size := 0, 0
+	for idx, v := range values {
+		if size > 0 && size+len(v) > targetSizeBytes {
+			if err := send(values[start:idx]); err != nil {
+				return err
+			}
+			start, size = idx, 0
+		}
+		size += len(v)
+	}
+	if start < len(values) {
+		return send(values[start:])
+	}
+	return nil
 }
 
 // MetricsForLabelMatchers implements IngesterServer.
@@ -1244,6 +1487,12 @@ func (i *Ingester) MetricsForLabelMatchers(ctx context.Context, req *client.Metr
 		return nil, err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(ctx, readRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -1314,6 +1563,12 @@ func (i *Ingester) UserStats(ctx context.Context, req *client.UserStatsRequest)
 		return nil, err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(ctx, readRequestClass)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -1365,6 +1620,12 @@ func (i *Ingester) LabelNamesAndValues(request *client.LabelNamesAndValuesReques
 		return err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(server.Context(), readRequestClass)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	userID, err := tenant.TenantID(server.Context())
 	if err != nil {
 		return err
@@ -1397,6 +1658,12 @@ func (i *Ingester) LabelValuesCardinality(req *client.LabelValuesCardinalityRequ
 		return err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(srv.Context(), readRequestClass)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	userID, err := tenant.TenantID(srv.Context())
 	if err != nil {
 		return err
@@ -1433,6 +1700,17 @@ func (i *Ingester) LabelValuesCardinality(req *client.LabelValuesCardinalityRequ
 		return fmt.Errorf("unknown count method %q", req.GetCountMethod())
 	}
 
+	if req.GetApproximate() {
+		return approximatedLabelValuesCardinality(
+			req.GetLabelNames(),
+			matchers,
+			idx,
+			postingsForMatchersFn,
+			labelValuesCardinalityTargetSizeBytes,
+			srv,
+		)
+	}
+
 	return labelValuesCardinality(
 		req.GetLabelNames(),
 		matchers,
@@ -1443,6 +1721,97 @@ func (i *Ingester) LabelValuesCardinality(req *client.LabelValuesCardinalityRequ
 	)
 }
 
+// activeSeriesTargetSizeBytes is the maximum size in bytes of the series labels in each message of the ActiveSeries
+// response. We arbitrarily set it to 1mb to avoid reaching the actual gRPC default limit (4mb).
+const activeSeriesTargetSizeBytes = 1 * 1024 * 1024
+
+// ActiveSeries implements IngesterServer.
+func (i *Ingester) ActiveSeries(req *client.ActiveSeriesRequest, srv client.Ingester_ActiveSeriesServer) error {
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+	if err := i.checkReadOverloaded(); err != nil {
+		return err
+	}
+
+	release, err := i.concurrencyLimiter.acquire(srv.Context(), readRequestClass)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	userID, err := tenant.TenantID(srv.Context())
+	if err != nil {
+		return err
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return nil
+	}
+	idx, err := db.Head().Index()
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	matchers, err := client.FromLabelMatchers(req.GetMatchers())
+	if err != nil {
+		return err
+	}
+
+	postings, err := tsdb.PostingsForMatchers(idx, matchers...)
+	if err != nil {
+		return err
+	}
+
+	return activeSeries(srv.Context(), idx, activeseries.NewPostings(db.activeSeries, postings), activeSeriesTargetSizeBytes, srv)
+}
+
+// activeSeries sends the labels of the series of the postings in batches, each one not larger than targetSizeBytes
+// unless it's a single series.
+func activeSeries(ctx context.Context, idx tsdb.IndexReader, postings index.Postings, targetSizeBytes int, srv client.Ingester_ActiveSeriesServer) error {
+	var (
+		builder   labels.ScratchBuilder
+		batch     []*mimirpb.Metric
+		batchSize int
+	)
+
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := idx.Series(postings.At(), &builder, nil); err != nil {
+			// The series may have been garbage collected since the postings were read.
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+
+		// The labels are copied, since the builder is reused for the following series.
+		metric := &mimirpb.Metric{Labels: mimirpb.FromLabelsToLabelAdapters(builder.Labels())}
+		metricSize := metric.Size()
+		if batchSize > 0 && batchSize+metricSize > targetSizeBytes {
+			if err := srv.Send(&client.ActiveSeriesResponse{Metric: batch}); err != nil {
+				return err
+			}
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, metric)
+		batchSize += metricSize
+	}
+	if err := postings.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return srv.Send(&client.ActiveSeriesResponse{Metric: batch})
+	}
+	return nil
+}
+
 func createUserStats(db *userTSDB, req *client.UserStatsRequest) (*client.UserStatsResponse, error) {
 	apiRate := db.ingestedAPISamples.Rate()
 	ruleRate := db.ingestedRuleSamples.Rate()
@@ -1450,7 +1819,7 @@ func createUserStats(db *userTSDB, req *client.UserStatsRequest) (*client.UserSt
 	var series uint64
 	switch req.GetCountMethod() {
 	case client.IN_MEMORY:
-		series = db.Head().NumSeries()
+		series = db.Head().NumSeries() + db.ephemeralNumSeries()
 	case client.ACTIVE:
 		activeSeries := db.activeSeries.Active()
 		series = uint64(activeSeries)
@@ -1477,6 +1846,12 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return err
 	}
 
+	release, err := i.concurrencyLimiter.acquire(stream.Context(), readRequestClass)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	spanlog, ctx := spanlogger.NewWithLogger(stream.Context(), i.logger, "Ingester.QueryStream")
 	defer spanlog.Finish()
 
@@ -1485,6 +1860,12 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return err
 	}
 
+	if i.faultInjector != nil {
+		if err := i.faultInjector.inject(ctx, userID, faultInjectionQuery); err != nil {
+			return err
+		}
+	}
+
 	from, through, matchers, err := client.FromQueryRequest(req)
 	if err != nil {
 		return err
@@ -1497,7 +1878,8 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return err
 	}
 
-	i.metrics.queries.Inc()
+	source := querysource.FromContext(ctx)
+	i.metrics.queries.WithLabelValues(source).Inc()
 
 	db := i.getTSDB(userID)
 	if db == nil {
@@ -1540,8 +1922,8 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		return err
 	}
 
-	i.metrics.queriedSeries.Observe(float64(numSeries))
-	i.metrics.queriedSamples.Observe(float64(numSamples))
+	i.metrics.queriedSeries.WithLabelValues(source).Observe(float64(numSeries))
+	i.metrics.queriedSamples.WithLabelValues(source).Observe(float64(numSamples))
 	level.Debug(spanlog).Log("series", numSeries, "samples", numSamples)
 	return nil
 }
@@ -2016,6 +2398,7 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 		instanceLimitsFn:    i.getInstanceLimits,
 		instanceSeriesCount: &i.seriesCount,
 		blockMinRetention:   i.cfg.BlocksStorageConfig.TSDB.Retention,
+		ephemeralRetention:  i.cfg.BlocksStorageConfig.TSDB.EphemeralSeriesRetentionPeriod,
 	}
 
 	maxExemplars := i.limiter.convertGlobalToLocalLimit(userID, i.limits.MaxGlobalExemplarsPerUser(userID))
@@ -2050,12 +2433,15 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 		BlockPostingsForMatchersCacheSize:  i.cfg.BlocksStorageConfig.TSDB.BlockPostingsForMatchersCacheSize,
 		BlockPostingsForMatchersCacheForce: i.cfg.BlocksStorageConfig.TSDB.BlockPostingsForMatchersCacheForce,
 		EnableNativeHistograms:             i.limits.NativeHistogramsIngestionEnabled(userID),
+		SamplesPerChunk:                    i.limits.SamplesPerChunk(userID),
 	}, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to open TSDB: %s", udir)
 	}
 	db.DisableCompactions() // we will compact on our own schedule
 
+	userDB.ephemeralOpts = i.ephemeralHeadOptions(userID, udir, userDB)
+
 	// Run compaction before using this TSDB. If there is data in head that needs to be put into blocks,
 	// this will actually create the blocks. If there is no data (empty TSDB), this is a no-op, although
 	// local blocks compaction may still take place if configured.
@@ -2101,6 +2487,28 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 	return userDB, nil
 }
 
+// ephemeralHeadOptions returns the options of the head storing the ephemeral series of a tenant.
+// The head is created only once the first ephemeral series is pushed.
+func (i *Ingester) ephemeralHeadOptions(userID, udir string, userDB *userTSDB) *tsdb.HeadOptions {
+	opts := tsdb.DefaultHeadOptions()
+	// Samples are appendable up to half of the chunk range older than the latest sample,
+	// so we double the retention period to accept samples as old as the retention period.
+	opts.ChunkRange = 2 * i.cfg.BlocksStorageConfig.TSDB.EphemeralSeriesRetentionPeriod.Milliseconds()
+	opts.ChunkDirRoot = filepath.Join(udir, ephemeralSeriesDirName)
+	opts.ChunkWriteBufferSize = i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteBufferSize
+	opts.ChunkEndTimeVariance = i.cfg.BlocksStorageConfig.TSDB.HeadChunksEndTimeVariance
+	opts.ChunkWriteQueueSize = i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteQueueSize
+	opts.StripeSize = i.cfg.BlocksStorageConfig.TSDB.StripeSize
+	opts.SeriesCallback = ephemeralSeriesCallback{userDB}
+	opts.IsolationDisabled = true
+	opts.SamplesPerChunk = i.limits.SamplesPerChunk(userID)
+	opts.EnableNativeHistograms.Store(i.limits.NativeHistogramsIngestionEnabled(userID))
+	opts.PostingsForMatchersCacheTTL = i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheTTL
+	opts.PostingsForMatchersCacheSize = i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheSize
+	opts.PostingsForMatchersCacheForce = i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheForce
+	return opts
+}
+
 func (i *Ingester) closeAllTSDB() {
 	i.tsdbsMtx.Lock()
 
@@ -2446,6 +2854,13 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 			return nil
 		}
 
+		idle := i.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.compactionIdleTimeout)
+
+		// Ephemeral series are never compacted into blocks, but removed once older than their retention period.
+		if err := userDB.truncateEphemeralHead(time.Now(), idle); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to truncate ephemeral series", "user", userID, "err", err)
+		}
+
 		// Don't do anything, if there is nothing to compact.
 		h := userDB.Head()
 		if h.NumSeries() == 0 {
@@ -2456,17 +2871,27 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 
 		i.metrics.compactionsTriggered.Inc()
 
+		earlyMaxTime, earlyCompaction := i.headEarlyCompactionMaxTime(userID, userDB)
+
 		reason := ""
 		switch {
 		case force:
 			reason = "forced"
 			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
 
-		case i.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.compactionIdleTimeout):
+		case idle:
 			reason = "idle"
 			level.Info(i.logger).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
 			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
 
+		case earlyCompaction:
+			reason = "early"
+			level.Info(i.logger).Log("msg", "TSDB head series exceed the early compaction threshold, compacting head early", "user", userID, "seriesBytes", userDB.headSeriesBytes.Load(), "maxTime", earlyMaxTime)
+			i.metrics.earlyCompactions.Inc()
+			if err = userDB.compactHeadUpTo(earlyMaxTime, i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds()); err == nil {
+				userDB.earlyCompacted.Store(true)
+			}
+
 		default:
 			reason = "regular"
 			err = userDB.Compact()
@@ -2483,6 +2908,35 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 	})
 }
 
+// headEarlyCompactionMaxTime returns the max time of the head samples to compact early, and whether the head
+// should be compacted early because its series exceed the tenant's threshold. Once compacted early, the head
+// isn't compacted early again until its series drop below the threshold. The samples older than half of the
+// block range are compacted, like the regular head compaction does so that the samples can still be appended
+// while compacting, on boundaries aligned to a quarter of the block range, so that the blocks don't span across
+// the block ranges.
+func (i *Ingester) headEarlyCompactionMaxTime(userID string, userDB *userTSDB) (int64, bool) {
+	threshold := i.limits.HeadEarlyCompactionSeriesBytes(userID)
+	if threshold <= 0 || userDB.headSeriesBytes.Load() <= int64(threshold) {
+		userDB.earlyCompacted.Store(false)
+		return 0, false
+	}
+	if userDB.earlyCompacted.Load() {
+		return 0, false
+	}
+
+	h := userDB.Head()
+	minTime, maxTime := h.MinTime(), h.MaxTime()
+	if minTime > maxTime {
+		// The head has no samples.
+		return 0, false
+	}
+
+	blockRange := i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds()
+	step := blockRange / 4
+	earlyMaxTime := ((maxTime-blockRange/2)/step)*step - 1
+	return earlyMaxTime, minTime <= earlyMaxTime
+}
+
 func (i *Ingester) closeAndDeleteIdleUserTSDBs(ctx context.Context) error {
 	for _, userID := range i.getTSDBUsers() {
 		if ctx.Err() != nil {
@@ -2531,7 +2985,7 @@ func (i *Ingester) closeAndDeleteUserTSDBIfIdle(userID string) tsdbCloseCheckRes
 	// At this point there are no more pushes to TSDB, and no possible compaction. Normally TSDB is empty,
 	// but if we're closing TSDB because of tenant deletion mark, then it may still contain some series.
 	// We need to remove these series from series count.
-	i.seriesCount.Sub(int64(userDB.Head().NumSeries()))
+	i.seriesCount.Sub(int64(userDB.Head().NumSeries() + userDB.ephemeralNumSeries()))
 
 	dir := userDB.db.Dir()
 
@@ -2561,7 +3015,11 @@ func (i *Ingester) closeAndDeleteUserTSDBIfIdle(userID string) tsdbCloseCheckRes
 
 	i.deleteUserMetadata(userID)
 	i.metrics.deletePerUserMetrics(userID)
+	if i.faultInjector != nil {
+		i.faultInjector.deletePerUserMetrics(userID)
+	}
 	i.metrics.deletePerUserCustomTrackerMetrics(userID, userDB.activeSeries.CurrentMatcherNames())
+	i.discardedSamplesExamples.DeleteUser(userID)
 
 	// And delete local data.
 	if err := os.RemoveAll(dir); err != nil {
@@ -2732,16 +3190,27 @@ func (i *Ingester) getInstanceLimits() *InstanceLimits {
 		return nil
 	}
 
-	if i.cfg.InstanceLimitsFn == nil {
-		return &i.cfg.DefaultLimits
+	l := &i.cfg.DefaultLimits
+	if i.cfg.InstanceLimitsFn != nil {
+		if rl := i.cfg.InstanceLimitsFn(); rl != nil {
+			l = rl
+		}
+	}
+
+	o := i.instanceLimitsOverrides.Load()
+	if o == nil {
+		return l
 	}
 
-	l := i.cfg.InstanceLimitsFn()
-	if l == nil {
-		return &i.cfg.DefaultLimits
+	// The overrides are applied only when either the configured limits or the overrides change,
+	// instead of on every push.
+	if cached := i.overriddenInstanceLimits.Load(); cached != nil && cached.configured == l && cached.overrides == o {
+		return cached.limits
 	}
 
-	return l
+	overridden := o.apply(*l)
+	i.overriddenInstanceLimits.Store(&overriddenInstanceLimits{configured: l, overrides: o, limits: &overridden})
+	return &overridden
 }
 
 // PrepareShutdownHandler inspects or changes the configuration of the ingester such that when
@@ -2857,6 +3326,36 @@ func (i *Ingester) Push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirp
 	return i.PushWithCleanup(ctx, pushReq)
 }
 
+// PushMultiTenant implements client.IngesterServer. Each write request is pushed as if it had been
+// received by Push, and its outcome is returned in the response, in the same order of the requests.
+func (i *Ingester) PushMultiTenant(ctx context.Context, req *client.MultiTenantWriteRequest) (*client.MultiTenantWriteResponse, error) {
+	// The call is authenticated for all the tenants of the batch, regardless of whether tenant federation is enabled.
+	tenantIDs, err := tenant.NewMultiResolver().TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &client.MultiTenantWriteResponse{Responses: make([]*client.TenantWriteResponse, 0, len(req.Requests))}
+	for _, tenantReq := range req.Requests {
+		// The tenant of each write request must be one of the tenants the call has been authenticated for.
+		if !slices.Contains(tenantIDs, tenantReq.TenantId) {
+			resp.Responses = append(resp.Responses, client.NewTenantWriteResponse(
+				httpgrpc.Errorf(http.StatusUnauthorized, "the write request tenant %q is not one of the tenants of the call", tenantReq.TenantId)))
+			continue
+		}
+
+		writeReq := tenantReq.Request
+		if writeReq == nil {
+			writeReq = &mimirpb.WriteRequest{}
+		}
+
+		_, err := i.Push(user.InjectOrgID(ctx, tenantReq.TenantId), writeReq)
+		resp.Responses = append(resp.Responses, client.NewTenantWriteResponse(err))
+	}
+
+	return resp, nil
+}
+
 // pushMetadata returns number of ingested metadata.
 func (i *Ingester) pushMetadata(ctx context.Context, userID string, metadata []*mimirpb.MetricMetadata) int {
 	ingestedMetadata := 0
@@ -2988,6 +3487,11 @@ func (i *Ingester) RingHandler() http.Handler {
 	return i.lifecycler
 }
 
+// RingRebalancingHandler returns the handler serving the rebalancing recommendations of the ingesters ring.
+func (i *Ingester) RingRebalancingHandler() http.Handler {
+	return ringadvisor.NewHandler(ringadvisor.ConfigFromRingConfig(i.cfg.IngesterRing.ToRingConfig(), true), i.lifecycler.KVStore, IngesterRingKey)
+}
+
 func initSelectHints(start, end int64) *storage.SelectHints {
 	return &storage.SelectHints{
 		Start: start,
@@ -3029,6 +3533,11 @@ func allOutOfBoundsHistograms(histograms []mimirpb.Histogram, minValidTime int64
 	return true
 }
 
+// DiscardedSamplesHandler returns the examples of series discarded by this ingester for the authenticated tenant.
+func (i *Ingester) DiscardedSamplesHandler(w http.ResponseWriter, r *http.Request) {
+	i.discardedSamplesExamples.Handler(w, r)
+}
+
 func (i *Ingester) UserRegistryHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := tenant.TenantID(r.Context())
 	if err != nil {
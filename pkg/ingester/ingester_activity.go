@@ -36,6 +36,11 @@ func (i *ActivityTrackerWrapper) Push(ctx context.Context, request *mimirpb.Writ
 	return i.ing.Push(ctx, request)
 }
 
+func (i *ActivityTrackerWrapper) PushMultiTenant(ctx context.Context, request *client.MultiTenantWriteRequest) (*client.MultiTenantWriteResponse, error) {
+	// No tracking in PushMultiTenant
+	return i.ing.PushMultiTenant(ctx, request)
+}
+
 func (i *ActivityTrackerWrapper) PushWithCleanup(ctx context.Context, r *push.Request) (*mimirpb.WriteResponse, error) {
 	// No tracking in PushWithCleanup
 	return i.ing.PushWithCleanup(ctx, r)
@@ -122,6 +127,24 @@ func (i *ActivityTrackerWrapper) LabelNamesAndValues(request *client.LabelNamesA
 	return i.ing.LabelNamesAndValues(request, server)
 }
 
+func (i *ActivityTrackerWrapper) LabelValuesStream(request *client.LabelValuesRequest, server client.Ingester_LabelValuesStreamServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/LabelValuesStream", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.LabelValuesStream(request, server)
+}
+
+func (i *ActivityTrackerWrapper) LabelNamesStream(request *client.LabelNamesRequest, server client.Ingester_LabelNamesStreamServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/LabelNamesStream", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.LabelNamesStream(request, server)
+}
+
 func (i *ActivityTrackerWrapper) LabelValuesCardinality(request *client.LabelValuesCardinalityRequest, server client.Ingester_LabelValuesCardinalityServer) error {
 	ix := i.tracker.Insert(func() string {
 		return requestActivity(server.Context(), "Ingester/LabelValuesCardinality", request)
@@ -131,6 +154,15 @@ func (i *ActivityTrackerWrapper) LabelValuesCardinality(request *client.LabelVal
 	return i.ing.LabelValuesCardinality(request, server)
 }
 
+func (i *ActivityTrackerWrapper) ActiveSeries(request *client.ActiveSeriesRequest, server client.Ingester_ActiveSeriesServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/ActiveSeries", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.ActiveSeries(request, server)
+}
+
 func (i *ActivityTrackerWrapper) FlushHandler(w http.ResponseWriter, r *http.Request) {
 	ix := i.tracker.Insert(func() string {
 		return requestActivity(r.Context(), "Ingester/FlushHandler", nil)
@@ -149,6 +181,15 @@ func (i *ActivityTrackerWrapper) PrepareShutdownHandler(w http.ResponseWriter, r
 	i.ing.PrepareShutdownHandler(w, r)
 }
 
+func (i *ActivityTrackerWrapper) InstanceLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/InstanceLimitsHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.InstanceLimitsHandler(w, r)
+}
+
 func (i *ActivityTrackerWrapper) ShutdownHandler(w http.ResponseWriter, r *http.Request) {
 	ix := i.tracker.Insert(func() string {
 		return requestActivity(r.Context(), "Ingester/ShutdownHandler", nil)
@@ -167,6 +208,15 @@ func (i *ActivityTrackerWrapper) UserRegistryHandler(writer http.ResponseWriter,
 	i.ing.UserRegistryHandler(writer, request)
 }
 
+func (i *ActivityTrackerWrapper) DiscardedSamplesHandler(writer http.ResponseWriter, request *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(request.Context(), "Ingester/DiscardedSamplesHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.DiscardedSamplesHandler(writer, request)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	userID, _ := tenant.TenantID(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
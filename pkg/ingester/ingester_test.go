@@ -31,6 +31,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/test"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
@@ -64,6 +65,7 @@ import (
 	"github.com/grafana/mimir/pkg/util"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/push"
+	"github.com/grafana/mimir/pkg/util/querysource"
 	util_test "github.com/grafana/mimir/pkg/util/test"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -754,6 +756,79 @@ func TestIngester_Push(t *testing.T) {
 				cortex_ingester_tsdb_exemplar_out_of_order_exemplars_total 0
 			`,
 		},
+		"should drop exemplar with unknown series in an exemplars-only request without failing": {
+			maxExemplars: 1,
+			reqs: []*mimirpb.WriteRequest{
+				// The samples of the series are sent in a separate request, which hasn't been received yet.
+				{
+					ExemplarsOnly: true,
+					Timeseries: []mimirpb.PreallocTimeseries{
+						{
+							TimeSeries: &mimirpb.TimeSeries{
+								Labels: []mimirpb.LabelAdapter{metricLabelAdapters[0]}, // Cannot reuse test slice var because it is cleared and returned to the pool
+								Exemplars: []mimirpb.Exemplar{
+									{
+										Labels:      []mimirpb.LabelAdapter{{Name: "traceID", Value: "123"}},
+										TimestampMs: 1000,
+										Value:       1000,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedErr:              nil,
+			expectedIngested:         nil,
+			expectedMetadataIngested: nil,
+			additionalMetrics: []string{
+				"cortex_ingester_tsdb_exemplar_exemplars_appended_total",
+				"cortex_ingester_tsdb_exemplar_exemplars_in_storage",
+				"cortex_ingester_tsdb_exemplar_series_with_exemplars_in_storage",
+				"cortex_ingester_tsdb_exemplar_last_exemplars_timestamp_seconds",
+				"cortex_ingester_tsdb_exemplar_out_of_order_exemplars_total",
+			},
+			expectedMetrics: `
+				# HELP cortex_ingester_ingested_samples_total The total number of samples ingested per user.
+				# TYPE cortex_ingester_ingested_samples_total counter
+				cortex_ingester_ingested_samples_total{user="test"} 0
+				# HELP cortex_ingester_ingested_samples_failures_total The total number of samples that errored on ingestion per user.
+				# TYPE cortex_ingester_ingested_samples_failures_total counter
+				cortex_ingester_ingested_samples_failures_total{user="test"} 0
+				# HELP cortex_ingester_memory_users The current number of users in memory.
+				# TYPE cortex_ingester_memory_users gauge
+				cortex_ingester_memory_users 1
+				# HELP cortex_ingester_memory_series The current number of series in memory.
+				# TYPE cortex_ingester_memory_series gauge
+				cortex_ingester_memory_series 0
+				# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
+				# TYPE cortex_ingester_memory_series_created_total counter
+				cortex_ingester_memory_series_created_total{user="test"} 0
+				# HELP cortex_ingester_memory_series_removed_total The total number of series that were removed per user.
+				# TYPE cortex_ingester_memory_series_removed_total counter
+				cortex_ingester_memory_series_removed_total{user="test"} 0
+
+				# HELP cortex_ingester_tsdb_exemplar_exemplars_appended_total Total number of TSDB exemplars appended.
+				# TYPE cortex_ingester_tsdb_exemplar_exemplars_appended_total counter
+				cortex_ingester_tsdb_exemplar_exemplars_appended_total{user="test"} 0
+
+				# HELP cortex_ingester_tsdb_exemplar_exemplars_in_storage Number of TSDB exemplars currently in storage.
+				# TYPE cortex_ingester_tsdb_exemplar_exemplars_in_storage gauge
+				cortex_ingester_tsdb_exemplar_exemplars_in_storage 0
+
+				# HELP cortex_ingester_tsdb_exemplar_series_with_exemplars_in_storage Number of TSDB series with exemplars currently in storage.
+				# TYPE cortex_ingester_tsdb_exemplar_series_with_exemplars_in_storage gauge
+				cortex_ingester_tsdb_exemplar_series_with_exemplars_in_storage{user="test"} 0
+
+				# HELP cortex_ingester_tsdb_exemplar_last_exemplars_timestamp_seconds The timestamp of the oldest exemplar stored in circular storage. Useful to check for what time range the current exemplar buffer limit allows. This usually means the last timestamp for all exemplars for a typical setup. This is not true though if one of the series timestamp is in future compared to rest series.
+				# TYPE cortex_ingester_tsdb_exemplar_last_exemplars_timestamp_seconds gauge
+				cortex_ingester_tsdb_exemplar_last_exemplars_timestamp_seconds{user="test"} 0
+
+				# HELP cortex_ingester_tsdb_exemplar_out_of_order_exemplars_total Total number of out-of-order exemplar ingestion failed attempts.
+				# TYPE cortex_ingester_tsdb_exemplar_out_of_order_exemplars_total counter
+				cortex_ingester_tsdb_exemplar_out_of_order_exemplars_total 0
+			`,
+		},
 		"should succeed with a request containing only metadata": {
 			maxExemplars: 1,
 			reqs: []*mimirpb.WriteRequest{
@@ -1652,6 +1727,224 @@ func Test_Ingester_LabelValues(t *testing.T) {
 	})
 }
 
+func TestIngester_LabelValuesStream_LabelNamesStream(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings(labels.MetricName, "test_1", "status", "200", "route", "get_user"),
+		labels.FromStrings(labels.MetricName, "test_1", "status", "500", "route", "get_user"),
+		labels.FromStrings(labels.MetricName, "test_2", "status", "404"),
+	} {
+		req, _, _, _ := mockWriteRequest(t, lbls, 1, 100000)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	t.Run("label values", func(t *testing.T) {
+		server := &mockLabelValuesStreamServer{context: ctx}
+		require.NoError(t, i.LabelValuesStream(&client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64, After: "200", Limit: 1}, server))
+		assert.Equal(t, []string{"404"}, server.values())
+
+		server = &mockLabelValuesStreamServer{context: ctx}
+		require.NoError(t, i.LabelValuesStream(&client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64}, server))
+		assert.Equal(t, []string{"200", "404", "500"}, server.values())
+	})
+
+	t.Run("label names", func(t *testing.T) {
+		server := &mockLabelNamesStreamServer{context: ctx}
+		require.NoError(t, i.LabelNamesStream(&client.LabelNamesRequest{EndTimestampMs: math.MaxInt64, After: labels.MetricName, Limit: 1}, server))
+		assert.Equal(t, []string{"route"}, server.names())
+
+		server = &mockLabelNamesStreamServer{context: ctx}
+		require.NoError(t, i.LabelNamesStream(&client.LabelNamesRequest{EndTimestampMs: math.MaxInt64}, server))
+		assert.Equal(t, []string{labels.MetricName, "route", "status"}, server.names())
+	})
+}
+
+func TestIngester_ActiveSeries(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	series := []labels.Labels{
+		labels.FromStrings(labels.MetricName, "test_1", "status", "200"),
+		labels.FromStrings(labels.MetricName, "test_1", "status", "500"),
+		labels.FromStrings(labels.MetricName, "test_2"),
+	}
+	for _, lbls := range series {
+		req, _, _, _ := mockWriteRequest(t, lbls, 1, 100000)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	activeSeries := func(t *testing.T, server *mockActiveSeriesServer) []labels.Labels {
+		var result []labels.Labels
+		for _, resp := range server.SentResponses {
+			for _, metric := range resp.Metric {
+				result = append(result, mimirpb.FromLabelAdaptersToLabels(metric.Labels))
+			}
+		}
+		slices.SortFunc(result, func(a, b labels.Labels) bool { return labels.Compare(a, b) < 0 })
+		return result
+	}
+
+	t.Run("should return the active series matching the matchers", func(t *testing.T) {
+		server := &mockActiveSeriesServer{context: ctx}
+		req := &client.ActiveSeriesRequest{Matchers: []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "test_1"}}}
+		require.NoError(t, i.ActiveSeries(req, server))
+		assert.Equal(t, series[:2], activeSeries(t, server))
+	})
+
+	t.Run("should not return the inactive series", func(t *testing.T) {
+		db := i.getTSDB("test")
+		db.activeSeries.Purge(time.Now().Add(time.Hour))
+
+		server := &mockActiveSeriesServer{context: ctx}
+		req := &client.ActiveSeriesRequest{Matchers: []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: labels.MetricName, Value: ".+"}}}
+		require.NoError(t, i.ActiveSeries(req, server))
+		assert.Empty(t, server.SentResponses)
+	})
+
+	t.Run("should return nothing for a tenant without TSDB", func(t *testing.T) {
+		server := &mockActiveSeriesServer{context: user.InjectOrgID(context.Background(), "unknown")}
+		require.NoError(t, i.ActiveSeries(&client.ActiveSeriesRequest{}, server))
+		assert.Empty(t, server.SentResponses)
+	})
+}
+
+func TestActiveSeries_Batching(t *testing.T) {
+	db, err := tsdb.Open(t.TempDir(), nil, nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	app := db.Appender(context.Background())
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := app.Append(0, labels.FromStrings(labels.MetricName, name), 1000, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	idx, err := db.Head().Index()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, idx.Close()) })
+
+	postings, err := tsdb.PostingsForMatchers(idx, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+"))
+	require.NoError(t, err)
+
+	// Each series takes 15 bytes, so the first two series are sent in the first batch.
+	server := &mockActiveSeriesServer{context: context.Background()}
+	require.NoError(t, activeSeries(context.Background(), idx, postings, 30, server))
+	require.Len(t, server.SentResponses, 2)
+	assert.Len(t, server.SentResponses[0].Metric, 2)
+	assert.Len(t, server.SentResponses[1].Metric, 1)
+}
+
+type mockActiveSeriesServer struct {
+	client.Ingester_ActiveSeriesServer
+	SentResponses []*client.ActiveSeriesResponse
+	context       context.Context
+}
+
+func (m *mockActiveSeriesServer) Send(response *client.ActiveSeriesResponse) error {
+	m.SentResponses = append(m.SentResponses, response)
+	return nil
+}
+
+func (m *mockActiveSeriesServer) Context() context.Context {
+	return m.context
+}
+
+func TestPaginateLabels(t *testing.T) {
+	values := []string{"a", "b", "d", "e"}
+
+	assert.Equal(t, values, paginateLabels(values, "", 0))
+	assert.Equal(t, []string{"a", "b"}, paginateLabels(values, "", 2))
+	assert.Equal(t, []string{"d", "e"}, paginateLabels(values, "b", 0))
+	assert.Equal(t, []string{"d"}, paginateLabels(values, "c", 1))
+	assert.Empty(t, paginateLabels(values, "e", 0))
+	assert.Empty(t, paginateLabels(values, "f", 2))
+}
+
+func TestSendLabelsInBatches(t *testing.T) {
+	var batches [][]string
+	send := func(batch []string) error {
+		batches = append(batches, batch)
+		return nil
+	}
+
+	require.NoError(t, sendLabelsInBatches([]string{"aa", "bb", "cccccc", "d", "e"}, 4, send))
+	// A value larger than the target size is sent in its own batch.
+	assert.Equal(t, [][]string{{"aa", "bb"}, {"cccccc"}, {"d", "e"}}, batches)
+
+	batches = nil
+	require.NoError(t, sendLabelsInBatches(nil, 4, send))
+	assert.Empty(t, batches)
+
+	require.EqualError(t, sendLabelsInBatches([]string{"a"}, 4, func([]string) error { return errors.New("send failed") }), "send failed")
+}
+
+type mockLabelValuesStreamServer struct {
+	client.Ingester_LabelValuesStreamServer
+	SentResponses []*client.LabelValuesResponse
+	context       context.Context
+}
+
+func (m *mockLabelValuesStreamServer) Send(response *client.LabelValuesResponse) error {
+	m.SentResponses = append(m.SentResponses, response)
+	return nil
+}
+
+func (m *mockLabelValuesStreamServer) Context() context.Context {
+	return m.context
+}
+
+func (m *mockLabelValuesStreamServer) values() []string {
+	var values []string
+	for _, resp := range m.SentResponses {
+		values = append(values, resp.LabelValues...)
+	}
+	return values
+}
+
+type mockLabelNamesStreamServer struct {
+	client.Ingester_LabelNamesStreamServer
+	SentResponses []*client.LabelNamesResponse
+	context       context.Context
+}
+
+func (m *mockLabelNamesStreamServer) Send(response *client.LabelNamesResponse) error {
+	m.SentResponses = append(m.SentResponses, response)
+	return nil
+}
+
+func (m *mockLabelNamesStreamServer) Context() context.Context {
+	return m.context
+}
+
+func (m *mockLabelNamesStreamServer) names() []string {
+	var names []string
+	for _, resp := range m.SentResponses {
+		names = append(names, resp.LabelNames...)
+	}
+	return names
+}
+
 func Test_Ingester_Query(t *testing.T) {
 	series := []series{
 		{labels.FromStrings(labels.MetricName, "test_1", "status", "200", "route", "get_user"), 1, 100000},
@@ -2270,6 +2563,83 @@ func TestIngester_Push_ShouldNotCreateTSDBIfNotInActiveState(t *testing.T) {
 	assert.False(t, tsdbCreated)
 }
 
+func TestIngester_Push_ShouldRecordDiscardedSamplesExamples(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	limits := defaultLimitsTestConfig()
+	limits.DiscardedSamplesExamplesPerReason = 10
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	series := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test"))
+
+	_, err = i.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series}, []mimirpb.Sample{{TimestampMs: 10, Value: 1}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+
+	// Only the first out-of-order sample of the request is recorded as an example.
+	_, err = i.Push(ctx, mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series, series}, []mimirpb.Sample{{TimestampMs: 9, Value: 1}, {TimestampMs: 8, Value: 1}}, nil, nil, mimirpb.API))
+	require.Error(t, err)
+
+	examples := i.discardedSamplesExamples.Examples("test")
+	require.Len(t, examples, 1)
+	assert.Equal(t, sampleOutOfOrder, examples[0].Reason)
+	assert.Equal(t, `{__name__="test"}`, examples[0].Labels)
+	assert.Equal(t, int64(9), examples[0].TimestampMs)
+}
+
+func TestIngester_PushMultiTenant(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	series := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test"))
+	writeReq := func(ts int64) *mimirpb.WriteRequest {
+		return mimirpb.ToWriteRequest([][]mimirpb.LabelAdapter{series}, []mimirpb.Sample{{TimestampMs: ts, Value: 1}}, nil, nil, mimirpb.API)
+	}
+
+	ctx := user.InjectOrgID(context.Background(), tenant.JoinTenantIDs([]string{"user-1", "user-2"}))
+	resp, err := i.PushMultiTenant(ctx, &client.MultiTenantWriteRequest{Requests: []*client.TenantWriteRequest{
+		{TenantId: "user-1", Request: writeReq(10)},
+		{TenantId: "user-2", Request: writeReq(10)},
+		// Out of order sample.
+		{TenantId: "user-1", Request: writeReq(5)},
+		// The call has not been authenticated for this tenant.
+		{TenantId: "user-3", Request: writeReq(10)},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, 4)
+
+	assert.NoError(t, resp.Responses[0].Err())
+	assert.NoError(t, resp.Responses[1].Err())
+
+	errResp, ok := httpgrpc.HTTPResponseFromError(resp.Responses[2].Err())
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), errResp.Code)
+
+	errResp, ok = httpgrpc.HTTPResponseFromError(resp.Responses[3].Err())
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusUnauthorized), errResp.Code)
+
+	// Only the tenants of the call have been ingested.
+	assert.NotNil(t, i.getTSDB("user-1"))
+	assert.NotNil(t, i.getTSDB("user-2"))
+	assert.Nil(t, i.getTSDB("user-3"))
+}
+
 func TestIngester_getOrCreateTSDB_ShouldNotAllowToCreateTSDBIfIngesterStateIsNotActive(t *testing.T) {
 	tests := map[string]struct {
 		state       ring.InstanceState
@@ -2886,6 +3256,67 @@ func TestIngester_QueryStream(t *testing.T) {
 	})
 }
 
+func TestIngester_QueryStream_ShouldTrackMetricsByQuerySource(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy.
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "foo"), 1, 1)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	// Create a GRPC server used to query back the data.
+	serv := grpc.NewServer(grpc.ChainStreamInterceptor(middleware.StreamServerUserHeaderInterceptor, querysource.ServerStreamInterceptor))
+	defer serv.GracefulStop()
+	client.RegisterIngesterServer(serv, i)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, serv.Serve(listener))
+	}()
+
+	c, err := client.MakeIngesterClient(listener.Addr().String(), defaultClientTestConfig())
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, source := range []string{querysource.API, querysource.Ruler, querysource.Ruler, querysource.Alerting} {
+		s, err := c.QueryStream(querysource.ContextWithSource(ctx, source), &client.QueryRequest{
+			StartTimestampMs: math.MinInt64,
+			EndTimestampMs:   math.MaxInt64,
+			Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+		})
+		require.NoError(t, err)
+
+		for {
+			_, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+		}
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_queries_total The total number of queries the ingester has handled.
+		# TYPE cortex_ingester_queries_total counter
+		cortex_ingester_queries_total{source="alerting"} 1
+		cortex_ingester_queries_total{source="api"} 1
+		cortex_ingester_queries_total{source="ruler"} 2
+	`), "cortex_ingester_queries_total"))
+}
+
 func TestIngester_QueryStream_TimeseriesWithManySamples(t *testing.T) {
 	// Create ingester.
 	cfg := defaultIngesterTestConfig(t)
@@ -3026,6 +3457,60 @@ func setupQueryingManySamplesAsChunksTest(ctx context.Context, t *testing.T, cfg
 	return c
 }
 
+func TestIngester_SamplesPerChunk(t *testing.T) {
+	const samplesCount = 960
+
+	limits := defaultLimitsTestConfig()
+	tenantLimits := map[string]*validation.Limits{
+		"user-2": func() *validation.Limits {
+			l := defaultLimitsTestConfig()
+			l.SamplesPerChunk = 240
+			return &l
+		}(),
+	}
+
+	overrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, defaultIngesterTestConfig(t), overrides, "", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	samples := make([]mimirpb.Sample, 0, samplesCount)
+	for s := 0; s < samplesCount; s++ {
+		samples = append(samples, mimirpb.Sample{Value: float64(s), TimestampMs: int64(s) * 1000})
+	}
+
+	for userID, expectedChunks := range map[string]int{"user-1": samplesCount / validation.DefaultSamplesPerChunk, "user-2": samplesCount / 240} {
+		ctx := user.InjectOrgID(context.Background(), userID)
+		_, err = i.Push(ctx, writeRequestSingleSeries(labels.FromStrings(labels.MetricName, "foo"), samples))
+		require.NoError(t, err)
+
+		q, err := i.getTSDB(userID).ChunkQuerier(ctx, math.MinInt64, math.MaxInt64)
+		require.NoError(t, err)
+
+		set := q.Select(false, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo"))
+		require.True(t, set.Next())
+
+		actualChunks := 0
+		it := set.At().Iterator(nil)
+		for it.Next() {
+			actualChunks++
+		}
+		require.NoError(t, it.Err())
+		require.NoError(t, q.Close())
+
+		// The TSDB cuts chunks based on a prediction of when the target number of samples is reached,
+		// so the actual number of chunks can slightly differ from the expected one.
+		assert.InDelta(t, expectedChunks, actualChunks, 1, userID)
+	}
+}
+
 func generateSamples(sampleCount int) []mimirpb.Sample {
 	samples := make([]mimirpb.Sample, 0, sampleCount)
 
@@ -3953,6 +4438,173 @@ func TestIngester_dontShipBlocksWhenTenantDeletionMarkerIsPresent(t *testing.T)
 	require.Equal(t, tsdbTenantMarkedForDeletion, i.closeAndDeleteUserTSDBIfIdle(userID))
 }
 
+func TestIngester_EphemeralSeries(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.EphemeralSeriesRetentionPeriod = 10 * time.Minute
+
+	limits := defaultLimitsTestConfig()
+	limits.EphemeralSeriesSelectors = []string{`{job="ci"}`, `{__name__="scratch"}`}
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+
+	push := func(lbls labels.Labels, ts time.Time) {
+		req, _, _, _ := mockWriteRequest(t, lbls, 1, util.TimeToMillis(ts))
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	push(labels.FromStrings(labels.MetricName, "builds", "job", "ci"), now)
+	push(labels.FromStrings(labels.MetricName, "scratch", "job", "prod"), now)
+	push(labels.FromStrings(labels.MetricName, "requests", "job", "prod"), now)
+
+	db := i.getTSDB(userID)
+	require.NotNil(t, db)
+	assert.Equal(t, uint64(1), db.Head().NumSeries())
+	assert.Equal(t, uint64(2), db.ephemeralNumSeries())
+	assert.Equal(t, int64(3), i.seriesCount.Load())
+
+	querySeries := func() []string {
+		q, err := db.Querier(ctx, math.MinInt64, math.MaxInt64)
+		require.NoError(t, err)
+		defer q.Close()
+
+		var names []string
+		ss := q.Select(true, nil, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+"))
+		for ss.Next() {
+			names = append(names, ss.At().Labels().Get(labels.MetricName))
+		}
+		require.NoError(t, ss.Err())
+		return names
+	}
+
+	// Both persistent and ephemeral series are queryable.
+	assert.Equal(t, []string{"builds", "requests", "scratch"}, querySeries())
+
+	// Ephemeral series are not compacted into blocks.
+	i.compactBlocks(ctx, true, nil)
+	assert.Equal(t, uint64(0), db.Head().NumSeries())
+	assert.Equal(t, uint64(2), db.ephemeralNumSeries())
+	assert.Equal(t, []string{"builds", "requests", "scratch"}, querySeries())
+
+	// Ephemeral series older than the retention period are removed.
+	push(labels.FromStrings(labels.MetricName, "builds", "job", "ci"), now.Add(15*time.Minute))
+	i.compactBlocks(ctx, false, nil)
+	assert.Equal(t, uint64(1), db.ephemeralNumSeries())
+	assert.Equal(t, int64(1), i.seriesCount.Load())
+	assert.Equal(t, []string{"builds", "requests"}, querySeries())
+}
+
+func TestIngester_EphemeralSeries_IdleTSDB(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.EphemeralSeriesRetentionPeriod = 10 * time.Minute
+
+	// We want it to be idle immediately (setting to 1ns because 0 means disabled).
+	cfg.BlocksStorageConfig.TSDB.CloseIdleTSDBTimeout = time.Nanosecond
+
+	limits := defaultLimitsTestConfig()
+	limits.EphemeralSeriesSelectors = []string{`{job="ci"}`}
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+
+	req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "builds", "job", "ci"), 1, util.TimeToMillis(now.Add(-5*time.Minute)))
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	db := i.getTSDB(userID)
+	require.NotNil(t, db)
+	require.Equal(t, uint64(0), db.Head().NumSeries())
+	require.Equal(t, uint64(1), db.ephemeralNumSeries())
+	db.setLastUpdate(now.Add(-time.Hour))
+
+	// The TSDB should not be closed while its ephemeral series are within the retention period.
+	assert.Equal(t, tsdbNotCompacted, i.closeAndDeleteUserTSDBIfIdle(userID))
+	require.Equal(t, uint64(1), db.ephemeralNumSeries())
+
+	// Compacting an idle TSDB should expire the ephemeral series relative to the current time, and not
+	// to the latest ephemeral sample, because no more samples are pushed.
+	require.NoError(t, db.truncateEphemeralHead(now.Add(6*time.Minute), false))
+	require.Equal(t, uint64(1), db.ephemeralNumSeries())
+	require.NoError(t, db.truncateEphemeralHead(now.Add(6*time.Minute), true))
+	require.Equal(t, uint64(0), db.ephemeralNumSeries())
+
+	// Once the ephemeral series have expired, the TSDB can be closed.
+	assert.Equal(t, tsdbIdleClosed, i.closeAndDeleteUserTSDBIfIdle(userID))
+	assert.Nil(t, i.getTSDB(userID))
+}
+
+func TestUserTSDB_splitEphemeralSeries(t *testing.T) {
+	series := func(names ...string) []mimirpb.PreallocTimeseries {
+		out := make([]mimirpb.PreallocTimeseries, 0, len(names))
+		for _, name := range names {
+			out = append(out, mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+				Labels: []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: name}},
+			}})
+		}
+		return out
+	}
+
+	tests := map[string]struct {
+		selectors          []string
+		input              []mimirpb.PreallocTimeseries
+		expectedPersistent []mimirpb.PreallocTimeseries
+		expectedEphemeral  []mimirpb.PreallocTimeseries
+	}{
+		"no series matching": {
+			selectors:          []string{`{__name__="scratch"}`},
+			input:              series("a", "b"),
+			expectedPersistent: series("a", "b"),
+		},
+		"some series matching": {
+			selectors:          []string{`{__name__="scratch"}`, `{__name__=~"tmp_.*"}`},
+			input:              series("a", "scratch", "b", "tmp_1"),
+			expectedPersistent: series("a", "b"),
+			expectedEphemeral:  series("scratch", "tmp_1"),
+		},
+		"all series matching": {
+			selectors:         []string{`{__name__=~".+"}`},
+			input:             series("a", "b"),
+			expectedEphemeral: series("a", "b"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := &userTSDB{}
+			persistent, ephemeral := db.splitEphemeralSeries(tc.selectors, tc.input)
+			assert.Equal(t, len(tc.expectedPersistent), len(persistent))
+			for idx := range tc.expectedPersistent {
+				assert.Equal(t, tc.expectedPersistent[idx].Labels, persistent[idx].Labels)
+			}
+			assert.Equal(t, len(tc.expectedEphemeral), len(ephemeral))
+			for idx := range tc.expectedEphemeral {
+				assert.Equal(t, tc.expectedEphemeral[idx].Labels, ephemeral[idx].Labels)
+			}
+		})
+	}
+}
+
 func TestIngester_seriesCountIsCorrectAfterClosingTSDBForDeletedTenant(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.BlocksStorageConfig.TSDB.ShipConcurrency = 2
@@ -4742,6 +5394,190 @@ func TestIngesterCompactIdleBlock(t *testing.T) {
     `), "cortex_ingester_memory_series_created_total", "cortex_ingester_memory_series_removed_total", "cortex_ingester_memory_users"))
 }
 
+func TestIngesterCompactHeadEarly(t *testing.T) {
+	const userID = "1"
+	blockRange := 2 * time.Hour
+
+	tests := map[string]struct {
+		threshold              int
+		expectedBlocks         int
+		expectedHeadMinTime    int64
+		expectedEarlyCompacted float64
+	}{
+		"early compaction disabled": {
+			threshold:           0,
+			expectedBlocks:      0,
+			expectedHeadMinTime: 0,
+		},
+		"head series below the threshold": {
+			threshold:           100,
+			expectedBlocks:      0,
+			expectedHeadMinTime: 0,
+		},
+		"head series above the threshold": {
+			threshold:              10,
+			expectedBlocks:         1,
+			expectedHeadMinTime:    (90 * time.Minute).Milliseconds(),
+			expectedEarlyCompacted: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.BlocksStorageConfig.TSDB.BlockRanges = []time.Duration{blockRange}
+
+			limits := defaultLimitsTestConfig()
+			limits.HeadEarlyCompactionSeriesBytes = testData.threshold
+
+			reg := prometheus.NewPedanticRegistry()
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", reg)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+
+			// Push samples spanning 2h45m, which isn't enough for the regular compaction to compact the head.
+			ctx := user.InjectOrgID(context.Background(), userID)
+			for ts := time.Duration(0); ts <= 2*time.Hour+45*time.Minute; ts += 15 * time.Minute {
+				req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test"), 0, ts.Milliseconds())
+				_, err := i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+
+			db := i.getTSDB(userID)
+			require.NotNil(t, db)
+			assert.Equal(t, int64(len(labels.MetricName)+len("test")), db.headSeriesBytes.Load())
+
+			i.compactBlocks(context.Background(), false, nil)
+
+			// The compacted blocks don't span across the block ranges, and the most recent samples are kept in the head.
+			blocks := db.Blocks()
+			require.Len(t, blocks, testData.expectedBlocks)
+			for _, b := range blocks {
+				assert.Equal(t, b.MinTime()/blockRange.Milliseconds(), (b.MaxTime()-1)/blockRange.Milliseconds())
+			}
+			assert.Equal(t, testData.expectedHeadMinTime, db.Head().MinTime())
+			assert.Equal(t, testData.expectedEarlyCompacted, testutil.ToFloat64(i.metrics.earlyCompactions))
+
+			// Compacting again doesn't compact anything else, because the samples left in the head are too recent.
+			i.compactBlocks(context.Background(), false, nil)
+			require.Len(t, db.Blocks(), testData.expectedBlocks)
+			assert.Equal(t, testData.expectedEarlyCompacted, testutil.ToFloat64(i.metrics.earlyCompactions))
+		})
+	}
+}
+
+func TestIngesterCompactHeadEarly_ShouldCompactAgainOnceTheHeadSeriesDroppedBelowTheThreshold(t *testing.T) {
+	const userID = "1"
+	blockRange := 2 * time.Hour
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.BlockRanges = []time.Duration{blockRange}
+
+	limits := defaultLimitsTestConfig()
+	limits.HeadEarlyCompactionSeriesBytes = 10
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	push := func(from, to time.Duration) {
+		for ts := from; ts <= to; ts += 15 * time.Minute {
+			req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test"), 0, ts.Milliseconds())
+			_, err := i.Push(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+
+	push(0, 2*time.Hour+45*time.Minute)
+	i.compactBlocks(context.Background(), false, nil)
+	require.Equal(t, 1.0, testutil.ToFloat64(i.metrics.earlyCompactions))
+
+	// The head isn't compacted early again while the head series don't drop below the threshold, even if
+	// the samples left in the head are old enough.
+	push(3*time.Hour, 3*time.Hour+30*time.Minute)
+	i.compactBlocks(context.Background(), false, nil)
+	require.Equal(t, 1.0, testutil.ToFloat64(i.metrics.earlyCompactions))
+
+	// The head series drop below the threshold, like when the series are removed from the head.
+	db := i.getTSDB(userID)
+	seriesBytes := db.headSeriesBytes.Load()
+	db.headSeriesBytes.Store(0)
+	i.compactBlocks(context.Background(), false, nil)
+	require.Equal(t, 1.0, testutil.ToFloat64(i.metrics.earlyCompactions))
+
+	// Once above the threshold again, the head is compacted early again, without spanning across the block ranges.
+	db.headSeriesBytes.Store(seriesBytes)
+	i.compactBlocks(context.Background(), false, nil)
+	require.Equal(t, 2.0, testutil.ToFloat64(i.metrics.earlyCompactions))
+	require.Len(t, db.Blocks(), 3)
+	assert.Equal(t, (150 * time.Minute).Milliseconds(), db.Head().MinTime())
+}
+
+func TestIngesterCompactHeadEarly_ShouldAcceptPushesWhileCompacting(t *testing.T) {
+	const userID = "1"
+	blockRange := 2 * time.Hour
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.BlockRanges = []time.Duration{blockRange}
+
+	limits := defaultLimitsTestConfig()
+	limits.HeadEarlyCompactionSeriesBytes = 10
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for ts := time.Duration(0); ts <= 2*time.Hour+45*time.Minute; ts += 15 * time.Minute {
+		req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test"), 0, ts.Milliseconds())
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// Push the most recent samples while the head is compacted early.
+	stop := make(chan struct{})
+	pushed := make(chan int64)
+	go func() {
+		ts := (2*time.Hour + 45*time.Minute).Milliseconds()
+		defer func() { pushed <- ts }()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test"), 0, ts+1)
+			if _, err := i.Push(ctx, req); err != nil {
+				assert.NoError(t, err)
+				return
+			}
+			ts++
+		}
+	}()
+
+	i.compactBlocks(context.Background(), false, nil)
+	close(stop)
+	lastPushed := <-pushed
+
+	db := i.getTSDB(userID)
+	require.Equal(t, 1.0, testutil.ToFloat64(i.metrics.earlyCompactions))
+	require.Len(t, db.Blocks(), 1)
+	assert.Equal(t, (90 * time.Minute).Milliseconds(), db.Head().MinTime())
+	assert.Equal(t, lastPushed, db.Head().MaxTime())
+}
+
 func TestIngesterCompactAndCloseIdleTSDB(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.BlocksStorageConfig.TSDB.ShipInterval = 1 * time.Second // Required to enable shipping.
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// instanceLimitsOverridesFilename is the name of the file, within the TSDB directory, where the
+// instance limits overrides set via the API are persisted.
+const instanceLimitsOverridesFilename = "instance-limits-overrides.yaml"
+
+// instanceLimitsOverrides are the instance limits set at run-time via the API. They take precedence
+// over the instance limits set in the runtime configuration or via CLI flags. Unset fields don't
+// override the configured limits.
+type instanceLimitsOverrides struct {
+	MaxIngestionRate        *float64 `yaml:"max_ingestion_rate,omitempty"`
+	MaxInMemoryTenants      *int64   `yaml:"max_tenants,omitempty"`
+	MaxInMemorySeries       *int64   `yaml:"max_series,omitempty"`
+	MaxInflightPushRequests *int64   `yaml:"max_inflight_push_requests,omitempty"`
+}
+
+func (o *instanceLimitsOverrides) validate() error {
+	if o.MaxIngestionRate != nil && *o.MaxIngestionRate < 0 {
+		return errors.New("max_ingestion_rate must be greater than or equal to 0")
+	}
+	if o.MaxInMemoryTenants != nil && *o.MaxInMemoryTenants < 0 {
+		return errors.New("max_tenants must be greater than or equal to 0")
+	}
+	if o.MaxInMemorySeries != nil && *o.MaxInMemorySeries < 0 {
+		return errors.New("max_series must be greater than or equal to 0")
+	}
+	if o.MaxInflightPushRequests != nil && *o.MaxInflightPushRequests < 0 {
+		return errors.New("max_inflight_push_requests must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// merge returns a copy of o with the fields set in other replacing the ones of o.
+func (o instanceLimitsOverrides) merge(other instanceLimitsOverrides) instanceLimitsOverrides {
+	if other.MaxIngestionRate != nil {
+		o.MaxIngestionRate = other.MaxIngestionRate
+	}
+	if other.MaxInMemoryTenants != nil {
+		o.MaxInMemoryTenants = other.MaxInMemoryTenants
+	}
+	if other.MaxInMemorySeries != nil {
+		o.MaxInMemorySeries = other.MaxInMemorySeries
+	}
+	if other.MaxInflightPushRequests != nil {
+		o.MaxInflightPushRequests = other.MaxInflightPushRequests
+	}
+	return o
+}
+
+// apply returns a copy of the limits with the overrides applied.
+func (o *instanceLimitsOverrides) apply(l InstanceLimits) InstanceLimits {
+	if o.MaxIngestionRate != nil {
+		l.MaxIngestionRate = *o.MaxIngestionRate
+	}
+	if o.MaxInMemoryTenants != nil {
+		l.MaxInMemoryTenants = *o.MaxInMemoryTenants
+	}
+	if o.MaxInMemorySeries != nil {
+		l.MaxInMemorySeries = *o.MaxInMemorySeries
+	}
+	if o.MaxInflightPushRequests != nil {
+		l.MaxInflightPushRequests = *o.MaxInflightPushRequests
+	}
+	return l
+}
+
+// overriddenInstanceLimits are the limits resulting from applying the overrides to the configured limits.
+type overriddenInstanceLimits struct {
+	configured *InstanceLimits
+	overrides  *instanceLimitsOverrides
+	limits     *InstanceLimits
+}
+
+// readInstanceLimitsOverrides reads the instance limits overrides persisted at the given path.
+// It returns nil if the file doesn't exist.
+func readInstanceLimitsOverrides(p string) (*instanceLimitsOverrides, error) {
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	o := &instanceLimitsOverrides{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(o); err != nil {
+		return nil, err
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// writeInstanceLimitsOverrides persists the instance limits overrides at the given path. The file is
+// written to a temporary file first and then renamed, so that it's never left partially written.
+func writeInstanceLimitsOverrides(p string, o instanceLimitsOverrides) error {
+	data, err := yaml.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	tmp := p + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	merr := multierror.New()
+	_, err = file.Write(data)
+	merr.Add(err)
+	merr.Add(file.Sync())
+	merr.Add(file.Close())
+	if err := merr.Err(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p)
+}
+
+// removeInstanceLimitsOverrides removes the instance limits overrides persisted at the given path, if any.
+func removeInstanceLimitsOverrides(p string) error {
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (i *Ingester) instanceLimitsOverridesPath() string {
+	return filepath.Join(i.cfg.BlocksStorageConfig.TSDB.Dir, instanceLimitsOverridesFilename)
+}
+
+// instanceLimitsResponse is the response of the instance limits API.
+type instanceLimitsResponse struct {
+	EffectiveLimits InstanceLimits          `yaml:"effective_limits"`
+	Overrides       instanceLimitsOverrides `yaml:"overrides"`
+}
+
+// InstanceLimitsHandler inspects or changes the instance limits of the ingester at run-time,
+// without restarting it. The overrides are persisted on disk and re-applied if the ingester
+// restarts, until they're removed.
+//
+// * `GET` shows the effective instance limits and the overrides
+// * `POST` sets the overrides in the YAML request body, keeping the other overrides unchanged
+// * `DELETE` removes all the overrides
+func (i *Ingester) InstanceLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	// Don't allow callers to change the instance limits while we're in the middle
+	// of starting or shutting down.
+	if i.State() != services.Running {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	i.instanceLimitsOverridesMtx.Lock()
+	defer i.instanceLimitsOverridesMtx.Unlock()
+
+	overridesPath := i.instanceLimitsOverridesPath()
+	switch r.Method {
+	case http.MethodGet:
+		resp := instanceLimitsResponse{}
+		if l := i.getInstanceLimits(); l != nil {
+			resp.EffectiveLimits = *l
+		}
+		if o := i.instanceLimitsOverrides.Load(); o != nil {
+			resp.Overrides = *o
+		}
+		util.WriteYAMLResponse(w, resp)
+
+	case http.MethodPost:
+		req := instanceLimitsOverrides{}
+		dec := yaml.NewDecoder(r.Body)
+		dec.KnownFields(true)
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, fmt.Sprintf("invalid instance limits overrides: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := req.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("invalid instance limits overrides: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		overrides := req
+		if o := i.instanceLimitsOverrides.Load(); o != nil {
+			overrides = o.merge(req)
+		}
+
+		if err := writeInstanceLimitsOverrides(overridesPath, overrides); err != nil {
+			level.Error(i.logger).Log("msg", "unable to persist instance limits overrides", "path", overridesPath, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		i.instanceLimitsOverrides.Store(&overrides)
+		level.Info(i.logger).Log("msg", "updated instance limits overrides", "path", overridesPath)
+
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if err := removeInstanceLimitsOverrides(overridesPath); err != nil {
+			level.Error(i.logger).Log("msg", "unable to remove instance limits overrides", "path", overridesPath, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		i.instanceLimitsOverrides.Store(nil)
+		level.Info(i.logger).Log("msg", "removed instance limits overrides", "path", overridesPath)
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
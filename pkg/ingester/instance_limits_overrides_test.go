@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngester_InstanceLimitsHandler(t *testing.T) {
+	dataDir := t.TempDir()
+	limits := &InstanceLimits{MaxInMemoryTenants: 20, MaxInMemorySeries: 30}
+
+	startIngester := func(t *testing.T) (*Ingester, *prometheus.Registry) {
+		reg := prometheus.NewRegistry()
+		cfg := defaultIngesterTestConfig(t)
+		cfg.InstanceLimitsFn = func() *InstanceLimits { return limits }
+
+		i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), dataDir, reg)
+		require.NoError(t, err)
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+		return i, reg
+	}
+
+	call := func(i *Ingester, method, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		i.InstanceLimitsHandler(resp, httptest.NewRequest(method, "/ingester/instance-limits", strings.NewReader(body)))
+		return resp
+	}
+
+	i, reg := startIngester(t)
+
+	resp := call(i, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `effective_limits:
+    max_ingestion_rate: 0
+    max_tenants: 20
+    max_series: 30
+    max_inflight_push_requests: 0
+overrides: {}
+`, resp.Body.String())
+
+	// Invalid overrides are rejected.
+	require.Equal(t, http.StatusBadRequest, call(i, http.MethodPost, "max_series: -1").Code)
+	require.Equal(t, http.StatusBadRequest, call(i, http.MethodPost, "unknown: 1").Code)
+
+	// Overrides are merged with the previous ones.
+	require.Equal(t, http.StatusNoContent, call(i, http.MethodPost, "max_series: 100").Code)
+	require.Equal(t, http.StatusNoContent, call(i, http.MethodPost, "max_ingestion_rate: 10.5").Code)
+
+	resp = call(i, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `effective_limits:
+    max_ingestion_rate: 10.5
+    max_tenants: 20
+    max_series: 100
+    max_inflight_push_requests: 0
+overrides:
+    max_ingestion_rate: 10.5
+    max_series: 100
+`, resp.Body.String())
+
+	expectedMetrics := `
+		# HELP cortex_ingester_instance_limits Instance limits used by this ingester.
+		# TYPE cortex_ingester_instance_limits gauge
+		cortex_ingester_instance_limits{limit="max_inflight_push_requests"} 0
+		cortex_ingester_instance_limits{limit="max_ingestion_rate"} 10.5
+		cortex_ingester_instance_limits{limit="max_series"} 100
+		cortex_ingester_instance_limits{limit="max_tenants"} 20
+	`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_ingester_instance_limits"))
+
+	// The overrides are not applied again until the limits change.
+	assert.Same(t, i.getInstanceLimits(), i.getInstanceLimits())
+
+	// Changes to the configured limits don't affect the overridden ones. The runtime configuration
+	// replaces the limits on changes, instead of changing them in place.
+	limits = &InstanceLimits{MaxInMemoryTenants: 50, MaxInMemorySeries: 40}
+	assert.Equal(t, &InstanceLimits{MaxIngestionRate: 10.5, MaxInMemoryTenants: 50, MaxInMemorySeries: 100}, i.getInstanceLimits())
+
+	require.Equal(t, http.StatusNoContent, call(i, http.MethodPost, "max_tenants: 60").Code)
+	assert.Equal(t, &InstanceLimits{MaxIngestionRate: 10.5, MaxInMemoryTenants: 60, MaxInMemorySeries: 100}, i.getInstanceLimits())
+
+	// The overrides are re-applied after a restart.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	require.Equal(t, http.StatusServiceUnavailable, call(i, http.MethodGet, "").Code)
+	require.FileExists(t, filepath.Join(dataDir, instanceLimitsOverridesFilename))
+
+	i, _ = startIngester(t)
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+	assert.Equal(t, &InstanceLimits{MaxIngestionRate: 10.5, MaxInMemoryTenants: 60, MaxInMemorySeries: 100}, i.getInstanceLimits())
+
+	// Removing the overrides restores the configured limits.
+	require.Equal(t, http.StatusNoContent, call(i, http.MethodDelete, "").Code)
+	assert.Same(t, limits, i.getInstanceLimits())
+	assert.NoFileExists(t, filepath.Join(dataDir, instanceLimitsOverridesFilename))
+}
@@ -4,9 +4,13 @@ package ingester
 
 import (
 	"context"
+	"math"
 	"sync"
 
+	"github.com/pkg/errors"
+
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"go.uber.org/atomic"
@@ -16,6 +20,14 @@ import (
 
 const (
 	checkContextErrorSeriesCount = 1000 // series count interval in which context cancellation must be checked.
+
+	// approximatedCardinalityExactSeries is the number of matching series which are always counted when the label
+	// values cardinality is approximated, so that the sampling only kicks in for a large number of series.
+	approximatedCardinalityExactSeries = 10_000
+
+	// approximatedCardinalitySamplingFactor defines the fraction of series sampled, beyond the exact ones, when the
+	// label values cardinality is approximated: 1 out of approximatedCardinalitySamplingFactor series is sampled.
+	approximatedCardinalitySamplingFactor = 100
 )
 
 type labelValueCountResult struct {
@@ -246,3 +258,146 @@ func countLabelValueSeries(
 	}
 	return count, nil
 }
+
+// approximatedLabelValuesCardinality estimates the series count of each value of the lbNames labels for the series
+// matching the matchers. Instead of looking up the postings of each label value, it reads the labels of the first
+// approximatedCardinalityExactSeries matching series and of a sample of the remaining ones, and then scales the
+// series counts of the sample up to the number of remaining series. The cost is one pass over the postings of the
+// matchers, regardless of the number of label values. Messages are immediately sent as soon they reach message size
+// threshold.
+func approximatedLabelValuesCardinality(
+	lbNames []string,
+	matchers []*labels.Matcher,
+	idxReader tsdb.IndexReader,
+	postingsForMatchersFn func(tsdb.IndexPostingsReader, ...*labels.Matcher) (index.Postings, error),
+	msgSizeThreshold int,
+	srv client.Ingester_LabelValuesCardinalityServer,
+) error {
+	ctx := srv.Context()
+
+	if len(matchers) == 0 {
+		// Select all series.
+		matchers = []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "", "")}
+	}
+
+	p, err := postingsForMatchersFn(idxReader, matchers...)
+	if err != nil {
+		return err
+	}
+
+	// Series counts by label name and value, for the exact series and for the sampled ones.
+	exactCounts := make(map[string]map[string]uint64, len(lbNames))
+	sampledCounts := make(map[string]map[string]uint64, len(lbNames))
+
+	var (
+		seriesCount        uint64
+		sampledSeriesCount uint64
+		builder            labels.ScratchBuilder
+	)
+
+	for p.Next() {
+		seriesCount++
+		if seriesCount%checkContextErrorSeriesCount == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		sampled := seriesCount > approximatedCardinalityExactSeries
+		if sampled && !isSampledSeries(p.At()) {
+			continue
+		}
+
+		if err := idxReader.Series(p.At(), &builder, nil); err != nil {
+			// The series may have been garbage collected in the meanwhile.
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+
+		counts := exactCounts
+		if sampled {
+			counts = sampledCounts
+			sampledSeriesCount++
+		}
+
+		lbls := builder.Labels()
+		for _, lblName := range lbNames {
+			lblValue := lbls.Get(lblName)
+			if lblValue == "" {
+				continue
+			}
+			if counts[lblName] == nil {
+				counts[lblName] = map[string]uint64{}
+			}
+			counts[lblName][lblValue]++
+		}
+	}
+	if p.Err() != nil {
+		return p.Err()
+	}
+
+	// Scale the series counts of the sample up to the number of series which have not been counted exactly.
+	scale := float64(0)
+	if sampledSeriesCount > 0 && seriesCount > approximatedCardinalityExactSeries {
+		scale = float64(seriesCount-approximatedCardinalityExactSeries) / float64(sampledSeriesCount)
+	}
+	for lblName, valuesCounts := range sampledCounts {
+		if exactCounts[lblName] == nil {
+			exactCounts[lblName] = make(map[string]uint64, len(valuesCounts))
+		}
+		for lblValue, count := range valuesCounts {
+			exactCounts[lblName][lblValue] += uint64(math.Round(float64(count) * scale))
+		}
+	}
+
+	resp := client.LabelValuesCardinalityResponse{}
+	respSize := 0
+
+	for _, lblName := range lbNames {
+		valuesCounts := exactCounts[lblName]
+		if len(valuesCounts) == 0 {
+			continue
+		}
+
+		var respItem *client.LabelValueSeriesCount
+		for lblValue, count := range valuesCounts {
+			if respItem == nil {
+				respItem = &client.LabelValueSeriesCount{
+					LabelName:        lblName,
+					LabelValueSeries: make(map[string]uint64),
+				}
+				resp.Items = append(resp.Items, respItem)
+			}
+
+			respItem.LabelValueSeries[lblValue] = count
+
+			respSize += len(lblValue)
+			if respSize < msgSizeThreshold {
+				continue
+			}
+			// Flush the response when reached message threshold.
+			if err := client.SendLabelValuesCardinalityResponse(srv, &resp); err != nil {
+				return err
+			}
+			resp.Items = resp.Items[:0]
+			respSize = 0
+			respItem = nil
+		}
+	}
+	// Send response in case there are any pending items.
+	if len(resp.Items) > 0 {
+		return client.SendLabelValuesCardinalityResponse(srv, &resp)
+	}
+	return nil
+}
+
+// isSampledSeries returns whether the series with the given reference is part of the sample used to approximate
+// the label values cardinality. The reference is hashed, so that series created one after the other (e.g. the
+// series exported by the same target) are not sampled together.
+func isSampledSeries(ref storage.SeriesRef) bool {
+	// Fibonacci hashing.
+	hash := uint64(ref) * 11400714819323198485
+	return (hash>>32)%approximatedCardinalitySamplingFactor == 0
+}
@@ -217,23 +217,80 @@ func TestIngester_LabelValuesCardinality_AllValuesToBeReturnedInSingleMessage(t
 				}
 			}
 
-			mockServer := &mockLabelValuesCardinalityServer{context: ctx}
-			req := &client.LabelValuesCardinalityRequest{
-				LabelNames: tCfg.labels,
-				Matchers:   tCfg.matchers,
-			}
-			err := in.LabelValuesCardinality(req, mockServer)
-			require.NoError(t, err)
-			if tCfg.expectedItems == nil {
-				require.Empty(t, mockServer.SentResponses)
-				return
+			// The approximated cardinality is exact for a small number of series.
+			for _, approximate := range []bool{false, true} {
+				t.Run(fmt.Sprintf("approximate=%t", approximate), func(t *testing.T) {
+					mockServer := &mockLabelValuesCardinalityServer{context: ctx}
+					req := &client.LabelValuesCardinalityRequest{
+						LabelNames:  tCfg.labels,
+						Matchers:    tCfg.matchers,
+						Approximate: approximate,
+					}
+					err := in.LabelValuesCardinality(req, mockServer)
+					require.NoError(t, err)
+					if tCfg.expectedItems == nil {
+						require.Empty(t, mockServer.SentResponses)
+						return
+					}
+					require.Len(t, mockServer.SentResponses, 1)
+					require.Equal(t, tCfg.expectedItems, mockServer.SentResponses[0].Items)
+				})
 			}
-			require.Len(t, mockServer.SentResponses, 1)
-			require.Equal(t, tCfg.expectedItems, mockServer.SentResponses[0].Items)
 		})
 	}
 }
 
+func TestIngester_LabelValuesCardinality_Approximated(t *testing.T) {
+	const numSeries = 50_000
+
+	in := prepareHealthyIngester(t)
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	samples := []mimirpb.Sample{{TimestampMs: 1_000, Value: 1}}
+	writeReq := &mimirpb.WriteRequest{Source: mimirpb.API}
+	for s := 0; s < numSeries; s++ {
+		writeReq.Timeseries = append(writeReq.Timeseries, mimirpb.PreallocTimeseries{
+			TimeSeries: &mimirpb.TimeSeries{
+				Labels: mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(
+					labels.MetricName, "metric",
+					"series", strconv.Itoa(s),
+					"mod_4", strconv.Itoa(s%4))),
+				Samples: samples,
+			},
+		})
+	}
+	_, err := in.Push(ctx, writeReq)
+	require.NoError(t, err)
+
+	mockServer := &mockLabelValuesCardinalityServer{context: ctx}
+	req := &client.LabelValuesCardinalityRequest{
+		LabelNames:  []string{labels.MetricName, "mod_4"},
+		Approximate: true,
+	}
+	require.NoError(t, in.LabelValuesCardinality(req, mockServer))
+
+	counts := map[string]map[string]uint64{}
+	for _, resp := range mockServer.SentResponses {
+		for _, item := range resp.Items {
+			if counts[item.LabelName] == nil {
+				counts[item.LabelName] = map[string]uint64{}
+			}
+			for value, count := range item.LabelValueSeries {
+				counts[item.LabelName][value] += count
+			}
+		}
+	}
+
+	// The count of a label value shared by all series is exact.
+	require.Equal(t, map[string]uint64{"metric": numSeries}, counts[labels.MetricName])
+
+	// The count of the other label values is estimated.
+	require.Len(t, counts["mod_4"], 4)
+	for value, count := range counts["mod_4"] {
+		require.InEpsilon(t, numSeries/4, count, 0.1, "label value %s", value)
+	}
+}
+
 func TestLabelNamesAndValues_ContextCancellation(t *testing.T) {
 	cctx, cancel := context.WithCancel(context.Background())
 
@@ -375,17 +432,20 @@ func BenchmarkIngester_LabelValuesCardinality(b *testing.B) {
 			},
 		},
 	} {
-		b.Run(bc.name, func(b *testing.B) {
-			for i := 0; i < b.N; i++ {
-				req := &client.LabelValuesCardinalityRequest{
-					LabelNames: bc.labelNames,
-					Matchers:   bc.matchers,
+		for _, approximate := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s, approximate=%t", bc.name, approximate), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					req := &client.LabelValuesCardinalityRequest{
+						LabelNames:  bc.labelNames,
+						Matchers:    bc.matchers,
+						Approximate: approximate,
+					}
+					mockServer := &mockLabelValuesCardinalityServer{context: ctx}
+					err := in.LabelValuesCardinality(req, mockServer)
+					require.NoError(b, err)
 				}
-				mockServer := &mockLabelValuesCardinalityServer{context: ctx}
-				err := in.LabelValuesCardinality(req, mockServer)
-				require.NoError(b, err)
-			}
-		})
+			})
+		}
 	}
 }
 
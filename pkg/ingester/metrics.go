@@ -24,10 +24,10 @@ type ingesterMetrics struct {
 	ingestedExemplarsFail prometheus.Counter
 	ingestedMetadataFail  prometheus.Counter
 
-	queries          prometheus.Counter
-	queriedSamples   prometheus.Histogram
-	queriedExemplars prometheus.Histogram
-	queriedSeries    prometheus.Histogram
+	queries          *prometheus.CounterVec
+	queriedSamples   *prometheus.HistogramVec
+	queriedExemplars *prometheus.HistogramVec
+	queriedSeries    *prometheus.HistogramVec
 
 	memMetadata             prometheus.Gauge
 	memUsers                prometheus.Gauge
@@ -49,6 +49,7 @@ type ingesterMetrics struct {
 	// Head compactions metrics.
 	compactionsTriggered   prometheus.Counter
 	compactionsFailed      prometheus.Counter
+	earlyCompactions       prometheus.Counter
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
@@ -129,28 +130,28 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_ingested_metadata_failures_total",
 			Help: "The total number of metadata that errored on ingestion.",
 		}),
-		queries: promauto.With(r).NewCounter(prometheus.CounterOpts{
+		queries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_ingester_queries_total",
 			Help: "The total number of queries the ingester has handled.",
-		}),
-		queriedSamples: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+		}, []string{"source"}),
+		queriedSamples: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
 			Name: "cortex_ingester_queried_samples",
 			Help: "The total number of samples returned from queries.",
 			// Could easily return 10m samples per query - 10*(8^(8-1)) = 20.9m.
 			Buckets: prometheus.ExponentialBuckets(10, 8, 8),
-		}),
-		queriedExemplars: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+		}, []string{"source"}),
+		queriedExemplars: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
 			Name: "cortex_ingester_queried_exemplars",
 			Help: "The total number of exemplars returned from queries.",
 			// A reasonable upper bound is around 6k - 10*(5^(5-1)) = 6250.
 			Buckets: prometheus.ExponentialBuckets(10, 5, 5),
-		}),
-		queriedSeries: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+		}, []string{"source"}),
+		queriedSeries: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
 			Name: "cortex_ingester_queried_series",
 			Help: "The total number of series returned from queries.",
 			// A reasonable upper bound is around 100k - 10*(8^(6-1)) = 327k.
 			Buckets: prometheus.ExponentialBuckets(10, 8, 6),
-		}),
+		}, []string{"source"}),
 		memMetadata: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_ingester_memory_metadata",
 			Help: "The current number of metadata in memory.",
@@ -263,6 +264,11 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_tsdb_compactions_failed_total",
 			Help: "Total number of compactions that failed.",
 		}),
+
+		earlyCompactions: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_early_compactions_total",
+			Help: "Total number of head compactions triggered early because the head series of the tenant exceeded the early compaction threshold.",
+		}),
 		appenderAddDuration: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_ingester_tsdb_appender_add_duration_seconds",
 			Help:    "The total time it takes for a push request to add samples to the TSDB appender.",
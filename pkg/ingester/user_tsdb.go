@@ -8,17 +8,22 @@ package ingester
 import (
 	"context"
 	"fmt"
+	"math"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util/extract"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 )
@@ -79,6 +84,8 @@ type userTSDB struct {
 	limiter        *Limiter
 
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
+	headSeriesBytes     atomic.Int64  // Size of the labels of the head series, excluding the ephemeral ones.
+	earlyCompacted      atomic.Bool   // Whether the head has been compacted early since the head series bytes exceeded the threshold.
 	instanceLimitsFn    func() *InstanceLimits
 
 	stateMtx       sync.RWMutex
@@ -108,6 +115,19 @@ type userTSDB struct {
 	// Cached shipped blocks.
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]time.Time
+
+	// In-memory head storing the ephemeral series, created on first use. Ephemeral
+	// series are never compacted into blocks, and are removed once older than the
+	// ephemeral retention period.
+	ephemeralMtx       sync.RWMutex
+	ephemeral          *tsdb.Head
+	ephemeralOpts      *tsdb.HeadOptions
+	ephemeralRetention time.Duration
+
+	// Matchers of the series to store as ephemeral, and the selectors they have been parsed from.
+	ephemeralMatchersMtx sync.Mutex
+	ephemeralSelectors   []string
+	ephemeralMatchers    [][]*labels.Matcher
 }
 
 // Explicitly wrapping the tsdb.DB functions that we use.
@@ -117,16 +137,55 @@ func (u *userTSDB) Appender(ctx context.Context) storage.Appender {
 }
 
 // Querier returns a new querier over the data partition for the given time range.
+// Ephemeral series, if any, are merged into the returned querier.
 func (u *userTSDB) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return u.db.Querier(ctx, mint, maxt)
+	q, err := u.db.Querier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+
+	h := u.ephemeralHead()
+	if h == nil {
+		return q, nil
+	}
+
+	eq, err := tsdb.NewBlockQuerier(tsdb.NewRangeHeadWithIsolationDisabled(h, mint, maxt), mint, maxt)
+	if err != nil {
+		_ = q.Close()
+		return nil, errors.Wrap(err, "failed to create ephemeral series querier")
+	}
+	return storage.NewMergeQuerier([]storage.Querier{q, eq}, nil, storage.ChainedSeriesMerge), nil
 }
 
 func (u *userTSDB) ChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.ChunkQuerier(ctx, mint, maxt)
+	q, err := u.db.ChunkQuerier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return u.mergeEphemeralChunkQuerier(q, mint, maxt, storage.NewCompactingChunkSeriesMerger(storage.ChainedSeriesMerge))
 }
 
 func (u *userTSDB) UnorderedChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.UnorderedChunkQuerier(ctx, mint, maxt)
+	q, err := u.db.UnorderedChunkQuerier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return u.mergeEphemeralChunkQuerier(q, mint, maxt, storage.NewConcatenatingChunkSeriesMerger())
+}
+
+// mergeEphemeralChunkQuerier merges the ephemeral series, if any, into the input querier.
+func (u *userTSDB) mergeEphemeralChunkQuerier(q storage.ChunkQuerier, mint, maxt int64, mergeFn storage.VerticalChunkSeriesMergeFunc) (storage.ChunkQuerier, error) {
+	h := u.ephemeralHead()
+	if h == nil {
+		return q, nil
+	}
+
+	eq, err := tsdb.NewBlockChunkQuerier(tsdb.NewRangeHeadWithIsolationDisabled(h, mint, maxt), mint, maxt)
+	if err != nil {
+		_ = q.Close()
+		return nil, errors.Wrap(err, "failed to create ephemeral series querier")
+	}
+	return storage.NewMergeChunkQuerier([]storage.ChunkQuerier{q, eq}, nil, mergeFn), nil
 }
 
 func (u *userTSDB) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
@@ -142,6 +201,9 @@ func (u *userTSDB) Blocks() []*tsdb.Block {
 }
 
 func (u *userTSDB) Close() error {
+	if err := u.closeEphemeralHead(); err != nil {
+		return err
+	}
 	return u.db.Close()
 }
 
@@ -201,6 +263,34 @@ func (u *userTSDB) compactHead(blockDuration int64) error {
 	return u.db.CompactOOOHead()
 }
 
+// compactHeadUpTo compacts the head samples with timestamp lower than or equal to maxTime into blocks,
+// and keeps the newer samples in the head. The blocks don't span across multiple block ranges, and the
+// out-of-order head isn't compacted. Like the regular head compaction, it runs while the samples are
+// appended, so maxTime must be lower than the min time of the samples which can be appended to the head.
+func (u *userTSDB) compactHeadUpTo(maxTime, blockDuration int64) error {
+	h := u.Head()
+	if minValidTime, ok := h.AppendableMinValidTime(); ok && maxTime >= minValidTime {
+		return fmt.Errorf("TSDB head cannot be compacted up to %d, because samples can still be appended up to it", maxTime)
+	}
+
+	for minTime := h.MinTime(); minTime <= maxTime; {
+		// Block max time is exclusive, so we do a -1 here.
+		blockMaxTime := util_math.Min(((minTime/blockDuration)+1)*blockDuration-1, maxTime)
+		rh := tsdb.NewRangeHeadWithIsolationDisabled(h, minTime, blockMaxTime)
+
+		// No new appends can start within the compacted range, but the ones started previously may still be in progress.
+		h.WaitForAppendersOverlapping(rh.MaxTime())
+
+		if err := u.db.CompactHead(rh); err != nil {
+			return err
+		}
+
+		// The head is truncated up to the block max time, so the head min time always increases.
+		minTime = h.MinTime()
+	}
+	return nil
+}
+
 func (u *userTSDB) PreCreation(metric labels.Labels) error {
 	if u.limiter == nil {
 		return nil
@@ -214,8 +304,8 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		}
 	}
 
-	// Total series limit.
-	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries())); err != nil {
+	// Total series limit. Ephemeral series count towards it too.
+	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries()+u.ephemeralNumSeries())); err != nil {
 		return err
 	}
 
@@ -232,6 +322,11 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 }
 
 func (u *userTSDB) PostCreation(metric labels.Labels) {
+	u.headSeriesBytes.Add(int64(labelsBytes(metric)))
+	u.postCreation(metric)
+}
+
+func (u *userTSDB) postCreation(metric labels.Labels) {
 	u.instanceSeriesCount.Inc()
 
 	metricName, err := extract.MetricNameFromLabels(metric)
@@ -243,6 +338,15 @@ func (u *userTSDB) PostCreation(metric labels.Labels) {
 }
 
 func (u *userTSDB) PostDeletion(metrics ...labels.Labels) {
+	deleted := 0
+	for _, metric := range metrics {
+		deleted += labelsBytes(metric)
+	}
+	u.headSeriesBytes.Sub(int64(deleted))
+	u.postDeletion(metrics...)
+}
+
+func (u *userTSDB) postDeletion(metrics ...labels.Labels) {
 	u.instanceSeriesCount.Sub(int64(len(metrics)))
 
 	for _, metric := range metrics {
@@ -255,6 +359,29 @@ func (u *userTSDB) PostDeletion(metrics ...labels.Labels) {
 	}
 }
 
+// ephemeralSeriesCallback is the series callback of the head storing the ephemeral series. The ephemeral
+// series don't count towards the head series bytes, because they're never compacted into blocks.
+type ephemeralSeriesCallback struct {
+	*userTSDB
+}
+
+func (c ephemeralSeriesCallback) PostCreation(metric labels.Labels) {
+	c.postCreation(metric)
+}
+
+func (c ephemeralSeriesCallback) PostDeletion(metrics ...labels.Labels) {
+	c.postDeletion(metrics...)
+}
+
+// labelsBytes returns the size of the names and values of the labels.
+func labelsBytes(lbls labels.Labels) int {
+	size := 0
+	lbls.Range(func(l labels.Label) {
+		size += len(l.Name) + len(l.Value)
+	})
+	return size
+}
+
 // blocksToDelete filters the input blocks and returns the blocks which are safe to be deleted from the ingester.
 func (u *userTSDB) blocksToDelete(blocks []*tsdb.Block) map[ulid.ULID]struct{} {
 	if u.db == nil {
@@ -356,6 +483,11 @@ func (u *userTSDB) shouldCloseTSDB(idleTimeout time.Duration) tsdbCloseCheckResu
 		return tsdbNotCompacted
 	}
 
+	// Ephemeral series are never compacted, so they're lost once closed. Wait until they've expired.
+	if u.hasUnexpiredEphemeralSeries(time.Now()) {
+		return tsdbNotCompacted
+	}
+
 	// Ensure that all blocks have been shipped.
 	if oldest := u.getOldestUnshippedBlockTime(); oldest > 0 {
 		return tsdbNotShipped
@@ -387,3 +519,178 @@ func (u *userTSDB) acquireAppendLock() error {
 func (u *userTSDB) releaseAppendLock() {
 	u.pushesInFlight.Done()
 }
+
+// ephemeralHead returns the head storing the ephemeral series, or nil if it hasn't been created yet.
+func (u *userTSDB) ephemeralHead() *tsdb.Head {
+	u.ephemeralMtx.RLock()
+	defer u.ephemeralMtx.RUnlock()
+
+	return u.ephemeral
+}
+
+// getOrCreateEphemeralHead returns the head storing the ephemeral series, creating it if it doesn't exist yet.
+func (u *userTSDB) getOrCreateEphemeralHead() (*tsdb.Head, error) {
+	if h := u.ephemeralHead(); h != nil {
+		return h, nil
+	}
+
+	u.ephemeralMtx.Lock()
+	defer u.ephemeralMtx.Unlock()
+
+	// Check again after having acquired the write lock.
+	if u.ephemeral != nil {
+		return u.ephemeral, nil
+	}
+	if u.ephemeralOpts == nil {
+		return nil, errors.New("ephemeral series storage is not configured")
+	}
+
+	// Ephemeral series are never persisted, so any head chunk left by a previous run is stale.
+	if err := os.RemoveAll(u.ephemeralOpts.ChunkDirRoot); err != nil {
+		return nil, errors.Wrap(err, "failed to remove ephemeral series directory")
+	}
+
+	h, err := tsdb.NewHead(nil, nil, nil, nil, u.ephemeralOpts, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ephemeral series head")
+	}
+	if err := h.Init(math.MinInt64); err != nil {
+		_ = h.Close()
+		return nil, errors.Wrap(err, "failed to initialise ephemeral series head")
+	}
+
+	u.ephemeral = h
+	return h, nil
+}
+
+// closeEphemeralHead closes the head storing the ephemeral series, if any, and removes its data from disk.
+func (u *userTSDB) closeEphemeralHead() error {
+	u.ephemeralMtx.Lock()
+	defer u.ephemeralMtx.Unlock()
+
+	if u.ephemeral == nil {
+		return nil
+	}
+
+	if err := u.ephemeral.Close(); err != nil {
+		return errors.Wrap(err, "failed to close ephemeral series head")
+	}
+	u.ephemeral = nil
+
+	return os.RemoveAll(u.ephemeralOpts.ChunkDirRoot)
+}
+
+func (u *userTSDB) ephemeralNumSeries() uint64 {
+	if h := u.ephemeralHead(); h != nil {
+		return h.NumSeries()
+	}
+	return 0
+}
+
+// truncateEphemeralHead removes from the ephemeral series head all samples older than
+// the ephemeral retention period, relative to the latest ephemeral sample, and the
+// series left without samples. If the TSDB is idle, no more samples are pushed, so the
+// retention period is relative to the input time instead.
+func (u *userTSDB) truncateEphemeralHead(now time.Time, idle bool) error {
+	h := u.ephemeralHead()
+	if h == nil || h.NumSeries() == 0 {
+		return nil
+	}
+
+	ref := h.MaxTime()
+	if idle && now.UnixMilli() > ref {
+		ref = now.UnixMilli()
+	}
+	return h.Truncate(ref - u.ephemeralRetention.Milliseconds())
+}
+
+// hasUnexpiredEphemeralSeries returns whether the ephemeral series head has samples within
+// the ephemeral retention period, relative to the input time.
+func (u *userTSDB) hasUnexpiredEphemeralSeries(now time.Time) bool {
+	h := u.ephemeralHead()
+	if h == nil || h.NumSeries() == 0 {
+		return false
+	}
+	return h.MaxTime() >= now.Add(-u.ephemeralRetention).UnixMilli()
+}
+
+// getEphemeralMatchers returns the matchers of the series to store as ephemeral, built
+// from the input selectors. Parsed matchers are cached until selectors change.
+func (u *userTSDB) getEphemeralMatchers(selectors []string) [][]*labels.Matcher {
+	u.ephemeralMatchersMtx.Lock()
+	defer u.ephemeralMatchersMtx.Unlock()
+
+	if slices.Equal(selectors, u.ephemeralSelectors) {
+		return u.ephemeralMatchers
+	}
+
+	matchers := make([][]*labels.Matcher, 0, len(selectors))
+	for _, selector := range selectors {
+		// Selectors have already been validated when loading the limits.
+		if m, err := parser.ParseMetricSelector(selector); err == nil {
+			matchers = append(matchers, m)
+		}
+	}
+
+	u.ephemeralSelectors = slices.Clone(selectors)
+	u.ephemeralMatchers = matchers
+	return matchers
+}
+
+// splitEphemeralSeries splits the input series into the ones to store as persistent series
+// and the ones to store as ephemeral series, according to the input selectors.
+func (u *userTSDB) splitEphemeralSeries(selectors []string, timeseries []mimirpb.PreallocTimeseries) (persistent, ephemeral []mimirpb.PreallocTimeseries) {
+	matchers := u.getEphemeralMatchers(selectors)
+	if len(matchers) == 0 {
+		return timeseries, nil
+	}
+
+	for idx, ts := range timeseries {
+		if !isEphemeralSeries(matchers, ts.Labels) {
+			if ephemeral != nil {
+				persistent = append(persistent, ts)
+			}
+			continue
+		}
+
+		// Allocate only once the first ephemeral series has been found.
+		if ephemeral == nil {
+			persistent = make([]mimirpb.PreallocTimeseries, 0, len(timeseries))
+			persistent = append(persistent, timeseries[:idx]...)
+			ephemeral = make([]mimirpb.PreallocTimeseries, 0, len(timeseries)-idx)
+		}
+		ephemeral = append(ephemeral, ts)
+	}
+
+	if ephemeral == nil {
+		return timeseries, nil
+	}
+	return persistent, ephemeral
+}
+
+// isEphemeralSeries returns whether the series with the input labels matches any of the input selectors' matchers.
+func isEphemeralSeries(selectors [][]*labels.Matcher, lbls []mimirpb.LabelAdapter) bool {
+	for _, matchers := range selectors {
+		if matchesLabelAdapters(matchers, lbls) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesLabelAdapters(matchers []*labels.Matcher, lbls []mimirpb.LabelAdapter) bool {
+	for _, m := range matchers {
+		value := ""
+		for _, l := range lbls {
+			if l.Name == m.Name {
+				value = l.Value
+				break
+			}
+		}
+
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mimir
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/dskit/grpcutil"
+	"github.com/grafana/dskit/services"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServiceModules maps the gRPC services to the module serving them, so that
+// their health reflects the state of the module. The modules must be initialised
+// as services: for example, the distributor is served by the DistributorService module.
+var grpcServiceModules = map[string]string{
+	"distributor.Distributor":          DistributorService,
+	"cortex.Ingester":                  IngesterService,
+	"gatewaypb.StoreGateway":           StoreGateway,
+	"ruler.Ruler":                      Ruler,
+	"alertmanagerpb.Alertmanager":      AlertManager,
+	"frontend.Frontend":                QueryFrontend,
+	"frontendv2pb.FrontendForQuerier":  QueryFrontend,
+	"schedulerpb.SchedulerForFrontend": QueryScheduler,
+	"schedulerpb.SchedulerForQuerier":  QueryScheduler,
+}
+
+const grpcHealthWatchInterval = time.Second
+
+// grpcHealthServer implements the standard gRPC health checking service. The empty service name reports
+// the health of the whole process, while the name of a gRPC service reports whether the module serving
+// it is running.
+type grpcHealthServer struct {
+	overall           *grpcutil.HealthCheck
+	shutdownRequested *atomic.Bool
+	serviceInfo       func() map[string]grpc.ServiceInfo
+	modules           map[string]services.Service
+	watchInterval     time.Duration
+}
+
+func newGRPCHealthServer(sm *services.Manager, shutdownRequested *atomic.Bool, serviceInfo func() map[string]grpc.ServiceInfo, modules map[string]services.Service) *grpcHealthServer {
+	return &grpcHealthServer{
+		overall: grpcutil.NewHealthCheckFrom(
+			grpcutil.WithShutdownRequested(shutdownRequested),
+			grpcutil.WithManager(sm),
+		),
+		shutdownRequested: shutdownRequested,
+		serviceInfo:       serviceInfo,
+		modules:           modules,
+		watchInterval:     grpcHealthWatchInterval,
+	}
+}
+
+// Check implements grpc_health_v1.HealthServer.
+func (h *grpcHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	s := h.status(ctx, req.Service)
+	if s == grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: s}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. The status of the service is sent once,
+// and then again each time it changes.
+func (h *grpcHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ticker := time.NewTicker(h.watchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		if s := h.status(stream.Context(), req.Service); s != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: s}); err != nil {
+				return err
+			}
+			last = s
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *grpcHealthServer) status(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if service != "" {
+		if _, ok := h.serviceInfo()[service]; !ok {
+			return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		if module, ok := grpcServiceModules[service]; ok {
+			if s, ok := h.modules[module]; ok {
+				if h.shutdownRequested.Load() || s.State() != services.Running {
+					return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+				}
+				return grpc_health_v1.HealthCheckResponse_SERVING
+			}
+		}
+	}
+
+	// The services not served by a module, like the health checking service itself, report the health of the whole process.
+	resp, err := h.overall.Check(ctx, nil)
+	if err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return resp.Status
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mimir
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCHealthServer_Check(t *testing.T) {
+	distributor := services.NewIdleService(nil, nil)
+	ingester := services.NewIdleService(nil, nil)
+	sm, err := services.NewManager(distributor, ingester)
+	require.NoError(t, err)
+
+	serviceInfo := func() map[string]grpc.ServiceInfo {
+		return map[string]grpc.ServiceInfo{
+			"distributor.Distributor": {},
+			"cortex.Ingester":         {},
+			"grpc.health.v1.Health":   {},
+		}
+	}
+	shutdownRequested := atomic.NewBool(false)
+	h := newGRPCHealthServer(sm, shutdownRequested, serviceInfo, map[string]services.Service{DistributorService: distributor, IngesterService: ingester})
+
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check("distributor.Distributor"))
+
+	require.NoError(t, services.StartManagerAndAwaitHealthy(context.Background(), sm))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check("distributor.Distributor"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check("cortex.Ingester"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check("grpc.health.v1.Health"))
+
+	// Each service reports the state of its own module.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), ingester))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check("distributor.Distributor"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check("cortex.Ingester"))
+
+	// All services are not serving once the shutdown has been requested.
+	shutdownRequested.Store(true)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check("distributor.Distributor"))
+
+	// Services not registered on the server are unknown.
+	_, err = h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "gatewaypb.StoreGateway"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	require.NoError(t, services.StopManagerAndAwaitStopped(context.Background(), sm))
+}
+
+func TestGRPCHealthServer_Watch(t *testing.T) {
+	distributor := services.NewIdleService(nil, nil)
+	sm, err := services.NewManager(distributor)
+	require.NoError(t, err)
+	require.NoError(t, services.StartManagerAndAwaitHealthy(context.Background(), sm))
+
+	serviceInfo := func() map[string]grpc.ServiceInfo {
+		return map[string]grpc.ServiceInfo{"distributor.Distributor": {}}
+	}
+	h := newGRPCHealthServer(sm, atomic.NewBool(false), serviceInfo, map[string]services.Service{DistributorService: distributor})
+	h.watchInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockHealthWatchServer{ctx: ctx, sent: make(chan *grpc_health_v1.HealthCheckResponse, 10)}
+	done := make(chan error)
+	go func() {
+		done <- h.Watch(&grpc_health_v1.HealthCheckRequest{Service: "distributor.Distributor"}, stream)
+	}()
+
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, (<-stream.sent).Status)
+
+	require.NoError(t, services.StopManagerAndAwaitStopped(context.Background(), sm))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, (<-stream.sent).Status)
+
+	// Unknown services are reported as such, instead of failing the stream.
+	unknownStream := &mockHealthWatchServer{ctx: ctx, sent: make(chan *grpc_health_v1.HealthCheckResponse, 10)}
+	go func() {
+		_ = h.Watch(&grpc_health_v1.HealthCheckRequest{Service: "unknown"}, unknownStream)
+	}()
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, (<-unknownStream.sent).Status)
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+}
+
+type mockHealthWatchServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *grpc_health_v1.HealthCheckResponse
+}
+
+func (m *mockHealthWatchServer) Send(resp *grpc_health_v1.HealthCheckResponse) error {
+	m.sent <- resp
+	return nil
+}
+
+func (m *mockHealthWatchServer) Context() context.Context {
+	return m.ctx
+}
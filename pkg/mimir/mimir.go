@@ -19,7 +19,6 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/flagext"
-	"github.com/grafana/dskit/grpcutil"
 	"github.com/grafana/dskit/kv/memberlist"
 	"github.com/grafana/dskit/modules"
 	"github.com/grafana/dskit/multierror"
@@ -30,7 +29,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
-	"github.com/prometheus/prometheus/promql"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	"github.com/weaveworks/common/server"
 	"github.com/weaveworks/common/signals"
@@ -48,14 +46,17 @@ import (
 	"github.com/grafana/mimir/pkg/api"
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
+	"github.com/grafana/mimir/pkg/distributor/limitspolicy"
 	"github.com/grafana/mimir/pkg/flusher"
 	"github.com/grafana/mimir/pkg/frontend"
 	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
 	frontendv1 "github.com/grafana/mimir/pkg/frontend/v1"
+	"github.com/grafana/mimir/pkg/graphite"
 	"github.com/grafana/mimir/pkg/ingester"
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/querier/engine"
 	"github.com/grafana/mimir/pkg/querier/tenantfederation"
 	querier_worker "github.com/grafana/mimir/pkg/querier/worker"
 	"github.com/grafana/mimir/pkg/ruler"
@@ -72,6 +73,8 @@ import (
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/noauth"
 	"github.com/grafana/mimir/pkg/util/process"
+	"github.com/grafana/mimir/pkg/util/querysource"
+	"github.com/grafana/mimir/pkg/util/ringadvisor"
 	"github.com/grafana/mimir/pkg/util/validation"
 	"github.com/grafana/mimir/pkg/util/validation/exporter"
 	"github.com/grafana/mimir/pkg/vault"
@@ -106,6 +109,7 @@ type Config struct {
 	EnableGoRuntimeMetrics          bool                   `yaml:"enable_go_runtime_metrics" category:"advanced"`
 	PrintConfig                     bool                   `yaml:"-"`
 	ApplicationName                 string                 `yaml:"-"`
+	ConfiguredLimits                map[string]struct{}    `yaml:"-"` // Names of the limits explicitly set by the configuration file or CLI flags.
 
 	API              api.Config                      `yaml:"api"`
 	Server           server.Config                   `yaml:"server"`
@@ -133,6 +137,7 @@ type Config struct {
 	QueryScheduler      scheduler.Config                           `yaml:"query_scheduler"`
 	UsageStats          usagestats.Config                          `yaml:"usage_stats"`
 	OverridesExporter   exporter.Config                            `yaml:"overrides_exporter"`
+	GraphiteWriteProxy  graphite.Config                            `yaml:"graphite_write_proxy"`
 
 	Common CommonConfig `yaml:"common"`
 
@@ -189,6 +194,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.QueryScheduler.RegisterFlags(f, logger)
 	c.UsageStats.RegisterFlags(f)
 	c.OverridesExporter.RegisterFlags(f, logger)
+	c.GraphiteWriteProxy.RegisterFlags(f)
 
 	c.Common.RegisterFlags(f)
 }
@@ -233,6 +239,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Ruler.Validate(c.LimitsConfig); err != nil {
 		return errors.Wrap(err, "invalid ruler config")
 	}
+	if c.Ruler.NotificationsDeadLetter.Enabled && c.RulerStorage.Backend == rulestorelocal.Name {
+		return errors.New("the ruler notifications dead-letter requires an object storage backend for the ruler storage, but the local backend is configured")
+	}
 	if err := c.BlocksStorage.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid TSDB config")
 	}
@@ -284,6 +293,11 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.OverridesExporter.Validate(); err != nil {
 		return errors.Wrap(err, "invalid overrides-exporter config")
 	}
+	if c.isAnyModuleEnabled(GraphiteWriteProxy) {
+		if err := c.GraphiteWriteProxy.Validate(); err != nil {
+			return errors.Wrap(err, "invalid graphite-write-proxy config")
+		}
+	}
 	// validate the default limits
 	if err := c.ValidateLimits(c.LimitsConfig); err != nil {
 		return err
@@ -297,6 +311,9 @@ func (c *Config) ValidateLimits(limits validation.Limits) error {
 	if err := c.Querier.ValidateLimits(limits); err != nil {
 		return errors.Wrap(err, "invalid limits config for querier")
 	}
+	if err := c.Ingester.ValidateLimits(limits); err != nil {
+		return errors.Wrap(err, "invalid limits config for ingester")
+	}
 	return nil
 }
 
@@ -680,6 +697,7 @@ type Mimir struct {
 	Overrides                *validation.Overrides
 	ActiveGroupsCleanup      *util.ActiveGroupsCleanupService
 	Distributor              *distributor.Distributor
+	DistributorLimitsPolicy  *limitspolicy.TenantLimits
 	Ingester                 *ingester.Ingester
 	Flusher                  *flusher.Flusher
 	Frontend                 *frontendv1.Frontend
@@ -687,7 +705,7 @@ type Mimir struct {
 	QuerierQueryable         prom_storage.SampleAndChunkQueryable
 	ExemplarQueryable        prom_storage.ExemplarQueryable
 	MetadataSupplier         querier.MetadataSupplier
-	QuerierEngine            *promql.Engine
+	QuerierEngine            *engine.MaxSamplesEngine
 	QueryFrontendTripperware querymiddleware.Tripperware
 	QueryFrontendCodec       querymiddleware.Codec
 	Ruler                    *ruler.Ruler
@@ -754,6 +772,7 @@ func New(cfg Config, reg prometheus.Registerer) (*Mimir, error) {
 	}
 
 	mimir.setupObjstoreTracing()
+	mimir.setupQuerySourcePropagation()
 	otel.SetTracerProvider(NewOpenTelemetryProviderBridge(opentracing.GlobalTracer()))
 
 	if err := mimir.setupModuleManager(); err != nil {
@@ -770,6 +789,13 @@ func (t *Mimir) setupObjstoreTracing() {
 	t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, ThanosTracerStreamInterceptor)
 }
 
+// setupQuerySourcePropagation appends a gRPC middleware used to attach the source of read-path requests
+// (user queries or rule evaluations), propagated by the gRPC clients, to the request context.
+func (t *Mimir) setupQuerySourcePropagation() {
+	t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, querysource.ServerUnaryInterceptor)
+	t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, querysource.ServerStreamInterceptor)
+}
+
 // Run starts Mimir running, and blocks until a Mimir stops.
 func (t *Mimir) Run() error {
 	mimirpb.TimeseriesUnmarshalCachingEnabled = t.Cfg.TimeseriesUnmarshalCachingOptimizationEnabled
@@ -804,9 +830,9 @@ func (t *Mimir) Run() error {
 	// implementation provided by module.Ring over the BasicLifecycler
 	// available in ingesters
 	if t.Ring != nil {
-		t.API.RegisterRing(t.Ring)
+		t.API.RegisterRing(t.Ring, ringadvisor.NewHandler(ringadvisor.ConfigFromRingConfig(t.Cfg.Ingester.IngesterRing.ToRingConfig(), true), t.Ring.KVClient, ingester.IngesterRingKey))
 	} else if t.Ingester != nil {
-		t.API.RegisterRing(t.Ingester.RingHandler())
+		t.API.RegisterRing(t.Ingester.RingHandler(), t.Ingester.RingRebalancingHandler())
 	}
 
 	// get all services, create service manager and tell it to start
@@ -825,10 +851,7 @@ func (t *Mimir) Run() error {
 
 	// before starting servers, register /ready handler and gRPC health check service.
 	t.Server.HTTP.Path("/ready").Handler(t.readyHandler(sm, shutdownRequested))
-	grpc_health_v1.RegisterHealthServer(t.Server.GRPC, grpcutil.NewHealthCheckFrom(
-		grpcutil.WithShutdownRequested(shutdownRequested),
-		grpcutil.WithManager(sm),
-	))
+	grpc_health_v1.RegisterHealthServer(t.Server.GRPC, newGRPCHealthServer(sm, shutdownRequested, t.Server.GRPC.GetServiceInfo, t.ServiceMap))
 
 	// Let's listen for events from this manager, and log them.
 	healthy := func() { level.Info(util_log.Logger).Log("msg", "Application started") }
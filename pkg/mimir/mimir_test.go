@@ -218,6 +218,19 @@ func TestMimir(t *testing.T) {
 			}
 		})
 	}
+
+	// The health of the gRPC services is reported by the services of their modules.
+	t.Run("modules serving gRPC services", func(t *testing.T) {
+		for grpcService, module := range grpcServiceModules {
+			cfg.Target = []string{All}
+			c, err := New(cfg, prometheus.NewPedanticRegistry())
+			require.NoError(t, err)
+
+			serviceMap, err := c.ModuleManager.InitModuleServices(module)
+			require.NoError(t, err)
+			require.NotNilf(t, serviceMap[module], "grpc service=%s module=%s", grpcService, module)
+		}
+	})
 }
 
 func TestMimirServerShutdownWithActivityTrackerEnabled(t *testing.T) {
@@ -460,6 +473,25 @@ func TestConfig_ValidateLimits(t *testing.T) {
 			}(),
 			hasError: true,
 		},
+		{
+			name:       "samples per chunk within the allowed range should pass validation",
+			testConfig: newDefaultConfig(),
+			limitsConfig: func() validation.Limits {
+				limits := newDefaultConfig().LimitsConfig
+				limits.SamplesPerChunk = validation.MaxSamplesPerChunk
+				return limits
+			}(),
+		},
+		{
+			name:       "samples per chunk out of the allowed range should return error",
+			testConfig: newDefaultConfig(),
+			limitsConfig: func() validation.Limits {
+				limits := newDefaultConfig().LimitsConfig
+				limits.SamplesPerChunk = validation.MaxSamplesPerChunk + 1
+				return limits
+			}(),
+			hasError: true,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			err := tc.testConfig.ValidateLimits(tc.limitsConfig)
@@ -484,6 +516,7 @@ func TestQueryIngestersWithinGlobalConfigIsUsedInsteadOfDefaultLimitConfig(t *te
 	cfg.Target = []string{Overrides}
 
 	cfg.RuntimeConfig.LoadPath = []string{filepath.Join(dir, "config.yaml")}
+	cfg.ActivityTracker.Filepath = filepath.Join(dir, "metrics-activity.log")
 
 	c, err := New(cfg, prometheus.NewPedanticRegistry())
 	require.NoError(t, err)
@@ -859,6 +892,7 @@ overrides:
 
 	cfg.RuntimeConfig.LoadPath = []string{loadPath}
 	cfg.RuntimeConfig.ReloadPeriod = 100 * time.Millisecond
+	cfg.ActivityTracker.Filepath = filepath.Join(dir, "metrics-activity.log")
 	cfg.Querier.QueryStoreAfter = 12 * time.Hour
 	cfg.LimitsConfig.QueryIngestersWithin = model.Duration(13 * time.Hour)
 
@@ -27,6 +27,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	prom_remote "github.com/prometheus/prometheus/storage/remote"
@@ -38,10 +39,15 @@ import (
 	"github.com/grafana/mimir/pkg/api"
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
+	"github.com/grafana/mimir/pkg/distributor/limitspolicy"
 	"github.com/grafana/mimir/pkg/flusher"
 	"github.com/grafana/mimir/pkg/frontend"
+	"github.com/grafana/mimir/pkg/frontend/federate"
+	"github.com/grafana/mimir/pkg/frontend/heavyqueries"
 	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
+	"github.com/grafana/mimir/pkg/frontend/queryrecorder"
 	"github.com/grafana/mimir/pkg/frontend/transport"
+	"github.com/grafana/mimir/pkg/graphite"
 	"github.com/grafana/mimir/pkg/ingester"
 	"github.com/grafana/mimir/pkg/querier"
 	"github.com/grafana/mimir/pkg/querier/engine"
@@ -73,6 +79,7 @@ const (
 	Server                     string = "server"
 	ActiveGroupsCleanupService string = "active-groups-cleanup-service"
 	Distributor                string = "distributor"
+	DistributorLimitsPolicy    string = "distributor-limits-policy"
 	DistributorService         string = "distributor-service"
 	Ingester                   string = "ingester"
 	IngesterService            string = "ingester-service"
@@ -92,6 +99,7 @@ const (
 	Vault                      string = "vault"
 	TenantFederation           string = "tenant-federation"
 	UsageStats                 string = "usage-stats"
+	GraphiteWriteProxy         string = "graphite-write-proxy"
 	All                        string = "all"
 
 	// Write Read and Backend are the targets used when using the read-write deployment mode.
@@ -276,6 +284,10 @@ func (t *Mimir) initRuntimeConfig() (services.Service, error) {
 	// TODO: Remove in Mimir 2.11.0
 	if t.Cfg.Querier.QueryIngestersWithin != querier.DefaultQuerierCfgQueryIngestersWithin {
 		t.Cfg.LimitsConfig.QueryIngestersWithin = model.Duration(t.Cfg.Querier.QueryIngestersWithin)
+		if t.Cfg.ConfiguredLimits == nil {
+			t.Cfg.ConfiguredLimits = map[string]struct{}{}
+		}
+		t.Cfg.ConfiguredLimits["query_ingesters_within"] = struct{}{}
 	}
 
 	// make sure to set default limits before we start loading configuration into memory
@@ -313,6 +325,7 @@ func (t *Mimir) initRuntimeConfig() (services.Service, error) {
 
 func (t *Mimir) initOverrides() (serv services.Service, err error) {
 	t.Overrides, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.TenantLimits)
+	t.API.RegisterEffectiveLimits(validation.EffectiveLimitsHandler(t.Cfg.LimitsConfig, t.Cfg.ConfiguredLimits, t.TenantLimits))
 	// overrides don't have operational state, nor do they need to do anything more in starting/stopping phase,
 	// so there is no need to return any service.
 	return nil, err
@@ -340,6 +353,19 @@ func (t *Mimir) initOverridesExporter() (services.Service, error) {
 	return overridesExporter, nil
 }
 
+func (t *Mimir) initGraphiteWriteProxy() (services.Service, error) {
+	tenantID := t.Cfg.GraphiteWriteProxy.TenantID
+	if tenantID == "" {
+		tenantID = t.Cfg.NoAuthTenant
+	}
+
+	proxy, err := graphite.NewWriteProxy(t.Cfg.GraphiteWriteProxy, tenantID, t.Distributor.Push, util_log.Logger, t.Registerer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to instantiate graphite-write-proxy")
+	}
+	return proxy, nil
+}
+
 func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	t.Cfg.Distributor.DistributorRing.Common.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Distributor.InstanceLimitsFn = distributorInstanceLimits(t.RuntimeConfig)
@@ -357,7 +383,15 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	t.Cfg.Distributor.StreamingChunksPerIngesterSeriesBufferSize = t.Cfg.Querier.StreamingChunksPerIngesterSeriesBufferSize
 	t.Cfg.Distributor.MinimizeIngesterRequests = t.Cfg.Querier.MinimizeIngesterRequests
 
-	t.Distributor, err = distributor.New(t.Cfg.Distributor, t.Cfg.IngesterClient, t.Overrides, t.ActiveGroupsCleanup, t.Ring, canJoinDistributorsRing, t.Registerer, util_log.Logger)
+	limits := t.Overrides
+	if t.DistributorLimitsPolicy != nil {
+		// The distributor enforces the limits received from the limits policy service, if any.
+		if limits, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.DistributorLimitsPolicy); err != nil {
+			return
+		}
+	}
+
+	t.Distributor, err = distributor.New(t.Cfg.Distributor, t.Cfg.IngesterClient, limits, t.ActiveGroupsCleanup, t.Ring, canJoinDistributorsRing, t.Registerer, util_log.Logger)
 	if err != nil {
 		return
 	}
@@ -369,8 +403,22 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	return t.Distributor, nil
 }
 
+func (t *Mimir) initDistributorLimitsPolicy() (serv services.Service, err error) {
+	// The limits policy service is only consulted by distributors receiving push requests, and not
+	// when the distributor is running as an internal dependency (ie. querier or ruler's dependency).
+	if !t.Cfg.Distributor.LimitsPolicy.Enabled() || !t.Cfg.isAnyModuleEnabled(Distributor, Write, All) {
+		return nil, nil
+	}
+
+	t.DistributorLimitsPolicy, err = limitspolicy.NewTenantLimits(t.Cfg.Distributor.LimitsPolicy, &t.Cfg.LimitsConfig, t.TenantLimits, util_log.Logger, t.Registerer)
+	if err != nil {
+		return nil, err
+	}
+	return t.DistributorLimitsPolicy, nil
+}
+
 func (t *Mimir) initDistributor() (serv services.Service, err error) {
-	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor, t.Registerer)
+	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor, t.Overrides, t.Registerer)
 
 	return nil, nil
 }
@@ -381,7 +429,11 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 	querierRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "querier"}, t.Registerer)
 
 	// Create a querier queryable and PromQL engine
-	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, t.Distributor.QueryChunkMetrics, util_log.Logger, t.ActivityTracker)
+	var eng *promql.Engine
+	t.QuerierQueryable, t.ExemplarQueryable, eng = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, t.Distributor.QueryChunkMetrics, util_log.Logger, t.ActivityTracker)
+
+	// Enforce the per-tenant max samples per query limit in the PromQL engine.
+	t.QuerierEngine = engine.NewMaxSamplesEngine(eng, engine.NewPromQLEngineOptions(t.Cfg.Querier.EngineConfig, t.ActivityTracker, util_log.Logger, nil), t.Overrides)
 
 	// Use the distributor to return metric metadata by default
 	t.MetadataSupplier = t.Distributor
@@ -595,7 +647,7 @@ func (t *Mimir) initFlusher() (serv services.Service, err error) {
 // initQueryFrontendTripperware instantiates the tripperware used by the query frontend
 // to optimize Prometheus query requests.
 func (t *Mimir) initQueryFrontendTripperware() (serv services.Service, err error) {
-	t.QueryFrontendCodec = querymiddleware.NewPrometheusCodec(t.Registerer, t.Cfg.Frontend.QueryMiddleware.QueryResultResponseFormat)
+	t.QueryFrontendCodec = querymiddleware.NewPrometheusCodec(t.Registerer, t.Cfg.Frontend.QueryMiddleware.QueryResultResponseFormat, t.Cfg.Frontend.QueryMiddleware.ResponseFormats)
 	promqlEngineRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "query-frontend"}, t.Registerer)
 
 	tripperware, err := querymiddleware.NewTripperware(
@@ -626,8 +678,31 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker)
+	var recorder *queryrecorder.Recorder
+	var handlerRecorder transport.QueryRecorder
+	if t.Cfg.Frontend.QueryRecording.Enabled {
+		bkt, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "query-frontend", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the bucket client for query recordings")
+		}
+
+		recorder = queryrecorder.NewRecorder(t.Cfg.Frontend.QueryRecording, bkt, util_log.Logger, t.Registerer)
+		handlerRecorder = recorder
+		t.API.RegisterQueryRecorder(recorder)
+	}
+
+	var heavyQueries *heavyqueries.Tracker
+	var handlerHeavyQueries transport.HeavyQueriesTracker
+	if t.Cfg.Frontend.HeavyQueries.Enabled {
+		heavyQueries = heavyqueries.NewTracker(t.Cfg.Frontend.HeavyQueries, t.Registerer)
+		handlerHeavyQueries = heavyQueries
+		t.API.RegisterHeavyQueriesTracker(heavyQueries)
+	}
+
+	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker, handlerRecorder, handlerHeavyQueries)
 	t.API.RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
+	t.API.RegisterQueryFrontendFederate(federate.NewHandler(roundTripper, t.Overrides, t.Cfg.Querier.EngineConfig.LookbackDelta, t.Cfg.Frontend.Handler.MaxBodySize, util_log.Logger))
+	t.API.RegisterQueryFrontendPassthrough(querymiddleware.PassthroughHandler(handler))
 
 	var frontendSvc services.Service
 	if frontendV1 != nil {
@@ -641,6 +716,18 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 
 	w := services.NewFailureWatcher()
 	return services.NewBasicService(func(_ context.Context) error {
+		if recorder != nil {
+			w.WatchService(recorder)
+			if err := services.StartAndAwaitRunning(context.Background(), recorder); err != nil {
+				return err
+			}
+		}
+		if heavyQueries != nil {
+			w.WatchService(heavyQueries)
+			if err := services.StartAndAwaitRunning(context.Background(), heavyQueries); err != nil {
+				return err
+			}
+		}
 		if frontendSvc != nil {
 			w.WatchService(frontendSvc)
 			// Note that we pass an independent context to the service, since we want to
@@ -658,6 +745,14 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	}, func(_ error) error {
 		handler.Stop()
 
+		// Stop the recorder once in-flight requests have completed, so that all recorded queries get uploaded.
+		if recorder != nil {
+			_ = services.StopAndAwaitTerminated(context.Background(), recorder)
+		}
+		if heavyQueries != nil {
+			_ = services.StopAndAwaitTerminated(context.Background(), heavyQueries)
+		}
+
 		if frontendSvc != nil {
 			return services.StopAndAwaitTerminated(context.Background(), frontendSvc)
 		}
@@ -697,7 +792,7 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		if err != nil {
 			return nil, err
 		}
-		remoteQuerier := ruler.NewRemoteQuerier(queryFrontendClient, t.Cfg.Querier.EngineConfig.Timeout, t.Cfg.Ruler.QueryFrontend.QueryResultResponseFormat, t.Cfg.API.PrometheusHTTPPrefix, util_log.Logger, ruler.WithOrgIDMiddleware)
+		remoteQuerier := ruler.NewRemoteQuerier(queryFrontendClient, t.Cfg.Querier.EngineConfig.Timeout, t.Cfg.Ruler.QueryFrontend.QueryResultResponseFormat, t.Cfg.API.PrometheusHTTPPrefix, util_log.Logger, ruler.WithOrgIDMiddleware, ruler.WithQuerySourceMiddleware)
 
 		embeddedQueryable = prom_remote.NewSampleAndChunkQueryableClient(
 			remoteQuerier,
@@ -757,8 +852,18 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		),
 	)
 
+	var deadLetter *ruler.NotificationsDeadLetter
+	if t.Cfg.Ruler.NotificationsDeadLetter.Enabled {
+		bkt, err := bucket.NewClient(context.Background(), t.Cfg.RulerStorage.Config, "ruler-notifications-dead-letter", util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the bucket client for the ruler notifications dead-letter")
+		}
+
+		deadLetter = ruler.NewNotificationsDeadLetter(t.Cfg.Ruler.NotificationsDeadLetter, bkt, t.Overrides, util_log.Logger, t.Registerer)
+	}
+
 	dnsResolver := dns.NewProvider(util_log.Logger, dnsProviderReg, dns.GolangResolverType)
-	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, t.Registerer, util_log.Logger, dnsResolver)
+	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, queryFunc, t.Overrides, deadLetter, t.Registerer, util_log.Logger, dnsResolver)
 	if err != nil {
 		return nil, err
 	}
@@ -778,6 +883,9 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 
 	// Expose HTTP/GRPC admin endpoints for the Ruler service
 	t.API.RegisterRuler(t.Ruler)
+	if deadLetter != nil {
+		t.API.RegisterRulerNotificationsDeadLetter(manager)
+	}
 
 	// Expose HTTP configuration and prometheus-compatible Ruler APIs
 	t.API.RegisterRulerAPI(ruler.NewAPI(t.Ruler, t.RulerDirectStorage, util_log.Logger), t.Cfg.Ruler.EnableAPI, t.BuildInfoHandler)
@@ -914,6 +1022,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(ActiveGroupsCleanupService, t.initActiveGroupsCleanupService, modules.UserInvisibleModule)
 	mm.RegisterModule(Distributor, t.initDistributor)
 	mm.RegisterModule(DistributorService, t.initDistributorService, modules.UserInvisibleModule)
+	mm.RegisterModule(DistributorLimitsPolicy, t.initDistributorLimitsPolicy, modules.UserInvisibleModule)
 	mm.RegisterModule(Ingester, t.initIngester)
 	mm.RegisterModule(IngesterService, t.initIngesterService, modules.UserInvisibleModule)
 	mm.RegisterModule(Flusher, t.initFlusher)
@@ -931,6 +1040,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(TenantFederation, t.initTenantFederation, modules.UserInvisibleModule)
 	mm.RegisterModule(UsageStats, t.initUsageStats, modules.UserInvisibleModule)
 	mm.RegisterModule(Vault, t.initVault, modules.UserInvisibleModule)
+	mm.RegisterModule(GraphiteWriteProxy, t.initGraphiteWriteProxy)
 	mm.RegisterModule(Write, nil)
 	mm.RegisterModule(Read, nil)
 	mm.RegisterModule(Backend, nil)
@@ -946,7 +1056,8 @@ func (t *Mimir) setupModuleManager() error {
 		Overrides:                {RuntimeConfig},
 		OverridesExporter:        {Overrides, MemberlistKV, Vault},
 		Distributor:              {DistributorService, API, ActiveGroupsCleanupService, Vault},
-		DistributorService:       {Ring, Overrides, Vault},
+		DistributorService:       {Ring, Overrides, DistributorLimitsPolicy, Vault},
+		DistributorLimitsPolicy:  {RuntimeConfig},
 		Ingester:                 {IngesterService, API, ActiveGroupsCleanupService, Vault},
 		IngesterService:          {Overrides, RuntimeConfig, MemberlistKV},
 		Flusher:                  {Overrides, API},
@@ -962,6 +1073,7 @@ func (t *Mimir) setupModuleManager() error {
 		Compactor:                {API, MemberlistKV, Overrides, Vault},
 		StoreGateway:             {API, Overrides, MemberlistKV, Vault},
 		TenantFederation:         {Queryable},
+		GraphiteWriteProxy:       {API, DistributorService},
 		Write:                    {Distributor, Ingester},
 		Read:                     {QueryFrontend, Querier},
 		Backend:                  {QueryScheduler, Ruler, StoreGateway, Compactor, AlertManager, OverridesExporter},
@@ -6,6 +6,7 @@
 package mimir
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"net/http"
@@ -36,6 +37,9 @@ type runtimeConfigValues struct {
 
 	IngesterLimits    *ingester.InstanceLimits    `yaml:"ingester_limits"`
 	DistributorLimits *distributor.InstanceLimits `yaml:"distributor_limits"`
+
+	// Names of the limits explicitly overridden for each tenant.
+	overriddenLimits map[string]map[string]struct{}
 }
 
 // runtimeConfigTenantLimits provides per-tenant limit overrides based on a runtimeconfig.Manager
@@ -56,6 +60,15 @@ func (l *runtimeConfigTenantLimits) ByUserID(userID string) *validation.Limits {
 	return l.AllByUserID()[userID]
 }
 
+func (l *runtimeConfigTenantLimits) OverriddenLimitsByUserID(userID string) map[string]struct{} {
+	cfg, ok := l.manager.GetConfig().(*runtimeConfigValues)
+	if cfg != nil && ok {
+		return cfg.overriddenLimits[userID]
+	}
+
+	return nil
+}
+
 func (l *runtimeConfigTenantLimits) AllByUserID() map[string]*validation.Limits {
 	cfg, ok := l.manager.GetConfig().(*runtimeConfigValues)
 	if cfg != nil && ok {
@@ -73,7 +86,12 @@ type runtimeConfigLoader struct {
 func (l *runtimeConfigLoader) load(r io.Reader) (interface{}, error) {
 	var overrides = &runtimeConfigValues{}
 
-	decoder := yaml.NewDecoder(r)
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
 	decoder.KnownFields(true)
 
 	// Decode the first document. An empty document (EOF) is OK.
@@ -97,6 +115,23 @@ func (l *runtimeConfigLoader) load(r io.Reader) (interface{}, error) {
 		}
 	}
 
+	// The limits of each tenant are decoded once more by name, to know which ones are explicitly overridden.
+	var overriddenLimits struct {
+		TenantLimits map[string]map[string]yaml.Node `yaml:"overrides"`
+	}
+	if err := yaml.Unmarshal(buf, &overriddenLimits); err != nil {
+		return nil, err
+	}
+	for userID, limits := range overriddenLimits.TenantLimits {
+		if overrides.overriddenLimits == nil {
+			overrides.overriddenLimits = make(map[string]map[string]struct{}, len(overriddenLimits.TenantLimits))
+		}
+		overrides.overriddenLimits[userID] = make(map[string]struct{}, len(limits))
+		for name := range limits {
+			overrides.overriddenLimits[userID][name] = struct{}{}
+		}
+	}
+
 	return overrides, nil
 }
 
@@ -54,6 +54,17 @@ overrides:
 	require.Equal(t, limits, *loadedLimits["1234"])
 	require.Equal(t, limits, *loadedLimits["1235"])
 	require.Equal(t, limits, *loadedLimits["1236"])
+
+	// The limits explicitly overridden for each tenant are tracked.
+	overriddenLimits := runtimeCfg.(*runtimeConfigValues).overriddenLimits["1235"]
+	assert.Equal(t, map[string]struct{}{
+		"ingestion_burst_size":             {},
+		"ingestion_rate":                   {},
+		"max_global_series_per_metric":     {},
+		"max_global_series_per_user":       {},
+		"ruler_max_rule_groups_per_tenant": {},
+		"ruler_max_rules_per_rule_group":   {},
+	}, overriddenLimits)
 }
 
 func TestRuntimeConfigLoader_ShouldLoadEmptyFile(t *testing.T) {
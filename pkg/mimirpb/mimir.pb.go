@@ -86,7 +86,7 @@ var MetricMetadata_MetricType_value = map[string]int32{
 }
 
 func (MetricMetadata_MetricType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{5, 0}
+	return fileDescriptor_86d4d7485f544059, []int{6, 0}
 }
 
 type Histogram_ResetHint int32
@@ -113,7 +113,7 @@ var Histogram_ResetHint_value = map[string]int32{
 }
 
 func (Histogram_ResetHint) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{8, 0}
+	return fileDescriptor_86d4d7485f544059, []int{9, 0}
 }
 
 // These values correspond to the possible status values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -135,7 +135,7 @@ var QueryResponse_Status_value = map[string]int32{
 }
 
 func (QueryResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15, 0}
+	return fileDescriptor_86d4d7485f544059, []int{16, 0}
 }
 
 // These values correspond to the possible error type values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -178,7 +178,7 @@ var QueryResponse_ErrorType_value = map[string]int32{
 }
 
 func (QueryResponse_ErrorType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15, 1}
+	return fileDescriptor_86d4d7485f544059, []int{16, 1}
 }
 
 type WriteRequest struct {
@@ -187,6 +187,12 @@ type WriteRequest struct {
 	Metadata   []*MetricMetadata       `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty"`
 	// Skip validation of label names.
 	SkipLabelNameValidation bool `protobuf:"varint,1000,opt,name=skip_label_name_validation,json=skipLabelNameValidation,proto3" json:"skip_label_name_validation,omitempty"`
+	// The request only carries the exemplars of the series, whose samples are sent separately with their own
+	// replication. The exemplars of the series which don't exist yet are dropped without failing the request.
+	ExemplarsOnly bool `protobuf:"varint,1001,opt,name=exemplars_only,json=exemplarsOnly,proto3" json:"exemplars_only,omitempty"`
+	// The request may be partially accepted if the remote timeout is about to expire before all the series have
+	// been written to a quorum of ingesters, instead of failing.
+	PartialAcceptance bool `protobuf:"varint,1002,opt,name=partial_acceptance,json=partialAcceptance,proto3" json:"partial_acceptance,omitempty"`
 }
 
 func (m *WriteRequest) Reset()      { *m = WriteRequest{} }
@@ -242,7 +248,24 @@ func (m *WriteRequest) GetSkipLabelNameValidation() bool {
 	return false
 }
 
+func (m *WriteRequest) GetExemplarsOnly() bool {
+	if m != nil {
+		return m.ExemplarsOnly
+	}
+	return false
+}
+
+func (m *WriteRequest) GetPartialAcceptance() bool {
+	if m != nil {
+		return m.PartialAcceptance
+	}
+	return false
+}
+
 type WriteResponse struct {
+	// The series which haven't been written to a quorum of ingesters, if the request has been partially accepted.
+	// The labels are the ones sent to the ingesters, after relabeling.
+	FailedSeries []FailedSeries `protobuf:"bytes,1,rep,name=failed_series,json=failedSeries,proto3" json:"failed_series"`
 }
 
 func (m *WriteResponse) Reset()      { *m = WriteResponse{} }
@@ -277,6 +300,49 @@ func (m *WriteResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
 
+func (m *WriteResponse) GetFailedSeries() []FailedSeries {
+	if m != nil {
+		return m.FailedSeries
+	}
+	return nil
+}
+
+type FailedSeries struct {
+	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
+}
+
+func (m *FailedSeries) Reset()      { *m = FailedSeries{} }
+func (*FailedSeries) ProtoMessage() {}
+func (*FailedSeries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86d4d7485f544059, []int{2}
+}
+func (m *FailedSeries) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FailedSeries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FailedSeries.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FailedSeries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FailedSeries.Merge(m, src)
+}
+func (m *FailedSeries) XXX_Size() int {
+	return m.Size()
+}
+func (m *FailedSeries) XXX_DiscardUnknown() {
+	xxx_messageInfo_FailedSeries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FailedSeries proto.InternalMessageInfo
+
 type TimeSeries struct {
 	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
 	// Sorted by time, oldest sample first.
@@ -288,7 +354,7 @@ type TimeSeries struct {
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
 func (*TimeSeries) ProtoMessage() {}
 func (*TimeSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{2}
+	return fileDescriptor_86d4d7485f544059, []int{3}
 }
 func (m *TimeSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -346,7 +412,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{3}
+	return fileDescriptor_86d4d7485f544059, []int{4}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -398,7 +464,7 @@ type Sample struct {
 func (m *Sample) Reset()      { *m = Sample{} }
 func (*Sample) ProtoMessage() {}
 func (*Sample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{4}
+	return fileDescriptor_86d4d7485f544059, []int{5}
 }
 func (m *Sample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -451,7 +517,7 @@ type MetricMetadata struct {
 func (m *MetricMetadata) Reset()      { *m = MetricMetadata{} }
 func (*MetricMetadata) ProtoMessage() {}
 func (*MetricMetadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{5}
+	return fileDescriptor_86d4d7485f544059, []int{6}
 }
 func (m *MetricMetadata) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -515,7 +581,7 @@ type Metric struct {
 func (m *Metric) Reset()      { *m = Metric{} }
 func (*Metric) ProtoMessage() {}
 func (*Metric) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{6}
+	return fileDescriptor_86d4d7485f544059, []int{7}
 }
 func (m *Metric) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -554,7 +620,7 @@ type Exemplar struct {
 func (m *Exemplar) Reset()      { *m = Exemplar{} }
 func (*Exemplar) ProtoMessage() {}
 func (*Exemplar) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{7}
+	return fileDescriptor_86d4d7485f544059, []int{8}
 }
 func (m *Exemplar) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -641,7 +707,7 @@ type Histogram struct {
 func (m *Histogram) Reset()      { *m = Histogram{} }
 func (*Histogram) ProtoMessage() {}
 func (*Histogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{8}
+	return fileDescriptor_86d4d7485f544059, []int{9}
 }
 func (m *Histogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -848,7 +914,7 @@ type FloatHistogram struct {
 func (m *FloatHistogram) Reset()      { *m = FloatHistogram{} }
 func (*FloatHistogram) ProtoMessage() {}
 func (*FloatHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{9}
+	return fileDescriptor_86d4d7485f544059, []int{10}
 }
 func (m *FloatHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -964,7 +1030,7 @@ type BucketSpan struct {
 func (m *BucketSpan) Reset()      { *m = BucketSpan{} }
 func (*BucketSpan) ProtoMessage() {}
 func (*BucketSpan) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{10}
+	return fileDescriptor_86d4d7485f544059, []int{11}
 }
 func (m *BucketSpan) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1016,7 +1082,7 @@ type FloatHistogramPair struct {
 func (m *FloatHistogramPair) Reset()      { *m = FloatHistogramPair{} }
 func (*FloatHistogramPair) ProtoMessage() {}
 func (*FloatHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{11}
+	return fileDescriptor_86d4d7485f544059, []int{12}
 }
 func (m *FloatHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1071,7 +1137,7 @@ type SampleHistogram struct {
 func (m *SampleHistogram) Reset()      { *m = SampleHistogram{} }
 func (*SampleHistogram) ProtoMessage() {}
 func (*SampleHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{12}
+	return fileDescriptor_86d4d7485f544059, []int{13}
 }
 func (m *SampleHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1132,7 +1198,7 @@ type HistogramBucket struct {
 func (m *HistogramBucket) Reset()      { *m = HistogramBucket{} }
 func (*HistogramBucket) ProtoMessage() {}
 func (*HistogramBucket) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{13}
+	return fileDescriptor_86d4d7485f544059, []int{14}
 }
 func (m *HistogramBucket) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1198,7 +1264,7 @@ type SampleHistogramPair struct {
 func (m *SampleHistogramPair) Reset()      { *m = SampleHistogramPair{} }
 func (*SampleHistogramPair) ProtoMessage() {}
 func (*SampleHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{14}
+	return fileDescriptor_86d4d7485f544059, []int{15}
 }
 func (m *SampleHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1251,13 +1317,14 @@ type QueryResponse struct {
 	//	*QueryResponse_Vector
 	//	*QueryResponse_Scalar
 	//	*QueryResponse_Matrix
-	Data isQueryResponse_Data `protobuf_oneof:"data"`
+	Data     isQueryResponse_Data `protobuf_oneof:"data"`
+	Warnings []string             `protobuf:"bytes,8,rep,name=warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15}
+	return fileDescriptor_86d4d7485f544059, []int{16}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1367,6 +1434,13 @@ func (m *QueryResponse) GetMatrix() *MatrixData {
 	return nil
 }
 
+func (m *QueryResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*QueryResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -1385,7 +1459,7 @@ type StringData struct {
 func (m *StringData) Reset()      { *m = StringData{} }
 func (*StringData) ProtoMessage() {}
 func (*StringData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{16}
+	return fileDescriptor_86d4d7485f544059, []int{17}
 }
 func (m *StringData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1436,7 +1510,7 @@ type VectorData struct {
 func (m *VectorData) Reset()      { *m = VectorData{} }
 func (*VectorData) ProtoMessage() {}
 func (*VectorData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{17}
+	return fileDescriptor_86d4d7485f544059, []int{18}
 }
 func (m *VectorData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1489,7 +1563,7 @@ type VectorSample struct {
 func (m *VectorSample) Reset()      { *m = VectorSample{} }
 func (*VectorSample) ProtoMessage() {}
 func (*VectorSample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{18}
+	return fileDescriptor_86d4d7485f544059, []int{19}
 }
 func (m *VectorSample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1549,7 +1623,7 @@ type VectorHistogram struct {
 func (m *VectorHistogram) Reset()      { *m = VectorHistogram{} }
 func (*VectorHistogram) ProtoMessage() {}
 func (*VectorHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{19}
+	return fileDescriptor_86d4d7485f544059, []int{20}
 }
 func (m *VectorHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1607,7 +1681,7 @@ type ScalarData struct {
 func (m *ScalarData) Reset()      { *m = ScalarData{} }
 func (*ScalarData) ProtoMessage() {}
 func (*ScalarData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{20}
+	return fileDescriptor_86d4d7485f544059, []int{21}
 }
 func (m *ScalarData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1657,7 +1731,7 @@ type MatrixData struct {
 func (m *MatrixData) Reset()      { *m = MatrixData{} }
 func (*MatrixData) ProtoMessage() {}
 func (*MatrixData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{21}
+	return fileDescriptor_86d4d7485f544059, []int{22}
 }
 func (m *MatrixData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1703,7 +1777,7 @@ type MatrixSeries struct {
 func (m *MatrixSeries) Reset()      { *m = MatrixSeries{} }
 func (*MatrixSeries) ProtoMessage() {}
 func (*MatrixSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{22}
+	return fileDescriptor_86d4d7485f544059, []int{23}
 }
 func (m *MatrixSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1761,6 +1835,7 @@ func init() {
 	proto.RegisterEnum("cortexpb.QueryResponse_ErrorType", QueryResponse_ErrorType_name, QueryResponse_ErrorType_value)
 	proto.RegisterType((*WriteRequest)(nil), "cortexpb.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "cortexpb.WriteResponse")
+	proto.RegisterType((*FailedSeries)(nil), "cortexpb.FailedSeries")
 	proto.RegisterType((*TimeSeries)(nil), "cortexpb.TimeSeries")
 	proto.RegisterType((*LabelPair)(nil), "cortexpb.LabelPair")
 	proto.RegisterType((*Sample)(nil), "cortexpb.Sample")
@@ -1787,117 +1862,123 @@ func init() {
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 1758 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x73, 0x1b, 0x49,
-	0x15, 0x56, 0x4b, 0x63, 0x49, 0xf3, 0x2c, 0xc9, 0xb3, 0xbd, 0xa9, 0xa0, 0x4d, 0x6d, 0x64, 0x67,
-	0x28, 0x16, 0x43, 0x81, 0x42, 0x65, 0x21, 0x5b, 0xbb, 0x15, 0x0a, 0x46, 0xf2, 0x24, 0xb6, 0xd7,
-	0x96, 0x4c, 0x4b, 0xca, 0xb2, 0x5c, 0x54, 0x23, 0xb9, 0x6d, 0x4d, 0xed, 0x8c, 0x66, 0x98, 0x19,
-	0x85, 0x98, 0x13, 0x17, 0x28, 0x8a, 0x13, 0x17, 0x2e, 0x14, 0x37, 0x0e, 0xf0, 0x17, 0xf0, 0x37,
-	0xa4, 0x8a, 0xa2, 0x2a, 0xc7, 0x2d, 0x0e, 0x29, 0xe2, 0x5c, 0xf6, 0x98, 0x03, 0x27, 0x4e, 0x54,
-	0xbf, 0x9e, 0x1f, 0x1a, 0xd9, 0x86, 0xc0, 0xfa, 0x36, 0xfd, 0xfa, 0x7b, 0xaf, 0xbf, 0x7e, 0xfd,
-	0xf5, 0xd3, 0x6b, 0xc1, 0xba, 0x6b, 0xbb, 0x76, 0xd0, 0xf6, 0x03, 0x2f, 0xf2, 0x68, 0x75, 0xea,
-	0x05, 0x11, 0x7f, 0xea, 0x4f, 0x6e, 0x7d, 0xfb, 0xd4, 0x8e, 0x66, 0x8b, 0x49, 0x7b, 0xea, 0xb9,
-	0x77, 0x4f, 0xbd, 0x53, 0xef, 0x2e, 0x02, 0x26, 0x8b, 0x13, 0x1c, 0xe1, 0x00, 0xbf, 0xa4, 0xa3,
-	0xfe, 0x97, 0x22, 0xd4, 0x3e, 0x09, 0xec, 0x88, 0x33, 0xfe, 0xd3, 0x05, 0x0f, 0x23, 0x7a, 0x04,
-	0x10, 0xd9, 0x2e, 0x0f, 0x79, 0x60, 0xf3, 0xb0, 0x49, 0xb6, 0x4a, 0xdb, 0xeb, 0xf7, 0x6e, 0xb4,
-	0x93, 0xf0, 0xed, 0xa1, 0xed, 0xf2, 0x01, 0xce, 0x75, 0x6e, 0x3d, 0x7b, 0xb1, 0x59, 0xf8, 0xfb,
-	0x8b, 0x4d, 0x7a, 0x14, 0x70, 0xcb, 0x71, 0xbc, 0xe9, 0x30, 0xf5, 0x63, 0x4b, 0x31, 0xe8, 0x87,
-	0x50, 0x1e, 0x78, 0x8b, 0x60, 0xca, 0x9b, 0xc5, 0x2d, 0xb2, 0xdd, 0xb8, 0x77, 0x27, 0x8b, 0xb6,
-	0xbc, 0x72, 0x5b, 0x82, 0xcc, 0xf9, 0xc2, 0x65, 0xb1, 0x03, 0xfd, 0x08, 0xaa, 0x2e, 0x8f, 0xac,
-	0x63, 0x2b, 0xb2, 0x9a, 0x25, 0xa4, 0xd2, 0xcc, 0x9c, 0x0f, 0x79, 0x14, 0xd8, 0xd3, 0xc3, 0x78,
-	0xbe, 0xa3, 0x3c, 0x7b, 0xb1, 0x49, 0x58, 0x8a, 0xa7, 0x0f, 0xe0, 0x56, 0xf8, 0x99, 0xed, 0x8f,
-	0x1d, 0x6b, 0xc2, 0x9d, 0xf1, 0xdc, 0x72, 0xf9, 0xf8, 0x89, 0xe5, 0xd8, 0xc7, 0x56, 0x64, 0x7b,
-	0xf3, 0xe6, 0x17, 0x95, 0x2d, 0xb2, 0x5d, 0x65, 0x5f, 0x11, 0x90, 0x03, 0x81, 0xe8, 0x59, 0x2e,
-	0x7f, 0x9c, 0xce, 0xeb, 0x9b, 0x00, 0x19, 0x1f, 0x5a, 0x81, 0x92, 0x71, 0xb4, 0xa7, 0x15, 0x68,
-	0x15, 0x14, 0x36, 0x3a, 0x30, 0x35, 0xa2, 0x6f, 0x40, 0x3d, 0x66, 0x1f, 0xfa, 0xde, 0x3c, 0xe4,
-	0xfa, 0x3f, 0x09, 0x40, 0x96, 0x1d, 0x6a, 0x40, 0x19, 0x57, 0x4e, 0x72, 0xf8, 0x76, 0x46, 0x1c,
-	0xd7, 0x3b, 0xb2, 0xec, 0xa0, 0x73, 0x23, 0x4e, 0x61, 0x0d, 0x4d, 0xc6, 0xb1, 0xe5, 0x47, 0x3c,
-	0x60, 0xb1, 0x23, 0xfd, 0x0e, 0x54, 0x42, 0xcb, 0xf5, 0x1d, 0x1e, 0x36, 0x8b, 0x18, 0x43, 0xcb,
-	0x62, 0x0c, 0x70, 0x02, 0x37, 0x5d, 0x60, 0x09, 0x8c, 0xde, 0x07, 0x95, 0x3f, 0xe5, 0xae, 0xef,
-	0x58, 0x41, 0x18, 0x27, 0x8c, 0x66, 0x3e, 0x66, 0x3c, 0x15, 0x7b, 0x65, 0x50, 0xfa, 0x21, 0xc0,
-	0xcc, 0x0e, 0x23, 0xef, 0x34, 0xb0, 0xdc, 0xb0, 0xa9, 0xac, 0x12, 0xde, 0x4d, 0xe6, 0x62, 0xcf,
-	0x25, 0xb0, 0xfe, 0x3d, 0x50, 0xd3, 0xfd, 0x50, 0x0a, 0x8a, 0x48, 0x74, 0x93, 0x6c, 0x91, 0xed,
-	0x1a, 0xc3, 0x6f, 0x7a, 0x03, 0xd6, 0x9e, 0x58, 0xce, 0x42, 0x9e, 0x7e, 0x8d, 0xc9, 0x81, 0x6e,
-	0x40, 0x59, 0x6e, 0x81, 0xde, 0x81, 0x1a, 0x8a, 0x25, 0xb2, 0x5c, 0x7f, 0xec, 0x86, 0x08, 0x2b,
-	0xb1, 0xf5, 0xd4, 0x76, 0x18, 0x66, 0x21, 0x44, 0x5c, 0x92, 0x84, 0xf8, 0x7d, 0x11, 0x1a, 0x79,
-	0x0d, 0xd0, 0x0f, 0x40, 0x89, 0xce, 0x7c, 0x89, 0x6b, 0xdc, 0xfb, 0xea, 0x55, 0x5a, 0x89, 0x87,
-	0xc3, 0x33, 0x9f, 0x33, 0x74, 0xa0, 0xdf, 0x02, 0xea, 0xa2, 0x6d, 0x7c, 0x62, 0xb9, 0xb6, 0x73,
-	0x86, 0x7a, 0x41, 0x2a, 0x2a, 0xd3, 0xe4, 0xcc, 0x43, 0x9c, 0x10, 0x32, 0x11, 0xdb, 0x9c, 0x71,
-	0xc7, 0x6f, 0x2a, 0x38, 0x8f, 0xdf, 0xc2, 0xb6, 0x98, 0xdb, 0x51, 0x73, 0x4d, 0xda, 0xc4, 0xb7,
-	0x7e, 0x06, 0x90, 0xad, 0x44, 0xd7, 0xa1, 0x32, 0xea, 0x7d, 0xdc, 0xeb, 0x7f, 0xd2, 0xd3, 0x0a,
-	0x62, 0xd0, 0xed, 0x8f, 0x7a, 0x43, 0x93, 0x69, 0x84, 0xaa, 0xb0, 0xf6, 0xc8, 0x18, 0x3d, 0x32,
-	0xb5, 0x22, 0xad, 0x83, 0xba, 0xbb, 0x37, 0x18, 0xf6, 0x1f, 0x31, 0xe3, 0x50, 0x2b, 0x51, 0x0a,
-	0x0d, 0x9c, 0xc9, 0x6c, 0x8a, 0x70, 0x1d, 0x8c, 0x0e, 0x0f, 0x0d, 0xf6, 0xa9, 0xb6, 0x26, 0x04,
-	0xb9, 0xd7, 0x7b, 0xd8, 0xd7, 0xca, 0xb4, 0x06, 0xd5, 0xc1, 0xd0, 0x18, 0x9a, 0x03, 0x73, 0xa8,
-	0x55, 0xf4, 0x8f, 0xa1, 0x2c, 0x97, 0xbe, 0x06, 0x21, 0xea, 0xbf, 0x22, 0x50, 0x4d, 0xc4, 0x73,
-	0x1d, 0xc2, 0xce, 0x49, 0x22, 0x39, 0xcf, 0x0b, 0x42, 0x28, 0x5d, 0x10, 0x82, 0xfe, 0xd7, 0x35,
-	0x50, 0x53, 0x31, 0xd2, 0xdb, 0xa0, 0x4e, 0xbd, 0xc5, 0x3c, 0x1a, 0xdb, 0xf3, 0x08, 0x8f, 0x5c,
-	0xd9, 0x2d, 0xb0, 0x2a, 0x9a, 0xf6, 0xe6, 0x11, 0xbd, 0x03, 0xeb, 0x72, 0xfa, 0xc4, 0xf1, 0xac,
-	0x48, 0xae, 0xb5, 0x5b, 0x60, 0x80, 0xc6, 0x87, 0xc2, 0x46, 0x35, 0x28, 0x85, 0x0b, 0x17, 0x57,
-	0x22, 0x4c, 0x7c, 0xd2, 0x9b, 0x50, 0x0e, 0xa7, 0x33, 0xee, 0x5a, 0x78, 0xb8, 0x6f, 0xb1, 0x78,
-	0x44, 0xbf, 0x06, 0x8d, 0x9f, 0xf3, 0xc0, 0x1b, 0x47, 0xb3, 0x80, 0x87, 0x33, 0xcf, 0x39, 0xc6,
-	0x83, 0x26, 0xac, 0x2e, 0xac, 0xc3, 0xc4, 0x48, 0xdf, 0x8b, 0x61, 0x19, 0xaf, 0x32, 0xf2, 0x22,
-	0xac, 0x26, 0xec, 0xdd, 0x84, 0xdb, 0x37, 0x41, 0x5b, 0xc2, 0x49, 0x82, 0x15, 0x24, 0x48, 0x58,
-	0x23, 0x45, 0x4a, 0x92, 0x06, 0x34, 0xe6, 0xfc, 0xd4, 0x8a, 0xec, 0x27, 0x7c, 0x1c, 0xfa, 0xd6,
-	0x3c, 0x6c, 0x56, 0x57, 0xab, 0x72, 0x67, 0x31, 0xfd, 0x8c, 0x47, 0x03, 0xdf, 0x9a, 0xc7, 0x37,
-	0xb4, 0x9e, 0x78, 0x08, 0x5b, 0x48, 0xbf, 0x0e, 0x1b, 0x69, 0x88, 0x63, 0xee, 0x44, 0x56, 0xd8,
-	0x54, 0xb7, 0x4a, 0xdb, 0x94, 0xa5, 0x91, 0x77, 0xd0, 0x9a, 0x03, 0x22, 0xb7, 0xb0, 0x09, 0x5b,
-	0xa5, 0x6d, 0x92, 0x01, 0x91, 0x98, 0x28, 0x6f, 0x0d, 0xdf, 0x0b, 0xed, 0x25, 0x52, 0xeb, 0xff,
-	0x9d, 0x54, 0xe2, 0x91, 0x92, 0x4a, 0x43, 0xc4, 0xa4, 0x6a, 0x92, 0x54, 0x62, 0xce, 0x48, 0xa5,
-	0xc0, 0x98, 0x54, 0x5d, 0x92, 0x4a, 0xcc, 0x31, 0xa9, 0x07, 0x00, 0x01, 0x0f, 0x79, 0x34, 0x9e,
-	0x89, 0xcc, 0x37, 0xb0, 0x08, 0xdc, 0xbe, 0xa4, 0x8c, 0xb5, 0x99, 0x40, 0xed, 0xda, 0xf3, 0x88,
-	0xa9, 0x41, 0xf2, 0x49, 0xdf, 0x05, 0x35, 0xd5, 0x5a, 0x73, 0x03, 0xc5, 0x97, 0x19, 0xf4, 0x8f,
-	0x40, 0x4d, 0xbd, 0xf2, 0x57, 0xb9, 0x02, 0xa5, 0x4f, 0xcd, 0x81, 0x46, 0x68, 0x19, 0x8a, 0xbd,
-	0xbe, 0x56, 0xcc, 0xae, 0x73, 0xe9, 0x96, 0xf2, 0xeb, 0x3f, 0xb6, 0x48, 0xa7, 0x02, 0x6b, 0xc8,
-	0xbb, 0x53, 0x03, 0xc8, 0x8e, 0x5d, 0xff, 0x9b, 0x02, 0x0d, 0x3c, 0xe2, 0x4c, 0xd2, 0x21, 0x50,
-	0x9c, 0xe3, 0xc1, 0x78, 0x65, 0x27, 0xf5, 0x8e, 0xf9, 0xaf, 0x17, 0x9b, 0xc6, 0xd2, 0xaf, 0xbb,
-	0x1f, 0x78, 0x2e, 0x8f, 0x66, 0x7c, 0x11, 0x2e, 0x7f, 0xba, 0xde, 0x31, 0x77, 0xee, 0xa6, 0x05,
-	0xba, 0xdd, 0x95, 0xe1, 0xb2, 0x1d, 0x6b, 0xd3, 0x15, 0xcb, 0x97, 0xd5, 0xfc, 0xed, 0xe5, 0x4d,
-	0x49, 0x15, 0x33, 0x35, 0xd5, 0xb0, 0xb8, 0xec, 0x72, 0x26, 0xbe, 0xec, 0x38, 0xb8, 0xe4, 0xe6,
-	0x5d, 0x83, 0xa2, 0xae, 0xe1, 0xa6, 0x7c, 0x03, 0xb4, 0x94, 0xc5, 0x04, 0xb1, 0x89, 0xd8, 0x52,
-	0x0d, 0xca, 0x10, 0x08, 0x4d, 0x57, 0x4b, 0xa0, 0xf2, 0xb2, 0xa4, 0x77, 0x28, 0x86, 0xee, 0x2b,
-	0x55, 0xa2, 0x15, 0xf7, 0x95, 0x6a, 0x59, 0xab, 0xec, 0x2b, 0x55, 0x55, 0x83, 0x7d, 0xa5, 0x5a,
-	0xd3, 0xea, 0xfb, 0x4a, 0x75, 0x43, 0xd3, 0x58, 0x56, 0xc5, 0xd8, 0x4a, 0xf5, 0x60, 0xab, 0xd7,
-	0x96, 0xad, 0x5e, 0x99, 0x65, 0x89, 0x3e, 0x00, 0xc8, 0xb6, 0x27, 0x4e, 0xd5, 0x3b, 0x39, 0x09,
-	0xb9, 0x2c, 0x8d, 0x6f, 0xb1, 0x78, 0x24, 0xec, 0x0e, 0x9f, 0x9f, 0x46, 0x33, 0x3c, 0x90, 0x3a,
-	0x8b, 0x47, 0xfa, 0x02, 0x68, 0x5e, 0x8c, 0xf8, 0x8b, 0xfe, 0x06, 0xbf, 0xce, 0x0f, 0x40, 0x4d,
-	0xe5, 0x86, 0x6b, 0xe5, 0xba, 0xb4, 0x7c, 0xcc, 0xb8, 0x4b, 0xcb, 0x1c, 0xf4, 0x39, 0x6c, 0xc8,
-	0x46, 0x20, 0xbb, 0x04, 0xa9, 0x62, 0xc8, 0x25, 0x8a, 0x29, 0x66, 0x8a, 0x79, 0x1f, 0x2a, 0x49,
-	0xde, 0x65, 0xaf, 0xf3, 0xce, 0x65, 0x2d, 0x0b, 0x22, 0x58, 0x82, 0xd4, 0x43, 0xd8, 0x58, 0x99,
-	0xa3, 0x2d, 0x80, 0x89, 0xb7, 0x98, 0x1f, 0x5b, 0x71, 0xcb, 0x4b, 0xb6, 0xd7, 0xd8, 0x92, 0x45,
-	0xf0, 0x71, 0xbc, 0x9f, 0xf1, 0x20, 0x51, 0x30, 0x0e, 0x84, 0x75, 0xe1, 0xfb, 0x3c, 0x88, 0x35,
-	0x2c, 0x07, 0x19, 0x77, 0x65, 0x89, 0xbb, 0xee, 0xc0, 0xdb, 0x2b, 0x9b, 0xc4, 0xe4, 0xe6, 0x2a,
-	0x4e, 0x71, 0xa5, 0xe2, 0xd0, 0x0f, 0x2e, 0xe6, 0xf5, 0x9d, 0xd5, 0x06, 0x30, 0x8d, 0xb7, 0x9c,
-	0xd2, 0x3f, 0x29, 0x50, 0xff, 0xd1, 0x82, 0x07, 0x67, 0x49, 0x6f, 0x4a, 0xef, 0x43, 0x39, 0x8c,
-	0xac, 0x68, 0x11, 0xc6, 0x9d, 0x51, 0x2b, 0x8b, 0x93, 0x03, 0xb6, 0x07, 0x88, 0x62, 0x31, 0x9a,
-	0xfe, 0x10, 0x80, 0x07, 0x81, 0x17, 0x8c, 0xb1, 0xab, 0xba, 0xd0, 0xbe, 0xe7, 0x7d, 0x4d, 0x81,
-	0xc4, 0x9e, 0x4a, 0xe5, 0xc9, 0xa7, 0xc8, 0x07, 0x0e, 0x30, 0x4b, 0x2a, 0x93, 0x03, 0xda, 0x16,
-	0x7c, 0x02, 0x7b, 0x7e, 0x8a, 0x69, 0xca, 0x5d, 0xd0, 0x01, 0xda, 0x77, 0xac, 0xc8, 0xda, 0x2d,
-	0xb0, 0x18, 0x25, 0xf0, 0x4f, 0xf8, 0x34, 0xf2, 0x02, 0xac, 0x40, 0x39, 0xfc, 0x63, 0xb4, 0x27,
-	0x78, 0x89, 0xc2, 0xf8, 0x53, 0xcb, 0xb1, 0x02, 0xfc, 0xf9, 0xcd, 0xc7, 0x47, 0x7b, 0x1a, 0x1f,
-	0x47, 0x02, 0xef, 0x5a, 0x51, 0x60, 0x3f, 0xc5, 0xf2, 0x95, 0xc3, 0x1f, 0xa2, 0x3d, 0xc1, 0x4b,
-	0x94, 0xfe, 0x1e, 0x94, 0x65, 0xa6, 0x44, 0xad, 0x37, 0x19, 0xeb, 0x33, 0xd9, 0xd2, 0x0d, 0x46,
-	0xdd, 0xae, 0x39, 0x18, 0x68, 0x44, 0x16, 0x7e, 0xfd, 0x77, 0x04, 0xd4, 0x34, 0x2d, 0xa2, 0x57,
-	0xeb, 0xf5, 0x7b, 0xa6, 0x84, 0x0e, 0xf7, 0x0e, 0xcd, 0xfe, 0x68, 0xa8, 0x11, 0xd1, 0xb8, 0x75,
-	0x8d, 0x5e, 0xd7, 0x3c, 0x30, 0x77, 0x64, 0x03, 0x68, 0xfe, 0xd8, 0xec, 0x8e, 0x86, 0x7b, 0xfd,
-	0x9e, 0x56, 0x12, 0x93, 0x1d, 0x63, 0x67, 0xbc, 0x63, 0x0c, 0x0d, 0x4d, 0x11, 0xa3, 0x3d, 0xd1,
-	0x33, 0xf6, 0x8c, 0x03, 0x6d, 0x8d, 0x6e, 0xc0, 0xfa, 0xa8, 0x67, 0x3c, 0x36, 0xf6, 0x0e, 0x8c,
-	0xce, 0x81, 0xa9, 0x95, 0x85, 0x6f, 0xaf, 0x3f, 0x1c, 0x3f, 0xec, 0x8f, 0x7a, 0x3b, 0x5a, 0x45,
-	0x34, 0x8f, 0x62, 0x68, 0x74, 0xbb, 0xe6, 0xd1, 0x10, 0x21, 0xd5, 0xf8, 0x07, 0xa9, 0x0c, 0x8a,
-	0xe8, 0x83, 0x75, 0x13, 0x20, 0xcb, 0x77, 0xbe, 0xcd, 0x56, 0xaf, 0x6a, 0xcb, 0x2e, 0x56, 0x00,
-	0xfd, 0x97, 0x04, 0x20, 0x3b, 0x07, 0x7a, 0x3f, 0x7b, 0xb7, 0xc8, 0x16, 0xf1, 0xe6, 0xea, 0x71,
-	0x5d, 0xfe, 0x7a, 0xf9, 0x41, 0xee, 0x15, 0x52, 0x5c, 0xbd, 0xd2, 0xd2, 0xf5, 0x3f, 0xbd, 0x45,
-	0xc6, 0x50, 0x5b, 0x8e, 0x2f, 0x4a, 0x9d, 0xec, 0xdd, 0x91, 0x87, 0xca, 0xe2, 0xd1, 0xff, 0xdf,
-	0x7f, 0xfe, 0x86, 0xc0, 0xc6, 0x0a, 0x8d, 0x2b, 0x17, 0xc9, 0x95, 0xc5, 0xe2, 0x1b, 0x94, 0xc5,
-	0xc2, 0xd2, 0x1d, 0x7e, 0x13, 0x32, 0xe2, 0xf0, 0x52, 0x31, 0x5f, 0xfe, 0x46, 0x7a, 0x93, 0xc3,
-	0xeb, 0x00, 0x64, 0x1a, 0xa7, 0xdf, 0x85, 0x72, 0xee, 0xe9, 0x7f, 0x73, 0xf5, 0x26, 0xc4, 0x8f,
-	0x7f, 0x49, 0x38, 0xc6, 0xea, 0x7f, 0x20, 0x50, 0x5b, 0x9e, 0xbe, 0x32, 0x29, 0xff, 0xfb, 0x93,
-	0xb6, 0x93, 0x13, 0x85, 0xac, 0xf3, 0xef, 0x5e, 0x95, 0x47, 0x7c, 0x7b, 0x5c, 0xd0, 0x45, 0xe7,
-	0xfb, 0xcf, 0x5f, 0xb6, 0x0a, 0x9f, 0xbf, 0x6c, 0x15, 0x5e, 0xbf, 0x6c, 0x91, 0x5f, 0x9c, 0xb7,
-	0xc8, 0x9f, 0xcf, 0x5b, 0xe4, 0xd9, 0x79, 0x8b, 0x3c, 0x3f, 0x6f, 0x91, 0x7f, 0x9c, 0xb7, 0xc8,
-	0x17, 0xe7, 0xad, 0xc2, 0xeb, 0xf3, 0x16, 0xf9, 0xed, 0xab, 0x56, 0xe1, 0xf9, 0xab, 0x56, 0xe1,
-	0xf3, 0x57, 0xad, 0xc2, 0x4f, 0x2a, 0xf8, 0x07, 0x8b, 0x3f, 0x99, 0x94, 0xf1, 0xaf, 0x92, 0xf7,
-	0xff, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x65, 0xcf, 0xac, 0x82, 0x72, 0x11, 0x00, 0x00,
+	// 1846 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x6f, 0x23, 0x49,
+	0xf5, 0x77, 0xdb, 0x1d, 0xdb, 0xfd, 0x62, 0x3b, 0x3d, 0xb5, 0xa3, 0xfd, 0x7a, 0xa3, 0x1d, 0x27,
+	0xd3, 0x5f, 0xb1, 0x04, 0x04, 0x1e, 0x34, 0x0b, 0xb3, 0xda, 0xd5, 0x20, 0x68, 0x3b, 0x9d, 0x49,
+	0xb2, 0x89, 0x9d, 0x2d, 0xdb, 0xb3, 0x2c, 0x17, 0xab, 0xe2, 0x54, 0xe2, 0xd6, 0xf6, 0x2f, 0xba,
+	0xcb, 0xb3, 0x13, 0x4e, 0x5c, 0x40, 0x88, 0x03, 0xe2, 0xc2, 0x05, 0x71, 0xe3, 0xc2, 0xff, 0xc1,
+	0x65, 0x24, 0x84, 0x34, 0xc7, 0x15, 0x87, 0x11, 0x93, 0xb9, 0x2c, 0x9c, 0xf6, 0xc0, 0x89, 0x13,
+	0xaa, 0xaa, 0xfe, 0xe9, 0x24, 0x30, 0x40, 0x6e, 0xfd, 0xde, 0xfb, 0xbc, 0xd7, 0xaf, 0xaa, 0x3e,
+	0xef, 0xf5, 0xab, 0x86, 0x55, 0xd7, 0x76, 0xed, 0xb0, 0x1b, 0x84, 0x3e, 0xf3, 0x51, 0x7d, 0xe6,
+	0x87, 0x8c, 0x3e, 0x0d, 0x8e, 0xd7, 0xbf, 0x79, 0x66, 0xb3, 0xf9, 0xe2, 0xb8, 0x3b, 0xf3, 0xdd,
+	0x7b, 0x67, 0xfe, 0x99, 0x7f, 0x4f, 0x00, 0x8e, 0x17, 0xa7, 0x42, 0x12, 0x82, 0x78, 0x92, 0x8e,
+	0xc6, 0x2f, 0x2b, 0xd0, 0xf8, 0x38, 0xb4, 0x19, 0xc5, 0xf4, 0x47, 0x0b, 0x1a, 0x31, 0x74, 0x04,
+	0xc0, 0x6c, 0x97, 0x46, 0x34, 0xb4, 0x69, 0xd4, 0x56, 0x36, 0x2b, 0x5b, 0xab, 0xf7, 0x6f, 0x77,
+	0x93, 0xf0, 0xdd, 0xb1, 0xed, 0xd2, 0x91, 0xb0, 0xf5, 0xd6, 0x9f, 0xbd, 0xd8, 0x28, 0xfd, 0xf9,
+	0xc5, 0x06, 0x3a, 0x0a, 0x29, 0x71, 0x1c, 0x7f, 0x36, 0x4e, 0xfd, 0x70, 0x2e, 0x06, 0x7a, 0x1f,
+	0xaa, 0x23, 0x7f, 0x11, 0xce, 0x68, 0xbb, 0xbc, 0xa9, 0x6c, 0xb5, 0xee, 0xdf, 0xcd, 0xa2, 0xe5,
+	0xdf, 0xdc, 0x95, 0x20, 0xcb, 0x5b, 0xb8, 0x38, 0x76, 0x40, 0x1f, 0x40, 0xdd, 0xa5, 0x8c, 0x9c,
+	0x10, 0x46, 0xda, 0x15, 0x91, 0x4a, 0x3b, 0x73, 0x3e, 0xa4, 0x2c, 0xb4, 0x67, 0x87, 0xb1, 0xbd,
+	0xa7, 0x3e, 0x7b, 0xb1, 0xa1, 0xe0, 0x14, 0x8f, 0x1e, 0xc2, 0x7a, 0xf4, 0xa9, 0x1d, 0x4c, 0x1d,
+	0x72, 0x4c, 0x9d, 0xa9, 0x47, 0x5c, 0x3a, 0x7d, 0x42, 0x1c, 0xfb, 0x84, 0x30, 0xdb, 0xf7, 0xda,
+	0x5f, 0xd4, 0x36, 0x95, 0xad, 0x3a, 0xfe, 0x3f, 0x0e, 0x39, 0xe0, 0x88, 0x01, 0x71, 0xe9, 0xe3,
+	0xd4, 0x8e, 0xde, 0x81, 0x16, 0x7d, 0x4a, 0xdd, 0xc0, 0x21, 0x61, 0x34, 0xf5, 0x3d, 0xe7, 0xbc,
+	0xfd, 0x57, 0xe9, 0xd1, 0x4c, 0xd5, 0x43, 0xcf, 0x39, 0x47, 0x5d, 0x40, 0x01, 0x09, 0x99, 0x4d,
+	0x9c, 0x29, 0x99, 0xcd, 0x68, 0xc0, 0x88, 0x37, 0xa3, 0xed, 0xbf, 0x49, 0xec, 0xad, 0xd8, 0x64,
+	0xa6, 0x16, 0x63, 0x03, 0x20, 0x5b, 0x27, 0xaa, 0x41, 0xc5, 0x3c, 0xda, 0xd3, 0x4b, 0xa8, 0x0e,
+	0x2a, 0x9e, 0x1c, 0x58, 0xba, 0x62, 0x60, 0x68, 0xc6, 0xbb, 0x12, 0x05, 0xbe, 0x17, 0x51, 0x64,
+	0x42, 0xf3, 0x94, 0xd8, 0x0e, 0x3d, 0x99, 0x16, 0xce, 0xe4, 0xcd, 0x6c, 0x23, 0x76, 0x84, 0x39,
+	0x3e, 0x15, 0xbe, 0x0d, 0x25, 0xdc, 0x38, 0xcd, 0xe9, 0x8c, 0x8f, 0xa0, 0x91, 0xc7, 0x20, 0x13,
+	0xaa, 0x62, 0x57, 0x92, 0x58, 0x6f, 0x64, 0xb1, 0xc4, 0x5e, 0x1c, 0x11, 0x3b, 0xec, 0xdd, 0x8e,
+	0x8f, 0xb7, 0x21, 0x54, 0xe6, 0x09, 0x09, 0x18, 0x0d, 0x71, 0xec, 0x68, 0xfc, 0x5d, 0x01, 0xc8,
+	0xb8, 0x70, 0x03, 0x11, 0xd1, 0xb7, 0xa0, 0x16, 0x11, 0x37, 0x70, 0x68, 0xd4, 0x2e, 0x8b, 0x18,
+	0x7a, 0x16, 0x63, 0x24, 0x0c, 0xf1, 0xda, 0x12, 0x18, 0x7a, 0x00, 0x5a, 0x7a, 0x18, 0x31, 0x3d,
+	0x50, 0xe6, 0x63, 0xc5, 0xa6, 0xd8, 0x2b, 0x83, 0xa2, 0xf7, 0x01, 0xe6, 0x76, 0xc4, 0xfc, 0xb3,
+	0x90, 0xb8, 0x51, 0x5b, 0x5d, 0x4e, 0x78, 0x37, 0xb1, 0xc5, 0x9e, 0x39, 0xb0, 0xf1, 0x1d, 0xd0,
+	0xd2, 0xf5, 0x20, 0x04, 0x2a, 0xa7, 0x55, 0x5b, 0xd9, 0x54, 0xb6, 0x1a, 0x58, 0x3c, 0xa3, 0xdb,
+	0xb0, 0xf2, 0x84, 0x38, 0x0b, 0xc9, 0xf5, 0x06, 0x96, 0x82, 0x61, 0x42, 0x55, 0x2e, 0x01, 0xdd,
+	0x85, 0x86, 0x28, 0x0d, 0x46, 0xdc, 0x60, 0xea, 0x46, 0x02, 0x56, 0xc1, 0xab, 0xa9, 0xee, 0x30,
+	0xca, 0x42, 0xf0, 0xb8, 0x4a, 0x12, 0xe2, 0x37, 0x65, 0x68, 0x15, 0x19, 0x8f, 0xde, 0x03, 0x95,
+	0x9d, 0x07, 0x12, 0xd7, 0xba, 0xff, 0xff, 0xd7, 0x55, 0x46, 0x2c, 0x8e, 0xcf, 0x03, 0x8a, 0x85,
+	0x03, 0xfa, 0x06, 0x20, 0x57, 0xe8, 0xa6, 0xa7, 0xc4, 0xb5, 0x9d, 0x73, 0x51, 0x1d, 0x22, 0x15,
+	0x0d, 0xeb, 0xd2, 0xb2, 0x23, 0x0c, 0xbc, 0x28, 0xf8, 0x32, 0xe7, 0xd4, 0x09, 0xda, 0xaa, 0xb0,
+	0x8b, 0x67, 0xae, 0x5b, 0x78, 0x36, 0x6b, 0xaf, 0x48, 0x1d, 0x7f, 0x36, 0xce, 0x01, 0xb2, 0x37,
+	0xa1, 0x55, 0xa8, 0x4d, 0x06, 0x1f, 0x0e, 0x86, 0x1f, 0x0f, 0xf4, 0x12, 0x17, 0xfa, 0xc3, 0xc9,
+	0x60, 0x6c, 0x61, 0x5d, 0x41, 0x1a, 0xac, 0x3c, 0x32, 0x27, 0x8f, 0x2c, 0xbd, 0x8c, 0x9a, 0xa0,
+	0xed, 0xee, 0x8d, 0xc6, 0xc3, 0x47, 0xd8, 0x3c, 0xd4, 0x2b, 0x08, 0x41, 0x4b, 0x58, 0x32, 0x9d,
+	0xca, 0x5d, 0x47, 0x93, 0xc3, 0x43, 0x13, 0x7f, 0xa2, 0xaf, 0xf0, 0x32, 0xd9, 0x1b, 0xec, 0x0c,
+	0xf5, 0x2a, 0x6a, 0x40, 0x7d, 0x34, 0x36, 0xc7, 0xd6, 0xc8, 0x1a, 0xeb, 0x35, 0xe3, 0x43, 0xa8,
+	0xca, 0x57, 0xdf, 0x04, 0xb5, 0x7f, 0xa6, 0x40, 0x3d, 0x21, 0xcf, 0x4d, 0x10, 0xbb, 0x40, 0x89,
+	0xe4, 0x3c, 0x2f, 0x11, 0xa1, 0x72, 0x89, 0x08, 0xc6, 0x1f, 0x57, 0x40, 0x4b, 0xc9, 0x88, 0xee,
+	0x80, 0x36, 0xf3, 0x17, 0x1e, 0x9b, 0xda, 0x1e, 0x13, 0x47, 0xae, 0xee, 0x96, 0x70, 0x5d, 0xa8,
+	0xf6, 0x3c, 0x86, 0xee, 0xc2, 0xaa, 0x34, 0x9f, 0x3a, 0x3e, 0x61, 0xf2, 0x5d, 0xbb, 0x25, 0x0c,
+	0x42, 0xb9, 0xc3, 0x75, 0x48, 0x87, 0x4a, 0xb4, 0x70, 0xc5, 0x9b, 0x14, 0xcc, 0x1f, 0xd1, 0x9b,
+	0x50, 0x8d, 0x66, 0x73, 0xea, 0x12, 0x71, 0xb8, 0xb7, 0x70, 0x2c, 0xa1, 0xaf, 0x40, 0xeb, 0xc7,
+	0x34, 0xf4, 0xa7, 0x6c, 0x1e, 0xd2, 0x68, 0xee, 0x3b, 0x27, 0xe2, 0xa0, 0x15, 0xdc, 0xe4, 0xda,
+	0x71, 0xa2, 0xe4, 0x4d, 0x52, 0xc0, 0xb2, 0xbc, 0xaa, 0x22, 0x2f, 0x05, 0x37, 0xb8, 0xbe, 0x9f,
+	0xe4, 0xf6, 0x75, 0xd0, 0x73, 0x38, 0x99, 0x60, 0x4d, 0x24, 0xa8, 0xe0, 0x56, 0x8a, 0x94, 0x49,
+	0x9a, 0xd0, 0xf2, 0xe8, 0x19, 0x61, 0xf6, 0x13, 0x3a, 0x8d, 0x02, 0xe2, 0x45, 0xed, 0xfa, 0xf2,
+	0x37, 0xa8, 0xb7, 0x98, 0x7d, 0x4a, 0xd9, 0x28, 0x20, 0x5e, 0x5c, 0xa1, 0xcd, 0xc4, 0x83, 0xeb,
+	0x22, 0xf4, 0x55, 0x58, 0x4b, 0x43, 0x9c, 0x50, 0x87, 0x91, 0xa8, 0xad, 0x6d, 0x56, 0xb6, 0x10,
+	0x4e, 0x23, 0x6f, 0x0b, 0x6d, 0x01, 0x28, 0x72, 0x8b, 0xda, 0xb0, 0x59, 0xd9, 0x52, 0x32, 0xa0,
+	0x48, 0x8c, 0xb7, 0xb7, 0x56, 0xe0, 0x47, 0x76, 0x2e, 0xa9, 0xd5, 0x7f, 0x9f, 0x54, 0xe2, 0x91,
+	0x26, 0x95, 0x86, 0x88, 0x93, 0x6a, 0xc8, 0xa4, 0x12, 0x75, 0x96, 0x54, 0x0a, 0x8c, 0x93, 0x6a,
+	0xca, 0xa4, 0x12, 0x75, 0x9c, 0xd4, 0x43, 0x80, 0x90, 0x46, 0x94, 0x4d, 0xe7, 0x7c, 0xe7, 0x5b,
+	0xa2, 0x09, 0xdc, 0xb9, 0xa2, 0x8d, 0x75, 0x31, 0x47, 0xed, 0xda, 0x1e, 0xc3, 0x5a, 0x98, 0x3c,
+	0xa2, 0xb7, 0x41, 0x4b, 0xb9, 0xd6, 0x5e, 0x13, 0xe4, 0xcb, 0x14, 0xc6, 0x07, 0xa0, 0xa5, 0x5e,
+	0xc5, 0x52, 0xae, 0x41, 0xe5, 0x13, 0x6b, 0xa4, 0x2b, 0xa8, 0x0a, 0xe5, 0xc1, 0x50, 0x2f, 0x67,
+	0xe5, 0x5c, 0x59, 0x57, 0x7f, 0xfe, 0xbb, 0x8e, 0xd2, 0xab, 0xc1, 0x8a, 0xc8, 0xbb, 0xd7, 0x00,
+	0xc8, 0x8e, 0xdd, 0xf8, 0x93, 0x0a, 0x2d, 0x71, 0xc4, 0x19, 0xa5, 0x23, 0x40, 0xc2, 0x46, 0xc3,
+	0xe9, 0xd2, 0x4a, 0x9a, 0x3d, 0xeb, 0x1f, 0x2f, 0x36, 0xcc, 0xdc, 0x2c, 0x13, 0x84, 0xbe, 0x4b,
+	0xd9, 0x9c, 0x2e, 0xa2, 0xfc, 0xa3, 0xeb, 0x9f, 0x50, 0xe7, 0x5e, 0xda, 0xa0, 0xbb, 0x7d, 0x19,
+	0x2e, 0x5b, 0xb1, 0x3e, 0x5b, 0xd2, 0xfc, 0xaf, 0x9c, 0xbf, 0x93, 0x5f, 0x94, 0x64, 0x31, 0xd6,
+	0x52, 0x0e, 0xf3, 0x62, 0x97, 0x96, 0xb8, 0xd8, 0x85, 0x70, 0x45, 0xe5, 0xdd, 0x00, 0xa3, 0x6e,
+	0xa0, 0x52, 0xbe, 0x06, 0x7a, 0x9a, 0xc5, 0xb1, 0xc0, 0x26, 0x64, 0x4b, 0x39, 0x28, 0x43, 0x08,
+	0x68, 0xfa, 0xb6, 0x04, 0x2a, 0x8b, 0x25, 0xad, 0xa1, 0x18, 0xba, 0xaf, 0xd6, 0x15, 0xbd, 0xbc,
+	0xaf, 0xd6, 0xab, 0x7a, 0x6d, 0x5f, 0xad, 0x6b, 0x3a, 0xec, 0xab, 0xf5, 0x86, 0xde, 0xdc, 0x57,
+	0xeb, 0x6b, 0xba, 0x8e, 0xb3, 0x2e, 0x86, 0x97, 0xba, 0x07, 0x5e, 0x2e, 0x5b, 0xbc, 0x5c, 0x32,
+	0x79, 0x8a, 0x3e, 0x04, 0xc8, 0x96, 0xc7, 0x4f, 0xd5, 0x3f, 0x3d, 0x8d, 0xa8, 0x6c, 0x8d, 0xb7,
+	0x70, 0x2c, 0x71, 0xbd, 0x43, 0xbd, 0x33, 0x36, 0x17, 0x07, 0xd2, 0xc4, 0xb1, 0x64, 0x2c, 0x00,
+	0x15, 0xc9, 0x28, 0xbe, 0xe8, 0xaf, 0xf1, 0x75, 0x7e, 0x08, 0x5a, 0x4a, 0x37, 0xf1, 0xae, 0xc2,
+	0x4c, 0x5a, 0x8c, 0x19, 0xcf, 0xa4, 0x99, 0x83, 0xe1, 0xc1, 0x9a, 0x1c, 0x04, 0xb2, 0x22, 0x48,
+	0x19, 0xa3, 0x5c, 0xc1, 0x98, 0x72, 0xc6, 0x98, 0x77, 0xa1, 0x96, 0xec, 0xbb, 0x9c, 0x75, 0xde,
+	0xba, 0x6a, 0x64, 0x11, 0x08, 0x9c, 0x20, 0x8d, 0x08, 0xd6, 0x96, 0x6c, 0xa8, 0x03, 0x70, 0xec,
+	0x2f, 0xbc, 0x13, 0x12, 0x0f, 0x93, 0xca, 0xd6, 0x0a, 0xce, 0x69, 0x78, 0x3e, 0x8e, 0xff, 0x19,
+	0x0d, 0x13, 0x06, 0x0b, 0x81, 0x6b, 0x17, 0x41, 0x40, 0xc3, 0x98, 0xc3, 0x52, 0xc8, 0x72, 0x57,
+	0x73, 0xb9, 0x1b, 0x0e, 0xbc, 0xb1, 0xb4, 0x48, 0xb1, 0xb9, 0x85, 0x8e, 0x53, 0x5e, 0xea, 0x38,
+	0xe8, 0xbd, 0xcb, 0xfb, 0xfa, 0xd6, 0xf2, 0x00, 0x98, 0xc6, 0xcb, 0x6f, 0xe9, 0x1f, 0x54, 0x68,
+	0x7e, 0xb4, 0xa0, 0xe1, 0x79, 0x3a, 0x31, 0x3f, 0x80, 0x6a, 0xc4, 0x08, 0x5b, 0x44, 0xf1, 0x64,
+	0xd4, 0xc9, 0xe2, 0x14, 0x80, 0xdd, 0x91, 0x40, 0xe1, 0x18, 0x8d, 0xbe, 0x0f, 0x40, 0xc3, 0xd0,
+	0x0f, 0xa7, 0x62, 0xaa, 0xba, 0x74, 0x59, 0x29, 0xfa, 0x5a, 0x1c, 0x29, 0x66, 0x2a, 0x8d, 0x26,
+	0x8f, 0x7c, 0x3f, 0x84, 0x20, 0x76, 0x49, 0xc3, 0x52, 0x40, 0x5d, 0x9e, 0x4f, 0x68, 0x7b, 0x67,
+	0x62, 0x9b, 0x0a, 0x05, 0x3a, 0x12, 0xfa, 0x6d, 0xc2, 0xc8, 0x6e, 0x09, 0xc7, 0x28, 0x8e, 0x7f,
+	0x42, 0x67, 0xcc, 0x0f, 0x45, 0x07, 0x2a, 0xe0, 0x1f, 0x0b, 0x7d, 0x82, 0x97, 0x28, 0x11, 0x7f,
+	0x46, 0x1c, 0x12, 0x8a, 0xcf, 0x6f, 0x31, 0xbe, 0xd0, 0xa7, 0xf1, 0x85, 0xc4, 0xf1, 0x2e, 0x61,
+	0xa1, 0xfd, 0x54, 0xb4, 0xaf, 0x02, 0xfe, 0x50, 0xe8, 0x13, 0xbc, 0x44, 0xa1, 0x75, 0xa8, 0x7f,
+	0x46, 0x42, 0xcf, 0xf6, 0xce, 0x64, 0x8b, 0xd1, 0x70, 0x2a, 0x1b, 0xef, 0x40, 0x55, 0xee, 0x22,
+	0xff, 0x0e, 0x58, 0x18, 0x0f, 0xb1, 0x1c, 0xf7, 0x46, 0x93, 0x7e, 0xdf, 0x1a, 0x8d, 0x74, 0x45,
+	0x7e, 0x14, 0x8c, 0x5f, 0x2b, 0xa0, 0xa5, 0x5b, 0xc6, 0xe7, 0xb8, 0xc1, 0x70, 0x60, 0x49, 0xe8,
+	0x78, 0xef, 0xd0, 0x1a, 0x4e, 0xc6, 0xba, 0xc2, 0x87, 0xba, 0xbe, 0x39, 0xe8, 0x5b, 0x07, 0xd6,
+	0xb6, 0x1c, 0x0e, 0xad, 0x1f, 0x58, 0xfd, 0xc9, 0x78, 0x6f, 0x38, 0xd0, 0x2b, 0xdc, 0xd8, 0x33,
+	0xb7, 0xa7, 0xdb, 0xe6, 0xd8, 0xd4, 0x55, 0x2e, 0xed, 0xf1, 0x79, 0x72, 0x60, 0x1e, 0xe8, 0x2b,
+	0x68, 0x0d, 0x56, 0x27, 0x03, 0xf3, 0xb1, 0xb9, 0x77, 0x60, 0xf6, 0x0e, 0x2c, 0xbd, 0xca, 0x7d,
+	0x07, 0xc3, 0xf1, 0x74, 0x67, 0x38, 0x19, 0x6c, 0xeb, 0x35, 0x3e, 0x58, 0x72, 0xd1, 0xec, 0xf7,
+	0xad, 0xa3, 0xb1, 0x80, 0xd4, 0xe3, 0x8f, 0x55, 0x15, 0x54, 0x3e, 0x23, 0x1b, 0x16, 0x40, 0x76,
+	0x16, 0xc5, 0x11, 0x5c, 0xbb, 0x6e, 0x64, 0xbb, 0xdc, 0x1d, 0x8c, 0x9f, 0x2a, 0x00, 0xd9, 0x19,
+	0xa1, 0x07, 0xd9, 0x9d, 0xe6, 0xd2, 0xad, 0x4d, 0xc2, 0xae, 0xbe, 0xd9, 0x7c, 0xaf, 0x70, 0x43,
+	0x29, 0x2f, 0x97, 0xbb, 0x74, 0xfd, 0x57, 0xf7, 0x94, 0x29, 0x34, 0xf2, 0xf1, 0x79, 0x1b, 0x94,
+	0x73, 0xbd, 0xc8, 0x43, 0xc3, 0xb1, 0xf4, 0xdf, 0xcf, 0xa6, 0xbf, 0x50, 0x60, 0x6d, 0x29, 0x8d,
+	0x6b, 0x5f, 0x52, 0x68, 0x99, 0xe5, 0xd7, 0x68, 0x99, 0xa5, 0x5c, 0x7d, 0xbf, 0x4e, 0x32, 0xfc,
+	0xf0, 0x52, 0xa2, 0x5f, 0x7d, 0x7f, 0x7a, 0x9d, 0xc3, 0xeb, 0x01, 0x64, 0xfc, 0x47, 0xdf, 0x86,
+	0xea, 0x75, 0x17, 0x6e, 0x89, 0x2a, 0x5c, 0xb8, 0x63, 0xac, 0xf1, 0x5b, 0x05, 0x1a, 0x79, 0xf3,
+	0xb5, 0x9b, 0xf2, 0x9f, 0x5f, 0x77, 0x7b, 0x05, 0x52, 0xc8, 0x6f, 0xc0, 0xdb, 0xd7, 0xed, 0xa3,
+	0xb8, 0x97, 0x5c, 0xe2, 0x45, 0xef, 0xbb, 0xcf, 0x5f, 0x76, 0x4a, 0x9f, 0xbf, 0xec, 0x94, 0xbe,
+	0x7c, 0xd9, 0x51, 0x7e, 0x72, 0xd1, 0x51, 0x7e, 0x7f, 0xd1, 0x51, 0x9e, 0x5d, 0x74, 0x94, 0xe7,
+	0x17, 0x1d, 0xe5, 0x2f, 0x17, 0x1d, 0xe5, 0x8b, 0x8b, 0x4e, 0xe9, 0xcb, 0x8b, 0x8e, 0xf2, 0xab,
+	0x57, 0x9d, 0xd2, 0xf3, 0x57, 0x9d, 0xd2, 0xe7, 0xaf, 0x3a, 0xa5, 0x1f, 0xd6, 0xc4, 0xaf, 0xa6,
+	0xe0, 0xf8, 0xb8, 0x2a, 0x7e, 0x1a, 0xbd, 0xfb, 0xcf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x45,
+	0x73, 0xab, 0x7c, 0x12, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -1976,6 +2057,12 @@ func (this *WriteRequest) Equal(that interface{}) bool {
 	if this.SkipLabelNameValidation != that1.SkipLabelNameValidation {
 		return false
 	}
+	if this.ExemplarsOnly != that1.ExemplarsOnly {
+		return false
+	}
+	if this.PartialAcceptance != that1.PartialAcceptance {
+		return false
+	}
 	return true
 }
 func (this *WriteResponse) Equal(that interface{}) bool {
@@ -1997,6 +2084,43 @@ func (this *WriteResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if len(this.FailedSeries) != len(that1.FailedSeries) {
+		return false
+	}
+	for i := range this.FailedSeries {
+		if !this.FailedSeries[i].Equal(&that1.FailedSeries[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *FailedSeries) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*FailedSeries)
+	if !ok {
+		that2, ok := that.(FailedSeries)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *TimeSeries) Equal(that interface{}) bool {
@@ -2658,6 +2782,14 @@ func (this *QueryResponse) Equal(that interface{}) bool {
 	} else if !this.Data.Equal(that1.Data) {
 		return false
 	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *QueryResponse_String_) Equal(that interface{}) bool {
@@ -2995,7 +3127,7 @@ func (this *WriteRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 10)
 	s = append(s, "&mimirpb.WriteRequest{")
 	s = append(s, "Timeseries: "+fmt.Sprintf("%#v", this.Timeseries)+",\n")
 	s = append(s, "Source: "+fmt.Sprintf("%#v", this.Source)+",\n")
@@ -3003,6 +3135,8 @@ func (this *WriteRequest) GoString() string {
 		s = append(s, "Metadata: "+fmt.Sprintf("%#v", this.Metadata)+",\n")
 	}
 	s = append(s, "SkipLabelNameValidation: "+fmt.Sprintf("%#v", this.SkipLabelNameValidation)+",\n")
+	s = append(s, "ExemplarsOnly: "+fmt.Sprintf("%#v", this.ExemplarsOnly)+",\n")
+	s = append(s, "PartialAcceptance: "+fmt.Sprintf("%#v", this.PartialAcceptance)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3010,8 +3144,25 @@ func (this *WriteResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 4)
+	s := make([]string, 0, 5)
 	s = append(s, "&mimirpb.WriteResponse{")
+	if this.FailedSeries != nil {
+		vs := make([]*FailedSeries, len(this.FailedSeries))
+		for i := range vs {
+			vs[i] = &this.FailedSeries[i]
+		}
+		s = append(s, "FailedSeries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *FailedSeries) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&mimirpb.FailedSeries{")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3272,7 +3423,7 @@ func (this *QueryResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 12)
 	s = append(s, "&mimirpb.QueryResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	s = append(s, "ErrorType: "+fmt.Sprintf("%#v", this.ErrorType)+",\n")
@@ -3280,6 +3431,7 @@ func (this *QueryResponse) GoString() string {
 	if this.Data != nil {
 		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
 	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3452,6 +3604,30 @@ func (m *WriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.PartialAcceptance {
+		i--
+		if m.PartialAcceptance {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x3e
+		i--
+		dAtA[i] = 0xd0
+	}
+	if m.ExemplarsOnly {
+		i--
+		if m.ExemplarsOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x3e
+		i--
+		dAtA[i] = 0xc8
+	}
 	if m.SkipLabelNameValidation {
 		i--
 		if m.SkipLabelNameValidation {
@@ -3520,6 +3696,57 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.FailedSeries) > 0 {
+		for iNdEx := len(m.FailedSeries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.FailedSeries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintMimir(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FailedSeries) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FailedSeries) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FailedSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintMimir(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
 	return len(dAtA) - i, nil
 }
 
@@ -4340,6 +4567,15 @@ func (m *QueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintMimir(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x42
+		}
+	}
 	if m.Data != nil {
 		{
 			size := m.Data.Size()
@@ -4791,6 +5027,12 @@ func (m *WriteRequest) Size() (n int) {
 	if m.SkipLabelNameValidation {
 		n += 3
 	}
+	if m.ExemplarsOnly {
+		n += 3
+	}
+	if m.PartialAcceptance {
+		n += 3
+	}
 	return n
 }
 
@@ -4800,6 +5042,27 @@ func (m *WriteResponse) Size() (n int) {
 	}
 	var l int
 	_ = l
+	if len(m.FailedSeries) > 0 {
+		for _, e := range m.FailedSeries {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *FailedSeries) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -5179,6 +5442,12 @@ func (m *QueryResponse) Size() (n int) {
 	if m.Data != nil {
 		n += m.Data.Size()
 	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -5385,6 +5654,8 @@ func (this *WriteRequest) String() string {
 		`Source:` + fmt.Sprintf("%v", this.Source) + `,`,
 		`Metadata:` + repeatedStringForMetadata + `,`,
 		`SkipLabelNameValidation:` + fmt.Sprintf("%v", this.SkipLabelNameValidation) + `,`,
+		`ExemplarsOnly:` + fmt.Sprintf("%v", this.ExemplarsOnly) + `,`,
+		`PartialAcceptance:` + fmt.Sprintf("%v", this.PartialAcceptance) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5393,7 +5664,23 @@ func (this *WriteResponse) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForFailedSeries := "[]FailedSeries{"
+	for _, f := range this.FailedSeries {
+		repeatedStringForFailedSeries += strings.Replace(strings.Replace(f.String(), "FailedSeries", "FailedSeries", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForFailedSeries += "}"
 	s := strings.Join([]string{`&WriteResponse{`,
+		`FailedSeries:` + repeatedStringForFailedSeries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *FailedSeries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&FailedSeries{`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5656,6 +5943,7 @@ func (this *QueryResponse) String() string {
 		`ErrorType:` + fmt.Sprintf("%v", this.ErrorType) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
 		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5948,6 +6236,46 @@ func (m *WriteRequest) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.SkipLabelNameValidation = bool(v != 0)
+		case 1001:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExemplarsOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ExemplarsOnly = bool(v != 0)
+		case 1002:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartialAcceptance", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PartialAcceptance = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -6001,6 +6329,127 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: WriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailedSeries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FailedSeries = append(m.FailedSeries, FailedSeries{})
+			if err := m.FailedSeries[len(m.FailedSeries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMimir(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FailedSeries) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMimir
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FailedSeries: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FailedSeries: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -8393,6 +8842,38 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Data = &QueryResponse_Matrix{v}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
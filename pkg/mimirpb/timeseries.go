@@ -104,6 +104,12 @@ func (p *PreallocTimeseries) SetLabels(lbls []LabelAdapter) {
 	p.clearUnmarshalData()
 }
 
+// SetLabelValue sets the value of the label at the input index, updating the slice in-place.
+func (p *PreallocTimeseries) SetLabelValue(idx int, value string) {
+	p.Labels[idx].Value = value
+	p.clearUnmarshalData()
+}
+
 // RemoveEmptyLabelValues remove labels with value=="" from this timeseries, updating the slice in-place.
 func (p *PreallocTimeseries) RemoveEmptyLabelValues() {
 	modified := false
@@ -147,6 +153,15 @@ func (p *PreallocTimeseries) ClearExemplars() {
 	p.clearUnmarshalData()
 }
 
+// TakeExemplars removes the exemplars from the series and returns them. The returned exemplars are
+// not reused once the series is returned to the pool.
+func (p *PreallocTimeseries) TakeExemplars() []Exemplar {
+	exemplars := p.Exemplars
+	p.Exemplars = nil
+	p.clearUnmarshalData()
+	return exemplars
+}
+
 // DeleteExemplarByMovingLast deletes the exemplar by moving the last one on top and shortening the slice
 func (p *PreallocTimeseries) DeleteExemplarByMovingLast(ix int) {
 	last := len(p.Exemplars) - 1
@@ -157,6 +172,12 @@ func (p *PreallocTimeseries) DeleteExemplarByMovingLast(ix int) {
 	p.clearUnmarshalData()
 }
 
+// SamplesReordered must be called once the samples or histograms of the series have been reordered in-place,
+// so that the series is marshalled again instead of reusing the original data used for unmarshalling it.
+func (p *PreallocTimeseries) SamplesReordered() {
+	p.clearUnmarshalData()
+}
+
 // clearUnmarshalData removes cached unmarshalled version of the message.
 func (p *PreallocTimeseries) clearUnmarshalData() {
 	p.marshalledData = nil
@@ -89,3 +89,22 @@ func (c *BlocksConsistencyChecker) Check(knownBlocks bucketindex.Blocks, knownDe
 
 	return missingBlocks
 }
+
+// RecentlyUploaded returns the upload time of the blocks uploaded so recently that the store-gateways
+// may have not discovered them yet, and are skipped by the consistency check.
+func (c *BlocksConsistencyChecker) RecentlyUploaded(knownBlocks bucketindex.Blocks) map[ulid.ULID]time.Time {
+	if c.uploadGracePeriod <= 0 {
+		return nil
+	}
+
+	var recent map[ulid.ULID]time.Time
+	for _, block := range knownBlocks {
+		if uploadedAt := block.GetUploadedAt(); time.Since(uploadedAt) < c.uploadGracePeriod {
+			if recent == nil {
+				recent = map[ulid.ULID]time.Time{}
+			}
+			recent[block.ID] = uploadedAt
+		}
+	}
+	return recent
+}
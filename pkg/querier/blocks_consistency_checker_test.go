@@ -124,3 +124,22 @@ func TestBlocksConsistencyChecker_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocksConsistencyChecker_RecentlyUploaded(t *testing.T) {
+	now := time.Now()
+	uploadGracePeriod := 10 * time.Minute
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	knownBlocks := bucketindex.Blocks{
+		{ID: block1, UploadedAt: now.Add(-time.Hour).Unix()},
+		{ID: block2, UploadedAt: now.Add(-time.Minute).Unix()},
+	}
+
+	c := NewBlocksConsistencyChecker(uploadGracePeriod, 0, log.NewNopLogger(), nil)
+	assert.Equal(t, map[ulid.ULID]time.Time{block2: time.Unix(now.Add(-time.Minute).Unix(), 0)}, c.RecentlyUploaded(knownBlocks))
+	assert.Nil(t, c.RecentlyUploaded(knownBlocks[:1]))
+
+	c = NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil)
+	assert.Nil(t, c.RecentlyUploaded(knownBlocks))
+}
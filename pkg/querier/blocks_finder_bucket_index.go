@@ -97,9 +97,9 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 		}
 
 		// Exclude blocks marked for deletion. This is the same logic as Thanos IgnoreDeletionMarkFilter.
+		// The deletion mark is returned anyway, so that the caller knows the data has been deleted.
 		if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > f.cfg.IgnoreDeletionMarksDelay.Seconds() {
 			delete(matchingBlocks, mark.ID)
-			continue
 		}
 
 		matchingDeletionMarks[mark.ID] = mark
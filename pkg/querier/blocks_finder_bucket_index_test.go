@@ -69,13 +69,16 @@ func TestBucketIndexBlocksFinder_GetBlocks(t *testing.T) {
 			expectedBlocks: bucketindex.Blocks{block4, block3, block2, block1},
 			expectedMarks: map[ulid.ULID]*bucketindex.BlockDeletionMark{
 				block3.ID: mark3,
+				block5.ID: mark5,
 			},
 		},
 		"query range starting at a block maxT": {
 			minT:           block3.MaxTime,
 			maxT:           60,
 			expectedBlocks: bucketindex.Blocks{block4},
-			expectedMarks:  map[ulid.ULID]*bucketindex.BlockDeletionMark{},
+			expectedMarks: map[ulid.ULID]*bucketindex.BlockDeletionMark{
+				block5.ID: mark5,
+			},
 		},
 		"query range ending at a block minT": {
 			minT:           block3.MinTime,
@@ -83,6 +86,7 @@ func TestBucketIndexBlocksFinder_GetBlocks(t *testing.T) {
 			expectedBlocks: bucketindex.Blocks{block4, block3},
 			expectedMarks: map[ulid.ULID]*bucketindex.BlockDeletionMark{
 				block3.ID: mark3,
+				block5.ID: mark5,
 			},
 		},
 		"query range within a single block": {
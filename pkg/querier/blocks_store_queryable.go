@@ -34,6 +34,7 @@ import (
 	grpc_metadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/series"
@@ -46,6 +47,7 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/checksum"
 	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	util_log "github.com/grafana/mimir/pkg/util/log"
@@ -60,14 +62,18 @@ const (
 	maxFetchSeriesAttempts = 3
 )
 
+var errDataDeleted = errors.New("data deleted: all the blocks containing samples in the query time range have been deleted, so no data has been returned from the long-term storage")
+
 // BlocksStoreSet is the interface used to get the clients to query series on a set of blocks.
 type BlocksStoreSet interface {
 	services.Service
 
 	// GetClientsFor returns the store gateway clients that should be used to
 	// query the set of blocks in input. The exclude parameter is the map of
-	// blocks -> store-gateway addresses that should be excluded.
-	GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error)
+	// blocks -> store-gateway addresses that should be excluded. The recentlyUploaded
+	// parameter is the map of blocks -> upload time of the blocks which may have not
+	// been discovered by all store-gateways yet.
+	GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, recentlyUploaded map[ulid.ULID]time.Time) (map[BlocksStoreClient][]ulid.ULID, error)
 }
 
 // BlocksFinder is the interface used to find blocks for a given user and time range.
@@ -76,6 +82,8 @@ type BlocksFinder interface {
 
 	// GetBlocks returns known blocks for userID containing samples within the range minT
 	// and maxT (milliseconds, both included). Returned blocks are sorted by MaxTime descending.
+	// Returned deletion marks may include the ones of blocks within the range which are not
+	// returned because they have been marked for deletion long enough to not be queried anymore.
 	GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error)
 }
 
@@ -95,6 +103,7 @@ type BlocksStoreLimits interface {
 
 	MaxLabelsQueryLength(userID string) time.Duration
 	MaxChunksPerQuery(userID string) int
+	CompactorBlocksRetentionPeriod(userID string) time.Duration
 	StoreGatewayTenantShardSize(userID string) int
 }
 
@@ -105,6 +114,8 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+	chunksChecksumsVerified                           prometheus.Counter
+	chunksChecksumMismatches                          prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -134,6 +145,14 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+		chunksChecksumsVerified: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_storegateway_chunks_checksums_verified_total",
+			Help: "Total number of chunks received from store-gateways whose checksum has been verified.",
+		}),
+		chunksChecksumMismatches: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_storegateway_chunks_checksum_mismatches_total",
+			Help: "Total number of chunks received from store-gateways whose checksum didn't match, because they have been corrupted.",
+		}),
 	}
 }
 
@@ -150,6 +169,9 @@ type BlocksStoreQueryable struct {
 	metrics         *blocksStoreQueryableMetrics
 	limits          BlocksStoreLimits
 
+	// If set, the querier verifies the checksum of the chunks received from store-gateways.
+	chunksChecksumsEnabled bool
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -161,6 +183,7 @@ func NewBlocksStoreQueryable(
 	consistency *BlocksConsistencyChecker,
 	limits BlocksStoreLimits,
 	queryStoreAfter time.Duration,
+	chunksChecksumsEnabled bool,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*BlocksStoreQueryable, error) {
@@ -179,6 +202,8 @@ func NewBlocksStoreQueryable(
 		subservicesWatcher: services.NewFailureWatcher(),
 		metrics:            newBlocksStoreQueryableMetrics(reg),
 		limits:             limits,
+
+		chunksChecksumsEnabled: chunksChecksumsEnabled,
 	}
 
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
@@ -243,7 +268,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		return nil, errors.Wrap(err, "failed to create store-gateway ring client")
 	}
 
-	stores, err = newBlocksStoreReplicationSet(storesRing, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, logger, reg)
+	stores, err = newBlocksStoreReplicationSet(storesRing, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, querierCfg.PreferFreshStoreGateways, logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create store set")
 	}
@@ -260,7 +285,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, querierCfg.StoreGatewayChunksChecksumsEnabled, logger, reg)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -311,6 +336,8 @@ func (q *BlocksStoreQueryable) Querier(ctx context.Context, mint, maxt int64) (s
 		consistency:     q.consistency,
 		logger:          q.logger,
 		queryStoreAfter: q.queryStoreAfter,
+
+		chunksChecksumsEnabled: q.chunksChecksumsEnabled,
 	}, nil
 }
 
@@ -328,6 +355,9 @@ type blocksStoreQuerier struct {
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// If set, the querier verifies the checksum of the chunks received from store-gateways.
+	chunksChecksumsEnabled bool
 }
 
 // Select implements storage.Querier interface.
@@ -370,10 +400,11 @@ func (q *blocksStoreQuerier) LabelNames(matchers ...*labels.Matcher) ([]string,
 		return queriedBlocks, nil
 	}
 
-	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
+	warnings, err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
 	if err != nil {
 		return nil, nil, err
 	}
+	resWarnings = append(resWarnings, warnings...)
 
 	return util.MergeSlices(resNameSets...), resWarnings, nil
 }
@@ -411,10 +442,11 @@ func (q *blocksStoreQuerier) LabelValues(name string, matchers ...*labels.Matche
 		return queriedBlocks, nil
 	}
 
-	err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
+	warnings, err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, nil, queryFunc)
 	if err != nil {
 		return nil, nil, err
 	}
+	resWarnings = append(resWarnings, warnings...)
 
 	return util.MergeSlices(resValueSets...), resWarnings, nil
 }
@@ -452,10 +484,11 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		return queriedBlocks, nil
 	}
 
-	err = q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, shard, queryFunc)
+	warnings, err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, shard, queryFunc)
 	if err != nil {
 		return storage.ErrSeriesSet(err)
 	}
+	resWarnings = append(resWarnings, warnings...)
 
 	if len(resSeriesSets) == 0 {
 		storage.EmptySeriesSet()
@@ -467,7 +500,10 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 }
 
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, shard *sharding.ShardSelector,
-	queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)) error {
+	queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)) (storage.Warnings, error) {
+	// Keep track of the query max time before any manipulation, to check whether the query time range is outside the retention.
+	queryMaxT := maxT
+
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
 	// now - queryStoreAfter, because the most recent time range is covered by ingesters. This
 	// optimization is particularly important for the blocks storage because can be used to skip
@@ -484,20 +520,20 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		if maxT < minT {
 			q.metrics.storesHit.Observe(0)
 			level.Debug(logger).Log("msg", "empty query time range after max time manipulation")
-			return nil
+			return nil, nil
 		}
 	}
 
 	// Find the list of blocks we need to query given the time range.
 	knownBlocks, knownDeletionMarks, err := q.finder.GetBlocks(ctx, q.userID, minT, maxT)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "no blocks found")
-		return nil
+		return q.noBlocksFoundWarnings(queryMaxT, knownDeletionMarks), nil
 	}
 
 	q.metrics.blocksFound.Add(float64(len(knownBlocks)))
@@ -521,6 +557,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		// At the beginning the list of blocks to query are all known blocks.
 		remainingBlocks = knownBlocks.GetULIDs()
 		attemptedBlocks = map[ulid.ULID][]string{}
+		recentBlocks    = q.consistency.RecentlyUploaded(knownBlocks)
 		touchedStores   = map[string]struct{}{}
 
 		resQueriedBlocks = []ulid.ULID(nil)
@@ -529,7 +566,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 	for attempt := 1; attempt <= maxFetchSeriesAttempts; attempt++ {
 		// Find the set of store-gateway instances having the blocks. The exclude parameter is the
 		// map of blocks queried so far, with the list of store-gateway addresses for each block.
-		clients, err := q.stores.GetClientsFor(q.userID, remainingBlocks, attemptedBlocks)
+		clients, err := q.stores.GetClientsFor(q.userID, remainingBlocks, attemptedBlocks, recentBlocks)
 		if err != nil {
 			// If it's a retry and we get an error, it means there are no more store-gateways left
 			// from which running another attempt, so we're just stopping retrying.
@@ -538,7 +575,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 				break
 			}
 
-			return err
+			return nil, err
 		}
 		level.Debug(logger).Log("msg", "found store-gateway instances to query", "num instances", len(clients), "attempt", attempt)
 
@@ -546,7 +583,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		// are only meant to cover missing blocks.
 		queriedBlocks, err := queryFunc(clients, minT, maxT)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		level.Debug(logger).Log("msg", "received series from all store-gateways", "queried blocks", strings.Join(convertULIDsToString(queriedBlocks), " "))
 
@@ -567,7 +604,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 			q.metrics.storesHit.Observe(float64(len(touchedStores)))
 			q.metrics.refetches.Observe(float64(attempt - 1))
 
-			return nil
+			return nil, nil
 		}
 
 		level.Debug(logger).Log("msg", "consistency check failed", "attempt", attempt, "missing blocks", strings.Join(convertULIDsToString(missingBlocks), " "))
@@ -578,7 +615,27 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 
 	// We've not been able to query all expected blocks after all retries.
 	level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "failed consistency check", "err", err)
-	return newStoreConsistencyCheckFailedError(remainingBlocks)
+	return nil, newStoreConsistencyCheckFailedError(remainingBlocks)
+}
+
+// noBlocksFoundWarnings returns the warnings explaining why no blocks have been found for a query time range
+// ending at maxT, so that the user gets an explicit notice instead of a silently empty result when the data
+// is outside the tenant's retention or has been deleted.
+func (q *blocksStoreQuerier) noBlocksFoundWarnings(maxT int64, deletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark) storage.Warnings {
+	if retention := q.limits.CompactorBlocksRetentionPeriod(q.userID); retention > 0 && maxT < util.TimeToMillis(time.Now().Add(-retention)) {
+		return storage.Warnings{newDataOutsideRetentionWarning(retention)}
+	}
+
+	// All blocks within the time range have been marked for deletion (e.g. by the retention enforcement).
+	if len(deletionMarks) > 0 {
+		return storage.Warnings{errDataDeleted}
+	}
+
+	return nil
+}
+
+func newDataOutsideRetentionWarning(retention time.Duration) error {
+	return fmt.Errorf("data outside retention: the query time range is before the blocks retention period of %s, so no data has been returned from the long-term storage", model.Duration(retention))
 }
 
 func newStoreConsistencyCheckFailedError(remainingBlocks []ulid.ULID) error {
@@ -705,6 +762,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 			if err != nil {
 				return errors.Wrapf(err, "failed to create series request")
 			}
+			req.ChunksChecksumsRequested = q.chunksChecksumsEnabled && !skipChunks
 
 			stream, err := c.Series(gCtx, req)
 			if err != nil {
@@ -743,6 +801,12 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 
 				// Response may either contain series, warning or hints.
 				if s := resp.GetSeries(); s != nil {
+					if req.ChunksChecksumsRequested && !q.verifyChunksChecksums(s) {
+						// Discard the series received from the store-gateway, so that the blocks are queried from other replicas.
+						level.Warn(spanLog).Log("msg", "received corrupted chunks from store-gateway", "remote", c.RemoteAddress(), "series", mimirpb.FromLabelAdaptersToLabels(s.Labels))
+						return nil
+					}
+
 					mySeries = append(mySeries, s)
 
 					// Add series fingerprint to query limiter; will return error if we are over the limit
@@ -819,6 +883,22 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 	return seriesSets, queriedBlocks, warnings, nil
 }
 
+// verifyChunksChecksums returns whether the checksum of all the chunks of the series matches.
+func (q *blocksStoreQuerier) verifyChunksChecksums(s *storepb.Series) bool {
+	for _, c := range s.Chunks {
+		if c.Raw == nil {
+			continue
+		}
+
+		q.metrics.chunksChecksumsVerified.Inc()
+		if checksum.Compute(c.Raw.Data) != c.Raw.Checksum {
+			q.metrics.chunksChecksumMismatches.Inc()
+			return false
+		}
+	}
+	return true
+}
+
 func shouldStopQueryFunc(err error) bool {
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return true
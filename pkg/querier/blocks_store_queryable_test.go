@@ -40,6 +40,7 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/checksum"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -904,6 +905,87 @@ func TestBlocksStoreQuerier_Select_cancelledContext(t *testing.T) {
 	}
 }
 
+func TestBlocksStoreQuerier_Select_ChunksChecksums(t *testing.T) {
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	var (
+		block       = ulid.MustNew(1, nil)
+		seriesLabel = labels.FromStrings(labels.MetricName, metricName, "series", "1")
+	)
+
+	withChecksum := func(resp *storepb.SeriesResponse, corrupted bool) *storepb.SeriesResponse {
+		for _, c := range resp.GetSeries().Chunks {
+			c.Raw.Checksum = checksum.Compute(c.Raw.Data)
+			if corrupted {
+				c.Raw.Checksum++
+			}
+		}
+		return resp
+	}
+
+	// The first store-gateway sends corrupted chunks, so the block is queried from another store-gateway.
+	stores := &blocksStoreSetMock{mockedResponses: []interface{}{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+				withChecksum(mockSeriesResponse(seriesLabel, minT, 1), true),
+				mockHintsResponse(block),
+			}}: {block},
+		},
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesResponses: []*storepb.SeriesResponse{
+				withChecksum(mockSeriesResponse(seriesLabel, minT, 2), false),
+				mockHintsResponse(block),
+			}}: {block},
+		},
+	}}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		{ID: block},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	q := &blocksStoreQuerier{
+		ctx:                    limiter.AddQueryLimiterToContext(context.Background(), limiter.NewQueryLimiter(0, 0, 0)),
+		minT:                   minT,
+		maxT:                   maxT,
+		userID:                 "user-1",
+		finder:                 finder,
+		stores:                 stores,
+		consistency:            NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:                 log.NewNopLogger(),
+		metrics:                newBlocksStoreQueryableMetrics(reg),
+		limits:                 &blocksStoreLimitsMock{},
+		chunksChecksumsEnabled: true,
+	}
+
+	set := q.Select(true, &storage.SelectHints{Start: minT, End: maxT}, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+	require.True(t, set.Next())
+	assert.Equal(t, seriesLabel, set.At().Labels())
+
+	it := set.At().Iterator(nil)
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	ts, v := it.At()
+	assert.Equal(t, minT, ts)
+	assert.Equal(t, 2.0, v)
+
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_storegateway_chunks_checksums_verified_total Total number of chunks received from store-gateways whose checksum has been verified.
+		# TYPE cortex_querier_storegateway_chunks_checksums_verified_total counter
+		cortex_querier_storegateway_chunks_checksums_verified_total 2
+		# HELP cortex_querier_storegateway_chunks_checksum_mismatches_total Total number of chunks received from store-gateways whose checksum didn't match, because they have been corrupted.
+		# TYPE cortex_querier_storegateway_chunks_checksum_mismatches_total counter
+		cortex_querier_storegateway_chunks_checksum_mismatches_total 1
+	`), "cortex_querier_storegateway_chunks_checksums_verified_total", "cortex_querier_storegateway_chunks_checksum_mismatches_total"))
+}
+
 func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	const (
 		metricName = "test_metric"
@@ -1560,6 +1642,73 @@ func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T)
 	}
 }
 
+func TestBlocksStoreQuerier_ShouldReturnWarningOnDataOutsideRetentionOrDeleted(t *testing.T) {
+	now := time.Now()
+	block1 := ulid.MustNew(1, nil)
+
+	tests := map[string]struct {
+		retentionPeriod  time.Duration
+		queryMaxT        int64
+		deletionMarks    map[ulid.ULID]*bucketindex.BlockDeletionMark
+		expectedWarnings storage.Warnings
+	}{
+		"should not return warnings if no data has been found within the retention": {
+			retentionPeriod: 24 * time.Hour,
+			queryMaxT:       util.TimeToMillis(now.Add(-time.Hour)),
+		},
+		"should not return warnings if no data has been found and the retention is disabled": {
+			retentionPeriod: 0,
+			queryMaxT:       util.TimeToMillis(now.Add(-48 * time.Hour)),
+		},
+		"should return a warning if the query time range is outside the retention": {
+			retentionPeriod:  24 * time.Hour,
+			queryMaxT:        util.TimeToMillis(now.Add(-48 * time.Hour)),
+			expectedWarnings: storage.Warnings{newDataOutsideRetentionWarning(24 * time.Hour)},
+		},
+		"should return a warning if all blocks within the query time range have been deleted": {
+			retentionPeriod:  0,
+			queryMaxT:        util.TimeToMillis(now.Add(-48 * time.Hour)),
+			deletionMarks:    map[ulid.ULID]*bucketindex.BlockDeletionMark{block1: {ID: block1, DeletionTime: now.Add(-2 * time.Hour).Unix()}},
+			expectedWarnings: storage.Warnings{errDataDeleted},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			queryMinT := testData.queryMaxT - time.Hour.Milliseconds()
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(bucketindex.Blocks(nil), testData.deletionMarks, error(nil))
+
+			q := &blocksStoreQuerier{
+				ctx:         context.Background(),
+				minT:        queryMinT,
+				maxT:        testData.queryMaxT,
+				userID:      "user-1",
+				finder:      finder,
+				stores:      &blocksStoreSetMock{},
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(nil),
+				limits:      &blocksStoreLimitsMock{compactorBlocksRetentionPeriod: testData.retentionPeriod},
+			}
+
+			set := q.selectSorted(&storage.SelectHints{Start: queryMinT, End: testData.queryMaxT})
+			require.False(t, set.Next())
+			require.NoError(t, set.Err())
+			assert.Equal(t, testData.expectedWarnings, set.Warnings())
+
+			_, warnings, err := q.LabelNames()
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedWarnings, warnings)
+
+			_, warnings, err = q.LabelValues(labels.MetricName)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedWarnings, warnings)
+		})
+	}
+}
+
 func TestBlocksStoreQuerier_MaxLabelsQueryRange(t *testing.T) {
 	const (
 		thirtyDays = 30 * 24 * time.Hour
@@ -1731,7 +1880,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 
 			// Instantiate the querier that will be executed to run the query.
 			logger := log.NewNopLogger()
-			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, logger, nil)
+			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, false, logger, nil)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
 			defer services.StopAndAwaitTerminated(context.Background(), queryable) // nolint:errcheck
@@ -1865,7 +2014,7 @@ type blocksStoreSetMock struct {
 	nextResult      int
 }
 
-func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
+func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.ULID][]string, _ map[ulid.ULID]time.Time) (map[BlocksStoreClient][]ulid.ULID, error) {
 	if m.nextResult >= len(m.mockedResponses) {
 		panic("not enough mocked results")
 	}
@@ -1986,9 +2135,10 @@ func (m *cancelerStoreGatewayClientMock) RemoteAddress() string {
 }
 
 type blocksStoreLimitsMock struct {
-	maxLabelsQueryLength        time.Duration
-	maxChunksPerQuery           int
-	storeGatewayTenantShardSize int
+	maxLabelsQueryLength           time.Duration
+	maxChunksPerQuery              int
+	storeGatewayTenantShardSize    int
+	compactorBlocksRetentionPeriod time.Duration
 }
 
 func (m *blocksStoreLimitsMock) MaxLabelsQueryLength(_ string) time.Duration {
@@ -1999,6 +2149,10 @@ func (m *blocksStoreLimitsMock) MaxChunksPerQuery(_ string) int {
 	return m.maxChunksPerQuery
 }
 
+func (m *blocksStoreLimitsMock) CompactorBlocksRetentionPeriod(_ string) time.Duration {
+	return m.compactorBlocksRetentionPeriod
+}
+
 func (m *blocksStoreLimitsMock) StoreGatewayTenantShardSize(_ string) int {
 	return m.storeGatewayTenantShardSize
 }
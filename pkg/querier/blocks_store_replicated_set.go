@@ -9,6 +9,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
@@ -40,6 +42,10 @@ type blocksStoreReplicationSet struct {
 	balancingStrategy loadBalancingStrategy
 	limits            BlocksStoreLimits
 
+	// Tracks the bucket index synced by each store-gateway, in order to prefer the replicas which have
+	// synced the recently uploaded blocks. Nil if disabled.
+	syncTracker *bucketIndexSyncTracker
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -50,14 +56,21 @@ func newBlocksStoreReplicationSet(
 	balancingStrategy loadBalancingStrategy,
 	limits BlocksStoreLimits,
 	clientConfig ClientConfig,
+	preferFreshReplicas bool,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*blocksStoreReplicationSet, error) {
+	var syncTracker *bucketIndexSyncTracker
+	if preferFreshReplicas {
+		syncTracker = newBucketIndexSyncTracker()
+	}
+
 	s := &blocksStoreReplicationSet{
 		storesRing:         storesRing,
-		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, logger, reg),
+		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, syncTracker, logger, reg),
 		balancingStrategy:  balancingStrategy,
 		limits:             limits,
+		syncTracker:        syncTracker,
 		subservicesWatcher: services.NewFailureWatcher(),
 	}
 
@@ -97,7 +110,7 @@ func (s *blocksStoreReplicationSet) stopping(_ error) error {
 	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
 }
 
-func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
+func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, recentlyUploaded map[ulid.ULID]time.Time) (map[BlocksStoreClient][]ulid.ULID, error) {
 	shards := map[string][]ulid.ULID{}
 
 	userRing := storegateway.GetShuffleShardingSubring(s.storesRing, userID, s.limits)
@@ -113,8 +126,14 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
 		}
 
-		// Pick a non excluded store-gateway instance.
-		addr := getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy)
+		// Pick a non excluded store-gateway instance. If the block has been recently uploaded, prefer
+		// the instances which have synced it, given some instances may have not discovered it yet.
+		var addr string
+		if uploadedAt, ok := recentlyUploaded[blockID]; ok && s.syncTracker != nil {
+			addr = s.getFreshestNonExcludedInstanceAddr(userID, set, exclude[blockID], uploadedAt)
+		} else {
+			addr = getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy)
+		}
 		if addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
 		}
@@ -153,3 +172,37 @@ func getNonExcludedInstanceAddr(set ring.ReplicationSet, exclude []string, balan
 
 	return ""
 }
+
+// getFreshestNonExcludedInstanceAddr picks a non excluded instance, preferring the ones which reported having synced
+// a bucket index updated after the block was uploaded, then the ones whose synced bucket index is unknown.
+func (s *blocksStoreReplicationSet) getFreshestNonExcludedInstanceAddr(userID string, set ring.ReplicationSet, exclude []string, uploadedAt time.Time) string {
+	const (
+		synced = iota
+		unknown
+		notSynced
+	)
+
+	rank := func(instance ring.InstanceDesc) int {
+		syncedAt, ok := s.syncTracker.get(instance.Addr, userID)
+		switch {
+		case !ok:
+			return unknown
+		case syncedAt.Before(uploadedAt):
+			return notSynced
+		default:
+			return synced
+		}
+	}
+
+	if s.balancingStrategy == randomLoadBalancing {
+		// Randomize the list of instances to not always query the same one among the equally fresh ones.
+		rand.Shuffle(len(set.Instances), func(i, j int) {
+			set.Instances[i], set.Instances[j] = set.Instances[j], set.Instances[i]
+		})
+	}
+	sort.SliceStable(set.Instances, func(i, j int) bool {
+		return rank(set.Instances[i]) < rank(set.Instances[j])
+	})
+
+	return getNonExcludedInstanceAddr(set, exclude, noLoadBalancing)
+}
@@ -340,7 +340,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 			}
 
 			reg := prometheus.NewPedanticRegistry()
-			s, err := newBlocksStoreReplicationSet(r, noLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg)
+			s, err := newBlocksStoreReplicationSet(r, noLoadBalancing, limits, ClientConfig{}, false, log.NewNopLogger(), reg)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 			defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -351,7 +351,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 				return err == nil && len(all.Instances) > 0
 			})
 
-			clients, err := s.GetClientsFor(userID, testData.queryBlocks, testData.exclude)
+			clients, err := s.GetClientsFor(userID, testData.queryBlocks, testData.exclude, nil)
 			assert.Equal(t, testData.expectedErr, err)
 			defer func() {
 				// Close all clients to ensure no goroutines are leaked.
@@ -406,7 +406,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 
 	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
 	reg := prometheus.NewPedanticRegistry()
-	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg)
+	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, false, log.NewNopLogger(), reg)
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -422,7 +422,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 	distribution := map[string]int{}
 
 	for n := 0; n < numRuns; n++ {
-		clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, nil)
+		clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, nil, nil)
 		require.NoError(t, err)
 		defer func() {
 			// Close all clients to ensure no goroutines are leaked.
@@ -446,6 +446,110 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 	}
 }
 
+func TestBlocksStoreReplicationSet_GetClientsFor_ShouldPreferReplicasWithFreshBucketIndex(t *testing.T) {
+	const (
+		numRuns      = 100
+		numInstances = 3
+	)
+
+	ctx := context.Background()
+	userID := "user-A"
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	block1UploadedAt := time.Now().Add(-time.Minute)
+
+	// Create a ring.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= numInstances; n++ {
+			d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), "", []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+		}
+		return d, true, nil
+	}))
+
+	// Configure a replication factor equal to the number of instances, so that every store-gateway gets all blocks.
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = numInstances
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
+	reg := prometheus.NewPedanticRegistry()
+	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, true, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(ring.Read)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	tests := map[string]struct {
+		recentlyUploaded map[ulid.ULID]time.Time
+		exclude          map[ulid.ULID][]string
+		expectedAddrs    []string
+	}{
+		"should prefer the store-gateway which has synced the recently uploaded block": {
+			recentlyUploaded: map[ulid.ULID]time.Time{block1: block1UploadedAt},
+			expectedAddrs:    []string{"127.0.0.2"},
+		},
+		"should prefer the store-gateway whose synced bucket index is unknown if the one which has synced the block is excluded": {
+			recentlyUploaded: map[ulid.ULID]time.Time{block1: block1UploadedAt},
+			exclude:          map[ulid.ULID][]string{block1: {"127.0.0.2"}},
+			expectedAddrs:    []string{"127.0.0.3"},
+		},
+		"should fallback to the store-gateway which hasn't synced the block if the other ones are excluded": {
+			recentlyUploaded: map[ulid.ULID]time.Time{block1: block1UploadedAt},
+			exclude:          map[ulid.ULID][]string{block1: {"127.0.0.2", "127.0.0.3"}},
+			expectedAddrs:    []string{"127.0.0.1"},
+		},
+		"should balance requests across all store-gateways if the block has not been recently uploaded": {
+			expectedAddrs: []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// The 1st store-gateway has synced a bucket index not including the block yet, the 2nd one has synced
+			// the block, while the bucket index synced by the 3rd one is unknown.
+			s.syncTracker.observe("127.0.0.1", userID, block1UploadedAt.Add(-time.Minute))
+			s.syncTracker.observe("127.0.0.2", userID, block1UploadedAt.Add(time.Minute))
+
+			distribution := map[string]int{}
+
+			for n := 0; n < numRuns; n++ {
+				clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, testData.exclude, testData.recentlyUploaded)
+				require.NoError(t, err)
+				defer func() {
+					// Close all clients to ensure no goroutines are leaked.
+					for c := range clients {
+						c.(io.Closer).Close() //nolint:errcheck
+					}
+				}()
+
+				require.Len(t, clients, 1)
+
+				for addr := range getStoreGatewayClientAddrs(clients) {
+					distribution[addr]++
+				}
+			}
+
+			actualAddrs := make([]string, 0, len(distribution))
+			for addr := range distribution {
+				actualAddrs = append(actualAddrs, addr)
+			}
+			assert.ElementsMatch(t, testData.expectedAddrs, actualAddrs)
+		})
+	}
+}
+
 func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[string][]ulid.ULID {
 	addrs := map[string][]ulid.ULID{}
 	for c, blockIDs := range clients {
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/mimir/pkg/storegateway"
+)
+
+// bucketIndexSyncTracker keeps track, for each store-gateway and tenant, of the update time of the
+// bucket index whose blocks the store-gateway reported to have synced in its last response.
+type bucketIndexSyncTracker struct {
+	mtx sync.RWMutex
+
+	// Store-gateway address -> tenant -> bucket index update time.
+	syncedAt map[string]map[string]time.Time
+}
+
+func newBucketIndexSyncTracker() *bucketIndexSyncTracker {
+	return &bucketIndexSyncTracker{
+		syncedAt: map[string]map[string]time.Time{},
+	}
+}
+
+func (t *bucketIndexSyncTracker) observe(addr, userID string, syncedAt time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	tenants := t.syncedAt[addr]
+	if tenants == nil {
+		tenants = map[string]time.Time{}
+		t.syncedAt[addr] = tenants
+	}
+	tenants[userID] = syncedAt
+}
+
+// observeMetadata records the bucket index update time reported in the response metadata, if any.
+func (t *bucketIndexSyncTracker) observeMetadata(addr, userID string, md metadata.MD) {
+	values := md.Get(storegateway.BucketIndexSyncedAtHeader)
+	if userID == "" || len(values) != 1 {
+		return
+	}
+
+	syncedAt, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return
+	}
+	t.observe(addr, userID, time.Unix(syncedAt, 0))
+}
+
+// get returns the update time of the bucket index synced by the store-gateway for the tenant,
+// and whether it's known.
+func (t *bucketIndexSyncTracker) get(addr, userID string) (time.Time, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	syncedAt, ok := t.syncedAt[addr][userID]
+	return syncedAt, ok
+}
+
+// forget removes everything tracked about the store-gateway.
+func (t *bucketIndexSyncTracker) forget(addr string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.syncedAt, addr)
+}
+
+// unaryClientInterceptor returns a gRPC interceptor tracking the bucket index synced by the store-gateway at addr.
+func (t *bucketIndexSyncTracker) unaryClientInterceptor(addr string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var md metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&md))...)
+		if err == nil {
+			t.observeMetadata(addr, getUserIDFromOutgoingContext(ctx), md)
+		}
+		return err
+	}
+}
+
+// streamClientInterceptor returns a gRPC interceptor tracking the bucket index synced by the store-gateway at addr.
+func (t *bucketIndexSyncTracker) streamClientInterceptor(addr string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &syncTrackingClientStream{
+			ClientStream: stream,
+			tracker:      t,
+			addr:         addr,
+			userID:       getUserIDFromOutgoingContext(ctx),
+		}, nil
+	}
+}
+
+// syncTrackingClientStream records the bucket index synced by the store-gateway once the response headers
+// have been received, which is guaranteed after the first message (or error) has been received.
+type syncTrackingClientStream struct {
+	grpc.ClientStream
+
+	tracker *bucketIndexSyncTracker
+	addr    string
+	userID  string
+	once    sync.Once
+}
+
+func (s *syncTrackingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	s.once.Do(func() {
+		if md, headerErr := s.ClientStream.Header(); headerErr == nil {
+			s.tracker.observeMetadata(s.addr, s.userID, md)
+		}
+	})
+
+	return err
+}
+
+func getUserIDFromOutgoingContext(ctx context.Context) string {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(storegateway.GrpcContextMetadataTenantID)
+	if len(values) != 1 {
+		return ""
+	}
+	return values[0]
+}
@@ -8,6 +8,7 @@ import (
 	"sort"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/dskit/tenant"
@@ -69,13 +70,44 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 			return
 		}
 
-		seriesCountTotal, cardinalityResponse, err := distributor.LabelValuesCardinality(ctx, cardinalityRequest.LabelNames, cardinalityRequest.Matchers, cardinalityRequest.CountMethod)
+		seriesCountTotal, cardinalityResponse, err := distributor.LabelValuesCardinality(ctx, cardinalityRequest.LabelNames, cardinalityRequest.Matchers, cardinalityRequest.CountMethod, cardinalityRequest.Approximate)
 		if err != nil {
 			respondFromError(err, w)
 			return
 		}
 
-		util.WriteJSONResponse(w, toLabelValuesCardinalityResponse(seriesCountTotal, cardinalityResponse, cardinalityRequest.Limit))
+		response := toLabelValuesCardinalityResponse(seriesCountTotal, cardinalityResponse, cardinalityRequest.Limit)
+		response.Approximated = cardinalityRequest.Approximate
+		util.WriteJSONResponse(w, response)
+	})
+}
+
+// ActiveSeriesCardinalityHandler creates handler for active series endpoint.
+func ActiveSeriesCardinalityHandler(d Distributor, limits *validation.Overrides) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tenantID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !limits.CardinalityAnalysisEnabled(tenantID) {
+			http.Error(w, fmt.Sprintf("cardinality analysis is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
+			return
+		}
+
+		activeSeriesRequest, err := cardinality.DecodeActiveSeriesRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		series, err := d.ActiveSeries(ctx, activeSeriesRequest.Matchers)
+		if err != nil {
+			respondFromError(err, w)
+			return
+		}
+		util.WriteJSONResponse(w, ActiveSeriesResponse{Data: series})
 	})
 }
 
@@ -121,6 +153,10 @@ func getValuesCountTotal(labelsWithValues []*ingester_client.LabelValues) int {
 	return valuesCountTotal
 }
 
+type ActiveSeriesResponse struct {
+	Data []labels.Labels `json:"data"`
+}
+
 type LabelNamesCardinalityResponse struct {
 	LabelValuesCountTotal int                          `json:"label_values_count_total"`
 	LabelNamesCount       int                          `json:"label_names_count"`
@@ -205,4 +241,7 @@ type labelNamesCardinality struct {
 type labelValuesCardinalityResponse struct {
 	SeriesCountTotal uint64                  `json:"series_count_total"`
 	Labels           []labelNamesCardinality `json:"labels"`
+
+	// Approximated is true if the series count of each label value has been estimated from a sample of the series.
+	Approximated bool `json:"approximated,omitempty"`
 }
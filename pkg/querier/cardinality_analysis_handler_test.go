@@ -553,6 +553,34 @@ func TestLabelValuesCardinalityHandler_Success(t *testing.T) {
 				}},
 			},
 		},
+		"should annotate the response when the label values cardinality is approximated": {
+			getRequestParams: "?label_names[]=__name__&approximate=true",
+			postRequestForm: url.Values{
+				"label_names[]": []string{"__name__"},
+				"approximate":   []string{"true"},
+			},
+			labelNames: []model.LabelName{"__name__"},
+			matcher:    []*labels.Matcher(nil),
+			scope:      cardinality.InMemoryMethod,
+			labelValuesCardinality: &client.LabelValuesCardinalityResponse{
+				Items: []*client.LabelValueSeriesCount{{
+					LabelName:        labels.MetricName,
+					LabelValueSeries: map[string]uint64{"test_1": 10},
+				}},
+			},
+			expectedResponse: labelValuesCardinalityResponse{
+				SeriesCountTotal: seriesCountTotal,
+				Labels: []labelNamesCardinality{{
+					LabelName:        "__name__",
+					LabelValuesCount: 1,
+					SeriesCount:      10,
+					Cardinality: []labelValuesCardinality{
+						{LabelValue: "test_1", SeriesCount: 10},
+					},
+				}},
+				Approximated: true,
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -787,6 +815,64 @@ func TestLabelValuesCardinalityHandler_DistributorError(t *testing.T) {
 }
 
 // createEnabledHandler creates a cardinalityHandler that can be either a LabelNamesCardinalityHandler or a LabelValuesCardinalityHandler
+func TestActiveSeriesCardinalityHandler(t *testing.T) {
+	series := []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "job", "b"),
+	}
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up")}
+
+	tests := map[string]struct {
+		url                    string
+		enabled                bool
+		distributorError       error
+		expectedHTTPStatusCode int
+		expectedHTTPBody       string
+	}{
+		"should return the active series": {
+			url:                    "/ignored-url?selector=up",
+			enabled:                true,
+			expectedHTTPStatusCode: http.StatusOK,
+			expectedHTTPBody:       `{"data":[{"__name__":"up","job":"a"},{"__name__":"up","job":"b"}]}`,
+		},
+		"should fail if the selector is missing": {
+			url:                    "/ignored-url",
+			enabled:                true,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+			expectedHTTPBody:       "missing 'selector' param\n",
+		},
+		"should fail if the cardinality analysis is disabled": {
+			url:                    "/ignored-url?selector=up",
+			expectedHTTPStatusCode: http.StatusBadRequest,
+			expectedHTTPBody:       "cardinality analysis is disabled for the tenant: test\n",
+		},
+		"should return the httpgrpc error of the distributor": {
+			url:                    "/ignored-url?selector=up",
+			enabled:                true,
+			distributorError:       httpgrpc.Errorf(http.StatusBadRequest, "too many series"),
+			expectedHTTPStatusCode: http.StatusBadRequest,
+			expectedHTTPBody:       "too many series",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			distributor := &mockDistributor{}
+			distributor.On("ActiveSeries", mock.Anything, matchers).Return(series, testData.distributorError)
+
+			limits := validation.Limits{CardinalityAnalysisEnabled: testData.enabled}
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			ActiveSeriesCardinalityHandler(distributor, overrides).ServeHTTP(recorder, createRequest(testData.url, "test"))
+
+			require.Equal(t, testData.expectedHTTPStatusCode, recorder.Code)
+			require.Equal(t, testData.expectedHTTPBody, recorder.Body.String())
+		})
+	}
+}
+
 func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
 	limits := validation.Limits{CardinalityAnalysisEnabled: true}
 	overrides, err := validation.NewOverrides(limits, nil)
@@ -827,6 +913,6 @@ func mockDistributorLabelNamesAndValues(items []*client.LabelValues, err error)
 
 func mockDistributorLabelValuesCardinality(labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod, seriesCount uint64, cardinalityResponse *client.LabelValuesCardinalityResponse, err error) *mockDistributor {
 	distributor := &mockDistributor{}
-	distributor.On("LabelValuesCardinality", mock.Anything, labelNames, matchers, countMethod).Return(seriesCount, cardinalityResponse, err)
+	distributor.On("LabelValuesCardinality", mock.Anything, labelNames, matchers, countMethod, mock.Anything).Return(seriesCount, cardinalityResponse, err)
 	return distributor
 }
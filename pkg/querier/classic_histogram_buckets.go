@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+const classicHistogramBucketSuffix = "_bucket"
+
+// classicBucketsQuerier is a storage.Querier which, when a selector of classic histogram buckets matches
+// no series, synthesizes the classic histogram bucket series from the native histogram with the same name
+// without the "_bucket" suffix, if any. This allows dashboards querying classic histograms to keep working
+// while the instrumentation is migrated to native histograms.
+type classicBucketsQuerier struct {
+	storage.Querier
+}
+
+func newClassicBucketsQuerier(q storage.Querier) storage.Querier {
+	return classicBucketsQuerier{Querier: q}
+}
+
+// Select implements storage.Querier.
+func (q classicBucketsQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	bucketsName, nativeMatchers, leMatchers, ok := classicBucketsSelector(matchers)
+	if !ok {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+
+	set := q.Querier.Select(sortSeries, hints, matchers...)
+	if set.Next() {
+		return &peekedSeriesSet{SeriesSet: set, peeked: set.At()}
+	}
+	if set.Err() != nil {
+		return set
+	}
+	warnings := set.Warnings()
+
+	nativeSet := q.Querier.Select(true, hints, nativeMatchers...)
+
+	var synthesized []storage.Series
+	for nativeSet.Next() {
+		synthesized = append(synthesized, synthesizeClassicBuckets(nativeSet.At(), bucketsName, leMatchers)...)
+	}
+	if err := nativeSet.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	warnings = append(warnings, nativeSet.Warnings()...)
+
+	if len(synthesized) > 0 {
+		nativeName := strings.TrimSuffix(bucketsName, classicHistogramBucketSuffix)
+		warnings = append(warnings, fmt.Errorf("the classic histogram buckets %q have been synthesized from the native histogram %q", bucketsName, nativeName))
+	}
+
+	return series.NewSeriesSetWithWarnings(series.NewConcreteSeriesSetFromUnsortedSeries(synthesized), warnings)
+}
+
+// classicBucketsSelector returns whether the input matchers select classic histogram buckets by their
+// metric name. If so, it returns the metric name, the matchers to select the native histogram with the
+// same name, and the matchers on the "le" label, which only apply to the synthesized buckets.
+func classicBucketsSelector(matchers []*labels.Matcher) (bucketsName string, nativeMatchers, leMatchers []*labels.Matcher, ok bool) {
+	for _, m := range matchers {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual && strings.HasSuffix(m.Value, classicHistogramBucketSuffix) && m.Value != classicHistogramBucketSuffix {
+			bucketsName = m.Value
+		}
+	}
+	if bucketsName == "" {
+		return "", nil, nil, false
+	}
+
+	nativeMatchers = make([]*labels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		switch {
+		case m.Name == labels.MetricName:
+			if m.Type == labels.MatchEqual {
+				m = labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, strings.TrimSuffix(bucketsName, classicHistogramBucketSuffix))
+			}
+			nativeMatchers = append(nativeMatchers, m)
+		case m.Name == labels.BucketLabel:
+			leMatchers = append(leMatchers, m)
+		default:
+			nativeMatchers = append(nativeMatchers, m)
+		}
+	}
+
+	return bucketsName, nativeMatchers, leMatchers, true
+}
+
+// classicBucketsSample is the cumulative count of a native histogram sample at each bucket upper bound.
+type classicBucketsSample struct {
+	t      int64
+	uppers []float64
+	counts []float64
+	total  float64
+}
+
+// countAt returns the count of observations less than or equal to the input upper bound.
+func (s classicBucketsSample) countAt(upper float64) float64 {
+	if math.IsInf(upper, +1) {
+		return s.total
+	}
+
+	// Number of buckets whose upper bound is less than or equal to the input one.
+	idx := sort.Search(len(s.uppers), func(i int) bool { return s.uppers[i] > upper })
+	if idx == 0 {
+		return 0
+	}
+	return s.counts[idx-1]
+}
+
+// synthesizeClassicBuckets returns a classic histogram bucket series, named bucketsName, for each
+// bucket upper bound of the native histogram samples of the input series. Bucket upper bounds may
+// change over time, because native histograms are sparse and their schema may change, so each
+// series has a sample for each native histogram sample.
+func synthesizeClassicBuckets(native storage.Series, bucketsName string, leMatchers []*labels.Matcher) []storage.Series {
+	var (
+		samples   []classicBucketsSample
+		allUppers = map[float64]struct{}{math.Inf(+1): {}}
+		it        = native.Iterator(nil)
+	)
+
+	for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+		var (
+			t  int64
+			fh *histogram.FloatHistogram
+		)
+		switch valType {
+		case chunkenc.ValHistogram:
+			var h *histogram.Histogram
+			t, h = it.AtHistogram()
+			fh = h.ToFloat()
+		case chunkenc.ValFloatHistogram:
+			t, fh = it.AtFloatHistogram()
+		default:
+			// Float samples can't be converted to buckets.
+			continue
+		}
+
+		sample := classicBucketsSample{t: t, total: fh.Count}
+		cumulative := 0.0
+		for bIt := fh.AllBucketIterator(); bIt.Next(); {
+			b := bIt.At()
+			cumulative += b.Count
+			sample.uppers = append(sample.uppers, b.Upper)
+			sample.counts = append(sample.counts, cumulative)
+			allUppers[b.Upper] = struct{}{}
+		}
+		samples = append(samples, sample)
+	}
+	if it.Err() != nil || len(samples) == 0 {
+		return nil
+	}
+
+	result := make([]storage.Series, 0, len(allUppers))
+	for upper := range allUppers {
+		lb := labels.NewBuilder(native.Labels())
+		lb.Set(labels.MetricName, bucketsName)
+		lb.Set(labels.BucketLabel, formatBucketUpperBound(upper))
+		lset := lb.Labels()
+
+		if !matchesAll(leMatchers, lset.Get(labels.BucketLabel)) {
+			continue
+		}
+
+		points := make([]model.SamplePair, 0, len(samples))
+		for _, s := range samples {
+			points = append(points, model.SamplePair{Timestamp: model.Time(s.t), Value: model.SampleValue(s.countAt(upper))})
+		}
+		result = append(result, series.NewConcreteSeries(lset, points, nil))
+	}
+	return result
+}
+
+func formatBucketUpperBound(upper float64) string {
+	if math.IsInf(upper, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(upper, 'g', -1, 64)
+}
+
+func matchesAll(matchers []*labels.Matcher, value string) bool {
+	for _, m := range matchers {
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// peekedSeriesSet is a storage.SeriesSet whose first series has already been read from the wrapped set.
+type peekedSeriesSet struct {
+	storage.SeriesSet
+	peeked storage.Series
+	cur    storage.Series
+}
+
+func (s *peekedSeriesSet) Next() bool {
+	if s.peeked != nil {
+		s.cur, s.peeked = s.peeked, nil
+		return true
+	}
+	if !s.SeriesSet.Next() {
+		return false
+	}
+	s.cur = s.SeriesSet.At()
+	return true
+}
+
+func (s *peekedSeriesSet) At() storage.Series {
+	return s.cur
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+func TestClassicBucketsQuerier_Select(t *testing.T) {
+	// Observations: 1 in the zero bucket, 2 in (0.5, 1], 1 in (1, 2].
+	h1 := &histogram.Histogram{
+		Schema:          0,
+		Count:           4,
+		Sum:             5,
+		ZeroThreshold:   0.001,
+		ZeroCount:       1,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []int64{2, -1},
+	}
+	// Observations: 1 in the zero bucket, 2 in (0.5, 1], 1 in (1, 2], 2 in (2, 4].
+	h2 := &histogram.Histogram{
+		Schema:          0,
+		Count:           6,
+		Sum:             12,
+		ZeroThreshold:   0.001,
+		ZeroCount:       1,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 3}},
+		PositiveBuckets: []int64{2, -1, 1},
+	}
+
+	upstream := &classicBucketsMockQuerier{series: []storage.Series{
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "native", "job", "a"), nil, []mimirpb.Histogram{
+			mimirpb.FromHistogramToHistogramProto(1000, h1),
+			mimirpb.FromHistogramToHistogramProto(2000, h2),
+		}),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "classic_bucket", "le", "1"), []model.SamplePair{{Timestamp: 1000, Value: 1}}, nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "classic"), nil, []mimirpb.Histogram{
+			mimirpb.FromHistogramToHistogramProto(1000, h1),
+		}),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "float"), []model.SamplePair{{Timestamp: 1000, Value: 1}}, nil),
+	}}
+
+	tests := map[string]struct {
+		matchers         []*labels.Matcher
+		expected         map[string][]float64
+		expectedWarnings int
+	}{
+		"should synthesize the buckets of the native histogram": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "native_bucket")},
+			expected: map[string][]float64{
+				`{__name__="native_bucket", job="a", le="0.001"}`: {1, 1},
+				`{__name__="native_bucket", job="a", le="1"}`:     {3, 3},
+				`{__name__="native_bucket", job="a", le="2"}`:     {4, 4},
+				`{__name__="native_bucket", job="a", le="4"}`:     {4, 6},
+				`{__name__="native_bucket", job="a", le="+Inf"}`:  {4, 6},
+			},
+			expectedWarnings: 1,
+		},
+		"should apply the le matchers to the synthesized buckets": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "native_bucket"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "a"),
+				labels.MustNewMatcher(labels.MatchEqual, labels.BucketLabel, "+Inf"),
+			},
+			expected: map[string][]float64{
+				`{__name__="native_bucket", job="a", le="+Inf"}`: {4, 6},
+			},
+			expectedWarnings: 1,
+		},
+		"should not synthesize the buckets if the classic histogram buckets exist": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "classic_bucket")},
+			expected: map[string][]float64{
+				`{__name__="classic_bucket", le="1"}`: {1},
+			},
+		},
+		"should not synthesize the buckets if the metric is not a native histogram": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "float_bucket")},
+			expected: map[string][]float64{},
+		},
+		"should not synthesize the buckets if the selector is not on classic histogram buckets": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "native")},
+			expected: map[string][]float64{
+				// The native histogram has no float samples.
+				`{__name__="native", job="a"}`: nil,
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			set := newClassicBucketsQuerier(upstream).Select(true, nil, testData.matchers...)
+
+			actual := map[string][]float64{}
+			for set.Next() {
+				var values []float64
+				it := set.At().Iterator(nil)
+				for it.Next() == chunkenc.ValFloat {
+					_, v := it.At()
+					values = append(values, v)
+				}
+				require.NoError(t, it.Err())
+				actual[set.At().Labels().String()] = values
+			}
+			require.NoError(t, set.Err())
+
+			assert.Equal(t, testData.expected, actual)
+			assert.Len(t, set.Warnings(), testData.expectedWarnings)
+		})
+	}
+}
+
+// classicBucketsMockQuerier is a storage.Querier returning the series matching the selector.
+type classicBucketsMockQuerier struct {
+	storage.Querier
+	series []storage.Series
+}
+
+func (m *classicBucketsMockQuerier) Select(_ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var matched []storage.Series
+	for _, s := range m.series {
+		if matchesAllLabels(matchers, s.Labels()) {
+			matched = append(matched, s)
+		}
+	}
+	return series.NewConcreteSeriesSetFromUnsortedSeries(matched)
+}
+
+func matchesAllLabels(matchers []*labels.Matcher, lset labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
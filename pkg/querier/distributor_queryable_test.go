@@ -702,6 +702,14 @@ func (m *mockDistributor) LabelNames(ctx context.Context, from, to model.Time, m
 	args := m.Called(ctx, from, to, matchers)
 	return args.Get(0).([]string), args.Error(1)
 }
+func (m *mockDistributor) PaginatedLabelValuesForLabelName(ctx context.Context, from, to model.Time, lbl model.LabelName, after string, limit int, matchers ...*labels.Matcher) ([]string, error) {
+	args := m.Called(ctx, from, to, lbl, after, limit, matchers)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockDistributor) PaginatedLabelNames(ctx context.Context, from, to model.Time, after string, limit int, matchers ...*labels.Matcher) ([]string, error) {
+	args := m.Called(ctx, from, to, after, limit, matchers)
+	return args.Get(0).([]string), args.Error(1)
+}
 func (m *mockDistributor) MetricsForLabelMatchers(ctx context.Context, from, to model.Time, matchers ...*labels.Matcher) ([]labels.Labels, error) {
 	args := m.Called(ctx, from, to, matchers)
 	return args.Get(0).([]labels.Labels), args.Error(1)
@@ -717,11 +725,16 @@ func (m *mockDistributor) LabelNamesAndValues(ctx context.Context, matchers []*l
 	return args.Get(0).(*client.LabelNamesAndValuesResponse), args.Error(1)
 }
 
-func (m *mockDistributor) LabelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod) (uint64, *client.LabelValuesCardinalityResponse, error) {
-	args := m.Called(ctx, labelNames, matchers, countMethod)
+func (m *mockDistributor) LabelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher, countMethod cardinality.CountMethod, approximate bool) (uint64, *client.LabelValuesCardinalityResponse, error) {
+	args := m.Called(ctx, labelNames, matchers, countMethod, approximate)
 	return args.Get(0).(uint64), args.Get(1).(*client.LabelValuesCardinalityResponse), args.Error(2)
 }
 
+func (m *mockDistributor) ActiveSeries(ctx context.Context, matchers []*labels.Matcher) ([]labels.Labels, error) {
+	args := m.Called(ctx, matchers)
+	return args.Get(0).([]labels.Labels), args.Error(1)
+}
+
 type mockConfigProvider struct {
 	queryIngestersWithin time.Duration
 	seenUserIDs          []string
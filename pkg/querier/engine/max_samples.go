@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+)
+
+// MaxSamplesLimits provides the per-tenant max samples per query limit.
+type MaxSamplesLimits interface {
+	// MaxSamplesPerQuery returns the maximum number of samples a query can load into memory.
+	// 0 means the limit configured in the engine applies.
+	MaxSamplesPerQuery(userID string) int
+}
+
+// MaxSamplesEngine wraps the PromQL engine to enforce the per-tenant max samples per query limit.
+//
+// The PromQL engine only supports a global max samples limit, so the queries of tenants with an
+// overridden limit are evaluated by a dedicated engine, lazily created for each distinct limit and
+// sharing the query tracker of the default engine. The metrics of the dedicated engines are not tracked.
+//
+// The peak number of samples loaded by each query and its limit are reported in the query stats.
+type MaxSamplesEngine struct {
+	defaultEngine     *promql.Engine
+	defaultMaxSamples int
+	opts              promql.EngineOpts
+	limits            MaxSamplesLimits
+
+	mtx         sync.Mutex
+	engines     map[int]*promql.Engine
+	queryLogger promql.QueryLogger
+}
+
+// NewMaxSamplesEngine returns a MaxSamplesEngine wrapping defaultEngine, which must have been
+// created with the input opts.
+func NewMaxSamplesEngine(defaultEngine *promql.Engine, opts promql.EngineOpts, limits MaxSamplesLimits) *MaxSamplesEngine {
+	defaultMaxSamples := opts.MaxSamples
+
+	// The engine metrics can be registered only once.
+	opts.Reg = nil
+
+	return &MaxSamplesEngine{
+		defaultEngine:     defaultEngine,
+		defaultMaxSamples: defaultMaxSamples,
+		opts:              opts,
+		limits:            limits,
+		engines:           map[int]*promql.Engine{},
+	}
+}
+
+// SetQueryLogger implements v1.QueryEngine.
+func (e *MaxSamplesEngine) SetQueryLogger(l promql.QueryLogger) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.queryLogger = l
+	e.defaultEngine.SetQueryLogger(l)
+	for _, eng := range e.engines {
+		eng.SetQueryLogger(l)
+	}
+}
+
+// NewInstantQuery implements v1.QueryEngine.
+func (e *MaxSamplesEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts *promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	eng, maxSamples := e.engineFor(ctx)
+
+	query, err := eng.NewInstantQuery(ctx, q, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &maxSamplesQuery{Query: query, maxSamples: maxSamples}, nil
+}
+
+// NewRangeQuery implements v1.QueryEngine.
+func (e *MaxSamplesEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts *promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	eng, maxSamples := e.engineFor(ctx)
+
+	query, err := eng.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &maxSamplesQuery{Query: query, maxSamples: maxSamples}, nil
+}
+
+// engineFor returns the engine enforcing the max samples limit of the tenants in the context, and the limit.
+// When a query spans multiple tenants, the smallest limit is enforced.
+func (e *MaxSamplesEngine) engineFor(ctx context.Context) (*promql.Engine, int) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		// The query will fail anyway when reading the tenant from the context.
+		return e.defaultEngine, e.defaultMaxSamples
+	}
+
+	maxSamples := 0
+	for _, tenantID := range tenantIDs {
+		if limit := e.limits.MaxSamplesPerQuery(tenantID); limit > 0 && (maxSamples == 0 || limit < maxSamples) {
+			maxSamples = limit
+		}
+	}
+
+	if maxSamples <= 0 || maxSamples == e.defaultMaxSamples {
+		return e.defaultEngine, e.defaultMaxSamples
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	eng, ok := e.engines[maxSamples]
+	if !ok {
+		opts := e.opts
+		opts.MaxSamples = maxSamples
+		eng = promql.NewEngine(opts)
+		if e.queryLogger != nil {
+			eng.SetQueryLogger(e.queryLogger)
+		}
+		e.engines[maxSamples] = eng
+	}
+	return eng, maxSamples
+}
+
+// maxSamplesQuery reports the samples loaded by the query and its limit in the query stats.
+type maxSamplesQuery struct {
+	promql.Query
+	maxSamples int
+}
+
+func (q *maxSamplesQuery) Exec(ctx context.Context) *promql.Result {
+	res := q.Query.Exec(ctx)
+
+	queryStats := stats.FromContext(ctx)
+	queryStats.UpdateMaxSamples(uint64(q.maxSamples))
+	if s := q.Query.Stats(); s != nil && s.Samples != nil {
+		queryStats.UpdatePeakSamples(uint64(s.Samples.PeakSamples))
+	}
+
+	return res
+}
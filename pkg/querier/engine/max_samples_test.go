@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+)
+
+type mockMaxSamplesLimits map[string]int
+
+func (l mockMaxSamplesLimits) MaxSamplesPerQuery(userID string) int {
+	return l[userID]
+}
+
+func TestMaxSamplesEngine(t *testing.T) {
+	// Enable the multi-tenant resolver to test queries spanning multiple tenants.
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	storage := teststorage.New(t)
+	t.Cleanup(func() { require.NoError(t, storage.Close()) })
+
+	// Write 100 samples, one per second.
+	app := storage.Appender(context.Background())
+	for i := int64(0); i < 100; i++ {
+		_, err := app.Append(0, labels.FromStrings(labels.MetricName, "metric"), i*1000, float64(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	opts := promql.EngineOpts{
+		Logger:     log.NewNopLogger(),
+		MaxSamples: 1000,
+		Timeout:    time.Minute,
+	}
+	limits := mockMaxSamplesLimits{
+		"limited":   10,
+		"unlimited": 0,
+		"default":   1000,
+	}
+	eng := NewMaxSamplesEngine(promql.NewEngine(opts), opts, limits)
+
+	tests := map[string]struct {
+		tenantID           string
+		expectedErr        bool
+		expectedMaxSamples uint64
+	}{
+		"tenant with a lower limit": {
+			tenantID:           "limited",
+			expectedErr:        true,
+			expectedMaxSamples: 10,
+		},
+		"tenant without an overridden limit": {
+			tenantID:           "unlimited",
+			expectedMaxSamples: 1000,
+		},
+		"tenant with the same limit as the engine": {
+			tenantID:           "default",
+			expectedMaxSamples: 1000,
+		},
+		"multiple tenants": {
+			tenantID:           "unlimited|limited",
+			expectedErr:        true,
+			expectedMaxSamples: 10,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), tc.tenantID))
+
+			q, err := eng.NewInstantQuery(ctx, storage, nil, "metric[100s]", time.UnixMilli(99*1000))
+			require.NoError(t, err)
+			res := q.Exec(ctx)
+
+			if tc.expectedErr {
+				var tooManySamplesErr promql.ErrTooManySamples
+				require.ErrorAs(t, res.Err, &tooManySamplesErr)
+			} else {
+				require.NoError(t, res.Err)
+				assert.Equal(t, uint64(100), queryStats.LoadPeakSamples())
+			}
+			assert.Equal(t, tc.expectedMaxSamples, queryStats.LoadMaxSamples())
+		})
+	}
+
+	// A dedicated engine should be created only for the overridden limit.
+	assert.Len(t, eng.engines, 1)
+	assert.Contains(t, eng.engines, 10)
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// PaginatedLabelsResponse is the response of the paginated label names and label values endpoints. The continuation
+// token is set when the page is full, and must be passed to the following request to get the next page.
+type PaginatedLabelsResponse struct {
+	Status            string   `json:"status"`
+	Data              []string `json:"data"`
+	ContinuationToken string   `json:"continuation_token,omitempty"`
+}
+
+// paginatedLabelsRequest is the decoded request of the paginated label names and label values endpoints.
+type paginatedLabelsRequest struct {
+	start, end model.Time
+	matchers   []*labels.Matcher
+	after      string
+	limit      int
+}
+
+// PaginatedLabelNamesHandler creates handler for the paginated label names endpoint. The endpoint only queries the
+// ingesters, so the time range of the request is clamped to the tenant's query ingesters within.
+func PaginatedLabelNamesHandler(d Distributor, limits *validation.Overrides) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tenantID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		req, err := decodePaginatedLabelsRequest(r, limits.PaginatedLabelsMaxLimit(tenantID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !req.clampToQueryIngestersWithin(limits.QueryIngestersWithin(tenantID), time.Now()) {
+			util.WriteJSONResponse(w, toPaginatedLabelsResponse(nil, req.limit))
+			return
+		}
+
+		names, err := d.PaginatedLabelNames(ctx, req.start, req.end, req.after, req.limit, req.matchers...)
+		if err != nil {
+			respondFromError(err, w)
+			return
+		}
+		util.WriteJSONResponse(w, toPaginatedLabelsResponse(names, req.limit))
+	})
+}
+
+// PaginatedLabelValuesHandler creates handler for the paginated label values endpoint. Like the paginated label names
+// endpoint, it only queries the ingesters.
+func PaginatedLabelValuesHandler(d Distributor, limits *validation.Overrides) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tenantID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		labelName := mux.Vars(r)["name"]
+		if !model.LabelName(labelName).IsValid() {
+			http.Error(w, fmt.Sprintf("invalid label name: %q", labelName), http.StatusBadRequest)
+			return
+		}
+
+		req, err := decodePaginatedLabelsRequest(r, limits.PaginatedLabelsMaxLimit(tenantID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !req.clampToQueryIngestersWithin(limits.QueryIngestersWithin(tenantID), time.Now()) {
+			util.WriteJSONResponse(w, toPaginatedLabelsResponse(nil, req.limit))
+			return
+		}
+
+		values, err := d.PaginatedLabelValuesForLabelName(ctx, req.start, req.end, model.LabelName(labelName), req.after, req.limit, req.matchers...)
+		if err != nil {
+			respondFromError(err, w)
+			return
+		}
+		util.WriteJSONResponse(w, toPaginatedLabelsResponse(values, req.limit))
+	})
+}
+
+// decodePaginatedLabelsRequest decodes the optional start, end, match[], limit and continuation_token parameters.
+// The limit defaults to maxLimit, and can't exceed it. A maxLimit of 0 disables the endpoints, because the pages
+// would be unlimited.
+func decodePaginatedLabelsRequest(r *http.Request, maxLimit int) (*paginatedLabelsRequest, error) {
+	if maxLimit <= 0 {
+		return nil, fmt.Errorf("the paginated labels API is disabled, because the paginated labels max limit is 0")
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	req := &paginatedLabelsRequest{start: model.Earliest, end: model.Latest, limit: maxLimit}
+
+	if start := r.FormValue("start"); start != "" {
+		ts, err := util.ParseTime(start)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid 'start' param")
+		}
+		req.start = model.Time(ts)
+	}
+	if end := r.FormValue("end"); end != "" {
+		ts, err := util.ParseTime(end)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid 'end' param")
+		}
+		req.end = model.Time(ts)
+	}
+	if req.end < req.start {
+		return nil, fmt.Errorf("the 'end' param must not be before the 'start' param")
+	}
+
+	matchParams := r.Form["match[]"]
+	if len(matchParams) > 1 {
+		return nil, fmt.Errorf("multiple 'match[]' params are not allowed")
+	}
+	if len(matchParams) == 1 {
+		matchers, err := parser.ParseMetricSelector(matchParams[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse 'match[]' param")
+		}
+		req.matchers = matchers
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid 'limit' param")
+		}
+		if parsed <= 0 {
+			return nil, fmt.Errorf("'limit' param cannot be less than 1")
+		}
+		if parsed > maxLimit {
+			return nil, fmt.Errorf("'limit' param cannot be greater than %d", maxLimit)
+		}
+		req.limit = parsed
+	}
+
+	if token := r.FormValue("continuation_token"); token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid 'continuation_token' param")
+		}
+		req.after = string(after)
+	}
+
+	return req, nil
+}
+
+// clampToQueryIngestersWithin restricts the time range of the request to the ingesters lookback, and returns false if
+// the request doesn't overlap it, because the ingesters have none of its label names and values.
+func (req *paginatedLabelsRequest) clampToQueryIngestersWithin(queryIngestersWithin time.Duration, now time.Time) bool {
+	if queryIngestersWithin == 0 {
+		return true
+	}
+
+	minT := model.TimeFromUnixNano(now.Add(-queryIngestersWithin).UnixNano())
+	if req.end < minT {
+		return false
+	}
+	if req.start < minT {
+		req.start = minT
+	}
+	return true
+}
+
+// toPaginatedLabelsResponse builds the response of a page, with a continuation token if the page is full.
+func toPaginatedLabelsResponse(values []string, limit int) *PaginatedLabelsResponse {
+	if values == nil {
+		values = []string{}
+	}
+
+	resp := &PaginatedLabelsResponse{Status: "success", Data: values}
+	if len(values) > 0 && len(values) == limit {
+		resp.ContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(values[len(values)-1]))
+	}
+	return resp
+}
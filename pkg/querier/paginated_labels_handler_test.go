@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestPaginatedLabelNamesHandler(t *testing.T) {
+	tests := map[string]struct {
+		url                    string
+		maxLimit               int
+		returned               []string
+		expectedAfter          string
+		expectedLimit          int
+		expectedMatchers       []*labels.Matcher
+		expectedHTTPStatusCode int
+		expectedResponse       *PaginatedLabelsResponse
+	}{
+		"the limit defaults to the max limit": {
+			url:                    "/ignored-url",
+			maxLimit:               3,
+			returned:               []string{"a", "b"},
+			expectedLimit:          3,
+			expectedHTTPStatusCode: http.StatusOK,
+			expectedResponse:       &PaginatedLabelsResponse{Status: "success", Data: []string{"a", "b"}},
+		},
+		"a full page returns a continuation token": {
+			url:                    "/ignored-url?limit=2&match[]=up",
+			maxLimit:               3,
+			returned:               []string{"a", "b"},
+			expectedLimit:          2,
+			expectedMatchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up")},
+			expectedHTTPStatusCode: http.StatusOK,
+			expectedResponse:       &PaginatedLabelsResponse{Status: "success", Data: []string{"a", "b"}, ContinuationToken: base64.RawURLEncoding.EncodeToString([]byte("b"))},
+		},
+		"the continuation token is decoded": {
+			url:                    "/ignored-url?limit=2&continuation_token=" + base64.RawURLEncoding.EncodeToString([]byte("b")),
+			maxLimit:               3,
+			returned:               []string{"c"},
+			expectedAfter:          "b",
+			expectedLimit:          2,
+			expectedHTTPStatusCode: http.StatusOK,
+			expectedResponse:       &PaginatedLabelsResponse{Status: "success", Data: []string{"c"}},
+		},
+		"an empty page": {
+			url:                    "/ignored-url?limit=2",
+			maxLimit:               3,
+			expectedLimit:          2,
+			expectedHTTPStatusCode: http.StatusOK,
+			expectedResponse:       &PaginatedLabelsResponse{Status: "success", Data: []string{}},
+		},
+		"the limit exceeds the max limit": {
+			url:                    "/ignored-url?limit=4",
+			maxLimit:               3,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+		"the limit is not positive": {
+			url:                    "/ignored-url?limit=0",
+			maxLimit:               3,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+		"the max limit is 0": {
+			url:                    "/ignored-url?limit=2",
+			maxLimit:               0,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+		"the continuation token is invalid": {
+			url:                    "/ignored-url?continuation_token=!",
+			maxLimit:               3,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+		"multiple selectors": {
+			url:                    "/ignored-url?match[]=up&match[]=down",
+			maxLimit:               3,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+		"the end is before the start": {
+			url:                    "/ignored-url?start=20&end=10",
+			maxLimit:               3,
+			expectedHTTPStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			distributor := &mockDistributor{}
+			distributor.On("PaginatedLabelNames", mock.Anything, model.Earliest, model.Latest, tc.expectedAfter, tc.expectedLimit, tc.expectedMatchers).Return(tc.returned, nil)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequestWithContext(user.InjectOrgID(context.Background(), "test"), http.MethodGet, tc.url, http.NoBody)
+			require.NoError(t, err)
+			PaginatedLabelNamesHandler(distributor, paginatedLabelsTestOverrides(t, tc.maxLimit)).ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.expectedHTTPStatusCode, recorder.Code)
+			if tc.expectedResponse == nil {
+				distributor.AssertNotCalled(t, "PaginatedLabelNames")
+				return
+			}
+
+			var resp PaginatedLabelsResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedResponse, &resp)
+		})
+	}
+}
+
+func TestPaginatedLabelValuesHandler(t *testing.T) {
+	distributor := &mockDistributor{}
+	distributor.On("PaginatedLabelValuesForLabelName", mock.Anything, model.Time(10000), model.Time(20000), model.LabelName("job"), "", 2, []*labels.Matcher(nil)).Return([]string{"a", "b"}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/paginated/label/{name}/values").Handler(PaginatedLabelValuesHandler(distributor, paginatedLabelsTestOverrides(t, 3)))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequestWithContext(user.InjectOrgID(context.Background(), "test"), http.MethodGet, "/api/v1/paginated/label/job/values?start=10&end=20&limit=2", http.NoBody)
+	require.NoError(t, err)
+	router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var resp PaginatedLabelsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, PaginatedLabelsResponse{Status: "success", Data: []string{"a", "b"}, ContinuationToken: base64.RawURLEncoding.EncodeToString([]byte("b"))}, resp)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequestWithContext(user.InjectOrgID(context.Background(), "test"), http.MethodGet, "/api/v1/paginated/label/0invalid/values", http.NoBody)
+	require.NoError(t, err)
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestPaginatedLabelsHandler_ShouldClampTheTimeRangeToQueryIngestersWithin(t *testing.T) {
+	now := time.Now()
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PaginatedLabelsMaxLimit = 3
+	limits.QueryIngestersWithin = model.Duration(time.Hour)
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		start, end        time.Time
+		expectedCall      bool
+		expectedStartFrom time.Time
+		expectedStartTo   time.Time
+	}{
+		"the start is clamped to the ingesters lookback": {
+			start:             now.Add(-2 * time.Hour),
+			end:               now,
+			expectedCall:      true,
+			expectedStartFrom: now.Add(-time.Hour),
+			expectedStartTo:   now.Add(-time.Hour),
+		},
+		"the start within the ingesters lookback is kept": {
+			start:             now.Add(-30 * time.Minute),
+			end:               now,
+			expectedCall:      true,
+			expectedStartFrom: now.Add(-30 * time.Minute),
+			expectedStartTo:   now.Add(-30 * time.Minute),
+		},
+		"the range before the ingesters lookback is not queried": {
+			start: now.Add(-3 * time.Hour),
+			end:   now.Add(-2 * time.Hour),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			from := model.TimeFromUnixNano(tc.expectedStartFrom.Add(-time.Second).UnixNano())
+			to := model.TimeFromUnixNano(tc.expectedStartTo.Add(time.Minute).UnixNano())
+			startMatcher := mock.MatchedBy(func(start model.Time) bool { return start >= from && start <= to })
+
+			distributor := &mockDistributor{}
+			distributor.On("PaginatedLabelNames", mock.Anything, startMatcher, mock.Anything, "", 3, []*labels.Matcher(nil)).Return([]string{"a"}, nil)
+
+			url := fmt.Sprintf("/ignored-url?start=%d&end=%d", tc.start.Unix(), tc.end.Unix())
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequestWithContext(user.InjectOrgID(context.Background(), "test"), http.MethodGet, url, http.NoBody)
+			require.NoError(t, err)
+			PaginatedLabelNamesHandler(distributor, overrides).ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			var resp PaginatedLabelsResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			if tc.expectedCall {
+				distributor.AssertCalled(t, "PaginatedLabelNames", mock.Anything, startMatcher, mock.Anything, "", 3, []*labels.Matcher(nil))
+				assert.Equal(t, []string{"a"}, resp.Data)
+			} else {
+				distributor.AssertNotCalled(t, "PaginatedLabelNames")
+				assert.Equal(t, []string{}, resp.Data)
+			}
+		})
+	}
+}
+
+func paginatedLabelsTestOverrides(t *testing.T, maxLimit int) *validation.Overrides {
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PaginatedLabelsMaxLimit = maxLimit
+	limits.QueryIngestersWithin = 0
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	return overrides
+}
@@ -54,6 +54,8 @@ type Config struct {
 	PreferStreamingChunks                      bool   `yaml:"prefer_streaming_chunks" category:"experimental"`
 	StreamingChunksPerIngesterSeriesBufferSize uint64 `yaml:"streaming_chunks_per_ingester_series_buffer_size" category:"experimental"`
 	MinimizeIngesterRequests                   bool   `yaml:"minimize_ingester_requests" category:"experimental"`
+	PreferFreshStoreGateways                   bool   `yaml:"prefer_fresh_store_gateways" category:"experimental"`
+	StoreGatewayChunksChecksumsEnabled         bool   `yaml:"store_gateway_chunks_checksums_enabled" category:"experimental"`
 
 	// PromQL engine config.
 	EngineConfig engine.Config `yaml:",inline"`
@@ -83,6 +85,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.QueryStoreAfter, queryStoreAfterFlag, 12*time.Hour, "The time after which a metric should be queried from storage and not just ingesters. 0 means all queries are sent to store. If this option is enabled, the time range of the query sent to the store-gateway will be manipulated to ensure the query end is not more recent than 'now - query-store-after'.")
 	f.BoolVar(&cfg.ShuffleShardingIngestersEnabled, "querier.shuffle-sharding-ingesters-enabled", true, fmt.Sprintf("Fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since -%s. If this setting is false or -%s is '0', queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).", validation.QueryIngestersWithinFlag, validation.QueryIngestersWithinFlag))
 	f.BoolVar(&cfg.PreferStreamingChunks, "querier.prefer-streaming-chunks", false, "Request ingesters stream chunks. Ingesters will only respond with a stream of chunks if the target ingester supports this, and this preference will be ignored by ingesters that do not support this.")
+	f.BoolVar(&cfg.PreferFreshStoreGateways, "querier.prefer-fresh-store-gateways", false, "If true, the blocks recently uploaded to the storage are preferably queried from the store-gateway replicas that reported having synced a tenant's bucket index updated after the blocks were uploaded. This reduces the chances of missing recently uploaded blocks at query time. Requires the bucket index to be enabled.")
+	f.BoolVar(&cfg.StoreGatewayChunksChecksumsEnabled, "querier.store-gateway-chunks-checksums-enabled", false, "If true, the querier requests the store-gateways to compute the checksum of the chunks they send, and verifies it to detect corrupted chunks. The series received from a store-gateway with corrupted chunks are discarded, and the blocks are queried from other store-gateway replicas.")
 	f.BoolVar(&cfg.MinimizeIngesterRequests, "querier.minimize-ingester-requests", false, "If true, when querying ingesters, only the minimum required ingesters required to reach quorum will be queried initially, with other ingesters queried only if needed due to failures from the initial set of ingesters. Enabling this option reduces resource consumption for the happy path at the cost of increased latency for the unhappy path.")
 
 	// Why 256 series / ingester?
@@ -233,6 +237,9 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			q.queriers = append(q.queriers, cqr)
 		}
 
+		if limits.NativeHistogramsAsClassicBucketsEnabled(userID) {
+			return newClassicBucketsQuerier(q), nil
+		}
 		return q, nil
 	})
 }
@@ -1038,6 +1038,12 @@ func (m *errDistributor) LabelValuesForLabelName(context.Context, model.Time, mo
 func (m *errDistributor) LabelNames(context.Context, model.Time, model.Time, ...*labels.Matcher) ([]string, error) {
 	return nil, errDistributorError
 }
+func (m *errDistributor) PaginatedLabelValuesForLabelName(context.Context, model.Time, model.Time, model.LabelName, string, int, ...*labels.Matcher) ([]string, error) {
+	return nil, errDistributorError
+}
+func (m *errDistributor) PaginatedLabelNames(context.Context, model.Time, model.Time, string, int, ...*labels.Matcher) ([]string, error) {
+	return nil, errDistributorError
+}
 func (m *errDistributor) MetricsForLabelMatchers(context.Context, model.Time, model.Time, ...*labels.Matcher) ([]labels.Labels, error) {
 	return nil, errDistributorError
 }
@@ -1046,10 +1052,14 @@ func (m *errDistributor) MetricsMetadata(context.Context) ([]scrape.MetricMetada
 	return nil, errDistributorError
 }
 
-func (m *errDistributor) LabelValuesCardinality(context.Context, []model.LabelName, []*labels.Matcher, cardinality.CountMethod) (uint64, *client.LabelValuesCardinalityResponse, error) {
+func (m *errDistributor) LabelValuesCardinality(context.Context, []model.LabelName, []*labels.Matcher, cardinality.CountMethod, bool) (uint64, *client.LabelValuesCardinalityResponse, error) {
 	return 0, nil, errDistributorError
 }
 
+func (m *errDistributor) ActiveSeries(context.Context, []*labels.Matcher) ([]labels.Labels, error) {
+	return nil, errDistributorError
+}
+
 type emptyDistributor struct{}
 
 func (d *emptyDistributor) LabelNamesAndValues(_ context.Context, _ []*labels.Matcher) (*client.LabelNamesAndValuesResponse, error) {
@@ -1072,6 +1082,14 @@ func (d *emptyDistributor) LabelNames(context.Context, model.Time, model.Time, .
 	return nil, nil
 }
 
+func (d *emptyDistributor) PaginatedLabelValuesForLabelName(context.Context, model.Time, model.Time, model.LabelName, string, int, ...*labels.Matcher) ([]string, error) {
+	return nil, nil
+}
+
+func (d *emptyDistributor) PaginatedLabelNames(context.Context, model.Time, model.Time, string, int, ...*labels.Matcher) ([]string, error) {
+	return nil, nil
+}
+
 func (d *emptyDistributor) MetricsForLabelMatchers(context.Context, model.Time, model.Time, ...*labels.Matcher) ([]labels.Labels, error) {
 	return nil, nil
 }
@@ -1080,10 +1098,14 @@ func (d *emptyDistributor) MetricsMetadata(context.Context) ([]scrape.MetricMeta
 	return nil, nil
 }
 
-func (d *emptyDistributor) LabelValuesCardinality(context.Context, []model.LabelName, []*labels.Matcher, cardinality.CountMethod) (uint64, *client.LabelValuesCardinalityResponse, error) {
+func (d *emptyDistributor) LabelValuesCardinality(context.Context, []model.LabelName, []*labels.Matcher, cardinality.CountMethod, bool) (uint64, *client.LabelValuesCardinalityResponse, error) {
 	return 0, nil, nil
 }
 
+func (d *emptyDistributor) ActiveSeries(context.Context, []*labels.Matcher) ([]labels.Labels, error) {
+	return nil, nil
+}
+
 func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 	testCases := []struct {
 		name                 string
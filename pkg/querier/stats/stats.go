@@ -171,6 +171,49 @@ func (s *Stats) LoadEstimatedSeriesCount() uint64 {
 	return atomic.LoadUint64(&s.EstimatedSeriesCount)
 }
 
+// UpdatePeakSamples sets the peak number of samples to the input value if greater than the current one.
+func (s *Stats) UpdatePeakSamples(samples uint64) {
+	if s == nil {
+		return
+	}
+
+	updateMaxUint64(&s.PeakSamples, samples)
+}
+
+func (s *Stats) LoadPeakSamples() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.PeakSamples)
+}
+
+// UpdateMaxSamples sets the max samples limit to the input value if greater than the current one.
+func (s *Stats) UpdateMaxSamples(samples uint64) {
+	if s == nil {
+		return
+	}
+
+	updateMaxUint64(&s.MaxSamples, samples)
+}
+
+func (s *Stats) LoadMaxSamples() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.MaxSamples)
+}
+
+func updateMaxUint64(addr *uint64, value uint64) {
+	for {
+		current := atomic.LoadUint64(addr)
+		if value <= current || atomic.CompareAndSwapUint64(addr, current, value) {
+			return
+		}
+	}
+}
+
 // Merge the provided Stats into this one.
 func (s *Stats) Merge(other *Stats) {
 	if s == nil || other == nil {
@@ -185,6 +228,8 @@ func (s *Stats) Merge(other *Stats) {
 	s.AddSplitQueries(other.LoadSplitQueries())
 	s.AddFetchedIndexBytes(other.LoadFetchedIndexBytes())
 	s.AddEstimatedSeriesCount(other.LoadEstimatedSeriesCount())
+	s.UpdatePeakSamples(other.LoadPeakSamples())
+	s.UpdateMaxSamples(other.LoadMaxSamples())
 }
 
 func ShouldTrackHTTPGRPCResponse(r *httpgrpc.HTTPResponse) bool {
@@ -46,6 +46,10 @@ type Stats struct {
 	FetchedIndexBytes uint64 `protobuf:"varint,7,opt,name=fetched_index_bytes,json=fetchedIndexBytes,proto3" json:"fetched_index_bytes,omitempty"`
 	// The estimated number of series to be fetched for the query
 	EstimatedSeriesCount uint64 `protobuf:"varint,8,opt,name=estimated_series_count,json=estimatedSeriesCount,proto3" json:"estimated_series_count,omitempty"`
+	// The peak number of samples loaded into memory by the PromQL engine to evaluate the query
+	PeakSamples uint64 `protobuf:"varint,9,opt,name=peak_samples,json=peakSamples,proto3" json:"peak_samples,omitempty"`
+	// The maximum number of samples the PromQL engine was allowed to load into memory to evaluate the query
+	MaxSamples uint64 `protobuf:"varint,10,opt,name=max_samples,json=maxSamples,proto3" json:"max_samples,omitempty"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -136,6 +140,20 @@ func (m *Stats) GetEstimatedSeriesCount() uint64 {
 	return 0
 }
 
+func (m *Stats) GetPeakSamples() uint64 {
+	if m != nil {
+		return m.PeakSamples
+	}
+	return 0
+}
+
+func (m *Stats) GetMaxSamples() uint64 {
+	if m != nil {
+		return m.MaxSamples
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
 }
@@ -143,30 +161,32 @@ func init() {
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 362 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x92, 0x3f, 0x4f, 0xc2, 0x40,
-	0x18, 0xc6, 0x7b, 0xf2, 0x47, 0x3c, 0x44, 0x63, 0x25, 0xa6, 0x32, 0xbc, 0x10, 0x1d, 0x64, 0x2a,
-	0x46, 0xdd, 0x5c, 0x0c, 0xb8, 0x38, 0x0a, 0x4e, 0x2e, 0x4d, 0xa1, 0x47, 0x69, 0x2c, 0x3d, 0xec,
-	0x5d, 0xa3, 0x6e, 0x7e, 0x04, 0xdd, 0xfc, 0x08, 0x7e, 0x14, 0x46, 0x46, 0x26, 0x95, 0x63, 0x71,
-	0xe4, 0x23, 0x98, 0xbb, 0xb6, 0x04, 0xdc, 0x7a, 0xef, 0xef, 0xfd, 0xe5, 0x79, 0x72, 0x3d, 0x5c,
-	0x64, 0xdc, 0xe6, 0xcc, 0x1c, 0x85, 0x94, 0x53, 0x3d, 0xa7, 0x0e, 0x95, 0xb2, 0x4b, 0x5d, 0xaa,
-	0x26, 0x0d, 0xf9, 0x15, 0xc3, 0x0a, 0xb8, 0x94, 0xba, 0x3e, 0x69, 0xa8, 0x53, 0x37, 0xea, 0x37,
-	0x9c, 0x28, 0xb4, 0xb9, 0x47, 0x83, 0x98, 0x1f, 0xbd, 0x67, 0x70, 0xae, 0x23, 0x7d, 0xfd, 0x0a,
-	0x6f, 0x3d, 0xd9, 0xbe, 0x6f, 0x71, 0x6f, 0x48, 0x0c, 0x54, 0x43, 0xf5, 0xe2, 0xd9, 0xa1, 0x19,
-	0xdb, 0x66, 0x6a, 0x9b, 0xd7, 0x89, 0xdd, 0x2c, 0x8c, 0xbf, 0xaa, 0xda, 0xc7, 0x77, 0x15, 0xb5,
-	0x0b, 0xd2, 0xba, 0xf3, 0x86, 0x44, 0x3f, 0xc5, 0xe5, 0x3e, 0xe1, 0xbd, 0x01, 0x71, 0x2c, 0x46,
-	0x42, 0x8f, 0x30, 0xab, 0x47, 0xa3, 0x80, 0x1b, 0x1b, 0x35, 0x54, 0xcf, 0xb6, 0xf5, 0x84, 0x75,
-	0x14, 0x6a, 0x49, 0xa2, 0x9b, 0x78, 0x3f, 0x35, 0x7a, 0x83, 0x28, 0x78, 0xb0, 0xba, 0x2f, 0x9c,
-	0x30, 0x23, 0xa3, 0x84, 0xbd, 0x04, 0xb5, 0x24, 0x69, 0x4a, 0xb0, 0x9a, 0xa0, 0xf6, 0xd3, 0x84,
-	0xec, 0x5a, 0x82, 0x12, 0x92, 0x84, 0x13, 0xbc, 0xcb, 0x06, 0x76, 0xe8, 0x10, 0xc7, 0x7a, 0x8c,
-	0x54, 0xb2, 0x91, 0xab, 0xa1, 0x7a, 0xa9, 0xbd, 0x93, 0x8c, 0x6f, 0xe3, 0xa9, 0x7e, 0x8c, 0x4b,
-	0x6c, 0xe4, 0x7b, 0x7c, 0xb9, 0x96, 0x57, 0x6b, 0xdb, 0x6a, 0x98, 0x2e, 0xad, 0xf4, 0xf5, 0x02,
-	0x87, 0x3c, 0x27, 0x7d, 0x37, 0xd7, 0xfa, 0xde, 0x48, 0x12, 0xf7, 0xbd, 0xc0, 0x07, 0x84, 0x71,
-	0x6f, 0x68, 0xf3, 0xff, 0x77, 0x52, 0x50, 0x4a, 0x79, 0x49, 0x57, 0x6e, 0xa5, 0x79, 0x39, 0x99,
-	0x81, 0x36, 0x9d, 0x81, 0xb6, 0x98, 0x01, 0x7a, 0x15, 0x80, 0x3e, 0x05, 0xa0, 0xb1, 0x00, 0x34,
-	0x11, 0x80, 0x7e, 0x04, 0xa0, 0x5f, 0x01, 0xda, 0x42, 0x00, 0x7a, 0x9b, 0x83, 0x36, 0x99, 0x83,
-	0x36, 0x9d, 0x83, 0x76, 0x1f, 0x3f, 0x83, 0x6e, 0x5e, 0xfd, 0xab, 0xf3, 0xbf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xba, 0xe5, 0x86, 0x2e, 0x23, 0x02, 0x00, 0x00,
+	// 399 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x92, 0xbd, 0x52, 0xea, 0x40,
+	0x18, 0x86, 0xb3, 0x87, 0x9f, 0x03, 0x1b, 0x38, 0x67, 0x4e, 0x0e, 0xe3, 0x44, 0x8a, 0x05, 0xb5,
+	0x90, 0x2a, 0x38, 0x6a, 0x67, 0xe3, 0x80, 0x8d, 0xa5, 0x60, 0x65, 0x93, 0x59, 0xc8, 0x12, 0x32,
+	0x24, 0xd9, 0x98, 0xdd, 0x8c, 0xd8, 0x79, 0x09, 0x96, 0x5e, 0x82, 0x97, 0x42, 0x49, 0xe3, 0x0c,
+	0x95, 0x4a, 0x68, 0x2c, 0xb9, 0x04, 0x67, 0x37, 0x09, 0x03, 0x76, 0xd9, 0xf7, 0x79, 0x9f, 0xf9,
+	0xbe, 0xd9, 0x2c, 0x54, 0x19, 0xc7, 0x9c, 0x19, 0x41, 0x48, 0x39, 0xd5, 0x0a, 0xf2, 0x50, 0xaf,
+	0xd9, 0xd4, 0xa6, 0x32, 0x69, 0x8b, 0xaf, 0x04, 0xd6, 0x91, 0x4d, 0xa9, 0xed, 0x92, 0xb6, 0x3c,
+	0x0d, 0xa2, 0x51, 0xdb, 0x8a, 0x42, 0xcc, 0x1d, 0xea, 0x27, 0xfc, 0xf0, 0x2d, 0x07, 0x0b, 0x7d,
+	0xe1, 0x6b, 0x97, 0xb0, 0xfc, 0x80, 0x5d, 0xd7, 0xe4, 0x8e, 0x47, 0x74, 0xd0, 0x04, 0x2d, 0xf5,
+	0x74, 0xdf, 0x48, 0x6c, 0x23, 0xb3, 0x8d, 0xab, 0xd4, 0xee, 0x94, 0x66, 0xef, 0x0d, 0xe5, 0xe5,
+	0xa3, 0x01, 0x7a, 0x25, 0x61, 0xdd, 0x3a, 0x1e, 0xd1, 0x4e, 0x60, 0x6d, 0x44, 0xf8, 0x70, 0x4c,
+	0x2c, 0x93, 0x91, 0xd0, 0x21, 0xcc, 0x1c, 0xd2, 0xc8, 0xe7, 0xfa, 0xaf, 0x26, 0x68, 0xe5, 0x7b,
+	0x5a, 0xca, 0xfa, 0x12, 0x75, 0x05, 0xd1, 0x0c, 0xf8, 0x3f, 0x33, 0x86, 0xe3, 0xc8, 0x9f, 0x98,
+	0x83, 0x47, 0x4e, 0x98, 0x9e, 0x93, 0xc2, 0xbf, 0x14, 0x75, 0x05, 0xe9, 0x08, 0xb0, 0x3d, 0x41,
+	0xf6, 0xb3, 0x09, 0xf9, 0x9d, 0x09, 0x52, 0x48, 0x27, 0x1c, 0xc3, 0xbf, 0x6c, 0x8c, 0x43, 0x8b,
+	0x58, 0xe6, 0x7d, 0x24, 0x27, 0xeb, 0x85, 0x26, 0x68, 0x55, 0x7b, 0x7f, 0xd2, 0xf8, 0x26, 0x49,
+	0xb5, 0x23, 0x58, 0x65, 0x81, 0xeb, 0xf0, 0x4d, 0xad, 0x28, 0x6b, 0x15, 0x19, 0x66, 0xa5, 0xad,
+	0x7d, 0x1d, 0xdf, 0x22, 0xd3, 0x74, 0xdf, 0xdf, 0x3b, 0xfb, 0x5e, 0x0b, 0x92, 0xec, 0x7b, 0x0e,
+	0xf7, 0x08, 0xe3, 0x8e, 0x87, 0xf9, 0xcf, 0x3b, 0x29, 0x49, 0xa5, 0xb6, 0xa1, 0xdb, 0xb7, 0x72,
+	0x00, 0x2b, 0x01, 0xc1, 0x13, 0x93, 0x61, 0x2f, 0x70, 0x09, 0xd3, 0xcb, 0xb2, 0xab, 0x8a, 0xac,
+	0x9f, 0x44, 0x5a, 0x03, 0xaa, 0x1e, 0x9e, 0x6e, 0x1a, 0x50, 0x36, 0xa0, 0x87, 0xa7, 0x69, 0xa1,
+	0x73, 0x31, 0x5f, 0x22, 0x65, 0xb1, 0x44, 0xca, 0x7a, 0x89, 0xc0, 0x53, 0x8c, 0xc0, 0x6b, 0x8c,
+	0xc0, 0x2c, 0x46, 0x60, 0x1e, 0x23, 0xf0, 0x19, 0x23, 0xf0, 0x15, 0x23, 0x65, 0x1d, 0x23, 0xf0,
+	0xbc, 0x42, 0xca, 0x7c, 0x85, 0x94, 0xc5, 0x0a, 0x29, 0x77, 0xc9, 0x53, 0x1a, 0x14, 0xe5, 0xff,
+	0x3e, 0xfb, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x68, 0x21, 0x8b, 0x50, 0x67, 0x02, 0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -212,13 +232,19 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.EstimatedSeriesCount != that1.EstimatedSeriesCount {
 		return false
 	}
+	if this.PeakSamples != that1.PeakSamples {
+		return false
+	}
+	if this.MaxSamples != that1.MaxSamples {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 12)
+	s := make([]string, 0, 14)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -228,6 +254,8 @@ func (this *Stats) GoString() string {
 	s = append(s, "SplitQueries: "+fmt.Sprintf("%#v", this.SplitQueries)+",\n")
 	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
 	s = append(s, "EstimatedSeriesCount: "+fmt.Sprintf("%#v", this.EstimatedSeriesCount)+",\n")
+	s = append(s, "PeakSamples: "+fmt.Sprintf("%#v", this.PeakSamples)+",\n")
+	s = append(s, "MaxSamples: "+fmt.Sprintf("%#v", this.MaxSamples)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -259,6 +287,16 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.MaxSamples != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.MaxSamples))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.PeakSamples != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.PeakSamples))
+		i--
+		dAtA[i] = 0x48
+	}
 	if m.EstimatedSeriesCount != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.EstimatedSeriesCount))
 		i--
@@ -345,6 +383,12 @@ func (m *Stats) Size() (n int) {
 	if m.EstimatedSeriesCount != 0 {
 		n += 1 + sovStats(uint64(m.EstimatedSeriesCount))
 	}
+	if m.PeakSamples != 0 {
+		n += 1 + sovStats(uint64(m.PeakSamples))
+	}
+	if m.MaxSamples != 0 {
+		n += 1 + sovStats(uint64(m.MaxSamples))
+	}
 	return n
 }
 
@@ -367,6 +411,8 @@ func (this *Stats) String() string {
 		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
 		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
 		`EstimatedSeriesCount:` + fmt.Sprintf("%v", this.EstimatedSeriesCount) + `,`,
+		`PeakSamples:` + fmt.Sprintf("%v", this.PeakSamples) + `,`,
+		`MaxSamples:` + fmt.Sprintf("%v", this.MaxSamples) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -574,6 +620,44 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeakSamples", wireType)
+			}
+			m.PeakSamples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PeakSamples |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSamples", wireType)
+			}
+			m.MaxSamples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSamples |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
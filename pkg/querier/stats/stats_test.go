@@ -115,6 +115,41 @@ func TestStats_AddSplitQueries(t *testing.T) {
 	})
 }
 
+func TestStats_UpdatePeakSamples(t *testing.T) {
+	t.Run("update and load peak samples", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.UpdatePeakSamples(10)
+		stats.UpdatePeakSamples(20)
+		stats.UpdatePeakSamples(15)
+
+		assert.Equal(t, uint64(20), stats.LoadPeakSamples())
+	})
+
+	t.Run("update and load peak samples nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.UpdatePeakSamples(1)
+
+		assert.Equal(t, uint64(0), stats.LoadPeakSamples())
+	})
+}
+
+func TestStats_UpdateMaxSamples(t *testing.T) {
+	t.Run("update and load max samples", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.UpdateMaxSamples(100)
+		stats.UpdateMaxSamples(50)
+
+		assert.Equal(t, uint64(100), stats.LoadMaxSamples())
+	})
+
+	t.Run("update and load max samples nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.UpdateMaxSamples(1)
+
+		assert.Equal(t, uint64(0), stats.LoadMaxSamples())
+	})
+}
+
 func TestStats_Merge(t *testing.T) {
 	t.Run("merge two stats objects", func(t *testing.T) {
 		stats1 := &Stats{}
@@ -124,6 +159,8 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddFetchedChunks(10)
 		stats1.AddShardedQueries(20)
 		stats1.AddSplitQueries(10)
+		stats1.UpdatePeakSamples(30)
+		stats1.UpdateMaxSamples(100)
 
 		stats2 := &Stats{}
 		stats2.AddWallTime(time.Second)
@@ -132,6 +169,8 @@ func TestStats_Merge(t *testing.T) {
 		stats2.AddFetchedChunks(11)
 		stats2.AddShardedQueries(21)
 		stats2.AddSplitQueries(11)
+		stats2.UpdatePeakSamples(40)
+		stats2.UpdateMaxSamples(100)
 
 		stats1.Merge(stats2)
 
@@ -141,6 +180,8 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(21), stats1.LoadFetchedChunks())
 		assert.Equal(t, uint32(41), stats1.LoadShardedQueries())
 		assert.Equal(t, uint32(21), stats1.LoadSplitQueries())
+		assert.Equal(t, uint64(40), stats1.LoadPeakSamples())
+		assert.Equal(t, uint64(100), stats1.LoadMaxSamples())
 	})
 
 	t.Run("merge two nil stats objects", func(t *testing.T) {
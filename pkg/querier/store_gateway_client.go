@@ -20,9 +20,12 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
-func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Registerer) client.PoolFactory {
+// newStoreGatewayClientFactory returns a factory of store-gateway clients. If syncTracker is not nil, the clients
+// track the bucket index synced by the store-gateways.
+func newStoreGatewayClientFactory(clientCfg grpcclient.Config, syncTracker *bucketIndexSyncTracker, reg prometheus.Registerer) client.PoolFactory {
 	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   "cortex",
 		Name:        "storegateway_client_request_duration_seconds",
@@ -32,12 +35,20 @@ func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Re
 	}, []string{"operation", "status_code"})
 
 	return func(addr string) (client.PoolClient, error) {
-		return dialStoreGatewayClient(clientCfg, addr, requestDuration)
+		return dialStoreGatewayClient(clientCfg, addr, syncTracker, requestDuration)
 	}
 }
 
-func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*storeGatewayClient, error) {
-	opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, syncTracker *bucketIndexSyncTracker, requestDuration *prometheus.HistogramVec) (*storeGatewayClient, error) {
+	unaryInterceptors, streamInterceptors := grpcclient.Instrument(requestDuration)
+	unaryInterceptors = append(unaryInterceptors, querysource.ClientUnaryInterceptor)
+	streamInterceptors = append(streamInterceptors, querysource.ClientStreamInterceptor)
+	if syncTracker != nil {
+		unaryInterceptors = append(unaryInterceptors, syncTracker.unaryClientInterceptor(addr))
+		streamInterceptors = append(streamInterceptors, syncTracker.streamClientInterceptor(addr))
+	}
+
+	opts, err := clientCfg.DialOption(unaryInterceptors, streamInterceptors)
 	if err != nil {
 		return nil, err
 	}
@@ -51,16 +62,21 @@ func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, requestDur
 		StoreGatewayClient: storegatewaypb.NewStoreGatewayClient(conn),
 		HealthClient:       grpc_health_v1.NewHealthClient(conn),
 		conn:               conn,
+		syncTracker:        syncTracker,
 	}, nil
 }
 
 type storeGatewayClient struct {
 	storegatewaypb.StoreGatewayClient
 	grpc_health_v1.HealthClient
-	conn *grpc.ClientConn
+	conn        *grpc.ClientConn
+	syncTracker *bucketIndexSyncTracker
 }
 
 func (c *storeGatewayClient) Close() error {
+	if c.syncTracker != nil {
+		c.syncTracker.forget(c.RemoteAddress())
+	}
 	return c.conn.Close()
 }
 
@@ -72,7 +88,7 @@ func (c *storeGatewayClient) RemoteAddress() string {
 	return c.conn.Target()
 }
 
-func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConfig ClientConfig, logger log.Logger, reg prometheus.Registerer) *client.Pool {
+func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConfig ClientConfig, syncTracker *bucketIndexSyncTracker, logger log.Logger, reg prometheus.Registerer) *client.Pool {
 	// We prefer sane defaults instead of exposing further config options.
 	clientCfg := grpcclient.Config{
 		MaxRecvMsgSize:      100 << 20,
@@ -97,7 +113,7 @@ func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConf
 		ConstLabels: map[string]string{"client": "querier"},
 	})
 
-	return client.NewPool("store-gateway", poolCfg, discovery, newStoreGatewayClientFactory(clientCfg, reg), clientsCount, logger)
+	return client.NewPool("store-gateway", poolCfg, discovery, newStoreGatewayClientFactory(clientCfg, syncTracker, reg), clientsCount, logger)
 }
 
 type ClientConfig struct {
@@ -8,7 +8,9 @@ package querier
 import (
 	"context"
 	"net"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/grpcclient"
@@ -18,7 +20,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 )
@@ -44,7 +48,7 @@ func Test_newStoreGatewayClientFactory(t *testing.T) {
 	flagext.DefaultValues(&cfg)
 
 	reg := prometheus.NewPedanticRegistry()
-	factory := newStoreGatewayClientFactory(cfg, reg)
+	factory := newStoreGatewayClientFactory(cfg, nil, reg)
 
 	for i := 0; i < 2; i++ {
 		client, err := factory(listener.Addr().String())
@@ -72,6 +76,61 @@ func Test_newStoreGatewayClientFactory(t *testing.T) {
 	assert.Equal(t, uint64(2), metrics[0].GetMetric()[0].GetHistogram().GetSampleCount())
 }
 
+func Test_newStoreGatewayClientFactory_ShouldTrackSyncedBucketIndex(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	defer grpcServer.GracefulStop()
+
+	srv := &mockSyncedBucketIndexStoreGatewayServer{
+		seriesSyncedAt:     time.Unix(100, 0),
+		labelNamesSyncedAt: time.Unix(200, 0),
+	}
+	storegatewaypb.RegisterStoreGatewayServer(grpcServer, srv)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, grpcServer.Serve(listener))
+	}()
+
+	cfg := grpcclient.Config{}
+	flagext.DefaultValues(&cfg)
+
+	tracker := newBucketIndexSyncTracker()
+	factory := newStoreGatewayClientFactory(cfg, tracker, prometheus.NewPedanticRegistry())
+
+	c, err := factory(listener.Addr().String())
+	require.NoError(t, err)
+	client := c.(*storeGatewayClient)
+
+	// Query series for a tenant.
+	ctx := metadata.AppendToOutgoingContext(user.InjectOrgID(context.Background(), "user-1"), storegateway.GrpcContextMetadataTenantID, "user-1")
+	stream, err := client.Series(ctx, &storepb.SeriesRequest{})
+	require.NoError(t, err)
+
+	// nolint:revive // Read the entire response from the stream.
+	for _, err = stream.Recv(); err == nil; {
+	}
+
+	syncedAt, ok := tracker.get(client.RemoteAddress(), "user-1")
+	require.True(t, ok)
+	assert.Equal(t, srv.seriesSyncedAt, syncedAt)
+
+	// Query label names for another tenant.
+	ctx = metadata.AppendToOutgoingContext(user.InjectOrgID(context.Background(), "user-2"), storegateway.GrpcContextMetadataTenantID, "user-2")
+	_, err = client.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	require.NoError(t, err)
+
+	syncedAt, ok = tracker.get(client.RemoteAddress(), "user-2")
+	require.True(t, ok)
+	assert.Equal(t, srv.labelNamesSyncedAt, syncedAt)
+
+	// Closing the client should forget about the store-gateway.
+	require.NoError(t, client.Close())
+	_, ok = tracker.get(client.RemoteAddress(), "user-1")
+	assert.False(t, ok)
+}
+
 type mockStoreGatewayServer struct{}
 
 func (m *mockStoreGatewayServer) Series(_ *storepb.SeriesRequest, _ storegatewaypb.StoreGateway_SeriesServer) error {
@@ -85,3 +144,23 @@ func (m *mockStoreGatewayServer) LabelNames(context.Context, *storepb.LabelNames
 func (m *mockStoreGatewayServer) LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, nil
 }
+
+type mockSyncedBucketIndexStoreGatewayServer struct {
+	seriesSyncedAt     time.Time
+	labelNamesSyncedAt time.Time
+}
+
+func (m *mockSyncedBucketIndexStoreGatewayServer) Series(_ *storepb.SeriesRequest, srv storegatewaypb.StoreGateway_SeriesServer) error {
+	return srv.SetHeader(metadata.Pairs(storegateway.BucketIndexSyncedAtHeader, strconv.FormatInt(m.seriesSyncedAt.Unix(), 10)))
+}
+
+func (m *mockSyncedBucketIndexStoreGatewayServer) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(storegateway.BucketIndexSyncedAtHeader, strconv.FormatInt(m.labelNamesSyncedAt.Unix(), 10))); err != nil {
+		return nil, err
+	}
+	return &storepb.LabelNamesResponse{}, nil
+}
+
+func (m *mockSyncedBucketIndexStoreGatewayServer) LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	return &storepb.LabelValuesResponse{}, nil
+}
@@ -114,7 +114,7 @@ func (fp *frontendProcessor) process(c frontendv1pb.Frontend_ProcessClient, infl
 			// and cancel the query.  We don't actually handle queries in parallel
 			// here, as we're running in lock step with the server - each Recv is
 			// paired with a Send.
-			go fp.runRequest(ctx, request.HttpRequest, request.StatsEnabled, func(response *httpgrpc.HTTPResponse, stats *querier_stats.Stats) error {
+			go fp.runRequest(ctx, request.HttpRequest, request.StatsEnabled, request.Timeout, func(response *httpgrpc.HTTPResponse, stats *querier_stats.Stats) error {
 				defer inflightQuery.Store(false)
 
 				return c.Send(&frontendv1pb.ClientToFrontend{
@@ -135,13 +135,21 @@ func (fp *frontendProcessor) process(c frontendv1pb.Frontend_ProcessClient, infl
 	}
 }
 
-func (fp *frontendProcessor) runRequest(ctx context.Context, request *httpgrpc.HTTPRequest, statsEnabled bool, sendHTTPResponse func(response *httpgrpc.HTTPResponse, stats *querier_stats.Stats) error) {
+func (fp *frontendProcessor) runRequest(ctx context.Context, request *httpgrpc.HTTPRequest, statsEnabled bool, timeout time.Duration, sendHTTPResponse func(response *httpgrpc.HTTPResponse, stats *querier_stats.Stats) error) {
 	// Create a per-request context and cancel it once we're done processing the request.
 	// This is important for queries that stream chunks from ingesters to the querier, as SeriesChunksStreamReader relies
 	// on the context being cancelled to abort streaming and terminate a goroutine if the query is aborted. Requests that
 	// go direct to a querier's HTTP API have a context created and cancelled in a similar way by the Go runtime's
 	// net/http package.
-	ctx, cancel := context.WithCancel(ctx)
+	//
+	// If the query has a timeout, we stop working on it once the frontend has abandoned it.
+	// The deadline is propagated to ingesters and store-gateways too.
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
 
 	var stats *querier_stats.Stats
@@ -171,6 +179,8 @@ func (fp *frontendProcessor) runRequest(ctx context.Context, request *httpgrpc.H
 		level.Error(fp.log).Log("msg", "error processing query", "err", errMsg)
 	}
 
+	setResultChecksum(request, response)
+
 	if err := sendHTTPResponse(response, stats); err != nil {
 		level.Error(fp.log).Log("msg", "error processing requests", "err", err)
 	}
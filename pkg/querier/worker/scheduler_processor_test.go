@@ -96,6 +96,51 @@ func TestSchedulerProcessor_processQueriesOnSingleStream(t *testing.T) {
 		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id"})
 	})
 
+	t.Run("should stop the query execution once the query timeout has elapsed", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+					Timeout:         100 * time.Millisecond,
+				}, nil
+			default:
+				// No more messages to process, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			defer workerCancel()
+
+			// The query execution context should have the deadline derived from the timeout.
+			ctx := args.Get(0).(context.Context)
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 100*time.Millisecond)
+
+			select {
+			case <-ctx.Done():
+				assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+			case <-time.After(time.Second):
+				assert.Fail(t, "the query execution context has not been canceled once the timeout elapsed")
+			}
+		}).Return(&httpgrpc.HTTPResponse{}, nil)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+		requestHandler.AssertNumberOfCalls(t, "Handle", 1)
+	})
+
 	t.Run("should not log an error when the query-scheduler is terminates while waiting for the next query to run", func(t *testing.T) {
 		sp, loopClient, requestHandler := prepareSchedulerProcessor()
 
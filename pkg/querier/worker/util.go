@@ -8,7 +8,10 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/httpgrpc"
 	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util/checksum"
 )
 
 // newExecutionContext returns a new execution context (execCtx) that wraps the input workerCtx and
@@ -56,3 +59,17 @@ func newExecutionContext(workerCtx context.Context, logger log.Logger) (execCtx
 
 	return
 }
+
+// setResultChecksum adds the checksum of the response body to the response headers,
+// if it has been requested by the query-frontend.
+func setResultChecksum(request *httpgrpc.HTTPRequest, response *httpgrpc.HTTPResponse) {
+	for _, h := range request.GetHeaders() {
+		if h.Key == checksum.RequestHTTPHeader {
+			response.Headers = append(response.Headers, &httpgrpc.Header{
+				Key:    checksum.ResponseHTTPHeader,
+				Values: []string{checksum.Format(response.Body)},
+			})
+			return
+		}
+	}
+}
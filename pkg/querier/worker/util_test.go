@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util/checksum"
+)
+
+func TestSetResultChecksum(t *testing.T) {
+	body := []byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)
+
+	t.Run("should not set the checksum if not requested", func(t *testing.T) {
+		response := &httpgrpc.HTTPResponse{Body: body}
+		setResultChecksum(&httpgrpc.HTTPRequest{}, response)
+		assert.Empty(t, response.Headers)
+
+		setResultChecksum(nil, response)
+		assert.Empty(t, response.Headers)
+	})
+
+	t.Run("should set the checksum if requested", func(t *testing.T) {
+		request := &httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{{Key: checksum.RequestHTTPHeader, Values: []string{"true"}}}}
+		response := &httpgrpc.HTTPResponse{Body: body}
+		setResultChecksum(request, response)
+		assert.Equal(t, []*httpgrpc.Header{{Key: checksum.ResponseHTTPHeader, Values: []string{checksum.Format(body)}}}, response.Headers)
+	})
+}
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	promRules "github.com/prometheus/prometheus/rules"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	alertStatePending  = "pending"
+	alertStateFiring   = "firing"
+	alertStateResolved = "resolved"
+	alertStateInactive = "inactive"
+)
+
+var errInvalidAlertStateHistoryMaxTransitions = errors.New("the alert state history max transitions per rule must be greater than 0")
+
+// AlertStateHistoryConfig holds the configuration of the alert state history kept by the ruler.
+type AlertStateHistoryConfig struct {
+	Enabled               bool `yaml:"enabled" category:"experimental"`
+	MaxTransitionsPerRule int  `yaml:"max_transitions_per_rule" category:"experimental"`
+}
+
+func (cfg *AlertStateHistoryConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ruler.alert-state-history.enabled", false, "True to keep in memory the recent state transitions of the alerts of each alerting rule evaluated by the ruler, and expose them through the alerts history API. The history is lost when the ruler restarts or when the rule group is moved to another ruler.")
+	f.IntVar(&cfg.MaxTransitionsPerRule, "ruler.alert-state-history.max-transitions-per-rule", 100, "Maximum number of alert state transitions kept for each alerting rule. Once reached, the oldest transitions are dropped.")
+}
+
+func (cfg *AlertStateHistoryConfig) Validate() error {
+	if cfg.Enabled && cfg.MaxTransitionsPerRule <= 0 {
+		return errInvalidAlertStateHistoryMaxTransitions
+	}
+	return nil
+}
+
+// alertStateHistory records the state transitions of the alerts of the alerting rules evaluated by the ruler:
+// an alert becoming pending, firing, and finally resolved, or inactive if it stopped being pending without firing.
+type alertStateHistory struct {
+	maxTransitions int
+
+	mtx sync.Mutex
+	// tenants holds the history of each tenant, by rule group and then by rule name.
+	tenants map[string]map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory
+}
+
+type alertStateHistoryGroupKey struct {
+	file, name string
+}
+
+type ruleAlertStateHistory struct {
+	// alerts is the last recorded state of each alert of the rule which isn't inactive, by labels hash.
+	alerts map[uint64]recordedAlertState
+
+	// transitions are the most recent transitions of the alerts of the rule, oldest first.
+	transitions []*AlertStateTransitionDesc
+}
+
+type recordedAlertState struct {
+	labels labels.Labels
+	state  string
+}
+
+func newAlertStateHistory(maxTransitions int) *alertStateHistory {
+	return &alertStateHistory{
+		maxTransitions: maxTransitions,
+		tenants:        map[string]map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory{},
+	}
+}
+
+// wrapEvalIterationFunc returns a rules.GroupEvalIterationFunc which records the transitions of the alerts of the
+// rule group once evaluated by next.
+func (h *alertStateHistory) wrapEvalIterationFunc(userID string, next promRules.GroupEvalIterationFunc) promRules.GroupEvalIterationFunc {
+	return func(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
+		next(ctx, g, evalTimestamp)
+		h.recordGroup(userID, g, evalTimestamp)
+	}
+}
+
+type alertSnapshot struct {
+	hash       uint64
+	labels     labels.Labels
+	state      promRules.AlertState
+	activeAt   time.Time
+	firedAt    time.Time
+	resolvedAt time.Time
+}
+
+// recordGroup records the transitions of the alerts of the rule group since its previous evaluation.
+func (h *alertStateHistory) recordGroup(userID string, g *promRules.Group, evalTimestamp time.Time) {
+	// Take a snapshot of the alerts before locking, to not hold the lock while waiting for the rules.
+	// Alerting rules with the same name are tracked together.
+	snapshots := map[string][]alertSnapshot{}
+	for _, r := range g.Rules() {
+		rule, ok := r.(*promRules.AlertingRule)
+		if !ok {
+			continue
+		}
+
+		alerts := snapshots[rule.Name()]
+		rule.ForEachActiveAlert(func(a *promRules.Alert) {
+			alerts = append(alerts, alertSnapshot{
+				hash:       a.Labels.Hash(),
+				labels:     a.Labels,
+				state:      a.State,
+				activeAt:   a.ActiveAt,
+				firedAt:    a.FiredAt,
+				resolvedAt: a.ResolvedAt,
+			})
+		})
+		snapshots[rule.Name()] = alerts
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	groups, ok := h.tenants[userID]
+	if !ok {
+		groups = map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory{}
+		h.tenants[userID] = groups
+	}
+
+	key := alertStateHistoryGroupKey{file: g.File(), name: g.Name()}
+	previous := groups[key]
+	current := make(map[string]*ruleAlertStateHistory, len(snapshots))
+	for name, alerts := range snapshots {
+		hist, ok := previous[name]
+		if !ok {
+			hist = &ruleAlertStateHistory{alerts: map[uint64]recordedAlertState{}}
+		}
+		hist.record(alerts, evalTimestamp, h.maxTransitions)
+		current[name] = hist
+	}
+
+	// The history of the rules removed from the group is dropped.
+	if len(current) == 0 {
+		delete(groups, key)
+	} else {
+		groups[key] = current
+	}
+}
+
+// record adds the transitions of the alerts since the previous evaluation of the rule.
+func (h *ruleAlertStateHistory) record(alerts []alertSnapshot, evalTimestamp time.Time, maxTransitions int) {
+	var transitions []*AlertStateTransitionDesc
+	add := func(lbls labels.Labels, state string, ts time.Time) {
+		transitions = append(transitions, &AlertStateTransitionDesc{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(lbls),
+			State:     state,
+			Timestamp: ts,
+		})
+	}
+
+	seen := make(map[uint64]struct{}, len(alerts))
+	for _, a := range alerts {
+		seen[a.hash] = struct{}{}
+		prev := h.alerts[a.hash].state
+
+		switch a.state {
+		case promRules.StatePending:
+			if prev != alertStatePending {
+				add(a.labels, alertStatePending, a.activeAt)
+			}
+			h.alerts[a.hash] = recordedAlertState{labels: a.labels, state: alertStatePending}
+		case promRules.StateFiring:
+			if prev != alertStateFiring {
+				add(a.labels, alertStateFiring, a.firedAt)
+			}
+			h.alerts[a.hash] = recordedAlertState{labels: a.labels, state: alertStateFiring}
+		case promRules.StateInactive:
+			// Resolved alerts are kept by the rule for a while, to notify the Alertmanager.
+			switch prev {
+			case alertStateFiring:
+				add(a.labels, alertStateResolved, a.resolvedAt)
+			case alertStatePending:
+				add(a.labels, alertStateInactive, a.resolvedAt)
+			}
+			delete(h.alerts, a.hash)
+		}
+	}
+
+	// Pending alerts are removed by the rule as soon as they stop being active.
+	for hash, prev := range h.alerts {
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		if prev.state == alertStateFiring {
+			add(prev.labels, alertStateResolved, evalTimestamp)
+		} else {
+			add(prev.labels, alertStateInactive, evalTimestamp)
+		}
+		delete(h.alerts, hash)
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].Timestamp.Before(transitions[j].Timestamp)
+	})
+	h.transitions = append(h.transitions, transitions...)
+	if len(h.transitions) > maxTransitions {
+		h.transitions = h.transitions[len(h.transitions)-maxTransitions:]
+	}
+}
+
+// history returns the recorded transitions of the alerts of the rule, oldest first.
+func (h *alertStateHistory) history(userID string, g *promRules.Group, ruleName string) []*AlertStateTransitionDesc {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	hist, ok := h.tenants[userID][alertStateHistoryGroupKey{file: g.File(), name: g.Name()}][ruleName]
+	if !ok {
+		return nil
+	}
+
+	// The transitions are never modified once recorded, so they can be shared.
+	return append([]*AlertStateTransitionDesc(nil), hist.transitions...)
+}
+
+// retainGroups drops the history of the tenant's rule groups which are not in the input ones.
+func (h *alertStateHistory) retainGroups(userID string, groups []*promRules.Group) {
+	keep := make(map[alertStateHistoryGroupKey]struct{}, len(groups))
+	for _, g := range groups {
+		keep[alertStateHistoryGroupKey{file: g.File(), name: g.Name()}] = struct{}{}
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for key := range h.tenants[userID] {
+		if _, ok := keep[key]; !ok {
+			delete(h.tenants[userID], key)
+		}
+	}
+	if len(h.tenants[userID]) == 0 {
+		delete(h.tenants, userID)
+	}
+}
+
+// removeUser drops the history of the tenant.
+func (h *alertStateHistory) removeUser(userID string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	delete(h.tenants, userID)
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestRuleAlertStateHistory_Record(t *testing.T) {
+	var (
+		t0 = time.Unix(1690000000, 0)
+		a  = labels.FromStrings("alertname", "UP_ALERT", "job", "a")
+		b  = labels.FromStrings("alertname", "UP_ALERT", "job", "b")
+	)
+
+	snapshot := func(lbls labels.Labels, state promRules.AlertState, activeAt, firedAt, resolvedAt time.Time) alertSnapshot {
+		return alertSnapshot{hash: lbls.Hash(), labels: lbls, state: state, activeAt: activeAt, firedAt: firedAt, resolvedAt: resolvedAt}
+	}
+	transition := func(lbls labels.Labels, state string, ts time.Time) *AlertStateTransitionDesc {
+		return &AlertStateTransitionDesc{Labels: mimirpb.FromLabelsToLabelAdapters(lbls), State: state, Timestamp: ts}
+	}
+
+	h := &ruleAlertStateHistory{alerts: map[uint64]recordedAlertState{}}
+
+	// Both alerts become pending.
+	h.record([]alertSnapshot{
+		snapshot(a, promRules.StatePending, t0, time.Time{}, time.Time{}),
+		snapshot(b, promRules.StatePending, t0, time.Time{}, time.Time{}),
+	}, t0, 10)
+
+	// The state of the alerts doesn't change.
+	h.record([]alertSnapshot{
+		snapshot(a, promRules.StatePending, t0, time.Time{}, time.Time{}),
+		snapshot(b, promRules.StatePending, t0, time.Time{}, time.Time{}),
+	}, t0.Add(time.Minute), 10)
+
+	// The first alert fires, while the second one stops being active and is removed by the rule.
+	h.record([]alertSnapshot{
+		snapshot(a, promRules.StateFiring, t0, t0.Add(2*time.Minute), time.Time{}),
+	}, t0.Add(2*time.Minute), 10)
+
+	// The first alert is resolved.
+	h.record([]alertSnapshot{
+		snapshot(a, promRules.StateInactive, t0, t0.Add(2*time.Minute), t0.Add(3*time.Minute)),
+	}, t0.Add(3*time.Minute), 10)
+
+	// The resolved alert is still kept by the rule.
+	h.record([]alertSnapshot{
+		snapshot(a, promRules.StateInactive, t0, t0.Add(2*time.Minute), t0.Add(3*time.Minute)),
+	}, t0.Add(4*time.Minute), 10)
+
+	assert.Equal(t, []*AlertStateTransitionDesc{
+		transition(a, alertStatePending, t0),
+		transition(b, alertStatePending, t0),
+		transition(a, alertStateFiring, t0.Add(2*time.Minute)),
+		transition(b, alertStateInactive, t0.Add(2*time.Minute)),
+		transition(a, alertStateResolved, t0.Add(3*time.Minute)),
+	}, h.transitions)
+	assert.Empty(t, h.alerts)
+
+	// A firing alert removed by the rule is resolved.
+	h.record([]alertSnapshot{
+		snapshot(b, promRules.StateFiring, t0.Add(4*time.Minute), t0.Add(5*time.Minute), time.Time{}),
+	}, t0.Add(5*time.Minute), 10)
+	h.record(nil, t0.Add(6*time.Minute), 10)
+
+	assert.Equal(t, []*AlertStateTransitionDesc{
+		transition(b, alertStateFiring, t0.Add(5*time.Minute)),
+		transition(b, alertStateResolved, t0.Add(6*time.Minute)),
+	}, h.transitions[len(h.transitions)-2:])
+
+	// Only the most recent transitions are kept.
+	h.record(nil, t0.Add(7*time.Minute), 3)
+	require.Len(t, h.transitions, 3)
+	assert.Equal(t, transition(a, alertStateResolved, t0.Add(3*time.Minute)), h.transitions[0])
+}
+
+func TestAlertStateHistory_RetainGroupsAndRemoveUser(t *testing.T) {
+	h := newAlertStateHistory(10)
+	group := func(file, name string) *promRules.Group {
+		return promRules.NewGroup(promRules.GroupOptions{File: file, Name: name, Opts: &promRules.ManagerOptions{}})
+	}
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		for _, g := range []*promRules.Group{group("ns-1", "group-1"), group("ns-2", "group-1")} {
+			h.tenants[userID] = mapOrNew(h.tenants[userID])
+			h.tenants[userID][alertStateHistoryGroupKey{file: g.File(), name: g.Name()}] = map[string]*ruleAlertStateHistory{
+				"UP_ALERT": {transitions: []*AlertStateTransitionDesc{{State: alertStatePending}}},
+			}
+		}
+	}
+
+	h.retainGroups("user-1", []*promRules.Group{group("ns-2", "group-1")})
+	assert.Empty(t, h.history("user-1", group("ns-1", "group-1"), "UP_ALERT"))
+	assert.Len(t, h.history("user-1", group("ns-2", "group-1"), "UP_ALERT"), 1)
+	assert.Len(t, h.history("user-2", group("ns-1", "group-1"), "UP_ALERT"), 1)
+
+	h.retainGroups("user-1", nil)
+	assert.NotContains(t, h.tenants, "user-1")
+
+	h.removeUser("user-2")
+	assert.Empty(t, h.tenants)
+}
+
+func mapOrNew(m map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory) map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory {
+	if m == nil {
+		return map[alertStateHistoryGroupKey]map[string]*ruleAlertStateHistory{}
+	}
+	return m
+}
@@ -74,6 +74,7 @@ type RuleGroup struct {
 	// same array.
 	Rules          []rule    `json:"rules"`
 	Interval       float64   `json:"interval"`
+	QueryOffset    float64   `json:"queryOffset"`
 	LastEvaluation time.Time `json:"lastEvaluation"`
 	EvaluationTime float64   `json:"evaluationTime"`
 	SourceTenants  []string  `json:"sourceTenants"`
@@ -81,6 +82,27 @@ type RuleGroup struct {
 
 type rule interface{}
 
+// AlertsHistory has the recent state transitions of the alerts of the alerting rules.
+type AlertsHistory struct {
+	Rules []*AlertingRuleHistory `json:"rules"`
+}
+
+// AlertingRuleHistory has the recent state transitions of the alerts of an alerting rule, oldest first.
+type AlertingRuleHistory struct {
+	Name        string                  `json:"name"`
+	Group       string                  `json:"group"`
+	File        string                  `json:"file"`
+	Transitions []*AlertStateTransition `json:"transitions"`
+}
+
+// AlertStateTransition is a transition of the state of an alert. State can be "pending", "firing", "resolved"
+// or "inactive", when the alert stops being pending without firing.
+type AlertStateTransition struct {
+	Labels    labels.Labels `json:"labels"`
+	State     string        `json:"state"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
 type alertingRule struct {
 	// State can be "pending", "firing", "inactive".
 	State          string        `json:"state"`
@@ -199,6 +221,7 @@ func (a *API) PrometheusRules(w http.ResponseWriter, req *http.Request) {
 			File:           g.Group.Namespace,
 			Rules:          make([]rule, len(g.ActiveRules)),
 			Interval:       g.Group.Interval.Seconds(),
+			QueryOffset:    g.Group.EvaluationDelay.Seconds(),
 			LastEvaluation: g.GetEvaluationTimestamp(),
 			EvaluationTime: g.GetEvaluationDuration().Seconds(),
 			SourceTenants:  g.Group.GetSourceTenants(),
@@ -307,6 +330,83 @@ func (a *API) PrometheusAlerts(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// PrometheusAlertsHistory returns the recent state transitions of the alerts of the alerting rules with the
+// requested name, optionally filtered by rule group and file.
+func (a *API) PrometheusAlertsHistory(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil || userID == "" {
+		level.Error(logger).Log("msg", "error extracting org id from context", "err", err)
+		respondServerError(logger, w, "no valid org id found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !a.ruler.cfg.AlertStateHistory.Enabled {
+		respondInvalidRequest(logger, w, "the alert state history is disabled")
+		return
+	}
+
+	rulesReq := RulesRequest{
+		Filter:                   AlertingRule,
+		RuleName:                 req.URL.Query()["rule_name"],
+		RuleGroup:                req.URL.Query()["rule_group"],
+		File:                     req.URL.Query()["file"],
+		IncludeAlertStateHistory: true,
+	}
+	if len(rulesReq.RuleName) == 0 {
+		respondInvalidRequest(logger, w, "the rule_name parameter is required")
+		return
+	}
+
+	rgs, err := a.ruler.GetRules(req.Context(), rulesReq)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	history := []*AlertingRuleHistory{}
+	for _, g := range rgs {
+		for _, rl := range g.ActiveRules {
+			transitions := make([]*AlertStateTransition, 0, len(rl.AlertStateHistory))
+			for _, t := range rl.AlertStateHistory {
+				transitions = append(transitions, &AlertStateTransition{
+					Labels:    mimirpb.FromLabelAdaptersToLabels(t.Labels),
+					State:     t.State,
+					Timestamp: t.Timestamp,
+				})
+			}
+			history = append(history, &AlertingRuleHistory{
+				Name:        rl.Rule.GetAlert(),
+				Group:       g.Group.Name,
+				File:        g.Group.Namespace,
+				Transitions: transitions,
+			})
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		if history[i].File != history[j].File {
+			return history[i].File < history[j].File
+		}
+		return history[i].Group < history[j].Group
+	})
+
+	b, err := json.Marshal(&response{
+		Status: "success",
+		Data:   &AlertsHistory{Rules: history},
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
+		respondServerError(logger, w, "unable to marshal the requested data")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if n, err := w.Write(b); err != nil {
+		level.Error(logger).Log("msg", "error writing response", "bytesWritten", n, "err", err)
+	}
+}
+
 var (
 	// ErrNoNamespace signals that no namespace was specified in the request
 	ErrNoNamespace = errors.New("a namespace must be provided in the request")
@@ -316,8 +416,16 @@ var (
 	ErrNoRuleGroups = errors.New("no rule groups found")
 	// ErrBadRuleGroup is returned when the provided rule group can not be unmarshalled
 	ErrBadRuleGroup = errors.New("unable to decode rule group")
+	// ErrBadNamespaceDefaults is returned when the provided namespace defaults can not be unmarshalled
+	ErrBadNamespaceDefaults = errors.New("unable to decode namespace defaults")
+	// ErrNoGroupVersion signals a group version url parameter was not found
+	ErrNoGroupVersion = errors.New("a rule group version must be provided in the request")
 )
 
+// RuleGroupAuthorHeader is the HTTP header identifying the author of the changes to the rule groups,
+// which is stored along with each rule group and its previous versions.
+const RuleGroupAuthorHeader = "X-Mimir-Rule-Group-Author"
+
 func marshalAndSend(output interface{}, w http.ResponseWriter, logger log.Logger) {
 	d, err := yaml.Marshal(&output)
 	if err != nil {
@@ -447,7 +555,7 @@ func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "retrieved rule groups from rule store", "userID", userID, "num_namespaces", len(rgs))
 
-	formatted := rgs.Formatted()
+	formatted := rgs.FormattedWithEnableConditions()
 	marshalAndSend(formatted, w, logger)
 }
 
@@ -469,7 +577,7 @@ func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	formatted := rulespb.FromProto(rg)
+	formatted := rulespb.FromProtoWithEnableCondition(rg)
 	marshalAndSend(formatted, w, logger)
 }
 
@@ -498,7 +606,39 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	errs := a.ruler.manager.ValidateRuleGroup(rg)
+	rg.EvaluationDelay, err = rulespb.MergeQueryOffset(payload, rg.EvaluationDelay)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid rule group query offset", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	enableCondition, err := rulespb.ParseEnableCondition(payload)
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to unmarshal rule group enable condition", "err", err.Error())
+		http.Error(w, ErrBadRuleGroup.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The rule group is stored as a template, but validated as it will be evaluated.
+	vars := a.ruler.limits.RulerRuleGroupTemplateVariables(userID)
+	if err := validateEnableCondition(rg.Name, enableCondition, vars); err != nil {
+		level.Error(logger).Log("msg", "invalid rule group enable condition", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validated := rg
+	if len(vars) > 0 {
+		validated, err = expandRuleGroupTemplate(rg, vars)
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to expand rule group template", "err", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	errs := a.ruler.manager.ValidateRuleGroup(validated)
 	if len(errs) > 0 {
 		e := []string{}
 		for _, err := range errs {
@@ -533,6 +673,8 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 	}
 
 	rgProto := rulespb.ToProto(userID, namespace, rg)
+	rgProto.EnableCondition = enableCondition
+	setRuleGroupLastModified(rgProto, req)
 
 	level.Debug(logger).Log("msg", "attempting to store rulegroup", "userID", userID, "group", rgProto.String())
 	err = a.store.SetRuleGroup(req.Context(), userID, namespace, rgProto)
@@ -595,6 +737,255 @@ func (a *API) DeleteRuleGroup(w http.ResponseWriter, req *http.Request) {
 	respondAccepted(w, logger)
 }
 
+// ruleGroupVersion describes a previous version of a rule group.
+type ruleGroupVersion struct {
+	Version        string `yaml:"version"`
+	LastModifiedBy string `yaml:"last_modified_by,omitempty"`
+	LastModified   string `yaml:"last_modified,omitempty"`
+}
+
+func (a *API) ListRuleGroupVersions(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, groupName, err := parseRequest(req, true, true)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	versions, err := a.store.ListRuleGroupVersions(req.Context(), userID, namespace, groupName)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	formatted := make([]ruleGroupVersion, 0, len(versions))
+	for _, v := range versions {
+		formatted = append(formatted, ruleGroupVersion{
+			Version:        v.ID,
+			LastModifiedBy: v.Group.LastModifiedBy,
+			LastModified:   formatRuleGroupLastModified(v.Group),
+		})
+	}
+	marshalAndSend(formatted, w, logger)
+}
+
+func (a *API) GetRuleGroupVersion(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, groupName, version, err := parseVersionRequest(req)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	rg, err := a.store.GetRuleGroupVersion(req.Context(), userID, namespace, groupName, version)
+	if err != nil {
+		if errors.Is(err, rulestore.ErrGroupVersionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	formatted := rulespb.FromProtoWithEnableCondition(rg)
+	marshalAndSend(formatted, w, logger)
+}
+
+// RollbackRuleGroup replaces a rule group with one of its previous versions. The rule group is
+// replaced with a single write to the storage, and the replaced rule group is retained as a
+// previous version too.
+func (a *API) RollbackRuleGroup(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, groupName, version, err := parseVersionRequest(req)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	rg, err := a.store.GetRuleGroupVersion(req.Context(), userID, namespace, groupName, version)
+	if err != nil {
+		if errors.Is(err, rulestore.ErrGroupVersionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	// The limits may have changed since the version was stored.
+	if err := a.ruler.AssertMaxRulesPerRuleGroup(userID, len(rg.Rules)); err != nil {
+		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only list rule groups when enforcing a max number of groups for this tenant.
+	if a.ruler.IsMaxRuleGroupsLimited(userID) {
+		rgs, err := a.store.ListRuleGroupsForUserAndNamespace(req.Context(), userID, "")
+		if err != nil {
+			level.Error(logger).Log("msg", "unable to fetch current rule groups for validation", "err", err.Error(), "user", userID)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		numGroups := len(rgs)
+		if !ruleGroupListContains(rgs, namespace, groupName) {
+			// The rule group has been deleted, so the rollback adds it back.
+			numGroups++
+		}
+		if err := a.ruler.AssertMaxRuleGroups(userID, numGroups); err != nil {
+			level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	setRuleGroupLastModified(rg, req)
+
+	level.Debug(logger).Log("msg", "attempting to roll back rulegroup", "userID", userID, "version", version, "group", rg.String())
+	if err := a.store.SetRuleGroup(req.Context(), userID, namespace, rg); err != nil {
+		level.Error(logger).Log("msg", "unable to store rule group", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.ruler.NotifySyncRulesAsync(userID)
+
+	respondAccepted(w, logger)
+}
+
+// parseVersionRequest parses the incoming request to parse out the userID, rules namespace, rule group
+// name and rule group version, and returns them in that order.
+func parseVersionRequest(req *http.Request) (userID, namespace, groupName, version string, err error) {
+	userID, namespace, groupName, err = parseRequest(req, true, true)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	version, exists := mux.Vars(req)["version"]
+	if !exists || version == "" {
+		return "", "", "", "", ErrNoGroupVersion
+	}
+
+	return userID, namespace, groupName, version, nil
+}
+
+// setRuleGroupLastModified sets the author of the request and the current time as the last modification of the rule group.
+func setRuleGroupLastModified(rg *rulespb.RuleGroupDesc, req *http.Request) {
+	rg.LastModifiedBy = req.Header.Get(RuleGroupAuthorHeader)
+	rg.LastModifiedTimestampMs = time.Now().UnixMilli()
+}
+
+func formatRuleGroupLastModified(rg *rulespb.RuleGroupDesc) string {
+	if rg.LastModifiedTimestampMs == 0 {
+		return ""
+	}
+	return time.UnixMilli(rg.LastModifiedTimestampMs).UTC().Format(time.RFC3339)
+}
+
+func ruleGroupListContains(rgs rulespb.RuleGroupList, namespace, groupName string) bool {
+	for _, rg := range rgs {
+		if rg.Namespace == namespace && rg.Name == groupName {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) GetNamespaceDefaults(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, _, err := parseRequest(req, true, false)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	defaults, err := a.store.GetNamespaceDefaults(req.Context(), userID, namespace)
+	if err != nil {
+		if errors.Is(err, rulestore.ErrNamespaceDefaultsNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	formatted := rulespb.NamespaceDefaultsFromProto(defaults)
+	marshalAndSend(formatted, w, logger)
+}
+
+func (a *API) SetNamespaceDefaults(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, _, err := parseRequest(req, true, false)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to read namespace defaults payload", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defaults := rulespb.NamespaceDefaults{}
+	if err := yaml.Unmarshal(payload, &defaults); err != nil {
+		level.Error(logger).Log("msg", "unable to unmarshal namespace defaults payload", "err", err.Error())
+		http.Error(w, ErrBadNamespaceDefaults.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defaults.EvaluationDelay, err = rulespb.MergeQueryOffset(payload, defaults.EvaluationDelay)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid namespace defaults query offset", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := defaults.Validate(); err != nil {
+		level.Error(logger).Log("msg", "unable to validate namespace defaults payload", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defaultsProto := rulespb.NamespaceDefaultsToProto(userID, namespace, defaults)
+
+	level.Debug(logger).Log("msg", "attempting to store namespace defaults", "userID", userID, "defaults", defaultsProto.String())
+	if err := a.store.SetNamespaceDefaults(req.Context(), userID, namespace, defaultsProto); err != nil {
+		level.Error(logger).Log("msg", "unable to store namespace defaults", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.ruler.NotifySyncRulesAsync(userID)
+
+	respondAccepted(w, logger)
+}
+
+func (a *API) DeleteNamespaceDefaults(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, namespace, _, err := parseRequest(req, true, false)
+	if err != nil {
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	err = a.store.DeleteNamespaceDefaults(req.Context(), userID, namespace)
+	if err != nil {
+		if errors.Is(err, rulestore.ErrNamespaceDefaultsNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondServerError(logger, w, err.Error())
+		return
+	}
+
+	a.ruler.NotifySyncRulesAsync(userID)
+
+	respondAccepted(w, logger)
+}
+
 // alertStateDescToPrometheusAlert converts AlertStateDesc to Alert. The returned data structure is suitable
 // to be exported by the user-facing API.
 func alertStateDescToPrometheusAlert(d *AlertStateDesc) *Alert {
@@ -24,13 +24,17 @@ import (
 	"github.com/grafana/dskit/test"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
 	"github.com/weaveworks/common/user"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/ruler/rulestore"
+	"github.com/grafana/mimir/pkg/ruler/rulestore/bucketclient"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -283,7 +287,70 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
+				},
+			},
+		},
+		"should report the query offset configured on the rule group": {
+			configuredRules: rulespb.RuleGroupList{
+				&rulespb.RuleGroupDesc{
+					Name:            "group1",
+					Namespace:       "namespace1",
+					User:            userID,
+					Rules:           []*rulespb.RuleDesc{createRecordingRule("UP_RULE", "up")},
+					Interval:        interval,
+					EvaluationDelay: 2 * time.Minute,
+				},
+			},
+			limits:             validation.MockDefaultOverrides(),
+			expectedConfigured: 1,
+			expectedRules: []*RuleGroup{
+				{
+					Name: "group1",
+					File: "namespace1",
+					Rules: []rule{
+						&recordingRule{
+							Name:   "UP_RULE",
+							Query:  "up",
+							Health: "unknown",
+							Type:   "recording",
+						},
+					},
+					Interval:    60,
+					QueryOffset: 120,
+				},
+			},
+		},
+		"should report the tenant's default query offset if the rule group doesn't configure it": {
+			configuredRules: rulespb.RuleGroupList{
+				&rulespb.RuleGroupDesc{
+					Name:      "group1",
+					Namespace: "namespace1",
+					User:      userID,
+					Rules:     []*rulespb.RuleDesc{createRecordingRule("UP_RULE", "up")},
+					Interval:  interval,
+				},
+			},
+			limits: validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+				tenantLimits[userID] = validation.MockDefaultLimits()
+				tenantLimits[userID].RulerEvaluationDelay = model.Duration(5 * time.Minute)
+			}),
+			expectedConfigured: 1,
+			expectedRules: []*RuleGroup{
+				{
+					Name: "group1",
+					File: "namespace1",
+					Rules: []rule{
+						&recordingRule{
+							Name:   "UP_RULE",
+							Query:  "up",
+							Health: "unknown",
+							Type:   "recording",
+						},
+					},
+					Interval:    60,
+					QueryOffset: 300,
 				},
 			},
 		},
@@ -315,7 +382,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Type:   "recording",
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -349,7 +417,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -403,7 +472,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -441,7 +511,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -478,7 +549,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts:        []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -509,7 +581,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -538,7 +611,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Type:   "recording",
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -571,7 +645,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G1"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -580,7 +655,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -589,7 +665,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -606,7 +683,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G1"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -615,7 +693,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -624,7 +703,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(1),
@@ -633,7 +713,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G1"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -642,7 +723,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -651,7 +733,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -675,7 +758,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -684,7 +768,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -693,7 +778,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN3G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -710,7 +796,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -719,7 +806,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN1G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -728,7 +816,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -737,7 +826,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN2G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(2),
@@ -746,7 +836,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN3G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -755,7 +846,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 						filterTestExpectedRule("NonUniqueNamedRule"),
 						filterTestExpectedAlert("UniqueNamedRuleN3G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -779,7 +871,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 					Rules: []rule{
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -795,7 +888,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 					Rules: []rule{
 						filterTestExpectedAlert("UniqueNamedRuleN1G2"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 				{
 					Name: groupName(3),
@@ -803,7 +897,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 					Rules: []rule{
 						filterTestExpectedAlert("UniqueNamedRuleN2G3"),
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -835,7 +930,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:    60,
+					QueryOffset: 60,
 				},
 			},
 		},
@@ -950,6 +1046,71 @@ func TestRuler_PrometheusAlerts(t *testing.T) {
 	require.Equal(t, string(expectedResponse), string(body))
 }
 
+func TestRuler_PrometheusAlertsHistory(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	cfg.AlertStateHistory.Enabled = true
+
+	r := prepareRuler(t, cfg, newMockRuleStore(mockRules), withRulerAddrAutomaticMapping())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), r))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), r))
+	})
+
+	// Rules will be synchronized asynchronously, so we wait until the expected number of rule groups
+	// has been synched.
+	test.Poll(t, 5*time.Second, len(mockRules["user1"]), func() interface{} {
+		ctx := user.InjectOrgID(context.Background(), "user1")
+		rls, _ := r.Rules(ctx, &RulesRequest{})
+		return len(rls.Groups)
+	})
+
+	a := NewAPI(r, r.directStore, log.NewNopLogger())
+
+	for name, tc := range map[string]struct {
+		path               string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"should return the history of the alerting rule": {
+			path:               "/prometheus/api/v1/alerts/history?rule_name=UP_ALERT",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"success","data":{"rules":[{"name":"UP_ALERT","group":"group1","file":"namespace1","transitions":[]}]},"errorType":"","error":""}`,
+		},
+		"should return no rules if the rule doesn't exist": {
+			path:               "/prometheus/api/v1/alerts/history?rule_name=UP_ALERT&rule_group=group2",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"success","data":{"rules":[]},"errorType":"","error":""}`,
+		},
+		"should fail without the rule name": {
+			path:               "/prometheus/api/v1/alerts/history",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       `{"status":"error","data":null,"errorType":"bad_data","error":"the rule_name parameter is required"}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := requestFor(t, http.MethodGet, "https://localhost:8080"+tc.path, nil, "user1")
+			w := httptest.NewRecorder()
+			a.PrometheusAlertsHistory(w, req)
+
+			resp := w.Result()
+			body, _ := io.ReadAll(resp.Body)
+			require.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			require.JSONEq(t, tc.expectedBody, string(body))
+		})
+	}
+
+	t.Run("should fail if the alert state history is disabled", func(t *testing.T) {
+		r.cfg.AlertStateHistory.Enabled = false
+		t.Cleanup(func() { r.cfg.AlertStateHistory.Enabled = true })
+
+		req := requestFor(t, http.MethodGet, "https://localhost:8080/prometheus/api/v1/alerts/history?rule_name=UP_ALERT", nil, "user1")
+		w := httptest.NewRecorder()
+		a.PrometheusAlertsHistory(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func TestAPI_CreateRuleGroup(t *testing.T) {
 	defaultCfg := defaultRulerConfig(t)
 
@@ -1036,6 +1197,63 @@ rules:
 `,
 			output: "name: test\ninterval: 15s\nsource_tenants: [t1, t2]\nrules:\n    - record: up_rule\n      expr: up{}\n    - alert: up_alert\n      expr: sum(up{}) > 1\n      for: 30s\n      labels:\n        test: test\n      annotations:\n        test: test\n",
 		},
+		{
+			name:   "with query offset",
+			cfg:    defaultCfg,
+			status: 202,
+			input: `
+name: test
+interval: 15s
+query_offset: 2m
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "name: test\ninterval: 15s\nevaluation_delay: 2m\nrules:\n    - record: up_rule\n      expr: up{}\n",
+		},
+		{
+			name:   "with query offset and evaluation delay configured with different values",
+			cfg:    defaultCfg,
+			status: 400,
+			input: `
+name: test
+interval: 15s
+query_offset: 2m
+evaluation_delay: 1m
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			err: errors.New("query_offset (2m) and evaluation_delay (1m) are the same setting and can't be configured with different values"),
+		},
+		{
+			name:   "with enable condition",
+			cfg:    defaultCfg,
+			status: 202,
+			input: `
+name: test
+interval: 15s
+enable_condition: count(up{job="mysql"}) > 0
+rules:
+- record: up_rule
+  expr: up{job="mysql"}
+`,
+			output: "name: test\ninterval: 15s\nrules:\n    - record: up_rule\n      expr: up{job=\"mysql\"}\nenable_condition: count(up{job=\"mysql\"}) > 0\n",
+		},
+		{
+			name:   "with invalid enable condition",
+			cfg:    defaultCfg,
+			status: 400,
+			input: `
+name: test
+interval: 15s
+enable_condition: count(up{job="mysql"}
+rules:
+- record: up_rule
+  expr: up{job="mysql"}
+`,
+			err: errors.New("invalid enable condition of rule group 'test': 1:22: parse error: unclosed left parenthesis"),
+		},
 	}
 
 	for _, tt := range tc {
@@ -1199,6 +1417,165 @@ func TestAPI_DeleteRuleGroup(t *testing.T) {
 	})
 }
 
+func TestAPI_NamespaceDefaults(t *testing.T) {
+	// Configure the ruler to only sync the rules based on notifications upon API changes.
+	cfg := defaultRulerConfig(t)
+	cfg.PollInterval = time.Hour
+	cfg.rulerSyncQueuePollFrequency = 100 * time.Millisecond
+
+	reg := prometheus.NewPedanticRegistry()
+	r := prepareRuler(t, cfg, newMockRuleStore(make(map[string]rulespb.RuleGroupList)), withStart(), withRulerAddrAutomaticMapping(), withPrometheusRegisterer(reg))
+	a := NewAPI(r, r.directStore, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/namespace-defaults/{namespace}").Methods(http.MethodGet).HandlerFunc(a.GetNamespaceDefaults)
+	router.Path("/prometheus/config/v1/namespace-defaults/{namespace}").Methods(http.MethodPost).HandlerFunc(a.SetNamespaceDefaults)
+	router.Path("/prometheus/config/v1/namespace-defaults/{namespace}").Methods(http.MethodDelete).HandlerFunc(a.DeleteNamespaceDefaults)
+
+	// Pre-condition check: the ruler should have run the initial rules sync.
+	verifySyncRulesMetric(t, reg, 1, 0)
+
+	// No defaults have been configured yet.
+	req := requestFor(t, http.MethodGet, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", nil, "user1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// Invalid defaults are rejected.
+	req = requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", strings.NewReader("labels:\n  invalid-name: value\n"), "user1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Configure the defaults.
+	input := "interval: 2m\nlabels:\n    team: a\n"
+	req = requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", strings.NewReader(input), "user1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	// Ensure the change triggered a rules sync notification.
+	verifySyncRulesMetric(t, reg, 1, 1)
+
+	req = requestFor(t, http.MethodGet, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", nil, "user1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, input, w.Body.String())
+
+	// Delete the defaults.
+	req = requestFor(t, http.MethodDelete, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", nil, "user1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	req = requestFor(t, http.MethodDelete, "https://localhost:8080/prometheus/config/v1/namespace-defaults/namespace1", nil, "user1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPI_RuleGroupVersions(t *testing.T) {
+	const userID = "user-1"
+
+	// Configure the ruler to only sync the rules based on notifications upon API changes.
+	cfg := defaultRulerConfig(t)
+	cfg.PollInterval = time.Hour
+	cfg.rulerSyncQueuePollFrequency = 100 * time.Millisecond
+
+	reg := prometheus.NewPedanticRegistry()
+	store := bucketclient.NewBucketRuleStore(objstore.NewInMemBucket(), nil, 5, 0, log.NewNopLogger())
+	r := prepareRuler(t, cfg, store, withStart(), withRulerAddrAutomaticMapping(), withPrometheusRegisterer(reg))
+	a := NewAPI(r, r.directStore, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/rules/{namespace}").Methods(http.MethodPost).HandlerFunc(a.CreateRuleGroup)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}").Methods(http.MethodGet).HandlerFunc(a.GetRuleGroup)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}/versions").Methods(http.MethodGet).HandlerFunc(a.ListRuleGroupVersions)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}/versions/{version}").Methods(http.MethodGet).HandlerFunc(a.GetRuleGroupVersion)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}/versions/{version}/rollback").Methods(http.MethodPost).HandlerFunc(a.RollbackRuleGroup)
+
+	serve := func(method, url, body, author string) *httptest.ResponseRecorder {
+		req := requestFor(t, method, "https://localhost:8080/prometheus/config/v1/rules"+url, strings.NewReader(body), userID)
+		if author != "" {
+			req.Header.Set(RuleGroupAuthorHeader, author)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listVersions := func() []ruleGroupVersion {
+		w := serve(http.MethodGet, "/test/group/versions", "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var versions []ruleGroupVersion
+		require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &versions))
+		return versions
+	}
+
+	const (
+		original = "name: group\nrules:\n    - record: UP_RULE\n      expr: up\n"
+		changed  = "name: group\nrules:\n    - record: UP_RULE\n      expr: up + 1\n"
+	)
+
+	require.Equal(t, http.StatusAccepted, serve(http.MethodPost, "/test", original, "user-a").Code)
+	require.Empty(t, listVersions())
+
+	require.Equal(t, http.StatusAccepted, serve(http.MethodPost, "/test", changed, "user-b").Code)
+
+	// The original rule group has been retained as a previous version, along with its author.
+	versions := listVersions()
+	require.Len(t, versions, 1)
+	require.Equal(t, "user-a", versions[0].LastModifiedBy)
+	require.NotEmpty(t, versions[0].LastModified)
+
+	w := serve(http.MethodGet, "/test/group/versions/"+versions[0].Version, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, original, w.Body.String())
+
+	// Roll back to the original rule group.
+	w = serve(http.MethodPost, "/test/group/versions/"+versions[0].Version+"/rollback", "", "user-c")
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	w = serve(http.MethodGet, "/test/group", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, original, w.Body.String())
+
+	rg, err := store.GetRuleGroup(context.Background(), userID, "test", "group")
+	require.NoError(t, err)
+	require.Equal(t, "user-c", rg.LastModifiedBy)
+
+	// The rolled back rule group has been retained as a previous version too.
+	versions = listVersions()
+	require.Len(t, versions, 2)
+	require.Equal(t, "user-b", versions[0].LastModifiedBy)
+	require.Equal(t, "user-a", versions[1].LastModifiedBy)
+
+	// Unknown versions can't be rolled back to.
+	require.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/test/group/versions/unknown", "", "").Code)
+	require.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/test/group/versions/unknown/rollback", "", "user-c").Code)
+
+	// The storage errors are reported as server errors.
+	failing := NewAPI(r, failingRuleGroupVersionsStore{RuleStore: store}, log.NewNopLogger())
+	router = mux.NewRouter()
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}/versions").Methods(http.MethodGet).HandlerFunc(failing.ListRuleGroupVersions)
+	router.Path("/prometheus/config/v1/rules/{namespace}/{groupName}/versions/{version}").Methods(http.MethodGet).HandlerFunc(failing.GetRuleGroupVersion)
+	require.Equal(t, http.StatusInternalServerError, serve(http.MethodGet, "/test/group/versions", "", "").Code)
+	require.Equal(t, http.StatusInternalServerError, serve(http.MethodGet, "/test/group/versions/"+versions[0].Version, "", "").Code)
+}
+
+type failingRuleGroupVersionsStore struct {
+	rulestore.RuleStore
+}
+
+func (failingRuleGroupVersionsStore) ListRuleGroupVersions(context.Context, string, string, string) ([]rulespb.RuleGroupVersion, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func (failingRuleGroupVersionsStore) GetRuleGroupVersion(context.Context, string, string, string, string) (*rulespb.RuleGroupDesc, error) {
+	return nil, errors.New("storage unavailable")
+}
+
 func TestRuler_LimitsPerGroup(t *testing.T) {
 	cfg := defaultRulerConfig(t)
 
@@ -1252,6 +1629,77 @@ rules:
 	}
 }
 
+func TestAPI_CreateRuleGroupTemplate(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+
+	r := prepareRuler(t, cfg, newMockRuleStore(make(map[string]rulespb.RuleGroupList)), withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerRuleGroupTemplateVariables = map[string]string{"cluster_list": "prod-1|prod-2"}
+	})))
+
+	a := NewAPI(r, r.directStore, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+
+	tc := []struct {
+		name   string
+		input  string
+		output string
+		status int
+	}{
+		{
+			name:   "when the template variables are defined",
+			status: http.StatusAccepted,
+			input: `
+name: test
+rules:
+- record: up_rule
+  expr: up{cluster=~"$cluster_list"}
+`,
+			output: "{\"status\":\"success\",\"data\":null,\"errorType\":\"\",\"error\":\"\"}",
+		},
+		{
+			name:   "when the template variables are not defined",
+			status: http.StatusBadRequest,
+			input: `
+name: test
+rules:
+- record: up_rule
+  expr: up{cluster=~"$clusters"} > $threshold
+`,
+			output: "rule group 'test' references undefined template variables: $clusters, $threshold\n",
+		},
+		{
+			name:   "when the enable condition references undefined template variables",
+			status: http.StatusBadRequest,
+			input: `
+name: test
+enable_condition: up{cluster=~"$clusters"}
+rules:
+- record: up_rule
+  expr: up{cluster=~"$cluster_list"}
+`,
+			output: "rule group 'test' references undefined template variables: $clusters\n",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			req := requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/rules/namespace", strings.NewReader(tt.input), "user1")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.output, w.Body.String())
+		})
+	}
+
+	// The rule group is stored as a template.
+	rg, err := r.directStore.GetRuleGroup(context.Background(), "user1", "namespace", "test")
+	require.NoError(t, err)
+	require.Equal(t, `up{cluster=~"$cluster_list"}`, rg.Rules[0].Expr)
+}
+
 func TestRuler_RulerGroupLimits(t *testing.T) {
 	cfg := defaultRulerConfig(t)
 
@@ -19,7 +19,6 @@ import (
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/metadata"
-	"github.com/prometheus/prometheus/notifier"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
@@ -30,6 +29,8 @@ import (
 	"github.com/grafana/mimir/pkg/querier"
 	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/querysource"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // Pusher is an ingester server that accepts pushes.
@@ -145,6 +146,10 @@ type RulesLimits interface {
 	RulerRecordingRulesEvaluationEnabled(userID string) bool
 	RulerAlertingRulesEvaluationEnabled(userID string) bool
 	RulerSyncRulesOnChangesEnabled(userID string) bool
+	RulerMaxConcurrentRuleGroups(userID string) int
+	RulerNotificationQueueOverflowPolicy(userID string) string
+	RulerAlertmanagerClientConfig(userID string) validation.RulerAlertmanagerClientConfig
+	RulerRuleGroupTemplateVariables(userID string) map[string]string
 }
 
 func MetricsQueryFunc(qf rules.QueryFunc, queries, failedQueries prometheus.Counter) rules.QueryFunc {
@@ -184,6 +189,18 @@ func MetricsQueryFunc(qf rules.QueryFunc, queries, failedQueries prometheus.Coun
 	}
 }
 
+// QuerySourceQueryFunc attaches the query source to the context of queries run to evaluate rules, so that
+// the cost of recording and alerting rules evaluation can be told apart from the cost of user queries.
+func QuerySourceQueryFunc(qf rules.QueryFunc) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		source := querysource.Ruler
+		if rules.FromOriginContext(ctx).Kind == rules.KindAlerting {
+			source = querysource.Alerting
+		}
+		return qf(querysource.ContextWithSource(ctx, source), qs, t)
+	}
+}
+
 func RecordAndReportRuleQueryMetrics(qf rules.QueryFunc, queryTime prometheus.Counter, logger log.Logger) rules.QueryFunc {
 	if queryTime == nil {
 		return qf
@@ -212,6 +229,7 @@ func RecordAndReportRuleQueryMetrics(qf rules.QueryFunc, queryTime prometheus.Co
 			logMessage := []interface{}{
 				"msg", "query stats",
 				"component", "ruler",
+				"source", querysource.FromContext(ctx),
 				"query_wall_time_seconds", wallTime.Seconds(),
 				"fetched_series_count", numSeries,
 				"fetched_chunk_bytes", numBytes,
@@ -242,8 +260,8 @@ type RulesManager interface {
 	RuleGroups() []*rules.Group
 }
 
-// ManagerFactory is a function that creates new RulesManager for given user and notifier.Manager.
-type ManagerFactory func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager
+// ManagerFactory is a function that creates new RulesManager for given user and AlertSender.
+type ManagerFactory func(ctx context.Context, userID string, notifier AlertSender, logger log.Logger, reg prometheus.Registerer) RulesManager
 
 func DefaultTenantManagerFactory(
 	cfg Config,
@@ -277,7 +295,7 @@ func DefaultTenantManagerFactory(
 			Help: "Total amount of wall clock time spent processing queries by the ruler.",
 		}, []string{"user"})
 	}
-	return func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager {
+	return func(ctx context.Context, userID string, notifier AlertSender, logger log.Logger, reg prometheus.Registerer) RulesManager {
 		var queryTime prometheus.Counter
 		if rulerQuerySeconds != nil {
 			queryTime = rulerQuerySeconds.WithLabelValues(userID)
@@ -286,6 +304,7 @@ func DefaultTenantManagerFactory(
 
 		wrappedQueryFunc = MetricsQueryFunc(queryFunc, totalQueries, failedQueries)
 		wrappedQueryFunc = RecordAndReportRuleQueryMetrics(wrappedQueryFunc, queryTime, logger)
+		wrappedQueryFunc = QuerySourceQueryFunc(wrappedQueryFunc)
 
 		return rules.NewManager(&rules.ManagerOptions{
 			Appendable:                 NewPusherAppendable(p, userID, totalWrites, failedWrites),
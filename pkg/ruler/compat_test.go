@@ -27,11 +27,13 @@ import (
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/util/querysource"
 	"github.com/grafana/mimir/pkg/util/test"
 )
 
@@ -357,6 +359,40 @@ func TestRecordAndReportRuleQueryMetrics(t *testing.T) {
 	require.GreaterOrEqual(t, testutil.ToFloat64(queryTime.WithLabelValues("userID")), float64(1))
 }
 
+func TestQuerySourceQueryFunc(t *testing.T) {
+	tests := map[string]struct {
+		ctx            context.Context
+		expectedSource string
+	}{
+		"recording rule": {
+			ctx:            rules.NewOriginContext(context.Background(), rules.RuleDetail{Kind: rules.KindRecording}),
+			expectedSource: querysource.Ruler,
+		},
+		"alerting rule": {
+			ctx:            rules.NewOriginContext(context.Background(), rules.RuleDetail{Kind: rules.KindAlerting}),
+			expectedSource: querysource.Alerting,
+		},
+		"unknown rule": {
+			ctx:            context.Background(),
+			expectedSource: querysource.Ruler,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actualSource string
+			qf := QuerySourceQueryFunc(func(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+				actualSource = querysource.FromContext(ctx)
+				return promql.Vector{}, nil
+			})
+
+			_, err := qf(testData.ctx, "test", time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedSource, actualSource)
+		})
+	}
+}
+
 // TestManagerFactory_CorrectQueryableUsed ensures that when evaluating a group with non-empty SourceTenants
 // the federated queryable is called. If SourceTenants are empty, then the regular queryable should be used.
 // This is to ensure that the `__tenant_id__` label is present for all rules evaluating within a federated rule group.
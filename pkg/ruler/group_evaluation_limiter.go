@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	promRules "github.com/prometheus/prometheus/rules"
+)
+
+// groupEvaluationLimiter bounds the number of rule groups concurrently evaluated for each tenant.
+// Rule groups whose evaluation is due while the tenant's limit is reached are queued and
+// evaluated in FIFO order, so that a burst of groups becoming due at the same time (e.g. after
+// a ruler restart) is spread over time instead of hitting the read and write path all at once.
+type groupEvaluationLimiter struct {
+	limits RulesLimits
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantGroupEvaluations
+
+	queueLength *prometheus.GaugeVec
+}
+
+// tenantGroupEvaluations holds the state of in-flight and queued rule group evaluations for a tenant.
+type tenantGroupEvaluations struct {
+	inflight int
+
+	// waiters is a FIFO queue of channels, one for each rule group waiting to be evaluated.
+	// The channel is closed once the rule group is allowed to proceed.
+	waiters list.List
+}
+
+func newGroupEvaluationLimiter(limits RulesLimits, reg prometheus.Registerer) *groupEvaluationLimiter {
+	return &groupEvaluationLimiter{
+		limits:  limits,
+		tenants: map[string]*tenantGroupEvaluations{},
+		queueLength: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_rule_groups_evaluation_queue_length",
+			Help: "Number of rule groups waiting to be evaluated because the tenant reached the maximum number of concurrently evaluated rule groups.",
+		}, []string{"user"}),
+	}
+}
+
+// wrapEvalIterationFunc returns a rules.GroupEvalIterationFunc which evaluates the rule group with next only
+// once the tenant is allowed to evaluate one more rule group concurrently.
+func (l *groupEvaluationLimiter) wrapEvalIterationFunc(userID string, next promRules.GroupEvalIterationFunc) promRules.GroupEvalIterationFunc {
+	return func(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
+		if err := l.acquire(ctx, userID); err != nil {
+			// The context is canceled only when the rule group is stopped, so there's nothing to evaluate.
+			return
+		}
+		defer l.release(userID)
+
+		next(ctx, g, evalTimestamp)
+	}
+}
+
+// acquire blocks until the tenant is allowed to evaluate one more rule group or the input
+// context is canceled.
+func (l *groupEvaluationLimiter) acquire(ctx context.Context, userID string) error {
+	maxConcurrent := l.limits.RulerMaxConcurrentRuleGroups(userID)
+
+	l.mtx.Lock()
+	tenant := l.getOrCreateTenant(userID)
+
+	// The limit may have been raised or removed in the meanwhile, so queued rule groups
+	// are given the chance to proceed first.
+	l.dequeueLocked(userID, tenant, maxConcurrent)
+
+	// Do not overtake rule groups already queued, to guarantee FIFO ordering.
+	if tenant.waiters.Len() == 0 && (maxConcurrent <= 0 || tenant.inflight < maxConcurrent) {
+		tenant.inflight++
+		l.mtx.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	elem := tenant.waiters.PushBack(ready)
+	l.queueLength.WithLabelValues(userID).Inc()
+	l.mtx.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+
+		select {
+		case <-ready:
+			// The slot has been granted in the meanwhile, so we have to give it back.
+			l.releaseLocked(userID, tenant)
+		default:
+			tenant.waiters.Remove(elem)
+			l.queueLength.WithLabelValues(userID).Dec()
+			l.cleanupLocked(userID, tenant)
+		}
+
+		return ctx.Err()
+	}
+}
+
+// release must be called once a rule group evaluation, previously allowed by acquire(), has completed.
+func (l *groupEvaluationLimiter) release(userID string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	tenant, ok := l.tenants[userID]
+	if !ok {
+		return
+	}
+
+	l.releaseLocked(userID, tenant)
+}
+
+func (l *groupEvaluationLimiter) releaseLocked(userID string, tenant *tenantGroupEvaluations) {
+	tenant.inflight--
+
+	// The limit is read again because it may have changed in the meanwhile.
+	l.dequeueLocked(userID, tenant, l.limits.RulerMaxConcurrentRuleGroups(userID))
+	l.cleanupLocked(userID, tenant)
+}
+
+// dequeueLocked lets the queued rule groups proceed, in FIFO order, until the tenant's limit is reached.
+func (l *groupEvaluationLimiter) dequeueLocked(userID string, tenant *tenantGroupEvaluations, maxConcurrent int) {
+	for tenant.waiters.Len() > 0 && (maxConcurrent <= 0 || tenant.inflight < maxConcurrent) {
+		ready := tenant.waiters.Remove(tenant.waiters.Front()).(chan struct{})
+		tenant.inflight++
+		l.queueLength.WithLabelValues(userID).Dec()
+		close(ready)
+	}
+}
+
+func (l *groupEvaluationLimiter) getOrCreateTenant(userID string) *tenantGroupEvaluations {
+	tenant, ok := l.tenants[userID]
+	if !ok {
+		tenant = &tenantGroupEvaluations{}
+		l.tenants[userID] = tenant
+	}
+	return tenant
+}
+
+// cleanupLocked removes the tenant state once there are no more in-flight or queued evaluations.
+func (l *groupEvaluationLimiter) cleanupLocked(userID string, tenant *tenantGroupEvaluations) {
+	if tenant.inflight == 0 && tenant.waiters.Len() == 0 {
+		delete(l.tenants, userID)
+	}
+}
+
+// removeUser removes the metrics tracked for the input tenant. The metrics are kept if there are
+// still rule groups queued, because they will be removed from the queue once their rules manager stops.
+func (l *groupEvaluationLimiter) removeUser(userID string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if tenant, ok := l.tenants[userID]; ok && tenant.waiters.Len() > 0 {
+		return
+	}
+	l.queueLength.DeleteLabelValues(userID)
+}
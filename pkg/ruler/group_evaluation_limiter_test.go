@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestGroupEvaluationLimiter(t *testing.T) {
+	const userID = "user-1"
+
+	limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits[userID] = validation.MockDefaultLimits()
+		tenantLimits[userID].RulerMaxConcurrentRuleGroups = 2
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	l := newGroupEvaluationLimiter(limits, reg)
+	ctx := context.Background()
+
+	// The first two rule groups should be allowed to proceed immediately.
+	require.NoError(t, l.acquire(ctx, userID))
+	require.NoError(t, l.acquire(ctx, userID))
+
+	// Any further rule group should be queued, in order.
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			if err := l.acquire(ctx, userID); !assert.NoError(t, err) {
+				return
+			}
+			order <- i
+		}()
+
+		// Wait until the rule group has been queued, to have a deterministic order.
+		test.Poll(t, time.Second, float64(i+1), func() interface{} {
+			return promtest.ToFloat64(l.queueLength.WithLabelValues(userID))
+		})
+	}
+
+	// A rule group waiting with a canceled context should be removed from the queue.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	errCh := make(chan error)
+	go func() {
+		errCh <- l.acquire(canceledCtx, userID)
+	}()
+	test.Poll(t, time.Second, float64(4), func() interface{} {
+		return promtest.ToFloat64(l.queueLength.WithLabelValues(userID))
+	})
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ruler_rule_groups_evaluation_queue_length Number of rule groups waiting to be evaluated because the tenant reached the maximum number of concurrently evaluated rule groups.
+		# TYPE cortex_ruler_rule_groups_evaluation_queue_length gauge
+		cortex_ruler_rule_groups_evaluation_queue_length{user="user-1"} 3
+	`), "cortex_ruler_rule_groups_evaluation_queue_length"))
+
+	// Releasing a slot should let queued rule groups proceed in FIFO order.
+	for i := 0; i < 3; i++ {
+		l.release(userID)
+		assert.Equal(t, i, <-order)
+	}
+
+	// Other tenants are not affected by the limit.
+	require.NoError(t, l.acquire(ctx, "user-2"))
+	require.NoError(t, l.acquire(ctx, "user-2"))
+	require.NoError(t, l.acquire(ctx, "user-2"))
+
+	// Once all evaluations have completed, the tenant state should be cleaned up.
+	for i := 0; i < 2; i++ {
+		l.release(userID)
+	}
+	for i := 0; i < 3; i++ {
+		l.release("user-2")
+	}
+
+	l.mtx.Lock()
+	assert.Empty(t, l.tenants)
+	l.mtx.Unlock()
+
+	l.removeUser(userID)
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(""), "cortex_ruler_rule_groups_evaluation_queue_length"))
+}
+
+func TestGroupEvaluationLimiter_LimitRemovedWhileQueued(t *testing.T) {
+	const userID = "user-1"
+
+	var tenantLimits *validation.Limits
+	limits := validation.MockOverrides(func(defaults *validation.Limits, limits map[string]*validation.Limits) {
+		tenantLimits = validation.MockDefaultLimits()
+		tenantLimits.RulerMaxConcurrentRuleGroups = 1
+		limits[userID] = tenantLimits
+	})
+
+	l := newGroupEvaluationLimiter(limits, prometheus.NewPedanticRegistry())
+	ctx := context.Background()
+
+	require.NoError(t, l.acquire(ctx, userID))
+
+	order := make(chan int, 2)
+	errs := make(chan error, 2)
+	acquire := func(i int) {
+		if err := l.acquire(ctx, userID); err != nil {
+			errs <- err
+			return
+		}
+		order <- i
+	}
+
+	for i := 0; i < 2; i++ {
+		go acquire(i)
+
+		test.Poll(t, time.Second, float64(i+1), func() interface{} {
+			return promtest.ToFloat64(l.queueLength.WithLabelValues(userID))
+		})
+	}
+
+	// Once the limit is removed, the queued rule groups should proceed before a new one.
+	l.mtx.Lock()
+	tenantLimits.RulerMaxConcurrentRuleGroups = 0
+	l.mtx.Unlock()
+
+	require.NoError(t, l.acquire(ctx, userID))
+	assert.Equal(t, float64(0), promtest.ToFloat64(l.queueLength.WithLabelValues(userID)))
+
+	var proceeded []int
+	for len(proceeded) < 2 {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case i := <-order:
+			proceeded = append(proceeded, i)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for the queued rule groups to proceed")
+		}
+	}
+	assert.ElementsMatch(t, []int{0, 1}, proceeded)
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"reflect"
 	"sync"
 
 	"github.com/go-kit/log"
@@ -34,10 +35,21 @@ import (
 type DefaultMultiTenantManager struct {
 	cfg            Config
 	notifierCfg    *config.Config
+	dnsResolver    cache.AddressProvider
 	managerFactory ManagerFactory
 
 	mapper *mapper
 
+	// Limits the number of rule groups concurrently evaluated by each tenant.
+	groupEvaluationLimiter *groupEvaluationLimiter
+
+	// Records the state transitions of the alerts. Nil if disabled.
+	alertStateHistory *alertStateHistory
+
+	// Skips the evaluation of the rule groups whose enable condition returns an empty result. Nil if the
+	// enable conditions can't be evaluated.
+	groupEnableConditions *groupEnableConditions
+
 	// Struct for holding per-user Prometheus rules Managers.
 	userManagerMtx sync.RWMutex
 	userManagers   map[string]RulesManager
@@ -49,6 +61,10 @@ type DefaultMultiTenantManager struct {
 	notifiersMtx sync.Mutex
 	notifiers    map[string]*rulerNotifier
 
+	// Stores the notifications which have not been delivered to the Alertmanager. Nil if disabled.
+	deadLetter *NotificationsDeadLetter
+	limits     RulesLimits
+
 	managersTotal                 prometheus.Gauge
 	lastReloadSuccessful          *prometheus.GaugeVec
 	lastReloadSuccessfulTimestamp *prometheus.GaugeVec
@@ -59,7 +75,9 @@ type DefaultMultiTenantManager struct {
 	rulerIsRunning atomic.Bool
 }
 
-func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg prometheus.Registerer, logger log.Logger, dnsResolver cache.AddressProvider) (*DefaultMultiTenantManager, error) {
+// NewDefaultMultiTenantManager makes a new DefaultMultiTenantManager. The conditionsQueryFunc is used to evaluate
+// the rule groups enable conditions, which are ignored if it's nil.
+func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, conditionsQueryFunc promRules.QueryFunc, limits RulesLimits, deadLetter *NotificationsDeadLetter, reg prometheus.Registerer, logger log.Logger, dnsResolver cache.AddressProvider) (*DefaultMultiTenantManager, error) {
 	ncfg, err := buildNotifierConfig(&cfg, dnsResolver)
 	if err != nil {
 		return nil, err
@@ -70,14 +88,30 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		reg.MustRegister(userManagerMetrics)
 	}
 
+	var history *alertStateHistory
+	if cfg.AlertStateHistory.Enabled {
+		history = newAlertStateHistory(cfg.AlertStateHistory.MaxTransitionsPerRule)
+	}
+
+	var conditions *groupEnableConditions
+	if conditionsQueryFunc != nil {
+		conditions = newGroupEnableConditions(conditionsQueryFunc, reg, logger)
+	}
+
 	return &DefaultMultiTenantManager{
-		cfg:                cfg,
-		notifierCfg:        ncfg,
-		managerFactory:     managerFactory,
-		notifiers:          map[string]*rulerNotifier{},
-		mapper:             newMapper(cfg.RulePath, logger),
-		userManagers:       map[string]RulesManager{},
-		userManagerMetrics: userManagerMetrics,
+		cfg:                    cfg,
+		notifierCfg:            ncfg,
+		dnsResolver:            dnsResolver,
+		managerFactory:         managerFactory,
+		notifiers:              map[string]*rulerNotifier{},
+		deadLetter:             deadLetter,
+		limits:                 limits,
+		mapper:                 newMapper(cfg.RulePath, logger),
+		groupEvaluationLimiter: newGroupEvaluationLimiter(limits, reg),
+		alertStateHistory:      history,
+		groupEnableConditions:  conditions,
+		userManagers:           map[string]RulesManager{},
+		userManagerMetrics:     userManagerMetrics,
 		managersTotal: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Namespace: "cortex",
 			Name:      "ruler_managers_total",
@@ -208,6 +242,18 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 		return
 	}
 
+	// The enable conditions are kept in memory, so they're updated even if the rules on disk haven't changed.
+	if r.groupEnableConditions != nil {
+		r.groupEnableConditions.setGroups(user, groups)
+	}
+
+	// The tenant's Alertmanager config may have changed since the notifier has been created.
+	if !created {
+		if err := r.syncNotifierConfig(user); err != nil {
+			level.Error(r.logger).Log("msg", "unable to update the notifier Alertmanager config, keeping the previous one", "user", user, "err", err)
+		}
+	}
+
 	// We need to update the manager only if it was just created or rules on disk have changed.
 	if !(created || update) {
 		level.Debug(r.logger).Log("msg", "rules have not changed, skipping rule manager update", "user", user)
@@ -217,13 +263,26 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 	level.Debug(r.logger).Log("msg", "updating rules", "user", user)
 	r.configUpdatesTotal.WithLabelValues(user).Inc()
 
-	err = manager.Update(r.cfg.EvaluationInterval, files, labels.EmptyLabels(), r.cfg.ExternalURL.String(), nil)
+	evalIterationFunc := promRules.DefaultEvalIterationFunc
+	if r.groupEnableConditions != nil {
+		evalIterationFunc = r.groupEnableConditions.wrapEvalIterationFunc(user, evalIterationFunc)
+	}
+	evalIterationFunc = r.groupEvaluationLimiter.wrapEvalIterationFunc(user, evalIterationFunc)
+	if r.alertStateHistory != nil {
+		evalIterationFunc = r.alertStateHistory.wrapEvalIterationFunc(user, evalIterationFunc)
+	}
+
+	err = manager.Update(r.cfg.EvaluationInterval, files, labels.EmptyLabels(), r.cfg.ExternalURL.String(), evalIterationFunc)
 	if err != nil {
 		r.lastReloadSuccessful.WithLabelValues(user).Set(0)
 		level.Error(r.logger).Log("msg", "unable to update rule manager", "user", user, "err", err)
 		return
 	}
 
+	if r.alertStateHistory != nil {
+		r.alertStateHistory.retainGroups(user, manager.RuleGroups())
+	}
+
 	r.lastReloadSuccessful.WithLabelValues(user).Set(1)
 	r.lastReloadSuccessfulTimestamp.WithLabelValues(user).SetToCurrentTime()
 }
@@ -270,7 +329,7 @@ func (r *DefaultMultiTenantManager) getOrCreateManager(ctx context.Context, user
 // newManager creates a prometheus rule manager wrapped with a user id
 // configured storage, appendable, notifier, and instrumentation
 func (r *DefaultMultiTenantManager) newManager(ctx context.Context, userID string) (RulesManager, error) {
-	notifier, err := r.getOrCreateNotifier(userID)
+	sender, err := r.getOrCreateSender(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -280,49 +339,123 @@ func (r *DefaultMultiTenantManager) newManager(ctx context.Context, userID strin
 	reg := prometheus.NewRegistry()
 	r.userManagerMetrics.AddUserRegistry(userID, reg)
 
-	return r.managerFactory(ctx, userID, notifier, r.logger, reg), nil
+	return r.managerFactory(ctx, userID, sender, r.logger, reg), nil
+}
+
+// getOrCreateSender returns the AlertSender sending the alert notifications of the user to the Alertmanager.
+func (r *DefaultMultiTenantManager) getOrCreateSender(userID string) (AlertSender, error) {
+	n, err := r.getOrCreateRulerNotifier(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.deadLetter == nil {
+		return n.notifier, nil
+	}
+	return &deadLetterSender{
+		userID:        userID,
+		notifier:      n.notifier,
+		queueLength:   n.queueLength,
+		queueCapacity: r.cfg.NotificationQueueCapacity,
+		limits:        r.limits,
+		deadLetter:    r.deadLetter,
+	}, nil
 }
 
 func (r *DefaultMultiTenantManager) getOrCreateNotifier(userID string) (*notifier.Manager, error) {
+	n, err := r.getOrCreateRulerNotifier(userID)
+	if err != nil {
+		return nil, err
+	}
+	return n.notifier, nil
+}
+
+func (r *DefaultMultiTenantManager) getOrCreateRulerNotifier(userID string) (*rulerNotifier, error) {
 	r.notifiersMtx.Lock()
 	defer r.notifiersMtx.Unlock()
 
 	n, ok := r.notifiers[userID]
 	if ok {
-		return n.notifier, nil
+		return n, nil
 	}
 
 	reg := prometheus.WrapRegistererWith(prometheus.Labels{"user": userID}, r.registry)
 	reg = prometheus.WrapRegistererWithPrefix("cortex_", reg)
+	var do doFunc = func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+		// Note: The passed-in context comes from the Prometheus notifier
+		// and does *not* contain the userID. So it needs to be added to the context
+		// here before using the context to inject the userID into the HTTP request.
+		ctx = user.InjectOrgID(ctx, userID)
+		if err := user.InjectOrgIDIntoHTTPRequest(ctx, req); err != nil {
+			return nil, err
+		}
+		// Jaeger complains the passed-in context has an invalid span ID, so start a new root span
+		sp := ot.GlobalTracer().StartSpan("notify", ot.Tag{Key: "organization", Value: userID})
+		defer sp.Finish()
+		ctx = ot.ContextWithSpan(ctx, sp)
+		_ = ot.GlobalTracer().Inject(sp.Context(), ot.HTTPHeaders, ot.HTTPHeadersCarrier(req.Header))
+		return ctxhttp.Do(ctx, client, req)
+	}
+	// The alerts replayed from the dead-letter are delivered synchronously, so they're never stored
+	// again in the dead-letter when their delivery fails.
+	deliverDo := do
+	if r.deadLetter != nil {
+		do = r.deadLetter.wrapDo(userID, r.limits, do)
+	}
+
 	n = newRulerNotifier(&notifier.Options{
 		QueueCapacity: r.cfg.NotificationQueueCapacity,
 		Registerer:    reg,
-		Do: func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
-			// Note: The passed-in context comes from the Prometheus notifier
-			// and does *not* contain the userID. So it needs to be added to the context
-			// here before using the context to inject the userID into the HTTP request.
-			ctx = user.InjectOrgID(ctx, userID)
-			if err := user.InjectOrgIDIntoHTTPRequest(ctx, req); err != nil {
-				return nil, err
-			}
-			// Jaeger complains the passed-in context has an invalid span ID, so start a new root span
-			sp := ot.GlobalTracer().StartSpan("notify", ot.Tag{Key: "organization", Value: userID})
-			defer sp.Finish()
-			ctx = ot.ContextWithSpan(ctx, sp)
-			_ = ot.GlobalTracer().Inject(sp.Context(), ot.HTTPHeaders, ot.HTTPHeadersCarrier(req.Header))
-			return ctxhttp.Do(ctx, client, req)
-		},
+		Do:            do,
 	}, log.With(r.logger, "user", userID))
+	n.do = deliverDo
 
 	n.run()
 
-	// This should never fail, unless there's a programming mistake.
-	if err := n.applyConfig(r.notifierCfg); err != nil {
+	if err := r.applyNotifierConfig(userID, n); err != nil {
+		n.stop()
 		return nil, err
 	}
 
 	r.notifiers[userID] = n
-	return n.notifier, nil
+	return n, nil
+}
+
+// syncNotifierConfig re-configures the notifier of the user, if any, when the user's Alertmanager config has changed.
+func (r *DefaultMultiTenantManager) syncNotifierConfig(userID string) error {
+	r.notifiersMtx.Lock()
+	defer r.notifiersMtx.Unlock()
+
+	n, ok := r.notifiers[userID]
+	if !ok || reflect.DeepEqual(n.tenantCfg, r.limits.RulerAlertmanagerClientConfig(userID)) {
+		return nil
+	}
+
+	return r.applyNotifierConfig(userID, n)
+}
+
+// applyNotifierConfig configures the notifier with the user's Alertmanager config, or the ruler-wide one if
+// the user doesn't have its own Alertmanager. Must be called with the notifiers lock held.
+func (r *DefaultMultiTenantManager) applyNotifierConfig(userID string, n *rulerNotifier) error {
+	tenantCfg := r.limits.RulerAlertmanagerClientConfig(userID)
+	if !tenantCfg.IsEnabled() {
+		// This should never fail, unless there's a programming mistake.
+		if err := n.applyConfig(r.notifierCfg); err != nil {
+			return err
+		}
+		n.tenantCfg = tenantCfg
+		return nil
+	}
+
+	ncfg, err := buildTenantNotifierConfig(&r.cfg, r.dnsResolver, tenantCfg)
+	if err != nil {
+		return errors.Wrap(err, "invalid tenant Alertmanager config")
+	}
+	if err := n.applyConfig(ncfg); err != nil {
+		return errors.Wrap(err, "invalid tenant Alertmanager config")
+	}
+	n.tenantCfg = tenantCfg
+	return nil
 }
 
 // removeUsersIf stops the manager and cleanup the resources for each user for which
@@ -345,12 +478,27 @@ func (r *DefaultMultiTenantManager) removeUsersIf(shouldRemove func(userID strin
 		r.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 		r.configUpdatesTotal.DeleteLabelValues(userID)
 		r.userManagerMetrics.RemoveUserRegistry(userID)
+		r.groupEvaluationLimiter.removeUser(userID)
+		if r.groupEnableConditions != nil {
+			r.groupEnableConditions.removeUser(userID)
+		}
+		if r.alertStateHistory != nil {
+			r.alertStateHistory.removeUser(userID)
+		}
 		level.Info(r.logger).Log("msg", "deleted rule manager and local rule files", "user", userID)
 	}
 
 	r.managersTotal.Set(float64(len(r.userManagers)))
 }
 
+// GetAlertStateHistory implements MultiTenantManager.
+func (r *DefaultMultiTenantManager) GetAlertStateHistory(userID string, group *promRules.Group, ruleName string) []*AlertStateTransitionDesc {
+	if r.alertStateHistory == nil {
+		return nil
+	}
+	return r.alertStateHistory.history(userID, group, ruleName)
+}
+
 func (r *DefaultMultiTenantManager) GetRules(userID string) []*promRules.Group {
 	r.userManagerMtx.RLock()
 	mngr, exists := r.userManagers[userID]
@@ -386,6 +534,11 @@ func (r *DefaultMultiTenantManager) Stop() {
 	r.userManagerMtx.Unlock()
 	level.Info(r.logger).Log("msg", "all user managers stopped")
 
+	// The notifiers and user managers have been stopped, so no more entries can be added to the dead-letter.
+	if r.deadLetter != nil {
+		r.deadLetter.stop()
+	}
+
 	// cleanup user rules directories
 	r.mapper.cleanup()
 }
@@ -0,0 +1,588 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"bytes"
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/tenant"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/notifier"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// NotificationsDeadLetterPrefix is the prefix, within the ruler storage bucket, where the notifications
+	// dead-letter entries of each tenant are stored.
+	NotificationsDeadLetterPrefix = "notifications-dead-letter"
+
+	deadLetterReasonQueueOverflow  = "queue-overflow"
+	deadLetterReasonDeliveryFailed = "delivery-failed"
+
+	// Timeout of the bucket operations issued to store an entry in the dead-letter. The context of the
+	// notification may already be expired when the entry is stored, so it's not used.
+	deadLetterUploadTimeout = 10 * time.Second
+
+	// Max number of entries waiting to be stored in the dead-letter by the background writer. Once
+	// reached, the notifications of the new entries are lost.
+	deadLetterWriteQueueCapacity = 1000
+)
+
+var (
+	errDeadLetterEntryNotFound     = errors.New("notifications dead-letter entry not found")
+	errInvalidDeadLetterMaxEntries = errors.New("the notifications dead-letter max entries per tenant must be greater than 0")
+	errInvalidDeadLetterMaxRetries = errors.New("the notifications dead-letter max retries must be greater than or equal to 0")
+	errDeadLetterWriteQueueFull    = errors.New("the notifications dead-letter write queue is full")
+	errDeadLetterStopped           = errors.New("the notifications dead-letter has been stopped")
+)
+
+// NotificationsDeadLetterConfig holds the configuration of the ruler notifications dead-letter.
+type NotificationsDeadLetterConfig struct {
+	Enabled             bool `yaml:"enabled" category:"experimental"`
+	MaxEntriesPerTenant int  `yaml:"max_entries_per_tenant" category:"experimental"`
+	MaxRetries          int  `yaml:"max_retries" category:"experimental"`
+}
+
+func (cfg *NotificationsDeadLetterConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ruler.notifications-dead-letter.enabled", false, "True to enable the notifications dead-letter, stored in the ruler storage bucket. Alert notifications are stored in the dead-letter only for the tenants whose notification queue overflow policy is "+validation.RulerNotificationQueueOverflowPolicyDeadLetter+". Stored notifications can be inspected and replayed through the ruler API.")
+	f.IntVar(&cfg.MaxEntriesPerTenant, "ruler.notifications-dead-letter.max-entries-per-tenant", 1000, "Maximum number of entries stored in the notifications dead-letter of each tenant. Once reached, the oldest entries are deleted.")
+	f.IntVar(&cfg.MaxRetries, "ruler.notifications-dead-letter.max-retries", 3, "Maximum number of times the delivery of a notification to an Alertmanager is retried, within the notification timeout, before storing it in the dead-letter.")
+}
+
+func (cfg *NotificationsDeadLetterConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MaxEntriesPerTenant <= 0 {
+		return errInvalidDeadLetterMaxEntries
+	}
+	if cfg.MaxRetries < 0 {
+		return errInvalidDeadLetterMaxRetries
+	}
+	return nil
+}
+
+// DeadLetterEntry is a set of alert notifications which have not been delivered to the Alertmanager.
+type DeadLetterEntry struct {
+	ID        string            `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Reason    string            `json:"reason"`
+	Error     string            `json:"error,omitempty"`
+	Alerts    []*notifier.Alert `json:"alerts"`
+}
+
+// deadLetterWrite is an entry waiting to be stored in the dead-letter by the background writer.
+type deadLetterWrite struct {
+	userID string
+	reason string
+	cause  error
+	alerts []*notifier.Alert
+}
+
+// NotificationsDeadLetter stores the alert notifications which have not been delivered to the Alertmanager,
+// either because they didn't fit in the tenant's notification queue or because their delivery failed after
+// retries. Each tenant's dead-letter is bounded to a max number of entries, deleting the oldest ones.
+//
+// The notifications are stored by a background writer, so that the notification path never waits for the
+// bucket. The background writer is started when the dead-letter is created, and must be stopped with stop().
+type NotificationsDeadLetter struct {
+	cfg         NotificationsDeadLetterConfig
+	bucket      objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	logger      log.Logger
+
+	// Serializes the writes, so that the max number of entries is enforced and the
+	// IDs of the entries are generated in order.
+	writeMtx sync.Mutex
+	entropy  io.Reader
+
+	writes   chan deadLetterWrite
+	stopping chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	entriesAdded    *prometheus.CounterVec
+	addFailures     prometheus.Counter
+	entriesReplayed *prometheus.CounterVec
+}
+
+// NewNotificationsDeadLetter makes a new NotificationsDeadLetter storing the entries in the input bucket.
+func NewNotificationsDeadLetter(cfg NotificationsDeadLetterConfig, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *NotificationsDeadLetter {
+	d := &NotificationsDeadLetter{
+		cfg:         cfg,
+		bucket:      bucket.NewPrefixedBucketClient(bkt, NotificationsDeadLetterPrefix),
+		cfgProvider: cfgProvider,
+		logger:      logger,
+		entropy:     ulid.Monotonic(crypto_rand.Reader, 0),
+		writes:      make(chan deadLetterWrite, deadLetterWriteQueueCapacity),
+		stopping:    make(chan struct{}),
+		entriesAdded: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_notifications_dead_letter_entries_added_total",
+			Help: "Total number of entries added to the notifications dead-letter.",
+		}, []string{"user", "reason"}),
+		addFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_notifications_dead_letter_add_failures_total",
+			Help: "Total number of entries which failed to be added to the notifications dead-letter. The notifications of these entries are lost.",
+		}),
+		entriesReplayed: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_notifications_dead_letter_entries_replayed_total",
+			Help: "Total number of notifications dead-letter entries replayed.",
+		}, []string{"user"}),
+	}
+
+	d.wg.Add(1)
+	go d.runWriter()
+
+	return d
+}
+
+// runWriter stores the enqueued entries in the dead-letter until stopped. The entries enqueued
+// before stopping are stored before returning.
+func (d *NotificationsDeadLetter) runWriter() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case w := <-d.writes:
+			d.addAndLog(w)
+		case <-d.stopping:
+			for {
+				select {
+				case w := <-d.writes:
+					d.addAndLog(w)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// stop stops the background writer, once the enqueued entries have been stored.
+func (d *NotificationsDeadLetter) stop() {
+	d.stopOnce.Do(func() { close(d.stopping) })
+	d.wg.Wait()
+}
+
+// Add stores the alerts in the tenant's dead-letter, deleting the oldest entries if the tenant's
+// max number of entries is exceeded.
+func (d *NotificationsDeadLetter) Add(ctx context.Context, userID, reason string, cause error, alerts []*notifier.Alert) error {
+	d.writeMtx.Lock()
+	defer d.writeMtx.Unlock()
+
+	now := time.Now()
+	entry := DeadLetterEntry{
+		ID:        ulid.MustNew(ulid.Timestamp(now), d.entropy).String(),
+		Timestamp: now,
+		Reason:    reason,
+		Alerts:    alerts,
+	}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshal dead-letter entry")
+	}
+
+	userBkt := bucket.NewUserBucketClient(userID, d.bucket, d.cfgProvider)
+	if err := userBkt.Upload(ctx, deadLetterEntryPath(entry.ID), bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "upload dead-letter entry")
+	}
+	d.entriesAdded.WithLabelValues(userID, reason).Inc()
+
+	ids, err := d.listIDs(ctx, userBkt)
+	if err != nil {
+		return errors.Wrap(err, "list dead-letter entries")
+	}
+
+	// The IDs are ULIDs, so the oldest entries come first.
+	for len(ids) > d.cfg.MaxEntriesPerTenant {
+		if err := userBkt.Delete(ctx, deadLetterEntryPath(ids[0])); err != nil && !userBkt.IsObjNotFoundErr(err) {
+			return errors.Wrap(err, "delete oldest dead-letter entry")
+		}
+		ids = ids[1:]
+	}
+
+	return nil
+}
+
+// enqueue enqueues the alerts to be stored in the tenant's dead-letter by the background writer. It never
+// blocks: if the write queue is full, the alerts are lost.
+func (d *NotificationsDeadLetter) enqueue(userID, reason string, cause error, alerts []*notifier.Alert) {
+	w := deadLetterWrite{userID: userID, reason: reason, cause: cause, alerts: alerts}
+
+	select {
+	case <-d.stopping:
+		d.logAddFailure(w, errDeadLetterStopped)
+		return
+	default:
+	}
+
+	select {
+	case d.writes <- w:
+	default:
+		d.logAddFailure(w, errDeadLetterWriteQueueFull)
+	}
+}
+
+// addAndLog stores the alerts in the tenant's dead-letter, logging any failure. The context of the
+// notification isn't used, because it may be already expired.
+func (d *NotificationsDeadLetter) addAndLog(w deadLetterWrite) {
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterUploadTimeout)
+	defer cancel()
+
+	if err := d.Add(ctx, w.userID, w.reason, w.cause, w.alerts); err != nil {
+		d.logAddFailure(w, err)
+		return
+	}
+	level.Warn(d.logger).Log("msg", "added alert notifications to the dead-letter", "user", w.userID, "reason", w.reason, "alerts", len(w.alerts), "cause", w.cause)
+}
+
+func (d *NotificationsDeadLetter) logAddFailure(w deadLetterWrite, err error) {
+	d.addFailures.Inc()
+	level.Error(d.logger).Log("msg", "failed to add alert notifications to the dead-letter", "user", w.userID, "reason", w.reason, "alerts", len(w.alerts), "err", err)
+}
+
+// List returns the entries of the tenant's dead-letter, oldest first.
+func (d *NotificationsDeadLetter) List(ctx context.Context, userID string) ([]DeadLetterEntry, error) {
+	userBkt := bucket.NewUserBucketClient(userID, d.bucket, d.cfgProvider)
+
+	ids, err := d.listIDs(ctx, userBkt)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DeadLetterEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := d.get(ctx, userBkt, id)
+		if errors.Is(err, errDeadLetterEntryNotFound) {
+			// The entry has been concurrently deleted.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// Get returns the entry of the tenant's dead-letter with the input ID.
+func (d *NotificationsDeadLetter) Get(ctx context.Context, userID, id string) (*DeadLetterEntry, error) {
+	if _, err := ulid.Parse(id); err != nil {
+		return nil, errDeadLetterEntryNotFound
+	}
+	return d.get(ctx, bucket.NewUserBucketClient(userID, d.bucket, d.cfgProvider), id)
+}
+
+// Delete deletes the entry of the tenant's dead-letter with the input ID.
+func (d *NotificationsDeadLetter) Delete(ctx context.Context, userID, id string) error {
+	if _, err := ulid.Parse(id); err != nil {
+		return errDeadLetterEntryNotFound
+	}
+
+	userBkt := bucket.NewUserBucketClient(userID, d.bucket, d.cfgProvider)
+	err := userBkt.Delete(ctx, deadLetterEntryPath(id))
+	if userBkt.IsObjNotFoundErr(err) {
+		return errDeadLetterEntryNotFound
+	}
+	return err
+}
+
+// alertDeliverFunc synchronously delivers the alerts to the Alertmanager, returning an error if the
+// delivery failed.
+type alertDeliverFunc func(ctx context.Context, alerts []*notifier.Alert) error
+
+// Replay delivers the alerts of the tenant's dead-letter entries with the input IDs, or all the entries if
+// no ID is specified, and deletes each entry once its alerts have been delivered. It stops at the first
+// delivery failure, keeping the entries not delivered yet. It returns the number of replayed entries.
+func (d *NotificationsDeadLetter) Replay(ctx context.Context, userID string, ids []string, deliver alertDeliverFunc) (int, error) {
+	var entries []DeadLetterEntry
+	if len(ids) == 0 {
+		var err error
+		if entries, err = d.List(ctx, userID); err != nil {
+			return 0, err
+		}
+	} else {
+		for _, id := range ids {
+			entry, err := d.Get(ctx, userID, id)
+			if err != nil {
+				return 0, errors.Wrapf(err, "entry %s", id)
+			}
+			entries = append(entries, *entry)
+		}
+	}
+
+	replayed := 0
+	for _, entry := range entries {
+		if err := deliver(ctx, entry.Alerts); err != nil {
+			return replayed, errors.Wrapf(err, "deliver entry %s", entry.ID)
+		}
+
+		// The entry has been delivered, so a failure deleting it would only cause it to be replayed again.
+		if err := d.Delete(ctx, userID, entry.ID); err != nil && !errors.Is(err, errDeadLetterEntryNotFound) {
+			return replayed, errors.Wrapf(err, "delete replayed entry %s", entry.ID)
+		}
+
+		replayed++
+		d.entriesReplayed.WithLabelValues(userID).Inc()
+	}
+
+	return replayed, nil
+}
+
+func (d *NotificationsDeadLetter) get(ctx context.Context, userBkt objstore.Bucket, id string) (*DeadLetterEntry, error) {
+	reader, err := userBkt.Get(ctx, deadLetterEntryPath(id))
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil, errDeadLetterEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	entry := &DeadLetterEntry{}
+	if err := json.NewDecoder(reader).Decode(entry); err != nil {
+		return nil, errors.Wrapf(err, "decode dead-letter entry %s", id)
+	}
+	return entry, nil
+}
+
+// listIDs returns the IDs of the entries in the user bucket, sorted.
+func (d *NotificationsDeadLetter) listIDs(ctx context.Context, userBkt objstore.Bucket) ([]string, error) {
+	var ids []string
+	err := userBkt.Iter(ctx, "", func(name string) error {
+		if id := strings.TrimSuffix(path.Base(name), ".json"); id != path.Base(name) {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func deadLetterEntryPath(id string) string {
+	return id + ".json"
+}
+
+// doFunc is the function used by the notifier to send a request to the Alertmanager.
+type doFunc func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error)
+
+// wrapDo returns a doFunc which, for tenants with the dead-letter overflow policy, retries the delivery of
+// the notifications which failed with a retriable error and stores them in the dead-letter if they can't be
+// delivered within the max retries. The notifier sends the notifications to each Alertmanager separately,
+// so the notifications are stored once for each Alertmanager they couldn't be delivered to.
+func (d *NotificationsDeadLetter) wrapDo(userID string, limits RulesLimits, do doFunc) doFunc {
+	return func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+		if limits.RulerNotificationQueueOverflowPolicy(userID) != validation.RulerNotificationQueueOverflowPolicyDeadLetter {
+			return do(ctx, client, req)
+		}
+
+		boff := backoff.New(ctx, backoff.Config{
+			MinBackoff: 100 * time.Millisecond,
+			MaxBackoff: time.Second,
+			MaxRetries: d.cfg.MaxRetries + 1,
+		})
+
+		var (
+			resp *http.Response
+			err  error
+		)
+		for {
+			resp, err = do(ctx, client, req)
+			if !isRetriableDeliveryFailure(resp, err) {
+				return resp, err
+			}
+
+			boff.Wait()
+			if !boff.Ongoing() || req.GetBody == nil {
+				break
+			}
+
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		cause := err
+		if cause == nil {
+			cause = fmt.Errorf("bad response status %s", resp.Status)
+		}
+
+		alerts, decodeErr := decodeRequestAlerts(req)
+		if decodeErr != nil {
+			d.addFailures.Inc()
+			level.Error(d.logger).Log("msg", "failed to decode the alert notifications to add to the dead-letter", "user", userID, "err", decodeErr)
+			return resp, err
+		}
+
+		d.enqueue(userID, deadLetterReasonDeliveryFailed, cause, alerts)
+		return resp, err
+	}
+}
+
+// isRetriableDeliveryFailure returns whether the delivery of notifications to the Alertmanager failed
+// with an error which may not occur if retried.
+func isRetriableDeliveryFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// decodeRequestAlerts decodes the alerts sent in the request to the Alertmanager. The payloads of both
+// the Alertmanager API v1 and v2 are compatible with notifier.Alert.
+func decodeRequestAlerts(req *http.Request) ([]*notifier.Alert, error) {
+	if req.GetBody == nil {
+		return nil, errors.New("the request body can't be read again")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	var alerts []*notifier.Alert
+	if err := json.NewDecoder(body).Decode(&alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// AlertSender sends alert notifications to the Alertmanager.
+type AlertSender interface {
+	Send(alerts ...*notifier.Alert)
+}
+
+// deadLetterSender sends alert notifications to the tenant's notifier. For tenants with the dead-letter overflow
+// policy, the alerts which don't fit in the notification queue are stored in the dead-letter, instead of dropping
+// the oldest queued alerts. The queue length is checked before sending, so alerts sent concurrently may still
+// overflow the queue.
+type deadLetterSender struct {
+	userID        string
+	notifier      AlertSender
+	queueLength   func() int
+	queueCapacity int
+	limits        RulesLimits
+	deadLetter    *NotificationsDeadLetter
+}
+
+func (s *deadLetterSender) Send(alerts ...*notifier.Alert) {
+	if s.limits.RulerNotificationQueueOverflowPolicy(s.userID) != validation.RulerNotificationQueueOverflowPolicyDeadLetter {
+		s.notifier.Send(alerts...)
+		return
+	}
+
+	free := s.queueCapacity - s.queueLength()
+	if free < 0 {
+		free = 0
+	}
+
+	if free < len(alerts) {
+		overflow := alerts[free:]
+		alerts = alerts[:free]
+		s.deadLetter.enqueue(s.userID, deadLetterReasonQueueOverflow, fmt.Errorf("the notification queue is full (capacity: %d)", s.queueCapacity), overflow)
+	}
+
+	if len(alerts) > 0 {
+		s.notifier.Send(alerts...)
+	}
+}
+
+type listDeadLetterResponse struct {
+	Entries []DeadLetterEntry `json:"entries"`
+}
+
+type replayDeadLetterResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+// NotificationsDeadLetterHandler serves the notifications dead-letter API:
+//   - GET lists the tenant's dead-letter entries, oldest first.
+//   - POST replays the tenant's dead-letter entries with the IDs specified by the "id" parameters, or all the
+//     entries if no ID is specified, delivering their alerts to the Alertmanager and deleting them once delivered.
+func (r *DefaultMultiTenantManager) NotificationsDeadLetterHandler(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), r.logger)
+
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if r.deadLetter == nil {
+		http.Error(w, "the notifications dead-letter is disabled", http.StatusNotFound)
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		entries, err := r.deadLetter.List(req.Context(), userID)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to list notifications dead-letter entries", "user", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		util.WriteJSONResponse(w, listDeadLetterResponse{Entries: entries})
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := r.getOrCreateRulerNotifier(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	replayed, err := r.deadLetter.Replay(req.Context(), userID, req.Form["id"], n.deliver)
+	if errors.Is(err, errDeadLetterEntryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to replay notifications dead-letter entries", "user", userID, "replayed", replayed, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(logger).Log("msg", "replayed notifications dead-letter entries", "user", userID, "replayed", replayed)
+	util.WriteJSONResponse(w, replayDeadLetterResponse{Replayed: replayed})
+}
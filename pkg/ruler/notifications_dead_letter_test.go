@@ -0,0 +1,461 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestNotificationsDeadLetter_AddListReplay(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	d := NewNotificationsDeadLetter(NotificationsDeadLetterConfig{Enabled: true, MaxEntriesPerTenant: 2}, bkt, nil, log.NewNopLogger(), nil)
+	t.Cleanup(d.stop)
+
+	for _, name := range []string{"first", "second", "third"} {
+		require.NoError(t, d.Add(ctx, userID, deadLetterReasonQueueOverflow, errors.New("queue full"), []*notifier.Alert{testAlert(name)}))
+	}
+	require.NoError(t, d.Add(ctx, "user-2", deadLetterReasonDeliveryFailed, nil, []*notifier.Alert{testAlert("other")}))
+
+	// The oldest entry should have been deleted once the max number of entries has been exceeded.
+	entries, err := d.List(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "second", entries[0].Alerts[0].Name())
+	assert.Equal(t, "third", entries[1].Alerts[0].Name())
+	assert.Equal(t, deadLetterReasonQueueOverflow, entries[0].Reason)
+	assert.Equal(t, "queue full", entries[0].Error)
+
+	// The entries should be stored under the dead-letter prefix, in the tenant's location.
+	exists, err := bkt.Exists(ctx, NotificationsDeadLetterPrefix+"/"+userID+"/"+entries[0].ID+".json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	sender := &mockAlertSender{}
+
+	// Replaying an unknown entry should fail.
+	_, err = d.Replay(ctx, userID, []string{"unknown"}, sender.deliver)
+	require.ErrorIs(t, err, errDeadLetterEntryNotFound)
+
+	// The entries which fail to be delivered should be kept.
+	sender.deliverErr = errors.New("delivery failed")
+	replayed, err := d.Replay(ctx, userID, nil, sender.deliver)
+	require.ErrorIs(t, err, sender.deliverErr)
+	assert.Equal(t, 0, replayed)
+
+	kept, err := d.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, entries, kept)
+	sender.deliverErr = nil
+
+	// Replay a single entry.
+	replayed, err = d.Replay(ctx, userID, []string{entries[1].ID}, sender.deliver)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []string{"third"}, sender.alertNames())
+
+	// Replay all the remaining entries.
+	replayed, err = d.Replay(ctx, userID, nil, sender.deliver)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []string{"third", "second"}, sender.alertNames())
+
+	entries, err = d.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// The entries of other tenants should be left untouched.
+	entries, err = d.List(ctx, "user-2")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, deadLetterReasonDeliveryFailed, entries[0].Reason)
+}
+
+func TestNotificationsDeadLetter_WrapDo(t *testing.T) {
+	const userID = "user-1"
+
+	tests := map[string]struct {
+		policy             string
+		statusCodes        []int
+		expectedAttempts   int
+		expectedStatus     int
+		expectedDeadLetter bool
+	}{
+		"should not retry if the tenant's overflow policy is drop-oldest": {
+			policy:           validation.RulerNotificationQueueOverflowPolicyDropOldest,
+			statusCodes:      []int{http.StatusServiceUnavailable},
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusServiceUnavailable,
+		},
+		"should not retry a successful delivery": {
+			policy:           validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			statusCodes:      []int{http.StatusOK},
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusOK,
+		},
+		"should not retry a non retriable failure": {
+			policy:           validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			statusCodes:      []int{http.StatusBadRequest},
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		"should retry a retriable failure until the delivery succeeds": {
+			policy:           validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusOK,
+		},
+		"should add the notifications to the dead-letter once the max retries are exhausted": {
+			policy:             validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			expectedAttempts:   3,
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedDeadLetter: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var (
+				mtx    sync.Mutex
+				bodies []string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				defer mtx.Unlock()
+
+				body := &bytes.Buffer{}
+				_, _ = body.ReadFrom(r.Body)
+				bodies = append(bodies, body.String())
+				w.WriteHeader(testData.statusCodes[len(bodies)-1])
+			}))
+			t.Cleanup(server.Close)
+
+			limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+				defaults.RulerNotificationQueueOverflowPolicy = testData.policy
+			})
+
+			ctx := context.Background()
+			d := NewNotificationsDeadLetter(NotificationsDeadLetterConfig{Enabled: true, MaxEntriesPerTenant: 10, MaxRetries: 2}, objstore.NewInMemBucket(), nil, log.NewNopLogger(), nil)
+			do := d.wrapDo(userID, limits, func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+				return client.Do(req.WithContext(ctx))
+			})
+
+			payload, err := json.Marshal([]*notifier.Alert{testAlert("test")})
+			require.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(payload))
+			require.NoError(t, err)
+
+			resp, err := do(ctx, server.Client(), req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, testData.expectedStatus, resp.StatusCode)
+
+			// Wait until the background writer has stored the entries.
+			d.stop()
+
+			// Each attempt should have sent the whole payload.
+			require.Len(t, bodies, testData.expectedAttempts)
+			for _, body := range bodies {
+				assert.Equal(t, string(payload), body)
+			}
+
+			entries, err := d.List(ctx, userID)
+			require.NoError(t, err)
+			if !testData.expectedDeadLetter {
+				assert.Empty(t, entries)
+				return
+			}
+
+			require.Len(t, entries, 1)
+			assert.Equal(t, deadLetterReasonDeliveryFailed, entries[0].Reason)
+			assert.Contains(t, entries[0].Error, "503")
+			require.Len(t, entries[0].Alerts, 1)
+			assert.Equal(t, "test", entries[0].Alerts[0].Name())
+		})
+	}
+}
+
+func TestDeadLetterSender(t *testing.T) {
+	const userID = "user-1"
+
+	alerts := []*notifier.Alert{testAlert("a"), testAlert("b"), testAlert("c"), testAlert("d")}
+
+	tests := map[string]struct {
+		policy             string
+		queueLength        int
+		expectedSent       []string
+		expectedDeadLetter []string
+	}{
+		"should send all the alerts if the tenant's overflow policy is drop-oldest": {
+			policy:       validation.RulerNotificationQueueOverflowPolicyDropOldest,
+			queueLength:  8,
+			expectedSent: []string{"a", "b", "c", "d"},
+		},
+		"should send all the alerts if they fit in the queue": {
+			policy:       validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			queueLength:  6,
+			expectedSent: []string{"a", "b", "c", "d"},
+		},
+		"should add the alerts which don't fit in the queue to the dead-letter": {
+			policy:             validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			queueLength:        8,
+			expectedSent:       []string{"a", "b"},
+			expectedDeadLetter: []string{"c", "d"},
+		},
+		"should add all the alerts to the dead-letter if the queue is full": {
+			policy:             validation.RulerNotificationQueueOverflowPolicyDeadLetter,
+			queueLength:        10,
+			expectedDeadLetter: []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+				defaults.RulerNotificationQueueOverflowPolicy = testData.policy
+			})
+
+			ctx := context.Background()
+			d := NewNotificationsDeadLetter(NotificationsDeadLetterConfig{Enabled: true, MaxEntriesPerTenant: 10}, objstore.NewInMemBucket(), nil, log.NewNopLogger(), nil)
+			notifier := &mockAlertSender{}
+
+			s := &deadLetterSender{
+				userID:        userID,
+				notifier:      notifier,
+				queueLength:   func() int { return testData.queueLength },
+				queueCapacity: 10,
+				limits:        limits,
+				deadLetter:    d,
+			}
+			s.Send(alerts...)
+
+			// Wait until the background writer has stored the entries.
+			d.stop()
+
+			if testData.expectedSent == nil {
+				assert.Empty(t, notifier.alertNames())
+			} else {
+				assert.Equal(t, testData.expectedSent, notifier.alertNames())
+			}
+
+			entries, err := d.List(ctx, userID)
+			require.NoError(t, err)
+			if testData.expectedDeadLetter == nil {
+				assert.Empty(t, entries)
+				return
+			}
+
+			require.Len(t, entries, 1)
+			assert.Equal(t, deadLetterReasonQueueOverflow, entries[0].Reason)
+
+			var names []string
+			for _, a := range entries[0].Alerts {
+				names = append(names, a.Name())
+			}
+			assert.Equal(t, testData.expectedDeadLetter, names)
+		})
+	}
+}
+
+func TestNotificationsDeadLetter_WriteQueueFull(t *testing.T) {
+	const userID = "user-1"
+
+	reg := prometheus.NewPedanticRegistry()
+	d := NewNotificationsDeadLetter(NotificationsDeadLetterConfig{Enabled: true, MaxEntriesPerTenant: 10}, objstore.NewInMemBucket(), nil, log.NewNopLogger(), reg)
+
+	// Block the background writer, so that the write queue fills up.
+	d.writeMtx.Lock()
+	d.enqueue(userID, deadLetterReasonQueueOverflow, nil, []*notifier.Alert{testAlert("blocked")})
+	test.Poll(t, time.Second, 0, func() interface{} { return len(d.writes) })
+
+	for i := 0; i < deadLetterWriteQueueCapacity+1; i++ {
+		d.enqueue(userID, deadLetterReasonQueueOverflow, nil, []*notifier.Alert{testAlert("test")})
+	}
+	d.writeMtx.Unlock()
+	d.stop()
+
+	// The entries which didn't fit in the write queue should have been lost, and the ones enqueued after
+	// stopping too.
+	d.enqueue(userID, deadLetterReasonQueueOverflow, nil, []*notifier.Alert{testAlert("stopped")})
+	assert.Equal(t, float64(2), testutil.ToFloat64(d.addFailures))
+
+	entries, err := d.List(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestRulerNotifier_QueueLength(t *testing.T) {
+	n := newRulerNotifier(&notifier.Options{
+		QueueCapacity: 10,
+		Registerer:    prometheus.NewPedanticRegistry(),
+	}, log.NewNopLogger())
+
+	assert.Equal(t, 0, n.queueLength())
+
+	// The notifier is not running, so the alerts are kept in the queue.
+	n.notifier.Send(testAlert("a"), testAlert("b"), testAlert("c"))
+	assert.Equal(t, 3, n.queueLength())
+}
+
+func TestDefaultMultiTenantManager_NotificationsDeadLetterHandler(t *testing.T) {
+	const userID = "user-1"
+
+	var (
+		mtx       sync.Mutex
+		amStatus  = http.StatusServiceUnavailable
+		amReqs    []*http.Request
+		amAlerts  []string
+		amBodyErr error
+	)
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		var alerts []*notifier.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			amBodyErr = err
+		}
+		amReqs = append(amReqs, r)
+		if amStatus == http.StatusOK {
+			for _, a := range alerts {
+				amAlerts = append(amAlerts, a.Name())
+			}
+		}
+		w.WriteHeader(amStatus)
+	}))
+	t.Cleanup(am.Close)
+
+	ctx := context.Background()
+	d := NewNotificationsDeadLetter(NotificationsDeadLetterConfig{Enabled: true, MaxEntriesPerTenant: 10}, objstore.NewInMemBucket(), nil, log.NewNopLogger(), nil)
+	require.NoError(t, d.Add(ctx, userID, deadLetterReasonQueueOverflow, nil, []*notifier.Alert{testAlert("a")}))
+	require.NoError(t, d.Add(ctx, userID, deadLetterReasonQueueOverflow, nil, []*notifier.Alert{testAlert("b")}))
+
+	cfg := Config{RulePath: t.TempDir(), NotificationQueueCapacity: 10, NotificationTimeout: 5 * time.Second, AlertmanagerURL: am.URL}
+	m, err := NewDefaultMultiTenantManager(cfg, managerMockFactory, nil, validation.MockDefaultOverrides(), d, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	serve := func(method, target string, withTenant bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if withTenant {
+			req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		}
+		resp := httptest.NewRecorder()
+		m.NotificationsDeadLetterHandler(resp, req)
+		return resp
+	}
+
+	// The tenant is required.
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/ruler/notifications_dead_letter", false).Code)
+
+	resp := serve(http.MethodGet, "/ruler/notifications_dead_letter", true)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	listed := listDeadLetterResponse{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	require.Len(t, listed.Entries, 2)
+	assert.Equal(t, "a", listed.Entries[0].Alerts[0].Name())
+
+	// Replaying an unknown entry should fail.
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/ruler/notifications_dead_letter?id=unknown", true).Code)
+
+	// Wait until the Alertmanager has been discovered.
+	n, err := m.getOrCreateRulerNotifier(userID)
+	require.NoError(t, err)
+	test.Poll(t, 10*time.Second, 1, func() interface{} { return len(n.notifier.Alertmanagers()) })
+
+	// The entries whose delivery fails should be kept.
+	resp = serve(http.MethodPost, "/ruler/notifications_dead_letter?id="+listed.Entries[0].ID, true)
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	entries, err := d.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	mtx.Lock()
+	amStatus = http.StatusOK
+	mtx.Unlock()
+
+	resp = serve(http.MethodPost, "/ruler/notifications_dead_letter?id="+listed.Entries[0].ID, true)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"replayed":1}`, resp.Body.String())
+
+	resp = serve(http.MethodPost, "/ruler/notifications_dead_letter", true)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"replayed":1}`, resp.Body.String())
+
+	entries, err = d.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// The replayed alerts should have been delivered to the Alertmanager, bypassing the notifier queue.
+	mtx.Lock()
+	defer mtx.Unlock()
+	require.NoError(t, amBodyErr)
+	assert.Equal(t, []string{"a", "b"}, amAlerts)
+	assert.Len(t, amReqs, 3)
+	assert.Equal(t, userID, amReqs[0].Header.Get(user.OrgIDHeaderName))
+	assert.Equal(t, 0, n.queueLength())
+}
+
+func testAlert(name string) *notifier.Alert {
+	return &notifier.Alert{Labels: labels.FromStrings(labels.AlertName, name)}
+}
+
+type mockAlertSender struct {
+	mtx        sync.Mutex
+	alerts     []*notifier.Alert
+	deliverErr error
+}
+
+func (s *mockAlertSender) deliver(_ context.Context, alerts []*notifier.Alert) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.deliverErr != nil {
+		return s.deliverErr
+	}
+	s.alerts = append(s.alerts, alerts...)
+	return nil
+}
+
+func (s *mockAlertSender) Send(alerts ...*notifier.Alert) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.alerts = append(s.alerts, alerts...)
+}
+
+func (s *mockAlertSender) alertNames() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var names []string
+	for _, a := range s.alerts {
+		names = append(names, a.Name())
+	}
+	return names
+}
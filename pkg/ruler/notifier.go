@@ -6,16 +6,25 @@
 package ruler
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	gklog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/crypto/tls"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
@@ -23,8 +32,11 @@ import (
 	"github.com/prometheus/prometheus/notifier"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
+var errNoAlertmanagerDiscovered = errors.New("no Alertmanager has been discovered")
+
 type NotifierConfig struct {
 	TLSEnabled bool             `yaml:"tls_enabled" category:"advanced"`
 	TLS        tls.ClientConfig `yaml:",inline"`
@@ -46,18 +58,46 @@ type rulerNotifier struct {
 	sdManager *discovery.Manager
 	wg        sync.WaitGroup
 	logger    gklog.Logger
+
+	queueLengthReg *queueLengthRegisterer
+
+	// The tenant's Alertmanager config the notifier has been configured with.
+	// Not enabled if the notifier uses the ruler-wide Alertmanager config.
+	tenantCfg validation.RulerAlertmanagerClientConfig
+
+	// The function used to deliver the alerts synchronously, bypassing the notifier queue.
+	// If nil, the requests are sent with the HTTP client as they are.
+	do doFunc
+
+	cfgMtx sync.Mutex
+	cfg    *config.Config
 }
 
 func newRulerNotifier(o *notifier.Options, l gklog.Logger) *rulerNotifier {
 	sdCtx, sdCancel := context.WithCancel(context.Background())
+
+	queueLengthReg := &queueLengthRegisterer{Registerer: o.Registerer}
+	if o.Registerer != nil {
+		opts := *o
+		opts.Registerer = queueLengthReg
+		o = &opts
+	}
+
 	return &rulerNotifier{
-		notifier:  notifier.NewManager(o, l),
-		sdCancel:  sdCancel,
-		sdManager: discovery.NewManager(sdCtx, l),
-		logger:    l,
+		notifier:       notifier.NewManager(o, l),
+		sdCancel:       sdCancel,
+		sdManager:      discovery.NewManager(sdCtx, l),
+		logger:         l,
+		queueLengthReg: queueLengthReg,
 	}
 }
 
+// queueLength returns the number of alerts in the notifier queue. It's always 0 if the notifier
+// has been created without a registerer.
+func (rn *rulerNotifier) queueLength() int {
+	return rn.queueLengthReg.queueLength()
+}
+
 // run starts the notifier. This function doesn't block and returns immediately.
 func (rn *rulerNotifier) run() {
 	rn.wg.Add(2)
@@ -78,6 +118,10 @@ func (rn *rulerNotifier) applyConfig(cfg *config.Config) error {
 		return err
 	}
 
+	rn.cfgMtx.Lock()
+	rn.cfg = cfg
+	rn.cfgMtx.Unlock()
+
 	sdCfgs := make(map[string]discovery.Configs)
 	for k, v := range cfg.AlertingConfig.AlertmanagerConfigs.ToMap() {
 		sdCfgs[k] = v.ServiceDiscoveryConfigs
@@ -91,6 +135,76 @@ func (rn *rulerNotifier) stop() {
 	rn.wg.Wait()
 }
 
+// deliver synchronously sends the alerts to each discovered Alertmanager, bypassing the notifier queue.
+// Like the notifier, the delivery succeeds if the alerts have been delivered to at least one Alertmanager.
+func (rn *rulerNotifier) deliver(ctx context.Context, alerts []*notifier.Alert) error {
+	rn.cfgMtx.Lock()
+	cfg := rn.cfg
+	rn.cfgMtx.Unlock()
+
+	amURLs := rn.notifier.Alertmanagers()
+	if cfg == nil || len(cfg.AlertingConfig.AlertmanagerConfigs) == 0 || len(amURLs) == 0 {
+		return errNoAlertmanagerDiscovered
+	}
+
+	// The Alertmanager configs built by the ruler all share the same HTTP client config and timeout.
+	amCfg := cfg.AlertingConfig.AlertmanagerConfigs[0]
+	client, err := config_util.NewClientFromConfig(amCfg.HTTPClientConfig, "alertmanager")
+	if err != nil {
+		return err
+	}
+
+	// The payloads of both the Alertmanager API v1 and v2 are compatible with notifier.Alert.
+	payload, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	do := rn.do
+	if do == nil {
+		do = func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+			return client.Do(req.WithContext(ctx))
+		}
+	}
+
+	if amCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(amCfg.Timeout))
+		defer cancel()
+	}
+
+	var (
+		delivered bool
+		lastErr   error
+	)
+	for _, amURL := range amURLs {
+		req, err := http.NewRequest(http.MethodPost, amURL.String(), bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := do(ctx, client, req)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "Alertmanager %s", amURL)
+			continue
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode/100 != 2 {
+			lastErr = fmt.Errorf("Alertmanager %s: bad response status %s", amURL, resp.Status)
+			continue
+		}
+		delivered = true
+	}
+
+	if !delivered {
+		return lastErr
+	}
+	return nil
+}
+
 // Builds a Prometheus config.Config from a ruler.Config with just the required
 // options to configure notifications to Alertmanager.
 func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*config.Config, error) {
@@ -99,7 +213,17 @@ func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*
 		return &config.Config{}, nil
 	}
 
-	amURLs := strings.Split(rulerConfig.AlertmanagerURL, ",")
+	return buildNotifierConfigWithClient(rulerConfig, resolver, rulerConfig.AlertmanagerURL, notifierHTTPClientConfig(rulerConfig.Notifier))
+}
+
+// buildTenantNotifierConfig builds a Prometheus config.Config sending the notifications to the tenant's Alertmanager,
+// which must be enabled. The timeouts and service discovery options are the ones of the ruler config.
+func buildTenantNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider, tenantCfg validation.RulerAlertmanagerClientConfig) (*config.Config, error) {
+	return buildNotifierConfigWithClient(rulerConfig, resolver, tenantCfg.AlertmanagerURL, tenantHTTPClientConfig(tenantCfg))
+}
+
+func buildNotifierConfigWithClient(rulerConfig *Config, resolver cache.AddressProvider, alertmanagerURL string, clientConfig config_util.HTTPClientConfig) (*config.Config, error) {
+	amURLs := strings.Split(alertmanagerURL, ",")
 	amConfigs := make([]*config.AlertmanagerConfig, 0, len(amURLs))
 
 	for _, rawURL := range amURLs {
@@ -115,7 +239,7 @@ func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*
 			sdConfig = staticTarget(url)
 		}
 
-		amConfigs = append(amConfigs, amConfigWithSD(rulerConfig, url, sdConfig))
+		amConfigs = append(amConfigs, amConfigWithSD(rulerConfig, url, sdConfig, clientConfig))
 	}
 
 	promConfig := &config.Config{
@@ -127,18 +251,18 @@ func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*
 	return promConfig, nil
 }
 
-func amConfigWithSD(rulerConfig *Config, url *url.URL, sdConfig discovery.Config) *config.AlertmanagerConfig {
+func amConfigWithSD(rulerConfig *Config, url *url.URL, sdConfig discovery.Config, clientConfig config_util.HTTPClientConfig) *config.AlertmanagerConfig {
 	amConfig := &config.AlertmanagerConfig{
 		APIVersion:              config.AlertmanagerAPIVersionV2,
 		Scheme:                  url.Scheme,
 		PathPrefix:              url.Path,
 		Timeout:                 model.Duration(rulerConfig.NotificationTimeout),
 		ServiceDiscoveryConfigs: discovery.Configs{sdConfig},
-		HTTPClientConfig:        config_util.HTTPClientConfig{},
+		HTTPClientConfig:        clientConfig,
 	}
 
-	// Check the URL for basic authentication information first
-	if url.User != nil {
+	// Use the URL basic authentication information, unless the client config has its own.
+	if url.User != nil && clientConfig.BasicAuth == nil {
 		amConfig.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
 			Username: url.User.Username(),
 		}
@@ -148,24 +272,98 @@ func amConfigWithSD(rulerConfig *Config, url *url.URL, sdConfig discovery.Config
 		}
 	}
 
+	return amConfig
+}
+
+func notifierHTTPClientConfig(notifierConfig NotifierConfig) config_util.HTTPClientConfig {
+	clientConfig := config_util.HTTPClientConfig{}
+
 	// Override URL basic authentication configs with hard coded config values if present
-	if rulerConfig.Notifier.BasicAuth.IsEnabled() {
-		amConfig.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
-			Username: rulerConfig.Notifier.BasicAuth.Username,
-			Password: config_util.Secret(rulerConfig.Notifier.BasicAuth.Password.String()),
+	if notifierConfig.BasicAuth.IsEnabled() {
+		clientConfig.BasicAuth = &config_util.BasicAuth{
+			Username: notifierConfig.BasicAuth.Username,
+			Password: config_util.Secret(notifierConfig.BasicAuth.Password.String()),
 		}
 	}
 
 	// Whether to use TLS or not.
-	if rulerConfig.Notifier.TLSEnabled {
-		amConfig.HTTPClientConfig.TLSConfig = config_util.TLSConfig{
-			CAFile:             rulerConfig.Notifier.TLS.CAPath,
-			CertFile:           rulerConfig.Notifier.TLS.CertPath,
-			KeyFile:            rulerConfig.Notifier.TLS.KeyPath,
-			InsecureSkipVerify: rulerConfig.Notifier.TLS.InsecureSkipVerify,
-			ServerName:         rulerConfig.Notifier.TLS.ServerName,
+	if notifierConfig.TLSEnabled {
+		clientConfig.TLSConfig = config_util.TLSConfig{
+			CAFile:             notifierConfig.TLS.CAPath,
+			CertFile:           notifierConfig.TLS.CertPath,
+			KeyFile:            notifierConfig.TLS.KeyPath,
+			InsecureSkipVerify: notifierConfig.TLS.InsecureSkipVerify,
+			ServerName:         notifierConfig.TLS.ServerName,
 		}
 	}
 
-	return amConfig
+	return clientConfig
+}
+
+func tenantHTTPClientConfig(tenantCfg validation.RulerAlertmanagerClientConfig) config_util.HTTPClientConfig {
+	clientConfig := config_util.HTTPClientConfig{
+		TLSConfig: config_util.TLSConfig{
+			CAFile:             tenantCfg.TLSCAPath,
+			CertFile:           tenantCfg.TLSCertPath,
+			KeyFile:            tenantCfg.TLSKeyPath,
+			InsecureSkipVerify: tenantCfg.TLSInsecureSkipVerify,
+			ServerName:         tenantCfg.TLSServerName,
+		},
+	}
+
+	if tenantCfg.OAuth2Enabled() {
+		clientConfig.OAuth2 = &config_util.OAuth2{
+			ClientID:     tenantCfg.OAuth2ClientID,
+			ClientSecret: tenantCfg.OAuth2ClientSecret,
+			TokenURL:     tenantCfg.OAuth2TokenURL,
+			Scopes:       tenantCfg.OAuth2Scopes,
+		}
+	}
+
+	return clientConfig
+}
+
+// queueLengthRegisterer is a prometheus.Registerer keeping a reference to the queue length gauge
+// registered by the notifier, which doesn't otherwise expose the length of its queue.
+type queueLengthRegisterer struct {
+	prometheus.Registerer
+
+	mtx   sync.Mutex
+	gauge prometheus.GaugeFunc
+}
+
+func (r *queueLengthRegisterer) Register(c prometheus.Collector) error {
+	r.capture(c)
+	return r.Registerer.Register(c)
+}
+
+func (r *queueLengthRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		r.capture(c)
+	}
+	r.Registerer.MustRegister(cs...)
+}
+
+func (r *queueLengthRegisterer) capture(c prometheus.Collector) {
+	if g, ok := c.(prometheus.GaugeFunc); ok && strings.Contains(g.Desc().String(), `"prometheus_notifications_queue_length"`) {
+		r.mtx.Lock()
+		r.gauge = g
+		r.mtx.Unlock()
+	}
+}
+
+func (r *queueLengthRegisterer) queueLength() int {
+	r.mtx.Lock()
+	g := r.gauge
+	r.mtx.Unlock()
+
+	if g == nil {
+		return 0
+	}
+
+	m := &dto.Metric{}
+	if err := g.Write(m); err != nil {
+		return 0
+	}
+	return int(m.GetGauge().GetValue())
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 func TestBuildNotifierConfig(t *testing.T) {
@@ -299,3 +300,58 @@ func TestBuildNotifierConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildTenantNotifierConfig(t *testing.T) {
+	rulerConfig := &Config{
+		// The ruler-wide client config must not be used for the tenant's Alertmanager.
+		Notifier:            NotifierConfig{BasicAuth: util.BasicAuth{Username: "ruler", Password: flagext.SecretWithValue("ruler")}},
+		NotificationTimeout: time.Minute,
+	}
+	tenantCfg := validation.RulerAlertmanagerClientConfig{
+		AlertmanagerURL:    "https://alertmanager.example.com/alertmanager",
+		TLSCertPath:        "cert.pem",
+		TLSKeyPath:         "key.pem",
+		TLSCAPath:          "ca.pem",
+		TLSServerName:      "alertmanager",
+		OAuth2ClientID:     "client",
+		OAuth2ClientSecret: "secret",
+		OAuth2TokenURL:     "https://auth.example.com/token",
+		OAuth2Scopes:       []string{"alerts"},
+	}
+
+	ncfg, err := buildTenantNotifierConfig(rulerConfig, nil, tenantCfg)
+	require.NoError(t, err)
+	require.Equal(t, &config.Config{
+		AlertingConfig: config.AlertingConfig{
+			AlertmanagerConfigs: []*config.AlertmanagerConfig{
+				{
+					APIVersion: "v2",
+					Scheme:     "https",
+					PathPrefix: "/alertmanager",
+					Timeout:    model.Duration(time.Minute),
+					ServiceDiscoveryConfigs: discovery.Configs{
+						discovery.StaticConfig{
+							{
+								Targets: []model.LabelSet{{"__address__": "alertmanager.example.com"}},
+							},
+						},
+					},
+					HTTPClientConfig: config_util.HTTPClientConfig{
+						OAuth2: &config_util.OAuth2{
+							ClientID:     "client",
+							ClientSecret: "secret",
+							TokenURL:     "https://auth.example.com/token",
+							Scopes:       []string{"alerts"},
+						},
+						TLSConfig: config_util.TLSConfig{
+							CAFile:     "ca.pem",
+							CertFile:   "cert.pem",
+							KeyFile:    "key.pem",
+							ServerName: "alertmanager",
+						},
+					},
+				},
+			},
+		},
+	}, ncfg)
+}
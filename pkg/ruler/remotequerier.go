@@ -30,6 +30,7 @@ import (
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 
+	"github.com/grafana/mimir/pkg/util/querysource"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/version"
 )
@@ -325,6 +326,16 @@ func WithOrgIDMiddleware(ctx context.Context, req *httpgrpc.HTTPRequest) error {
 	return nil
 }
 
+// WithQuerySourceMiddleware attaches the query source header to the outgoing request by inspecting the passed context,
+// so that the query-frontend can tell rule evaluations apart from user queries.
+func WithQuerySourceMiddleware(ctx context.Context, req *httpgrpc.HTTPRequest) error {
+	req.Headers = append(req.Headers, &httpgrpc.Header{
+		Key:    querysource.HTTPHeader,
+		Values: []string{querysource.FromContext(ctx)},
+	})
+	return nil
+}
+
 func getHeader(headers []*httpgrpc.Header, name string) string {
 	for _, h := range headers {
 		if h.Key == name && len(h.Values) > 0 {
@@ -25,6 +25,7 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/querysource"
 )
 
 type mockHTTPGRPCClient func(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error)
@@ -619,3 +620,27 @@ func TestRemoteQuerier_StatusErrorResponses(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, codes.Code(http.StatusUnprocessableEntity), st.Code())
 }
+
+func TestRemoteQuerier_QuerySourceMiddleware(t *testing.T) {
+	var inReq *httpgrpc.HTTPRequest
+	mockClientFn := func(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error) {
+		inReq = req
+		return &httpgrpc.HTTPResponse{
+			Code: http.StatusOK,
+			Headers: []*httpgrpc.Header{
+				{Key: "Content-Type", Values: []string{"application/json"}},
+			},
+			Body: []byte(`{
+				"status": "success","data": {"resultType":"vector","result":[]}
+			}`),
+		}, nil
+	}
+	q := NewRemoteQuerier(mockHTTPGRPCClient(mockClientFn), time.Minute, formatJSON, "/prometheus", log.NewNopLogger(), WithQuerySourceMiddleware)
+
+	ctx := querysource.ContextWithSource(context.Background(), querysource.Alerting)
+	_, err := q.Query(ctx, "qs", time.Now())
+	require.NoError(t, err)
+
+	require.NotNil(t, inReq)
+	require.Equal(t, querysource.Alerting, getHeader(inReq.Headers, querysource.HTTPHeader))
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql/parser"
+	promRules "github.com/prometheus/prometheus/rules"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+const (
+	enableConditionResultEnabled  = "enabled"
+	enableConditionResultDisabled = "disabled"
+	enableConditionResultFailed   = "failed"
+)
+
+// validateEnableCondition validates the enable condition of a rule group, as it will be evaluated once the
+// template variables are substituted into it.
+func validateEnableCondition(group, condition string, vars map[string]string) error {
+	if condition == "" {
+		return nil
+	}
+
+	unresolved := map[string]struct{}{}
+	collectUnresolvedTemplateVariables(condition, vars, unresolved)
+	if len(unresolved) > 0 {
+		return unresolvedTemplateVariablesError(group, unresolved)
+	}
+
+	if _, err := parser.ParseExpr(expandTemplateVariables(condition, vars)); err != nil {
+		return errors.Wrapf(err, "invalid enable condition of rule group '%s'", group)
+	}
+	return nil
+}
+
+// groupEnableConditions evaluates the enable conditions of the rule groups before each of their evaluations.
+// The rule groups whose enable condition returns an empty result are not evaluated, so that the rule groups
+// distributed to many tenants don't run for the tenants lacking the relevant workloads.
+type groupEnableConditions struct {
+	queryFunc promRules.QueryFunc
+	logger    log.Logger
+
+	mtx sync.RWMutex
+	// tenants holds the enable condition of each tenant's rule groups, by namespace and then by rule group name.
+	// The rule groups without an enable condition are not tracked.
+	tenants map[string]map[string]map[string]string
+
+	evaluations *prometheus.CounterVec
+}
+
+func newGroupEnableConditions(queryFunc promRules.QueryFunc, reg prometheus.Registerer, logger log.Logger) *groupEnableConditions {
+	return &groupEnableConditions{
+		queryFunc: queryFunc,
+		logger:    logger,
+		tenants:   map[string]map[string]map[string]string{},
+		evaluations: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_rule_group_enable_condition_evaluations_total",
+			Help: "Total number of evaluations of the rule groups enable conditions, by result. The rule groups are not evaluated when the result is disabled.",
+		}, []string{"user", "result"}),
+	}
+}
+
+// setGroups replaces the tenant's enable conditions with the ones of the input rule groups.
+func (c *groupEnableConditions) setGroups(userID string, groups rulespb.RuleGroupList) {
+	namespaces := map[string]map[string]string{}
+	for _, g := range groups {
+		if g.GetEnableCondition() == "" {
+			continue
+		}
+		if namespaces[g.Namespace] == nil {
+			namespaces[g.Namespace] = map[string]string{}
+		}
+		namespaces[g.Namespace][g.Name] = g.EnableCondition
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if len(namespaces) == 0 {
+		delete(c.tenants, userID)
+		return
+	}
+	c.tenants[userID] = namespaces
+}
+
+func (c *groupEnableConditions) condition(userID string, g *promRules.Group) string {
+	// The rule files are named after the url path escaped namespace, see mapper.MapRules().
+	namespace, err := url.PathUnescape(filepath.Base(g.File()))
+	if err != nil {
+		return ""
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.tenants[userID][namespace][g.Name()]
+}
+
+// wrapEvalIterationFunc returns a rules.GroupEvalIterationFunc which evaluates the rule group with next only if
+// the rule group has no enable condition, or its enable condition returns a non-empty result.
+func (c *groupEnableConditions) wrapEvalIterationFunc(userID string, next promRules.GroupEvalIterationFunc) promRules.GroupEvalIterationFunc {
+	return func(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
+		condition := c.condition(userID, g)
+		if condition == "" {
+			next(ctx, g, evalTimestamp)
+			return
+		}
+
+		enabled, err := c.evaluate(ctx, condition, evalTimestamp.Add(-g.EvaluationDelay()))
+		switch {
+		case err != nil:
+			// The rule group is evaluated anyway, to not miss any alert because of a failing enable condition.
+			level.Warn(c.logger).Log("msg", "failed to evaluate the rule group enable condition, evaluating the rule group anyway", "user", userID, "file", g.File(), "group", g.Name(), "err", err)
+			c.evaluations.WithLabelValues(userID, enableConditionResultFailed).Inc()
+		case !enabled:
+			level.Debug(c.logger).Log("msg", "skipping the rule group evaluation because its enable condition returned an empty result", "user", userID, "file", g.File(), "group", g.Name())
+			c.evaluations.WithLabelValues(userID, enableConditionResultDisabled).Inc()
+			return
+		default:
+			c.evaluations.WithLabelValues(userID, enableConditionResultEnabled).Inc()
+		}
+
+		next(ctx, g, evalTimestamp)
+	}
+}
+
+// evaluate returns whether the enable condition returns a non-empty result at the input timestamp.
+func (c *groupEnableConditions) evaluate(ctx context.Context, condition string, ts time.Time) (bool, error) {
+	vector, err := c.queryFunc(ctx, condition, ts)
+	if err != nil {
+		return false, err
+	}
+	return len(vector) > 0, nil
+}
+
+func (c *groupEnableConditions) removeUser(userID string) {
+	c.mtx.Lock()
+	delete(c.tenants, userID)
+	c.mtx.Unlock()
+
+	c.evaluations.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
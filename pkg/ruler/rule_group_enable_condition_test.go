@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+func TestValidateEnableCondition(t *testing.T) {
+	vars := map[string]string{"job": "mysql"}
+
+	assert.NoError(t, validateEnableCondition("group", "", vars))
+	assert.NoError(t, validateEnableCondition("group", `up{job="$job"}`, vars))
+	assert.EqualError(t, validateEnableCondition("group", `up{job="$jobs"}`, vars), "rule group 'group' references undefined template variables: $jobs")
+	assert.ErrorContains(t, validateEnableCondition("group", `up{job="mysql"`, vars), "invalid enable condition of rule group 'group'")
+}
+
+func TestGroupEnableConditions_WrapEvalIterationFunc(t *testing.T) {
+	const userID = "user-1"
+
+	var queries []string
+	queryFunc := func(_ context.Context, q string, ts time.Time) (promql.Vector, error) {
+		queries = append(queries, q)
+		switch {
+		case strings.Contains(q, "failing"):
+			return nil, errors.New("query failed")
+		case strings.Contains(q, "mysql"):
+			return promql.Vector{{Metric: labels.FromStrings("job", "mysql"), T: ts.UnixMilli(), F: 1}}, nil
+		default:
+			return nil, nil
+		}
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	c := newGroupEnableConditions(queryFunc, reg, log.NewNopLogger())
+	c.setGroups(userID, rulespb.RuleGroupList{
+		{Namespace: "ns/1", Name: "mysql", EnableCondition: `up{job="mysql"}`},
+		{Namespace: "ns/1", Name: "redis", EnableCondition: `up{job="redis"}`},
+		{Namespace: "ns/1", Name: "failing", EnableCondition: `up{job="failing"}`},
+		{Namespace: "ns/1", Name: "unconditional"},
+	})
+
+	group := func(name string) *promRules.Group {
+		// The rule files are named like in the mapper.
+		file := filepath.Join("/rules", userID, url.PathEscape("ns/1"))
+		return promRules.NewGroup(promRules.GroupOptions{File: file, Name: name, Opts: &promRules.ManagerOptions{}})
+	}
+
+	var evaluated []string
+	evalIterationFunc := c.wrapEvalIterationFunc(userID, func(_ context.Context, g *promRules.Group, _ time.Time) {
+		evaluated = append(evaluated, g.Name())
+	})
+
+	for _, name := range []string{"mysql", "redis", "failing", "unconditional"} {
+		evalIterationFunc(context.Background(), group(name), time.Now())
+	}
+
+	// The rule groups whose condition can't be evaluated are evaluated anyway.
+	assert.Equal(t, []string{"mysql", "failing", "unconditional"}, evaluated)
+	assert.Equal(t, []string{`up{job="mysql"}`, `up{job="redis"}`, `up{job="failing"}`}, queries)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ruler_rule_group_enable_condition_evaluations_total Total number of evaluations of the rule groups enable conditions, by result. The rule groups are not evaluated when the result is disabled.
+		# TYPE cortex_ruler_rule_group_enable_condition_evaluations_total counter
+		cortex_ruler_rule_group_enable_condition_evaluations_total{result="disabled",user="user-1"} 1
+		cortex_ruler_rule_group_enable_condition_evaluations_total{result="enabled",user="user-1"} 1
+		cortex_ruler_rule_group_enable_condition_evaluations_total{result="failed",user="user-1"} 1
+	`), "cortex_ruler_rule_group_enable_condition_evaluations_total"))
+
+	// The conditions removed from the rule groups are not evaluated anymore.
+	c.setGroups(userID, rulespb.RuleGroupList{{Namespace: "ns/1", Name: "redis"}})
+	evaluated = nil
+	evalIterationFunc(context.Background(), group("redis"), time.Now())
+	assert.Equal(t, []string{"redis"}, evaluated)
+
+	c.removeUser(userID)
+	assert.Empty(t, c.tenants)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "cortex_ruler_rule_group_enable_condition_evaluations_total"))
+}
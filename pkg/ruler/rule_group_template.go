@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/rulefmt"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+// templateVariableRegexp matches the references to the rule group template variables, like $cluster_list.
+var templateVariableRegexp = regexp.MustCompile(`\$([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// expandTemplateVariables replaces the references to the variables defined in vars with their values.
+// The references to undefined variables, like the $labels and $value of the alerting rules templates, are left untouched.
+func expandTemplateVariables(text string, vars map[string]string) string {
+	if !strings.Contains(text, "$") {
+		return text
+	}
+
+	return templateVariableRegexp.ReplaceAllStringFunc(text, func(ref string) string {
+		if value, ok := vars[ref[1:]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// collectUnresolvedTemplateVariables adds to unresolved the names of the variables referenced by the rule
+// expression which are not defined in vars. PromQL expressions never contain a $, so any reference left
+// in them would make the expression invalid.
+func collectUnresolvedTemplateVariables(expr string, vars map[string]string, unresolved map[string]struct{}) {
+	if !strings.Contains(expr, "$") {
+		return
+	}
+
+	for _, match := range templateVariableRegexp.FindAllStringSubmatch(expr, -1) {
+		if _, ok := vars[match[1]]; !ok {
+			unresolved[match[1]] = struct{}{}
+		}
+	}
+}
+
+func unresolvedTemplateVariablesError(group string, unresolved map[string]struct{}) error {
+	names := make([]string, 0, len(unresolved))
+	for name := range unresolved {
+		names = append(names, "$"+name)
+	}
+	sort.Strings(names)
+
+	return fmt.Errorf("rule group '%s' references undefined template variables: %s", group, strings.Join(names, ", "))
+}
+
+// expandRuleGroupTemplate returns a copy of the rule group with the template variables substituted into
+// the rules expressions, labels and annotations. It fails if a rule expression references an undefined variable.
+func expandRuleGroupTemplate(rg rulefmt.RuleGroup, vars map[string]string) (rulefmt.RuleGroup, error) {
+	unresolved := map[string]struct{}{}
+	rules := make([]rulefmt.RuleNode, 0, len(rg.Rules))
+
+	for _, r := range rg.Rules {
+		collectUnresolvedTemplateVariables(r.Expr.Value, vars, unresolved)
+		r.Expr.Value = expandTemplateVariables(r.Expr.Value, vars)
+		r.Labels = expandTemplateVariablesInMap(r.Labels, vars)
+		r.Annotations = expandTemplateVariablesInMap(r.Annotations, vars)
+		rules = append(rules, r)
+	}
+
+	if len(unresolved) > 0 {
+		return rg, unresolvedTemplateVariablesError(rg.Name, unresolved)
+	}
+
+	rg.Rules = rules
+	return rg, nil
+}
+
+func expandTemplateVariablesInMap(m map[string]string, vars map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	expanded := make(map[string]string, len(m))
+	for k, v := range m {
+		expanded[k] = expandTemplateVariables(v, vars)
+	}
+	return expanded
+}
+
+// expandRuleGroupDescTemplate is like expandRuleGroupTemplate, but for the rule groups loaded from the store.
+// The input rule group is not modified.
+func expandRuleGroupDescTemplate(rg *rulespb.RuleGroupDesc, vars map[string]string) (*rulespb.RuleGroupDesc, error) {
+	unresolved := map[string]struct{}{}
+	rules := make([]*rulespb.RuleDesc, 0, len(rg.Rules))
+
+	for _, r := range rg.Rules {
+		collectUnresolvedTemplateVariables(r.Expr, vars, unresolved)
+
+		expanded := *r
+		expanded.Expr = expandTemplateVariables(r.Expr, vars)
+		expanded.Labels = expandTemplateVariablesInLabels(r.Labels, vars)
+		expanded.Annotations = expandTemplateVariablesInLabels(r.Annotations, vars)
+		rules = append(rules, &expanded)
+	}
+	collectUnresolvedTemplateVariables(rg.EnableCondition, vars, unresolved)
+
+	if len(unresolved) > 0 {
+		return nil, unresolvedTemplateVariablesError(rg.Name, unresolved)
+	}
+
+	expanded := *rg
+	expanded.Rules = rules
+	expanded.EnableCondition = expandTemplateVariables(rg.EnableCondition, vars)
+	return &expanded, nil
+}
+
+func expandTemplateVariablesInLabels(labels []mimirpb.LabelAdapter, vars map[string]string) []mimirpb.LabelAdapter {
+	if labels == nil {
+		return nil
+	}
+
+	expanded := make([]mimirpb.LabelAdapter, 0, len(labels))
+	for _, l := range labels {
+		expanded = append(expanded, mimirpb.LabelAdapter{Name: l.Name, Value: expandTemplateVariables(l.Value, vars)})
+	}
+	return expanded
+}
+
+// expandRuleGroupsTemplates substitutes the template variables configured for each tenant into its rule groups.
+// The rule groups referencing undefined variables, for example because the variables have been removed from the
+// tenant's runtime config after the rule group has been stored, are filtered out.
+//
+// This function doesn't modify the input configs in place, for the same reasons as filterRuleGroupsByEnabled.
+func expandRuleGroupsTemplates(configs map[string]rulespb.RuleGroupList, limits RulesLimits, logger log.Logger) map[string]rulespb.RuleGroupList {
+	// Quick case: nothing to do if no user has template variables.
+	shouldExpand := false
+	for userID := range configs {
+		if len(limits.RulerRuleGroupTemplateVariables(userID)) > 0 {
+			shouldExpand = true
+			break
+		}
+	}
+
+	if !shouldExpand {
+		return configs
+	}
+
+	expanded := make(map[string]rulespb.RuleGroupList, len(configs))
+
+	for userID, groups := range configs {
+		vars := limits.RulerRuleGroupTemplateVariables(userID)
+		if len(vars) == 0 {
+			expanded[userID] = groups
+			continue
+		}
+
+		expanded[userID] = make(rulespb.RuleGroupList, 0, len(groups))
+		for _, group := range groups {
+			expandedGroup, err := expandRuleGroupDescTemplate(group, vars)
+			if err != nil {
+				level.Warn(logger).Log("msg", "filtered out rule group because of unresolved template variables", "user", userID, "namespace", group.Namespace, "err", err)
+				continue
+			}
+
+			expanded[userID] = append(expanded[userID], expandedGroup)
+		}
+	}
+
+	return expanded
+}
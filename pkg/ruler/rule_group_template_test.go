@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestExpandTemplateVariables(t *testing.T) {
+	vars := map[string]string{"cluster_list": "prod-1|prod-2", "threshold": "0.5"}
+
+	assert.Equal(t, `up{cluster=~"prod-1|prod-2"} < 0.5`, expandTemplateVariables(`up{cluster=~"$cluster_list"} < $threshold`, vars))
+	assert.Equal(t, "no variables", expandTemplateVariables("no variables", vars))
+	// The variables of the alerting rules templates are left untouched.
+	assert.Equal(t, "{{ $labels.instance }} is above 0.5: {{ $value }}", expandTemplateVariables("{{ $labels.instance }} is above $threshold: {{ $value }}", vars))
+	// Variables are matched by their full name.
+	assert.Equal(t, "$thresholds", expandTemplateVariables("$thresholds", vars))
+}
+
+func TestExpandRuleGroupTemplate(t *testing.T) {
+	vars := map[string]string{"cluster_list": "prod-1|prod-2", "threshold": "0.5", "team": "platform"}
+
+	rg := rulefmt.RuleGroup{
+		Name: "group",
+		Rules: []rulefmt.RuleNode{{
+			Alert:       yaml.Node{Kind: yaml.ScalarNode, Value: "HighErrorRate"},
+			Expr:        yaml.Node{Kind: yaml.ScalarNode, Value: `rate(errors{cluster=~"$cluster_list"}[5m]) > $threshold`},
+			Labels:      map[string]string{"team": "$team"},
+			Annotations: map[string]string{"summary": "{{ $labels.cluster }} error rate above $threshold"},
+		}},
+	}
+
+	expanded, err := expandRuleGroupTemplate(rg, vars)
+	require.NoError(t, err)
+	assert.Equal(t, `rate(errors{cluster=~"prod-1|prod-2"}[5m]) > 0.5`, expanded.Rules[0].Expr.Value)
+	assert.Equal(t, map[string]string{"team": "platform"}, expanded.Rules[0].Labels)
+	assert.Equal(t, map[string]string{"summary": "{{ $labels.cluster }} error rate above 0.5"}, expanded.Rules[0].Annotations)
+
+	// The input rule group is not modified.
+	assert.Equal(t, `rate(errors{cluster=~"$cluster_list"}[5m]) > $threshold`, rg.Rules[0].Expr.Value)
+	assert.Equal(t, map[string]string{"team": "$team"}, rg.Rules[0].Labels)
+
+	// The expressions referencing undefined variables are rejected.
+	rg.Rules[0].Expr.Value = "rate(errors[$window]) > $max or $threshold"
+	_, err = expandRuleGroupTemplate(rg, vars)
+	assert.EqualError(t, err, "rule group 'group' references undefined template variables: $max, $window")
+}
+
+func TestExpandRuleGroupsTemplates(t *testing.T) {
+	limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits["user-1"] = validation.MockDefaultLimits()
+		tenantLimits["user-1"].RulerRuleGroupTemplateVariables = map[string]string{"threshold": "10"}
+	})
+
+	templated := createRuleGroup("group-1", "user-1", createRecordingRule("record:1", "up > $threshold"))
+	templated.Rules[0].Labels = []mimirpb.LabelAdapter{{Name: "threshold", Value: "$threshold"}}
+	templated.EnableCondition = "count(up) > $threshold"
+	unresolved := createRuleGroup("group-2", "user-1", createRecordingRule("record:2", "up > $unknown"))
+	plain := createRuleGroup("group-1", "user-2", createRecordingRule("record:1", "up"))
+
+	configs := map[string]rulespb.RuleGroupList{
+		"user-1": {templated, unresolved},
+		"user-2": {plain},
+	}
+
+	expectedGroup := createRuleGroup("group-1", "user-1", createRecordingRule("record:1", "up > 10"))
+	expectedGroup.Rules[0].Labels = []mimirpb.LabelAdapter{{Name: "threshold", Value: "10"}}
+	expectedGroup.EnableCondition = "count(up) > 10"
+
+	expanded := expandRuleGroupsTemplates(configs, limits, log.NewNopLogger())
+	assert.Equal(t, map[string]rulespb.RuleGroupList{
+		"user-1": {expectedGroup},
+		"user-2": {plain},
+	}, expanded)
+
+	// The input rule groups are not modified.
+	assert.Equal(t, "up > $threshold", templated.Rules[0].Expr)
+	assert.Equal(t, "$threshold", templated.Rules[0].Labels[0].Value)
+	assert.Equal(t, "count(up) > $threshold", templated.EnableCondition)
+
+	// The configs are returned as they are when no tenant has template variables.
+	assert.Equal(t, configs, expandRuleGroupsTemplates(configs, validation.MockDefaultOverrides(), log.NewNopLogger()))
+}
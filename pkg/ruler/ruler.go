@@ -108,6 +108,10 @@ type Config struct {
 	NotificationTimeout time.Duration `yaml:"notification_timeout" category:"advanced"`
 	// Client configs for interacting with the Alertmanager
 	Notifier NotifierConfig `yaml:"alertmanager_client"`
+	// Storage of the notifications which have not been delivered to the Alertmanager.
+	NotificationsDeadLetter NotificationsDeadLetterConfig `yaml:"notifications_dead_letter"`
+	// Recent state transitions of the alerts, exposed through the API.
+	AlertStateHistory AlertStateHistoryConfig `yaml:"alert_state_history"`
 
 	// Max time to tolerate outage for restoring "for" state of alert.
 	OutageTolerance time.Duration `yaml:"for_outage_tolerance" category:"advanced"`
@@ -149,6 +153,14 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errors.Wrap(err, "invalid ruler query-frontend config")
 	}
 
+	if err := cfg.NotificationsDeadLetter.Validate(); err != nil {
+		return errors.Wrap(err, "invalid ruler notifications dead-letter config")
+	}
+
+	if err := cfg.AlertStateHistory.Validate(); err != nil {
+		return errors.Wrap(err, "invalid ruler alert state history config")
+	}
+
 	return nil
 }
 
@@ -157,6 +169,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.ClientTLSConfig.RegisterFlagsWithPrefix("ruler.client", f)
 	cfg.Ring.RegisterFlags(f, logger)
 	cfg.Notifier.RegisterFlags(f)
+	cfg.NotificationsDeadLetter.RegisterFlags(f)
+	cfg.AlertStateHistory.RegisterFlags(f)
 	cfg.TenantFederation.RegisterFlags(f)
 	cfg.QueryFrontend.RegisterFlags(f)
 
@@ -252,6 +266,10 @@ type MultiTenantManager interface {
 	// GetRules fetches rules for a particular tenant (userID).
 	GetRules(userID string) []*promRules.Group
 
+	// GetAlertStateHistory returns the recent state transitions of the alerts of the rule of the tenant's group,
+	// oldest first. It returns nil if the alert state history is disabled.
+	GetAlertStateHistory(userID string, group *promRules.Group, ruleName string) []*AlertStateTransitionDesc
+
 	// Stop stops all Manager components.
 	Stop()
 
@@ -563,6 +581,9 @@ func (r *Ruler) syncRules(ctx context.Context, userIDs []string, reason rulesSyn
 	// Filter out all rules for which their evaluation has been disabled for the given tenant.
 	configs = filterRuleGroupsByEnabled(configs, r.limits, r.logger)
 
+	// Substitute the tenants' template variables into their rule groups.
+	configs = expandRuleGroupsTemplates(configs, r.limits, r.logger)
+
 	// Sync the rule groups.
 	if len(userIDs) > 0 {
 		// Ensure the configs map is not nil.
@@ -606,9 +627,34 @@ func (r *Ruler) loadRuleGroupsToSync(ctx context.Context, configs map[string]rul
 	// we filter out any missing rule group, not considering it as an hard error.
 	configs = filterRuleGroupsByNotMissing(configs, missing, r.logger)
 
+	if err := r.applyNamespaceDefaults(ctx, configs); err != nil {
+		return configs, err
+	}
+
 	return configs, nil
 }
 
+// applyNamespaceDefaults merges the namespace defaults configured by each user into its rule groups.
+func (r *Ruler) applyNamespaceDefaults(ctx context.Context, configs map[string]rulespb.RuleGroupList) error {
+	userIDs := make([]string, 0, len(configs))
+	for userID := range configs {
+		userIDs = append(userIDs, userID)
+	}
+
+	return concurrency.ForEachUser(ctx, userIDs, loadRulesConcurrency, func(ctx context.Context, userID string) error {
+		defaults, err := r.directStore.LoadNamespaceDefaults(ctx, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load namespace defaults for user %s", userID)
+		}
+
+		// Each user's rule groups are only accessed by one goroutine, so they can be safely modified.
+		for _, rg := range configs[userID] {
+			rulespb.ApplyNamespaceDefaults(rg, defaults[rg.Namespace])
+		}
+		return nil
+	})
+}
+
 // listRuleGroupsToSyncForAllUsers lists all the rule groups that should be synched by this ruler instance.
 // This function should be used only when syncing the rule groups, because it expects the
 // storage view to be eventually consistent (due to optional caching).
@@ -821,13 +867,14 @@ func filterRuleGroupByEnabled(group *rulespb.RuleGroupDesc, recordingEnabled, al
 
 	// Create a copy of the group and remove some rules.
 	filtered = &rulespb.RuleGroupDesc{
-		Name:          group.Name,
-		Namespace:     group.Namespace,
-		Interval:      group.Interval,
-		Rules:         make([]*rulespb.RuleDesc, 0, len(group.Rules)-removedRules),
-		User:          group.User,
-		Options:       group.Options,
-		SourceTenants: group.SourceTenants,
+		Name:            group.Name,
+		Namespace:       group.Namespace,
+		Interval:        group.Interval,
+		EvaluationDelay: group.EvaluationDelay,
+		Rules:           make([]*rulespb.RuleDesc, 0, len(group.Rules)-removedRules),
+		User:            group.User,
+		Options:         group.Options,
+		SourceTenants:   group.SourceTenants,
 	}
 
 	for _, rule := range group.Rules {
@@ -996,7 +1043,8 @@ func (fs StringFilterSet) IsFiltered(val string) bool {
 }
 
 func (r *Ruler) getLocalRules(userID string, req RulesRequest) ([]*GroupStateDesc, error) {
-	groups := r.manager.GetRules(userID)
+	manager := r.manager
+	groups := manager.GetRules(userID)
 
 	groupDescs := make([]*GroupStateDesc, 0, len(groups))
 	prefix := filepath.Join(r.cfg.RulePath, userID) + "/"
@@ -1037,11 +1085,12 @@ func (r *Ruler) getLocalRules(userID string, req RulesRequest) ([]*GroupStateDes
 
 		groupDesc := &GroupStateDesc{
 			Group: &rulespb.RuleGroupDesc{
-				Name:          group.Name(),
-				Namespace:     decodedNamespace,
-				Interval:      interval,
-				User:          userID,
-				SourceTenants: group.SourceTenants(),
+				Name:            group.Name(),
+				Namespace:       decodedNamespace,
+				Interval:        interval,
+				EvaluationDelay: group.EvaluationDelay(),
+				User:            userID,
+				SourceTenants:   group.SourceTenants(),
 			},
 
 			EvaluationTimestamp: group.GetLastEvaluation(),
@@ -1095,6 +1144,9 @@ func (r *Ruler) getLocalRules(userID string, req RulesRequest) ([]*GroupStateDes
 					EvaluationTimestamp: rule.GetEvaluationTimestamp(),
 					EvaluationDuration:  rule.GetEvaluationDuration(),
 				}
+				if req.IncludeAlertStateHistory {
+					ruleDesc.AlertStateHistory = manager.GetAlertStateHistory(userID, group, rule.Name())
+				}
 			case *promRules.RecordingRule:
 				if !getRecordingRules {
 					continue
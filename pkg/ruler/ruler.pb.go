@@ -68,6 +68,8 @@ type RulesRequest struct {
 	RuleName  []string              `protobuf:"bytes,2,rep,name=rule_name,json=ruleName,proto3" json:"rule_name,omitempty"`
 	RuleGroup []string              `protobuf:"bytes,3,rep,name=rule_group,json=ruleGroup,proto3" json:"rule_group,omitempty"`
 	File      []string              `protobuf:"bytes,4,rep,name=file,proto3" json:"file,omitempty"`
+	// True to include the recent alert state transitions of the alerting rules.
+	IncludeAlertStateHistory bool `protobuf:"varint,5,opt,name=include_alert_state_history,json=includeAlertStateHistory,proto3" json:"include_alert_state_history,omitempty"`
 }
 
 func (m *RulesRequest) Reset()      { *m = RulesRequest{} }
@@ -130,6 +132,13 @@ func (m *RulesRequest) GetFile() []string {
 	return nil
 }
 
+func (m *RulesRequest) GetIncludeAlertStateHistory() bool {
+	if m != nil {
+		return m.IncludeAlertStateHistory
+	}
+	return false
+}
+
 type RulesResponse struct {
 	Groups []*GroupStateDesc `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
 }
@@ -324,13 +333,14 @@ func (m *GroupStateDesc) GetEvaluationDuration() time.Duration {
 
 // RuleStateDesc is a proto representation of a Prometheus Rule
 type RuleStateDesc struct {
-	Rule                *rulespb.RuleDesc `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
-	State               string            `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
-	Health              string            `protobuf:"bytes,3,opt,name=health,proto3" json:"health,omitempty"`
-	LastError           string            `protobuf:"bytes,4,opt,name=lastError,proto3" json:"lastError,omitempty"`
-	Alerts              []*AlertStateDesc `protobuf:"bytes,5,rep,name=alerts,proto3" json:"alerts,omitempty"`
-	EvaluationTimestamp time.Time         `protobuf:"bytes,6,opt,name=evaluationTimestamp,proto3,stdtime" json:"evaluationTimestamp"`
-	EvaluationDuration  time.Duration     `protobuf:"bytes,7,opt,name=evaluationDuration,proto3,stdduration" json:"evaluationDuration"`
+	Rule                *rulespb.RuleDesc           `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	State               string                      `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Health              string                      `protobuf:"bytes,3,opt,name=health,proto3" json:"health,omitempty"`
+	LastError           string                      `protobuf:"bytes,4,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	Alerts              []*AlertStateDesc           `protobuf:"bytes,5,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	EvaluationTimestamp time.Time                   `protobuf:"bytes,6,opt,name=evaluationTimestamp,proto3,stdtime" json:"evaluationTimestamp"`
+	EvaluationDuration  time.Duration               `protobuf:"bytes,7,opt,name=evaluationDuration,proto3,stdduration" json:"evaluationDuration"`
+	AlertStateHistory   []*AlertStateTransitionDesc `protobuf:"bytes,8,rep,name=alert_state_history,json=alertStateHistory,proto3" json:"alert_state_history,omitempty"`
 }
 
 func (m *RuleStateDesc) Reset()      { *m = RuleStateDesc{} }
@@ -414,6 +424,13 @@ func (m *RuleStateDesc) GetEvaluationDuration() time.Duration {
 	return 0
 }
 
+func (m *RuleStateDesc) GetAlertStateHistory() []*AlertStateTransitionDesc {
+	if m != nil {
+		return m.AlertStateHistory
+	}
+	return nil
+}
+
 type AlertStateDesc struct {
 	State           string                                              `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
 	Labels          []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,2,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"labels"`
@@ -515,6 +532,60 @@ func (m *AlertStateDesc) GetKeepFiringSince() time.Time {
 	return time.Time{}
 }
 
+// AlertStateTransitionDesc is a transition of the state of an alert, recorded by the ruler evaluating its rule.
+type AlertStateTransitionDesc struct {
+	Labels []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"labels"`
+	// State is the state of the alert after the transition: pending, firing, resolved or inactive.
+	State     string    `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Timestamp time.Time `protobuf:"bytes,3,opt,name=timestamp,proto3,stdtime" json:"timestamp"`
+}
+
+func (m *AlertStateTransitionDesc) Reset()      { *m = AlertStateTransitionDesc{} }
+func (*AlertStateTransitionDesc) ProtoMessage() {}
+func (*AlertStateTransitionDesc) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9ecbec0a4cfddea6, []int{7}
+}
+func (m *AlertStateTransitionDesc) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AlertStateTransitionDesc) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AlertStateTransitionDesc.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AlertStateTransitionDesc) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AlertStateTransitionDesc.Merge(m, src)
+}
+func (m *AlertStateTransitionDesc) XXX_Size() int {
+	return m.Size()
+}
+func (m *AlertStateTransitionDesc) XXX_DiscardUnknown() {
+	xxx_messageInfo_AlertStateTransitionDesc.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AlertStateTransitionDesc proto.InternalMessageInfo
+
+func (m *AlertStateTransitionDesc) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *AlertStateTransitionDesc) GetTimestamp() time.Time {
+	if m != nil {
+		return m.Timestamp
+	}
+	return time.Time{}
+}
+
 func init() {
 	proto.RegisterEnum("ruler.RulesRequest_RuleType", RulesRequest_RuleType_name, RulesRequest_RuleType_value)
 	proto.RegisterType((*RulesRequest)(nil), "ruler.RulesRequest")
@@ -524,67 +595,73 @@ func init() {
 	proto.RegisterType((*GroupStateDesc)(nil), "ruler.GroupStateDesc")
 	proto.RegisterType((*RuleStateDesc)(nil), "ruler.RuleStateDesc")
 	proto.RegisterType((*AlertStateDesc)(nil), "ruler.AlertStateDesc")
+	proto.RegisterType((*AlertStateTransitionDesc)(nil), "ruler.AlertStateTransitionDesc")
 }
 
 func init() { proto.RegisterFile("ruler.proto", fileDescriptor_9ecbec0a4cfddea6) }
 
 var fileDescriptor_9ecbec0a4cfddea6 = []byte{
-	// 871 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xcf, 0x6f, 0x1b, 0x45,
-	0x14, 0xde, 0x71, 0xe2, 0x1f, 0xfb, 0x9c, 0xa6, 0xc9, 0x24, 0xc0, 0xd6, 0x94, 0x4d, 0x64, 0x2e,
-	0x11, 0x52, 0xd6, 0x60, 0x22, 0x10, 0x12, 0x02, 0x1c, 0xb5, 0x45, 0x48, 0x08, 0x55, 0xeb, 0xc2,
-	0xd5, 0x1a, 0xdb, 0xe3, 0xcd, 0xa8, 0xeb, 0xdd, 0x65, 0x66, 0xd6, 0x22, 0x27, 0xf8, 0x13, 0x7a,
-	0xe4, 0xcc, 0x89, 0xbf, 0x83, 0x53, 0x8f, 0x11, 0xa7, 0x0a, 0xa1, 0x42, 0x9c, 0x0b, 0xc7, 0xfe,
-	0x09, 0x68, 0xde, 0xec, 0xd6, 0x76, 0x1b, 0x10, 0x16, 0x70, 0x89, 0xe7, 0xcd, 0xfb, 0xbe, 0xef,
-	0xcd, 0xfb, 0xe6, 0xed, 0x04, 0x9a, 0x32, 0x8f, 0xb9, 0x0c, 0x32, 0x99, 0xea, 0x94, 0x56, 0x31,
-	0x68, 0x1d, 0x47, 0x42, 0x9f, 0xe5, 0xc3, 0x60, 0x94, 0x4e, 0x3b, 0x51, 0x1a, 0xa5, 0x1d, 0xcc,
-	0x0e, 0xf3, 0x09, 0x46, 0x18, 0xe0, 0xca, 0xb2, 0x5a, 0x7e, 0x94, 0xa6, 0x51, 0xcc, 0x17, 0xa8,
-	0x71, 0x2e, 0x99, 0x16, 0x69, 0x52, 0xe4, 0x0f, 0x5e, 0xcc, 0x6b, 0x31, 0xe5, 0x4a, 0xb3, 0x69,
-	0x56, 0x00, 0xde, 0x5e, 0xae, 0x27, 0xd9, 0x84, 0x25, 0xac, 0x33, 0x15, 0x53, 0x21, 0x3b, 0xd9,
-	0xc3, 0xc8, 0xae, 0xb2, 0xa1, 0xfd, 0x2d, 0x18, 0xef, 0xfd, 0x2d, 0x03, 0xbb, 0xc0, 0xbf, 0x2a,
-	0x1b, 0xda, 0x5f, 0xcb, 0x6b, 0xff, 0x4c, 0x60, 0x2b, 0x34, 0x71, 0xc8, 0xbf, 0xce, 0xb9, 0xd2,
-	0xf4, 0x04, 0x6a, 0x13, 0x11, 0x6b, 0x2e, 0x3d, 0x72, 0x48, 0x8e, 0xb6, 0xbb, 0xb7, 0x03, 0xeb,
-	0xc7, 0x32, 0x08, 0x83, 0x07, 0xe7, 0x19, 0x0f, 0x0b, 0x2c, 0x7d, 0x1d, 0x5c, 0x03, 0x1b, 0x24,
-	0x6c, 0xca, 0xbd, 0xca, 0xe1, 0xc6, 0x91, 0x1b, 0x36, 0xcc, 0xc6, 0x17, 0x6c, 0xca, 0xe9, 0x1b,
-	0x00, 0x98, 0x8c, 0x64, 0x9a, 0x67, 0xde, 0x06, 0x66, 0x11, 0xfe, 0xa9, 0xd9, 0xa0, 0x14, 0x36,
-	0x27, 0x22, 0xe6, 0xde, 0x26, 0x26, 0x70, 0xdd, 0xfe, 0x10, 0x1a, 0x65, 0x0d, 0xda, 0x84, 0x7a,
-	0x2f, 0x39, 0x37, 0xe1, 0x8e, 0x43, 0x77, 0x60, 0xab, 0x17, 0x73, 0xa9, 0x45, 0x12, 0xe1, 0x0e,
-	0xa1, 0xbb, 0x70, 0x23, 0xe4, 0xa3, 0x54, 0x8e, 0xcb, 0xad, 0x4a, 0xfb, 0x23, 0xb8, 0x51, 0x1c,
-	0x57, 0x65, 0x69, 0xa2, 0x38, 0x3d, 0x86, 0x1a, 0x16, 0x57, 0x1e, 0x39, 0xdc, 0x38, 0x6a, 0x76,
-	0x5f, 0x29, 0x9a, 0xc2, 0x03, 0xf4, 0x35, 0xd3, 0xfc, 0x0e, 0x57, 0xa3, 0xb0, 0x00, 0xb5, 0x8f,
-	0x61, 0xa7, 0x7f, 0x9e, 0x8c, 0x56, 0x7c, 0xb9, 0x05, 0x8d, 0x5c, 0x71, 0x39, 0x10, 0x63, 0x2b,
-	0xe2, 0x86, 0x75, 0x13, 0x7f, 0x36, 0x56, 0xed, 0x3d, 0xd8, 0x5d, 0x82, 0xdb, 0x92, 0xed, 0x1f,
-	0x2a, 0xb0, 0xbd, 0x2a, 0x4f, 0xdf, 0x82, 0xaa, 0xb5, 0xc0, 0x38, 0xdb, 0xec, 0xee, 0x07, 0xf6,
-	0x22, 0xc2, 0xd2, 0x09, 0x3c, 0x83, 0x85, 0xd0, 0xf7, 0x61, 0x8b, 0x8d, 0xb4, 0x98, 0xf1, 0x01,
-	0x82, 0xd0, 0xd3, 0x92, 0x62, 0x2f, 0x63, 0x71, 0xec, 0xa6, 0x45, 0x62, 0x7d, 0xfa, 0x15, 0xec,
-	0xf1, 0x19, 0x8b, 0x73, 0x9c, 0xb7, 0x07, 0xe5, 0x5c, 0x79, 0x1b, 0x58, 0xb2, 0x15, 0xd8, 0xc9,
-	0x0b, 0xca, 0xc9, 0x0b, 0x9e, 0x23, 0x4e, 0x1b, 0x8f, 0x9f, 0x1e, 0x38, 0x8f, 0x7e, 0x3b, 0x20,
-	0xe1, 0x75, 0x02, 0xb4, 0x0f, 0x74, 0xb1, 0x7d, 0xa7, 0x98, 0x67, 0x6f, 0x13, 0x65, 0x6f, 0xbd,
-	0x24, 0x5b, 0x02, 0xac, 0xea, 0xf7, 0x46, 0xf5, 0x1a, 0x7a, 0xfb, 0xd7, 0x8a, 0xbd, 0xa9, 0x85,
-	0x47, 0x6f, 0xc2, 0xa6, 0x69, 0xb1, 0xb0, 0xe8, 0xe6, 0x92, 0x45, 0xd8, 0x2a, 0x26, 0xe9, 0x3e,
-	0x54, 0x95, 0x61, 0x78, 0x95, 0x43, 0x72, 0xe4, 0x86, 0x36, 0xa0, 0xaf, 0x42, 0xed, 0x8c, 0xb3,
-	0x58, 0x9f, 0x61, 0xb3, 0x6e, 0x58, 0x44, 0xf4, 0x36, 0xb8, 0x31, 0x53, 0xfa, 0xae, 0x94, 0xa9,
-	0xc4, 0x03, 0xbb, 0xe1, 0x62, 0xc3, 0x8c, 0x06, 0x33, 0x03, 0xa5, 0xbc, 0xea, 0xca, 0x68, 0xe0,
-	0x94, 0x2d, 0x8d, 0x86, 0x05, 0xfd, 0x95, 0xbd, 0xb5, 0xff, 0xc7, 0xde, 0xfa, 0xbf, 0xb3, 0xf7,
-	0xa7, 0x2a, 0x6c, 0xaf, 0xf6, 0xb1, 0xb0, 0x8e, 0x2c, 0x5b, 0x37, 0x81, 0x5a, 0xcc, 0x86, 0x3c,
-	0x2e, 0xe7, 0x6c, 0x2f, 0x18, 0xa5, 0x52, 0xf3, 0x6f, 0xb2, 0x61, 0xf0, 0xb9, 0xd9, 0xbf, 0xcf,
-	0x84, 0x3c, 0xfd, 0xc0, 0xd4, 0xfa, 0xe5, 0xe9, 0xc1, 0x3b, 0xff, 0xe4, 0x71, 0xb2, 0xbc, 0xde,
-	0x98, 0x65, 0x9a, 0xcb, 0xb0, 0x50, 0xa7, 0x19, 0x34, 0x59, 0x92, 0xa4, 0x1a, 0x8f, 0xa7, 0xf0,
-	0x29, 0xf8, 0xef, 0x8b, 0x2d, 0x97, 0x30, 0xfd, 0x1a, 0x5f, 0x38, 0x5e, 0x3c, 0x09, 0x6d, 0x40,
-	0x7b, 0xe0, 0x16, 0x5f, 0x17, 0xd3, 0x5e, 0x75, 0x8d, 0xbb, 0x6b, 0x58, 0x5a, 0x4f, 0xd3, 0x8f,
-	0xa1, 0x31, 0x11, 0x92, 0x8f, 0x8d, 0xc2, 0x3a, 0xb7, 0x5f, 0x47, 0x56, 0x4f, 0xd3, 0xbb, 0xd0,
-	0x94, 0x5c, 0xa5, 0xf1, 0xcc, 0x6a, 0xd4, 0xd7, 0xd0, 0x80, 0x92, 0xd8, 0xd3, 0xf4, 0x1e, 0x6c,
-	0x99, 0x61, 0x1e, 0x28, 0x9e, 0x68, 0xa3, 0xd3, 0x58, 0x47, 0xc7, 0x30, 0xfb, 0x3c, 0xd1, 0xf6,
-	0x38, 0x33, 0x16, 0x8b, 0xf1, 0x20, 0x4f, 0xb4, 0x88, 0x3d, 0x77, 0x1d, 0x19, 0x24, 0x7e, 0x69,
-	0x78, 0xf4, 0x3e, 0xec, 0x3e, 0xe4, 0x3c, 0x1b, 0x4c, 0x84, 0x14, 0x49, 0x34, 0x50, 0x22, 0x19,
-	0x71, 0x0f, 0xd6, 0x10, 0xbb, 0x69, 0xe8, 0xf7, 0x90, 0xdd, 0x37, 0xe4, 0xee, 0xb7, 0x50, 0x35,
-	0x9f, 0xbf, 0xa4, 0x27, 0x76, 0xa1, 0xe8, 0xde, 0x35, 0xff, 0x92, 0x5a, 0xfb, 0xab, 0x9b, 0xc5,
-	0x2b, 0xec, 0xd0, 0x4f, 0xc0, 0x7d, 0xfe, 0x38, 0xd3, 0xd7, 0x0a, 0xd0, 0x8b, 0xaf, 0x7b, 0xcb,
-	0x7b, 0x39, 0x51, 0x2a, 0x9c, 0x9e, 0x5c, 0x5c, 0xfa, 0xce, 0x93, 0x4b, 0xdf, 0x79, 0x76, 0xe9,
-	0x93, 0xef, 0xe6, 0x3e, 0xf9, 0x71, 0xee, 0x93, 0xc7, 0x73, 0x9f, 0x5c, 0xcc, 0x7d, 0xf2, 0xfb,
-	0xdc, 0x27, 0x7f, 0xcc, 0x7d, 0xe7, 0xd9, 0xdc, 0x27, 0x8f, 0xae, 0x7c, 0xe7, 0xe2, 0xca, 0x77,
-	0x9e, 0x5c, 0xf9, 0xce, 0xb0, 0x86, 0x5d, 0xbe, 0xfb, 0x67, 0x00, 0x00, 0x00, 0xff, 0xff, 0x3c,
-	0xcd, 0xed, 0x61, 0x4f, 0x08, 0x00, 0x00,
+	// 960 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0x4f, 0x6f, 0x1b, 0xc5,
+	0x1b, 0xf6, 0xda, 0xf1, 0x9f, 0x7d, 0x9d, 0xa6, 0xc9, 0x38, 0xbf, 0x1f, 0x5b, 0xb7, 0xac, 0x2d,
+	0x73, 0xb1, 0x90, 0xe2, 0x40, 0x88, 0x40, 0x48, 0xfc, 0x73, 0xd4, 0x16, 0x90, 0x10, 0x54, 0xeb,
+	0xc0, 0x75, 0x35, 0xb6, 0xc7, 0x9b, 0x51, 0xd7, 0xbb, 0xcb, 0xcc, 0x6c, 0x44, 0x4e, 0xf0, 0x11,
+	0xca, 0x8d, 0x33, 0x27, 0x2e, 0x7c, 0x09, 0x4e, 0x3d, 0xe6, 0x58, 0x21, 0x54, 0x88, 0x73, 0xe1,
+	0xd8, 0x8f, 0x80, 0xe6, 0x9d, 0xdd, 0xda, 0x6e, 0x5c, 0x54, 0x03, 0xbd, 0xc4, 0xf3, 0xce, 0xfb,
+	0x3c, 0xcf, 0xcc, 0xfb, 0xce, 0x33, 0xb3, 0x81, 0xba, 0x48, 0x43, 0x26, 0x7a, 0x89, 0x88, 0x55,
+	0x4c, 0xca, 0x18, 0x34, 0xf7, 0x02, 0xae, 0x4e, 0xd2, 0x61, 0x6f, 0x14, 0x4f, 0xf7, 0x83, 0x38,
+	0x88, 0xf7, 0x31, 0x3b, 0x4c, 0x27, 0x18, 0x61, 0x80, 0x23, 0xc3, 0x6a, 0xba, 0x41, 0x1c, 0x07,
+	0x21, 0x9b, 0xa3, 0xc6, 0xa9, 0xa0, 0x8a, 0xc7, 0x51, 0x96, 0x6f, 0x3d, 0x9b, 0x57, 0x7c, 0xca,
+	0xa4, 0xa2, 0xd3, 0x24, 0x03, 0xbc, 0xb1, 0xb8, 0x9e, 0xa0, 0x13, 0x1a, 0xd1, 0xfd, 0x29, 0x9f,
+	0x72, 0xb1, 0x9f, 0xdc, 0x0f, 0xcc, 0x28, 0x19, 0x9a, 0xdf, 0x8c, 0xf1, 0xf6, 0xdf, 0x32, 0xb0,
+	0x0a, 0xfc, 0x2b, 0x93, 0xa1, 0xf9, 0x35, 0xbc, 0xce, 0xf7, 0x45, 0xd8, 0xf4, 0x74, 0xec, 0xb1,
+	0xaf, 0x53, 0x26, 0x15, 0x39, 0x84, 0xca, 0x84, 0x87, 0x8a, 0x09, 0xc7, 0x6a, 0x5b, 0xdd, 0xad,
+	0x83, 0x5b, 0x3d, 0xd3, 0x8f, 0x45, 0x10, 0x06, 0xc7, 0x67, 0x09, 0xf3, 0x32, 0x2c, 0xb9, 0x09,
+	0xb6, 0x86, 0xf9, 0x11, 0x9d, 0x32, 0xa7, 0xd8, 0x2e, 0x75, 0x6d, 0xaf, 0xa6, 0x27, 0x3e, 0xa7,
+	0x53, 0x46, 0x5e, 0x05, 0xc0, 0x64, 0x20, 0xe2, 0x34, 0x71, 0x4a, 0x98, 0x45, 0xf8, 0xc7, 0x7a,
+	0x82, 0x10, 0xd8, 0x98, 0xf0, 0x90, 0x39, 0x1b, 0x98, 0xc0, 0x31, 0x79, 0x1f, 0x6e, 0xf2, 0x68,
+	0x14, 0xa6, 0x63, 0xe6, 0xd3, 0x90, 0x09, 0xe5, 0x4b, 0x45, 0x15, 0xf3, 0x4f, 0xb8, 0x54, 0xb1,
+	0x38, 0x73, 0xca, 0x6d, 0xab, 0x5b, 0xf3, 0x9c, 0x0c, 0xd2, 0xd7, 0x88, 0x81, 0x06, 0x7c, 0x62,
+	0xf2, 0x9d, 0xf7, 0xa0, 0x96, 0x6f, 0x91, 0xd4, 0xa1, 0xda, 0x8f, 0xce, 0x74, 0xb8, 0x5d, 0x20,
+	0xdb, 0xb0, 0x89, 0x68, 0x1e, 0x05, 0x38, 0x63, 0x91, 0x1d, 0xb8, 0xe6, 0xb1, 0x51, 0x2c, 0xc6,
+	0xf9, 0x54, 0xb1, 0xf3, 0x01, 0x5c, 0xcb, 0xaa, 0x95, 0x49, 0x1c, 0x49, 0x46, 0xf6, 0xa0, 0x82,
+	0x7b, 0x97, 0x8e, 0xd5, 0x2e, 0x75, 0xeb, 0x07, 0xff, 0xcb, 0x7a, 0x82, 0xfb, 0xc7, 0x85, 0x6f,
+	0x33, 0x39, 0xf2, 0x32, 0x50, 0x67, 0x0f, 0xb6, 0x07, 0x67, 0xd1, 0x68, 0xa9, 0xad, 0x37, 0xa0,
+	0x96, 0x4a, 0x26, 0x7c, 0x3e, 0x36, 0x22, 0xb6, 0x57, 0xd5, 0xf1, 0xa7, 0x63, 0xd9, 0x69, 0xc0,
+	0xce, 0x02, 0xdc, 0x2c, 0xd9, 0xf9, 0xb1, 0x08, 0x5b, 0xcb, 0xf2, 0xe4, 0x75, 0x28, 0x9b, 0x0e,
+	0xea, 0x83, 0xa9, 0x1f, 0xec, 0xf6, 0xcc, 0x39, 0x7a, 0x79, 0x23, 0x71, 0x0f, 0x06, 0x42, 0xde,
+	0x81, 0x4d, 0x3a, 0x52, 0xfc, 0x94, 0xf9, 0x08, 0xc2, 0x23, 0xc9, 0x29, 0xe6, 0x2c, 0xe7, 0xdb,
+	0xae, 0x1b, 0x24, 0xae, 0x4f, 0xbe, 0x82, 0x06, 0x3b, 0xa5, 0x61, 0x8a, 0x76, 0x3d, 0xce, 0x6d,
+	0xe9, 0x94, 0x70, 0xc9, 0x66, 0xcf, 0x18, 0xb7, 0x97, 0x1b, 0xb7, 0xf7, 0x14, 0x71, 0x54, 0x7b,
+	0xf8, 0xb8, 0x55, 0x78, 0xf0, 0x7b, 0xcb, 0xf2, 0x56, 0x09, 0x90, 0x01, 0x90, 0xf9, 0xf4, 0xed,
+	0xec, 0x3a, 0x38, 0x1b, 0x28, 0x7b, 0xe3, 0x8a, 0x6c, 0x0e, 0x30, 0xaa, 0x3f, 0x68, 0xd5, 0x15,
+	0xf4, 0xce, 0xcf, 0x25, 0x73, 0x52, 0xf3, 0x1e, 0xbd, 0x06, 0x1b, 0xba, 0xc4, 0xac, 0x45, 0xd7,
+	0x17, 0x5a, 0x84, 0xa5, 0x62, 0x92, 0xec, 0x42, 0x19, 0xed, 0xe4, 0x14, 0xdb, 0x56, 0xd7, 0xf6,
+	0x4c, 0x40, 0xfe, 0x0f, 0x95, 0x13, 0x46, 0x43, 0x75, 0x82, 0xc5, 0xda, 0x5e, 0x16, 0x91, 0x5b,
+	0x60, 0x87, 0x54, 0xaa, 0x3b, 0x42, 0xc4, 0x02, 0x37, 0x6c, 0x7b, 0xf3, 0x09, 0x6d, 0x0d, 0x34,
+	0xa8, 0x74, 0xca, 0x4b, 0xd6, 0x98, 0x7b, 0xd2, 0x58, 0xc3, 0x80, 0x9e, 0xd7, 0xde, 0xca, 0xcb,
+	0x69, 0x6f, 0xf5, 0x5f, 0xb5, 0x97, 0x7c, 0x01, 0x8d, 0x55, 0x97, 0xaf, 0x86, 0x85, 0xb6, 0xae,
+	0x14, 0x7a, 0x2c, 0x68, 0x24, 0x39, 0x2a, 0xe8, 0x92, 0x77, 0xe8, 0x95, 0x6b, 0xf9, 0x4b, 0x19,
+	0xb6, 0x96, 0x1b, 0x33, 0x3f, 0x0b, 0x6b, 0xf1, 0x2c, 0x26, 0x50, 0x09, 0xe9, 0x90, 0x85, 0xb9,
+	0x71, 0x1b, 0xbd, 0x51, 0x2c, 0x14, 0xfb, 0x26, 0x19, 0xf6, 0x3e, 0xd3, 0xf3, 0xf7, 0x28, 0x17,
+	0x47, 0xef, 0xea, 0xcd, 0xff, 0xfa, 0xb8, 0xf5, 0xe6, 0x8b, 0x3c, 0x96, 0x86, 0xd7, 0x1f, 0xd3,
+	0x44, 0x31, 0xe1, 0x65, 0xea, 0x24, 0x81, 0x3a, 0x8d, 0xa2, 0x58, 0x61, 0xbd, 0x12, 0x9f, 0xa6,
+	0xff, 0x7e, 0xb1, 0xc5, 0x25, 0x74, 0xbd, 0xba, 0xd1, 0x0c, 0x9d, 0x64, 0x79, 0x26, 0x20, 0x7d,
+	0xb0, 0xb3, 0xeb, 0x4a, 0x15, 0x3e, 0x6e, 0x2f, 0x6a, 0x86, 0x9a, 0xa1, 0xf5, 0x15, 0xf9, 0x10,
+	0x6a, 0x13, 0x2e, 0xd8, 0x58, 0x2b, 0xac, 0x63, 0xa7, 0x2a, 0xb2, 0xfa, 0x8a, 0xdc, 0x81, 0xba,
+	0x60, 0x32, 0x0e, 0x4f, 0x8d, 0x46, 0x75, 0x0d, 0x0d, 0xc8, 0x89, 0x7d, 0x45, 0xee, 0xc2, 0xa6,
+	0xbe, 0x1d, 0xbe, 0x64, 0x91, 0xd2, 0x3a, 0xb5, 0x75, 0x74, 0x34, 0x73, 0xc0, 0x22, 0x65, 0xb6,
+	0x73, 0x4a, 0x43, 0x3e, 0xf6, 0xd3, 0x48, 0xf1, 0xd0, 0xb1, 0xd7, 0x91, 0x41, 0xe2, 0x97, 0x9a,
+	0x47, 0xee, 0xc1, 0xce, 0x7d, 0xc6, 0x12, 0x7f, 0xc2, 0x05, 0x8f, 0x02, 0x5f, 0xf2, 0x68, 0xc4,
+	0x1c, 0x58, 0x43, 0xec, 0xba, 0xa6, 0xdf, 0x45, 0xf6, 0x40, 0x93, 0x3b, 0xbf, 0x59, 0xe0, 0x3c,
+	0xcf, 0xf4, 0x0b, 0xc6, 0xb5, 0x5e, 0xaa, 0x71, 0x57, 0x3f, 0x61, 0x47, 0x60, 0xab, 0x7f, 0xf4,
+	0x64, 0xcf, 0x69, 0x07, 0xdf, 0x42, 0x59, 0x3f, 0x97, 0x82, 0x1c, 0x9a, 0x81, 0x24, 0x8d, 0x15,
+	0xff, 0x01, 0x34, 0x77, 0x97, 0x27, 0xb3, 0xaf, 0x56, 0x81, 0x7c, 0x04, 0xf6, 0xd3, 0x8f, 0x19,
+	0x79, 0x25, 0x03, 0x3d, 0xfb, 0x35, 0x6c, 0x3a, 0x57, 0x13, 0xb9, 0xc2, 0xd1, 0xe1, 0xf9, 0x85,
+	0x5b, 0x78, 0x74, 0xe1, 0x16, 0x9e, 0x5c, 0xb8, 0xd6, 0x77, 0x33, 0xd7, 0xfa, 0x69, 0xe6, 0x5a,
+	0x0f, 0x67, 0xae, 0x75, 0x3e, 0x73, 0xad, 0x3f, 0x66, 0xae, 0xf5, 0xe7, 0xcc, 0x2d, 0x3c, 0x99,
+	0xb9, 0xd6, 0x83, 0x4b, 0xb7, 0x70, 0x7e, 0xe9, 0x16, 0x1e, 0x5d, 0xba, 0x85, 0x61, 0x05, 0xeb,
+	0x7b, 0xeb, 0xaf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xf1, 0xd9, 0xd2, 0x83, 0xbe, 0x09, 0x00, 0x00,
 }
 
 func (x RulesRequest_RuleType) String() string {
@@ -640,6 +717,9 @@ func (this *RulesRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.IncludeAlertStateHistory != that1.IncludeAlertStateHistory {
+		return false
+	}
 	return true
 }
 func (this *RulesResponse) Equal(that interface{}) bool {
@@ -804,6 +884,14 @@ func (this *RuleStateDesc) Equal(that interface{}) bool {
 	if this.EvaluationDuration != that1.EvaluationDuration {
 		return false
 	}
+	if len(this.AlertStateHistory) != len(that1.AlertStateHistory) {
+		return false
+	}
+	for i := range this.AlertStateHistory {
+		if !this.AlertStateHistory[i].Equal(that1.AlertStateHistory[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *AlertStateDesc) Equal(that interface{}) bool {
@@ -867,16 +955,52 @@ func (this *AlertStateDesc) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *AlertStateTransitionDesc) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AlertStateTransitionDesc)
+	if !ok {
+		that2, ok := that.(AlertStateTransitionDesc)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
+	}
+	if this.State != that1.State {
+		return false
+	}
+	if !this.Timestamp.Equal(that1.Timestamp) {
+		return false
+	}
+	return true
+}
 func (this *RulesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&ruler.RulesRequest{")
 	s = append(s, "Filter: "+fmt.Sprintf("%#v", this.Filter)+",\n")
 	s = append(s, "RuleName: "+fmt.Sprintf("%#v", this.RuleName)+",\n")
 	s = append(s, "RuleGroup: "+fmt.Sprintf("%#v", this.RuleGroup)+",\n")
 	s = append(s, "File: "+fmt.Sprintf("%#v", this.File)+",\n")
+	s = append(s, "IncludeAlertStateHistory: "+fmt.Sprintf("%#v", this.IncludeAlertStateHistory)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -932,7 +1056,7 @@ func (this *RuleStateDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 12)
 	s = append(s, "&ruler.RuleStateDesc{")
 	if this.Rule != nil {
 		s = append(s, "Rule: "+fmt.Sprintf("%#v", this.Rule)+",\n")
@@ -945,6 +1069,9 @@ func (this *RuleStateDesc) GoString() string {
 	}
 	s = append(s, "EvaluationTimestamp: "+fmt.Sprintf("%#v", this.EvaluationTimestamp)+",\n")
 	s = append(s, "EvaluationDuration: "+fmt.Sprintf("%#v", this.EvaluationDuration)+",\n")
+	if this.AlertStateHistory != nil {
+		s = append(s, "AlertStateHistory: "+fmt.Sprintf("%#v", this.AlertStateHistory)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -967,6 +1094,18 @@ func (this *AlertStateDesc) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *AlertStateTransitionDesc) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&ruler.AlertStateTransitionDesc{")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	s = append(s, "State: "+fmt.Sprintf("%#v", this.State)+",\n")
+	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringRuler(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1116,6 +1255,16 @@ func (m *RulesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.IncludeAlertStateHistory {
+		i--
+		if m.IncludeAlertStateHistory {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
 	if len(m.File) > 0 {
 		for iNdEx := len(m.File) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.File[iNdEx])
@@ -1328,6 +1477,20 @@ func (m *RuleStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.AlertStateHistory) > 0 {
+		for iNdEx := len(m.AlertStateHistory) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.AlertStateHistory[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRuler(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
 	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err4 != nil {
 		return 0, err4
@@ -1506,6 +1669,58 @@ func (m *AlertStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *AlertStateTransitionDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AlertStateTransitionDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AlertStateTransitionDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	n13, err13 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Timestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Timestamp):])
+	if err13 != nil {
+		return 0, err13
+	}
+	i -= n13
+	i = encodeVarintRuler(dAtA, i, uint64(n13))
+	i--
+	dAtA[i] = 0x1a
+	if len(m.State) > 0 {
+		i -= len(m.State)
+		copy(dAtA[i:], m.State)
+		i = encodeVarintRuler(dAtA, i, uint64(len(m.State)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintRuler(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintRuler(dAtA []byte, offset int, v uint64) int {
 	offset -= sovRuler(v)
 	base := offset
@@ -1544,6 +1759,9 @@ func (m *RulesRequest) Size() (n int) {
 			n += 1 + l + sovRuler(uint64(l))
 		}
 	}
+	if m.IncludeAlertStateHistory {
+		n += 2
+	}
 	return n
 }
 
@@ -1641,6 +1859,12 @@ func (m *RuleStateDesc) Size() (n int) {
 	n += 1 + l + sovRuler(uint64(l))
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration)
 	n += 1 + l + sovRuler(uint64(l))
+	if len(m.AlertStateHistory) > 0 {
+		for _, e := range m.AlertStateHistory {
+			l = e.Size()
+			n += 1 + l + sovRuler(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -1684,6 +1908,27 @@ func (m *AlertStateDesc) Size() (n int) {
 	return n
 }
 
+func (m *AlertStateTransitionDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovRuler(uint64(l))
+		}
+	}
+	l = len(m.State)
+	if l > 0 {
+		n += 1 + l + sovRuler(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Timestamp)
+	n += 1 + l + sovRuler(uint64(l))
+	return n
+}
+
 func sovRuler(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1699,6 +1944,7 @@ func (this *RulesRequest) String() string {
 		`RuleName:` + fmt.Sprintf("%v", this.RuleName) + `,`,
 		`RuleGroup:` + fmt.Sprintf("%v", this.RuleGroup) + `,`,
 		`File:` + fmt.Sprintf("%v", this.File) + `,`,
+		`IncludeAlertStateHistory:` + fmt.Sprintf("%v", this.IncludeAlertStateHistory) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1764,6 +2010,11 @@ func (this *RuleStateDesc) String() string {
 		repeatedStringForAlerts += strings.Replace(f.String(), "AlertStateDesc", "AlertStateDesc", 1) + ","
 	}
 	repeatedStringForAlerts += "}"
+	repeatedStringForAlertStateHistory := "[]*AlertStateTransitionDesc{"
+	for _, f := range this.AlertStateHistory {
+		repeatedStringForAlertStateHistory += strings.Replace(f.String(), "AlertStateTransitionDesc", "AlertStateTransitionDesc", 1) + ","
+	}
+	repeatedStringForAlertStateHistory += "}"
 	s := strings.Join([]string{`&RuleStateDesc{`,
 		`Rule:` + strings.Replace(fmt.Sprintf("%v", this.Rule), "RuleDesc", "rulespb.RuleDesc", 1) + `,`,
 		`State:` + fmt.Sprintf("%v", this.State) + `,`,
@@ -1772,6 +2023,7 @@ func (this *RuleStateDesc) String() string {
 		`Alerts:` + repeatedStringForAlerts + `,`,
 		`EvaluationTimestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationTimestamp), "Timestamp", "timestamp.Timestamp", 1), `&`, ``, 1) + `,`,
 		`EvaluationDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`AlertStateHistory:` + repeatedStringForAlertStateHistory + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1795,6 +2047,18 @@ func (this *AlertStateDesc) String() string {
 	}, "")
 	return s
 }
+func (this *AlertStateTransitionDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AlertStateTransitionDesc{`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`State:` + fmt.Sprintf("%v", this.State) + `,`,
+		`Timestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timestamp), "Timestamp", "timestamp.Timestamp", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringRuler(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1947,6 +2211,26 @@ func (m *RulesRequest) Unmarshal(dAtA []byte) error {
 			}
 			m.File = append(m.File, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IncludeAlertStateHistory", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IncludeAlertStateHistory = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRuler(dAtA[iNdEx:])
@@ -2646,6 +2930,40 @@ func (m *RuleStateDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AlertStateHistory", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AlertStateHistory = append(m.AlertStateHistory, &AlertStateTransitionDesc{})
+			if err := m.AlertStateHistory[len(m.AlertStateHistory)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRuler(dAtA[iNdEx:])
@@ -3032,6 +3350,158 @@ func (m *AlertStateDesc) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *AlertStateTransitionDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRuler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AlertStateTransitionDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AlertStateTransitionDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, github_com_grafana_mimir_pkg_mimirpb.LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.State = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.Timestamp, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRuler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipRuler(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
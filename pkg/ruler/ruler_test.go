@@ -238,7 +238,7 @@ func prepareRulerManager(t *testing.T, cfg Config, opts ...prepareOption) *Defau
 	pusher.MockPush(&mimirpb.WriteResponse{}, nil)
 
 	managerFactory := DefaultTenantManagerFactory(cfg, pusher, noopQueryable, noopQueryFunc, options.limits, options.registerer)
-	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, prometheus.NewRegistry(), options.logger, nil)
+	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, noopQueryFunc, options.limits, nil, prometheus.NewRegistry(), options.logger, nil)
 	require.NoError(t, err)
 
 	return manager
@@ -287,6 +287,85 @@ func TestNotifierSendsUserIDHeader(t *testing.T) {
 	`), "cortex_prometheus_notifications_dropped_total"))
 }
 
+func TestNotifierSendsToTenantAlertmanager(t *testing.T) {
+	receivedAlerts := func() (*httptest.Server, chan *http.Request) {
+		received := make(chan *http.Request, 10)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r
+		}))
+		t.Cleanup(ts.Close)
+		return ts, received
+	}
+	defaultAM, defaultReceived := receivedAlerts()
+	tenantAM, tenantReceived := receivedAlerts()
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tenant-token","token_type":"Bearer"}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	var overrides map[string]*validation.Limits
+	limits := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits["1"] = validation.MockDefaultLimits()
+		tenantLimits["1"].RulerAlertmanagerClientConfig = validation.RulerAlertmanagerClientConfig{
+			AlertmanagerURL:    tenantAM.URL,
+			OAuth2ClientID:     "client",
+			OAuth2ClientSecret: "secret",
+			OAuth2TokenURL:     tokenServer.URL,
+		}
+		overrides = tenantLimits
+	})
+
+	cfg := defaultRulerConfig(t)
+	cfg.AlertmanagerURL = defaultAM.URL
+
+	manager := prepareRulerManager(t, cfg, withLimits(limits))
+	defer manager.Stop()
+
+	sendAlert := func(userID string) {
+		n, err := manager.getOrCreateNotifier(userID)
+		require.NoError(t, err)
+
+		// Loop until notifier discovery syncs up
+		for len(n.Alertmanagers()) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		n.Send(&notifier.Alert{
+			Labels: labels.FromStrings("alertname", "testalert"),
+		})
+	}
+
+	sendAlert("1")
+	select {
+	case r := <-tenantReceived:
+		assert.Equal(t, "Bearer tenant-token", r.Header.Get("Authorization"))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the tenant's Alertmanager has not received the alert")
+	}
+
+	sendAlert("2")
+	select {
+	case r := <-defaultReceived:
+		assert.Empty(t, r.Header.Get("Authorization"))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the default Alertmanager has not received the alert")
+	}
+
+	assert.Empty(t, tenantReceived)
+	assert.Empty(t, defaultReceived)
+
+	// The notifier is re-configured when the tenant's Alertmanager config changes.
+	overrides["1"] = validation.MockDefaultLimits()
+	require.NoError(t, manager.syncNotifierConfig("1"))
+	n, err := manager.getOrCreateRulerNotifier("1")
+	require.NoError(t, err)
+	assert.False(t, n.tenantCfg.IsEnabled())
+}
+
 func TestRuler_Rules(t *testing.T) {
 	testCases := map[string]struct {
 		mockRules map[string]rulespb.RuleGroupList
@@ -998,7 +1077,7 @@ func TestRuler_NotifySyncRulesAsync_ShouldTriggerRulesSyncingOnAllRulersWhenEnab
 			bucketClient, err := bucket.NewClient(ctx, bucketCfg, "ruler-storage", logger, nil)
 			require.NoError(t, err)
 
-			store := bucketclient.NewBucketRuleStore(bucketClient, nil, logger)
+			store := bucketclient.NewBucketRuleStore(bucketClient, nil, 0, 0, logger)
 
 			// Create an in-memory ring backend.
 			kvStore, cleanUp := consul.NewInMemoryClient(ring.GetCodec(), logger, nil)
@@ -1142,7 +1221,7 @@ func TestRuler_NotifySyncRulesAsync_ShouldTriggerRulesSyncingAndCorrectlyHandleT
 	bucketClient, err := bucket.NewClient(ctx, bucketCfg, "ruler-storage", logger, nil)
 	require.NoError(t, err)
 
-	store := bucketclient.NewBucketRuleStore(bucketClient, nil, logger)
+	store := bucketclient.NewBucketRuleStore(bucketClient, nil, 0, 0, logger)
 
 	// Create an in-memory ring backend.
 	kvStore, cleanUp := consul.NewInMemoryClient(ring.GetCodec(), logger, nil)
@@ -1292,7 +1371,7 @@ func TestRuler_NotifySyncRulesAsync_ShouldNotTriggerRulesSyncingOnAllRulersWhenD
 	bucketClient, err := bucket.NewClient(ctx, bucketCfg, "ruler-storage", logger, nil)
 	require.NoError(t, err)
 
-	store := bucketclient.NewBucketRuleStore(bucketClient, nil, logger)
+	store := bucketclient.NewBucketRuleStore(bucketClient, nil, 0, 0, logger)
 
 	// Create an in-memory ring backend.
 	kvStore, cleanUp := consul.NewInMemoryClient(ring.GetCodec(), logger, nil)
@@ -1387,7 +1466,7 @@ func TestRuler_DeleteTenantConfiguration_ShouldDeleteTenantConfigurationAndTrigg
 	}
 
 	obj := objstore.NewInMemBucket()
-	rs := bucketclient.NewBucketRuleStore(obj, nil, log.NewNopLogger())
+	rs := bucketclient.NewBucketRuleStore(obj, nil, 0, 0, log.NewNopLogger())
 
 	// "upload" rule groups
 	for _, key := range ruleGroups {
@@ -1860,6 +1939,39 @@ func TestFilterRuleGroupsByNotMissing(t *testing.T) {
 	}
 }
 
+func TestRuler_LoadRuleGroupsToSync_ShouldApplyNamespaceDefaults(t *testing.T) {
+	ctx := context.Background()
+	store := newMockRuleStore(map[string]rulespb.RuleGroupList{
+		"user-1": {
+			{Name: "group-1", Namespace: "namespace-1", User: "user-1", Rules: []*rulespb.RuleDesc{createRecordingRule("first", "up")}},
+			{Name: "group-2", Namespace: "namespace-2", User: "user-1", Interval: time.Hour, Rules: []*rulespb.RuleDesc{createRecordingRule("second", "up")}},
+		},
+	})
+	require.NoError(t, store.SetNamespaceDefaults(ctx, "user-1", "namespace-1", &rulespb.NamespaceDefaultsDesc{
+		Namespace: "namespace-1",
+		User:      "user-1",
+		Interval:  2 * time.Minute,
+		Labels:    []mimirpb.LabelAdapter{{Name: "team", Value: "a"}},
+	}))
+
+	r := prepareRuler(t, defaultRulerConfig(t), store)
+
+	configs := map[string]rulespb.RuleGroupList{
+		"user-1": {
+			{Name: "group-1", Namespace: "namespace-1", User: "user-1"},
+			{Name: "group-2", Namespace: "namespace-2", User: "user-1"},
+		},
+	}
+	actual, err := r.loadRuleGroupsToSync(ctx, configs)
+	require.NoError(t, err)
+	require.Len(t, actual["user-1"], 2)
+
+	assert.Equal(t, 2*time.Minute, actual["user-1"][0].Interval)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "team", Value: "a"}}, actual["user-1"][0].Rules[0].Labels)
+	assert.Equal(t, time.Hour, actual["user-1"][1].Interval)
+	assert.Empty(t, actual["user-1"][1].Rules[0].Labels)
+}
+
 func BenchmarkFilterRuleGroupsByEnabled(b *testing.B) {
 	const (
 		numTenants                    = 1000
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+// enableCondition holds the enable_condition setting of a rule group, which is not supported by the Prometheus
+// rule groups format.
+type enableCondition struct {
+	EnableCondition string `yaml:"enable_condition,omitempty"`
+}
+
+// ParseEnableCondition parses the enable_condition from the YAML payload of a rule group. The enable condition is
+// a PromQL expression, and the rule group is evaluated only if the expression returns a non-empty result.
+func ParseEnableCondition(payload []byte) (string, error) {
+	condition := enableCondition{}
+	if err := yaml.Unmarshal(payload, &condition); err != nil {
+		return "", err
+	}
+	return condition.EnableCondition, nil
+}
+
+// RuleGroupWithEnableCondition is a formatted rule group along with its enable condition, as returned by the
+// ruler configuration API.
+type RuleGroupWithEnableCondition struct {
+	rulefmt.RuleGroup `yaml:",inline"`
+	EnableCondition   string `yaml:"enable_condition,omitempty"`
+}
+
+// FromProtoWithEnableCondition is like FromProto, but keeps the enable condition of the rule group.
+func FromProtoWithEnableCondition(rg *RuleGroupDesc) RuleGroupWithEnableCondition {
+	return RuleGroupWithEnableCondition{
+		RuleGroup:       FromProto(rg),
+		EnableCondition: rg.GetEnableCondition(),
+	}
+}
+
+// FormattedWithEnableConditions is like Formatted, but keeps the enable conditions of the rule groups.
+func (l RuleGroupList) FormattedWithEnableConditions() map[string][]RuleGroupWithEnableCondition {
+	ruleMap := map[string][]RuleGroupWithEnableCondition{}
+	for _, g := range l {
+		ruleMap[g.Namespace] = append(ruleMap[g.Namespace], FromProtoWithEnableCondition(g))
+	}
+	return ruleMap
+}
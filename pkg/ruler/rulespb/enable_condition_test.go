@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseEnableCondition(t *testing.T) {
+	condition, err := ParseEnableCondition([]byte("name: group\nenable_condition: count(up{job=\"mysql\"}) > 0"))
+	require.NoError(t, err)
+	assert.Equal(t, `count(up{job="mysql"}) > 0`, condition)
+
+	condition, err = ParseEnableCondition([]byte("name: group"))
+	require.NoError(t, err)
+	assert.Equal(t, "", condition)
+
+	_, err = ParseEnableCondition([]byte("name: group\nenable_condition: [invalid]"))
+	require.Error(t, err)
+}
+
+func TestFromProtoWithEnableCondition(t *testing.T) {
+	desc := &RuleGroupDesc{
+		Name:            "group",
+		Namespace:       "namespace",
+		Interval:        time.Minute,
+		Rules:           []*RuleDesc{{Record: "mysql:up", Expr: `up{job="mysql"}`}},
+		EnableCondition: `up{job="mysql"}`,
+	}
+
+	out, err := yaml.Marshal(FromProtoWithEnableCondition(desc))
+	require.NoError(t, err)
+	assert.Equal(t, `name: group
+interval: 1m
+rules:
+    - record: mysql:up
+      expr: up{job="mysql"}
+enable_condition: up{job="mysql"}
+`, string(out))
+
+	// The payload returned by the API can be sent back to the API.
+	var rg rulefmt.RuleGroup
+	require.NoError(t, yaml.Unmarshal(out, &rg))
+	condition, err := ParseEnableCondition(out)
+	require.NoError(t, err)
+	assert.Equal(t, desc.EnableCondition, condition)
+
+	// The enable condition is omitted if not set.
+	desc.EnableCondition = ""
+	out, err = yaml.Marshal(FromProtoWithEnableCondition(desc))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "enable_condition")
+
+	formatted := RuleGroupList{desc}.FormattedWithEnableConditions()
+	require.Len(t, formatted["namespace"], 1)
+	assert.Equal(t, FromProto(desc), formatted["namespace"][0].RuleGroup)
+}
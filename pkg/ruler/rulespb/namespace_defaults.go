@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb" //lint:ignore faillint allowed to import other protobuf
+)
+
+// NamespaceDefaults is the formatted representation of the defaults applied to all rule groups of a namespace.
+type NamespaceDefaults struct {
+	// Interval is the evaluation interval of rule groups not specifying it.
+	Interval model.Duration `yaml:"interval,omitempty"`
+	// EvaluationDelay is the evaluation delay (query offset) of rule groups not specifying it.
+	EvaluationDelay *model.Duration `yaml:"evaluation_delay,omitempty"`
+	// Labels are appended to all rules, unless a rule already has a label with the same name.
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// Validate returns an error if the namespace defaults are invalid.
+func (d NamespaceDefaults) Validate() error {
+	if d.Interval < 0 {
+		return fmt.Errorf("invalid namespace defaults: interval must not be negative")
+	}
+	if d.EvaluationDelay != nil && *d.EvaluationDelay < 0 {
+		return fmt.Errorf("invalid namespace defaults: evaluation delay must not be negative")
+	}
+	for name := range d.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid namespace defaults: invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
+// NamespaceDefaultsToProto transforms the formatted namespace defaults to their protobuf representation.
+func NamespaceDefaultsToProto(user, namespace string, d NamespaceDefaults) *NamespaceDefaultsDesc {
+	desc := &NamespaceDefaultsDesc{
+		Namespace: namespace,
+		User:      user,
+		Interval:  time.Duration(d.Interval),
+	}
+	if len(d.Labels) > 0 {
+		desc.Labels = mimirpb.FromLabelsToLabelAdapters(labels.FromMap(d.Labels))
+	}
+	if d.EvaluationDelay != nil && *d.EvaluationDelay > 0 {
+		desc.EvaluationDelay = time.Duration(*d.EvaluationDelay)
+	}
+	return desc
+}
+
+// NamespaceDefaultsFromProto transforms the namespace defaults protobuf to their formatted representation.
+func NamespaceDefaultsFromProto(desc *NamespaceDefaultsDesc) NamespaceDefaults {
+	d := NamespaceDefaults{
+		Interval: model.Duration(desc.GetInterval()),
+	}
+	if desc.GetEvaluationDelay() > 0 {
+		d.EvaluationDelay = new(model.Duration)
+		*d.EvaluationDelay = model.Duration(desc.GetEvaluationDelay())
+	}
+	if len(desc.Labels) > 0 {
+		d.Labels = mimirpb.FromLabelAdaptersToLabels(desc.Labels).Map()
+	}
+	return d
+}
+
+// ApplyNamespaceDefaults merges the namespace defaults into the rule group, which is modified in place.
+// Settings explicitly configured on the rule group, or on its rules, take precedence over the defaults.
+func ApplyNamespaceDefaults(rg *RuleGroupDesc, defaults *NamespaceDefaultsDesc) {
+	if defaults == nil {
+		return
+	}
+
+	if rg.Interval == 0 {
+		rg.Interval = defaults.Interval
+	}
+	if rg.EvaluationDelay == 0 {
+		rg.EvaluationDelay = defaults.EvaluationDelay
+	}
+
+	if len(defaults.Labels) == 0 {
+		return
+	}
+
+	for _, r := range rg.Rules {
+		lb := labels.NewBuilder(mimirpb.FromLabelAdaptersToLabels(defaults.Labels))
+		for _, l := range r.Labels {
+			lb.Set(l.Name, l.Value)
+		}
+		r.Labels = mimirpb.FromLabelsToLabelAdapters(lb.Labels())
+	}
+}
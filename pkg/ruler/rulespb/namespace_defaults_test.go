@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestNamespaceDefaultsRoundtrip(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty": "{}\n",
+		"all settings": `interval: 2m
+evaluation_delay: 1m
+labels:
+    env: prod
+    team: a
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var defaults NamespaceDefaults
+			require.NoError(t, yaml.Unmarshal([]byte(input), &defaults))
+			require.NoError(t, defaults.Validate())
+
+			desc := NamespaceDefaultsToProto("user", "namespace", defaults)
+			out, err := yaml.Marshal(NamespaceDefaultsFromProto(desc))
+			require.NoError(t, err)
+			assert.Equal(t, input, string(out))
+		})
+	}
+}
+
+func TestNamespaceDefaults_Validate(t *testing.T) {
+	negative := model.Duration(-time.Minute)
+
+	assert.Error(t, NamespaceDefaults{Interval: negative}.Validate())
+	assert.Error(t, NamespaceDefaults{EvaluationDelay: &negative}.Validate())
+	assert.Error(t, NamespaceDefaults{Labels: map[string]string{"invalid-name": "value"}}.Validate())
+	assert.NoError(t, NamespaceDefaults{Labels: map[string]string{"valid_name": "value"}}.Validate())
+}
+
+func TestApplyNamespaceDefaults(t *testing.T) {
+	defaults := &NamespaceDefaultsDesc{
+		Interval:        time.Minute,
+		EvaluationDelay: 2 * time.Minute,
+		Labels:          []mimirpb.LabelAdapter{{Name: "env", Value: "prod"}, {Name: "team", Value: "a"}},
+	}
+
+	t.Run("should apply the defaults to settings not configured on the rule group", func(t *testing.T) {
+		rg := &RuleGroupDesc{
+			Rules: []*RuleDesc{
+				{Record: "first", Expr: "up"},
+				{Alert: "second", Expr: "up == 0", Labels: []mimirpb.LabelAdapter{{Name: "severity", Value: "critical"}}},
+			},
+		}
+
+		ApplyNamespaceDefaults(rg, defaults)
+
+		assert.Equal(t, time.Minute, rg.Interval)
+		assert.Equal(t, 2*time.Minute, rg.EvaluationDelay)
+		assert.Equal(t, []mimirpb.LabelAdapter{{Name: "env", Value: "prod"}, {Name: "team", Value: "a"}}, rg.Rules[0].Labels)
+		assert.Equal(t, []mimirpb.LabelAdapter{{Name: "env", Value: "prod"}, {Name: "severity", Value: "critical"}, {Name: "team", Value: "a"}}, rg.Rules[1].Labels)
+	})
+
+	t.Run("should not override settings configured on the rule group", func(t *testing.T) {
+		rg := &RuleGroupDesc{
+			Interval:        time.Hour,
+			EvaluationDelay: time.Second,
+			Rules: []*RuleDesc{
+				{Record: "first", Expr: "up", Labels: []mimirpb.LabelAdapter{{Name: "team", Value: "b"}}},
+			},
+		}
+
+		ApplyNamespaceDefaults(rg, defaults)
+
+		assert.Equal(t, time.Hour, rg.Interval)
+		assert.Equal(t, time.Second, rg.EvaluationDelay)
+		assert.Equal(t, []mimirpb.LabelAdapter{{Name: "env", Value: "prod"}, {Name: "team", Value: "b"}}, rg.Rules[0].Labels)
+	})
+
+	t.Run("should do nothing on no defaults", func(t *testing.T) {
+		rg := &RuleGroupDesc{Rules: []*RuleDesc{{Record: "first", Expr: "up"}}}
+
+		ApplyNamespaceDefaults(rg, nil)
+
+		assert.Equal(t, &RuleGroupDesc{Rules: []*RuleDesc{{Record: "first", Expr: "up"}}}, rg)
+	})
+}
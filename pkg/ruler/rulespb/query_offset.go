@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+// queryOffset holds the query_offset setting, which is the name used by Prometheus for the evaluation delay.
+type queryOffset struct {
+	QueryOffset *model.Duration `yaml:"query_offset,omitempty"`
+}
+
+// MergeQueryOffset parses the query_offset from the YAML payload of a rule group, or of namespace defaults,
+// and merges it with the evaluation delay parsed from the same payload. The query offset and the evaluation
+// delay are the same setting, so an error is returned if both are configured with different values.
+func MergeQueryOffset(payload []byte, evaluationDelay *model.Duration) (*model.Duration, error) {
+	offset := queryOffset{}
+	if err := yaml.Unmarshal(payload, &offset); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case offset.QueryOffset == nil:
+		return evaluationDelay, nil
+	case evaluationDelay == nil:
+		return offset.QueryOffset, nil
+	case *offset.QueryOffset != *evaluationDelay:
+		return nil, fmt.Errorf("query_offset (%s) and evaluation_delay (%s) are the same setting and can't be configured with different values", offset.QueryOffset, evaluationDelay)
+	default:
+		return evaluationDelay, nil
+	}
+}
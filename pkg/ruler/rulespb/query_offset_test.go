@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeQueryOffset(t *testing.T) {
+	duration := func(d time.Duration) *model.Duration {
+		md := model.Duration(d)
+		return &md
+	}
+
+	tests := map[string]struct {
+		input         string
+		expected      *model.Duration
+		expectedError string
+	}{
+		"neither query offset nor evaluation delay": {
+			input:    "name: group",
+			expected: nil,
+		},
+		"only evaluation delay": {
+			input:    "name: group\nevaluation_delay: 1m",
+			expected: duration(time.Minute),
+		},
+		"only query offset": {
+			input:    "name: group\nquery_offset: 2m",
+			expected: duration(2 * time.Minute),
+		},
+		"query offset and evaluation delay with the same value": {
+			input:    "name: group\nquery_offset: 1m\nevaluation_delay: 60s",
+			expected: duration(time.Minute),
+		},
+		"query offset and evaluation delay with different values": {
+			input:         "name: group\nquery_offset: 2m\nevaluation_delay: 1m",
+			expectedError: "query_offset (2m) and evaluation_delay (1m) are the same setting and can't be configured with different values",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var rg rulefmt.RuleGroup
+			require.NoError(t, yaml.Unmarshal([]byte(testData.input), &rg))
+
+			actual, err := MergeQueryOffset([]byte(testData.input), rg.EvaluationDelay)
+			if testData.expectedError != "" {
+				require.EqualError(t, err, testData.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rulespb
+
+// RuleGroupVersion is a previous version of a rule group, retained in the storage when
+// the rule group has been changed or deleted.
+type RuleGroupVersion struct {
+	// ID identifies the version. Versions are sorted by ID, from the oldest to the most recent.
+	ID    string
+	Group *RuleGroupDesc
+}
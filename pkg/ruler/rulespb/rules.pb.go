@@ -47,6 +47,11 @@ type RuleGroupDesc struct {
 	SourceTenants                 []string      `protobuf:"bytes,10,rep,name=sourceTenants,proto3" json:"sourceTenants,omitempty"`
 	EvaluationDelay               time.Duration `protobuf:"bytes,11,opt,name=evaluationDelay,proto3,stdduration" json:"evaluationDelay"`
 	AlignEvaluationTimeOnInterval bool          `protobuf:"varint,12,opt,name=align_evaluation_time_on_interval,json=alignEvaluationTimeOnInterval,proto3" json:"align_evaluation_time_on_interval,omitempty"`
+	// The author and the time of the last change of the rule group through the ruler configuration API.
+	LastModifiedBy          string `protobuf:"bytes,13,opt,name=last_modified_by,json=lastModifiedBy,proto3" json:"last_modified_by,omitempty"`
+	LastModifiedTimestampMs int64  `protobuf:"varint,14,opt,name=last_modified_timestamp_ms,json=lastModifiedTimestampMs,proto3" json:"last_modified_timestamp_ms,omitempty"`
+	// The PromQL expression which must return a non-empty result for the rule group to be evaluated.
+	EnableCondition string `protobuf:"bytes,15,opt,name=enable_condition,json=enableCondition,proto3" json:"enable_condition,omitempty"`
 }
 
 func (m *RuleGroupDesc) Reset()      { *m = RuleGroupDesc{} }
@@ -144,6 +149,27 @@ func (m *RuleGroupDesc) GetAlignEvaluationTimeOnInterval() bool {
 	return false
 }
 
+func (m *RuleGroupDesc) GetLastModifiedBy() string {
+	if m != nil {
+		return m.LastModifiedBy
+	}
+	return ""
+}
+
+func (m *RuleGroupDesc) GetLastModifiedTimestampMs() int64 {
+	if m != nil {
+		return m.LastModifiedTimestampMs
+	}
+	return 0
+}
+
+func (m *RuleGroupDesc) GetEnableCondition() string {
+	if m != nil {
+		return m.EnableCondition
+	}
+	return ""
+}
+
 // RuleDesc is a proto representation of a Prometheus Rule
 type RuleDesc struct {
 	Expr          string                                              `protobuf:"bytes,1,opt,name=expr,proto3" json:"expr,omitempty"`
@@ -222,52 +248,130 @@ func (m *RuleDesc) GetKeepFiringFor() time.Duration {
 	return 0
 }
 
+// NamespaceDefaultsDesc is a proto representation of the defaults applied to all rule groups of a namespace.
+type NamespaceDefaultsDesc struct {
+	Namespace       string                                              `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	User            string                                              `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Interval        time.Duration                                       `protobuf:"bytes,3,opt,name=interval,proto3,stdduration" json:"interval"`
+	EvaluationDelay time.Duration                                       `protobuf:"bytes,4,opt,name=evaluationDelay,proto3,stdduration" json:"evaluationDelay"`
+	Labels          []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,5,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"labels"`
+}
+
+func (m *NamespaceDefaultsDesc) Reset()      { *m = NamespaceDefaultsDesc{} }
+func (*NamespaceDefaultsDesc) ProtoMessage() {}
+func (*NamespaceDefaultsDesc) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e722d3e922f0937, []int{2}
+}
+func (m *NamespaceDefaultsDesc) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NamespaceDefaultsDesc) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NamespaceDefaultsDesc.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NamespaceDefaultsDesc) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceDefaultsDesc.Merge(m, src)
+}
+func (m *NamespaceDefaultsDesc) XXX_Size() int {
+	return m.Size()
+}
+func (m *NamespaceDefaultsDesc) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceDefaultsDesc.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceDefaultsDesc proto.InternalMessageInfo
+
+func (m *NamespaceDefaultsDesc) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *NamespaceDefaultsDesc) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *NamespaceDefaultsDesc) GetInterval() time.Duration {
+	if m != nil {
+		return m.Interval
+	}
+	return 0
+}
+
+func (m *NamespaceDefaultsDesc) GetEvaluationDelay() time.Duration {
+	if m != nil {
+		return m.EvaluationDelay
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*RuleGroupDesc)(nil), "rules.RuleGroupDesc")
 	proto.RegisterType((*RuleDesc)(nil), "rules.RuleDesc")
+	proto.RegisterType((*NamespaceDefaultsDesc)(nil), "rules.NamespaceDefaultsDesc")
 }
 
 func init() { proto.RegisterFile("rules.proto", fileDescriptor_8e722d3e922f0937) }
 
 var fileDescriptor_8e722d3e922f0937 = []byte{
-	// 589 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x53, 0x31, 0x6f, 0xd4, 0x30,
-	0x14, 0x8e, 0xb9, 0x5c, 0x9a, 0xf8, 0x38, 0xb5, 0x32, 0x15, 0x4a, 0x2b, 0x70, 0x8f, 0x0a, 0xa4,
-	0x5b, 0xc8, 0x41, 0x11, 0x03, 0x03, 0x42, 0xad, 0x4a, 0x81, 0x02, 0x02, 0x45, 0x9d, 0x58, 0x22,
-	0x27, 0xf5, 0x85, 0xa8, 0x89, 0x6d, 0x39, 0x49, 0xd5, 0x6e, 0xfc, 0x04, 0x46, 0x7e, 0x02, 0x23,
-	0xbf, 0x80, 0xb9, 0x63, 0xc7, 0x8a, 0xa1, 0xd0, 0x74, 0x61, 0xec, 0x4f, 0x40, 0xb6, 0x73, 0x6d,
-	0x29, 0xcb, 0x2d, 0x4c, 0x79, 0xcf, 0xdf, 0xfb, 0xde, 0xfb, 0xfc, 0x3d, 0x07, 0xf6, 0x64, 0x9d,
-	0xd3, 0x32, 0x10, 0x92, 0x57, 0x1c, 0x75, 0x75, 0xb2, 0x78, 0x3f, 0xcd, 0xaa, 0x8f, 0x75, 0x1c,
-	0x24, 0xbc, 0x18, 0xa5, 0x3c, 0xe5, 0x23, 0x8d, 0xc6, 0xf5, 0x58, 0x67, 0x3a, 0xd1, 0x91, 0x61,
-	0x2d, 0xe2, 0x94, 0xf3, 0x34, 0xa7, 0x17, 0x55, 0xdb, 0xb5, 0x24, 0x55, 0xc6, 0x59, 0x8b, 0x2f,
-	0x5c, 0xc5, 0x09, 0xdb, 0x6f, 0xa1, 0x07, 0x97, 0x27, 0x49, 0x32, 0x26, 0x8c, 0x8c, 0x8a, 0xac,
-	0xc8, 0xe4, 0x48, 0xec, 0xa4, 0x26, 0x12, 0xb1, 0xf9, 0x1a, 0xc6, 0xf2, 0xf7, 0x0e, 0xec, 0x87,
-	0x75, 0x4e, 0x5f, 0x48, 0x5e, 0x8b, 0x75, 0x5a, 0x26, 0x08, 0x41, 0x9b, 0x91, 0x82, 0xfa, 0x60,
-	0x00, 0x86, 0x5e, 0xa8, 0x63, 0x74, 0x0b, 0x7a, 0xea, 0x5b, 0x0a, 0x92, 0x50, 0xff, 0x9a, 0x06,
-	0x2e, 0x0e, 0xd0, 0x33, 0xe8, 0x66, 0xac, 0xa2, 0x72, 0x97, 0xe4, 0x7e, 0x67, 0x00, 0x86, 0xbd,
-	0x95, 0x85, 0xc0, 0x68, 0x0c, 0x26, 0x1a, 0x83, 0xf5, 0xf6, 0x0e, 0x6b, 0xee, 0xc1, 0xf1, 0x92,
-	0xf5, 0xe5, 0xe7, 0x12, 0x08, 0xcf, 0x49, 0xe8, 0x1e, 0x34, 0x4e, 0xf9, 0xf6, 0xa0, 0x33, 0xec,
-	0xad, 0xcc, 0x06, 0xc6, 0x44, 0xa5, 0x4b, 0x49, 0x0a, 0x0d, 0xaa, 0x94, 0xd5, 0x25, 0x95, 0xbe,
-	0x63, 0x94, 0xa9, 0x18, 0x05, 0x70, 0x86, 0x0b, 0xd5, 0xb8, 0xf4, 0x3d, 0x4d, 0x9e, 0xff, 0x67,
-	0xf4, 0x2a, 0xdb, 0x0f, 0x27, 0x45, 0xe8, 0x2e, 0xec, 0x97, 0xbc, 0x96, 0x09, 0xdd, 0xa2, 0x8c,
-	0xb0, 0xaa, 0xf4, 0xe1, 0xa0, 0x33, 0xf4, 0xc2, 0xbf, 0x0f, 0xd1, 0x5b, 0x38, 0x4b, 0x77, 0x49,
-	0x5e, 0x6b, 0xc9, 0xeb, 0x34, 0x27, 0xfb, 0x7e, 0x6f, 0xfa, 0x8b, 0x5d, 0xe5, 0xa2, 0x97, 0xf0,
-	0x0e, 0xc9, 0xb3, 0x94, 0x45, 0x17, 0x40, 0x54, 0x65, 0x05, 0x8d, 0x38, 0x8b, 0xce, 0x9d, 0xbb,
-	0x3e, 0x00, 0x43, 0x37, 0xbc, 0xad, 0x0b, 0x9f, 0x9f, 0xd7, 0x6d, 0x65, 0x05, 0x7d, 0xc7, 0x5e,
-	0xb5, 0x45, 0x9b, 0xb6, 0xdb, 0x9d, 0x73, 0x36, 0x6d, 0x77, 0x66, 0xce, 0xdd, 0xb4, 0x5d, 0x77,
-	0xce, 0x5b, 0xfe, 0xd6, 0x81, 0xee, 0xc4, 0x28, 0xe5, 0x10, 0xdd, 0x13, 0x72, 0xb2, 0x3b, 0x15,
-	0xa3, 0x9b, 0xd0, 0x91, 0x34, 0xe1, 0x72, 0xbb, 0x5d, 0x5c, 0x9b, 0xa1, 0x79, 0xd8, 0x25, 0x39,
-	0x95, 0x95, 0x5e, 0x99, 0x17, 0x9a, 0x04, 0x3d, 0x86, 0x9d, 0x31, 0x97, 0xbe, 0x3d, 0xfd, 0x6d,
-	0x55, 0x3d, 0x7a, 0x0d, 0x67, 0x77, 0x28, 0x15, 0xd1, 0x38, 0x93, 0x19, 0x4b, 0x23, 0xd5, 0xa2,
-	0x3f, 0x7d, 0x8b, 0xbe, 0xe2, 0x6e, 0x68, 0xea, 0x06, 0x97, 0x68, 0x0c, 0x9d, 0x9c, 0xc4, 0x34,
-	0x2f, 0xfd, 0xae, 0x5e, 0xe9, 0x8d, 0x20, 0xe1, 0xb2, 0xa2, 0x7b, 0x22, 0x0e, 0xde, 0xa8, 0xf3,
-	0xf7, 0x24, 0x93, 0x6b, 0x4f, 0x14, 0xfb, 0xc7, 0xf1, 0xd2, 0xc3, 0x69, 0x9e, 0xbc, 0xe1, 0xad,
-	0x6e, 0x13, 0x51, 0x51, 0x19, 0xb6, 0xdd, 0x91, 0x80, 0x3d, 0xc2, 0x18, 0xaf, 0x88, 0x79, 0x3f,
-	0xce, 0x7f, 0x19, 0x76, 0x79, 0x84, 0x5e, 0x5c, 0x7f, 0xed, 0xe9, 0xe1, 0x09, 0xb6, 0x8e, 0x4e,
-	0xb0, 0x75, 0x76, 0x82, 0xc1, 0xa7, 0x06, 0x83, 0xaf, 0x0d, 0x06, 0x07, 0x0d, 0x06, 0x87, 0x0d,
-	0x06, 0xbf, 0x1a, 0x0c, 0x7e, 0x37, 0xd8, 0x3a, 0x6b, 0x30, 0xf8, 0x7c, 0x8a, 0xad, 0xc3, 0x53,
-	0x6c, 0x1d, 0x9d, 0x62, 0xeb, 0xc3, 0x8c, 0xfe, 0x09, 0x44, 0x1c, 0x3b, 0xda, 0xca, 0x47, 0x7f,
-	0x02, 0x00, 0x00, 0xff, 0xff, 0x16, 0xa3, 0xc2, 0x1a, 0x6b, 0x04, 0x00, 0x00,
+	// 713 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x54, 0x4f, 0x6f, 0xd3, 0x4a,
+	0x10, 0xcf, 0x36, 0x4e, 0xea, 0x6c, 0x5e, 0x9a, 0x68, 0x5f, 0xdf, 0x7b, 0x6e, 0xf5, 0xd8, 0x86,
+	0x0a, 0xa4, 0x70, 0xc0, 0x81, 0x22, 0x0e, 0x08, 0x21, 0xd4, 0x10, 0x0a, 0x14, 0x0a, 0xc8, 0xea,
+	0x89, 0x8b, 0xb5, 0x4e, 0x36, 0x66, 0x55, 0x7b, 0xd7, 0x5a, 0xdb, 0x55, 0x73, 0xe3, 0x23, 0x70,
+	0xe4, 0x23, 0x70, 0xe4, 0x2b, 0x70, 0xeb, 0x31, 0xc7, 0x8a, 0x43, 0xa1, 0xe9, 0x85, 0x63, 0x3f,
+	0x02, 0xda, 0x75, 0xdc, 0xa6, 0x85, 0x43, 0xa4, 0x0a, 0x4e, 0x9e, 0x7f, 0xbf, 0x99, 0xd9, 0x99,
+	0xf9, 0x19, 0x56, 0x65, 0x1a, 0xd0, 0xd8, 0x8e, 0xa4, 0x48, 0x04, 0x2a, 0x69, 0x65, 0xf9, 0xa6,
+	0xcf, 0x92, 0xb7, 0xa9, 0x67, 0xf7, 0x44, 0xd8, 0xf6, 0x85, 0x2f, 0xda, 0xda, 0xeb, 0xa5, 0x03,
+	0xad, 0x69, 0x45, 0x4b, 0x19, 0x6a, 0x19, 0xfb, 0x42, 0xf8, 0x01, 0x3d, 0x8b, 0xea, 0xa7, 0x92,
+	0x24, 0x4c, 0xf0, 0x89, 0x7f, 0xe9, 0xa2, 0x9f, 0xf0, 0xe1, 0xc4, 0x75, 0x6b, 0xba, 0x92, 0x24,
+	0x03, 0xc2, 0x49, 0x3b, 0x64, 0x21, 0x93, 0xed, 0x68, 0xc7, 0xcf, 0xa4, 0xc8, 0xcb, 0xbe, 0x19,
+	0x62, 0x75, 0x64, 0xc0, 0x9a, 0x93, 0x06, 0xf4, 0x89, 0x14, 0x69, 0xd4, 0xa5, 0x71, 0x0f, 0x21,
+	0x68, 0x70, 0x12, 0x52, 0x0b, 0x34, 0x41, 0xab, 0xe2, 0x68, 0x19, 0xfd, 0x0f, 0x2b, 0xea, 0x1b,
+	0x47, 0xa4, 0x47, 0xad, 0x39, 0xed, 0x38, 0x33, 0xa0, 0x87, 0xd0, 0x64, 0x3c, 0xa1, 0x72, 0x97,
+	0x04, 0x56, 0xb1, 0x09, 0x5a, 0xd5, 0xb5, 0x25, 0x3b, 0xeb, 0xd1, 0xce, 0x7b, 0xb4, 0xbb, 0x93,
+	0x37, 0x74, 0xcc, 0xfd, 0xc3, 0x95, 0xc2, 0x87, 0xaf, 0x2b, 0xc0, 0x39, 0x05, 0xa1, 0xeb, 0x30,
+	0x9b, 0x94, 0x65, 0x34, 0x8b, 0xad, 0xea, 0x5a, 0xdd, 0xce, 0x86, 0xa8, 0xfa, 0x52, 0x2d, 0x39,
+	0x99, 0x57, 0x75, 0x96, 0xc6, 0x54, 0x5a, 0xe5, 0xac, 0x33, 0x25, 0x23, 0x1b, 0xce, 0x8b, 0x48,
+	0x25, 0x8e, 0xad, 0x8a, 0x06, 0x2f, 0xfe, 0x54, 0x7a, 0x9d, 0x0f, 0x9d, 0x3c, 0x08, 0x5d, 0x83,
+	0xb5, 0x58, 0xa4, 0xb2, 0x47, 0xb7, 0x29, 0x27, 0x3c, 0x89, 0x2d, 0xd8, 0x2c, 0xb6, 0x2a, 0xce,
+	0x79, 0x23, 0xda, 0x82, 0x75, 0xba, 0x4b, 0x82, 0x54, 0xb7, 0xdc, 0xa5, 0x01, 0x19, 0x5a, 0xd5,
+	0xd9, 0x1f, 0x76, 0x11, 0x8b, 0x9e, 0xc2, 0xab, 0x24, 0x60, 0x3e, 0x77, 0xcf, 0x1c, 0x6e, 0xc2,
+	0x42, 0xea, 0x0a, 0xee, 0x9e, 0x4e, 0xee, 0xaf, 0x26, 0x68, 0x99, 0xce, 0x15, 0x1d, 0xf8, 0xf8,
+	0x34, 0x6e, 0x9b, 0x85, 0xf4, 0x15, 0x7f, 0x96, 0x4f, 0xaa, 0x05, 0x1b, 0x01, 0x89, 0x13, 0x37,
+	0x14, 0x7d, 0x36, 0x60, 0xb4, 0xef, 0x7a, 0x43, 0xab, 0xa6, 0xc7, 0xb1, 0xa0, 0xec, 0x5b, 0x13,
+	0x73, 0x67, 0x88, 0xee, 0xc3, 0xe5, 0xf3, 0x91, 0xaa, 0x60, 0x9c, 0x90, 0x30, 0x72, 0xc3, 0xd8,
+	0x5a, 0x68, 0x82, 0x56, 0xd1, 0xf9, 0x6f, 0x1a, 0xb3, 0x9d, 0xfb, 0xb7, 0x62, 0x74, 0x03, 0x36,
+	0x28, 0x27, 0x5e, 0x40, 0xdd, 0x9e, 0xe0, 0x7d, 0xa6, 0x1a, 0xb1, 0xea, 0xba, 0x4c, 0x3d, 0xb3,
+	0x3f, 0xca, 0xcd, 0x9b, 0x86, 0x59, 0x6a, 0x94, 0x37, 0x0d, 0x73, 0xbe, 0x61, 0x6e, 0x1a, 0xa6,
+	0xd9, 0xa8, 0xac, 0x7e, 0x2a, 0x42, 0x33, 0x5f, 0x9d, 0xda, 0x19, 0xdd, 0x8b, 0x64, 0x7e, 0x4d,
+	0x4a, 0x46, 0xff, 0xc2, 0xb2, 0xa4, 0x3d, 0x21, 0xfb, 0x93, 0x53, 0x9a, 0x68, 0x68, 0x11, 0x96,
+	0x48, 0x40, 0x65, 0xa2, 0x8f, 0xa8, 0xe2, 0x64, 0x0a, 0xba, 0x0b, 0x8b, 0x03, 0x21, 0x2d, 0x63,
+	0xf6, 0xf9, 0xab, 0x78, 0xf4, 0x1c, 0xd6, 0x77, 0x28, 0x8d, 0xdc, 0x01, 0x93, 0x8c, 0xfb, 0xae,
+	0x4a, 0x51, 0x9b, 0x3d, 0x45, 0x4d, 0x61, 0x37, 0x34, 0x74, 0x43, 0x48, 0x34, 0x80, 0xe5, 0x80,
+	0x78, 0x34, 0x88, 0xad, 0x92, 0x3e, 0xb2, 0xbf, 0xed, 0x9e, 0x90, 0x09, 0xdd, 0x8b, 0x3c, 0xfb,
+	0x85, 0xb2, 0xbf, 0x26, 0x4c, 0x76, 0xee, 0x29, 0xf4, 0x97, 0xc3, 0x95, 0xdb, 0xb3, 0x90, 0x30,
+	0xc3, 0xad, 0xf7, 0x49, 0x94, 0x50, 0xe9, 0x4c, 0xb2, 0xa3, 0x08, 0x56, 0x09, 0xe7, 0x22, 0x21,
+	0xd9, 0x45, 0x97, 0x7f, 0x4b, 0xb1, 0xe9, 0x12, 0x7a, 0x71, 0xb5, 0xd5, 0xcf, 0x73, 0xf0, 0x9f,
+	0x97, 0x39, 0x9f, 0xbb, 0x74, 0x40, 0xd2, 0x20, 0x89, 0xf5, 0xfe, 0xce, 0x31, 0x1f, 0x5c, 0x64,
+	0x7e, 0xce, 0xc8, 0xb9, 0x29, 0x46, 0x5e, 0xfa, 0x6f, 0xf0, 0x0b, 0xf2, 0x19, 0x97, 0x20, 0xdf,
+	0x1f, 0xda, 0x5d, 0xe7, 0xc1, 0xe8, 0x08, 0x17, 0x0e, 0x8e, 0x70, 0xe1, 0xe4, 0x08, 0x83, 0x77,
+	0x63, 0x0c, 0x3e, 0x8e, 0x31, 0xd8, 0x1f, 0x63, 0x30, 0x1a, 0x63, 0xf0, 0x6d, 0x8c, 0xc1, 0xf7,
+	0x31, 0x2e, 0x9c, 0x8c, 0x31, 0x78, 0x7f, 0x8c, 0x0b, 0xa3, 0x63, 0x5c, 0x38, 0x38, 0xc6, 0x85,
+	0x37, 0xf3, 0xfa, 0xd7, 0x16, 0x79, 0x5e, 0x59, 0x3f, 0xea, 0xce, 0x8f, 0x00, 0x00, 0x00, 0xff,
+	0xff, 0xd3, 0x3d, 0x31, 0x6e, 0x41, 0x06, 0x00, 0x00,
 }
 
 func (this *RuleGroupDesc) Equal(that interface{}) bool {
@@ -331,6 +435,15 @@ func (this *RuleGroupDesc) Equal(that interface{}) bool {
 	if this.AlignEvaluationTimeOnInterval != that1.AlignEvaluationTimeOnInterval {
 		return false
 	}
+	if this.LastModifiedBy != that1.LastModifiedBy {
+		return false
+	}
+	if this.LastModifiedTimestampMs != that1.LastModifiedTimestampMs {
+		return false
+	}
+	if this.EnableCondition != that1.EnableCondition {
+		return false
+	}
 	return true
 }
 func (this *RuleDesc) Equal(that interface{}) bool {
@@ -385,11 +498,52 @@ func (this *RuleDesc) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *NamespaceDefaultsDesc) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*NamespaceDefaultsDesc)
+	if !ok {
+		that2, ok := that.(NamespaceDefaultsDesc)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Namespace != that1.Namespace {
+		return false
+	}
+	if this.User != that1.User {
+		return false
+	}
+	if this.Interval != that1.Interval {
+		return false
+	}
+	if this.EvaluationDelay != that1.EvaluationDelay {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *RuleGroupDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 16)
 	s = append(s, "&rulespb.RuleGroupDesc{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
@@ -404,6 +558,9 @@ func (this *RuleGroupDesc) GoString() string {
 	s = append(s, "SourceTenants: "+fmt.Sprintf("%#v", this.SourceTenants)+",\n")
 	s = append(s, "EvaluationDelay: "+fmt.Sprintf("%#v", this.EvaluationDelay)+",\n")
 	s = append(s, "AlignEvaluationTimeOnInterval: "+fmt.Sprintf("%#v", this.AlignEvaluationTimeOnInterval)+",\n")
+	s = append(s, "LastModifiedBy: "+fmt.Sprintf("%#v", this.LastModifiedBy)+",\n")
+	s = append(s, "LastModifiedTimestampMs: "+fmt.Sprintf("%#v", this.LastModifiedTimestampMs)+",\n")
+	s = append(s, "EnableCondition: "+fmt.Sprintf("%#v", this.EnableCondition)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -423,6 +580,20 @@ func (this *RuleDesc) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *NamespaceDefaultsDesc) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&rulespb.NamespaceDefaultsDesc{")
+	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
+	s = append(s, "User: "+fmt.Sprintf("%#v", this.User)+",\n")
+	s = append(s, "Interval: "+fmt.Sprintf("%#v", this.Interval)+",\n")
+	s = append(s, "EvaluationDelay: "+fmt.Sprintf("%#v", this.EvaluationDelay)+",\n")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringRules(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -451,6 +622,25 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.EnableCondition) > 0 {
+		i -= len(m.EnableCondition)
+		copy(dAtA[i:], m.EnableCondition)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.EnableCondition)))
+		i--
+		dAtA[i] = 0x7a
+	}
+	if m.LastModifiedTimestampMs != 0 {
+		i = encodeVarintRules(dAtA, i, uint64(m.LastModifiedTimestampMs))
+		i--
+		dAtA[i] = 0x70
+	}
+	if len(m.LastModifiedBy) > 0 {
+		i -= len(m.LastModifiedBy)
+		copy(dAtA[i:], m.LastModifiedBy)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.LastModifiedBy)))
+		i--
+		dAtA[i] = 0x6a
+	}
 	if m.AlignEvaluationTimeOnInterval {
 		i--
 		if m.AlignEvaluationTimeOnInterval {
@@ -626,6 +816,73 @@ func (m *RuleDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *NamespaceDefaultsDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NamespaceDefaultsDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *NamespaceDefaultsDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintRules(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDelay, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDelay):])
+	if err5 != nil {
+		return 0, err5
+	}
+	i -= n5
+	i = encodeVarintRules(dAtA, i, uint64(n5))
+	i--
+	dAtA[i] = 0x22
+	n6, err6 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
+	if err6 != nil {
+		return 0, err6
+	}
+	i -= n6
+	i = encodeVarintRules(dAtA, i, uint64(n6))
+	i--
+	dAtA[i] = 0x1a
+	if len(m.User) > 0 {
+		i -= len(m.User)
+		copy(dAtA[i:], m.User)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.User)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Namespace) > 0 {
+		i -= len(m.Namespace)
+		copy(dAtA[i:], m.Namespace)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.Namespace)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintRules(dAtA []byte, offset int, v uint64) int {
 	offset -= sovRules(v)
 	base := offset
@@ -680,6 +937,17 @@ func (m *RuleGroupDesc) Size() (n int) {
 	if m.AlignEvaluationTimeOnInterval {
 		n += 2
 	}
+	l = len(m.LastModifiedBy)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
+	if m.LastModifiedTimestampMs != 0 {
+		n += 1 + sovRules(uint64(m.LastModifiedTimestampMs))
+	}
+	l = len(m.EnableCondition)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
 	return n
 }
 
@@ -720,6 +988,33 @@ func (m *RuleDesc) Size() (n int) {
 	return n
 }
 
+func (m *NamespaceDefaultsDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Namespace)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval)
+	n += 1 + l + sovRules(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDelay)
+	n += 1 + l + sovRules(uint64(l))
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovRules(uint64(l))
+		}
+	}
+	return n
+}
+
 func sovRules(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -750,6 +1045,9 @@ func (this *RuleGroupDesc) String() string {
 		`SourceTenants:` + fmt.Sprintf("%v", this.SourceTenants) + `,`,
 		`EvaluationDelay:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDelay), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`AlignEvaluationTimeOnInterval:` + fmt.Sprintf("%v", this.AlignEvaluationTimeOnInterval) + `,`,
+		`LastModifiedBy:` + fmt.Sprintf("%v", this.LastModifiedBy) + `,`,
+		`LastModifiedTimestampMs:` + fmt.Sprintf("%v", this.LastModifiedTimestampMs) + `,`,
+		`EnableCondition:` + fmt.Sprintf("%v", this.EnableCondition) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -770,6 +1068,20 @@ func (this *RuleDesc) String() string {
 	}, "")
 	return s
 }
+func (this *NamespaceDefaultsDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&NamespaceDefaultsDesc{`,
+		`Namespace:` + fmt.Sprintf("%v", this.Namespace) + `,`,
+		`User:` + fmt.Sprintf("%v", this.User) + `,`,
+		`Interval:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Interval), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`EvaluationDelay:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDelay), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringRules(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1089,6 +1401,89 @@ func (m *RuleGroupDesc) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.AlignEvaluationTimeOnInterval = bool(v != 0)
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastModifiedBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LastModifiedBy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastModifiedTimestampMs", wireType)
+			}
+			m.LastModifiedTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastModifiedTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EnableCondition", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EnableCondition = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRules(dAtA[iNdEx:])
@@ -1396,6 +1791,223 @@ func (m *RuleDesc) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *NamespaceDefaultsDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRules
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NamespaceDefaultsDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NamespaceDefaultsDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Namespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Namespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.User = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Interval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvaluationDelay", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.EvaluationDelay, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, github_com_grafana_mimir_pkg_mimirpb.LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRules(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRules
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRules
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipRules(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
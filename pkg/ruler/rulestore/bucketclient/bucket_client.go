@@ -8,15 +8,20 @@ package bucketclient
 import (
 	"bytes"
 	"context"
+	crypto_rand "crypto/rand"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"path"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gogo/protobuf/proto"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/objstore"
 	"golang.org/x/sync/errgroup"
@@ -30,41 +35,67 @@ const (
 	// RulesPrefix is the bucket prefix under which all tenants rule groups are stored.
 	RulesPrefix = "rules"
 
+	// namespaceDefaultsPrefix is the per-tenant prefix under which the namespace defaults are stored.
+	// It's not a valid base64 encoded namespace, so it never clashes with rule groups.
+	namespaceDefaultsPrefix = ".namespace-defaults" + objstore.DirDelim
+
+	// ruleGroupVersionsPrefix is the per-tenant prefix under which the previous versions of the rule groups are stored.
+	// It's not a valid base64 encoded namespace, so it never clashes with rule groups.
+	ruleGroupVersionsPrefix = ".rule-group-versions" + objstore.DirDelim
+
 	loadConcurrency = 10
 )
 
 var (
-	errInvalidRuleGroupKey = errors.New("invalid rule group object key")
-	errEmptyUser           = errors.New("empty user")
-	errEmptyNamespace      = errors.New("empty namespace")
-	errEmptyGroupName      = errors.New("empty group name")
+	errInvalidRuleGroupKey         = errors.New("invalid rule group object key")
+	errInvalidNamespaceDefaultsKey = errors.New("invalid namespace defaults object key")
+	errEmptyUser                   = errors.New("empty user")
+	errEmptyNamespace              = errors.New("empty namespace")
+	errEmptyGroupName              = errors.New("empty group name")
 )
 
 // BucketRuleStore is used to support the RuleStore interface against an object storage backend. It is implemented
 // using the Thanos objstore.Bucket interface
 type BucketRuleStore struct {
-	bucket      objstore.Bucket
-	cfgProvider bucket.TenantConfigProvider
-	logger      log.Logger
+	bucket                  objstore.Bucket
+	cfgProvider             bucket.TenantConfigProvider
+	versionsRetained        int
+	versionsRetentionPeriod time.Duration
+	logger                  log.Logger
+
+	// entropyMtx protects entropy, which generates monotonic version IDs within the same millisecond.
+	entropyMtx sync.Mutex
+	entropy    io.Reader
 }
 
-func NewBucketRuleStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *BucketRuleStore {
+// NewBucketRuleStore creates a new BucketRuleStore. If versionsRetained is greater than 0, the store retains up to
+// versionsRetained previous versions of each rule group when the rule group is changed or deleted. If
+// versionsRetentionPeriod is greater than 0, the previous versions older than versionsRetentionPeriod are deleted too.
+func NewBucketRuleStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, versionsRetained int, versionsRetentionPeriod time.Duration, logger log.Logger) *BucketRuleStore {
 	return &BucketRuleStore{
-		bucket:      bucket.NewPrefixedBucketClient(bkt, RulesPrefix),
-		cfgProvider: cfgProvider,
-		logger:      logger,
+		bucket:                  bucket.NewPrefixedBucketClient(bkt, RulesPrefix),
+		cfgProvider:             cfgProvider,
+		versionsRetained:        versionsRetained,
+		versionsRetentionPeriod: versionsRetentionPeriod,
+		logger:                  logger,
+		entropy:                 ulid.Monotonic(crypto_rand.Reader, 0),
 	}
 }
 
 // getRuleGroup loads and return a rules group. If existing rule group is supplied, it is Reset and reused. If nil, new RuleGroupDesc is allocated.
 func (b *BucketRuleStore) getRuleGroup(ctx context.Context, userID, namespace, groupName string, rg *rulespb.RuleGroupDesc) (*rulespb.RuleGroupDesc, error) {
+	return b.getRuleGroupObject(ctx, userID, getRuleGroupObjectKey(namespace, groupName), rg, rulestore.ErrGroupNotFound)
+}
+
+// getRuleGroupObject loads and return the rule group stored in the object, or returns errNotFound if the object doesn't exist.
+// If existing rule group is supplied, it is Reset and reused. If nil, new RuleGroupDesc is allocated.
+func (b *BucketRuleStore) getRuleGroupObject(ctx context.Context, userID, objectKey string, rg *rulespb.RuleGroupDesc, errNotFound error) (*rulespb.RuleGroupDesc, error) {
 	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
-	objectKey := getRuleGroupObjectKey(namespace, groupName)
 
 	reader, err := userBucket.Get(ctx, objectKey)
 	if userBucket.IsObjNotFoundErr(err) {
 		level.Debug(b.logger).Log("msg", "rule group does not exist", "user", userID, "key", objectKey)
-		return nil, rulestore.ErrGroupNotFound
+		return nil, errNotFound
 	}
 
 	if err != nil {
@@ -119,6 +150,10 @@ func (b *BucketRuleStore) ListRuleGroupsForUserAndNamespace(ctx context.Context,
 	}
 
 	err := userBucket.Iter(ctx, prefix, func(key string) error {
+		if strings.HasPrefix(key, namespaceDefaultsPrefix) || strings.HasPrefix(key, ruleGroupVersionsPrefix) {
+			return nil
+		}
+
 		namespace, group, err := parseRuleGroupObjectKey(key)
 		if err != nil {
 			level.Warn(b.logger).Log("msg", "invalid rule group object key found while listing rule groups", "user", userID, "key", key, "err", err)
@@ -213,17 +248,35 @@ func (b *BucketRuleStore) SetRuleGroup(ctx context.Context, userID string, names
 		return err
 	}
 
-	return userBucket.Upload(ctx, getRuleGroupObjectKey(namespace, group.Name), bytes.NewBuffer(data))
+	if err := b.retainRuleGroupVersion(ctx, userID, namespace, group.Name); err != nil {
+		return err
+	}
+
+	if err := userBucket.Upload(ctx, getRuleGroupObjectKey(namespace, group.Name), bytes.NewBuffer(data)); err != nil {
+		return err
+	}
+
+	b.pruneRuleGroupVersions(ctx, userID, namespace)
+	return nil
 }
 
 // DeleteRuleGroup implements rules.RuleStore.
 func (b *BucketRuleStore) DeleteRuleGroup(ctx context.Context, userID string, namespace string, group string) error {
+	if err := b.retainRuleGroupVersion(ctx, userID, namespace, group); err != nil {
+		return err
+	}
+
 	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
 	err := userBucket.Delete(ctx, getRuleGroupObjectKey(namespace, group))
 	if b.bucket.IsObjNotFoundErr(err) {
 		return rulestore.ErrGroupNotFound
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	b.pruneRuleGroupVersions(ctx, userID, namespace)
+	return nil
 }
 
 // DeleteNamespace implements rules.RuleStore.
@@ -233,7 +286,19 @@ func (b *BucketRuleStore) DeleteNamespace(ctx context.Context, userID string, na
 		return err
 	}
 
-	if len(ruleGroupList) == 0 {
+	deletedDefaults, err := b.deleteNamespaceDefaultsForNamespace(ctx, userID, namespace)
+	if err != nil {
+		return err
+	}
+
+	if namespace == "" {
+		// The previous versions of the rule groups are deleted along with the tenant rule groups, instead of being retained.
+		if err := b.deleteAllRuleGroupVersions(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if len(ruleGroupList) == 0 && !deletedDefaults {
 		return rulestore.ErrGroupNamespaceNotFound
 	}
 
@@ -242,6 +307,12 @@ func (b *BucketRuleStore) DeleteNamespace(ctx context.Context, userID string, na
 		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if namespace != "" {
+			if err := b.retainRuleGroupVersion(ctx, userID, rg.Namespace, rg.Name); err != nil {
+				return err
+			}
+		}
+
 		objectKey := getRuleGroupObjectKey(rg.Namespace, rg.Name)
 		level.Debug(b.logger).Log("msg", "deleting rule group", "user", userID, "namespace", namespace, "key", objectKey)
 		err = userBucket.Delete(ctx, objectKey)
@@ -251,9 +322,344 @@ func (b *BucketRuleStore) DeleteNamespace(ctx context.Context, userID string, na
 		}
 	}
 
+	if namespace != "" {
+		b.pruneRuleGroupVersions(ctx, userID, namespace)
+	}
+	return nil
+}
+
+// ListRuleGroupVersions implements rules.RuleStore.
+func (b *BucketRuleStore) ListRuleGroupVersions(ctx context.Context, userID, namespace, group string) ([]rulespb.RuleGroupVersion, error) {
+	ids, err := b.listRuleGroupVersionIDs(ctx, userID, namespace, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	versions := make([]rulespb.RuleGroupVersion, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		if b.isRuleGroupVersionExpired(ids[i], now) {
+			// Not pruned yet.
+			continue
+		}
+
+		rg, err := b.GetRuleGroupVersion(ctx, userID, namespace, group, ids[i])
+		if errors.Is(err, rulestore.ErrGroupVersionNotFound) {
+			// Deleted in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, rulespb.RuleGroupVersion{ID: ids[i], Group: rg})
+	}
+
+	return versions, nil
+}
+
+// GetRuleGroupVersion implements rules.RuleStore.
+func (b *BucketRuleStore) GetRuleGroupVersion(ctx context.Context, userID, namespace, group, version string) (*rulespb.RuleGroupDesc, error) {
+	if _, err := ulid.Parse(version); err != nil || b.isRuleGroupVersionExpired(version, time.Now()) {
+		return nil, rulestore.ErrGroupVersionNotFound
+	}
+
+	return b.getRuleGroupObject(ctx, userID, getRuleGroupVersionsPrefix(namespace, group)+version, nil, rulestore.ErrGroupVersionNotFound)
+}
+
+// retainRuleGroupVersion copies the current rule group, if any, among its previous versions. It's a no-op
+// if the store is not configured to retain previous versions.
+func (b *BucketRuleStore) retainRuleGroupVersion(ctx context.Context, userID, namespace, group string) error {
+	if b.versionsRetained <= 0 {
+		return nil
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+	objectKey := getRuleGroupObjectKey(namespace, group)
+
+	reader, err := userBucket.Get(ctx, objectKey)
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get rule group %s", objectKey)
+	}
+	defer func() { _ = reader.Close() }()
+
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read rule group %s", objectKey)
+	}
+
+	versionKey := getRuleGroupVersionsPrefix(namespace, group) + b.newVersionID()
+	if err := userBucket.Upload(ctx, versionKey, bytes.NewReader(buf)); err != nil {
+		return errors.Wrapf(err, "failed to upload rule group version %s", versionKey)
+	}
+
 	return nil
 }
 
+// pruneRuleGroupVersions deletes the previous versions of the rule groups of the namespace, including the
+// deleted rule groups, which exceed the number of versions to retain or are older than the retention period.
+// Failures are only logged, because the versions are pruned again at the next change of the namespace.
+func (b *BucketRuleStore) pruneRuleGroupVersions(ctx context.Context, userID, namespace string) {
+	if b.versionsRetained <= 0 {
+		return
+	}
+
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+
+	idsByGroup := map[string][]string{}
+	err := userBucket.Iter(ctx, ruleGroupVersionsPrefix+getNamespacePrefix(namespace), func(key string) error {
+		groupPrefix, id := path.Split(key)
+		if _, err := ulid.Parse(id); err != nil {
+			// Do not fail just because of a spurious item in the bucket.
+			return nil
+		}
+
+		idsByGroup[groupPrefix] = append(idsByGroup[groupPrefix], id)
+		return nil
+	}, objstore.WithRecursiveIter)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "unable to list rule group versions to prune", "user", userID, "namespace", namespace, "err", err)
+		return
+	}
+
+	now := time.Now()
+	for groupPrefix, ids := range idsByGroup {
+		// ULIDs are lexicographically sortable by time, so the versions to prune are the first ones.
+		sort.Strings(ids)
+		for i, id := range ids {
+			if len(ids)-i <= b.versionsRetained && !b.isRuleGroupVersionExpired(id, now) {
+				break
+			}
+
+			key := groupPrefix + id
+			if err := userBucket.Delete(ctx, key); err != nil && !userBucket.IsObjNotFoundErr(err) {
+				level.Warn(b.logger).Log("msg", "unable to prune rule group version", "user", userID, "key", key, "err", err)
+				return
+			}
+		}
+	}
+}
+
+// deleteAllRuleGroupVersions deletes the previous versions of all the rule groups of the tenant.
+func (b *BucketRuleStore) deleteAllRuleGroupVersions(ctx context.Context, userID string) error {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+
+	var keys []string
+	err := userBucket.Iter(ctx, ruleGroupVersionsPrefix, func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}, objstore.WithRecursiveIter)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := userBucket.Delete(ctx, key); err != nil && !userBucket.IsObjNotFoundErr(err) {
+			level.Error(b.logger).Log("msg", "unable to delete rule group version", "user", userID, "key", key, "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// isRuleGroupVersionExpired returns whether the version, which must be a valid ULID, is older than the retention period.
+func (b *BucketRuleStore) isRuleGroupVersionExpired(version string, now time.Time) bool {
+	if b.versionsRetentionPeriod <= 0 {
+		return false
+	}
+	return ulid.MustParse(version).Time() < uint64(now.Add(-b.versionsRetentionPeriod).UnixMilli())
+}
+
+func (b *BucketRuleStore) newVersionID() string {
+	b.entropyMtx.Lock()
+	defer b.entropyMtx.Unlock()
+
+	return ulid.MustNew(ulid.Now(), b.entropy).String()
+}
+
+// listRuleGroupVersionIDs returns the IDs of the previous versions of the rule group, from the oldest one.
+func (b *BucketRuleStore) listRuleGroupVersionIDs(ctx context.Context, userID, namespace, group string) ([]string, error) {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+	versionsPrefix := getRuleGroupVersionsPrefix(namespace, group)
+
+	var ids []string
+	err := userBucket.Iter(ctx, versionsPrefix, func(key string) error {
+		id := strings.TrimPrefix(key, versionsPrefix)
+		if _, err := ulid.Parse(id); err != nil {
+			level.Warn(b.logger).Log("msg", "invalid rule group version object key found while listing rule group versions", "user", userID, "key", key, "err", err)
+
+			// Do not fail just because of a spurious item in the bucket.
+			return nil
+		}
+
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ULIDs are lexicographically sortable by time.
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// LoadNamespaceDefaults implements rules.RuleStore.
+func (b *BucketRuleStore) LoadNamespaceDefaults(ctx context.Context, userID string) (map[string]*rulespb.NamespaceDefaultsDesc, error) {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+
+	var namespaces []string
+	err := userBucket.Iter(ctx, namespaceDefaultsPrefix, func(key string) error {
+		namespace, err := parseNamespaceDefaultsObjectKey(key)
+		if err != nil {
+			level.Warn(b.logger).Log("msg", "invalid namespace defaults object key found while listing namespace defaults", "user", userID, "key", key, "err", err)
+
+			// Do not fail just because of a spurious item in the bucket.
+			return nil
+		}
+
+		namespaces = append(namespaces, namespace)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*rulespb.NamespaceDefaultsDesc, len(namespaces))
+	for _, namespace := range namespaces {
+		defaults, err := b.GetNamespaceDefaults(ctx, userID, namespace)
+		if errors.Is(err, rulestore.ErrNamespaceDefaultsNotFound) {
+			// Deleted in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result[namespace] = defaults
+	}
+
+	return result, nil
+}
+
+// GetNamespaceDefaults implements rules.RuleStore.
+func (b *BucketRuleStore) GetNamespaceDefaults(ctx context.Context, userID, namespace string) (*rulespb.NamespaceDefaultsDesc, error) {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+	objectKey := getNamespaceDefaultsObjectKey(namespace)
+
+	reader, err := userBucket.Get(ctx, objectKey)
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil, rulestore.ErrNamespaceDefaultsNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get namespace defaults %s", objectKey)
+	}
+	defer func() { _ = reader.Close() }()
+
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read namespace defaults %s", objectKey)
+	}
+
+	defaults := &rulespb.NamespaceDefaultsDesc{}
+	if err := proto.Unmarshal(buf, defaults); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal namespace defaults %s", objectKey)
+	}
+
+	return defaults, nil
+}
+
+// SetNamespaceDefaults implements rules.RuleStore.
+func (b *BucketRuleStore) SetNamespaceDefaults(ctx context.Context, userID, namespace string, defaults *rulespb.NamespaceDefaultsDesc) error {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+	data, err := proto.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+
+	return userBucket.Upload(ctx, getNamespaceDefaultsObjectKey(namespace), bytes.NewBuffer(data))
+}
+
+// DeleteNamespaceDefaults implements rules.RuleStore.
+func (b *BucketRuleStore) DeleteNamespaceDefaults(ctx context.Context, userID, namespace string) error {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+	err := userBucket.Delete(ctx, getNamespaceDefaultsObjectKey(namespace))
+	if b.bucket.IsObjNotFoundErr(err) {
+		return rulestore.ErrNamespaceDefaultsNotFound
+	}
+	return err
+}
+
+// deleteNamespaceDefaultsForNamespace deletes the defaults of the input namespace, or of all namespaces
+// if the input namespace is empty. Returns whether any namespace defaults have been deleted.
+func (b *BucketRuleStore) deleteNamespaceDefaultsForNamespace(ctx context.Context, userID, namespace string) (bool, error) {
+	userBucket := bucket.NewUserBucketClient(userID, b.bucket, b.cfgProvider)
+
+	var keys []string
+	if namespace != "" {
+		// Not all object storages return an error when deleting a non-existing object.
+		key := getNamespaceDefaultsObjectKey(namespace)
+		exists, err := userBucket.Exists(ctx, key)
+		if err != nil || !exists {
+			return false, err
+		}
+		keys = []string{key}
+	} else {
+		err := userBucket.Iter(ctx, namespaceDefaultsPrefix, func(key string) error {
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	deleted := false
+	for _, key := range keys {
+		err := userBucket.Delete(ctx, key)
+		if b.bucket.IsObjNotFoundErr(err) {
+			continue
+		}
+		if err != nil {
+			level.Error(b.logger).Log("msg", "unable to delete namespace defaults", "user", userID, "key", key, "err", err)
+			return deleted, err
+		}
+		deleted = true
+	}
+
+	return deleted, nil
+}
+
+func getNamespaceDefaultsObjectKey(namespace string) string {
+	return namespaceDefaultsPrefix + base64.URLEncoding.EncodeToString([]byte(namespace))
+}
+
+// parseNamespaceDefaultsObjectKey parses a bucket object key in the format ".namespace-defaults/<namespace>".
+func parseNamespaceDefaultsObjectKey(key string) (string, error) {
+	encodedNamespace := strings.TrimPrefix(key, namespaceDefaultsPrefix)
+	if encodedNamespace == key || strings.Contains(encodedNamespace, objstore.DirDelim) {
+		return "", errInvalidNamespaceDefaultsKey
+	}
+
+	decodedNamespace, err := base64.URLEncoding.DecodeString(encodedNamespace)
+	if err != nil {
+		return "", err
+	}
+
+	if len(decodedNamespace) == 0 {
+		return "", errEmptyNamespace
+	}
+
+	return string(decodedNamespace), nil
+}
+
+func getRuleGroupVersionsPrefix(namespace, group string) string {
+	return ruleGroupVersionsPrefix + getRuleGroupObjectKey(namespace, group) + objstore.DirDelim
+}
+
 func getNamespacePrefix(namespace string) string {
 	return base64.URLEncoding.EncodeToString([]byte(namespace)) + objstore.DirDelim
 }
@@ -6,13 +6,17 @@
 package bucketclient
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/base64"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
@@ -32,7 +36,7 @@ type testGroup struct {
 }
 
 func TestListRules(t *testing.T) {
-	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, 0, 0, log.NewNopLogger())
 
 	groups := []testGroup{
 		{user: "user1", namespace: "hello", ruleGroup: rulefmt.RuleGroup{Name: "first testGroup"}},
@@ -93,7 +97,7 @@ func TestListRules(t *testing.T) {
 }
 
 func TestLoadRules(t *testing.T) {
-	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, 0, 0, log.NewNopLogger())
 	groups := []testGroup{
 		{user: "user1", namespace: "hello", ruleGroup: rulefmt.RuleGroup{Name: "first testGroup", Interval: model.Duration(time.Minute), Rules: []rulefmt.RuleNode{{
 			For:           model.Duration(5 * time.Minute),
@@ -206,7 +210,7 @@ func TestLoadRules(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	bucketClient := objstore.NewInMemBucket()
-	rs := NewBucketRuleStore(bucketClient, nil, log.NewNopLogger())
+	rs := NewBucketRuleStore(bucketClient, nil, 0, 0, log.NewNopLogger())
 
 	groups := []testGroup{
 		{user: "user1", namespace: "A", ruleGroup: rulefmt.RuleGroup{Name: "1"}},
@@ -266,6 +270,207 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestNamespaceDefaults(t *testing.T) {
+	ctx := context.Background()
+	bucketClient := objstore.NewInMemBucket()
+	rs := NewBucketRuleStore(bucketClient, nil, 0, 0, log.NewNopLogger())
+
+	require.NoError(t, rs.SetRuleGroup(ctx, "user1", "A", rulespb.ToProto("user1", "A", rulefmt.RuleGroup{Name: "1"})))
+	require.NoError(t, rs.SetRuleGroup(ctx, "user1", "B", rulespb.ToProto("user1", "B", rulefmt.RuleGroup{Name: "2"})))
+
+	defaultsA := rulespb.NamespaceDefaultsToProto("user1", "A", rulespb.NamespaceDefaults{Interval: model.Duration(time.Minute), Labels: map[string]string{"team": "a"}})
+	defaultsC := rulespb.NamespaceDefaultsToProto("user1", "C", rulespb.NamespaceDefaults{Interval: model.Duration(time.Hour)})
+	require.NoError(t, rs.SetNamespaceDefaults(ctx, "user1", "A", defaultsA))
+	require.NoError(t, rs.SetNamespaceDefaults(ctx, "user1", "C", defaultsC))
+
+	// Namespace defaults should not be listed as rule groups.
+	groups, err := rs.ListRuleGroupsForUserAndNamespace(ctx, "user1", "")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	users, err := rs.ListAllUsers(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user1"}, users)
+
+	actual, err := rs.GetNamespaceDefaults(ctx, "user1", "A")
+	require.NoError(t, err)
+	require.Equal(t, defaultsA, actual)
+
+	_, err = rs.GetNamespaceDefaults(ctx, "user1", "B")
+	require.Equal(t, rulestore.ErrNamespaceDefaultsNotFound, err)
+
+	all, err := rs.LoadNamespaceDefaults(ctx, "user1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]*rulespb.NamespaceDefaultsDesc{"A": defaultsA, "C": defaultsC}, all)
+
+	all, err = rs.LoadNamespaceDefaults(ctx, "user2")
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	// Deleting a namespace deletes its defaults too, even if it has no rule groups.
+	require.NoError(t, rs.DeleteNamespace(ctx, "user1", "A"))
+	require.NoError(t, rs.DeleteNamespace(ctx, "user1", "C"))
+	require.Equal(t, rulestore.ErrGroupNamespaceNotFound, rs.DeleteNamespace(ctx, "user1", "C"))
+
+	require.Equal(t, []string{
+		"rules/user1/" + getRuleGroupObjectKey("B", "2"),
+	}, getSortedObjectKeys(bucketClient))
+
+	require.NoError(t, rs.SetNamespaceDefaults(ctx, "user1", "B", defaultsA))
+	require.NoError(t, rs.DeleteNamespaceDefaults(ctx, "user1", "B"))
+	require.Equal(t, rulestore.ErrNamespaceDefaultsNotFound, rs.DeleteNamespaceDefaults(ctx, "user1", "B"))
+
+	// Deleting all namespaces deletes all defaults too.
+	require.NoError(t, rs.SetNamespaceDefaults(ctx, "user1", "D", defaultsA))
+	require.NoError(t, rs.DeleteNamespace(ctx, "user1", ""))
+	require.Empty(t, getSortedObjectKeys(bucketClient))
+}
+
+func TestRuleGroupVersions(t *testing.T) {
+	ctx := context.Background()
+	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, 2, 0, log.NewNopLogger())
+
+	setGroup := func(interval time.Duration, author string) {
+		desc := rulespb.ToProto("user1", "hello", rulefmt.RuleGroup{Name: "group", Interval: model.Duration(interval)})
+		desc.LastModifiedBy = author
+		require.NoError(t, rs.SetRuleGroup(ctx, "user1", "hello", desc))
+	}
+	versionAuthors := func() []string {
+		versions, err := rs.ListRuleGroupVersions(ctx, "user1", "hello", "group")
+		require.NoError(t, err)
+
+		authors := []string{}
+		for _, v := range versions {
+			authors = append(authors, v.Group.LastModifiedBy)
+
+			// The version can be loaded by ID.
+			rg, err := rs.GetRuleGroupVersion(ctx, "user1", "hello", "group", v.ID)
+			require.NoError(t, err)
+			require.Equal(t, v.Group, rg)
+		}
+		return authors
+	}
+
+	// No version is retained when the rule group is created.
+	setGroup(time.Minute, "user-a")
+	require.Empty(t, versionAuthors())
+
+	// The replaced rule groups are retained, up to the configured number of versions.
+	setGroup(2*time.Minute, "user-b")
+	require.Equal(t, []string{"user-a"}, versionAuthors())
+
+	setGroup(3*time.Minute, "user-c")
+	setGroup(4*time.Minute, "user-d")
+	require.Equal(t, []string{"user-c", "user-b"}, versionAuthors())
+
+	versions, err := rs.ListRuleGroupVersions(ctx, "user1", "hello", "group")
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Minute, versions[0].Group.Interval)
+
+	// The versions are not listed as rule groups.
+	groups, err := rs.ListRuleGroupsForUserAndNamespace(ctx, "user1", "")
+	require.NoError(t, err)
+	require.Equal(t, rulespb.RuleGroupList{{User: "user1", Namespace: "hello", Name: "group"}}, groups)
+
+	// The deleted rule groups are retained too.
+	require.NoError(t, rs.DeleteRuleGroup(ctx, "user1", "hello", "group"))
+	require.Equal(t, []string{"user-d", "user-c"}, versionAuthors())
+
+	setGroup(5*time.Minute, "user-e")
+	require.Equal(t, []string{"user-d", "user-c"}, versionAuthors())
+
+	require.NoError(t, rs.DeleteNamespace(ctx, "user1", "hello"))
+	require.Equal(t, []string{"user-e", "user-d"}, versionAuthors())
+
+	_, err = rs.GetRuleGroupVersion(ctx, "user1", "hello", "group", "invalid")
+	require.ErrorIs(t, err, rulestore.ErrGroupVersionNotFound)
+	_, err = rs.GetRuleGroupVersion(ctx, "user1", "hello", "group", "01H0000000000000000000000")
+	require.ErrorIs(t, err, rulestore.ErrGroupVersionNotFound)
+
+	// The versions are deleted along with the tenant rule groups.
+	setGroup(6*time.Minute, "user-f")
+	require.NoError(t, rs.DeleteNamespace(ctx, "user1", ""))
+	require.Empty(t, versionAuthors())
+}
+
+func TestRuleGroupVersions_RetentionPeriod(t *testing.T) {
+	ctx := context.Background()
+	bucketClient := objstore.NewInMemBucket()
+	rs := NewBucketRuleStore(bucketClient, nil, 5, time.Hour, log.NewNopLogger())
+
+	setGroup := func(name, author string) {
+		desc := rulespb.ToProto("user1", "hello", rulefmt.RuleGroup{Name: name})
+		desc.LastModifiedBy = author
+		require.NoError(t, rs.SetRuleGroup(ctx, "user1", "hello", desc))
+	}
+	uploadVersion := func(name, author string, ts time.Time) string {
+		desc := rulespb.ToProto("user1", "hello", rulefmt.RuleGroup{Name: name})
+		desc.LastModifiedBy = author
+		data, err := proto.Marshal(desc)
+		require.NoError(t, err)
+
+		id := ulid.MustNew(ulid.Timestamp(ts), rand.Reader).String()
+		require.NoError(t, bucketClient.Upload(ctx, RulesPrefix+"/user1/"+getRuleGroupVersionsPrefix("hello", name)+id, bytes.NewReader(data)))
+		return id
+	}
+	versionAuthors := func(name string) []string {
+		versions, err := rs.ListRuleGroupVersions(ctx, "user1", "hello", name)
+		require.NoError(t, err)
+
+		authors := []string{}
+		for _, v := range versions {
+			authors = append(authors, v.Group.LastModifiedBy)
+		}
+		return authors
+	}
+
+	expiredID := uploadVersion("group", "user-a", time.Now().Add(-2*time.Hour))
+	uploadVersion("group", "user-b", time.Now().Add(-time.Minute))
+	// The versions of a deleted rule group.
+	uploadVersion("deleted", "user-c", time.Now().Add(-2*time.Hour))
+
+	// The expired versions are not listed, nor loaded, even before being pruned.
+	require.Equal(t, []string{"user-b"}, versionAuthors("group"))
+	_, err := rs.GetRuleGroupVersion(ctx, "user1", "hello", "group", expiredID)
+	require.ErrorIs(t, err, rulestore.ErrGroupVersionNotFound)
+
+	// The expired versions of all the rule groups of the namespace are pruned when the namespace is changed.
+	setGroup("group", "user-d")
+	require.Equal(t, []string{"user-b"}, versionAuthors("group"))
+	require.Empty(t, versionAuthors("deleted"))
+
+	for _, key := range getSortedObjectKeys(bucketClient) {
+		assert.NotContains(t, key, expiredID)
+		assert.NotContains(t, key, getRuleGroupVersionsPrefix("hello", "deleted"))
+	}
+}
+
+func TestRuleGroupVersions_Disabled(t *testing.T) {
+	ctx := context.Background()
+	rs := NewBucketRuleStore(objstore.NewInMemBucket(), nil, 0, 0, log.NewNopLogger())
+
+	desc := rulespb.ToProto("user1", "hello", rulefmt.RuleGroup{Name: "group"})
+	require.NoError(t, rs.SetRuleGroup(ctx, "user1", "hello", desc))
+	require.NoError(t, rs.SetRuleGroup(ctx, "user1", "hello", desc))
+	require.NoError(t, rs.DeleteRuleGroup(ctx, "user1", "hello", "group"))
+
+	versions, err := rs.ListRuleGroupVersions(ctx, "user1", "hello", "group")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+}
+
+func TestParseNamespaceDefaultsObjectKey(t *testing.T) {
+	namespace, err := parseNamespaceDefaultsObjectKey(getNamespaceDefaultsObjectKey("namespace/with/slashes"))
+	require.NoError(t, err)
+	assert.Equal(t, "namespace/with/slashes", namespace)
+
+	_, err = parseNamespaceDefaultsObjectKey(getRuleGroupObjectKey("namespace", "group"))
+	assert.Equal(t, errInvalidNamespaceDefaultsKey, err)
+
+	_, err = parseNamespaceDefaultsObjectKey(namespaceDefaultsPrefix)
+	assert.Equal(t, errEmptyNamespace, err)
+}
+
 func getSortedObjectKeys(bucketClient interface{}) []string {
 	if typed, ok := bucketClient.(*objstore.InMemBucket); ok {
 		var keys []string
@@ -414,7 +619,7 @@ func TestListAllRuleGroupsWithNoNamespaceOrGroup(t *testing.T) {
 		},
 	}
 
-	s := NewBucketRuleStore(obj, nil, log.NewNopLogger())
+	s := NewBucketRuleStore(obj, nil, 0, 0, log.NewNopLogger())
 	out, err := s.ListRuleGroupsForUserAndNamespace(context.Background(), "user1", "")
 	require.NoError(t, err)
 	require.Equal(t, 0, len(out))
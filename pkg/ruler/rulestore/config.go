@@ -6,10 +6,12 @@
 package rulestore
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/dskit/cache"
@@ -19,7 +21,12 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket"
 )
 
-var supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
+var (
+	supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
+
+	errInvalidRuleGroupVersionsRetained        = errors.New("the number of rule group versions to retain can't be negative")
+	errInvalidRuleGroupVersionsRetentionPeriod = errors.New("the rule group versions retention period can't be negative")
+)
 
 // Config configures a rule store.
 type Config struct {
@@ -28,6 +35,9 @@ type Config struct {
 
 	// Cache holds the configuration used for the ruler storage cache.
 	Cache cache.BackendConfig `yaml:"cache"`
+
+	RuleGroupVersionsRetained        int           `yaml:"rule_group_versions_retained" category:"experimental"`
+	RuleGroupVersionsRetentionPeriod time.Duration `yaml:"rule_group_versions_retention_period" category:"experimental"`
 }
 
 // RegisterFlags registers the backend storage config.
@@ -41,6 +51,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.Cache.Backend, prefix+"cache.backend", "", fmt.Sprintf("Backend for ruler storage cache, if not empty. The cache is supported for any storage backend except %q. Supported values: %s.", local.Name, strings.Join(supportedCacheBackends, ", ")))
 	cfg.Cache.Memcached.RegisterFlagsWithPrefix(prefix+"cache.memcached.", f)
 	cfg.Cache.Redis.RegisterFlagsWithPrefix(prefix+"cache.redis.", f)
+
+	f.IntVar(&cfg.RuleGroupVersionsRetained, prefix+"rule-group-versions-retained", 0, "Number of previous versions of each rule group to retain in the storage when the rule group is changed or deleted. The previous versions can be listed and restored through the ruler configuration API. 0 to disable.")
+	f.DurationVar(&cfg.RuleGroupVersionsRetentionPeriod, prefix+"rule-group-versions-retention-period", 30*24*time.Hour, "How long the previous versions of the rule groups are retained in the storage, including the versions of the deleted rule groups. The previous versions are pruned when their namespace is changed, and deleted along with the tenant rule groups. 0 to retain them until they exceed the number of versions to retain.")
 }
 
 func (cfg *Config) Validate() error {
@@ -48,6 +61,13 @@ func (cfg *Config) Validate() error {
 		return err
 	}
 
+	if cfg.RuleGroupVersionsRetained < 0 {
+		return errInvalidRuleGroupVersionsRetained
+	}
+	if cfg.RuleGroupVersionsRetentionPeriod < 0 {
+		return errInvalidRuleGroupVersionsRetentionPeriod
+	}
+
 	return cfg.Cache.Validate()
 }
 
@@ -109,11 +109,42 @@ func (l *Client) DeleteRuleGroup(_ context.Context, _, _, _ string) error {
 	return errors.New("DeleteRuleGroup unsupported in rule local store")
 }
 
+// ListRuleGroupVersions implements RuleStore
+func (l *Client) ListRuleGroupVersions(_ context.Context, _, _, _ string) ([]rulespb.RuleGroupVersion, error) {
+	return nil, errors.New("ListRuleGroupVersions unsupported in rule local store")
+}
+
+// GetRuleGroupVersion implements RuleStore
+func (l *Client) GetRuleGroupVersion(_ context.Context, _, _, _, _ string) (*rulespb.RuleGroupDesc, error) {
+	return nil, errors.New("GetRuleGroupVersion unsupported in rule local store")
+}
+
 // DeleteNamespace implements RulerStore
 func (l *Client) DeleteNamespace(_ context.Context, _, _ string) error {
 	return errors.New("DeleteNamespace unsupported in rule local store")
 }
 
+// LoadNamespaceDefaults implements RuleStore. Namespace defaults are not supported in rule local store,
+// so no defaults are returned.
+func (l *Client) LoadNamespaceDefaults(_ context.Context, _ string) (map[string]*rulespb.NamespaceDefaultsDesc, error) {
+	return nil, nil
+}
+
+// GetNamespaceDefaults implements RuleStore
+func (l *Client) GetNamespaceDefaults(_ context.Context, _, _ string) (*rulespb.NamespaceDefaultsDesc, error) {
+	return nil, errors.New("GetNamespaceDefaults unsupported in rule local store")
+}
+
+// SetNamespaceDefaults implements RuleStore
+func (l *Client) SetNamespaceDefaults(_ context.Context, _, _ string, _ *rulespb.NamespaceDefaultsDesc) error {
+	return errors.New("SetNamespaceDefaults unsupported in rule local store")
+}
+
+// DeleteNamespaceDefaults implements RuleStore
+func (l *Client) DeleteNamespaceDefaults(_ context.Context, _, _ string) error {
+	return errors.New("DeleteNamespaceDefaults unsupported in rule local store")
+}
+
 func (l *Client) loadAllRulesGroupsForUser(ctx context.Context, userID string) (rulespb.RuleGroupList, error) {
 	var allLists rulespb.RuleGroupList
 
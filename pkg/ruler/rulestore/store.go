@@ -19,6 +19,10 @@ var (
 	ErrGroupNamespaceNotFound = errors.New("group namespace does not exist")
 	// ErrUserNotFound is returned if the user does not currently exist
 	ErrUserNotFound = errors.New("no rule groups found for user")
+	// ErrNamespaceDefaultsNotFound is returned if a namespace has no defaults
+	ErrNamespaceDefaultsNotFound = errors.New("namespace defaults do not exist")
+	// ErrGroupVersionNotFound is returned if a rule group version does not exist
+	ErrGroupVersionNotFound = errors.New("group version does not exist")
 )
 
 // RuleStore is used to store and retrieve rules.
@@ -49,12 +53,33 @@ type RuleStore interface {
 	LoadRuleGroups(ctx context.Context, groupsToLoad map[string]rulespb.RuleGroupList) (missing rulespb.RuleGroupList, err error)
 
 	GetRuleGroup(ctx context.Context, userID, namespace, group string) (*rulespb.RuleGroupDesc, error)
+
+	// SetRuleGroup stores the rule group. The replaced rule group, if any, is retained as a previous version
+	// if the store is configured to retain previous versions.
 	SetRuleGroup(ctx context.Context, userID, namespace string, group *rulespb.RuleGroupDesc) error
 
-	// DeleteRuleGroup deletes single rule group.
+	// DeleteRuleGroup deletes single rule group. The deleted rule group is retained as a previous version
+	// if the store is configured to retain previous versions.
 	DeleteRuleGroup(ctx context.Context, userID, namespace string, group string) error
 
+	// ListRuleGroupVersions returns the previous versions of a rule group retained in the storage, from the most recent one.
+	ListRuleGroupVersions(ctx context.Context, userID, namespace, group string) ([]rulespb.RuleGroupVersion, error)
+
+	// GetRuleGroupVersion returns a previous version of a rule group, or ErrGroupVersionNotFound if the version does not exist.
+	GetRuleGroupVersion(ctx context.Context, userID, namespace, group, version string) (*rulespb.RuleGroupDesc, error)
+
 	// DeleteNamespace lists rule groups for given user and namespace, and deletes all rule groups.
 	// If namespace is empty, deletes all rule groups for user.
+	// The namespace defaults, if any, are deleted too.
 	DeleteNamespace(ctx context.Context, userID, namespace string) error
+
+	// LoadNamespaceDefaults returns the defaults of all the namespaces of a user, by namespace.
+	LoadNamespaceDefaults(ctx context.Context, userID string) (map[string]*rulespb.NamespaceDefaultsDesc, error)
+
+	// GetNamespaceDefaults returns the defaults of a namespace, or ErrNamespaceDefaultsNotFound if the namespace has no defaults.
+	GetNamespaceDefaults(ctx context.Context, userID, namespace string) (*rulespb.NamespaceDefaultsDesc, error)
+	SetNamespaceDefaults(ctx context.Context, userID, namespace string, defaults *rulespb.NamespaceDefaultsDesc) error
+
+	// DeleteNamespaceDefaults deletes the defaults of a namespace, or returns ErrNamespaceDefaultsNotFound if the namespace has no defaults.
+	DeleteNamespaceDefaults(ctx context.Context, userID, namespace string) error
 }
@@ -49,8 +49,8 @@ func NewRuleStore(ctx context.Context, cfg rulestore.Config, cfgProvider bucket.
 		return nil, nil, err
 	}
 
-	directStore = bucketclient.NewBucketRuleStore(directBucketClient, cfgProvider, logger)
-	cachedStore = bucketclient.NewBucketRuleStore(cachedBucketClient, cfgProvider, logger)
+	directStore = bucketclient.NewBucketRuleStore(directBucketClient, cfgProvider, cfg.RuleGroupVersionsRetained, cfg.RuleGroupVersionsRetentionPeriod, logger)
+	cachedStore = bucketclient.NewBucketRuleStore(cachedBucketClient, cfgProvider, cfg.RuleGroupVersionsRetained, cfg.RuleGroupVersionsRetentionPeriod, logger)
 
 	return directStore, cachedStore, nil
 }
@@ -42,9 +42,10 @@ var (
 )
 
 type mockRuleStore struct {
-	rules        map[string]rulespb.RuleGroupList
-	missingRules rulespb.RuleGroupList
-	mtx          sync.Mutex
+	rules             map[string]rulespb.RuleGroupList
+	missingRules      rulespb.RuleGroupList
+	namespaceDefaults map[string]map[string]*rulespb.NamespaceDefaultsDesc
+	mtx               sync.Mutex
 }
 
 func newMockRuleStore(rules map[string]rulespb.RuleGroupList) *mockRuleStore {
@@ -191,6 +192,14 @@ func (m *mockRuleStore) DeleteRuleGroup(_ context.Context, userID string, namesp
 	return nil
 }
 
+func (m *mockRuleStore) ListRuleGroupVersions(context.Context, string, string, string) ([]rulespb.RuleGroupVersion, error) {
+	return nil, nil
+}
+
+func (m *mockRuleStore) GetRuleGroupVersion(context.Context, string, string, string, string) (*rulespb.RuleGroupDesc, error) {
+	return nil, rulestore.ErrGroupVersionNotFound
+}
+
 func (m *mockRuleStore) DeleteNamespace(_ context.Context, userID, namespace string) error {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
@@ -219,3 +228,50 @@ func (m *mockRuleStore) DeleteNamespace(_ context.Context, userID, namespace str
 
 	return nil
 }
+
+func (m *mockRuleStore) LoadNamespaceDefaults(_ context.Context, userID string) (map[string]*rulespb.NamespaceDefaultsDesc, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	result := make(map[string]*rulespb.NamespaceDefaultsDesc, len(m.namespaceDefaults[userID]))
+	for namespace, defaults := range m.namespaceDefaults[userID] {
+		result[namespace] = defaults
+	}
+	return result, nil
+}
+
+func (m *mockRuleStore) GetNamespaceDefaults(_ context.Context, userID, namespace string) (*rulespb.NamespaceDefaultsDesc, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	defaults, ok := m.namespaceDefaults[userID][namespace]
+	if !ok {
+		return nil, rulestore.ErrNamespaceDefaultsNotFound
+	}
+	return defaults, nil
+}
+
+func (m *mockRuleStore) SetNamespaceDefaults(_ context.Context, userID, namespace string, defaults *rulespb.NamespaceDefaultsDesc) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.namespaceDefaults == nil {
+		m.namespaceDefaults = map[string]map[string]*rulespb.NamespaceDefaultsDesc{}
+	}
+	if m.namespaceDefaults[userID] == nil {
+		m.namespaceDefaults[userID] = map[string]*rulespb.NamespaceDefaultsDesc{}
+	}
+	m.namespaceDefaults[userID][namespace] = defaults
+	return nil
+}
+
+func (m *mockRuleStore) DeleteNamespaceDefaults(_ context.Context, userID, namespace string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.namespaceDefaults[userID][namespace]; !ok {
+		return rulestore.ErrNamespaceDefaultsNotFound
+	}
+	delete(m.namespaceDefaults[userID], namespace)
+	return nil
+}
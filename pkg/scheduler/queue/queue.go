@@ -63,14 +63,25 @@ type RequestQueue struct {
 
 	queueLength       *prometheus.GaugeVec   // Per user and reason.
 	discardedRequests *prometheus.CounterVec // Per user.
+
+	spill        SpillConfig
+	spillMetrics SpillMetrics
 }
 
 func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec) *RequestQueue {
+	return NewRequestQueueWithSpill(maxOutstandingPerTenant, forgetDelay, queueLength, discardedRequests, SpillConfig{}, SpillMetrics{})
+}
+
+// NewRequestQueueWithSpill is like NewRequestQueue, but the requests of a tenant whose queue is full
+// wait in the tenant spill queue, if configured, before being rejected.
+func NewRequestQueueWithSpill(maxOutstandingPerTenant int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec, spill SpillConfig, spillMetrics SpillMetrics) *RequestQueue {
 	q := &RequestQueue{
 		queues:                  newUserQueues(maxOutstandingPerTenant, forgetDelay),
 		connectedQuerierWorkers: atomic.NewInt32(0),
 		queueLength:             queueLength,
 		discardedRequests:       discardedRequests,
+		spill:                   spill,
+		spillMetrics:            spillMetrics,
 	}
 
 	q.cond = contextCond{Cond: sync.NewCond(&q.mtx)}
@@ -83,7 +94,10 @@ func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, que
 // this user use (zero or negative = all queriers). It is passed to each EnqueueRequest, because it can change
 // between calls.
 //
-// If request is successfully enqueued, successFn is called with the lock held, before any querier can receive the request.
+// If the tenant queue is full, the request waits in the tenant spill queue, if configured. If the request
+// can't be spilled either, the returned ErrTooManyRequests carries a back-off hint, see RetryAfter.
+//
+// If request is successfully enqueued or spilled, successFn is called with the lock held, before any querier can receive the request.
 func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers int, successFn func()) error {
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
@@ -108,8 +122,15 @@ func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers in
 		}
 		return nil
 	default:
+		if q.spillLocked(userID, req) {
+			if successFn != nil {
+				successFn()
+			}
+			return nil
+		}
+
 		q.discardedRequests.WithLabelValues(userID).Inc()
-		return ErrTooManyRequests
+		return tooManyRequestsError{retryAfter: q.retryAfterLocked(userID)}
 	}
 }
 
@@ -147,6 +168,7 @@ FindQueue:
 		// Pick next request from the queue.
 		for {
 			request := <-queue
+			q.promoteSpilledLocked(userID, queue)
 			if len(queue) == 0 {
 				q.queues.deleteQueue(userID)
 			}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SpillConfig configures the optional per-tenant spill queue, where the requests of a tenant wait
+// for room in the tenant queue once it's full, instead of being rejected right away.
+type SpillConfig struct {
+	// MaxSpilledPerTenant is the maximum number of requests waiting in the spill queue of each tenant. 0 to disable.
+	MaxSpilledPerTenant int
+
+	// MaxWait is the maximum time a request waits in the spill queue.
+	MaxWait time.Duration
+
+	// Expired is called, without holding the queue lock, with each spilled request which hasn't
+	// been moved to the tenant queue within MaxWait. The request is not returned by the queue anymore.
+	Expired func(Request)
+}
+
+func (cfg SpillConfig) enabled() bool {
+	return cfg.MaxSpilledPerTenant > 0
+}
+
+// SpillMetrics holds the per-tenant metrics of the spill queues.
+type SpillMetrics struct {
+	QueueLength     *prometheus.GaugeVec
+	SpilledRequests *prometheus.CounterVec
+	ExpiredRequests *prometheus.CounterVec
+}
+
+type spilledRequest struct {
+	req      Request
+	deadline time.Time
+	timer    *time.Timer
+	promoted bool
+}
+
+// tooManyRequestsError is ErrTooManyRequests with a hint on how long the client should back off.
+type tooManyRequestsError struct {
+	retryAfter time.Duration
+}
+
+func (e tooManyRequestsError) Error() string {
+	return ErrTooManyRequests.Error()
+}
+
+func (e tooManyRequestsError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+// RetryAfter returns how long the client should back off before retrying a request rejected
+// with ErrTooManyRequests, or 0 if unknown.
+func RetryAfter(err error) time.Duration {
+	var tooManyErr tooManyRequestsError
+	if errors.As(err, &tooManyErr) {
+		return tooManyErr.retryAfter
+	}
+	return 0
+}
+
+// spillLocked adds the request to the spill queue of the tenant, whose queue must be full.
+// Returns false if the request can't be spilled. Must be called with the lock held.
+func (q *RequestQueue) spillLocked(userID string, req Request) bool {
+	uq := q.queues.userQueues[userID]
+	if !q.spill.enabled() || uq == nil || len(uq.spilled) >= q.spill.MaxSpilledPerTenant {
+		return false
+	}
+
+	sr := &spilledRequest{req: req, deadline: time.Now().Add(q.spill.MaxWait)}
+	sr.timer = time.AfterFunc(q.spill.MaxWait, func() { q.expireSpilled(userID, sr) })
+	uq.spilled = append(uq.spilled, sr)
+
+	q.spillMetrics.QueueLength.WithLabelValues(userID).Inc()
+	q.spillMetrics.SpilledRequests.WithLabelValues(userID).Inc()
+	return true
+}
+
+// retryAfterLocked returns how long a client should back off before retrying the requests of a
+// tenant whose queue and spill queue are full: by then, the oldest spilled request has left the
+// spill queue. Must be called with the lock held.
+func (q *RequestQueue) retryAfterLocked(userID string) time.Duration {
+	uq := q.queues.userQueues[userID]
+	if !q.spill.enabled() || uq == nil || len(uq.spilled) == 0 {
+		return 0
+	}
+
+	if retryAfter := time.Until(uq.spilled[0].deadline); retryAfter > 0 {
+		return retryAfter
+	}
+	return 0
+}
+
+// promoteSpilledLocked moves the oldest unexpired spilled request of the tenant to its queue,
+// which must have room for it. Must be called with the lock held.
+func (q *RequestQueue) promoteSpilledLocked(userID string, queue chan Request) {
+	uq := q.queues.userQueues[userID]
+	if uq == nil {
+		return
+	}
+
+	for idx, sr := range uq.spilled {
+		// If the timer has already fired, the request is being expired.
+		if !sr.timer.Stop() {
+			continue
+		}
+
+		uq.spilled = append(uq.spilled[:idx], uq.spilled[idx+1:]...)
+		q.spillMetrics.QueueLength.WithLabelValues(userID).Dec()
+
+		sr.promoted = true
+		queue <- sr.req
+		q.queueLength.WithLabelValues(userID).Inc()
+		return
+	}
+}
+
+func (q *RequestQueue) expireSpilled(userID string, sr *spilledRequest) {
+	q.mtx.Lock()
+	if sr.promoted {
+		q.mtx.Unlock()
+		return
+	}
+
+	// The tenant queue may have been deleted meanwhile, together with the spilled requests.
+	if uq := q.queues.userQueues[userID]; uq != nil {
+		for idx, other := range uq.spilled {
+			if other == sr {
+				uq.spilled = append(uq.spilled[:idx], uq.spilled[idx+1:]...)
+				break
+			}
+		}
+	}
+	q.spillMetrics.QueueLength.WithLabelValues(userID).Dec()
+	q.spillMetrics.ExpiredRequests.WithLabelValues(userID).Inc()
+	q.mtx.Unlock()
+
+	if q.spill.Expired != nil {
+		q.spill.Expired(sr.req)
+	}
+}
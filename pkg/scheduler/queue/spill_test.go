@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestQueue_Spill(t *testing.T) {
+	t.Run("should reject requests right away if the spill queue is disabled", func(t *testing.T) {
+		queue, _ := newSpillTestQueue(t, SpillConfig{})
+
+		require.NoError(t, queue.EnqueueRequest("user-1", "request-1", 0, nil))
+
+		err := queue.EnqueueRequest("user-1", "request-2", 0, nil)
+		require.ErrorIs(t, err, ErrTooManyRequests)
+		assert.Equal(t, time.Duration(0), RetryAfter(err))
+	})
+
+	t.Run("should spill requests once the tenant queue is full and move them to the tenant queue in order", func(t *testing.T) {
+		queue, reg := newSpillTestQueue(t, SpillConfig{MaxSpilledPerTenant: 2, MaxWait: time.Minute})
+
+		spilled := 0
+		for _, req := range []string{"request-1", "request-2", "request-3"} {
+			require.NoError(t, queue.EnqueueRequest("user-1", req, 0, func() { spilled++ }))
+		}
+		assert.Equal(t, 3, spilled)
+
+		// Both the tenant queue and the spill queue are full.
+		err := queue.EnqueueRequest("user-1", "request-4", 0, nil)
+		require.ErrorIs(t, err, ErrTooManyRequests)
+		assert.Greater(t, RetryAfter(err), time.Duration(0))
+		assert.LessOrEqual(t, RetryAfter(err), time.Minute)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP spill_queue_length Number of queries waiting in the spill queue.
+			# TYPE spill_queue_length gauge
+			spill_queue_length{user="user-1"} 2
+			# HELP spilled_requests_total Total number of spilled requests.
+			# TYPE spilled_requests_total counter
+			spilled_requests_total{user="user-1"} 2
+		`), "spill_queue_length", "spilled_requests_total"))
+
+		for _, expected := range []string{"request-1", "request-2", "request-3"} {
+			req, _, err := queue.GetNextRequestForQuerier(context.Background(), FirstUser(), "querier-1")
+			require.NoError(t, err)
+			assert.Equal(t, expected, req)
+		}
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP spill_queue_length Number of queries waiting in the spill queue.
+			# TYPE spill_queue_length gauge
+			spill_queue_length{user="user-1"} 0
+		`), "spill_queue_length"))
+	})
+
+	t.Run("should expire requests waiting in the spill queue for longer than the max wait", func(t *testing.T) {
+		expired := make(chan Request, 1)
+		queue, reg := newSpillTestQueue(t, SpillConfig{
+			MaxSpilledPerTenant: 1,
+			MaxWait:             100 * time.Millisecond,
+			Expired:             func(req Request) { expired <- req },
+		})
+
+		require.NoError(t, queue.EnqueueRequest("user-1", "request-1", 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-1", "request-2", 0, nil))
+
+		select {
+		case req := <-expired:
+			assert.Equal(t, "request-2", req)
+		case <-time.After(time.Second):
+			require.FailNow(t, "the spilled request has not expired")
+		}
+
+		req, _, err := queue.GetNextRequestForQuerier(context.Background(), FirstUser(), "querier-1")
+		require.NoError(t, err)
+		assert.Equal(t, "request-1", req)
+
+		// The expired request is not returned by the queue.
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, _, err = queue.GetNextRequestForQuerier(ctx, FirstUser(), "querier-1")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP expired_spilled_requests_total Total number of expired spilled requests.
+			# TYPE expired_spilled_requests_total counter
+			expired_spilled_requests_total{user="user-1"} 1
+			# HELP spill_queue_length Number of queries waiting in the spill queue.
+			# TYPE spill_queue_length gauge
+			spill_queue_length{user="user-1"} 0
+		`), "spill_queue_length", "expired_spilled_requests_total"))
+	})
+}
+
+func newSpillTestQueue(t *testing.T, cfg SpillConfig) (*RequestQueue, *prometheus.Registry) {
+	reg := prometheus.NewPedanticRegistry()
+	queue := NewRequestQueueWithSpill(1, 0,
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+		cfg,
+		SpillMetrics{
+			QueueLength:     promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{Name: "spill_queue_length", Help: "Number of queries waiting in the spill queue."}, []string{"user"}),
+			SpilledRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{Name: "spilled_requests_total", Help: "Total number of spilled requests."}, []string{"user"}),
+			ExpiredRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{Name: "expired_spilled_requests_total", Help: "Total number of expired spilled requests."}, []string{"user"}),
+		})
+
+	ctx := context.Background()
+	require.NoError(t, services.StartAndAwaitRunning(ctx, queue))
+	queue.RegisterQuerierConnection("querier-1")
+
+	t.Cleanup(func() {
+		// The queue waits for the connected queriers to dequeue all the requests before stopping.
+		queue.UnregisterQuerierConnection("querier-1")
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, queue))
+	})
+
+	return queue, reg
+}
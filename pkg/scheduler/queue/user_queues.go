@@ -53,6 +53,9 @@ type queues struct {
 type userQueue struct {
 	ch chan Request
 
+	// Requests waiting for room in ch, oldest first.
+	spilled []*spilledRequest
+
 	// If not nil, only these queriers can handle user requests. If nil, all queriers can.
 	// We set this to nil if number of available queriers <= maxQueriers.
 	queriers    map[string]struct{}
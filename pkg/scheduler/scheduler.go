@@ -68,6 +68,9 @@ type Scheduler struct {
 	// Metrics.
 	queueLength              *prometheus.GaugeVec
 	discardedRequests        *prometheus.CounterVec
+	spillQueueLength         *prometheus.GaugeVec
+	spilledRequests          *prometheus.CounterVec
+	expiredSpilledRequests   *prometheus.CounterVec
 	cancelledRequests        *prometheus.CounterVec
 	connectedQuerierClients  prometheus.GaugeFunc
 	connectedFrontendClients prometheus.GaugeFunc
@@ -92,6 +95,8 @@ type connectedFrontend struct {
 type Config struct {
 	MaxOutstandingPerTenant int                       `yaml:"max_outstanding_requests_per_tenant"`
 	QuerierForgetDelay      time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
+	MaxSpilledPerTenant     int                       `yaml:"max_spilled_requests_per_tenant" category:"experimental"`
+	SpillMaxWait            time.Duration             `yaml:"spill_max_wait" category:"experimental"`
 	GRPCClientConfig        grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
 	ServiceDiscovery        schedulerdiscovery.Config `yaml:",inline"`
 }
@@ -99,11 +104,19 @@ type Config struct {
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per query-scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
+	f.IntVar(&cfg.MaxSpilledPerTenant, "query-scheduler.max-spilled-requests-per-tenant", 0, "Maximum number of requests per tenant per query-scheduler waiting in the spill queue once the tenant queue is full, instead of failing right away with HTTP response status code 429. 0 to disable the spill queue.")
+	f.DurationVar(&cfg.SpillMaxWait, "query-scheduler.spill-max-wait", 5*time.Second, "Maximum time a request waits in the spill queue for room in the tenant queue. Requests waiting longer fail with HTTP response status code 429.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	cfg.ServiceDiscovery.RegisterFlags(f, logger)
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.MaxSpilledPerTenant < 0 {
+		return errors.New("the max spilled requests per tenant must be greater than or equal to 0")
+	}
+	if cfg.MaxSpilledPerTenant > 0 && cfg.SpillMaxWait <= 0 {
+		return errors.New("the spill max wait must be greater than 0 when the spill queue is enabled")
+	}
 	return cfg.ServiceDiscovery.Validate()
 }
 
@@ -134,7 +147,29 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "cortex_query_scheduler_discarded_requests_total",
 		Help: "Total number of query requests discarded.",
 	}, []string{"user"})
-	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests)
+	s.spillQueueLength = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_spill_queue_length",
+		Help: "Number of queries waiting in the spill queue for room in the tenant queue.",
+	}, []string{"user"})
+	s.spilledRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_spilled_requests_total",
+		Help: "Total number of query requests added to the spill queue because the tenant queue was full.",
+	}, []string{"user"})
+	s.expiredSpilledRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_spilled_requests_expired_total",
+		Help: "Total number of query requests which failed because they waited in the spill queue for longer than the max wait.",
+	}, []string{"user"})
+	s.requestQueue = queue.NewRequestQueueWithSpill(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests,
+		queue.SpillConfig{
+			MaxSpilledPerTenant: cfg.MaxSpilledPerTenant,
+			MaxWait:             cfg.SpillMaxWait,
+			Expired:             s.spilledRequestExpired,
+		},
+		queue.SpillMetrics{
+			QueueLength:     s.spillQueueLength,
+			SpilledRequests: s.spilledRequests,
+			ExpiredRequests: s.expiredSpilledRequests,
+		})
 
 	s.queueDuration = promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_query_scheduler_queue_duration_seconds",
@@ -243,7 +278,7 @@ func (s *Scheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_Front
 			case err == nil:
 				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
 			case errors.Is(err, queue.ErrTooManyRequests):
-				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
+				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, RetryAfter: queue.RetryAfter(err)}
 			default:
 				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.ERROR, Error: err.Error()}
 			}
@@ -306,8 +341,17 @@ func (s *Scheduler) frontendDisconnected(frontendAddress string) {
 }
 
 func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr string, msg *schedulerpb.FrontendToScheduler) error {
-	// Create new context for this request, to support cancellation.
-	ctx, cancel := context.WithCancel(frontendContext)
+	// Create new context for this request, to support cancellation. The request is abandoned
+	// by the frontend once its timeout has elapsed, so there's no reason to keep it longer.
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if msg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(frontendContext, msg.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(frontendContext)
+	}
 	shouldCancel := true
 	defer func() {
 		if shouldCancel {
@@ -452,6 +496,8 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 			FrontendAddress: req.frontendAddress,
 			HttpRequest:     req.request,
 			StatsEnabled:    req.statsEnabled,
+			// Propagate the time left to execute the query, excluding the time spent in the queue.
+			Timeout: util.RemainingTimeout(req.ctx),
 		})
 		if err != nil {
 			errCh <- err
@@ -482,18 +528,43 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 }
 
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
+	s.forwardResponseToFrontend(ctx, req, &httpgrpc.HTTPResponse{
+		Code: http.StatusInternalServerError,
+		Body: []byte(requestErr.Error()),
+	})
+}
+
+// spilledRequestExpired fails a request which waited in the spill queue for longer than the max wait.
+func (s *Scheduler) spilledRequestExpired(r queue.Request) {
+	req := r.(*schedulerRequest)
+	req.queueSpan.Finish()
+
+	// The request may have been cancelled meanwhile, e.g. because the frontend gave up on it.
+	if req.ctx.Err() != nil {
+		s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
+		return
+	}
+
+	go func() {
+		defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
+
+		s.forwardResponseToFrontend(req.ctx, req, httpgrpcutil.TooManyRequestsResponse("too many outstanding requests", s.cfg.SpillMaxWait))
+	}()
+}
+
+func (s *Scheduler) forwardResponseToFrontend(ctx context.Context, req *schedulerRequest, resp *httpgrpc.HTTPResponse) {
 	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor},
 		nil)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to forward response", "frontend", req.frontendAddress, "err", err, "status", resp.Code)
 		return
 	}
 
 	conn, err := grpc.DialContext(ctx, req.frontendAddress, opts...)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to forward response", "frontend", req.frontendAddress, "err", err, "status", resp.Code)
 		return
 	}
 
@@ -505,15 +576,12 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 
 	userCtx := user.InjectOrgID(ctx, req.userID)
 	_, err = client.QueryResult(userCtx, &frontendv2pb.QueryResultRequest{
-		QueryID: req.queryID,
-		HttpResponse: &httpgrpc.HTTPResponse{
-			Code: http.StatusInternalServerError,
-			Body: []byte(requestErr.Error()),
-		},
+		QueryID:      req.queryID,
+		HttpResponse: resp,
 	})
 
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to forward error to frontend", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to forward response to frontend", "frontend", req.frontendAddress, "err", err, "status", resp.Code)
 		return
 	}
 }
@@ -572,6 +640,9 @@ func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
 	s.queueLength.DeleteLabelValues(user)
 	s.discardedRequests.DeleteLabelValues(user)
 	s.cancelledRequests.DeleteLabelValues(user)
+	s.spillQueueLength.DeleteLabelValues(user)
+	s.spilledRequests.DeleteLabelValues(user)
+	s.expiredSpilledRequests.DeleteLabelValues(user)
 }
 
 func (s *Scheduler) getConnectedFrontendClientsMetric() float64 {
@@ -36,9 +36,14 @@ import (
 const testMaxOutstandingPerTenant = 5
 
 func setupScheduler(t *testing.T, reg prometheus.Registerer) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
+	return setupSchedulerWithConfig(t, reg, func(*Config) {})
+}
+
+func setupSchedulerWithConfig(t *testing.T, reg prometheus.Registerer, cfgFn func(cfg *Config)) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
 	cfg := Config{}
 	flagext.DefaultValues(&cfg)
 	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
+	cfgFn(&cfg)
 
 	s, err := NewScheduler(cfg, &limits{queriers: 2}, log.NewNopLogger(), reg)
 	require.NoError(t, err)
@@ -123,6 +128,55 @@ func TestSchedulerEnqueueWithCancel(t *testing.T) {
 	verifyNoPendingRequestsLeft(t, scheduler)
 }
 
+func TestSchedulerEnqueueWithTimeout(t *testing.T) {
+	t.Run("should propagate the time left to execute the query to the querier", func(t *testing.T) {
+		scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     1,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+			Timeout:     time.Minute,
+		})
+
+		// Wait some time, to ensure the time spent in the queue is deducted from the timeout.
+		time.Sleep(100 * time.Millisecond)
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), msg.QueryID)
+		require.Greater(t, msg.Timeout, time.Duration(0))
+		require.LessOrEqual(t, msg.Timeout, time.Minute-100*time.Millisecond)
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+
+	t.Run("should not forward the request to the querier once the timeout has elapsed", func(t *testing.T) {
+		scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     1,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+			Timeout:     10 * time.Millisecond,
+		})
+
+		time.Sleep(100 * time.Millisecond)
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		verifyQuerierDoesntReceiveRequest(t, querierLoop, 500*time.Millisecond)
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+}
+
 func initQuerierLoop(t *testing.T, querierClient schedulerpb.SchedulerForQuerierClient, querier string) schedulerpb.SchedulerForQuerier_QuerierLoopClient {
 	querierLoop, err := querierClient.QuerierLoop(context.Background())
 	require.NoError(t, err)
@@ -354,27 +408,7 @@ func TestSchedulerMaxOutstandingRequests(t *testing.T) {
 func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	_, frontendClient, querierClient := setupScheduler(t, nil)
 
-	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
-	frontendAddress := ""
-
-	// Setup frontend grpc server
-	{
-		frontendGrpcServer := grpc.NewServer()
-		frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
-
-		l, err := net.Listen("tcp", "localhost:0")
-		require.NoError(t, err)
-
-		frontendAddress = l.Addr().String()
-
-		go func() {
-			_ = frontendGrpcServer.Serve(l)
-		}()
-
-		t.Cleanup(func() {
-			_ = l.Close()
-		})
-	}
+	fm, frontendAddress := setupFrontendMock(t)
 
 	// After preparations, start frontend and querier.
 	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
@@ -411,6 +445,76 @@ func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	})
 }
 
+func TestSchedulerSpillQueue(t *testing.T) {
+	const maxSpilled = 2
+
+	reg := prometheus.NewPedanticRegistry()
+	_, frontendClient, _ := setupSchedulerWithConfig(t, reg, func(cfg *Config) {
+		cfg.MaxSpilledPerTenant = maxSpilled
+		cfg.SpillMaxWait = 200 * time.Millisecond
+	})
+
+	fm, frontendAddress := setupFrontendMock(t)
+	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
+
+	// Fill both the tenant queue and the spill queue.
+	for i := 0; i < testMaxOutstandingPerTenant+maxSpilled; i++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     uint64(i),
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+	}
+
+	// One more query from the same user is rejected, with a back-off hint.
+	require.NoError(t, frontendLoop.Send(&schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     100,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	}))
+	msg, err := frontendLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, msg.Status)
+	require.Greater(t, msg.RetryAfter, time.Duration(0))
+
+	// No querier is connected, so the spilled queries expire and the frontend is notified.
+	for i := testMaxOutstandingPerTenant; i < testMaxOutstandingPerTenant+maxSpilled; i++ {
+		queryID := uint64(i)
+		test.Poll(t, 2*time.Second, true, func() interface{} {
+			resp := fm.getRequest(queryID)
+			if resp == nil {
+				return false
+			}
+
+			require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+			require.Equal(t, []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"1"}}}, resp.Headers)
+			return true
+		})
+	}
+	require.Nil(t, fm.getRequest(0))
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_queue_length Number of queries in the queue.
+		# TYPE cortex_query_scheduler_queue_length gauge
+		cortex_query_scheduler_queue_length{user="test"} 5
+		# HELP cortex_query_scheduler_spill_queue_length Number of queries waiting in the spill queue for room in the tenant queue.
+		# TYPE cortex_query_scheduler_spill_queue_length gauge
+		cortex_query_scheduler_spill_queue_length{user="test"} 0
+		# HELP cortex_query_scheduler_spilled_requests_total Total number of query requests added to the spill queue because the tenant queue was full.
+		# TYPE cortex_query_scheduler_spilled_requests_total counter
+		cortex_query_scheduler_spilled_requests_total{user="test"} 2
+		# HELP cortex_query_scheduler_spilled_requests_expired_total Total number of query requests which failed because they waited in the spill queue for longer than the max wait.
+		# TYPE cortex_query_scheduler_spilled_requests_expired_total counter
+		cortex_query_scheduler_spilled_requests_expired_total{user="test"} 2
+		# HELP cortex_query_scheduler_discarded_requests_total Total number of query requests discarded.
+		# TYPE cortex_query_scheduler_discarded_requests_total counter
+		cortex_query_scheduler_discarded_requests_total{user="test"} 1
+	`), "cortex_query_scheduler_queue_length", "cortex_query_scheduler_spill_queue_length", "cortex_query_scheduler_spilled_requests_total",
+		"cortex_query_scheduler_spilled_requests_expired_total", "cortex_query_scheduler_discarded_requests_total"))
+}
+
 func TestSchedulerMetrics(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 
@@ -499,6 +603,26 @@ func verifyNoPendingRequestsLeft(t *testing.T, scheduler *Scheduler) {
 	})
 }
 
+func setupFrontendMock(t *testing.T) (*frontendMock, string) {
+	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
+
+	frontendGrpcServer := grpc.NewServer()
+	frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = frontendGrpcServer.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	return fm, l.Addr().String()
+}
+
 type limits struct {
 	queriers int
 }
@@ -62,3 +62,16 @@ func TestRingConfig_CustomConfigToBasicLifecyclerConfig(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expected, actual)
 }
+
+func TestRingConfig_IPv6ConfigToBasicLifecyclerConfig(t *testing.T) {
+	cfg := RingConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.InstanceID = "test"
+	cfg.InstanceAddr = "2001:db8::1"
+	cfg.InstancePort = 9095
+	cfg.EnableIPv6 = true
+
+	actual, err := cfg.ToBasicLifecyclerConfig(log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:9095", actual.Addr)
+}
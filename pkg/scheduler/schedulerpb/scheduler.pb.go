@@ -8,6 +8,8 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
+	_ "github.com/golang/protobuf/ptypes/duration"
 	httpgrpc "github.com/weaveworks/common/httpgrpc"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -18,12 +20,14 @@ import (
 	reflect "reflect"
 	strconv "strconv"
 	strings "strings"
+	time "time"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -139,6 +143,9 @@ type SchedulerToQuerier struct {
 	// Whether query statistics tracking should be enabled. The response will include
 	// statistics only when this option is enabled.
 	StatsEnabled bool `protobuf:"varint,5,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// The time left to execute the query, after which the query has been abandoned by the frontend.
+	// The querier should stop working on the query once it has elapsed. 0 means no timeout.
+	Timeout time.Duration `protobuf:"bytes,6,opt,name=timeout,proto3,stdduration" json:"timeout"`
 }
 
 func (m *SchedulerToQuerier) Reset()      { *m = SchedulerToQuerier{} }
@@ -208,6 +215,13 @@ func (m *SchedulerToQuerier) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *SchedulerToQuerier) GetTimeout() time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
 type FrontendToScheduler struct {
 	Type FrontendToSchedulerType `protobuf:"varint,1,opt,name=type,proto3,enum=schedulerpb.FrontendToSchedulerType" json:"type,omitempty"`
 	// Used by INIT message. Will be put into all requests passed to querier.
@@ -219,6 +233,8 @@ type FrontendToScheduler struct {
 	UserID       string                `protobuf:"bytes,4,opt,name=userID,proto3" json:"userID,omitempty"`
 	HttpRequest  *httpgrpc.HTTPRequest `protobuf:"bytes,5,opt,name=httpRequest,proto3" json:"httpRequest,omitempty"`
 	StatsEnabled bool                  `protobuf:"varint,6,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// The time left to execute the query, after which the query is abandoned by the frontend. 0 means no timeout.
+	Timeout time.Duration `protobuf:"bytes,7,opt,name=timeout,proto3,stdduration" json:"timeout"`
 }
 
 func (m *FrontendToScheduler) Reset()      { *m = FrontendToScheduler{} }
@@ -295,9 +311,18 @@ func (m *FrontendToScheduler) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *FrontendToScheduler) GetTimeout() time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
 type SchedulerToFrontend struct {
 	Status SchedulerToFrontendStatus `protobuf:"varint,1,opt,name=status,proto3,enum=schedulerpb.SchedulerToFrontendStatus" json:"status,omitempty"`
 	Error  string                    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// How long the frontend should back off before retrying, set along with the TOO_MANY_REQUESTS_PER_TENANT status. 0 if unknown.
+	RetryAfter time.Duration `protobuf:"bytes,3,opt,name=retryAfter,proto3,stdduration" json:"retryAfter"`
 }
 
 func (m *SchedulerToFrontend) Reset()      { *m = SchedulerToFrontend{} }
@@ -346,6 +371,13 @@ func (m *SchedulerToFrontend) GetError() string {
 	return ""
 }
 
+func (m *SchedulerToFrontend) GetRetryAfter() time.Duration {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
 type NotifyQuerierShutdownRequest struct {
 	QuerierID string `protobuf:"bytes,1,opt,name=querierID,proto3" json:"querierID,omitempty"`
 }
@@ -438,48 +470,53 @@ func init() {
 func init() { proto.RegisterFile("scheduler.proto", fileDescriptor_2b3fc28395a6d9c5) }
 
 var fileDescriptor_2b3fc28395a6d9c5 = []byte{
-	// 644 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4f, 0x4f, 0xdb, 0x4e,
-	0x10, 0xf5, 0x86, 0x24, 0xc0, 0x84, 0xdf, 0x0f, 0x77, 0x81, 0x36, 0x8d, 0xe8, 0x12, 0x45, 0x55,
-	0x95, 0x72, 0x48, 0xaa, 0xb4, 0x52, 0x7b, 0x40, 0x95, 0x52, 0x30, 0x25, 0x2a, 0x75, 0x60, 0xb3,
-	0x51, 0xff, 0x5c, 0x22, 0x92, 0x2c, 0x09, 0x02, 0xbc, 0x66, 0x6d, 0x17, 0xe5, 0xd6, 0x63, 0x8f,
-	0xfd, 0x18, 0xfd, 0x28, 0xbd, 0x54, 0xe2, 0xc8, 0xa1, 0x87, 0x62, 0x2e, 0x3d, 0xf2, 0x11, 0xaa,
-	0x38, 0x76, 0xea, 0xa4, 0x0e, 0x70, 0x9b, 0x1d, 0xbf, 0xe7, 0x9d, 0xf7, 0x66, 0x66, 0x61, 0xde,
-	0x6a, 0x75, 0x79, 0xdb, 0x39, 0xe2, 0xb2, 0x60, 0x4a, 0x61, 0x0b, 0x9c, 0x1a, 0x26, 0xcc, 0x66,
-	0x66, 0xb1, 0x23, 0x3a, 0xc2, 0xcb, 0x17, 0xfb, 0xd1, 0x00, 0x92, 0x79, 0xd6, 0x39, 0xb0, 0xbb,
-	0x4e, 0xb3, 0xd0, 0x12, 0xc7, 0xc5, 0x53, 0xbe, 0xf7, 0x89, 0x9f, 0x0a, 0x79, 0x68, 0x15, 0x5b,
-	0xe2, 0xf8, 0x58, 0x18, 0xc5, 0xae, 0x6d, 0x9b, 0x1d, 0x69, 0xb6, 0x86, 0xc1, 0x80, 0x95, 0x2b,
-	0x01, 0xde, 0x75, 0xb8, 0x3c, 0xe0, 0x92, 0x89, 0x5a, 0x70, 0x07, 0x5e, 0x86, 0xd9, 0x93, 0x41,
-	0xb6, 0xb2, 0x91, 0x46, 0x59, 0x94, 0x9f, 0xa5, 0x7f, 0x13, 0xb9, 0x1f, 0x08, 0xf0, 0x10, 0xcb,
-	0x84, 0xcf, 0xc7, 0x69, 0x98, 0xee, 0x63, 0x7a, 0x3e, 0x25, 0x4e, 0x83, 0x23, 0x7e, 0x0e, 0xa9,
-	0xfe, 0xb5, 0x94, 0x9f, 0x38, 0xdc, 0xb2, 0xd3, 0xb1, 0x2c, 0xca, 0xa7, 0x4a, 0x4b, 0x85, 0x61,
-	0x29, 0x5b, 0x8c, 0xed, 0xf8, 0x1f, 0x69, 0x18, 0x89, 0xf3, 0x30, 0xbf, 0x2f, 0x85, 0x61, 0x73,
-	0xa3, 0x5d, 0x6e, 0xb7, 0x25, 0xb7, 0xac, 0xf4, 0x94, 0x57, 0xcd, 0x78, 0x1a, 0xdf, 0x85, 0xa4,
-	0x63, 0x79, 0xe5, 0xc6, 0x3d, 0x80, 0x7f, 0xc2, 0x39, 0x98, 0xb3, 0xec, 0x3d, 0xdb, 0xd2, 0x8c,
-	0xbd, 0xe6, 0x11, 0x6f, 0xa7, 0x13, 0x59, 0x94, 0x9f, 0xa1, 0x23, 0xb9, 0xdc, 0x97, 0x18, 0x2c,
-	0x6c, 0xfa, 0xff, 0x0b, 0xbb, 0xf0, 0x02, 0xe2, 0x76, 0xcf, 0xe4, 0x9e, 0x9a, 0xff, 0x4b, 0x0f,
-	0x0b, 0xa1, 0x1e, 0x14, 0x22, 0xf0, 0xac, 0x67, 0x72, 0xea, 0x31, 0xa2, 0xea, 0x8e, 0x45, 0xd7,
-	0x1d, 0x32, 0x6d, 0x6a, 0xd4, 0xb4, 0x49, 0x8a, 0xc6, 0xcc, 0x4c, 0xdc, 0xda, 0xcc, 0x71, 0x2b,
-	0x92, 0x11, 0x56, 0x1c, 0xc2, 0x42, 0xa8, 0xb3, 0x81, 0x48, 0xfc, 0x12, 0x92, 0x7d, 0x98, 0x63,
-	0xf9, 0x5e, 0x3c, 0x1a, 0xf1, 0x22, 0x82, 0x51, 0xf3, 0xd0, 0xd4, 0x67, 0xe1, 0x45, 0x48, 0x70,
-	0x29, 0x85, 0xf4, 0x5d, 0x18, 0x1c, 0x72, 0x6b, 0xb0, 0xac, 0x0b, 0xfb, 0x60, 0xbf, 0xe7, 0x4f,
-	0x50, 0xad, 0xeb, 0xd8, 0x6d, 0x71, 0x6a, 0x04, 0x05, 0x5f, 0x3f, 0x85, 0x2b, 0xf0, 0x60, 0x02,
-	0xdb, 0x32, 0x85, 0x61, 0xf1, 0xd5, 0x35, 0xb8, 0x37, 0xa1, 0x4b, 0x78, 0x06, 0xe2, 0x15, 0xbd,
-	0xc2, 0x54, 0x05, 0xa7, 0x60, 0x5a, 0xd3, 0x77, 0xeb, 0x5a, 0x5d, 0x53, 0x11, 0x06, 0x48, 0xae,
-	0x97, 0xf5, 0x75, 0x6d, 0x5b, 0x8d, 0xad, 0xb6, 0xe0, 0xfe, 0x44, 0x5d, 0x38, 0x09, 0xb1, 0xea,
-	0x1b, 0x55, 0xc1, 0x59, 0x58, 0x66, 0xd5, 0x6a, 0xe3, 0x6d, 0x59, 0xff, 0xd0, 0xa0, 0xda, 0x6e,
-	0x5d, 0xab, 0xb1, 0x5a, 0x63, 0x47, 0xa3, 0x0d, 0xa6, 0xe9, 0x65, 0x9d, 0xa9, 0x08, 0xcf, 0x42,
-	0x42, 0xa3, 0xb4, 0x4a, 0xd5, 0x18, 0xbe, 0x03, 0xff, 0xd5, 0xb6, 0xea, 0x8c, 0x55, 0xf4, 0xd7,
-	0x8d, 0x8d, 0xea, 0x3b, 0x5d, 0x9d, 0x2a, 0xfd, 0x44, 0x21, 0xbf, 0x37, 0x85, 0x0c, 0x56, 0xa9,
-	0x0e, 0x29, 0x3f, 0xdc, 0x16, 0xc2, 0xc4, 0x2b, 0x23, 0x76, 0xff, 0xbb, 0xaf, 0x99, 0x95, 0x49,
-	0xfd, 0xf0, 0xb1, 0x39, 0x25, 0x8f, 0x9e, 0x20, 0x6c, 0xc0, 0x52, 0xa4, 0x65, 0xf8, 0xf1, 0x08,
-	0xff, 0xba, 0xa6, 0x64, 0x56, 0x6f, 0x03, 0x1d, 0x74, 0xa0, 0x64, 0xc2, 0x62, 0x58, 0xdd, 0x70,
-	0x9c, 0xde, 0xc3, 0x5c, 0x10, 0x7b, 0xfa, 0xb2, 0x37, 0xad, 0x56, 0x26, 0x7b, 0xd3, 0xc0, 0x0d,
-	0x14, 0xbe, 0x2a, 0x9f, 0x5d, 0x10, 0xe5, 0xfc, 0x82, 0x28, 0x57, 0x17, 0x04, 0x7d, 0x76, 0x09,
-	0xfa, 0xe6, 0x12, 0xf4, 0xdd, 0x25, 0xe8, 0xcc, 0x25, 0xe8, 0x97, 0x4b, 0xd0, 0x6f, 0x97, 0x28,
-	0x57, 0x2e, 0x41, 0x5f, 0x2f, 0x89, 0x72, 0x76, 0x49, 0x94, 0xf3, 0x4b, 0xa2, 0x7c, 0x0c, 0xbf,
-	0xae, 0xcd, 0xa4, 0xf7, 0x30, 0x3e, 0xfd, 0x13, 0x00, 0x00, 0xff, 0xff, 0x88, 0x0c, 0xfe, 0x56,
-	0x84, 0x05, 0x00, 0x00,
+	// 726 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x95, 0xcd, 0x4e, 0xdb, 0x4a,
+	0x14, 0xc7, 0x3d, 0x21, 0x1f, 0x70, 0xc2, 0xbd, 0xe4, 0x0e, 0x70, 0x6f, 0x88, 0xb8, 0x93, 0x28,
+	0xaa, 0xaa, 0x94, 0x85, 0x53, 0xa5, 0x95, 0xda, 0x05, 0xad, 0x14, 0xc0, 0x94, 0xa8, 0xd4, 0x01,
+	0xc7, 0x51, 0x3f, 0x36, 0x51, 0x3e, 0x26, 0x1f, 0x2a, 0xf1, 0x98, 0xf1, 0xb8, 0x28, 0xbb, 0x3e,
+	0x40, 0x17, 0x5d, 0xf6, 0x11, 0xba, 0xec, 0x5b, 0x94, 0x25, 0x4b, 0x16, 0x55, 0x5b, 0xc2, 0xa6,
+	0x4b, 0x1e, 0xa1, 0x8a, 0x63, 0xa7, 0x0e, 0x4d, 0x00, 0x75, 0x37, 0xe7, 0xf8, 0xff, 0x97, 0xcf,
+	0xf9, 0x9d, 0x33, 0x36, 0x2c, 0x58, 0xf5, 0x36, 0x6d, 0xd8, 0x07, 0x94, 0xcb, 0x26, 0x67, 0x82,
+	0xe1, 0xe8, 0x28, 0x61, 0xd6, 0x12, 0x4b, 0x2d, 0xd6, 0x62, 0x4e, 0x3e, 0x3b, 0x38, 0x0d, 0x25,
+	0x09, 0xd2, 0x62, 0xac, 0x75, 0x40, 0xb3, 0x4e, 0x54, 0xb3, 0x9b, 0xd9, 0x86, 0xcd, 0xab, 0xa2,
+	0xc3, 0x0c, 0xf7, 0xf9, 0xfd, 0x56, 0x47, 0xb4, 0xed, 0x9a, 0x5c, 0x67, 0xdd, 0xec, 0x11, 0xad,
+	0xbe, 0xa1, 0x47, 0x8c, 0xbf, 0xb6, 0xb2, 0x75, 0xd6, 0xed, 0x32, 0x23, 0xdb, 0x16, 0xc2, 0x6c,
+	0x71, 0xb3, 0x3e, 0x3a, 0x0c, 0x5d, 0xe9, 0x1c, 0xe0, 0x7d, 0x9b, 0xf2, 0x0e, 0xe5, 0x3a, 0x2b,
+	0x79, 0x35, 0xe0, 0x55, 0x98, 0x3b, 0x1c, 0x66, 0x0b, 0x5b, 0x71, 0x94, 0x42, 0x99, 0x39, 0xed,
+	0x57, 0x22, 0xfd, 0x2e, 0x00, 0x78, 0xa4, 0xd5, 0x99, 0xeb, 0xc7, 0x71, 0x88, 0x0c, 0x34, 0x3d,
+	0xd7, 0x12, 0xd4, 0xbc, 0x10, 0x3f, 0x80, 0xe8, 0xe0, 0xb5, 0x1a, 0x3d, 0xb4, 0xa9, 0x25, 0xe2,
+	0x81, 0x14, 0xca, 0x44, 0x73, 0xcb, 0xf2, 0xa8, 0x94, 0x1d, 0x5d, 0xdf, 0x73, 0x1f, 0x6a, 0x7e,
+	0x25, 0xce, 0xc0, 0x42, 0x93, 0x33, 0x43, 0x50, 0xa3, 0x91, 0x6f, 0x34, 0x38, 0xb5, 0xac, 0xf8,
+	0x8c, 0x53, 0xcd, 0xe5, 0x34, 0xfe, 0x17, 0xc2, 0xb6, 0xe5, 0x94, 0x1b, 0x74, 0x04, 0x6e, 0x84,
+	0xd3, 0x30, 0x6f, 0x89, 0xaa, 0xb0, 0x14, 0xa3, 0x5a, 0x3b, 0xa0, 0x8d, 0x78, 0x28, 0x85, 0x32,
+	0xb3, 0xda, 0x58, 0x0e, 0x3f, 0x82, 0x88, 0xe8, 0x74, 0x29, 0xb3, 0x45, 0x3c, 0xec, 0x94, 0xb6,
+	0x22, 0x0f, 0x59, 0xcb, 0x1e, 0x6b, 0x79, 0xcb, 0x65, 0xbd, 0x31, 0x7b, 0xfc, 0x35, 0x29, 0x7d,
+	0xf8, 0x96, 0x44, 0x9a, 0xe7, 0x49, 0x7f, 0x0e, 0xc0, 0xe2, 0xb6, 0x5b, 0x8e, 0x1f, 0xe2, 0x43,
+	0x08, 0x8a, 0x9e, 0x49, 0x1d, 0x18, 0x7f, 0xe7, 0x6e, 0xc9, 0xbe, 0x11, 0xcb, 0x13, 0xf4, 0x7a,
+	0xcf, 0xa4, 0x9a, 0xe3, 0x98, 0xd4, 0x76, 0x60, 0x72, 0xdb, 0x3e, 0xe6, 0x33, 0xe3, 0xcc, 0xa7,
+	0x01, 0xb9, 0x34, 0x8b, 0xd0, 0x8d, 0x67, 0x71, 0x99, 0x64, 0xf8, 0x6a, 0x92, 0x91, 0x3f, 0x20,
+	0xf9, 0x09, 0xc1, 0xa2, 0x6f, 0xb1, 0x3c, 0x48, 0xf8, 0x31, 0x84, 0x07, 0xaf, 0xb1, 0x2d, 0x97,
+	0xe5, 0xed, 0x31, 0x96, 0x13, 0x1c, 0x25, 0x47, 0xad, 0xb9, 0x2e, 0xbc, 0x04, 0x21, 0xca, 0x39,
+	0xe3, 0x2e, 0xc5, 0x61, 0x80, 0x37, 0x01, 0x38, 0x15, 0xbc, 0x97, 0x6f, 0x0a, 0xca, 0x1d, 0x7c,
+	0x37, 0xac, 0xd7, 0x67, 0x4b, 0xaf, 0xc3, 0xaa, 0xca, 0x44, 0xa7, 0xd9, 0x73, 0x6f, 0x41, 0xa9,
+	0x6d, 0x8b, 0x06, 0x3b, 0x32, 0x3c, 0x6a, 0x57, 0xdf, 0xa4, 0x24, 0xfc, 0x3f, 0xc5, 0x6d, 0x99,
+	0xcc, 0xb0, 0xe8, 0xda, 0x3a, 0xfc, 0x37, 0x65, 0x55, 0xf0, 0x2c, 0x04, 0x0b, 0x6a, 0x41, 0x8f,
+	0x49, 0x38, 0x0a, 0x11, 0x45, 0xdd, 0x2f, 0x2b, 0x65, 0x25, 0x86, 0x30, 0x40, 0x78, 0x33, 0xaf,
+	0x6e, 0x2a, 0xbb, 0xb1, 0xc0, 0x5a, 0x1d, 0x56, 0xa6, 0xc2, 0xc1, 0x61, 0x08, 0x14, 0x9f, 0xc6,
+	0x24, 0x9c, 0x82, 0x55, 0xbd, 0x58, 0xac, 0x3c, 0xcb, 0xab, 0x2f, 0x2b, 0x9a, 0xb2, 0x5f, 0x56,
+	0x4a, 0x7a, 0xa9, 0xb2, 0xa7, 0x68, 0x15, 0x5d, 0x51, 0xf3, 0xaa, 0x1e, 0x43, 0x78, 0x0e, 0x42,
+	0x8a, 0xa6, 0x15, 0xb5, 0x58, 0x00, 0xff, 0x03, 0x7f, 0x95, 0x76, 0xca, 0xba, 0x5e, 0x50, 0x9f,
+	0x54, 0xb6, 0x8a, 0xcf, 0xd5, 0xd8, 0x4c, 0xee, 0x8b, 0x7f, 0x68, 0xdb, 0x8c, 0x7b, 0x9f, 0x83,
+	0x32, 0x44, 0xdd, 0xe3, 0x2e, 0x63, 0x26, 0x4e, 0x8e, 0xcd, 0xec, 0xf7, 0x6f, 0x4e, 0x22, 0x39,
+	0x6d, 0xa8, 0xae, 0x36, 0x2d, 0x65, 0xd0, 0x5d, 0x84, 0x0d, 0x58, 0x9e, 0x88, 0x0c, 0xdf, 0x19,
+	0xf3, 0x5f, 0x35, 0x94, 0xc4, 0xda, 0x4d, 0xa4, 0xc3, 0x09, 0xe4, 0x4c, 0x58, 0xf2, 0x77, 0x37,
+	0xda, 0xc9, 0x17, 0x30, 0xef, 0x9d, 0x9d, 0xfe, 0x52, 0xd7, 0xdd, 0xef, 0x44, 0xea, 0xba, 0xad,
+	0x1d, 0x76, 0xb8, 0x91, 0x3f, 0x39, 0x23, 0xd2, 0xe9, 0x19, 0x91, 0x2e, 0xce, 0x08, 0x7a, 0xdb,
+	0x27, 0xe8, 0x63, 0x9f, 0xa0, 0xe3, 0x3e, 0x41, 0x27, 0x7d, 0x82, 0xbe, 0xf7, 0x09, 0xfa, 0xd1,
+	0x27, 0xd2, 0x45, 0x9f, 0xa0, 0xf7, 0xe7, 0x44, 0x3a, 0x39, 0x27, 0xd2, 0xe9, 0x39, 0x91, 0x5e,
+	0xf9, 0xff, 0x20, 0xb5, 0xb0, 0xb3, 0xbe, 0xf7, 0x7e, 0x06, 0x00, 0x00, 0xff, 0xff, 0xe5, 0x2a,
+	0xd2, 0xd4, 0x68, 0x06, 0x00, 0x00,
 }
 
 func (x FrontendToSchedulerType) String() string {
@@ -554,6 +591,9 @@ func (this *SchedulerToQuerier) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.Timeout != that1.Timeout {
+		return false
+	}
 	return true
 }
 func (this *FrontendToScheduler) Equal(that interface{}) bool {
@@ -593,6 +633,9 @@ func (this *FrontendToScheduler) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.Timeout != that1.Timeout {
+		return false
+	}
 	return true
 }
 func (this *SchedulerToFrontend) Equal(that interface{}) bool {
@@ -620,6 +663,9 @@ func (this *SchedulerToFrontend) Equal(that interface{}) bool {
 	if this.Error != that1.Error {
 		return false
 	}
+	if this.RetryAfter != that1.RetryAfter {
+		return false
+	}
 	return true
 }
 func (this *NotifyQuerierShutdownRequest) Equal(that interface{}) bool {
@@ -681,7 +727,7 @@ func (this *SchedulerToQuerier) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&schedulerpb.SchedulerToQuerier{")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	if this.HttpRequest != nil {
@@ -690,6 +736,7 @@ func (this *SchedulerToQuerier) GoString() string {
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
 	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "Timeout: "+fmt.Sprintf("%#v", this.Timeout)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -697,7 +744,7 @@ func (this *FrontendToScheduler) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 10)
+	s := make([]string, 0, 11)
 	s = append(s, "&schedulerpb.FrontendToScheduler{")
 	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
@@ -707,6 +754,7 @@ func (this *FrontendToScheduler) GoString() string {
 		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
 	}
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "Timeout: "+fmt.Sprintf("%#v", this.Timeout)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -714,10 +762,11 @@ func (this *SchedulerToFrontend) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&schedulerpb.SchedulerToFrontend{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "RetryAfter: "+fmt.Sprintf("%#v", this.RetryAfter)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1078,6 +1127,14 @@ func (m *SchedulerToQuerier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintScheduler(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x32
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1142,6 +1199,14 @@ func (m *FrontendToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err3 != nil {
+		return 0, err3
+	}
+	i -= n3
+	i = encodeVarintScheduler(dAtA, i, uint64(n3))
+	i--
+	dAtA[i] = 0x3a
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1211,6 +1276,14 @@ func (m *SchedulerToFrontend) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.RetryAfter, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.RetryAfter):])
+	if err5 != nil {
+		return 0, err5
+	}
+	i -= n5
+	i = encodeVarintScheduler(dAtA, i, uint64(n5))
+	i--
+	dAtA[i] = 0x1a
 	if len(m.Error) > 0 {
 		i -= len(m.Error)
 		copy(dAtA[i:], m.Error)
@@ -1327,6 +1400,8 @@ func (m *SchedulerToQuerier) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovScheduler(uint64(l))
 	return n
 }
 
@@ -1357,6 +1432,8 @@ func (m *FrontendToScheduler) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovScheduler(uint64(l))
 	return n
 }
 
@@ -1373,6 +1450,8 @@ func (m *SchedulerToFrontend) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovScheduler(uint64(l))
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.RetryAfter)
+	n += 1 + l + sovScheduler(uint64(l))
 	return n
 }
 
@@ -1424,6 +1503,7 @@ func (this *SchedulerToQuerier) String() string {
 		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`Timeout:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timeout), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1439,6 +1519,7 @@ func (this *FrontendToScheduler) String() string {
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`HttpRequest:` + strings.Replace(fmt.Sprintf("%v", this.HttpRequest), "HTTPRequest", "httpgrpc.HTTPRequest", 1) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`Timeout:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timeout), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1450,6 +1531,7 @@ func (this *SchedulerToFrontend) String() string {
 	s := strings.Join([]string{`&SchedulerToFrontend{`,
 		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`RetryAfter:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.RetryAfter), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1734,6 +1816,39 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -1945,6 +2060,39 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -2049,6 +2197,39 @@ func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
 			}
 			m.Error = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetryAfter", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.RetryAfter, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
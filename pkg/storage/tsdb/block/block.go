@@ -42,7 +42,8 @@ const (
 
 // Download downloads directory that is meant to be block directory. If any of the files
 // have a hash calculated in the meta file and it matches with what is in the destination path then
-// we do not download it. We always re-download the meta file.
+// we do not download it. We always re-download the meta file. The downloaded files are verified
+// against the digests stored in the meta file, if any, and ErrDigestMismatch is returned on mismatch.
 func Download(ctx context.Context, logger log.Logger, bucket objstore.Bucket, id ulid.ULID, dst string, options ...objstore.DownloadOption) error {
 	if err := os.MkdirAll(dst, 0750); err != nil {
 		return errors.Wrap(err, "create dir")
@@ -57,8 +58,16 @@ func Download(ctx context.Context, logger log.Logger, bucket objstore.Bucket, id
 		return err
 	}
 
+	meta, err := ReadMetaFromDir(dst)
+	if err != nil {
+		return errors.Wrap(err, "read meta")
+	}
+	if err := VerifyFileDigests(dst, meta.Thanos.Files); err != nil {
+		return err
+	}
+
 	chunksDir := filepath.Join(dst, ChunksDirname)
-	_, err := os.Stat(chunksDir)
+	_, err = os.Stat(chunksDir)
 	if os.IsNotExist(err) {
 		// This can happen if block is empty. We cannot easily upload empty directory, so create one here.
 		return os.Mkdir(chunksDir, os.ModePerm)
@@ -284,6 +293,7 @@ func GetSegmentFiles(blockDir string) []string {
 }
 
 // GatherFileStats returns File entry for files inside TSDB block (index, chunks, meta.json).
+// The digest of the index and chunks files is computed by reading them.
 func GatherFileStats(blockDir string) (res []File, _ error) {
 	files, err := os.ReadDir(filepath.Join(blockDir, ChunksDirname))
 	if err != nil {
@@ -295,9 +305,15 @@ func GatherFileStats(blockDir string) (res []File, _ error) {
 			return nil, errors.Wrapf(err, "getting file info %v", filepath.Join(ChunksDirname, f.Name()))
 		}
 
+		digest, err := fileCRC32C(filepath.Join(blockDir, ChunksDirname, f.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "computing digest of %v", filepath.Join(ChunksDirname, f.Name()))
+		}
+
 		mf := File{
 			RelPath:   filepath.Join(ChunksDirname, f.Name()),
 			SizeBytes: fi.Size(),
+			CRC32C:    digest,
 		}
 		res = append(res, mf)
 	}
@@ -306,9 +322,14 @@ func GatherFileStats(blockDir string) (res []File, _ error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, IndexFilename))
 	}
+	indexDigest, err := fileCRC32C(filepath.Join(blockDir, IndexFilename))
+	if err != nil {
+		return nil, errors.Wrapf(err, "computing digest of %v", filepath.Join(blockDir, IndexFilename))
+	}
 	mf := File{
 		RelPath:   indexFile.Name(),
 		SizeBytes: indexFile.Size(),
+		CRC32C:    indexDigest,
 	}
 	res = append(res, mf)
 
@@ -207,7 +207,7 @@ func TestUpload(t *testing.T) {
 		chunkFileSize := getFileSize(t, filepath.Join(tmpDir, b1.String(), ChunksDirname, "000001"))
 		require.Equal(t, chunkFileSize, int64(len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")])))
 		require.Equal(t, 401, len(bkt.Objects()[path.Join(b1.String(), IndexFilename)]))
-		require.Equal(t, 568, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
+		require.Equal(t, 620, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
 
 		origMeta, err := ReadMetaFromDir(path.Join(tmpDir, "test", b1.String()))
 		require.NoError(t, err)
@@ -217,8 +217,8 @@ func TestUpload(t *testing.T) {
 
 		files := uploadedMeta.Thanos.Files
 		require.Len(t, files, 3)
-		require.Equal(t, File{RelPath: "chunks/000001", SizeBytes: chunkFileSize}, files[0])
-		require.Equal(t, File{RelPath: "index", SizeBytes: 401}, files[1])
+		require.Equal(t, File{RelPath: "chunks/000001", SizeBytes: chunkFileSize, CRC32C: testCRC32C(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")])}, files[0])
+		require.Equal(t, File{RelPath: "index", SizeBytes: 401, CRC32C: testCRC32C(bkt.Objects()[path.Join(b1.String(), IndexFilename)])}, files[1])
 		require.Equal(t, File{RelPath: "meta.json", SizeBytes: 0}, files[2]) // meta.json is added to the files without its size.
 
 		// clear files before comparing against original meta.json
@@ -234,7 +234,7 @@ func TestUpload(t *testing.T) {
 		chunkFileSize := getFileSize(t, filepath.Join(tmpDir, b1.String(), ChunksDirname, "000001"))
 		require.Equal(t, chunkFileSize, int64(len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")])))
 		require.Equal(t, 401, len(bkt.Objects()[path.Join(b1.String(), IndexFilename)]))
-		require.Equal(t, 568, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
+		require.Equal(t, 620, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
 	})
 
 	t.Run("upload with no external labels works just fine", func(t *testing.T) {
@@ -255,7 +255,7 @@ func TestUpload(t *testing.T) {
 		require.Equal(t, 6, len(bkt.Objects())) // 3 from b1, 3 from b2
 		require.Equal(t, chunkFileSize, int64(len(bkt.Objects()[path.Join(b2.String(), ChunksDirname, "000001")])))
 		require.Equal(t, 401, len(bkt.Objects()[path.Join(b2.String(), IndexFilename)]))
-		require.Equal(t, 547, len(bkt.Objects()[path.Join(b2.String(), MetaFilename)]))
+		require.Equal(t, 599, len(bkt.Objects()[path.Join(b2.String(), MetaFilename)]))
 
 		origMeta, err := ReadMetaFromDir(path.Join(tmpDir, b2.String()))
 		require.NoError(t, err)
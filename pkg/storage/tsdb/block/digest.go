@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrDigestMismatch is returned when the content of a block file doesn't match the digest stored in the block meta.
+	ErrDigestMismatch = errors.New("block file digest mismatch")
+)
+
+// VerifyFileDigests verifies the files of a local block directory against the digests stored in the block meta.
+// Files without a digest (e.g. uploaded before digests were introduced) are not verified.
+func VerifyFileDigests(blockDir string, files []File) error {
+	for _, f := range files {
+		if f.CRC32C == "" {
+			continue
+		}
+
+		actual, err := fileCRC32C(filepath.Join(blockDir, filepath.FromSlash(f.RelPath)))
+		if err != nil {
+			return errors.Wrapf(err, "compute digest of %s", f.RelPath)
+		}
+		if actual != f.CRC32C {
+			return digestMismatchError(f, actual)
+		}
+	}
+	return nil
+}
+
+// VerifyObjectDigest verifies a file of the block in the bucket against the digest stored in the block meta.
+// The whole object is read from the bucket. Files without a digest are not verified.
+func VerifyObjectDigest(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, f File) (err error) {
+	if f.CRC32C == "" {
+		return nil
+	}
+
+	rc, err := bkt.Get(ctx, path.Join(id.String(), f.RelPath))
+	if err != nil {
+		return errors.Wrapf(err, "get %s", f.RelPath)
+	}
+	defer runutil.CloseWithErrCapture(&err, rc, "close %s", f.RelPath)
+
+	actual, err := readerCRC32C(rc)
+	if err != nil {
+		return errors.Wrapf(err, "compute digest of %s", f.RelPath)
+	}
+	if actual != f.CRC32C {
+		return digestMismatchError(f, actual)
+	}
+	return nil
+}
+
+func digestMismatchError(f File, actual string) error {
+	return errors.Wrapf(ErrDigestMismatch, "%s: expected crc32c %s, got %s", f.RelPath, f.CRC32C, actual)
+}
+
+// fileCRC32C returns the hex-encoded CRC32C of the file content.
+func fileCRC32C(filename string) (_ string, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close %s", filename)
+
+	return readerCRC32C(f)
+}
+
+func readerCRC32C(r io.Reader) (string, error) {
+	h := crc32.New(castagnoliTable)
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08x", h.Sum32()), nil
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestDownload_ShouldVerifyFileDigests(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	bkt := objstore.NewInMemBucket()
+	id, err := CreateBlock(ctx, tmpDir, fiveLabels, 100, 0, 1000, labels.FromStrings("ext1", "val1"))
+	require.NoError(t, err)
+	require.NoError(t, Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, id.String()), nil))
+
+	t.Run("should succeed on intact block", func(t *testing.T) {
+		require.NoError(t, Download(ctx, log.NewNopLogger(), bkt, id, filepath.Join(t.TempDir(), id.String())))
+	})
+
+	t.Run("should fail on corrupted block file", func(t *testing.T) {
+		corruptObject(t, bkt, path.Join(id.String(), ChunksDirname, "000001"))
+
+		err := Download(ctx, log.NewNopLogger(), bkt, id, filepath.Join(t.TempDir(), id.String()))
+		require.ErrorIs(t, err, ErrDigestMismatch)
+		assert.Contains(t, err.Error(), "chunks/000001")
+	})
+}
+
+func TestVerifyFileDigests(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	id, err := CreateBlock(ctx, tmpDir, fiveLabels, 100, 0, 1000, labels.EmptyLabels())
+	require.NoError(t, err)
+	blockDir := filepath.Join(tmpDir, id.String())
+
+	files, err := GatherFileStats(blockDir)
+	require.NoError(t, err)
+	require.NoError(t, VerifyFileDigests(blockDir, files))
+
+	// Files without digest are not verified.
+	withoutDigests := make([]File, 0, len(files))
+	for _, f := range files {
+		withoutDigests = append(withoutDigests, File{RelPath: f.RelPath, SizeBytes: f.SizeBytes})
+	}
+	require.NoError(t, VerifyFileDigests(blockDir, withoutDigests))
+
+	files[0].CRC32C = "00000000"
+	require.ErrorIs(t, VerifyFileDigests(blockDir, files), ErrDigestMismatch)
+}
+
+func TestVerifyObjectDigest(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	id := ulid.MustNew(1, nil)
+
+	content := []byte("index content")
+	require.NoError(t, bkt.Upload(ctx, path.Join(id.String(), IndexFilename), bytes.NewReader(content)))
+
+	assert.NoError(t, VerifyObjectDigest(ctx, bkt, id, File{RelPath: IndexFilename, CRC32C: testCRC32C(content)}))
+	assert.NoError(t, VerifyObjectDigest(ctx, bkt, id, File{RelPath: IndexFilename}))
+	assert.ErrorIs(t, VerifyObjectDigest(ctx, bkt, id, File{RelPath: IndexFilename, CRC32C: "00000000"}), ErrDigestMismatch)
+	assert.Error(t, VerifyObjectDigest(ctx, bkt, id, File{RelPath: "missing", CRC32C: "00000000"}))
+}
+
+// corruptObject flips a byte of the object in the bucket, keeping its size.
+func corruptObject(t *testing.T, bkt *objstore.InMemBucket, name string) {
+	content := append([]byte(nil), bkt.Objects()[name]...)
+	require.NotEmpty(t, content)
+	content[len(content)/2] ^= 0xff
+	require.NoError(t, bkt.Upload(context.Background(), name, bytes.NewReader(content)))
+}
+
+func testCRC32C(content []byte) string {
+	return fmt.Sprintf("%08x", crc32.Checksum(content, castagnoliTable))
+}
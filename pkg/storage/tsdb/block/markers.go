@@ -72,6 +72,15 @@ const (
 	IndexSizeExceedingNoCompactReason = "index-size-exceeding"
 	// OutOfOrderChunksNoCompactReason is a reason of to no compact block with index contains out of order chunk so that the compaction is not blocked.
 	OutOfOrderChunksNoCompactReason = "block-index-out-of-order-chunk"
+	// DigestMismatchNoCompactReason is a reason to not compact a block whose files don't match the digests stored in its meta.json,
+	// so that the compaction is not blocked by a block corrupted in the object storage.
+	DigestMismatchNoCompactReason = "block-digest-mismatch"
+	// InvalidNativeHistogramsNoCompactReason is a reason to not compact a block with invalid native histogram samples,
+	// so that the compaction is not blocked by a block whose compacted native histograms would fail the verification.
+	InvalidNativeHistogramsNoCompactReason = "block-invalid-native-histograms"
+	// InvalidCompactedIndexNoCompactReason is a reason to not compact the source blocks of a compacted block whose
+	// index is invalid, so that the compaction is not blocked by source blocks which are compacted to an invalid block.
+	InvalidCompactedIndexNoCompactReason = "block-invalid-compacted-index"
 )
 
 // NoCompactMark marker stores reason of block being excluded from compaction if needed.
@@ -89,6 +89,8 @@ type File struct {
 	RelPath string `json:"rel_path"`
 	// SizeBytes is optional (e.g meta.json does not show size).
 	SizeBytes int64 `json:"size_bytes,omitempty"`
+	// CRC32C is the hex-encoded CRC32C (Castagnoli) of the file content. Optional (e.g meta.json does not have it).
+	CRC32C string `json:"crc32c,omitempty"`
 
 	// The json field "hash" is reserved because it is used by Thanos for the file hash.
 }
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"path/filepath"
+
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+)
+
+// NativeHistogramStats holds statistics about the native histogram chunks of a block.
+type NativeHistogramStats struct {
+	// Chunks is the number of integer and float histogram chunks.
+	Chunks int64
+	// Bytes is the size of the histogram chunks data.
+	Bytes int64
+	// Samples is the number of histogram samples.
+	Samples int64
+	// UnknownCounterResetChunks is the number of histogram chunks whose counter reset header is unknown, meaning that
+	// it's not known whether the first sample of the chunk is a counter reset.
+	UnknownCounterResetChunks int64
+}
+
+// Add adds the other stats to s.
+func (s *NativeHistogramStats) Add(other NativeHistogramStats) {
+	s.Chunks += other.Chunks
+	s.Bytes += other.Bytes
+	s.Samples += other.Samples
+	s.UnknownCounterResetChunks += other.UnknownCounterResetChunks
+}
+
+// GatherNativeHistogramStats reads all the chunks of the block and returns statistics about its native histogram
+// chunks. The samples of the histogram chunks are validated, and an error is returned if any of them is invalid.
+// The float chunks are read, but not decoded.
+func GatherNativeHistogramStats(blockDir string) (stats NativeHistogramStats, err error) {
+	r, err := index.NewFileReader(filepath.Join(blockDir, IndexFilename))
+	if err != nil {
+		return stats, errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "gather native histogram stats index reader")
+
+	cr, err := chunks.NewDirReader(filepath.Join(blockDir, ChunksDirname), nil)
+	if err != nil {
+		return stats, errors.Wrap(err, "open chunks dir")
+	}
+	defer runutil.CloseWithErrCapture(&err, cr, "gather native histogram stats chunks reader")
+
+	p, err := r.Postings(index.AllPostingsKey())
+	if err != nil {
+		return stats, errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	for p.Next() {
+		if err := r.Series(p.At(), &builder, &chks); err != nil {
+			return stats, errors.Wrap(err, "read series")
+		}
+
+		for _, meta := range chks {
+			chk, err := cr.Chunk(meta)
+			if err != nil {
+				return stats, errors.Wrapf(err, "read chunk %d of series %s", meta.Ref, builder.Labels())
+			}
+
+			var hint chunkenc.CounterResetHeader
+			switch c := chk.(type) {
+			case *chunkenc.HistogramChunk:
+				hint = c.GetCounterResetHeader()
+			case *chunkenc.FloatHistogramChunk:
+				hint = c.GetCounterResetHeader()
+			default:
+				continue
+			}
+
+			stats.Chunks++
+			stats.Bytes += int64(len(chk.Bytes()))
+			if hint == chunkenc.UnknownCounterReset {
+				stats.UnknownCounterResetChunks++
+			}
+
+			samples, err := validateHistogramChunk(chk)
+			if err != nil {
+				return stats, errors.Wrapf(err, "chunk %d of series %s", meta.Ref, builder.Labels())
+			}
+			stats.Samples += samples
+		}
+	}
+	if p.Err() != nil {
+		return stats, errors.Wrap(p.Err(), "walk postings")
+	}
+
+	return stats, nil
+}
+
+// validateHistogramChunk validates all the samples of a histogram chunk and returns their number.
+func validateHistogramChunk(chk chunkenc.Chunk) (int64, error) {
+	samples := int64(0)
+
+	it := chk.Iterator(nil)
+	for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+		if err := validateHistogramSample(it, valType); err != nil {
+			return samples, err
+		}
+		samples++
+	}
+	if err := it.Err(); err != nil {
+		return samples, errors.Wrap(err, "iterate over histogram chunk samples")
+	}
+	if samples == 0 {
+		return samples, errors.New("no samples found in histogram chunk")
+	}
+
+	return samples, nil
+}
+
+// validateHistogramSample validates the current sample of the iterator, if it's a histogram.
+func validateHistogramSample(it chunkenc.Iterator, valType chunkenc.ValueType) error {
+	switch valType {
+	case chunkenc.ValHistogram:
+		ts, h := it.AtHistogram()
+		if err := tsdb.ValidateHistogram(h); err != nil {
+			return errors.Wrapf(err, "invalid histogram sample at %s", formatTimestamp(ts))
+		}
+	case chunkenc.ValFloatHistogram:
+		ts, fh := it.AtFloatHistogram()
+		if err := tsdb.ValidateFloatHistogram(fh); err != nil {
+			return errors.Wrapf(err, "invalid float histogram sample at %s", formatTimestamp(ts))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestGatherNativeHistogramStats(t *testing.T) {
+	histogramChunk := func(hint chunkenc.CounterResetHeader, histograms ...*histogram.Histogram) chunks.Meta {
+		chk := chunkenc.NewHistogramChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		for i, h := range histograms {
+			app.AppendHistogram(int64(i), h)
+		}
+		chk.SetCounterResetHeader(hint)
+		return chunks.Meta{MinTime: 0, MaxTime: int64(len(histograms) - 1), Chunk: chk}
+	}
+
+	floatChunk := func() chunks.Meta {
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		app.Append(0, 1)
+		app.Append(1, 2)
+		return chunks.Meta{MinTime: 0, MaxTime: 1, Chunk: chk}
+	}
+
+	t.Run("valid histogram chunks", func(t *testing.T) {
+		specs := SeriesSpecs{
+			{Labels: labels.FromStrings(labels.MetricName, "float"), Chunks: []chunks.Meta{floatChunk()}},
+			{Labels: labels.FromStrings(labels.MetricName, "histogram_1"), Chunks: []chunks.Meta{
+				histogramChunk(chunkenc.UnknownCounterReset, test.GenerateTestHistogram(0), test.GenerateTestHistogram(1)),
+			}},
+			{Labels: labels.FromStrings(labels.MetricName, "histogram_2"), Chunks: []chunks.Meta{
+				histogramChunk(chunkenc.NotCounterReset, test.GenerateTestHistogram(2)),
+			}},
+		}
+
+		dir := t.TempDir()
+		meta, err := GenerateBlockFromSpec("", dir, specs)
+		require.NoError(t, err)
+		blockDir := filepath.Join(dir, meta.ULID.String())
+
+		stats, err := GatherNativeHistogramStats(blockDir)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stats.Chunks)
+		assert.Equal(t, int64(3), stats.Samples)
+		assert.Equal(t, int64(1), stats.UnknownCounterResetChunks)
+		assert.Equal(t, int64(len(specs[1].Chunks[0].Chunk.Bytes())+len(specs[2].Chunks[0].Chunk.Bytes())), stats.Bytes)
+
+		require.NoError(t, VerifyBlock(log.NewNopLogger(), blockDir, meta.MinTime, meta.MaxTime+1, true))
+	})
+
+	t.Run("invalid histogram chunk", func(t *testing.T) {
+		// The count of the histogram is lower than the sum of its buckets.
+		invalid := test.GenerateTestHistogram(1)
+		invalid.Count = 1
+
+		dir := t.TempDir()
+		meta, err := GenerateBlockFromSpec("", dir, SeriesSpecs{
+			{Labels: labels.FromStrings(labels.MetricName, "histogram"), Chunks: []chunks.Meta{
+				histogramChunk(chunkenc.UnknownCounterReset, test.GenerateTestHistogram(0), invalid),
+			}},
+		})
+		require.NoError(t, err)
+		blockDir := filepath.Join(dir, meta.ULID.String())
+
+		_, err = GatherNativeHistogramStats(blockDir)
+		require.ErrorContains(t, err, "invalid histogram sample")
+
+		// The histogram samples are not validated when verifying the chunks, for example on block upload.
+		require.NoError(t, VerifyBlock(log.NewNopLogger(), blockDir, meta.MinTime, meta.MaxTime+1, true))
+	})
+
+	t.Run("block without histogram chunks", func(t *testing.T) {
+		dir := t.TempDir()
+		meta, err := GenerateBlockFromSpec("", dir, SeriesSpecs{
+			{Labels: labels.FromStrings(labels.MetricName, "float"), Chunks: []chunks.Meta{floatChunk()}},
+		})
+		require.NoError(t, err)
+
+		stats, err := GatherNativeHistogramStats(filepath.Join(dir, meta.ULID.String()))
+		require.NoError(t, err)
+		assert.Equal(t, NativeHistogramStats{}, stats)
+	})
+}
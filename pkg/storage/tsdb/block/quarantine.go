@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// QuarantinePathname is the location, relative to the tenant's bucket location, where the corrupted blocks
+	// are moved to. Blocks in this location are not discovered by any component, and are kept for investigation.
+	QuarantinePathname = "quarantine"
+
+	// QuarantineMarkFilename is the known json filename of the file storing details about why a block has been
+	// quarantined. It's stored in the quarantined block dir, and uploaded after all the other files of the block,
+	// so a quarantined block without it is a partially quarantined one.
+	QuarantineMarkFilename = "quarantine-mark.json"
+
+	// QuarantineMarkVersion1 is the version of quarantine-mark file supported by Mimir.
+	QuarantineMarkVersion1 = 1
+)
+
+// QuarantineReason is a reason for a block to be quarantined.
+type QuarantineReason string
+
+const (
+	// UploadVerificationQuarantineReason is the reason of the blocks whose upload to the object storage failed the verification.
+	UploadVerificationQuarantineReason QuarantineReason = "upload-verification-failed"
+)
+
+// QuarantineMark stores the reason of a block being quarantined.
+type QuarantineMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+	// Version of the file.
+	Version int `json:"version"`
+	// Details is a human readable string giving details of reason.
+	Details string `json:"details,omitempty"`
+
+	// QuarantineTime is a unix timestamp of when the block was quarantined.
+	QuarantineTime int64            `json:"quarantine_time"`
+	Reason         QuarantineReason `json:"reason"`
+}
+
+func (m *QuarantineMark) markerFilename() string { return QuarantineMarkFilename }
+
+// QuarantineDir returns the path, relative to the tenant's bucket location, of a quarantined block.
+func QuarantineDir(id ulid.ULID) string {
+	return path.Join(QuarantinePathname, id.String())
+}
+
+// Quarantine moves the block uploaded from the local blockDir to the quarantine location. The quarantined copy is
+// the local one, uploaded again to the quarantine location, since the uploaded block can't be read reliably. Then
+// the quarantine mark is written, and finally the uploaded block is deleted from its original location.
+func Quarantine(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDir string, reason QuarantineReason, details string, quarantined prometheus.Counter) error {
+	id, err := ulid.Parse(filepath.Base(blockDir))
+	if err != nil {
+		return errors.Wrap(err, "not a block dir")
+	}
+
+	if err := objstore.UploadDir(ctx, logger, bkt, blockDir, QuarantineDir(id)); err != nil {
+		return errors.Wrapf(err, "upload block %s to quarantine", id)
+	}
+
+	mark, err := json.Marshal(QuarantineMark{
+		ID:             id,
+		Version:        QuarantineMarkVersion1,
+		Details:        details,
+		QuarantineTime: time.Now().Unix(),
+		Reason:         reason,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode quarantine mark")
+	}
+
+	markFile := path.Join(QuarantineDir(id), QuarantineMarkFilename)
+	if err := bkt.Upload(ctx, markFile, bytes.NewReader(mark)); err != nil {
+		return errors.Wrapf(err, "upload file %s to bucket", markFile)
+	}
+
+	// The block is safe in the quarantine location, so it's deleted from its original location straight away,
+	// to prevent it from being queried or compacted. If the deletion fails halfway, the partial block is cleaned
+	// up by the blocks cleaner.
+	if err := Delete(ctx, logger, bkt, id); err != nil {
+		return errors.Wrapf(err, "delete quarantined block %s from its original location", id)
+	}
+
+	quarantined.Inc()
+	level.Warn(logger).Log("msg", "block has been quarantined", "block", id, "reason", reason, "details", details)
+	return nil
+}
+
+// IsQuarantined returns whether the block has been quarantined, which is when its quarantine mark exists.
+func IsQuarantined(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (bool, error) {
+	return bkt.Exists(ctx, path.Join(QuarantineDir(id), QuarantineMarkFilename))
+}
+
+// ListQuarantinedBlocks returns the IDs of the blocks in the quarantine location, including the partially
+// quarantined ones.
+func ListQuarantinedBlocks(ctx context.Context, bkt objstore.BucketReader) ([]ulid.ULID, error) {
+	var ids []ulid.ULID
+
+	err := bkt.Iter(ctx, QuarantinePathname+"/", func(name string) error {
+		if id, ok := IsBlockDir(name); ok {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list quarantined blocks")
+	}
+	return ids, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	tmpDir := t.TempDir()
+
+	id, err := CreateBlock(ctx, tmpDir, fiveLabels, 100, 0, 1000, labels.FromStrings("ext1", "val1"))
+	require.NoError(t, err)
+	blockDir := filepath.Join(tmpDir, id.String())
+
+	bkt := objstore.WithNoopInstr(objstore.NewInMemBucket())
+	require.NoError(t, Upload(ctx, logger, bkt, blockDir, nil))
+
+	quarantined, err := IsQuarantined(ctx, bkt, id)
+	require.NoError(t, err)
+	require.False(t, quarantined)
+
+	counter := promauto.With(prometheus.NewRegistry()).NewCounter(prometheus.CounterOpts{Name: "test"})
+	require.NoError(t, Quarantine(ctx, logger, bkt, blockDir, UploadVerificationQuarantineReason, "corrupted", counter))
+	require.Equal(t, float64(1), promtest.ToFloat64(counter))
+
+	// The block has been deleted from its original location.
+	exists, err := bkt.Exists(ctx, path.Join(id.String(), MetaFilename))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// The block has been moved to the quarantine location.
+	for _, name := range []string{MetaFilename, IndexFilename, path.Join(ChunksDirname, "000001")} {
+		exists, err := bkt.Exists(ctx, path.Join(QuarantineDir(id), name))
+		require.NoError(t, err)
+		require.True(t, exists, name)
+	}
+
+	mark := QuarantineMark{}
+	require.NoError(t, ReadMarker(ctx, logger, bkt, QuarantineDir(id), &mark))
+	require.Equal(t, id, mark.ID)
+	require.Equal(t, QuarantineMarkVersion1, mark.Version)
+	require.Equal(t, UploadVerificationQuarantineReason, mark.Reason)
+	require.Equal(t, "corrupted", mark.Details)
+
+	quarantined, err = IsQuarantined(ctx, bkt, id)
+	require.NoError(t, err)
+	require.True(t, quarantined)
+
+	ids, err := ListQuarantinedBlocks(ctx, bkt)
+	require.NoError(t, err)
+	require.Equal(t, []ulid.ULID{id}, ids)
+}
@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+)
+
+// indexFooterLen is the length of the footer of an index file, holding its table of contents and the checksum of it.
+const indexFooterLen = 6*8 + crc32.Size
+
+var (
+	// ErrUploadedBlockCorrupted is returned when a block uploaded to the object storage doesn't match its local copy.
+	ErrUploadedBlockCorrupted = errors.New("uploaded block is corrupted")
+
+	// ErrLocalBlockInvalid is returned when the local copy of an uploaded block is invalid. The uploaded block isn't
+	// corrupted, since it matches the local copy, but it's invalid too.
+	ErrLocalBlockInvalid = errors.New("local block is invalid")
+)
+
+// VerifyUploadedBlock verifies the block uploaded to the object storage from the local blockDir:
+//
+// - The meta.json is downloaded again, and the size of each uploaded file it lists must match the local file.
+//
+// - The footer of the uploaded index, holding its table of contents, is downloaded again, and its checksum is
+// checked. It must match the footer of the local index.
+//
+// - The series of the local index, which the uploaded one has been checked to match, are checked to be ordered.
+//
+// The returned error wraps ErrUploadedBlockCorrupted if the uploaded block doesn't match the local one, or
+// ErrLocalBlockInvalid if the local index is invalid, while other errors, for example failing to read the object
+// storage, leave it undecided.
+func VerifyUploadedBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDir string) error {
+	id, err := ulid.Parse(filepath.Base(blockDir))
+	if err != nil {
+		return errors.Wrap(err, "not a block dir")
+	}
+
+	meta, err := downloadUploadedMeta(ctx, logger, bkt, id)
+	if err != nil {
+		return err
+	}
+
+	indexSize := int64(-1)
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath == MetaFilename {
+			continue
+		}
+
+		localStat, err := os.Stat(filepath.Join(blockDir, filepath.FromSlash(f.RelPath)))
+		if os.IsNotExist(err) {
+			return uploadedBlockCorruptedError(err, "file %s listed by the uploaded %s doesn't exist locally", f.RelPath, MetaFilename)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "stat local file %s", f.RelPath)
+		}
+
+		attrs, err := bkt.Attributes(ctx, path.Join(id.String(), f.RelPath))
+		if bkt.IsObjNotFoundErr(err) {
+			return uploadedBlockCorruptedError(err, "file %s not found", f.RelPath)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "get attributes of %s", f.RelPath)
+		}
+		if attrs.Size != localStat.Size() {
+			return uploadedBlockCorruptedError(nil, "file %s has size %d, expected %d", f.RelPath, attrs.Size, localStat.Size())
+		}
+
+		if f.RelPath == IndexFilename {
+			indexSize = attrs.Size
+		}
+	}
+	if indexSize < indexFooterLen {
+		return uploadedBlockCorruptedError(nil, "index not found in the uploaded meta.json, or too small")
+	}
+
+	footer, err := downloadIndexFooter(ctx, bkt, id, indexSize)
+	if err != nil {
+		return err
+	}
+	if _, err := index.NewTOCFromByteSlice(byteSlice(footer)); err != nil {
+		return uploadedBlockCorruptedError(err, "invalid index footer")
+	}
+
+	localFooter, err := readIndexFooter(filepath.Join(blockDir, IndexFilename))
+	if err != nil {
+		return errors.Wrap(err, "read local index footer")
+	}
+	if !bytes.Equal(footer, localFooter) {
+		return uploadedBlockCorruptedError(nil, "index footer doesn't match the local one")
+	}
+
+	if err := verifySeriesOrder(filepath.Join(blockDir, IndexFilename)); err != nil {
+		return fmt.Errorf("%w: invalid index: %s", ErrLocalBlockInvalid, err.Error())
+	}
+
+	return nil
+}
+
+func uploadedBlockCorruptedError(cause error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if cause != nil {
+		msg += ": " + cause.Error()
+	}
+	return fmt.Errorf("%w: %s", ErrUploadedBlockCorrupted, msg)
+}
+
+// downloadUploadedMeta downloads the meta.json of the uploaded block. Unlike DownloadMeta, it tells
+// a missing or malformed meta.json from an error reading it.
+func downloadUploadedMeta(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID) (Meta, error) {
+	rc, err := bkt.Get(ctx, path.Join(id.String(), MetaFilename))
+	if bkt.IsObjNotFoundErr(err) {
+		return Meta{}, uploadedBlockCorruptedError(err, "%s not found", MetaFilename)
+	}
+	if err != nil {
+		return Meta{}, errors.Wrapf(err, "get %s", MetaFilename)
+	}
+	defer runutil.CloseWithLogOnErr(logger, rc, "download uploaded meta")
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return Meta{}, errors.Wrapf(err, "read %s", MetaFilename)
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return Meta{}, uploadedBlockCorruptedError(err, "unmarshal %s", MetaFilename)
+	}
+	if meta.ULID != id {
+		return Meta{}, uploadedBlockCorruptedError(nil, "%s has block ID %s", MetaFilename, meta.ULID)
+	}
+	return meta, nil
+}
+
+func downloadIndexFooter(ctx context.Context, bkt objstore.Bucket, id ulid.ULID, indexSize int64) (_ []byte, err error) {
+	rc, err := bkt.GetRange(ctx, path.Join(id.String(), IndexFilename), indexSize-indexFooterLen, indexFooterLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "get index footer")
+	}
+	defer runutil.CloseWithErrCapture(&err, rc, "close index footer reader")
+
+	footer, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read index footer")
+	}
+	return footer, nil
+}
+
+func readIndexFooter(indexFile string) (_ []byte, err error) {
+	f, err := os.Open(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close index file")
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < indexFooterLen {
+		return nil, errors.Errorf("index file too small: %d bytes", stat.Size())
+	}
+
+	footer := make([]byte, indexFooterLen)
+	if _, err := f.ReadAt(footer, stat.Size()-indexFooterLen); err != nil {
+		return nil, err
+	}
+	return footer, nil
+}
+
+// verifySeriesOrder checks that the series of the index are ordered by their labels.
+func verifySeriesOrder(indexFile string) (err error) {
+	r, err := index.NewFileReader(indexFile)
+	if err != nil {
+		return errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "verify series order index reader")
+
+	p, err := r.Postings(index.AllPostingsKey())
+	if err != nil {
+		return errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		builder  labels.ScratchBuilder
+		chks     []chunks.Meta
+		lastLset labels.Labels
+	)
+	for p.Next() {
+		if err := r.Series(p.At(), &builder, &chks); err != nil {
+			return errors.Wrap(err, "read series")
+		}
+		lset := builder.Labels()
+		if !lastLset.IsEmpty() && labels.Compare(lastLset, lset) >= 0 {
+			return errors.Errorf("series %s out of order; previous %s", lset, lastLset)
+		}
+		lastLset = lset
+	}
+	return errors.Wrap(p.Err(), "walk postings")
+}
+
+type byteSlice []byte
+
+func (b byteSlice) Len() int                    { return len(b) }
+func (b byteSlice) Range(start, end int) []byte { return b[start:end] }
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestVerifyUploadedBlock(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	upload := func(t *testing.T) (objstore.Bucket, string, ulid.ULID) {
+		tmpDir := t.TempDir()
+		id, err := CreateBlock(ctx, tmpDir, fiveLabels, 100, 0, 1000, labels.FromStrings("ext1", "val1"))
+		require.NoError(t, err)
+
+		bkt := objstore.NewInMemBucket()
+		blockDir := filepath.Join(tmpDir, id.String())
+		require.NoError(t, Upload(ctx, logger, bkt, blockDir, nil))
+		return bkt, blockDir, id
+	}
+
+	t.Run("valid upload", func(t *testing.T) {
+		bkt, blockDir, _ := upload(t)
+		require.NoError(t, VerifyUploadedBlock(ctx, logger, bkt, blockDir))
+	})
+
+	t.Run("missing meta.json", func(t *testing.T) {
+		bkt, blockDir, id := upload(t)
+		require.NoError(t, bkt.Delete(ctx, path.Join(id.String(), MetaFilename)))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "meta.json not found")
+	})
+
+	t.Run("malformed meta.json", func(t *testing.T) {
+		bkt, blockDir, id := upload(t)
+		require.NoError(t, bkt.Upload(ctx, path.Join(id.String(), MetaFilename), bytes.NewReader([]byte("{"))))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "unmarshal meta.json")
+	})
+
+	t.Run("missing chunks file", func(t *testing.T) {
+		bkt, blockDir, id := upload(t)
+		require.NoError(t, bkt.Delete(ctx, path.Join(id.String(), ChunksDirname, "000001")))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "file chunks/000001 not found")
+	})
+
+	t.Run("truncated index", func(t *testing.T) {
+		bkt, blockDir, id := upload(t)
+		index := readObject(t, bkt, path.Join(id.String(), IndexFilename))
+		require.NoError(t, bkt.Upload(ctx, path.Join(id.String(), IndexFilename), bytes.NewReader(index[:len(index)-1])))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "file index has size")
+	})
+
+	t.Run("corrupted index footer", func(t *testing.T) {
+		bkt, blockDir, id := upload(t)
+		index := readObject(t, bkt, path.Join(id.String(), IndexFilename))
+		index[len(index)-10] ^= 0xff
+		require.NoError(t, bkt.Upload(ctx, path.Join(id.String(), IndexFilename), bytes.NewReader(index)))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "invalid index footer")
+	})
+
+	t.Run("local file missing", func(t *testing.T) {
+		bkt, blockDir, _ := upload(t)
+		require.NoError(t, os.Remove(filepath.Join(blockDir, ChunksDirname, "000001")))
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.ErrorIs(t, err, ErrUploadedBlockCorrupted)
+		require.ErrorContains(t, err, "file chunks/000001 listed by the uploaded meta.json doesn't exist locally")
+	})
+
+	t.Run("local index not readable", func(t *testing.T) {
+		bkt, blockDir, _ := upload(t)
+		require.NoError(t, os.Chmod(filepath.Join(blockDir, IndexFilename), 0))
+		if _, err := os.ReadFile(filepath.Join(blockDir, IndexFilename)); err == nil {
+			t.Skip("the file permissions are not enforced")
+		}
+
+		err := VerifyUploadedBlock(ctx, logger, bkt, blockDir)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrUploadedBlockCorrupted)
+	})
+
+	t.Run("not a block dir", func(t *testing.T) {
+		err := VerifyUploadedBlock(ctx, logger, objstore.NewInMemBucket(), t.TempDir())
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrUploadedBlockCorrupted)
+	})
+}
+
+func readObject(t *testing.T, bkt objstore.Bucket, name string) []byte {
+	rc, err := bkt.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rc.Close()) }()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(rc)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
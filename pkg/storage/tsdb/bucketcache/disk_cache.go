@@ -0,0 +1,501 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// diskCacheHeaderSize is the size of the fixed part of the header of each cached file:
+	// the expiration timestamp (unix milliseconds) followed by the length of the key.
+	diskCacheHeaderSize = 8 + 4
+
+	// diskCacheTmpSuffix is the suffix of files being written, which are ignored when loading the cache.
+	diskCacheTmpSuffix = ".tmp"
+)
+
+var _ cache.Cache = (*DiskCache)(nil)
+
+type diskCacheEntry struct {
+	key       string
+	size      int64
+	expiresAt time.Time
+}
+
+// DiskCache is a cache.Cache storing items on the local disk, bounded by the total size of the
+// stored items and evicting the least recently used items first. It optionally wraps an
+// underlying cache: items are always stored in both caches, but fetched from the underlying
+// cache only if missing on disk, in which case they're stored on disk too.
+//
+// Items stored on disk survive restarts: the cache is reloaded from the directory on startup.
+type DiskCache struct {
+	c           cache.Cache
+	name        string
+	dir         string
+	maxSize     int64
+	fetchedTTL  time.Duration
+	logger      log.Logger
+	storeQueue  chan map[string][]byte
+	stopOnce    sync.Once
+	stopCh      chan struct{}
+	storeWorker sync.WaitGroup
+
+	mtx     sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int64
+
+	requests      prometheus.Counter
+	hits          prometheus.Counter
+	evictions     prometheus.Counter
+	storesDropped prometheus.Counter
+	failures      *prometheus.CounterVec
+}
+
+// WrapWithDiskCache wraps the underlying cache c, which may be nil, with a cache storing up to
+// maxSizeBytes of items in dir. Items fetched from the underlying cache are stored on disk with
+// fetchedTTL, because their original TTL is unknown. Stores on disk are asynchronous and items
+// are dropped if more than storeQueueSize stores are pending.
+func WrapWithDiskCache(c cache.Cache, name, dir string, maxSizeBytes uint64, fetchedTTL time.Duration, storeQueueSize int, logger log.Logger, reg prometheus.Registerer) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "create disk cache directory %s", dir)
+	}
+
+	d := &DiskCache{
+		c:          c,
+		name:       name,
+		dir:        filepath.Clean(dir),
+		maxSize:    int64(maxSizeBytes),
+		fetchedTTL: fetchedTTL,
+		logger:     log.With(logger, "name", "disk-"+name),
+		storeQueue: make(chan map[string][]byte, storeQueueSize),
+		stopCh:     make(chan struct{}),
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cache_disk_requests_total",
+			Help:        "Total number of requests to the disk cache.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cache_disk_hits_total",
+			Help:        "Total number of requests to the disk cache that were a hit.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		evictions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cache_disk_evictions_total",
+			Help:        "Total number of items evicted from the disk cache to honor the max size.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		storesDropped: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cache_disk_stores_dropped_total",
+			Help:        "Total number of items not stored in the disk cache because the store queue was full.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "cache_disk_operation_failures_total",
+			Help:        "Total number of disk cache operations which failed.",
+			ConstLabels: map[string]string{"name": name},
+		}, []string{"operation"}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cache_disk_items_count",
+		Help:        "Total number of items currently in the disk cache.",
+		ConstLabels: map[string]string{"name": name},
+	}, func() float64 {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+
+		return float64(d.lru.Len())
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cache_disk_size_bytes",
+		Help:        "Total size of the items currently in the disk cache.",
+		ConstLabels: map[string]string{"name": name},
+	}, func() float64 {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+
+		return float64(d.size)
+	})
+
+	if err := d.load(); err != nil {
+		return nil, errors.Wrapf(err, "load disk cache from %s", dir)
+	}
+
+	d.storeWorker.Add(1)
+	go d.storeLoop()
+
+	return d, nil
+}
+
+// Stop stops the asynchronous stores, waiting until the store in progress, if any, has completed.
+// Pending stores are discarded.
+func (d *DiskCache) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+		d.storeWorker.Wait()
+	})
+}
+
+func (d *DiskCache) StoreAsync(data map[string][]byte, ttl time.Duration) {
+	if d.c != nil {
+		d.c.StoreAsync(data, ttl)
+	}
+
+	d.enqueueStore(data, ttl)
+}
+
+func (d *DiskCache) Fetch(ctx context.Context, keys []string, opts ...cache.Option) map[string][]byte {
+	d.requests.Add(float64(len(keys)))
+
+	found := make(map[string][]byte, len(keys))
+	miss := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		if data, ok := d.get(k); ok {
+			found[k] = data
+			continue
+		}
+		miss = append(miss, k)
+	}
+	d.hits.Add(float64(len(found)))
+
+	if len(miss) == 0 || d.c == nil {
+		return found
+	}
+
+	fetched := d.c.Fetch(ctx, miss, opts...)
+	if len(fetched) == 0 {
+		return found
+	}
+
+	for k, v := range fetched {
+		found[k] = v
+	}
+
+	// The fetched items may be backed by a memory pool released once the caller is done with them,
+	// but it's safe to pass them to enqueueStore() because items are copied while being encoded.
+	d.enqueueStore(fetched, d.fetchedTTL)
+
+	return found
+}
+
+func (d *DiskCache) Delete(ctx context.Context, key string) error {
+	d.mtx.Lock()
+	elem, ok := d.entries[key]
+	if ok {
+		d.removeElement(elem)
+	}
+	d.mtx.Unlock()
+
+	if ok {
+		d.removeFile(key)
+	}
+
+	if d.c != nil {
+		return d.c.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (d *DiskCache) Name() string {
+	return "disk-" + d.name
+}
+
+func (d *DiskCache) enqueueStore(data map[string][]byte, ttl time.Duration) {
+	expiresAt := time.Now().Add(ttl)
+
+	// Items are encoded upfront, so that the store loop doesn't need to know their expiration.
+	items := make(map[string][]byte, len(data))
+	for k, v := range data {
+		items[k] = encodeDiskCacheItem(k, v, expiresAt)
+	}
+
+	select {
+	case d.storeQueue <- items:
+	default:
+		d.storesDropped.Add(float64(len(data)))
+	}
+}
+
+func (d *DiskCache) storeLoop() {
+	defer d.storeWorker.Done()
+
+	for {
+		select {
+		case items := <-d.storeQueue:
+			for k, encoded := range items {
+				d.store(k, encoded)
+			}
+		case <-d.stopCh:
+			return
+		}
+	}
+}
+
+// store writes the encoded item to disk and adds it to the cache, evicting the least recently
+// used items if the cache exceeds its max size.
+func (d *DiskCache) store(key string, encoded []byte) {
+	size := int64(len(encoded))
+	if size > d.maxSize {
+		return
+	}
+
+	path := d.path(key)
+	if err := writeFileAtomically(path, encoded); err != nil {
+		d.failures.WithLabelValues("store").Inc()
+		level.Warn(d.logger).Log("msg", "failed to store item in disk cache", "path", path, "err", err)
+		return
+	}
+
+	expiresAt := time.UnixMilli(int64(binary.BigEndian.Uint64(encoded)))
+
+	d.mtx.Lock()
+	if elem, ok := d.entries[key]; ok {
+		// The file has been overwritten, so we just need to update the accounting.
+		d.removeElement(elem)
+	}
+	d.addEntry(&diskCacheEntry{key: key, size: size, expiresAt: expiresAt})
+	evicted := d.evict()
+	d.mtx.Unlock()
+
+	for _, k := range evicted {
+		d.removeFile(k)
+	}
+}
+
+// get returns the data of the item stored on disk for the input key, if any and not expired.
+func (d *DiskCache) get(key string) ([]byte, bool) {
+	d.mtx.Lock()
+	elem, ok := d.entries[key]
+	if !ok {
+		d.mtx.Unlock()
+		return nil, false
+	}
+
+	entry := elem.Value.(*diskCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		d.removeElement(elem)
+		d.mtx.Unlock()
+
+		d.removeFile(key)
+		return nil, false
+	}
+
+	d.lru.MoveToFront(elem)
+	d.mtx.Unlock()
+
+	encoded, err := os.ReadFile(d.path(key))
+	if err == nil {
+		var storedKey string
+		var data []byte
+		if storedKey, _, data, err = decodeDiskCacheItem(encoded); err == nil && storedKey == key {
+			return data, true
+		}
+	}
+
+	// The file may have been removed in the meanwhile, otherwise it's unreadable or corrupted.
+	if err != nil && !os.IsNotExist(err) {
+		d.failures.WithLabelValues("fetch").Inc()
+	}
+
+	d.mtx.Lock()
+	if d.entries[key] == elem {
+		d.removeElement(elem)
+	}
+	d.mtx.Unlock()
+
+	return nil, false
+}
+
+// load rebuilds the cache from the items found on disk. Expired, corrupted and incomplete
+// items are removed, as well as items exceeding the max size, least recently written first.
+func (d *DiskCache) load() error {
+	var entries []*diskCacheEntry
+	modTimes := map[string]time.Time{}
+	now := time.Now()
+
+	err := filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		if strings.HasSuffix(path, diskCacheTmpSuffix) {
+			return os.Remove(path)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		key, expiresAt, ok := readDiskCacheItemHeader(path, info.Size())
+		if !ok || now.After(expiresAt) || d.path(key) != path {
+			return os.Remove(path)
+		}
+
+		entries = append(entries, &diskCacheEntry{key: key, size: info.Size(), expiresAt: expiresAt})
+		modTimes[key] = info.ModTime()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Add the items from the least to the most recently written, so that the
+	// most recently written ones are the last to be evicted.
+	sort.Slice(entries, func(i, j int) bool {
+		return modTimes[entries[i].key].Before(modTimes[entries[j].key])
+	})
+
+	d.mtx.Lock()
+	for _, entry := range entries {
+		d.addEntry(entry)
+	}
+	evicted := d.evict()
+	d.mtx.Unlock()
+
+	for _, k := range evicted {
+		d.removeFile(k)
+	}
+
+	return nil
+}
+
+// addEntry adds the entry as the most recently used one. The caller must hold the lock.
+func (d *DiskCache) addEntry(entry *diskCacheEntry) {
+	d.entries[entry.key] = d.lru.PushFront(entry)
+	d.size += entry.size
+}
+
+// removeElement removes the element from the cache accounting. The caller must hold the lock.
+func (d *DiskCache) removeElement(elem *list.Element) {
+	entry := d.lru.Remove(elem).(*diskCacheEntry)
+	delete(d.entries, entry.key)
+	d.size -= entry.size
+}
+
+// evict removes the least recently used entries until the cache doesn't exceed the max size,
+// and returns their keys. The caller must hold the lock, and remove the returned keys' files.
+func (d *DiskCache) evict() []string {
+	var evicted []string
+	for d.size > d.maxSize {
+		elem := d.lru.Back()
+		if elem == nil {
+			break
+		}
+
+		evicted = append(evicted, elem.Value.(*diskCacheEntry).key)
+		d.removeElement(elem)
+	}
+
+	d.evictions.Add(float64(len(evicted)))
+	return evicted
+}
+
+func (d *DiskCache) removeFile(key string) {
+	if err := os.Remove(d.path(key)); err != nil && !os.IsNotExist(err) {
+		d.failures.WithLabelValues("delete").Inc()
+		level.Warn(d.logger).Log("msg", "failed to remove item from disk cache", "key", key, "err", err)
+	}
+}
+
+// path returns the path of the file storing the item with the input key. Files are spread
+// across sub-directories to avoid a huge number of files in a single directory.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// encodeDiskCacheItem encodes the item into the format it's stored on disk: the expiration
+// timestamp, the key length and the key, followed by the data.
+func encodeDiskCacheItem(key string, data []byte, expiresAt time.Time) []byte {
+	encoded := make([]byte, diskCacheHeaderSize, diskCacheHeaderSize+len(key)+len(data))
+	binary.BigEndian.PutUint64(encoded, uint64(expiresAt.UnixMilli()))
+	binary.BigEndian.PutUint32(encoded[8:], uint32(len(key)))
+	encoded = append(encoded, key...)
+	return append(encoded, data...)
+}
+
+func decodeDiskCacheItem(encoded []byte) (key string, expiresAt time.Time, data []byte, err error) {
+	if len(encoded) < diskCacheHeaderSize {
+		return "", time.Time{}, nil, errors.New("disk cache item is too short")
+	}
+
+	expiresAt = time.UnixMilli(int64(binary.BigEndian.Uint64(encoded)))
+	keyLen := int(binary.BigEndian.Uint32(encoded[8:]))
+	if len(encoded) < diskCacheHeaderSize+keyLen {
+		return "", time.Time{}, nil, errors.New("disk cache item key is truncated")
+	}
+
+	key = string(encoded[diskCacheHeaderSize : diskCacheHeaderSize+keyLen])
+	return key, expiresAt, encoded[diskCacheHeaderSize+keyLen:], nil
+}
+
+// readDiskCacheItemHeader reads the key and expiration of the item stored at path, whose file has the input size.
+func readDiskCacheItemHeader(path string, size int64) (key string, expiresAt time.Time, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	defer f.Close()
+
+	header := make([]byte, diskCacheHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return "", time.Time{}, false
+	}
+
+	keyLen := int64(binary.BigEndian.Uint32(header[8:]))
+	if diskCacheHeaderSize+keyLen > size {
+		return "", time.Time{}, false
+	}
+
+	keyBytes := make([]byte, keyLen)
+	if _, err := io.ReadFull(f, keyBytes); err != nil {
+		return "", time.Time{}, false
+	}
+
+	return string(keyBytes), time.UnixMilli(int64(binary.BigEndian.Uint64(header))), true
+}
+
+// writeFileAtomically writes data to a temporary file and renames it to path, so that
+// readers never see a partially written file.
+func writeFileAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp := path + diskCacheTmpSuffix
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
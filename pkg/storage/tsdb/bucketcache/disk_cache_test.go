@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bucketcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskCache(t *testing.T, c cache.Cache, dir string, maxSizeBytes uint64) *DiskCache {
+	t.Helper()
+
+	d, err := WrapWithDiskCache(c, "test", dir, maxSizeBytes, time.Hour, 100, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	t.Cleanup(d.Stop)
+	return d
+}
+
+// waitDiskCacheItems waits until the disk cache holds the expected number of items.
+func waitDiskCacheItems(t *testing.T, d *DiskCache, expected int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+		return len(d.entries) == expected
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDiskCache_StoreAndFetch(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDiskCache(t, nil, t.TempDir(), 1024*1024)
+
+	d.StoreAsync(map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, time.Hour)
+	waitDiskCacheItems(t, d, 2)
+
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, d.Fetch(ctx, []string{"a", "b", "c"}))
+	assert.Equal(t, float64(3), promtest.ToFloat64(d.requests))
+	assert.Equal(t, float64(2), promtest.ToFloat64(d.hits))
+
+	require.NoError(t, d.Delete(ctx, "a"))
+	assert.Equal(t, map[string][]byte{"b": []byte("value-b")}, d.Fetch(ctx, []string{"a", "b"}))
+	assert.Equal(t, "disk-test", d.Name())
+}
+
+func TestDiskCache_Expiration(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDiskCache(t, nil, t.TempDir(), 1024*1024)
+
+	d.StoreAsync(map[string][]byte{"expired": []byte("value")}, -time.Minute)
+	d.StoreAsync(map[string][]byte{"valid": []byte("value")}, time.Hour)
+	waitDiskCacheItems(t, d, 2)
+
+	assert.Equal(t, map[string][]byte{"valid": []byte("value")}, d.Fetch(ctx, []string{"expired", "valid"}))
+	waitDiskCacheItems(t, d, 1)
+	assert.NoFileExists(t, d.path("expired"))
+}
+
+func TestDiskCache_EvictionBySize(t *testing.T) {
+	ctx := context.Background()
+	value := make([]byte, 100)
+
+	// Each item takes 12 bytes of header, 1 byte of key and 100 bytes of value.
+	d := newTestDiskCache(t, nil, t.TempDir(), 2*113)
+
+	d.StoreAsync(map[string][]byte{"a": value}, time.Hour)
+	waitDiskCacheItems(t, d, 1)
+	d.StoreAsync(map[string][]byte{"b": value}, time.Hour)
+	waitDiskCacheItems(t, d, 2)
+
+	// Access "a", so that "b" is the least recently used item.
+	require.Len(t, d.Fetch(ctx, []string{"a"}), 1)
+
+	d.StoreAsync(map[string][]byte{"c": value}, time.Hour)
+	require.Eventually(t, func() bool {
+		return promtest.ToFloat64(d.evictions) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, map[string][]byte{"a": value, "c": value}, d.Fetch(ctx, []string{"a", "b", "c"}))
+	assert.NoFileExists(t, d.path("b"))
+}
+
+func TestDiskCache_ReloadOnRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	d := newTestDiskCache(t, nil, dir, 1024*1024)
+	d.StoreAsync(map[string][]byte{"a": []byte("value-a")}, time.Hour)
+	d.StoreAsync(map[string][]byte{"expired": []byte("value")}, -time.Minute)
+	waitDiskCacheItems(t, d, 2)
+	d.Stop()
+
+	// Simulate a corrupted item and a write interrupted by the restart.
+	corruptedPath := filepath.Join(dir, "00", "corrupted")
+	require.NoError(t, os.MkdirAll(filepath.Dir(corruptedPath), os.ModePerm))
+	require.NoError(t, os.WriteFile(corruptedPath, []byte("corrupted"), 0o600))
+	require.NoError(t, os.WriteFile(d.path("a")+".tmp", []byte("incomplete"), 0o600))
+
+	d = newTestDiskCache(t, nil, dir, 1024*1024)
+	waitDiskCacheItems(t, d, 1)
+
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a")}, d.Fetch(ctx, []string{"a", "b", "expired"}))
+	assert.NoFileExists(t, corruptedPath)
+	assert.NoFileExists(t, d.path("a")+".tmp")
+	assert.NoFileExists(t, d.path("expired"))
+}
+
+func TestDiskCache_UnderlyingCache(t *testing.T) {
+	ctx := context.Background()
+	underlying := cache.NewMockCache()
+	d := newTestDiskCache(t, underlying, t.TempDir(), 1024*1024)
+
+	// Items stored through the disk cache are stored in the underlying cache too.
+	d.StoreAsync(map[string][]byte{"a": []byte("value-a")}, time.Hour)
+	waitDiskCacheItems(t, d, 1)
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a")}, underlying.Fetch(ctx, []string{"a"}))
+
+	// Items missing on disk are fetched from the underlying cache and then stored on disk.
+	underlying.StoreAsync(map[string][]byte{"b": []byte("value-b")}, time.Hour)
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, d.Fetch(ctx, []string{"a", "b", "c"}))
+	waitDiskCacheItems(t, d, 2)
+
+	require.NoError(t, underlying.Delete(ctx, "b"))
+	assert.Equal(t, map[string][]byte{"b": []byte("value-b")}, d.Fetch(ctx, []string{"b"}))
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/tenant"
@@ -30,6 +31,11 @@ const subrangeSize int64 = 16000
 
 var supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
 
+var (
+	errInvalidChunksDiskCacheMaxSize        = errors.New("the chunks disk cache max size must be greater than 0")
+	errInvalidChunksDiskCacheStoreQueueSize = errors.New("the chunks disk cache store queue size must be greater than 0")
+)
+
 type ChunksCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 
@@ -38,6 +44,34 @@ type ChunksCacheConfig struct {
 	AttributesInMemoryMaxItems      int           `yaml:"attributes_in_memory_max_items" category:"advanced"`
 	SubrangeTTL                     time.Duration `yaml:"subrange_ttl" category:"advanced"`
 	FineGrainedChunksCachingEnabled bool          `yaml:"fine_grained_chunks_caching_enabled" category:"experimental"`
+
+	DiskCache ChunksDiskCacheConfig `yaml:"disk_cache"`
+}
+
+// ChunksDiskCacheConfig configures the local disk tier of the chunks cache.
+type ChunksDiskCacheConfig struct {
+	Dir            string `yaml:"dir" category:"experimental"`
+	MaxSizeBytes   uint64 `yaml:"max_size_bytes" category:"experimental"`
+	StoreQueueSize int    `yaml:"store_queue_size" category:"experimental"`
+}
+
+func (cfg *ChunksDiskCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.StringVar(&cfg.Dir, prefix+"dir", "", "Directory to store the local disk tier of the chunks cache in. The disk tier is checked before the chunks cache backend, if any, and stores the most recently used chunks ranges, to reduce the requests to the object storage for frequently accessed chunks. The content of the directory is reused after a restart. Empty to disable the disk tier.")
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(10*units.Gibibyte), "Maximum size, in bytes, of the items stored in the local disk tier of the chunks cache. Once exceeded, the least recently used items are evicted.")
+	f.IntVar(&cfg.StoreQueueSize, prefix+"store-queue-size", 1000, "Maximum number of pending asynchronous writes to the local disk tier of the chunks cache. Once exceeded, items are not stored on disk.")
+}
+
+func (cfg *ChunksDiskCacheConfig) Validate() error {
+	if cfg.Dir == "" {
+		return nil
+	}
+	if cfg.MaxSizeBytes == 0 {
+		return errInvalidChunksDiskCacheMaxSize
+	}
+	if cfg.StoreQueueSize <= 0 {
+		return errInvalidChunksDiskCacheStoreQueueSize
+	}
+	return nil
 }
 
 func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -51,9 +85,14 @@ func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.IntVar(&cfg.AttributesInMemoryMaxItems, prefix+"attributes-in-memory-max-items", 50000, "Maximum number of object attribute items to keep in a first level in-memory LRU cache. Metadata will be stored and fetched in-memory before hitting the cache backend. 0 to disable the in-memory cache.")
 	f.DurationVar(&cfg.SubrangeTTL, prefix+"subrange-ttl", 24*time.Hour, "TTL for caching individual chunks subranges.")
 	f.BoolVar(&cfg.FineGrainedChunksCachingEnabled, prefix+"fine-grained-chunks-caching-enabled", false, "Enable fine-grained caching of chunks in the store-gateway. This reduces the required bandwidth and memory utilization.")
+
+	cfg.DiskCache.RegisterFlagsWithPrefix(f, prefix+"disk-cache.")
 }
 
 func (cfg *ChunksCacheConfig) Validate() error {
+	if err := cfg.DiskCache.Validate(); err != nil {
+		return err
+	}
 	return cfg.BackendConfig.Validate()
 }
 
@@ -96,6 +135,29 @@ func (cfg *MetadataCacheConfig) Validate() error {
 	return cfg.BackendConfig.Validate()
 }
 
+// WrapWithChunksDiskCache wraps the input chunks cache, which may be nil, with the local disk tier
+// of the chunks cache if configured. Otherwise, the input chunks cache is returned.
+func WrapWithChunksDiskCache(chunksCache cache.Cache, chunksConfig ChunksCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+	if chunksConfig.DiskCache.Dir == "" {
+		return chunksCache, nil
+	}
+
+	diskCache, err := bucketcache.WrapWithDiskCache(
+		chunksCache,
+		"chunks-cache",
+		chunksConfig.DiskCache.Dir,
+		chunksConfig.DiskCache.MaxSizeBytes,
+		chunksConfig.SubrangeTTL,
+		chunksConfig.DiskCache.StoreQueueSize,
+		logger,
+		prometheus.WrapRegistererWithPrefix("cortex_", reg),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create chunks disk cache")
+	}
+	return diskCache, nil
+}
+
 func CreateCachingBucket(chunksCache cache.Cache, chunksConfig ChunksCacheConfig, metadataConfig MetadataCacheConfig, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) (objstore.Bucket, error) {
 	cfg := bucketcache.NewCachingBucketConfig()
 	cachingConfigured := false
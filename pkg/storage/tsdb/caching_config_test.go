@@ -6,6 +6,7 @@
 package tsdb
 
 import (
+	"flag"
 	"fmt"
 	"testing"
 
@@ -39,3 +40,46 @@ func TestIsBlockIndexFile(t *testing.T) {
 	assert.True(t, isBlockIndexFile(fmt.Sprintf("%s/index", blockID.String())))
 	assert.True(t, isBlockIndexFile(fmt.Sprintf("/%s/index", blockID.String())))
 }
+
+func TestChunksDiskCacheConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup       func(cfg *ChunksDiskCacheConfig)
+		expectedErr error
+	}{
+		"should pass on disabled disk cache": {
+			setup: func(cfg *ChunksDiskCacheConfig) {
+				cfg.MaxSizeBytes = 0
+				cfg.StoreQueueSize = 0
+			},
+		},
+		"should pass on enabled disk cache with default config": {
+			setup: func(cfg *ChunksDiskCacheConfig) {
+				cfg.Dir = "/tmp/chunks-cache"
+			},
+		},
+		"should fail on invalid max size": {
+			setup: func(cfg *ChunksDiskCacheConfig) {
+				cfg.Dir = "/tmp/chunks-cache"
+				cfg.MaxSizeBytes = 0
+			},
+			expectedErr: errInvalidChunksDiskCacheMaxSize,
+		},
+		"should fail on invalid store queue size": {
+			setup: func(cfg *ChunksDiskCacheConfig) {
+				cfg.Dir = "/tmp/chunks-cache"
+				cfg.StoreQueueSize = 0
+			},
+			expectedErr: errInvalidChunksDiskCacheStoreQueueSize,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := ChunksDiskCacheConfig{}
+			cfg.RegisterFlagsWithPrefix(flag.NewFlagSet("test", flag.PanicOnError), "")
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expectedErr, cfg.Validate())
+		})
+	}
+}
@@ -112,7 +112,9 @@ var (
 	errInvalidWALSegmentSizeBytes   = errors.New("invalid TSDB WAL segment size bytes")
 	errInvalidWALReplayConcurrency  = errors.New("invalid TSDB WAL replay concurrency")
 	errInvalidStripeSize            = errors.New("invalid TSDB stripe size")
+	errInvalidEphemeralRetention    = errors.New("invalid TSDB ephemeral series retention period")
 	errInvalidStreamingBatchSize    = errors.New("invalid store-gateway streaming batch size")
+	errInvalidLazyLoadingLimits     = errors.New("invalid index-header lazy loading concurrency or queue size, must not be negative")
 	errEmptyBlockranges             = errors.New("empty block ranges for TSDB")
 )
 
@@ -241,6 +243,9 @@ type TSDBConfig struct {
 	// BlockPostingsForMatchersCacheForce forces the usage of postings for matchers cache for all calls compacted blocks
 	// regardless of the `concurrent` param.
 	BlockPostingsForMatchersCacheForce bool `yaml:"block_postings_for_matchers_cache_force" category:"experimental"`
+
+	// EphemeralSeriesRetentionPeriod is how long ephemeral series are kept in the ingesters memory.
+	EphemeralSeriesRetentionPeriod time.Duration `yaml:"ephemeral_series_retention_period" category:"experimental"`
 }
 
 // RegisterFlags registers the TSDBConfig flags.
@@ -276,6 +281,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.BlockPostingsForMatchersCacheTTL, "blocks-storage.tsdb.block-postings-for-matchers-cache-ttl", 10*time.Second, "How long to cache postings for matchers in each compacted block queried from the ingester. 0 disables the cache and just deduplicates the in-flight calls.")
 	f.IntVar(&cfg.BlockPostingsForMatchersCacheSize, "blocks-storage.tsdb.block-postings-for-matchers-cache-size", 100, "Maximum number of entries in the cache for postings for matchers in each compacted block when TTL is greater than 0.")
 	f.BoolVar(&cfg.BlockPostingsForMatchersCacheForce, "blocks-storage.tsdb.block-postings-for-matchers-cache-force", false, "Force the cache to be used for postings for matchers in compacted blocks, even if it's not a concurrent (query-sharding) call.")
+	f.DurationVar(&cfg.EphemeralSeriesRetentionPeriod, "blocks-storage.tsdb.ephemeral-series-retention-period", 10*time.Minute, "How long ephemeral series are kept in the ingesters memory. Samples of ephemeral series older than this period, relative to the latest sample of the tenant's ephemeral series, are rejected and removed from memory.")
 }
 
 // Validate the config.
@@ -319,6 +325,10 @@ func (cfg *TSDBConfig) Validate(logger log.Logger) error {
 		return errInvalidWALReplayConcurrency
 	}
 
+	if cfg.EphemeralSeriesRetentionPeriod <= 0 {
+		return errInvalidEphemeralRetention
+	}
+
 	return nil
 }
 
@@ -359,6 +369,12 @@ type BucketStoreConfig struct {
 	// Controls whether index-header lazy loading is enabled.
 	IndexHeaderLazyLoadingEnabled     bool          `yaml:"index_header_lazy_loading_enabled" category:"advanced"`
 	IndexHeaderLazyLoadingIdleTimeout time.Duration `yaml:"index_header_lazy_loading_idle_timeout" category:"advanced"`
+	IndexHeaderLazyLoadingConcurrency int           `yaml:"index_header_lazy_loading_concurrency" category:"experimental"`
+	IndexHeaderLazyLoadingQueueSize   int           `yaml:"index_header_lazy_loading_queue_size" category:"experimental"`
+
+	// Controls the loading of the tenants configured to be lazily loaded.
+	LazyTenantsLoadTimeout time.Duration `yaml:"lazy_tenants_load_timeout" category:"experimental"`
+	LazyTenantsIdleTimeout time.Duration `yaml:"lazy_tenants_idle_timeout" category:"experimental"`
 
 	// Controls the partitioner, used to aggregate multiple GET object API requests.
 	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes" category:"advanced"`
@@ -419,6 +435,10 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
 	f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", true, "If enabled, store-gateway will lazy load an index-header only once required by a query.")
 	f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 60*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will offload unused index-headers after 'idle timeout' inactivity.")
+	f.IntVar(&cfg.IndexHeaderLazyLoadingConcurrency, "blocks-storage.bucket-store.index-header-lazy-loading-concurrency", 0, "If index-header lazy loading is enabled and this setting is > 0, maximum number of index-headers the store-gateway lazy loads concurrently, across all tenants. The other lazy loads wait in a queue served round-robin across tenants. 0 to disable the limit.")
+	f.IntVar(&cfg.IndexHeaderLazyLoadingQueueSize, "blocks-storage.bucket-store.index-header-lazy-loading-queue-size", 1000, "Maximum number of index-header lazy loads waiting for -blocks-storage.bucket-store.index-header-lazy-loading-concurrency. The queries requiring an index-header which can't be queued fail. 0 for an unlimited queue.")
+	f.DurationVar(&cfg.LazyTenantsLoadTimeout, "blocks-storage.bucket-store.lazy-tenants-load-timeout", 10*time.Second, "Maximum time a query waits for the blocks of a tenant configured with -store-gateway.lazy-tenant-loading-enabled to be loaded, when the tenant is queried and its blocks aren't loaded yet. If the blocks aren't loaded within this time, the query fails while the blocks loading continues in the background.")
+	f.DurationVar(&cfg.LazyTenantsIdleTimeout, "blocks-storage.bucket-store.lazy-tenants-idle-timeout", time.Hour, "How long the blocks of a tenant configured with -store-gateway.lazy-tenant-loading-enabled are kept loaded after the tenant was last queried. Once elapsed, the tenant's blocks are unloaded at the next blocks sync.")
 	f.Uint64Var(&cfg.PartitionerMaxGapBytes, "blocks-storage.bucket-store.partitioner-max-gap-bytes", DefaultPartitionerMaxGapSize, "Max size - in bytes - of a gap for which the partitioner aggregates together two bucket GET object requests.")
 	f.IntVar(&cfg.StreamingBatchSize, "blocks-storage.bucket-store.batch-series-size", 5000, "This option controls how many series to fetch per batch. The batch size must be greater than 0.")
 	f.IntVar(&cfg.ChunkRangesPerSeries, "blocks-storage.bucket-store.fine-grained-chunks-caching-ranges-per-series", 1, "This option controls into how many ranges the chunks of each series from each block are split. This value is effectively the number of chunks cache items per series per block when -blocks-storage.bucket-store.chunks-cache.fine-grained-chunks-caching-enabled is enabled.")
@@ -431,6 +451,9 @@ func (cfg *BucketStoreConfig) Validate(logger log.Logger) error {
 	if cfg.StreamingBatchSize <= 0 {
 		return errInvalidStreamingBatchSize
 	}
+	if cfg.IndexHeaderLazyLoadingConcurrency < 0 || cfg.IndexHeaderLazyLoadingQueueSize < 0 {
+		return errInvalidLazyLoadingLimits
+	}
 	if err := cfg.IndexCache.Validate(); err != nil {
 		return errors.Wrap(err, "index-cache configuration")
 	}
@@ -127,6 +127,18 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errInvalidStreamingBatchSize,
 		},
+		"should fail on negative index-header lazy loading concurrency": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderLazyLoadingConcurrency = -1
+			},
+			expectedErr: errInvalidLazyLoadingLimits,
+		},
+		"should fail on negative index-header lazy loading queue size": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderLazyLoadingQueueSize = -1
+			},
+			expectedErr: errInvalidLazyLoadingLimits,
+		},
 	}
 
 	for testName, testData := range tests {
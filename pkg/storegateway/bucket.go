@@ -34,6 +34,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/objstore/tracing"
+	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -51,8 +52,10 @@ import (
 	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/checksum"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
+	"github.com/grafana/mimir/pkg/util/querysource"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 )
 
@@ -130,8 +133,20 @@ type BucketStore struct {
 	// Additional configuration for experimental indexheader.BinaryReader behaviour.
 	indexHeaderCfg indexheader.Config
 
+	// indexHeaderLazyLoadLimiter limits the concurrent index-header lazy loads across all tenants. Nil if unlimited.
+	indexHeaderLazyLoadLimiter *indexheader.LazyLoadLimiter
+
 	// postingsStrategy is a strategy shared among all tenants.
 	postingsStrategy postingsSelectionStrategy
+
+	// Unix timestamp (seconds precision) of the update of the bucket index whose blocks have been
+	// synced by the last successful SyncBlocks(), or 0 if unknown.
+	bucketIndexSyncedAt atomic.Int64
+}
+
+// bucketIndexFetcher is implemented by the blocks metadata fetchers reading the bucket index.
+type bucketIndexFetcher interface {
+	BucketIndexUpdatedAt() time.Time
 }
 
 type noopCache struct{}
@@ -195,6 +210,13 @@ func WithChunksCache(cache chunkscache.Cache) BucketStoreOption {
 	}
 }
 
+// WithIndexHeaderLazyLoadLimiter sets the limiter of the concurrent index-header lazy loads, shared across tenants.
+func WithIndexHeaderLazyLoadLimiter(limiter *indexheader.LazyLoadLimiter) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.indexHeaderLazyLoadLimiter = limiter
+	}
+}
+
 // WithQueryGate sets a queryGate to use instead of a noopGate.
 func WithQueryGate(queryGate gate.Gate) BucketStoreOption {
 	return func(s *BucketStore) {
@@ -259,7 +281,7 @@ func NewBucketStore(
 	}
 
 	// Depend on the options
-	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, lazyIndexReaderIdleTimeout, metrics.indexHeaderReaderMetrics)
+	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, lazyIndexReaderIdleTimeout, s.indexHeaderLazyLoadLimiter, userID, metrics.indexHeaderReaderMetrics)
 
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, errors.Wrap(err, "create dir")
@@ -298,6 +320,11 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		return metaFetchErr
 	}
 
+	var indexUpdatedAt time.Time
+	if f, ok := s.fetcher.(bucketIndexFetcher); ok {
+		indexUpdatedAt = f.BucketIndexUpdatedAt()
+	}
+
 	var wg sync.WaitGroup
 	blockc := make(chan *block.Meta)
 
@@ -341,6 +368,10 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		level.Info(s.logger).Log("msg", "dropped outdated block", "block", id)
 	}
 
+	if !indexUpdatedAt.IsZero() {
+		s.bucketIndexSyncedAt.Store(indexUpdatedAt.Unix())
+	}
+
 	return nil
 }
 
@@ -401,6 +432,12 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta) (err error
 	}()
 	s.metrics.blockLoads.Inc()
 
+	if s.indexHeaderCfg.VerifyIndexDigestOnDownload {
+		if err := s.verifyIndexDigest(ctx, meta, dir); err != nil {
+			return err
+		}
+	}
+
 	indexHeaderReader, err := s.indexReaderPool.NewBinaryReader(
 		ctx,
 		s.logger,
@@ -452,6 +489,27 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta) (err error
 	return nil
 }
 
+// verifyIndexDigest verifies the block index in the bucket against the digest stored in the block meta,
+// unless the index header has already been built from it by this store-gateway.
+func (s *BucketStore) verifyIndexDigest(ctx context.Context, meta *block.Meta, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, block.IndexHeaderFilename)); err == nil {
+		return nil
+	}
+
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath != block.IndexFilename {
+			continue
+		}
+
+		err := block.VerifyObjectDigest(ctx, s.bkt, meta.ULID, f)
+		if errors.Is(err, block.ErrDigestMismatch) {
+			s.metrics.blockDigestMismatches.Inc()
+		}
+		return errors.Wrap(err, "verify index digest")
+	}
+	return nil
+}
+
 func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	defer func() {
 		if returnErr != nil {
@@ -530,6 +588,15 @@ type seriesChunks struct {
 	chks []storepb.AggrChunk
 }
 
+// BucketIndexSyncedAt returns the update time of the bucket index whose blocks have been synced
+// by the last successful blocks sync, or the zero time if unknown (e.g. the bucket index is not used).
+func (s *BucketStore) BucketIndexSyncedAt() time.Time {
+	if syncedAt := s.bucketIndexSyncedAt.Load(); syncedAt > 0 {
+		return time.Unix(syncedAt, 0)
+	}
+	return time.Time{}
+}
+
 // Series implements the storepb.StoreServer interface.
 func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) (err error) {
 	defer func() {
@@ -583,7 +650,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		chunksLimiter    = s.chunksLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunks"))
 		seriesLimiter    = s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
 	)
-	defer s.recordSeriesCallResult(stats)
+	defer s.recordSeriesCallResult(querysource.FromContext(ctx), stats)
 
 	if req.Hints != nil {
 		reqHints := &hintspb.SeriesRequestHints{}
@@ -657,6 +724,9 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				lset, _ = seriesSet.At()
 			} else {
 				lset, series.Chunks = seriesSet.At()
+				if req.ChunksChecksumsRequested {
+					setChunksChecksums(series.Chunks)
+				}
 
 				chunksCount += len(series.Chunks)
 				s.metrics.chunkSizeBytes.Observe(float64(chunksSize(series.Chunks)))
@@ -713,6 +783,15 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	return err
 }
 
+// setChunksChecksums sets the checksum of the data of each chunk, so that the querier can detect corrupted chunks.
+func setChunksChecksums(chks []storepb.AggrChunk) {
+	for _, chk := range chks {
+		if chk.Raw != nil {
+			chk.Raw.Checksum = checksum.Compute(chk.Raw.Data)
+		}
+	}
+}
+
 func chunksSize(chks []storepb.AggrChunk) (size int) {
 	for _, chk := range chks {
 		size += chk.Size() // This gets the encoded proto size.
@@ -816,7 +895,7 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 	return set, resHints, nil
 }
 
-func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
+func (s *BucketStore) recordSeriesCallResult(source string, safeStats *safeQueryStats) {
 	stats := safeStats.export()
 	s.recordPostingsStats(stats)
 	s.recordSeriesStats(stats)
@@ -833,7 +912,7 @@ func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 	s.metrics.seriesDataFetched.WithLabelValues("chunks", "refetched").Observe(float64(stats.chunksRefetched))
 	s.metrics.seriesDataSizeFetched.WithLabelValues("chunks", "refetched").Observe(float64(stats.chunksRefetchedSizeSum))
 
-	s.metrics.seriesBlocksQueried.Observe(float64(stats.blocksQueried))
+	s.metrics.seriesBlocksQueried.WithLabelValues(source).Observe(float64(stats.blocksQueried))
 
 	if s.fineGrainedChunksCachingEnabled {
 		s.metrics.seriesDataTouched.WithLabelValues("chunks", "processed").Observe(float64(stats.chunksProcessed))
@@ -850,10 +929,10 @@ func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 		s.metrics.seriesDataSizeTouched.WithLabelValues("chunks", "returned").Observe(float64(stats.chunksTouchedSizeSum))
 	}
 
-	s.metrics.resultSeriesCount.Observe(float64(stats.mergedSeriesCount))
+	s.metrics.resultSeriesCount.WithLabelValues(source).Observe(float64(stats.mergedSeriesCount))
 }
 
-func (s *BucketStore) recordLabelNamesCallResult(safeStats *safeQueryStats) {
+func (s *BucketStore) recordLabelNamesCallResult(source string, safeStats *safeQueryStats) {
 	stats := safeStats.export()
 	s.recordPostingsStats(stats)
 	s.recordSeriesStats(stats)
@@ -861,7 +940,7 @@ func (s *BucketStore) recordLabelNamesCallResult(safeStats *safeQueryStats) {
 	s.recordSeriesHashCacheStats(stats)
 	s.recordStreamingSeriesStats(stats)
 
-	s.metrics.seriesBlocksQueried.Observe(float64(stats.blocksQueried))
+	s.metrics.seriesBlocksQueried.WithLabelValues(source).Observe(float64(stats.blocksQueried))
 }
 
 func (s *BucketStore) recordLabelValuesCallResult(safeStats *safeQueryStats) {
@@ -955,7 +1034,7 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 		resHints = &hintspb.LabelNamesResponseHints{}
 	)
 
-	defer s.recordLabelNamesCallResult(stats)
+	defer s.recordLabelNamesCallResult(querysource.FromContext(ctx), stats)
 
 	var reqBlockMatchers []*labels.Matcher
 	if req.Hints != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
@@ -34,6 +35,9 @@ type BucketIndexMetadataFetcher struct {
 	logger      log.Logger
 	filters     []block.MetadataFilter
 	metrics     *block.FetcherMetrics
+
+	// Unix timestamp (seconds precision) of the last bucket index successfully fetched.
+	lastIndexUpdatedAt atomic.Int64
 }
 
 func NewBucketIndexMetadataFetcher(
@@ -117,6 +121,16 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 
 	f.metrics.Synced.WithLabelValues(block.LoadedMeta).Set(float64(len(metas)))
 	f.metrics.Submit()
+	f.lastIndexUpdatedAt.Store(idx.UpdatedAt)
 
 	return metas, nil, nil
 }
+
+// BucketIndexUpdatedAt returns the time the last bucket index successfully fetched was updated at,
+// or the zero time if no bucket index has been fetched yet.
+func (f *BucketIndexMetadataFetcher) BucketIndexUpdatedAt() time.Time {
+	if updatedAt := f.lastIndexUpdatedAt.Load(); updatedAt > 0 {
+		return time.Unix(updatedAt, 0)
+	}
+	return time.Time{}
+}
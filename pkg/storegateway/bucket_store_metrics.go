@@ -22,14 +22,15 @@ import (
 type BucketStoreMetrics struct {
 	blockLoads            prometheus.Counter
 	blockLoadFailures     prometheus.Counter
+	blockDigestMismatches prometheus.Counter
 	blockDrops            prometheus.Counter
 	blockDropFailures     prometheus.Counter
 	seriesDataTouched     *prometheus.SummaryVec
 	seriesDataFetched     *prometheus.SummaryVec
 	seriesDataSizeTouched *prometheus.SummaryVec
 	seriesDataSizeFetched *prometheus.SummaryVec
-	seriesBlocksQueried   prometheus.Summary
-	resultSeriesCount     prometheus.Summary
+	seriesBlocksQueried   *prometheus.SummaryVec
+	resultSeriesCount     *prometheus.SummaryVec
 	chunkSizeBytes        prometheus.Histogram
 	queriesDropped        *prometheus.CounterVec
 	seriesRefetches       prometheus.Counter
@@ -67,6 +68,10 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_block_load_failures_total",
 		Help: "Total number of failed remote block loading attempts.",
 	})
+	m.blockDigestMismatches = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_block_digest_mismatches_total",
+		Help: "Total number of remote blocks not loaded because their index doesn't match the digest stored in the block meta.json, a sign of corruption in the object storage.",
+	})
 	m.blockDrops = promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "cortex_bucket_store_block_drops_total",
 		Help: "Total number of local blocks that were dropped.",
@@ -93,18 +98,18 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Help: "Size of all items of a data type in a block were fetched for a single Series/LabelValues/LabelNames request. This includes chunks from the cache and the object storage.",
 	}, []string{"data_type", "stage"})
 
-	m.seriesBlocksQueried = promauto.With(reg).NewSummary(prometheus.SummaryOpts{
+	m.seriesBlocksQueried = promauto.With(reg).NewSummaryVec(prometheus.SummaryOpts{
 		Name: "cortex_bucket_store_series_blocks_queried",
 		Help: "Number of blocks in a bucket store that were touched to satisfy a query.",
-	})
+	}, []string{"source"})
 	m.seriesRefetches = promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "cortex_bucket_store_series_refetches_total",
 		Help: "Total number of cases where the built-in max series size was not enough to fetch series from index, resulting in refetch.",
 	})
-	m.resultSeriesCount = promauto.With(reg).NewSummary(prometheus.SummaryOpts{
+	m.resultSeriesCount = promauto.With(reg).NewSummaryVec(prometheus.SummaryOpts{
 		Name: "cortex_bucket_store_series_result_series",
 		Help: "Number of series observed in the final result of a query after merging identical series from different blocks.",
-	})
+	}, []string{"source"})
 	m.queriesDropped = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 		Name: "cortex_bucket_store_queries_dropped_total",
 		Help: "Number of queries that were dropped due to the max chunks per query limit.",
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -24,13 +25,16 @@ import (
 	tsdb_errors "github.com/prometheus/prometheus/tsdb/errors"
 	"github.com/prometheus/prometheus/tsdb/hashcache"
 	"github.com/thanos-io/objstore"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
 	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	"github.com/grafana/mimir/pkg/storegateway/indexheader"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
@@ -41,6 +45,10 @@ import (
 // (This is now separate from DeprecatedTenantIDExternalLabel to signify different use case.)
 const GrpcContextMetadataTenantID = "__org_id__"
 
+// BucketIndexSyncedAtHeader is the gRPC response header used by the store-gateway to report the update time
+// (unix timestamp, seconds precision) of the tenant's bucket index whose blocks have been synced by the store-gateway.
+const BucketIndexSyncedAtHeader = "mimir-bucket-index-synced-at"
+
 // BucketStores is a multi-tenant wrapper of Thanos BucketStore.
 type BucketStores struct {
 	logger             log.Logger
@@ -57,6 +65,9 @@ type BucketStores struct {
 
 	chunksCache chunkscache.Cache
 
+	// Local disk tier of the chunks cache, storing the items in the background. Nil if not configured.
+	chunksDiskCache *bucketcache.DiskCache
+
 	// Series hash cache shared across all tenants.
 	seriesHashCache *hashcache.SeriesHashCache
 
@@ -66,16 +77,34 @@ type BucketStores struct {
 	// Gate used to limit query concurrency across all tenants.
 	queryGate gate.Gate
 
+	// Limiter of the index-header lazy loads across all tenants. Nil if unlimited.
+	indexHeaderLazyLoadLimiter *indexheader.LazyLoadLimiter
+
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
 	stores   map[string]*BucketStore
 
+	// Keeps track of the tenants whose blocks are loaded only once queried.
+	lazyTenantsMu       sync.Mutex
+	lazyTenantsLoading  map[string]*lazyTenantLoad
+	lazyTenantsLastUsed map[string]time.Time
+
 	// Metrics.
 	syncTimes         prometheus.Histogram
 	syncLastSuccess   prometheus.Gauge
 	tenantsDiscovered prometheus.Gauge
 	tenantsSynced     prometheus.Gauge
 	blocksLoaded      prometheus.GaugeFunc
+
+	lazyTenantsLoads        prometheus.Counter
+	lazyTenantsLoadFailures prometheus.Counter
+	lazyTenantsUnloads      prometheus.Counter
+}
+
+// lazyTenantLoad is the loading of the blocks of a lazy tenant, shared by all the queries waiting for it.
+type lazyTenantLoad struct {
+	done chan struct{}
+	err  error
 }
 
 // NewBucketStores makes a new BucketStores.
@@ -85,6 +114,11 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		return nil, errors.Wrapf(err, "chunks-cache")
 	}
 
+	chunksCacheClient, err = tsdb.WrapWithChunksDiskCache(chunksCacheClient, cfg.BucketStore.ChunksCache, logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
 	cachingBucket, err := tsdb.CreateCachingBucket(chunksCacheClient, cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, bucketClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "create caching bucket")
@@ -96,17 +130,19 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	queryGate = gate.NewInstrumented(queryGateReg, cfg.BucketStore.MaxConcurrent, queryGate)
 
 	u := &BucketStores{
-		logger:             logger,
-		cfg:                cfg,
-		limits:             limits,
-		bucket:             cachingBucket,
-		shardingStrategy:   shardingStrategy,
-		stores:             map[string]*BucketStore{},
-		bucketStoreMetrics: NewBucketStoreMetrics(reg),
-		metaFetcherMetrics: NewMetadataFetcherMetrics(),
-		queryGate:          queryGate,
-		partitioners:       newGapBasedPartitioners(cfg.BucketStore.PartitionerMaxGapBytes, reg),
-		seriesHashCache:    hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
+		logger:              logger,
+		cfg:                 cfg,
+		limits:              limits,
+		bucket:              cachingBucket,
+		shardingStrategy:    shardingStrategy,
+		stores:              map[string]*BucketStore{},
+		lazyTenantsLoading:  map[string]*lazyTenantLoad{},
+		lazyTenantsLastUsed: map[string]time.Time{},
+		bucketStoreMetrics:  NewBucketStoreMetrics(reg),
+		metaFetcherMetrics:  NewMetadataFetcherMetrics(),
+		queryGate:           queryGate,
+		partitioners:        newGapBasedPartitioners(cfg.BucketStore.PartitionerMaxGapBytes, reg),
+		seriesHashCache:     hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
 		syncBackoffConfig: backoff.Config{
 			MinBackoff: 1 * time.Second,
 			MaxBackoff: 10 * time.Second,
@@ -136,6 +172,18 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		Name: "cortex_bucket_store_blocks_loaded",
 		Help: "Number of currently loaded blocks.",
 	}, u.getBlocksLoadedMetric)
+	u.lazyTenantsLoads = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_stores_lazy_tenants_loads_total",
+		Help: "Total number of times the blocks of a lazy tenant have been loaded because the tenant has been queried.",
+	})
+	u.lazyTenantsLoadFailures = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_stores_lazy_tenants_load_failures_total",
+		Help: "Total number of times the blocks of a lazy tenant failed to be loaded.",
+	})
+	u.lazyTenantsUnloads = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_stores_lazy_tenants_unloads_total",
+		Help: "Total number of times the blocks of a lazy tenant have been unloaded because the tenant has not been queried for longer than the idle timeout.",
+	})
 
 	// Init the index cache.
 	if u.indexCache, err = tsdb.NewIndexCache(cfg.BucketStore.IndexCache, logger, reg); err != nil {
@@ -147,6 +195,12 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		return nil, errors.Wrap(err, "create chunks cache")
 	}
 	u.chunksCache = chunkscache.NewTracingCache(chunksCache, logger)
+	u.chunksDiskCache, _ = chunksCacheClient.(*bucketcache.DiskCache)
+
+	// The number of concurrent index-header lazy loads across the tenants BucketStores are limited.
+	if cfg.BucketStore.IndexHeaderLazyLoadingEnabled && cfg.BucketStore.IndexHeaderLazyLoadingConcurrency > 0 {
+		u.indexHeaderLazyLoadLimiter = indexheader.NewLazyLoadLimiter(cfg.BucketStore.IndexHeaderLazyLoadingConcurrency, cfg.BucketStore.IndexHeaderLazyLoadingQueueSize, prometheus.WrapRegistererWithPrefix("cortex_bucket_store_", reg))
+	}
 
 	if reg != nil {
 		reg.MustRegister(u.metaFetcherMetrics)
@@ -155,6 +209,13 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	return u, nil
 }
 
+// stop stops the background operations shared across all tenants, waiting until they're done.
+func (u *BucketStores) stop() {
+	if u.chunksDiskCache != nil {
+		u.chunksDiskCache.Stop()
+	}
+}
+
 // InitialSync does an initial synchronization of blocks for all users.
 func (u *BucketStores) InitialSync(ctx context.Context) error {
 	level.Info(u.logger).Log("msg", "synchronizing TSDB blocks for all users")
@@ -257,6 +318,11 @@ func (u *BucketStores) syncUsersBlocks(ctx context.Context, f func(context.Conte
 	// Lazily create a bucket store for each new user found
 	// and submit a sync job for each user.
 	for userID := range includeUserIDs {
+		// The blocks of lazy tenants are only synced once loaded by a query.
+		if u.limits.StoreGatewayLazyTenantLoadingEnabled(userID) && !u.keepLazyTenantLoaded(userID) {
+			continue
+		}
+
 		bs, err := u.getOrCreateStore(userID)
 		if err != nil {
 			errsMx.Lock()
@@ -299,11 +365,19 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 		return fmt.Errorf("no userID")
 	}
 
-	store := u.getStore(userID)
+	store, err := u.getStoreForQuery(spanCtx, userID)
+	if err != nil {
+		return err
+	}
 	if store == nil {
 		return nil
 	}
 
+	if md := bucketIndexSyncedAtMetadata(store); md != nil {
+		// The header is just a hint for the querier, so we don't fail the request if it can't be set.
+		_ = srv.SetHeader(md)
+	}
+
 	return store.Series(req, spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
@@ -320,11 +394,18 @@ func (u *BucketStores) LabelNames(ctx context.Context, req *storepb.LabelNamesRe
 		return nil, fmt.Errorf("no userID")
 	}
 
-	store := u.getStore(userID)
+	store, err := u.getStoreForQuery(spanCtx, userID)
+	if err != nil {
+		return nil, err
+	}
 	if store == nil {
 		return &storepb.LabelNamesResponse{}, nil
 	}
 
+	if md := bucketIndexSyncedAtMetadata(store); md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
 	return store.LabelNames(ctx, req)
 }
 
@@ -338,14 +419,31 @@ func (u *BucketStores) LabelValues(ctx context.Context, req *storepb.LabelValues
 		return nil, fmt.Errorf("no userID")
 	}
 
-	store := u.getStore(userID)
+	store, err := u.getStoreForQuery(spanCtx, userID)
+	if err != nil {
+		return nil, err
+	}
 	if store == nil {
 		return &storepb.LabelValuesResponse{}, nil
 	}
 
+	if md := bucketIndexSyncedAtMetadata(store); md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
 	return store.LabelValues(ctx, req)
 }
 
+// bucketIndexSyncedAtMetadata returns the gRPC metadata reporting the bucket index synced by the store,
+// or nil if unknown.
+func bucketIndexSyncedAtMetadata(store *BucketStore) metadata.MD {
+	syncedAt := store.BucketIndexSyncedAt()
+	if syncedAt.IsZero() {
+		return nil
+	}
+	return metadata.Pairs(BucketIndexSyncedAtHeader, strconv.FormatInt(syncedAt.Unix(), 10))
+}
+
 // scanUsers in the bucket and return the list of found users. If an error occurs while
 // iterating the bucket, it may return both an error and a subset of the users in the bucket.
 func (u *BucketStores) scanUsers(ctx context.Context) ([]string, error) {
@@ -408,6 +506,148 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		return bs, nil
 	}
 
+	bs, fetcherReg, err := u.createStore(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.stores[userID] = bs
+	u.metaFetcherMetrics.AddUserRegistry(userID, fetcherReg)
+
+	return bs, nil
+}
+
+// getStoreForQuery returns the bucket store of the user to query. The blocks of lazy tenants are loaded
+// on the first query, waiting for them up to the configured load timeout. It returns nil if the user
+// has no bucket store in this store-gateway.
+func (u *BucketStores) getStoreForQuery(ctx context.Context, userID string) (*BucketStore, error) {
+	if !u.limits.StoreGatewayLazyTenantLoadingEnabled(userID) {
+		return u.getStore(userID), nil
+	}
+
+	u.lazyTenantsMu.Lock()
+	if store := u.getStore(userID); store != nil {
+		u.lazyTenantsLastUsed[userID] = time.Now()
+		u.lazyTenantsMu.Unlock()
+		return store, nil
+	}
+	load, loading := u.lazyTenantsLoading[userID]
+	u.lazyTenantsMu.Unlock()
+
+	if !loading {
+		// Only load the blocks of tenants owned by this store-gateway.
+		ownedUserIDs, err := u.shardingStrategy.FilterUsers(ctx, []string{userID})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to check tenant ownership")
+		}
+		if len(ownedUserIDs) == 0 {
+			return nil, nil
+		}
+
+		u.lazyTenantsMu.Lock()
+		// Check again for the store in the event it was loaded in-between locks.
+		if store := u.getStore(userID); store != nil {
+			u.lazyTenantsLastUsed[userID] = time.Now()
+			u.lazyTenantsMu.Unlock()
+			return store, nil
+		}
+		load, loading = u.lazyTenantsLoading[userID]
+		if !loading {
+			load = &lazyTenantLoad{done: make(chan struct{})}
+			u.lazyTenantsLoading[userID] = load
+
+			// The loading is not bound to the query, so that it's not canceled if the query times out.
+			go u.loadLazyTenant(userID, load)
+		}
+		u.lazyTenantsMu.Unlock()
+	}
+
+	timer := time.NewTimer(u.cfg.BucketStore.LazyTenantsLoadTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, fmt.Errorf("the blocks of tenant %s are still being loaded, please retry later", userID)
+	case <-load.done:
+	}
+
+	if load.err != nil {
+		return nil, errors.Wrapf(load.err, "failed to load the blocks of tenant %s", userID)
+	}
+	return u.getStore(userID), nil
+}
+
+// loadLazyTenant creates the bucket store of a lazy tenant and loads its blocks.
+// The store is added to the stores only once all its blocks have been loaded.
+func (u *BucketStores) loadLazyTenant(userID string, load *lazyTenantLoad) {
+	userLogger := util_log.WithUserID(userID, u.logger)
+	level.Info(userLogger).Log("msg", "loading blocks of lazy tenant")
+
+	bs, fetcherReg, err := u.createStore(userID)
+	if err == nil {
+		if err = bs.InitialSync(context.Background()); err != nil {
+			if closeErr := bs.RemoveBlocksAndClose(); closeErr != nil {
+				level.Warn(userLogger).Log("msg", "failed to close bucket store after failing to load blocks of lazy tenant", "err", closeErr)
+			}
+		}
+	}
+
+	u.lazyTenantsMu.Lock()
+	if err == nil {
+		u.storesMu.Lock()
+		u.stores[userID] = bs
+		u.storesMu.Unlock()
+		u.metaFetcherMetrics.AddUserRegistry(userID, fetcherReg)
+
+		u.lazyTenantsLastUsed[userID] = time.Now()
+		u.lazyTenantsLoads.Inc()
+		level.Info(userLogger).Log("msg", "loaded blocks of lazy tenant")
+	} else {
+		u.lazyTenantsLoadFailures.Inc()
+		level.Warn(userLogger).Log("msg", "failed to load blocks of lazy tenant", "err", err)
+	}
+
+	load.err = err
+	delete(u.lazyTenantsLoading, userID)
+	u.lazyTenantsMu.Unlock()
+
+	close(load.done)
+}
+
+// keepLazyTenantLoaded returns whether the blocks of the lazy tenant are loaded and should be kept loaded.
+// The blocks of a lazy tenant which hasn't been queried for longer than the idle timeout are unloaded,
+// and its local files deleted.
+func (u *BucketStores) keepLazyTenantLoaded(userID string) bool {
+	u.lazyTenantsMu.Lock()
+	defer u.lazyTenantsMu.Unlock()
+
+	if u.getStore(userID) == nil {
+		return false
+	}
+	if time.Since(u.lazyTenantsLastUsed[userID]) < u.cfg.BucketStore.LazyTenantsIdleTimeout {
+		return true
+	}
+
+	userLogger := util_log.WithUserID(userID, u.logger)
+	if err := u.closeBucketStore(userID); err != nil {
+		level.Warn(userLogger).Log("msg", "failed to close bucket store of idle lazy tenant", "err", err)
+	}
+	if err := os.RemoveAll(u.syncDirForUser(userID)); err != nil {
+		level.Warn(userLogger).Log("msg", "failed to delete sync directory of idle lazy tenant", "err", err)
+	}
+
+	delete(u.lazyTenantsLastUsed, userID)
+	u.lazyTenantsUnloads.Inc()
+	level.Info(userLogger).Log("msg", "unloaded blocks of idle lazy tenant")
+
+	return false
+}
+
+// createStore creates a new bucket store for the user, without adding it to the stores.
+// It returns the store and the registry of its blocks metadata fetcher metrics.
+func (u *BucketStores) createStore(userID string) (*BucketStore, *prometheus.Registry, error) {
 	userLogger := util_log.WithUserID(userID, u.logger)
 
 	level.Info(userLogger).Log("msg", "creating user bucket store")
@@ -449,7 +689,7 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 			filters,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -458,6 +698,7 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		WithIndexCache(u.indexCache),
 		WithChunksCache(u.chunksCache),
 		WithQueryGate(u.queryGate),
+		WithIndexHeaderLazyLoadLimiter(u.indexHeaderLazyLoadLimiter),
 		WithFineGrainedChunksCaching(u.cfg.BucketStore.ChunksCache.FineGrainedChunksCachingEnabled),
 	}
 
@@ -486,13 +727,10 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		bucketStoreOpts...,
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	u.stores[userID] = bs
-	u.metaFetcherMetrics.AddUserRegistry(userID, fetcherReg)
-
-	return bs, nil
+	return bs, fetcherReg, nil
 }
 
 func selectPostingsStrategy(l log.Logger, name string, worstCaseSeriesPreference float64) postingsSelectionStrategy {
@@ -532,6 +770,10 @@ func (u *BucketStores) closeBucketStoreAndDeleteLocalFilesForExcludedTenants(inc
 			continue
 		}
 
+		u.lazyTenantsMu.Lock()
+		delete(u.lazyTenantsLastUsed, userID)
+		u.lazyTenantsMu.Unlock()
+
 		err := u.closeBucketStore(userID)
 		switch {
 		case errors.Is(err, errBucketStoreNotFound):
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
+	dskit_metrics "github.com/grafana/dskit/metrics"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -37,6 +39,8 @@ import (
 	filesystemstore "github.com/thanos-io/objstore/providers/filesystem"
 	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	grpc_metadata "google.golang.org/grpc/metadata"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
@@ -44,13 +48,32 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/querysource"
 	"github.com/grafana/mimir/pkg/util/test"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
+func TestBucketStores_StopShouldStopChunksDiskCache(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.ChunksCache.DiskCache.Dir = t.TempDir()
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, stores.chunksDiskCache)
+
+	// The goroutine storing the items on disk should have terminated once stopped.
+	stores.stop()
+}
+
 func TestBucketStores_InitialSync(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -264,6 +287,213 @@ func TestBucketStores_SyncBlocks(t *testing.T) {
 	assert.Greater(t, testutil.ToFloat64(stores.syncLastSuccess), float64(0))
 }
 
+func TestBucketStores_ShouldLoadBlocksOfLazyTenantsOnFirstQuery(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	const (
+		lazyUserID  = "user-lazy"
+		eagerUserID = "user-eager"
+		metricName  = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.LazyTenantsLoadTimeout = 10 * time.Second
+	cfg.BucketStore.LazyTenantsIdleTimeout = time.Hour
+
+	storageDir := t.TempDir()
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	generateStorageBlock(t, storageDir, lazyUserID, metricName, 10, 100, 15)
+	generateStorageBlock(t, storageDir, eagerUserID, metricName, 10, 100, 15)
+
+	lazyLimits := defaultLimitsConfig()
+	lazyLimits.StoreGatewayLazyTenantLoadingEnabled = true
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+		lazyUserID: &lazyLimits,
+	}))
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, overrides, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	// The initial sync should only load the blocks of the eager tenant.
+	require.NoError(t, stores.InitialSync(ctx))
+	assert.NotNil(t, stores.getStore(eagerUserID))
+	assert.Nil(t, stores.getStore(lazyUserID))
+
+	// The first query of the lazy tenant should load its blocks.
+	seriesSet, warnings, err := querySeries(t, stores, lazyUserID, metricName, 20, 40)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, seriesSet, 1)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: metricName}}, seriesSet[0].Labels)
+	assert.NotNil(t, stores.getStore(lazyUserID))
+
+	// A sync shouldn't unload the blocks of the lazy tenant while it's queried.
+	require.NoError(t, stores.SyncBlocks(ctx))
+	assert.NotNil(t, stores.getStore(lazyUserID))
+	assert.DirExists(t, stores.syncDirForUser(lazyUserID))
+
+	// A sync should unload the blocks of the lazy tenant once idle.
+	stores.lazyTenantsMu.Lock()
+	stores.lazyTenantsLastUsed[lazyUserID] = time.Now().Add(-2 * time.Hour)
+	stores.lazyTenantsMu.Unlock()
+
+	require.NoError(t, stores.SyncBlocks(ctx))
+	assert.Nil(t, stores.getStore(lazyUserID))
+	assert.NoDirExists(t, stores.syncDirForUser(lazyUserID))
+	assert.NotNil(t, stores.getStore(eagerUserID))
+
+	// The blocks of the lazy tenant should be loaded again once queried.
+	seriesSet, _, err = querySeries(t, stores, lazyUserID, metricName, 20, 40)
+	require.NoError(t, err)
+	assert.Len(t, seriesSet, 1)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_stores_lazy_tenants_loads_total Total number of times the blocks of a lazy tenant have been loaded because the tenant has been queried.
+			# TYPE cortex_bucket_stores_lazy_tenants_loads_total counter
+			cortex_bucket_stores_lazy_tenants_loads_total 2
+
+			# HELP cortex_bucket_stores_lazy_tenants_load_failures_total Total number of times the blocks of a lazy tenant failed to be loaded.
+			# TYPE cortex_bucket_stores_lazy_tenants_load_failures_total counter
+			cortex_bucket_stores_lazy_tenants_load_failures_total 0
+
+			# HELP cortex_bucket_stores_lazy_tenants_unloads_total Total number of times the blocks of a lazy tenant have been unloaded because the tenant has not been queried for longer than the idle timeout.
+			# TYPE cortex_bucket_stores_lazy_tenants_unloads_total counter
+			cortex_bucket_stores_lazy_tenants_unloads_total 1
+	`),
+		"cortex_bucket_stores_lazy_tenants_loads_total",
+		"cortex_bucket_stores_lazy_tenants_load_failures_total",
+		"cortex_bucket_stores_lazy_tenants_unloads_total",
+	))
+}
+
+func TestBucketStores_ShouldReportSyncedBucketIndex(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.BucketIndex.DeprecatedEnabled = true
+
+	storageDir := t.TempDir()
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bkt, defaultLimitsOverrides(t), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// Generate a block and the bucket index including it.
+	generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	idx, _, err := bucketindex.NewUpdater(bkt, userID, nil, log.NewNopLogger()).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	idx.UpdatedAt = time.Now().Add(-time.Minute).Unix()
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+
+	require.NoError(t, stores.InitialSync(ctx))
+	expected := strconv.FormatInt(idx.UpdatedAt, 10)
+
+	srv := newBucketStoreTestServer(t, stores)
+	conn, err := grpc.Dial(srv.serverListener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, conn.Close()) })
+
+	client := storepb.NewStoreClient(conn)
+	reqCtx := setUserIDToGRPCContext(ctx, userID)
+
+	// The synced bucket index should be reported when querying series.
+	stream, err := client.Series(reqCtx, &storepb.SeriesRequest{
+		MinTime:  10,
+		MaxTime:  100,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: metricName}},
+	})
+	require.NoError(t, err)
+	for {
+		if _, err := stream.Recv(); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+	}
+	md, err := stream.Header()
+	require.NoError(t, err)
+	assert.Equal(t, []string{expected}, md.Get(BucketIndexSyncedAtHeader))
+
+	// The synced bucket index should be reported when querying label names.
+	md = nil
+	_, err = client.LabelNames(reqCtx, &storepb.LabelNamesRequest{Start: 10, End: 100}, grpc.Header(&md))
+	require.NoError(t, err)
+	assert.Equal(t, []string{expected}, md.Get(BucketIndexSyncedAtHeader))
+}
+
+func TestBucketStores_ShouldTrackSeriesMetricsByQuerySource(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bkt, defaultLimitsOverrides(t), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// Create a gRPC server attaching the query source propagated by the client to the context.
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.ChainStreamInterceptor(querysource.ServerStreamInterceptor))
+	storepb.RegisterStoreServer(server, stores)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.GracefulStop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithStreamInterceptor(querysource.ClientStreamInterceptor))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, conn.Close()) })
+
+	client := storepb.NewStoreClient(conn)
+
+	for _, source := range []string{querysource.API, querysource.Ruler, querysource.Alerting, querysource.Alerting} {
+		reqCtx := setUserIDToGRPCContext(querysource.ContextWithSource(ctx, source), userID)
+		stream, err := client.Series(reqCtx, &storepb.SeriesRequest{
+			MinTime:  10,
+			MaxTime:  100,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: metricName}},
+		})
+		require.NoError(t, err)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+		}
+	}
+
+	metrics, err := dskit_metrics.NewMetricFamilyMapFromGatherer(reg)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), numObservationsForSummaries(t, "cortex_bucket_store_series_result_series", metrics, "source", querysource.API))
+	assert.Equal(t, uint64(1), numObservationsForSummaries(t, "cortex_bucket_store_series_result_series", metrics, "source", querysource.Ruler))
+	assert.Equal(t, uint64(2), numObservationsForSummaries(t, "cortex_bucket_store_series_result_series", metrics, "source", querysource.Alerting))
+}
+
 func TestBucketStores_syncUsersBlocks(t *testing.T) {
 	test.VerifyNoLeak(t)
 
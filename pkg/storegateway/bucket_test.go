@@ -62,6 +62,7 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader/index"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/checksum"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/test"
 )
@@ -1750,7 +1751,7 @@ func TestBucketStore_Series_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		logger:          logger,
 		indexCache:      indexCache,
 		chunksCache:     chunkscache.NoopCache{},
-		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, 0, indexheader.NewReaderPoolMetrics(nil)),
+		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, 0, nil, "", indexheader.NewReaderPoolMetrics(nil)),
 		metrics:         NewBucketStoreMetrics(nil),
 		blockSet:        &bucketBlockSet{blocks: []*bucketBlock{b1, b2}},
 		blocks: map[ulid.ULID]*bucketBlock{
@@ -1945,6 +1946,75 @@ func TestBucketStore_Series_ErrorUnmarshallingRequestHints(t *testing.T) {
 	assert.Equal(t, true, regexp.MustCompile(".*unmarshal series request hints.*").MatchString(err.Error()))
 }
 
+func TestBucketStore_SyncBlocks_ShouldNotLoadBlocksWithIndexDigestMismatch(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	bktDir := filepath.Join(tmpDir, "bkt")
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, bkt.Close()) }()
+
+	logger := log.NewNopLogger()
+	instrBkt := objstore.WithNoopInstr(bkt)
+
+	// Upload two blocks, and then corrupt the index of the first one.
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2"), labels.FromStrings("a", "3")}
+	var blockIDs []ulid.ULID
+	for i := 0; i < 2; i++ {
+		id, err := block.CreateBlock(ctx, filepath.Join(tmpDir, "tmp"), series, 10, 0, 1000, labels.EmptyLabels())
+		require.NoError(t, err)
+		require.NoError(t, block.Upload(ctx, logger, bkt, filepath.Join(tmpDir, "tmp", id.String()), nil))
+		blockIDs = append(blockIDs, id)
+	}
+
+	indexFile := filepath.Join(bktDir, blockIDs[0].String(), block.IndexFilename)
+	index, err := os.ReadFile(indexFile)
+	require.NoError(t, err)
+	index[len(index)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(indexFile, index, 0644))
+
+	fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, tmpDir, nil, nil)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	store, err := NewBucketStore(
+		"test",
+		instrBkt,
+		fetcher,
+		filepath.Join(tmpDir, "store"),
+		5000,
+		1,
+		selectAllStrategy{},
+		newStaticChunksLimiterFactory(0),
+		newStaticSeriesLimiterFactory(0),
+		newGapBasedPartitioners(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+		10,
+		mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+		indexheader.Config{VerifyIndexDigestOnDownload: true},
+		false,
+		0,
+		hashcache.NewSeriesHashCache(1024*1024),
+		NewBucketStoreMetrics(reg),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, store.RemoveBlocksAndClose()) }()
+
+	require.NoError(t, store.SyncBlocks(ctx))
+
+	store.blocksMx.RLock()
+	assert.NotContains(t, store.blocks, blockIDs[0])
+	assert.Contains(t, store.blocks, blockIDs[1])
+	store.blocksMx.RUnlock()
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_block_digest_mismatches_total Total number of remote blocks not loaded because their index doesn't match the digest stored in the block meta.json, a sign of corruption in the object storage.
+		# TYPE cortex_bucket_store_block_digest_mismatches_total counter
+		cortex_bucket_store_block_digest_mismatches_total 1
+	`), "cortex_bucket_store_block_digest_mismatches_total"))
+}
+
 func TestBucketStore_Series_CanceledRequest(t *testing.T) {
 	tmpDir := t.TempDir()
 	bktDir := filepath.Join(tmpDir, "bkt")
@@ -2163,6 +2233,7 @@ func testBucketStoreSeriesBlockWithMultipleChunks(
 	tests := map[string]struct {
 		reqMinTime      int64
 		reqMaxTime      int64
+		reqChecksums    bool
 		expectedSamples int
 	}{
 		"query the entire block": {
@@ -2170,6 +2241,12 @@ func testBucketStoreSeriesBlockWithMultipleChunks(
 			reqMaxTime:      math.MaxInt64,
 			expectedSamples: 10000,
 		},
+		"query the entire block with chunks checksums": {
+			reqMinTime:      math.MinInt64,
+			reqMaxTime:      math.MaxInt64,
+			reqChecksums:    true,
+			expectedSamples: 10000,
+		},
 		"query the beginning of the block": {
 			reqMinTime:      0,
 			reqMaxTime:      100,
@@ -2195,6 +2272,7 @@ func testBucketStoreSeriesBlockWithMultipleChunks(
 				Matchers: []storepb.LabelMatcher{
 					{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "test"},
 				},
+				ChunksChecksumsRequested: testData.reqChecksums,
 			}
 
 			seriesSet, _, _, err := srv.Series(context.Background(), req)
@@ -2204,6 +2282,12 @@ func testBucketStoreSeriesBlockWithMultipleChunks(
 			// Count the number of samples in the returned chunks.
 			numSamples := 0
 			for _, rawChunk := range seriesSet[0].Chunks {
+				if testData.reqChecksums {
+					assert.Equal(t, checksum.Compute(rawChunk.Raw.Data), rawChunk.Raw.Checksum)
+				} else {
+					assert.Zero(t, rawChunk.Raw.Checksum)
+				}
+
 				decodedChunk, err := chunkenc.FromData(encoding, rawChunk.Raw.Data)
 				assert.NoError(t, err)
 
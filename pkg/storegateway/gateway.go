@@ -9,6 +9,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/go-kit/log"
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/ringadvisor"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -80,6 +82,7 @@ type StoreGateway struct {
 	// Ring used for sharding blocks.
 	ringLifecycler *ring.BasicLifecycler
 	ring           *ring.Ring
+	ringAdvisor    http.Handler
 
 	// Subservices manager (ring, lifecycler)
 	subservices        *services.Manager
@@ -159,6 +162,7 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 	if err != nil {
 		return nil, errors.Wrap(err, "create ring client")
 	}
+	g.ringAdvisor = ringadvisor.NewHandler(ringadvisor.ConfigFromRingConfig(ringCfg, true), ringStore, RingKey)
 
 	shardingStrategy = NewShuffleShardingStrategy(g.ring, lifecyclerCfg.ID, lifecyclerCfg.Addr, limits, logger)
 
@@ -177,6 +181,9 @@ func (g *StoreGateway) starting(ctx context.Context) (err error) {
 	// from the ring. We do it ensuring dependencies are gracefully stopped if they
 	// were already started.
 	defer func() {
+		if err != nil {
+			g.stores.stop()
+		}
 		if err == nil || g.subservices == nil {
 			return
 		}
@@ -293,6 +300,7 @@ func (g *StoreGateway) stopping(_ error) error {
 		}
 	}
 
+	g.stores.stop()
 	g.unsetPrepareShutdownMarker()
 	return nil
 }
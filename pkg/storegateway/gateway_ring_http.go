@@ -45,3 +45,13 @@ func (c *StoreGateway) RingHandler(w http.ResponseWriter, req *http.Request) {
 
 	c.ring.ServeHTTP(w, req)
 }
+
+// RingRebalancingHandler serves the rebalancing recommendations of the store-gateways ring.
+func (c *StoreGateway) RingRebalancingHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Store gateway is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	c.ringAdvisor.ServeHTTP(w, req)
+}
@@ -54,9 +54,12 @@ type Reader interface {
 type Config struct {
 	MaxIdleFileHandles uint `yaml:"max_idle_file_handles" category:"advanced"`
 	VerifyOnLoad       bool `yaml:"verify_on_load" category:"advanced"`
+
+	VerifyIndexDigestOnDownload bool `yaml:"verify_index_digest_on_download" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.UintVar(&cfg.MaxIdleFileHandles, prefix+"max-idle-file-handles", 1, "Maximum number of idle file handles the store-gateway keeps open for each index header file.")
 	f.BoolVar(&cfg.VerifyOnLoad, prefix+"verify-on-load", false, "If true, verify the checksum of index headers upon loading them (either on startup or lazily when lazy loading is enabled). Setting to true helps detect disk corruption at the cost of slowing down index header loading.")
+	f.BoolVar(&cfg.VerifyIndexDigestOnDownload, prefix+"verify-index-digest-on-download", false, "If true, before building the index header of a block not yet loaded by this store-gateway, verify the whole block index in the object storage against the digest stored in the block meta.json. Blocks with a mismatching index are not loaded. Setting to true helps detect object storage corruption at the cost of downloading the whole index of each new block.")
 }
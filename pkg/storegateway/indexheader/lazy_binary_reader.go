@@ -256,7 +256,11 @@ func (r *LazyBinaryReader) load() (returnErr error) {
 	reader, err := r.readerFactory()
 	if err != nil {
 		r.metrics.loadFailedCount.Inc()
-		r.readerErr = err
+		// The lazy load can be retried once the queue of the lazy loads isn't full anymore, or if the wait
+		// for the lazy load limiter has been canceled.
+		if !errors.Is(err, ErrLazyLoadQueueFull) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			r.readerErr = err
+		}
 		return errors.Wrapf(err, "lazy load index-header file at %s", r.filepath)
 	}
 
@@ -172,6 +172,55 @@ func TestLazyBinaryReader_ShouldReopenOnUsageAfterClose(t *testing.T) {
 	})
 }
 
+func TestLazyBinaryReader_ShouldRetryLoadRejectedByTheLazyLoadLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir := filepath.Join(t.TempDir(), "test-indexheader")
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bkt.Close()) })
+
+	// Create block.
+	blockID, err := block.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("a", "1"),
+		labels.FromStrings("a", "2"),
+		labels.FromStrings("a", "3"),
+	}, 100, 0, 1000, labels.FromStrings("ext1", "1"))
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, blockID.String()), nil))
+
+	// Fill the queue of the limiter.
+	limiter := NewLazyLoadLimiter(1, 1, nil)
+	release, err := limiter.acquire(context.Background(), "user-1")
+	require.NoError(t, err)
+	go func() {
+		release, err := limiter.acquire(context.Background(), "user-1")
+		if err == nil {
+			release()
+		}
+	}()
+	waitQueued(t, limiter, 1)
+
+	pool := newReaderPool(log.NewNopLogger(), true, 0, limiter, "user-1", NewReaderPoolMetrics(nil))
+	reader, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
+	require.NoError(t, err)
+	r := reader.(*LazyBinaryReader)
+	t.Cleanup(func() {
+		require.NoError(t, r.Close())
+	})
+
+	_, err = r.IndexVersion()
+	require.ErrorIs(t, err, ErrLazyLoadQueueFull)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(r.metrics.loadFailedCount))
+
+	// The index-header is loaded once the queue has room.
+	release()
+	v, err := r.IndexVersion()
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+	require.Equal(t, float64(2), promtestutil.ToFloat64(r.metrics.loadCount))
+}
+
 func TestLazyBinaryReader_unload_ShouldReturnErrorIfNotIdle(t *testing.T) {
 	ctx := context.Background()
 
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrLazyLoadQueueFull is returned when an index-header can't be lazy loaded because too many lazy loads are queued.
+var ErrLazyLoadQueueFull = errors.New("too many index-header lazy loads are queued")
+
+// LazyLoadLimiter limits the number of index-headers lazy loaded concurrently across all tenants. The lazy loads
+// exceeding the concurrency wait in a bounded queue, which is served round-robin across tenants, so that a tenant
+// loading many index-headers at once doesn't delay the other tenants' loads.
+type LazyLoadLimiter struct {
+	concurrency int
+	maxQueued   int
+
+	mtx     sync.Mutex
+	running int
+	queued  int
+	// tenants are the queues of the tenants with waiting lazy loads, in the order they're served.
+	tenants []*lazyLoadTenantQueue
+	// next is the index of the tenant whose queue is served next.
+	next int
+
+	queueLength   prometheus.Gauge
+	queueWait     prometheus.Histogram
+	queueRejected prometheus.Counter
+}
+
+type lazyLoadTenantQueue struct {
+	userID  string
+	waiters []chan struct{}
+}
+
+// NewLazyLoadLimiter makes a new LazyLoadLimiter allowing up to concurrency lazy loads at the same time, and
+// queueing up to maxQueued lazy loads. The concurrency and the queue are unlimited if 0.
+func NewLazyLoadLimiter(concurrency, maxQueued int, reg prometheus.Registerer) *LazyLoadLimiter {
+	return &LazyLoadLimiter{
+		concurrency: concurrency,
+		maxQueued:   maxQueued,
+		queueLength: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "indexheader_lazy_load_queue_length",
+			Help: "Number of index-header lazy loads waiting for the lazy loading concurrency limit.",
+		}),
+		queueWait: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "indexheader_lazy_load_queue_wait_duration_seconds",
+			Help:    "Time the index-header lazy loads waited for the lazy loading concurrency limit.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120},
+		}),
+		queueRejected: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_lazy_load_queue_rejected_total",
+			Help: "Total number of index-header lazy loads rejected because the queue was full.",
+		}),
+	}
+}
+
+// acquire waits until the tenant can lazy load an index-header, and returns the function to call once loaded.
+// It returns ErrLazyLoadQueueFull if the lazy load can't be queued, and the context error if the context is done
+// while waiting. A nil limiter doesn't limit the lazy loads.
+func (l *LazyLoadLimiter) acquire(ctx context.Context, userID string) (release func(), _ error) {
+	if l == nil || l.concurrency <= 0 {
+		return func() {}, nil
+	}
+
+	l.mtx.Lock()
+	if l.running < l.concurrency && l.queued == 0 {
+		l.running++
+		l.mtx.Unlock()
+		l.queueWait.Observe(0)
+		return l.release, nil
+	}
+	if l.maxQueued > 0 && l.queued >= l.maxQueued {
+		l.mtx.Unlock()
+		l.queueRejected.Inc()
+		return nil, ErrLazyLoadQueueFull
+	}
+
+	waiter := make(chan struct{})
+	l.enqueue(userID, waiter)
+	l.mtx.Unlock()
+
+	start := time.Now()
+	select {
+	case <-waiter:
+		l.queueWait.Observe(time.Since(start).Seconds())
+
+		// The running slot has been handed over by the lazy load which released it.
+		return l.release, nil
+
+	case <-ctx.Done():
+		l.mtx.Lock()
+		dequeued := l.dequeue(userID, waiter)
+		l.mtx.Unlock()
+
+		if !dequeued {
+			// The running slot has been handed over while the context was done, so it's passed on.
+			l.release()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// enqueue adds the waiter to the tenant's queue. Must be called with the lock held.
+func (l *LazyLoadLimiter) enqueue(userID string, waiter chan struct{}) {
+	l.queued++
+	l.queueLength.Inc()
+
+	for _, q := range l.tenants {
+		if q.userID == userID {
+			q.waiters = append(q.waiters, waiter)
+			return
+		}
+	}
+	l.tenants = append(l.tenants, &lazyLoadTenantQueue{userID: userID, waiters: []chan struct{}{waiter}})
+}
+
+// dequeue removes the waiter from the tenant's queue, and returns whether it was still queued. Must be called with
+// the lock held.
+func (l *LazyLoadLimiter) dequeue(userID string, waiter chan struct{}) bool {
+	for i, q := range l.tenants {
+		if q.userID != userID {
+			continue
+		}
+
+		for j, w := range q.waiters {
+			if w != waiter {
+				continue
+			}
+
+			q.waiters = append(q.waiters[:j], q.waiters[j+1:]...)
+			if len(q.waiters) == 0 {
+				l.tenants = append(l.tenants[:i], l.tenants[i+1:]...)
+				// The tenants after the removed one move back by one index.
+				if i < l.next {
+					l.next--
+				}
+			}
+
+			l.queued--
+			l.queueLength.Dec()
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// release hands over the running slot to the first waiter of the next tenant with waiting lazy loads, if any.
+func (l *LazyLoadLimiter) release() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if len(l.tenants) == 0 {
+		l.running--
+		return
+	}
+
+	if l.next >= len(l.tenants) {
+		l.next = 0
+	}
+	q := l.tenants[l.next]
+	waiter := q.waiters[0]
+	q.waiters = q.waiters[1:]
+
+	if len(q.waiters) == 0 {
+		// The next tenant is now at the same index.
+		l.tenants = append(l.tenants[:l.next], l.tenants[l.next+1:]...)
+	} else {
+		l.next++
+	}
+
+	l.queued--
+	l.queueLength.Dec()
+	close(waiter)
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyLoadLimiter_Unlimited(t *testing.T) {
+	for name, l := range map[string]*LazyLoadLimiter{
+		"nil limiter":        nil,
+		"no concurrency set": NewLazyLoadLimiter(0, 1, nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var releases []func()
+			for i := 0; i < 10; i++ {
+				release, err := l.acquire(context.Background(), "user-1")
+				require.NoError(t, err)
+				releases = append(releases, release)
+			}
+			for _, release := range releases {
+				release()
+			}
+		})
+	}
+}
+
+func TestLazyLoadLimiter_ShouldRejectLoadsOnceTheQueueIsFull(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	l := NewLazyLoadLimiter(1, 1, reg)
+
+	release, err := l.acquire(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	// The second load is queued.
+	acquired := make(chan struct{})
+	go func() {
+		release, err := l.acquire(context.Background(), "user-1")
+		assert.NoError(t, err)
+		close(acquired)
+		release()
+	}()
+	waitQueued(t, l, 1)
+
+	// The third load is rejected.
+	_, err = l.acquire(context.Background(), "user-2")
+	require.ErrorIs(t, err, ErrLazyLoadQueueFull)
+
+	release()
+	<-acquired
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP indexheader_lazy_load_queue_length Number of index-header lazy loads waiting for the lazy loading concurrency limit.
+		# TYPE indexheader_lazy_load_queue_length gauge
+		indexheader_lazy_load_queue_length 0
+		# HELP indexheader_lazy_load_queue_rejected_total Total number of index-header lazy loads rejected because the queue was full.
+		# TYPE indexheader_lazy_load_queue_rejected_total counter
+		indexheader_lazy_load_queue_rejected_total 1
+	`), "indexheader_lazy_load_queue_length", "indexheader_lazy_load_queue_rejected_total"))
+
+	// Once all loads are done, a new load doesn't wait.
+	require.Eventually(t, func() bool {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		return l.running == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestLazyLoadLimiter_ShouldStopWaitingOnceTheContextIsDone(t *testing.T) {
+	l := NewLazyLoadLimiter(1, 0, nil)
+
+	release, err := l.acquire(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	// Queue a load of each tenant, and cancel the first one.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := l.acquire(ctx, "user-1")
+		canceled <- err
+	}()
+	waitQueued(t, l, 1)
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := l.acquire(context.Background(), "user-2")
+		assert.NoError(t, err)
+		close(acquired)
+		release()
+	}()
+	waitQueued(t, l, 2)
+
+	cancel()
+	require.ErrorIs(t, <-canceled, context.Canceled)
+	waitQueued(t, l, 1)
+
+	// The running slot is handed over to the load still queued.
+	release()
+	<-acquired
+
+	require.Eventually(t, func() bool {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		return l.running == 0 && len(l.tenants) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestLazyLoadLimiter_ShouldServeTheQueueRoundRobinAcrossTenants(t *testing.T) {
+	l := NewLazyLoadLimiter(1, 0, nil)
+
+	release, err := l.acquire(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	// Queue many loads of a tenant, and then a few of another one.
+	var (
+		orderMx sync.Mutex
+		order   []string
+		wg      sync.WaitGroup
+		queued  int
+	)
+	queue := func(userID string, count int) {
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				release, err := l.acquire(context.Background(), userID)
+				assert.NoError(t, err)
+
+				orderMx.Lock()
+				order = append(order, userID)
+				orderMx.Unlock()
+				release()
+			}()
+
+			// Wait until queued, to queue the loads in order.
+			queued++
+			waitQueued(t, l, queued)
+		}
+	}
+	queue("user-1", 3)
+	queue("user-2", 2)
+
+	release()
+	wg.Wait()
+
+	// The loads of the second tenant don't wait for all the loads of the first one.
+	assert.Equal(t, []string{"user-1", "user-2", "user-1", "user-2", "user-1"}, order)
+}
+
+func waitQueued(t *testing.T, l *LazyLoadLimiter, expected int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		return l.queued == expected
+	}, time.Second, time.Millisecond)
+}
@@ -40,6 +40,8 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 type ReaderPool struct {
 	lazyReaderEnabled     bool
 	lazyReaderIdleTimeout time.Duration
+	lazyLoadLimiter       *LazyLoadLimiter
+	userID                string
 	logger                log.Logger
 	metrics               *ReaderPoolMetrics
 
@@ -52,8 +54,9 @@ type ReaderPool struct {
 }
 
 // NewReaderPool makes a new ReaderPool and starts a background task for unloading idle Readers if enabled.
-func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, metrics *ReaderPoolMetrics) *ReaderPool {
-	p := newReaderPool(logger, lazyReaderEnabled, lazyReaderIdleTimeout, metrics)
+// The lazy loads of the tenant's index-headers are limited by the lazyLoadLimiter, if not nil.
+func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, lazyLoadLimiter *LazyLoadLimiter, userID string, metrics *ReaderPoolMetrics) *ReaderPool {
+	p := newReaderPool(logger, lazyReaderEnabled, lazyReaderIdleTimeout, lazyLoadLimiter, userID, metrics)
 
 	// Start a goroutine to close idle readers (only if required).
 	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
@@ -75,12 +78,14 @@ func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTime
 }
 
 // newReaderPool makes a new ReaderPool.
-func newReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, metrics *ReaderPoolMetrics) *ReaderPool {
+func newReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, lazyLoadLimiter *LazyLoadLimiter, userID string, metrics *ReaderPoolMetrics) *ReaderPool {
 	return &ReaderPool{
 		logger:                logger,
 		metrics:               metrics,
 		lazyReaderEnabled:     lazyReaderEnabled,
 		lazyReaderIdleTimeout: lazyReaderIdleTimeout,
+		lazyLoadLimiter:       lazyLoadLimiter,
+		userID:                userID,
 		lazyReaders:           make(map[*LazyBinaryReader]struct{}),
 		close:                 make(chan struct{}),
 	}
@@ -99,7 +104,7 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	}
 
 	if p.lazyReaderEnabled {
-		reader, err = NewLazyBinaryReader(ctx, readerFactory, logger, bkt, dir, id, p.metrics.lazyReader, p.onLazyReaderClosed)
+		reader, err = NewLazyBinaryReader(ctx, p.limitLazyLoads(ctx, readerFactory), logger, bkt, dir, id, p.metrics.lazyReader, p.onLazyReaderClosed)
 	} else {
 		reader, err = readerFactory()
 	}
@@ -118,6 +123,24 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	return reader, err
 }
 
+// limitLazyLoads wraps the readerFactory to wait for the lazy load limiter before loading the index-header. The wait
+// ends early once the context is done.
+func (p *ReaderPool) limitLazyLoads(ctx context.Context, readerFactory func() (Reader, error)) func() (Reader, error) {
+	if p.lazyLoadLimiter == nil {
+		return readerFactory
+	}
+
+	return func() (Reader, error) {
+		release, err := p.lazyLoadLimiter.acquire(ctx, p.userID)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return readerFactory()
+	}
+}
+
 // Close the pool and stop checking for idle readers. No reader tracked by this pool
 // will be closed. It's the caller responsibility to close readers.
 func (p *ReaderPool) Close() {
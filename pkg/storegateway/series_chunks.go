@@ -473,6 +473,9 @@ func initializeChunks(chunksRange seriesChunkRefsRange, chunks []storepb.AggrChu
 		chunks[cIdx].MaxTime = chunksRange.refs[cIdx].maxTime
 		if chunks[cIdx].Raw == nil {
 			chunks[cIdx].Raw = &storepb.Chunk{}
+		} else {
+			// The chunk may have been used by a previous request which requested checksums.
+			chunks[cIdx].Raw.Checksum = 0
 		}
 	}
 }
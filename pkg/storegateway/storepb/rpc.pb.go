@@ -40,6 +40,8 @@ type SeriesRequest struct {
 	// The content of this field and whether it's supported depends on the
 	// implementation of a specific store.
 	Hints *types.Any `protobuf:"bytes,9,opt,name=hints,proto3" json:"hints,omitempty"`
+	// chunks_checksums_requested controls whether computing the checksum of the chunks sent in series responses.
+	ChunksChecksumsRequested bool `protobuf:"varint,14,opt,name=chunks_checksums_requested,json=chunksChecksumsRequested,proto3" json:"chunks_checksums_requested,omitempty"`
 }
 
 func (m *SeriesRequest) Reset()      { *m = SeriesRequest{} }
@@ -402,51 +404,53 @@ func init() {
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 692 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0x31, 0x6f, 0xd3, 0x40,
-	0x14, 0xc7, 0x7d, 0xf1, 0xd9, 0xb9, 0x5c, 0x9a, 0xca, 0xbd, 0x96, 0xca, 0x35, 0xd2, 0x35, 0xb2,
-	0x84, 0x14, 0x21, 0x70, 0x51, 0x91, 0x40, 0x8c, 0x4d, 0x25, 0x54, 0x2c, 0x60, 0x70, 0x11, 0x03,
-	0x4b, 0xe4, 0xa4, 0xd7, 0xc4, 0x6a, 0x62, 0x07, 0x9f, 0x0d, 0xcd, 0xc6, 0x47, 0xe0, 0x63, 0x20,
-	0x31, 0xf3, 0x05, 0x98, 0xba, 0xd1, 0xb1, 0x13, 0x22, 0xee, 0xc2, 0xd8, 0x99, 0x09, 0xf9, 0xce,
-	0x69, 0x6a, 0x14, 0x54, 0x2a, 0xb1, 0xe5, 0xfd, 0xff, 0x2f, 0x77, 0xef, 0xff, 0x7b, 0x67, 0x5c,
-	0x8b, 0xc7, 0x3d, 0x67, 0x1c, 0x47, 0x49, 0x44, 0xf4, 0x64, 0xe0, 0x87, 0x11, 0xb7, 0xea, 0xc9,
-	0x64, 0xcc, 0xb8, 0x14, 0xad, 0xfb, 0xfd, 0x20, 0x19, 0xa4, 0x5d, 0xa7, 0x17, 0x8d, 0xb6, 0xfa,
-	0x51, 0x3f, 0xda, 0x12, 0x72, 0x37, 0x3d, 0x14, 0x95, 0x28, 0xc4, 0xaf, 0xa2, 0x7d, 0xa3, 0x1f,
-	0x45, 0xfd, 0x21, 0x9b, 0x77, 0xf9, 0xe1, 0x44, 0x5a, 0xf6, 0x2f, 0x80, 0x1b, 0xfb, 0x2c, 0x0e,
-	0x18, 0xf7, 0xd8, 0xdb, 0x94, 0xf1, 0x84, 0x6c, 0x60, 0x34, 0x0a, 0xc2, 0x4e, 0x12, 0x8c, 0x98,
-	0x09, 0x9a, 0xa0, 0xa5, 0x7a, 0xd5, 0x51, 0x10, 0xbe, 0x0a, 0x46, 0x4c, 0x58, 0xfe, 0xb1, 0xb4,
-	0x2a, 0x85, 0xe5, 0x1f, 0x0b, 0xeb, 0x51, 0x6e, 0x25, 0xbd, 0x01, 0x8b, 0xb9, 0xa9, 0x36, 0xd5,
-	0x56, 0x7d, 0x7b, 0xcd, 0x91, 0x93, 0x3b, 0xcf, 0xfd, 0x2e, 0x1b, 0xbe, 0x90, 0x66, 0x1b, 0x9e,
-	0x7c, 0xdf, 0x54, 0xbc, 0xcb, 0x5e, 0xb2, 0x89, 0xeb, 0xfc, 0x28, 0x18, 0x77, 0x7a, 0x83, 0x34,
-	0x3c, 0xe2, 0x26, 0x6a, 0x82, 0x16, 0xf2, 0x70, 0x2e, 0xed, 0x0a, 0x85, 0xdc, 0xc5, 0xda, 0x20,
-	0x08, 0x13, 0x6e, 0xd6, 0x9a, 0x40, 0x9c, 0x2a, 0xb3, 0x38, 0xb3, 0x2c, 0xce, 0x4e, 0x38, 0xf1,
-	0x64, 0x8b, 0x0b, 0x11, 0x34, 0x34, 0x17, 0x22, 0xcd, 0xd0, 0x5d, 0x88, 0x74, 0xa3, 0xea, 0x42,
-	0x54, 0x35, 0x90, 0x0b, 0x11, 0x36, 0xea, 0x2e, 0x44, 0x75, 0x63, 0xc9, 0x85, 0x68, 0xc9, 0x68,
-	0xb8, 0x10, 0x35, 0x8c, 0x65, 0xfb, 0x31, 0xd6, 0xf6, 0x13, 0x3f, 0xe1, 0xc4, 0xc1, 0xab, 0x87,
-	0x2c, 0x9f, 0xe8, 0xa0, 0x13, 0x84, 0x07, 0xec, 0xb8, 0xd3, 0x9d, 0x24, 0x8c, 0x8b, 0xf8, 0xd0,
-	0x5b, 0x29, 0xac, 0x67, 0xb9, 0xd3, 0xce, 0x0d, 0xfb, 0x0b, 0xc0, 0xcb, 0x33, 0x6a, 0x7c, 0x1c,
-	0x85, 0x9c, 0x91, 0x16, 0xd6, 0xb9, 0x50, 0xc4, 0xbf, 0xea, 0xdb, 0xcb, 0xb3, 0xf8, 0xb2, 0x6f,
-	0x4f, 0xf1, 0x0a, 0x9f, 0x58, 0xb8, 0xfa, 0xde, 0x8f, 0xc3, 0x20, 0xec, 0x0b, 0x88, 0xb5, 0x3d,
-	0xc5, 0x9b, 0x09, 0xe4, 0xde, 0x2c, 0xad, 0xfa, 0xf7, 0xb4, 0x7b, 0x4a, 0x91, 0x97, 0xdc, 0xc1,
-	0x1a, 0xcf, 0xe7, 0x37, 0xa1, 0xe8, 0x6e, 0x5c, 0x5e, 0x99, 0x8b, 0x79, 0x9b, 0x70, 0xdb, 0x08,
-	0xeb, 0x31, 0xe3, 0xe9, 0x30, 0xb1, 0x3f, 0x03, 0xbc, 0x22, 0xd6, 0xf1, 0xd2, 0x1f, 0xcd, 0x37,
-	0xbe, 0x26, 0x8e, 0x89, 0x13, 0x71, 0xa9, 0xea, 0xc9, 0x82, 0x18, 0x58, 0x65, 0xe1, 0x81, 0x38,
-	0x5a, 0xf5, 0xf2, 0x9f, 0xf3, 0x55, 0x68, 0xd7, 0xae, 0xa2, 0xf4, 0x1e, 0xf4, 0x7f, 0x7f, 0x0f,
-	0x2e, 0x44, 0xc0, 0xa8, 0xb8, 0x10, 0x55, 0x0c, 0xd5, 0x8e, 0x31, 0xb9, 0x3a, 0x6c, 0x01, 0x7a,
-	0x0d, 0x6b, 0x61, 0x2e, 0x98, 0xa0, 0xa9, 0xb6, 0x6a, 0x9e, 0x2c, 0x88, 0x85, 0x51, 0xc1, 0x90,
-	0x9b, 0x15, 0x61, 0x5c, 0xd6, 0xf3, 0xb9, 0xd5, 0x6b, 0xe7, 0xb6, 0xbf, 0x82, 0xe2, 0xd2, 0xd7,
-	0xfe, 0x30, 0x2d, 0x21, 0x1a, 0xe6, 0xaa, 0x58, 0x6e, 0xcd, 0x93, 0xc5, 0x1c, 0x1c, 0x5c, 0x00,
-	0x4e, 0x5b, 0x00, 0x4e, 0xbf, 0x19, 0xb8, 0xea, 0x8d, 0xc0, 0x55, 0x0c, 0xd5, 0x85, 0x48, 0x35,
-	0xa0, 0x9d, 0xe2, 0xd5, 0x52, 0x86, 0x82, 0xdc, 0x3a, 0xd6, 0xdf, 0x09, 0xa5, 0x40, 0x57, 0x54,
-	0xff, 0x8b, 0xdd, 0xf6, 0x37, 0x90, 0x7f, 0x4f, 0x51, 0xcc, 0xc8, 0x13, 0xac, 0xcb, 0x67, 0x4f,
-	0x6e, 0x95, 0x3f, 0x83, 0x82, 0xa7, 0xb5, 0xfe, 0xa7, 0x2c, 0x47, 0x7c, 0x00, 0xc8, 0x2e, 0xc6,
-	0xf3, 0xa5, 0x93, 0x8d, 0x52, 0xf6, 0xab, 0xaf, 0xd6, 0xb2, 0x16, 0x59, 0x45, 0xd2, 0xa7, 0xb8,
-	0x7e, 0x05, 0x00, 0x29, 0xb7, 0x96, 0x36, 0x6b, 0xdd, 0x5e, 0xe8, 0xc9, 0x73, 0xda, 0x3b, 0x27,
-	0x53, 0xaa, 0x9c, 0x4e, 0xa9, 0x72, 0x36, 0xa5, 0xca, 0xc5, 0x94, 0x82, 0x0f, 0x19, 0x05, 0x9f,
-	0x32, 0x0a, 0x4e, 0x32, 0x0a, 0x4e, 0x33, 0x0a, 0x7e, 0x64, 0x14, 0xfc, 0xcc, 0xa8, 0x72, 0x91,
-	0x51, 0xf0, 0xf1, 0x9c, 0x2a, 0xa7, 0xe7, 0x54, 0x39, 0x3b, 0xa7, 0xca, 0x9b, 0x2a, 0xcf, 0x41,
-	0x8c, 0xbb, 0x5d, 0x5d, 0x90, 0x7a, 0xf8, 0x3b, 0x00, 0x00, 0xff, 0xff, 0xe8, 0x55, 0x20, 0x90,
-	0xd3, 0x05, 0x00, 0x00,
+	// 725 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0x41, 0x6b, 0xdb, 0x4a,
+	0x10, 0xc7, 0xb5, 0xd6, 0x4a, 0x5e, 0xaf, 0x63, 0xa3, 0x6c, 0xf2, 0x82, 0xa2, 0x07, 0x1b, 0x63,
+	0x78, 0x60, 0x1e, 0xef, 0x39, 0x25, 0x85, 0x96, 0x42, 0x2f, 0x71, 0xa0, 0xa4, 0xa2, 0xed, 0x41,
+	0x29, 0x3d, 0xf4, 0x62, 0x64, 0x7b, 0x63, 0x8b, 0x58, 0x92, 0xab, 0x95, 0xda, 0xf8, 0xd6, 0x8f,
+	0xd0, 0x8f, 0x51, 0xe8, 0xb9, 0xd0, 0x73, 0x4f, 0xb9, 0x35, 0xc7, 0x9c, 0x4a, 0xad, 0x5c, 0x7a,
+	0xcc, 0x47, 0x28, 0xda, 0x95, 0x62, 0xbb, 0xb8, 0xa4, 0x81, 0xde, 0x3c, 0xff, 0xff, 0x78, 0x76,
+	0xe6, 0x37, 0x23, 0x5c, 0x89, 0x26, 0xfd, 0xf6, 0x24, 0x0a, 0xe3, 0x90, 0xe8, 0xf1, 0xc8, 0x0d,
+	0x42, 0x6e, 0x55, 0xe3, 0xe9, 0x84, 0x71, 0x29, 0x5a, 0xff, 0x0f, 0xbd, 0x78, 0x94, 0xf4, 0xda,
+	0xfd, 0xd0, 0xdf, 0x1d, 0x86, 0xc3, 0x70, 0x57, 0xc8, 0xbd, 0xe4, 0x58, 0x44, 0x22, 0x10, 0xbf,
+	0xf2, 0xf4, 0xed, 0x61, 0x18, 0x0e, 0xc7, 0x6c, 0x9e, 0xe5, 0x06, 0x53, 0x69, 0x35, 0x3f, 0x95,
+	0x70, 0xed, 0x88, 0x45, 0x1e, 0xe3, 0x0e, 0x7b, 0x95, 0x30, 0x1e, 0x93, 0x6d, 0x8c, 0x7c, 0x2f,
+	0xe8, 0xc6, 0x9e, 0xcf, 0x4c, 0xd0, 0x00, 0x2d, 0xd5, 0x29, 0xfb, 0x5e, 0xf0, 0xdc, 0xf3, 0x99,
+	0xb0, 0xdc, 0x53, 0x69, 0x95, 0x72, 0xcb, 0x3d, 0x15, 0xd6, 0xbd, 0xcc, 0x8a, 0xfb, 0x23, 0x16,
+	0x71, 0x53, 0x6d, 0xa8, 0xad, 0xea, 0xde, 0x66, 0x5b, 0x76, 0xde, 0x7e, 0xe2, 0xf6, 0xd8, 0xf8,
+	0xa9, 0x34, 0x3b, 0xf0, 0xec, 0xeb, 0x8e, 0xe2, 0x5c, 0xe7, 0x92, 0x1d, 0x5c, 0xe5, 0x27, 0xde,
+	0xa4, 0xdb, 0x1f, 0x25, 0xc1, 0x09, 0x37, 0x51, 0x03, 0xb4, 0x90, 0x83, 0x33, 0xe9, 0x40, 0x28,
+	0xe4, 0x5f, 0xac, 0x8d, 0xbc, 0x20, 0xe6, 0x66, 0xa5, 0x01, 0x44, 0x55, 0x39, 0x4b, 0xbb, 0x98,
+	0xa5, 0xbd, 0x1f, 0x4c, 0x1d, 0x99, 0x42, 0x1e, 0x62, 0x4b, 0xd6, 0xe9, 0xf6, 0x47, 0xac, 0x7f,
+	0xc2, 0x13, 0x9f, 0x77, 0x23, 0x39, 0x16, 0x1b, 0x98, 0x75, 0x51, 0xdb, 0x94, 0x19, 0x07, 0x45,
+	0x82, 0x53, 0xf8, 0x36, 0x44, 0xd0, 0xd0, 0x6c, 0x88, 0x34, 0x43, 0xb7, 0x21, 0xd2, 0x8d, 0xb2,
+	0x0d, 0x51, 0xd9, 0x40, 0x36, 0x44, 0xd8, 0xa8, 0xda, 0x10, 0x55, 0x8d, 0x35, 0x1b, 0xa2, 0x35,
+	0xa3, 0x66, 0x43, 0x54, 0x33, 0xea, 0xcd, 0xfb, 0x58, 0x3b, 0x8a, 0xdd, 0x98, 0x93, 0x36, 0xde,
+	0x38, 0x66, 0xd9, 0x3c, 0x83, 0xae, 0x17, 0x0c, 0xd8, 0x69, 0xb7, 0x37, 0x8d, 0x19, 0x17, 0xf0,
+	0xa0, 0xb3, 0x9e, 0x5b, 0x8f, 0x33, 0xa7, 0x93, 0x19, 0xcd, 0x8f, 0x00, 0xd7, 0x0b, 0xe6, 0x7c,
+	0x12, 0x06, 0x9c, 0x91, 0x16, 0xd6, 0xb9, 0x50, 0xc4, 0xbf, 0xaa, 0x7b, 0xf5, 0x02, 0x9e, 0xcc,
+	0x3b, 0x54, 0x9c, 0xdc, 0x27, 0x16, 0x2e, 0xbf, 0x71, 0xa3, 0xc0, 0x0b, 0x86, 0x62, 0x05, 0x95,
+	0x43, 0xc5, 0x29, 0x04, 0xf2, 0x5f, 0xc1, 0x4a, 0xfd, 0x35, 0xab, 0x43, 0xa5, 0xa0, 0xf5, 0x0f,
+	0xd6, 0x78, 0xd6, 0xbf, 0x09, 0x45, 0x76, 0xed, 0xfa, 0xc9, 0x4c, 0xcc, 0xd2, 0x84, 0xdb, 0x41,
+	0x58, 0x8f, 0x18, 0x4f, 0xc6, 0x71, 0xf3, 0x03, 0xc0, 0xeb, 0x62, 0x99, 0xcf, 0x5c, 0x7f, 0x7e,
+	0x2f, 0x9b, 0xa2, 0x4c, 0x14, 0x8b, 0x47, 0x55, 0x47, 0x06, 0xc4, 0xc0, 0x2a, 0x0b, 0x06, 0xa2,
+	0xb4, 0xea, 0x64, 0x3f, 0xe7, 0x8b, 0xd4, 0x6e, 0x5e, 0xe4, 0xe2, 0x35, 0xe9, 0xbf, 0x7f, 0x4d,
+	0x36, 0x44, 0xc0, 0x28, 0xd9, 0x10, 0x95, 0x0c, 0xb5, 0x19, 0x61, 0xb2, 0xd8, 0x6c, 0x0e, 0x7a,
+	0x13, 0x6b, 0x41, 0x26, 0x98, 0xa0, 0xa1, 0xb6, 0x2a, 0x8e, 0x0c, 0x88, 0x85, 0x51, 0xce, 0x90,
+	0x9b, 0x25, 0x61, 0x5c, 0xc7, 0xf3, 0xbe, 0xd5, 0x1b, 0xfb, 0x6e, 0x7e, 0x06, 0xf9, 0xa3, 0x2f,
+	0xdc, 0x71, 0xb2, 0x84, 0x68, 0x9c, 0xa9, 0x62, 0xb9, 0x15, 0x47, 0x06, 0x73, 0x70, 0x70, 0x05,
+	0x38, 0x6d, 0x05, 0x38, 0xfd, 0x76, 0xe0, 0xca, 0xb7, 0x02, 0x57, 0x32, 0x54, 0x1b, 0x22, 0xd5,
+	0x80, 0xcd, 0x04, 0x6f, 0x2c, 0xcd, 0x90, 0x93, 0xdb, 0xc2, 0xfa, 0x6b, 0xa1, 0xe4, 0xe8, 0xf2,
+	0xe8, 0x4f, 0xb1, 0xdb, 0xfb, 0x02, 0xb2, 0xef, 0x29, 0x8c, 0x18, 0x79, 0x80, 0x75, 0x79, 0xf6,
+	0xe4, 0xaf, 0xe5, 0xcf, 0x20, 0xe7, 0x69, 0x6d, 0xfd, 0x2c, 0xcb, 0x16, 0xef, 0x00, 0x72, 0x80,
+	0xf1, 0x7c, 0xe9, 0x64, 0x7b, 0x69, 0xf6, 0xc5, 0xab, 0xb5, 0xac, 0x55, 0x56, 0x3e, 0xe9, 0x23,
+	0x5c, 0x5d, 0x00, 0x40, 0x96, 0x53, 0x97, 0x36, 0x6b, 0xfd, 0xbd, 0xd2, 0x93, 0x75, 0x3a, 0xfb,
+	0x67, 0x33, 0xaa, 0x9c, 0xcf, 0xa8, 0x72, 0x31, 0xa3, 0xca, 0xd5, 0x8c, 0x82, 0xb7, 0x29, 0x05,
+	0xef, 0x53, 0x0a, 0xce, 0x52, 0x0a, 0xce, 0x53, 0x0a, 0xbe, 0xa5, 0x14, 0x7c, 0x4f, 0xa9, 0x72,
+	0x95, 0x52, 0xf0, 0xee, 0x92, 0x2a, 0xe7, 0x97, 0x54, 0xb9, 0xb8, 0xa4, 0xca, 0xcb, 0x32, 0xcf,
+	0x40, 0x4c, 0x7a, 0x3d, 0x5d, 0x90, 0xba, 0xfb, 0x23, 0x00, 0x00, 0xff, 0xff, 0x0e, 0x66, 0x92,
+	0x58, 0x11, 0x06, 0x00, 0x00,
 }
 
 func (this *SeriesRequest) Equal(that interface{}) bool {
@@ -488,6 +492,9 @@ func (this *SeriesRequest) Equal(that interface{}) bool {
 	if !this.Hints.Equal(that1.Hints) {
 		return false
 	}
+	if this.ChunksChecksumsRequested != that1.ChunksChecksumsRequested {
+		return false
+	}
 	return true
 }
 func (this *Stats) Equal(that interface{}) bool {
@@ -803,7 +810,7 @@ func (this *SeriesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&storepb.SeriesRequest{")
 	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
 	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
@@ -818,6 +825,7 @@ func (this *SeriesRequest) GoString() string {
 	if this.Hints != nil {
 		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
 	}
+	s = append(s, "ChunksChecksumsRequested: "+fmt.Sprintf("%#v", this.ChunksChecksumsRequested)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1177,6 +1185,16 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ChunksChecksumsRequested {
+		i--
+		if m.ChunksChecksumsRequested {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x70
+	}
 	if m.Hints != nil {
 		{
 			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
@@ -1626,6 +1644,9 @@ func (m *SeriesRequest) Size() (n int) {
 		l = m.Hints.Size()
 		n += 1 + l + sovRpc(uint64(l))
 	}
+	if m.ChunksChecksumsRequested {
+		n += 2
+	}
 	return n
 }
 
@@ -1824,6 +1845,7 @@ func (this *SeriesRequest) String() string {
 		`Matchers:` + repeatedStringForMatchers + `,`,
 		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
 		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`ChunksChecksumsRequested:` + fmt.Sprintf("%v", this.ChunksChecksumsRequested) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2114,6 +2136,26 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksChecksumsRequested", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ChunksChecksumsRequested = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -82,6 +82,9 @@ func (LabelMatcher_Type) EnumDescriptor() ([]byte, []int) {
 type Chunk struct {
 	Type Chunk_Encoding                                       `protobuf:"varint,1,opt,name=type,proto3,enum=thanos.Chunk_Encoding" json:"type,omitempty"`
 	Data github_com_grafana_mimir_pkg_mimirpb.UnsafeByteSlice `protobuf:"bytes,2,opt,name=data,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.UnsafeByteSlice" json:"data"`
+	// checksum is the CRC32 (Castagnoli) checksum of the data. It's only set if requested
+	// via SeriesRequest.chunks_checksums_requested.
+	Checksum uint32 `protobuf:"varint,3,opt,name=checksum,proto3" json:"checksum,omitempty"`
 }
 
 func (m *Chunk) Reset()      { *m = Chunk{} }
@@ -242,43 +245,44 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 567 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xf6, 0x26, 0x8e, 0xe3, 0x6c, 0x5b, 0x58, 0xb6, 0x15, 0x72, 0x7b, 0xd8, 0x46, 0x3e, 0x45,
-	0x48, 0x75, 0xa0, 0x70, 0x41, 0xe2, 0xd2, 0xa0, 0xa0, 0x2a, 0xe2, 0xa7, 0x75, 0x8b, 0x84, 0x10,
-	0x52, 0xb5, 0x76, 0x37, 0xce, 0xaa, 0xf1, 0x8f, 0xd6, 0x1b, 0x68, 0x6f, 0x7d, 0x04, 0x5e, 0x81,
-	0x1b, 0x2f, 0x82, 0x94, 0x1b, 0x39, 0x56, 0x1c, 0x2a, 0xe2, 0x5c, 0x38, 0xf6, 0x11, 0x90, 0xd7,
-	0x0e, 0x24, 0xea, 0xa5, 0x27, 0xcf, 0xcc, 0xf7, 0xcd, 0x7c, 0x9f, 0x67, 0x6c, 0xb8, 0x22, 0x2f,
-	0x12, 0x96, 0x3a, 0x89, 0x88, 0x65, 0x8c, 0x0d, 0x39, 0xa0, 0x51, 0x9c, 0x6e, 0xed, 0x04, 0x5c,
-	0x0e, 0x46, 0x9e, 0xe3, 0xc7, 0x61, 0x3b, 0x88, 0x83, 0xb8, 0xad, 0x60, 0x6f, 0xd4, 0x57, 0x99,
-	0x4a, 0x54, 0x54, 0xb4, 0x6d, 0x3d, 0x5e, 0xa4, 0x0b, 0xda, 0xa7, 0x11, 0x6d, 0x87, 0x3c, 0xe4,
-	0xa2, 0x9d, 0x9c, 0x05, 0x45, 0x94, 0x78, 0xc5, 0xb3, 0xe8, 0xb0, 0x7f, 0x02, 0x58, 0x7b, 0x39,
-	0x18, 0x45, 0x67, 0xf8, 0x11, 0xd4, 0x73, 0x07, 0x16, 0x68, 0x82, 0xd6, 0xbd, 0xdd, 0x87, 0x4e,
-	0xe1, 0xc0, 0x51, 0xa0, 0xd3, 0x8d, 0xfc, 0xf8, 0x94, 0x47, 0x81, 0xab, 0x38, 0xf8, 0x00, 0xea,
-	0xa7, 0x54, 0x52, 0xab, 0xd2, 0x04, 0xad, 0xd5, 0xce, 0x8b, 0xf1, 0xf5, 0xb6, 0xf6, 0xeb, 0x7a,
-	0xfb, 0xd9, 0x5d, 0xd4, 0x9d, 0xf7, 0x51, 0x4a, 0xfb, 0xac, 0x73, 0x21, 0xd9, 0xd1, 0x90, 0xfb,
-	0xcc, 0x55, 0x93, 0xec, 0x7d, 0x68, 0xce, 0x35, 0xf0, 0x1a, 0x6c, 0x28, 0xd5, 0x93, 0x0f, 0xef,
-	0x5c, 0xa4, 0xe1, 0x75, 0x78, 0xbf, 0x48, 0xf7, 0x79, 0x2a, 0xe3, 0x40, 0xd0, 0x10, 0x01, 0x6c,
-	0xc1, 0x8d, 0xa2, 0xf8, 0x6a, 0x18, 0x53, 0xf9, 0x1f, 0xa9, 0xd8, 0xdf, 0x00, 0x34, 0x8e, 0x98,
-	0xe0, 0x2c, 0xc5, 0x7d, 0x68, 0x0c, 0xa9, 0xc7, 0x86, 0xa9, 0x05, 0x9a, 0xd5, 0xd6, 0xca, 0xee,
-	0xba, 0xe3, 0xc7, 0x42, 0xb2, 0xf3, 0xc4, 0x73, 0x5e, 0xe7, 0xf5, 0x03, 0xca, 0x45, 0xe7, 0x79,
-	0xe9, 0xfe, 0xc9, 0x9d, 0xdc, 0xab, 0xbe, 0xbd, 0x53, 0x9a, 0x48, 0x26, 0xdc, 0x72, 0x3a, 0x6e,
-	0x43, 0xc3, 0xcf, 0xcd, 0xa4, 0x56, 0x45, 0xe9, 0x3c, 0x98, 0x2f, 0x6f, 0x2f, 0x08, 0x84, 0xb2,
-	0xd9, 0xd1, 0x73, 0x15, 0xb7, 0xa4, 0xd9, 0x97, 0x00, 0x36, 0xfe, 0x61, 0x78, 0x13, 0x9a, 0x21,
-	0x8f, 0x4e, 0x24, 0x0f, 0x8b, 0xed, 0x57, 0xdd, 0x7a, 0xc8, 0xa3, 0x63, 0x1e, 0x32, 0x05, 0xd1,
-	0xf3, 0x02, 0xaa, 0x94, 0x10, 0x3d, 0x57, 0xd0, 0x36, 0xac, 0x0a, 0xfa, 0xc5, 0xaa, 0x36, 0x41,
-	0x6b, 0x65, 0x77, 0x6d, 0xe9, 0x5c, 0x6e, 0x8e, 0xf4, 0x74, 0x53, 0x47, 0xb5, 0x9e, 0x6e, 0xd6,
-	0x90, 0xd1, 0xd3, 0x4d, 0x03, 0xd5, 0x7b, 0xba, 0x59, 0x47, 0x66, 0x4f, 0x37, 0x4d, 0xd4, 0xb0,
-	0x7f, 0x00, 0xb8, 0xaa, 0x5e, 0xe6, 0x0d, 0x95, 0xfe, 0x80, 0x09, 0xbc, 0xb3, 0x74, 0xff, 0xcd,
-	0xf9, 0xc0, 0x45, 0x8e, 0x73, 0x7c, 0x91, 0xb0, 0xf2, 0x13, 0xc0, 0x50, 0x8f, 0x68, 0xe9, 0xaa,
-	0xe1, 0xaa, 0x18, 0x6f, 0xc0, 0xda, 0x67, 0x3a, 0x1c, 0x31, 0x65, 0xaa, 0xe1, 0x16, 0x89, 0xfd,
-	0x09, 0xea, 0x79, 0x5f, 0x7e, 0xc7, 0xc5, 0x61, 0x27, 0xdd, 0x43, 0xa4, 0xe1, 0x0d, 0x88, 0x96,
-	0x8a, 0x6f, 0xbb, 0x87, 0x08, 0xdc, 0xa2, 0xba, 0x5d, 0x54, 0xb9, 0x4d, 0x75, 0xbb, 0xa8, 0xda,
-	0xd9, 0x1b, 0x4f, 0x89, 0x36, 0x99, 0x12, 0xed, 0x6a, 0x4a, 0xb4, 0x9b, 0x29, 0x01, 0x97, 0x19,
-	0x01, 0xdf, 0x33, 0x02, 0xc6, 0x19, 0x01, 0x93, 0x8c, 0x80, 0xdf, 0x19, 0x01, 0x7f, 0x32, 0xa2,
-	0xdd, 0x64, 0x04, 0x7c, 0x9d, 0x11, 0x6d, 0x32, 0x23, 0xda, 0xd5, 0x8c, 0x68, 0x1f, 0xeb, 0xa9,
-	0x8c, 0x05, 0x4b, 0x3c, 0xcf, 0x50, 0xbf, 0xc2, 0xd3, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x08,
-	0x77, 0xfb, 0x5f, 0x82, 0x03, 0x00, 0x00,
+	// 585 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x4e, 0xdb, 0x4c,
+	0x14, 0xf5, 0x24, 0x8e, 0xe3, 0x0c, 0xf0, 0x7d, 0xd3, 0x01, 0x55, 0x86, 0xc5, 0x10, 0x79, 0x15,
+	0x55, 0xc2, 0x69, 0x69, 0x37, 0x95, 0xba, 0x21, 0x55, 0x2a, 0x14, 0xf5, 0x07, 0x06, 0x2a, 0x55,
+	0x55, 0x25, 0x34, 0x36, 0x13, 0x67, 0x44, 0xfc, 0xa3, 0xf1, 0xa4, 0x85, 0x1d, 0x8f, 0xd0, 0x57,
+	0xe8, 0xae, 0x2f, 0x52, 0x89, 0x25, 0x4b, 0xd4, 0x05, 0x6a, 0xcc, 0xa6, 0x52, 0x37, 0x3c, 0x42,
+	0xe5, 0xb1, 0xa1, 0x89, 0xd8, 0xb0, 0xf2, 0x9c, 0x7b, 0xce, 0xbd, 0xf7, 0xdc, 0xb9, 0x63, 0xb8,
+	0xa0, 0x4e, 0x52, 0x9e, 0x79, 0xa9, 0x4c, 0x54, 0x82, 0x2d, 0x35, 0x62, 0x71, 0x92, 0xad, 0x6d,
+	0x84, 0x42, 0x8d, 0x26, 0xbe, 0x17, 0x24, 0x51, 0x37, 0x4c, 0xc2, 0xa4, 0xab, 0x69, 0x7f, 0x32,
+	0xd4, 0x48, 0x03, 0x7d, 0x2a, 0xd3, 0xd6, 0x1e, 0xcf, 0xca, 0x25, 0x1b, 0xb2, 0x98, 0x75, 0x23,
+	0x11, 0x09, 0xd9, 0x4d, 0x8f, 0xc2, 0xf2, 0x94, 0xfa, 0xe5, 0xb7, 0xcc, 0x70, 0xff, 0x00, 0xd8,
+	0x78, 0x39, 0x9a, 0xc4, 0x47, 0xf8, 0x11, 0x34, 0x0b, 0x07, 0x0e, 0x68, 0x83, 0xce, 0x7f, 0x9b,
+	0x0f, 0xbd, 0xd2, 0x81, 0xa7, 0x49, 0xaf, 0x1f, 0x07, 0xc9, 0xa1, 0x88, 0x43, 0xaa, 0x35, 0x78,
+	0x07, 0x9a, 0x87, 0x4c, 0x31, 0xa7, 0xd6, 0x06, 0x9d, 0xc5, 0xde, 0x8b, 0xb3, 0xcb, 0x75, 0xe3,
+	0xe7, 0xe5, 0xfa, 0xb3, 0xfb, 0x74, 0xf7, 0xde, 0xc7, 0x19, 0x1b, 0xf2, 0xde, 0x89, 0xe2, 0x7b,
+	0x63, 0x11, 0x70, 0xaa, 0x2b, 0xe1, 0x35, 0x68, 0x07, 0x23, 0x1e, 0x1c, 0x65, 0x93, 0xc8, 0xa9,
+	0xb7, 0x41, 0x67, 0x89, 0xde, 0x62, 0x77, 0x1b, 0xda, 0x37, 0xfd, 0xf1, 0x12, 0x6c, 0x69, 0x47,
+	0x07, 0x1f, 0xde, 0x51, 0x64, 0xe0, 0x65, 0xf8, 0x7f, 0x09, 0xb7, 0x45, 0xa6, 0x92, 0x50, 0xb2,
+	0x08, 0x01, 0xec, 0xc0, 0x95, 0x32, 0xf8, 0x6a, 0x9c, 0x30, 0xf5, 0x8f, 0xa9, 0xb9, 0xdf, 0x00,
+	0xb4, 0xf6, 0xb8, 0x14, 0x3c, 0xc3, 0x43, 0x68, 0x8d, 0x99, 0xcf, 0xc7, 0x99, 0x03, 0xda, 0xf5,
+	0xce, 0xc2, 0xe6, 0xb2, 0x17, 0x24, 0x52, 0xf1, 0xe3, 0xd4, 0xf7, 0x5e, 0x17, 0xf1, 0x1d, 0x26,
+	0x64, 0xef, 0x79, 0x35, 0xd9, 0x93, 0x7b, 0x4d, 0xa6, 0xf3, 0xb6, 0x0e, 0x59, 0xaa, 0xb8, 0xa4,
+	0x55, 0x75, 0xdc, 0x85, 0x56, 0x50, 0x98, 0xc9, 0x9c, 0x9a, 0xee, 0xf3, 0xe0, 0xe6, 0x62, 0xb7,
+	0xc2, 0x50, 0x6a, 0x9b, 0x3d, 0xb3, 0xe8, 0x42, 0x2b, 0x99, 0x7b, 0x0a, 0x60, 0xeb, 0x96, 0xc3,
+	0xab, 0xd0, 0x8e, 0x44, 0x7c, 0xa0, 0x44, 0x54, 0x6e, 0xa6, 0x4e, 0x9b, 0x91, 0x88, 0xf7, 0x45,
+	0xc4, 0x35, 0xc5, 0x8e, 0x4b, 0xaa, 0x56, 0x51, 0xec, 0x58, 0x53, 0xeb, 0xb0, 0x2e, 0xd9, 0x17,
+	0x7d, 0x91, 0x0b, 0x9b, 0x4b, 0x73, 0xab, 0xa4, 0x05, 0x33, 0x30, 0x6d, 0x13, 0x35, 0x06, 0xa6,
+	0xdd, 0x40, 0xd6, 0xc0, 0xb4, 0x2d, 0xd4, 0x1c, 0x98, 0x76, 0x13, 0xd9, 0x03, 0xd3, 0xb6, 0x51,
+	0xcb, 0xfd, 0x01, 0xe0, 0xa2, 0x1e, 0xe6, 0x0d, 0x53, 0xc1, 0x88, 0x4b, 0xbc, 0x31, 0xf7, 0x36,
+	0x56, 0x6f, 0x0a, 0xce, 0x6a, 0xbc, 0xfd, 0x93, 0x94, 0x57, 0xcf, 0x03, 0x43, 0x33, 0x66, 0x95,
+	0xab, 0x16, 0xd5, 0x67, 0xbc, 0x02, 0x1b, 0x9f, 0xd9, 0x78, 0xc2, 0xb5, 0xa9, 0x16, 0x2d, 0x81,
+	0xfb, 0x09, 0x9a, 0x45, 0x5e, 0xb1, 0xc7, 0xd9, 0x62, 0x07, 0xfd, 0x5d, 0x64, 0xe0, 0x15, 0x88,
+	0xe6, 0x82, 0x6f, 0xfb, 0xbb, 0x08, 0xdc, 0x91, 0xd2, 0x3e, 0xaa, 0xdd, 0x95, 0xd2, 0x3e, 0xaa,
+	0xf7, 0xb6, 0xce, 0xa6, 0xc4, 0x38, 0x9f, 0x12, 0xe3, 0x62, 0x4a, 0x8c, 0xeb, 0x29, 0x01, 0xa7,
+	0x39, 0x01, 0xdf, 0x73, 0x02, 0xce, 0x72, 0x02, 0xce, 0x73, 0x02, 0x7e, 0xe5, 0x04, 0xfc, 0xce,
+	0x89, 0x71, 0x9d, 0x13, 0xf0, 0xf5, 0x8a, 0x18, 0xe7, 0x57, 0xc4, 0xb8, 0xb8, 0x22, 0xc6, 0xc7,
+	0x66, 0xa6, 0x12, 0xc9, 0x53, 0xdf, 0xb7, 0xf4, 0x6f, 0xf2, 0xf4, 0x6f, 0x00, 0x00, 0x00, 0xff,
+	0xff, 0x37, 0x60, 0xcb, 0x43, 0x9e, 0x03, 0x00, 0x00,
 }
 
 func (x Chunk_Encoding) String() string {
@@ -320,6 +324,9 @@ func (this *Chunk) Equal(that interface{}) bool {
 	if !this.Data.Equal(that1.Data) {
 		return false
 	}
+	if this.Checksum != that1.Checksum {
+		return false
+	}
 	return true
 }
 func (this *Series) Equal(that interface{}) bool {
@@ -423,10 +430,11 @@ func (this *Chunk) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&storepb.Chunk{")
 	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
 	s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	s = append(s, "Checksum: "+fmt.Sprintf("%#v", this.Checksum)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -501,6 +509,11 @@ func (m *Chunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Checksum != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.Checksum))
+		i--
+		dAtA[i] = 0x18
+	}
 	{
 		size := m.Data.Size()
 		i -= size
@@ -679,6 +692,9 @@ func (m *Chunk) Size() (n int) {
 	}
 	l = m.Data.Size()
 	n += 1 + l + sovTypes(uint64(l))
+	if m.Checksum != 0 {
+		n += 1 + sovTypes(uint64(m.Checksum))
+	}
 	return n
 }
 
@@ -755,6 +771,7 @@ func (this *Chunk) String() string {
 	s := strings.Join([]string{`&Chunk{`,
 		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
 		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`Checksum:` + fmt.Sprintf("%v", this.Checksum) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -888,6 +905,25 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Checksum", wireType)
+			}
+			m.Checksum = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Checksum |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
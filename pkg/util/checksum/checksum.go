@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package checksum computes the checksums used to detect the corruption of the query results exchanged
+// between queriers and query-frontends, and of the chunks sent by store-gateways to queriers.
+package checksum
+
+import (
+	"hash/crc32"
+	"strconv"
+)
+
+const (
+	// RequestHTTPHeader is the HTTP header set by the query-frontend to request the checksum of the query result.
+	RequestHTTPHeader = "X-Mimir-Result-Checksum-Requested"
+
+	// ResponseHTTPHeader is the HTTP header carrying the checksum of the query result.
+	ResponseHTTPHeader = "X-Mimir-Result-Checksum"
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Compute returns the CRC32 (Castagnoli) checksum of data.
+func Compute(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoliTable)
+}
+
+// Format returns the checksum of data formatted to be sent in the ResponseHTTPHeader.
+func Format(data []byte) string {
+	return strconv.FormatUint(uint64(Compute(data)), 16)
+}
+
+// Verify returns whether the formatted checksum, as returned by Format, matches the checksum of data.
+func Verify(data []byte, formatted string) bool {
+	expected, err := strconv.ParseUint(formatted, 16, 32)
+	if err != nil {
+		return false
+	}
+	return uint32(expected) == Compute(data)
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package checksum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	data := []byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	formatted := Format(data)
+
+	assert.True(t, Verify(data, formatted))
+	assert.False(t, Verify(data, ""))
+	assert.False(t, Verify(data, "not-a-checksum"))
+
+	// Flip a single bit.
+	corrupted := append([]byte(nil), data...)
+	corrupted[10] ^= 1
+	assert.False(t, Verify(corrupted, formatted))
+}
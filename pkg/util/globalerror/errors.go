@@ -24,6 +24,9 @@ const (
 	SeriesWithDuplicateLabelNames ID = "duplicate-label-names"
 	SeriesLabelsNotSorted         ID = "labels-not-sorted"
 	SampleTooFarInFuture          ID = "too-far-in-future"
+	SampleTimestampsNotMonotonic  ID = "sample-timestamps-not-monotonic"
+	LabelNameNotAllowed           ID = "label-name-not-allowed"
+	MissingRequiredLabelName      ID = "missing-required-label-name"
 	MaxSeriesPerMetric            ID = "max-series-per-metric"
 	MaxMetadataPerMetric          ID = "max-metadata-per-metric"
 	MaxSeriesPerUser              ID = "max-series-per-user"
@@ -47,7 +50,7 @@ const (
 
 	MetricMetadataMissingMetricName ID = "metadata-missing-metric-name"
 	MetricMetadataMetricNameTooLong ID = "metric-name-too-long"
-	MetricMetadataHelpTooLong       ID = "help-too-long" // unused, left here to prevent reuse for different purpose
+	MetricMetadataHelpTooLong       ID = "help-too-long"
 	MetricMetadataUnitTooLong       ID = "unit-too-long"
 
 	MaxQueryLength              ID = "max-query-length"
@@ -55,7 +58,10 @@ const (
 	MaxQueryExpressionSizeBytes ID = "max-query-expression-size-bytes"
 	RequestRateLimited          ID = "tenant-max-request-rate"
 	IngestionRateLimited        ID = "tenant-max-ingestion-rate"
+	IngestionBytesRateLimited   ID = "tenant-max-ingestion-bytes-rate"
+	IngestionQuotaExceeded      ID = "tenant-ingestion-quota-exceeded"
 	TooManyHAClusters           ID = "tenant-too-many-ha-clusters"
+	TenantCircuitBreakerOpen    ID = "tenant-circuit-breaker-open"
 
 	SampleTimestampTooOld    ID = "sample-timestamp-too-old"
 	SampleOutOfOrder         ID = "sample-out-of-order"
@@ -96,6 +102,23 @@ func (id ID) MessageWithStrategyAndPerTenantLimitConfig(msg, strategy, flag stri
 		msg, errPrefix, id, strategy, plural, flagsList)
 }
 
+// IDFromMessage returns the error ID appended to the message by one of the ID message functions,
+// or false if the message has no error ID.
+func IDFromMessage(msg string) (ID, bool) {
+	// The error ID follows the message, which may include arbitrary user input like the series labels.
+	start := strings.LastIndex(msg, "("+errPrefix)
+	if start < 0 {
+		return "", false
+	}
+	start += len(errPrefix) + 1
+
+	end := strings.IndexByte(msg[start:], ')')
+	if end <= 0 {
+		return "", false
+	}
+	return ID(msg[start : start+end]), true
+}
+
 func buildFlagsList(flag string, addFlags ...string) (string, string) {
 	var sb strings.Builder
 	sb.WriteString("-")
@@ -58,3 +58,21 @@ func TestID_MessageWithPerTenantLimitConfig(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.actual)
 	}
 }
+
+func TestIDFromMessage(t *testing.T) {
+	for msg, expected := range map[string]ID{
+		MissingMetricName.Message("an error"):                                                                 MissingMetricName,
+		SeriesLabelNameTooLong.MessageWithPerTenantLimitConfig("an error", "my-flag1"):                        SeriesLabelNameTooLong,
+		IngestionRateLimited.MessageWithStrategyAndPerTenantLimitConfig("an error", "a strategy", "my-flag1"): IngestionRateLimited,
+		SeriesInvalidLabel.Message("an error with series '{name=\"(err-mimir-fake)\"}'"):                      SeriesInvalidLabel,
+	} {
+		id, ok := IDFromMessage(msg)
+		assert.True(t, ok)
+		assert.Equal(t, expected, id)
+	}
+
+	for _, msg := range []string{"an error", "an error (err-mimir-", "an error (err-mimir-)"} {
+		_, ok := IDFromMessage(msg)
+		assert.False(t, ok, msg)
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package zstd registers the zstd gRPC compressor. Importing it makes the zstd compressor available
+// to both the gRPC clients, which can use it to compress the requests, and the gRPC servers, which can
+// decompress the requests compressed with it.
+package zstd
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the name registered for the zstd compressor.
+const Name = "zstd"
+
+func init() {
+	encoding.RegisterCompressor(newCompressor())
+}
+
+type compressor struct {
+	encodersPool sync.Pool
+	decodersPool sync.Pool
+}
+
+func newCompressor() *compressor {
+	c := &compressor{}
+	c.encodersPool = sync.Pool{
+		New: func() interface{} {
+			// The options are valid, so the error can be ignored.
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedFastest))
+			return enc
+		},
+	}
+	c.decodersPool = sync.Pool{
+		New: func() interface{} {
+			// The options are valid, so the error can be ignored. A decoder with concurrency 1
+			// doesn't run background goroutines, so it's safe to drop it from the pool.
+			dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+			return dec
+		},
+	}
+	return c
+}
+
+func (c *compressor) Name() string {
+	return Name
+}
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc := c.encodersPool.Get().(*zstd.Encoder)
+	enc.Reset(w)
+	return &writeCloser{enc: enc, pool: &c.encodersPool}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec := c.decodersPool.Get().(*zstd.Decoder)
+	if err := dec.Reset(r); err != nil {
+		c.decodersPool.Put(dec)
+		return nil, err
+	}
+	return &reader{dec: dec, pool: &c.decodersPool}, nil
+}
+
+type writeCloser struct {
+	enc  *zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *writeCloser) Write(p []byte) (int, error) {
+	return w.enc.Write(p)
+}
+
+func (w *writeCloser) Close() error {
+	defer func() {
+		// Release the reference to the underlying writer before returning the encoder to the pool.
+		w.enc.Reset(nil)
+		w.pool.Put(w.enc)
+	}()
+	return w.enc.Close()
+}
+
+type reader struct {
+	dec  *zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.dec == nil {
+		return 0, io.EOF
+	}
+
+	n, err := r.dec.Read(p)
+	if err == io.EOF {
+		// The decoder isn't used anymore once the whole message has been read.
+		_ = r.dec.Reset(nil)
+		r.pool.Put(r.dec)
+		r.dec = nil
+	}
+	return n, err
+}
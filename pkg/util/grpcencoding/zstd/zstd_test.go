@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressor(t *testing.T) {
+	c := encoding.GetCompressor(Name)
+	require.NotNil(t, c)
+
+	for _, input := range []string{"", "short", strings.Repeat("a long message, ", 100000)} {
+		// Run each message a few times, to reuse the pooled encoders and decoders.
+		for i := 0; i < 3; i++ {
+			buf := &bytes.Buffer{}
+			w, err := c.Compress(buf)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(input))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			r, err := c.Decompress(buf)
+			require.NoError(t, err)
+			output, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, input, string(output))
+		}
+	}
+}
+
+func TestCompressor_DecompressInvalidInput(t *testing.T) {
+	c := encoding.GetCompressor(Name)
+
+	r, err := c.Decompress(strings.NewReader("not compressed with zstd"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+}
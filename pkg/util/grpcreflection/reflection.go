@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package grpcreflection provides the gRPC server reflection service for the services generated with gogoproto.
+package grpcreflection
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path"
+	"sync"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Register registers the gRPC server reflection service on the server, so that tools like grpcurl
+// can list and call the services of the server without their .proto files.
+func Register(s *grpc.Server) {
+	reflectionpb.RegisterServerReflectionServer(s, reflection.NewServer(reflection.ServerOptions{
+		Services:           s,
+		DescriptorResolver: newResolver(s),
+	}))
+}
+
+// resolver resolves the descriptors of the files registered in the gogoproto registry, which the
+// default gRPC reflection service can't see because it only looks up the protobuf global registry.
+//
+// The gogoproto files are registered by their file name, while they're imported by their full path,
+// for example "mimir.proto" is imported as "github.com/grafana/mimir/pkg/mimirpb/mimir.proto". Imports
+// which can't be found by their full path are looked up by their file name, and rewritten to it, so
+// that the descriptors returned to clients are consistent.
+type resolver struct {
+	services reflection.ServiceInfoProvider
+
+	mtx            sync.Mutex
+	files          *protoregistry.Files
+	servicesLoaded bool
+}
+
+func newResolver(services reflection.ServiceInfoProvider) *resolver {
+	return &resolver{
+		services: services,
+		files:    &protoregistry.Files{},
+	}
+}
+
+// FindFileByPath implements protodesc.Resolver.
+func (r *resolver) FindFileByPath(p string) (protoreflect.FileDescriptor, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.findFileByPath(p)
+}
+
+// FindDescriptorByName implements protodesc.Resolver.
+func (r *resolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if d, err := r.findDescriptorByName(name); err == nil || r.servicesLoaded {
+		return d, err
+	}
+
+	// The files are loaded lazily, so the symbol may be defined in a file which hasn't been loaded yet.
+	// The services of the server reference the file they're defined in, so we load them and retry.
+	r.servicesLoaded = true
+	for _, info := range r.services.GetServiceInfo() {
+		if file, ok := info.Metadata.(string); ok {
+			_, _ = r.findFileByPath(file)
+		}
+	}
+	return r.findDescriptorByName(name)
+}
+
+func (r *resolver) findDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+func (r *resolver) findFileByPath(p string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(p); err == nil {
+		return fd, nil
+	}
+	if data := gogoproto.FileDescriptor(p); data != nil {
+		return r.loadGogoFile(p, data)
+	}
+	if fd, err := protoregistry.GlobalFiles.FindFileByPath(p); err == nil {
+		return fd, nil
+	}
+
+	if name := path.Base(p); name != p {
+		if fd, err := r.files.FindFileByPath(name); err == nil {
+			return fd, nil
+		}
+		if data := gogoproto.FileDescriptor(name); data != nil {
+			return r.loadGogoFile(name, data)
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+// loadGogoFile builds the descriptor of the gogoproto file from its gzipped descriptor, resolving
+// its imports first, and registers it.
+func (r *resolver) loadGogoFile(p string, gzipped []byte) (protoreflect.FileDescriptor, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(gzipped))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the descriptor of %s", p)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the descriptor of %s", p)
+	}
+
+	fdp := &descriptorpb.FileDescriptorProto{}
+	if err := proto.Unmarshal(data, fdp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the descriptor of %s", p)
+	}
+
+	for i, dep := range fdp.Dependency {
+		fd, err := r.findFileByPath(dep)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve the import %s of %s", dep, p)
+		}
+		fdp.Dependency[i] = fd.Path()
+	}
+
+	fd, err := protodesc.NewFile(fdp, lockedResolver{r})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build the descriptor of %s", p)
+	}
+	if err := r.files.RegisterFile(fd); err != nil {
+		return nil, errors.Wrapf(err, "failed to register the descriptor of %s", p)
+	}
+	return fd, nil
+}
+
+// lockedResolver is the resolver used while its lock is already held.
+type lockedResolver struct {
+	r *resolver
+}
+
+func (l lockedResolver) FindFileByPath(p string) (protoreflect.FileDescriptor, error) {
+	return l.r.findFileByPath(p)
+}
+
+func (l lockedResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return l.r.findDescriptorByName(name)
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package grpcreflection
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/grafana/mimir/pkg/distributor/distributorpb"
+	"github.com/grafana/mimir/pkg/ingester/client"
+)
+
+func TestRegister(t *testing.T) {
+	server := grpc.NewServer()
+	distributorpb.RegisterDistributorServer(server, &distributorpb.UnimplementedDistributorServer{})
+	client.RegisterIngesterServer(server, &client.UnimplementedIngesterServer{})
+	Register(server)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+
+	// The services of the server are listed.
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"distributor.Distributor", "cortex.Ingester", "grpc.reflection.v1alpha.ServerReflection"}, services)
+
+	// The descriptors of the files defining the services can be resolved, together with their imports.
+	// Each file is sent once per stream, so the files are accumulated across the responses.
+	files := &descriptorpb.FileDescriptorSet{}
+	for _, symbol := range []string{"cortex.Ingester", "distributor.Distributor"} {
+		require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+		}))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Nil(t, resp.GetErrorResponse(), "symbol: %s", symbol)
+
+		for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			require.NoError(t, proto.Unmarshal(data, fdp))
+			files.File = append(files.File, fdp)
+		}
+	}
+
+	registry, err := protodesc.NewFiles(files)
+	require.NoError(t, err)
+	for _, name := range []protoreflect.FullName{"cortex.Ingester.Push", "distributor.Distributor.Push", "cortexpb.WriteRequest"} {
+		_, err = registry.FindDescriptorByName(name)
+		assert.NoError(t, err, "name: %s", name)
+	}
+
+	// Unknown symbols are reported as not found.
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "unknown.Service"},
+	}))
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+}
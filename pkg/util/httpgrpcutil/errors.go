@@ -3,7 +3,10 @@
 package httpgrpcutil
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/weaveworks/common/httpgrpc"
 )
@@ -34,3 +37,17 @@ func PrioritizeRecoverableErr(errs ...error) error {
 
 	return firstErr
 }
+
+// TooManyRequestsResponse returns a HTTP 429 response. If retryAfter is greater than 0, the response
+// has a Retry-After header, telling the client how many seconds to back off before retrying.
+func TooManyRequestsResponse(body string, retryAfter time.Duration) *httpgrpc.HTTPResponse {
+	resp := &httpgrpc.HTTPResponse{
+		Code: http.StatusTooManyRequests,
+		Body: []byte(body),
+	}
+	if retryAfter > 0 {
+		seconds := int64(math.Ceil(retryAfter.Seconds()))
+		resp.Headers = append(resp.Headers, &httpgrpc.Header{Key: "Retry-After", Values: []string{strconv.FormatInt(seconds, 10)}})
+	}
+	return resp
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
@@ -62,3 +63,13 @@ func TestPrioritizeRecoverableErr(t *testing.T) {
 		})
 	}
 }
+
+func TestTooManyRequestsResponse(t *testing.T) {
+	resp := TooManyRequestsResponse("too many requests", 0)
+	require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	require.Equal(t, "too many requests", string(resp.Body))
+	require.Empty(t, resp.Headers)
+
+	resp = TooManyRequestsResponse("too many requests", 1500*time.Millisecond)
+	require.Equal(t, []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"2"}}}, resp.Headers)
+}
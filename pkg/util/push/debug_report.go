@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// DebugHeader is the HTTP header requesting a DebugReport of how the write request has been processed.
+const DebugHeader = "X-Mimir-Debug-Push"
+
+const (
+	DebugStepPassed   = "passed"
+	DebugStepRejected = "rejected"
+	DebugStepDropped  = "dropped"
+)
+
+type debugReportContextKey int
+
+const debugReportKey debugReportContextKey = 0
+
+// DebugReport is a structured report of how a write request has been processed, returned to the client
+// which requested it with the DebugHeader. The report is only collected once enabled for the tenant,
+// which must be allowed to request it. It's safe for concurrent use, and all its methods are no-op
+// on a nil report.
+type DebugReport struct {
+	mtx     sync.Mutex
+	enabled bool
+
+	Tenant    string                `json:"tenant,omitempty"`
+	Steps     []DebugReportStep     `json:"steps"`
+	Ingesters []DebugReportIngester `json:"ingesters"`
+	Duration  model.Duration        `json:"duration"`
+	Error     string                `json:"error,omitempty"`
+
+	startTime time.Time
+}
+
+// DebugReportStep describes the decision taken by a step of the write path.
+type DebugReportStep struct {
+	Name     string         `json:"name"`
+	Decision string         `json:"decision"`
+	Reason   string         `json:"reason,omitempty"`
+	Series   int            `json:"series"`
+	Metadata int            `json:"metadata"`
+	Duration model.Duration `json:"duration"`
+}
+
+// DebugReportIngester describes the push of a subset of the write request to an ingester.
+// Ingesters which haven't replied yet when the response is sent to the client are not reported.
+type DebugReportIngester struct {
+	Addr     string         `json:"addr"`
+	Zone     string         `json:"zone,omitempty"`
+	Series   int            `json:"series"`
+	Metadata int            `json:"metadata"`
+	Duration model.Duration `json:"duration"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// NewDebugReport makes a new DebugReport.
+func NewDebugReport() *DebugReport {
+	return &DebugReport{
+		Steps:     []DebugReportStep{},
+		Ingesters: []DebugReportIngester{},
+		startTime: time.Now(),
+	}
+}
+
+// ContextWithDebugReport returns a new context carrying the input report.
+func ContextWithDebugReport(ctx context.Context, report *DebugReport) context.Context {
+	return context.WithValue(ctx, debugReportKey, report)
+}
+
+// DebugReportFromContext returns the DebugReport carried by the context, or nil if there's none.
+func DebugReportFromContext(ctx context.Context) *DebugReport {
+	report, _ := ctx.Value(debugReportKey).(*DebugReport)
+	return report
+}
+
+// Enable enables the collection of the report, once the tenant which sent the write request
+// has been allowed to request it.
+func (r *DebugReport) Enable(tenantID string) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.enabled = true
+	r.Tenant = tenantID
+}
+
+// Enabled returns whether the report is collected.
+func (r *DebugReport) Enabled() bool {
+	if r == nil {
+		return false
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.enabled
+}
+
+// AddStep adds the decision taken by a step of the write path.
+func (r *DebugReport) AddStep(step DebugReportStep) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if !r.enabled {
+		return
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// AddIngester adds the outcome of the push to an ingester.
+func (r *DebugReport) AddIngester(ingester DebugReportIngester) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if !r.enabled {
+		return
+	}
+	r.Ingesters = append(r.Ingesters, ingester)
+}
+
+// finish records the outcome of the write request.
+func (r *DebugReport) finish(errMsg string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.Duration = model.Duration(time.Since(r.startTime))
+	r.Error = errMsg
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *DebugReport) MarshalJSON() ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// The alias type has no methods, so that it's marshalled with the default encoding.
+	type plain DebugReport
+	return json.Marshal((*plain)(r))
+}
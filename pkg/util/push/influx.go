@@ -0,0 +1,391 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/middleware"
+	"go.uber.org/multierr"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	influxParseError = "influx_parse_error"
+
+	// influxValueField is the name of the field which is mapped to a series named after the measurement only.
+	influxValueField = "value"
+)
+
+// InfluxLimits are the per-tenant limits used by the Influx line protocol handler.
+type InfluxLimits interface {
+	InfluxIngestionEnabled(userID string) bool
+}
+
+// InfluxHandler is a http.Handler accepting Influx line protocol write requests. Each numeric or boolean
+// field of a point is mapped to a series named <measurement>_<field>, or <measurement> for the "value"
+// field, with the tags of the point as labels. String fields are ignored.
+func InfluxHandler(
+	maxRecvMsgSize int,
+	sourceIPs *middleware.SourceIPExtractor,
+	allowSkipLabelNameValidation bool,
+	limits InfluxLimits,
+	reg prometheus.Registerer,
+	push Func,
+) http.Handler {
+	discardedDueToInfluxParseError := validation.DiscardedSamplesCounter(reg, influxParseError)
+
+	return handler(maxRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, push, func(ctx context.Context, r *http.Request, maxRecvMsgSize int, dst []byte, req *mimirpb.PreallocWriteRequest) ([]byte, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !limits.InfluxIngestionEnabled(userID) {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, "Influx line protocol ingestion is disabled for the tenant: %v", userID)
+		}
+
+		precision, err := parseInfluxPrecision(r.FormValue("precision"))
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := readBody(r, maxRecvMsgSize)
+		if err != nil {
+			return body, err
+		}
+
+		timeseries, parseErrs := influxLinesToTimeseries(body, precision, time.Now())
+		if len(parseErrs) > 0 {
+			discardedDueToInfluxParseError.WithLabelValues(userID, "").Add(float64(len(parseErrs))) // Group is empty here as the lines couldn't be parsed
+
+			msg := multierr.Combine(parseErrs...).Error()
+			if len(msg) > maxErrMsgLen {
+				msg = msg[:maxErrMsgLen]
+			}
+
+			if len(timeseries) == 0 {
+				return body, errors.New(msg)
+			}
+
+			level.Warn(log.WithContext(ctx, log.Logger)).Log("msg", "Influx line protocol parse error", "err", msg)
+		}
+
+		req.Timeseries = timeseries
+		return body, nil
+	})
+}
+
+// parseInfluxPrecision returns the unit of the timestamps of the points, by default nanoseconds.
+func parseInfluxPrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, httpgrpc.Errorf(http.StatusBadRequest, "invalid precision %q, supported: [ns, us, ms, s]", precision)
+	}
+}
+
+// influxLinesToTimeseries converts the points of the input to time series, one per numeric or boolean field.
+// The points without timestamp are given the now timestamp. It returns an error for each line which couldn't
+// be parsed.
+func influxLinesToTimeseries(body []byte, precision time.Duration, now time.Time) ([]mimirpb.PreallocTimeseries, []error) {
+	var (
+		timeseries = mimirpb.PreallocTimeseriesSliceFromPool()
+		errs       []error
+	)
+
+	for lineNo, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		p, err := parseInfluxLine(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNo+1, err))
+			continue
+		}
+
+		timestampMs := now.UnixMilli()
+		if p.hasTimestamp {
+			timestampMs = p.timestamp * int64(precision) / int64(time.Millisecond)
+		}
+
+		labels, err := influxTagsToLabels(p.tags)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNo+1, err))
+			continue
+		}
+		// The metric name doesn't always sort first, because the tag keys may start with upper case letters.
+		nameIdx := sort.Search(len(labels), func(i int) bool { return labels[i].Name > model.MetricNameLabel })
+
+		for _, f := range p.fields {
+			name := p.measurement
+			if f.key != influxValueField {
+				name += "_" + f.key
+			}
+
+			ts := mimirpb.TimeseriesFromPool()
+			ts.Labels = append(ts.Labels, labels[:nameIdx]...)
+			ts.Labels = append(ts.Labels, mimirpb.LabelAdapter{Name: model.MetricNameLabel, Value: sanitizeInfluxName(name)})
+			ts.Labels = append(ts.Labels, labels[nameIdx:]...)
+			ts.Samples = append(ts.Samples, mimirpb.Sample{TimestampMs: timestampMs, Value: f.value})
+			timeseries = append(timeseries, mimirpb.PreallocTimeseries{TimeSeries: ts})
+		}
+	}
+
+	return timeseries, errs
+}
+
+// influxTagsToLabels returns the labels of the tags, sorted by their sanitized name.
+func influxTagsToLabels(tags []influxTag) ([]mimirpb.LabelAdapter, error) {
+	labels := make([]mimirpb.LabelAdapter, 0, len(tags))
+	for _, t := range tags {
+		labels = append(labels, mimirpb.LabelAdapter{Name: sanitizeInfluxName(t.key), Value: t.value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	for i, l := range labels {
+		if l.Name == model.MetricNameLabel {
+			return nil, fmt.Errorf("tag key %s is reserved", model.MetricNameLabel)
+		}
+		if i > 0 && labels[i-1].Name == l.Name {
+			return nil, fmt.Errorf("duplicate tag key %s", l.Name)
+		}
+	}
+	return labels, nil
+}
+
+// sanitizeInfluxName replaces the characters not allowed in Prometheus metric and label names with underscores.
+func sanitizeInfluxName(name string) string {
+	valid := func(i int, r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (r >= '0' && r <= '9' && i > 0)
+	}
+
+	sanitized := true
+	for i, r := range name {
+		if !valid(i, r) {
+			sanitized = false
+			break
+		}
+	}
+	if sanitized {
+		return name
+	}
+
+	b := strings.Builder{}
+	b.Grow(len(name))
+	for i, r := range name {
+		if valid(i, r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+type influxTag struct {
+	key, value string
+}
+
+type influxField struct {
+	key   string
+	value float64
+}
+
+type influxPoint struct {
+	measurement  string
+	tags         []influxTag
+	fields       []influxField
+	timestamp    int64
+	hasTimestamp bool
+}
+
+// parseInfluxLine parses a line of the Influx line protocol:
+//
+//	<measurement>[,<tag_key>=<tag_value>...] <field_key>=<field_value>[,<field_key>=<field_value>...] [<timestamp>]
+//
+// String fields are parsed, but not returned.
+func parseInfluxLine(line []byte) (influxPoint, error) {
+	var (
+		p   influxPoint
+		pos int
+	)
+
+	p.measurement, pos = readInfluxToken(line, pos, ", ")
+	if p.measurement == "" {
+		return p, errors.New("missing measurement")
+	}
+
+	for pos < len(line) && line[pos] == ',' {
+		var key, value string
+		key, pos = readInfluxToken(line, pos+1, ",= ")
+		if pos >= len(line) || line[pos] != '=' {
+			return p, fmt.Errorf("missing tag value for tag key %q", key)
+		}
+		value, pos = readInfluxToken(line, pos+1, ",= ")
+		if key == "" || value == "" {
+			return p, errors.New("empty tag key or value")
+		}
+		p.tags = append(p.tags, influxTag{key: key, value: value})
+	}
+
+	if pos >= len(line) || line[pos] != ' ' {
+		return p, errors.New("missing fields")
+	}
+	pos = skipInfluxSpaces(line, pos)
+
+	for {
+		var key string
+		key, pos = readInfluxToken(line, pos, ",= ")
+		if key == "" || pos >= len(line) || line[pos] != '=' {
+			return p, errors.New("invalid field, expected <field_key>=<field_value>")
+		}
+		pos++
+
+		if pos < len(line) && line[pos] == '"' {
+			end, err := skipInfluxString(line, pos)
+			if err != nil {
+				return p, fmt.Errorf("field %q: %w", key, err)
+			}
+			pos = end
+		} else {
+			var raw string
+			raw, pos = readInfluxToken(line, pos, ", ")
+			value, err := parseInfluxFieldValue(raw)
+			if err != nil {
+				return p, fmt.Errorf("field %q: %w", key, err)
+			}
+			p.fields = append(p.fields, influxField{key: key, value: value})
+		}
+
+		if pos >= len(line) || line[pos] != ',' {
+			break
+		}
+		pos++
+	}
+
+	pos = skipInfluxSpaces(line, pos)
+	if pos < len(line) {
+		ts, err := strconv.ParseInt(string(line[pos:]), 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid timestamp %q", line[pos:])
+		}
+		p.timestamp, p.hasTimestamp = ts, true
+	}
+
+	return p, nil
+}
+
+// readInfluxToken reads an unescaped token from pos, until one of the unescaped stop characters.
+// It returns the token and the position of the stop character, or the end of the line.
+func readInfluxToken(line []byte, pos int, stops string) (string, int) {
+	start := pos
+	escaped := false
+	for ; pos < len(line); pos++ {
+		c := line[pos]
+		if c == '\\' && pos+1 < len(line) {
+			escaped = true
+			pos++
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+	}
+
+	token := line[start:pos]
+	if !escaped {
+		return string(token), pos
+	}
+
+	// Backslashes only escape commas, equal signs, spaces and backslashes.
+	b := make([]byte, 0, len(token))
+	for i := 0; i < len(token); i++ {
+		if token[i] == '\\' && i+1 < len(token) && strings.IndexByte(`,= \`, token[i+1]) >= 0 {
+			i++
+		}
+		b = append(b, token[i])
+	}
+	return string(b), pos
+}
+
+// skipInfluxString returns the position after the closing quote of the string field starting at pos.
+func skipInfluxString(line []byte, pos int) (int, error) {
+	for pos++; pos < len(line); pos++ {
+		switch line[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1, nil
+		}
+	}
+	return pos, errors.New("unterminated string")
+}
+
+func skipInfluxSpaces(line []byte, pos int) int {
+	for pos < len(line) && line[pos] == ' ' {
+		pos++
+	}
+	return pos
+}
+
+// parseInfluxFieldValue parses a float, integer (with the i suffix), unsigned integer (with the u suffix)
+// or boolean field value.
+func parseInfluxFieldValue(raw string) (float64, error) {
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, nil
+	case "":
+		return 0, errors.New("empty value")
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer value %q", raw)
+		}
+		return float64(v), nil
+	case 'u':
+		v, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid unsigned integer value %q", raw)
+		}
+		return float64(v), nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", raw)
+	}
+	return v, nil
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestParseInfluxLine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		line        string
+		expected    influxPoint
+		expectedErr string
+	}{
+		"measurement, tags, fields and timestamp": {
+			line: `cpu,host=server01,region=us-west usage_idle=12.5,usage_user=3i,ok=t,count=7u 1465839830100400200`,
+			expected: influxPoint{
+				measurement:  "cpu",
+				tags:         []influxTag{{"host", "server01"}, {"region", "us-west"}},
+				fields:       []influxField{{"usage_idle", 12.5}, {"usage_user", 3}, {"ok", 1}, {"count", 7}},
+				timestamp:    1465839830100400200,
+				hasTimestamp: true,
+			},
+		},
+		"no tags and no timestamp": {
+			line: `temperature value=21.3`,
+			expected: influxPoint{
+				measurement: "temperature",
+				fields:      []influxField{{"value", 21.3}},
+			},
+		},
+		"escaped characters": {
+			line: `my\ measurement,tag\,key=tag\ value\=1 field\=key=1`,
+			expected: influxPoint{
+				measurement: "my measurement",
+				tags:        []influxTag{{"tag,key", "tag value=1"}},
+				fields:      []influxField{{"field=key", 1}},
+			},
+		},
+		"string fields are skipped": {
+			line: `event message="hello, \"world\" ",level=2i 10`,
+			expected: influxPoint{
+				measurement:  "event",
+				fields:       []influxField{{"level", 2}},
+				timestamp:    10,
+				hasTimestamp: true,
+			},
+		},
+		"missing measurement": {
+			line:        `,host=a value=1`,
+			expectedErr: "missing measurement",
+		},
+		"missing fields": {
+			line:        `cpu,host=a`,
+			expectedErr: "missing fields",
+		},
+		"missing tag value": {
+			line:        `cpu,host value=1`,
+			expectedErr: `missing tag value for tag key "host"`,
+		},
+		"invalid field": {
+			line:        `cpu value`,
+			expectedErr: "invalid field, expected <field_key>=<field_value>",
+		},
+		"invalid field value": {
+			line:        `cpu value=abc`,
+			expectedErr: `field "value": invalid value "abc"`,
+		},
+		"invalid integer field value": {
+			line:        `cpu value=1.5i`,
+			expectedErr: `field "value": invalid integer value "1.5i"`,
+		},
+		"unterminated string": {
+			line:        `cpu message="abc`,
+			expectedErr: `field "message": unterminated string`,
+		},
+		"invalid timestamp": {
+			line:        `cpu value=1 abc`,
+			expectedErr: `invalid timestamp "abc"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := parseInfluxLine([]byte(tc.line))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestInfluxLinesToTimeseries(t *testing.T) {
+	now := time.UnixMilli(1000)
+	body := strings.Join([]string{
+		`# comment`,
+		`cpu,region=us-west,host=server01 usage_idle=12.5,value=3 1465839830100400200`,
+		``,
+		`disk.io,1host=a read=1`,
+		`invalid`,
+		`cpu,__name__=foo value=1`,
+		`mem,zone=b,Host=B,a.b=c,Region=X value=2`,
+	}, "\n")
+
+	timeseries, errs := influxLinesToTimeseries([]byte(body), time.Nanosecond, now)
+	defer mimirpb.ReuseSlice(timeseries)
+
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "line 5: missing fields")
+	assert.EqualError(t, errs[1], "line 6: tag key __name__ is reserved")
+
+	require.Len(t, timeseries, 4)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "__name__", Value: "cpu_usage_idle"}, {Name: "host", Value: "server01"}, {Name: "region", Value: "us-west"}}, timeseries[0].Labels)
+	assert.Equal(t, []mimirpb.Sample{{TimestampMs: 1465839830100, Value: 12.5}}, timeseries[0].Samples)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "server01"}, {Name: "region", Value: "us-west"}}, timeseries[1].Labels)
+	assert.Equal(t, []mimirpb.Sample{{TimestampMs: 1465839830100, Value: 3}}, timeseries[1].Samples)
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "__name__", Value: "disk_io_read"}, {Name: "_host", Value: "a"}}, timeseries[2].Labels)
+	assert.Equal(t, []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}, timeseries[2].Samples)
+	// The upper case tag keys sort before the metric name.
+	assert.Equal(t, []mimirpb.LabelAdapter{{Name: "Host", Value: "B"}, {Name: "Region", Value: "X"}, {Name: "__name__", Value: "mem"}, {Name: "a_b", Value: "c"}, {Name: "zone", Value: "b"}}, timeseries[3].Labels)
+}
+
+type influxLimitsMock map[string]bool
+
+func (m influxLimitsMock) InfluxIngestionEnabled(userID string) bool {
+	return m[userID]
+}
+
+func TestInfluxHandler(t *testing.T) {
+	for name, tc := range map[string]struct {
+		tenant             string
+		body               string
+		query              string
+		expectedStatus     int
+		expectedTimeseries int
+		expectedTimestamp  int64
+		expectedDiscarded  string
+	}{
+		"valid request": {
+			tenant:             "enabled",
+			body:               "cpu,host=a value=1,usage=2 1700000000\nmem,host=a used=3 1700000000\n",
+			query:              "?precision=s",
+			expectedStatus:     http.StatusOK,
+			expectedTimeseries: 3,
+			expectedTimestamp:  1700000000000,
+		},
+		"partially invalid request": {
+			tenant:             "enabled",
+			body:               "cpu,host=a value=1 1700000000000\ninvalid\n",
+			query:              "?precision=ms",
+			expectedStatus:     http.StatusOK,
+			expectedTimeseries: 1,
+			expectedTimestamp:  1700000000000,
+			expectedDiscarded: `
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{group="",reason="influx_parse_error",user="enabled"} 1
+			`,
+		},
+		"invalid request": {
+			tenant:         "enabled",
+			body:           "invalid\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedDiscarded: `
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{group="",reason="influx_parse_error",user="enabled"} 1
+			`,
+		},
+		"invalid precision": {
+			tenant:         "enabled",
+			body:           "cpu value=1\n",
+			query:          "?precision=h",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"disabled for the tenant": {
+			tenant:         "disabled",
+			body:           "cpu value=1\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			pushed := false
+
+			handler := InfluxHandler(100000, nil, false, influxLimitsMock{"enabled": true}, reg, func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				request, err := pushReq.WriteRequest()
+				if err != nil {
+					return nil, err
+				}
+				defer pushReq.CleanUp()
+
+				pushed = true
+				assert.Len(t, request.Timeseries, tc.expectedTimeseries)
+				for _, ts := range request.Timeseries {
+					assert.Equal(t, tc.expectedTimestamp, ts.Samples[0].TimestampMs)
+				}
+				return &mimirpb.WriteResponse{}, nil
+			})
+
+			req, err := http.NewRequest("POST", "http://localhost/api/v1/push/influx"+tc.query, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			req.Header.Set("X-Scope-OrgID", tc.tenant)
+			_, ctx, err := tenant.ExtractTenantIDFromHTTPRequest(req)
+			require.NoError(t, err)
+			req = req.WithContext(ctx)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+			assert.Equal(t, tc.expectedStatus == http.StatusOK, pushed)
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(tc.expectedDiscarded), "cortex_discarded_samples_total"))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// IngestionReportHeader is the HTTP header requesting an IngestionReport of what happened to the samples of the
+// write request. Its value is the mode the report is returned to the client with.
+const IngestionReportHeader = "X-Mimir-Ingestion-Report"
+
+const (
+	// IngestionReportModeHeader returns the JSON encoded report in the IngestionReportHeader response header,
+	// leaving the response body unchanged.
+	IngestionReportModeHeader = "header"
+	// IngestionReportModeBody returns the JSON encoded report as the response body, with the same status code
+	// the response would have if the report wasn't requested.
+	IngestionReportModeBody = "body"
+)
+
+// maxIngestionReportHeaderSize is the maximum size of the IngestionReportHeader response header. The number of
+// reasons of the report is bounded, but its error message is as long as the error of the response, so it's
+// truncated to fit.
+const maxIngestionReportHeaderSize = 4096
+
+// IngestionReportReasonUnknown is the reason of the samples which failed the validation with an error
+// without a discard reason.
+const IngestionReportReasonUnknown = "unknown"
+
+type ingestionReportContextKey int
+
+const ingestionReportKey ingestionReportContextKey = 0
+
+// IngestionReport summarizes what happened to the samples of a write request, returned to the client which
+// requested it with the IngestionReportHeader. Samples include both float and histogram samples. It's safe
+// for concurrent use, and all its methods are no-op on a nil report.
+type IngestionReport struct {
+	mtx sync.Mutex
+
+	ReceivedSamples       int `json:"received_samples"`
+	AcceptedSamples       int `json:"accepted_samples"`
+	DedupedSamples        int `json:"deduped_samples"`
+	RelabelDroppedSamples int `json:"relabel_dropped_samples"`
+	InvalidSamples        int `json:"invalid_samples"`
+	// InvalidSamplesByReason is the number of samples which failed the validation, by discard reason, like the reason
+	// label of the cortex_discarded_samples_total metric.
+	InvalidSamplesByReason map[string]int `json:"invalid_samples_by_reason"`
+	// FailedSeries is the number of series not written to the ingesters, when the write request is partially accepted.
+	FailedSeries int    `json:"failed_series"`
+	Error        string `json:"error,omitempty"`
+}
+
+// NewIngestionReport makes a new IngestionReport.
+func NewIngestionReport() *IngestionReport {
+	return &IngestionReport{
+		InvalidSamplesByReason: map[string]int{},
+	}
+}
+
+// ContextWithIngestionReport returns a new context carrying the input report.
+func ContextWithIngestionReport(ctx context.Context, report *IngestionReport) context.Context {
+	return context.WithValue(ctx, ingestionReportKey, report)
+}
+
+// IngestionReportFromContext returns the IngestionReport carried by the context, or nil if there's none.
+func IngestionReportFromContext(ctx context.Context) *IngestionReport {
+	report, _ := ctx.Value(ingestionReportKey).(*IngestionReport)
+	return report
+}
+
+// AddReceived adds samples received by the distributor, before any of them is modified or dropped.
+func (r *IngestionReport) AddReceived(samples int) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.ReceivedSamples += samples
+}
+
+// AddAccepted adds samples which passed the validation and have been written.
+func (r *IngestionReport) AddAccepted(samples int) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.AcceptedSamples += samples
+}
+
+// AddDeduped adds samples deduplicated by the HA tracker.
+func (r *IngestionReport) AddDeduped(samples int) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.DedupedSamples += samples
+}
+
+// AddRelabelDropped adds samples dropped because their series have been dropped by relabeling.
+func (r *IngestionReport) AddRelabelDropped(samples int) {
+	if r == nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.RelabelDroppedSamples += samples
+}
+
+// AddInvalid adds samples which failed the validation for the input reason.
+func (r *IngestionReport) AddInvalid(reason string, samples int) {
+	if r == nil || samples == 0 {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.InvalidSamples += samples
+	r.InvalidSamplesByReason[reason] += samples
+}
+
+// finish records the outcome of the write request.
+func (r *IngestionReport) finish(failedSeries int, errMsg string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.FailedSeries = failedSeries
+	r.Error = errMsg
+}
+
+// truncateError truncates the error message of the report to maxLength bytes.
+func (r *IngestionReport) truncateError(maxLength int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if maxLength < 0 {
+		maxLength = 0
+	}
+	if len(r.Error) > maxLength {
+		r.Error = strings.ToValidUTF8(r.Error[:maxLength], "")
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *IngestionReport) MarshalJSON() ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// The alias type has no methods, so that it's marshalled with the default encoding.
+	type plain IngestionReport
+	return json.Marshal((*plain)(r))
+}
@@ -7,6 +7,7 @@ package push
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -38,8 +39,17 @@ var bufferPool = sync.Pool{
 }
 
 const SkipLabelNameValidationHeader = "X-Mimir-SkipLabelNameValidation"
+
+// PartialAcceptanceHeader allows the partial acceptance of the write request. If partially accepted, the response
+// body is the protobuf-encoded mimirpb.WriteResponse, listing the series which haven't been written.
+const PartialAcceptanceHeader = "X-Mimir-Partial-Acceptance"
 const statusClientClosedRequest = 499
 
+const (
+	maxRecvMsgSizeFlag          = "distributor.max-recv-msg-size"
+	maxOversizedRecvMsgSizeFlag = "distributor.max-oversized-recv-msg-size"
+)
+
 // Handler is a http.Handler which accepts WriteRequests.
 func Handler(
 	maxRecvMsgSize int,
@@ -47,17 +57,24 @@ func Handler(
 	allowSkipLabelNameValidation bool,
 	push Func,
 ) http.Handler {
-	return handler(maxRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, push, func(ctx context.Context, r *http.Request, maxRecvMsgSize int, dst []byte, req *mimirpb.PreallocWriteRequest) ([]byte, error) {
+	return handler(maxRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, push, remoteWriteParser(maxRecvMsgSizeFlag))
+}
+
+// remoteWriteParser returns a parserFunc reading a snappy compressed remote write request.
+// limitFlag is the flag configuring the max message size, reported when the request is rejected.
+func remoteWriteParser(limitFlag string) parserFunc {
+	return func(ctx context.Context, r *http.Request, maxRecvMsgSize int, dst []byte, req *mimirpb.PreallocWriteRequest) ([]byte, error) {
 		res, err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, dst, req, util.RawSnappy)
 		if errors.Is(err, util.MsgSizeTooLargeErr{}) {
-			err = distributorMaxWriteMessageSizeErr{actual: int(r.ContentLength), limit: maxRecvMsgSize}
+			err = distributorMaxWriteMessageSizeErr{actual: int(r.ContentLength), limit: maxRecvMsgSize, limitFlag: limitFlag}
 		}
 		return res, err
-	})
+	}
 }
 
 type distributorMaxWriteMessageSizeErr struct {
 	actual, limit int
+	limitFlag     string
 }
 
 func (e distributorMaxWriteMessageSizeErr) Error() string {
@@ -65,7 +82,7 @@ func (e distributorMaxWriteMessageSizeErr) Error() string {
 	if e.actual < 0 {
 		msgSizeDesc = ""
 	}
-	return globalerror.DistributorMaxWriteMessageSize.MessageWithPerInstanceLimitConfig(fmt.Sprintf("the incoming push request has been rejected because its message size%s is larger than the allowed limit of %d bytes", msgSizeDesc, e.limit), "distributor.max-recv-msg-size")
+	return globalerror.DistributorMaxWriteMessageSize.MessageWithPerInstanceLimitConfig(fmt.Sprintf("the incoming push request has been rejected because its message size%s is larger than the allowed limit of %d bytes", msgSizeDesc, e.limit), e.limitFlag)
 }
 
 func handler(maxRecvMsgSize int,
@@ -107,6 +124,9 @@ func handler(maxRecvMsgSize int,
 			} else {
 				req.SkipLabelNameValidation = false
 			}
+			if r.Header.Get(PartialAcceptanceHeader) == "true" {
+				req.PartialAcceptance = true
+			}
 
 			cleanup := func() {
 				mimirpb.ReuseSlice(req.Timeseries)
@@ -114,8 +134,33 @@ func handler(maxRecvMsgSize int,
 			}
 			return &req.WriteRequest, cleanup, nil
 		}
+		var debugReport *DebugReport
+		if r.Header.Get(DebugHeader) == "true" {
+			debugReport = NewDebugReport()
+			ctx = ContextWithDebugReport(ctx, debugReport)
+		}
+
+		var ingestionReport *IngestionReport
+		ingestionReportMode := r.Header.Get(IngestionReportHeader)
+		if ingestionReportMode == IngestionReportModeHeader || ingestionReportMode == IngestionReportModeBody {
+			ingestionReport = NewIngestionReport()
+			ctx = ContextWithIngestionReport(ctx, ingestionReport)
+		}
+
 		req := newRequest(supplier)
-		if _, err := push(ctx, req); err != nil {
+		resp, err := push(ctx, req)
+		if debugReport.Enabled() {
+			writeDebugReport(w, debugReport, err)
+			return
+		}
+		if ingestionReport != nil {
+			if ingestionReportMode == IngestionReportModeBody {
+				writeIngestionReport(w, ingestionReport, resp, err)
+				return
+			}
+			setIngestionReportHeader(w, ingestionReport, resp, err)
+		}
+		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				http.Error(w, err.Error(), statusClientClosedRequest)
 				level.Warn(logger).Log("msg", "push request canceled", "err", err)
@@ -129,7 +174,105 @@ func handler(maxRecvMsgSize int,
 			if resp.GetCode() != 202 {
 				level.Error(logger).Log("msg", "push error", "err", err)
 			}
+			// Headers like Retry-After tell the client how to handle the error.
+			for _, h := range resp.GetHeaders() {
+				for _, v := range h.Values {
+					w.Header().Add(h.Key, v)
+				}
+			}
 			http.Error(w, string(resp.Body), int(resp.Code))
+			return
+		}
+		if resp != nil && len(resp.FailedSeries) > 0 {
+			writePartialAcceptanceResponse(w, resp)
 		}
 	})
 }
+
+// writePartialAcceptanceResponse writes the response listing the series which haven't been written.
+func writePartialAcceptanceResponse(w http.ResponseWriter, resp *mimirpb.WriteResponse) {
+	data, err := resp.Marshal()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// writeDebugReport writes the debug report as the response to the write request, with the
+// same status code the response would have if the debug report wasn't requested.
+func writeDebugReport(w http.ResponseWriter, report *DebugReport, err error) {
+	code, errMsg := responseStatus(err)
+	report.finish(errMsg)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// writeIngestionReport writes the ingestion report as the response to the write request, with the
+// same status code the response would have if the ingestion report wasn't requested.
+func writeIngestionReport(w http.ResponseWriter, report *IngestionReport, resp *mimirpb.WriteResponse, err error) {
+	code, errMsg := responseStatus(err)
+	report.finish(len(resp.GetFailedSeries()), errMsg)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// setIngestionReportHeader sets the ingestion report as a response header, leaving the response unchanged.
+// The error message of the report is truncated if the header would exceed maxIngestionReportHeaderSize.
+func setIngestionReportHeader(w http.ResponseWriter, report *IngestionReport, resp *mimirpb.WriteResponse, err error) {
+	_, errMsg := responseStatus(err)
+	report.finish(len(resp.GetFailedSeries()), errMsg)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+	if excess := len(data) - maxIngestionReportHeaderSize; excess > 0 {
+		report.truncateError(len(report.Error) - excess)
+		if data, err = json.Marshal(report); err != nil {
+			return
+		}
+	}
+	if len(data) > maxIngestionReportHeaderSize {
+		// The escaping of the error message made it longer than its truncated length.
+		report.truncateError(0)
+		if data, err = json.Marshal(report); err != nil {
+			return
+		}
+	}
+	w.Header().Set(IngestionReportHeader, string(data))
+}
+
+// responseStatus returns the status code and the error message of the response to a write request
+// which returned the input error.
+func responseStatus(err error) (code int, errMsg string) {
+	if err == nil {
+		return http.StatusOK, ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return statusClientClosedRequest, err.Error()
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return int(resp.Code), string(resp.Body)
+	}
+	return http.StatusInternalServerError, err.Error()
+}
@@ -9,10 +9,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -338,6 +340,254 @@ func TestHandler_contextCanceledRequest(t *testing.T) {
 	assert.Equal(t, 499, resp.Code)
 }
 
+func TestHandler_errorWithRetryAfter(t *testing.T) {
+	req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
+	resp := httptest.NewRecorder()
+	handler := Handler(100000, nil, false, func(_ context.Context, req *Request) (*mimirpb.WriteResponse, error) {
+		defer req.CleanUp()
+		return nil, httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+			Code:    http.StatusServiceUnavailable,
+			Body:    []byte("overloaded"),
+			Headers: []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"5"}}},
+		})
+	})
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "5", resp.Header().Get("Retry-After"))
+	assert.Contains(t, resp.Body.String(), "overloaded")
+}
+
+func TestHandler_PartialAcceptance(t *testing.T) {
+	failedSeries := []mimirpb.FailedSeries{{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}}}}
+
+	for name, allowed := range map[string]bool{"allowed": true, "not allowed": false} {
+		t.Run(name, func(t *testing.T) {
+			req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
+			if allowed {
+				req.Header.Set(PartialAcceptanceHeader, "true")
+			}
+			resp := httptest.NewRecorder()
+			handler := Handler(100000, nil, false, func(_ context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				defer pushReq.CleanUp()
+				req, err := pushReq.WriteRequest()
+				require.NoError(t, err)
+				require.Equal(t, allowed, req.PartialAcceptance)
+
+				if !req.PartialAcceptance {
+					return &mimirpb.WriteResponse{}, nil
+				}
+				return &mimirpb.WriteResponse{FailedSeries: failedSeries}, nil
+			})
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			if !allowed {
+				assert.Empty(t, resp.Body.Bytes())
+				return
+			}
+			assert.Equal(t, "application/x-protobuf", resp.Header().Get("Content-Type"))
+			var writeResp mimirpb.WriteResponse
+			require.NoError(t, writeResp.Unmarshal(resp.Body.Bytes()))
+			assert.Equal(t, failedSeries, writeResp.FailedSeries)
+		})
+	}
+}
+
+func TestHandler_DebugReport(t *testing.T) {
+	tests := map[string]struct {
+		debugHeader      bool
+		enableReport     bool
+		pushErr          error
+		expectedCode     int
+		expectedReport   bool
+		expectedErrorMsg string
+	}{
+		"should not reply with the report if not requested": {
+			enableReport: true,
+			expectedCode: http.StatusOK,
+		},
+		"should not reply with the report if requested but not enabled for the tenant": {
+			debugHeader:  true,
+			expectedCode: http.StatusOK,
+		},
+		"should reply with the report if requested and enabled for the tenant": {
+			debugHeader:    true,
+			enableReport:   true,
+			expectedCode:   http.StatusOK,
+			expectedReport: true,
+		},
+		"should reply with the report and the status code of the error if the push failed": {
+			debugHeader:      true,
+			enableReport:     true,
+			pushErr:          httpgrpc.Errorf(http.StatusTooManyRequests, "rate limited"),
+			expectedCode:     http.StatusTooManyRequests,
+			expectedReport:   true,
+			expectedErrorMsg: "rate limited",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
+			if testData.debugHeader {
+				req.Header.Set(DebugHeader, "true")
+			}
+
+			handler := Handler(100000, nil, false, func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				defer pushReq.CleanUp()
+
+				report := DebugReportFromContext(ctx)
+				if testData.enableReport {
+					report.Enable("user")
+				}
+				report.AddStep(DebugReportStep{Name: "test", Decision: DebugStepPassed, Series: 1})
+				report.AddIngester(DebugReportIngester{Addr: "ingester-1", Series: 1})
+
+				return &mimirpb.WriteResponse{}, testData.pushErr
+			})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, testData.expectedCode, resp.Code)
+
+			if !testData.expectedReport {
+				assert.Empty(t, resp.Body.String())
+				return
+			}
+
+			assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+			report := &DebugReport{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), report))
+			assert.Equal(t, "user", report.Tenant)
+			assert.Equal(t, []DebugReportStep{{Name: "test", Decision: DebugStepPassed, Series: 1}}, report.Steps)
+			assert.Equal(t, []DebugReportIngester{{Addr: "ingester-1", Series: 1}}, report.Ingesters)
+			assert.Equal(t, testData.expectedErrorMsg, report.Error)
+		})
+	}
+}
+
+func TestHandler_IngestionReport(t *testing.T) {
+	tests := map[string]struct {
+		mode             string
+		pushErr          error
+		expectedCode     int
+		expectedReport   bool
+		expectedErrorMsg string
+	}{
+		"should not reply with the report if not requested": {
+			expectedCode: http.StatusOK,
+		},
+		"should not reply with the report if the mode is unknown": {
+			mode:         "unknown",
+			expectedCode: http.StatusOK,
+		},
+		"should reply with the report in the response header": {
+			mode:           IngestionReportModeHeader,
+			expectedCode:   http.StatusOK,
+			expectedReport: true,
+		},
+		"should reply with the report in the response header and the error in the response body if the push failed": {
+			mode:             IngestionReportModeHeader,
+			pushErr:          httpgrpc.Errorf(http.StatusBadRequest, "invalid series"),
+			expectedCode:     http.StatusBadRequest,
+			expectedReport:   true,
+			expectedErrorMsg: "invalid series",
+		},
+		"should reply with the report in the response body": {
+			mode:           IngestionReportModeBody,
+			expectedCode:   http.StatusOK,
+			expectedReport: true,
+		},
+		"should reply with the report in the response body and the status code of the error if the push failed": {
+			mode:             IngestionReportModeBody,
+			pushErr:          httpgrpc.Errorf(http.StatusAccepted, "deduped"),
+			expectedCode:     http.StatusAccepted,
+			expectedReport:   true,
+			expectedErrorMsg: "deduped",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
+			if testData.mode != "" {
+				req.Header.Set(IngestionReportHeader, testData.mode)
+			}
+
+			handler := Handler(100000, nil, false, func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				defer pushReq.CleanUp()
+
+				report := IngestionReportFromContext(ctx)
+				report.AddReceived(3)
+				report.AddInvalid("label_name_too_long", 1)
+				report.AddAccepted(2)
+
+				return &mimirpb.WriteResponse{}, testData.pushErr
+			})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, testData.expectedCode, resp.Code)
+
+			var data []byte
+			switch {
+			case !testData.expectedReport:
+				assert.Empty(t, resp.Header().Get(IngestionReportHeader))
+				return
+			case testData.mode == IngestionReportModeHeader:
+				data = []byte(resp.Header().Get(IngestionReportHeader))
+				if testData.pushErr == nil {
+					assert.Empty(t, resp.Body.String())
+				} else {
+					assert.Contains(t, resp.Body.String(), testData.expectedErrorMsg)
+				}
+			default:
+				assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+				data = resp.Body.Bytes()
+			}
+
+			report := &IngestionReport{}
+			require.NoError(t, json.Unmarshal(data, report))
+			assert.Equal(t, 3, report.ReceivedSamples)
+			assert.Equal(t, 2, report.AcceptedSamples)
+			assert.Equal(t, 1, report.InvalidSamples)
+			assert.Equal(t, map[string]int{"label_name_too_long": 1}, report.InvalidSamplesByReason)
+			assert.Equal(t, testData.expectedErrorMsg, report.Error)
+		})
+	}
+}
+
+func TestHandler_IngestionReportHeaderShouldBeCapped(t *testing.T) {
+	longErrorMsg := strings.Repeat("invalid series \"x\" ", maxIngestionReportHeaderSize/10)
+
+	req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
+	req.Header.Set(IngestionReportHeader, IngestionReportModeHeader)
+
+	handler := Handler(100000, nil, false, func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+		defer pushReq.CleanUp()
+
+		IngestionReportFromContext(ctx).AddReceived(3)
+		return &mimirpb.WriteResponse{}, httpgrpc.Errorf(http.StatusBadRequest, longErrorMsg)
+	})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	header := resp.Header().Get(IngestionReportHeader)
+	assert.LessOrEqual(t, len(header), maxIngestionReportHeaderSize)
+
+	report := &IngestionReport{}
+	require.NoError(t, json.Unmarshal([]byte(header), report))
+	assert.Equal(t, 3, report.ReceivedSamples)
+	assert.NotEmpty(t, report.Error)
+	assert.True(t, strings.HasPrefix(longErrorMsg, report.Error))
+
+	// The response body still contains the whole error.
+	assert.Contains(t, resp.Body.String(), longErrorMsg)
+}
+
 func TestHandler_EnsureSkipLabelNameValidationBehaviour(t *testing.T) {
 	tests := []struct {
 		name                                      string
@@ -615,7 +865,7 @@ func (bufCloser) Close() error                 { return nil }
 func (n bufCloser) BytesBuffer() *bytes.Buffer { return n.Buffer }
 
 func TestNewDistributorMaxWriteMessageSizeErr(t *testing.T) {
-	err := distributorMaxWriteMessageSizeErr{actual: 100, limit: 50}
+	err := distributorMaxWriteMessageSizeErr{actual: 100, limit: 50, limitFlag: maxRecvMsgSizeFlag}
 	msg := `the incoming push request has been rejected because its message size of 100 bytes is larger than the allowed limit of 50 bytes (err-mimir-distributor-max-write-message-size). To adjust the related limit, configure -distributor.max-recv-msg-size, or contact your service administrator.`
 
 	assert.Equal(t, msg, err.Error())
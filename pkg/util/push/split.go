@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strings"
+
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/middleware"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// SplittingHandler is a http.Handler which accepts WriteRequests up to maxOversizedRecvMsgSize.
+// Requests larger than maxRecvMsgSize are split into multiple push requests, each one not larger than
+// maxRecvMsgSize, which are pushed sequentially. If any of them fails, an error reporting the failure
+// of each part is returned.
+func SplittingHandler(
+	maxRecvMsgSize, maxOversizedRecvMsgSize int,
+	sourceIPs *middleware.SourceIPExtractor,
+	allowSkipLabelNameValidation bool,
+	push Func,
+) http.Handler {
+	return handler(maxOversizedRecvMsgSize, sourceIPs, allowSkipLabelNameValidation, splittingPush(maxRecvMsgSize, push), remoteWriteParser(maxOversizedRecvMsgSizeFlag))
+}
+
+func splittingPush(maxPartSize int, push Func) Func {
+	return func(ctx context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			pushReq.CleanUp()
+			return nil, err
+		}
+
+		if req.Size() <= maxPartSize {
+			return push(ctx, pushReq)
+		}
+
+		parts := splitWriteRequest(req, maxPartSize)
+
+		// The series have been moved to the parts, each one releasing its own series once pushed. The buffer
+		// the series have been unmarshalled from is released once all the parts have been cleaned up.
+		req.Timeseries = req.Timeseries[:0]
+		req.Metadata = nil
+		remaining := atomic.NewInt64(int64(len(parts)))
+
+		var errs []error
+		var failedSeries []mimirpb.FailedSeries
+		for _, part := range parts {
+			part := part
+			partReq := NewParsedRequest(part)
+			partReq.AddCleanup(func() {
+				mimirpb.ReuseSlice(part.Timeseries)
+				if remaining.Dec() == 0 {
+					pushReq.CleanUp()
+				}
+			})
+
+			if ctx.Err() != nil {
+				// Don't push the remaining parts if the request has been canceled.
+				partReq.CleanUp()
+				errs = append(errs, ctx.Err())
+				continue
+			}
+
+			resp, err := push(ctx, partReq)
+			errs = append(errs, err)
+			if resp != nil {
+				failedSeries = append(failedSeries, resp.FailedSeries...)
+			}
+		}
+
+		if err := splitPushError(errs); err != nil {
+			return nil, err
+		}
+		return &mimirpb.WriteResponse{FailedSeries: failedSeries}, nil
+	}
+}
+
+// splitWriteRequest splits req into multiple requests whose marshalled size is not larger than maxSize,
+// unless a single series or metadata is larger than it.
+func splitWriteRequest(req *mimirpb.WriteRequest, maxSize int) []*mimirpb.WriteRequest {
+	var (
+		parts    []*mimirpb.WriteRequest
+		part     *mimirpb.WriteRequest
+		partSize int
+	)
+
+	// The size of the fields which are replicated in each part.
+	baseSize := (&mimirpb.WriteRequest{Source: req.Source, SkipLabelNameValidation: req.SkipLabelNameValidation, PartialAcceptance: req.PartialAcceptance}).Size()
+
+	add := func(entrySize int) {
+		// Each repeated entry is marshalled with a 1 byte field tag and its length.
+		entrySize += 1 + (bits.Len64(uint64(entrySize)|1)+6)/7
+
+		if part == nil || (partSize+entrySize > maxSize && len(part.Timeseries)+len(part.Metadata) > 0) {
+			part = &mimirpb.WriteRequest{
+				Timeseries:              mimirpb.PreallocTimeseriesSliceFromPool(),
+				Source:                  req.Source,
+				SkipLabelNameValidation: req.SkipLabelNameValidation,
+				PartialAcceptance:       req.PartialAcceptance,
+			}
+			parts = append(parts, part)
+			partSize = baseSize
+		}
+		partSize += entrySize
+	}
+
+	for _, ts := range req.Timeseries {
+		add(ts.Size())
+		part.Timeseries = append(part.Timeseries, ts)
+	}
+	for _, m := range req.Metadata {
+		add(m.Size())
+		part.Metadata = append(part.Metadata, m)
+	}
+
+	return parts
+}
+
+// splitPushError merges the errors returned pushing each part of a split request. The returned error has
+// the highest HTTP status code of the failed parts, so that the request is retried if any of them can be.
+func splitPushError(errs []error) error {
+	var (
+		failed  []string
+		code    int32
+		headers []*httpgrpc.Header
+	)
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		msg := err.Error()
+		partCode := int32(http.StatusInternalServerError)
+		var partHeaders []*httpgrpc.Header
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			msg = string(resp.Body)
+			partCode = resp.Code
+			partHeaders = resp.Headers
+		} else if err == context.Canceled {
+			partCode = statusClientClosedRequest
+		}
+
+		failed = append(failed, fmt.Sprintf("part %d: %s", i+1, msg))
+		if partCode > code {
+			code = partCode
+			headers = partHeaders
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+		Code:    code,
+		Body:    []byte(fmt.Sprintf("%d of %d parts of the split push request failed: %s", len(failed), len(errs), strings.Join(failed, "; "))),
+		Headers: headers,
+	})
+}
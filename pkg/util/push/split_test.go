@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package push
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestSplittingHandler(t *testing.T) {
+	const (
+		maxRecvMsgSize          = 500
+		maxOversizedRecvMsgSize = 10000
+	)
+
+	smallReq := createSplitTestWriteRequest(2, 1)
+	require.Less(t, smallReq.Size(), maxRecvMsgSize)
+
+	largeReq := createSplitTestWriteRequest(50, 5)
+	require.Greater(t, largeReq.Size(), maxRecvMsgSize)
+	require.Less(t, largeReq.Size(), maxOversizedRecvMsgSize)
+
+	tooLargeReq := createSplitTestWriteRequest(500, 0)
+	require.Greater(t, tooLargeReq.Size(), maxOversizedRecvMsgSize)
+
+	tests := map[string]struct {
+		req                *mimirpb.WriteRequest
+		pushErrs           map[int]error
+		expectedCode       int
+		expectedParts      int
+		expectedErrorMsg   string
+		expectedRetryAfter string
+	}{
+		"should push a request smaller than the max recv msg size as is": {
+			req:           smallReq,
+			expectedCode:  http.StatusOK,
+			expectedParts: 1,
+		},
+		"should split a request larger than the max recv msg size": {
+			req:          largeReq,
+			expectedCode: http.StatusOK,
+		},
+		"should reject a request larger than the max oversized recv msg size": {
+			req:              tooLargeReq,
+			expectedCode:     http.StatusBadRequest,
+			expectedParts:    0,
+			expectedErrorMsg: "configure -distributor.max-oversized-recv-msg-size",
+		},
+		"should return the error of each failed part": {
+			req: largeReq,
+			pushErrs: map[int]error{
+				1: httpgrpc.Errorf(http.StatusBadRequest, "bad part"),
+				2: httpgrpc.Errorf(http.StatusTooManyRequests, "too many requests"),
+			},
+			expectedCode:     http.StatusTooManyRequests,
+			expectedErrorMsg: "2 of %d parts of the split push request failed: part 2: bad part; part 3: too many requests",
+		},
+		"should return the headers of the part with the highest status code": {
+			req: largeReq,
+			pushErrs: map[int]error{
+				1: httpgrpc.Errorf(http.StatusBadRequest, "bad part"),
+				2: httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+					Code:    http.StatusServiceUnavailable,
+					Body:    []byte("overloaded"),
+					Headers: []*httpgrpc.Header{{Key: "Retry-After", Values: []string{"3"}}},
+				}),
+			},
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedErrorMsg:   "2 of %d parts of the split push request failed: part 2: bad part; part 3: overloaded",
+			expectedRetryAfter: "3",
+		},
+		"should return a server error if any part failed with a server error": {
+			req: largeReq,
+			pushErrs: map[int]error{
+				0: fmt.Errorf("unexpected"),
+				1: httpgrpc.Errorf(http.StatusBadRequest, "bad part"),
+			},
+			expectedCode:     http.StatusInternalServerError,
+			expectedErrorMsg: "2 of %d parts of the split push request failed: part 1: unexpected; part 2: bad part",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var (
+				mtx           sync.Mutex
+				pushedSeries  []string
+				pushedMeta    []string
+				pushedParts   int
+				cleanedParts  int
+				expectedParts = testData.expectedParts
+			)
+
+			pushFunc := func(_ context.Context, pushReq *Request) (*mimirpb.WriteResponse, error) {
+				defer func() {
+					pushReq.CleanUp()
+
+					mtx.Lock()
+					cleanedParts++
+					mtx.Unlock()
+				}()
+
+				req, err := pushReq.WriteRequest()
+				if err != nil {
+					return nil, err
+				}
+
+				mtx.Lock()
+				defer mtx.Unlock()
+
+				assert.LessOrEqual(t, req.Size(), maxRecvMsgSize)
+				assert.Equal(t, mimirpb.API, req.Source)
+				for _, ts := range req.Timeseries {
+					pushedSeries = append(pushedSeries, ts.Labels[0].Value)
+				}
+				for _, m := range req.Metadata {
+					pushedMeta = append(pushedMeta, m.MetricFamilyName)
+				}
+
+				partIdx := pushedParts
+				pushedParts++
+				return &mimirpb.WriteResponse{}, testData.pushErrs[partIdx]
+			}
+
+			body, err := testData.req.Marshal()
+			require.NoError(t, err)
+
+			resp := httptest.NewRecorder()
+			SplittingHandler(maxRecvMsgSize, maxOversizedRecvMsgSize, nil, false, pushFunc).ServeHTTP(resp, createRequest(t, body))
+
+			if expectedParts == 0 && testData.expectedCode == http.StatusOK || testData.pushErrs != nil {
+				expectedParts = len(splitWriteRequest(testData.req, maxRecvMsgSize))
+				assert.Greater(t, expectedParts, 1)
+			}
+
+			assert.Equal(t, testData.expectedCode, resp.Code)
+			if testData.expectedErrorMsg != "" {
+				expectedMsg := testData.expectedErrorMsg
+				if testData.pushErrs != nil {
+					expectedMsg = fmt.Sprintf(expectedMsg, expectedParts)
+				}
+				assert.Contains(t, resp.Body.String(), expectedMsg)
+			}
+			assert.Equal(t, testData.expectedRetryAfter, resp.Header().Get("Retry-After"))
+
+			assert.Equal(t, expectedParts, pushedParts)
+			if expectedParts == 0 {
+				return
+			}
+
+			// All the series and metadata should have been pushed exactly once, in order.
+			assert.Equal(t, cleanedParts, pushedParts)
+			require.Len(t, pushedSeries, len(testData.req.Timeseries))
+			for i, ts := range testData.req.Timeseries {
+				assert.Equal(t, ts.Labels[0].Value, pushedSeries[i])
+			}
+			require.Len(t, pushedMeta, len(testData.req.Metadata))
+			for i, m := range testData.req.Metadata {
+				assert.Equal(t, m.MetricFamilyName, pushedMeta[i])
+			}
+		})
+	}
+}
+
+func TestSplitWriteRequest(t *testing.T) {
+	req := createSplitTestWriteRequest(100, 10)
+	req.SkipLabelNameValidation = true
+
+	for _, maxSize := range []int{1, 100, 500, 1000, req.Size()} {
+		t.Run(fmt.Sprintf("max size: %d", maxSize), func(t *testing.T) {
+			parts := splitWriteRequest(req, maxSize)
+
+			var series, metadata int
+			for _, part := range parts {
+				assert.Equal(t, req.Source, part.Source)
+				assert.Equal(t, req.SkipLabelNameValidation, part.SkipLabelNameValidation)
+
+				// A part can be larger than the max size only if it contains a single entry.
+				if entries := len(part.Timeseries) + len(part.Metadata); entries > 1 {
+					assert.LessOrEqual(t, part.Size(), maxSize)
+				} else {
+					assert.Equal(t, 1, entries)
+				}
+
+				for _, ts := range part.Timeseries {
+					assert.Same(t, req.Timeseries[series].TimeSeries, ts.TimeSeries)
+					series++
+				}
+				for _, m := range part.Metadata {
+					assert.Same(t, req.Metadata[metadata], m)
+					metadata++
+				}
+			}
+
+			assert.Equal(t, len(req.Timeseries), series)
+			assert.Equal(t, len(req.Metadata), metadata)
+			if maxSize >= req.Size() {
+				assert.Len(t, parts, 1)
+			}
+		})
+	}
+}
+
+func createSplitTestWriteRequest(numSeries, numMetadata int) *mimirpb.WriteRequest {
+	req := &mimirpb.WriteRequest{Source: mimirpb.API}
+	for i := 0; i < numSeries; i++ {
+		req.Timeseries = append(req.Timeseries, mimirpb.PreallocTimeseries{
+			TimeSeries: &mimirpb.TimeSeries{
+				Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: fmt.Sprintf("series_%d", i)}},
+				Samples: []mimirpb.Sample{{Value: float64(i), TimestampMs: int64(i)}},
+			},
+		})
+	}
+	for i := 0; i < numMetadata; i++ {
+		req.Metadata = append(req.Metadata, &mimirpb.MetricMetadata{
+			Type:             mimirpb.COUNTER,
+			MetricFamilyName: fmt.Sprintf("series_%d", i),
+			Help:             "help",
+		})
+	}
+	return req
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package querysource propagates the source of a read-path request (a user query, a recording rule evaluation
+// or an alerting rule evaluation) from the component receiving it down to ingesters and store-gateways, so that
+// the cost of rule evaluations can be told apart from the cost of interactive queries.
+package querysource
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/weaveworks/common/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// API is the source of queries received through the HTTP API.
+	API = "api"
+
+	// Ruler is the source of queries run to evaluate recording rules.
+	Ruler = "ruler"
+
+	// Alerting is the source of queries run to evaluate alerting rules.
+	Alerting = "alerting"
+
+	// HTTPHeader is the HTTP header used to propagate the source of a query.
+	HTTPHeader = "X-Mimir-Query-Source"
+
+	// metadataKey is the gRPC metadata key used to propagate the source of a query.
+	metadataKey = "x-mimir-query-source"
+)
+
+type contextKey int
+
+const sourceContextKey contextKey = 0
+
+// IsValid returns whether source is a known query source.
+func IsValid(source string) bool {
+	switch source {
+	case API, Ruler, Alerting:
+		return true
+	default:
+		return false
+	}
+}
+
+// ContextWithSource returns a new context with the query source attached.
+// Unknown sources are replaced with API.
+func ContextWithSource(ctx context.Context, source string) context.Context {
+	if !IsValid(source) {
+		source = API
+	}
+	return context.WithValue(ctx, sourceContextKey, source)
+}
+
+// FromContext returns the query source attached to the context, or API if none is attached.
+func FromContext(ctx context.Context) string {
+	if source, ok := ctx.Value(sourceContextKey).(string); ok {
+		return source
+	}
+	return API
+}
+
+// FromHTTPRequest returns the query source set in the request headers, or API if none or an unknown one is set.
+func FromHTTPRequest(r *http.Request) string {
+	if source := r.Header.Get(HTTPHeader); IsValid(source) {
+		return source
+	}
+	return API
+}
+
+// NewHTTPMiddleware returns a middleware attaching the query source set in the request headers to the request context.
+func NewHTTPMiddleware() middleware.Interface {
+	return middleware.Func(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithSource(r.Context(), FromHTTPRequest(r))))
+		})
+	})
+}
+
+// injectIntoOutgoingContext adds the query source attached to the context to the outgoing gRPC metadata.
+func injectIntoOutgoingContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, metadataKey, FromContext(ctx))
+}
+
+// extractFromIncomingContext attaches the query source set in the incoming gRPC metadata to the context.
+func extractFromIncomingContext(ctx context.Context) context.Context {
+	source := API
+	if values := metadata.ValueFromIncomingContext(ctx, metadataKey); len(values) == 1 {
+		source = values[0]
+	}
+	return ContextWithSource(ctx, source)
+}
+
+// ClientUnaryInterceptor propagates the query source attached to the context to the gRPC server.
+func ClientUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectIntoOutgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+// ClientStreamInterceptor propagates the query source attached to the context to the gRPC server.
+func ClientStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectIntoOutgoingContext(ctx), desc, cc, method, opts...)
+}
+
+// ServerUnaryInterceptor attaches the query source propagated by the gRPC client to the request context.
+func ServerUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(extractFromIncomingContext(ctx), req)
+}
+
+// ServerStreamInterceptor attaches the query source propagated by the gRPC client to the stream context.
+func ServerStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, wrappedServerStream{
+		ctx:          extractFromIncomingContext(ss.Context()),
+		ServerStream: ss,
+	})
+}
+
+type wrappedServerStream struct {
+	ctx context.Context
+	grpc.ServerStream
+}
+
+func (ss wrappedServerStream) Context() context.Context {
+	return ss.ctx
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querysource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestContextWithSource(t *testing.T) {
+	tests := map[string]struct {
+		source   string
+		expected string
+	}{
+		"api":      {source: API, expected: API},
+		"ruler":    {source: Ruler, expected: Ruler},
+		"alerting": {source: Alerting, expected: Alerting},
+		"empty":    {source: "", expected: API},
+		"unknown":  {source: "unknown", expected: API},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testData.expected, FromContext(ContextWithSource(context.Background(), testData.source)))
+		})
+	}
+
+	t.Run("no source in context", func(t *testing.T) {
+		assert.Equal(t, API, FromContext(context.Background()))
+	})
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		header   string
+		expected string
+	}{
+		"no header":      {header: "", expected: API},
+		"ruler header":   {header: Ruler, expected: Ruler},
+		"alerting":       {header: Alerting, expected: Alerting},
+		"unknown header": {header: "unknown", expected: API},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual string
+			handler := NewHTTPMiddleware().Wrap(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				actual = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if testData.header != "" {
+				req.Header.Set(HTTPHeader, testData.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	for _, source := range []string{API, Ruler, Alerting} {
+		t.Run(source, func(t *testing.T) {
+			ctx := ContextWithSource(context.Background(), source)
+
+			// Propagate the source from the client.
+			var outgoing metadata.MD
+			err := ClientUnaryInterceptor(ctx, "method", nil, nil, nil, func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				outgoing, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			})
+			require.NoError(t, err)
+
+			// Extract the source on the server.
+			var actual string
+			_, err = ServerUnaryInterceptor(metadata.NewIncomingContext(context.Background(), outgoing), nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				actual = FromContext(ctx)
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, source, actual)
+		})
+	}
+
+	t.Run("no source propagated by the client", func(t *testing.T) {
+		var actual string
+		_, err := ServerUnaryInterceptor(context.Background(), nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			actual = FromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, API, actual)
+	})
+}
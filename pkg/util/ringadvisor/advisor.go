@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ringadvisor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+)
+
+// DefaultTolerance is the default relative deviation from the expected token ownership an instance can have
+// before a token adjustment is recommended.
+const DefaultTolerance = 0.1
+
+// Config describes the ring to analyze.
+type Config struct {
+	ReplicationFactor    int
+	ZoneAwarenessEnabled bool
+	HeartbeatTimeout     time.Duration
+
+	// TokenOwnership is whether the load is distributed across the instances by their tokens. When false, like for
+	// the distributors ring which is only used to count the instances, the token ownership is not analyzed.
+	TokenOwnership bool
+}
+
+// ConfigFromRingConfig returns the Config of the analysis of a ring with the input configuration.
+func ConfigFromRingConfig(rc ring.Config, tokenOwnership bool) Config {
+	return Config{
+		ReplicationFactor:    rc.ReplicationFactor,
+		ZoneAwarenessEnabled: rc.ZoneAwarenessEnabled,
+		HeartbeatTimeout:     rc.HeartbeatTimeout,
+		TokenOwnership:       tokenOwnership,
+	}
+}
+
+// Report is the result of the analysis of the instances and tokens of a ring. It's only a recommendation,
+// nothing is changed in the ring.
+type Report struct {
+	ReplicationFactor    int     `json:"replication_factor"`
+	ZoneAwarenessEnabled bool    `json:"zone_awareness_enabled"`
+	Tolerance            float64 `json:"tolerance"`
+
+	Zones           []ZoneReport     `json:"zones"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// ZoneReport is the analysis of the instances of a zone. When the zone awareness is disabled, all the
+// instances of the ring are analyzed as a single zone.
+type ZoneReport struct {
+	Zone             string `json:"zone"`
+	Instances        int    `json:"instances"`
+	HealthyInstances int    `json:"healthy_instances"`
+	Tokens           int    `json:"tokens"`
+
+	// MinOwnership and MaxOwnership are the min and max fraction of the token space of the zone owned by
+	// a single instance.
+	MinOwnership float64 `json:"min_ownership"`
+	MaxOwnership float64 `json:"max_ownership"`
+
+	InstancesReports []InstanceReport `json:"instances_reports"`
+}
+
+// InstanceReport is the analysis of a single instance.
+type InstanceReport struct {
+	ID      string `json:"id"`
+	State   string `json:"state"`
+	Healthy bool   `json:"healthy"`
+	Tokens  int    `json:"tokens"`
+
+	// Ownership is the fraction of the token space of the zone owned by the instance, and ExpectedOwnership
+	// the fraction it would own if the tokens were perfectly balanced across the instances of the zone.
+	Ownership         float64 `json:"ownership"`
+	ExpectedOwnership float64 `json:"expected_ownership"`
+
+	// SuggestedTokens is the number of tokens that would bring the ownership of the instance close to the
+	// expected one. It's 0 when no adjustment is recommended.
+	SuggestedTokens int `json:"suggested_tokens,omitempty"`
+}
+
+// RecommendationKind is the kind of change recommended by a Recommendation.
+type RecommendationKind string
+
+const (
+	RecommendationAddZones         RecommendationKind = "add-zones"
+	RecommendationScaleZone        RecommendationKind = "scale-zone"
+	RecommendationAdjustTokens     RecommendationKind = "adjust-tokens"
+	RecommendationFixNoTokens      RecommendationKind = "instance-without-tokens"
+	RecommendationFixUnhealthy     RecommendationKind = "unhealthy-instance"
+	RecommendationNoInstancesFound RecommendationKind = "no-instances"
+)
+
+// Recommendation is a change to the ring which would improve its balance.
+type Recommendation struct {
+	Kind     RecommendationKind `json:"kind"`
+	Zone     string             `json:"zone,omitempty"`
+	Instance string             `json:"instance,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// Analyze analyzes the instances and tokens of the ring and returns the recommended changes to balance it.
+// Instances whose ownership deviates from the expected one by more than tolerance, relative to the expected
+// ownership, get a token adjustment recommended.
+func Analyze(cfg Config, desc *ring.Desc, tolerance float64, now time.Time) Report {
+	report := Report{
+		ReplicationFactor:    cfg.ReplicationFactor,
+		ZoneAwarenessEnabled: cfg.ZoneAwarenessEnabled,
+		Tolerance:            tolerance,
+		Zones:                []ZoneReport{},
+		Recommendations:      []Recommendation{},
+	}
+
+	if desc == nil || len(desc.Ingesters) == 0 {
+		report.Recommendations = append(report.Recommendations, Recommendation{
+			Kind:    RecommendationNoInstancesFound,
+			Message: "no instances found in the ring",
+		})
+		return report
+	}
+
+	// Group the instances by zone, or in a single group if the zone awareness is disabled.
+	instancesByZone := map[string][]string{}
+	for id, inst := range desc.Ingesters {
+		zone := ""
+		if cfg.ZoneAwarenessEnabled {
+			zone = inst.Zone
+		}
+		instancesByZone[zone] = append(instancesByZone[zone], id)
+	}
+
+	zones := make([]string, 0, len(instancesByZone))
+	for zone := range instancesByZone {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		ids := instancesByZone[zone]
+		sort.Strings(ids)
+		report.Zones = append(report.Zones, analyzeZone(cfg, desc, zone, ids, now))
+	}
+
+	report.Recommendations = append(report.Recommendations, zonesRecommendations(cfg, report.Zones)...)
+	for _, z := range report.Zones {
+		report.Recommendations = append(report.Recommendations, instancesRecommendations(cfg, z, tolerance)...)
+	}
+
+	return report
+}
+
+func analyzeZone(cfg Config, desc *ring.Desc, zone string, ids []string, now time.Time) ZoneReport {
+	z := ZoneReport{
+		Zone:             zone,
+		Instances:        len(ids),
+		InstancesReports: make([]InstanceReport, 0, len(ids)),
+	}
+
+	// Each token owns the range of the token space between the previous token of the zone (excluded)
+	// and itself (included), wrapping around the ring.
+	type tokenOwner struct {
+		token    uint32
+		instance string
+	}
+	var tokens []tokenOwner
+	for _, id := range ids {
+		for _, t := range desc.Ingesters[id].Tokens {
+			tokens = append(tokens, tokenOwner{token: t, instance: id})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].token < tokens[j].token })
+	z.Tokens = len(tokens)
+
+	owned := make(map[string]uint64, len(ids))
+	for i, t := range tokens {
+		prev := tokens[(i+len(tokens)-1)%len(tokens)].token
+		// The uint32 subtraction wraps around the ring. A single token owns the whole token space.
+		size := uint64(t.token - prev)
+		if len(tokens) == 1 {
+			size = math.MaxUint32 + 1
+		}
+		owned[t.instance] += size
+	}
+
+	expected := 1 / float64(len(ids))
+	for i, id := range ids {
+		inst := desc.Ingesters[id]
+		healthy := inst.IsHeartbeatHealthy(cfg.HeartbeatTimeout, now)
+		if healthy {
+			z.HealthyInstances++
+		}
+
+		ownership := float64(owned[id]) / (math.MaxUint32 + 1)
+		z.InstancesReports = append(z.InstancesReports, InstanceReport{
+			ID:                id,
+			State:             inst.State.String(),
+			Healthy:           healthy,
+			Tokens:            len(inst.Tokens),
+			Ownership:         ownership,
+			ExpectedOwnership: expected,
+		})
+
+		if i == 0 || ownership < z.MinOwnership {
+			z.MinOwnership = ownership
+		}
+		if i == 0 || ownership > z.MaxOwnership {
+			z.MaxOwnership = ownership
+		}
+	}
+
+	return z
+}
+
+func zonesRecommendations(cfg Config, zones []ZoneReport) []Recommendation {
+	if !cfg.ZoneAwarenessEnabled {
+		return nil
+	}
+
+	var recs []Recommendation
+	if len(zones) < cfg.ReplicationFactor {
+		recs = append(recs, Recommendation{
+			Kind:    RecommendationAddZones,
+			Message: fmt.Sprintf("the ring has %d zones, fewer than the replication factor %d: add %d zones", len(zones), cfg.ReplicationFactor, cfg.ReplicationFactor-len(zones)),
+		})
+	}
+
+	// Each zone holds a full replica of the data, so the zones with fewer instances have more load per instance.
+	maxInstances := 0
+	for _, z := range zones {
+		if z.Instances > maxInstances {
+			maxInstances = z.Instances
+		}
+	}
+	for _, z := range zones {
+		if z.Instances < maxInstances {
+			recs = append(recs, Recommendation{
+				Kind:    RecommendationScaleZone,
+				Zone:    z.Zone,
+				Message: fmt.Sprintf("scale up zone %q from %d to %d instances to match the largest zone", z.Zone, z.Instances, maxInstances),
+			})
+		}
+	}
+
+	return recs
+}
+
+// instancesRecommendations returns the recommendations for the instances of the zone, and sets their suggested tokens.
+func instancesRecommendations(cfg Config, z ZoneReport, tolerance float64) []Recommendation {
+	var recs []Recommendation
+	for i := range z.InstancesReports {
+		inst := &z.InstancesReports[i]
+
+		if !inst.Healthy {
+			recs = append(recs, Recommendation{
+				Kind:     RecommendationFixUnhealthy,
+				Zone:     z.Zone,
+				Instance: inst.ID,
+				Message:  fmt.Sprintf("instance %q is unhealthy: restart it, or forget it if it's been permanently removed", inst.ID),
+			})
+		}
+
+		if !cfg.TokenOwnership {
+			continue
+		}
+
+		if inst.Tokens == 0 {
+			recs = append(recs, Recommendation{
+				Kind:     RecommendationFixNoTokens,
+				Zone:     z.Zone,
+				Instance: inst.ID,
+				Message:  fmt.Sprintf("instance %q has no tokens and doesn't own any data", inst.ID),
+			})
+			continue
+		}
+
+		if math.Abs(inst.Ownership-inst.ExpectedOwnership) <= tolerance*inst.ExpectedOwnership {
+			continue
+		}
+
+		// The ownership of an instance is roughly proportional to its number of tokens.
+		inst.SuggestedTokens = int(math.Max(1, math.Round(float64(inst.Tokens)*inst.ExpectedOwnership/inst.Ownership)))
+		action := fmt.Sprintf("change its tokens from %d to %d", inst.Tokens, inst.SuggestedTokens)
+		if inst.SuggestedTokens == inst.Tokens {
+			// Changing the number of tokens doesn't help, the tokens are just unevenly spread.
+			action = "regenerate its tokens"
+		}
+		recs = append(recs, Recommendation{
+			Kind:     RecommendationAdjustTokens,
+			Zone:     z.Zone,
+			Instance: inst.ID,
+			Message: fmt.Sprintf("instance %q owns %.2f%% of the token space instead of %.2f%%: %s",
+				inst.ID, inst.Ownership*100, inst.ExpectedOwnership*100, action),
+		})
+	}
+	return recs
+}
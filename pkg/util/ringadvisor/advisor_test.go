@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ringadvisor
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	const quarter = (math.MaxUint32 + 1) / 4
+	now := time.Now()
+
+	t.Run("balanced zones", func(t *testing.T) {
+		desc := ring.NewDesc()
+		desc.AddIngester("a-1", "", "zone-a", []uint32{0, 2 * quarter}, ring.ACTIVE, now)
+		desc.AddIngester("a-2", "", "zone-a", []uint32{quarter, 3 * quarter}, ring.ACTIVE, now)
+		desc.AddIngester("b-1", "", "zone-b", []uint32{quarter}, ring.ACTIVE, now)
+		desc.AddIngester("b-2", "", "zone-b", []uint32{3 * quarter}, ring.ACTIVE, now)
+
+		report := Analyze(Config{ReplicationFactor: 2, ZoneAwarenessEnabled: true, HeartbeatTimeout: time.Minute, TokenOwnership: true}, desc, DefaultTolerance, now)
+		assert.Empty(t, report.Recommendations)
+		require.Len(t, report.Zones, 2)
+		for _, z := range report.Zones {
+			assert.Equal(t, 2, z.Instances)
+			assert.Equal(t, 2, z.HealthyInstances)
+			assert.Equal(t, 0.5, z.MinOwnership)
+			assert.Equal(t, 0.5, z.MaxOwnership)
+		}
+	})
+
+	t.Run("unbalanced zones and tokens", func(t *testing.T) {
+		desc := ring.NewDesc()
+		desc.AddIngester("a-1", "", "zone-a", []uint32{0, quarter, 2 * quarter}, ring.ACTIVE, now)
+		desc.AddIngester("a-2", "", "zone-a", []uint32{3 * quarter}, ring.ACTIVE, now)
+		desc.AddIngester("a-3", "", "zone-a", nil, ring.PENDING, now)
+		desc.AddIngester("b-1", "", "zone-b", []uint32{quarter}, ring.ACTIVE, now)
+		unhealthy := desc.AddIngester("b-2", "", "zone-b", []uint32{3 * quarter}, ring.ACTIVE, now)
+		unhealthy.Timestamp = now.Add(-time.Hour).Unix()
+		desc.Ingesters["b-2"] = unhealthy
+
+		report := Analyze(Config{ReplicationFactor: 3, ZoneAwarenessEnabled: true, HeartbeatTimeout: time.Minute, TokenOwnership: true}, desc, DefaultTolerance, now)
+		require.Len(t, report.Zones, 2)
+
+		zoneA := report.Zones[0]
+		assert.Equal(t, "zone-a", zoneA.Zone)
+		assert.Equal(t, 4, zoneA.Tokens)
+		assert.Equal(t, 0.0, zoneA.MinOwnership)
+		assert.Equal(t, 0.75, zoneA.MaxOwnership)
+		assert.Equal(t, 1, zoneA.InstancesReports[0].SuggestedTokens)
+		assert.Equal(t, 1, zoneA.InstancesReports[1].SuggestedTokens)
+		assert.Equal(t, 0, zoneA.InstancesReports[2].SuggestedTokens)
+
+		zoneB := report.Zones[1]
+		assert.Equal(t, 1, zoneB.HealthyInstances)
+
+		kinds := map[RecommendationKind][]string{}
+		for _, r := range report.Recommendations {
+			kinds[r.Kind] = append(kinds[r.Kind], r.Zone+"/"+r.Instance)
+		}
+		assert.Equal(t, map[RecommendationKind][]string{
+			RecommendationAddZones:     {"/"},
+			RecommendationScaleZone:    {"zone-b/"},
+			RecommendationAdjustTokens: {"zone-a/a-1", "zone-a/a-2"},
+			RecommendationFixNoTokens:  {"zone-a/a-3"},
+			RecommendationFixUnhealthy: {"zone-b/b-2"},
+		}, kinds)
+	})
+
+	t.Run("zone awareness disabled", func(t *testing.T) {
+		desc := ring.NewDesc()
+		desc.AddIngester("a-1", "", "zone-a", []uint32{0}, ring.ACTIVE, now)
+		desc.AddIngester("b-1", "", "zone-b", []uint32{quarter}, ring.ACTIVE, now)
+
+		report := Analyze(Config{ReplicationFactor: 1, HeartbeatTimeout: time.Minute, TokenOwnership: true}, desc, DefaultTolerance, now)
+		require.Len(t, report.Zones, 1)
+		assert.Equal(t, 2, report.Zones[0].Instances)
+		assert.Equal(t, 0.25, report.Zones[0].MinOwnership)
+		assert.Equal(t, 0.75, report.Zones[0].MaxOwnership)
+		assert.Len(t, report.Recommendations, 2)
+
+		// The token ownership is not analyzed when the ring is only used to count the instances.
+		report = Analyze(Config{ReplicationFactor: 1, HeartbeatTimeout: time.Minute}, desc, DefaultTolerance, now)
+		assert.Empty(t, report.Recommendations)
+
+		// The deviation is within a large enough tolerance.
+		report = Analyze(Config{ReplicationFactor: 1, HeartbeatTimeout: time.Minute, TokenOwnership: true}, desc, 0.5, now)
+		assert.Empty(t, report.Recommendations)
+	})
+
+	t.Run("empty ring", func(t *testing.T) {
+		report := Analyze(Config{ReplicationFactor: 3}, ring.NewDesc(), DefaultTolerance, now)
+		assert.Empty(t, report.Zones)
+		require.Len(t, report.Recommendations, 1)
+		assert.Equal(t, RecommendationNoInstancesFound, report.Recommendations[0].Kind)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	const key = "ring"
+
+	store, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	desc := ring.NewDesc()
+	desc.AddIngester("a-1", "", "zone-a", []uint32{0}, ring.ACTIVE, time.Now())
+	desc.AddIngester("a-2", "", "zone-a", []uint32{(math.MaxUint32 + 1) / 4}, ring.ACTIVE, time.Now())
+	require.NoError(t, store.CAS(context.Background(), key, func(interface{}) (interface{}, bool, error) {
+		return desc, true, nil
+	}))
+
+	h := NewHandler(Config{ReplicationFactor: 1, HeartbeatTimeout: time.Minute, TokenOwnership: true}, store, key)
+
+	for name, tc := range map[string]struct {
+		url                     string
+		expectedStatus          int
+		expectedRecommendations int
+	}{
+		"default tolerance": {url: "/", expectedStatus: http.StatusOK, expectedRecommendations: 2},
+		"custom tolerance":  {url: "/?tolerance=0.5", expectedStatus: http.StatusOK, expectedRecommendations: 0},
+		"invalid tolerance": {url: "/?tolerance=-1", expectedStatus: http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			require.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			require.Len(t, report.Zones, 1)
+			assert.Len(t, report.Zones[0].InstancesReports, 2)
+			assert.Len(t, report.Recommendations, tc.expectedRecommendations)
+		})
+	}
+}
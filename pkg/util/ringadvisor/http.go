@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ringadvisor
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/ring"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const toleranceParam = "tolerance"
+
+type handler struct {
+	cfg    Config
+	client kv.Client
+	key    string
+}
+
+// NewHandler returns an http.Handler serving the JSON encoded Report of the ring stored in the KV store
+// at the input key. The tolerance of the analysis can be set with the "tolerance" query parameter.
+func NewHandler(cfg Config, client kv.Client, key string) http.Handler {
+	return &handler{cfg: cfg, client: client, key: key}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	tolerance := DefaultTolerance
+	if v := req.FormValue(toleranceParam); v != "" {
+		var err error
+		tolerance, err = strconv.ParseFloat(v, 64)
+		if err != nil || tolerance < 0 {
+			http.Error(w, fmt.Sprintf("invalid %s parameter %q: must be a non-negative number", toleranceParam, v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	val, err := h.client.Get(req.Context(), h.key)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read the ring: %v", err), http.StatusInternalServerError)
+		return
+	}
+	desc, _ := val.(*ring.Desc)
+
+	util.WriteJSONResponse(w, Analyze(h.cfg, desc, tolerance, time.Now()))
+}
@@ -6,6 +6,7 @@
 package util
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"net/http"
@@ -98,3 +99,17 @@ func NewDisableableTicker(interval time.Duration) (func(), <-chan time.Time) {
 	tick := time.NewTicker(interval)
 	return func() { tick.Stop() }, tick.C
 }
+
+// RemainingTimeout returns the time left until the deadline of ctx, or 0 if ctx has no deadline.
+// If the deadline has already expired, the smallest positive duration is returned, so that 0
+// always means no timeout.
+func RemainingTimeout(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	if timeout := time.Until(deadline); timeout > 0 {
+		return timeout
+	}
+	return time.Nanosecond
+}
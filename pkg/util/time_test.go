@@ -6,6 +6,7 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"testing"
@@ -174,3 +175,17 @@ func TestNewDisableableTicker_Disabled(t *testing.T) {
 		break
 	}
 }
+
+func TestRemainingTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), RemainingTimeout(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	timeout := RemainingTimeout(ctx)
+	assert.LessOrEqual(t, timeout, time.Hour)
+	assert.Greater(t, timeout, 59*time.Minute)
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Minute))
+	defer cancel()
+	assert.Equal(t, time.Nanosecond, RemainingTimeout(ctx))
+}
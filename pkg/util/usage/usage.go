@@ -161,6 +161,7 @@ func parseStructure(structure interface{}, fields map[uintptr]reflect.StructFiel
 // then gets confused.
 var ignoredStructTypes = []reflect.Type{
 	reflect.TypeOf(flagext.Secret{}),
+	reflect.TypeOf(flagext.URLValue{}),
 	reflect.TypeOf(activeseries.CustomTrackersConfig{}),
 }
 
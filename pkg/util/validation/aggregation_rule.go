@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	AggregationSum = "sum"
+	AggregationAvg = "avg"
+	// AggregationSumCounters sums the increases of the aggregated counters, taking their resets into account.
+	AggregationSumCounters = "sum_counters"
+)
+
+// AggregationRule aggregates away some labels of the series received by the distributor, before they're
+// written to the ingesters.
+type AggregationRule struct {
+	Match       string   `yaml:"match" json:"match"`
+	DropLabels  []string `yaml:"drop_labels" json:"drop_labels"`
+	Aggregation string   `yaml:"aggregation" json:"aggregation"`
+}
+
+// Matchers returns the label matchers of the series selector of the rule.
+func (r AggregationRule) Matchers() ([]*labels.Matcher, error) {
+	return parser.ParseMetricSelector(r.Match)
+}
+
+func (r AggregationRule) validate() error {
+	if _, err := r.Matchers(); err != nil {
+		return fmt.Errorf("invalid aggregation rule selector %q: %w", r.Match, err)
+	}
+	if len(r.DropLabels) == 0 {
+		return fmt.Errorf("the aggregation rule %q doesn't drop any label", r.Match)
+	}
+	for _, name := range r.DropLabels {
+		if name == model.MetricNameLabel {
+			return errors.New("the aggregation rules can't drop the metric name")
+		}
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid aggregation rule label %q", name)
+		}
+	}
+	switch r.Aggregation {
+	case AggregationSum, AggregationAvg, AggregationSumCounters:
+	default:
+		return fmt.Errorf("invalid aggregation %q of the aggregation rule %q", r.Aggregation, r.Match)
+	}
+	return nil
+}
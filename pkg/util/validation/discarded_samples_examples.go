@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// DiscardedSamplesExamplesLimits provides the per-tenant number of examples of discarded series to keep.
+type DiscardedSamplesExamplesLimits interface {
+	DiscardedSamplesExamplesPerReason(userID string) int
+}
+
+// DiscardedSampleExample is an example of a series whose samples have been discarded.
+type DiscardedSampleExample struct {
+	Reason string `json:"reason"`
+	Labels string `json:"labels"`
+	// TimestampMs is the timestamp of the discarded sample, or 0 if the series has been discarded
+	// regardless of its samples (e.g. because of invalid labels).
+	TimestampMs int64     `json:"timestamp_ms,omitempty"`
+	DiscardedAt time.Time `json:"discarded_at"`
+}
+
+// DiscardedSamplesExamplesResponse is the response of the discarded samples examples endpoints.
+type DiscardedSamplesExamplesResponse struct {
+	Examples []DiscardedSampleExample `json:"examples"`
+}
+
+// DiscardedSamplesExamples keeps, for each tenant and discard reason, the most recent examples of series
+// whose samples have been discarded, so that tenants can find out where their samples went without
+// access to the logs of the operator.
+type DiscardedSamplesExamples struct {
+	limits DiscardedSamplesExamplesLimits
+	now    func() time.Time
+
+	mtx sync.Mutex
+	// Examples by tenant and reason.
+	tenants map[string]map[string]*discardedSamplesExamplesRing
+}
+
+// discardedSamplesExamplesRing is a ring buffer of examples.
+type discardedSamplesExamplesRing struct {
+	examples []DiscardedSampleExample
+	// next is the index of the example to overwrite once the ring is full.
+	next int
+}
+
+func NewDiscardedSamplesExamples(limits DiscardedSamplesExamplesLimits) *DiscardedSamplesExamples {
+	return &DiscardedSamplesExamples{
+		limits:  limits,
+		now:     time.Now,
+		tenants: map[string]map[string]*discardedSamplesExamplesRing{},
+	}
+}
+
+// Record keeps the input series as an example of series discarded for the input reason.
+// The timestamp of the discarded sample is 0 if unknown. A nil DiscardedSamplesExamples doesn't record anything.
+func (e *DiscardedSamplesExamples) Record(userID, reason string, series []mimirpb.LabelAdapter, timestampMs int64) {
+	if e == nil {
+		return
+	}
+
+	maxExamples := e.limits.DiscardedSamplesExamplesPerReason(userID)
+	if maxExamples <= 0 {
+		return
+	}
+
+	// Formatting the labels copies them, so that we don't retain the (unsafe) labels of the write request.
+	example := DiscardedSampleExample{
+		Reason:      reason,
+		Labels:      mimirpb.FromLabelAdaptersToLabels(series).String(),
+		TimestampMs: timestampMs,
+		DiscardedAt: e.now(),
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	reasons, ok := e.tenants[userID]
+	if !ok {
+		reasons = map[string]*discardedSamplesExamplesRing{}
+		e.tenants[userID] = reasons
+	}
+
+	ring, ok := reasons[reason]
+	if !ok {
+		ring = &discardedSamplesExamplesRing{}
+		reasons[reason] = ring
+	}
+
+	ring.add(example, maxExamples)
+}
+
+// Examples returns the examples of the tenant, sorted by reason and, for each reason, from the oldest to the most recent.
+func (e *DiscardedSamplesExamples) Examples(userID string) []DiscardedSampleExample {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	reasons := make([]string, 0, len(e.tenants[userID]))
+	for reason := range e.tenants[userID] {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	examples := []DiscardedSampleExample{}
+	for _, reason := range reasons {
+		examples = append(examples, e.tenants[userID][reason].ordered()...)
+	}
+	return examples
+}
+
+// DeleteUser removes the examples of the input tenant.
+func (e *DiscardedSamplesExamples) DeleteUser(userID string) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	delete(e.tenants, userID)
+}
+
+// Handler returns the examples of discarded series of the authenticated tenant.
+func (e *DiscardedSamplesExamples) Handler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	util.WriteJSONResponse(w, DiscardedSamplesExamplesResponse{Examples: e.Examples(userID)})
+}
+
+func (r *discardedSamplesExamplesRing) add(example DiscardedSampleExample, maxExamples int) {
+	// The number of examples to keep may have been changed since the last example has been added.
+	if len(r.examples) > maxExamples {
+		r.examples = r.ordered()[len(r.examples)-maxExamples:]
+		r.next = 0
+	}
+
+	if len(r.examples) < maxExamples {
+		if r.next != 0 {
+			r.examples = r.ordered()
+			r.next = 0
+		}
+		r.examples = append(r.examples, example)
+		return
+	}
+
+	r.examples[r.next] = example
+	r.next = (r.next + 1) % maxExamples
+}
+
+// ordered returns the examples from the oldest to the most recent.
+func (r *discardedSamplesExamplesRing) ordered() []DiscardedSampleExample {
+	ordered := make([]DiscardedSampleExample, 0, len(r.examples))
+	ordered = append(ordered, r.examples[r.next:]...)
+	return append(ordered, r.examples[:r.next]...)
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+type discardedSamplesExamplesLimitsMock map[string]int
+
+func (m discardedSamplesExamplesLimitsMock) DiscardedSamplesExamplesPerReason(userID string) int {
+	return m[userID]
+}
+
+func TestDiscardedSamplesExamples(t *testing.T) {
+	limits := discardedSamplesExamplesLimitsMock{"user-1": 2}
+	examples := NewDiscardedSamplesExamples(limits)
+	now := time.Unix(1000, 0)
+	examples.now = func() time.Time { return now }
+
+	series := func(name string) []mimirpb.LabelAdapter {
+		return []mimirpb.LabelAdapter{{Name: "__name__", Value: name}, {Name: "job", Value: "test"}}
+	}
+
+	// Examples are not recorded for tenants with the feature disabled.
+	examples.Record("user-2", "too_far_in_future", series("series_1"), 1)
+	assert.Empty(t, examples.Examples("user-2"))
+
+	// Only the most recent examples are kept for each reason.
+	examples.Record("user-1", "too_far_in_future", series("series_1"), 1)
+	examples.Record("user-1", "too_far_in_future", series("series_2"), 2)
+	examples.Record("user-1", "too_far_in_future", series("series_3"), 3)
+	examples.Record("user-1", "label_name_too_long", series("series_4"), 0)
+
+	assert.Equal(t, []DiscardedSampleExample{
+		{Reason: "label_name_too_long", Labels: `{__name__="series_4", job="test"}`, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_2", job="test"}`, TimestampMs: 2, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_3", job="test"}`, TimestampMs: 3, DiscardedAt: now},
+	}, examples.Examples("user-1"))
+
+	// Lowering the number of examples drops the oldest ones.
+	limits["user-1"] = 1
+	examples.Record("user-1", "too_far_in_future", series("series_5"), 5)
+	assert.Equal(t, []DiscardedSampleExample{
+		{Reason: "label_name_too_long", Labels: `{__name__="series_4", job="test"}`, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_5", job="test"}`, TimestampMs: 5, DiscardedAt: now},
+	}, examples.Examples("user-1"))
+
+	// Raising the number of examples keeps the existing ones.
+	limits["user-1"] = 3
+	examples.Record("user-1", "too_far_in_future", series("series_6"), 6)
+	examples.Record("user-1", "too_far_in_future", series("series_7"), 7)
+	examples.Record("user-1", "too_far_in_future", series("series_8"), 8)
+	assert.Equal(t, []DiscardedSampleExample{
+		{Reason: "label_name_too_long", Labels: `{__name__="series_4", job="test"}`, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_6", job="test"}`, TimestampMs: 6, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_7", job="test"}`, TimestampMs: 7, DiscardedAt: now},
+		{Reason: "too_far_in_future", Labels: `{__name__="series_8", job="test"}`, TimestampMs: 8, DiscardedAt: now},
+	}, examples.Examples("user-1"))
+
+	examples.DeleteUser("user-1")
+	assert.Empty(t, examples.Examples("user-1"))
+}
+
+func TestDiscardedSamplesExamples_NilShouldNotRecord(t *testing.T) {
+	var examples *DiscardedSamplesExamples
+
+	require.NotPanics(t, func() {
+		examples.Record("user-1", "too_far_in_future", []mimirpb.LabelAdapter{{Name: "__name__", Value: "series"}}, 1)
+	})
+}
+
+func TestDiscardedSamplesExamples_Handler(t *testing.T) {
+	examples := NewDiscardedSamplesExamples(discardedSamplesExamplesLimitsMock{"user-1": 10})
+	examples.Record("user-1", "too_far_in_future", []mimirpb.LabelAdapter{{Name: "__name__", Value: "series"}}, 1)
+
+	t.Run("should return the examples of the authenticated tenant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/distributor/discarded_samples", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+		resp := httptest.NewRecorder()
+		examples.Handler(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var actual DiscardedSamplesExamplesResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &actual))
+		require.Len(t, actual.Examples, 1)
+		assert.Equal(t, "too_far_in_future", actual.Examples[0].Reason)
+		assert.Equal(t, `{__name__="series"}`, actual.Examples[0].Labels)
+		assert.Equal(t, int64(1), actual.Examples[0].TimestampMs)
+	})
+
+	t.Run("should return no examples for other tenants", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/distributor/discarded_samples", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-2"))
+		resp := httptest.NewRecorder()
+		examples.Handler(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"examples":[]}`, resp.Body.String())
+	})
+
+	t.Run("should fail on missing tenant", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		examples.Handler(resp, httptest.NewRequest("GET", "/distributor/discarded_samples", nil))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
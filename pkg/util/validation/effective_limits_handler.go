@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// EffectiveLimitSourceDefault is the source of the limits with their built-in default value.
+	EffectiveLimitSourceDefault = "default"
+	// EffectiveLimitSourceConfig is the source of the limits set by the configuration file or CLI flags.
+	EffectiveLimitSourceConfig = "config"
+	// EffectiveLimitSourceRuntimeConfig is the source of the limits overridden for the tenant in the runtime configuration.
+	EffectiveLimitSourceRuntimeConfig = "runtime_config"
+)
+
+type EffectiveLimit struct {
+	Value  json.RawMessage `json:"value"`
+	Source string          `json:"source"`
+}
+
+type EffectiveLimitsResponse struct {
+	TenantID string                    `json:"tenant_id"`
+	Limits   map[string]EffectiveLimit `json:"limits"`
+}
+
+// OverriddenTenantLimits is implemented by the TenantLimits which know the limits explicitly overridden for each tenant.
+type OverriddenTenantLimits interface {
+	// OverriddenLimitsByUserID returns the names of the limits explicitly overridden for the tenant.
+	OverriddenLimitsByUserID(userID string) map[string]struct{}
+}
+
+// EffectiveLimitsHandler returns all the limits of the tenant, as currently applied by the component serving the
+// request, with the source of each value. configuredLimits are the names of the limits explicitly set by the
+// configuration file or CLI flags, and the limits overridden for the tenant are the ones explicitly set in the
+// runtime configuration, if tenantLimits implements OverriddenTenantLimits.
+func EffectiveLimitsHandler(defaultLimits Limits, configuredLimits map[string]struct{}, tenantLimits TenantLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := tenant.TenantID(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		userLimits := &defaultLimits
+		var overriddenLimits map[string]struct{}
+		if tenantLimits != nil {
+			if l := tenantLimits.ByUserID(userID); l != nil {
+				userLimits = l
+				if overridden, ok := tenantLimits.(OverriddenTenantLimits); ok {
+					overriddenLimits = overridden.OverriddenLimitsByUserID(userID)
+				}
+			}
+		}
+
+		limits, err := effectiveLimits(userLimits, configuredLimits, overriddenLimits)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		util.WriteJSONResponse(w, EffectiveLimitsResponse{
+			TenantID: userID,
+			Limits:   limits,
+		})
+	}
+}
+
+// effectiveLimits returns the limits of the tenant, by JSON name, with the source of each value.
+func effectiveLimits(userLimits *Limits, configuredLimits, overriddenLimits map[string]struct{}) (map[string]EffectiveLimit, error) {
+	values, err := limitsJSONValues(userLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]EffectiveLimit, len(values))
+	for name, value := range values {
+		source := EffectiveLimitSourceDefault
+		if _, ok := overriddenLimits[name]; ok {
+			source = EffectiveLimitSourceRuntimeConfig
+		} else if _, ok := configuredLimits[name]; ok {
+			source = EffectiveLimitSourceConfig
+		}
+		out[name] = EffectiveLimit{Value: value, Source: source}
+	}
+	return out, nil
+}
+
+func limitsJSONValues(l *Limits) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// LimitNamesSetByFlags returns the names of the limits set by the CLI flags of fs, which must have been
+// registered by Limits.RegisterFlags.
+func LimitNamesSetByFlags(fs *flag.FlagSet) []string {
+	// The flags are mapped to the limits by the address of the limit they set.
+	l := &Limits{}
+	limitsFlags := flag.NewFlagSet("", flag.ContinueOnError)
+	l.RegisterFlags(limitsFlags)
+
+	namesByAddr := map[uintptr]string{}
+	v := reflect.ValueOf(l).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			namesByAddr[v.Field(i).Addr().Pointer()] = name
+		}
+	}
+
+	namesByFlag := map[string]string{}
+	limitsFlags.VisitAll(func(f *flag.Flag) {
+		if fv := reflect.ValueOf(f.Value); fv.Kind() == reflect.Pointer {
+			if name, ok := namesByAddr[fv.Pointer()]; ok {
+				namesByFlag[f.Name] = name
+			}
+		}
+	})
+
+	var names []string
+	fs.Visit(func(f *flag.Flag) {
+		if name, ok := namesByFlag[f.Name]; ok {
+			names = append(names, name)
+		}
+	})
+	return names
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestEffectiveLimitsHandler(t *testing.T) {
+	defaults := Limits{}
+	flagext.DefaultValues(&defaults)
+	defaults.IngestionBurstSize = 500000
+
+	// The tenant overrides the ingestion burst size with the configured value, and the max series with the default value.
+	tenantLimits := defaults
+	tenantLimits.IngestionRate = 20000
+	tenantLimits.IngestionBurstSize = 500000
+
+	handler := EffectiveLimitsHandler(defaults, map[string]struct{}{"ingestion_burst_size": {}}, &mockOverriddenTenantLimits{
+		TenantLimits: NewMockTenantLimits(map[string]*Limits{"test-with-override": &tenantLimits}),
+		overridden: map[string]map[string]struct{}{
+			"test-with-override": {"ingestion_rate": {}, "ingestion_burst_size": {}, "max_global_series_per_user": {}},
+		},
+	})
+
+	for _, tc := range []struct {
+		name               string
+		orgID              string
+		expectedStatusCode int
+		expectedLimits     map[string]EffectiveLimit
+	}{
+		{
+			name:               "Authenticated user with override",
+			orgID:              "test-with-override",
+			expectedStatusCode: http.StatusOK,
+			expectedLimits: map[string]EffectiveLimit{
+				"ingestion_rate":                {Value: json.RawMessage("20000"), Source: EffectiveLimitSourceRuntimeConfig},
+				"ingestion_burst_size":          {Value: json.RawMessage("500000"), Source: EffectiveLimitSourceRuntimeConfig},
+				"max_global_series_per_user":    {Value: json.RawMessage("150000"), Source: EffectiveLimitSourceRuntimeConfig},
+				"max_global_exemplars_per_user": {Value: json.RawMessage("0"), Source: EffectiveLimitSourceDefault},
+			},
+		},
+		{
+			name:               "Authenticated user without override",
+			orgID:              "test-no-override",
+			expectedStatusCode: http.StatusOK,
+			expectedLimits: map[string]EffectiveLimit{
+				"ingestion_rate":             {Value: json.RawMessage("10000"), Source: EffectiveLimitSourceDefault},
+				"ingestion_burst_size":       {Value: json.RawMessage("500000"), Source: EffectiveLimitSourceConfig},
+				"max_global_series_per_user": {Value: json.RawMessage("150000"), Source: EffectiveLimitSourceDefault},
+			},
+		},
+		{
+			name:               "Unauthenticated user",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/api/v1/effective_limits", nil)
+			if tc.orgID != "" {
+				request = request.WithContext(user.InjectOrgID(context.Background(), tc.orgID))
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedStatusCode, recorder.Result().StatusCode)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var response EffectiveLimitsResponse
+			require.NoError(t, json.NewDecoder(recorder.Result().Body).Decode(&response))
+			assert.Equal(t, tc.orgID, response.TenantID)
+			for name, expected := range tc.expectedLimits {
+				assert.Equal(t, expected, response.Limits[name], name)
+			}
+
+			// All the limits are returned.
+			values, err := limitsJSONValues(&defaults)
+			require.NoError(t, err)
+			assert.Len(t, response.Limits, len(values))
+		})
+	}
+}
+
+type mockOverriddenTenantLimits struct {
+	TenantLimits
+	overridden map[string]map[string]struct{}
+}
+
+func (l *mockOverriddenTenantLimits) OverriddenLimitsByUserID(userID string) map[string]struct{} {
+	return l.overridden[userID]
+}
+
+func TestLimitNamesSetByFlags(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	(&Limits{}).RegisterFlags(fs)
+	fs.Int("other", 0, "")
+
+	require.NoError(t, fs.Parse([]string{"-distributor.ingestion-rate-limit=100", "-other=1", "-ingester.max-global-series-per-user=10"}))
+	assert.ElementsMatch(t, []string{"ingestion_rate", "max_global_series_per_user"}, LimitNamesSetByFlags(fs))
+}
@@ -21,12 +21,39 @@ import (
 //nolint:revive // ignore stutter warning
 type ValidationError error
 
+// DiscardReason returns the reason the samples rejected with the input series validation error are discarded with,
+// matching the reason label of the cortex_discarded_samples_total metric, or false if the error isn't a series
+// validation error.
+func DiscardReason(err error) (string, bool) {
+	switch e := err.(type) {
+	case genericValidationError:
+		return e.reason, true
+	case sampleValidationError:
+		return e.reason, true
+	case labelValueTooLongError:
+		return reasonLabelValueTooLong, true
+	case tooManyLabelsError:
+		return reasonMaxLabelNamesPerSeries, true
+	case noMetricNameError:
+		return reasonMissingMetricName, true
+	case invalidMetricNameError:
+		return reasonInvalidMetricName, true
+	case maxNativeHistogramBucketsError:
+		return reasonMaxNativeHistogramBuckets, true
+	case nonMonotonicTimestampsError:
+		return reasonNonMonotonicTimestamps, true
+	default:
+		return "", false
+	}
+}
+
 // genericValidationError is a basic implementation of ValidationError which can be used when the
 // error format only contains the cause and the series.
 type genericValidationError struct {
 	message string
 	cause   string
 	series  []mimirpb.LabelAdapter
+	reason  string
 }
 
 func (e genericValidationError) Error() string {
@@ -42,6 +69,7 @@ func newLabelNameTooLongError(series []mimirpb.LabelAdapter, labelName string) V
 		message: labelNameTooLongMsgFormat,
 		cause:   labelName,
 		series:  series,
+		reason:  reasonLabelNameTooLong,
 	}
 }
 
@@ -73,6 +101,7 @@ func newInvalidLabelError(series []mimirpb.LabelAdapter, labelName string) Valid
 		message: invalidLabelMsgFormat,
 		cause:   labelName,
 		series:  series,
+		reason:  reasonInvalidLabel,
 	}
 }
 
@@ -84,6 +113,33 @@ func newDuplicatedLabelError(series []mimirpb.LabelAdapter, labelName string) Va
 		message: duplicateLabelMsgFormat,
 		cause:   labelName,
 		series:  series,
+		reason:  reasonDuplicateLabelNames,
+	}
+}
+
+var labelNameNotAllowedMsgFormat = globalerror.LabelNameNotAllowed.MessageWithPerTenantLimitConfig(
+	"received a series with a label name not allowed, label: '%.200s' series: '%.200s'",
+	allowedLabelNamesFlag)
+
+func newLabelNameNotAllowedError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
+	return genericValidationError{
+		message: labelNameNotAllowedMsgFormat,
+		cause:   labelName,
+		series:  series,
+		reason:  reasonLabelNameNotAllowed,
+	}
+}
+
+var missingRequiredLabelNameMsgFormat = globalerror.MissingRequiredLabelName.MessageWithPerTenantLimitConfig(
+	"received a series missing a required label name, label: '%.200s' series: '%.200s'",
+	requiredLabelNamesFlag)
+
+func newMissingRequiredLabelNameError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
+	return genericValidationError{
+		message: missingRequiredLabelNameMsgFormat,
+		cause:   labelName,
+		series:  series,
+		reason:  reasonMissingRequiredLabelName,
 	}
 }
 
@@ -134,6 +190,7 @@ type sampleValidationError struct {
 	message    string
 	metricName string
 	timestamp  int64
+	reason     string
 }
 
 func (e sampleValidationError) Error() string {
@@ -170,9 +227,36 @@ func newSampleTimestampTooNewError(metricName string, timestamp int64) Validatio
 		message:    sampleTimestampTooNewMsgFormat,
 		metricName: metricName,
 		timestamp:  timestamp,
+		reason:     reasonTooFarInFuture,
 	}
 }
 
+type nonMonotonicTimestampsError struct {
+	seriesLabels      []mimirpb.LabelAdapter
+	sampleType        string
+	index             int
+	timestamp         int64
+	previousTimestamp int64
+}
+
+func newNonMonotonicTimestampsError(seriesLabels []mimirpb.LabelAdapter, sampleType string, index int, timestamp, previousTimestamp int64) ValidationError {
+	return nonMonotonicTimestampsError{
+		seriesLabels:      seriesLabels,
+		sampleType:        sampleType,
+		index:             index,
+		timestamp:         timestamp,
+		previousTimestamp: previousTimestamp,
+	}
+}
+
+var nonMonotonicTimestampsMsgFormat = globalerror.SampleTimestampsNotMonotonic.MessageWithPerTenantLimitConfig(
+	"received a series whose %s timestamps are not monotonically increasing within the write request, the %s at index %d has timestamp %d which is older than the previous timestamp %d, series: '%.200s'",
+	nonMonotonicSamplesPolicyFlag)
+
+func (e nonMonotonicTimestampsError) Error() string {
+	return fmt.Sprintf(nonMonotonicTimestampsMsgFormat, e.sampleType, e.sampleType, e.index, e.timestamp, e.previousTimestamp, mimirpb.FromLabelAdaptersToLabels(e.seriesLabels).String())
+}
+
 // exemplarValidationError is a ValidationError implementation suitable for exemplar validation errors.
 type exemplarValidationError struct {
 	message        string
@@ -255,6 +339,18 @@ func newMetadataMetricNameTooLongError(metadata *mimirpb.MetricMetadata) Validat
 	}
 }
 
+var metadataHelpTooLongMsgFormat = globalerror.MetricMetadataHelpTooLong.MessageWithPerTenantLimitConfig(
+	"received a metric metadata whose help length exceeds the limit, help: '%.200s' metric name: '%.200s'",
+	maxMetadataLengthFlag, metadataLengthPolicyFlag)
+
+func newMetadataHelpTooLongError(metadata *mimirpb.MetricMetadata) ValidationError {
+	return metadataValidationError{
+		message:    metadataHelpTooLongMsgFormat,
+		cause:      metadata.GetHelp(),
+		metricName: metadata.GetMetricFamilyName(),
+	}
+}
+
 var metadataUnitTooLongMsgFormat = globalerror.MetricMetadataUnitTooLong.MessageWithPerTenantLimitConfig(
 	"received a metric metadata whose unit name length exceeds the limit, unit: '%.200s' metric name: '%.200s'",
 	maxMetadataLengthFlag)
@@ -297,6 +393,17 @@ func NewIngestionRateLimitedError(limit float64, burst int) LimitError {
 		ingestionRateFlag, ingestionBurstSizeFlag))
 }
 
+func NewIngestionQuotaExceededError(quota IngestionQuota) LimitError {
+	return LimitError(globalerror.IngestionQuotaExceeded.Message(
+		fmt.Sprintf("the series matching the selector %s have been rejected because the tenant exceeded the ingestion quota %q, set to %v samples/s with a maximum allowed burst of %d. This limit is applied on the samples received across all distributors. To adjust the quota, configure ingestion_quotas in the runtime configuration, or contact your service administrator", quota.Match, quota.Name, quota.SamplesPerSecond, quota.BurstSize())))
+}
+
+func NewIngestionBytesRateLimitedError(limit float64, burst int) LimitError {
+	return LimitError(globalerror.IngestionBytesRateLimited.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the request has been rejected because the tenant exceeded the ingestion bytes rate limit, set to %v bytes/s with a maximum allowed burst of %d. This limit is applied on the uncompressed size of the write requests received across all distributors", limit, burst),
+		ingestionBytesRateFlag, ingestionBytesBurstSizeFlag))
+}
+
 // formatLabelSet formats label adapters as a metric name with labels, while preserving
 // label order, and keeping duplicates. If there are multiple "__name__" labels, only
 // first one is used as metric name, other ones will be included as regular labels.
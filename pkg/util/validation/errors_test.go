@@ -41,7 +41,59 @@ func TestNewRequestRateLimitedError(t *testing.T) {
 	assert.Equal(t, "the request has been rejected because the tenant exceeded the request rate limit, set to 10 requests/s across all distributors with a maximum allowed burst of 5 (err-mimir-tenant-max-request-rate). To adjust the related per-tenant limits, configure -distributor.request-rate-limit and -distributor.request-burst-size, or contact your service administrator.", err.Error())
 }
 
+func TestNewIngestionBytesRateLimitedError(t *testing.T) {
+	err := NewIngestionBytesRateLimitedError(1000, 500)
+	assert.Equal(t, "the request has been rejected because the tenant exceeded the ingestion bytes rate limit, set to 1000 bytes/s with a maximum allowed burst of 500. This limit is applied on the uncompressed size of the write requests received across all distributors (err-mimir-tenant-max-ingestion-bytes-rate). To adjust the related per-tenant limits, configure -distributor.ingestion-bytes-rate-limit and -distributor.ingestion-bytes-burst-size, or contact your service administrator.", err.Error())
+}
+
 func TestNewIngestionRateLimitedError(t *testing.T) {
 	err := NewIngestionRateLimitedError(10, 5)
 	assert.Equal(t, "the request has been rejected because the tenant exceeded the ingestion rate limit, set to 10 items/s with a maximum allowed burst of 5. This limit is applied on the total number of samples, exemplars and metadata received across all distributors (err-mimir-tenant-max-ingestion-rate). To adjust the related per-tenant limits, configure -distributor.ingestion-rate-limit and -distributor.ingestion-burst-size, or contact your service administrator.", err.Error())
 }
+
+func TestDiscardReason(t *testing.T) {
+	series := []mimirpb.LabelAdapter{{Name: "__name__", Value: "test"}}
+
+	tests := map[string]struct {
+		err            error
+		expectedReason string
+		expectedOK     bool
+	}{
+		"label name too long": {
+			err:            newLabelNameTooLongError(series, "label"),
+			expectedReason: reasonLabelNameTooLong,
+			expectedOK:     true,
+		},
+		"label value too long": {
+			err:            newLabelValueTooLongError(series, "value"),
+			expectedReason: reasonLabelValueTooLong,
+			expectedOK:     true,
+		},
+		"missing required label name": {
+			err:            newMissingRequiredLabelNameError(series, "job"),
+			expectedReason: reasonMissingRequiredLabelName,
+			expectedOK:     true,
+		},
+		"sample too far in future": {
+			err:            newSampleTimestampTooNewError("test", 10),
+			expectedReason: reasonTooFarInFuture,
+			expectedOK:     true,
+		},
+		"max native histogram buckets": {
+			err:            newMaxNativeHistogramBucketsError(series, 10, 20, 10),
+			expectedReason: reasonMaxNativeHistogramBuckets,
+			expectedOK:     true,
+		},
+		"not a series validation error": {
+			err: newMetadataMetricNameMissingError(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reason, ok := DiscardReason(tc.err)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// HALabelPair identifies the HA replicas of a level of a nested HA topology, like the Prometheus replicas
+// of the clusters of a region: the cluster is identified by the values of the cluster labels, and its
+// replicas by the value of the replica label.
+type HALabelPair struct {
+	Name          string   `yaml:"name" json:"name"`
+	ClusterLabels []string `yaml:"cluster_labels" json:"cluster_labels"`
+	ReplicaLabel  string   `yaml:"replica_label" json:"replica_label"`
+}
+
+func (p HALabelPair) validate() error {
+	if p.Name == "" {
+		return errors.New("the HA label pair name must not be empty")
+	}
+	if strings.Contains(p.Name, "/") {
+		return fmt.Errorf("the name of the HA label pair %q must not contain '/'", p.Name)
+	}
+	if len(p.ClusterLabels) == 0 {
+		return fmt.Errorf("the HA label pair %q must have at least one cluster label", p.Name)
+	}
+	for _, l := range p.ClusterLabels {
+		if !model.LabelName(l).IsValid() {
+			return fmt.Errorf("invalid cluster label %q of the HA label pair %q", l, p.Name)
+		}
+	}
+	if !model.LabelName(p.ReplicaLabel).IsValid() {
+		return fmt.Errorf("invalid replica label %q of the HA label pair %q", p.ReplicaLabel, p.Name)
+	}
+	for _, l := range p.ClusterLabels {
+		if l == p.ReplicaLabel {
+			return fmt.Errorf("the replica label %q of the HA label pair %q must not be one of its cluster labels", p.ReplicaLabel, p.Name)
+		}
+	}
+	return nil
+}
+
+func validateHALabelPairs(pairs []HALabelPair) error {
+	names := make(map[string]struct{}, len(pairs))
+	for _, p := range pairs {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("duplicate HA label pair %q", p.Name)
+		}
+		names[p.Name] = struct{}{}
+	}
+	return nil
+}
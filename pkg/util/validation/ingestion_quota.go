@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// IngestionQuota caps the rate of the samples received by the distributor for the series matching a selector,
+// like the series of a namespace, without rate limiting the other series of the tenant.
+type IngestionQuota struct {
+	Name             string  `yaml:"name" json:"name"`
+	Match            string  `yaml:"match" json:"match"`
+	SamplesPerSecond float64 `yaml:"samples_per_second" json:"samples_per_second"`
+	Burst            int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+}
+
+// Matchers returns the label matchers of the series selector of the quota.
+func (q IngestionQuota) Matchers() ([]*labels.Matcher, error) {
+	return parser.ParseMetricSelector(q.Match)
+}
+
+// BurstSize returns the maximum number of samples which can be received at once. It defaults to the number of
+// samples per second.
+func (q IngestionQuota) BurstSize() int {
+	if q.Burst > 0 {
+		return q.Burst
+	}
+	return int(math.Ceil(q.SamplesPerSecond))
+}
+
+func (q IngestionQuota) validate() error {
+	if q.Name == "" {
+		return errors.New("the ingestion quota name must not be empty")
+	}
+	if _, err := q.Matchers(); err != nil {
+		return fmt.Errorf("invalid selector %q of the ingestion quota %q: %w", q.Match, q.Name, err)
+	}
+	if q.SamplesPerSecond <= 0 {
+		return fmt.Errorf("the samples per second of the ingestion quota %q must be greater than 0", q.Name)
+	}
+	if q.Burst < 0 {
+		return fmt.Errorf("the burst of the ingestion quota %q must not be negative", q.Name)
+	}
+	return nil
+}
+
+func validateIngestionQuotas(quotas []IngestionQuota) error {
+	names := make(map[string]struct{}, len(quotas))
+	for _, q := range quotas {
+		if err := q.validate(); err != nil {
+			return err
+		}
+		if _, ok := names[q.Name]; ok {
+			return fmt.Errorf("duplicate ingestion quota %q", q.Name)
+		}
+		names[q.Name] = struct{}{}
+	}
+	return nil
+}
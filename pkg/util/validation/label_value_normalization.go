@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// LabelValueNormalizationRule normalizes the values of a label of the series received by the distributor.
+type LabelValueNormalizationRule struct {
+	Label         string            `yaml:"label" json:"label"`
+	Lowercase     bool              `yaml:"lowercase,omitempty" json:"lowercase,omitempty"`
+	StripPrefixes []string          `yaml:"strip_prefixes,omitempty" json:"strip_prefixes,omitempty"`
+	ValueMappings map[string]string `yaml:"value_mappings,omitempty" json:"value_mappings,omitempty"`
+}
+
+// Normalize returns the normalized label value. The value is lowercased first, if enabled, then the
+// first matching prefix is stripped, and finally the value is replaced according to the value mappings.
+func (r LabelValueNormalizationRule) Normalize(value string) string {
+	if r.Lowercase {
+		value = strings.ToLower(value)
+	}
+	for _, prefix := range r.StripPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			value = value[len(prefix):]
+			break
+		}
+	}
+	if mapped, ok := r.ValueMappings[value]; ok {
+		value = mapped
+	}
+	return value
+}
+
+func (r LabelValueNormalizationRule) validate() error {
+	if !model.LabelName(r.Label).IsValid() {
+		return fmt.Errorf("invalid label value normalization rule label %q", r.Label)
+	}
+	if !r.Lowercase && len(r.StripPrefixes) == 0 && len(r.ValueMappings) == 0 {
+		return fmt.Errorf("the label value normalization rule of the label %q doesn't normalize anything", r.Label)
+	}
+	for _, prefix := range r.StripPrefixes {
+		if prefix == "" {
+			return errors.New("empty label value normalization rule prefix")
+		}
+	}
+	return nil
+}
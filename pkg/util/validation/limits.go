@@ -11,13 +11,18 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql/parser"
+	"golang.org/x/exp/slices"
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v3"
 
@@ -37,8 +42,16 @@ const (
 	maxLabelNameLengthFlag                 = "validation.max-length-label-name"
 	maxLabelValueLengthFlag                = "validation.max-length-label-value"
 	maxMetadataLengthFlag                  = "validation.max-metadata-length"
+	metadataLengthPolicyFlag               = "validation.metadata-length-policy"
 	maxNativeHistogramBucketsFlag          = "validation.max-native-histogram-buckets"
 	creationGracePeriodFlag                = "validation.create-grace-period"
+	nonMonotonicSamplesPolicyFlag          = "validation.non-monotonic-samples-policy"
+	allowedLabelNamesFlag                  = "validation.allowed-label-names"
+	requiredLabelNamesFlag                 = "validation.required-label-names"
+	requiredLabelNamesExemptionsFlag       = "validation.required-label-names-exemption-selectors"
+	labelNamesPolicyActionFlag             = "validation.label-names-policy-action"
+	seriesShardingSchemeFlag               = "distributor.series-sharding-scheme"
+	seriesShardingMigrationSchemeFlag      = "distributor.series-sharding-migration-scheme"
 	maxPartialQueryLengthFlag              = "querier.max-partial-query-length"
 	maxTotalQueryLengthFlag                = "query-frontend.max-total-query-length"
 	maxQueryExpressionSizeBytesFlag        = "query-frontend.max-query-expression-size-bytes"
@@ -46,6 +59,8 @@ const (
 	requestBurstSizeFlag                   = "distributor.request-burst-size"
 	ingestionRateFlag                      = "distributor.ingestion-rate-limit"
 	ingestionBurstSizeFlag                 = "distributor.ingestion-burst-size"
+	ingestionBytesRateFlag                 = "distributor.ingestion-bytes-rate-limit"
+	ingestionBytesBurstSizeFlag            = "distributor.ingestion-bytes-burst-size"
 	HATrackerMaxClustersFlag               = "distributor.ha-tracker.max-clusters"
 	resultsCacheTTLFlag                    = "query-frontend.results-cache-ttl"
 	resultsCacheTTLForOutOfOrderWindowFlag = "query-frontend.results-cache-ttl-for-out-of-order-time-window"
@@ -53,6 +68,79 @@ const (
 
 	// MinCompactorPartialBlockDeletionDelay is the minimum partial blocks deletion delay that can be configured in Mimir.
 	MinCompactorPartialBlockDeletionDelay = 4 * time.Hour
+
+	// DefaultSamplesPerChunk is the default target number of float samples per TSDB chunk, the same as the TSDB default.
+	DefaultSamplesPerChunk = 120
+	// MinSamplesPerChunk and MaxSamplesPerChunk are the bounds of the target number of float samples per TSDB chunk
+	// that can be configured in Mimir. Chunks larger than the upper bound would often exceed the chunk size estimated
+	// by the store-gateway when fetching chunks from the bucket, causing chunks to be refetched.
+	MinSamplesPerChunk = 30
+	MaxSamplesPerChunk = 480
+
+	// RulerNotificationQueueOverflowPolicyDropOldest drops the oldest alert notifications when the ruler notification queue is full.
+	RulerNotificationQueueOverflowPolicyDropOldest = "drop-oldest"
+	// RulerNotificationQueueOverflowPolicyDeadLetter stores the alert notifications which don't fit in the ruler notification queue,
+	// or can't be delivered to the Alertmanager, in the notifications dead-letter.
+	RulerNotificationQueueOverflowPolicyDeadLetter = "dead-letter"
+
+	// NonMonotonicSamplesPolicyAllow forwards the series whose samples timestamps are not monotonically increasing
+	// within a write request to the ingesters as they are.
+	NonMonotonicSamplesPolicyAllow = "allow"
+	// NonMonotonicSamplesPolicySort sorts by timestamp the samples of the series whose samples timestamps are not
+	// monotonically increasing within a write request.
+	NonMonotonicSamplesPolicySort = "sort"
+	// NonMonotonicSamplesPolicyReject rejects the series whose samples timestamps are not monotonically increasing
+	// within a write request.
+	NonMonotonicSamplesPolicyReject = "reject"
+
+	// LabelNamesPolicyActionDropLabel drops the label names not allowed from the series, and drops the series
+	// missing a required label name.
+	LabelNamesPolicyActionDropLabel = "drop-label"
+	// LabelNamesPolicyActionDropSeries drops the series with a label name not allowed or missing a required label name.
+	LabelNamesPolicyActionDropSeries = "drop-series"
+	// LabelNamesPolicyActionRejectRequest rejects the whole write request if any of its series has a label name
+	// not allowed or misses a required label name.
+	LabelNamesPolicyActionRejectRequest = "reject-request"
+
+	// SeriesShardingSchemeV1 hashes the tenant ID and the series labels, in the order they're received, with FNV-1a.
+	SeriesShardingSchemeV1 = "v1"
+	// SeriesShardingSchemeV2 hashes the tenant ID and the series labels, sorted by name, with xxhash.
+	SeriesShardingSchemeV2 = "v2"
+
+	// MetadataLengthPolicyTruncate truncates the HELP of the metadata longer than the max metadata length.
+	MetadataLengthPolicyTruncate = "truncate"
+	// MetadataLengthPolicyReject rejects the metadata whose HELP is longer than the max metadata length.
+	MetadataLengthPolicyReject = "reject"
+)
+
+// Classes of the errors returned to the query-frontend by the downstream queriers.
+const (
+	// QueryErrorClassNetwork is the class of the errors caused by the network or an unavailable querier.
+	QueryErrorClassNetwork = "network"
+	// QueryErrorClassTimeout is the class of the errors caused by a query timing out.
+	QueryErrorClassTimeout = "timeout"
+	// QueryErrorClassResourceExhausted is the class of the errors caused by an overloaded or rate limited downstream.
+	QueryErrorClassResourceExhausted = "resource_exhausted"
+	// QueryErrorClassBadData is the class of the errors caused by the query itself, which can never succeed.
+	QueryErrorClassBadData = "bad_data"
+	// QueryErrorClassInternal is the class of the other server errors.
+	QueryErrorClassInternal = "internal"
+)
+
+// QueryErrorClasses are all the classes of the errors returned to the query-frontend by the downstream queriers.
+var QueryErrorClasses = []string{QueryErrorClassNetwork, QueryErrorClassTimeout, QueryErrorClassResourceExhausted, QueryErrorClassBadData, QueryErrorClassInternal}
+
+var (
+	ruleGroupTemplateVariableNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// reservedRuleGroupTemplateVariables are the variables available to the templates of the alerting rules,
+	// which can't be overridden by the rule group template variables.
+	reservedRuleGroupTemplateVariables = map[string]struct{}{
+		"labels":         {},
+		"value":          {},
+		"externalLabels": {},
+		"externalURL":    {},
+	}
 )
 
 // LimitError are errors that do not comply with the limits specified.
@@ -66,24 +154,51 @@ func (e LimitError) Error() string {
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	RequestRate               float64             `yaml:"request_rate" json:"request_rate"`
-	RequestBurstSize          int                 `yaml:"request_burst_size" json:"request_burst_size"`
-	IngestionRate             float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
-	IngestionBurstSize        int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
-	AcceptHASamples           bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
-	HAClusterLabel            string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
-	HAReplicaLabel            string              `yaml:"ha_replica_label" json:"ha_replica_label"`
-	HAMaxClusters             int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
-	DropLabels                flagext.StringSlice `yaml:"drop_labels" json:"drop_labels" category:"advanced"`
-	MaxLabelNameLength        int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
-	MaxLabelValueLength       int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
-	MaxLabelNamesPerSeries    int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
-	MaxMetadataLength         int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
-	MaxNativeHistogramBuckets int                 `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
-	CreationGracePeriod       model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
-	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
-	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
-	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs. Labels available during the relabeling phase and cleaned afterwards: __meta_tenant_id" category:"experimental"`
+	RequestRate               float64                `yaml:"request_rate" json:"request_rate"`
+	RequestBurstSize          int                    `yaml:"request_burst_size" json:"request_burst_size"`
+	IngestionRate             float64                `yaml:"ingestion_rate" json:"ingestion_rate"`
+	IngestionBurstSize        int                    `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
+	IngestionBytesRate        float64                `yaml:"ingestion_bytes_rate" json:"ingestion_bytes_rate" category:"experimental"`
+	IngestionBytesBurstSize   int                    `yaml:"ingestion_bytes_burst_size" json:"ingestion_bytes_burst_size" category:"experimental"`
+	AcceptHASamples           bool                   `yaml:"accept_ha_samples" json:"accept_ha_samples"`
+	HAClusterLabel            string                 `yaml:"ha_cluster_label" json:"ha_cluster_label"`
+	HAReplicaLabel            string                 `yaml:"ha_replica_label" json:"ha_replica_label"`
+	HAMaxClusters             int                    `yaml:"ha_max_clusters" json:"ha_max_clusters"`
+	DropLabels                flagext.StringSlice    `yaml:"drop_labels" json:"drop_labels" category:"advanced"`
+	MaxLabelNameLength        int                    `yaml:"max_label_name_length" json:"max_label_name_length"`
+	MaxLabelValueLength       int                    `yaml:"max_label_value_length" json:"max_label_value_length"`
+	MaxLabelNamesPerSeries    int                    `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxMetadataLength         int                    `yaml:"max_metadata_length" json:"max_metadata_length"`
+	MaxNativeHistogramBuckets int                    `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
+	CreationGracePeriod       model.Duration         `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
+	NonMonotonicSamplesPolicy string                 `yaml:"non_monotonic_samples_policy" json:"non_monotonic_samples_policy" category:"experimental"`
+	AllowedLabelNames         flagext.StringSliceCSV `yaml:"allowed_label_names" json:"allowed_label_names" category:"experimental"`
+	RequiredLabelNames        flagext.StringSliceCSV `yaml:"required_label_names" json:"required_label_names" category:"experimental"`
+	RequiredLabelNamesExempt  flagext.StringSlice    `yaml:"required_label_names_exemption_selectors" json:"required_label_names_exemption_selectors" category:"experimental"`
+	LabelNamesPolicyAction    string                 `yaml:"label_names_policy_action" json:"label_names_policy_action" category:"experimental"`
+	EnforceMetadataMetricName bool                   `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
+	IngestionTenantShardSize  int                    `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	SeriesShardingScheme      string                 `yaml:"series_sharding_scheme" json:"series_sharding_scheme" category:"experimental"`
+	SeriesShardingMigration   string                 `yaml:"series_sharding_migration_scheme" json:"series_sharding_migration_scheme" category:"experimental"`
+	MetricRelabelConfigs      []*relabel.Config      `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs. Labels available during the relabeling phase and cleaned afterwards: __meta_tenant_id" category:"experimental"`
+	PushDebugReportEnabled    bool                   `yaml:"push_debug_report_enabled" json:"push_debug_report_enabled" category:"experimental"`
+	InfluxIngestionEnabled    bool                   `yaml:"influx_ingestion_enabled" json:"influx_ingestion_enabled" category:"experimental"`
+
+	HALabelPairs []HALabelPair `yaml:"ha_label_pairs,omitempty" json:"ha_label_pairs,omitempty" doc:"nocli|description=List of HA label pairs identifying the levels of a nested HA topology, like the Prometheus replicas of the clusters of a region, from the outermost to the innermost. When set, they take precedence over the HA cluster and replica labels. For each pair, the HA cluster is identified by the values of the cluster_labels, and its replicas by the value of the replica_label. The pairs are evaluated in order: the series are deduplicated as soon as the replica of a level isn't elected, otherwise the replica label is removed. Each pair tracks its own elected replicas, whose cluster is named after the pair name and the values of the cluster labels, like <name>/<value>/<value>." category:"experimental"`
+
+	MaxExemplarsPerSeriesPerMinute int `yaml:"max_exemplars_per_series_per_minute" json:"max_exemplars_per_series_per_minute" category:"experimental"`
+
+	LabelValueNormalizationRules []LabelValueNormalizationRule `yaml:"label_value_normalization_rules,omitempty" json:"label_value_normalization_rules,omitempty" doc:"nocli|description=List of rules normalizing the label values of the received series, applied in order before the metric relabel configs. Each rule applies to the values of a label: it lowercases them if lowercase is true, strips the first matching prefix among strip_prefixes, and replaces them according to the value_mappings lookup table." category:"experimental"`
+
+	AggregationRules []AggregationRule `yaml:"aggregation_rules,omitempty" json:"aggregation_rules,omitempty" doc:"nocli|description=List of rules aggregating the received series before they're written to the ingesters, when the distributor aggregation is enabled with -distributor.aggregation.enabled. The series matching the match selector of a rule, and carrying float samples, are aggregated, with the sum or avg aggregation of their latest values, or the sum_counters aggregation of their increases taking the counter resets into account, into the series without the drop_labels and with the -distributor.aggregation.instance-label label. The aggregated series are written once per -distributor.aggregation.flush-interval. The first matching rule applies." category:"experimental"`
+
+	IngestionQuotas []IngestionQuota `yaml:"ingestion_quotas,omitempty" json:"ingestion_quotas,omitempty" doc:"nocli|description=List of quotas capping the rate of the samples received for the series matching the match selector of each quota, in samples_per_second with a maximum burst of burst samples, defaulting to samples_per_second. Like the ingestion rate limit, the quotas are applied across all distributors. The series exceeding a quota are discarded, while the other series of the tenant are still ingested. The first matching quota applies." category:"experimental"`
+
+	MetadataLengthPolicy string `yaml:"metadata_length_policy" json:"metadata_length_policy" category:"experimental"`
+
+	DualWriteEnabled bool `yaml:"dual_write_enabled" json:"dual_write_enabled" category:"experimental"`
+
+	SpillQueueMaxBytes int `yaml:"spill_queue_max_bytes" json:"spill_queue_max_bytes" category:"experimental"`
 
 	// Ingester enforced limits.
 	// Series
@@ -101,14 +216,29 @@ type Limits struct {
 	// Max allowed time window for out-of-order samples.
 	OutOfOrderTimeWindow                 model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window" category:"experimental"`
 	OutOfOrderBlocksExternalLabelEnabled bool           `yaml:"out_of_order_blocks_external_label_enabled" json:"out_of_order_blocks_external_label_enabled" category:"experimental"`
+	// TSDB chunks encoding
+	SamplesPerChunk int `yaml:"samples_per_chunk" json:"samples_per_chunk" category:"experimental"`
+	// Ephemeral series
+	EphemeralSeriesSelectors flagext.StringSlice `yaml:"ephemeral_series_selectors" json:"ephemeral_series_selectors" category:"experimental"`
+	// Head compaction
+	HeadEarlyCompactionSeriesBytes int `yaml:"head_early_compaction_series_bytes_threshold" json:"head_early_compaction_series_bytes_threshold" category:"experimental"`
+	// Faults injected in the appends and queries, for resilience testing.
+	IngesterFaultInjectionPushLatency    model.Duration `yaml:"ingester_fault_injection_push_latency" json:"ingester_fault_injection_push_latency" category:"experimental"`
+	IngesterFaultInjectionPushErrorRate  float64        `yaml:"ingester_fault_injection_push_error_rate" json:"ingester_fault_injection_push_error_rate" category:"experimental"`
+	IngesterFaultInjectionQueryLatency   model.Duration `yaml:"ingester_fault_injection_query_latency" json:"ingester_fault_injection_query_latency" category:"experimental"`
+	IngesterFaultInjectionQueryErrorRate float64        `yaml:"ingester_fault_injection_query_error_rate" json:"ingester_fault_injection_query_error_rate" category:"experimental"`
 
 	// User defined label to give the option of subdividing specific metrics by another label
 	SeparateMetricsGroupLabel string `yaml:"separate_metrics_group_label" json:"separate_metrics_group_label" category:"experimental"`
 
+	// Examples of discarded series kept for the tenant to diagnose discarded samples.
+	DiscardedSamplesExamplesPerReason int `yaml:"discarded_samples_examples_per_reason" json:"discarded_samples_examples_per_reason" category:"experimental"`
+
 	// Querier enforced limits.
 	MaxChunksPerQuery               int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
 	MaxFetchedSeriesPerQuery        int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
 	MaxFetchedChunkBytesPerQuery    int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxSamplesPerQuery              int            `yaml:"max_samples_per_query" json:"max_samples_per_query" category:"experimental"`
 	MaxQueryLookback                model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxPartialQueryLength           model.Duration `yaml:"max_partial_query_length" json:"max_partial_query_length"`
 	MaxQueryParallelism             int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
@@ -118,32 +248,57 @@ type Limits struct {
 	QueryShardingTotalShards        int            `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
 	QueryShardingMaxShardedQueries  int            `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
 	QueryShardingMaxRegexpSizeBytes int            `yaml:"query_sharding_max_regexp_size_bytes" json:"query_sharding_max_regexp_size_bytes"`
+	QueryShardingTopKOversampling   float64        `yaml:"query_sharding_topk_oversampling_factor" json:"query_sharding_topk_oversampling_factor" category:"experimental"`
 	SplitInstantQueriesByInterval   model.Duration `yaml:"split_instant_queries_by_interval" json:"split_instant_queries_by_interval" category:"experimental"`
 	QueryIngestersWithin            model.Duration `yaml:"query_ingesters_within" json:"query_ingesters_within" category:"advanced"`
 
+	// Synthesizes classic histogram buckets from native histograms, for dashboards not migrated yet.
+	NativeHistogramsAsClassicBucketsEnabled bool `yaml:"native_histograms_as_classic_buckets_enabled" json:"native_histograms_as_classic_buckets_enabled" category:"experimental"`
+
 	// Query-frontend limits.
 	MaxTotalQueryLength                    model.Duration `yaml:"max_total_query_length" json:"max_total_query_length"`
 	ResultsCacheTTL                        model.Duration `yaml:"results_cache_ttl" json:"results_cache_ttl" category:"experimental"`
 	ResultsCacheTTLForOutOfOrderTimeWindow model.Duration `yaml:"results_cache_ttl_for_out_of_order_time_window" json:"results_cache_ttl_for_out_of_order_time_window" category:"experimental"`
 	ResultsCacheTTLForCardinalityQuery     model.Duration `yaml:"results_cache_ttl_for_cardinality_query" json:"results_cache_ttl_for_cardinality_query" category:"experimental"`
+	ResultsCacheSlidingTTLMax              model.Duration `yaml:"results_cache_sliding_ttl_max" json:"results_cache_sliding_ttl_max" category:"experimental"`
 	MaxQueryExpressionSizeBytes            int            `yaml:"max_query_expression_size_bytes" json:"max_query_expression_size_bytes" category:"experimental"`
+	MaxQueryExecutionTime                  model.Duration `yaml:"max_query_execution_time" json:"max_query_execution_time" category:"experimental"`
+	FuseStepMisalignedQueries              bool           `yaml:"fuse_step_misaligned_queries" json:"fuse_step_misaligned_queries" category:"experimental"`
+	ExtendedQuerySyntaxEnabled             bool           `yaml:"extended_query_syntax_enabled" json:"extended_query_syntax_enabled" category:"experimental"`
+	FederateEndpointEnabled                bool           `yaml:"federate_endpoint_enabled" json:"federate_endpoint_enabled" category:"experimental"`
+	FederateMaxSeries                      int            `yaml:"federate_max_series" json:"federate_max_series" category:"experimental"`
+	PassthroughURL                         string         `yaml:"passthrough_url" json:"passthrough_url" category:"experimental"`
+	ResultsCacheTTLForPassthrough          model.Duration `yaml:"results_cache_ttl_for_passthrough" json:"results_cache_ttl_for_passthrough" category:"experimental"`
+	// Classes of the downstream errors the query-frontend retries queries on.
+	QueryRetryErrorClasses flagext.StringSliceCSV `yaml:"query_retry_error_classes" json:"query_retry_error_classes" category:"experimental"`
+	// Read access policies of the sub-users of the tenant, enforced by the query-frontend.
+	QueryAccessPolicies map[string]QueryAccessPolicy `yaml:"query_access_policies" json:"query_access_policies" doc:"nocli|description=Query access policies of the sub-users of the tenant, identified by the header configured with -query-frontend.sub-user-header. Each policy restricts the series and the time range the sub-user can query through the query-frontend. Requests without a sub-user and sub-users without a policy can't query the tenant, if the tenant has any policy." category:"experimental"`
 
 	// Cardinality
 	CardinalityAnalysisEnabled                    bool `yaml:"cardinality_analysis_enabled" json:"cardinality_analysis_enabled"`
 	LabelNamesAndValuesResultsMaxSizeBytes        int  `yaml:"label_names_and_values_results_max_size_bytes" json:"label_names_and_values_results_max_size_bytes"`
 	LabelValuesMaxCardinalityLabelNamesPerRequest int  `yaml:"label_values_max_cardinality_label_names_per_request" json:"label_values_max_cardinality_label_names_per_request"`
 
+	PaginatedLabelsMaxLimit         int `yaml:"paginated_labels_max_limit" json:"paginated_labels_max_limit" category:"experimental"`
+	ActiveSeriesResultsMaxSizeBytes int `yaml:"active_series_results_max_size_bytes" json:"active_series_results_max_size_bytes" category:"experimental"`
+
 	// Ruler defaults and limits.
-	RulerEvaluationDelay                 model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
-	RulerTenantShardSize                 int            `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
-	RulerMaxRulesPerRuleGroup            int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
-	RulerMaxRuleGroupsPerTenant          int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
-	RulerRecordingRulesEvaluationEnabled bool           `yaml:"ruler_recording_rules_evaluation_enabled" json:"ruler_recording_rules_evaluation_enabled" category:"experimental"`
-	RulerAlertingRulesEvaluationEnabled  bool           `yaml:"ruler_alerting_rules_evaluation_enabled" json:"ruler_alerting_rules_evaluation_enabled" category:"experimental"`
-	RulerSyncRulesOnChangesEnabled       bool           `yaml:"ruler_sync_rules_on_changes_enabled" json:"ruler_sync_rules_on_changes_enabled" category:"advanced"`
+	RulerEvaluationDelay                 model.Duration                `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
+	RulerTenantShardSize                 int                           `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
+	RulerMaxRulesPerRuleGroup            int                           `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
+	RulerMaxRuleGroupsPerTenant          int                           `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerRecordingRulesEvaluationEnabled bool                          `yaml:"ruler_recording_rules_evaluation_enabled" json:"ruler_recording_rules_evaluation_enabled" category:"experimental"`
+	RulerAlertingRulesEvaluationEnabled  bool                          `yaml:"ruler_alerting_rules_evaluation_enabled" json:"ruler_alerting_rules_evaluation_enabled" category:"experimental"`
+	RulerSyncRulesOnChangesEnabled       bool                          `yaml:"ruler_sync_rules_on_changes_enabled" json:"ruler_sync_rules_on_changes_enabled" category:"advanced"`
+	RulerMaxConcurrentRuleGroups         int                           `yaml:"ruler_max_concurrent_rule_groups_per_tenant" json:"ruler_max_concurrent_rule_groups_per_tenant" category:"experimental"`
+	RulerNotificationQueueOverflowPolicy string                        `yaml:"ruler_notification_queue_overflow_policy" json:"ruler_notification_queue_overflow_policy" category:"experimental"`
+	RulerAlertmanagerClientConfig        RulerAlertmanagerClientConfig `yaml:"ruler_alertmanager_client" json:"ruler_alertmanager_client" doc:"description=Per-tenant Alertmanager the ruler sends the tenant's alert notifications to, instead of the one configured with -ruler.alertmanager-url."`
+	// Variables substituted into the tenant's rule groups, so that one rule template can be shared by many tenants.
+	RulerRuleGroupTemplateVariables map[string]string `yaml:"ruler_rule_group_template_variables" json:"ruler_rule_group_template_variables" doc:"nocli|description=Variables substituted into the rule groups of the tenant when they're synced by the ruler. Each variable is referenced as $name in the rule expressions, labels and annotations, and is replaced with its value. Rule groups whose expressions reference variables which are not defined for the tenant are rejected." category:"experimental"`
 
 	// Store-gateway.
-	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantShardSize          int  `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayLazyTenantLoadingEnabled bool `yaml:"store_gateway_lazy_tenant_loading_enabled" json:"store_gateway_lazy_tenant_loading_enabled" category:"experimental"`
 
 	// Compactor.
 	CompactorBlocksRetentionPeriod        model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
@@ -177,15 +332,24 @@ type Limits struct {
 	AlertmanagerMaxAlertsSizeBytes             int `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
 
 	extensions map[string]interface{}
+
+	// requiredLabelNamesExemptMatchers are the parsed RequiredLabelNamesExempt selectors.
+	requiredLabelNamesExemptMatchers [][]*labels.Matcher
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
 func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.IngestionTenantShardSize, "distributor.ingestion-tenant-shard-size", 0, "The tenant's shard size used by shuffle-sharding. This value is the total size of the shard (ie. it is not the number of ingesters in the shard per zone, but the number of ingesters in the shard across all zones, if zone-awareness is enabled). Must be set both on ingesters and distributors. 0 disables shuffle sharding.")
+	f.StringVar(&l.SeriesShardingScheme, seriesShardingSchemeFlag, SeriesShardingSchemeV1, fmt.Sprintf("The scheme used to compute the ring token of the tenant's series, which determines the ingesters the series are written to. Supported values are: %s (FNV-1a of the labels in the order they're received), %s (xxhash of the labels sorted by name). Changing the scheme moves most series to other ingesters: to avoid gaps in the queries of the recent data, write the series with both schemes with -%s for at least the ingesters TSDB head retention before and after the change.", SeriesShardingSchemeV1, SeriesShardingSchemeV2, seriesShardingMigrationSchemeFlag))
+	f.StringVar(&l.SeriesShardingMigration, seriesShardingMigrationSchemeFlag, "", fmt.Sprintf("The scheme used to compute a second ring token of the tenant's series while migrating from or to another -%s. The series are written both to the ingesters owning the token of -%s and to the ones owning the token of this scheme, and the write requests succeed only if both writes succeed. The metadata is only written with -%s. Empty to disable.", seriesShardingSchemeFlag, seriesShardingSchemeFlag, seriesShardingSchemeFlag))
+	f.BoolVar(&l.PushDebugReportEnabled, "distributor.push-debug-report-enabled", false, "Allow the tenant to request a structured report of how a write request has been processed by the distributor, by setting the X-Mimir-Debug-Push: true header on the push request. The report replaces the response body, and includes the decision of each step of the write path and the ingesters the write request has been sent to.")
+	f.BoolVar(&l.InfluxIngestionEnabled, "distributor.influx-ingestion-enabled", false, "Allow the tenant to write samples in the Influx line protocol to the /api/v1/push/influx endpoint.")
 	f.Float64Var(&l.RequestRate, requestRateFlag, 0, "Per-tenant push request rate limit in requests per second. 0 to disable.")
 	f.IntVar(&l.RequestBurstSize, requestBurstSizeFlag, 0, "Per-tenant allowed push request burst size. 0 to disable.")
 	f.Float64Var(&l.IngestionRate, ingestionRateFlag, 10000, "Per-tenant ingestion rate limit in samples per second.")
 	f.IntVar(&l.IngestionBurstSize, ingestionBurstSizeFlag, 200000, "Per-tenant allowed ingestion burst size (in number of samples).")
+	f.Float64Var(&l.IngestionBytesRate, ingestionBytesRateFlag, 0, "Per-tenant ingestion rate limit in bytes per second, measured on the uncompressed write requests. 0 to disable.")
+	f.IntVar(&l.IngestionBytesBurstSize, ingestionBytesBurstSizeFlag, 0, "Per-tenant allowed ingestion burst size (in bytes). Write requests bigger than the burst size are always rejected. 0 to use the ingestion bytes rate limit as burst size.")
 	f.BoolVar(&l.AcceptHASamples, "distributor.ha-tracker.enable-for-all-users", false, "Flag to enable, for all tenants, handling of samples with external labels identifying replicas in an HA Prometheus setup.")
 	f.StringVar(&l.HAClusterLabel, "distributor.ha-tracker.cluster", "cluster", "Prometheus label to look for in samples to identify a Prometheus HA cluster.")
 	f.StringVar(&l.HAReplicaLabel, "distributor.ha-tracker.replica", "__replica__", "Prometheus label to look for in samples to identify a Prometheus HA replica.")
@@ -194,11 +358,20 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxLabelNameLength, maxLabelNameLengthFlag, 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, maxLabelValueLengthFlag, 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
 	f.IntVar(&l.MaxLabelNamesPerSeries, maxLabelNamesPerSeriesFlag, 30, "Maximum number of label names per series.")
-	f.IntVar(&l.MaxMetadataLength, maxMetadataLengthFlag, 1024, "Maximum length accepted for metric metadata. Metadata refers to Metric Name, HELP and UNIT. Longer metadata is dropped except for HELP which is truncated.")
+	f.IntVar(&l.MaxMetadataLength, maxMetadataLengthFlag, 1024, "Maximum length accepted for metric metadata. Metadata refers to Metric Name, HELP and UNIT. Longer metadata is dropped except for HELP, which is handled according to -"+metadataLengthPolicyFlag+".")
+	f.StringVar(&l.MetadataLengthPolicy, metadataLengthPolicyFlag, MetadataLengthPolicyTruncate, fmt.Sprintf("What to do with the metric metadata whose HELP is longer than -%s. Supported values are: %s (truncate the HELP to the maximum length), %s (reject the metadata). Metadata whose metric name or unit is longer than the limit is always rejected.", maxMetadataLengthFlag, MetadataLengthPolicyTruncate, MetadataLengthPolicyReject))
 	f.IntVar(&l.MaxNativeHistogramBuckets, maxNativeHistogramBucketsFlag, 0, "Maximum number of buckets per native histogram sample. 0 to disable the limit.")
 	_ = l.CreationGracePeriod.Set("10m")
+	f.StringVar(&l.NonMonotonicSamplesPolicy, nonMonotonicSamplesPolicyFlag, NonMonotonicSamplesPolicyAllow, fmt.Sprintf("What to do with the series whose samples timestamps are not monotonically increasing within a write request. Float samples and native histogram samples are checked separately. Supported values are: %s (forward the samples to the ingesters as they are, where the ones older than the previous sample of the series may be rejected as out-of-order), %s (sort the samples of the series by timestamp), %s (reject the series with an error reporting the first out-of-order sample).", NonMonotonicSamplesPolicyAllow, NonMonotonicSamplesPolicySort, NonMonotonicSamplesPolicyReject))
+	f.Var(&l.AllowedLabelNames, allowedLabelNamesFlag, "Comma-separated list of the label names allowed in the series received by the distributor, in addition to the metric name. An empty list allows all label names.")
+	f.Var(&l.RequiredLabelNames, requiredLabelNamesFlag, "Comma-separated list of the label names required in the series received by the distributor. A label with an empty value is missing.")
+	f.Var(&l.RequiredLabelNamesExempt, requiredLabelNamesExemptionsFlag, "Series selectors, like '{job=\"legacy\"}', matching the series exempted from the required label names of -"+requiredLabelNamesFlag+". This flag can be repeated to configure multiple selectors.")
+	f.StringVar(&l.LabelNamesPolicyAction, labelNamesPolicyActionFlag, LabelNamesPolicyActionDropSeries, fmt.Sprintf("What to do with the series violating the allowed and required label names of -%s and -%s. Supported values are: %s (drop the label names not allowed from the series, and drop the series missing a required label name), %s (drop the series), %s (reject the whole write request).", allowedLabelNamesFlag, requiredLabelNamesFlag, LabelNamesPolicyActionDropLabel, LabelNamesPolicyActionDropSeries, LabelNamesPolicyActionRejectRequest))
 	f.Var(&l.CreationGracePeriod, creationGracePeriodFlag, "Controls how far into the future incoming samples are accepted compared to the wall clock. Any sample with timestamp `t` will be rejected if `t > (now + validation.create-grace-period)`. Also used by query-frontend to avoid querying too far into the future. 0 to disable.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.BoolVar(&l.DualWriteEnabled, "distributor.dual-write-enabled", false, "Enable the replication of the tenant write requests to the second cluster configured with -distributor.dual-write.url.")
+	f.IntVar(&l.SpillQueueMaxBytes, "distributor.spill-queue-max-bytes", 0, "Maximum size, in bytes, of the tenant write requests queued on disk by each distributor while they can't be written to the ingesters, when the spill queue is enabled with -distributor.spill-queue.dir. 0 to not queue the tenant write requests.")
+	f.IntVar(&l.MaxExemplarsPerSeriesPerMinute, "distributor.max-exemplars-per-series-per-minute", 0, "Maximum number of exemplars accepted per series per minute by each distributor. Exceeding exemplars are discarded, while the samples of the series are ingested. 0 to disable the limit.")
 
 	f.IntVar(&l.MaxGlobalSeriesPerUser, MaxSeriesPerUserFlag, 150000, "The maximum number of in-memory series per tenant, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, MaxSeriesPerMetricFlag, 0, "The maximum number of in-memory series per metric name, across the cluster before replication. 0 to disable.")
@@ -210,12 +383,23 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", fmt.Sprintf("Non-zero value enables out-of-order support for most recent samples that are within the time window in relation to the TSDB's maximum time, i.e., within [db.maxTime-timeWindow, db.maxTime]). The ingester will need more memory as a factor of rate of out-of-order samples being ingested and the number of series that are getting out-of-order samples. If query falls into this window, cached results will use value from -%s option to specify TTL for resulting cache entry.", resultsCacheTTLForOutOfOrderWindowFlag))
 	f.BoolVar(&l.NativeHistogramsIngestionEnabled, "ingester.native-histograms-ingestion-enabled", false, "Enable ingestion of native histogram samples. If false, native histogram samples are ignored without an error. To query native histograms with query-sharding enabled make sure to set -query-frontend.query-result-response-format to 'protobuf'.")
 	f.BoolVar(&l.OutOfOrderBlocksExternalLabelEnabled, "ingester.out-of-order-blocks-external-label-enabled", false, "Whether the shipper should label out-of-order blocks with an external label before uploading them. Setting this label will compact out-of-order blocks separately from non-out-of-order blocks")
+	f.IntVar(&l.SamplesPerChunk, "ingester.samples-per-chunk", DefaultSamplesPerChunk, fmt.Sprintf("Target number of float samples per TSDB chunk. Larger chunks compress better and reduce the long-term storage size of tenants with stable series, at the cost of more memory used by the ingesters. The value must be between %d and %d. The value is applied when the tenant's TSDB is opened, so a change takes effect for an existing tenant after the ingesters are restarted.", MinSamplesPerChunk, MaxSamplesPerChunk))
+
+	f.Var(&l.EphemeralSeriesSelectors, "ingester.ephemeral-series-selectors", "Series selectors, like '{job=\"ci\"}', matching the series to store as ephemeral series. Ephemeral series are kept only in the ingesters memory for the period configured by -blocks-storage.tsdb.ephemeral-series-retention-period, can be queried, but are never compacted into blocks or shipped to the long-term storage. Ephemeral series count towards the series limits. This flag can be repeated to configure multiple selectors.")
+	f.IntVar(&l.HeadEarlyCompactionSeriesBytes, "ingester.head-early-compaction-series-bytes-threshold", 0, "If greater than 0, the in-memory TSDB head of the tenant is compacted into blocks ahead of the regular compaction when the labels of its in-memory series exceed this number of bytes in an ingester. The early compaction only compacts the samples older than half of the block range, on boundaries aligned to a quarter of the block range, so that the samples keep being ingested while compacting and the blocks don't span across the block ranges. Once compacted early, the head isn't compacted early again until its series drop below the threshold. Once compacted, samples older than the head min time are rejected unless out-of-order ingestion is enabled. 0 to disable.")
+
+	f.Var(&l.IngesterFaultInjectionPushLatency, "ingester.fault-injection-push-latency", "Latency added to each write request of the tenant received by the ingesters, when the fault injection is enabled with -ingester.fault-injection-enabled. 0 to disable.")
+	f.Float64Var(&l.IngesterFaultInjectionPushErrorRate, "ingester.fault-injection-push-error-rate", 0, "Ratio, between 0 and 1, of the write requests of the tenant failed on purpose by the ingesters with a 5xx error, when the fault injection is enabled with -ingester.fault-injection-enabled. 0 to disable.")
+	f.Var(&l.IngesterFaultInjectionQueryLatency, "ingester.fault-injection-query-latency", "Latency added to each query of the tenant received by the ingesters, when the fault injection is enabled with -ingester.fault-injection-enabled. 0 to disable.")
+	f.Float64Var(&l.IngesterFaultInjectionQueryErrorRate, "ingester.fault-injection-query-error-rate", 0, "Ratio, between 0 and 1, of the queries of the tenant failed on purpose by the ingesters with a 5xx error, when the fault injection is enabled with -ingester.fault-injection-enabled. 0 to disable.")
 
 	f.StringVar(&l.SeparateMetricsGroupLabel, "validation.separate-metrics-group-label", "", "Label used to define the group label for metrics separation. For each write request, the group is obtained from the first non-empty group label from the first timeseries in the incoming list of timeseries. Specific distributor and ingester metrics will be further separated adding a 'group' label with group label's value. Currently applies to the following metrics: cortex_discarded_samples_total")
+	f.IntVar(&l.DiscardedSamplesExamplesPerReason, "validation.discarded-samples-examples-per-reason", 0, "Number of most recent examples of discarded series, with the timestamp of the discarded sample, kept by distributors and ingesters for each discard reason. The tenant can fetch the examples from the distributor and ingester discarded samples endpoints. 0 to disable.")
 
 	f.IntVar(&l.MaxChunksPerQuery, MaxChunksPerQueryFlag, 2e6, "Maximum number of chunks that can be fetched in a single query from ingesters and long-term storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, MaxSeriesPerQueryFlag, 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, MaxChunkBytesPerQueryFlag, 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler. 0 to disable.")
+	f.IntVar(&l.MaxSamplesPerQuery, "querier.max-samples-per-query", 0, "Maximum number of samples a single query can load into memory in the PromQL engine. This limit is enforced in the querier and overrides -querier.max-samples for the tenant. 0 to use -querier.max-samples.")
 	f.Var(&l.MaxPartialQueryLength, maxPartialQueryLengthFlag, "Limit the time range for partial queries at the querier level.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split (by time) or partial (by shard) queries that will be scheduled in parallel by the query-frontend for a single input query. This limit is introduced to have a fairer query scheduling and avoid a single query over a large time range saturating all available queriers.")
@@ -223,6 +407,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.LabelNamesAndValuesResultsMaxSizeBytes, "querier.label-names-and-values-results-max-size-bytes", 400*1024*1024, "Maximum size in bytes of distinct label names and values. When querier receives response from ingester, it merges the response with responses from other ingesters. This maximum size limit is applied to the merged(distinct) results. If the limit is reached, an error is returned.")
 	f.BoolVar(&l.CardinalityAnalysisEnabled, "querier.cardinality-analysis-enabled", false, "Enables endpoints used for cardinality analysis.")
 	f.IntVar(&l.LabelValuesMaxCardinalityLabelNamesPerRequest, "querier.label-values-max-cardinality-label-names-per-request", 100, "Maximum number of label names allowed to be queried in a single /api/v1/cardinality/label_values API call.")
+	f.IntVar(&l.ActiveSeriesResultsMaxSizeBytes, "querier.active-series-results-max-size-bytes", 400*1024*1024, "Maximum size in bytes of the distinct active series returned by a single /api/v1/cardinality/active_series API call. If the limit is reached, an error is returned.")
+	f.IntVar(&l.PaginatedLabelsMaxLimit, "querier.paginated-labels-max-limit", 10000, "Maximum number of label names or values returned by each page of the /api/v1/paginated/labels and /api/v1/paginated/label/{name}/values API calls. The requests without a limit use this limit. 0 disables the paginated labels API.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "query-frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
 
@@ -230,9 +416,11 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.QueryShardingTotalShards, "query-frontend.query-sharding-total-shards", 16, "The amount of shards to use when doing parallelisation via query sharding by tenant. 0 to disable query sharding for tenant. Query sharding implementation will adjust the number of query shards based on compactor shards. This allows querier to not search the blocks which cannot possibly have the series for given query shard.")
 	f.IntVar(&l.QueryShardingMaxShardedQueries, "query-frontend.query-sharding-max-sharded-queries", 128, "The max number of sharded queries that can be run for a given received query. 0 to disable limit.")
 	f.IntVar(&l.QueryShardingMaxRegexpSizeBytes, "query-frontend.query-sharding-max-regexp-size-bytes", 4096, "Disable query sharding for any query containing a regular expression matcher longer than the configured number of bytes. 0 to disable the limit.")
+	f.Float64Var(&l.QueryShardingTopKOversampling, "query-frontend.query-sharding-topk-oversampling-factor", 0, "If greater than 0, topk and bottomk aggregations are sharded too. The ones over sum, count, min and max aggregations are approximated by selecting the number of elements requested by the query multiplied by this factor in each shard, and the responses of the approximated queries include a warning. Must be 0 or greater than or equal to 1. 0 to disable.")
 	f.Var(&l.SplitInstantQueriesByInterval, "query-frontend.split-instant-queries-by-interval", "Split instant queries by an interval and execute in parallel. 0 to disable it.")
 	_ = l.QueryIngestersWithin.Set("13h")
 	f.Var(&l.QueryIngestersWithin, QueryIngestersWithinFlag, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
+	f.BoolVar(&l.NativeHistogramsAsClassicBucketsEnabled, "querier.native-histograms-as-classic-buckets-enabled", false, "When a selector of classic histogram buckets (a metric name with the _bucket suffix) matches no series, but a native histogram with the same name without the suffix exists, synthesize the classic histogram bucket series from the native histogram at query time. The query response is annotated with a warning when the buckets have been synthesized.")
 
 	_ = l.RulerEvaluationDelay.Set("1m")
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed.")
@@ -242,6 +430,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.RulerRecordingRulesEvaluationEnabled, "ruler.recording-rules-evaluation-enabled", true, "Controls whether recording rules evaluation is enabled. This configuration option can be used to forcefully disable recording rules evaluation on a per-tenant basis.")
 	f.BoolVar(&l.RulerAlertingRulesEvaluationEnabled, "ruler.alerting-rules-evaluation-enabled", true, "Controls whether alerting rules evaluation is enabled. This configuration option can be used to forcefully disable alerting rules evaluation on a per-tenant basis.")
 	f.BoolVar(&l.RulerSyncRulesOnChangesEnabled, "ruler.sync-rules-on-changes-enabled", true, "True to enable a re-sync of the configured rule groups as soon as they're changed via ruler's config API. This re-sync is in addition of the periodic syncing. When enabled, it may take up to few tens of seconds before a configuration change triggers the re-sync.")
+	f.IntVar(&l.RulerMaxConcurrentRuleGroups, "ruler.max-concurrent-rule-groups-per-tenant", 0, "Maximum number of rule groups of a tenant that can be evaluated concurrently by a ruler. Rule groups whose evaluation is due when the limit is reached are queued and evaluated in order of arrival. 0 to disable.")
+	f.StringVar(&l.RulerNotificationQueueOverflowPolicy, "ruler.notification-queue-overflow-policy", RulerNotificationQueueOverflowPolicyDropOldest, fmt.Sprintf("What to do with alert notifications which don't fit in the notification queue. Supported values are: %s (drop the oldest notifications in the queue), %s (store the notifications which don't fit in the queue, and the ones which can't be delivered to the Alertmanager after retries, in the notifications dead-letter; requires -ruler.notifications-dead-letter.enabled=true).", RulerNotificationQueueOverflowPolicyDropOldest, RulerNotificationQueueOverflowPolicyDeadLetter))
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. Also used by query-frontend to avoid querying beyond the retention period. 0 to disable.")
 	f.IntVar(&l.CompactorSplitAndMergeShards, "compactor.split-and-merge-shards", 0, "The number of shards to use when splitting blocks. 0 to disable splitting.")
@@ -261,10 +451,22 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.ResultsCacheTTLForOutOfOrderTimeWindow.Set("10m")
 	f.Var(&l.ResultsCacheTTLForOutOfOrderTimeWindow, resultsCacheTTLForOutOfOrderWindowFlag, fmt.Sprintf("Time to live duration for cached query results if query falls into out-of-order time window. This is lower than -%s so that incoming out-of-order samples are returned in the query results sooner.", resultsCacheTTLFlag))
 	f.Var(&l.ResultsCacheTTLForCardinalityQuery, "query-frontend.results-cache-ttl-for-cardinality-query", "Time to live duration for cached cardinality query results. The value 0 disables the cache.")
+	f.Var(&l.ResultsCacheSlidingTTLMax, "query-frontend.results-cache-sliding-ttl-max", fmt.Sprintf("If greater than -%s, cached query results get a time to live duration equal to the age of their most recent data when cached, bounded between -%s and this value, so that the results of older data are cached for longer. Results falling into the out-of-order time window aren't affected. The value 0 disables the sliding time to live.", resultsCacheTTLFlag, resultsCacheTTLFlag))
 	f.IntVar(&l.MaxQueryExpressionSizeBytes, maxQueryExpressionSizeBytesFlag, 0, "Max size of the raw query, in bytes. 0 to not apply a limit to the size of the query.")
+	f.Var(&l.MaxQueryExecutionTime, "query-frontend.max-query-execution-time", "Maximum time a query can take to execute, from when it's received by the query-frontend. Once elapsed, the query is abandoned by the query-frontend, and the time left to execute it is propagated to the query-scheduler, queriers, ingesters and store-gateways, so that they stop working on it too. 0 to disable.")
+	l.QueryRetryErrorClasses = []string{QueryErrorClassNetwork, QueryErrorClassInternal}
+	f.Var(&l.QueryRetryErrorClasses, "query-frontend.retry-error-classes", fmt.Sprintf("Comma-separated list of the classes of the downstream errors the query-frontend retries queries on. Supported values are: %s. The %s class includes the errors caused by the query itself, which can never succeed when retried.", strings.Join(QueryErrorClasses, ", "), QueryErrorClassBadData))
+	f.BoolVar(&l.FuseStepMisalignedQueries, "query-frontend.fuse-step-misaligned-queries", false, "Align the start and end of range queries to their step, and run concurrent range queries which are identical once aligned only once, sharing the results. This reduces the load caused by dashboards auto-refreshed by many clients, whose queries only differ by a jitter of the start and end smaller than the step.")
+
+	f.BoolVar(&l.ExtendedQuerySyntaxEnabled, "query-frontend.extended-query-syntax-enabled", false, "Enable the extended query syntax in range and instant queries: the $__interval, $__interval_ms, $__range, $__range_ms and $__range_s variables, resolved from the step and time range of range queries, and the arithmetic between durations and numbers, like [5m + $__interval], in ranges and subqueries. The query-frontend rewrites the queries to standard PromQL before running them.")
+	f.BoolVar(&l.FederateEndpointEnabled, "query-frontend.federate-endpoint-enabled", false, "Enable the Prometheus-compatible /federate endpoint in the query-frontend, which returns the latest samples of the series matching the match[] selectors in the text exposition format, for scrapers federating from Prometheus.")
+	f.IntVar(&l.FederateMaxSeries, "query-frontend.federate-max-series", 10000, "Maximum number of series a request to the /federate endpoint can return. 0 = no limit.")
+	f.StringVar(&l.PassthroughURL, "query-frontend.passthrough-url", "", "URL of a Prometheus-compatible endpoint the query-frontend proxies the requests to the Prometheus HTTP API paths not implemented by Mimir to, for example while migrating from a system still holding some of the data. The path of the request following the Prometheus HTTP prefix is appended to the URL. Empty to disable.")
+	f.Var(&l.ResultsCacheTTLForPassthrough, "query-frontend.results-cache-ttl-for-passthrough", "Time to live duration for the cached responses of the GET requests proxied to -query-frontend.passthrough-url. The value 0 disables the cache.")
 
 	// Store-gateway.
 	f.IntVar(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The tenant's shard size, used when store-gateway sharding is enabled. Value of 0 disables shuffle sharding for the tenant, that is all tenant blocks are sharded across all store-gateway replicas.")
+	f.BoolVar(&l.StoreGatewayLazyTenantLoadingEnabled, "store-gateway.lazy-tenant-loading-enabled", false, "True to not load the tenant's blocks when the store-gateway starts and syncs blocks, but only once the tenant is queried. The blocks are unloaded once the tenant isn't queried for longer than -blocks-storage.bucket-store.lazy-tenants-idle-timeout. Useful for tenants which are rarely queried.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
@@ -308,6 +510,8 @@ func (l *Limits) unmarshal(decode func(any) error) error {
 		*l = *defaultLimits
 		// Make copy of default limits, otherwise unmarshalling would modify map in default limits.
 		l.copyNotificationIntegrationLimits(defaultLimits.NotificationRateLimitPerIntegration)
+		l.copyQueryAccessPolicies(defaultLimits.QueryAccessPolicies)
+		l.copyRulerRuleGroupTemplateVariables(defaultLimits.RulerRuleGroupTemplateVariables)
 	}
 
 	// Decode into a reflection-crafted struct that has fields for the extensions.
@@ -336,9 +540,145 @@ func (l *Limits) validate() error {
 		}
 	}
 
+	for _, rule := range l.LabelValueNormalizationRules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range l.AggregationRules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateIngestionQuotas(l.IngestionQuotas); err != nil {
+		return err
+	}
+
+	if err := validateHALabelPairs(l.HALabelPairs); err != nil {
+		return err
+	}
+
+	if err := l.compileRequiredLabelNamesExemptions(); err != nil {
+		return err
+	}
+
+	for _, selector := range l.EphemeralSeriesSelectors {
+		if _, err := parser.ParseMetricSelector(selector); err != nil {
+			return fmt.Errorf("invalid ephemeral series selector %q: %w", selector, err)
+		}
+	}
+
+	if l.QueryShardingTopKOversampling != 0 && l.QueryShardingTopKOversampling < 1 {
+		return fmt.Errorf("the query sharding topk oversampling factor must be 0 or greater than or equal to 1")
+	}
+
+	if l.HeadEarlyCompactionSeriesBytes < 0 {
+		return fmt.Errorf("the head early compaction series bytes threshold must not be negative")
+	}
+
+	if l.PaginatedLabelsMaxLimit < 0 {
+		return fmt.Errorf("the paginated labels max limit must not be negative")
+	}
+
+	if l.IngesterFaultInjectionPushErrorRate < 0 || l.IngesterFaultInjectionPushErrorRate > 1 {
+		return fmt.Errorf("the ingester fault injection push error rate must be between 0 and 1")
+	}
+	if l.IngesterFaultInjectionQueryErrorRate < 0 || l.IngesterFaultInjectionQueryErrorRate > 1 {
+		return fmt.Errorf("the ingester fault injection query error rate must be between 0 and 1")
+	}
+
+	switch l.NonMonotonicSamplesPolicy {
+	case "", NonMonotonicSamplesPolicyAllow, NonMonotonicSamplesPolicySort, NonMonotonicSamplesPolicyReject:
+	default:
+		return fmt.Errorf("invalid non-monotonic samples policy %q", l.NonMonotonicSamplesPolicy)
+	}
+
+	switch l.LabelNamesPolicyAction {
+	case "", LabelNamesPolicyActionDropLabel, LabelNamesPolicyActionDropSeries, LabelNamesPolicyActionRejectRequest:
+	default:
+		return fmt.Errorf("invalid label names policy action %q", l.LabelNamesPolicyAction)
+	}
+
+	switch l.SeriesShardingScheme {
+	case "", SeriesShardingSchemeV1, SeriesShardingSchemeV2:
+	default:
+		return fmt.Errorf("invalid series sharding scheme %q", l.SeriesShardingScheme)
+	}
+
+	switch l.SeriesShardingMigration {
+	case "", SeriesShardingSchemeV1, SeriesShardingSchemeV2:
+	default:
+		return fmt.Errorf("invalid series sharding migration scheme %q", l.SeriesShardingMigration)
+	}
+
+	switch l.MetadataLengthPolicy {
+	case "", MetadataLengthPolicyTruncate, MetadataLengthPolicyReject:
+	default:
+		return fmt.Errorf("invalid metadata length policy %q", l.MetadataLengthPolicy)
+	}
+
+	for subUser, policy := range l.QueryAccessPolicies {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("sub-user %q: %w", subUser, err)
+		}
+	}
+
+	if l.PassthroughURL != "" {
+		if u, err := url.Parse(l.PassthroughURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid passthrough URL %q: must be an absolute http or https URL", l.PassthroughURL)
+		}
+	}
+
+	for _, class := range l.QueryRetryErrorClasses {
+		if !slices.Contains(QueryErrorClasses, class) {
+			return fmt.Errorf("invalid query retry error class %q", class)
+		}
+	}
+
+	switch l.RulerNotificationQueueOverflowPolicy {
+	case "", RulerNotificationQueueOverflowPolicyDropOldest, RulerNotificationQueueOverflowPolicyDeadLetter:
+	default:
+		return fmt.Errorf("invalid ruler notification queue overflow policy %q", l.RulerNotificationQueueOverflowPolicy)
+	}
+
+	if err := l.RulerAlertmanagerClientConfig.validate(); err != nil {
+		return err
+	}
+
+	for name := range l.RulerRuleGroupTemplateVariables {
+		if !ruleGroupTemplateVariableNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid ruler rule group template variable name %q", name)
+		}
+		if _, ok := reservedRuleGroupTemplateVariables[name]; ok {
+			return fmt.Errorf("ruler rule group template variable name %q is reserved for the alerting rules templates", name)
+		}
+	}
+
 	return nil
 }
 
+func (l *Limits) copyQueryAccessPolicies(defaults map[string]QueryAccessPolicy) {
+	if defaults == nil {
+		return
+	}
+	l.QueryAccessPolicies = make(map[string]QueryAccessPolicy, len(defaults))
+	for k, v := range defaults {
+		l.QueryAccessPolicies[k] = v
+	}
+}
+
+func (l *Limits) copyRulerRuleGroupTemplateVariables(defaults map[string]string) {
+	if defaults == nil {
+		return
+	}
+	l.RulerRuleGroupTemplateVariables = make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		l.RulerRuleGroupTemplateVariables[k] = v
+	}
+}
+
 func (l *Limits) copyNotificationIntegrationLimits(defaults NotificationRateLimitMap) {
 	l.NotificationRateLimitPerIntegration = make(map[string]float64, len(defaults))
 	for k, v := range defaults {
@@ -375,8 +715,27 @@ type Overrides struct {
 	tenantLimits  TenantLimits
 }
 
+// compileRequiredLabelNamesExemptions parses the required label names exemption selectors once, so that they don't
+// have to be parsed on every push.
+func (l *Limits) compileRequiredLabelNamesExemptions() error {
+	var matchers [][]*labels.Matcher
+	for _, selector := range l.RequiredLabelNamesExempt {
+		m, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return fmt.Errorf("invalid required label names exemption selector %q: %w", selector, err)
+		}
+		matchers = append(matchers, m)
+	}
+	l.requiredLabelNamesExemptMatchers = matchers
+	return nil
+}
+
 // NewOverrides makes a new Overrides.
 func NewOverrides(defaults Limits, tenantLimits TenantLimits) (*Overrides, error) {
+	// The default limits may have been set by the flags only, which aren't validated when parsed.
+	if err := defaults.compileRequiredLabelNamesExemptions(); err != nil {
+		return nil, err
+	}
 	return &Overrides{
 		tenantLimits:  tenantLimits,
 		defaultLimits: &defaults,
@@ -403,20 +762,46 @@ func (o *Overrides) LabelNamesAndValuesResultsMaxSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).LabelNamesAndValuesResultsMaxSizeBytes
 }
 
+// NativeHistogramsAsClassicBucketsEnabled returns whether classic histogram buckets are synthesized
+// from native histograms at query time, when the queried classic histogram buckets don't exist.
+func (o *Overrides) NativeHistogramsAsClassicBucketsEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).NativeHistogramsAsClassicBucketsEnabled
+}
+
 func (o *Overrides) CardinalityAnalysisEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).CardinalityAnalysisEnabled
 }
 
+// ActiveSeriesResultsMaxSizeBytes returns the maximum size in bytes of the distinct active series of an active series request.
+func (o *Overrides) ActiveSeriesResultsMaxSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).ActiveSeriesResultsMaxSizeBytes
+}
+
 // LabelValuesMaxCardinalityLabelNamesPerRequest returns the maximum number of label names per cardinality request.
 func (o *Overrides) LabelValuesMaxCardinalityLabelNamesPerRequest(userID string) int {
 	return o.getOverridesForUser(userID).LabelValuesMaxCardinalityLabelNamesPerRequest
 }
 
+// PaginatedLabelsMaxLimit returns the maximum number of label names or values of each page of the paginated labels API.
+func (o *Overrides) PaginatedLabelsMaxLimit(userID string) int {
+	return o.getOverridesForUser(userID).PaginatedLabelsMaxLimit
+}
+
 // IngestionBurstSize returns the burst size for ingestion rate.
 func (o *Overrides) IngestionBurstSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionBurstSize
 }
 
+// IngestionBytesRate returns the limit on ingestion rate (uncompressed bytes per second).
+func (o *Overrides) IngestionBytesRate(userID string) float64 {
+	return o.getOverridesForUser(userID).IngestionBytesRate
+}
+
+// IngestionBytesBurstSize returns the burst size for ingestion bytes rate.
+func (o *Overrides) IngestionBytesBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).IngestionBytesBurstSize
+}
+
 // AcceptHASamples returns whether the distributor should track and accept samples from HA replicas for this user.
 func (o *Overrides) AcceptHASamples(userID string) bool {
 	return o.getOverridesForUser(userID).AcceptHASamples
@@ -432,6 +817,11 @@ func (o *Overrides) HAReplicaLabel(userID string) string {
 	return o.getOverridesForUser(userID).HAReplicaLabel
 }
 
+// HALabelPairs returns the HA label pairs identifying the levels of the nested HA topology of a given user.
+func (o *Overrides) HALabelPairs(userID string) []HALabelPair {
+	return o.getOverridesForUser(userID).HALabelPairs
+}
+
 // DropLabels returns the list of labels to be dropped when ingesting HA samples for the user.
 func (o *Overrides) DropLabels(userID string) flagext.StringSlice {
 	return o.getOverridesForUser(userID).DropLabels
@@ -459,6 +849,12 @@ func (o *Overrides) MaxMetadataLength(userID string) int {
 	return o.getOverridesForUser(userID).MaxMetadataLength
 }
 
+// MetadataLengthPolicy returns what to do with the metadata whose HELP is longer
+// than the max metadata length.
+func (o *Overrides) MetadataLengthPolicy(userID string) string {
+	return o.getOverridesForUser(userID).MetadataLengthPolicy
+}
+
 // MaxNativeHistogramBuckets returns the maximum number of buckets per native
 // histogram sample.
 func (o *Overrides) MaxNativeHistogramBuckets(userID string) int {
@@ -471,6 +867,33 @@ func (o *Overrides) CreationGracePeriod(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).CreationGracePeriod)
 }
 
+// NonMonotonicSamplesPolicy returns what to do with the series whose samples timestamps are not monotonically
+// increasing within a write request.
+func (o *Overrides) NonMonotonicSamplesPolicy(userID string) string {
+	return o.getOverridesForUser(userID).NonMonotonicSamplesPolicy
+}
+
+// AllowedLabelNames returns the label names allowed in the series, in addition to the metric name.
+// All label names are allowed if empty.
+func (o *Overrides) AllowedLabelNames(userID string) []string {
+	return o.getOverridesForUser(userID).AllowedLabelNames
+}
+
+// RequiredLabelNames returns the label names required in the series.
+func (o *Overrides) RequiredLabelNames(userID string) []string {
+	return o.getOverridesForUser(userID).RequiredLabelNames
+}
+
+// RequiredLabelNamesExemptionMatchers returns the matchers of the series exempted from the required label names.
+func (o *Overrides) RequiredLabelNamesExemptionMatchers(userID string) [][]*labels.Matcher {
+	return o.getOverridesForUser(userID).requiredLabelNamesExemptMatchers
+}
+
+// LabelNamesPolicyAction returns what to do with the series violating the allowed and required label names.
+func (o *Overrides) LabelNamesPolicyAction(userID string) string {
+	return o.getOverridesForUser(userID).LabelNamesPolicyAction
+}
+
 // MaxGlobalSeriesPerUser returns the maximum number of series a user is allowed to store across the cluster.
 func (o *Overrides) MaxGlobalSeriesPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalSeriesPerUser
@@ -497,6 +920,12 @@ func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQuery
 }
 
+// MaxSamplesPerQuery returns the maximum number of samples a query can load into memory in the
+// PromQL engine. 0 means the engine's default applies.
+func (o *Overrides) MaxSamplesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxSamplesPerQuery
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLookback)
@@ -517,6 +946,31 @@ func (o *Overrides) MaxQueryExpressionSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueryExpressionSizeBytes
 }
 
+// QueryRetryErrorClasses returns the classes of the downstream errors the query-frontend retries queries on.
+func (o *Overrides) QueryRetryErrorClasses(userID string) []string {
+	return o.getOverridesForUser(userID).QueryRetryErrorClasses
+}
+
+// ExtendedQuerySyntaxEnabled returns whether the query-frontend supports the extended query syntax for the tenant.
+func (o *Overrides) ExtendedQuerySyntaxEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).ExtendedQuerySyntaxEnabled
+}
+
+// QueryAccessPolicies returns the query access policies of the sub-users of the tenant.
+func (o *Overrides) QueryAccessPolicies(userID string) map[string]QueryAccessPolicy {
+	return o.getOverridesForUser(userID).QueryAccessPolicies
+}
+
+// MaxQueryExecutionTime returns the maximum time a query can take to execute, from when it's received by the query-frontend.
+func (o *Overrides) MaxQueryExecutionTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxQueryExecutionTime)
+}
+
+// FuseStepMisalignedQueries returns whether range queries should be aligned to their step, and concurrent identical queries fused.
+func (o *Overrides) FuseStepMisalignedQueries(userID string) bool {
+	return o.getOverridesForUser(userID).FuseStepMisalignedQueries
+}
+
 // MaxLabelsQueryLength returns the limit of the length (in time) of a label names or values request.
 func (o *Overrides) MaxLabelsQueryLength(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxLabelsQueryLength)
@@ -558,6 +1012,12 @@ func (o *Overrides) QueryShardingMaxRegexpSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).QueryShardingMaxRegexpSizeBytes
 }
 
+// QueryShardingTopKOversamplingFactor returns the factor applied to the number of elements selected by the
+// sharded topk and bottomk aggregations. 0 if topk and bottomk aggregations shouldn't be sharded.
+func (o *Overrides) QueryShardingTopKOversamplingFactor(userID string) float64 {
+	return o.getOverridesForUser(userID).QueryShardingTopKOversampling
+}
+
 // SplitInstantQueriesByInterval returns the split time interval to use when splitting an instant query
 // via the query-frontend. 0 to disable limit.
 func (o *Overrides) SplitInstantQueriesByInterval(userID string) time.Duration {
@@ -585,6 +1045,11 @@ func (o *Overrides) MaxGlobalMetadataPerMetric(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalMetadataPerMetric
 }
 
+// MaxExemplarsPerSeriesPerMinute returns the maximum number of exemplars accepted per series per minute by each distributor.
+func (o *Overrides) MaxExemplarsPerSeriesPerMinute(userID string) int {
+	return o.getOverridesForUser(userID).MaxExemplarsPerSeriesPerMinute
+}
+
 // MaxGlobalExemplarsPerUser returns the maximum number of exemplars held in memory across the cluster.
 func (o *Overrides) MaxGlobalExemplarsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalExemplarsPerUser
@@ -599,6 +1064,42 @@ func (o *Overrides) OutOfOrderTimeWindow(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).OutOfOrderTimeWindow)
 }
 
+// IngesterFaultInjectionPushLatency returns the latency injected in the write requests of the user received by the ingesters.
+func (o *Overrides) IngesterFaultInjectionPushLatency(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngesterFaultInjectionPushLatency)
+}
+
+// IngesterFaultInjectionPushErrorRate returns the ratio of the write requests of the user failed on purpose by the ingesters.
+func (o *Overrides) IngesterFaultInjectionPushErrorRate(userID string) float64 {
+	return o.getOverridesForUser(userID).IngesterFaultInjectionPushErrorRate
+}
+
+// IngesterFaultInjectionQueryLatency returns the latency injected in the queries of the user received by the ingesters.
+func (o *Overrides) IngesterFaultInjectionQueryLatency(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngesterFaultInjectionQueryLatency)
+}
+
+// IngesterFaultInjectionQueryErrorRate returns the ratio of the queries of the user failed on purpose by the ingesters.
+func (o *Overrides) IngesterFaultInjectionQueryErrorRate(userID string) float64 {
+	return o.getOverridesForUser(userID).IngesterFaultInjectionQueryErrorRate
+}
+
+// SamplesPerChunk returns the target number of float samples per TSDB chunk for the user.
+func (o *Overrides) SamplesPerChunk(userID string) int {
+	return o.getOverridesForUser(userID).SamplesPerChunk
+}
+
+// HeadEarlyCompactionSeriesBytes returns the number of bytes of the labels of the user's in-memory series
+// in an ingester above which the user's TSDB head is compacted early. 0 if disabled.
+func (o *Overrides) HeadEarlyCompactionSeriesBytes(userID string) int {
+	return o.getOverridesForUser(userID).HeadEarlyCompactionSeriesBytes
+}
+
+// EphemeralSeriesSelectors returns the series selectors matching the series to store as ephemeral series for the user.
+func (o *Overrides) EphemeralSeriesSelectors(userID string) []string {
+	return o.getOverridesForUser(userID).EphemeralSeriesSelectors
+}
+
 // OutOfOrderBlocksExternalLabelEnabled returns if the shipper is flagging out-of-order blocks with an external label.
 func (o *Overrides) OutOfOrderBlocksExternalLabelEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).OutOfOrderBlocksExternalLabelEnabled
@@ -609,6 +1110,22 @@ func (o *Overrides) SeparateMetricsGroupLabel(userID string) string {
 	return o.getOverridesForUser(userID).SeparateMetricsGroupLabel
 }
 
+// DiscardedSamplesExamplesPerReason returns the number of examples of discarded series to keep for each discard reason.
+func (o *Overrides) DiscardedSamplesExamplesPerReason(userID string) int {
+	return o.getOverridesForUser(userID).DiscardedSamplesExamplesPerReason
+}
+
+// SeriesShardingScheme returns the scheme used to compute the ring token of the series of a given user.
+func (o *Overrides) SeriesShardingScheme(userID string) string {
+	return o.getOverridesForUser(userID).SeriesShardingScheme
+}
+
+// SeriesShardingMigrationScheme returns the scheme used to compute the second ring token of the series of a given
+// user while migrating between sharding schemes. Empty if the user isn't migrating.
+func (o *Overrides) SeriesShardingMigrationScheme(userID string) string {
+	return o.getOverridesForUser(userID).SeriesShardingMigration
+}
+
 // IngestionTenantShardSize returns the ingesters shard size for a given user.
 func (o *Overrides) IngestionTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionTenantShardSize
@@ -673,11 +1190,46 @@ func (o *Overrides) CompactorBlockUploadMaxBlockSizeBytes(userID string) int64 {
 	return o.getOverridesForUser(userID).CompactorBlockUploadMaxBlockSizeBytes
 }
 
+// DualWriteEnabled returns whether the write requests of the user are replicated to the second cluster.
+func (o *Overrides) DualWriteEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).DualWriteEnabled
+}
+
+// SpillQueueMaxBytes returns the maximum size of the write requests of the user queued on disk by each distributor.
+func (o *Overrides) SpillQueueMaxBytes(userID string) int {
+	return o.getOverridesForUser(userID).SpillQueueMaxBytes
+}
+
+// LabelValueNormalizationRules returns the label value normalization rules for a given user.
+func (o *Overrides) LabelValueNormalizationRules(userID string) []LabelValueNormalizationRule {
+	return o.getOverridesForUser(userID).LabelValueNormalizationRules
+}
+
+// AggregationRules returns the rules aggregating the series received by the distributor for a given user.
+func (o *Overrides) AggregationRules(userID string) []AggregationRule {
+	return o.getOverridesForUser(userID).AggregationRules
+}
+
+// IngestionQuotas returns the quotas capping the rate of the samples received for some series of a given user.
+func (o *Overrides) IngestionQuotas(userID string) []IngestionQuota {
+	return o.getOverridesForUser(userID).IngestionQuotas
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.getOverridesForUser(userID).MetricRelabelConfigs
 }
 
+// PushDebugReportEnabled returns whether the tenant is allowed to request a debug report of its write requests.
+func (o *Overrides) PushDebugReportEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).PushDebugReportEnabled
+}
+
+// InfluxIngestionEnabled returns whether the tenant is allowed to write samples in the Influx line protocol.
+func (o *Overrides) InfluxIngestionEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).InfluxIngestionEnabled
+}
+
 // NativeHistogramsIngestionEnabled returns whether to ingest native histograms in the ingester
 func (o *Overrides) NativeHistogramsIngestionEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).NativeHistogramsIngestionEnabled
@@ -713,6 +1265,32 @@ func (o *Overrides) RulerSyncRulesOnChangesEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).RulerSyncRulesOnChangesEnabled
 }
 
+// RulerMaxConcurrentRuleGroups returns the maximum number of rule groups that can be evaluated concurrently for a given user.
+func (o *Overrides) RulerMaxConcurrentRuleGroups(userID string) int {
+	return o.getOverridesForUser(userID).RulerMaxConcurrentRuleGroups
+}
+
+// RulerNotificationQueueOverflowPolicy returns the policy applied to the alert notifications which don't fit in the notification queue for a given user.
+func (o *Overrides) RulerNotificationQueueOverflowPolicy(userID string) string {
+	return o.getOverridesForUser(userID).RulerNotificationQueueOverflowPolicy
+}
+
+// RulerAlertmanagerClientConfig returns the configuration of the Alertmanager the ruler sends the alert notifications
+// of a given user to. The Alertmanager is not overridden for the user if the returned config is not enabled.
+func (o *Overrides) RulerAlertmanagerClientConfig(userID string) RulerAlertmanagerClientConfig {
+	return o.getOverridesForUser(userID).RulerAlertmanagerClientConfig
+}
+
+// RulerRuleGroupTemplateVariables returns the variables substituted into the rule groups of a given user.
+func (o *Overrides) RulerRuleGroupTemplateVariables(userID string) map[string]string {
+	return o.getOverridesForUser(userID).RulerRuleGroupTemplateVariables
+}
+
+// StoreGatewayLazyTenantLoadingEnabled returns whether the store-gateway should load the user's blocks only once queried.
+func (o *Overrides) StoreGatewayLazyTenantLoadingEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayLazyTenantLoadingEnabled
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).StoreGatewayTenantShardSize
@@ -834,6 +1412,32 @@ func (o *Overrides) ResultsCacheTTLForCardinalityQuery(user string) time.Duratio
 	return time.Duration(o.getOverridesForUser(user).ResultsCacheTTLForCardinalityQuery)
 }
 
+// ResultsCacheSlidingTTLMax returns the maximum TTL of cached results, when their TTL slides with the age of their data.
+func (o *Overrides) ResultsCacheSlidingTTLMax(user string) time.Duration {
+	return time.Duration(o.getOverridesForUser(user).ResultsCacheSlidingTTLMax)
+}
+
+// FederateEndpointEnabled returns whether the tenant can use the /federate endpoint of the query-frontend.
+func (o *Overrides) FederateEndpointEnabled(user string) bool {
+	return o.getOverridesForUser(user).FederateEndpointEnabled
+}
+
+// FederateMaxSeries returns the maximum number of series a request to the /federate endpoint can return.
+func (o *Overrides) FederateMaxSeries(user string) int {
+	return o.getOverridesForUser(user).FederateMaxSeries
+}
+
+// PassthroughURL returns the URL of the endpoint the query-frontend proxies the requests to the Prometheus HTTP API
+// paths not implemented by Mimir to. Empty if disabled.
+func (o *Overrides) PassthroughURL(user string) string {
+	return o.getOverridesForUser(user).PassthroughURL
+}
+
+// ResultsCacheTTLForPassthrough returns the TTL of the cached responses of the requests proxied to the PassthroughURL.
+func (o *Overrides) ResultsCacheTTLForPassthrough(user string) time.Duration {
+	return time.Duration(o.getOverridesForUser(user).ResultsCacheTTLForPassthrough)
+}
+
 func (o *Overrides) getOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.ByUserID(userID)
@@ -860,6 +1464,17 @@ func SmallestPositiveIntPerTenant(tenantIDs []string, f func(string) int) int {
 	return *result
 }
 
+// AllTrueBooleansPerTenant returns true only if the supplied limit function
+// returns true for all given tenants. It returns false if an empty tenant list is given.
+func AllTrueBooleansPerTenant(tenantIDs []string, f func(string) bool) bool {
+	for _, tenantID := range tenantIDs {
+		if !f(tenantID) {
+			return false
+		}
+	}
+	return len(tenantIDs) > 0
+}
+
 // SmallestPositiveNonZeroIntPerTenant is returning the minimal positive and
 // non-zero value of the supplied limit function for all given tenants. In many
 // limits a value of 0 means unlimited so the method will return 0 only if all
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -202,6 +203,36 @@ func TestSmallestPositiveIntPerTenant(t *testing.T) {
 	}
 }
 
+func TestAllTrueBooleansPerTenant(t *testing.T) {
+	tenantLimits := map[string]*Limits{
+		"tenant-a": {
+			FuseStepMisalignedQueries: true,
+		},
+		"tenant-b": {
+			FuseStepMisalignedQueries: true,
+		},
+	}
+
+	defaults := Limits{
+		FuseStepMisalignedQueries: false,
+	}
+	ov, err := NewOverrides(defaults, NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		tenantIDs []string
+		expected  bool
+	}{
+		{tenantIDs: []string{}, expected: false},
+		{tenantIDs: []string{"tenant-a"}, expected: true},
+		{tenantIDs: []string{"tenant-c"}, expected: false},
+		{tenantIDs: []string{"tenant-a", "tenant-b"}, expected: true},
+		{tenantIDs: []string{"tenant-a", "tenant-b", "tenant-c"}, expected: false},
+	} {
+		assert.Equal(t, tc.expected, AllTrueBooleansPerTenant(tc.tenantIDs, ov.FuseStepMisalignedQueries))
+	}
+}
+
 func TestSmallestPositiveNonZeroIntPerTenant(t *testing.T) {
 	tenantLimits := map[string]*Limits{
 		"tenant-a": {
@@ -650,6 +681,227 @@ metric_relabel_configs:
 	})
 }
 
+func TestUnmarshalRulerNotificationQueueOverflowPolicy(t *testing.T) {
+	for _, policy := range []string{RulerNotificationQueueOverflowPolicyDropOldest, RulerNotificationQueueOverflowPolicyDeadLetter} {
+		limits := Limits{}
+		require.NoError(t, yaml.Unmarshal([]byte("ruler_notification_queue_overflow_policy: "+policy), &limits))
+		assert.Equal(t, policy, limits.RulerNotificationQueueOverflowPolicy)
+	}
+
+	limits := Limits{}
+	err := yaml.Unmarshal([]byte("ruler_notification_queue_overflow_policy: unknown"), &limits)
+	require.ErrorContains(t, err, `invalid ruler notification queue overflow policy "unknown"`)
+}
+
+func TestUnmarshalRulerRuleGroupTemplateVariables(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`ruler_rule_group_template_variables: {cluster_list: "prod-1|prod-2", threshold: "0.5"}`), &limits))
+	assert.Equal(t, map[string]string{"cluster_list": "prod-1|prod-2", "threshold": "0.5"}, limits.RulerRuleGroupTemplateVariables)
+
+	limits = Limits{}
+	err := yaml.Unmarshal([]byte(`ruler_rule_group_template_variables: {"1st": "x"}`), &limits)
+	require.ErrorContains(t, err, `invalid ruler rule group template variable name "1st"`)
+
+	limits = Limits{}
+	err = yaml.Unmarshal([]byte(`ruler_rule_group_template_variables: {labels: "x"}`), &limits)
+	require.ErrorContains(t, err, `ruler rule group template variable name "labels" is reserved`)
+}
+
+func TestUnmarshalEphemeralSeriesSelectors(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`ephemeral_series_selectors: ['{job="ci"}', 'scratch_metric']`), &limits))
+	assert.Equal(t, []string{`{job="ci"}`, "scratch_metric"}, []string(limits.EphemeralSeriesSelectors))
+
+	limits = Limits{}
+	err := yaml.Unmarshal([]byte(`ephemeral_series_selectors: ['{job=}']`), &limits)
+	require.ErrorContains(t, err, `invalid ephemeral series selector "{job=}"`)
+}
+
+func TestUnmarshalRequiredLabelNamesExemptionSelectors(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`required_label_names_exemption_selectors: ['{job="legacy"}', 'up']`), &limits))
+	assert.Equal(t, []string{`{job="legacy"}`, "up"}, []string(limits.RequiredLabelNamesExempt))
+	assert.Equal(t, [][]*labels.Matcher{
+		{labels.MustNewMatcher(labels.MatchEqual, "job", "legacy")},
+		{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "up")},
+	}, limits.requiredLabelNamesExemptMatchers)
+
+	limits = Limits{}
+	err := yaml.Unmarshal([]byte(`required_label_names_exemption_selectors: ['{job=}']`), &limits)
+	require.ErrorContains(t, err, `invalid required label names exemption selector "{job=}"`)
+}
+
+func TestRequiredLabelNamesExemptionMatchers(t *testing.T) {
+	defaults := Limits{
+		RequiredLabelNamesExempt: []string{`{job="legacy"}`},
+	}
+
+	l := defaults
+	require.NoError(t, yaml.Unmarshal([]byte(`required_label_names_exemption_selectors: ['{job="ci"}']`), &l))
+	tenantLimits := map[string]*Limits{"user1": &l}
+
+	// The default selectors set by the flags are compiled when creating the overrides.
+	ov, err := NewOverrides(defaults, NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+	assert.Equal(t, [][]*labels.Matcher{{labels.MustNewMatcher(labels.MatchEqual, "job", "legacy")}}, ov.RequiredLabelNamesExemptionMatchers("user2"))
+	assert.Equal(t, [][]*labels.Matcher{{labels.MustNewMatcher(labels.MatchEqual, "job", "ci")}}, ov.RequiredLabelNamesExemptionMatchers("user1"))
+
+	// An invalid default selector set by the flags fails the creation of the overrides.
+	defaults.RequiredLabelNamesExempt = []string{`{job=}`}
+	_, err = NewOverrides(defaults, nil)
+	require.ErrorContains(t, err, `invalid required label names exemption selector "{job=}"`)
+}
+
+func TestUnmarshalQueryAccessPolicies(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+query_access_policies:
+  dev:
+    selector: '{namespace="dev"}'
+    max_query_lookback: 1d
+  ops: {}
+`), &limits))
+	assert.Equal(t, map[string]QueryAccessPolicy{
+		"dev": {Selector: `{namespace="dev"}`, MaxQueryLookback: model.Duration(24 * time.Hour)},
+		"ops": {},
+	}, limits.QueryAccessPolicies)
+
+	limits = Limits{}
+	err := yaml.Unmarshal([]byte(`query_access_policies: {dev: {selector: '{namespace=}'}}`), &limits)
+	require.ErrorContains(t, err, `sub-user "dev": invalid query access policy selector "{namespace=}"`)
+}
+
+func TestUnmarshalLabelValueNormalizationRules(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+label_value_normalization_rules:
+  - label: env
+    lowercase: true
+    strip_prefixes: [env-]
+    value_mappings: {prod: production}
+`), &limits))
+	require.Len(t, limits.LabelValueNormalizationRules, 1)
+
+	rule := limits.LabelValueNormalizationRules[0]
+	assert.Equal(t, "production", rule.Normalize("ENV-Prod"))
+	assert.Equal(t, "dev", rule.Normalize("env-dev"))
+	assert.Equal(t, "production", rule.Normalize("production"))
+
+	for yml, expectedErr := range map[string]string{
+		`label_value_normalization_rules: [{label: "in-valid", lowercase: true}]`:    `invalid label value normalization rule label "in-valid"`,
+		`label_value_normalization_rules: [{label: env}]`:                            `the label value normalization rule of the label "env" doesn't normalize anything`,
+		`label_value_normalization_rules: [{label: env, strip_prefixes: ["", "a"]}]`: `empty label value normalization rule prefix`,
+	} {
+		limits = Limits{}
+		require.ErrorContains(t, yaml.Unmarshal([]byte(yml), &limits), expectedErr)
+	}
+}
+
+func TestUnmarshalAggregationRules(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+aggregation_rules:
+  - match: '{__name__="http_requests_total"}'
+    drop_labels: [pod, instance]
+    aggregation: sum
+`), &limits))
+	require.Equal(t, []AggregationRule{{
+		Match:       `{__name__="http_requests_total"}`,
+		DropLabels:  []string{"pod", "instance"},
+		Aggregation: AggregationSum,
+	}}, limits.AggregationRules)
+
+	for yml, expectedErr := range map[string]string{
+		`aggregation_rules: [{match: "{job=}", drop_labels: [pod], aggregation: sum}]`:             `invalid aggregation rule selector "{job=}"`,
+		`aggregation_rules: [{match: "{job=\"a\"}", aggregation: sum}]`:                            `the aggregation rule "{job=\"a\"}" doesn't drop any label`,
+		`aggregation_rules: [{match: "{job=\"a\"}", drop_labels: [__name__], aggregation: sum}]`:   `the aggregation rules can't drop the metric name`,
+		`aggregation_rules: [{match: "{job=\"a\"}", drop_labels: ["in-valid"], aggregation: sum}]`: `invalid aggregation rule label "in-valid"`,
+		`aggregation_rules: [{match: "{job=\"a\"}", drop_labels: [pod], aggregation: max}]`:        `invalid aggregation "max" of the aggregation rule "{job=\"a\"}"`,
+	} {
+		limits = Limits{}
+		require.ErrorContains(t, yaml.Unmarshal([]byte(yml), &limits), expectedErr)
+	}
+}
+
+func TestUnmarshalIngestionQuotas(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+ingestion_quotas:
+  - name: noisy-team
+    match: '{namespace="noisy-team"}'
+    samples_per_second: 1000.5
+  - name: other-team
+    match: '{namespace="other-team"}'
+    samples_per_second: 1000
+    burst: 5000
+`), &limits))
+	require.Equal(t, []IngestionQuota{
+		{Name: "noisy-team", Match: `{namespace="noisy-team"}`, SamplesPerSecond: 1000.5},
+		{Name: "other-team", Match: `{namespace="other-team"}`, SamplesPerSecond: 1000, Burst: 5000},
+	}, limits.IngestionQuotas)
+	assert.Equal(t, 1001, limits.IngestionQuotas[0].BurstSize())
+	assert.Equal(t, 5000, limits.IngestionQuotas[1].BurstSize())
+
+	for yml, expectedErr := range map[string]string{
+		`ingestion_quotas: [{match: "{job=\"a\"}", samples_per_second: 1}]`:                                                                  `the ingestion quota name must not be empty`,
+		`ingestion_quotas: [{name: a, match: "{job=}", samples_per_second: 1}]`:                                                              `invalid selector "{job=}" of the ingestion quota "a"`,
+		`ingestion_quotas: [{name: a, match: "{job=\"a\"}"}]`:                                                                                `the samples per second of the ingestion quota "a" must be greater than 0`,
+		`ingestion_quotas: [{name: a, match: "{job=\"a\"}", samples_per_second: 1, burst: -1}]`:                                              `the burst of the ingestion quota "a" must not be negative`,
+		`ingestion_quotas: [{name: a, match: "{job=\"a\"}", samples_per_second: 1}, {name: a, match: "{job=\"b\"}", samples_per_second: 1}]`: `duplicate ingestion quota "a"`,
+	} {
+		limits = Limits{}
+		require.ErrorContains(t, yaml.Unmarshal([]byte(yml), &limits), expectedErr)
+	}
+}
+
+func TestUnmarshalHALabelPairs(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+ha_label_pairs:
+  - name: region
+    cluster_labels: [region]
+    replica_label: __region_replica__
+  - name: cluster
+    cluster_labels: [region, cluster]
+    replica_label: __replica__
+`), &limits))
+	require.Equal(t, []HALabelPair{
+		{Name: "region", ClusterLabels: []string{"region"}, ReplicaLabel: "__region_replica__"},
+		{Name: "cluster", ClusterLabels: []string{"region", "cluster"}, ReplicaLabel: "__replica__"},
+	}, limits.HALabelPairs)
+
+	for yml, expectedErr := range map[string]string{
+		`ha_label_pairs: [{cluster_labels: [cluster], replica_label: replica}]`:                                                                       `the HA label pair name must not be empty`,
+		`ha_label_pairs: [{name: a/b, cluster_labels: [cluster], replica_label: replica}]`:                                                            `the name of the HA label pair "a/b" must not contain '/'`,
+		`ha_label_pairs: [{name: a, replica_label: replica}]`:                                                                                         `the HA label pair "a" must have at least one cluster label`,
+		`ha_label_pairs: [{name: a, cluster_labels: ["1cluster"], replica_label: replica}]`:                                                           `invalid cluster label "1cluster" of the HA label pair "a"`,
+		`ha_label_pairs: [{name: a, cluster_labels: [cluster]}]`:                                                                                      `invalid replica label "" of the HA label pair "a"`,
+		`ha_label_pairs: [{name: a, cluster_labels: [cluster], replica_label: cluster}]`:                                                              `the replica label "cluster" of the HA label pair "a" must not be one of its cluster labels`,
+		`ha_label_pairs: [{name: a, cluster_labels: [cluster], replica_label: replica}, {name: a, cluster_labels: [region], replica_label: replica}]`: `duplicate HA label pair "a"`,
+	} {
+		limits = Limits{}
+		require.ErrorContains(t, yaml.Unmarshal([]byte(yml), &limits), expectedErr)
+	}
+}
+
+func TestUnmarshalIngesterFaultInjection(t *testing.T) {
+	limits := Limits{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+ingester_fault_injection_push_latency: 1s
+ingester_fault_injection_query_error_rate: 0.5
+`), &limits))
+	assert.Equal(t, model.Duration(time.Second), limits.IngesterFaultInjectionPushLatency)
+	assert.Equal(t, 0.5, limits.IngesterFaultInjectionQueryErrorRate)
+
+	for yml, expectedErr := range map[string]string{
+		`ingester_fault_injection_push_error_rate: 1.5`:   "the ingester fault injection push error rate must be between 0 and 1",
+		`ingester_fault_injection_query_error_rate: -0.1`: "the ingester fault injection query error rate must be between 0 and 1",
+	} {
+		limits = Limits{}
+		require.EqualError(t, yaml.Unmarshal([]byte(yml), &limits), expectedErr)
+	}
+}
+
 type structExtension struct {
 	Foo int `yaml:"foo" json:"foo"`
 }
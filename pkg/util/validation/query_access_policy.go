@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// QueryAccessPolicy restricts the series and the time range a sub-user of a tenant can query.
+type QueryAccessPolicy struct {
+	Selector         string         `yaml:"selector" json:"selector" doc:"nocli|description=Series selector, for example {namespace=\"dev\"}, whose label matchers are added to every selector of the queries run by the sub-user. If empty, the sub-user can query all the series of the tenant."`
+	MaxQueryLookback model.Duration `yaml:"max_query_lookback" json:"max_query_lookback" doc:"nocli|description=Limit how long back data can be queried by the sub-user, including the range and offset of the selectors of the queries. 0 to disable."`
+}
+
+// Matchers returns the label matchers of the policy selector.
+func (p QueryAccessPolicy) Matchers() ([]*labels.Matcher, error) {
+	if p.Selector == "" {
+		return nil, nil
+	}
+	return parser.ParseMetricSelector(p.Selector)
+}
+
+func (p QueryAccessPolicy) validate() error {
+	if _, err := p.Matchers(); err != nil {
+		return fmt.Errorf("invalid query access policy selector %q: %w", p.Selector, err)
+	}
+	return nil
+}
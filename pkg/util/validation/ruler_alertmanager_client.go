@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/dskit/dns"
+	config_util "github.com/prometheus/common/config"
+)
+
+// RulerAlertmanagerClientConfig configures the Alertmanager the ruler sends the alert notifications of a tenant to,
+// overriding the ruler-wide Alertmanager configuration.
+type RulerAlertmanagerClientConfig struct {
+	AlertmanagerURL string `yaml:"alertmanager_url" json:"alertmanager_url" doc:"nocli|description=Comma-separated list of URL(s) of the Alertmanager(s) to send the tenant's alert notifications to, instead of the ones configured with -ruler.alertmanager-url. Supports the same DNS service discovery format as -ruler.alertmanager-url. If empty, the ruler-wide Alertmanager configuration is used and the other options of this block are ignored." category:"experimental"`
+
+	TLSCertPath           string `yaml:"tls_cert_path" json:"tls_cert_path" doc:"nocli|description=Path to the client certificate, which will be used for authenticating with the tenant's Alertmanager. If set, tls_key_path must be set too." category:"experimental"`
+	TLSKeyPath            string `yaml:"tls_key_path" json:"tls_key_path" doc:"nocli|description=Path to the key for the client certificate." category:"experimental"`
+	TLSCAPath             string `yaml:"tls_ca_path" json:"tls_ca_path" doc:"nocli|description=Path to the CA certificates to validate the tenant's Alertmanager certificate. If not set, the host's root CA certificates are used." category:"experimental"`
+	TLSServerName         string `yaml:"tls_server_name" json:"tls_server_name" doc:"nocli|description=Override the expected name on the tenant's Alertmanager certificate." category:"experimental"`
+	TLSInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify" json:"tls_insecure_skip_verify" doc:"nocli|description=Skip validating the tenant's Alertmanager certificate." category:"experimental"`
+
+	OAuth2ClientID     string             `yaml:"oauth2_client_id" json:"oauth2_client_id" doc:"nocli|description=OAuth2 client ID. Enables the OAuth2 client credentials flow when set, which requires oauth2_token_url too." category:"experimental"`
+	OAuth2ClientSecret config_util.Secret `yaml:"oauth2_client_secret" json:"oauth2_client_secret" doc:"nocli|description=OAuth2 client secret." category:"experimental"`
+	OAuth2TokenURL     string             `yaml:"oauth2_token_url" json:"oauth2_token_url" doc:"nocli|description=Endpoint used to fetch the OAuth2 access token." category:"experimental"`
+	OAuth2Scopes       []string           `yaml:"oauth2_scopes" json:"oauth2_scopes" doc:"nocli|description=Optional scopes to include with the OAuth2 token request." category:"experimental"`
+}
+
+// IsEnabled returns whether the tenant has its own Alertmanager.
+func (cfg RulerAlertmanagerClientConfig) IsEnabled() bool {
+	return cfg.AlertmanagerURL != ""
+}
+
+// OAuth2Enabled returns whether the OAuth2 client credentials flow is enabled.
+func (cfg RulerAlertmanagerClientConfig) OAuth2Enabled() bool {
+	return cfg.OAuth2ClientID != ""
+}
+
+func (cfg RulerAlertmanagerClientConfig) validate() error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	for _, rawURL := range strings.Split(cfg.AlertmanagerURL, ",") {
+		qType, rawURL := dns.GetQTypeName(rawURL)
+		switch dns.QType(qType) {
+		case "", dns.A, dns.SRV, dns.SRVNoA:
+		default:
+			return fmt.Errorf("invalid DNS service discovery prefix %q in ruler Alertmanager URL", qType)
+		}
+
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid ruler Alertmanager URL %q: %w", rawURL, err)
+		}
+		if parsedURL.Host == "" {
+			return fmt.Errorf("improperly formatted ruler Alertmanager URL %q (maybe the scheme is missing?)", rawURL)
+		}
+		if parsedURL.User != nil && cfg.OAuth2Enabled() {
+			return fmt.Errorf("the ruler Alertmanager URL %q can't have basic authentication credentials when OAuth2 is enabled", parsedURL.Redacted())
+		}
+	}
+
+	if (cfg.TLSCertPath == "") != (cfg.TLSKeyPath == "") {
+		return fmt.Errorf("both the TLS certificate and key paths of the ruler Alertmanager client must be set")
+	}
+
+	if cfg.OAuth2Enabled() {
+		if cfg.OAuth2TokenURL == "" {
+			return fmt.Errorf("the OAuth2 token URL of the ruler Alertmanager client must be set when the OAuth2 client ID is set")
+		}
+		if _, err := url.Parse(cfg.OAuth2TokenURL); err != nil {
+			return fmt.Errorf("invalid OAuth2 token URL %q of the ruler Alertmanager client: %w", cfg.OAuth2TokenURL, err)
+		}
+	} else if cfg.OAuth2ClientSecret != "" || cfg.OAuth2TokenURL != "" {
+		return fmt.Errorf("the OAuth2 client ID of the ruler Alertmanager client must be set when the OAuth2 client secret or token URL are set")
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@
 package validation
 
 import (
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -13,8 +14,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/extract"
 	"github.com/grafana/mimir/pkg/util/globalerror"
 )
@@ -38,6 +41,9 @@ var (
 	reasonMaxNativeHistogramBuckets = metricReasonFromErrorID(globalerror.MaxNativeHistogramBuckets)
 	reasonDuplicateLabelNames       = metricReasonFromErrorID(globalerror.SeriesWithDuplicateLabelNames)
 	reasonTooFarInFuture            = metricReasonFromErrorID(globalerror.SampleTooFarInFuture)
+	reasonNonMonotonicTimestamps    = metricReasonFromErrorID(globalerror.SampleTimestampsNotMonotonic)
+	reasonLabelNameNotAllowed       = metricReasonFromErrorID(globalerror.LabelNameNotAllowed)
+	reasonMissingRequiredLabelName  = metricReasonFromErrorID(globalerror.MissingRequiredLabelName)
 
 	// Discarded exemplars reasons.
 	reasonExemplarLabelsMissing    = metricReasonFromErrorID(globalerror.ExemplarLabelsMissing)
@@ -45,15 +51,23 @@ var (
 	reasonExemplarTimestampInvalid = metricReasonFromErrorID(globalerror.ExemplarTimestampInvalid)
 	reasonExemplarLabelsBlank      = "exemplar_labels_blank"
 	reasonExemplarTooOld           = "exemplar_too_old"
+	reasonExemplarsPerSeriesLimit  = "exemplars_per_series_limit"
 
 	// Discarded metadata reasons.
 	reasonMetadataMetricNameTooLong = metricReasonFromErrorID(globalerror.MetricMetadataMetricNameTooLong)
+	reasonMetadataHelpTooLong       = metricReasonFromErrorID(globalerror.MetricMetadataHelpTooLong)
 	reasonMetadataUnitTooLong       = metricReasonFromErrorID(globalerror.MetricMetadataUnitTooLong)
 
 	// ReasonRateLimited is one of the values for the reason to discard samples.
 	// Declared here to avoid duplication in ingester and distributor.
 	ReasonRateLimited = "rate_limited" // same for request and ingestion which are separate errors, so not using metricReasonFromErrorID with global error
 
+	// ReasonBytesRateLimited is one of the values for the reason to discard requests.
+	ReasonBytesRateLimited = "bytes_rate_limited"
+
+	// ReasonIngestionQuotaExceeded is one of the reasons for discarding samples.
+	ReasonIngestionQuotaExceeded = "ingestion_quota_exceeded"
+
 	// ReasonTooManyHAClusters is one of the reasons for discarding samples.
 	ReasonTooManyHAClusters = "too_many_ha_clusters"
 )
@@ -124,6 +138,12 @@ type SampleValidationMetrics struct {
 	maxNativeHistogramBuckets *prometheus.CounterVec
 	duplicateLabelNames       *prometheus.CounterVec
 	tooFarInFuture            *prometheus.CounterVec
+	nonMonotonicTimestamps    *prometheus.CounterVec
+	labelNameNotAllowed       *prometheus.CounterVec
+	missingRequiredLabelName  *prometheus.CounterVec
+
+	// examples keeps examples of the discarded series. May be nil.
+	examples *DiscardedSamplesExamples
 }
 
 func (m *SampleValidationMetrics) DeleteUserMetrics(userID string) {
@@ -137,6 +157,9 @@ func (m *SampleValidationMetrics) DeleteUserMetrics(userID string) {
 	m.maxNativeHistogramBuckets.DeletePartialMatch(filter)
 	m.duplicateLabelNames.DeletePartialMatch(filter)
 	m.tooFarInFuture.DeletePartialMatch(filter)
+	m.nonMonotonicTimestamps.DeletePartialMatch(filter)
+	m.labelNameNotAllowed.DeletePartialMatch(filter)
+	m.missingRequiredLabelName.DeletePartialMatch(filter)
 }
 
 func (m *SampleValidationMetrics) DeleteUserMetricsForGroup(userID, group string) {
@@ -149,9 +172,14 @@ func (m *SampleValidationMetrics) DeleteUserMetricsForGroup(userID, group string
 	m.maxNativeHistogramBuckets.DeleteLabelValues(userID, group)
 	m.duplicateLabelNames.DeleteLabelValues(userID, group)
 	m.tooFarInFuture.DeleteLabelValues(userID, group)
+	m.nonMonotonicTimestamps.DeleteLabelValues(userID, group)
+	m.labelNameNotAllowed.DeleteLabelValues(userID, group)
+	m.missingRequiredLabelName.DeleteLabelValues(userID, group)
 }
 
-func NewSampleValidationMetrics(r prometheus.Registerer) *SampleValidationMetrics {
+// NewSampleValidationMetrics returns the metrics used by samples validation. The discarded series are recorded
+// as examples in the input DiscardedSamplesExamples, if not nil.
+func NewSampleValidationMetrics(r prometheus.Registerer, examples *DiscardedSamplesExamples) *SampleValidationMetrics {
 	return &SampleValidationMetrics{
 		missingMetricName:         DiscardedSamplesCounter(r, reasonMissingMetricName),
 		invalidMetricName:         DiscardedSamplesCounter(r, reasonInvalidMetricName),
@@ -162,6 +190,10 @@ func NewSampleValidationMetrics(r prometheus.Registerer) *SampleValidationMetric
 		maxNativeHistogramBuckets: DiscardedSamplesCounter(r, reasonMaxNativeHistogramBuckets),
 		duplicateLabelNames:       DiscardedSamplesCounter(r, reasonDuplicateLabelNames),
 		tooFarInFuture:            DiscardedSamplesCounter(r, reasonTooFarInFuture),
+		nonMonotonicTimestamps:    DiscardedSamplesCounter(r, reasonNonMonotonicTimestamps),
+		labelNameNotAllowed:       DiscardedSamplesCounter(r, reasonLabelNameNotAllowed),
+		missingRequiredLabelName:  DiscardedSamplesCounter(r, reasonMissingRequiredLabelName),
+		examples:                  examples,
 	}
 }
 
@@ -172,6 +204,7 @@ type ExemplarValidationMetrics struct {
 	labelsTooLong    *prometheus.CounterVec
 	labelsBlank      *prometheus.CounterVec
 	tooOld           *prometheus.CounterVec
+	perSeriesLimit   *prometheus.CounterVec
 }
 
 func (m *ExemplarValidationMetrics) DeleteUserMetrics(userID string) {
@@ -180,6 +213,7 @@ func (m *ExemplarValidationMetrics) DeleteUserMetrics(userID string) {
 	m.labelsTooLong.DeleteLabelValues(userID)
 	m.labelsBlank.DeleteLabelValues(userID)
 	m.tooOld.DeleteLabelValues(userID)
+	m.perSeriesLimit.DeleteLabelValues(userID)
 }
 
 func NewExemplarValidationMetrics(r prometheus.Registerer) *ExemplarValidationMetrics {
@@ -189,15 +223,23 @@ func NewExemplarValidationMetrics(r prometheus.Registerer) *ExemplarValidationMe
 		labelsTooLong:    DiscardedExemplarsCounter(r, reasonExemplarLabelsTooLong),
 		labelsBlank:      DiscardedExemplarsCounter(r, reasonExemplarLabelsBlank),
 		tooOld:           DiscardedExemplarsCounter(r, reasonExemplarTooOld),
+		perSeriesLimit:   DiscardedExemplarsCounter(r, reasonExemplarsPerSeriesLimit),
 	}
 }
 
+// DiscardedPerSeriesLimit tracks the exemplars discarded because the series exceeded the
+// maximum number of exemplars per series per minute.
+func (m *ExemplarValidationMetrics) DiscardedPerSeriesLimit(userID string, count int) {
+	m.perSeriesLimit.WithLabelValues(userID).Add(float64(count))
+}
+
 // ValidateSample returns an err if the sample is invalid.
 // The returned error may retain the provided series labels.
 // It uses the passed 'now' time to measure the relative time of the sample.
 func ValidateSample(m *SampleValidationMetrics, now model.Time, cfg SampleValidationConfig, userID, group string, ls []mimirpb.LabelAdapter, s mimirpb.Sample) ValidationError {
 	if model.Time(s.TimestampMs) > now.Add(cfg.CreationGracePeriod(userID)) {
 		m.tooFarInFuture.WithLabelValues(userID, group).Inc()
+		m.examples.Record(userID, reasonTooFarInFuture, ls, s.TimestampMs)
 		unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
 		return newSampleTimestampTooNewError(unsafeMetricName, s.TimestampMs)
 	}
@@ -211,6 +253,7 @@ func ValidateSample(m *SampleValidationMetrics, now model.Time, cfg SampleValida
 func ValidateSampleHistogram(m *SampleValidationMetrics, now model.Time, cfg SampleValidationConfig, userID, group string, ls []mimirpb.LabelAdapter, s mimirpb.Histogram) ValidationError {
 	if model.Time(s.Timestamp) > now.Add(cfg.CreationGracePeriod(userID)) {
 		m.tooFarInFuture.WithLabelValues(userID, group).Inc()
+		m.examples.Record(userID, reasonTooFarInFuture, ls, s.Timestamp)
 		unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
 		return newSampleTimestampTooNewError(unsafeMetricName, s.Timestamp)
 	}
@@ -224,6 +267,7 @@ func ValidateSampleHistogram(m *SampleValidationMetrics, now model.Time, cfg Sam
 		}
 		if bucketCount > bucketLimit {
 			m.maxNativeHistogramBuckets.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonMaxNativeHistogramBuckets, ls, s.Timestamp)
 			return newMaxNativeHistogramBucketsError(ls, s.Timestamp, bucketCount, bucketLimit)
 		}
 	}
@@ -231,6 +275,47 @@ func ValidateSampleHistogram(m *SampleValidationMetrics, now model.Time, cfg Sam
 	return nil
 }
 
+// ValidateMonotonicTimestamps returns an error if the timestamps of the float samples, or the ones of the
+// native histogram samples, of the series are not monotonically increasing.
+// The returned error may retain the provided series labels.
+func ValidateMonotonicTimestamps(m *SampleValidationMetrics, userID, group string, ls []mimirpb.LabelAdapter, samples []mimirpb.Sample, histograms []mimirpb.Histogram) ValidationError {
+	for i := 1; i < len(samples); i++ {
+		if samples[i].TimestampMs < samples[i-1].TimestampMs {
+			m.nonMonotonicTimestamps.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonNonMonotonicTimestamps, ls, samples[i].TimestampMs)
+			return newNonMonotonicTimestampsError(ls, "sample", i, samples[i].TimestampMs, samples[i-1].TimestampMs)
+		}
+	}
+
+	for i := 1; i < len(histograms); i++ {
+		if histograms[i].Timestamp < histograms[i-1].Timestamp {
+			m.nonMonotonicTimestamps.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonNonMonotonicTimestamps, ls, histograms[i].Timestamp)
+			return newNonMonotonicTimestampsError(ls, "histogram sample", i, histograms[i].Timestamp, histograms[i-1].Timestamp)
+		}
+	}
+
+	return nil
+}
+
+// SortSamplesByTimestamp sorts the float samples and the native histogram samples of a series by timestamp,
+// if they're not monotonically increasing already. Returns whether any sorting was required.
+func SortSamplesByTimestamp(samples []mimirpb.Sample, histograms []mimirpb.Histogram) bool {
+	sorted := false
+
+	if !sort.SliceIsSorted(samples, func(i, j int) bool { return samples[i].TimestampMs < samples[j].TimestampMs }) {
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].TimestampMs < samples[j].TimestampMs })
+		sorted = true
+	}
+
+	if !sort.SliceIsSorted(histograms, func(i, j int) bool { return histograms[i].Timestamp < histograms[j].Timestamp }) {
+		sort.SliceStable(histograms, func(i, j int) bool { return histograms[i].Timestamp < histograms[j].Timestamp })
+		sorted = true
+	}
+
+	return sorted
+}
+
 // ValidateExemplar returns an error if the exemplar is invalid.
 // The returned error may retain the provided series labels.
 func ValidateExemplar(m *ExemplarValidationMetrics, userID string, ls []mimirpb.LabelAdapter, e mimirpb.Exemplar) ValidationError {
@@ -292,6 +377,82 @@ func ExemplarTimestampOK(m *ExemplarValidationMetrics, userID string, minTS int6
 	return true
 }
 
+// LabelNamesPolicyConfig helps with getting the tenant's policy on the label names of the series.
+type LabelNamesPolicyConfig interface {
+	AllowedLabelNames(userID string) []string
+	RequiredLabelNames(userID string) []string
+	RequiredLabelNamesExemptionMatchers(userID string) [][]*labels.Matcher
+	LabelNamesPolicyAction(userID string) string
+}
+
+// ValidateLabelNamesPolicy returns an error if the series has a label name not allowed by the tenant, or misses a
+// label name required by the tenant. If the tenant's policy action is LabelNamesPolicyActionDropLabel, the label
+// names not allowed are dropped from the series instead, and their number is returned. The required label names are
+// not checked for the series exempted by the tenant, which are matched after the label names not allowed are dropped.
+// The returned error may retain the provided series labels.
+func ValidateLabelNamesPolicy(m *SampleValidationMetrics, cfg LabelNamesPolicyConfig, userID, group string, ts *mimirpb.PreallocTimeseries) (droppedLabels int, err ValidationError) {
+	if allowed := cfg.AllowedLabelNames(userID); len(allowed) > 0 {
+		dropLabels := cfg.LabelNamesPolicyAction(userID) == LabelNamesPolicyActionDropLabel
+
+		for i := 0; i < len(ts.Labels); {
+			name := ts.Labels[i].Name
+			if name == model.MetricNameLabel || util.StringsContain(allowed, name) {
+				i++
+				continue
+			}
+			if !dropLabels {
+				m.labelNameNotAllowed.WithLabelValues(userID, group).Inc()
+				m.examples.Record(userID, reasonLabelNameNotAllowed, ts.Labels, 0)
+				return droppedLabels, newLabelNameNotAllowedError(ts.Labels, name)
+			}
+
+			// Removing the label keeps the labels sorted, and clears the series bytes cached when unmarshalled.
+			ts.RemoveLabel(name)
+			droppedLabels++
+		}
+	}
+
+	required := cfg.RequiredLabelNames(userID)
+	if len(required) == 0 || matchesAnySelector(cfg.RequiredLabelNamesExemptionMatchers(userID), ts.Labels) {
+		return droppedLabels, nil
+	}
+
+	for _, required := range required {
+		found := false
+		for _, l := range ts.Labels {
+			if l.Name == required && l.Value != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.missingRequiredLabelName.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonMissingRequiredLabelName, ts.Labels, 0)
+			return droppedLabels, newMissingRequiredLabelNameError(ts.Labels, required)
+		}
+	}
+
+	return droppedLabels, nil
+}
+
+// matchesAnySelector returns whether the series with the input labels matches all the matchers of any of the selectors.
+func matchesAnySelector(selectors [][]*labels.Matcher, lbls []mimirpb.LabelAdapter) bool {
+	series := mimirpb.FromLabelAdaptersToLabels(lbls)
+	for _, matchers := range selectors {
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(series.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
 // LabelValidationConfig helps with getting required config to validate labels.
 type LabelValidationConfig interface {
 	MaxLabelNamesPerSeries(userID string) int
@@ -305,17 +466,20 @@ func ValidateLabels(m *SampleValidationMetrics, cfg LabelValidationConfig, userI
 	unsafeMetricName, err := extract.UnsafeMetricNameFromLabelAdapters(ls)
 	if err != nil {
 		m.missingMetricName.WithLabelValues(userID, group).Inc()
+		m.examples.Record(userID, reasonMissingMetricName, ls, 0)
 		return newNoMetricNameError()
 	}
 
 	if !model.IsValidMetricName(model.LabelValue(unsafeMetricName)) {
 		m.invalidMetricName.WithLabelValues(userID, group).Inc()
+		m.examples.Record(userID, reasonInvalidMetricName, ls, 0)
 		return newInvalidMetricNameError(unsafeMetricName)
 	}
 
 	numLabelNames := len(ls)
 	if numLabelNames > cfg.MaxLabelNamesPerSeries(userID) {
 		m.maxLabelNamesPerSeries.WithLabelValues(userID, group).Inc()
+		m.examples.Record(userID, reasonMaxLabelNamesPerSeries, ls, 0)
 		return newTooManyLabelsError(ls, cfg.MaxLabelNamesPerSeries(userID))
 	}
 
@@ -325,15 +489,19 @@ func ValidateLabels(m *SampleValidationMetrics, cfg LabelValidationConfig, userI
 	for _, l := range ls {
 		if !skipLabelNameValidation && !model.LabelName(l.Name).IsValid() {
 			m.invalidLabel.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonInvalidLabel, ls, 0)
 			return newInvalidLabelError(ls, l.Name)
 		} else if len(l.Name) > maxLabelNameLength {
 			m.labelNameTooLong.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonLabelNameTooLong, ls, 0)
 			return newLabelNameTooLongError(ls, l.Name)
 		} else if len(l.Value) > maxLabelValueLength {
 			m.labelValueTooLong.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonLabelValueTooLong, ls, 0)
 			return newLabelValueTooLongError(ls, l.Value)
 		} else if lastLabelName == l.Name {
 			m.duplicateLabelNames.WithLabelValues(userID, group).Inc()
+			m.examples.Record(userID, reasonDuplicateLabelNames, ls, 0)
 			return newDuplicatedLabelError(ls, l.Name)
 		}
 
@@ -346,20 +514,31 @@ func ValidateLabels(m *SampleValidationMetrics, cfg LabelValidationConfig, userI
 type MetadataValidationMetrics struct {
 	missingMetricName *prometheus.CounterVec
 	metricNameTooLong *prometheus.CounterVec
+	helpTooLong       *prometheus.CounterVec
 	unitTooLong       *prometheus.CounterVec
+
+	// helpTruncated is not a discard reason: the metadata is accepted with a truncated HELP.
+	helpTruncated *prometheus.CounterVec
 }
 
 func (m *MetadataValidationMetrics) DeleteUserMetrics(userID string) {
 	m.missingMetricName.DeleteLabelValues(userID)
 	m.metricNameTooLong.DeleteLabelValues(userID)
+	m.helpTooLong.DeleteLabelValues(userID)
 	m.unitTooLong.DeleteLabelValues(userID)
+	m.helpTruncated.DeleteLabelValues(userID)
 }
 
 func NewMetadataValidationMetrics(r prometheus.Registerer) *MetadataValidationMetrics {
 	return &MetadataValidationMetrics{
 		missingMetricName: DiscardedMetadataCounter(r, reasonMissingMetricName),
 		metricNameTooLong: DiscardedMetadataCounter(r, reasonMetadataMetricNameTooLong),
+		helpTooLong:       DiscardedMetadataCounter(r, reasonMetadataHelpTooLong),
 		unitTooLong:       DiscardedMetadataCounter(r, reasonMetadataUnitTooLong),
+		helpTruncated: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_truncated_metadata_total",
+			Help: "The total number of metadata whose HELP was truncated because longer than the max metadata length.",
+		}, []string{"user"}),
 	}
 }
 
@@ -367,6 +546,7 @@ func NewMetadataValidationMetrics(r prometheus.Registerer) *MetadataValidationMe
 type MetadataValidationConfig interface {
 	EnforceMetadataMetricName(userID string) bool
 	MaxMetadataLength(userID string) int
+	MetadataLengthPolicy(userID string) string
 }
 
 // CleanAndValidateMetadata returns an err if a metric metadata is invalid.
@@ -378,7 +558,19 @@ func CleanAndValidateMetadata(m *MetadataValidationMetrics, cfg MetadataValidati
 
 	maxMetadataValueLength := cfg.MaxMetadataLength(userID)
 
-	if len(metadata.Help) > maxMetadataValueLength {
+	var err error
+	if len(metadata.GetMetricFamilyName()) > maxMetadataValueLength {
+		m.metricNameTooLong.WithLabelValues(userID).Inc()
+		err = newMetadataMetricNameTooLongError(metadata)
+	} else if len(metadata.Unit) > maxMetadataValueLength {
+		m.unitTooLong.WithLabelValues(userID).Inc()
+		err = newMetadataUnitTooLongError(metadata)
+	} else if len(metadata.Help) > maxMetadataValueLength {
+		if cfg.MetadataLengthPolicy(userID) == MetadataLengthPolicyReject {
+			m.helpTooLong.WithLabelValues(userID).Inc()
+			return newMetadataHelpTooLongError(metadata)
+		}
+
 		newlen := 0
 		for idx := range metadata.Help {
 			if idx > maxMetadataValueLength {
@@ -387,15 +579,7 @@ func CleanAndValidateMetadata(m *MetadataValidationMetrics, cfg MetadataValidati
 			newlen = idx // idx is the index of the next character, making it the length of what comes before
 		}
 		metadata.Help = metadata.Help[:newlen]
-	}
-
-	var err error
-	if len(metadata.GetMetricFamilyName()) > maxMetadataValueLength {
-		m.metricNameTooLong.WithLabelValues(userID).Inc()
-		err = newMetadataMetricNameTooLongError(metadata)
-	} else if len(metadata.Unit) > maxMetadataValueLength {
-		m.unitTooLong.WithLabelValues(userID).Inc()
-		err = newMetadataUnitTooLongError(metadata)
+		m.helpTruncated.WithLabelValues(userID).Inc()
 	}
 
 	return err
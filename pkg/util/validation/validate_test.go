@@ -14,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -41,6 +42,7 @@ func (v validateLabelsCfg) MaxLabelValueLength(_ string) int {
 type validateMetadataCfg struct {
 	enforceMetadataMetricName bool
 	maxMetadataLength         int
+	metadataLengthPolicy      string
 }
 
 func (vm validateMetadataCfg) EnforceMetadataMetricName(_ string) bool {
@@ -51,9 +53,13 @@ func (vm validateMetadataCfg) MaxMetadataLength(_ string) int {
 	return vm.maxMetadataLength
 }
 
+func (vm validateMetadataCfg) MetadataLengthPolicy(_ string) string {
+	return vm.metadataLengthPolicy
+}
+
 func TestValidateLabels(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
-	s := NewSampleValidationMetrics(reg)
+	s := NewSampleValidationMetrics(reg, nil)
 
 	var cfg validateLabelsCfg
 	userID := "testUser"
@@ -302,7 +308,11 @@ func TestValidateMetadata(t *testing.T) {
 			cortex_discarded_metadata_total{reason="unit_too_long",user="testUser"} 1
 
 			cortex_discarded_metadata_total{reason="random reason",user="different user"} 1
-	`), "cortex_discarded_metadata_total"))
+
+			# HELP cortex_truncated_metadata_total The total number of metadata whose HELP was truncated because longer than the max metadata length.
+			# TYPE cortex_truncated_metadata_total counter
+			cortex_truncated_metadata_total{user="testUser"} 3
+	`), "cortex_discarded_metadata_total", "cortex_truncated_metadata_total"))
 
 	m.DeleteUserMetrics(userID)
 
@@ -310,7 +320,40 @@ func TestValidateMetadata(t *testing.T) {
 			# HELP cortex_discarded_metadata_total The total number of metadata that were discarded.
 			# TYPE cortex_discarded_metadata_total counter
 			cortex_discarded_metadata_total{reason="random reason",user="different user"} 1
-	`), "cortex_discarded_metadata_total"))
+	`), "cortex_discarded_metadata_total", "cortex_truncated_metadata_total"))
+}
+
+func TestValidateMetadata_RejectPolicy(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewMetadataValidationMetrics(reg)
+
+	userID := "testUser"
+	cfg := validateMetadataCfg{
+		enforceMetadataMetricName: true,
+		maxMetadataLength:         22,
+		metadataLengthPolicy:      MetadataLengthPolicyReject,
+	}
+
+	valid := &mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines", Type: mimirpb.COUNTER, Help: "Number of goroutines.", Unit: ""}
+	require.NoError(t, CleanAndValidateMetadata(m, cfg, userID, valid))
+	assert.Equal(t, "Number of goroutines.", valid.Help)
+
+	longHelp := &mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines", Type: mimirpb.COUNTER, Help: "Number of goroutines that currently exist.", Unit: ""}
+	err := CleanAndValidateMetadata(m, cfg, userID, longHelp)
+	assert.Equal(t, newMetadataHelpTooLongError(&mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines", Help: "Number of goroutines that currently exist."}), err)
+	assert.Equal(t, "Number of goroutines that currently exist.", longHelp.Help, "help should not be truncated")
+
+	// The metric name is checked before the HELP.
+	longNameAndHelp := &mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines_and_routines_and_routines", Type: mimirpb.COUNTER, Help: "Number of goroutines that currently exist.", Unit: ""}
+	err = CleanAndValidateMetadata(m, cfg, userID, longNameAndHelp)
+	assert.Equal(t, newMetadataMetricNameTooLongError(longNameAndHelp), err)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_discarded_metadata_total The total number of metadata that were discarded.
+			# TYPE cortex_discarded_metadata_total counter
+			cortex_discarded_metadata_total{reason="help_too_long",user="testUser"} 1
+			cortex_discarded_metadata_total{reason="metric_name_too_long",user="testUser"} 1
+	`), "cortex_discarded_metadata_total", "cortex_truncated_metadata_total"))
 }
 
 func TestValidateLabelDuplication(t *testing.T) {
@@ -321,7 +364,7 @@ func TestValidateLabelDuplication(t *testing.T) {
 
 	userID := "testUser"
 
-	actual := ValidateLabels(NewSampleValidationMetrics(nil), cfg, userID, "", []mimirpb.LabelAdapter{
+	actual := ValidateLabels(NewSampleValidationMetrics(nil, nil), cfg, userID, "", []mimirpb.LabelAdapter{
 		{Name: model.MetricNameLabel, Value: "a"},
 		{Name: model.MetricNameLabel, Value: "b"},
 	}, false)
@@ -331,7 +374,7 @@ func TestValidateLabelDuplication(t *testing.T) {
 	}, model.MetricNameLabel)
 	assert.Equal(t, expected, actual)
 
-	actual = ValidateLabels(NewSampleValidationMetrics(nil), cfg, userID, "", []mimirpb.LabelAdapter{
+	actual = ValidateLabels(NewSampleValidationMetrics(nil, nil), cfg, userID, "", []mimirpb.LabelAdapter{
 		{Name: model.MetricNameLabel, Value: "a"},
 		{Name: "a", Value: "a"},
 		{Name: "a", Value: "a"},
@@ -356,6 +399,211 @@ func (c sampleValidationConfig) MaxNativeHistogramBuckets(_ string) int {
 	return c.maxNativeHistogramBuckets
 }
 
+func TestValidateMonotonicTimestamps(t *testing.T) {
+	userID := "testUser"
+	ls := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}}
+
+	registry := prometheus.NewRegistry()
+	metrics := NewSampleValidationMetrics(registry, nil)
+
+	assert.NoError(t, ValidateMonotonicTimestamps(metrics, userID, "", ls, nil, nil))
+	assert.NoError(t, ValidateMonotonicTimestamps(metrics, userID, "", ls,
+		[]mimirpb.Sample{{TimestampMs: 1}, {TimestampMs: 2}, {TimestampMs: 2}},
+		[]mimirpb.Histogram{{Timestamp: 1}, {Timestamp: 3}}))
+
+	err := ValidateMonotonicTimestamps(metrics, userID, "", ls,
+		[]mimirpb.Sample{{TimestampMs: 1}, {TimestampMs: 3}, {TimestampMs: 2}}, nil)
+	assert.Equal(t, newNonMonotonicTimestampsError(ls, "sample", 2, 2, 3), err)
+	assert.Equal(t, `received a series whose sample timestamps are not monotonically increasing within the write request, the sample at index 2 has timestamp 2 which is older than the previous timestamp 3, series: '{__name__="foo"}' (err-mimir-sample-timestamps-not-monotonic). To adjust the related per-tenant limit, configure -validation.non-monotonic-samples-policy, or contact your service administrator.`, err.Error())
+
+	err = ValidateMonotonicTimestamps(metrics, userID, "", ls,
+		[]mimirpb.Sample{{TimestampMs: 1}},
+		[]mimirpb.Histogram{{Timestamp: 5}, {Timestamp: 4}})
+	assert.Equal(t, newNonMonotonicTimestampsError(ls, "histogram sample", 1, 4, 5), err)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+			# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+			# TYPE cortex_discarded_samples_total counter
+			cortex_discarded_samples_total{group="",reason="sample_timestamps_not_monotonic",user="testUser"} 2
+	`), "cortex_discarded_samples_total"))
+}
+
+type labelNamesPolicyCfg struct {
+	allowed    []string
+	required   []string
+	exemptions [][]*labels.Matcher
+	action     string
+}
+
+func (c labelNamesPolicyCfg) AllowedLabelNames(_ string) []string {
+	return c.allowed
+}
+
+func (c labelNamesPolicyCfg) RequiredLabelNames(_ string) []string {
+	return c.required
+}
+
+func (c labelNamesPolicyCfg) RequiredLabelNamesExemptionMatchers(_ string) [][]*labels.Matcher {
+	return c.exemptions
+}
+
+func (c labelNamesPolicyCfg) LabelNamesPolicyAction(_ string) string {
+	return c.action
+}
+
+func TestValidateLabelNamesPolicy(t *testing.T) {
+	userID := "testUser"
+	series := func(names ...string) []mimirpb.LabelAdapter {
+		ls := []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}}
+		for _, name := range names {
+			ls = append(ls, mimirpb.LabelAdapter{Name: name, Value: "value"})
+		}
+		return ls
+	}
+	exemption := func(name string) [][]*labels.Matcher {
+		return [][]*labels.Matcher{{labels.MustNewMatcher(labels.MatchEqual, name, "value")}}
+	}
+
+	tests := map[string]struct {
+		cfg                   labelNamesPolicyCfg
+		labels                []mimirpb.LabelAdapter
+		expectedLabels        []mimirpb.LabelAdapter
+		expectedDroppedLabels int
+		expectedErr           ValidationError
+	}{
+		"no policy": {
+			labels:         series("a", "b"),
+			expectedLabels: series("a", "b"),
+		},
+		"all label names allowed": {
+			cfg:            labelNamesPolicyCfg{allowed: []string{"a", "b", "c"}, action: LabelNamesPolicyActionDropSeries},
+			labels:         series("a", "b"),
+			expectedLabels: series("a", "b"),
+		},
+		"label name not allowed, drop series": {
+			cfg:            labelNamesPolicyCfg{allowed: []string{"a", "c"}, action: LabelNamesPolicyActionDropSeries},
+			labels:         series("a", "b"),
+			expectedLabels: series("a", "b"),
+			expectedErr:    newLabelNameNotAllowedError(series("a", "b"), "b"),
+		},
+		"label name not allowed, reject request": {
+			cfg:            labelNamesPolicyCfg{allowed: []string{"b"}, action: LabelNamesPolicyActionRejectRequest},
+			labels:         series("a", "b"),
+			expectedLabels: series("a", "b"),
+			expectedErr:    newLabelNameNotAllowedError(series("a", "b"), "a"),
+		},
+		"label names not allowed, drop label": {
+			cfg:                   labelNamesPolicyCfg{allowed: []string{"b"}, action: LabelNamesPolicyActionDropLabel},
+			labels:                series("a", "b", "c"),
+			expectedLabels:        series("b"),
+			expectedDroppedLabels: 2,
+		},
+		"required label names": {
+			cfg:            labelNamesPolicyCfg{required: []string{"a", "b"}, action: LabelNamesPolicyActionDropLabel},
+			labels:         series("a", "b", "c"),
+			expectedLabels: series("a", "b", "c"),
+		},
+		"missing required label name": {
+			cfg:            labelNamesPolicyCfg{required: []string{"a", "b"}, action: LabelNamesPolicyActionDropLabel},
+			labels:         series("a", "c"),
+			expectedLabels: series("a", "c"),
+			expectedErr:    newMissingRequiredLabelNameError(series("a", "c"), "b"),
+		},
+		"missing required label name, exempted series": {
+			cfg:            labelNamesPolicyCfg{required: []string{"a", "b"}, exemptions: exemption("c"), action: LabelNamesPolicyActionDropSeries},
+			labels:         series("a", "c"),
+			expectedLabels: series("a", "c"),
+		},
+		"label name not allowed, exempted series": {
+			cfg:            labelNamesPolicyCfg{allowed: []string{"a"}, required: []string{"a"}, exemptions: exemption("b"), action: LabelNamesPolicyActionDropSeries},
+			labels:         series("a", "b"),
+			expectedLabels: series("a", "b"),
+			expectedErr:    newLabelNameNotAllowedError(series("a", "b"), "b"),
+		},
+		"missing required label name, exemption matching a dropped label": {
+			cfg:                   labelNamesPolicyCfg{allowed: []string{"a"}, required: []string{"b"}, exemptions: exemption("c"), action: LabelNamesPolicyActionDropLabel},
+			labels:                series("a", "c"),
+			expectedLabels:        series("a"),
+			expectedDroppedLabels: 1,
+			expectedErr:           newMissingRequiredLabelNameError(series("a"), "b"),
+		},
+		"required label name with empty value": {
+			cfg:            labelNamesPolicyCfg{required: []string{"a"}, action: LabelNamesPolicyActionDropSeries},
+			labels:         []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}, {Name: "a"}},
+			expectedLabels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}, {Name: "a"}},
+			expectedErr:    newMissingRequiredLabelNameError([]mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}, {Name: "a"}}, "a"),
+		},
+		"required label name dropped because not allowed": {
+			cfg:                   labelNamesPolicyCfg{allowed: []string{"b"}, required: []string{"a"}, action: LabelNamesPolicyActionDropLabel},
+			labels:                series("a", "b"),
+			expectedLabels:        series("b"),
+			expectedDroppedLabels: 1,
+			expectedErr:           newMissingRequiredLabelNameError(series("b"), "a"),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			metrics := NewSampleValidationMetrics(prometheus.NewPedanticRegistry(), nil)
+
+			// Unmarshal the series, like the distributor does, so that the series bytes are cached.
+			data, err := (&mimirpb.TimeSeries{Labels: testData.labels}).Marshal()
+			require.NoError(t, err)
+			ts := &mimirpb.PreallocTimeseries{}
+			require.NoError(t, ts.Unmarshal(data))
+
+			droppedLabels, validationErr := ValidateLabelNamesPolicy(metrics, testData.cfg, userID, "", ts)
+			assert.Equal(t, testData.expectedErr, validationErr)
+			assert.Equal(t, testData.expectedDroppedLabels, droppedLabels)
+			assert.Equal(t, testData.expectedLabels, ts.Labels)
+
+			// The marshalled series has the labels left.
+			data, err = ts.Marshal()
+			require.NoError(t, err)
+			marshalled := mimirpb.TimeSeries{}
+			require.NoError(t, marshalled.Unmarshal(data))
+			assert.Equal(t, testData.expectedLabels, marshalled.Labels)
+		})
+	}
+}
+
+func TestValidateLabelNamesPolicy_Metrics(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	metrics := NewSampleValidationMetrics(registry, nil)
+	cfg := labelNamesPolicyCfg{allowed: []string{"a"}, required: []string{"a"}, action: LabelNamesPolicyActionDropSeries}
+
+	_, err := ValidateLabelNamesPolicy(metrics, cfg, "testUser", "", &mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+		Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}, {Name: "b", Value: "value"}},
+	}})
+	assert.Equal(t, `received a series with a label name not allowed, label: 'b' series: 'foo{b="value"}' (err-mimir-label-name-not-allowed). To adjust the related per-tenant limit, configure -validation.allowed-label-names, or contact your service administrator.`, err.Error())
+
+	_, err = ValidateLabelNamesPolicy(metrics, cfg, "testUser", "", &mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+		Labels: []mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}},
+	}})
+	assert.Equal(t, `received a series missing a required label name, label: 'a' series: 'foo' (err-mimir-missing-required-label-name). To adjust the related per-tenant limit, configure -validation.required-label-names, or contact your service administrator.`, err.Error())
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+			# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+			# TYPE cortex_discarded_samples_total counter
+			cortex_discarded_samples_total{group="",reason="label_name_not_allowed",user="testUser"} 1
+			cortex_discarded_samples_total{group="",reason="missing_required_label_name",user="testUser"} 1
+	`), "cortex_discarded_samples_total"))
+}
+
+func TestSortSamplesByTimestamp(t *testing.T) {
+	samples := []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}}
+	histograms := []mimirpb.Histogram{{Timestamp: 1}, {Timestamp: 2}}
+	assert.False(t, SortSamplesByTimestamp(samples, histograms))
+
+	samples = []mimirpb.Sample{{TimestampMs: 3, Value: 3}, {TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}, {TimestampMs: 1, Value: 4}}
+	assert.True(t, SortSamplesByTimestamp(samples, histograms))
+	assert.Equal(t, []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 4}, {TimestampMs: 2, Value: 2}, {TimestampMs: 3, Value: 3}}, samples)
+
+	histograms = []mimirpb.Histogram{{Timestamp: 2}, {Timestamp: 1}}
+	assert.True(t, SortSamplesByTimestamp(nil, histograms))
+	assert.Equal(t, []mimirpb.Histogram{{Timestamp: 1}, {Timestamp: 2}}, histograms)
+}
+
 func TestMaxNativeHistorgramBuckets(t *testing.T) {
 	// All will have 2 buckets, one negative and one positive
 	testCases := map[string]mimirpb.Histogram{
@@ -466,7 +714,7 @@ func TestMaxNativeHistorgramBuckets(t *testing.T) {
 	}
 
 	registry := prometheus.NewRegistry()
-	metrics := NewSampleValidationMetrics(registry)
+	metrics := NewSampleValidationMetrics(registry, nil)
 
 	for _, limit := range []int{0, 1, 2} {
 		for name, h := range testCases {
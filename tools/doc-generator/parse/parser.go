@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/util/fieldcategory"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 var (
@@ -347,6 +348,12 @@ func getFieldCustomType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
+	case reflect.TypeOf(map[string]validation.QueryAccessPolicy{}).String():
+		return "map of sub-user (string) to query access policy", true
+	case reflect.TypeOf([]validation.LabelValueNormalizationRule{}).String():
+		return "list of label value normalization rules", true
+	case reflect.TypeOf([]validation.AggregationRule{}).String():
+		return "list of aggregation rules", true
 	default:
 		return "", false
 	}
@@ -427,6 +434,12 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
+	case reflect.TypeOf(map[string]validation.QueryAccessPolicy{}).String():
+		return "map of sub-user (string) to query access policy", true
+	case reflect.TypeOf([]validation.LabelValueNormalizationRule{}).String():
+		return "list of label value normalization rules", true
+	case reflect.TypeOf([]validation.AggregationRule{}).String():
+		return "list of aggregation rules", true
 	default:
 		return "", false
 	}
@@ -460,6 +473,12 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf(map[string]float64{})
 	case "list of durations":
 		return reflect.TypeOf(tsdb.DurationList{})
+	case "map of sub-user (string) to query access policy":
+		return reflect.TypeOf(map[string]validation.QueryAccessPolicy{})
+	case "list of label value normalization rules":
+		return reflect.TypeOf([]validation.LabelValueNormalizationRule{})
+	case "list of aggregation rules":
+		return reflect.TypeOf([]validation.AggregationRule{})
 	default:
 		panic("unknown field type " + typ)
 	}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/frontend/queryrecorder"
+)
+
+type config struct {
+	recordingFile  string
+	targetURL      string
+	tenantID       string
+	speedFactor    float64
+	shiftTimeRange bool
+	timeout        time.Duration
+	topDiffs       int
+}
+
+type result struct {
+	query        queryrecorder.RecordedQuery
+	statusCode   int
+	responseTime time.Duration
+	err          error
+}
+
+func main() {
+	cfg := config{}
+	flag.StringVar(&cfg.recordingFile, "recording-file", "", "Path to the query recording file, as returned by the query-frontend query recordings API.")
+	flag.StringVar(&cfg.targetURL, "target-url", "", "Base URL of the Mimir cluster to replay the queries against (e.g. http://query-frontend:8080).")
+	flag.StringVar(&cfg.tenantID, "tenant-id", "", "Tenant ID to use when replaying the queries. Defaults to the tenant the queries have been recorded for.")
+	flag.Float64Var(&cfg.speedFactor, "speed-factor", 1, "Pacing of the replayed queries compared to the original pacing. 1 replays the queries with the original pacing, 2 replays them twice as fast. 0 replays all queries at once.")
+	flag.BoolVar(&cfg.shiftTimeRange, "shift-time-range", false, "True to shift the time range of the replayed queries by the time elapsed since the recording started. When disabled, the queries are replayed with the original time range, so that results are deterministic.")
+	flag.DurationVar(&cfg.timeout, "timeout", 2*time.Minute, "Timeout of each replayed query.")
+	flag.IntVar(&cfg.topDiffs, "top-diffs", 10, "Number of queries with the largest latency increase to report.")
+	flag.Parse()
+
+	if cfg.recordingFile == "" || cfg.targetURL == "" {
+		log.Fatalln("both -recording-file and -target-url are required")
+	}
+	if cfg.speedFactor < 0 {
+		log.Fatalln("-speed-factor must be greater than or equal to 0")
+	}
+
+	rec, err := readRecording(cfg.recordingFile)
+	if err != nil {
+		log.Fatalln("failed to read recording:", err)
+	}
+	if cfg.tenantID == "" {
+		cfg.tenantID = rec.TenantID
+	}
+
+	log.Printf("replaying %d queries of recording %s against %s", len(rec.Queries), rec.ID, cfg.targetURL)
+	results := replay(context.Background(), cfg, rec)
+	report(os.Stdout, cfg, results)
+}
+
+func readRecording(path string) (*queryrecorder.Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rec := &queryrecorder.Recording{}
+	if err := json.NewDecoder(f).Decode(rec); err != nil {
+		return nil, err
+	}
+
+	// Replay queries in the order they've been received.
+	sort.SliceStable(rec.Queries, func(i, j int) bool {
+		return rec.Queries[i].OffsetMillis < rec.Queries[j].OffsetMillis
+	})
+	return rec, nil
+}
+
+// replay issues all recorded queries against the target, honoring the configured pacing.
+func replay(ctx context.Context, cfg config, rec *queryrecorder.Recording) []result {
+	client := &http.Client{Timeout: cfg.timeout}
+	results := make([]result, len(rec.Queries))
+	replayStart := time.Now()
+	shift := replayStart.Sub(rec.StartTime)
+
+	wg := sync.WaitGroup{}
+	for i, q := range rec.Queries {
+		if delay := replayOffset(q, cfg.speedFactor) - time.Since(replayStart); delay > 0 {
+			time.Sleep(delay)
+		}
+
+		params := q.Params
+		if cfg.shiftTimeRange {
+			params = shiftTimeParams(params, shift)
+		}
+
+		wg.Add(1)
+		go func(i int, q queryrecorder.RecordedQuery, params url.Values) {
+			defer wg.Done()
+			results[i] = execute(ctx, client, cfg, q, params)
+		}(i, q, params)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// replayOffset returns when the query should be replayed, relative to the start of the replay.
+// A speed factor of 0 replays all queries at once.
+func replayOffset(q queryrecorder.RecordedQuery, speedFactor float64) time.Duration {
+	if speedFactor == 0 {
+		return 0
+	}
+	return time.Duration(float64(q.OffsetMillis) * float64(time.Millisecond) / speedFactor)
+}
+
+func execute(ctx context.Context, client *http.Client, cfg config, q queryrecorder.RecordedQuery, params url.Values) result {
+	res := result{query: q}
+
+	var req *http.Request
+	var err error
+	target := strings.TrimSuffix(cfg.targetURL, "/") + q.Path
+	if q.Method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, q.Method, target+"?"+params.Encode(), nil)
+	}
+	if err != nil {
+		res.err = err
+		return res
+	}
+	req.Header.Set("X-Scope-OrgID", cfg.tenantID)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.err = err
+		res.responseTime = time.Since(start)
+		return res
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	res.responseTime = time.Since(start)
+	res.statusCode = resp.StatusCode
+	return res
+}
+
+// shiftTimeParams returns a copy of the input params with the time parameters shifted by the input duration.
+func shiftTimeParams(params url.Values, shift time.Duration) url.Values {
+	shifted := make(url.Values, len(params))
+	for name, values := range params {
+		shifted[name] = append([]string(nil), values...)
+	}
+
+	for _, name := range []string{"start", "end", "time"} {
+		value := shifted.Get(name)
+		if value == "" {
+			continue
+		}
+		if ts, err := strconv.ParseFloat(value, 64); err == nil {
+			shifted.Set(name, strconv.FormatFloat(ts+shift.Seconds(), 'f', -1, 64))
+		} else if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			shifted.Set(name, ts.Add(shift).Format(time.RFC3339Nano))
+		}
+	}
+
+	return shifted
+}
+
+func report(w io.Writer, cfg config, results []result) {
+	var (
+		originalLatencies []float64
+		replayLatencies   []float64
+		transportErrors   int
+		statusMismatches  int
+		originalByClass   = map[string]int{}
+		replayByClass     = map[string]int{}
+	)
+
+	for _, res := range results {
+		originalLatencies = append(originalLatencies, res.query.ResponseTimeMillis)
+		originalByClass[statusClass(res.query.StatusCode)]++
+
+		if res.err != nil {
+			transportErrors++
+			replayByClass["error"]++
+			continue
+		}
+
+		replayLatencies = append(replayLatencies, float64(res.responseTime)/float64(time.Millisecond))
+		replayByClass[statusClass(res.statusCode)]++
+		if res.statusCode != res.query.StatusCode {
+			statusMismatches++
+		}
+	}
+
+	fmt.Fprintf(w, "Replayed queries: %d\n", len(results))
+	fmt.Fprintf(w, "Transport errors: %d\n", transportErrors)
+	fmt.Fprintf(w, "Status code mismatches: %d\n\n", statusMismatches)
+
+	fmt.Fprintf(w, "%-10s %10s %10s\n", "status", "original", "replay")
+	for _, class := range []string{"2xx", "4xx", "5xx", "other", "error"} {
+		if originalByClass[class] == 0 && replayByClass[class] == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%-10s %10d %10d\n", class, originalByClass[class], replayByClass[class])
+	}
+
+	fmt.Fprintf(w, "\n%-10s %14s %14s\n", "latency", "original (ms)", "replay (ms)")
+	for _, p := range []float64{50, 90, 99, 100} {
+		fmt.Fprintf(w, "%-10s %14.1f %14.1f\n", fmt.Sprintf("p%v", p), percentile(originalLatencies, p), percentile(replayLatencies, p))
+	}
+
+	// Report the queries with the largest latency increase.
+	diffs := make([]result, 0, len(results))
+	for _, res := range results {
+		if res.err == nil {
+			diffs = append(diffs, res)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return latencyDiff(diffs[i]) > latencyDiff(diffs[j])
+	})
+	if len(diffs) > cfg.topDiffs {
+		diffs = diffs[:cfg.topDiffs]
+	}
+
+	if len(diffs) > 0 {
+		fmt.Fprintf(w, "\nQueries with the largest latency increase:\n")
+		for _, res := range diffs {
+			fmt.Fprintf(w, "%+10.1fms  %s %s?%s (status %d -> %d)\n", latencyDiff(res), res.query.Method, res.query.Path, res.query.Params.Encode(), res.query.StatusCode, res.statusCode)
+		}
+	}
+}
+
+func latencyDiff(res result) float64 {
+	return float64(res.responseTime)/float64(time.Millisecond) - res.query.ResponseTimeMillis
+}
+
+func statusClass(code int) string {
+	switch {
+	case code >= 200 && code < 300:
+		return "2xx"
+	case code >= 400 && code < 500:
+		return "4xx"
+	case code >= 500 && code < 600:
+		return "5xx"
+	default:
+		return "other"
+	}
+}
+
+// percentile returns the p-th percentile of the input values using the nearest-rank method.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
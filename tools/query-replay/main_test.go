@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/frontend/queryrecorder"
+)
+
+func TestReplayOffset(t *testing.T) {
+	tests := map[string]struct {
+		offsetMillis   int64
+		speedFactor    float64
+		expectedOffset time.Duration
+	}{
+		"original pacing": {
+			offsetMillis:   1500,
+			speedFactor:    1,
+			expectedOffset: 1500 * time.Millisecond,
+		},
+		"accelerated pacing": {
+			offsetMillis:   1500,
+			speedFactor:    3,
+			expectedOffset: 500 * time.Millisecond,
+		},
+		"slowed down pacing": {
+			offsetMillis:   1500,
+			speedFactor:    0.5,
+			expectedOffset: 3 * time.Second,
+		},
+		"all queries at once": {
+			offsetMillis:   1500,
+			speedFactor:    0,
+			expectedOffset: 0,
+		},
+		"first query": {
+			offsetMillis:   0,
+			speedFactor:    2,
+			expectedOffset: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := queryrecorder.RecordedQuery{OffsetMillis: tc.offsetMillis}
+			assert.Equal(t, tc.expectedOffset, replayOffset(q, tc.speedFactor))
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		received []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		mtx.Lock()
+		received = append(received, r.Method+" "+r.Header.Get("X-Scope-OrgID")+" "+r.Form.Get("query"))
+		mtx.Unlock()
+
+		if r.Form.Get("query") == "invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	rec := &queryrecorder.Recording{
+		TenantID: "user-1",
+		Queries: []queryrecorder.RecordedQuery{
+			{OffsetMillis: 0, Method: http.MethodGet, Path: "/api/v1/query", Params: url.Values{"query": []string{"up"}}, StatusCode: http.StatusOK},
+			{OffsetMillis: 100, Method: http.MethodPost, Path: "/api/v1/query", Params: url.Values{"query": []string{"invalid"}}, StatusCode: http.StatusOK},
+		},
+	}
+	cfg := config{targetURL: server.URL, tenantID: "user-2", speedFactor: 1, timeout: time.Minute}
+
+	start := time.Now()
+	results := replay(context.Background(), cfg, rec)
+
+	// The second query is replayed after its original offset.
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	require.Len(t, results, 2)
+	assert.Equal(t, http.StatusOK, results[0].statusCode)
+	assert.Equal(t, http.StatusBadRequest, results[1].statusCode)
+	assert.ElementsMatch(t, []string{"GET user-2 up", "POST user-2 invalid"}, received)
+}
+
+func TestReport(t *testing.T) {
+	query := func(statusCode int, responseTimeMillis float64, query string) queryrecorder.RecordedQuery {
+		return queryrecorder.RecordedQuery{
+			Method:             http.MethodGet,
+			Path:               "/api/v1/query",
+			Params:             url.Values{"query": []string{query}},
+			StatusCode:         statusCode,
+			ResponseTimeMillis: responseTimeMillis,
+		}
+	}
+
+	tests := map[string]struct {
+		topDiffs int
+		results  []result
+		expected string
+	}{
+		"no queries": {
+			topDiffs: 10,
+			expected: `Replayed queries: 0
+Transport errors: 0
+Status code mismatches: 0
+
+status       original     replay
+
+latency     original (ms)    replay (ms)
+p50                   0.0            0.0
+p90                   0.0            0.0
+p99                   0.0            0.0
+p100                  0.0            0.0
+`,
+		},
+		"same status codes and latency increase": {
+			topDiffs: 10,
+			results: []result{
+				{query: query(200, 10, "a"), statusCode: 200, responseTime: 15 * time.Millisecond},
+				{query: query(200, 20, "b"), statusCode: 200, responseTime: 10 * time.Millisecond},
+			},
+			expected: `Replayed queries: 2
+Transport errors: 0
+Status code mismatches: 0
+
+status       original     replay
+2xx                 2          2
+
+latency     original (ms)    replay (ms)
+p50                  10.0           10.0
+p90                  20.0           15.0
+p99                  20.0           15.0
+p100                 20.0           15.0
+
+Queries with the largest latency increase:
+      +5.0ms  GET /api/v1/query?query=a (status 200 -> 200)
+     -10.0ms  GET /api/v1/query?query=b (status 200 -> 200)
+`,
+		},
+		"status code mismatches, transport errors and top diffs": {
+			topDiffs: 1,
+			results: []result{
+				{query: query(200, 10, "a"), statusCode: 500, responseTime: 30 * time.Millisecond},
+				{query: query(422, 5, "b"), statusCode: 422, responseTime: 5 * time.Millisecond},
+				{query: query(200, 10, "c"), err: errors.New("connection refused")},
+			},
+			expected: `Replayed queries: 3
+Transport errors: 1
+Status code mismatches: 1
+
+status       original     replay
+2xx                 2          0
+4xx                 1          1
+5xx                 0          1
+error               0          1
+
+latency     original (ms)    replay (ms)
+p50                  10.0            5.0
+p90                  10.0           30.0
+p99                  10.0           30.0
+p100                 10.0           30.0
+
+Queries with the largest latency increase:
+     +20.0ms  GET /api/v1/query?query=a (status 200 -> 500)
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			report(out, config{topDiffs: tc.topDiffs}, tc.results)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := map[string]struct {
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		"empty":          {values: nil, p: 50, expected: 0},
+		"single value":   {values: []float64{3}, p: 99, expected: 3},
+		"median":         {values: []float64{4, 1, 3, 2}, p: 50, expected: 2},
+		"nearest rank":   {values: []float64{4, 1, 3, 2}, p: 60, expected: 3},
+		"max":            {values: []float64{4, 1, 3, 2}, p: 100, expected: 4},
+		"lowest nonzero": {values: []float64{4, 1, 3, 2}, p: 0, expected: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, percentile(tc.values, tc.p))
+		})
+	}
+}